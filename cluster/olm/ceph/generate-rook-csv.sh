@@ -88,15 +88,21 @@ OLM_ROLE_BINDING_YAML_FILE="$OLM_CATALOG_DIR/deploy/role_binding.yaml"
 OLM_SERVICE_ACCOUNT_YAML_FILE="$OLM_CATALOG_DIR/deploy/service_account.yaml"
 CEPH_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephclusters.ceph.rook.io.crd.yaml"
 CEPH_BLOCK_POOLS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephblockpools.ceph.rook.io.crd.yaml"
+CEPH_BLOCK_POOL_RADOS_NAMESPACES_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephblockpoolradosnamespaces.ceph.rook.io.crd.yaml"
 CEPH_OBJECT_STORE_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephobjectstores.ceph.rook.io.crd.yaml"
 CEPH_OBJECT_STORE_USERS_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephobjectstoreusers.ceph.rook.io.crd.yaml"
 CEPH_OBJECT_REALM_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephobjectrealms.ceph.rook.io.crd.yaml"
 CEPH_OBJECT_ZONEGROUP_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephobjectzonegroups.ceph.rook.io.crd.yaml"
 CEPH_OBJECT_ZONE_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephobjectzones.ceph.rook.io.crd.yaml"
+CEPH_BUCKET_TOPIC_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephbuckettopics.ceph.rook.io.crd.yaml"
+CEPH_BUCKET_NOTIFICATION_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephbucketnotifications.ceph.rook.io.crd.yaml"
+CEPH_COSI_DRIVER_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephcosidrivers.ceph.rook.io.crd.yaml"
 CEPH_FILESYSTEMS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephfilesystems.ceph.rook.io.crd.yaml"
 CEPH_NFS_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephnfses.ceph.rook.io.crd.yaml"
 CEPH_CLIENT_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephclients.ceph.rook.io.crd.yaml"
 CEPH_RBD_MIRROR_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephrbdmirrors.ceph.rook.io.crd.yaml"
+CEPH_FILESYSTEM_MIRROR_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephfilesystemmirrors.ceph.rook.io.crd.yaml"
+CEPH_FILESYSTEM_SUBVOLUMEGROUP_CRD_YAML_FILE="$OLM_CATALOG_DIR/deploy/crds/cephfilesystemsubvolumegroups.ceph.rook.io.crd.yaml"
 CEPH_EXTERNAL_SCRIPT_FILE="cluster/examples/kubernetes/ceph/create-external-cluster-resources.py"
 
 if [[ -d "$CSV_BUNDLE_PATH" ]]; then
@@ -215,13 +221,19 @@ function generate_crds_yaml() {
     sed -n '/^# OLM: BEGIN CEPH OBJECT REALM CRD$/,/# OLM: END CEPH OBJECT REALM CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_OBJECT_REALM_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH OBJECT ZONEGROUP CRD$/,/# OLM: END CEPH OBJECT ZONEGROUP CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_OBJECT_ZONEGROUP_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH OBJECT ZONE CRD$/,/# OLM: END CEPH OBJECT ZONE CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_OBJECT_ZONE_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH BUCKET TOPIC CRD$/,/# OLM: END CEPH BUCKET TOPIC CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_BUCKET_TOPIC_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH BUCKET NOTIFICATION CRD$/,/# OLM: END CEPH BUCKET NOTIFICATION CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_BUCKET_NOTIFICATION_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH COSI DRIVER CRD$/,/# OLM: END CEPH COSI DRIVER CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_COSI_DRIVER_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH BLOCK POOL CRD$/,/# OLM: END CEPH BLOCK POOL CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_BLOCK_POOLS_CRD_YAML_FILE"
+    sed -n '/^# OLM: BEGIN CEPH BLOCK POOL RADOS NAMESPACE CRD$/,/# OLM: END CEPH BLOCK POOL RADOS NAMESPACE CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_BLOCK_POOL_RADOS_NAMESPACES_CRD_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH NFS CRD$/,/# OLM: END CEPH NFS CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_NFS_CRD_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH CLIENT CRD$/,/# OLM: END CEPH CLIENT CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_CLIENT_CRD_YAML_FILE"
     sed -n '/^# OLM: BEGIN CEPH RBD MIRROR CRD$/,/# OLM: END CEPH RBD MIRROR CRD$/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_RBD_MIRROR_CRD_YAML_FILE"
 
     if [ -n "$OLM_INCLUDE_CEPHFS_CSI" ]; then
         sed -n '/^# OLM: BEGIN CEPH FS CRD$/,/# OLM: END CEPH FS CRD/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_FILESYSTEMS_CRD_YAML_FILE"
+        sed -n '/^# OLM: BEGIN CEPH FS MIRROR CRD$/,/# OLM: END CEPH FS MIRROR CRD/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_FILESYSTEM_MIRROR_CRD_YAML_FILE"
+        sed -n '/^# OLM: BEGIN CEPH FS SUBVOLUMEGROUP CRD$/,/# OLM: END CEPH FS SUBVOLUMEGROUP CRD/p' "$COMMON_YAML_FILE" | grep -v '^#' > "$CEPH_FILESYSTEM_SUBVOLUMEGROUP_CRD_YAML_FILE"
     fi
 }
 
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ceph
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/cmd/rook/rook"
+	"github.com/rook/rook/pkg/daemon/ceph/backup"
+	"github.com/rook/rook/pkg/util/flags"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupDir            string
+	backupRetain         int
+	backupBucketEndpoint string
+	backupBucketName     string
+	backupBucketPrefix   string
+	backupAccessKey      string
+	backupSecretKey      string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup",
+	Short: "Backs up the maps of the mon store, the keyrings and the config of the cluster to a directory or a bucket",
+}
+
+func init() {
+	backupCmd.Flags().StringVar(&backupDir, "backup-dir", "", "directory where the backups are written")
+	backupCmd.Flags().IntVar(&backupRetain, "retain", 7, "number of backups kept")
+	backupCmd.Flags().StringVar(&backupBucketEndpoint, "bucket-endpoint", "", "endpoint of the bucket where the backups are uploaded")
+	backupCmd.Flags().StringVar(&backupBucketName, "bucket-name", "", "name of the bucket where the backups are uploaded")
+	backupCmd.Flags().StringVar(&backupBucketPrefix, "bucket-prefix", "", "prefix of the names of the backups in the bucket")
+	backupCmd.Flags().StringVar(&backupAccessKey, "bucket-access-key", "", "access key of the bucket")
+	backupCmd.Flags().StringVar(&backupSecretKey, "bucket-secret-key", "", "secret key of the bucket")
+	flags.SetFlagsFromEnv(backupCmd.Flags(), rook.RookEnvVarPrefix)
+	backupCmd.RunE = startBackup
+}
+
+func startBackup(cmd *cobra.Command, args []string) error {
+	rook.SetLogLevel()
+	rook.LogStartupInfo(backupCmd.Flags())
+
+	var target backup.Target
+	if backupDir != "" {
+		target = backup.NewDirectoryTarget(backupDir)
+	} else if backupBucketName != "" {
+		var err error
+		target, err = backup.NewBucketTarget(backupBucketEndpoint, backupBucketName, backupBucketPrefix, backupAccessKey, backupSecretKey)
+		if err != nil {
+			rook.TerminateFatal(err)
+		}
+	} else {
+		rook.TerminateFatal(errors.New("either --backup-dir or --bucket-name is required"))
+	}
+
+	// the ceph config and the admin keyring are generated in the default location by the init container
+	context := rook.NewContext()
+	context.ConfigDir = "/etc"
+	if _, err := backup.Run(context, "ceph", target, backupRetain, time.Now()); err != nil {
+		rook.TerminateFatal(errors.Wrap(err, "failed to back up the cluster"))
+	}
+	return nil
+}
@@ -58,7 +58,8 @@ func init() {
 		agentCmd,
 		admissionCmd,
 		osdCmd,
-		configCmd)
+		configCmd,
+		backupCmd)
 }
 
 func createContext() *clusterd.Context {
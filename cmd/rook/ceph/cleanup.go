@@ -18,6 +18,7 @@ package ceph
 
 import (
 	"github.com/rook/rook/cmd/rook/rook"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cleanup "github.com/rook/rook/pkg/daemon/ceph/cleanup"
 	"github.com/rook/rook/pkg/util/flags"
 	"github.com/spf13/cobra"
@@ -29,6 +30,10 @@ var (
 	monSecret       string
 	clusterFSID     string
 	clusterName     string
+	cleanupOSDID    int
+	sanitizeMethod  string
+	sanitizeSource  string
+	sanitizeCount   int32
 )
 
 var cleanUpCmd = &cobra.Command{
@@ -42,6 +47,10 @@ func init() {
 	cleanUpCmd.Flags().StringVar(&monSecret, "mon-secret", "", "monitor secret from the keyring")
 	cleanUpCmd.Flags().StringVar(&clusterFSID, "cluster-fsid", "", "ceph cluster fsid")
 	cleanUpCmd.Flags().StringVar(&clusterName, "cluster-name", "", "ceph cluster name")
+	cleanUpCmd.Flags().StringVar(&sanitizeMethod, "sanitize-method", string(cephv1.SanitizeMethodQuick), "sanitize method to use (quick or complete)")
+	cleanUpCmd.Flags().StringVar(&sanitizeSource, "sanitize-data-source", string(cephv1.SanitizeDataSourceZero), "data source written on the disks (zero or random)")
+	cleanUpCmd.Flags().Int32Var(&sanitizeCount, "sanitize-iteration", cephv1.DefaultSanitizeIteration, "number of times the disks are overwritten")
+	cleanUpCmd.Flags().IntVar(&cleanupOSDID, "osd-id", -1, "id of the only osd whose disk is wiped, all the osds of the node if not set")
 	flags.SetFlagsFromEnv(cleanUpCmd.Flags(), rook.RookEnvVarPrefix)
 	cleanUpCmd.RunE = startCleanUp
 }
@@ -61,10 +70,19 @@ func startCleanUp(cmd *cobra.Command, args []string) error {
 	s := cleanup.NewDiskSanitizer(createContext(),
 		clusterName,
 		clusterFSID,
+		&cephv1.SanitizeDisksSpec{
+			Method:     cephv1.SanitizeMethodProperty(sanitizeMethod),
+			DataSource: cephv1.SanitizeDataSourceProperty(sanitizeSource),
+			Iteration:  sanitizeCount,
+		},
 	)
 
 	// Start OSD wipe process
-	cleanup.StartSanitizeDisks(s)
+	if cleanupOSDID >= 0 {
+		cleanup.StartSanitizeOSDDisk(s, cleanupOSDID)
+	} else {
+		cleanup.StartSanitizeDisks(s)
+	}
 
 	return nil
 }
@@ -22,11 +22,14 @@ import (
 	"strconv"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/api/resource"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/cmd/rook/rook"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	osddaemon "github.com/rook/rook/pkg/daemon/ceph/osd"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	osdcfg "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
@@ -52,10 +55,25 @@ var osdStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Starts the osd daemon", // OSDs that were provisioned by ceph-volume
 }
+var osdEncryptionCmd = &cobra.Command{
+	Use:   "encryption",
+	Short: "Manages the encrypted block of an osd on a pvc",
+}
+var osdEncryptionOpenCmd = &cobra.Command{
+	Use:   "open",
+	Short: "Opens the dm-crypt device of the encrypted block with its key from the key management service",
+}
+var osdEncryptionRotateKeyCmd = &cobra.Command{
+	Use:   "rotate-key",
+	Short: "Replaces the key of the encrypted block in the key management service",
+}
 
 var (
 	osdDataDeviceFilter     string
 	osdDataDevicePathFilter string
+	osdDataDeviceMinSize    string
+	osdDataDeviceMaxSize    string
+	osdDataDeviceType       string
 	ownerRefID              string
 	mountSourcePath         string
 	mountPath               string
@@ -69,6 +87,8 @@ var (
 	blockPath               string
 	lvBackedPV              bool
 	driveGroups             string
+	encryptedDevice         string
+	encryptedPVCName        string
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -80,6 +100,9 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().StringVar(&cfg.devices, "data-devices", "", "comma separated list of devices to use for storage")
 	provisionCmd.Flags().StringVar(&osdDataDeviceFilter, "data-device-filter", "", "a regex filter for the device names to use, or \"all\"")
 	provisionCmd.Flags().StringVar(&osdDataDevicePathFilter, "data-device-path-filter", "", "a regex filter for the device path names to use")
+	provisionCmd.Flags().StringVar(&osdDataDeviceMinSize, "data-device-min-size", "", "the minimum size of the devices to use, such as 100Gi")
+	provisionCmd.Flags().StringVar(&osdDataDeviceMaxSize, "data-device-max-size", "", "the maximum size of the devices to use, such as 4Ti")
+	provisionCmd.Flags().StringVar(&osdDataDeviceType, "data-device-type", "", "the type of the devices to use: hdd, ssd or nvme")
 	provisionCmd.Flags().StringVar(&cfg.metadataDevice, "metadata-device", "", "device to use for metadata (e.g. a high performance SSD/NVMe device)")
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
@@ -96,10 +119,19 @@ func addOSDFlags(command *cobra.Command) {
 	osdStartCmd.Flags().StringVar(&blockPath, "block-path", "", "Block path for the OSD created by ceph-volume")
 	osdStartCmd.Flags().BoolVar(&lvBackedPV, "lv-backed-pv", false, "Whether the PV located on LV")
 
+	// flags for the encrypted blocks of the osds on pvcs
+	for _, cmd := range []*cobra.Command{osdEncryptionOpenCmd, osdEncryptionRotateKeyCmd} {
+		cmd.Flags().StringVar(&encryptedDevice, "device", "", "the path of the encrypted block")
+		cmd.Flags().StringVar(&encryptedPVCName, "pvc-name", "", "the name of the pvc of the osd")
+	}
+	osdEncryptionCmd.AddCommand(osdEncryptionOpenCmd,
+		osdEncryptionRotateKeyCmd)
+
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd,
 		provisionCmd,
-		osdStartCmd)
+		osdStartCmd,
+		osdEncryptionCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -122,10 +154,14 @@ func init() {
 	flags.SetFlagsFromEnv(osdConfigCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdStartCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(osdEncryptionOpenCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(osdEncryptionRotateKeyCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	provisionCmd.RunE = prepareOSD
 	osdStartCmd.RunE = startOSD
+	osdEncryptionOpenCmd.RunE = openEncryptedBlock
+	osdEncryptionRotateKeyCmd.RunE = rotateEncryptionKey
 }
 
 // Start the osd daemon if provisioned by ceph-volume
@@ -147,6 +183,41 @@ func startOSD(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Open the encrypted block of an osd on a pvc before the osd is activated
+func openEncryptedBlock(cmd *cobra.Command, args []string) error {
+	if err := flags.VerifyRequiredFlags(osdEncryptionOpenCmd, []string{"device", "pvc-name"}); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(osdEncryptionOpenCmd.Flags())
+
+	context := createContext()
+	kmsConfig := kms.NewConfigFromEnv(context, clusterInfo.Name)
+	err := osddaemon.OpenEncryptedBlock(context, kmsConfig, encryptedDevice, oposd.EncryptionDMName(encryptedPVCName), kms.EncryptionKeyName(encryptedPVCName))
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
+// Rotate the encryption key of the encrypted block of an osd on a pvc
+func rotateEncryptionKey(cmd *cobra.Command, args []string) error {
+	if err := flags.VerifyRequiredFlags(osdEncryptionRotateKeyCmd, []string{"device", "pvc-name"}); err != nil {
+		return err
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(osdEncryptionRotateKeyCmd.Flags())
+
+	context := createContext()
+	kmsConfig := kms.NewConfigFromEnv(context, clusterInfo.Name)
+	if err := osddaemon.RotateEncryptionKey(context, kmsConfig, encryptedDevice, kms.EncryptionKeyName(encryptedPVCName)); err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
 func verifyConfigFlags(configCmd *cobra.Command) error {
 	required := []string{"cluster-id", "node-name"}
 	if err := flags.VerifyRequiredFlags(configCmd, required); err != nil {
@@ -210,6 +281,9 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 			rook.TerminateFatal(errors.Wrapf(err, "failed to parse device list (%q)", cfg.devices))
 		}
 	}
+	if err := setDeviceSizeAndTypeFilters(dataDevices); err != nil {
+		return err
+	}
 
 	context := createContext()
 	commonOSDInit(provisionCmd)
@@ -253,23 +327,57 @@ func getLocation(clientset kubernetes.Interface) (string, error) {
 	// get the value the operator instructed to use as the host name in the CRUSH map
 	hostNameLabel := os.Getenv("ROOK_CRUSHMAP_HOSTNAME")
 
-	loc, err := oposd.GetLocationWithNode(clientset, os.Getenv(k8sutil.NodeNameEnvVar), hostNameLabel)
+	// get the node labels the operator instructed to use for the CRUSH bucket types
+	topologyMapping := map[string]string{}
+	if mapping := os.Getenv(oposd.TopologyMappingEnvVarName); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &topologyMapping); err != nil {
+			return "", errors.Wrapf(err, "failed to unmarshal the topology mapping %q", mapping)
+		}
+	}
+
+	loc, err := oposd.GetLocationWithNode(clientset, os.Getenv(k8sutil.NodeNameEnvVar), hostNameLabel, topologyMapping)
 	if err != nil {
 		return "", err
 	}
 	return loc, nil
 }
 
-func updateLocationWithNodeLabels(location *[]string, nodeLabels map[string]string) {
-	oposd.UpdateLocationWithNodeLabels(location, nodeLabels)
+func updateLocationWithNodeLabels(location *[]string, nodeLabels map[string]string, topologyMapping map[string]string) {
+	oposd.UpdateLocationWithNodeLabels(location, nodeLabels, topologyMapping)
+}
+
+// setDeviceSizeAndTypeFilters restricts the desired devices to the devices of the size and type given in the flags
+func setDeviceSizeAndTypeFilters(dataDevices []osddaemon.DesiredDevice) error {
+	selection := rookv1.Selection{MinSize: osdDataDeviceMinSize, MaxSize: osdDataDeviceMaxSize, DeviceType: osdDataDeviceType}
+	if err := selection.ValidateDeviceFilters(); err != nil {
+		return errors.Wrap(err, "failed to validate the device filters")
+	}
+
+	var minSize, maxSize uint64
+	if osdDataDeviceMinSize != "" {
+		size := resource.MustParse(osdDataDeviceMinSize)
+		minSize = uint64(size.Value())
+	}
+	if osdDataDeviceMaxSize != "" {
+		size := resource.MustParse(osdDataDeviceMaxSize)
+		maxSize = uint64(size.Value())
+	}
+	for i := range dataDevices {
+		dataDevices[i].MinSize = minSize
+		dataDevices[i].MaxSize = maxSize
+		dataDevices[i].DeviceType = osdDataDeviceType
+	}
+	return nil
 }
 
 // Parse the devices, which are comma separated. A colon indicates a non-default number of osds per device
-// or a non collocated metadata device.
+// or a non collocated metadata or wal device.
 // For example, one osd will be created on each of sda and sdb, with 5 osds on the nvme01 device.
 //   sda:1:::,sdb:1:::,nvme01:5:::
 // For example, 3 osds will use sdb SSD for db and 3 osds will use sdc SSD for db.
 //   sdd:1:::sdb,sde:1:::sdb,sdf:1:::sdb,sdg:1:::sdc,sdh:1:::sdc,sdi:1:::sdc
+// For example, 2 osds will use sdb SSD for db and nvme01 for wal.
+//   sdd:1:::sdb:nvme01,sde:1:::sdb:nvme01
 func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 	var result []osddaemon.DesiredDevice
 	parsed := strings.Split(devices, ",")
@@ -299,6 +407,9 @@ func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 		if len(parts) > 4 {
 			d.MetadataDevice = parts[4]
 		}
+		if len(parts) > 5 {
+			d.WalDevice = parts[5]
+		}
 		result = append(result, d)
 	}
 
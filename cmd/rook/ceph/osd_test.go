@@ -84,6 +84,14 @@ func TestParseDesiredDevices(t *testing.T) {
 	assert.False(t, result[2].IsDevicePathFilter)
 	assert.False(t, result[3].IsDevicePathFilter)
 
+	// metadataDevice and walDevice
+	devices = "sdd:1:::sdb:nvme01,sde:1:::sdb"
+	result, err = parseDevices(devices)
+	assert.Nil(t, err)
+	assert.Equal(t, "sdb", result[0].MetadataDevice)
+	assert.Equal(t, "nvme01", result[0].WalDevice)
+	assert.Equal(t, "sdb", result[1].MetadataDevice)
+	assert.Equal(t, "", result[1].WalDevice)
 }
 
 func TestDetectCrushLocation(t *testing.T) {
@@ -91,7 +99,7 @@ func TestDetectCrushLocation(t *testing.T) {
 	nodeLabels := map[string]string{}
 
 	// no change to the location if there are no labels
-	updateLocationWithNodeLabels(&location, nodeLabels)
+	updateLocationWithNodeLabels(&location, nodeLabels, nil)
 	assert.Equal(t, 1, len(location))
 	assert.Equal(t, "host=foo", location[0])
 
@@ -101,7 +109,7 @@ func TestDetectCrushLocation(t *testing.T) {
 		"invalid.topology.rook.io/rack": "r1",
 		"topology.rook.io/zone":         "z1",
 	}
-	updateLocationWithNodeLabels(&location, nodeLabels)
+	updateLocationWithNodeLabels(&location, nodeLabels, nil)
 	assert.Equal(t, 1, len(location))
 	assert.Equal(t, "host=foo", location[0])
 
@@ -120,10 +128,17 @@ func TestDetectCrushLocation(t *testing.T) {
 		"row=row1",
 		"zone=zone1",
 	}
-	updateLocationWithNodeLabels(&location, nodeLabels)
+	updateLocationWithNodeLabels(&location, nodeLabels, nil)
 
 	assert.Equal(t, 5, len(location))
 	for i, locString := range location {
 		assert.Equal(t, locString, expected[i])
 	}
+
+	// the labels of the topology mapping override the topology labels
+	nodeLabels["example.com/rack"] = "rack.2"
+	topologyMapping := map[string]string{"rack": "example.com/rack", "room": "example.com/room"}
+	updateLocationWithNodeLabels(&location, nodeLabels, topologyMapping)
+	assert.Equal(t, 5, len(location))
+	assert.Equal(t, "rack=rack-2", location[1])
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
 	"github.com/rook/rook/pkg/util/flags"
+	"github.com/rook/rook/pkg/util/log"
 	"github.com/rook/rook/pkg/version"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -50,6 +51,7 @@ var RootCmd = &cobra.Command{
 
 var (
 	logLevelRaw        string
+	logFormat          string
 	operatorImage      string
 	serviceAccountName string
 	Cfg                = &Config{}
@@ -66,6 +68,7 @@ type Config struct {
 //  3) command line parameter
 func init() {
 	RootCmd.PersistentFlags().StringVar(&logLevelRaw, "log-level", "INFO", "logging level for logging/tracing output (valid values: CRITICAL,ERROR,WARNING,NOTICE,INFO,DEBUG,TRACE)")
+	RootCmd.PersistentFlags().StringVar(&logFormat, "log-format", log.TextFormat, "format of the logging output (valid values: text,json)")
 	RootCmd.PersistentFlags().StringVar(&operatorImage, "operator-image", "", "Override the image url that the operator uses. The default is read from the operator pod.")
 	RootCmd.PersistentFlags().StringVar(&serviceAccountName, "service-account", "", "Override the service account that the operator uses. The default is read from the operator pod.")
 
@@ -74,8 +77,12 @@ func init() {
 	flags.SetFlagsFromEnv(RootCmd.PersistentFlags(), RookEnvVarPrefix)
 }
 
-// SetLogLevel set log level based on provided log option.
+// SetLogLevel set log level and format based on provided log options.
 func SetLogLevel() {
+	if err := log.SetFormat(logFormat); err != nil {
+		logger.Warningf("failed to set log format. %v", err)
+	}
+
 	// parse given log level string then set up corresponding global logging level
 	ll, err := capnslog.ParseLevel(logLevelRaw)
 	if err != nil {
@@ -40,6 +40,11 @@ func GetCleanupAnnotations(a rook.AnnotationsSpec) rook.Annotations {
 	return mergeAllAnnotationsWithKey(a, KeyCleanup)
 }
 
+// GetCrashCollectorAnnotations returns the Annotations for the crash collectors
+func GetCrashCollectorAnnotations(a rook.AnnotationsSpec) rook.Annotations {
+	return mergeAllAnnotationsWithKey(a, KeyCrashCollector)
+}
+
 func mergeAllAnnotationsWithKey(a rook.AnnotationsSpec, name rook.KeyType) rook.Annotations {
 	all := a.All()
 	if all != nil {
@@ -31,3 +31,35 @@ func (p *PoolSpec) IsCompressionEnabled() bool {
 func (p *ReplicatedSpec) IsTargetRatioEnabled() bool {
 	return p.TargetSizeRatio != 0
 }
+
+// GetTargetSizeRatio returns the target size ratio of the pool, set for the pool or for its replicas
+func (p *PoolSpec) GetTargetSizeRatio() float64 {
+	if p.TargetSizeRatio != 0 {
+		return p.TargetSizeRatio
+	}
+	return p.Replicated.TargetSizeRatio
+}
+
+// GetFailureDomain returns the failure domain of the pool, which an erasure coded pool can override for its chunks
+func (p *PoolSpec) GetFailureDomain() string {
+	if p.IsErasureCoded() && p.ErasureCoded.FailureDomain != "" {
+		return p.ErasureCoded.FailureDomain
+	}
+	return p.FailureDomain
+}
+
+// GetCrushRoot returns the crush root of the pool, which an erasure coded pool can override for its chunks
+func (p *PoolSpec) GetCrushRoot() string {
+	if p.IsErasureCoded() && p.ErasureCoded.CrushRoot != "" {
+		return p.ErasureCoded.CrushRoot
+	}
+	return p.CrushRoot
+}
+
+// GetDeviceClass returns the device class of the pool, which an erasure coded pool can override for its chunks
+func (p *PoolSpec) GetDeviceClass() string {
+	if p.IsErasureCoded() && p.ErasureCoded.DeviceClass != "" {
+		return p.ErasureCoded.DeviceClass
+	}
+	return p.DeviceClass
+}
@@ -19,6 +19,16 @@ package v1
 const (
 	// DeleteDataDirOnHostsConfirmation represents the validation to destry dataDirHostPath
 	DeleteDataDirOnHostsConfirmation CleanupConfirmationProperty = "yes-really-destroy-data"
+	// SanitizeMethodQuick only wipes the metadata of the disks
+	SanitizeMethodQuick SanitizeMethodProperty = "quick"
+	// SanitizeMethodComplete wipes the whole disks
+	SanitizeMethodComplete SanitizeMethodProperty = "complete"
+	// SanitizeDataSourceZero writes zeros on the disks
+	SanitizeDataSourceZero SanitizeDataSourceProperty = "zero"
+	// SanitizeDataSourceRandom writes random data on the disks
+	SanitizeDataSourceRandom SanitizeDataSourceProperty = "random"
+	// DefaultSanitizeIteration is the number of times the disks are overwritten if not set
+	DefaultSanitizeIteration int32 = 1
 )
 
 // HasDataDirCleanPolicy returns whether the cluster has a data dir policy
@@ -29,3 +39,27 @@ func (c *CleanupPolicySpec) HasDataDirCleanPolicy() bool {
 func (c *CleanupConfirmationProperty) String() string {
 	return string(*c)
 }
+
+// GetMethod returns the sanitize method, quick by default
+func (s *SanitizeDisksSpec) GetMethod() SanitizeMethodProperty {
+	if s.Method == "" {
+		return SanitizeMethodQuick
+	}
+	return s.Method
+}
+
+// GetDataSource returns the source of the data written on the disks, zeros by default
+func (s *SanitizeDisksSpec) GetDataSource() SanitizeDataSourceProperty {
+	if s.DataSource == "" {
+		return SanitizeDataSourceZero
+	}
+	return s.DataSource
+}
+
+// GetIteration returns the number of times the disks are overwritten, once by default
+func (s *SanitizeDisksSpec) GetIteration() int32 {
+	if s.Iteration <= 0 {
+		return DefaultSanitizeIteration
+	}
+	return s.Iteration
+}
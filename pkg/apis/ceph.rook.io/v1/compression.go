@@ -0,0 +1,82 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"github.com/pkg/errors"
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
+)
+
+const (
+	// CompressionAlgorithmZstd is the zstd compression algorithm, only supported by the OSDs from nautilus
+	CompressionAlgorithmZstd = "zstd"
+)
+
+var (
+	// compressionModes are the inline compression modes of bluestore
+	compressionModes = []string{"none", "passive", "aggressive", "force"}
+	// compressionAlgorithms are the inline compression algorithms of bluestore
+	compressionAlgorithms = []string{"snappy", "zlib", CompressionAlgorithmZstd, "lz4"}
+)
+
+// ValidateCompression ensures the compression mode and algorithm are known to bluestore
+func ValidateCompression(mode, algorithm string) error {
+	if mode != "" && !contains(compressionModes, mode) {
+		return errors.Errorf("unrecognized compression mode %q, must be one of %v", mode, compressionModes)
+	}
+	if algorithm != "" && !contains(compressionAlgorithms, algorithm) {
+		return errors.Errorf("unrecognized compression algorithm %q, must be one of %v", algorithm, compressionAlgorithms)
+	}
+	return nil
+}
+
+// CompressionConfig returns the ceph options of the default compression of the OSDs, failing if the settings are invalid
+func CompressionConfig(compression *rook.BluestoreCompressionSpec) (map[string]string, error) {
+	options := map[string]string{}
+	if compression == nil {
+		return options, nil
+	}
+	if err := ValidateCompression(compression.Mode, compression.Algorithm); err != nil {
+		return nil, err
+	}
+	if compression.Mode != "" {
+		options["bluestore_compression_mode"] = compression.Mode
+	}
+	if compression.Algorithm != "" {
+		options["bluestore_compression_algorithm"] = compression.Algorithm
+	}
+	return options, nil
+}
+
+// validateStorageCompression ensures the default compression of the OSDs is valid and its options are not set in the
+// cephConfig as well
+func validateStorageCompression(spec ClusterSpec) error {
+	options, err := CompressionConfig(spec.Storage.Compression)
+	if err != nil {
+		return errors.Wrap(err, "invalid config : storage:compression")
+	}
+	return validateConfigConflicts("storage:compression", options, spec.CephConfig)
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
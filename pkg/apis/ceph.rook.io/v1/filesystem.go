@@ -0,0 +1,38 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import "fmt"
+
+// DataPoolName returns the name of the ceph pool of the data pool of the filesystem at the given index
+func (f *CephFilesystem) DataPoolName(index int) string {
+	if name := f.Spec.DataPools[index].Name; name != "" {
+		return fmt.Sprintf("%s-%s", f.Name, name)
+	}
+	return fmt.Sprintf("%s-data%d", f.Name, index)
+}
+
+// DefaultDataPoolIndex returns the index of the default data pool of the filesystem, the first data pool if no pool is
+// marked as the default
+func (spec *FilesystemSpec) DefaultDataPoolIndex() int {
+	for i, p := range spec.DataPools {
+		if p.Default {
+			return i
+		}
+	}
+	return 0
+}
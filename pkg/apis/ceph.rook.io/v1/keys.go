@@ -24,5 +24,10 @@ const (
 	KeyMon     rook.KeyType = "mon"
 	KeyMgr     rook.KeyType = "mgr"
 	KeyOSD     rook.KeyType = "osd"
+	KeyRgw     rook.KeyType = "rgw"
+	KeyMds     rook.KeyType = "mds"
 	KeyCleanup rook.KeyType = "cleanup"
+
+	KeyCrashCollector rook.KeyType = "crashcollector"
+	KeyOSDPrepare     rook.KeyType = "prepareosd"
 )
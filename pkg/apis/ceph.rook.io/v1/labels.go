@@ -0,0 +1,54 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
+)
+
+// GetMgrLabels returns the Labels for the MGR service
+func GetMgrLabels(l rook.LabelsSpec) rook.Labels {
+	return mergeAllLabelsWithKey(l, KeyMgr)
+}
+
+// GetMonLabels returns the Labels for the MON service
+func GetMonLabels(l rook.LabelsSpec) rook.Labels {
+	return mergeAllLabelsWithKey(l, KeyMon)
+}
+
+// GetOSDLabels returns the Labels for the OSD service
+func GetOSDLabels(l rook.LabelsSpec) rook.Labels {
+	return mergeAllLabelsWithKey(l, KeyOSD)
+}
+
+// GetCleanupLabels returns the Labels for the cleanup job
+func GetCleanupLabels(l rook.LabelsSpec) rook.Labels {
+	return mergeAllLabelsWithKey(l, KeyCleanup)
+}
+
+// GetCrashCollectorLabels returns the Labels for the crash collectors
+func GetCrashCollectorLabels(l rook.LabelsSpec) rook.Labels {
+	return mergeAllLabelsWithKey(l, KeyCrashCollector)
+}
+
+func mergeAllLabelsWithKey(l rook.LabelsSpec, name rook.KeyType) rook.Labels {
+	all := l.All()
+	if all != nil {
+		return all.Merge(l[name])
+	}
+	return l[name]
+}
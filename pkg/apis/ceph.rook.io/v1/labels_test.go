@@ -0,0 +1,47 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelsMerge(t *testing.T) {
+	// No labels defined
+	assert.Nil(t, GetOSDLabels(rook.LabelsSpec{}))
+
+	// Only a specific component label without "all"
+	testLabels := rook.LabelsSpec{
+		"mgr":            {"mgrkey": "mgrval"},
+		"crashcollector": {"crashkey": "crashval"},
+	}
+	assert.Equal(t, rook.Labels{"mgrkey": "mgrval"}, GetMgrLabels(testLabels))
+	assert.Equal(t, rook.Labels{"crashkey": "crashval"}, GetCrashCollectorLabels(testLabels))
+	assert.Nil(t, GetMonLabels(testLabels))
+
+	// Merge with "all", the component overriding "all"
+	testLabels = rook.LabelsSpec{
+		"all": {"allkey": "allval", "team": "storage"},
+		"mgr": {"mgrkey": "mgrval", "team": "monitoring"},
+	}
+	assert.Equal(t, rook.Labels{"allkey": "allval", "team": "storage"}, GetMonLabels(testLabels))
+	assert.Equal(t, rook.Labels{"allkey": "allval", "mgrkey": "mgrval", "team": "monitoring"}, GetMgrLabels(testLabels))
+	assert.Equal(t, rook.Labels{"allkey": "allval", "team": "storage"}, GetOSDLabels(testLabels))
+}
@@ -34,3 +34,13 @@ func GetMgrLivenessProbe(l CephClusterHealthCheckSpec) *corev1.Probe {
 func GetOSDLivenessProbe(l CephClusterHealthCheckSpec) *corev1.Probe {
 	return l.LivenessProbe[ResourcesKeyOSD].Probe
 }
+
+// GetRgwLivenessProbe returns the liveness probe for the RGW service
+func GetRgwLivenessProbe(l CephClusterHealthCheckSpec) *corev1.Probe {
+	return l.LivenessProbe[KeyRgw].Probe
+}
+
+// GetMdsLivenessProbe returns the liveness probe for the MDS service
+func GetMdsLivenessProbe(l CephClusterHealthCheckSpec) *corev1.Probe {
+	return l.LivenessProbe[KeyMds].Probe
+}
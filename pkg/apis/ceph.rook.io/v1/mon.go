@@ -0,0 +1,68 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import "strings"
+
+// DefaultStretchClusterFailureDomainLabel is the node label giving the zone of the nodes of a stretch cluster
+const DefaultStretchClusterFailureDomainLabel = "topology.kubernetes.io/zone"
+
+// IsStretchCluster returns whether the mons are spread across the zones of a stretch cluster
+func (s *MonSpec) IsStretchCluster() bool {
+	return s.StretchCluster != nil && len(s.StretchCluster.Zones) > 0
+}
+
+// HasZones returns whether the mons are spread across the zones of a stretch cluster or of the mon zones
+func (s *MonSpec) HasZones() bool {
+	return s.IsStretchCluster() || len(s.Zones) > 0
+}
+
+// GetZoneLabel returns the node label giving the zone of the nodes the mons are spread across
+func (s *MonSpec) GetZoneLabel() string {
+	if s.IsStretchCluster() {
+		return s.StretchCluster.GetFailureDomainLabel()
+	}
+	if s.FailureDomainLabel == "" {
+		return DefaultStretchClusterFailureDomainLabel
+	}
+	return s.FailureDomainLabel
+}
+
+// GetFailureDomainLabel returns the node label giving the zone of the nodes
+func (s *StretchClusterSpec) GetFailureDomainLabel() string {
+	if s.FailureDomainLabel == "" {
+		return DefaultStretchClusterFailureDomainLabel
+	}
+	return s.FailureDomainLabel
+}
+
+// GetFailureDomainType returns the CRUSH bucket type of the zones, which is the name of the failure domain label
+// without its prefix, such as "zone" for topology.kubernetes.io/zone or "datacenter" for topology.rook.io/datacenter
+func (s *StretchClusterSpec) GetFailureDomainType() string {
+	label := s.GetFailureDomainLabel()
+	return label[strings.LastIndex(label, "/")+1:]
+}
+
+// GetArbiterZone returns the name of the arbiter zone, or an empty string if none is defined
+func (s *StretchClusterSpec) GetArbiterZone() string {
+	for _, zone := range s.Zones {
+		if zone.Arbiter {
+			return zone.Name
+		}
+	}
+	return ""
+}
@@ -16,9 +16,37 @@ limitations under the License.
 
 package v1
 
+import (
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+)
+
 // IsHost get whether to use host network provider. This method also preserve
 // compatibility with the old HostNetwork field.
 func (net *NetworkSpec) IsHost() bool {
 	rookNet := net.NetworkSpec
 	return (net.HostNetwork && net.Provider == "") || rookNet.IsHost()
 }
+
+// IsHostFor gets whether the daemons of the given key use the host network, the daemonHostNetwork of the key
+// overriding the network of the cluster
+func (net *NetworkSpec) IsHostFor(key rookv1.KeyType) bool {
+	if hostNetwork, ok := net.DaemonHostNetwork[key]; ok {
+		return hostNetwork
+	}
+	return net.IsHost()
+}
+
+// IsIPv6 gets whether the daemons are reached on their IPv6 addresses
+func (net *NetworkSpec) IsIPv6() bool {
+	return net.IPFamily == IPv6
+}
+
+// IsEncryptionEnabled gets whether the msgr2 connections are in secure mode
+func (net *NetworkSpec) IsEncryptionEnabled() bool {
+	return net.Connections != nil && net.Connections.Encryption != nil && net.Connections.Encryption.Enabled
+}
+
+// IsCompressionEnabled gets whether the msgr2 connections between the osds are compressed
+func (net *NetworkSpec) IsCompressionEnabled() bool {
+	return net.Connections != nil && net.Connections.Compression != nil && net.Connections.Compression.Enabled
+}
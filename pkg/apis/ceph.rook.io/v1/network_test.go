@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/ghodss/yaml"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,3 +46,16 @@ func TestNetworkCeph_IsHostLegacy(t *testing.T) {
 
 	assert.True(t, net.IsHost())
 }
+
+func TestNetworkCeph_IsHostFor(t *testing.T) {
+	net := NetworkSpec{DaemonHostNetwork: map[rookv1.KeyType]bool{KeyMon: true, KeyOSD: true}}
+	assert.True(t, net.IsHostFor(KeyMon))
+	assert.True(t, net.IsHostFor(KeyOSD))
+	assert.False(t, net.IsHostFor(KeyRgw))
+
+	// the daemons without an override use the network of the cluster
+	net = NetworkSpec{HostNetwork: true, DaemonHostNetwork: map[rookv1.KeyType]bool{KeyRgw: false, KeyMds: false}}
+	assert.True(t, net.IsHostFor(KeyMon))
+	assert.False(t, net.IsHostFor(KeyRgw))
+	assert.False(t, net.IsHostFor(KeyMds))
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+)
+
+// overrideKeys are the daemons whose containers can be given environment variables and extra volumes
+var overrideKeys = []rookv1.KeyType{rookv1.KeyAll, KeyMon, KeyMgr, KeyOSD, KeyOSDPrepare, KeyCrashCollector, KeyCleanup, KeyRgw, KeyMds}
+
+// GetDaemonEnv returns the environment variables of the containers of a daemon, its own variables replacing the
+// variables of all the daemons with the same name
+func GetDaemonEnv(e DaemonEnvSpec, key rookv1.KeyType) []v1.EnvVar {
+	env := []v1.EnvVar{}
+	for _, all := range e[rookv1.KeyAll] {
+		if !hasEnvVar(e[key], all.Name) {
+			env = append(env, all)
+		}
+	}
+	return append(env, e[key]...)
+}
+
+func hasEnvVar(env []v1.EnvVar, name string) bool {
+	for _, envVar := range env {
+		if envVar.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// GetDaemonVolumeMounts returns the extra volumes of the containers of a daemon, its own volumes replacing the volumes
+// of all the daemons with the same name
+func GetDaemonVolumeMounts(m DaemonVolumeMountsSpec, key rookv1.KeyType) []DaemonVolumeMount {
+	mounts := []DaemonVolumeMount{}
+	for _, all := range m[rookv1.KeyAll] {
+		if !hasVolumeMount(m[key], all.Name) {
+			mounts = append(mounts, all)
+		}
+	}
+	return append(mounts, m[key]...)
+}
+
+func hasVolumeMount(mounts []DaemonVolumeMount, name string) bool {
+	for _, mount := range mounts {
+		if mount.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Volume returns the volume of the pod of the extra volume
+func (m DaemonVolumeMount) Volume() v1.Volume {
+	return v1.Volume{
+		Name: m.Name,
+		VolumeSource: v1.VolumeSource{
+			HostPath:  m.HostPath,
+			Secret:    m.Secret,
+			ConfigMap: m.ConfigMap,
+		},
+	}
+}
+
+// VolumeMount returns the mount of the extra volume in the containers
+func (m DaemonVolumeMount) VolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{Name: m.Name, MountPath: m.MountPath, SubPath: m.SubPath, ReadOnly: m.ReadOnly}
+}
+
+// validateDaemonOverrides ensures the environment variables and the extra volumes are set for known daemons, and that
+// the volumes can be mounted
+func validateDaemonOverrides(spec ClusterSpec) error {
+	for key, env := range spec.Env {
+		if !isOverrideKey(key) {
+			return errors.Errorf("invalid config : env key %q is not one of %q", key, overrideKeys)
+		}
+		for i, envVar := range env {
+			if envVar.Name == "" {
+				return errors.Errorf("invalid config : env:%s[%d]:name must be set", key, i)
+			}
+		}
+	}
+
+	for key, mounts := range spec.VolumeMounts {
+		if !isOverrideKey(key) {
+			return errors.Errorf("invalid config : volumeMounts key %q is not one of %q", key, overrideKeys)
+		}
+		names := map[string]struct{}{}
+		for i, mount := range mounts {
+			if mount.Name == "" {
+				return errors.Errorf("invalid config : volumeMounts:%s[%d]:name must be set", key, i)
+			}
+			if _, ok := names[mount.Name]; ok {
+				return errors.Errorf("invalid config : volumeMounts:%s[%d]:name %q is not unique", key, i, mount.Name)
+			}
+			names[mount.Name] = struct{}{}
+			if !filepath.IsAbs(mount.MountPath) {
+				return errors.Errorf("invalid config : volumeMounts:%s[%d]:mountPath %q must be an absolute path", key, i, mount.MountPath)
+			}
+			sources := 0
+			for _, set := range []bool{mount.HostPath != nil, mount.Secret != nil, mount.ConfigMap != nil} {
+				if set {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return errors.Errorf("invalid config : volumeMounts:%s[%d] must have exactly one of hostPath, secret or configMap", key, i)
+			}
+		}
+	}
+	return nil
+}
+
+func isOverrideKey(key rookv1.KeyType) bool {
+	for _, overrideKey := range overrideKeys {
+		if key == overrideKey {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestDaemonOverridesMerge(t *testing.T) {
+	// nothing set
+	assert.Equal(t, []v1.EnvVar{}, GetDaemonEnv(DaemonEnvSpec{}, KeyMon))
+	assert.Equal(t, []DaemonVolumeMount{}, GetDaemonVolumeMounts(nil, KeyMon))
+
+	// the daemon replaces the variables and the volumes of all the daemons with the same name
+	env := DaemonEnvSpec{
+		"all": {{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}, {Name: "NO_PROXY", Value: "localhost"}},
+		"rgw": {{Name: "NO_PROXY", Value: "localhost,.svc"}},
+	}
+	assert.Equal(t, env["all"], GetDaemonEnv(env, KeyMon))
+	assert.Equal(t, []v1.EnvVar{{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}, {Name: "NO_PROXY", Value: "localhost,.svc"}}, GetDaemonEnv(env, KeyRgw))
+
+	mounts := DaemonVolumeMountsSpec{
+		"all": {{Name: "ca", MountPath: "/etc/pki/ca", ConfigMap: &v1.ConfigMapVolumeSource{LocalObjectReference: v1.LocalObjectReference{Name: "ca"}}}},
+		"osd": {{Name: "tools", MountPath: "/opt/tools", HostPath: &v1.HostPathVolumeSource{Path: "/opt/tools"}}},
+	}
+	assert.Equal(t, mounts["all"], GetDaemonVolumeMounts(mounts, KeyMgr))
+	assert.Equal(t, []DaemonVolumeMount{mounts["all"][0], mounts["osd"][0]}, GetDaemonVolumeMounts(mounts, KeyOSD))
+
+	volume := mounts["osd"][0].Volume()
+	assert.Equal(t, "tools", volume.Name)
+	assert.Equal(t, "/opt/tools", volume.HostPath.Path)
+	assert.Nil(t, volume.Secret)
+	assert.Equal(t, v1.VolumeMount{Name: "tools", MountPath: "/opt/tools"}, mounts["osd"][0].VolumeMount())
+}
+
+func TestValidateDaemonOverrides(t *testing.T) {
+	secret := &v1.SecretVolumeSource{SecretName: "ca"}
+	spec := ClusterSpec{
+		Env:          DaemonEnvSpec{"all": {{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}},
+		VolumeMounts: DaemonVolumeMountsSpec{"rgw": {{Name: "ca", MountPath: "/etc/pki/ca", Secret: secret}}},
+	}
+	assert.NoError(t, validateDaemonOverrides(spec))
+
+	// unknown daemon
+	spec.Env["toolbox"] = []v1.EnvVar{{Name: "FOO"}}
+	assert.Error(t, validateDaemonOverrides(spec))
+	delete(spec.Env, "toolbox")
+
+	// the variables must be named
+	spec.Env["mon"] = []v1.EnvVar{{Value: "foo"}}
+	assert.Error(t, validateDaemonOverrides(spec))
+	delete(spec.Env, "mon")
+
+	// the mount path must be absolute
+	spec.VolumeMounts["rgw"][0].MountPath = "etc/pki/ca"
+	assert.Error(t, validateDaemonOverrides(spec))
+	spec.VolumeMounts["rgw"][0].MountPath = "/etc/pki/ca"
+
+	// the names must be unique
+	spec.VolumeMounts["rgw"] = append(spec.VolumeMounts["rgw"], DaemonVolumeMount{Name: "ca", MountPath: "/etc/ca", Secret: secret})
+	assert.Error(t, validateDaemonOverrides(spec))
+	spec.VolumeMounts["rgw"][1].Name = "ca2"
+	assert.NoError(t, validateDaemonOverrides(spec))
+
+	// exactly one source
+	spec.VolumeMounts["rgw"][1].HostPath = &v1.HostPathVolumeSource{Path: "/etc/ca"}
+	assert.Error(t, validateDaemonOverrides(spec))
+	spec.VolumeMounts["rgw"][1].HostPath = nil
+	spec.VolumeMounts["rgw"][1].Secret = nil
+	assert.Error(t, validateDaemonOverrides(spec))
+}
@@ -16,7 +16,11 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
+	"github.com/pkg/errors"
 	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	v1 "k8s.io/api/core/v1"
 )
 
 // GetMgrPlacement returns the placement for the MGR service
@@ -34,7 +38,55 @@ func GetOSDPlacement(p rookv1.PlacementSpec) rookv1.Placement {
 	return p.All().Merge(p[KeyOSD])
 }
 
+// GetPrepareOSDPlacement returns the placement of the OSD prepare pods, the placement of the OSDs if not set
+func GetPrepareOSDPlacement(p rookv1.PlacementSpec) rookv1.Placement {
+	if _, ok := p[KeyOSDPrepare]; ok {
+		return p.All().Merge(p[KeyOSDPrepare])
+	}
+	return GetOSDPlacement(p)
+}
+
 // GetCleanupPlacement returns the placement the cleanup job
 func GetCleanupPlacement(p rookv1.PlacementSpec) rookv1.Placement {
 	return p.All().Merge(p[KeyCleanup])
 }
+
+// validatePlacement ensures the topology spread constraints of the placement can be scheduled, kubernetes otherwise
+// rejecting the pods of the daemon
+func validatePlacement(name string, p rookv1.Placement) error {
+	for i, constraint := range p.TopologySpreadConstraints {
+		if constraint.MaxSkew < 1 {
+			return errors.Errorf("invalid config : %s:topologySpreadConstraints[%d]:maxSkew %d must be at least 1", name, i, constraint.MaxSkew)
+		}
+		if constraint.TopologyKey == "" {
+			return errors.Errorf("invalid config : %s:topologySpreadConstraints[%d]:topologyKey must be set", name, i)
+		}
+		switch constraint.WhenUnsatisfiable {
+		case v1.DoNotSchedule, v1.ScheduleAnyway:
+		default:
+			return errors.Errorf("invalid config : %s:topologySpreadConstraints[%d]:whenUnsatisfiable %q is not %q or %q",
+				name, i, constraint.WhenUnsatisfiable, v1.DoNotSchedule, v1.ScheduleAnyway)
+		}
+	}
+	return nil
+}
+
+// validatePlacements ensures the placements of the daemons of the cluster and of its osds on pvc can be scheduled
+func validatePlacements(spec ClusterSpec) error {
+	for key, p := range spec.Placement {
+		if err := validatePlacement(fmt.Sprintf("placement:%s", key), p); err != nil {
+			return err
+		}
+	}
+	for _, set := range spec.Storage.StorageClassDeviceSets {
+		if err := validatePlacement(fmt.Sprintf("storageClassDeviceSets:%s:placement", set.Name), set.Placement); err != nil {
+			return err
+		}
+		if set.PreparePlacement != nil {
+			if err := validatePlacement(fmt.Sprintf("storageClassDeviceSets:%s:preparePlacement", set.Name), *set.PreparePlacement); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -51,3 +51,29 @@ func GetCleanupPriorityClassName(p rook.PriorityClassNamesSpec) string {
 	}
 	return p[KeyCleanup]
 }
+
+// GetCrashCollectorPriorityClassName returns the priority class name for the crash collectors
+func GetCrashCollectorPriorityClassName(p rook.PriorityClassNamesSpec) string {
+	if _, ok := p[KeyCrashCollector]; !ok {
+		return p.All()
+	}
+	return p[KeyCrashCollector]
+}
+
+// GetRGWPriorityClassName returns the priority class name for the rgw pods of the object stores that do not set
+// their own
+func GetRGWPriorityClassName(p rook.PriorityClassNamesSpec) string {
+	if _, ok := p[KeyRgw]; !ok {
+		return p.All()
+	}
+	return p[KeyRgw]
+}
+
+// GetMdsPriorityClassName returns the priority class name for the mds pods of the filesystems that do not set their
+// own
+func GetMdsPriorityClassName(p rook.PriorityClassNamesSpec) string {
+	if _, ok := p[KeyMds]; !ok {
+		return p.All()
+	}
+	return p[KeyMds]
+}
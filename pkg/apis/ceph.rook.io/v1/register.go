@@ -60,8 +60,20 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephClusterList{},
 		&CephBlockPool{},
 		&CephBlockPoolList{},
+		&CephBlockPoolRadosNamespace{},
+		&CephBlockPoolRadosNamespaceList{},
 		&CephFilesystem{},
 		&CephFilesystemList{},
+		&CephFilesystemMirror{},
+		&CephFilesystemMirrorList{},
+		&CephFilesystemSubVolumeGroup{},
+		&CephFilesystemSubVolumeGroupList{},
+		&CephBucketTopic{},
+		&CephBucketTopicList{},
+		&CephCOSIDriver{},
+		&CephCOSIDriverList{},
+		&CephBucketNotification{},
+		&CephBucketNotificationList{},
 		&CephNFS{},
 		&CephNFSList{},
 		&CephObjectStore{},
@@ -17,8 +17,12 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+
+	"github.com/pkg/errors"
 	rook "github.com/rook/rook/pkg/apis/rook.io/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
@@ -65,3 +69,56 @@ func GetCrashCollectorResources(p rook.ResourceSpec) v1.ResourceRequirements {
 func GetCleanupResources(p rook.ResourceSpec) v1.ResourceRequirements {
 	return p[ResourcesKeyCleanup]
 }
+
+// daemonMemoryMinimum is the memory limit below which a ceph daemon fails to start or is killed under load
+type daemonMemoryMinimum struct {
+	key     string
+	minimum resource.Quantity
+}
+
+// daemonMemoryMinimums are the memory minimums of the daemons of the cluster, in the order they are validated
+var daemonMemoryMinimums = []daemonMemoryMinimum{
+	{key: ResourcesKeyMon, minimum: resource.MustParse("1Gi")},
+	{key: ResourcesKeyMgr, minimum: resource.MustParse("512Mi")},
+	{key: ResourcesKeyOSD, minimum: resource.MustParse("2Gi")},
+}
+
+// mdsMemoryMinimum is the memory minimum of the mds daemons of the filesystems
+var mdsMemoryMinimum = resource.MustParse("4Gi")
+
+// ValidateResourceLimits ensures the memory limits of the daemons are not below the minimums of the ceph daemons. The
+// operator also validates the limits since the admission controller may not be enabled.
+func ValidateResourceLimits(spec ClusterSpec) error {
+	for _, daemon := range daemonMemoryMinimums {
+		if err := validateMemoryLimit(fmt.Sprintf("resources:%s", daemon.key), spec.Resources[daemon.key], daemon.minimum); err != nil {
+			return err
+		}
+	}
+	osdMinimum := daemonMemoryMinimums[2].minimum
+	for _, set := range spec.Storage.StorageClassDeviceSets {
+		if err := validateMemoryLimit(fmt.Sprintf("storage:storageClassDeviceSets:%s:resources", set.Name), set.Resources, osdMinimum); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateMemoryLimit(name string, resources v1.ResourceRequirements, minimum resource.Quantity) error {
+	limit, ok := resources.Limits[v1.ResourceMemory]
+	if ok && limit.Cmp(minimum) < 0 {
+		return errors.Errorf("invalid config : %s memory limit %q is below the minimum of %q for the daemon to run", name, limit.String(), minimum.String())
+	}
+	return nil
+}
+
+// resourceWarnings returns the warnings about the daemons whose resources have no requests, the daemons then being
+// scheduled on nodes that may not have the memory to run them
+func resourceWarnings(spec ClusterSpec) []string {
+	warnings := []string{}
+	for _, daemon := range daemonMemoryMinimums {
+		if len(spec.Resources[daemon.key].Requests) == 0 {
+			warnings = append(warnings, fmt.Sprintf("resources:%s has no requests, the %s daemons may be scheduled on nodes without the %s of memory they need", daemon.key, daemon.key, daemon.minimum.String()))
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+)
+
+// OSDConfigSection is the section of the mon config store where the scrub and compression options are set
+const OSDConfigSection = "osd"
+
+// ScrubbingConfig returns the ceph options of the scrub schedule of the OSDs, failing if the schedule is invalid
+func ScrubbingConfig(scrubbing *rookv1.ScrubbingSpec) (map[string]string, error) {
+	options := map[string]string{}
+	if scrubbing == nil {
+		return options, nil
+	}
+
+	for _, setting := range []struct {
+		option string
+		value  *int
+		max    int
+	}{
+		{"osd_scrub_begin_hour", scrubbing.BeginHour, 23},
+		{"osd_scrub_end_hour", scrubbing.EndHour, 23},
+		{"osd_scrub_begin_week_day", scrubbing.BeginWeekDay, 6},
+		{"osd_scrub_end_week_day", scrubbing.EndWeekDay, 6},
+	} {
+		if setting.value == nil {
+			continue
+		}
+		if *setting.value < 0 || *setting.value > setting.max {
+			return nil, errors.Errorf("%s %d must be between 0 and %d", setting.option, *setting.value, setting.max)
+		}
+		options[setting.option] = strconv.Itoa(*setting.value)
+	}
+	if (scrubbing.BeginHour == nil) != (scrubbing.EndHour == nil) {
+		return nil, errors.New("beginHour and endHour must be set together")
+	}
+	if (scrubbing.BeginWeekDay == nil) != (scrubbing.EndWeekDay == nil) {
+		return nil, errors.New("beginWeekDay and endWeekDay must be set together")
+	}
+
+	intervals := map[string]time.Duration{}
+	for _, setting := range []struct {
+		option string
+		value  string
+	}{
+		{"osd_scrub_min_interval", scrubbing.MinInterval},
+		{"osd_scrub_max_interval", scrubbing.MaxInterval},
+		{"osd_deep_scrub_interval", scrubbing.DeepInterval},
+	} {
+		if setting.value == "" {
+			continue
+		}
+		interval, err := time.ParseDuration(setting.value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s %q", setting.option, setting.value)
+		}
+		if interval <= 0 {
+			return nil, errors.Errorf("%s %q must be positive", setting.option, setting.value)
+		}
+		intervals[setting.option] = interval
+		// the intervals of the ceph options are in seconds
+		options[setting.option] = strconv.FormatInt(int64(interval.Seconds()), 10)
+	}
+	minInterval, minOK := intervals["osd_scrub_min_interval"]
+	maxInterval, maxOK := intervals["osd_scrub_max_interval"]
+	if minOK && maxOK && minInterval > maxInterval {
+		return nil, errors.Errorf("minInterval %q is longer than maxInterval %q", scrubbing.MinInterval, scrubbing.MaxInterval)
+	}
+
+	if scrubbing.MaxScrubs < 0 {
+		return nil, errors.Errorf("maxScrubs %d must be positive", scrubbing.MaxScrubs)
+	}
+	if scrubbing.MaxScrubs > 0 {
+		options["osd_max_scrubs"] = strconv.Itoa(scrubbing.MaxScrubs)
+	}
+	return options, nil
+}
+
+// validateScrubbing ensures the scrub schedule is valid and its options are not set in the cephConfig as well
+func validateScrubbing(spec ClusterSpec) error {
+	options, err := ScrubbingConfig(spec.Storage.Scrubbing)
+	if err != nil {
+		return errors.Wrap(err, "invalid config : storage:scrubbing")
+	}
+	return validateConfigConflicts("storage:scrubbing", options, spec.CephConfig)
+}
+
+// validateConfigConflicts ensures the options set from a setting of the spec are not set in the cephConfig as well,
+// where the operator would set them twice with different values
+func validateConfigConflicts(setting string, options map[string]string, cephConfig map[string]map[string]string) error {
+	for option := range options {
+		for _, who := range []string{"global", OSDConfigSection} {
+			if _, ok := cephConfig[who][option]; ok {
+				return errors.Errorf("invalid config : the option %q of %s is also set in cephConfig:%s", option, setting, who)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultKeyRotationPeriod is the duration between two rotations of the ceph keys when none is set
+const DefaultKeyRotationPeriod = 7 * 24 * time.Hour
+
+// IsEnabled gets whether an external key management service stores the encryption keys of the osds
+func (kms *KeyManagementServiceSpec) IsEnabled() bool {
+	return len(kms.ConnectionDetails) != 0
+}
+
+// GetPeriod returns the duration between two rotations of the ceph keys
+func (k *KeyRotationSpec) GetPeriod() (time.Duration, error) {
+	if k.Period == "" {
+		return DefaultKeyRotationPeriod, nil
+	}
+	period, err := time.ParseDuration(k.Period)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid key rotation period %q", k.Period)
+	}
+	if period <= 0 {
+		return 0, errors.Errorf("invalid key rotation period %q, it must be positive", k.Period)
+	}
+	return period, nil
+}
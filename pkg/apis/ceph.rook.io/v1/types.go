@@ -21,6 +21,7 @@ import (
 
 	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -42,14 +43,42 @@ type CephCluster struct {
 }
 
 type CephClusterHealthCheckSpec struct {
+	// Paused stops the monitoring of all the daemons regardless of their individual settings
+	Paused        bool                                 `json:"paused,omitempty"`
 	DaemonHealth  DaemonHealthSpec                     `json:"daemonHealth,omitempty"`
 	LivenessProbe map[rookv1.KeyType]*rookv1.ProbeSpec `json:"livenessProbe,omitempty"`
+	// EscalatedWarnings are the ceph health check codes (e.g. RECENT_CRASH) reported as HEALTH_ERR instead of HEALTH_WARN
+	EscalatedWarnings []string `json:"escalatedWarnings,omitempty"`
+	// CommandTimeout is the duration after which a ceph command of the health checkers is considered failed, 30s by default
+	CommandTimeout string `json:"commandTimeout,omitempty"`
+	// Remediation enables the automatic handling of some ceph health warnings
+	Remediation HealthRemediationSpec `json:"remediation,omitempty"`
+}
+
+// HealthRemediationSpec represents the ceph health warnings handled automatically by the status health check
+type HealthRemediationSpec struct {
+	// RepairInconsistentPGs runs 'ceph pg repair' on the PGs reported inconsistent
+	RepairInconsistentPGs bool `json:"repairInconsistentPGs,omitempty"`
+	// RestartCrashedDaemons is the number of new crashes of a mon, mgr, osd or mds after which its pod is restarted
+	// and its crashes archived, 0 to never restart the daemons
+	RestartCrashedDaemons int `json:"restartCrashedDaemons,omitempty"`
+	// EnablePoolApplications tags the pools of the Ceph CRs reported without application with rbd, cephfs or rgw
+	EnablePoolApplications bool `json:"enablePoolApplications,omitempty"`
+	// MinInterval is the minimum duration between two remediations of a same PG, daemon or pool, 1h by default
+	MinInterval string `json:"minInterval,omitempty"`
 }
 
 type DaemonHealthSpec struct {
-	Status              HealthCheckSpec `json:"status,omitempty"`
-	Monitor             HealthCheckSpec `json:"mon,omitempty"`
-	ObjectStorageDaemon HealthCheckSpec `json:"osd,omitempty"`
+	Status              HealthCheckSpec    `json:"status,omitempty"`
+	Monitor             MonHealthCheckSpec `json:"mon,omitempty"`
+	ObjectStorageDaemon HealthCheckSpec    `json:"osd,omitempty"`
+}
+
+// MonHealthCheckSpec represents the health check of the mons
+type MonHealthCheckSpec struct {
+	HealthCheckSpec `json:",inline"`
+	// DisableFailover suspends the failover of the mons out of quorum, e.g. while a mon node is rebooted on purpose
+	DisableFailover bool `json:"disableFailover,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -74,6 +103,9 @@ type ClusterSpec struct {
 	// The annotations-related configuration to add/set on each Pod related object.
 	Annotations rookv1.AnnotationsSpec `json:"annotations,omitempty"`
 
+	// The labels-related configuration to add/set on each Pod related object.
+	Labels rookv1.LabelsSpec `json:"labels,omitempty"`
+
 	// The placement-related configuration to pass to kubernetes (affinity, node selector, tolerations).
 	Placement rookv1.PlacementSpec `json:"placement,omitempty"`
 
@@ -86,6 +118,12 @@ type ClusterSpec struct {
 	// PriorityClassNames sets priority classes on components
 	PriorityClassNames rookv1.PriorityClassNamesSpec `json:"priorityClassNames,omitempty"`
 
+	// Env are the environment variables added to the containers of the daemons, e.g. proxy settings
+	Env DaemonEnvSpec `json:"env,omitempty"`
+
+	// VolumeMounts are the extra volumes mounted in the containers of the daemons, e.g. a custom CA bundle
+	VolumeMounts DaemonVolumeMountsSpec `json:"volumeMounts,omitempty"`
+
 	// The path on the host where config and data can be persisted.
 	DataDirHostPath string `json:"dataDirHostPath,omitempty"`
 
@@ -126,6 +164,111 @@ type ClusterSpec struct {
 
 	// Internal daemon healthchecks and liveness probe
 	HealthCheck CephClusterHealthCheckSpec `json:"healthCheck"`
+
+	// Security represents the security settings of the cluster
+	Security SecuritySpec `json:"security,omitempty"`
+
+	// CephConfig is the ceph configuration applied to the mon config store, by section (such as "global"
+	// or "osd.0") and option name
+	CephConfig map[string]map[string]string `json:"cephConfig,omitempty"`
+
+	// Toolbox is the toolbox deployed by the operator to run the ceph commands on the cluster
+	Toolbox ToolboxSpec `json:"toolbox,omitempty"`
+
+	// LogCollector writes the logs of the daemons to files under the dataDirHostPath, rotated by a sidecar
+	LogCollector LogCollectorSpec `json:"logCollector,omitempty"`
+
+	// Backup is the periodic backup of the metadata of the cluster to a PVC or an S3 bucket
+	Backup BackupSpec `json:"backup,omitempty"`
+
+	// Telemetry is the opt-in to the telemetry of the ceph project and the weekly report of the cluster, the
+	// telemetry being left as is when not set
+	Telemetry *TelemetrySpec `json:"telemetry,omitempty"`
+
+	// ImagePullSecrets are the secrets of the private registries of the images, added to all the pods generated by
+	// the operator for the cluster
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// UpdateStrategy is how the osds are restarted when their deployments change, the osds being updated one after
+	// the other when not set
+	UpdateStrategy *UpdateStrategySpec `json:"updateStrategy,omitempty"`
+
+	// DriftPolicy is how the changes made out of band to the deployments, services and configmaps of the cluster are
+	// handled, "revert" or "report". They are only fixed on the next reconcile of the cluster when not set.
+	DriftPolicy DriftPolicyType `json:"driftPolicy,omitempty"`
+
+	// ReconcileStrategy "paused" stops the reconcile of the cluster and the remediation of its health checks, so that
+	// the admins can take manual control of the cluster
+	ReconcileStrategy ReconcileStrategy `json:"reconcileStrategy,omitempty"`
+}
+
+// ReconcileStrategy is whether the operator reconciles the cluster
+type ReconcileStrategy string
+
+const (
+	// ReconcileStrategyPaused stops the reconcile and the health remediation of the cluster, its status still being
+	// reported
+	ReconcileStrategyPaused ReconcileStrategy = "paused"
+)
+
+// UpdateStrategyType is how the osd deployments are updated
+type UpdateStrategyType string
+
+const (
+	// UpdateStrategyOneByOne updates the osd deployments one after the other, checking the osds are ok to stop
+	UpdateStrategyOneByOne UpdateStrategyType = "OneByOne"
+	// UpdateStrategyWaves updates the osd deployments by waves of failure domains, the PGs being clean between waves
+	UpdateStrategyWaves UpdateStrategyType = "Waves"
+)
+
+// UpdateStrategySpec represents the rollout of the changes of the osd deployments
+type UpdateStrategySpec struct {
+	// Type is OneByOne (the default) or Waves
+	Type UpdateStrategyType `json:"type,omitempty"`
+	// FailureDomain is the CRUSH bucket type grouping the osds restarted together, "host" by default
+	FailureDomain string `json:"failureDomain,omitempty"`
+	// WaveSize is the number of failure domains restarted by a wave, 1 by default
+	WaveSize int `json:"waveSize,omitempty"`
+	// WaveTimeout is how long the osds of a wave may take to be ready, and the PGs to be clean before the next wave,
+	// as a duration such as "10m", the default
+	WaveTimeout string `json:"waveTimeout,omitempty"`
+}
+
+// DriftPolicyType is how the changes made out of band to the objects managed by the operator are handled
+type DriftPolicyType string
+
+const (
+	// DriftPolicyRevert reverts the changed fields as soon as the change is seen
+	DriftPolicyRevert DriftPolicyType = "revert"
+	// DriftPolicyReport reports the changed objects in the status of the CephCluster without reverting them
+	DriftPolicyReport DriftPolicyType = "report"
+)
+
+// SecuritySpec represents the security settings of the cluster
+type SecuritySpec struct {
+	// KeyManagementService is the external key management service storing the encryption keys of the osds,
+	// the keys being stored in kubernetes secrets otherwise
+	KeyManagementService KeyManagementServiceSpec `json:"kms,omitempty"`
+	// KeyRotation is the schedule of the rotation of the keys of the admin, the daemons and the CSI drivers
+	KeyRotation KeyRotationSpec `json:"keyRotation,omitempty"`
+}
+
+// KeyRotationSpec represents the schedule of the rotation of the ceph keys
+type KeyRotationSpec struct {
+	// Enabled rotates the keys periodically
+	Enabled bool `json:"enabled,omitempty"`
+	// Period is the duration between two rotations, such as "720h". Defaults to a week.
+	Period string `json:"period,omitempty"`
+}
+
+// KeyManagementServiceSpec represents the settings of the key management service
+type KeyManagementServiceSpec struct {
+	// ConnectionDetails are the settings of the connection to the key management service, such as KMS_PROVIDER,
+	// VAULT_ADDR and VAULT_AUTH_METHOD
+	ConnectionDetails map[string]string `json:"connectionDetails,omitempty"`
+	// TokenSecretName is the name of the secret in the cluster namespace holding the token of the key management
+	// service in its "token" key
+	TokenSecretName string `json:"tokenSecretName,omitempty"`
 }
 
 // VersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -135,6 +278,13 @@ type CephVersionSpec struct {
 
 	// Whether to allow unsupported versions (do not set to true in production)
 	AllowUnsupported bool `json:"allowUnsupported,omitempty"`
+
+	// SkipImageVersionDetection skips the job detecting the ceph version of the image, which cannot run in some
+	// restricted environments, the declared Version being the version of the image
+	SkipImageVersionDetection bool `json:"skipImageVersionDetection,omitempty"`
+
+	// Version is the exact ceph version of the image, such as 15.2.4, required to skip the image version detection
+	Version string `json:"version,omitempty"`
 }
 
 // DriveGroupsSpec is a list Ceph Drive Group specifications.
@@ -166,6 +316,49 @@ type DashboardSpec struct {
 	Port int `json:"port,omitempty"`
 	// Whether SSL should be used
 	SSL bool `json:"ssl,omitempty"`
+	// AdminPasswordSecret is the key of a secret in the cluster namespace holding the password of the admin user,
+	// the "password" key by default. If not set, a random password is generated in the rook-ceph-dashboard-password secret.
+	AdminPasswordSecret *v1.SecretKeySelector `json:"adminPasswordSecret,omitempty"`
+	// Service customizes the dashboard service, e.g. to expose the dashboard outside of the cluster
+	Service *ServiceSpec `json:"service,omitempty"`
+}
+
+// ServiceSpec represents the customization of a service created by the operator, to expose the daemons outside of
+// the kubernetes cluster
+type ServiceSpec struct {
+	// Type is the type of the service, ClusterIP by default. NodePort and LoadBalancer expose the daemons outside of
+	// the cluster.
+	Type v1.ServiceType `json:"type,omitempty"`
+	// Annotations are added to the service, e.g. to configure the load balancer of the cloud provider
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges are the CIDRs of the clients allowed through the load balancer of a LoadBalancer service
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+}
+
+// DaemonEnvSpec is the environment variables of the containers of the daemons, keyed by daemon, the variables of the
+// "all" key being added to all the daemons
+type DaemonEnvSpec map[rookv1.KeyType][]v1.EnvVar
+
+// DaemonVolumeMountsSpec is the extra volumes of the containers of the daemons, keyed by daemon, the volumes of the
+// "all" key being mounted in all the daemons
+type DaemonVolumeMountsSpec map[rookv1.KeyType][]DaemonVolumeMount
+
+// DaemonVolumeMount is an extra volume mounted in the containers of a daemon, from exactly one of the sources
+type DaemonVolumeMount struct {
+	// Name is the name of the volume in the pod, which must not be the name of a volume of the daemon
+	Name string `json:"name"`
+	// MountPath is the absolute path of the volume in the containers
+	MountPath string `json:"mountPath"`
+	// SubPath is the path within the volume to mount, its root by default
+	SubPath string `json:"subPath,omitempty"`
+	// ReadOnly mounts the volume read-only
+	ReadOnly bool `json:"readOnly,omitempty"`
+	// HostPath is a path of the host of the daemon
+	HostPath *v1.HostPathVolumeSource `json:"hostPath,omitempty"`
+	// Secret is a secret in the namespace of the cluster
+	Secret *v1.SecretVolumeSource `json:"secret,omitempty"`
+	// ConfigMap is a configmap in the namespace of the cluster
+	ConfigMap *v1.ConfigMapVolumeSource `json:"configMap,omitempty"`
 }
 
 // MonitoringSpec represents the settings for Prometheus based Ceph monitoring
@@ -174,9 +367,19 @@ type MonitoringSpec struct {
 	// types must exist or the creation will fail.
 	Enabled bool `json:"enabled,omitempty"`
 
-	// The namespace where the prometheus rules and alerts should be created.
+	// The namespace where the service monitor, the prometheus rules and alerts should be created.
 	// If empty, the same namespace as the cluster will be used.
 	RulesNamespace string `json:"rulesNamespace,omitempty"`
+
+	// Whether to skip the creation of the default prometheus rules, e.g. to maintain custom alerts instead
+	DisablePrometheusRules bool `json:"disablePrometheusRules,omitempty"`
+
+	// ExternalMgrEndpoints are the addresses of the prometheus endpoints of the mgrs of an external cluster,
+	// exposed with a service in the namespace of the cluster
+	ExternalMgrEndpoints []v1.EndpointAddress `json:"externalMgrEndpoints,omitempty"`
+
+	// ExternalMgrPrometheusPort is the port of the prometheus endpoints of the mgrs of an external cluster, 9283 by default
+	ExternalMgrPrometheusPort uint16 `json:"externalMgrPrometheusPort,omitempty"`
 }
 
 type ClusterStatus struct {
@@ -186,6 +389,87 @@ type ClusterStatus struct {
 	Conditions  []Condition     `json:"conditions,omitempty"`
 	CephStatus  *CephStatus     `json:"ceph,omitempty"`
 	CephVersion *ClusterVersion `json:"version,omitempty"`
+	// ExternalCephVersion is the version of the mons of the external cluster, detected on every reconcile
+	ExternalCephVersion string `json:"externalCephVersion,omitempty"`
+	// DaemonHealth is the summary of the daemons as seen by the last ceph status check
+	DaemonHealth *DaemonHealthStatus `json:"daemonHealth,omitempty"`
+	// DeviceClasses is the capacity of the OSDs of each CRUSH device class as seen by the last ceph status check
+	DeviceClasses []DeviceClassStatus `json:"deviceClasses,omitempty"`
+	// Upgrade is the progress of the daemons towards the ceph version of the image of the cluster
+	Upgrade *UpgradeStatus `json:"upgrade,omitempty"`
+	// Progress is the step of the orchestration of the cluster being run
+	Progress *OrchestrationProgress `json:"progress,omitempty"`
+	// MgrModules are the outcome of the configuration of the mgr modules of the spec
+	MgrModules []MgrModuleStatus `json:"mgrModules,omitempty"`
+	// Warnings are the non-fatal issues of the cluster settings, such as the daemons without resource requests
+	Warnings []string `json:"warnings,omitempty"`
+	// Balancer is the state of the balancer as seen by the last ceph status check, when the balancer is in the spec
+	Balancer *BalancerStatus `json:"balancer,omitempty"`
+	// Backup is the outcome of the last backups of the metadata of the cluster, when the backups are enabled
+	Backup *BackupStatus `json:"backup,omitempty"`
+	// DriftedResources are the objects of the cluster changed out of band, when the drift policy is "report"
+	DriftedResources []DriftedResource `json:"driftedResources,omitempty"`
+	// PendingTopologyChanges are the nodes added to or removed from the kubernetes cluster whose OSDs are not
+	// provisioned or removed yet, when all the nodes are used
+	PendingTopologyChanges []TopologyChange `json:"pendingTopologyChanges,omitempty"`
+}
+
+const (
+	// TopologyChangeAdd is the change of a node added to the kubernetes cluster, whose OSDs are to be provisioned
+	TopologyChangeAdd = "Add"
+	// TopologyChangeRemove is the change of a node deleted from the kubernetes cluster, whose OSDs are to be removed
+	TopologyChangeRemove = "Remove"
+)
+
+// TopologyChange is a node added to or removed from the kubernetes cluster whose OSDs are not reconciled yet
+type TopologyChange struct {
+	// Node is the name of the node
+	Node string `json:"node"`
+	// Change is Add or Remove
+	Change string `json:"change"`
+	// OSDs are the ids of the OSDs of a removed node
+	OSDs []int `json:"osds,omitempty"`
+	// Since is when the change was first seen
+	Since string `json:"since,omitempty"`
+}
+
+// DriftedResource is an object of the cluster whose fields set by the operator were changed out of band
+type DriftedResource struct {
+	// Kind is the kind of the object, e.g. Deployment
+	Kind string `json:"kind"`
+	// Name is the name of the object
+	Name string `json:"name"`
+	// Since is when the change was first seen
+	Since string `json:"since,omitempty"`
+	// Changes is the patch that would revert the changed fields
+	Changes string `json:"changes,omitempty"`
+}
+
+// BalancerStatus represents the state of the balancer and the score of the distribution of the PGs
+type BalancerStatus struct {
+	Mode         string  `json:"mode"`
+	Active       bool    `json:"active"`
+	MaxMisplaced float64 `json:"maxMisplaced,omitempty"`
+	// Score is the score of the current distribution of the PGs, lower is better
+	Score       float64 `json:"score"`
+	LastChecked string  `json:"lastChecked,omitempty"`
+}
+
+// MgrModuleStatus represents whether a mgr module of the spec was enabled or disabled as requested
+type MgrModuleStatus struct {
+	Name       string `json:"name"`
+	Enabled    bool   `json:"enabled"`
+	Configured bool   `json:"configured"`
+	Message    string `json:"message,omitempty"`
+}
+
+// OrchestrationProgress represents the steps of the orchestration of the cluster already completed
+type OrchestrationProgress struct {
+	Step        string `json:"step,omitempty"`
+	Completed   int    `json:"completed"`
+	Total       int    `json:"total"`
+	Percent     int    `json:"percent"`
+	LastUpdated string `json:"lastUpdated,omitempty"`
 }
 
 type CephStatus struct {
@@ -196,6 +480,61 @@ type CephStatus struct {
 	PreviousHealth string                       `json:"previousHealth,omitempty"`
 }
 
+// DaemonHealthStatus represents the health of the ceph daemons of the cluster
+type DaemonHealthStatus struct {
+	Mon         MonHealthStatus `json:"mon"`
+	Mgr         MgrHealthStatus `json:"mgr"`
+	OSD         OSDHealthStatus `json:"osd"`
+	LastChecked string          `json:"lastChecked,omitempty"`
+}
+
+// UpgradeStatus represents the number of daemons of each type running the ceph version of the cluster
+type UpgradeStatus struct {
+	TargetVersion string `json:"targetVersion,omitempty"`
+	// Daemons are listed in the order they are upgraded
+	Daemons     []DaemonUpgradeStatus `json:"daemons,omitempty"`
+	Complete    bool                  `json:"complete"`
+	LastChecked string                `json:"lastChecked,omitempty"`
+}
+
+// DaemonUpgradeStatus represents the number of daemons of a type already running the target version
+type DaemonUpgradeStatus struct {
+	Type     string `json:"type"`
+	Upgraded int    `json:"upgraded"`
+	Total    int    `json:"total"`
+}
+
+// MonHealthStatus represents the quorum of the mons
+type MonHealthStatus struct {
+	Count    int `json:"count"`
+	InQuorum int `json:"inQuorum"`
+}
+
+// MgrHealthStatus represents the active and standby mgrs
+type MgrHealthStatus struct {
+	Active    string `json:"active,omitempty"`
+	Available bool   `json:"available"`
+	Standbys  int    `json:"standbys"`
+}
+
+// OSDHealthStatus represents the number of osds in each state
+type OSDHealthStatus struct {
+	Count int `json:"count"`
+	Up    int `json:"up"`
+	Down  int `json:"down"`
+	In    int `json:"in"`
+	Out   int `json:"out"`
+}
+
+// DeviceClassStatus represents the capacity of the OSDs of a CRUSH device class, for the pools targeting the class
+type DeviceClassStatus struct {
+	Name           string `json:"name"`
+	OSDs           int    `json:"osds"`
+	BytesTotal     uint64 `json:"bytesTotal"`
+	BytesUsed      uint64 `json:"bytesUsed"`
+	BytesAvailable uint64 `json:"bytesAvailable"`
+}
+
 type ClusterVersion struct {
 	Image   string `json:"image,omitempty"`
 	Version string `json:"version,omitempty"`
@@ -204,6 +543,10 @@ type ClusterVersion struct {
 type CephHealthMessage struct {
 	Severity string `json:"severity"`
 	Message  string `json:"message"`
+	// Detail lists the messages of 'ceph health detail' for the check, such as the PGs degraded
+	Detail []string `json:"detail,omitempty"`
+	// Remediation is a hint to resolve the check
+	Remediation string `json:"remediation,omitempty"`
 }
 
 type Condition struct {
@@ -228,6 +571,9 @@ const (
 	ConditionUpgrading   ConditionType = "Upgrading"
 	ConditionDeleting    ConditionType = "Deleting"
 	ConditionHealthy     ConditionType = "Healthy"
+	ConditionPaused      ConditionType = "Paused"
+	// ConditionDeletionIsBlocked is set while the deletion of the CR waits for its dependents to be removed
+	ConditionDeletionIsBlocked ConditionType = "DeletionIsBlocked"
 	// DefaultFailureDomain for PoolSpec
 	DefaultFailureDomain = "host"
 )
@@ -247,11 +593,149 @@ type MonSpec struct {
 	Count                int                       `json:"count,omitempty"`
 	AllowMultiplePerNode bool                      `json:"allowMultiplePerNode,omitempty"`
 	VolumeClaimTemplate  *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	// StretchCluster spreads the mons across two data zones and an arbiter zone, and enables the stretch mode of ceph
+	StretchCluster *StretchClusterSpec `json:"stretchCluster,omitempty"`
+	// DisableCanary places the mons on the nodes matching their placement without scheduling canary pods first
+	DisableCanary bool `json:"disableCanary,omitempty"`
+	// Zones spreads the mons evenly across the zones, each mon only running on the nodes of its zone
+	Zones []MonZoneSpec `json:"zones,omitempty"`
+	// FailureDomainLabel is the node label giving the zone of the nodes for the mon zones, topology.kubernetes.io/zone by default
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+}
+
+// MonZoneSpec represents a zone the mons are spread across
+type MonZoneSpec struct {
+	// Name is the value of the failure domain label of the nodes of the zone
+	Name string `json:"name,omitempty"`
+}
+
+// StretchClusterSpec represents the zones of a cluster stretched across two data zones, with an arbiter zone breaking the ties
+type StretchClusterSpec struct {
+	// FailureDomainLabel is the node label giving the zone of the nodes, topology.kubernetes.io/zone by default
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
+	// Zones are the two data zones and the arbiter zone
+	Zones []StretchClusterZoneSpec `json:"zones,omitempty"`
+}
+
+// StretchClusterZoneSpec represents a zone of a stretch cluster
+type StretchClusterZoneSpec struct {
+	// Name is the value of the failure domain label of the nodes of the zone
+	Name string `json:"name,omitempty"`
+	// Arbiter is whether the zone only runs the tiebreaker mon
+	Arbiter bool `json:"arbiter,omitempty"`
 }
 
 // MgrSpec represents options to configure a ceph mgr
 type MgrSpec struct {
 	Modules []Module `json:"modules,omitempty"`
+	// Balancer is the mode and the misplaced ratio of the balancer, applied and kept in sync by the operator
+	Balancer *BalancerSpec `json:"balancer,omitempty"`
+}
+
+const (
+	// BalancerModeUpmap balances the PGs with the upmap entries of the osd map, requiring luminous clients
+	BalancerModeUpmap = "upmap"
+	// BalancerModeCrushCompat balances the PGs by adjusting the weights of the compat weight-set
+	BalancerModeCrushCompat = "crush-compat"
+	// BalancerModeOff turns off the balancer
+	BalancerModeOff = "off"
+)
+
+// BalancerSpec represents the settings of the balancer mgr module
+type BalancerSpec struct {
+	// Mode is one of upmap (the default), crush-compat or off
+	Mode string `json:"mode,omitempty"`
+	// MaxMisplaced is the max ratio of the PGs misplaced by the balancer at once, the ceph default of 0.05 if not set
+	MaxMisplaced float64 `json:"maxMisplaced,omitempty"`
+}
+
+// ToolboxSpec represents the toolbox deployment maintained by the operator, running the ceph image of the cluster
+type ToolboxSpec struct {
+	// Enabled deploys the toolbox, the toolbox deployed by the operator being removed when disabled
+	Enabled bool `json:"enabled,omitempty"`
+	// Resources are the resource requirements of the toolbox container
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// Placement is the placement of the toolbox pod
+	Placement rookv1.Placement `json:"placement,omitempty"`
+}
+
+// BackupSpec represents the periodic backup of the maps of the mon store, the keyrings and the config of the cluster
+type BackupSpec struct {
+	// Enabled schedules the backups, the backup cronjob being removed when disabled
+	Enabled bool `json:"enabled,omitempty"`
+	// Schedule is the cron schedule of the backups, daily by default
+	Schedule string `json:"schedule,omitempty"`
+	// Retain is the number of backups kept, the older backups being removed, 7 by default
+	Retain int `json:"retain,omitempty"`
+	// PersistentVolumeClaim is the claim of the volume where the backups are written
+	// +optional
+	PersistentVolumeClaim *v1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	// Bucket is the S3 bucket where the backups are uploaded
+	// +optional
+	Bucket *BackupBucketSpec `json:"bucket,omitempty"`
+	// Resources are the resource requirements of the backup container
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// Placement is the placement of the backup pods
+	Placement rookv1.Placement `json:"placement,omitempty"`
+}
+
+// BackupBucketSpec represents the S3 bucket where the backups are uploaded
+type BackupBucketSpec struct {
+	// Endpoint is the URL of the S3 endpoint, such as http://rook-ceph-rgw-my-store.rook-ceph:80
+	Endpoint string `json:"endpoint"`
+	// Name is the name of the bucket
+	Name string `json:"name"`
+	// Prefix is prepended to the names of the backup objects
+	Prefix string `json:"prefix,omitempty"`
+	// SecretName is the secret in the namespace of the cluster with the AccessKey and the SecretKey of the bucket,
+	// such as the secret of a CephObjectStoreUser
+	SecretName string `json:"secretName"`
+}
+
+// BackupStatus represents the outcome of the last backups of the cluster
+type BackupStatus struct {
+	// LastBackupTime is the completion time of the last successful backup
+	LastBackupTime string `json:"lastBackupTime,omitempty"`
+	// LastFailureTime is the time of the last failed backup, if it failed after the last successful backup
+	LastFailureTime string `json:"lastFailureTime,omitempty"`
+	Message         string `json:"message,omitempty"`
+}
+
+// The channels of the telemetry mgr module
+const (
+	TelemetryChannelBasic  = "basic"
+	TelemetryChannelCrash  = "crash"
+	TelemetryChannelDevice = "device"
+	TelemetryChannelIdent  = "ident"
+)
+
+// TelemetryChannels are the channels of the telemetry mgr module
+var TelemetryChannels = []string{TelemetryChannelBasic, TelemetryChannelCrash, TelemetryChannelDevice, TelemetryChannelIdent}
+
+// TelemetrySpec represents the settings of the telemetry mgr module, applied and kept in sync by the operator
+type TelemetrySpec struct {
+	// Enabled turns the telemetry on, accepting the license of the shared data, the telemetry being turned off when
+	// disabled
+	Enabled bool `json:"enabled,omitempty"`
+	// Channels are the channels sent with the telemetry, the ceph defaults basic, crash and device if not set
+	Channels []string `json:"channels,omitempty"`
+	// Contact, Description and Organization identify the cluster in the ident channel
+	Contact      string `json:"contact,omitempty"`
+	Description  string `json:"description,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	// Report generates the weekly report of the versions, the capacity and the health history of the cluster in a
+	// configmap, to attach to the support cases. The report is not sent anywhere.
+	Report bool `json:"report,omitempty"`
+}
+
+// LogCollectorSpec represents the logging of the daemons to files, rotated by a sidecar of the daemons
+type LogCollectorSpec struct {
+	// Enabled writes the logs of the daemons to files under the dataDirHostPath in addition to their stderr
+	Enabled bool `json:"enabled,omitempty"`
+	// Periodicity is the rotation of the log files, one of hourly, daily (the default), weekly or monthly
+	Periodicity string `json:"periodicity,omitempty"`
+	// MaxLogSize rotates a log file before its periodicity once it grows over the size
+	MaxLogSize *resource.Quantity `json:"maxLogSize,omitempty"`
 }
 
 // Module represents mgr modules that the user wants to enable or disable
@@ -268,6 +752,9 @@ type ExternalSpec struct {
 // CrashCollectorSpec represents options to configure the crash controller
 type CrashCollectorSpec struct {
 	Disable bool `json:"disable"`
+	// DaysToRetain is the number of days the crash reports are kept by the mgr crash module, the ceph default of a
+	// year applying if unset
+	DaysToRetain uint `json:"daysToRetain,omitempty"`
 }
 
 // +genclient
@@ -277,8 +764,8 @@ type CrashCollectorSpec struct {
 type CephBlockPool struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              PoolSpec `json:"spec"`
-	Status            *Status  `json:"status"`
+	Spec              PoolSpec             `json:"spec"`
+	Status            *CephBlockPoolStatus `json:"status"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -302,6 +789,10 @@ type PoolSpec struct {
 	// The inline compression mode in Bluestore OSD to set to (options are: none, passive, aggressive, force)
 	CompressionMode string `json:"compressionMode"`
 
+	// The inline compression algorithm of the pool (options are: snappy, zlib, zstd, lz4), the default algorithm
+	// of the OSDs being used if not set
+	CompressionAlgorithm string `json:"compressionAlgorithm,omitempty"`
+
 	// The replication settings
 	Replicated ReplicatedSpec `json:"replicated"`
 
@@ -310,12 +801,170 @@ type PoolSpec struct {
 
 	// Parameters is a list of properties to enable on a given pool
 	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// TargetSizeRatio is the expected share of the cluster capacity used by the pool, given to the pg autoscaler.
+	// It applies to all the pool types and overrides replicated.targetSizeRatio.
+	TargetSizeRatio float64 `json:"targetSizeRatio,omitempty"`
+
+	// PgAutoscaleMode is the mode of the pg autoscaler for the pool: on, off or warn
+	PgAutoscaleMode string `json:"pgAutoscaleMode,omitempty"`
+
+	// Quotas limits the bytes and the objects of the pool, the quotas are not managed if not set
+	Quotas *QuotaSpec `json:"quotas,omitempty"`
+
+	// The rbd mirroring settings
+	Mirroring MirroringSpec `json:"mirroring"`
+
+	// The VolumeSnapshotClass generated for the rbd images of the pool. Only used by the block pools.
+	SnapshotClass *SnapshotClassSpec `json:"snapshotClass,omitempty"`
+
+	// Preserve the pool, its crush rule and its erasure code profile on deletion. Only used by the block pools, the
+	// filesystems and the object stores having their own setting.
+	PreservePoolsOnDelete bool `json:"preservePoolsOnDelete,omitempty"`
+}
+
+// QuotaSpec represents the quotas of a pool
+type QuotaSpec struct {
+	// MaxBytes is the maximum number of bytes stored in the pool, unlimited if not set
+	MaxBytes *uint64 `json:"maxBytes,omitempty"`
+	// MaxObjects is the maximum number of objects stored in the pool, unlimited if not set
+	MaxObjects *uint64 `json:"maxObjects,omitempty"`
 }
 
 type Status struct {
 	Phase string `json:"phase,omitempty"`
 }
 
+// CephBlockPoolStatus represents the status of a pool
+type CephBlockPoolStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// MirroringStatus is the rbd mirroring status of the pool, as seen by the last reconcile
+	MirroringStatus *MirroringStatusSpec `json:"mirroringStatus,omitempty"`
+	// Info has the names of the secrets created for the pool, e.g. for its rbd mirroring bootstrap peer token
+	Info map[string]string `json:"info,omitempty"`
+	// Usage is the usage of the pool and the health of its PGs, refreshed periodically
+	Usage *PoolUsageStatus `json:"usage,omitempty"`
+}
+
+// PoolUsageStatus represents the usage of a pool and the health of its PGs
+type PoolUsageStatus struct {
+	// UsedBytes is the number of bytes used by the pool, including its replicas or coding chunks
+	UsedBytes uint64 `json:"usedBytes"`
+	// AvailableBytes is the number of bytes that can still be written to the pool
+	AvailableBytes uint64 `json:"availableBytes"`
+	// PGHealth is HEALTH_OK when all the PGs of the pool are active and clean, HEALTH_WARN when some of them
+	// are not clean and HEALTH_ERR when some of them are not active
+	PGHealth string `json:"pgHealth,omitempty"`
+	// PGStates is the number of PGs of the pool in each state
+	PGStates map[string]int `json:"pgStates,omitempty"`
+	// LastChecked is the last time the usage was checked
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details is the error of the last check, if the usage could not be retrieved
+	Details string `json:"details,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolRadosNamespace represents a RADOS namespace of a block pool, isolating the rbd images of a tenant
+type CephBlockPoolRadosNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              RadosNamespaceSpec                 `json:"spec"`
+	Status            *CephBlockPoolRadosNamespaceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolRadosNamespaceList is a list of CephBlockPoolRadosNamespace
+type CephBlockPoolRadosNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBlockPoolRadosNamespace `json:"items"`
+}
+
+// RadosNamespaceSpec represents the spec of a RADOS namespace
+type RadosNamespaceSpec struct {
+	// BlockPoolName is the name of the CephBlockPool the namespace is created in, in the namespace of the CR
+	BlockPoolName string `json:"blockPoolName"`
+}
+
+// CephBlockPoolRadosNamespaceStatus represents the status of a RADOS namespace
+type CephBlockPoolRadosNamespaceStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Info has the clusterID of the namespace in the csi config, to set in the storage classes of the namespace
+	Info map[string]string `json:"info,omitempty"`
+}
+
+// MirroringSpec represents the rbd mirroring settings of a pool
+type MirroringSpec struct {
+	// Enabled whether the images of the pool are mirrored to the peers of the pool
+	Enabled bool `json:"enabled,omitempty"`
+	// Mode is "pool" to mirror all the images of the pool, or "image" to mirror only the images with mirroring enabled
+	Mode string `json:"mode,omitempty"`
+	// SnapshotSchedules are the schedules of the mirror snapshots of the images of the pool
+	SnapshotSchedules []SnapshotScheduleSpec `json:"snapshotSchedules,omitempty"`
+}
+
+// SnapshotScheduleSpec represents a schedule of the mirror snapshots of a pool or of the snapshots of a filesystem
+type SnapshotScheduleSpec struct {
+	// Path is the directory of the filesystem to snapshot, "/" by default. Only used by the filesystems.
+	Path string `json:"path,omitempty"`
+	// Interval between the snapshots, e.g. 30m, 1h or 2d for a pool, and 1h, 1d or 1w for a filesystem
+	Interval string `json:"interval,omitempty"`
+	// StartTime is the optional time of the first snapshot, in the ISO 8601 format, e.g. 14:00:00-05:00 for a pool
+	// and 2020-01-01T14:00:00 for a filesystem
+	StartTime string `json:"startTime,omitempty"`
+	// Retention is the number of snapshots of the path kept per period, e.g. 24h4w to keep 24 hourly and 4 weekly
+	// snapshots. Only used by the filesystems.
+	Retention string `json:"retention,omitempty"`
+}
+
+// SnapshotClassSpec represents the VolumeSnapshotClass generated by the operator for the csi snapshots of a pool
+// or of a filesystem
+type SnapshotClassSpec struct {
+	// Enabled whether the VolumeSnapshotClass is generated
+	Enabled bool `json:"enabled,omitempty"`
+	// Name of the VolumeSnapshotClass, "<namespace>-<name>-rbd" for a pool and "<namespace>-<name>-cephfs" for a
+	// filesystem by default
+	Name string `json:"name,omitempty"`
+	// DeletionPolicy of the snapshots of the class, Delete (the default) or Retain
+	DeletionPolicy SnapshotDeletionPolicy `json:"deletionPolicy,omitempty"`
+}
+
+// SnapshotDeletionPolicy is whether the ceph snapshot is deleted with its VolumeSnapshotContent
+type SnapshotDeletionPolicy string
+
+const (
+	// SnapshotDeletionPolicyDelete deletes the ceph snapshot with its VolumeSnapshotContent
+	SnapshotDeletionPolicyDelete SnapshotDeletionPolicy = "Delete"
+	// SnapshotDeletionPolicyRetain keeps the ceph snapshot when its VolumeSnapshotContent is deleted
+	SnapshotDeletionPolicyRetain SnapshotDeletionPolicy = "Retain"
+)
+
+// MirroringStatusSpec represents the rbd mirroring status of a pool
+type MirroringStatusSpec struct {
+	// Summary is the mirroring status of the pool as reported by rbd
+	Summary PoolMirroringStatusSummarySpec `json:"summary,omitempty"`
+	// LastChecked is the last time the mirroring status was checked
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details is the error of the last check, if the status could not be retrieved
+	Details string `json:"details,omitempty"`
+}
+
+// PoolMirroringStatusSummarySpec is the summary of the rbd mirroring status of a pool
+type PoolMirroringStatusSummarySpec struct {
+	// Health is the overall mirroring health of the pool
+	Health string `json:"health,omitempty"`
+	// DaemonHealth is the health of the rbd-mirror daemons
+	DaemonHealth string `json:"daemon_health,omitempty"`
+	// ImageHealth is the health of the mirrored images
+	ImageHealth string `json:"image_health,omitempty"`
+	// States is the number of images in each mirroring state
+	States map[string]int `json:"states,omitempty"`
+}
+
 // ReplicatedSpec represents the spec for replication in a pool
 type ReplicatedSpec struct {
 	// Size - Number of copies per object in a replicated storage pool, including the object itself (required for replicated pool type)
@@ -326,6 +975,17 @@ type ReplicatedSpec struct {
 
 	// RequireSafeReplicaSize if false allows you to set replica 1
 	RequireSafeReplicaSize bool `json:"requireSafeReplicaSize"`
+
+	// HybridStorage places the primary replica and the other replicas on osds of different device classes
+	HybridStorage *HybridStorageSpec `json:"hybridStorage,omitempty"`
+}
+
+// HybridStorageSpec represents the device classes of the replicas of a hybrid pool
+type HybridStorageSpec struct {
+	// PrimaryDeviceClass is the device class of the primary replica, which serves the reads, e.g. ssd
+	PrimaryDeviceClass string `json:"primaryDeviceClass"`
+	// SecondaryDeviceClass is the device class of the other replicas, e.g. hdd
+	SecondaryDeviceClass string `json:"secondaryDeviceClass"`
 }
 
 // ErasureCodeSpec represents the spec for erasure code in a pool
@@ -338,6 +998,21 @@ type ErasureCodedSpec struct {
 
 	// The algorithm for erasure coding
 	Algorithm string `json:"algorithm"`
+
+	// Plugin is the erasure code plugin: jerasure, isa or clay. The plugin of the default profile is used if not set.
+	Plugin string `json:"plugin,omitempty"`
+
+	// Technique is the erasure code technique of the plugin, e.g. reed_sol_van or cauchy_good
+	Technique string `json:"technique,omitempty"`
+
+	// FailureDomain spreads the chunks across this failure domain instead of the failure domain of the pool
+	FailureDomain string `json:"failureDomain,omitempty"`
+
+	// CrushRoot places the chunks under this crush root instead of the crush root of the pool
+	CrushRoot string `json:"crushRoot,omitempty"`
+
+	// DeviceClass places the chunks on the osds of this device class instead of the device class of the pool
+	DeviceClass string `json:"deviceClass,omitempty"`
 }
 
 // +genclient
@@ -347,8 +1022,42 @@ type ErasureCodedSpec struct {
 type CephFilesystem struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata"`
-	Spec              FilesystemSpec `json:"spec"`
-	Status            *Status        `json:"status"`
+	Spec              FilesystemSpec        `json:"spec"`
+	Status            *CephFilesystemStatus `json:"status"`
+}
+
+// CephFilesystemStatus represents the status of a filesystem
+type CephFilesystemStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// MDSHealth is the health of the mds daemons of the filesystem, refreshed periodically
+	MDSHealth *MDSHealthStatus `json:"mdsHealth,omitempty"`
+}
+
+// MDSHealthStatus represents the health of the mds daemons and the client sessions of a filesystem
+type MDSHealthStatus struct {
+	// Health is HEALTH_OK, HEALTH_WARN when an mds is laggy or stuck or standbys are missing, and HEALTH_ERR when
+	// a rank is damaged or failed
+	Health string `json:"health,omitempty"`
+	// ActiveRanks is the number of ranks with an active mds
+	ActiveRanks int `json:"activeRanks"`
+	// Standbys is the number of mds of the filesystem in standby or standby-replay
+	Standbys int `json:"standbys"`
+	// WantedStandbys is the number of standbys deployed for the filesystem
+	WantedStandbys int `json:"wantedStandbys"`
+	// Sessions is the number of client sessions of the filesystem
+	Sessions int `json:"sessions"`
+	// LaggyDaemons are the mds not reporting to the mons
+	LaggyDaemons []string `json:"laggyDaemons,omitempty"`
+	// StuckDaemons are the mds in a transitional state, e.g. up:replay or up:rejoin, for longer than a check interval
+	StuckDaemons []string `json:"stuckDaemons,omitempty"`
+	// DamagedRanks are the ranks with damaged metadata, requiring a repair by the admin
+	DamagedRanks []int `json:"damagedRanks,omitempty"`
+	// FailedRanks are the ranks without an mds
+	FailedRanks []int `json:"failedRanks,omitempty"`
+	// LastChecked is the time of the last check, in the RFC3339 format
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details is the error of the last check, if it failed
+	Details string `json:"details,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -359,19 +1068,120 @@ type CephFilesystemList struct {
 	Items           []CephFilesystem `json:"items"`
 }
 
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemSubVolumeGroup represents a subvolume group of a filesystem, isolating the subvolumes of a tenant
+type CephFilesystemSubVolumeGroup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              SubVolumeGroupSpec                  `json:"spec"`
+	Status            *CephFilesystemSubVolumeGroupStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemSubVolumeGroupList is a list of CephFilesystemSubVolumeGroup
+type CephFilesystemSubVolumeGroupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephFilesystemSubVolumeGroup `json:"items"`
+}
+
+// SubVolumeGroupSpec represents the spec of a subvolume group
+type SubVolumeGroupSpec struct {
+	// FilesystemName is the name of the CephFilesystem the group is created in, in the namespace of the CR
+	FilesystemName string `json:"filesystemName"`
+
+	// Pinning pins the directory of the group to the MDS ranks, the group is not pinned if not set
+	Pinning *SubVolumeGroupPinningSpec `json:"pinning,omitempty"`
+
+	// Quota is the maximum size of the data of the group, unlimited if not set. Requires ceph quincy or newer.
+	Quota *resource.Quantity `json:"quota,omitempty"`
+}
+
+// SubVolumeGroupPinningSpec represents the pinning policy of a subvolume group, only one of its policies may be set
+type SubVolumeGroupPinningSpec struct {
+	// Export pins the group to an MDS rank, -1 removes the pin
+	Export *int `json:"export,omitempty"`
+
+	// Distributed spreads the subvolumes of the group across the MDS ranks when 1, 0 removes the pin
+	Distributed *int `json:"distributed,omitempty"`
+
+	// Random pins the subdirectories of the group to a random MDS rank with this probability, between 0 and 1
+	Random *float64 `json:"random,omitempty"`
+}
+
+// CephFilesystemSubVolumeGroupStatus represents the status of a subvolume group
+type CephFilesystemSubVolumeGroupStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// Info has the clusterID of the group in the csi config, to set in the storage classes of the group
+	Info map[string]string `json:"info,omitempty"`
+}
+
 // FilesystemSpec represents the spec of a file system
 type FilesystemSpec struct {
 	// The metadata pool settings
 	MetadataPool PoolSpec `json:"metadataPool,omitempty"`
 
 	// The data pool settings
-	DataPools []PoolSpec `json:"dataPools,omitempty"`
+	DataPools []NamedPoolSpec `json:"dataPools,omitempty"`
 
 	// Preserve pools on filesystem deletion
 	PreservePoolsOnDelete bool `json:"preservePoolsOnDelete"`
 
 	// The mds pod info
 	MetadataServer MetadataServerSpec `json:"metadataServer"`
+
+	// The snapshot mirroring settings
+	Mirroring *FSMirroringSpec `json:"mirroring,omitempty"`
+
+	// The schedules of the snapshots of the filesystem
+	SnapshotSchedules []SnapshotScheduleSpec `json:"snapshotSchedules,omitempty"`
+
+	// The VolumeSnapshotClass generated for the volumes of the filesystem
+	SnapshotClass *SnapshotClassSpec `json:"snapshotClass,omitempty"`
+
+	// The health checks of the filesystem
+	HealthCheck FilesystemHealthCheckSpec `json:"healthCheck,omitempty"`
+}
+
+// FilesystemHealthCheckSpec represents the health checks of a filesystem
+type FilesystemHealthCheckSpec struct {
+	MDS MDSHealthCheckSpec `json:"mds,omitempty"`
+}
+
+// MDSHealthCheckSpec represents the health check of the mds daemons of a filesystem
+type MDSHealthCheckSpec struct {
+	HealthCheckSpec `json:",inline"`
+	// RestartStuckAfter is the duration after which the pod of an mds laggy or stuck in a transitional state is
+	// restarted. The stuck mds are only reported if not set.
+	RestartStuckAfter string `json:"restartStuckAfter,omitempty"`
+}
+
+// NamedPoolSpec represents a data pool of a filesystem, which the directories of the filesystem can be placed on with
+// their layout
+type NamedPoolSpec struct {
+	// Name is the name of the pool in the filesystem, the pool being named <fs>-<name>. The pool is named
+	// <fs>-data<index> if not set.
+	Name string `json:"name,omitempty"`
+
+	// Default is whether the pool is the default data pool of the filesystem, the first data pool being the default
+	// if none is set. The default data pool is set when the filesystem is created and cannot be changed.
+	Default bool `json:"default,omitempty"`
+
+	// The pool settings, including the quotas of the pool
+	PoolSpec `json:",inline"`
+}
+
+// FSMirroringSpec represents the snapshot mirroring settings of a filesystem
+type FSMirroringSpec struct {
+	// Enabled whether the snapshots of the filesystem are mirrored to its peers, by the cephfs-mirror daemon
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Peers are the remote clusters the filesystem is mirrored to
+	Peers *MirroringPeerSpec `json:"peers,omitempty"`
 }
 
 type MetadataServerSpec struct {
@@ -433,6 +1243,98 @@ type ObjectStoreSpec struct {
 
 	// The rgw endpoint healthcheck
 	HealthCheck BucketHealthCheckSpec `json:"healthCheck"`
+
+	// StorageClasses are the additional storage classes of the default placement target, the objects of each storage
+	// class being stored in its own data pool
+	StorageClasses []ObjectStorageClassSpec `json:"storageClasses,omitempty"`
+
+	// PlacementTargets are the additional placement targets of the store, with their own bucket index and data pools
+	PlacementTargets []ObjectPlacementTargetSpec `json:"placementTargets,omitempty"`
+
+	// The authentication of the users of the store by external services
+	Auth ObjectStoreAuthSpec `json:"auth,omitempty"`
+
+	// The protocols served by the gateways
+	Protocols ObjectStoreProtocolSpec `json:"protocols,omitempty"`
+
+	// The hostnames of the store, for the virtual-hosted-style bucket urls
+	Hosting *ObjectStoreHostingSpec `json:"hosting,omitempty"`
+}
+
+// ObjectStoreHostingSpec represents the hostnames of an object store
+type ObjectStoreHostingSpec struct {
+	// DNSNames are the hostnames of the S3 API of the store, a bucket being reachable with the virtual-hosted-style
+	// url <bucket>.<dns name> in addition to the path-style url <dns name>/<bucket>
+	DNSNames []string `json:"dnsNames,omitempty"`
+	// S3WebsiteDNSName is the hostname of the static websites of the buckets, reachable as <bucket>.<dns name>
+	S3WebsiteDNSName string `json:"s3WebsiteDnsName,omitempty"`
+}
+
+// ObjectStoreAuthSpec represents the external services authenticating the users of an object store
+type ObjectStoreAuthSpec struct {
+	// Keystone authenticates the users with the tokens of an OpenStack Keystone service
+	Keystone *KeystoneSpec `json:"keystone,omitempty"`
+}
+
+// KeystoneSpec represents the OpenStack Keystone service authenticating the users of an object store
+type KeystoneSpec struct {
+	// URL is the url of the Keystone identity API, such as https://keystone.example.com:5000
+	URL string `json:"url"`
+	// ServiceUserSecretName is the name of the secret with the credentials of the Keystone service user of the
+	// gateways, in the OS_USERNAME, OS_PASSWORD, OS_PROJECT_NAME and OS_USER_DOMAIN_NAME keys
+	ServiceUserSecretName string `json:"serviceUserSecretName"`
+	// AcceptedRoles are the Keystone roles of the users allowed to access the store
+	AcceptedRoles []string `json:"acceptedRoles"`
+	// ImplicitTenants creates the users of the store in a tenant named after their Keystone project, for the
+	// "swift" or "s3" protocol only or for both with "true"
+	ImplicitTenants string `json:"implicitTenants,omitempty"`
+	// TokenCacheSize is the number of Keystone tokens cached by each gateway, 10000 by default
+	TokenCacheSize *int `json:"tokenCacheSize,omitempty"`
+}
+
+// ObjectStoreProtocolSpec represents the protocols served by the gateways of an object store
+type ObjectStoreProtocolSpec struct {
+	// S3 are the settings of the S3 API
+	S3 *S3Spec `json:"s3,omitempty"`
+	// Swift are the settings of the OpenStack Swift API
+	Swift *SwiftSpec `json:"swift,omitempty"`
+}
+
+// S3Spec represents the settings of the S3 API of an object store
+type S3Spec struct {
+	// AuthUseKeystone authenticates the S3 requests signed with the EC2 credentials of the Keystone users
+	AuthUseKeystone *bool `json:"authUseKeystone,omitempty"`
+}
+
+// SwiftSpec represents the settings of the OpenStack Swift API of an object store
+type SwiftSpec struct {
+	// Enabled serves the Swift API, true by default
+	Enabled *bool `json:"enabled,omitempty"`
+	// AccountInURL expects the account of the Swift requests in their url, such as /swift/v1/AUTH_<project id>
+	AccountInURL *bool `json:"accountInUrl,omitempty"`
+	// URLPrefix is the prefix of the url of the Swift API, "swift" by default
+	URLPrefix *string `json:"urlPrefix,omitempty"`
+}
+
+// ObjectPlacementTargetSpec represents a placement target of an object store, chosen by a bucket when it is created
+type ObjectPlacementTargetSpec struct {
+	// Name is the id of the placement target, given in the location constraint of the buckets
+	Name string `json:"name"`
+	// IndexPool is the pool of the bucket indexes, which must be replicated
+	IndexPool PoolSpec `json:"indexPool"`
+	// DataPool is the pool of the objects of the STANDARD storage class
+	DataPool PoolSpec `json:"dataPool"`
+	// StorageClasses are the additional storage classes of the placement target
+	StorageClasses []ObjectStorageClassSpec `json:"storageClasses,omitempty"`
+}
+
+// ObjectStorageClassSpec represents a storage class of a placement target, chosen by the storage class header of an
+// S3 request
+type ObjectStorageClassSpec struct {
+	// Name is the name of the storage class, such as COLD
+	Name string `json:"name"`
+	// DataPool is the pool of the objects of the storage class
+	DataPool PoolSpec `json:"dataPool"`
 }
 
 type BucketHealthCheckSpec struct {
@@ -461,6 +1363,10 @@ type GatewaySpec struct {
 	// The name of the secret that stores the ssl certificate for secure rgw connections
 	SSLCertificateRef string `json:"sslCertificateRef"`
 
+	// The name of the secret that stores the CA bundle, at the cabundle key, trusted by the rgw pods and the clients of
+	// the operator for the TLS connections, such as to a gateway certificate signed by a private CA
+	CaBundleRef string `json:"caBundleRef,omitempty"`
+
 	// The affinity to place the rgw pods (default is to place on any available node)
 	Placement rookv1.Placement `json:"placement"`
 
@@ -475,6 +1381,23 @@ type GatewaySpec struct {
 
 	// ExternalRgwEndpoints points to external rgw endpoint(s)
 	ExternalRgwEndpoints []v1.EndpointAddress `json:"externalRgwEndpoints,omitempty"`
+
+	// Autoscale lets a HorizontalPodAutoscaler created by the operator set the instances from the CPU usage of the rgw pods
+	Autoscale *GatewayAutoscaleSpec `json:"autoscale,omitempty"`
+
+	// Service customizes the rgw service, e.g. to expose the gateways outside of the cluster
+	Service *ServiceSpec `json:"service,omitempty"`
+}
+
+// GatewayAutoscaleSpec represents the autoscaling of the rgw pods of an object store from their CPU usage
+type GatewayAutoscaleSpec struct {
+	// MinInstances is the minimum number of rgw pods, 1 by default
+	MinInstances int32 `json:"minInstances,omitempty"`
+	// MaxInstances is the maximum number of rgw pods
+	MaxInstances int32 `json:"maxInstances"`
+	// TargetCPUUtilizationPercentage is the average CPU usage of the rgw pods targeted, in percent of their CPU
+	// request, 80 by default
+	TargetCPUUtilizationPercentage int32 `json:"targetCPUUtilizationPercentage,omitempty"`
 }
 
 type ZoneSpec struct {
@@ -487,6 +1410,13 @@ type ObjectStoreStatus struct {
 	Message      string            `json:"message,omitempty"`
 	BucketStatus *BucketStatus     `json:"bucketStatus,omitempty"`
 	Info         map[string]string `json:"info,omitempty"`
+	// Instances is the number of rgw pods of the store, read by the autoscaler through the scale subresource
+	Instances int32 `json:"instances,omitempty"`
+	// Selector is the label selector of the rgw pods of the store, read by the autoscaler to get the CPU usage of the pods
+	Selector string `json:"selector,omitempty"`
+	// Endpoints are the urls of the gateways outside of the cluster, from the addresses of the load balancer of the
+	// rgw service
+	Endpoints []string `json:"endpoints,omitempty"`
 }
 
 type BucketStatus struct {
@@ -521,8 +1451,109 @@ type ObjectStoreUserSpec struct {
 	Store string `json:"store,omitempty"`
 	//The display name for the ceph users
 	DisplayName string `json:"displayName,omitempty"`
+	// Quotas limits the buckets and the objects of the user, the quotas are not managed if not set
+	Quotas *ObjectUserQuotaSpec `json:"quotas,omitempty"`
+	// Capabilities grants the user the permissions of the rgw admin operations, the capabilities are not managed if not set
+	Capabilities *ObjectUserCapSpec `json:"capabilities,omitempty"`
+	// Secrets are the secrets of the keys of the user to create in addition to the secret in the namespace of the
+	// user, such as in the namespaces of the applications or in the format of an s3 client
+	Secrets []ObjectUserSecretSpec `json:"secrets,omitempty"`
+}
+
+// ObjectUserSecretSpec represents a secret of the keys of an object store user
+type ObjectUserSecretSpec struct {
+	// Namespace is the namespace of the secret, the namespace of the user if not set
+	Namespace string `json:"namespace,omitempty"`
+	// Name is the name of the secret, the name of the secret of the user if not set
+	Name string `json:"name,omitempty"`
+	// Format is the format of the keys in the secret: rook, aws, s3cmd or rclone. The rook format is used if not set.
+	Format string `json:"format,omitempty"`
+}
+
+const (
+	// ObjectUserSecretFormatRook stores the keys and the endpoint as AccessKey, SecretKey and Endpoint
+	ObjectUserSecretFormatRook = "rook"
+	// ObjectUserSecretFormatAWS stores the keys and the endpoint as the environment variables of the aws clients
+	ObjectUserSecretFormatAWS = "aws"
+	// ObjectUserSecretFormatS3cmd stores the config file of s3cmd as .s3cfg
+	ObjectUserSecretFormatS3cmd = "s3cmd"
+	// ObjectUserSecretFormatRclone stores the config file of rclone as rclone.conf
+	ObjectUserSecretFormatRclone = "rclone"
+)
+
+// ObjectUserQuotaSpec can be used to set quotas for the object store user to limit their usage
+type ObjectUserQuotaSpec struct {
+	// MaxBuckets is the maximum number of buckets the user can own, its current value being kept if not set
+	MaxBuckets *int `json:"maxBuckets,omitempty"`
+	// MaxSize is the maximum size of the objects of the user as a quantity such as "10Gi", unlimited if not set
+	MaxSize *string `json:"maxSize,omitempty"`
+	// MaxObjects is the maximum number of objects of the user, unlimited if not set
+	MaxObjects *int64 `json:"maxObjects,omitempty"`
+}
+
+// ObjectUserCapSpec represent the capabilities of the object store user on the rgw admin operations,
+// each of them being "read", "write", "read, write" or "*", and no permission if not set
+type ObjectUserCapSpec struct {
+	// User is the permission on the users
+	User string `json:"user,omitempty"`
+	// Bucket is the permission on the buckets
+	Bucket string `json:"bucket,omitempty"`
+	// MetaData is the permission on the metadata
+	MetaData string `json:"metadata,omitempty"`
+	// Usage is the permission on the usage statistics
+	Usage string `json:"usage,omitempty"`
+	// Zone is the permission on the zone
+	Zone string `json:"zone,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephCOSIDriver represents the Ceph driver of the Container Object Storage Interface (COSI), provisioning the buckets
+// of the BucketClaims in the object stores
+type CephCOSIDriver struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              CephCOSIDriverSpec `json:"spec"`
+	Status            *Status            `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephCOSIDriverList is a list of CephCOSIDriver
+type CephCOSIDriverList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephCOSIDriver `json:"items"`
 }
 
+// CephCOSIDriverSpec represents the deployment of the COSI driver
+type CephCOSIDriverSpec struct {
+	// Image is the image of the Ceph COSI driver
+	Image string `json:"image,omitempty"`
+	// ObjectProvisionerImage is the image of the COSI provisioner sidecar of the driver
+	ObjectProvisionerImage string `json:"objectProvisionerImage,omitempty"`
+	// DeploymentStrategy is whether the driver is deployed, Auto by default
+	DeploymentStrategy COSIDeploymentStrategy `json:"deploymentStrategy,omitempty"`
+	// Placement is the placement of the driver pod
+	Placement rookv1.Placement `json:"placement,omitempty"`
+	// Resources are the resource requirements of the driver container
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// COSIDeploymentStrategy is the strategy of the deployment of the COSI driver
+type COSIDeploymentStrategy string
+
+const (
+	// COSIDeploymentStrategyAuto deploys the driver when the COSI API is served by the Kubernetes cluster
+	COSIDeploymentStrategyAuto COSIDeploymentStrategy = "Auto"
+	// COSIDeploymentStrategyForce always deploys the driver
+	COSIDeploymentStrategyForce COSIDeploymentStrategy = "Force"
+	// COSIDeploymentStrategyNever never deploys the driver, removing the deployed driver
+	COSIDeploymentStrategyNever COSIDeploymentStrategy = "Never"
+)
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -544,6 +1575,14 @@ type CephObjectRealmList struct {
 
 // ObjectRealmSpec represent the spec of an ObjectRealm
 type ObjectRealmSpec struct {
+	// Pull is the master zone of the realm in another cluster, to sync the realm from instead of creating it
+	Pull PullSpec `json:"pull,omitempty"`
+}
+
+// PullSpec represents the endpoint to pull a realm from
+type PullSpec struct {
+	// Endpoint is the url of the rgw of the master zone of the realm, e.g. http://10.2.105.133:80
+	Endpoint string `json:"endpoint,omitempty"`
 }
 
 // +genclient
@@ -594,6 +1633,158 @@ type CephObjectZoneList struct {
 type ObjectZoneSpec struct {
 	//The display name for the ceph users
 	ZoneGroup string `json:"zoneGroup"`
+
+	// The metadata pool settings of the zone, shared by its object stores
+	MetadataPool PoolSpec `json:"metadataPool,omitempty"`
+
+	// The data pool settings of the zone, shared by its object stores
+	DataPool PoolSpec `json:"dataPool,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBucketTopic represents a topic of an object store the bucket notifications are sent to
+type CephBucketTopic struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BucketTopicSpec    `json:"spec"`
+	Status            *BucketTopicStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBucketTopicList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBucketTopic `json:"items"`
+}
+
+// BucketTopicSpec represents the spec of a bucket topic
+type BucketTopicSpec struct {
+	// The name of the object store the topic is created in
+	ObjectStoreName string `json:"objectStoreName"`
+
+	// The namespace of the object store, the namespace of the topic if not set
+	ObjectStoreNamespace string `json:"objectStoreNamespace,omitempty"`
+
+	// Data sent in the notifications of the topic
+	OpaqueData string `json:"opaqueData,omitempty"`
+
+	// Persistent sends the notifications asynchronously, they are retried until the endpoint gets them
+	Persistent bool `json:"persistent,omitempty"`
+
+	// Endpoint the notifications of the topic are sent to, exactly one of its endpoints must be set
+	Endpoint TopicEndpointSpec `json:"endpoint"`
+}
+
+// TopicEndpointSpec represents the endpoint the notifications of a topic are sent to
+type TopicEndpointSpec struct {
+	// HTTP sends the notifications to an HTTP endpoint
+	HTTP *HTTPEndpointSpec `json:"http,omitempty"`
+
+	// AMQP sends the notifications to an AMQP exchange
+	AMQP *AMQPEndpointSpec `json:"amqp,omitempty"`
+
+	// Kafka sends the notifications to a Kafka topic with the name of the bucket topic
+	Kafka *KafkaEndpointSpec `json:"kafka,omitempty"`
+}
+
+// HTTPEndpointSpec represents an HTTP endpoint of a topic
+type HTTPEndpointSpec struct {
+	// The URI of the endpoint, e.g. http[s]://<fqdn>[:<port>][/<resource>]
+	URI string `json:"uri"`
+
+	// Whether the certificate of an https endpoint is not verified
+	DisableVerifySSL bool `json:"disableVerifySSL,omitempty"`
+}
+
+// AMQPEndpointSpec represents an AMQP endpoint of a topic
+type AMQPEndpointSpec struct {
+	// The URI of the endpoint, e.g. amqp[s]://[<user>:<password>@]<fqdn>[:<port>][/<vhost>]
+	URI string `json:"uri"`
+
+	// The exchange the notifications are sent to, must exist
+	Exchange string `json:"exchange"`
+
+	// Whether the certificate of an amqps endpoint is not verified
+	DisableVerifySSL bool `json:"disableVerifySSL,omitempty"`
+
+	// The ack level required from the endpoint, one of none, broker or routable. Defaults to broker
+	AckLevel string `json:"ackLevel,omitempty"`
+}
+
+// KafkaEndpointSpec represents a Kafka endpoint of a topic
+type KafkaEndpointSpec struct {
+	// The URI of the endpoint, e.g. kafka://[<user>:<password>@]<fqdn>[:<port]
+	URI string `json:"uri"`
+
+	// Whether the connection to the broker uses SSL
+	UseSSL bool `json:"useSSL,omitempty"`
+
+	// Whether the certificate of the broker is not verified
+	DisableVerifySSL bool `json:"disableVerifySSL,omitempty"`
+
+	// The ack level required from the broker, one of none or broker. Defaults to broker
+	AckLevel string `json:"ackLevel,omitempty"`
+}
+
+// BucketTopicStatus represents the status of a bucket topic
+type BucketTopicStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// The ARN of the topic, referred to by the bucket notifications
+	ARN *string `json:"ARN,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBucketNotification represents the notifications of the buckets of the object bucket claims labelled with it
+type CephBucketNotification struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              BucketNotificationSpec `json:"spec"`
+	Status            *Status                `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type CephBucketNotificationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBucketNotification `json:"items"`
+}
+
+// BucketNotificationEvent is an event of the objects of a bucket, e.g. s3:ObjectCreated:*
+type BucketNotificationEvent string
+
+// BucketNotificationSpec represents the spec of a bucket notification
+type BucketNotificationSpec struct {
+	// The name of the CephBucketTopic the notifications are sent to, in the namespace of the notification
+	Topic string `json:"topic"`
+
+	// The events notified, all of them if not set
+	Events []BucketNotificationEvent `json:"events,omitempty"`
+
+	// Filter restricts the objects notified
+	Filter *NotificationFilterSpec `json:"filter,omitempty"`
+}
+
+// NotificationFilterSpec represents the filters of the objects notified
+type NotificationFilterSpec struct {
+	// KeyFilters restrict the keys of the objects notified
+	KeyFilters []NotificationKeyFilterRule `json:"keyFilters,omitempty"`
+}
+
+// NotificationKeyFilterRule represents a filter of the keys of the objects notified
+type NotificationKeyFilterRule struct {
+	// Name of the filter, one of prefix, suffix or regex
+	Name string `json:"name"`
+
+	// Value matched by the keys
+	Value string `json:"value"`
 }
 
 // +genclient
@@ -620,6 +1811,51 @@ type NFSGaneshaSpec struct {
 	RADOS GaneshaRADOSSpec `json:"rados"`
 
 	Server GaneshaServerSpec `json:"server"`
+
+	// Exports are the NFS exports of the ganesha servers, backed by a path of a filesystem or by a bucket
+	Exports []NFSExportSpec `json:"exports,omitempty"`
+}
+
+// NFSExportSpec represents an NFS export of the ganesha servers
+type NFSExportSpec struct {
+	// ID is the unique id of the export, from 1 to 65535
+	ID int `json:"id"`
+
+	// PseudoPath is the NFSv4 path of the export on the ganesha servers
+	PseudoPath string `json:"pseudoPath"`
+
+	// AccessType is the access of the clients to the export: RW (default), RO or None
+	AccessType string `json:"accessType,omitempty"`
+
+	// Squash maps the users of the clients to the anonymous user: none (default), root or all
+	Squash string `json:"squash,omitempty"`
+
+	// CephFS exports a path of a CephFilesystem
+	CephFS *NFSExportCephFSSpec `json:"cephfs,omitempty"`
+
+	// RGW exports a bucket of a CephObjectStore
+	RGW *NFSExportRGWSpec `json:"rgw,omitempty"`
+}
+
+// NFSExportCephFSSpec represents a path of a CephFilesystem exported over NFS
+type NFSExportCephFSSpec struct {
+	// Filesystem is the name of the CephFilesystem in the namespace of the CephNFS
+	Filesystem string `json:"filesystem"`
+
+	// Path is the exported path of the filesystem, the root of the filesystem if not set
+	Path string `json:"path,omitempty"`
+}
+
+// NFSExportRGWSpec represents a bucket of a CephObjectStore exported over NFS
+type NFSExportRGWSpec struct {
+	// ObjectStore is the name of the CephObjectStore in the namespace of the CephNFS
+	ObjectStore string `json:"objectStore"`
+
+	// Bucket is the name of the exported bucket
+	Bucket string `json:"bucket"`
+
+	// User is the name of the CephObjectStoreUser accessing the bucket
+	User string `json:"user"`
 }
 
 type GaneshaRADOSSpec struct {
@@ -653,8 +1889,57 @@ type NetworkSpec struct {
 
 	// HostNetwork to enable host network
 	HostNetwork bool `json:"hostNetwork"`
+
+	// DaemonHostNetwork overrides the HostNetwork of the daemons of the given keys, such as the mons and the osds on the
+	// host network and the rgw and the mds on the pod network
+	// +optional
+	DaemonHostNetwork map[rookv1.KeyType]bool `json:"daemonHostNetwork,omitempty"`
+
+	// IPFamily is the single stack IPv6 or IPv4 protocol of the daemons, IPv4 by default
+	IPFamily IPFamilyType `json:"ipFamily,omitempty"`
+
+	// DualStack binds the daemons to both the IPv4 and the IPv6 addresses, the mons being reached on the addresses
+	// of the IPFamily
+	DualStack bool `json:"dualStack,omitempty"`
+
+	// Connections are the settings of the msgr2 connections of the daemons and the clients
+	// +optional
+	Connections *ConnectionsSpec `json:"connections,omitempty"`
+}
+
+// ConnectionsSpec represents the settings of the connections on the wire
+type ConnectionsSpec struct {
+	// Encryption is the msgr2 secure mode of the connections
+	// +optional
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+
+	// Compression is the msgr2 compression of the connections between the osds
+	// +optional
+	Compression *CompressionSpec `json:"compression,omitempty"`
+}
+
+// EncryptionSpec represents the encryption of the connections
+type EncryptionSpec struct {
+	// Enabled encrypts the traffic of the daemons and the clients, the crc mode checking only the integrity otherwise
+	Enabled bool `json:"enabled,omitempty"`
 }
 
+// CompressionSpec represents the compression of the connections
+type CompressionSpec struct {
+	// Enabled compresses the traffic between the osds, which requires Ceph Quincy or newer
+	Enabled bool `json:"enabled,omitempty"`
+}
+
+// IPFamilyType represents the single stack IPv4 or IPv6 protocol
+type IPFamilyType string
+
+const (
+	// IPv4 internet protocol version
+	IPv4 IPFamilyType = "IPv4"
+	// IPv6 internet protocol version
+	IPv6 IPFamilyType = "IPv6"
+)
+
 // DisruptionManagementSpec configures management of daemon disruptions
 type DisruptionManagementSpec struct {
 
@@ -671,6 +1956,10 @@ type DisruptionManagementSpec struct {
 
 	// Namespace to look for MDBs by the machineDisruptionBudgetController
 	MachineDisruptionBudgetNamespace string `json:"machineDisruptionBudgetNamespace,omitempty"`
+
+	// ManageNodeMaintenance sets noout on the OSDs of the cordoned nodes until they are schedulable again, the OSDs
+	// of the nodes under maintenance never being removed
+	ManageNodeMaintenance bool `json:"manageNodeMaintenance,omitempty"`
 }
 
 // +genclient
@@ -694,14 +1983,44 @@ type CephClientList struct {
 type ClientSpec struct {
 	Name string            `json:"name"`
 	Caps map[string]string `json:"caps"`
+	// SecretNamespace is the namespace of the keyring secret of the client, the namespace of the client by default
+	SecretNamespace string `json:"secretNamespace,omitempty"`
+	// KeyRotationPolicy is when the key of the client is regenerated
+	KeyRotationPolicy ClientKeyRotationPolicy `json:"keyRotationPolicy,omitempty"`
 }
 
+// ClientKeyRotationPolicy is when the key of a client is regenerated
+type ClientKeyRotationPolicy string
+
+const (
+	// ClientKeyRotationNever keeps the key of the client
+	ClientKeyRotationNever ClientKeyRotationPolicy = "Never"
+	// ClientKeyRotationOnDemand regenerates the key of the client when its rotation annotation changes
+	ClientKeyRotationOnDemand ClientKeyRotationPolicy = "OnDemand"
+)
+
 type CleanupPolicySpec struct {
 	Confirmation CleanupConfirmationProperty `json:"confirmation,omitempty"`
+	// SanitizeDisks is how the disks of the osds are sanitized by the cleanup jobs
+	SanitizeDisks SanitizeDisksSpec `json:"sanitizeDisks,omitempty"`
 }
 
 type CleanupConfirmationProperty string
 
+// SanitizeDisksSpec represents the way the disks of the osds are sanitized when the cluster is deleted
+type SanitizeDisksSpec struct {
+	// Method is "quick" to only wipe the metadata of the disks or "complete" to wipe the whole disks
+	Method SanitizeMethodProperty `json:"method,omitempty"`
+	// DataSource is "zero" to write zeros or "random" to write random data on the disks
+	DataSource SanitizeDataSourceProperty `json:"dataSource,omitempty"`
+	// Iteration is the number of times the disks are overwritten
+	Iteration int32 `json:"iteration,omitempty"`
+}
+
+type SanitizeMethodProperty string
+
+type SanitizeDataSourceProperty string
+
 // +genclient
 // +genclient:noStatus
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
@@ -736,4 +2055,49 @@ type RBDMirroringSpec struct {
 
 	// PriorityClassName sets priority classes on the rgw pods
 	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// Peers are the remote clusters the images are mirrored from
+	Peers MirroringPeerSpec `json:"peers,omitempty"`
+}
+
+// MirroringPeerSpec represents the peers of the rbd or cephfs mirroring
+type MirroringPeerSpec struct {
+	// SecretNames are the names of the secrets with the bootstrap peer tokens of the remote clusters
+	SecretNames []string `json:"secretNames,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemMirror is the Ceph Filesystem Mirror object definition
+type CephFilesystemMirror struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              FilesystemMirroringSpec `json:"spec"`
+	Status            *Status                 `json:"status"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephFilesystemMirrorList is a list of CephFilesystemMirror
+type CephFilesystemMirrorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephFilesystemMirror `json:"items"`
+}
+
+// FilesystemMirroringSpec is the filesystem mirroring specification
+type FilesystemMirroringSpec struct {
+	// The affinity to place the cephfs-mirror pod (default is to place on any available node)
+	Placement rookv1.Placement `json:"placement,omitempty"`
+
+	// The annotations-related configuration to add/set on each Pod related object.
+	Annotations rookv1.Annotations `json:"annotations,omitempty"`
+
+	// The resource requirements for the cephfs-mirror pod
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+
+	// PriorityClassName sets priority class on the cephfs-mirror pod
+	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
@@ -17,11 +17,20 @@ limitations under the License.
 package v1
 
 import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
 
@@ -29,6 +38,42 @@ import (
 // will be registered for the validating webhook.
 var _ webhook.Validator = &CephCluster{}
 
+const (
+	// minMonCount and maxMonCount are the bounds of the mon count of a cluster
+	minMonCount = 1
+	maxMonCount = 9
+	// defaultMonCount is the mon count the operator starts when the count is not set
+	defaultMonCount = 3
+
+	// stretchClusterZoneCount and stretchClusterMonCount are the zones and mons of a stretch cluster
+	stretchClusterZoneCount = 3
+	stretchClusterMonCount  = 5
+	// stretchClusterMinCephMajorVersion is pacific, the first release with the stretch mode
+	stretchClusterMinCephMajorVersion = 16
+	// compressionMinCephMajorVersion is quincy, the first release compressing the msgr2 connections
+	compressionMinCephMajorVersion = 17
+
+	// Unfortunately this is a duplicate of the const RemoveOSDsAnnotation in the controller package, but done to avoid import cycle
+	removeOSDsAnnotation = "osd.rook.io/remove"
+)
+
+var (
+	// RejectCephDowngrades rejects the updates of the ceph image to a previous major version instead of only warning
+	RejectCephDowngrades = false
+
+	// AllowedRulesNamespaces are the namespaces, besides their own, where the clusters may create their prometheus rules
+	AllowedRulesNamespaces = []string{}
+
+	// imageRegex matches a container image reference such as quay.io/ceph/ceph:v15.2.4 or ceph/ceph@sha256:<digest>
+	imageRegex = regexp.MustCompile(`^(?:[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]*[a-zA-Z0-9])?)*(?::[0-9]+)?/)?` +
+		`[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-*)[a-z0-9]+)*)*` +
+		`(?::[\w][\w.-]{0,127})?(?:@[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,})?$`)
+	// imageMajorVersionRegex extracts the major version of an image tag such as v15.2.4
+	imageMajorVersionRegex = regexp.MustCompile(`^v?([0-9]+)(?:[.-]|$)`)
+	// cephVersionRegex matches the declared ceph version of an image such as 15.2.4 or 15.2.4-0
+	cephVersionRegex = regexp.MustCompile(`^([0-9]+)\.[0-9]+\.[0-9]+(?:-[0-9]+)?$`)
+)
+
 func (c *CephCluster) ValidateCreate() error {
 	logger.Infof("validate create cephcluster %q", c.ObjectMeta.Name)
 
@@ -38,11 +83,17 @@ func (c *CephCluster) ValidateCreate() error {
 
 	//If external mode enabled, then check if other fields are empty
 	if c.Spec.External.Enable {
-		if c.Spec.Mon != (MonSpec{}) || c.Spec.Dashboard != (DashboardSpec{}) || c.Spec.Monitoring != (MonitoringSpec{}) || c.Spec.DisruptionManagement != (DisruptionManagementSpec{}) || len(c.Spec.Mgr.Modules) > 0 || len(c.Spec.Network.Provider) > 0 || len(c.Spec.Network.Selectors) > 0 {
-			return errors.New("invalid create : external mode enabled cannot have mon,dashboard,monitoring,network,disruptionManagement,storage fields in CR")
+		if c.Spec.Mon != (MonSpec{}) || c.Spec.Dashboard != (DashboardSpec{}) || c.Spec.DisruptionManagement != (DisruptionManagementSpec{}) || len(c.Spec.Mgr.Modules) > 0 || c.Spec.Mgr.Balancer != nil || len(c.Spec.Network.Provider) > 0 || len(c.Spec.Network.Selectors) > 0 {
+			return errors.New("invalid create : external mode enabled cannot have mon,dashboard,network,disruptionManagement,storage fields in CR")
 		}
+		// the monitoring settings expose the metrics of the mgrs of the external cluster
+		return validateRulesNamespace(*c)
 	}
-	return nil
+
+	if err := validateTimeouts(c.Spec, nil); err != nil {
+		return err
+	}
+	return validateManagedCluster(*c)
 }
 
 func (c *CephCluster) ValidateUpdate(old runtime.Object) error {
@@ -52,7 +103,18 @@ func (c *CephCluster) ValidateUpdate(old runtime.Object) error {
 		return err
 	}
 
+	if !c.Spec.External.Enable {
+		if err := validateManagedCluster(*c); err != nil {
+			return err
+		}
+	}
+
 	occ := old.(*CephCluster)
+	if !c.Spec.External.Enable {
+		if err := validateTimeouts(c.Spec, &occ.Spec); err != nil {
+			return err
+		}
+	}
 	return validateUpdatedCephCluster(c, occ)
 }
 
@@ -61,21 +123,346 @@ func (c *CephCluster) ValidateDelete() error {
 }
 
 func validateUpdatedCephCluster(updatedCephCluster *CephCluster, found *CephCluster) error {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
 	if updatedCephCluster.Spec.DataDirHostPath != found.Spec.DataDirHostPath {
-		return errors.Errorf("invalid update: DataDirHostPath change from %q to %q is not allowed", found.Spec.DataDirHostPath, updatedCephCluster.Spec.DataDirHostPath)
+		allErrs = append(allErrs, field.Forbidden(specPath.Child("dataDirHostPath"),
+			fmt.Sprintf("change from %q to %q is not allowed, the daemons keep their data under the path", found.Spec.DataDirHostPath, updatedCephCluster.Spec.DataDirHostPath)))
 	}
 
+	networkPath := specPath.Child("network")
 	if updatedCephCluster.Spec.Network.HostNetwork != found.Spec.Network.HostNetwork {
-		return errors.Errorf("invalid update: HostNetwork change from %q to %q is not allowed", strconv.FormatBool(found.Spec.Network.HostNetwork), strconv.FormatBool(updatedCephCluster.Spec.Network.HostNetwork))
+		allErrs = append(allErrs, field.Forbidden(networkPath.Child("hostNetwork"),
+			fmt.Sprintf("change from %q to %q is not allowed", strconv.FormatBool(found.Spec.Network.HostNetwork), strconv.FormatBool(updatedCephCluster.Spec.Network.HostNetwork))))
+	}
+
+	if updatedCephCluster.Spec.Network.IsHostFor(KeyMon) != found.Spec.Network.IsHostFor(KeyMon) {
+		allErrs = append(allErrs, field.Forbidden(networkPath.Child("daemonHostNetwork").Key(string(KeyMon)),
+			fmt.Sprintf("change from %q to %q is not allowed, the mons keep their addresses", strconv.FormatBool(found.Spec.Network.IsHostFor(KeyMon)), strconv.FormatBool(updatedCephCluster.Spec.Network.IsHostFor(KeyMon)))))
 	}
 
 	if updatedCephCluster.Spec.Network.Provider != found.Spec.Network.Provider {
-		return errors.Errorf("invalid update: Provider change from %q to %q is not allowed", found.Spec.Network.Provider, updatedCephCluster.Spec.Network.Provider)
+		allErrs = append(allErrs, field.Forbidden(networkPath.Child("provider"),
+			fmt.Sprintf("change from %q to %q is not allowed", found.Spec.Network.Provider, updatedCephCluster.Spec.Network.Provider)))
+	}
+
+	if updatedCephCluster.Spec.Network.IPFamily != found.Spec.Network.IPFamily {
+		allErrs = append(allErrs, field.Forbidden(networkPath.Child("ipFamily"),
+			fmt.Sprintf("change from %q to %q is not allowed, the mons keep their addresses", found.Spec.Network.IPFamily, updatedCephCluster.Spec.Network.IPFamily)))
+	}
+
+	monPath := specPath.Child("mon")
+	if !reflect.DeepEqual(updatedCephCluster.Spec.Mon.StretchCluster, found.Spec.Mon.StretchCluster) {
+		allErrs = append(allErrs, field.Forbidden(monPath.Child("stretchCluster"), "change is not allowed, the zones of the mons are set when the cluster is created"))
+	}
+
+	// the mons removed at once must leave a majority of the previous mons to keep the quorum
+	oldCount, newCount := monCount(found.Spec), monCount(updatedCephCluster.Spec)
+	if quorum := oldCount/2 + 1; newCount > 0 && newCount < quorum {
+		allErrs = append(allErrs, field.Invalid(monPath.Child("count"), newCount,
+			fmt.Sprintf("decrease from %d is not allowed below %d, the remaining mons would lose the quorum", oldCount, quorum)))
+	}
+
+	allErrs = append(allErrs, validateRemovedDevices(updatedCephCluster, found, specPath.Child("storage"))...)
+
+	oldMajor, oldOK := imageMajorVersion(found.Spec.CephVersion.Image)
+	newMajor, newOK := imageMajorVersion(updatedCephCluster.Spec.CephVersion.Image)
+	if oldOK && newOK && newMajor < oldMajor {
+		if RejectCephDowngrades {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("cephVersion", "image"),
+				fmt.Sprintf("downgrade from %q to %q is not allowed", found.Spec.CephVersion.Image, updatedCephCluster.Spec.CephVersion.Image)))
+		} else {
+			logger.Warningf("ceph image is downgraded from %q to %q, downgrading ceph to a previous major version is not supported", found.Spec.CephVersion.Image, updatedCephCluster.Spec.CephVersion.Image)
+		}
+	}
+
+	if len(allErrs) > 0 {
+		return errors.Wrap(allErrs.ToAggregate(), "invalid update")
+	}
+	return nil
+}
+
+// validateRemovedDevices ensures no device is removed from the storage while the cluster has osds, since the
+// osd of the device would be left behind. The osds can first be removed with the osd remove annotation.
+func validateRemovedDevices(updatedCephCluster *CephCluster, found *CephCluster, storagePath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if found.Status.DaemonHealth == nil || found.Status.DaemonHealth.OSD.Count == 0 {
+		return allErrs
+	}
+	if updatedCephCluster.Annotations[removeOSDsAnnotation] != "" {
+		return allErrs
+	}
+
+	allErrs = append(allErrs, validateRemovedNodeDevices(updatedCephCluster.Spec.Storage.Devices, found.Spec.Storage.Devices, storagePath.Child("devices"))...)
+
+	updatedNodes := map[string]rookv1.Node{}
+	for _, node := range updatedCephCluster.Spec.Storage.Nodes {
+		updatedNodes[node.Name] = node
+	}
+	for i, node := range found.Spec.Storage.Nodes {
+		nodePath := storagePath.Child("nodes").Index(i)
+		updatedNode, ok := updatedNodes[node.Name]
+		if !ok {
+			if len(node.Devices) > 0 {
+				allErrs = append(allErrs, field.Forbidden(nodePath,
+					fmt.Sprintf("node %q with devices cannot be removed while the cluster has osds, remove its osds first", node.Name)))
+			}
+			continue
+		}
+		allErrs = append(allErrs, validateRemovedNodeDevices(updatedNode.Devices, node.Devices, nodePath.Child("devices"))...)
+	}
+	return allErrs
+}
+
+func validateRemovedNodeDevices(updatedDevices, devices []rookv1.Device, devicesPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	updatedNames := map[string]struct{}{}
+	for _, device := range updatedDevices {
+		updatedNames[deviceName(device)] = struct{}{}
+	}
+	for i, device := range devices {
+		if _, ok := updatedNames[deviceName(device)]; !ok {
+			allErrs = append(allErrs, field.Forbidden(devicesPath.Index(i),
+				fmt.Sprintf("device %q cannot be removed while the cluster has osds, remove its osd first", deviceName(device))))
+		}
+	}
+	return allErrs
+}
+
+// deviceName returns the full path of the device if set, its name otherwise
+func deviceName(device rookv1.Device) string {
+	if device.FullPath != "" {
+		return device.FullPath
+	}
+	return device.Name
+}
+
+// validateManagedCluster validates the settings of the clusters whose daemons are managed by rook, which are not external
+func validateManagedCluster(c CephCluster) error {
+	if err := ValidateMonCount(c.Spec); err != nil {
+		return err
+	}
+
+	if err := validateStretchCluster(c.Spec); err != nil {
+		return err
+	}
+
+	if err := validateMonZones(c.Spec); err != nil {
+		return err
+	}
+
+	if err := validateRulesNamespace(c); err != nil {
+		return err
+	}
+
+	if len(c.Spec.Monitoring.ExternalMgrEndpoints) > 0 {
+		return errors.New("monitoring.externalMgrEndpoints is only supported with an external cluster")
+	}
+
+	if err := validateConnections(c.Spec); err != nil {
+		return err
+	}
+
+	if err := validateDaemonHostNetwork(c.Spec); err != nil {
+		return err
+	}
+
+	if err := validateServiceSpec("dashboard:service", c.Spec.Dashboard.Service); err != nil {
+		return errors.Wrap(err, "invalid config")
+	}
+
+	if err := validateBackup(c.Spec.Backup); err != nil {
+		return err
+	}
+
+	if err := validateDaemonOverrides(c.Spec); err != nil {
+		return err
+	}
+
+	return validateCephImage(c.Spec.CephVersion.Image)
+}
+
+// validateConnections ensures the ceph version of the image supports the settings of the connections
+func validateConnections(spec ClusterSpec) error {
+	if !spec.Network.IsCompressionEnabled() {
+		return nil
+	}
+	if major, ok := imageMajorVersion(spec.CephVersion.Image); ok && major < compressionMinCephMajorVersion {
+		return errors.Errorf("invalid config : network:connections:compression requires ceph quincy or newer, the image %q is too old", spec.CephVersion.Image)
 	}
 
 	return nil
 }
 
+// validateDaemonHostNetwork ensures the host network is overridden for the daemons supporting it, and not along with a
+// network provider
+func validateDaemonHostNetwork(spec ClusterSpec) error {
+	if len(spec.Network.DaemonHostNetwork) == 0 {
+		return nil
+	}
+	if spec.Network.Provider != "" {
+		return errors.Errorf("invalid config : network:daemonHostNetwork cannot be set with network:provider %q", spec.Network.Provider)
+	}
+	for key := range spec.Network.DaemonHostNetwork {
+		switch key {
+		case KeyMon, KeyMgr, KeyOSD, KeyRgw, KeyMds:
+		default:
+			return errors.Errorf("invalid config : network:daemonHostNetwork key %q is not one of %q, %q, %q, %q or %q", key, KeyMon, KeyMgr, KeyOSD, KeyRgw, KeyMds)
+		}
+	}
+	// the mons on the host network listen on the same ports of the node
+	if spec.Network.IsHostFor(KeyMon) && spec.Mon.AllowMultiplePerNode && monCount(spec) > 1 {
+		return errors.New("invalid config : mon:allowMultiplePerNode cannot be set with the mons on the host network")
+	}
+	return nil
+}
+
+// validateServiceSpec validates the customization of a service created by the operator
+func validateServiceSpec(path string, service *ServiceSpec) error {
+	if service == nil {
+		return nil
+	}
+	switch service.Type {
+	case "", v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer:
+	default:
+		return errors.Errorf("%s:type %q is not one of %q, %q or %q", path, service.Type, v1.ServiceTypeClusterIP, v1.ServiceTypeNodePort, v1.ServiceTypeLoadBalancer)
+	}
+	if len(service.LoadBalancerSourceRanges) > 0 && service.Type != v1.ServiceTypeLoadBalancer {
+		return errors.Errorf("%s:loadBalancerSourceRanges can only be set with the %q type", path, v1.ServiceTypeLoadBalancer)
+	}
+	for _, cidr := range service.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			return errors.Errorf("%s:loadBalancerSourceRanges %q is not a CIDR", path, cidr)
+		}
+	}
+	return nil
+}
+
+// validateRulesNamespace ensures the prometheus rules are created in the namespace of the cluster or in an allowed namespace
+func validateRulesNamespace(c CephCluster) error {
+	rulesNamespace := c.Spec.Monitoring.RulesNamespace
+	if rulesNamespace == "" || rulesNamespace == c.Namespace {
+		return nil
+	}
+	for _, namespace := range AllowedRulesNamespaces {
+		if rulesNamespace == namespace {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid config : monitoring.rulesNamespace %q must be the namespace of the cluster %q or one of the allowed namespaces %v", rulesNamespace, c.Namespace, AllowedRulesNamespaces)
+}
+
+// validateCephImage ensures the ceph image is set and is a valid container image reference
+func validateCephImage(image string) error {
+	if image == "" {
+		return errors.New("invalid config : cephVersion:image must be set")
+	}
+	if !imageRegex.MatchString(image) {
+		return errors.Errorf("invalid config : cephVersion:image %q is not a valid container image reference", image)
+	}
+
+	return nil
+}
+
+// validateOSDPrepare ensures the limits of the OSD prepare jobs are not negative and the batch delay is a duration
+func validateOSDPrepare(prepare *rookv1.PrepareSpec) error {
+	if prepare == nil {
+		return nil
+	}
+	if prepare.MaxConcurrentJobs < 0 {
+		return errors.Errorf("invalid config : storage:prepare:maxConcurrentJobs %d must not be negative", prepare.MaxConcurrentJobs)
+	}
+	if prepare.MaxConcurrentJobsPerNode < 0 {
+		return errors.Errorf("invalid config : storage:prepare:maxConcurrentJobsPerNode %d must not be negative", prepare.MaxConcurrentJobsPerNode)
+	}
+	if prepare.BatchDelay != "" {
+		delay, err := time.ParseDuration(prepare.BatchDelay)
+		if err != nil || delay < 0 {
+			return errors.Errorf("invalid config : storage:prepare:batchDelay %q is not a duration such as 30s", prepare.BatchDelay)
+		}
+	}
+	return nil
+}
+
+// validateRemovedNodeGracePeriod ensures the grace period of the OSDs of the deleted nodes is a duration, the OSDs of
+// the deleted nodes being only removed when all the nodes are used
+func validateRemovedNodeGracePeriod(storage rookv1.StorageScopeSpec) error {
+	if storage.RemovedNodeGracePeriod == "" {
+		return nil
+	}
+	period, err := time.ParseDuration(storage.RemovedNodeGracePeriod)
+	if err != nil || period <= 0 {
+		return errors.Errorf("invalid config : storage:removedNodeGracePeriod %q is not a duration such as 24h", storage.RemovedNodeGracePeriod)
+	}
+	if !storage.UseAllNodes {
+		return errors.New("invalid config : storage:removedNodeGracePeriod requires storage:useAllNodes, the OSDs of the nodes listed in the spec are removed with their node in the spec")
+	}
+	return nil
+}
+
+// validateUpdateStrategy ensures the update strategy type is known, the wave size is not negative and the wave
+// timeout is a duration
+func validateUpdateStrategy(strategy *UpdateStrategySpec) error {
+	if strategy == nil {
+		return nil
+	}
+	if strategy.Type != "" && strategy.Type != UpdateStrategyOneByOne && strategy.Type != UpdateStrategyWaves {
+		return errors.Errorf("invalid config : updateStrategy:type %q is not one of %s, %s", strategy.Type, UpdateStrategyOneByOne, UpdateStrategyWaves)
+	}
+	if strategy.WaveSize < 0 {
+		return errors.Errorf("invalid config : updateStrategy:waveSize %d must not be negative", strategy.WaveSize)
+	}
+	if strategy.WaveTimeout != "" {
+		timeout, err := time.ParseDuration(strategy.WaveTimeout)
+		if err != nil || timeout <= 0 {
+			return errors.Errorf("invalid config : updateStrategy:waveTimeout %q is not a duration such as 10m", strategy.WaveTimeout)
+		}
+	}
+	return nil
+}
+
+// validateDeclaredCephVersion ensures the ceph version declared to skip the image version detection is a version of
+// the major version of the image tag
+func validateDeclaredCephVersion(spec CephVersionSpec) error {
+	if spec.Version == "" {
+		if spec.SkipImageVersionDetection {
+			return errors.New("invalid config : cephVersion:version must be set to skip the image version detection")
+		}
+		return nil
+	}
+	m := cephVersionRegex.FindStringSubmatch(spec.Version)
+	if m == nil {
+		return errors.Errorf("invalid config : cephVersion:version %q is not a ceph version such as 15.2.4", spec.Version)
+	}
+	if imageMajor, ok := imageMajorVersion(spec.Image); ok && strconv.Itoa(imageMajor) != m[1] {
+		return errors.Errorf("invalid config : cephVersion:version %q is not a version of the image %q", spec.Version, spec.Image)
+	}
+
+	return nil
+}
+
+// imageMajorVersion returns the major version of the tag of an image, if the tag is a version
+func imageMajorVersion(image string) (int, bool) {
+	// the tag follows the last colon, unless the colon is the port of the registry
+	i := strings.LastIndex(image, ":")
+	if i < 0 || strings.Contains(image[i:], "/") || strings.Contains(image, "@") {
+		return 0, false
+	}
+
+	match := imageMajorVersionRegex.FindStringSubmatch(image[i+1:])
+	if match == nil {
+		return 0, false
+	}
+	major, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+
+	return major, true
+}
+
 // Validate resources that need validated for both creates and updates
 func validateCommon(cluster CephCluster) error {
 	// If drive groups are set, only storage for OSDs on PVCs can be used simultaneously
@@ -112,5 +499,456 @@ func validateCommon(cluster CephCluster) error {
 		}
 	}
 
+	if err := validateNodesDeviceSelection(cluster.Spec.Storage); err != nil {
+		return err
+	}
+
+	if err := validateDeviceFilters(cluster.Spec.Storage); err != nil {
+		return err
+	}
+
+	if err := validateCephConfig(cluster.Spec.CephConfig); err != nil {
+		return err
+	}
+
+	if err := validateScrubbing(cluster.Spec); err != nil {
+		return err
+	}
+
+	if err := validateStorageCompression(cluster.Spec); err != nil {
+		return err
+	}
+
+	if err := validateOSDPrepare(cluster.Spec.Storage.Prepare); err != nil {
+		return err
+	}
+
+	if err := validateRemovedNodeGracePeriod(cluster.Spec.Storage); err != nil {
+		return err
+	}
+
+	if err := validateUpdateStrategy(cluster.Spec.UpdateStrategy); err != nil {
+		return err
+	}
+
+	if err := validateLogCollector(cluster.Spec.LogCollector); err != nil {
+		return err
+	}
+
+	if err := validateHealthRemediation(cluster.Spec.HealthCheck.Remediation); err != nil {
+		return err
+	}
+
+	if err := validateBalancer(cluster.Spec.Mgr); err != nil {
+		return err
+	}
+
+	if err := validateTelemetry(cluster.Spec); err != nil {
+		return err
+	}
+
+	if err := validateDeclaredCephVersion(cluster.Spec.CephVersion); err != nil {
+		return err
+	}
+
+	if err := validatePlacements(cluster.Spec); err != nil {
+		return err
+	}
+
+	if !cluster.Spec.External.Enable {
+		if err := ValidateResourceLimits(cluster.Spec); err != nil {
+			return err
+		}
+	}
+
+	if cluster.Spec.ReconcileStrategy != "" && cluster.Spec.ReconcileStrategy != ReconcileStrategyPaused {
+		return errors.Errorf("invalid config : reconcileStrategy %q is not %q", cluster.Spec.ReconcileStrategy, ReconcileStrategyPaused)
+	}
+
+	if cluster.Spec.DriftPolicy != "" && cluster.Spec.DriftPolicy != DriftPolicyRevert && cluster.Spec.DriftPolicy != DriftPolicyReport {
+		return errors.Errorf("invalid config : driftPolicy %q is not one of %s, %s", cluster.Spec.DriftPolicy, DriftPolicyRevert, DriftPolicyReport)
+	}
+
+	return nil
+}
+
+// validateLogCollector ensures the log files are rotated with a periodicity of logrotate and a positive size
+func validateLogCollector(logCollector LogCollectorSpec) error {
+	switch logCollector.Periodicity {
+	case "", "hourly", "daily", "weekly", "monthly":
+	default:
+		return errors.Errorf("invalid config : logCollector:periodicity %q is not one of hourly, daily, weekly or monthly", logCollector.Periodicity)
+	}
+	if logCollector.MaxLogSize != nil && logCollector.MaxLogSize.Value() <= 0 {
+		return errors.Errorf("invalid config : logCollector:maxLogSize %q must be positive", logCollector.MaxLogSize.String())
+	}
+	return nil
+}
+
+// validateBackup ensures the backups have a cron schedule and are written to either a PVC or a bucket
+func validateBackup(backup BackupSpec) error {
+	if !backup.Enabled {
+		return nil
+	}
+	if backup.Schedule != "" && !strings.HasPrefix(backup.Schedule, "@") && len(strings.Fields(backup.Schedule)) != 5 {
+		return errors.Errorf("invalid config : backup:schedule %q is not a cron schedule", backup.Schedule)
+	}
+	if backup.Retain < 0 {
+		return errors.Errorf("invalid config : backup:retain %d must not be negative", backup.Retain)
+	}
+	if (backup.PersistentVolumeClaim == nil) == (backup.Bucket == nil) {
+		return errors.New("invalid config : backup requires either a persistentVolumeClaim or a bucket")
+	}
+	if backup.PersistentVolumeClaim != nil && backup.PersistentVolumeClaim.ClaimName == "" {
+		return errors.New("invalid config : backup:persistentVolumeClaim:claimName must be set")
+	}
+	if backup.Bucket != nil {
+		if backup.Bucket.Name == "" || backup.Bucket.SecretName == "" {
+			return errors.New("invalid config : backup:bucket:name and backup:bucket:secretName must be set")
+		}
+		endpoint, err := url.Parse(backup.Bucket.Endpoint)
+		if err != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" {
+			return errors.Errorf("invalid config : backup:bucket:endpoint %q is not an http or https URL", backup.Bucket.Endpoint)
+		}
+	}
+	return nil
+}
+
+// validateTelemetry ensures the telemetry channels are known, the telemetry module not being configured as well with
+// the modules
+func validateTelemetry(spec ClusterSpec) error {
+	if spec.Telemetry == nil {
+		return nil
+	}
+	for _, module := range spec.Mgr.Modules {
+		if module.Name == "telemetry" {
+			return errors.New("invalid config : the telemetry module cannot be set in both mgr:modules and telemetry")
+		}
+	}
+	for _, channel := range spec.Telemetry.Channels {
+		known := false
+		for _, c := range TelemetryChannels {
+			known = known || c == channel
+		}
+		if !known {
+			return errors.Errorf("invalid config : telemetry:channels %q is not one of %s", channel, strings.Join(TelemetryChannels, ", "))
+		}
+	}
+	return nil
+}
+
+// validateBalancer ensures the balancer mode is known and its misplaced ratio is a ratio, the balancer not being
+// configured as well with the modules
+func validateBalancer(mgr MgrSpec) error {
+	if mgr.Balancer == nil {
+		return nil
+	}
+	switch mgr.Balancer.Mode {
+	case "", BalancerModeUpmap, BalancerModeCrushCompat, BalancerModeOff:
+	default:
+		return errors.Errorf("invalid config : mgr:balancer:mode %q is not one of %s, %s or %s", mgr.Balancer.Mode, BalancerModeUpmap, BalancerModeCrushCompat, BalancerModeOff)
+	}
+	if mgr.Balancer.MaxMisplaced < 0 || mgr.Balancer.MaxMisplaced > 1 {
+		return errors.Errorf("invalid config : mgr:balancer:maxMisplaced %v must be between 0 and 1", mgr.Balancer.MaxMisplaced)
+	}
+	for _, module := range mgr.Modules {
+		if module.Name == "balancer" {
+			return errors.New("invalid config : the balancer cannot be set in both mgr:modules and mgr:balancer")
+		}
+	}
+	return nil
+}
+
+// validateCephConfig ensures the sections and the options of the ceph config are named
+func validateCephConfig(cephConfig map[string]map[string]string) error {
+	for who, options := range cephConfig {
+		if strings.TrimSpace(who) == "" {
+			return errors.New("invalid config : cephConfig: the section name cannot be empty")
+		}
+		for option := range options {
+			if strings.TrimSpace(option) == "" {
+				return errors.Errorf("invalid config : cephConfig:%s: the option name cannot be empty", who)
+			}
+		}
+	}
+	return nil
+}
+
+// Warnings returns the non-fatal issues of the cluster settings. The cluster is still admitted, the admission response of
+// this controller-runtime version cannot carry warnings: the operator reports them in the status of the CephCluster.
+func Warnings(cluster CephCluster) []string {
+	warnings := []string{}
+	if !cluster.Spec.External.Enable && !selectsStorage(cluster.Spec) {
+		warnings = append(warnings, "storage:useAllDevices is false and none of storage:devices, storage:deviceFilter or storage:devicePathFilter is set, no OSD will be provisioned")
+	}
+	if !cluster.Spec.External.Enable {
+		warnings = append(warnings, resourceWarnings(cluster.Spec)...)
+	}
+
+	return warnings
+}
+
+// selectsStorage returns whether the storage spec can select any device for the OSDs
+func selectsStorage(spec ClusterSpec) bool {
+	if len(spec.DriveGroups) > 0 || len(spec.Storage.StorageClassDeviceSets) > 0 {
+		return true
+	}
+
+	selections := []rookv1.Selection{spec.Storage.Selection}
+	for _, node := range spec.Storage.Nodes {
+		selections = append(selections, node.Selection)
+	}
+	for _, selection := range selections {
+		if selection.UseAllDevices != nil && *selection.UseAllDevices {
+			return true
+		}
+		if len(selection.Devices) > 0 || selection.DeviceFilter != "" || selection.DevicePathFilter != "" {
+			return true
+		}
+		if len(selection.Directories) > 0 || len(selection.VolumeClaimTemplates) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateDeviceFilters checks the size and the type filters and the device classes of the devices of the cluster, of
+// its nodes and of its device sets
+func validateDeviceFilters(storage rookv1.StorageScopeSpec) error {
+	if err := storage.Selection.ValidateDeviceFilters(); err != nil {
+		return errors.Wrap(err, "invalid config : storage")
+	}
+	for _, node := range storage.Nodes {
+		if err := node.Selection.ValidateDeviceFilters(); err != nil {
+			return errors.Wrapf(err, "invalid config : storage:nodes:%s", node.Name)
+		}
+	}
+	for _, deviceSet := range storage.StorageClassDeviceSets {
+		if err := rookv1.ValidateDeviceClass(deviceSet.DeviceClass); err != nil {
+			return errors.Wrapf(err, "invalid config : storage:storageClassDeviceSets:%s", deviceSet.Name)
+		}
+	}
+	return nil
+}
+
+// validateNodesDeviceSelection ensures each node selects its devices in a single way
+func validateNodesDeviceSelection(storage rookv1.StorageScopeSpec) error {
+	for _, node := range storage.Nodes {
+		selectors := []string{}
+		if node.UseAllDevices != nil && *node.UseAllDevices {
+			selectors = append(selectors, "useAllDevices")
+		}
+		if node.DeviceFilter != "" {
+			selectors = append(selectors, "deviceFilter")
+		}
+		if node.DevicePathFilter != "" {
+			selectors = append(selectors, "devicePathFilter")
+		}
+		if len(node.Devices) > 0 {
+			selectors = append(selectors, "devices")
+		}
+		if len(selectors) > 1 {
+			return errors.Errorf("invalid config : storage:nodes:%s selects devices with conflicting settings: %s, only one of them can be set", node.Name, strings.Join(selectors, ", "))
+		}
+
+		devices := map[string]struct{}{}
+		for _, device := range node.Devices {
+			name := deviceName(device)
+			if _, ok := devices[name]; ok {
+				return errors.Errorf("invalid config : storage:nodes:%s lists device %q more than once", node.Name, name)
+			}
+			devices[name] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
+// ValidateMonCount ensures the mon count allows the mons to form a quorum. The operator also validates the mon count
+// since the admission controller may not be enabled.
+func ValidateMonCount(spec ClusterSpec) error {
+	count := monCount(spec)
+	if count < minMonCount || count > maxMonCount {
+		return errors.Errorf("invalid config : mon:count %d must be between %d and %d, an odd count such as 3 or 5 is recommended", count, minMonCount, maxMonCount)
+	}
+	if count%2 == 0 {
+		// Test clusters running several mons on the same node may use any count, this is not possible on the host network
+		if spec.Mon.AllowMultiplePerNode && !spec.Network.IsHostFor(KeyMon) {
+			logger.Warningf("mon:count %d is even, an odd count is recommended to tolerate the same number of failures with fewer mons", count)
+			return nil
+		}
+		return errors.Errorf("invalid config : mon:count %d is even, an odd count such as %d or %d is required for the mons to keep a quorum", count, count-1, count+1)
+	}
+
+	return nil
+}
+
+// monCount returns the mon count of the cluster, an unset count being defaulted by the operator
+func monCount(spec ClusterSpec) int {
+	if spec.Mon.Count == 0 {
+		return defaultMonCount
+	}
+	return spec.Mon.Count
+}
+
+// validateStretchCluster ensures the mons of a stretch cluster can be spread across two data zones and an arbiter zone
+func validateStretchCluster(spec ClusterSpec) error {
+	if !spec.Mon.IsStretchCluster() {
+		return nil
+	}
+
+	zones := spec.Mon.StretchCluster.Zones
+	if len(zones) != stretchClusterZoneCount {
+		return errors.Errorf("invalid config : mon:stretchCluster:zones has %d zones, exactly %d zones are required, two data zones and an arbiter zone", len(zones), stretchClusterZoneCount)
+	}
+
+	names := map[string]struct{}{}
+	arbiters := 0
+	for _, zone := range zones {
+		if zone.Name == "" {
+			return errors.New("invalid config : mon:stretchCluster:zones must all have a name")
+		}
+		if _, ok := names[zone.Name]; ok {
+			return errors.Errorf("invalid config : mon:stretchCluster:zones lists zone %q more than once", zone.Name)
+		}
+		names[zone.Name] = struct{}{}
+		if zone.Arbiter {
+			arbiters++
+		}
+	}
+	if arbiters != 1 {
+		return errors.Errorf("invalid config : mon:stretchCluster:zones has %d arbiter zones, exactly one is required", arbiters)
+	}
+
+	if major, ok := imageMajorVersion(spec.CephVersion.Image); ok && major < stretchClusterMinCephMajorVersion {
+		return errors.Errorf("invalid config : mon:stretchCluster requires ceph pacific or newer, the image %q is too old", spec.CephVersion.Image)
+	}
+
+	// two mons in each data zone and the tiebreaker mon in the arbiter zone
+	if spec.Mon.Count != stretchClusterMonCount {
+		return errors.Errorf("invalid config : mon:count %d is not supported by a stretch cluster, the count must be %d", spec.Mon.Count, stretchClusterMonCount)
+	}
+
+	return nil
+}
+
+// validateMonZones ensures the zones the mons are spread across are named once each, and are not combined with the
+// zones of a stretch cluster
+func validateMonZones(spec ClusterSpec) error {
+	if len(spec.Mon.Zones) == 0 {
+		return nil
+	}
+	if spec.Mon.IsStretchCluster() {
+		return errors.New("invalid config : mon:zones cannot be set with mon:stretchCluster, the zones of a stretch cluster are set in mon:stretchCluster:zones")
+	}
+
+	names := map[string]struct{}{}
+	for _, zone := range spec.Mon.Zones {
+		if zone.Name == "" {
+			return errors.New("invalid config : mon:zones must all have a name")
+		}
+		if _, ok := names[zone.Name]; ok {
+			return errors.Errorf("invalid config : mon:zones lists zone %q more than once", zone.Name)
+		}
+		names[zone.Name] = struct{}{}
+	}
+
+	return nil
+}
+
+// validateTimeouts ensures the different wait timeouts of the cluster are consistent with each other. On update, the
+// old spec is given so that the health check settings admitted before they were validated are only rejected once they
+// are changed.
+func validateTimeouts(spec ClusterSpec, old *ClusterSpec) error {
+	osdMaintenanceTimeout := spec.DisruptionManagement.OSDMaintenanceTimeout
+	if osdMaintenanceTimeout < 0 && (old == nil || old.DisruptionManagement.OSDMaintenanceTimeout != osdMaintenanceTimeout) {
+		return errors.Errorf("invalid config : disruptionManagement:osdMaintenanceTimeout %d cannot be negative", osdMaintenanceTimeout)
+	}
+
+	// the commands of the health checks are given up after the command timeout, unparseable timeouts being ignored
+	// by the health checkers
+	var commandTimeout time.Duration
+	if spec.HealthCheck.CommandTimeout != "" {
+		commandTimeout, _ = time.ParseDuration(spec.HealthCheck.CommandTimeout)
+	}
+
+	oldDaemons := map[string]HealthCheckSpec{}
+	if old != nil {
+		oldDaemons = daemonHealthChecks(*old)
+	}
+	for daemon, healthCheck := range daemonHealthChecks(spec) {
+		oldHealthCheck, updated := oldDaemons[daemon]
+		interval, err := parseHealthCheckDuration(daemon, "interval", healthCheck.Interval, updated && oldHealthCheck.Interval == healthCheck.Interval)
+		if err != nil {
+			return err
+		}
+		timeout, err := parseHealthCheckDuration(daemon, "timeout", healthCheck.Timeout, updated && oldHealthCheck.Timeout == healthCheck.Timeout)
+		if err != nil {
+			return err
+		}
+		// The mon timeout is the time a mon can be out of quorum across checks, it would always be exceeded by the
+		// time the next check runs. The osd and status timeouts only bound a single check.
+		if daemon == "mon" && interval > 0 && timeout > 0 && timeout < interval {
+			logger.Warningf("healthCheck:daemonHealth:%s:timeout %q is shorter than the check interval %q, the timeout will expire on the first failed check", daemon, healthCheck.Timeout, healthCheck.Interval)
+		}
+		// A ceph command hanging for longer than the interval delays the next checks. The status timeout overrides
+		// the command timeout of the status check.
+		if daemon == "status" && timeout > 0 {
+			continue
+		}
+		if interval > 0 && commandTimeout >= interval {
+			logger.Warningf("healthCheck:commandTimeout %q is not shorter than the healthCheck:daemonHealth:%s:interval %q, a hanging ceph command will delay the next check", spec.HealthCheck.CommandTimeout, daemon, healthCheck.Interval)
+		}
+	}
+
+	return nil
+}
+
+// daemonHealthChecks returns the health check settings of the cluster by daemon
+func daemonHealthChecks(spec ClusterSpec) map[string]HealthCheckSpec {
+	return map[string]HealthCheckSpec{
+		"mon":    spec.HealthCheck.DaemonHealth.Monitor.HealthCheckSpec,
+		"osd":    spec.HealthCheck.DaemonHealth.ObjectStorageDaemon,
+		"status": spec.HealthCheck.DaemonHealth.Status,
+	}
+}
+
+// parseHealthCheckDuration parses the interval or the timeout of the health check of a daemon. An invalid or negative
+// duration is rejected, unless it is unchanged by an update, the health checkers then keep on ignoring it.
+func parseHealthCheckDuration(daemon, setting, value string, unchanged bool) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	duration, err := time.ParseDuration(value)
+	if err == nil && duration >= 0 {
+		return duration, nil
+	}
+	if unchanged {
+		logger.Warningf("healthCheck:daemonHealth:%s:%s %q is not a valid duration, admitting it since it is unchanged", daemon, setting, value)
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid config : healthCheck:daemonHealth:%s:%s %q", daemon, setting, value)
+	}
+	return 0, errors.Errorf("invalid config : healthCheck:daemonHealth:%s:%s %q cannot be negative", daemon, setting, value)
+}
+
+// validateHealthRemediation ensures the daemons are restarted after a positive number of crashes and the remediations
+// are rate limited by a positive interval
+func validateHealthRemediation(remediation HealthRemediationSpec) error {
+	if remediation.RestartCrashedDaemons < 0 {
+		return errors.Errorf("invalid config : healthCheck:remediation:restartCrashedDaemons %d cannot be negative", remediation.RestartCrashedDaemons)
+	}
+	if remediation.MinInterval != "" {
+		interval, err := time.ParseDuration(remediation.MinInterval)
+		if err != nil {
+			return errors.Wrapf(err, "invalid config : healthCheck:remediation:minInterval %q", remediation.MinInterval)
+		}
+		if interval <= 0 {
+			return errors.Errorf("invalid config : healthCheck:remediation:minInterval %q must be positive", remediation.MinInterval)
+		}
+	}
+
 	return nil
 }
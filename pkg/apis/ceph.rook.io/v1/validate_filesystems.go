@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+var _ webhook.Validator = &CephFilesystem{}
+
+func (f *CephFilesystem) ValidateCreate() error {
+	logger.Infof("validate create cephfilesystem %q", f.Name)
+	return validateFilesystemSpec(f)
+}
+
+func (f *CephFilesystem) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephfilesystem %q", f.Name)
+	if err := validateFilesystemSpec(f); err != nil {
+		return err
+	}
+
+	// the pools of the filesystem already exist, their type cannot be changed
+	oldFilesystem := old.(*CephFilesystem)
+	if err := validatePoolTypeUpdate(f.Spec.MetadataPool, oldFilesystem.Spec.MetadataPool); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	for i := range f.Spec.DataPools {
+		if i >= len(oldFilesystem.Spec.DataPools) {
+			break
+		}
+		if err := validatePoolTypeUpdate(f.Spec.DataPools[i].PoolSpec, oldFilesystem.Spec.DataPools[i].PoolSpec); err != nil {
+			return errors.Wrapf(err, "invalid data pool %d", i)
+		}
+		if f.DataPoolName(i) != oldFilesystem.DataPoolName(i) {
+			return errors.Errorf("invalid update: the name of data pool %d cannot be changed, new data pools must be added at the end", i)
+		}
+	}
+
+	// the default data pool is set when the filesystem is created
+	if len(oldFilesystem.Spec.DataPools) > 0 && len(f.Spec.DataPools) > 0 {
+		if f.Spec.DefaultDataPoolIndex() != oldFilesystem.Spec.DefaultDataPoolIndex() {
+			return errors.New("invalid update: the default data pool of the filesystem cannot be changed")
+		}
+	}
+	return nil
+}
+
+func (f *CephFilesystem) ValidateDelete() error {
+	return nil
+}
+
+// validateFilesystemSpec validates the settings of the filesystem that do not depend on the state of the cluster
+func validateFilesystemSpec(f *CephFilesystem) error {
+	spec := f.Spec
+	if spec.MetadataServer.ActiveCount < 1 {
+		return errors.New("invalid create: metadataServer.activeCount must be at least 1")
+	}
+	if err := validateMemoryLimit("metadataServer:resources", spec.MetadataServer.Resources, mdsMemoryMinimum); err != nil {
+		return err
+	}
+	if err := validateMDSHealthCheck(spec.HealthCheck.MDS); err != nil {
+		return err
+	}
+	if err := validatePlacement("metadataServer:placement", spec.MetadataServer.Placement); err != nil {
+		return err
+	}
+
+	// no data pool means that the filesystem is expected to exist already
+	if len(spec.DataPools) == 0 {
+		return nil
+	}
+	if err := ValidatePoolSpecs(spec.MetadataPool); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	names := map[string]bool{}
+	defaults := 0
+	for i, p := range spec.DataPools {
+		if err := ValidatePoolSpecs(p.PoolSpec); err != nil {
+			return errors.Wrapf(err, "invalid data pool %d", i)
+		}
+		name := f.DataPoolName(i)
+		if err := ValidatePoolName(name); err != nil {
+			return errors.Wrapf(err, "invalid data pool %d", i)
+		}
+		if names[name] {
+			return errors.Errorf("invalid create: data pool %d is named %q like another data pool", i, name)
+		}
+		names[name] = true
+		if p.Default {
+			defaults++
+		}
+	}
+	if defaults > 1 {
+		return errors.New("invalid create: only one data pool can be the default data pool")
+	}
+	return nil
+}
+
+// validateMDSHealthCheck ensures the interval of the mds health check and the threshold of the restart of the stuck
+// mds are positive durations
+func validateMDSHealthCheck(healthCheck MDSHealthCheckSpec) error {
+	for setting, value := range map[string]string{
+		"interval":          healthCheck.Interval,
+		"restartStuckAfter": healthCheck.RestartStuckAfter,
+	} {
+		if value == "" {
+			continue
+		}
+		duration, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid config : healthCheck:mds:%s %q", setting, value)
+		}
+		if duration <= 0 {
+			return errors.Errorf("invalid config : healthCheck:mds:%s %q must be positive", setting, value)
+		}
+	}
+	return nil
+}
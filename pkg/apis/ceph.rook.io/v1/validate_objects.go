@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"reflect"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+const (
+	// maxPort is the highest port the gateway can listen on
+	maxPort = 65535
+)
+
+var _ webhook.Validator = &CephObjectStore{}
+
+func (s *CephObjectStore) ValidateCreate() error {
+	logger.Infof("validate create cephobjectstore %q", s.Name)
+	return validateObjectStoreSpec(s.Spec)
+}
+
+func (s *CephObjectStore) ValidateUpdate(old runtime.Object) error {
+	logger.Infof("validate update cephobjectstore %q", s.Name)
+	if err := validateObjectStoreSpec(s.Spec); err != nil {
+		return err
+	}
+
+	// the pools of the store already exist, their type cannot be changed
+	oldStore := old.(*CephObjectStore)
+	if err := validatePoolTypeUpdate(s.Spec.MetadataPool, oldStore.Spec.MetadataPool); err != nil {
+		return errors.Wrap(err, "invalid metadata pool")
+	}
+	if err := validatePoolTypeUpdate(s.Spec.DataPool, oldStore.Spec.DataPool); err != nil {
+		return errors.Wrap(err, "invalid data pool")
+	}
+	return nil
+}
+
+func (s *CephObjectStore) ValidateDelete() error {
+	return nil
+}
+
+// validateObjectStoreSpec validates the settings of the store that do not depend on the state of the cluster
+func validateObjectStoreSpec(spec ObjectStoreSpec) error {
+	if spec.Gateway.Port < 0 || spec.Gateway.Port > maxPort {
+		return errors.Errorf("invalid create: gateway.port value of %d must be between 0 and %d", spec.Gateway.Port, maxPort)
+	}
+	if spec.Gateway.SecurePort < 0 || spec.Gateway.SecurePort > maxPort {
+		return errors.Errorf("invalid create: gateway.securePort value of %d must be between 0 and %d", spec.Gateway.SecurePort, maxPort)
+	}
+	if spec.Gateway.SecurePort > 0 && spec.Gateway.SSLCertificateRef == "" {
+		return errors.New("invalid create: gateway.sslCertificateRef must be set with gateway.securePort")
+	}
+	if spec.Gateway.Instances < 0 {
+		return errors.Errorf("invalid create: gateway.instances value of %d must not be negative", spec.Gateway.Instances)
+	}
+	if err := validatePlacement("gateway:placement", spec.Gateway.Placement); err != nil {
+		return err
+	}
+	if err := validateServiceSpec("gateway:service", spec.Gateway.Service); err != nil {
+		return errors.Wrap(err, "invalid create")
+	}
+
+	// the pools of a store in a zone are the pools of the zone
+	if spec.IsMultisite() && (!isEmptyPoolSpec(spec.MetadataPool) || !isEmptyPoolSpec(spec.DataPool)) {
+		return errors.Errorf("invalid create: the pools of the object store must not be set with zone %q, they are the pools of the zone", spec.Zone.Name)
+	}
+
+	// the pools may be empty if they were already created, such as by the ceph mgr
+	if !isEmptyPoolSpec(spec.MetadataPool) {
+		if err := ValidatePoolSpecs(spec.MetadataPool); err != nil {
+			return errors.Wrap(err, "invalid metadata pool")
+		}
+	}
+	if !isEmptyPoolSpec(spec.DataPool) {
+		if err := ValidatePoolSpecs(spec.DataPool); err != nil {
+			return errors.Wrap(err, "invalid data pool")
+		}
+	}
+	return nil
+}
+
+func isEmptyPoolSpec(ps PoolSpec) bool {
+	return reflect.DeepEqual(ps, PoolSpec{})
+}
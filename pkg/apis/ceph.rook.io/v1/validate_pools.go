@@ -17,6 +17,8 @@ limitations under the License.
 package v1
 
 import (
+	"regexp"
+
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -28,11 +30,37 @@ var (
 	logger      = capnslog.NewPackageLogger("github.com/rook/rook", webhookName)
 )
 
+const (
+	// minSafeReplicaSize is the smallest replica count of a pool that requires a safe replica size
+	minSafeReplicaSize = 3
+	// pgAutoscaleModeParameter is the pool parameter of the pg autoscaler mode
+	pgAutoscaleModeParameter = "pg_autoscale_mode"
+	ecPluginJerasure         = "jerasure"
+	ecPluginISA              = "isa"
+	ecPluginClay             = "clay"
+)
+
+var (
+	// jerasureTechniques are the techniques of the jerasure erasure code plugin
+	jerasureTechniques = []string{"reed_sol_van", "reed_sol_r6_op", "cauchy_orig", "cauchy_good", "liberation", "blaum_roth", "liber8tion"}
+	// isaTechniques are the techniques of the isa erasure code plugin
+	isaTechniques = []string{"reed_sol_van", "cauchy"}
+	// twoCodingChunksTechniques are the techniques only supporting 2 coding chunks
+	twoCodingChunksTechniques = []string{"reed_sol_r6_op", "liberation", "blaum_roth", "liber8tion"}
+)
+
+// poolNameRegex matches the pool names that need no escaping in the ceph and rbd commands, e.g. in a "pool/image" spec
+var poolNameRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
 var _ webhook.Validator = &CephBlockPool{}
 
 func (p *CephBlockPool) ValidateCreate() error {
 	logger.Infof("validate create cephblockpool %v", p)
 
+	if err := ValidatePoolName(p.Name); err != nil {
+		return errors.Wrap(err, "invalid create")
+	}
+
 	err := ValidatePoolSpecs(p.Spec)
 	if err != nil {
 		return err
@@ -52,6 +80,22 @@ func ValidatePoolSpecs(ps PoolSpec) error {
 		}
 	}
 
+	if err := validateReplicatedSpec(ps.Replicated); err != nil {
+		return err
+	}
+
+	if err := validateHybridStorage(ps); err != nil {
+		return err
+	}
+
+	if err := ValidatePoolAutoscaling(ps); err != nil {
+		return err
+	}
+
+	if err := ValidateCompression(ps.CompressionMode, ps.CompressionAlgorithm); err != nil {
+		return errors.Wrap(err, "invalid create")
+	}
+
 	if ps.Replicated.Size == 0 && ps.Replicated.TargetSizeRatio == 0 {
 		// Check if datachunks is set and has value less than 2.
 		if ps.ErasureCoded.DataChunks < 2 && ps.ErasureCoded.DataChunks != 0 {
@@ -62,6 +106,131 @@ func ValidatePoolSpecs(ps PoolSpec) error {
 		if ps.ErasureCoded.CodingChunks < 1 && ps.ErasureCoded.CodingChunks != 0 {
 			return errors.New("invalid create: erasurecoded.codingchunks needs minimum value of 1")
 		}
+
+		// Coding chunks must be strictly less than data chunks, otherwise more capacity is spent on coding than on data
+		if ps.ErasureCoded.DataChunks > 0 && ps.ErasureCoded.CodingChunks >= ps.ErasureCoded.DataChunks {
+			return errors.Errorf("invalid create: erasurecoded.codingchunks (%d) must be less than erasurecoded.datachunks (%d)", ps.ErasureCoded.CodingChunks, ps.ErasureCoded.DataChunks)
+		}
+
+		// The chunks are spread across distinct failure domains, so the failure domain must be chosen for the cluster topology
+		if ps.ErasureCoded.DataChunks > 0 && ps.GetFailureDomain() == "" {
+			return errors.Errorf("invalid create: failuredomain must be set for erasurecoded pools, %d failure domains are needed for the data and coding chunks", ps.ErasureCoded.DataChunks+ps.ErasureCoded.CodingChunks)
+		}
+	}
+
+	if err := validateErasureCodedSpec(ps.ErasureCoded); err != nil {
+		return err
+	}
+	return nil
+}
+
+// validateErasureCodedSpec ensures the erasure code plugin supports the technique and the coding chunks of the pool
+func validateErasureCodedSpec(ec ErasureCodedSpec) error {
+	if ec.DataChunks == 0 && ec.CodingChunks == 0 {
+		if ec.Plugin != "" || ec.Technique != "" || ec.FailureDomain != "" || ec.CrushRoot != "" || ec.DeviceClass != "" {
+			return errors.New("invalid create: the erasure code settings require erasurecoded.datachunks and erasurecoded.codingchunks")
+		}
+		return nil
+	}
+	if ec.Technique != "" && ec.Plugin == "" {
+		return errors.New("invalid create: erasurecoded.technique requires erasurecoded.plugin, the technique of the default profile may belong to another plugin")
+	}
+
+	var techniques []string
+	switch ec.Plugin {
+	case "":
+		return nil
+	case ecPluginJerasure, ecPluginClay:
+		// the clay plugin builds on the jerasure plugin by default
+		techniques = jerasureTechniques
+	case ecPluginISA:
+		techniques = isaTechniques
+	default:
+		return errors.Errorf("invalid create: unrecognized erasure code plugin %q, must be %s, %s or %s", ec.Plugin, ecPluginJerasure, ecPluginISA, ecPluginClay)
+	}
+	if ec.Technique == "" {
+		return nil
+	}
+	found := false
+	for _, technique := range techniques {
+		if technique == ec.Technique {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return errors.Errorf("invalid create: erasure code plugin %q does not support the technique %q, must be one of %v", ec.Plugin, ec.Technique, techniques)
+	}
+	for _, technique := range twoCodingChunksTechniques {
+		if technique == ec.Technique && ec.CodingChunks != 2 {
+			return errors.Errorf("invalid create: the erasure code technique %q requires 2 coding chunks, not %d", ec.Technique, ec.CodingChunks)
+		}
+	}
+	return nil
+}
+
+// ValidatePoolName ensures the pool name is accepted by ceph and the rbd tools. Ceph does not limit the length of the
+// pool names, so the names are only checked for the characters needing escaping. It is only checked by the admission
+// controller, the pools created before with other names being still reconciled.
+func ValidatePoolName(name string) error {
+	if !poolNameRegex.MatchString(name) {
+		return errors.Errorf("pool name %q must start with a letter or a digit and contain only letters, digits, '.', '-' and '_'", name)
+	}
+	return nil
+}
+
+// validateReplicatedSpec ensures a replicated pool requiring a safe replica size has enough replicas
+func validateReplicatedSpec(rs ReplicatedSpec) error {
+	if rs.Size > 0 && rs.RequireSafeReplicaSize && rs.Size < minSafeReplicaSize {
+		return errors.Errorf("invalid create: replicated.size (%d) must be at least %d when replicated.requireSafeReplicaSize is true", rs.Size, minSafeReplicaSize)
+	}
+	return nil
+}
+
+// validateHybridStorage ensures a hybrid pool places its primary and its other replicas on two device classes
+func validateHybridStorage(ps PoolSpec) error {
+	hybrid := ps.Replicated.HybridStorage
+	if hybrid == nil {
+		return nil
+	}
+	if hybrid.PrimaryDeviceClass == "" || hybrid.SecondaryDeviceClass == "" {
+		return errors.New("invalid create: replicated.hybridStorage requires the primaryDeviceClass and the secondaryDeviceClass")
+	}
+	if hybrid.PrimaryDeviceClass == hybrid.SecondaryDeviceClass {
+		return errors.Errorf("invalid create: the primary and secondary device classes of replicated.hybridStorage are both %q", hybrid.PrimaryDeviceClass)
+	}
+	if ps.DeviceClass != "" {
+		return errors.New("invalid create: deviceClass cannot be set with replicated.hybridStorage")
+	}
+	if ps.Replicated.Size < 2 {
+		return errors.New("invalid create: replicated.hybridStorage requires a replicated.size of at least 2")
+	}
+	return nil
+}
+
+// ValidatePoolAutoscaling ensures the pg autoscaler settings of the pool are consistent, the pg count being
+// managed by the autoscaler when it is on
+func ValidatePoolAutoscaling(ps PoolSpec) error {
+	if ps.TargetSizeRatio < 0 || ps.Replicated.TargetSizeRatio < 0 {
+		return errors.New("invalid create: targetSizeRatio cannot be negative")
+	}
+
+	mode := ps.Parameters[pgAutoscaleModeParameter]
+	if ps.PgAutoscaleMode != "" {
+		if mode != "" && mode != ps.PgAutoscaleMode {
+			return errors.Errorf("invalid create: pgAutoscaleMode %q conflicts with the %s parameter %q", ps.PgAutoscaleMode, pgAutoscaleModeParameter, mode)
+		}
+		mode = ps.PgAutoscaleMode
+	}
+	if mode != "" && mode != "on" && mode != "off" && mode != "warn" {
+		return errors.Errorf("invalid create: pgAutoscaleMode %q must be on, off or warn", mode)
+	}
+	if mode == "on" {
+		for _, parameter := range []string{"pg_num", "pgp_num"} {
+			if _, ok := ps.Parameters[parameter]; ok {
+				return errors.Errorf("invalid create: the %s parameter cannot be set with the pg autoscaler on", parameter)
+			}
+		}
 	}
 	return nil
 }
@@ -73,20 +242,58 @@ func (p *CephBlockPool) ValidateUpdate(old runtime.Object) error {
 	if err != nil {
 		return err
 	}
-	if p.Spec.ErasureCoded.CodingChunks > 0 || p.Spec.ErasureCoded.DataChunks > 0 || p.Spec.ErasureCoded.Algorithm != "" {
-		if ocbp.Spec.Replicated.Size > 0 || ocbp.Spec.Replicated.TargetSizeRatio > 0 {
+	if err := validatePoolTypeUpdate(p.Spec, ocbp.Spec); err != nil {
+		return err
+	}
+	return validatePlacementUpdate(p.Spec, ocbp.Spec)
+}
+
+// validatePoolTypeUpdate ensures an existing pool is not changed from replicated to erasure coded or the other way around
+func validatePoolTypeUpdate(ps, old PoolSpec) error {
+	if ps.ErasureCoded.CodingChunks > 0 || ps.ErasureCoded.DataChunks > 0 || ps.ErasureCoded.Algorithm != "" {
+		if old.Replicated.Size > 0 || old.Replicated.TargetSizeRatio > 0 {
 			return errors.New("invalid update: replicated field is set already in previous object. cannot be changed to use erasurecoded")
 		}
 	}
 
-	if p.Spec.Replicated.Size > 0 || p.Spec.Replicated.TargetSizeRatio > 0 {
-		if ocbp.Spec.ErasureCoded.CodingChunks > 0 || ocbp.Spec.ErasureCoded.DataChunks > 0 || ocbp.Spec.ErasureCoded.Algorithm != "" {
+	if ps.Replicated.Size > 0 || ps.Replicated.TargetSizeRatio > 0 {
+		if old.ErasureCoded.CodingChunks > 0 || old.ErasureCoded.DataChunks > 0 || old.ErasureCoded.Algorithm != "" {
 			return errors.New("invalid update: erasurecoded field is set already in previous object. cannot be changed to use replicated")
 		}
 	}
 	return nil
 }
 
+// validatePlacementUpdate ensures the placement of an existing pool is only changed where the operator applies the change.
+// The failure domain of a replicated pool is changed with a new crush rule, while the placement of an erasure coded pool
+// is set by its erasure code profile, which cannot be changed once the pool is created.
+func validatePlacementUpdate(ps, old PoolSpec) error {
+	if old.IsErasureCoded() {
+		if ps.ErasureCoded.DataChunks != old.ErasureCoded.DataChunks || ps.ErasureCoded.CodingChunks != old.ErasureCoded.CodingChunks {
+			return errors.Errorf("invalid update: the data and coding chunks of an erasure coded pool cannot be changed from %d+%d to %d+%d, the erasure code profile of a pool is set when it is created",
+				old.ErasureCoded.DataChunks, old.ErasureCoded.CodingChunks, ps.ErasureCoded.DataChunks, ps.ErasureCoded.CodingChunks)
+		}
+		if ps.ErasureCoded.Plugin != old.ErasureCoded.Plugin || ps.ErasureCoded.Technique != old.ErasureCoded.Technique {
+			return errors.New("invalid update: the erasure code plugin and technique of an erasure coded pool cannot be changed, the erasure code profile of a pool is set when it is created")
+		}
+		if ps.GetFailureDomain() != old.GetFailureDomain() {
+			return errors.Errorf("invalid update: the failure domain of an erasure coded pool cannot be changed from %q to %q, only the failure domain of a replicated pool can be changed",
+				old.GetFailureDomain(), ps.GetFailureDomain())
+		}
+	}
+	if ps.GetCrushRoot() != old.GetCrushRoot() {
+		return errors.Errorf("invalid update: the crush root of a pool cannot be changed from %q to %q, only the failure domain of a replicated pool can be changed", old.GetCrushRoot(), ps.GetCrushRoot())
+	}
+	if ps.GetDeviceClass() != old.GetDeviceClass() {
+		return errors.Errorf("invalid update: the device class of a pool cannot be changed from %q to %q, only the failure domain of a replicated pool can be changed", old.GetDeviceClass(), ps.GetDeviceClass())
+	}
+	if (ps.Replicated.HybridStorage == nil) != (old.Replicated.HybridStorage == nil) ||
+		(old.Replicated.HybridStorage != nil && *ps.Replicated.HybridStorage != *old.Replicated.HybridStorage) {
+		return errors.New("invalid update: replicated.hybridStorage of a pool cannot be changed, only the failure domain of a replicated pool can be changed")
+	}
+	return nil
+}
+
 func (p *CephBlockPool) ValidateDelete() error {
 	return nil
 }
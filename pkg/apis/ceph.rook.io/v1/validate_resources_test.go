@@ -1,9 +1,16 @@
 package v1
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"testing"
 )
 
 func TestCephClusterValidateCreate(t *testing.T) {
@@ -13,17 +20,69 @@ func TestCephClusterValidateCreate(t *testing.T) {
 		},
 		Spec: ClusterSpec{
 			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
 		},
 	}
 	err := c.ValidateCreate()
 	assert.NoError(t, err)
 	c.Spec.External.Enable = true
+	c.Spec.Dashboard = DashboardSpec{Enabled: true}
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+
+	// the metrics of the mgrs of an external cluster are exposed with the monitoring settings
+	c.Spec.Dashboard = DashboardSpec{}
+	c.Spec.Mon = MonSpec{}
 	c.Spec.Monitoring = MonitoringSpec{
-		Enabled:        true,
-		RulesNamespace: "rook-ceph",
+		Enabled:              true,
+		ExternalMgrEndpoints: []v1.EndpointAddress{{IP: "192.168.0.2"}},
+	}
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
+	c.Spec.External.Enable = false
+	c.Spec.Mon = MonSpec{Count: 3}
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "externalMgrEndpoints")
+}
+
+func TestValidateRulesNamespace(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph",
+			Namespace: "rook-ceph",
+		},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Monitoring:      MonitoringSpec{Enabled: true},
+		},
 	}
+
+	// the namespace of the cluster is used by default
+	err := c.ValidateCreate()
+	assert.NoError(t, err)
+
+	// matching namespace
+	c.Spec.Monitoring.RulesNamespace = "rook-ceph"
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
+
+	// mismatching namespace
+	c.Spec.Monitoring.RulesNamespace = "other"
 	err = c.ValidateCreate()
 	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"other"`)
+	err = c.ValidateUpdate(c.DeepCopy())
+	assert.Error(t, err)
+
+	// allowed namespace
+	AllowedRulesNamespaces = []string{"monitoring", "other"}
+	defer func() { AllowedRulesNamespaces = []string{} }()
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
 }
 
 func TestValidatePoolSpec(t *testing.T) {
@@ -32,6 +91,7 @@ func TestValidatePoolSpec(t *testing.T) {
 			Name: "ec-pool",
 		},
 		Spec: PoolSpec{
+			FailureDomain: "host",
 			ErasureCoded: ErasureCodedSpec{
 				CodingChunks: 1,
 				DataChunks:   2,
@@ -41,9 +101,175 @@ func TestValidatePoolSpec(t *testing.T) {
 	err := ValidatePoolSpecs(p.Spec)
 	assert.NoError(t, err)
 
+	// the failure domain is required for erasure coded pools
+	p.Spec.FailureDomain = ""
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failuredomain")
+	p.Spec.FailureDomain = "host"
+
+	// replicated pools keep the default failure domain
+	replicated := PoolSpec{Replicated: ReplicatedSpec{Size: 3}}
+	err = ValidatePoolSpecs(replicated)
+	assert.NoError(t, err)
+
 	p.Spec.ErasureCoded.DataChunks = 1
 	err = ValidatePoolSpecs(p.Spec)
 	assert.Error(t, err)
+
+	// coding chunks equal to data chunks
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CodingChunks = 2
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "(2)")
+
+	// coding chunks greater than data chunks
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CodingChunks = 3
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "(3)")
+
+	// coding chunks less than data chunks
+	p.Spec.ErasureCoded.DataChunks = 3
+	p.Spec.ErasureCoded.CodingChunks = 2
+	err = ValidatePoolSpecs(p.Spec)
+	assert.NoError(t, err)
+
+	// the failure domain can be set for the chunks only
+	p.Spec.FailureDomain = ""
+	p.Spec.ErasureCoded.FailureDomain = "host"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.NoError(t, err)
+
+	// the plugin must support the technique and its coding chunks
+	p.Spec.ErasureCoded.Plugin = "isa"
+	p.Spec.ErasureCoded.Technique = "cauchy"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.NoError(t, err)
+	p.Spec.ErasureCoded.Technique = "liberation"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not support")
+	p.Spec.ErasureCoded.Plugin = "jerasure"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.NoError(t, err)
+	p.Spec.ErasureCoded.CodingChunks = 1
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires 2 coding chunks")
+	p.Spec.ErasureCoded.Plugin = "shec"
+	p.Spec.ErasureCoded.Technique = ""
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unrecognized erasure code plugin")
+	p.Spec.ErasureCoded.Plugin = ""
+	p.Spec.ErasureCoded.Technique = "cauchy_good"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.Error(t, err)
+	p.Spec.ErasureCoded = ErasureCodedSpec{DataChunks: 3, CodingChunks: 2, Plugin: "clay"}
+	p.Spec.FailureDomain = "host"
+	err = ValidatePoolSpecs(p.Spec)
+	assert.NoError(t, err)
+
+	// replicated pools may rely on the default failure domain
+	rp := PoolSpec{Replicated: ReplicatedSpec{Size: 3}}
+	err = ValidatePoolSpecs(rp)
+	assert.NoError(t, err)
+	rp.ErasureCoded.DeviceClass = "ssd"
+	err = ValidatePoolSpecs(rp)
+	assert.Error(t, err)
+
+	// a hybrid pool needs two device classes for its replicas
+	rp = PoolSpec{Replicated: ReplicatedSpec{Size: 3, HybridStorage: &HybridStorageSpec{PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}}
+	assert.NoError(t, ValidatePoolSpecs(rp))
+	rp.DeviceClass = "ssd"
+	assert.Error(t, ValidatePoolSpecs(rp))
+	rp.DeviceClass = ""
+	rp.Replicated.HybridStorage.SecondaryDeviceClass = "ssd"
+	assert.Error(t, ValidatePoolSpecs(rp))
+	rp.Replicated.HybridStorage.SecondaryDeviceClass = ""
+	assert.Error(t, ValidatePoolSpecs(rp))
+	rp.Replicated.HybridStorage.SecondaryDeviceClass = "hdd"
+	rp.Replicated.Size = 1
+	assert.Error(t, ValidatePoolSpecs(rp))
+
+	// a safe replica size is required at creation
+	bp := &CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool"},
+		Spec:       PoolSpec{Replicated: ReplicatedSpec{Size: 1, RequireSafeReplicaSize: true}},
+	}
+	err = bp.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requireSafeReplicaSize")
+	bp.Spec.Replicated.Size = 3
+	err = bp.ValidateCreate()
+	assert.NoError(t, err)
+
+	// an unsafe replica size is allowed when explicitly requested
+	bp.Spec.Replicated = ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}
+	err = bp.ValidateCreate()
+	assert.NoError(t, err)
+
+	// negative values cannot be unmarshalled into the unsigned chunk counts
+	err = json.Unmarshal([]byte(`{"erasureCoded":{"dataChunks":-3,"codingChunks":2}}`), &p.Spec)
+	assert.Error(t, err)
+}
+
+func TestValidatePoolAutoscaling(t *testing.T) {
+	p := PoolSpec{Replicated: ReplicatedSpec{Size: 3}, TargetSizeRatio: 0.2, PgAutoscaleMode: "on"}
+	assert.NoError(t, ValidatePoolSpecs(p))
+
+	// the pg count is managed by the autoscaler
+	p.Parameters = map[string]string{"pg_num": "128"}
+	assert.Error(t, ValidatePoolSpecs(p))
+	p.PgAutoscaleMode = "off"
+	assert.NoError(t, ValidatePoolSpecs(p))
+
+	// the mode is set once
+	p.Parameters["pg_autoscale_mode"] = "on"
+	assert.Error(t, ValidatePoolSpecs(p))
+	p.Parameters = map[string]string{"pg_autoscale_mode": "on", "pgp_num": "128"}
+	p.PgAutoscaleMode = ""
+	assert.Error(t, ValidatePoolSpecs(p))
+	p.Parameters = nil
+
+	p.PgAutoscaleMode = "auto"
+	assert.Error(t, ValidatePoolSpecs(p))
+	p.PgAutoscaleMode = ""
+	p.TargetSizeRatio = -1
+	assert.Error(t, ValidatePoolSpecs(p))
+}
+
+func TestValidatePoolName(t *testing.T) {
+	p := &CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "replicapool",
+		},
+		Spec: PoolSpec{
+			Replicated: ReplicatedSpec{Size: 3},
+		},
+	}
+	err := p.ValidateCreate()
+	assert.NoError(t, err)
+
+	// ceph does not limit the length of the names
+	p.Name = strings.Repeat("a", 64)
+	err = p.ValidateCreate()
+	assert.NoError(t, err)
+
+	// disallowed characters
+	p.Name = "replica/pool@snap"
+	err = p.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"replica/pool@snap"`)
+
+	// must start with a letter or a digit
+	err = ValidatePoolName("-replicapool")
+	assert.Error(t, err)
+	err = ValidatePoolName("replica.pool_1-a")
+	assert.NoError(t, err)
 }
 
 func TestCephBlockPoolValidateUpdate(t *testing.T) {
@@ -60,6 +286,31 @@ func TestCephBlockPoolValidateUpdate(t *testing.T) {
 	up.Spec.ErasureCoded.CodingChunks = 1
 	err := up.ValidateUpdate(p)
 	assert.Error(t, err)
+
+	// the failure domain of a replicated pool can be changed
+	up = p.DeepCopy()
+	up.Spec.FailureDomain = "rack"
+	assert.NoError(t, up.ValidateUpdate(p))
+	up.Spec.CrushRoot = "other"
+	err = up.ValidateUpdate(p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "crush root")
+
+	// the placement of an erasure coded pool is set by its erasure code profile
+	ec := &CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "ec-pool"},
+		Spec:       PoolSpec{FailureDomain: "host", ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}},
+	}
+	up = ec.DeepCopy()
+	up.Spec.FailureDomain = "rack"
+	err = up.ValidateUpdate(ec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failure domain of an erasure coded pool")
+	up = ec.DeepCopy()
+	up.Spec.ErasureCoded.DataChunks = 4
+	err = up.ValidateUpdate(ec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "data and coding chunks")
 }
 
 func TestCephClusterValidateUpdate(t *testing.T) {
@@ -69,6 +320,8 @@ func TestCephClusterValidateUpdate(t *testing.T) {
 		},
 		Spec: ClusterSpec{
 			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
 		},
 	}
 	err := c.ValidateCreate()
@@ -79,4 +332,1184 @@ func TestCephClusterValidateUpdate(t *testing.T) {
 	uc.Spec.DataDirHostPath = "var/rook"
 	err = uc.ValidateUpdate(c)
 	assert.Error(t, err)
+
+	// the errors name the changed fields
+	uc.Spec.Network.HostNetwork = true
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.dataDirHostPath: Forbidden")
+	assert.Contains(t, err.Error(), "spec.network.hostNetwork: Forbidden")
+
+	// the ip family is only set at creation, the dual stack can be enabled
+	uc = c.DeepCopy()
+	uc.Spec.Network.DualStack = true
+	assert.NoError(t, uc.ValidateUpdate(c))
+	uc.Spec.Network.IPFamily = IPv6
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.network.ipFamily: Forbidden")
+
+	// the mons cannot move to the host network, the other daemons can
+	uc = c.DeepCopy()
+	uc.Spec.Network.DaemonHostNetwork = map[rookv1.KeyType]bool{KeyOSD: true, KeyRgw: false}
+	assert.NoError(t, uc.ValidateUpdate(c))
+	uc.Spec.Network.DaemonHostNetwork[KeyMon] = true
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.network.daemonHostNetwork[mon]: Forbidden")
+
+	// the stretch cluster is only set at creation
+	uc = c.DeepCopy()
+	uc.Spec.Mon.StretchCluster = &StretchClusterSpec{Zones: []StretchClusterZoneSpec{{Name: "a"}}}
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+
+	// the mon count cannot drop below the quorum of the previous mons
+	c5 := c.DeepCopy()
+	c5.Spec.Mon.Count = 5
+	uc = c5.DeepCopy()
+	uc.Spec.Mon.Count = 3
+	assert.NoError(t, uc.ValidateUpdate(c5))
+	uc.Spec.Mon.Count = 1
+	err = uc.ValidateUpdate(c5)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.mon.count: Invalid value: 1")
+}
+
+func TestCephClusterValidateRemovedDevices(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rook-ceph",
+		},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage: rookv1.StorageScopeSpec{
+				Nodes: []rookv1.Node{
+					{Name: "a", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}, {Name: "sdc"}}}},
+					{Name: "b", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}}}},
+				},
+			},
+		},
+	}
+	uc := c.DeepCopy()
+	uc.Spec.Storage.Nodes[0].Devices = uc.Spec.Storage.Nodes[0].Devices[:1]
+	uc.Spec.Storage.Nodes = uc.Spec.Storage.Nodes[:1]
+
+	// the devices can be removed before the osds are created
+	assert.NoError(t, uc.ValidateUpdate(c))
+
+	// the devices cannot be removed while the cluster has osds
+	c.Status.DaemonHealth = &DaemonHealthStatus{OSD: OSDHealthStatus{Count: 3}}
+	err := uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "spec.storage.nodes[0].devices[1]: Forbidden")
+	assert.Contains(t, err.Error(), "spec.storage.nodes[1]: Forbidden")
+
+	// the devices can be removed along with their osds
+	uc.Annotations = map[string]string{removeOSDsAnnotation: "1,2"}
+	assert.NoError(t, uc.ValidateUpdate(c))
+}
+
+func TestValidateTimeouts(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "rook-ceph",
+		},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			DisruptionManagement: DisruptionManagementSpec{
+				OSDMaintenanceTimeout: 30,
+			},
+			HealthCheck: CephClusterHealthCheckSpec{
+				DaemonHealth: DaemonHealthSpec{
+					Monitor: MonHealthCheckSpec{HealthCheckSpec: HealthCheckSpec{Interval: "45s", Timeout: "600s"}},
+				},
+			},
+		},
+	}
+	// consistent timeouts
+	err := c.ValidateCreate()
+	assert.NoError(t, err)
+
+	// negative maintenance timeout
+	c.Spec.DisruptionManagement.OSDMaintenanceTimeout = -1
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "osdMaintenanceTimeout")
+	c.Spec.DisruptionManagement.OSDMaintenanceTimeout = 30
+
+	// the same rules apply on update
+	uc := c.DeepCopy()
+	uc.Spec.DisruptionManagement.OSDMaintenanceTimeout = -1
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+
+	// timeouts inconsistent with the intervals are only warnings
+	c.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "10s"
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
+	c.Spec.HealthCheck.CommandTimeout = "60s"
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
+
+	// invalid durations
+	c.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "ten minutes"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	c.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "-10s"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+
+	// the invalid durations admitted before are only rejected on update once they are changed
+	c.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "ten minutes"
+	uc = c.DeepCopy()
+	uc.Spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Interval = "120s"
+	err = uc.ValidateUpdate(c)
+	assert.NoError(t, err)
+	uc.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "eleven minutes"
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	uc.Spec.HealthCheck.DaemonHealth.Monitor.Timeout = "600s"
+	uc.Spec.HealthCheck.DaemonHealth.Status.Interval = "one minute"
+	err = uc.ValidateUpdate(c)
+	assert.Error(t, err)
+}
+
+func TestValidateMonCount(t *testing.T) {
+	tests := []struct {
+		name                 string
+		count                int
+		allowMultiplePerNode bool
+		hostNetwork          bool
+		wantErr              bool
+	}{
+		{"zero", 0, false, false, false},
+		{"negative", -1, false, false, true},
+		{"two", 2, false, false, true},
+		{"three", 3, false, false, false},
+		{"four", 4, false, false, true},
+		{"nine", 9, false, false, false},
+		{"eleven", 11, false, false, true},
+		{"two-multiple-per-node", 2, true, false, false},
+		{"two-multiple-per-node-host-network", 2, true, true, true},
+		{"zero-multiple-per-node", 0, true, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CephCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+				Spec: ClusterSpec{
+					DataDirHostPath: "/var/lib/rook",
+					Mon:             MonSpec{Count: tt.count, AllowMultiplePerNode: tt.allowMultiplePerNode},
+					CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+				},
+			}
+			if tt.hostNetwork {
+				c.Spec.Network.HostNetwork = true
+			}
+			err := c.ValidateCreate()
+			assert.Equal(t, tt.wantErr, err != nil, "create: %v", err)
+			err = c.ValidateUpdate(c.DeepCopy())
+			assert.Equal(t, tt.wantErr, err != nil, "update: %v", err)
+		})
+	}
+
+	// the mons may use the host network when the other daemons do not, and the other way around
+	spec := ClusterSpec{Mon: MonSpec{Count: 2, AllowMultiplePerNode: true}}
+	spec.Network.DaemonHostNetwork = map[rookv1.KeyType]bool{KeyMon: true}
+	assert.Error(t, ValidateMonCount(spec))
+	spec.Network.HostNetwork = true
+	spec.Network.DaemonHostNetwork[KeyMon] = false
+	assert.NoError(t, ValidateMonCount(spec))
+
+	// external clusters do not manage the mons
+	c := &CephCluster{Spec: ClusterSpec{External: ExternalSpec{Enable: true}}}
+	assert.NoError(t, c.ValidateCreate())
+}
+
+func TestValidateStretchCluster(t *testing.T) {
+	zones := func(arbiters ...bool) []StretchClusterZoneSpec {
+		z := []StretchClusterZoneSpec{}
+		for i, arbiter := range arbiters {
+			z = append(z, StretchClusterZoneSpec{Name: fmt.Sprintf("zone%d", i), Arbiter: arbiter})
+		}
+		return z
+	}
+	tests := []struct {
+		name    string
+		count   int
+		image   string
+		zones   []StretchClusterZoneSpec
+		wantErr bool
+	}{
+		{"valid", 5, "ceph/ceph:v16.1.0", zones(false, false, true), false},
+		{"untagged-image", 5, "ceph/ceph", zones(true, false, false), false},
+		{"two-zones", 5, "ceph/ceph:v16.1.0", zones(false, true), true},
+		{"no-arbiter", 5, "ceph/ceph:v16.1.0", zones(false, false, false), true},
+		{"two-arbiters", 5, "ceph/ceph:v16.1.0", zones(false, true, true), true},
+		{"three-mons", 3, "ceph/ceph:v16.1.0", zones(false, false, true), true},
+		{"octopus", 5, "ceph/ceph:v15.2.4", zones(false, false, true), true},
+		{"duplicate-zone", 5, "ceph/ceph:v16.1.0", []StretchClusterZoneSpec{{Name: "a"}, {Name: "a"}, {Name: "b", Arbiter: true}}, true},
+		{"unnamed-zone", 5, "ceph/ceph:v16.1.0", []StretchClusterZoneSpec{{Name: "a"}, {}, {Name: "b", Arbiter: true}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := ClusterSpec{
+				Mon:         MonSpec{Count: tt.count, StretchCluster: &StretchClusterSpec{Zones: tt.zones}},
+				CephVersion: CephVersionSpec{Image: tt.image},
+			}
+			err := validateStretchCluster(spec)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+
+	// the zones are not validated when the cluster is not stretched
+	assert.NoError(t, validateStretchCluster(ClusterSpec{Mon: MonSpec{Count: 3, StretchCluster: &StretchClusterSpec{}}}))
+}
+
+func TestValidateMonZones(t *testing.T) {
+	stretchCluster := &StretchClusterSpec{Zones: []StretchClusterZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c", Arbiter: true}}}
+	tests := []struct {
+		name    string
+		mon     MonSpec
+		wantErr bool
+	}{
+		{"no-zones", MonSpec{Count: 3}, false},
+		{"valid", MonSpec{Count: 3, Zones: []MonZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c"}}}, false},
+		{"fewer-zones", MonSpec{Count: 5, Zones: []MonZoneSpec{{Name: "a"}, {Name: "b"}}}, false},
+		{"duplicate-zone", MonSpec{Count: 3, Zones: []MonZoneSpec{{Name: "a"}, {Name: "a"}}}, true},
+		{"unnamed-zone", MonSpec{Count: 3, Zones: []MonZoneSpec{{Name: "a"}, {}}}, true},
+		{"stretch-cluster", MonSpec{Count: 5, Zones: []MonZoneSpec{{Name: "a"}}, StretchCluster: stretchCluster}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateMonZones(ClusterSpec{Mon: tt.mon})
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+}
+
+func TestMonSpecZones(t *testing.T) {
+	s := &MonSpec{}
+	assert.False(t, s.HasZones())
+	assert.Equal(t, "topology.kubernetes.io/zone", s.GetZoneLabel())
+
+	s.Zones = []MonZoneSpec{{Name: "a"}}
+	s.FailureDomainLabel = "topology.rook.io/rack"
+	assert.True(t, s.HasZones())
+	assert.Equal(t, "topology.rook.io/rack", s.GetZoneLabel())
+
+	s = &MonSpec{StretchCluster: &StretchClusterSpec{Zones: []StretchClusterZoneSpec{{Name: "a"}}, FailureDomainLabel: "topology.rook.io/datacenter"}}
+	assert.True(t, s.HasZones())
+	assert.Equal(t, "topology.rook.io/datacenter", s.GetZoneLabel())
+}
+
+func TestValidateConnections(t *testing.T) {
+	connections := func(encryption, compression bool) *ConnectionsSpec {
+		return &ConnectionsSpec{Encryption: &EncryptionSpec{Enabled: encryption}, Compression: &CompressionSpec{Enabled: compression}}
+	}
+	tests := []struct {
+		name        string
+		image       string
+		connections *ConnectionsSpec
+		wantErr     bool
+	}{
+		{"default", "ceph/ceph:v15.2.4", nil, false},
+		{"encryption", "ceph/ceph:v15.2.4", connections(true, false), false},
+		{"compression", "ceph/ceph:v17.2.0", connections(true, true), false},
+		{"untagged-compression", "ceph/ceph", connections(false, true), false},
+		{"pacific-compression", "ceph/ceph:v16.2.0", connections(false, true), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := ClusterSpec{
+				Network:     NetworkSpec{Connections: tt.connections},
+				CephVersion: CephVersionSpec{Image: tt.image},
+			}
+			err := validateConnections(spec)
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+}
+
+func TestValidateDaemonHostNetwork(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Network:         NetworkSpec{DaemonHostNetwork: map[rookv1.KeyType]bool{KeyMon: true, KeyOSD: true, KeyRgw: false}},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Network.DaemonHostNetwork[KeyCleanup] = true
+	assert.Error(t, c.ValidateCreate())
+	delete(c.Spec.Network.DaemonHostNetwork, KeyCleanup)
+
+	// the mons on the host network cannot share the nodes
+	c.Spec.Mon.AllowMultiplePerNode = true
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Network.DaemonHostNetwork[KeyMon] = false
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Network.Provider = "multus"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateBackup(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Backup: BackupSpec{
+				Enabled:               true,
+				Schedule:              "0 2 * * *",
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "ceph-backup"},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Backup.Schedule = "every day"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Backup.Schedule = "@weekly"
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Backup.Retain = -1
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Backup.Retain = 0
+
+	// either a PVC or a bucket
+	c.Spec.Backup.Bucket = &BackupBucketSpec{Endpoint: "https://s3.example.com", Name: "backups", SecretName: "backup-keys"}
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Backup.PersistentVolumeClaim = nil
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Backup.Bucket.Endpoint = "s3.example.com"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Backup.Bucket.Endpoint = "http://rook-ceph-rgw-my-store:80"
+	assert.NoError(t, c.ValidateCreate())
+	c.Spec.Backup.Bucket.SecretName = ""
+	assert.Error(t, c.ValidateCreate())
+
+	// the settings are not validated when disabled
+	c.Spec.Backup.Enabled = false
+	assert.NoError(t, c.ValidateCreate())
+}
+
+func TestValidateTelemetry(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Telemetry:       &TelemetrySpec{Enabled: true, Channels: []string{TelemetryChannelBasic, TelemetryChannelIdent}},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Telemetry.Channels = []string{"perf"}
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Telemetry.Channels = nil
+
+	// the telemetry module is configured with the telemetry settings only
+	c.Spec.Mgr.Modules = []Module{{Name: "telemetry", Enabled: true}}
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Telemetry = nil
+	assert.NoError(t, c.ValidateCreate())
+}
+
+func TestValidateDeclaredCephVersion(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "registry.example.com/ceph/ceph:v15.2.4", SkipImageVersionDetection: true},
+		},
+	}
+	// the version must be declared to skip the detection
+	assert.Error(t, c.ValidateCreate())
+
+	c.Spec.CephVersion.Version = "15.2.4"
+	assert.NoError(t, c.ValidateCreate())
+	c.Spec.CephVersion.Version = "15.2.4-0"
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.CephVersion.Version = "v15.2.4"
+	assert.Error(t, c.ValidateCreate())
+	// not the major version of the image tag
+	c.Spec.CephVersion.Version = "14.2.11"
+	assert.Error(t, c.ValidateCreate())
+	// the major version is not checked without a version tag
+	c.Spec.CephVersion.Image = "registry.example.com/ceph/ceph:latest-octopus"
+	assert.NoError(t, c.ValidateCreate())
+}
+
+func TestValidateUpdateStrategy(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			UpdateStrategy:  &UpdateStrategySpec{Type: UpdateStrategyWaves, FailureDomain: "rack", WaveSize: 2, WaveTimeout: "15m"},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.UpdateStrategy.Type = "AllAtOnce"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.UpdateStrategy.Type = UpdateStrategyOneByOne
+	c.Spec.UpdateStrategy.WaveSize = -1
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.UpdateStrategy.WaveSize = 0
+	c.Spec.UpdateStrategy.WaveTimeout = "0s"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateDriftPolicy(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			DriftPolicy:     DriftPolicyReport,
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.DriftPolicy = "ignore"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateOSDPrepare(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage: rookv1.StorageScopeSpec{
+				Prepare: &rookv1.PrepareSpec{MaxConcurrentJobs: 10, MaxConcurrentJobsPerNode: 1, BatchDelay: "30s"},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Storage.Prepare.MaxConcurrentJobs = -1
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.Prepare.MaxConcurrentJobs = 0
+	c.Spec.Storage.Prepare.MaxConcurrentJobsPerNode = -1
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.Prepare.MaxConcurrentJobsPerNode = 0
+	c.Spec.Storage.Prepare.BatchDelay = "30"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.Prepare.BatchDelay = "-30s"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateRemovedNodeGracePeriod(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage:         rookv1.StorageScopeSpec{UseAllNodes: true, RemovedNodeGracePeriod: "24h"},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Storage.RemovedNodeGracePeriod = "24"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.RemovedNodeGracePeriod = "0s"
+	assert.Error(t, c.ValidateCreate())
+
+	// the nodes listed in the spec are not removed with their kubernetes node
+	c.Spec.Storage.RemovedNodeGracePeriod = "24h"
+	c.Spec.Storage.UseAllNodes = false
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateServiceSpec(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Dashboard:       DashboardSpec{Enabled: true, Service: &ServiceSpec{Type: v1.ServiceTypeLoadBalancer, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Dashboard.Service.LoadBalancerSourceRanges = []string{"10.0.0.0"}
+	assert.Error(t, c.ValidateCreate())
+
+	// the source ranges only apply to a load balancer
+	assert.NoError(t, validateServiceSpec("gateway:service", nil))
+	assert.NoError(t, validateServiceSpec("gateway:service", &ServiceSpec{Type: v1.ServiceTypeNodePort}))
+	assert.Error(t, validateServiceSpec("gateway:service", &ServiceSpec{Type: v1.ServiceTypeNodePort, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}))
+	assert.Error(t, validateServiceSpec("gateway:service", &ServiceSpec{Type: v1.ServiceTypeExternalName}))
+}
+
+func TestStretchClusterSpec(t *testing.T) {
+	s := &StretchClusterSpec{Zones: []StretchClusterZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c", Arbiter: true}}}
+	assert.Equal(t, "topology.kubernetes.io/zone", s.GetFailureDomainLabel())
+	assert.Equal(t, "zone", s.GetFailureDomainType())
+	assert.Equal(t, "c", s.GetArbiterZone())
+
+	s.FailureDomainLabel = "topology.rook.io/datacenter"
+	assert.Equal(t, "datacenter", s.GetFailureDomainType())
+
+	s.Zones[2].Arbiter = false
+	assert.Equal(t, "", s.GetArbiterZone())
+}
+
+func TestValidateNodesDeviceSelection(t *testing.T) {
+	useAllDevices := true
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage: rookv1.StorageScopeSpec{
+				Nodes: []rookv1.Node{
+					{Name: "node1", Selection: rookv1.Selection{DeviceFilter: "^sd."}},
+					{Name: "node2", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}, {Name: "sdc"}}}},
+				},
+			},
+		},
+	}
+	// a single selector per node
+	err := c.ValidateCreate()
+	assert.NoError(t, err)
+
+	// both a filter and explicit devices
+	c.Spec.Storage.Nodes[1].DeviceFilter = "^sd."
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node2")
+	err = c.ValidateUpdate(c.DeepCopy())
+	assert.Error(t, err)
+	c.Spec.Storage.Nodes[1].DeviceFilter = ""
+
+	// both filters
+	c.Spec.Storage.Nodes[0].DevicePathFilter = "^/dev/disk/by-path/pci-.*"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node1")
+	c.Spec.Storage.Nodes[0].DevicePathFilter = ""
+
+	// all devices along with a filter
+	c.Spec.Storage.Nodes[0].UseAllDevices = &useAllDevices
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	c.Spec.Storage.Nodes[0].UseAllDevices = nil
+
+	// the same device listed twice
+	c.Spec.Storage.Nodes[1].Devices = append(c.Spec.Storage.Nodes[1].Devices, rookv1.Device{Name: "sdb"})
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node2")
+	c.Spec.Storage.Nodes[1].Devices = c.Spec.Storage.Nodes[1].Devices[:2]
+
+	// the size and type filters of the devices
+	c.Spec.Storage.MinSize = "100Gi"
+	c.Spec.Storage.Nodes[0].DeviceType = rookv1.DeviceTypeSSD
+	assert.NoError(t, c.ValidateCreate())
+	c.Spec.Storage.MaxSize = "10Gi"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.MaxSize = ""
+	c.Spec.Storage.Nodes[0].DeviceType = "tape"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node1")
+	c.Spec.Storage.Nodes[0].DeviceType = ""
+
+	// the custom device classes
+	c.Spec.Storage.DeviceClass = "nvme-meta"
+	c.Spec.Storage.Nodes[1].Devices[0].DeviceClass = "ssd"
+	c.Spec.Storage.StorageClassDeviceSets = []rookv1.StorageClassDeviceSet{{Name: "set1", DeviceClass: "hdd_archive"}}
+	assert.NoError(t, c.ValidateCreate())
+	c.Spec.Storage.StorageClassDeviceSets[0].DeviceClass = "hdd archive"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "set1")
+	c.Spec.Storage.StorageClassDeviceSets = nil
+	c.Spec.Storage.Nodes[1].Devices[0].DeviceClass = "-ssd"
+	err = c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "node2")
+}
+
+func TestValidateCephConfig(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			CephConfig: map[string]map[string]string{
+				"global": {"osd_pool_default_size": "3"},
+				"osd.0":  {"osd_max_backfills": "2"},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.CephConfig["global"][""] = "1"
+	assert.Error(t, c.ValidateCreate())
+	delete(c.Spec.CephConfig["global"], "")
+
+	c.Spec.CephConfig[" "] = map[string]string{"debug_ms": "1"}
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestScrubbingConfig(t *testing.T) {
+	options, err := ScrubbingConfig(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, options)
+
+	beginHour, endHour, beginDay, endDay := 1, 5, 0, 6
+	scrubbing := &rookv1.ScrubbingSpec{
+		BeginHour: &beginHour, EndHour: &endHour, BeginWeekDay: &beginDay, EndWeekDay: &endDay,
+		MinInterval: "24h", MaxInterval: "168h", DeepInterval: "336h", MaxScrubs: 2,
+	}
+	options, err = ScrubbingConfig(scrubbing)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"osd_scrub_begin_hour":     "1",
+		"osd_scrub_end_hour":       "5",
+		"osd_scrub_begin_week_day": "0",
+		"osd_scrub_end_week_day":   "6",
+		"osd_scrub_min_interval":   "86400",
+		"osd_scrub_max_interval":   "604800",
+		"osd_deep_scrub_interval":  "1209600",
+		"osd_max_scrubs":           "2",
+	}, options)
+
+	// invalid schedules
+	endHour = 24
+	_, err = ScrubbingConfig(scrubbing)
+	assert.Error(t, err)
+	endHour = 5
+	scrubbing.EndWeekDay = nil
+	_, err = ScrubbingConfig(scrubbing)
+	assert.Error(t, err)
+	scrubbing.EndWeekDay = &endDay
+	scrubbing.MinInterval = "720h"
+	_, err = ScrubbingConfig(scrubbing)
+	assert.Error(t, err)
+	scrubbing.MinInterval = "one day"
+	_, err = ScrubbingConfig(scrubbing)
+	assert.Error(t, err)
+	scrubbing.MinInterval = "24h"
+	scrubbing.MaxScrubs = -1
+	_, err = ScrubbingConfig(scrubbing)
+	assert.Error(t, err)
+}
+
+func TestCompressionConfig(t *testing.T) {
+	options, err := CompressionConfig(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, options)
+
+	options, err = CompressionConfig(&rookv1.BluestoreCompressionSpec{Mode: "passive", Algorithm: "snappy"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"bluestore_compression_mode": "passive", "bluestore_compression_algorithm": "snappy"}, options)
+
+	_, err = CompressionConfig(&rookv1.BluestoreCompressionSpec{Mode: "always"})
+	assert.Error(t, err)
+	_, err = CompressionConfig(&rookv1.BluestoreCompressionSpec{Algorithm: "gzip"})
+	assert.Error(t, err)
+
+	// the options cannot be set in the cephConfig as well
+	spec := ClusterSpec{
+		Storage:    rookv1.StorageScopeSpec{Compression: &rookv1.BluestoreCompressionSpec{Mode: "aggressive"}},
+		CephConfig: map[string]map[string]string{"osd": {"bluestore_compression_algorithm": "zstd"}},
+	}
+	assert.NoError(t, validateStorageCompression(spec))
+	spec.CephConfig["osd"]["bluestore_compression_mode"] = "none"
+	assert.Error(t, validateStorageCompression(spec))
+}
+
+func TestValidateScrubbing(t *testing.T) {
+	maxInterval := "168h"
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage:         rookv1.StorageScopeSpec{Scrubbing: &rookv1.ScrubbingSpec{MaxInterval: maxInterval}},
+			CephConfig:      map[string]map[string]string{"osd": {"osd_max_backfills": "2"}},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	// the options of the schedule cannot be set in the cephConfig as well
+	c.Spec.CephConfig["global"] = map[string]string{"osd_scrub_max_interval": "86400"}
+	assert.Error(t, c.ValidateCreate())
+	delete(c.Spec.CephConfig, "global")
+
+	c.Spec.Storage.Scrubbing.MaxInterval = "-1h"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateLogCollector(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			LogCollector:    LogCollectorSpec{Enabled: true},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.LogCollector.Periodicity = "weekly"
+	maxLogSize := resource.MustParse("500M")
+	c.Spec.LogCollector.MaxLogSize = &maxLogSize
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.LogCollector.Periodicity = "1h"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.LogCollector.Periodicity = "daily"
+
+	maxLogSize = resource.MustParse("0")
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateBalancer(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Mgr:             MgrSpec{Balancer: &BalancerSpec{Mode: BalancerModeCrushCompat, MaxMisplaced: 0.02}},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Mgr.Balancer.Mode = "fast"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Mgr.Balancer.Mode = BalancerModeOff
+
+	c.Spec.Mgr.Balancer.MaxMisplaced = 1.5
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Mgr.Balancer.MaxMisplaced = 0
+
+	// the balancer is configured in a single place
+	c.Spec.Mgr.Modules = []Module{{Name: "balancer", Enabled: true}}
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Mgr.Modules = nil
+
+	// the balancer of an external cluster is not managed
+	c.Spec.External.Enable = true
+	c.Spec.Mon = MonSpec{}
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateReconcileStrategy(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath:   "/var/lib/rook",
+			Mon:               MonSpec{Count: 3},
+			CephVersion:       CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			ReconcileStrategy: ReconcileStrategyPaused,
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.ReconcileStrategy = "stopped"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidateHealthRemediation(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			HealthCheck: CephClusterHealthCheckSpec{
+				Remediation: HealthRemediationSpec{RepairInconsistentPGs: true, RestartCrashedDaemons: 3, MinInterval: "30m"},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.HealthCheck.Remediation.RestartCrashedDaemons = -1
+	assert.Error(t, c.ValidateCreate())
+
+	c.Spec.HealthCheck.Remediation.RestartCrashedDaemons = 0
+	c.Spec.HealthCheck.Remediation.MinInterval = "soon"
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.HealthCheck.Remediation.MinInterval = "0s"
+	assert.Error(t, c.ValidateCreate())
+}
+
+func TestValidatePlacements(t *testing.T) {
+	spread := v1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1.DoNotSchedule}
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Placement:       rookv1.PlacementSpec{KeyMon: {TopologySpreadConstraints: []v1.TopologySpreadConstraint{spread}}},
+			Storage: rookv1.StorageScopeSpec{
+				StorageClassDeviceSets: []rookv1.StorageClassDeviceSet{{Name: "set1", PreparePlacement: &rookv1.Placement{}}},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	c.Spec.Placement[KeyMon].TopologySpreadConstraints[0].MaxSkew = 0
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Placement[KeyMon].TopologySpreadConstraints[0].MaxSkew = 1
+
+	c.Spec.Storage.StorageClassDeviceSets[0].PreparePlacement.TopologySpreadConstraints = []v1.TopologySpreadConstraint{
+		{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: "Never"},
+	}
+	assert.Error(t, c.ValidateCreate())
+	c.Spec.Storage.StorageClassDeviceSets[0].PreparePlacement.TopologySpreadConstraints[0].WhenUnsatisfiable = v1.ScheduleAnyway
+	assert.NoError(t, c.ValidateCreate())
+
+	// the gateways of an object store are spread across the zones
+	s := &CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "store"},
+		Spec: ObjectStoreSpec{
+			Gateway: GatewaySpec{Port: 80, Instances: 3, Placement: rookv1.Placement{TopologySpreadConstraints: []v1.TopologySpreadConstraint{spread}}},
+		},
+	}
+	assert.NoError(t, s.ValidateCreate())
+	s.Spec.Gateway.Placement.TopologySpreadConstraints[0].TopologyKey = ""
+	assert.Error(t, s.ValidateCreate())
+}
+
+func TestGetPrepareOSDPlacement(t *testing.T) {
+	osdToleration := v1.Toleration{Key: "storage", Operator: v1.TolerationOpExists}
+	p := rookv1.PlacementSpec{KeyOSD: {Tolerations: []v1.Toleration{osdToleration}}}
+	assert.Equal(t, []v1.Toleration{osdToleration}, GetPrepareOSDPlacement(p).Tolerations)
+
+	// the prepare placement replaces the placement of the osds
+	prepareToleration := v1.Toleration{Key: "prepare", Operator: v1.TolerationOpExists}
+	p[KeyOSDPrepare] = rookv1.Placement{Tolerations: []v1.Toleration{prepareToleration}}
+	assert.Equal(t, []v1.Toleration{prepareToleration}, GetPrepareOSDPlacement(p).Tolerations)
+}
+
+func TestValidateCephImage(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+		},
+	}
+
+	// empty image
+	err := c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "cephVersion:image")
+
+	// garbage images
+	for _, image := range []string{"ceph/ceph:v15.2.4 ", "Ceph/Ceph", "ceph/ceph:", "ceph//ceph", "ceph/ceph@sha256:xyz"} {
+		c.Spec.CephVersion.Image = image
+		err = c.ValidateCreate()
+		assert.Error(t, err, image)
+	}
+
+	// valid images
+	for _, image := range []string{"ceph/ceph:v15.2.4", "quay.io/ceph/ceph:v15", "localhost:5000/ceph/daemon-base:latest-master",
+		"ceph/ceph@sha256:a4b3d7f6c5e0c4a7a2b9d0c2b1f5e8e3d4c6b7a8f9e0d1c2b3a4f5e6d7c8b9a0"} {
+		c.Spec.CephVersion.Image = image
+		err = c.ValidateCreate()
+		assert.NoError(t, err, image)
+	}
+
+	// external clusters do not run ceph images
+	c.Spec.CephVersion.Image = ""
+	c.Spec.Mon = MonSpec{}
+	c.Spec.External.Enable = true
+	err = c.ValidateCreate()
+	assert.NoError(t, err)
+}
+
+func TestValidateCephDowngrade(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+		},
+	}
+
+	// upgrade
+	uc := c.DeepCopy()
+	uc.Spec.CephVersion.Image = "ceph/ceph:v16.2.0"
+	assert.NoError(t, uc.ValidateUpdate(c))
+
+	// downgrade is only a warning by default
+	uc.Spec.CephVersion.Image = "ceph/ceph:v14.2.10"
+	assert.NoError(t, uc.ValidateUpdate(c))
+
+	// downgrade is rejected when required
+	RejectCephDowngrades = true
+	defer func() { RejectCephDowngrades = false }()
+	err := uc.ValidateUpdate(c)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "downgrade")
+
+	// a minor version change or an unversioned tag is not a downgrade
+	uc.Spec.CephVersion.Image = "ceph/ceph:v15.2.1"
+	assert.NoError(t, uc.ValidateUpdate(c))
+	uc.Spec.CephVersion.Image = "localhost:5000/ceph/ceph:latest-master"
+	assert.NoError(t, uc.ValidateUpdate(c))
+}
+
+func TestImageMajorVersion(t *testing.T) {
+	tests := []struct {
+		image string
+		major int
+		ok    bool
+	}{
+		{"ceph/ceph:v15.2.4", 15, true},
+		{"ceph/ceph:v14", 14, true},
+		{"ceph/ceph:15.2.4-20200630", 15, true},
+		{"localhost:5000/ceph/ceph:v16.1.0", 16, true},
+		{"localhost:5000/ceph/ceph", 0, false},
+		{"ceph/ceph:latest-master", 0, false},
+		{"ceph/ceph", 0, false},
+	}
+	for _, tt := range tests {
+		major, ok := imageMajorVersion(tt.image)
+		assert.Equal(t, tt.ok, ok, tt.image)
+		assert.Equal(t, tt.major, major, tt.image)
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	useAllDevices := false
+	requests := v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")}}
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Storage: rookv1.StorageScopeSpec{
+				Selection: rookv1.Selection{UseAllDevices: &useAllDevices},
+			},
+			Resources: rookv1.ResourceSpec{"mon": requests, "mgr": requests, "osd": requests},
+		},
+	}
+
+	// no device selected, the cluster is admitted with a warning
+	warnings := Warnings(*c)
+	assert.Equal(t, 1, len(warnings))
+	assert.Contains(t, warnings[0], "no OSD will be provisioned")
+	assert.NoError(t, c.ValidateCreate())
+	assert.NoError(t, c.ValidateUpdate(c.DeepCopy()))
+
+	// devices selected at the cluster level
+	c.Spec.Storage.DeviceFilter = "^sd."
+	assert.Equal(t, 0, len(Warnings(*c)))
+	c.Spec.Storage.DeviceFilter = ""
+
+	// devices selected on a node
+	c.Spec.Storage.Nodes = []rookv1.Node{{Name: "node1", Selection: rookv1.Selection{Devices: []rookv1.Device{{Name: "sdb"}}}}}
+	assert.Equal(t, 0, len(Warnings(*c)))
+	c.Spec.Storage.Nodes = nil
+
+	// all devices
+	useAllDevices = true
+	assert.Equal(t, 0, len(Warnings(*c)))
+
+	// external clusters do not provision OSDs
+	useAllDevices = false
+	c.Spec.External.Enable = true
+	assert.Equal(t, 0, len(Warnings(*c)))
+}
+
+func TestValidateResourceLimits(t *testing.T) {
+	c := &CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph"},
+		Spec: ClusterSpec{
+			DataDirHostPath: "/var/lib/rook",
+			Mon:             MonSpec{Count: 3},
+			CephVersion:     CephVersionSpec{Image: "ceph/ceph:v15.2.4"},
+			Resources: rookv1.ResourceSpec{
+				"mon": {Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}},
+				"osd": {Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")}},
+			},
+		},
+	}
+	assert.NoError(t, c.ValidateCreate())
+
+	// the daemons without requests are reported
+	warnings := resourceWarnings(c.Spec)
+	assert.Equal(t, 3, len(warnings))
+	assert.Contains(t, warnings[0], "resources:mon has no requests")
+
+	// an osd limit below the minimum is rejected
+	c.Spec.Resources["osd"] = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}}
+	err := c.ValidateCreate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "resources:osd memory limit \"1Gi\" is below the minimum of \"2Gi\"")
+	assert.Error(t, c.ValidateUpdate(c.DeepCopy()))
+	delete(c.Spec.Resources, "osd")
+
+	// as well as the limit of the osds of a device set
+	c.Spec.Storage.StorageClassDeviceSets = []rookv1.StorageClassDeviceSet{{
+		Name:      "set1",
+		Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")}},
+	}}
+	err = ValidateResourceLimits(c.Spec)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "storageClassDeviceSets:set1")
+	c.Spec.Storage.StorageClassDeviceSets = nil
+
+	// the limits of the mons
+	c.Spec.Resources["mon"] = v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")}}
+	assert.Error(t, ValidateResourceLimits(c.Spec))
+
+	// the limits of the external clusters are not validated
+	c.Spec.External.Enable = true
+	c.Spec.Mon = MonSpec{}
+	assert.NoError(t, c.ValidateCreate())
+}
+
+func TestCephObjectStoreValidate(t *testing.T) {
+	s := &CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "store"},
+		Spec: ObjectStoreSpec{
+			MetadataPool: PoolSpec{Replicated: ReplicatedSpec{Size: 3}},
+			DataPool:     PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}, FailureDomain: "host"},
+			Gateway:      GatewaySpec{Port: 80, Instances: 1},
+		},
+	}
+	assert.NoError(t, s.ValidateCreate())
+
+	// the pools may be empty if they already exist
+	empty := s.DeepCopy()
+	empty.Spec.MetadataPool = PoolSpec{}
+	empty.Spec.DataPool = PoolSpec{}
+	assert.NoError(t, empty.ValidateCreate())
+
+	// the pools of a store in a zone are the pools of the zone
+	empty.Spec.Zone.Name = "zone-a"
+	assert.NoError(t, empty.ValidateCreate())
+	multisite := s.DeepCopy()
+	multisite.Spec.Zone.Name = "zone-a"
+	assert.Error(t, multisite.ValidateCreate())
+
+	invalid := s.DeepCopy()
+	invalid.Spec.Gateway.Port = 70000
+	assert.Error(t, invalid.ValidateCreate())
+
+	invalid = s.DeepCopy()
+	invalid.Spec.Gateway.SecurePort = 443
+	assert.Error(t, invalid.ValidateCreate())
+	invalid.Spec.Gateway.SSLCertificateRef = "cert"
+	assert.NoError(t, invalid.ValidateCreate())
+
+	invalid = s.DeepCopy()
+	invalid.Spec.DataPool.FailureDomain = ""
+	assert.Error(t, invalid.ValidateCreate())
+
+	// the data pool cannot be changed to replicated
+	up := s.DeepCopy()
+	up.Spec.DataPool = PoolSpec{Replicated: ReplicatedSpec{Size: 3}}
+	assert.NoError(t, up.ValidateCreate())
+	assert.Error(t, up.ValidateUpdate(s))
+}
+
+func TestCephFilesystemValidate(t *testing.T) {
+	f := &CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs"},
+		Spec: FilesystemSpec{
+			MetadataPool:   PoolSpec{Replicated: ReplicatedSpec{Size: 3}},
+			DataPools:      []NamedPoolSpec{{PoolSpec: PoolSpec{Replicated: ReplicatedSpec{Size: 3}}}},
+			MetadataServer: MetadataServerSpec{ActiveCount: 1},
+		},
+	}
+	assert.NoError(t, f.ValidateCreate())
+
+	invalid := f.DeepCopy()
+	invalid.Spec.MetadataServer.ActiveCount = 0
+	assert.Error(t, invalid.ValidateCreate())
+
+	invalid = f.DeepCopy()
+	invalid.Spec.MetadataPool = PoolSpec{}
+	assert.Error(t, invalid.ValidateCreate())
+
+	// the mds memory limit cannot be below the minimum
+	invalid = f.DeepCopy()
+	invalid.Spec.MetadataServer.Resources.Limits = v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")}
+	assert.Error(t, invalid.ValidateCreate())
+
+	// the existing filesystem needs no pool
+	existing := f.DeepCopy()
+	existing.Spec.MetadataPool = PoolSpec{}
+	existing.Spec.DataPools = nil
+	assert.NoError(t, existing.ValidateCreate())
+
+	// a data pool can be added, but an existing one cannot be changed to erasure coded
+	up := f.DeepCopy()
+	up.Spec.DataPools = append(up.Spec.DataPools, NamedPoolSpec{PoolSpec: PoolSpec{ErasureCoded: ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}, FailureDomain: "host"}})
+	assert.NoError(t, up.ValidateUpdate(f))
+	up.Spec.DataPools[0] = up.Spec.DataPools[1]
+	assert.Error(t, up.ValidateUpdate(f))
+
+	// the named data pools are unique and only one of them is the default
+	named := f.DeepCopy()
+	named.Spec.DataPools = append(named.Spec.DataPools, NamedPoolSpec{Name: "fast", Default: true, PoolSpec: PoolSpec{Replicated: ReplicatedSpec{Size: 3}}})
+	assert.NoError(t, named.ValidateCreate())
+	assert.Equal(t, "myfs-data0", named.DataPoolName(0))
+	assert.Equal(t, "myfs-fast", named.DataPoolName(1))
+	assert.Equal(t, 1, named.Spec.DefaultDataPoolIndex())
+	invalid = named.DeepCopy()
+	invalid.Spec.DataPools[0].Name = "fast"
+	assert.Error(t, invalid.ValidateCreate())
+	invalid = named.DeepCopy()
+	invalid.Spec.DataPools[0].Default = true
+	assert.Error(t, invalid.ValidateCreate())
+	invalid = named.DeepCopy()
+	invalid.Spec.DataPools[1].Name = "fast/ssd"
+	assert.Error(t, invalid.ValidateCreate())
+
+	// the names and the default of the existing data pools cannot be changed
+	invalid = named.DeepCopy()
+	invalid.Spec.DataPools[1].Name = "ssd"
+	assert.Error(t, invalid.ValidateUpdate(named))
+	invalid = named.DeepCopy()
+	invalid.Spec.DataPools[1].Default = false
+	assert.Error(t, invalid.ValidateUpdate(named))
+
+	// the mds health check durations are positive
+	checked := f.DeepCopy()
+	checked.Spec.HealthCheck.MDS = MDSHealthCheckSpec{HealthCheckSpec: HealthCheckSpec{Interval: "30s"}, RestartStuckAfter: "10m"}
+	assert.NoError(t, checked.ValidateCreate())
+	checked.Spec.HealthCheck.MDS.RestartStuckAfter = "-5m"
+	assert.Error(t, checked.ValidateCreate())
+	checked.Spec.HealthCheck.MDS.RestartStuckAfter = "10"
+	assert.Error(t, checked.ValidateCreate())
 }
@@ -40,7 +40,11 @@ func (a Annotations) ApplyToObjectMeta(t *metav1.ObjectMeta) {
 // original Annotations with the attributes of the supplied one. The supplied
 // Placement's attributes will override the original ones if defined.
 func (a Annotations) Merge(with Annotations) Annotations {
-	ret := a
+	// the original annotations are copied, being shared by the components
+	ret := Annotations{}
+	for k, v := range a {
+		ret[k] = v
+	}
 	for k, v := range with {
 		if _, ok := ret[k]; !ok {
 			ret[k] = v
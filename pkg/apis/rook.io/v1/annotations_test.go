@@ -82,3 +82,9 @@ func TestAnnotations_Merge(t *testing.T) {
 		"hello": "world",
 	}, testAnnotationsPart1.Merge(testAnnotationsPart2).GetMapStringString())
 }
+
+func TestAnnotations_MergeKeepsOriginal(t *testing.T) {
+	all := Annotations{"foo": "bar"}
+	all.Merge(Annotations{"mgr": "val"})
+	assert.Equal(t, Annotations{"foo": "bar"}, all)
+}
@@ -0,0 +1,55 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// All returns the labels defined for 'all' the components
+func (l LabelsSpec) All() Labels {
+	return l[KeyAll]
+}
+
+// ApplyToObjectMeta adds the labels to the object meta. The labels already set, such as the labels selecting the
+// pods of the components, are not overwritten.
+func (l Labels) ApplyToObjectMeta(t *metav1.ObjectMeta) {
+	if len(l) == 0 {
+		return
+	}
+	if t.Labels == nil {
+		t.Labels = map[string]string{}
+	}
+	for k, v := range l {
+		if _, ok := t.Labels[k]; !ok {
+			t.Labels[k] = v
+		}
+	}
+}
+
+// Merge returns the labels resulting from merging the original labels with the supplied ones. The supplied labels
+// override the original ones if defined.
+func (l Labels) Merge(with Labels) Labels {
+	ret := Labels{}
+	for k, v := range l {
+		ret[k] = v
+	}
+	for k, v := range with {
+		ret[k] = v
+	}
+	return ret
+}
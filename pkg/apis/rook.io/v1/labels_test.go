@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLabels_ApplyToObjectMeta(t *testing.T) {
+	objMeta := &metav1.ObjectMeta{Labels: map[string]string{"app": "rook-ceph-mon"}}
+	testLabels := Labels{
+		"app":  "mine",
+		"team": "storage",
+	}
+	testLabels.ApplyToObjectMeta(objMeta)
+	// the labels already set are kept
+	assert.Equal(t, map[string]string{"app": "rook-ceph-mon", "team": "storage"}, objMeta.Labels)
+
+	objMeta = &metav1.ObjectMeta{}
+	Labels{}.ApplyToObjectMeta(objMeta)
+	assert.Nil(t, objMeta.Labels)
+}
+
+func TestLabels_Merge(t *testing.T) {
+	all := Labels{
+		"foo":   "bar",
+		"hello": "world",
+	}
+	specific := Labels{
+		"bar":   "foo",
+		"hello": "earth",
+	}
+	assert.Equal(t, Labels{
+		"foo":   "bar",
+		"bar":   "foo",
+		"hello": "earth",
+	}, all.Merge(specific))
+	// the original labels are not modified
+	assert.Equal(t, 2, len(all))
+}
@@ -15,6 +15,26 @@ limitations under the License.
 */
 package v1
 
+import (
+	"regexp"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// DeviceTypeHDD selects the rotational devices
+	DeviceTypeHDD = "hdd"
+	// DeviceTypeSSD selects the non-rotational devices that are not nvme devices
+	DeviceTypeSSD = "ssd"
+	// DeviceTypeNVMe selects the nvme devices
+	DeviceTypeNVMe = "nvme"
+)
+
+// deviceClassRegex matches the names of the CRUSH device classes, the built-in hdd, ssd and nvme classes as well as
+// custom classes such as nvme-meta
+var deviceClassRegex = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_.-]*$`)
+
 // AnyUseAllDevices gets whether to use all devices
 func (s *StorageScopeSpec) AnyUseAllDevices() bool {
 	if s.Selection.GetUseAllDevices() {
@@ -83,6 +103,10 @@ func (s *StorageScopeSpec) resolveNodeSelection(node *Node) {
 
 	resolveString(&(node.Selection.DeviceFilter), s.Selection.DeviceFilter, "")
 	resolveString(&(node.Selection.DevicePathFilter), s.Selection.DevicePathFilter, "")
+	resolveString(&(node.Selection.MinSize), s.Selection.MinSize, "")
+	resolveString(&(node.Selection.MaxSize), s.Selection.MaxSize, "")
+	resolveString(&(node.Selection.DeviceType), s.Selection.DeviceType, "")
+	resolveString(&(node.Selection.DeviceClass), s.Selection.DeviceClass, "")
 
 	if len(node.Selection.Devices) == 0 {
 		node.Selection.Devices = s.Devices
@@ -119,6 +143,47 @@ func (s *Selection) GetUseAllDevices() bool {
 	return s.UseAllDevices != nil && *(s.UseAllDevices)
 }
 
+// ValidateDeviceFilters checks the size and the type filters of the devices
+func (s *Selection) ValidateDeviceFilters() error {
+	var minSize, maxSize resource.Quantity
+	var err error
+	if s.MinSize != "" {
+		if minSize, err = resource.ParseQuantity(s.MinSize); err != nil {
+			return errors.Wrapf(err, "invalid minSize %q", s.MinSize)
+		}
+	}
+	if s.MaxSize != "" {
+		if maxSize, err = resource.ParseQuantity(s.MaxSize); err != nil {
+			return errors.Wrapf(err, "invalid maxSize %q", s.MaxSize)
+		}
+		if s.MinSize != "" && minSize.Cmp(maxSize) > 0 {
+			return errors.Errorf("minSize %q is larger than maxSize %q", s.MinSize, s.MaxSize)
+		}
+	}
+	switch s.DeviceType {
+	case "", DeviceTypeHDD, DeviceTypeSSD, DeviceTypeNVMe:
+	default:
+		return errors.Errorf("invalid deviceType %q, must be one of %q, %q or %q", s.DeviceType, DeviceTypeHDD, DeviceTypeSSD, DeviceTypeNVMe)
+	}
+	if err := ValidateDeviceClass(s.DeviceClass); err != nil {
+		return err
+	}
+	for _, device := range s.Devices {
+		if err := ValidateDeviceClass(device.DeviceClass); err != nil {
+			return errors.Wrapf(err, "invalid device %q", device.Name)
+		}
+	}
+	return nil
+}
+
+// ValidateDeviceClass checks the name of a CRUSH device class, an empty class letting ceph detect the class
+func ValidateDeviceClass(deviceClass string) error {
+	if deviceClass != "" && !deviceClassRegex.MatchString(deviceClass) {
+		return errors.Errorf("invalid deviceClass %q, must only contain letters, digits, '-', '_' and '.'", deviceClass)
+	}
+	return nil
+}
+
 func resolveString(setting *string, parent, defaultVal string) {
 	if *setting == "" {
 		if parent != "" {
@@ -245,3 +245,32 @@ func TestStorageScopeSpec_NodeWithNameExists(t *testing.T) {
 	assert.True(t, spec.NodeWithNameExists("node1"))
 	assert.True(t, spec.NodeWithNameExists("node2"))
 }
+
+func TestValidateDeviceFilters(t *testing.T) {
+	assert.NoError(t, (&Selection{}).ValidateDeviceFilters())
+	assert.NoError(t, (&Selection{MinSize: "100Gi", MaxSize: "4Ti", DeviceType: DeviceTypeNVMe}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{MinSize: "big"}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{MaxSize: "big"}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{MinSize: "4Ti", MaxSize: "100Gi"}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{DeviceType: "tape"}).ValidateDeviceFilters())
+	assert.NoError(t, (&Selection{DeviceClass: "nvme-meta", Devices: []Device{{Name: "sdb", DeviceClass: "ssd"}}}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{DeviceClass: "nvme meta"}).ValidateDeviceFilters())
+	assert.Error(t, (&Selection{Devices: []Device{{Name: "sdb", DeviceClass: "ssd/"}}}).ValidateDeviceFilters())
+}
+
+func TestResolveNodeDeviceFilters(t *testing.T) {
+	storageSpec := StorageScopeSpec{
+		Selection: Selection{MinSize: "100Gi", DeviceType: DeviceTypeHDD, DeviceClass: "hdd-archive"},
+		Nodes: []Node{
+			{Name: "node1", Selection: Selection{DeviceType: DeviceTypeSSD}},
+			{Name: "node2", Selection: Selection{DeviceClass: "nvme-meta"}},
+		},
+	}
+	node := storageSpec.ResolveNode("node1")
+	assert.Equal(t, "100Gi", node.Selection.MinSize)
+	assert.Equal(t, "", node.Selection.MaxSize)
+	assert.Equal(t, DeviceTypeSSD, node.Selection.DeviceType)
+	assert.Equal(t, "hdd-archive", node.Selection.DeviceClass)
+	node = storageSpec.ResolveNode("node2")
+	assert.Equal(t, "nvme-meta", node.Selection.DeviceClass)
+}
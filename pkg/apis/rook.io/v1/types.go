@@ -34,6 +34,59 @@ type StorageScopeSpec struct {
 	Selection
 	VolumeSources          []VolumeSource          `json:"volumeSources,omitempty"`
 	StorageClassDeviceSets []StorageClassDeviceSet `json:"storageClassDeviceSets"`
+	// TopologyMapping maps the CRUSH bucket types such as rack or zone to the node labels of the
+	// same bucket type, these labels taking precedence over the standard topology labels
+	TopologyMapping map[string]string `json:"topologyMapping,omitempty"`
+	// Scrubbing is the schedule of the scrubs of the OSDs
+	Scrubbing *ScrubbingSpec `json:"scrubbing,omitempty"`
+	// Compression is the default bluestore compression of the OSDs, the pools overriding it with their compression mode
+	Compression *BluestoreCompressionSpec `json:"compression,omitempty"`
+	// Prepare throttles the OSD prepare jobs, which all run at once when not set
+	Prepare *PrepareSpec `json:"prepare,omitempty"`
+	// RemovedNodeGracePeriod is how long the OSDs of a node deleted from the cluster are kept before their removal,
+	// such as 24h, when all the nodes are used. The OSDs of the deleted nodes are only reported when not set.
+	RemovedNodeGracePeriod string `json:"removedNodeGracePeriod,omitempty"`
+}
+
+// PrepareSpec throttles the OSD prepare jobs of a cluster. The jobs are started by batches, the next batch being
+// started once the OSDs of the previous batch are started.
+type PrepareSpec struct {
+	// MaxConcurrentJobs is the number of prepare jobs of a batch, no limit when not set
+	MaxConcurrentJobs int `json:"maxConcurrentJobs,omitempty"`
+	// MaxConcurrentJobsPerNode is the number of prepare jobs of a batch on the same node, the jobs of the OSDs on PVCs
+	// counting for the node of their local volume
+	MaxConcurrentJobsPerNode int `json:"maxConcurrentJobsPerNode,omitempty"`
+	// BatchDelay is the delay before starting the next batch, such as 30s
+	BatchDelay string `json:"batchDelay,omitempty"`
+}
+
+// BluestoreCompressionSpec represents the default inline compression of the data written by the OSDs
+type BluestoreCompressionSpec struct {
+	// Mode is the compression mode: none, passive, aggressive or force
+	Mode string `json:"mode,omitempty"`
+	// Algorithm is the compression algorithm: snappy, zlib, zstd or lz4
+	Algorithm string `json:"algorithm,omitempty"`
+}
+
+// ScrubbingSpec represents the schedule of the scrubs of the OSDs, the scheduled scrubs only starting within the
+// hours and the week days of the window. The window wraps around when its end is lower than its begin.
+type ScrubbingSpec struct {
+	// BeginHour is the first hour of the window, from 0 to 23
+	BeginHour *int `json:"beginHour,omitempty"`
+	// EndHour is the hour ending the window, from 0 to 23
+	EndHour *int `json:"endHour,omitempty"`
+	// BeginWeekDay is the first day of the window, from 0 (Sunday) to 6
+	BeginWeekDay *int `json:"beginWeekDay,omitempty"`
+	// EndWeekDay is the day ending the window, from 0 (Sunday) to 6
+	EndWeekDay *int `json:"endWeekDay,omitempty"`
+	// MinInterval is the interval between the scrubs of a PG when the load of the cluster is low, such as 24h
+	MinInterval string `json:"minInterval,omitempty"`
+	// MaxInterval is the interval after which a PG is scrubbed regardless of the load of the cluster, such as 168h
+	MaxInterval string `json:"maxInterval,omitempty"`
+	// DeepInterval is the interval between the deep scrubs of a PG, such as 168h
+	DeepInterval string `json:"deepInterval,omitempty"`
+	// MaxScrubs is the max number of simultaneous scrubs of an OSD
+	MaxScrubs int `json:"maxScrubs,omitempty"`
 }
 
 type Node struct {
@@ -44,9 +97,11 @@ type Node struct {
 }
 
 type Device struct {
-	Name     string            `json:"name,omitempty"`
-	FullPath string            `json:"fullpath,omitempty"`
-	Config   map[string]string `json:"config"`
+	Name     string `json:"name,omitempty"`
+	FullPath string `json:"fullpath,omitempty"`
+	// The CRUSH device class of the OSDs created on the device, overriding the class of the node
+	DeviceClass string            `json:"deviceClass,omitempty"`
+	Config      map[string]string `json:"config"`
 }
 
 type Directory struct {
@@ -61,6 +116,14 @@ type Selection struct {
 	DeviceFilter string `json:"deviceFilter,omitempty"`
 	// A regular expression to allow more fine-grained selection of devices with path names
 	DevicePathFilter string `json:"devicePathFilter,omitempty"`
+	// The minimum size of the devices to select, such as 100Gi
+	MinSize string `json:"minSize,omitempty"`
+	// The maximum size of the devices to select, such as 4Ti
+	MaxSize string `json:"maxSize,omitempty"`
+	// The type of the devices to select: hdd, ssd or nvme
+	DeviceType string `json:"deviceType,omitempty"`
+	// The CRUSH device class of the OSDs created on the selected devices, such as ssd or a custom class like nvme-meta
+	DeviceClass string `json:"deviceClass,omitempty"`
 	// List of devices to use as storage devices
 	Devices []Device `json:"devices,omitempty"`
 	// List of host directories to use as storage
@@ -107,16 +170,24 @@ type AnnotationsSpec map[KeyType]Annotations
 
 type Annotations map[string]string
 
+// LabelsSpec is the labels of the components, keyed by component
+type LabelsSpec map[KeyType]Labels
+
+// Labels are the labels of a component
+type Labels map[string]string
+
 type StorageClassDeviceSet struct {
 	Name                 string                     `json:"name,omitempty"`                 // A unique identifier for the set
 	Count                int                        `json:"count,omitempty"`                // Number of devices in this set
 	Resources            v1.ResourceRequirements    `json:"resources,omitempty"`            // Requests/limits for the devices
 	Placement            Placement                  `json:"placement,omitempty"`            // Placement constraints for the devices
+	PreparePlacement     *Placement                 `json:"preparePlacement,omitempty"`     // Placement constraints for the osd prepare pods, the placement of the devices if not set
 	Config               map[string]string          `json:"config,omitempty"`               // Provider-specific device configuration
 	VolumeClaimTemplates []v1.PersistentVolumeClaim `json:"volumeClaimTemplates,omitempty"` // List of PVC templates for the underlying storage devices
 	Portable             bool                       `json:"portable,omitempty"`             // OSD portability across the hosts
 	TuneSlowDeviceClass  bool                       `json:"tuneDeviceClass,omitempty"`      // TuneSlowDeviceClass Tune the OSD when running on a slow Device Class
 	SchedulerName        string                     `json:"schedulerName,omitempty"`        // Scheduler name for OSD pod placement
+	DeviceClass          string                     `json:"deviceClass,omitempty"`          // CRUSH device class of the OSDs of the set
 }
 
 // VolumeSource is a volume source spec for Rook
@@ -125,6 +196,7 @@ type VolumeSource struct {
 	PVCSources          map[string]v1.PersistentVolumeClaimVolumeSource `json:"pvcSources,omitempty"`
 	Resources           v1.ResourceRequirements                         `json:"resources,omitempty"`
 	Placement           Placement                                       `json:"placement,omitempty"`
+	PreparePlacement    *Placement                                      `json:"preparePlacement,omitempty"`
 	Config              map[string]string                               `json:"config,omitempty"`
 	Portable            bool                                            `json:"portable,omitempty"`         // Portable OSD portability across the hosts
 	TuneSlowDeviceClass bool                                            `json:"tuneDeviceClass,omitempty"`  // TuneSlowDeviceClass Tune the OSD when running on a slow Device Class
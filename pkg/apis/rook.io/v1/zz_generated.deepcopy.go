@@ -78,6 +78,22 @@ func (in AnnotationsSpec) DeepCopy() AnnotationsSpec {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BluestoreCompressionSpec) DeepCopyInto(out *BluestoreCompressionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BluestoreCompressionSpec.
+func (in *BluestoreCompressionSpec) DeepCopy() *BluestoreCompressionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BluestoreCompressionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Device) DeepCopyInto(out *Device) {
 	*out = *in
@@ -124,6 +140,60 @@ func (in *Directory) DeepCopy() *Directory {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in Labels) DeepCopyInto(out *Labels) {
+	{
+		in := &in
+		*out = make(Labels, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Labels.
+func (in Labels) DeepCopy() Labels {
+	if in == nil {
+		return nil
+	}
+	out := new(Labels)
+	in.DeepCopyInto(out)
+	return *out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in LabelsSpec) DeepCopyInto(out *LabelsSpec) {
+	{
+		in := &in
+		*out = make(LabelsSpec, len(*in))
+		for key, val := range *in {
+			var outVal map[string]string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make(Labels, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+			(*out)[key] = outVal
+		}
+		return
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LabelsSpec.
+func (in LabelsSpec) DeepCopy() LabelsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LabelsSpec)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	*out = *in
@@ -261,6 +331,22 @@ func (in PlacementSpec) DeepCopy() PlacementSpec {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrepareSpec) DeepCopyInto(out *PrepareSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrepareSpec.
+func (in *PrepareSpec) DeepCopy() *PrepareSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrepareSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in PriorityClassNamesSpec) DeepCopyInto(out *PriorityClassNamesSpec) {
 	{
@@ -326,6 +412,42 @@ func (in ResourceSpec) DeepCopy() ResourceSpec {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScrubbingSpec) DeepCopyInto(out *ScrubbingSpec) {
+	*out = *in
+	if in.BeginHour != nil {
+		in, out := &in.BeginHour, &out.BeginHour
+		*out = new(int)
+		**out = **in
+	}
+	if in.EndHour != nil {
+		in, out := &in.EndHour, &out.EndHour
+		*out = new(int)
+		**out = **in
+	}
+	if in.BeginWeekDay != nil {
+		in, out := &in.BeginWeekDay, &out.BeginWeekDay
+		*out = new(int)
+		**out = **in
+	}
+	if in.EndWeekDay != nil {
+		in, out := &in.EndWeekDay, &out.EndWeekDay
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScrubbingSpec.
+func (in *ScrubbingSpec) DeepCopy() *ScrubbingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ScrubbingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Selection) DeepCopyInto(out *Selection) {
 	*out = *in
@@ -373,6 +495,11 @@ func (in *StorageClassDeviceSet) DeepCopyInto(out *StorageClassDeviceSet) {
 	*out = *in
 	in.Resources.DeepCopyInto(&out.Resources)
 	in.Placement.DeepCopyInto(&out.Placement)
+	if in.PreparePlacement != nil {
+		in, out := &in.PreparePlacement, &out.PreparePlacement
+		*out = new(Placement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = make(map[string]string, len(*in))
@@ -432,6 +559,28 @@ func (in *StorageScopeSpec) DeepCopyInto(out *StorageScopeSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.TopologyMapping != nil {
+		in, out := &in.TopologyMapping, &out.TopologyMapping
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Scrubbing != nil {
+		in, out := &in.Scrubbing, &out.Scrubbing
+		*out = new(ScrubbingSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Compression != nil {
+		in, out := &in.Compression, &out.Compression
+		*out = new(BluestoreCompressionSpec)
+		**out = **in
+	}
+	if in.Prepare != nil {
+		in, out := &in.Prepare, &out.Prepare
+		*out = new(PrepareSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -457,6 +606,11 @@ func (in *VolumeSource) DeepCopyInto(out *VolumeSource) {
 	}
 	in.Resources.DeepCopyInto(&out.Resources)
 	in.Placement.DeepCopyInto(&out.Placement)
+	if in.PreparePlacement != nil {
+		in, out := &in.PreparePlacement, &out.PreparePlacement
+		*out = new(Placement)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Config != nil {
 		in, out := &in.Config, &out.Config
 		*out = make(map[string]string, len(*in))
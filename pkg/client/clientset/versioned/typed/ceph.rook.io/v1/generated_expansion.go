@@ -20,12 +20,24 @@ package v1
 
 type CephBlockPoolExpansion interface{}
 
+type CephBlockPoolRadosNamespaceExpansion interface{}
+
+type CephBucketNotificationExpansion interface{}
+
+type CephBucketTopicExpansion interface{}
+
+type CephCOSIDriverExpansion interface{}
+
 type CephClientExpansion interface{}
 
 type CephClusterExpansion interface{}
 
 type CephFilesystemExpansion interface{}
 
+type CephFilesystemMirrorExpansion interface{}
+
+type CephFilesystemSubVolumeGroupExpansion interface{}
+
 type CephNFSExpansion interface{}
 
 type CephObjectRealmExpansion interface{}
@@ -0,0 +1,154 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup writes the maps of the mon store, the keyrings and the config of a cluster to an archive, keeping
+// the last archives in a directory or a bucket.
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	archivePrefix = "ceph-backup-"
+	archiveSuffix = ".tar.gz"
+	// archiveTimeFormat sorts the names of the archives by the time of the backup
+	archiveTimeFormat = "20060102-150405"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "backup")
+
+// backupFile is a file of the archive with the ceph command dumping it
+type backupFile struct {
+	name string
+	args []string
+	json bool
+}
+
+// backupFiles are the files of the archive. The database of the mon store cannot be copied while the mons are
+// running, the maps and the config-key store are dumped instead, from which a mon store can be rebuilt.
+var backupFiles = []backupFile{
+	{name: "monmap", args: []string{"mon", "getmap"}},
+	{name: "osdmap", args: []string{"osd", "getmap"}},
+	{name: "crushmap", args: []string{"osd", "getcrushmap"}},
+	{name: "fsmap.json", args: []string{"fs", "dump"}, json: true},
+	{name: "config.json", args: []string{"config", "dump"}, json: true},
+	{name: "config-key.json", args: []string{"config-key", "dump"}, json: true},
+	{name: "keyring", args: []string{"auth", "export"}},
+}
+
+// Target is where the backup archives are stored
+type Target interface {
+	// Put stores an archive
+	Put(name string, data []byte) error
+	// List returns the names of the stored archives
+	List() ([]string, error)
+	// Remove removes an archive
+	Remove(name string) error
+}
+
+// Run writes the backup archive of the cluster to the target, and removes the oldest archives to keep the last
+// retain archives
+func Run(context *clusterd.Context, clusterName string, target Target, retain int, now time.Time) (string, error) {
+	data, err := archive(context, clusterName, now)
+	if err != nil {
+		return "", err
+	}
+	name := archiveName(now)
+	if err := target.Put(name, data); err != nil {
+		return "", errors.Wrapf(err, "failed to store backup %q", name)
+	}
+	logger.Infof("stored backup %q of %d bytes", name, len(data))
+
+	if err := prune(target, retain); err != nil {
+		return name, errors.Wrap(err, "failed to remove the old backups")
+	}
+	return name, nil
+}
+
+// archive returns the gzipped tar archive of the files dumped from the cluster
+func archive(context *clusterd.Context, clusterName string, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, file := range backupFiles {
+		cmd := cephclient.NewCephCommand(context, clusterName, file.args)
+		cmd.JsonOutput = file.json
+		content, err := cmd.Run()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to dump %q with %q", file.name, strings.Join(file.args, " "))
+		}
+		header := &tar.Header{Name: file.name, Mode: 0600, Size: int64(len(content)), ModTime: now}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %q to the archive", file.name)
+		}
+		if _, err := tw.Write(content); err != nil {
+			return nil, errors.Wrapf(err, "failed to add %q to the archive", file.name)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to close the archive")
+	}
+	if err := gz.Close(); err != nil {
+		return nil, errors.Wrap(err, "failed to compress the archive")
+	}
+	return buf.Bytes(), nil
+}
+
+// archiveName returns the name of the archive of a backup, the names sorting by the time of the backups
+func archiveName(now time.Time) string {
+	return fmt.Sprintf("%s%s%s", archivePrefix, now.UTC().Format(archiveTimeFormat), archiveSuffix)
+}
+
+func isArchive(name string) bool {
+	return strings.HasPrefix(name, archivePrefix) && strings.HasSuffix(name, archiveSuffix)
+}
+
+// prune removes the oldest archives of the target, keeping the last retain archives
+func prune(target Target, retain int) error {
+	names, err := target.List()
+	if err != nil {
+		return err
+	}
+	archives := []string{}
+	for _, name := range names {
+		if isArchive(name) {
+			archives = append(archives, name)
+		}
+	}
+	if len(archives) <= retain {
+		return nil
+	}
+	sort.Strings(archives)
+	for _, name := range archives[:len(archives)-retain] {
+		logger.Infof("removing backup %q", name)
+		if err := target.Remove(name); err != nil {
+			return errors.Wrapf(err, "failed to remove backup %q", name)
+		}
+	}
+	return nil
+}
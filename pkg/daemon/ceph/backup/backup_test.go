@@ -0,0 +1,137 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	failAuth := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "auth" && failAuth {
+				return "", errors.New("timed out")
+			}
+			return strings.Join(args[:2], " "), nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	dir, err := ioutil.TempDir("", "backup")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(path.Join(dir, "notes.txt"), []byte("kept"), 0600))
+	target := NewDirectoryTarget(dir)
+
+	// the archive has the files dumped from the cluster
+	now := time.Date(2020, 10, 15, 12, 0, 0, 0, time.UTC)
+	name, err := Run(context, "rook-ceph", target, 2, now)
+	assert.NoError(t, err)
+	assert.Equal(t, "ceph-backup-20201015-120000.tar.gz", name)
+	data, err := ioutil.ReadFile(path.Join(dir, name))
+	assert.NoError(t, err)
+	files := readArchive(t, data)
+	assert.Equal(t, len(backupFiles), len(files))
+	assert.Equal(t, "mon getmap", files["monmap"])
+	assert.Equal(t, "config-key dump", files["config-key.json"])
+	assert.Equal(t, "auth export", files["keyring"])
+
+	// the oldest archives are removed
+	for i := 1; i <= 2; i++ {
+		_, err = Run(context, "rook-ceph", target, 2, now.Add(time.Duration(i)*time.Hour))
+		assert.NoError(t, err)
+	}
+	names, err := target.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"ceph-backup-20201015-130000.tar.gz", "ceph-backup-20201015-140000.tar.gz", "notes.txt"}, names)
+
+	// a failed dump stores no archive
+	failAuth = true
+	_, err = Run(context, "rook-ceph", target, 2, now.Add(3*time.Hour))
+	assert.Error(t, err)
+	names, err = target.List()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(names))
+}
+
+func TestPrune(t *testing.T) {
+	target := &memoryTarget{archives: map[string]bool{
+		"ceph-backup-20201013-000000.tar.gz": true,
+		"ceph-backup-20201015-000000.tar.gz": true,
+		"ceph-backup-20201014-000000.tar.gz": true,
+		"other.tar.gz":                       true,
+	}}
+	assert.NoError(t, prune(target, 3))
+	assert.Equal(t, 4, len(target.archives))
+
+	assert.NoError(t, prune(target, 1))
+	assert.Equal(t, map[string]bool{"ceph-backup-20201015-000000.tar.gz": true, "other.tar.gz": true}, target.archives)
+}
+
+type memoryTarget struct {
+	archives map[string]bool
+}
+
+func (t *memoryTarget) Put(name string, data []byte) error {
+	t.archives[name] = true
+	return nil
+}
+
+func (t *memoryTarget) List() ([]string, error) {
+	names := []string{}
+	for name := range t.archives {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (t *memoryTarget) Remove(name string) error {
+	delete(t.archives, name)
+	return nil
+}
+
+func readArchive(t *testing.T, data []byte) map[string]string {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	assert.NoError(t, err)
+	tr := tar.NewReader(gz)
+	files := map[string]string{}
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		content, err := ioutil.ReadAll(tr)
+		assert.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+	return files
+}
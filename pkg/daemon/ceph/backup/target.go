@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+)
+
+// directoryTarget stores the archives in a directory, such as the mount of a PVC
+type directoryTarget struct {
+	dir string
+}
+
+// NewDirectoryTarget returns the target storing the archives in the directory
+func NewDirectoryTarget(dir string) Target {
+	return &directoryTarget{dir: dir}
+}
+
+func (t *directoryTarget) Put(name string, data []byte) error {
+	// the archive is renamed once written so that a partial archive is never kept as a backup
+	tmp := path.Join(t.dir, "."+name)
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return errors.Wrapf(err, "failed to write %q", tmp)
+	}
+	return os.Rename(tmp, path.Join(t.dir, name))
+}
+
+func (t *directoryTarget) List() ([]string, error) {
+	files, err := ioutil.ReadDir(t.dir)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list directory %q", t.dir)
+	}
+	names := []string{}
+	for _, file := range files {
+		if !file.IsDir() {
+			names = append(names, file.Name())
+		}
+	}
+	return names, nil
+}
+
+func (t *directoryTarget) Remove(name string) error {
+	return os.Remove(path.Join(t.dir, name))
+}
+
+// bucketTarget uploads the archives to an S3 bucket, under the prefix
+type bucketTarget struct {
+	client *s3.S3
+	bucket string
+	prefix string
+}
+
+// NewBucketTarget returns the target uploading the archives to the S3 bucket of the endpoint
+func NewBucketTarget(endpoint, bucket, prefix, accessKey, secretKey string) (Target, error) {
+	agent, err := object.NewS3Agent(accessKey, secretKey, endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to endpoint %q", endpoint)
+	}
+	return &bucketTarget{client: agent.Client, bucket: bucket, prefix: prefix}, nil
+}
+
+func (t *bucketTarget) Put(name string, data []byte) error {
+	_, err := t.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(t.bucket),
+		Key:    aws.String(t.prefix + name),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
+
+func (t *bucketTarget) List() ([]string, error) {
+	names := []string{}
+	err := t.client.ListObjectsPages(&s3.ListObjectsInput{Bucket: aws.String(t.bucket), Prefix: aws.String(t.prefix)},
+		func(page *s3.ListObjectsOutput, lastPage bool) bool {
+			for _, o := range page.Contents {
+				names = append(names, strings.TrimPrefix(aws.StringValue(o.Key), t.prefix))
+			}
+			return true
+		})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list bucket %q", t.bucket)
+	}
+	return names, nil
+}
+
+func (t *bucketTarget) Remove(name string) error {
+	_, err := t.client.DeleteObject(&s3.DeleteObjectInput{Bucket: aws.String(t.bucket), Key: aws.String(t.prefix + name)})
+	return err
+}
@@ -23,17 +23,20 @@ import (
 	"sync"
 
 	"github.com/coreos/pkg/capnslog"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/osd"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 )
 
 const (
-	ddBS      = "1M"           // DD's block size
-	ddCount   = "10"           // DD runs over the first 10 offsets
-	ddFlags   = "direct,dsync" // DD's sync flags"
-	ddIf      = "/dev/zero"
-	ddUtility = "dd"
+	ddBS         = "1M"           // DD's block size
+	ddCount      = "10"           // DD runs over the first 10 offsets
+	ddFlags      = "direct,dsync" // DD's sync flags"
+	ddZeroIf     = "/dev/zero"
+	ddRandomIf   = "/dev/urandom"
+	ddUtility    = "dd"
+	ddDiskIsFull = "No space left on device" // DD reached the end of the disk
 )
 
 var (
@@ -42,39 +45,61 @@ var (
 
 // DiskSanitizer is simple struct to old the context to execute the commands
 type DiskSanitizer struct {
-	context     *clusterd.Context
-	clusterName string
-	clusterFSID string
+	context           *clusterd.Context
+	clusterName       string
+	clusterFSID       string
+	sanitizeDisksSpec *cephv1.SanitizeDisksSpec
 }
 
 // NewDiskSanitizer is function that returns a full filled DiskSanitizer object
-func NewDiskSanitizer(context *clusterd.Context, clusterName, clusterFSID string) *DiskSanitizer {
+func NewDiskSanitizer(context *clusterd.Context, clusterName, clusterFSID string, sanitizeDisksSpec *cephv1.SanitizeDisksSpec) *DiskSanitizer {
 	return &DiskSanitizer{
-		context:     context,
-		clusterName: clusterName,
-		clusterFSID: clusterFSID,
+		context:           context,
+		clusterName:       clusterName,
+		clusterFSID:       clusterFSID,
+		sanitizeDisksSpec: sanitizeDisksSpec,
 	}
 }
 
 // StartSanitizeDisks main entrypoint of the cleanup package
 func StartSanitizeDisks(sanitizer *DiskSanitizer) {
+	sanitizer.sanitizeDisks(func(osdID int) bool { return true })
+}
+
+// StartSanitizeOSDDisk wipes the disk of a single osd, the other osds of the node are left untouched
+func StartSanitizeOSDDisk(sanitizer *DiskSanitizer, osdID int) {
+	sanitizer.sanitizeDisks(func(id int) bool { return id == osdID })
+}
+
+// sanitizeDisks wipes the disks of the osds of the node selected by the filter
+func (s *DiskSanitizer) sanitizeDisks(filter func(osdID int) bool) {
 	// LVM based OSDs
-	osdLVMList, err := osd.GetCephVolumeLVMOSDs(sanitizer.context, sanitizer.clusterName, sanitizer.clusterFSID, "", false, false)
+	osdLVMList, err := osd.GetCephVolumeLVMOSDs(s.context, s.clusterName, s.clusterFSID, "", false, false)
 	if err != nil {
 		logger.Errorf("failed to list lvm osd(s). %v", err)
 	} else {
 		// Start the sanitizing sequence
-		sanitizer.sanitizeLVMDisk(osdLVMList)
+		s.sanitizeLVMDisk(filterOSDs(osdLVMList, filter))
 	}
 
 	// Raw based OSDs
-	osdRawList, err := osd.GetCephVolumeRawOSDs(sanitizer.context, sanitizer.clusterName, sanitizer.clusterFSID, "", "", false)
+	osdRawList, err := osd.GetCephVolumeRawOSDs(s.context, s.clusterName, s.clusterFSID, "", "", false)
 	if err != nil {
 		logger.Errorf("failed to list raw osd(s). %v", err)
 	} else {
 		// Start the sanitizing sequence
-		sanitizer.sanitizeRawDisk(osdRawList)
+		s.sanitizeRawDisk(filterOSDs(osdRawList, filter))
+	}
+}
+
+func filterOSDs(osds []oposd.OSDInfo, filter func(osdID int) bool) []oposd.OSDInfo {
+	var selected []oposd.OSDInfo
+	for _, osd := range osds {
+		if filter(osd.ID) {
+			selected = append(selected, osd)
+		}
 	}
+	return selected
 }
 
 func (s *DiskSanitizer) sanitizeRawDisk(osdRawList []oposd.OSDInfo) {
@@ -146,14 +171,23 @@ func (s *DiskSanitizer) returnPVDevice(disk string) []string {
 }
 
 func (s *DiskSanitizer) buildDDArgs(disk string) []string {
+	ddIf := ddZeroIf
+	if s.sanitizeDisksSpec.GetDataSource() == cephv1.SanitizeDataSourceRandom {
+		ddIf = ddRandomIf
+	}
+
 	ddArgs := []string{
 		fmt.Sprintf("if=%s", ddIf),
 		fmt.Sprintf("of=%s", disk),
 		fmt.Sprintf("bs=%s", ddBS),
-		fmt.Sprintf("count=%s", ddCount),
 		fmt.Sprintf("oflag=%s", ddFlags),
 	}
 
+	// the complete method writes until the end of the disk
+	if s.sanitizeDisksSpec.GetMethod() == cephv1.SanitizeMethodQuick {
+		ddArgs = append(ddArgs, fmt.Sprintf("count=%s", ddCount))
+	}
+
 	return ddArgs
 }
 
@@ -161,11 +195,16 @@ func (s *DiskSanitizer) executeSanitizeCommand(disk string, wg *sync.WaitGroup)
 	// On return, notify the WaitGroup that we’re done
 	defer wg.Done()
 
-	output, err := s.context.Executor.ExecuteCommandWithCombinedOutput(ddUtility, s.buildDDArgs(disk)...)
-	if err != nil {
-		logger.Errorf("failed to sanitize osd disk %q. %s. %v", disk, output, err)
+	iteration := s.sanitizeDisksSpec.GetIteration()
+	for i := int32(1); i <= iteration; i++ {
+		logger.Infof("sanitizing osd disk %q, pass %d/%d", disk, i, iteration)
+		output, err := s.context.Executor.ExecuteCommandWithCombinedOutput(ddUtility, s.buildDDArgs(disk)...)
+		if err != nil && !strings.Contains(output, ddDiskIsFull) {
+			logger.Errorf("failed to sanitize osd disk %q. %s. %v", disk, output, err)
+			return
+		}
+		logger.Infof("%s\n", output)
 	}
 
-	logger.Infof("%s\n", output)
 	logger.Infof("successfully sanitized osd disk %q", disk)
 }
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cleanup
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildDDArgs(t *testing.T) {
+	// the metadata of the disk is overwritten with zeros by default
+	s := NewDiskSanitizer(&clusterd.Context{}, "rook-ceph", "fsid", &cephv1.SanitizeDisksSpec{})
+	assert.Equal(t, []string{"if=/dev/zero", "of=/dev/sda", "bs=1M", "oflag=direct,dsync", "count=10"}, s.buildDDArgs("/dev/sda"))
+
+	// the whole disk is overwritten with random data
+	s = NewDiskSanitizer(&clusterd.Context{}, "rook-ceph", "fsid", &cephv1.SanitizeDisksSpec{Method: cephv1.SanitizeMethodComplete, DataSource: cephv1.SanitizeDataSourceRandom})
+	assert.Equal(t, []string{"if=/dev/urandom", "of=/dev/sda", "bs=1M", "oflag=direct,dsync"}, s.buildDDArgs("/dev/sda"))
+}
+
+func TestExecuteSanitizeCommand(t *testing.T) {
+	ddCount := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, ddUtility, command)
+			ddCount++
+			// dd fails once the end of the disk is reached
+			return "dd: error writing '/dev/sda': No space left on device", errors.New("exit status 1")
+		},
+	}
+	s := NewDiskSanitizer(&clusterd.Context{Executor: executor}, "rook-ceph", "fsid", &cephv1.SanitizeDisksSpec{Method: cephv1.SanitizeMethodComplete, Iteration: 3})
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	s.executeSanitizeCommand("/dev/sda", &wg)
+	wg.Wait()
+	assert.Equal(t, 3, ddCount)
+}
@@ -17,6 +17,10 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
@@ -42,7 +46,8 @@ func AuthGetOrCreate(context *clusterd.Context, clusterName, name, keyringPath s
 func AuthGetKey(context *clusterd.Context, clusterName, name string) (string, error) {
 	logger.Infof("getting ceph auth key %q", name)
 	args := []string{"auth", "get-key", name}
-	buf, err := NewCephCommand(context, clusterName, args).Run()
+	monCommand := map[string]interface{}{"prefix": "auth get-key", "entity": name}
+	buf, err := newMonCommand(context, clusterName, monCommand, args).Run()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to get key for %s", name)
 	}
@@ -54,7 +59,8 @@ func AuthGetKey(context *clusterd.Context, clusterName, name string) (string, er
 func AuthGetOrCreateKey(context *clusterd.Context, clusterName, name string, caps []string) (string, error) {
 	logger.Infof("getting or creating ceph auth key %q", name)
 	args := append([]string{"auth", "get-or-create-key", name}, caps...)
-	buf, err := NewCephCommand(context, clusterName, args).Run()
+	monCommand := map[string]interface{}{"prefix": "auth get-or-create-key", "entity": name, "caps": caps}
+	buf, err := newMonCommand(context, clusterName, monCommand, args).Run()
 	if err != nil {
 		return "", errors.Wrapf(err, "failed get-or-create-key %s", name)
 	}
@@ -66,7 +72,8 @@ func AuthGetOrCreateKey(context *clusterd.Context, clusterName, name string, cap
 func AuthUpdateCaps(context *clusterd.Context, clusterName, name string, caps []string) error {
 	logger.Infof("updating ceph auth caps %q to %v", name, caps)
 	args := append([]string{"auth", "caps", name}, caps...)
-	_, err := NewCephCommand(context, clusterName, args).Run()
+	monCommand := map[string]interface{}{"prefix": "auth caps", "entity": name, "caps": caps}
+	_, err := newMonCommand(context, clusterName, monCommand, args).Run()
 	if err != nil {
 		return errors.Wrapf(err, "failed to update caps for %s", name)
 	}
@@ -77,7 +84,8 @@ func AuthUpdateCaps(context *clusterd.Context, clusterName, name string, caps []
 func AuthGetCaps(context *clusterd.Context, clusterName, name string) (caps map[string]string, error error) {
 	logger.Infof("getting ceph auth caps for %q", name)
 	args := append([]string{"auth", "get", name})
-	output, err := NewCephCommand(context, clusterName, args).Run()
+	monCommand := map[string]interface{}{"prefix": "auth get", "entity": name}
+	output, err := newMonCommand(context, clusterName, monCommand, args).Run()
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to get caps for %q", name)
 	}
@@ -105,11 +113,26 @@ func AuthGetCaps(context *clusterd.Context, clusterName, name string) (caps map[
 	return caps, err
 }
 
+// AuthImport imports the users of the keyring, replacing the keys and the capabilities of the existing ones.
+func AuthImport(context *clusterd.Context, clusterName, keyringPath string) error {
+	logger.Infof("importing ceph auth keyring %q", keyringPath)
+	args := []string{"auth", "import", "-i", keyringPath}
+	cmd := NewCephCommand(context, clusterName, args)
+	cmd.JsonOutput = false
+	cmd.OutputFile = false
+	_, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to import keyring %s", keyringPath)
+	}
+	return nil
+}
+
 // AuthDelete will delete the given user.
 func AuthDelete(context *clusterd.Context, clusterName, name string) error {
 	logger.Infof("deleting ceph auth %q", name)
 	args := []string{"auth", "del", name}
-	_, err := NewCephCommand(context, clusterName, args).Run()
+	monCommand := map[string]interface{}{"prefix": "auth del", "entity": name}
+	_, err := newMonCommand(context, clusterName, monCommand, args).Run()
 	if err != nil {
 		return errors.Wrapf(err, "failed to delete auth for %s", name)
 	}
@@ -123,3 +146,59 @@ func parseAuthKey(buf []byte) (string, error) {
 	}
 	return resp["key"].(string), nil
 }
+
+// GenerateAuthKey generates a new key for a ceph user
+func GenerateAuthKey(context *clusterd.Context) (string, error) {
+	key, err := context.Executor.ExecuteCommandWithOutput("ceph-authtool", "--gen-print-key")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate a new key")
+	}
+	return strings.TrimSpace(key), nil
+}
+
+// AuthImportKey replaces the key of the existing user with the given key. The user is given the caps, a list of
+// daemon type and caps pairs, or keeps its current caps if none are given.
+func AuthImportKey(context *clusterd.Context, clusterName, name, key string, caps []string) error {
+	if caps == nil {
+		currentCaps, err := AuthGetCaps(context, clusterName, name)
+		if err != nil {
+			return err
+		}
+		for _, daemon := range []string{"mon", "mds", "mgr", "osd"} {
+			if daemonCaps, ok := currentCaps[daemon]; ok {
+				caps = append(caps, daemon, daemonCaps)
+			}
+		}
+	}
+
+	keyring := fmt.Sprintf("[%s]\n\tkey = %s\n", name, key)
+	for i := 0; i+1 < len(caps); i += 2 {
+		keyring += fmt.Sprintf("\tcaps %s = %q\n", caps[i], caps[i+1])
+	}
+	file, err := ioutil.TempFile("", "auth-keyring")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the keyring file")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(keyring)
+	file.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write the keyring file")
+	}
+
+	return AuthImport(context, clusterName, file.Name())
+}
+
+// AuthRotateKey replaces the key of the existing user with a new one, giving it the caps or keeping its current caps
+// if none are given. It returns the new key.
+func AuthRotateKey(context *clusterd.Context, clusterName, name string, caps []string) (string, error) {
+	key, err := GenerateAuthKey(context)
+	if err != nil {
+		return "", err
+	}
+	if err := AuthImportKey(context, clusterName, name, key, caps); err != nil {
+		return "", errors.Wrapf(err, "failed to rotate the key of %s", name)
+	}
+	logger.Infof("rotated ceph auth key %q", name)
+	return key, nil
+}
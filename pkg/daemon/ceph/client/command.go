@@ -19,10 +19,13 @@ package client
 import (
 	"fmt"
 	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
 )
 
 // RunAllCephCommandsInToolbox - when running the e2e tests, all ceph commands need to be run in the toolbox.
@@ -36,6 +39,8 @@ const (
 	CephTool = "ceph"
 	// RBDTool is the name of the CLI tool for 'rbd'
 	RBDTool = "rbd"
+	// RadosTool is the name of the CLI tool for 'rados'
+	RadosTool = "rados"
 	// Kubectl is the name of the CLI tool for 'kubectl'
 	Kubectl = "kubectl"
 	// CrushTool is the name of the CLI tool for 'crushtool'
@@ -46,6 +51,109 @@ const (
 	DefaultPGCount = "0"
 )
 
+// CommandSettings are the timeout and the circuit breaker settings of the commands run against the ceph clusters
+type CommandSettings struct {
+	// Timeout fails the commands not returning in time when the caller did not set a timeout, no timeout if not positive
+	Timeout time.Duration
+	// BreakerThreshold is the number of consecutive commands failing to reach a cluster after which the commands
+	// against the cluster fail immediately, the breaker being disabled if not positive
+	BreakerThreshold int
+	// BreakerBackoff is how long the commands fail before probing the cluster again, doubling up to BreakerMaxBackoff
+	// while the cluster is unreachable
+	BreakerBackoff    time.Duration
+	BreakerMaxBackoff time.Duration
+	// CephBindings runs the status, pool and auth commands with the go-ceph bindings when the operator is built with
+	// them, instead of running the ceph CLI
+	CephBindings bool
+}
+
+var (
+	commandSettings = DefaultCommandSettings()
+	breakers        = map[string]*exec.CircuitBreaker{}
+	breakersMutex   sync.Mutex
+)
+
+// DefaultCommandSettings returns the default command settings, the commands timing out after 2 minutes and failing
+// immediately after 3 commands did not reach the cluster
+func DefaultCommandSettings() CommandSettings {
+	return CommandSettings{
+		Timeout:           2 * time.Minute,
+		BreakerThreshold:  3,
+		BreakerBackoff:    30 * time.Second,
+		BreakerMaxBackoff: 5 * time.Minute,
+		CephBindings:      true,
+	}
+}
+
+// SetCommandSettings sets the command settings, resetting the circuit breakers of the clusters
+func SetCommandSettings(settings CommandSettings) {
+	logger.Infof("ceph commands time out after %s, failing for %s up to %s after %d commands did not reach the cluster",
+		settings.Timeout.String(), settings.BreakerBackoff.String(), settings.BreakerMaxBackoff.String(), settings.BreakerThreshold)
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	commandSettings = settings
+	breakers = map[string]*exec.CircuitBreaker{}
+}
+
+// IsClusterUnreachable returns whether the commands against the cluster fail immediately since it did not respond to
+// the last commands
+func IsClusterUnreachable(clusterName string) bool {
+	return clusterBreaker(clusterName).IsOpen()
+}
+
+// clusterBreaker returns the circuit breaker of the commands against a cluster
+func clusterBreaker(clusterName string) *exec.CircuitBreaker {
+	breakersMutex.Lock()
+	defer breakersMutex.Unlock()
+	breaker, ok := breakers[clusterName]
+	if !ok {
+		breaker = exec.NewCircuitBreaker(fmt.Sprintf("ceph cluster %q", clusterName),
+			commandSettings.BreakerThreshold, commandSettings.BreakerBackoff, commandSettings.BreakerMaxBackoff)
+		breakers[clusterName] = breaker
+	}
+	return breaker
+}
+
+// ExecuteClusterCommand runs a command against a cluster with the circuit breaker of the cluster, failing immediately
+// while the cluster is unreachable. Without a timeout, the command fails after the timeout of the command settings,
+// its process being killed so that the hung commands do not pile up.
+func ExecuteClusterCommand(context *clusterd.Context, clusterName string, timeout time.Duration, execute func(executor exec.Executor) (string, error)) (string, error) {
+	breaker := clusterBreaker(clusterName)
+	if err := breaker.Allow(); err != nil {
+		return "", err
+	}
+
+	executor := context.Executor
+	if timeout == 0 && commandSettings.Timeout > 0 {
+		timeoutExecutor, ok := executor.(*exec.TimeoutCommandExecutor)
+		if !ok {
+			executor = &exec.TimeoutCommandExecutor{Executor: executor, Timeout: commandSettings.Timeout}
+		} else if timeoutExecutor.Timeout <= 0 {
+			// the commands of a stoppable context keep failing when it is done
+			executor = &exec.TimeoutCommandExecutor{Executor: timeoutExecutor.Executor, Timeout: commandSettings.Timeout, Context: timeoutExecutor.Context}
+		}
+	}
+	output, err := execute(executor)
+	breaker.Record(isClusterUnreachable(output, err))
+	return output, err
+}
+
+// ExecuteClusterCommandWithOutput runs a command with output against a cluster, with the circuit breaker and the
+// timeout of the commands against the cluster
+func ExecuteClusterCommandWithOutput(context *clusterd.Context, clusterName, command string, args ...string) (string, error) {
+	return ExecuteClusterCommand(context, clusterName, 0, func(executor exec.Executor) (string, error) {
+		return executor.ExecuteCommandWithOutput(command, args...)
+	})
+}
+
+// isClusterUnreachable returns whether a command failed to reach the cluster, timing out or failing to connect
+func isClusterUnreachable(output string, err error) bool {
+	if err == nil {
+		return false
+	}
+	return exec.IsTimeout(err) || strings.Contains(output, "error connecting to the cluster") || strings.Contains(output, "RADOS timed out")
+}
+
 // CephConfFilePath returns the location to the cluster's config file in the operator container.
 func CephConfFilePath(configDir, clusterName string) string {
 	confFile := fmt.Sprintf("%s.config", clusterName)
@@ -54,14 +162,14 @@ func CephConfFilePath(configDir, clusterName string) string {
 
 // FinalizeCephCommandArgs builds the command line to be called
 func FinalizeCephCommandArgs(command string, args []string, configDir, clusterName, username string) (string, []string) {
-	// the rbd client tool does not support the '--connect-timeout' option
+	// the rbd and rados client tools do not support the '--connect-timeout' option
 	// so we only use it for the 'ceph' command
 	// Also, there is no point of adding that option to 'crushtool' since that CLI does not connect to anything
 	// 'crushtool' is a utility that lets you create, compile, decompile and test CRUSH map files.
 
 	// we could use a slice and iterate over it but since we have only 3 elements
 	// I don't think this is worth a loop
-	if command != "rbd" && command != "crushtool" && command != "radosgw-admin" {
+	if command != "rbd" && command != "crushtool" && command != "radosgw-admin" && command != "rados" {
 		args = append(args, "--connect-timeout="+cephConnectionTimeout)
 	}
 
@@ -96,6 +204,8 @@ type CephToolCommand struct {
 	JsonOutput  bool
 	OutputFile  bool
 	authUser    string
+	// monCommand is the mon command run with the go-ceph bindings instead of the args of the ceph CLI if set
+	monCommand map[string]interface{}
 }
 
 func newCephToolCommand(tool string, context *clusterd.Context, clusterName string, args []string) *CephToolCommand {
@@ -121,7 +231,24 @@ func NewRBDCommand(context *clusterd.Context, clusterName string, args []string)
 	return cmd
 }
 
+// NewRadosCommand returns a rados command with a JSON output read from stdout
+func NewRadosCommand(context *clusterd.Context, clusterName string, args []string) *CephToolCommand {
+	cmd := newCephToolCommand(RadosTool, context, clusterName, args)
+	cmd.OutputFile = false
+	return cmd
+}
+
 func (c *CephToolCommand) run() ([]byte, error) {
+	if c.monCommand != nil && c.tool == CephTool {
+		monCommand := c.monCommand
+		if c.JsonOutput {
+			monCommand["format"] = "json"
+		}
+		if output, ok, err := runMonCommand(c.context, c.clusterName, c.authUser, monCommand); ok {
+			return output, err
+		}
+	}
+
 	command, args := FinalizeCephCommandArgs(c.tool, c.args, c.context.ConfigDir, c.clusterName, c.authUser)
 	if c.JsonOutput {
 		args = append(args, "--format", "json")
@@ -132,33 +259,27 @@ func (c *CephToolCommand) run() ([]byte, error) {
 		}
 	}
 
-	var output string
-	var err error
-
-	if c.OutputFile {
-		if command == Kubectl {
-			// Kubectl commands targeting the toolbox container generate a temp
-			// file in the wrong place, so we will instead capture the output
-			// from stdout for the tests
-			if c.timeout == 0 {
-				output, err = c.context.Executor.ExecuteCommandWithOutput(command, args...)
-			} else {
-				output, err = c.context.Executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
+	output, err := ExecuteClusterCommand(c.context, c.clusterName, c.timeout, func(executor exec.Executor) (string, error) {
+		if c.OutputFile {
+			if command == Kubectl {
+				// Kubectl commands targeting the toolbox container generate a temp
+				// file in the wrong place, so we will instead capture the output
+				// from stdout for the tests
+				if c.timeout == 0 {
+					return executor.ExecuteCommandWithOutput(command, args...)
+				}
+				return executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
 			}
-		} else {
 			if c.timeout == 0 {
-				output, err = c.context.Executor.ExecuteCommandWithOutputFile(command, "--out-file", args...)
-			} else {
-				output, err = c.context.Executor.ExecuteCommandWithOutputFileTimeout(c.timeout, command, "--out-file", args...)
+				return executor.ExecuteCommandWithOutputFile(command, "--out-file", args...)
 			}
+			return executor.ExecuteCommandWithOutputFileTimeout(c.timeout, command, "--out-file", args...)
 		}
-	} else {
 		if c.timeout == 0 {
-			output, err = c.context.Executor.ExecuteCommandWithOutput(command, args...)
-		} else {
-			output, err = c.context.Executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
+			return executor.ExecuteCommandWithOutput(command, args...)
 		}
-	}
+		return executor.ExecuteCommandWithTimeout(c.timeout, command, args...)
+	})
 
 	return []byte(output), err
 }
@@ -17,8 +17,16 @@ limitations under the License.
 package client
 
 import (
+	gocontext "context"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -110,3 +118,84 @@ func TestFinalizeCephCommandArgsClusterDefaultName(t *testing.T) {
 	assert.Exactly(t, expectedCommand, cmd)
 	assert.Exactly(t, expectedArgs, args)
 }
+
+func TestCommandCircuitBreaker(t *testing.T) {
+	defer SetCommandSettings(DefaultCommandSettings())
+	SetCommandSettings(CommandSettings{Timeout: 50 * time.Millisecond, BreakerThreshold: 2, BreakerBackoff: time.Hour, BreakerMaxBackoff: time.Hour})
+	var calls int32
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			atomic.AddInt32(&calls, 1)
+			// the mons of the rook-ceph cluster never answer
+			if strings.Contains(strings.Join(args, " "), "--cluster=rook-ceph ") {
+				time.Sleep(time.Second)
+			}
+			return `{"health":{"status":"HEALTH_OK"}}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the hung commands time out
+	_, err := Status(context, "rook-ceph")
+	assert.True(t, exec.IsTimeout(err))
+	assert.False(t, IsClusterUnreachable("rook-ceph"))
+	_, err = Status(context, "rook-ceph")
+	assert.True(t, exec.IsTimeout(err))
+	assert.True(t, IsClusterUnreachable("rook-ceph"))
+
+	// the commands then fail without running while the cluster is unreachable
+	_, err = Status(context, "rook-ceph")
+	assert.True(t, exec.IsCircuitOpen(err))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// the commands against the other clusters still run
+	_, err = Status(context, "other-cluster")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+// killableExecutor is a mock executor whose output file commands hang until their context is done
+type killableExecutor struct {
+	exectest.MockExecutor
+	killed int32
+}
+
+func (e *killableExecutor) ExecuteCommandWithEnvContext(ctx gocontext.Context, env []string, command string, arg ...string) error {
+	return e.ExecuteCommandWithEnv(env, command, arg...)
+}
+
+func (e *killableExecutor) ExecuteCommandWithOutputContext(ctx gocontext.Context, command string, arg ...string) (string, error) {
+	return e.ExecuteCommandWithOutput(command, arg...)
+}
+
+func (e *killableExecutor) ExecuteCommandWithCombinedOutputContext(ctx gocontext.Context, command string, arg ...string) (string, error) {
+	return e.ExecuteCommandWithCombinedOutput(command, arg...)
+}
+
+func (e *killableExecutor) ExecuteCommandWithOutputFileContext(ctx gocontext.Context, command, outfileArg string, arg ...string) (string, error) {
+	<-ctx.Done()
+	atomic.AddInt32(&e.killed, 1)
+	return "", errors.New("signal: killed")
+}
+
+func TestCommandTimeoutKillsProcess(t *testing.T) {
+	defer SetCommandSettings(DefaultCommandSettings())
+	SetCommandSettings(CommandSettings{Timeout: 50 * time.Millisecond})
+	executor := &killableExecutor{}
+	context := &clusterd.Context{Executor: executor}
+
+	// the command times out and its process is killed instead of running in the background
+	_, err := Status(context, "rook-ceph")
+	assert.True(t, exec.IsTimeout(err))
+	for i := 0; i < 50 && atomic.LoadInt32(&executor.killed) == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&executor.killed))
+}
+
+func TestIsClusterUnreachable(t *testing.T) {
+	assert.False(t, isClusterUnreachable("", nil))
+	assert.False(t, isClusterUnreachable("Error EINVAL: invalid command", errors.New("exit status 22")))
+	assert.True(t, isClusterUnreachable("[errno 110] RADOS timed out (error connecting to the cluster)", errors.New("exit status 1")))
+	assert.True(t, isClusterUnreachable("", &exec.TimeoutError{Command: "ceph", Timeout: time.Second}))
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// CrashInfo is a crash of a ceph daemon reported by the mgr crash module
+type CrashInfo struct {
+	ID        string `json:"crash_id"`
+	Entity    string `json:"entity_name"`
+	Timestamp string `json:"timestamp"`
+}
+
+// GetNewCrashes returns the crashes not archived yet
+func GetNewCrashes(context *clusterd.Context, clusterName string) ([]CrashInfo, error) {
+	args := []string{"crash", "ls-new"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the new crashes")
+	}
+
+	var crashes []CrashInfo
+	if err := json.Unmarshal(buf, &crashes); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal crash ls-new response")
+	}
+
+	return crashes, nil
+}
+
+// ArchiveCrash archives a crash, which is then no longer reported by the RECENT_CRASH health check
+func ArchiveCrash(context *clusterd.Context, clusterName, crashID string) error {
+	args := []string{"crash", "archive", crashID}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to archive crash %q", crashID)
+	}
+
+	return nil
+}
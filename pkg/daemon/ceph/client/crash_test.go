@@ -0,0 +1,40 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetNewCrashes(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outfileArg string, args ...string) (string, error) {
+		assert.Equal(t, "crash", args[0])
+		assert.Equal(t, "ls-new", args[1])
+		return `[{"crash_id":"2020-06-01_10:00:00.000000Z_1234","entity_name":"osd.1","timestamp":"2020-06-01 10:00:00.000000Z"}]`, nil
+	}
+
+	crashes, err := GetNewCrashes(&clusterd.Context{Executor: executor}, "rook-ceph")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(crashes))
+	assert.Equal(t, "osd.1", crashes[0].Entity)
+	assert.Equal(t, "2020-06-01_10:00:00.000000Z_1234", crashes[0].ID)
+}
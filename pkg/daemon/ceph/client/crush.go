@@ -174,6 +174,17 @@ func formatProperty(name, value string) string {
 	return fmt.Sprintf("%s=%s", name, value)
 }
 
+// MoveCrushBucket moves an existing bucket of the CRUSH map such as a host to the given location
+func MoveCrushBucket(context *clusterd.Context, clusterName, name string, location []string) error {
+	args := append([]string{"osd", "crush", "move", name}, location...)
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to move crush bucket %q to %v. %s", name, location, string(buf))
+	}
+
+	return nil
+}
+
 // GetOSDOnHost returns the list of osds running on a given host
 func GetOSDOnHost(context *clusterd.Context, clusterName, node string) (string, error) {
 	args := []string{"osd", "crush", "ls", node}
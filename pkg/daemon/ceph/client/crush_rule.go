@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// stretchCrushRuleTemplate places two replicas on different hosts in each of the two data zones
+const stretchCrushRuleTemplate = `
+rule %s {
+	id %d
+	type replicated
+	min_size 1
+	max_size 10
+	step take default
+	step choose firstn 0 type %s
+	step chooseleaf firstn 2 type host
+	step emit
+}
+`
+
+// hybridCrushRuleTemplate places the primary replica on the osds of a device class and the other replicas on the osds
+// of another device class
+const hybridCrushRuleTemplate = `
+rule %s {
+	id %d
+	type replicated
+	min_size 1
+	max_size 10
+	step take %s class %s
+	step chooseleaf firstn 1 type %s
+	step emit
+	step take %s class %s
+	step chooseleaf firstn -1 type %s
+	step emit
+}
+`
+
+// CreateStretchCrushRule creates the CRUSH rule of a stretch cluster, replicating the data across the two data zones of
+// the given bucket type. Since the rule needs two choose steps, it cannot be created with the ceph CLI.
+func CreateStretchCrushRule(context *clusterd.Context, clusterName, ruleName, bucketType string) error {
+	err := createCrushRule(context, clusterName, ruleName, func(ruleID int) string {
+		return fmt.Sprintf(stretchCrushRuleTemplate, ruleName, ruleID, bucketType)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("created the stretch crush rule %q across the %s buckets", ruleName, bucketType)
+	return nil
+}
+
+// CreateHybridCrushRule creates the CRUSH rule of a hybrid pool, placing the primary replica on the osds of the primary
+// device class and the other replicas on the osds of the secondary device class. Since the rule needs two take steps,
+// it cannot be created with the ceph CLI.
+func CreateHybridCrushRule(context *clusterd.Context, clusterName, ruleName, crushRoot, failureDomain, primaryDeviceClass, secondaryDeviceClass string) error {
+	err := createCrushRule(context, clusterName, ruleName, func(ruleID int) string {
+		return fmt.Sprintf(hybridCrushRuleTemplate, ruleName, ruleID, crushRoot, primaryDeviceClass, failureDomain, crushRoot, secondaryDeviceClass, failureDomain)
+	})
+	if err != nil {
+		return err
+	}
+	logger.Infof("created the hybrid crush rule %q with the primary replica on %q and the other replicas on %q", ruleName, primaryDeviceClass, secondaryDeviceClass)
+	return nil
+}
+
+// createCrushRule adds a rule to the CRUSH map unless a rule of the same name exists: the CRUSH map is decompiled, the
+// rule is appended to it and the map is compiled and set back
+func createCrushRule(context *clusterd.Context, clusterName, ruleName string, rule func(ruleID int) string) error {
+	crushMap, err := GetCrushMap(context, clusterName)
+	if err != nil {
+		return err
+	}
+
+	ruleID := 0
+	for _, rule := range crushMap.Rules {
+		if rule.Name == ruleName {
+			logger.Debugf("crush rule %q already exists", ruleName)
+			return nil
+		}
+		if rule.ID >= ruleID {
+			ruleID = rule.ID + 1
+		}
+	}
+
+	dir, err := ioutil.TempDir("", "crushmap")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the crush map directory")
+	}
+	defer os.RemoveAll(dir)
+	compiledPath := path.Join(dir, "crushmap")
+	decompiledPath := path.Join(dir, "crushmap.txt")
+
+	if err := runCrushMapCommand(context, clusterName, CephTool, "osd", "getcrushmap", "--out-file", compiledPath); err != nil {
+		return errors.Wrap(err, "failed to get the crush map")
+	}
+	if err := runCrushMapCommand(context, clusterName, CrushTool, "--decompile", compiledPath, "--outfn", decompiledPath); err != nil {
+		return errors.Wrap(err, "failed to decompile the crush map")
+	}
+
+	decompiled, err := ioutil.ReadFile(decompiledPath)
+	if err != nil {
+		return errors.Wrap(err, "failed to read the decompiled crush map")
+	}
+	decompiled = append(decompiled, []byte(rule(ruleID))...)
+	if err := ioutil.WriteFile(decompiledPath, decompiled, 0600); err != nil {
+		return errors.Wrap(err, "failed to write the decompiled crush map")
+	}
+
+	if err := runCrushMapCommand(context, clusterName, CrushTool, "--compile", decompiledPath, "--outfn", compiledPath); err != nil {
+		return errors.Wrapf(err, "failed to compile the crush map with the rule %q", ruleName)
+	}
+	if err := runCrushMapCommand(context, clusterName, CephTool, "osd", "setcrushmap", "--in-file", compiledPath); err != nil {
+		return errors.Wrapf(err, "failed to set the crush map with the rule %q", ruleName)
+	}
+	return nil
+}
+
+// runCrushMapCommand runs a command reading or writing a crush map file
+func runCrushMapCommand(context *clusterd.Context, clusterName, tool string, args ...string) error {
+	command, args := FinalizeCephCommandArgs(tool, args, context.ConfigDir, clusterName, AdminUsername)
+	return context.Executor.ExecuteCommand(command, args...)
+}
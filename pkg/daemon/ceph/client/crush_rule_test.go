@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateStretchCrushRule(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return testCrushMap, nil
+		}
+		return "", errors.Errorf("unexpected ceph command '%v'", args)
+	}
+	commands := []string{}
+	compiledRule := ""
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		logger.Infof("Command: %s %v", command, args)
+		commands = append(commands, command+" "+args[0])
+		switch args[0] {
+		case "--decompile":
+			return ioutil.WriteFile(args[3], []byte("# begin crush map\n# end crush map\n"), 0600)
+		case "--compile":
+			text, err := ioutil.ReadFile(args[1])
+			compiledRule = string(text)
+			return err
+		}
+		return nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := CreateStretchCrushRule(context, "rook", "stretch_rule", "zone")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ceph osd", "crushtool --decompile", "crushtool --compile", "ceph osd"}, commands)
+	assert.Contains(t, compiledRule, "# end crush map\n\nrule stretch_rule {\n\tid 2\n")
+	assert.Contains(t, compiledRule, "step choose firstn 0 type zone\n")
+
+	// the rule is not created again
+	commands = []string{}
+	err = CreateStretchCrushRule(context, "rook", "replicated_ruleset", "zone")
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+
+	// the crush map is not set when it fails to compile
+	commands = []string{}
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		commands = append(commands, command+" "+args[0])
+		if args[0] == "--decompile" {
+			return ioutil.WriteFile(args[3], []byte{}, 0600)
+		}
+		if args[0] == "--compile" {
+			return errors.New("invalid rule")
+		}
+		return nil
+	}
+	err = CreateStretchCrushRule(context, "rook", "stretch_rule", "zone")
+	assert.Error(t, err)
+	assert.Equal(t, []string{"ceph osd", "crushtool --decompile", "crushtool --compile"}, commands)
+}
+
+func TestCreateHybridCrushRule(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return testCrushMap, nil
+		}
+		return "", errors.Errorf("unexpected ceph command '%v'", args)
+	}
+	compiledRule := ""
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		switch args[0] {
+		case "--decompile":
+			return ioutil.WriteFile(args[3], []byte("# end crush map\n"), 0600)
+		case "--compile":
+			text, err := ioutil.ReadFile(args[1])
+			compiledRule = string(text)
+			return err
+		}
+		return nil
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := CreateHybridCrushRule(context, "rook", "hybridpool", "default", "host", "ssd", "hdd")
+	assert.NoError(t, err)
+	assert.Contains(t, compiledRule, "rule hybridpool {\n\tid 2\n")
+	assert.Contains(t, compiledRule, "\tstep take default class ssd\n\tstep chooseleaf firstn 1 type host\n\tstep emit\n")
+	assert.Contains(t, compiledRule, "\tstep take default class hdd\n\tstep chooseleaf firstn -1 type host\n\tstep emit\n")
+}
@@ -65,33 +65,45 @@ func GetErasureCodeProfileDetails(context *clusterd.Context, namespace, name str
 	return ecProfileDetails, nil
 }
 
+// CreateErasureCodeProfile creates the erasure code profile of the pool, replacing the profile of the same name
+// if it exists. The pools created from the profile before keep their layout.
 func CreateErasureCodeProfile(context *clusterd.Context, namespace, profileName string, pool cephv1.PoolSpec) error {
-	// look up the default profile so we can use the default plugin/technique
-	defaultProfile, err := GetErasureCodeProfileDetails(context, namespace, "default")
-	if err != nil {
-		return errors.Wrap(err, "failed to look up default erasure code profile")
+	plugin := pool.ErasureCoded.Plugin
+	technique := pool.ErasureCoded.Technique
+	if plugin == "" {
+		// look up the default profile so we can use the default plugin/technique
+		defaultProfile, err := GetErasureCodeProfileDetails(context, namespace, "default")
+		if err != nil {
+			return errors.Wrap(err, "failed to look up default erasure code profile")
+		}
+		plugin = defaultProfile.Plugin
+		technique = defaultProfile.Technique
 	}
 
 	// define the profile with a set of key/value pairs
 	profilePairs := []string{
 		fmt.Sprintf("k=%d", pool.ErasureCoded.DataChunks),
 		fmt.Sprintf("m=%d", pool.ErasureCoded.CodingChunks),
-		fmt.Sprintf("plugin=%s", defaultProfile.Plugin),
-		fmt.Sprintf("technique=%s", defaultProfile.Technique),
+		fmt.Sprintf("plugin=%s", plugin),
+	}
+	if technique != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("technique=%s", technique))
 	}
-	if pool.FailureDomain != "" {
-		profilePairs = append(profilePairs, fmt.Sprintf("crush-failure-domain=%s", pool.FailureDomain))
+	if failureDomain := pool.GetFailureDomain(); failureDomain != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("crush-failure-domain=%s", failureDomain))
 	}
-	if pool.CrushRoot != "" {
-		profilePairs = append(profilePairs, fmt.Sprintf("crush-root=%s", pool.CrushRoot))
+	if crushRoot := pool.GetCrushRoot(); crushRoot != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("crush-root=%s", crushRoot))
 	}
-	if pool.DeviceClass != "" {
-		profilePairs = append(profilePairs, fmt.Sprintf("crush-device-class=%s", pool.DeviceClass))
+	if deviceClass := pool.GetDeviceClass(); deviceClass != "" {
+		profilePairs = append(profilePairs, fmt.Sprintf("crush-device-class=%s", deviceClass))
 	}
 
+	// an existing profile with other settings is only replaced when forced
 	args := []string{"osd", "erasure-code-profile", "set", profileName}
 	args = append(args, profilePairs...)
-	_, err = NewCephCommand(context, namespace, args).Run()
+	args = append(args, "--force")
+	_, err := NewCephCommand(context, namespace, args).Run()
 	if err != nil {
 		return errors.Wrap(err, "failed to set ec-profile")
 	}
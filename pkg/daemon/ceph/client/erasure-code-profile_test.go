@@ -87,3 +87,33 @@ func testCreateProfile(t *testing.T, failureDomain, crushRoot, deviceClass strin
 	err := CreateErasureCodeProfile(context, "myns", "myapp", spec)
 	assert.Nil(t, err)
 }
+
+func TestCreateProfileWithPlugin(t *testing.T) {
+	spec := cephv1.PoolSpec{
+		FailureDomain: "host",
+		DeviceClass:   "hdd",
+		ErasureCoded: cephv1.ErasureCodedSpec{
+			DataChunks:    4,
+			CodingChunks:  2,
+			Plugin:        "clay",
+			FailureDomain: "rack",
+			DeviceClass:   "ssd",
+		},
+	}
+
+	var setArgs []string
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "erasure-code-profile" && args[2] == "set" {
+			setArgs = args[3:10]
+			return "", nil
+		}
+		// the default profile is not needed with the plugin set
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := CreateErasureCodeProfile(context, "myns", "myapp", spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myapp", "k=4", "m=2", "plugin=clay", "crush-failure-domain=rack", "crush-device-class=ssd", "--force"}, setArgs)
+}
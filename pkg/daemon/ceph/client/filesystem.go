@@ -73,6 +73,26 @@ type MDSInfo struct {
 	Rank    int    `json:"rank"`
 	State   string `json:"state"`
 	Address string `json:"addr"`
+	// LaggySince is set when the mds is not reporting to the mons
+	LaggySince string `json:"laggy_since,omitempty"`
+}
+
+// FSMap is a representation of the json structure returned by 'ceph fs dump'
+type FSMap struct {
+	Epoch       int                     `json:"epoch"`
+	Standbys    []MDSInfo               `json:"standbys"`
+	Filesystems []CephFilesystemDetails `json:"filesystems"`
+}
+
+// FilesystemStatus is a representation of the json structure returned by 'ceph fs status'
+type FilesystemStatus struct {
+	Clients []FilesystemClients `json:"clients"`
+}
+
+// FilesystemClients is the number of client sessions of a filesystem, as returned by 'ceph fs status'
+type FilesystemClients struct {
+	Clients    int    `json:"clients"`
+	Filesystem string `json:"fs"`
 }
 
 // ListFilesystems lists all filesystems provided by the Ceph cluster.
@@ -109,6 +129,45 @@ func GetFilesystem(context *clusterd.Context, clusterName string, fsName string)
 	return &fs, nil
 }
 
+// DumpFilesystems gets the mds maps of all the filesystems and the standby mds daemons of the cluster.
+func DumpFilesystems(context *clusterd.Context, clusterName string) (*FSMap, error) {
+	args := []string{"fs", "dump"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dump filesystems")
+	}
+
+	var fsMap FSMap
+	err = json.Unmarshal(buf, &fsMap)
+	if err != nil {
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+
+	return &fsMap, nil
+}
+
+// GetFilesystemClientCount gets the number of client sessions of a filesystem, as reported by the mgr.
+func GetFilesystemClientCount(context *clusterd.Context, clusterName string, fsName string) (int, error) {
+	args := []string{"fs", "status", fsName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get status of file system %s", fsName)
+	}
+
+	var status FilesystemStatus
+	err = json.Unmarshal(buf, &status)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
+	}
+
+	for _, clients := range status.Clients {
+		if clients.Filesystem == fsName {
+			return clients.Clients, nil
+		}
+	}
+	return 0, nil
+}
+
 // AllowStandbyReplay gets detailed status information about a Ceph filesystem.
 func AllowStandbyReplay(context *clusterd.Context, clusterName string, fsName string, allowStandbyReplay bool) error {
 	logger.Infof("setting allow_standby_replay for filesystem %q", fsName)
@@ -155,17 +214,24 @@ func CreateFilesystem(context *clusterd.Context, clusterName, name, metadataPool
 
 	// add each additional pool
 	for i := 1; i < len(dataPools); i++ {
-		poolName := dataPools[i]
-		args = []string{"fs", "add_data_pool", name, poolName}
-		_, err = NewCephCommand(context, clusterName, args).Run()
-		if err != nil {
-			logger.Errorf("failed to add pool %q to file system %q. %v", poolName, name, err)
+		if err := AddDataPool(context, clusterName, name, dataPools[i]); err != nil {
+			logger.Errorf("%v", err)
 		}
 	}
 
 	return nil
 }
 
+// AddDataPool adds a data pool to a filesystem, the directories of the filesystem being placed on the pool with their
+// layout
+func AddDataPool(context *clusterd.Context, clusterName, fsName, poolName string) error {
+	args := []string{"fs", "add_data_pool", fsName, poolName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to add pool %q to file system %q", poolName, fsName)
+	}
+	return nil
+}
+
 // IsMultiFSEnabled returns true if ROOK_ALLOW_MULTIPLE_FILESYSTEMS is set to "true", allowing
 // Rook to create multiple Ceph filesystems. False if Rook is not allowed to do so.
 func IsMultiFSEnabled() bool {
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// FilesystemMirroringModuleName is the mgr module managing the snapshot mirroring of the filesystems
+	FilesystemMirroringModuleName = "mirroring"
+	// fsMirrorRemoteUser is the user created in the cluster of a bootstrap peer token for the peers to access the filesystem
+	fsMirrorRemoteUser = "client.mirror_remote"
+)
+
+// fsBootstrapPeerToken is the json structure returned by 'ceph fs snapshot mirror peer_bootstrap create'
+type fsBootstrapPeerToken struct {
+	Token string `json:"token"`
+}
+
+// EnableFilesystemSnapshotMirror enables the snapshot mirroring of the filesystem
+func EnableFilesystemSnapshotMirror(context *clusterd.Context, clusterName, fsName string) error {
+	logger.Infof("enabling snapshot mirroring of filesystem %q", fsName)
+	args := []string{"fs", "snapshot", "mirror", "enable", fsName}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable snapshot mirroring of filesystem %q", fsName)
+	}
+	return nil
+}
+
+// DisableFilesystemSnapshotMirror disables the snapshot mirroring of the filesystem
+func DisableFilesystemSnapshotMirror(context *clusterd.Context, clusterName, fsName string) error {
+	logger.Infof("disabling snapshot mirroring of filesystem %q", fsName)
+	args := []string{"fs", "snapshot", "mirror", "disable", fsName}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to disable snapshot mirroring of filesystem %q", fsName)
+	}
+	return nil
+}
+
+// CreateFSMirrorBootstrapPeer creates the bootstrap peer token of the filesystem, to import in the peer clusters
+func CreateFSMirrorBootstrapPeer(context *clusterd.Context, clusterName, fsName, siteName string) ([]byte, error) {
+	args := []string{"fs", "snapshot", "mirror", "peer_bootstrap", "create", fsName, fsMirrorRemoteUser, siteName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create bootstrap peer token of filesystem %q", fsName)
+	}
+
+	var token fsBootstrapPeerToken
+	if err := json.Unmarshal(buf, &token); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal bootstrap peer token of filesystem %q", fsName)
+	}
+	return []byte(token.Token), nil
+}
+
+// ImportFSMirrorBootstrapPeer imports the bootstrap peer token of a remote cluster, for the snapshots of the filesystem
+// to be mirrored to that cluster
+func ImportFSMirrorBootstrapPeer(context *clusterd.Context, clusterName, fsName string, token []byte) error {
+	logger.Infof("importing bootstrap peer token of filesystem %q", fsName)
+	args := []string{"fs", "snapshot", "mirror", "peer_bootstrap", "import", fsName, string(token)}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to import bootstrap peer token of filesystem %q", fsName)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnableFilesystemSnapshotMirror(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			assert.Equal(t, []string{"fs", "snapshot", "mirror", "enable", "myfs"}, args[:5])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, EnableFilesystemSnapshotMirror(context, "foocluster", "myfs"))
+}
+
+func TestCreateFSMirrorBootstrapPeer(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[3] == "peer_bootstrap" && args[4] == "create" {
+				assert.Equal(t, []string{"myfs", "client.mirror_remote", "site1"}, args[5:8])
+				return `{"token": "eyJmc2lkIjogIjBkZjE3MjE3In0="}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	token, err := CreateFSMirrorBootstrapPeer(context, "foocluster", "myfs", "site1")
+	assert.NoError(t, err)
+	assert.Equal(t, "eyJmc2lkIjogIjBkZjE3MjE3In0=", string(token))
+}
+
+func TestImportFSMirrorBootstrapPeer(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			assert.Equal(t, []string{"fs", "snapshot", "mirror", "peer_bootstrap", "import", "myfs", "token"}, args[:7])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, ImportFSMirrorBootstrapPeer(context, "foocluster", "myfs", []byte("token")))
+}
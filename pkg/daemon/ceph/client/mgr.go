@@ -17,7 +17,10 @@ limitations under the License.
 package client
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -27,8 +30,25 @@ import (
 
 var (
 	moduleEnableWaitTime = 5 * time.Second
+
+	// balancerScoreRegex extracts the score of the output of "ceph balancer eval"
+	balancerScoreRegex = regexp.MustCompile(`score ([0-9.eE+-]+)`)
+)
+
+const (
+	balancerModeOff = "off"
+	// balancerMaxMisplacedOption is the max ratio of misplaced PGs of the balancer
+	balancerMaxMisplacedOption = "target_max_misplaced_ratio"
+	// telemetryLicense is the license of the data shared with the telemetry, required to turn it on
+	telemetryLicense = "sharing-1-0"
 )
 
+// BalancerStatus is the output of "ceph balancer status"
+type BalancerStatus struct {
+	Active bool   `json:"active"`
+	Mode   string `json:"mode"`
+}
+
 // MgrEnableModule enables a mgr module
 func MgrEnableModule(context *clusterd.Context, clusterName, name string, force bool) error {
 	retryCount := 5
@@ -172,3 +192,103 @@ func ConfigureBalancerModule(context *clusterd.Context, clusterName, balancerMod
 
 	return nil
 }
+
+// ApplyBalancerMode sets the mode of the balancer and turns it on, or turns it off with the "off" mode
+func ApplyBalancerMode(context *clusterd.Context, clusterName, mode string) error {
+	if mode == balancerModeOff {
+		return MgrDisableModule(context, clusterName, "balancer")
+	}
+	if err := ConfigureBalancerModule(context, clusterName, mode); err != nil {
+		return err
+	}
+	return MgrEnableModule(context, clusterName, "balancer", false)
+}
+
+// GetBalancerStatus returns the mode of the balancer and whether it is on
+func GetBalancerStatus(context *clusterd.Context, clusterName string) (*BalancerStatus, error) {
+	args := []string{"balancer", "status"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the balancer status")
+	}
+
+	var status BalancerStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the balancer status")
+	}
+	return &status, nil
+}
+
+// GetBalancerScore returns the score of the current distribution of the PGs, lower is better
+func GetBalancerScore(context *clusterd.Context, clusterName string) (float64, error) {
+	args := []string{"balancer", "eval"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to evaluate the balancer score")
+	}
+
+	match := balancerScoreRegex.FindStringSubmatch(string(buf))
+	if match == nil {
+		return 0, errors.Errorf("failed to find the balancer score in %q", string(buf))
+	}
+	score, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse the balancer score %q", match[1])
+	}
+	return score, nil
+}
+
+// GetBalancerMaxMisplaced returns the max ratio of the PGs misplaced by the balancer at once
+func GetBalancerMaxMisplaced(context *clusterd.Context, clusterName string) (float64, error) {
+	args := []string{"config", "get", "mgr", balancerMaxMisplacedOption}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to get %s", balancerMaxMisplacedOption)
+	}
+	ratio, err := strconv.ParseFloat(strings.TrimSpace(string(buf)), 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse %s %q", balancerMaxMisplacedOption, string(buf))
+	}
+	return ratio, nil
+}
+
+// SetBalancerMaxMisplaced sets the max ratio of the PGs misplaced by the balancer at once
+func SetBalancerMaxMisplaced(context *clusterd.Context, clusterName string, ratio float64) error {
+	args := []string{"config", "set", "mgr", balancerMaxMisplacedOption, strconv.FormatFloat(ratio, 'f', -1, 64)}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to set %s to %v", balancerMaxMisplacedOption, ratio)
+	}
+	return nil
+}
+
+// TelemetryStatus is the output of "ceph telemetry status"
+type TelemetryStatus struct {
+	Enabled bool `json:"enabled"`
+}
+
+// GetTelemetryStatus returns whether the telemetry is on
+func GetTelemetryStatus(context *clusterd.Context, clusterName string) (*TelemetryStatus, error) {
+	args := []string{"telemetry", "status"}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the telemetry status")
+	}
+
+	var status TelemetryStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the telemetry status")
+	}
+	return &status, nil
+}
+
+// SetTelemetry turns the telemetry on, accepting the license of the shared data, or off
+func SetTelemetry(context *clusterd.Context, clusterName string, enabled bool) error {
+	args := []string{"telemetry", "off"}
+	if enabled {
+		args = []string{"telemetry", "on", "--license", telemetryLicense}
+	}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to turn %q the telemetry", args[1])
+	}
+	return nil
+}
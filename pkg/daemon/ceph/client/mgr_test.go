@@ -133,3 +133,36 @@ func TestSetBalancerMode(t *testing.T) {
 	err := setBalancerMode(&clusterd.Context{Executor: executor}, "clusterName", "upmap")
 	assert.NoError(t, err)
 }
+
+func TestBalancerStatusAndScore(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		switch {
+		case args[0] == "balancer" && args[1] == "status":
+			return `{"active":true,"last_optimize_duration":"0:00:00.001","mode":"crush-compat","plans":[]}`, nil
+		case args[0] == "balancer" && args[1] == "eval":
+			return "current cluster score 0.023456 (lower is better)", nil
+		case args[0] == "config" && args[1] == "get" && args[3] == "target_max_misplaced_ratio":
+			return "0.050000\n", nil
+		case args[0] == "config" && args[1] == "set" && args[3] == "target_max_misplaced_ratio":
+			assert.Equal(t, "0.02", args[4])
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetBalancerStatus(context, "clusterName")
+	assert.NoError(t, err)
+	assert.Equal(t, &BalancerStatus{Active: true, Mode: "crush-compat"}, status)
+
+	score, err := GetBalancerScore(context, "clusterName")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.023456, score)
+
+	ratio, err := GetBalancerMaxMisplaced(context, "clusterName")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.05, ratio)
+	assert.NoError(t, SetBalancerMaxMisplaced(context, "clusterName", 0.02))
+}
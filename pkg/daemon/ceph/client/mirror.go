@@ -0,0 +1,244 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+// rbdStartTimeLayouts are the formats of the start times of the rbd snapshot schedules
+var rbdStartTimeLayouts = []string{"15:04:05-07:00", "15:04:05-0700", "15:04-07:00", "15:04:05", "15:04"}
+
+// rbdSnapshotSchedule is a schedule listed by 'rbd mirror snapshot schedule ls'
+type rbdSnapshotSchedule struct {
+	Interval  string `json:"interval"`
+	StartTime string `json:"start_time"`
+}
+
+// PoolMirroringStatus is the mirroring status of a pool
+type PoolMirroringStatus struct {
+	Summary cephv1.PoolMirroringStatusSummarySpec `json:"summary"`
+}
+
+// EnablePoolMirroring enables the rbd mirroring of the pool in the mode of its mirroring spec
+func EnablePoolMirroring(context *clusterd.Context, clusterName string, pool cephv1.PoolSpec, poolName string) error {
+	logger.Infof("enabling mirroring of pool %q in mode %q", poolName, pool.Mirroring.Mode)
+	args := []string{"mirror", "pool", "enable", poolName, pool.Mirroring.Mode}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable mirroring of pool %q. %s", poolName, string(output))
+	}
+	return nil
+}
+
+// DisablePoolMirroring disables the rbd mirroring of the pool
+func DisablePoolMirroring(context *clusterd.Context, clusterName, poolName string) error {
+	logger.Infof("disabling mirroring of pool %q", poolName)
+	args := []string{"mirror", "pool", "disable", poolName}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to disable mirroring of pool %q. %s", poolName, string(output))
+	}
+	return nil
+}
+
+// CreateRBDMirrorBootstrapPeer creates the bootstrap peer token of the pool, to import in the peer clusters
+func CreateRBDMirrorBootstrapPeer(context *clusterd.Context, clusterName, poolName, siteName string) ([]byte, error) {
+	args := []string{"mirror", "pool", "peer", "bootstrap", "create", poolName, "--site-name", siteName}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create bootstrap peer token of pool %q. %s", poolName, string(output))
+	}
+	return []byte(strings.TrimSpace(string(output))), nil
+}
+
+// ImportRBDMirrorBootstrapPeer imports the bootstrap peer token of a pool of a remote cluster, for the images of the pool
+// to be mirrored to the local cluster. Importing the token of a peer already added updates the peer.
+func ImportRBDMirrorBootstrapPeer(context *clusterd.Context, clusterName, poolName string, token []byte) error {
+	logger.Infof("importing bootstrap peer token of pool %q", poolName)
+	dir, err := ioutil.TempDir("", "rbd-mirror-peer")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the bootstrap peer token directory")
+	}
+	defer os.RemoveAll(dir)
+	tokenPath := path.Join(dir, "token")
+	if err := ioutil.WriteFile(tokenPath, token, 0600); err != nil {
+		return errors.Wrap(err, "failed to write the bootstrap peer token")
+	}
+
+	args := []string{"mirror", "pool", "peer", "bootstrap", "import", poolName, tokenPath}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to import bootstrap peer token of pool %q. %s", poolName, string(output))
+	}
+	return nil
+}
+
+// AddSnapshotSchedules adds the schedules of the mirror snapshots of the images of the pool
+// The schedules already added are left untouched by rbd.
+func AddSnapshotSchedules(context *clusterd.Context, clusterName, poolName string, schedules []cephv1.SnapshotScheduleSpec) error {
+	for _, schedule := range schedules {
+		args := []string{"mirror", "snapshot", "schedule", "add", "--pool", poolName, schedule.Interval}
+		if schedule.StartTime != "" {
+			args = append(args, schedule.StartTime)
+		}
+		cmd := NewRBDCommand(context, clusterName, args)
+		output, err := cmd.Run()
+		if err != nil {
+			return errors.Wrapf(err, "failed to add snapshot schedule %q of pool %q. %s", schedule.Interval, poolName, string(output))
+		}
+		logger.Debugf("added snapshot schedule %q of pool %q", schedule.Interval, poolName)
+	}
+	return nil
+}
+
+// ListSnapshotSchedules returns the schedules of the mirror snapshots of the images of the pool
+func ListSnapshotSchedules(context *clusterd.Context, clusterName, poolName string) ([]cephv1.SnapshotScheduleSpec, error) {
+	args := []string{"mirror", "snapshot", "schedule", "ls", "--pool", poolName}
+	cmd := NewRBDCommand(context, clusterName, args)
+	cmd.JsonOutput = true
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list snapshot schedules of pool %q. %s", poolName, string(buf))
+	}
+	if len(strings.TrimSpace(string(buf))) == 0 {
+		return nil, nil
+	}
+
+	var listed []rbdSnapshotSchedule
+	if err := json.Unmarshal(buf, &listed); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal snapshot schedules of pool %q. raw buffer response: %s", poolName, string(buf))
+	}
+	schedules := make([]cephv1.SnapshotScheduleSpec, 0, len(listed))
+	for _, l := range listed {
+		schedules = append(schedules, cephv1.SnapshotScheduleSpec{Interval: l.Interval, StartTime: l.StartTime})
+	}
+	return schedules, nil
+}
+
+// RemoveSnapshotSchedule removes a schedule of the mirror snapshots of the images of the pool
+func RemoveSnapshotSchedule(context *clusterd.Context, clusterName, poolName string, schedule cephv1.SnapshotScheduleSpec) error {
+	args := []string{"mirror", "snapshot", "schedule", "remove", "--pool", poolName, schedule.Interval}
+	if schedule.StartTime != "" {
+		args = append(args, schedule.StartTime)
+	}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove snapshot schedule %q of pool %q. %s", schedule.Interval, poolName, string(output))
+	}
+	logger.Infof("removed snapshot schedule %q of pool %q", schedule.Interval, poolName)
+	return nil
+}
+
+// ReconcileSnapshotSchedules makes the schedules of the mirror snapshots of the images of the pool match the given
+// schedules, removing the schedules of the pool not listed and adding the missing ones
+func ReconcileSnapshotSchedules(context *clusterd.Context, clusterName, poolName string, schedules []cephv1.SnapshotScheduleSpec) error {
+	current, err := ListSnapshotSchedules(context, clusterName, poolName)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range current {
+		if !containsRBDSnapshotSchedule(schedules, c) {
+			if err := RemoveSnapshotSchedule(context, clusterName, poolName, c); err != nil {
+				return err
+			}
+		}
+	}
+
+	var missing []cephv1.SnapshotScheduleSpec
+	for _, schedule := range schedules {
+		if !containsRBDSnapshotSchedule(current, schedule) {
+			missing = append(missing, schedule)
+		}
+	}
+	return AddSnapshotSchedules(context, clusterName, poolName, missing)
+}
+
+// containsRBDSnapshotSchedule returns whether the schedule is in the list, rbd reporting the intervals in their
+// largest unit and a start time only when one was given
+func containsRBDSnapshotSchedule(schedules []cephv1.SnapshotScheduleSpec, schedule cephv1.SnapshotScheduleSpec) bool {
+	for _, s := range schedules {
+		if normalizeRBDInterval(s.Interval) == normalizeRBDInterval(schedule.Interval) &&
+			sameStartTime(s.StartTime, schedule.StartTime, rbdStartTimeLayouts) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeRBDInterval returns the interval in its largest unit the way rbd reports it, e.g. 1d for 24h
+func normalizeRBDInterval(interval string) string {
+	if len(interval) < 2 {
+		return interval
+	}
+	value, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil {
+		return interval
+	}
+	minutes := value
+	switch interval[len(interval)-1] {
+	case 'm':
+	case 'h':
+		minutes = value * 60
+	case 'd':
+		minutes = value * 60 * 24
+	default:
+		return interval
+	}
+
+	switch {
+	case minutes%(60*24) == 0:
+		return fmt.Sprintf("%dd", minutes/(60*24))
+	case minutes%60 == 0:
+		return fmt.Sprintf("%dh", minutes/60)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// GetPoolMirroringStatus returns the rbd mirroring status of the pool
+func GetPoolMirroringStatus(context *clusterd.Context, clusterName, poolName string) (*PoolMirroringStatus, error) {
+	args := []string{"mirror", "pool", "status", poolName}
+	cmd := NewRBDCommand(context, clusterName, args)
+	cmd.JsonOutput = true
+	buf, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get mirroring status of pool %q. %s", poolName, string(buf))
+	}
+
+	var status PoolMirroringStatus
+	if err := json.Unmarshal(buf, &status); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal mirroring status of pool %q. raw buffer response: %s", poolName, string(buf))
+	}
+	return &status, nil
+}
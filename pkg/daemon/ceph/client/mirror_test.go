@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnablePoolMirroring(t *testing.T) {
+	pool := cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			assert.Equal(t, []string{"mirror", "pool", "enable", "pool1", "image"}, args[:5])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, EnablePoolMirroring(context, "foocluster", pool, "pool1"))
+}
+
+func TestCreateRBDMirrorBootstrapPeer(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, []string{"mirror", "pool", "peer", "bootstrap", "create", "pool1", "--site-name", "site1"}, args[:8])
+			return "eyJmc2lkIjoiYzZiMDg3ZjItNzgyOS00ZGJiLWJjZmMtNTNkYzM0ZTBiMzVkIn0=\n", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	token, err := CreateRBDMirrorBootstrapPeer(context, "foocluster", "pool1", "site1")
+	assert.NoError(t, err)
+	assert.Equal(t, "eyJmc2lkIjoiYzZiMDg3ZjItNzgyOS00ZGJiLWJjZmMtNTNkYzM0ZTBiMzVkIn0=", string(token))
+}
+
+func TestImportRBDMirrorBootstrapPeer(t *testing.T) {
+	imported := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, []string{"mirror", "pool", "peer", "bootstrap", "import", "pool1"}, args[:6])
+			token, err := ioutil.ReadFile(args[6])
+			assert.NoError(t, err)
+			imported = string(token)
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, ImportRBDMirrorBootstrapPeer(context, "foocluster", "pool1", []byte("token")))
+	assert.Equal(t, "token", imported)
+}
+
+func TestAddSnapshotSchedules(t *testing.T) {
+	var added [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			added = append(added, args[4:])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	schedules := []cephv1.SnapshotScheduleSpec{{Interval: "24h"}, {Interval: "1h", StartTime: "14:00:00-05:00"}}
+	assert.NoError(t, AddSnapshotSchedules(context, "foocluster", "pool1", schedules))
+	assert.Equal(t, 2, len(added))
+	assert.Equal(t, []string{"--pool", "pool1", "24h"}, added[0][:3])
+	assert.Equal(t, []string{"--pool", "pool1", "1h", "14:00:00-05:00"}, added[1][:4])
+}
+
+func TestReconcileSnapshotSchedules(t *testing.T) {
+	var added, removed [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			switch args[3] {
+			case "ls":
+				return `[{"interval":"1d","start_time":"14:00:00-05:00"},{"interval":"30m","start_time":null}]`, nil
+			case "add":
+				added = append(added, args[4:])
+			case "remove":
+				removed = append(removed, args[4:])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the schedule of 24h matches the schedule of 1d, the schedule of 30m went away and the schedule of 1h is new
+	schedules := []cephv1.SnapshotScheduleSpec{{Interval: "24h", StartTime: "14:00-05:00"}, {Interval: "1h"}}
+	assert.NoError(t, ReconcileSnapshotSchedules(context, "foocluster", "pool1", schedules))
+	assert.Equal(t, 1, len(removed))
+	assert.Equal(t, []string{"--pool", "pool1", "30m"}, removed[0][:3])
+	assert.Equal(t, 1, len(added))
+	assert.Equal(t, []string{"--pool", "pool1", "1h"}, added[0][:3])
+}
+
+func TestNormalizeRBDInterval(t *testing.T) {
+	assert.Equal(t, "1d", normalizeRBDInterval("24h"))
+	assert.Equal(t, "2h", normalizeRBDInterval("120m"))
+	assert.Equal(t, "90m", normalizeRBDInterval("90m"))
+	assert.Equal(t, "3d", normalizeRBDInterval("3d"))
+	assert.Equal(t, "bad", normalizeRBDInterval("bad"))
+}
+
+func TestGetPoolMirroringStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "mirror" && args[1] == "pool" && args[2] == "status" {
+				return `{"summary":{"health":"WARNING","daemon_health":"OK","image_health":"WARNING","states":{"starting_replay":1}}}`, nil
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetPoolMirroringStatus(context, "foocluster", "pool1")
+	assert.NoError(t, err)
+	assert.Equal(t, "WARNING", status.Summary.Health)
+	assert.Equal(t, "OK", status.Summary.DaemonHealth)
+	assert.Equal(t, "WARNING", status.Summary.ImageHealth)
+	assert.Equal(t, map[string]int{"starting_replay": 1}, status.Summary.States)
+}
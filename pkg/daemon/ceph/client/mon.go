@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
@@ -27,7 +28,8 @@ import (
 type MonStatusResponse struct {
 	Quorum []int `json:"quorum"`
 	MonMap struct {
-		Mons []MonMapEntry `json:"mons"`
+		Mons        []MonMapEntry `json:"mons"`
+		StretchMode bool          `json:"stretch_mode"`
 	} `json:"monmap"`
 }
 
@@ -72,7 +74,7 @@ func GetMonQuorumStatusHealth(context *clusterd.Context, clusterName, userName s
 	args := []string{"quorum_status", "--format", "json"}
 	command, args := FinalizeCephCommandArgs("ceph", args, context.ConfigDir, clusterName, userName)
 
-	buf, err := context.Executor.ExecuteCommandWithOutput(command, args...)
+	buf, err := ExecuteClusterCommandWithOutput(context, clusterName, command, args...)
 	if err != nil {
 		return MonStatusResponse{}, errors.Wrap(err, "mon quorum status failed")
 	}
@@ -85,3 +87,41 @@ func GetMonQuorumStatusHealth(context *clusterd.Context, clusterName, userName s
 
 	return resp, nil
 }
+
+// SetMonElectionStrategy sets the strategy used by the mons to elect the leader, such as "connectivity" for the stretch mode
+func SetMonElectionStrategy(context *clusterd.Context, clusterName, strategy string) error {
+	args := []string{"mon", "set", "election_strategy", strategy}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the mon election strategy to %q. %s", strategy, string(buf))
+	}
+
+	logger.Infof("mon election strategy set to %q", strategy)
+	return nil
+}
+
+// SetMonLocation sets the CRUSH location of a mon, which the stretch mode uses to find the zone of the mon
+func SetMonLocation(context *clusterd.Context, clusterName, monName, bucketType, bucketName string) error {
+	location := fmt.Sprintf("%s=%s", bucketType, bucketName)
+	args := []string{"mon", "set_location", monName, location}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the location of mon %q to %q. %s", monName, location, string(buf))
+	}
+
+	logger.Infof("mon %q location set to %q", monName, location)
+	return nil
+}
+
+// EnableStretchMode enables the stretch mode of the cluster with the given tiebreaker mon, CRUSH rule and bucket type
+// of the zones. The stretch mode cannot be disabled once enabled.
+func EnableStretchMode(context *clusterd.Context, clusterName, tiebreakerMon, ruleName, bucketType string) error {
+	args := []string{"mon", "enable_stretch_mode", tiebreakerMon, ruleName, bucketType}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to enable the stretch mode with the tiebreaker mon %q. %s", tiebreakerMon, string(buf))
+	}
+
+	logger.Infof("stretch mode enabled with the tiebreaker mon %q", tiebreakerMon)
+	return nil
+}
@@ -17,6 +17,7 @@ package client
 
 import (
 	"encoding/json"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -46,6 +47,16 @@ type OSDNodeUsage struct {
 	Utilization json.Number `json:"utilization"`
 	Variance    json.Number `json:"var"`
 	Pgs         json.Number `json:"pgs"`
+	DeviceClass string      `json:"device_class"`
+}
+
+// DeviceClassUsage is the capacity of the OSDs of a CRUSH device class
+type DeviceClassUsage struct {
+	Name    string
+	OSDs    int
+	KB      uint64
+	UsedKB  uint64
+	AvailKB uint64
 }
 
 type OSDPerfStats struct {
@@ -215,6 +226,40 @@ func GetOSDUsage(context *clusterd.Context, clusterName string) (*OSDUsage, erro
 	return &osdUsage, nil
 }
 
+// ByDeviceClass sums the capacity of the OSDs of each device class, sorted by the name of the classes
+func (u *OSDUsage) ByDeviceClass() []DeviceClassUsage {
+	classes := map[string]*DeviceClassUsage{}
+	for _, osd := range u.OSDNodes {
+		if osd.DeviceClass == "" {
+			continue
+		}
+		class, ok := classes[osd.DeviceClass]
+		if !ok {
+			class = &DeviceClassUsage{Name: osd.DeviceClass}
+			classes[osd.DeviceClass] = class
+		}
+		class.OSDs++
+		class.KB += jsonNumberToUint64(osd.KB)
+		class.UsedKB += jsonNumberToUint64(osd.UsedKB)
+		class.AvailKB += jsonNumberToUint64(osd.AvailKB)
+	}
+
+	usage := make([]DeviceClassUsage, 0, len(classes))
+	for _, class := range classes {
+		usage = append(usage, *class)
+	}
+	sort.Slice(usage, func(i, j int) bool { return usage[i].Name < usage[j].Name })
+	return usage
+}
+
+func jsonNumberToUint64(n json.Number) uint64 {
+	val, err := n.Int64()
+	if err != nil || val < 0 {
+		return 0
+	}
+	return uint64(val)
+}
+
 func GetOSDPerfStats(context *clusterd.Context, clusterName string) (*OSDPerfStats, error) {
 	args := []string{"osd", "perf"}
 	buf, err := NewCephCommand(context, clusterName, args).Run()
@@ -252,6 +297,26 @@ func OSDOut(context *clusterd.Context, clusterName string, osdID int) (string, e
 	return string(buf), err
 }
 
+// PurgeOSD removes the osd from the crush map, deletes its key and removes it from the osd map
+func PurgeOSD(context *clusterd.Context, clusterName string, osdID int) error {
+	args := []string{"osd", "purge", strconv.Itoa(osdID), "--yes-i-really-mean-it"}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to purge osd.%d", osdID)
+	}
+	return nil
+}
+
+// RepairPG instructs the primary osd of an inconsistent PG to repair it
+func RepairPG(context *clusterd.Context, clusterName, pgID string) error {
+	args := []string{"pg", "repair", pgID}
+	_, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to repair pg %s", pgID)
+	}
+	return nil
+}
+
 func OsdSafeToDestroy(context *clusterd.Context, clusterName string, osdID int) (bool, error) {
 	args := []string{"osd", "safe-to-destroy", strconv.Itoa(osdID)}
 	cmd := NewCephCommand(context, clusterName, args)
@@ -110,3 +110,26 @@ func TestOsdListNum(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(list))
 }
+
+func TestOSDUsageByDeviceClass(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "df" {
+			return `{"nodes":[
+				{"id":0,"name":"osd.0","device_class":"nvme-meta","kb":1000,"kb_used":100,"kb_avail":900},
+				{"id":1,"name":"osd.1","device_class":"hdd","kb":4000,"kb_used":1000,"kb_avail":3000},
+				{"id":2,"name":"osd.2","device_class":"nvme-meta","kb":1000,"kb_used":300,"kb_avail":700},
+				{"id":3,"name":"osd.3","device_class":"","kb":0,"kb_used":0,"kb_avail":0}],
+				"summary":{"total_kb":6000,"total_kb_used":1400,"total_kb_avail":4600}}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	usage, err := GetOSDUsage(&clusterd.Context{Executor: executor}, "rook")
+	assert.NoError(t, err)
+	assert.Equal(t, []DeviceClassUsage{
+		{Name: "hdd", OSDs: 1, KB: 4000, UsedKB: 1000, AvailKB: 3000},
+		{Name: "nvme-meta", OSDs: 2, KB: 2000, UsedKB: 400, AvailKB: 1600},
+	}, usage.ByDeviceClass())
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 )
 
 const (
@@ -32,6 +33,7 @@ const (
 	reallyConfirmFlag       = "--yes-i-really-really-mean-it"
 	targetSizeRatioProperty = "target_size_ratio"
 	compressionModeProperty = "compression_mode"
+	compressionAlgoProperty = "compression_algorithm"
 	PgAutoscaleModeProperty = "pg_autoscale_mode"
 	PgAutoscaleModeOn       = "on"
 )
@@ -46,6 +48,7 @@ type CephStoragePoolDetails struct {
 	Number                 int     `json:"pool_id"`
 	Size                   uint    `json:"size"`
 	ErasureCodeProfile     string  `json:"erasure_code_profile"`
+	CrushRule              string  `json:"crush_rule"`
 	FailureDomain          string  `json:"failureDomain"`
 	CrushRoot              string  `json:"crushRoot"`
 	DeviceClass            string  `json:"deviceClass"`
@@ -87,7 +90,8 @@ type PoolStatistics struct {
 
 func ListPoolSummaries(context *clusterd.Context, namespace string) ([]CephStoragePoolSummary, error) {
 	args := []string{"osd", "lspools"}
-	output, err := NewCephCommand(context, namespace, args).Run()
+	monCommand := map[string]interface{}{"prefix": "osd lspools"}
+	output, err := newMonCommand(context, namespace, monCommand, args).Run()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list pools")
 	}
@@ -116,7 +120,8 @@ func GetPoolNamesByID(context *clusterd.Context, namespace string) (map[int]stri
 // GetPoolDetails gets all the details of a given pool
 func GetPoolDetails(context *clusterd.Context, namespace, name string) (CephStoragePoolDetails, error) {
 	args := []string{"osd", "pool", "get", name, "all"}
-	output, err := NewCephCommand(context, namespace, args).Run()
+	monCommand := map[string]interface{}{"prefix": "osd pool get", "pool": name, "var": "all"}
+	output, err := newMonCommand(context, namespace, monCommand, args).Run()
 	if err != nil {
 		return CephStoragePoolDetails{}, errors.Wrapf(err, "failed to get pool %s details. %s", name, string(output))
 	}
@@ -175,7 +180,8 @@ func CreatePoolWithProfile(context *clusterd.Context, namespace, poolName string
 		true /* enableECOverwrite */)
 }
 
-func checkForImagesInPool(context *clusterd.Context, name, namespace string) error {
+// CheckForImagesInPool returns an error if rbd images or snapshots are present in the pool
+func CheckForImagesInPool(context *clusterd.Context, name, namespace string) error {
 	var err error
 	var stats = new(PoolStatistics)
 	logger.Debugf("checking any images/snapshosts present in pool %q", name)
@@ -194,17 +200,68 @@ func checkForImagesInPool(context *clusterd.Context, name, namespace string) err
 	return errors.Errorf("pool %q contains images/snapshosts", name)
 }
 
-// DeletePool purges a pool from Ceph
+// radosObject is an object listed by rados
+type radosObject struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// CheckForObjectsInPool returns an error if objects are present in the pool. The objects counted in the pool stats are
+// checked first, and the pool is then listed, the stats lagging behind the latest writes.
+func CheckForObjectsInPool(context *clusterd.Context, namespace, name string) error {
+	logger.Debugf("checking any objects present in pool %q", name)
+	stats, err := GetPoolStats(context, namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the stats of pool %q", name)
+	}
+	for _, pool := range stats.Pools {
+		if pool.Name == name && pool.Stats.Objects > 0 {
+			return errors.Errorf("pool %q contains %d objects", name, int64(pool.Stats.Objects))
+		}
+	}
+
+	args := []string{"--pool", name, "ls", "--all"}
+	output, err := NewRadosCommand(context, namespace, args).Run()
+	if err != nil {
+		if strings.Contains(string(output), "No such file or directory") {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to list the objects of pool %q", name)
+	}
+	var objects []radosObject
+	if err := json.Unmarshal(output, &objects); err != nil {
+		return errors.Wrapf(err, "failed to unmarshal the objects of pool %q", name)
+	}
+	if len(objects) > 0 {
+		return errors.Errorf("pool %q contains objects, such as %q", name, objects[0].Name)
+	}
+
+	logger.Infof("no objects present in pool %q", name)
+	return nil
+}
+
+// DeletePool purges a pool from Ceph, unless rbd images are present in the pool
 func DeletePool(context *clusterd.Context, namespace string, name string) error {
+	return deletePool(context, namespace, name, false)
+}
+
+// ForceDeletePool purges a pool from Ceph along with the rbd images present in the pool
+func ForceDeletePool(context *clusterd.Context, namespace string, name string) error {
+	return deletePool(context, namespace, name, true)
+}
+
+func deletePool(context *clusterd.Context, namespace string, name string, force bool) error {
 	// check if the pool exists
 	pool, err := GetPoolDetails(context, namespace, name)
 	if err != nil {
 		return errors.Wrapf(err, "failed to get pool %q details", name)
 	}
 
-	err = checkForImagesInPool(context, name, namespace)
-	if err != nil {
-		return errors.Wrapf(err, "failed to check if pool %q has rbd images", name)
+	if !force {
+		err = CheckForImagesInPool(context, name, namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if pool %q has rbd images", name)
+		}
 	}
 
 	logger.Infof("purging pool %q (id=%d)", name, pool.Number)
@@ -215,16 +272,28 @@ func DeletePool(context *clusterd.Context, namespace string, name string) error
 	}
 
 	// remove the crush rule for this pool and ignore the error in case the rule is still in use or not found
-	args = []string{"osd", "crush", "rule", "rm", name}
-	_, err = NewCephCommand(context, namespace, args).Run()
-	if err != nil {
-		logger.Errorf("failed to delete crush rule %q. %v", name, err)
+	deleteCrushRule(context, namespace, name)
+	// remove the crush rule created when the failure domain of the pool was changed
+	if isPoolFailureDomainRule(name, pool.CrushRule) {
+		deleteCrushRule(context, namespace, pool.CrushRule)
+	}
+
+	// remove the erasure code profile created for this pool, the profiles of the object stores being named after them
+	if pool.ErasureCodeProfile != "" && pool.ErasureCodeProfile == GetErasureCodeProfileForPool(name) {
+		if err := DeleteErasureCodeProfile(context, namespace, pool.ErasureCodeProfile); err != nil {
+			logger.Errorf("failed to delete erasure code profile %q. %v", pool.ErasureCodeProfile, err)
+		}
 	}
 
 	logger.Infof("purge completed for pool %q", name)
 	return nil
 }
 
+// EnablePoolApplication tags the pool with the application using it, such as rbd, cephfs or rgw
+func EnablePoolApplication(context *clusterd.Context, clusterName, poolName, appName string) error {
+	return givePoolAppTag(context, clusterName, poolName, appName)
+}
+
 func givePoolAppTag(context *clusterd.Context, namespace string, poolName string, appName string) error {
 	args := []string{"osd", "pool", "application", "enable", poolName, appName, confirmFlag}
 	_, err := NewCephCommand(context, namespace, args).Run()
@@ -235,19 +304,35 @@ func givePoolAppTag(context *clusterd.Context, namespace string, poolName string
 	return nil
 }
 
+// ValidateCompressionVersion checks the compression algorithm is supported by the ceph version of the cluster
+func ValidateCompressionVersion(algorithm string, cephVersion cephver.CephVersion) error {
+	if algorithm == cephv1.CompressionAlgorithmZstd && !cephVersion.IsAtLeastNautilus() {
+		return errors.Errorf("compression algorithm %q requires ceph nautilus, the cluster runs %q", algorithm, cephVersion.String())
+	}
+	return nil
+}
+
 func setCommonPoolProperties(context *clusterd.Context, pool cephv1.PoolSpec, namespace, poolName, appName string) error {
 	if len(pool.Parameters) == 0 {
 		pool.Parameters = make(map[string]string)
 	}
 
-	if pool.Replicated.IsTargetRatioEnabled() {
-		pool.Parameters[targetSizeRatioProperty] = strconv.FormatFloat(pool.Replicated.TargetSizeRatio, 'f', -1, 32)
+	if targetSizeRatio := pool.GetTargetSizeRatio(); targetSizeRatio != 0 {
+		pool.Parameters[targetSizeRatioProperty] = strconv.FormatFloat(targetSizeRatio, 'f', -1, 32)
+	}
+
+	if pool.PgAutoscaleMode != "" {
+		pool.Parameters[PgAutoscaleModeProperty] = pool.PgAutoscaleMode
 	}
 
 	if pool.IsCompressionEnabled() {
 		pool.Parameters[compressionModeProperty] = pool.CompressionMode
 	}
 
+	if pool.CompressionAlgorithm != "" {
+		pool.Parameters[compressionAlgoProperty] = pool.CompressionAlgorithm
+	}
+
 	// Apply properties
 	for propName, propValue := range pool.Parameters {
 		err := SetPoolProperty(context, namespace, poolName, propName, propValue)
@@ -256,6 +341,12 @@ func setCommonPoolProperties(context *clusterd.Context, pool cephv1.PoolSpec, na
 		}
 	}
 
+	if pool.Quotas != nil {
+		if err := SetPoolQuotas(context, namespace, poolName, *pool.Quotas); err != nil {
+			return err
+		}
+	}
+
 	// ensure that the newly created pool gets an application tag
 	if appName != "" {
 		err := givePoolAppTag(context, namespace, poolName, appName)
@@ -328,6 +419,11 @@ func createReplicationCrushRule(context *clusterd.Context, namespace, ruleName s
 	if pool.CrushRoot != "" {
 		crushRoot = pool.CrushRoot
 	}
+
+	if hybrid := pool.Replicated.HybridStorage; hybrid != nil {
+		return CreateHybridCrushRule(context, namespace, ruleName, crushRoot, failureDomain, hybrid.PrimaryDeviceClass, hybrid.SecondaryDeviceClass)
+	}
+
 	args := []string{"osd", "crush", "rule", "create-replicated", ruleName, crushRoot, failureDomain}
 
 	var deviceClass string
@@ -344,17 +440,106 @@ func createReplicationCrushRule(context *clusterd.Context, namespace, ruleName s
 	return nil
 }
 
+// UpdatePoolFailureDomain moves a replicated pool to the failure domain of its spec when its crush rule places the
+// replicas on another failure domain. A crush rule is created for the failure domain and set on the pool, ceph then
+// moving the data of the pool. It returns whether the crush rule of the pool was changed.
+func UpdatePoolFailureDomain(context *clusterd.Context, namespace, poolName string, pool cephv1.PoolSpec) (bool, error) {
+	failureDomain := pool.FailureDomain
+	if failureDomain == "" {
+		logger.Debugf("skipping the check of the failure domain of pool %q, it is not specified", poolName)
+		return false, nil
+	}
+
+	details, err := GetPoolDetails(context, namespace, poolName)
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to get pool %q details", poolName)
+	}
+	crush, err := GetCrushMap(context, namespace)
+	if err != nil {
+		return false, err
+	}
+	current := crushRuleFailureDomain(crush, details.CrushRule)
+	if current == "" {
+		return false, errors.Errorf("failed to find the failure domain of crush rule %q of pool %q", details.CrushRule, poolName)
+	}
+	if current == failureDomain {
+		return false, nil
+	}
+
+	ruleName := fmt.Sprintf("%s_%s", poolName, failureDomain)
+	logger.Infof("changing the failure domain of pool %q from %q to %q with crush rule %q", poolName, current, failureDomain, ruleName)
+	if err := createReplicationCrushRule(context, namespace, ruleName, pool); err != nil {
+		return false, err
+	}
+	if err := SetPoolProperty(context, namespace, poolName, "crush_rule", ruleName); err != nil {
+		return false, err
+	}
+
+	// the rule of a previous change is not used anymore, the rule created with the pool is removed with the pool
+	if isPoolFailureDomainRule(poolName, details.CrushRule) {
+		deleteCrushRule(context, namespace, details.CrushRule)
+	}
+	return true, nil
+}
+
+// crushRuleFailureDomain returns the failure domain of a crush rule, i.e. the bucket type of its last choose step, or
+// an empty string if the rule is not found
+func crushRuleFailureDomain(crush CrushMap, ruleName string) string {
+	for _, rule := range crush.Rules {
+		if rule.Name != ruleName {
+			continue
+		}
+		failureDomain := ""
+		for _, step := range rule.Steps {
+			if strings.HasPrefix(step.Operation, "choose") {
+				failureDomain = step.Type
+			}
+		}
+		return failureDomain
+	}
+	return ""
+}
+
+// isPoolFailureDomainRule returns whether the crush rule was created when the failure domain of the pool was changed
+func isPoolFailureDomainRule(poolName, ruleName string) bool {
+	return strings.HasPrefix(ruleName, poolName+"_")
+}
+
+// deleteCrushRule removes a crush rule, the error being ignored in case the rule is still in use or not found
+func deleteCrushRule(context *clusterd.Context, namespace, ruleName string) {
+	args := []string{"osd", "crush", "rule", "rm", ruleName}
+	if _, err := NewCephCommand(context, namespace, args).Run(); err != nil {
+		logger.Errorf("failed to delete crush rule %q. %v", ruleName, err)
+	}
+}
+
 // SetPoolProperty sets a property to a given pool
 func SetPoolProperty(context *clusterd.Context, namespace, name, propName, propVal string) error {
 	args := []string{"osd", "pool", "set", name, propName, propVal}
+	monCommand := map[string]interface{}{"prefix": "osd pool set", "pool": name, "var": propName, "val": propVal}
 	logger.Infof("setting pool property %q to %q on pool %q", propName, propVal, name)
-	_, err := NewCephCommand(context, namespace, args).Run()
+	_, err := newMonCommand(context, namespace, monCommand, args).Run()
 	if err != nil {
 		return errors.Wrapf(err, "failed to set pool property %q on pool %q", propName, name)
 	}
 	return nil
 }
 
+// SetPoolQuotas sets the quotas of a pool, the quotas not set being removed
+func SetPoolQuotas(context *clusterd.Context, namespace, poolName string, quotas cephv1.QuotaSpec) error {
+	for quota, value := range map[string]*uint64{"max_bytes": quotas.MaxBytes, "max_objects": quotas.MaxObjects} {
+		var limit uint64
+		if value != nil {
+			limit = *value
+		}
+		args := []string{"osd", "pool", "set-quota", poolName, quota, strconv.FormatUint(limit, 10)}
+		if _, err := NewCephCommand(context, namespace, args).Run(); err != nil {
+			return errors.Wrapf(err, "failed to set quota %q on pool %q", quota, poolName)
+		}
+	}
+	return nil
+}
+
 // SetPoolReplicatedSizeProperty sets the replica size of a pool
 func SetPoolReplicatedSizeProperty(context *clusterd.Context, namespace, poolName, size string) error {
 	propName := "size"
@@ -373,7 +558,8 @@ func SetPoolReplicatedSizeProperty(context *clusterd.Context, namespace, poolNam
 
 func GetPoolStats(context *clusterd.Context, namespace string) (*CephStoragePoolStats, error) {
 	args := []string{"df", "detail"}
-	output, err := NewCephCommand(context, namespace, args).Run()
+	monCommand := map[string]interface{}{"prefix": "df", "detail": "detail"}
+	output, err := newMonCommand(context, namespace, monCommand, args).Run()
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to get pool stats")
 	}
@@ -386,6 +572,34 @@ func GetPoolStats(context *clusterd.Context, namespace string) (*CephStoragePool
 	return &poolStats, nil
 }
 
+// poolPGList is the list of the PGs of a pool
+type poolPGList struct {
+	PGStats []struct {
+		PGID  string `json:"pgid"`
+		State string `json:"state"`
+	} `json:"pg_stats"`
+}
+
+// GetPoolPGStates returns the number of PGs of the pool in each state
+func GetPoolPGStates(context *clusterd.Context, namespace, poolName string) (map[string]int, error) {
+	args := []string{"pg", "ls-by-pool", poolName}
+	output, err := NewCephCommand(context, namespace, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the pgs of pool %q", poolName)
+	}
+
+	var pgs poolPGList
+	if err := json.Unmarshal(output, &pgs); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the pgs of pool %q. %s", poolName, string(output))
+	}
+
+	states := map[string]int{}
+	for _, pg := range pgs.PGStats {
+		states[pg.State]++
+	}
+	return states, nil
+}
+
 func GetPoolStatistics(context *clusterd.Context, name, namespace string) (*PoolStatistics, error) {
 	args := []string{"pool", "stats", name}
 	cmd := NewRBDCommand(context, namespace, args)
@@ -16,7 +16,9 @@ limitations under the License.
 package client
 
 import (
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -184,6 +186,47 @@ func testCreateReplicaPool(t *testing.T, failureDomain, crushRoot, deviceClass,
 	}
 }
 
+func TestSetCommonPoolProperties(t *testing.T) {
+	properties := map[string]string{}
+	quotas := map[string]string{}
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "pool" && args[2] == "set" {
+			properties[args[4]] = args[5]
+			return "", nil
+		}
+		if args[1] == "pool" && args[2] == "set-quota" {
+			assert.Equal(t, "mypool", args[3])
+			quotas[args[4]] = args[5]
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	// the target size ratio of the pool overrides the ratio of the replicas
+	maxBytes := uint64(1024)
+	p := cephv1.PoolSpec{
+		Replicated:      cephv1.ReplicatedSpec{Size: 3, TargetSizeRatio: 0.1},
+		TargetSizeRatio: 0.5,
+		PgAutoscaleMode: "warn",
+		Quotas:          &cephv1.QuotaSpec{MaxBytes: &maxBytes},
+	}
+	assert.NoError(t, setCommonPoolProperties(context, p, "myns", "mypool", ""))
+	assert.Equal(t, "0.5", properties[targetSizeRatioProperty])
+	assert.Equal(t, "warn", properties[PgAutoscaleModeProperty])
+
+	// the quotas not set are removed
+	assert.Equal(t, map[string]string{"max_bytes": "1024", "max_objects": "0"}, quotas)
+
+	// the quotas are not managed if not set
+	quotas = map[string]string{}
+	p.Quotas = nil
+	assert.NoError(t, setCommonPoolProperties(context, p, "myns", "mypool", ""))
+	assert.Empty(t, quotas)
+}
+
 func testIsStringInSlice(a string, list []string) bool {
 	for _, b := range list {
 		if b == a {
@@ -228,6 +271,24 @@ func TestGetPoolStatistics(t *testing.T) {
 	assert.Nil(t, stats)
 }
 
+func TestGetPoolPGStates(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "pg" && args[1] == "ls-by-pool" && args[2] == "replicapool" {
+			return `{"pg_ready":true,"pg_stats":[{"pgid":"1.0","state":"active+clean"},{"pgid":"1.1","state":"active+clean"},{"pgid":"1.2","state":"active+undersized+degraded"}]}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	states, err := GetPoolPGStates(context, "myns", "replicapool")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"active+clean": 2, "active+undersized+degraded": 1}, states)
+
+	_, err = GetPoolPGStates(context, "myns", "rbd")
+	assert.Error(t, err)
+}
+
 func TestSetPoolReplicatedSizeProperty(t *testing.T) {
 	poolName := "mypool"
 	executor := &exectest.MockExecutor{}
@@ -266,3 +327,115 @@ func TestSetPoolReplicatedSizeProperty(t *testing.T) {
 	err = SetPoolReplicatedSizeProperty(context, "myns", poolName, "1")
 	assert.NoError(t, err)
 }
+
+func TestCheckForObjectsInPool(t *testing.T) {
+	objects := 0
+	listed := `[]`
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "df" {
+			return fmt.Sprintf(`{"pools":[{"name":"myfs-data0","id":2,"stats":{"objects":%d}}]}`, objects), nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == RadosTool && args[2] == "ls" {
+			assert.Equal(t, "myfs-data0", args[1])
+			return listed, nil
+		}
+		return "", errors.Errorf("unexpected command %s %q", command, args)
+	}
+
+	assert.NoError(t, CheckForObjectsInPool(context, "myns", "myfs-data0"))
+
+	// the objects not counted in the stats yet are listed
+	listed = `[{"namespace":"","name":"10000000000.00000000"}]`
+	assert.Error(t, CheckForObjectsInPool(context, "myns", "myfs-data0"))
+
+	objects = 1
+	listed = `[]`
+	assert.Error(t, CheckForObjectsInPool(context, "myns", "myfs-data0"))
+}
+
+func TestDeletePoolWithErasureCodeProfile(t *testing.T) {
+	deleted := []string{}
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+			return fmt.Sprintf(`{"pool":"%s","pool_id":1,"erasure_code_profile":"ecpool_ecprofile"}`, args[3]), nil
+		}
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "delete" {
+			deleted = append(deleted, "pool "+args[3])
+			return "", nil
+		}
+		if args[0] == "osd" && args[1] == "crush" && args[2] == "rule" && args[3] == "rm" {
+			deleted = append(deleted, "rule "+args[4])
+			return "", nil
+		}
+		if args[0] == "osd" && args[1] == "erasure-code-profile" && args[2] == "rm" {
+			deleted = append(deleted, "profile "+args[3])
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	// the pool, its crush rule and its erasure code profile are deleted
+	assert.NoError(t, ForceDeletePool(context, "myns", "ecpool"))
+	assert.Equal(t, []string{"pool ecpool", "rule ecpool", "profile ecpool_ecprofile"}, deleted)
+
+	// a profile not created for the pool is left
+	deleted = []string{}
+	assert.NoError(t, ForceDeletePool(context, "myns", "otherpool"))
+	assert.Equal(t, []string{"pool otherpool", "rule otherpool"}, deleted)
+}
+
+func TestUpdatePoolFailureDomain(t *testing.T) {
+	crushRule := "replicapool"
+	commands := []string{}
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+			return fmt.Sprintf(`{"pool":"replicapool","pool_id":1,"crush_rule":"%s"}`, crushRule), nil
+		}
+		if args[0] == "osd" && args[1] == "crush" && args[2] == "dump" {
+			return `{"rules":[
+				{"rule_id":1,"rule_name":"replicapool","steps":[{"op":"take","item_name":"default"},{"op":"chooseleaf_firstn","num":0,"type":"host"},{"op":"emit"}]},
+				{"rule_id":2,"rule_name":"replicapool_rack","steps":[{"op":"take","item_name":"default"},{"op":"chooseleaf_firstn","num":0,"type":"rack"},{"op":"emit"}]}]}`, nil
+		}
+		commands = append(commands, strings.Join(args[0:5], " "))
+		return "", nil
+	}
+
+	// the pool is already on its failure domain
+	pool := cephv1.PoolSpec{FailureDomain: "host", Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	changed, err := UpdatePoolFailureDomain(context, "myns", "replicapool", pool)
+	assert.NoError(t, err)
+	assert.False(t, changed)
+	assert.Equal(t, 0, len(commands))
+
+	// a rule is created for the new failure domain and set on the pool
+	pool.FailureDomain = "rack"
+	changed, err = UpdatePoolFailureDomain(context, "myns", "replicapool", pool)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{
+		"osd crush rule create-replicated replicapool_rack",
+		"osd pool set replicapool crush_rule",
+	}, commands)
+
+	// the rule of the previous change is removed
+	crushRule = "replicapool_rack"
+	commands = []string{}
+	pool.FailureDomain = "zone"
+	changed, err = UpdatePoolFailureDomain(context, "myns", "replicapool", pool)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, []string{
+		"osd crush rule create-replicated replicapool_zone",
+		"osd pool set replicapool crush_rule",
+		"osd crush rule rm replicapool_rack",
+	}, commands)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// radosConnection is a connection to the mons of a cluster with the go-ceph bindings
+type radosConnection interface {
+	// MonCommand runs a mon command, returning its output and its status message
+	MonCommand(args []byte) ([]byte, string, error)
+	Shutdown()
+}
+
+// radosConnectionConfig is the config of a connection to a cluster as a ceph user
+type radosConnectionConfig struct {
+	ClusterName string
+	UserName    string
+	ConfigFile  string
+	KeyringFile string
+	Timeout     time.Duration
+}
+
+// errRadosConnectionLost is returned by the connections failing to reach the mons, the connection being closed
+var errRadosConnectionLost = errors.New("lost the connection to the mons")
+
+var (
+	// newRadosConnection connects to a cluster with the go-ceph bindings, nil when the operator is built without the
+	// ceph_bindings build tag
+	newRadosConnection func(config radosConnectionConfig) (radosConnection, error)
+
+	radosConnections      = map[string]radosConnection{}
+	radosConnectionsMutex sync.Mutex
+)
+
+// CephBindingsAvailable returns whether the operator is built with the go-ceph bindings
+func CephBindingsAvailable() bool {
+	return newRadosConnection != nil
+}
+
+// newMonCommand returns a ceph command run as the mon command with the go-ceph bindings when they are enabled, or
+// with the ceph CLI and its args otherwise
+func newMonCommand(context *clusterd.Context, clusterName string, monCommand map[string]interface{}, args []string) *CephToolCommand {
+	cmd := NewCephCommand(context, clusterName, args)
+	cmd.monCommand = monCommand
+	return cmd
+}
+
+// runMonCommand runs a mon command with the go-ceph bindings. The command is not handled when the bindings are
+// disabled or fail to connect to the cluster, the caller then running the ceph CLI.
+func runMonCommand(context *clusterd.Context, clusterName, userName string, monCommand map[string]interface{}) ([]byte, bool, error) {
+	// the ceph CLI fails immediately as well while the cluster is unreachable
+	if !commandSettings.CephBindings || newRadosConnection == nil || RunAllCephCommandsInToolbox || IsClusterUnreachable(clusterName) {
+		return nil, false, nil
+	}
+	conn, err := radosConnectionFor(context, clusterName, userName)
+	if err != nil {
+		logger.Debugf("running the ceph CLI instead of the go-ceph bindings. %v", err)
+		return nil, false, nil
+	}
+	args, err := json.Marshal(monCommand)
+	if err != nil {
+		return nil, true, errors.Wrapf(err, "failed to marshal mon command %v", monCommand)
+	}
+
+	// the mon command counts for the circuit breaker of the cluster like the ceph CLI
+	output, err := ExecuteClusterCommand(context, clusterName, commandSettings.Timeout, func(exec.Executor) (string, error) {
+		buf, status, err := conn.MonCommand(args)
+		if err != nil {
+			if errors.Cause(err) == errRadosConnectionLost {
+				closeRadosConnection(clusterName, userName)
+				return "", &exec.TimeoutError{Command: fmt.Sprintf("%v", monCommand["prefix"]), Timeout: commandSettings.Timeout}
+			}
+			return status, errors.Wrapf(err, "mon command %v failed. %s", monCommand["prefix"], status)
+		}
+		return string(buf), nil
+	})
+	return []byte(output), true, err
+}
+
+// runMonCommandWithUser runs a mon command with json output as the given user, with the go-ceph bindings if enabled or
+// with the ceph CLI and its args otherwise
+func runMonCommandWithUser(context *clusterd.Context, clusterName, userName string, monCommand map[string]interface{}, args []string) ([]byte, error) {
+	monCommand["format"] = "json"
+	if output, ok, err := runMonCommand(context, clusterName, userName, monCommand); ok {
+		return output, err
+	}
+	command, args := FinalizeCephCommandArgs(CephTool, append(args, "--format", "json"), context.ConfigDir, clusterName, userName)
+	output, err := ExecuteClusterCommandWithOutput(context, clusterName, command, args...)
+	return []byte(output), err
+}
+
+// radosConnectionFor returns the connection of the user to the cluster, connecting if not connected yet
+func radosConnectionFor(context *clusterd.Context, clusterName, userName string) (radosConnection, error) {
+	key := radosConnectionKey(clusterName, userName)
+	radosConnectionsMutex.Lock()
+	conn, ok := radosConnections[key]
+	radosConnectionsMutex.Unlock()
+	if ok {
+		return conn, nil
+	}
+
+	// connecting to an unreachable cluster takes up to the timeout, not blocking the commands of the other clusters
+	config := radosConnectionConfig{
+		ClusterName: clusterName,
+		UserName:    userName,
+		ConfigFile:  CephConfFilePath(context.ConfigDir, clusterName),
+		KeyringFile: path.Join(context.ConfigDir, clusterName, fmt.Sprintf("%s.keyring", userName)),
+		Timeout:     commandSettings.Timeout,
+	}
+	if clusterName == "ceph" && context.ConfigDir == "/etc" {
+		config.ConfigFile = ""
+		config.KeyringFile = ""
+	}
+	conn, err := newRadosConnection(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to cluster %q as %q", clusterName, userName)
+	}
+
+	radosConnectionsMutex.Lock()
+	defer radosConnectionsMutex.Unlock()
+	if existing, ok := radosConnections[key]; ok {
+		// another command connected in the meantime
+		conn.Shutdown()
+		return existing, nil
+	}
+	logger.Infof("connected to cluster %q as %q with the go-ceph bindings", clusterName, userName)
+	radosConnections[key] = conn
+	return conn, nil
+}
+
+func radosConnectionKey(clusterName, userName string) string {
+	return clusterName + "/" + userName
+}
+
+// closeRadosConnection closes the connection of the user to the cluster, the next command connecting again
+func closeRadosConnection(clusterName, userName string) {
+	radosConnectionsMutex.Lock()
+	defer radosConnectionsMutex.Unlock()
+	key := radosConnectionKey(clusterName, userName)
+	if conn, ok := radosConnections[key]; ok {
+		conn.Shutdown()
+		delete(radosConnections, key)
+	}
+}
+
+// CloseRadosConnections closes the connections to a cluster, when the cluster is deleted
+func CloseRadosConnections(clusterName string) {
+	radosConnectionsMutex.Lock()
+	defer radosConnectionsMutex.Unlock()
+	for key, conn := range radosConnections {
+		if strings.HasPrefix(key, radosConnectionKey(clusterName, "")) {
+			conn.Shutdown()
+			delete(radosConnections, key)
+		}
+	}
+}
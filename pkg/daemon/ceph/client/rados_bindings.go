@@ -0,0 +1,86 @@
+// +build ceph_bindings
+
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"fmt"
+	"syscall"
+
+	"github.com/ceph/go-ceph/rados"
+	"github.com/pkg/errors"
+)
+
+// The go-ceph bindings link the operator with librados, the operator being built with cgo and the ceph_bindings
+// build tag.
+func init() {
+	newRadosConnection = newGoCephConnection
+}
+
+// goCephConnection is a connection to the mons of a cluster with librados
+type goCephConnection struct {
+	conn *rados.Conn
+}
+
+func newGoCephConnection(config radosConnectionConfig) (radosConnection, error) {
+	conn, err := rados.NewConnWithClusterAndUser(config.ClusterName, config.UserName)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the rados connection")
+	}
+	if config.ConfigFile != "" {
+		if err := conn.ReadConfigFile(config.ConfigFile); err != nil {
+			return nil, errors.Wrapf(err, "failed to read config file %q", config.ConfigFile)
+		}
+	} else if err := conn.ReadDefaultConfigFile(); err != nil {
+		return nil, errors.Wrap(err, "failed to read the default config file")
+	}
+	if config.KeyringFile != "" {
+		if err := conn.SetConfigOption("keyring", config.KeyringFile); err != nil {
+			return nil, errors.Wrapf(err, "failed to set keyring %q", config.KeyringFile)
+		}
+	}
+	if config.Timeout > 0 {
+		// the connection and the mon commands fail like the ceph CLI instead of hanging on unreachable mons
+		timeout := fmt.Sprintf("%d", int(config.Timeout.Seconds()))
+		for _, option := range []string{"client_mount_timeout", "rados_mon_op_timeout"} {
+			if err := conn.SetConfigOption(option, timeout); err != nil {
+				return nil, errors.Wrapf(err, "failed to set %s", option)
+			}
+		}
+	}
+	if err := conn.Connect(); err != nil {
+		return nil, errors.Wrap(err, "failed to connect")
+	}
+	return &goCephConnection{conn: conn}, nil
+}
+
+// MonCommand runs a mon command, the connection being lost if the mons do not answer
+func (c *goCephConnection) MonCommand(args []byte) ([]byte, string, error) {
+	buf, status, err := c.conn.MonCommand(args)
+	if radosErr, ok := err.(rados.RadosError); ok {
+		switch -int(radosErr) {
+		case int(syscall.ETIMEDOUT), int(syscall.ENOTCONN), int(syscall.ESHUTDOWN):
+			return buf, status, errors.Wrap(errRadosConnectionLost, radosErr.Error())
+		}
+	}
+	return buf, status, err
+}
+
+func (c *goCephConnection) Shutdown() {
+	c.conn.Shutdown()
+}
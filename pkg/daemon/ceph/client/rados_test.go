@@ -0,0 +1,101 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRadosConnection struct {
+	commands []map[string]interface{}
+	output   string
+	shutdown bool
+}
+
+func (c *fakeRadosConnection) MonCommand(args []byte) ([]byte, string, error) {
+	var command map[string]interface{}
+	if err := json.Unmarshal(args, &command); err != nil {
+		return nil, "", err
+	}
+	c.commands = append(c.commands, command)
+	return []byte(c.output), "", nil
+}
+
+func (c *fakeRadosConnection) Shutdown() {
+	c.shutdown = true
+}
+
+func TestMonCommandWithBindings(t *testing.T) {
+	conn := &fakeRadosConnection{output: `{"health":{"status":"HEALTH_OK"}}`}
+	connectErr := errors.New("failed to connect")
+	newRadosConnection = func(config radosConnectionConfig) (radosConnection, error) {
+		assert.Equal(t, "/var/lib/rook/rook-ceph/rook-ceph.config", config.ConfigFile)
+		if config.UserName != AdminUsername {
+			return nil, connectErr
+		}
+		return conn, nil
+	}
+	defer func() {
+		newRadosConnection = nil
+		CloseRadosConnections("rook-ceph")
+	}()
+	cliCalls := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			cliCalls++
+			return `{"health":{"status":"HEALTH_WARN"}}`, nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, ConfigDir: "/var/lib/rook"}
+
+	// the mon command runs with the bindings instead of the CLI
+	status, err := StatusWithUser(context, "rook-ceph", AdminUsername)
+	assert.NoError(t, err)
+	assert.Equal(t, "HEALTH_OK", status.Health.Status)
+	assert.Equal(t, 0, cliCalls)
+	assert.Equal(t, []map[string]interface{}{{"prefix": "status", "format": "json"}}, conn.commands)
+
+	// the CLI runs when the bindings fail to connect
+	status, err = StatusWithUser(context, "rook-ceph", "client.healthchecker")
+	assert.NoError(t, err)
+	assert.Equal(t, "HEALTH_WARN", status.Health.Status)
+	assert.Equal(t, 1, cliCalls)
+
+	// the connection is closed with the cluster
+	CloseRadosConnections("rook-ceph")
+	assert.True(t, conn.shutdown)
+}
+
+func TestMonCommandWithoutBindings(t *testing.T) {
+	assert.False(t, CephBindingsAvailable())
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			assert.Equal(t, "auth", args[0])
+			assert.Equal(t, "get-key", args[1])
+			return `{"key":"mysecurekey"}`, nil
+		},
+	}
+	key, err := AuthGetKey(&clusterd.Context{Executor: executor}, "rook-ceph", "client.csi-rbd-node")
+	assert.NoError(t, err)
+	assert.Equal(t, "mysecurekey", key)
+}
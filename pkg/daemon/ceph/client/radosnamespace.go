@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+type radosNamespace struct {
+	Name string `json:"name"`
+}
+
+// ListRadosNamespaces returns the names of the RADOS namespaces of the rbd images of a pool
+func ListRadosNamespaces(context *clusterd.Context, clusterName, poolName string) ([]string, error) {
+	args := []string{"namespace", "ls", poolName}
+	cmd := NewRBDCommand(context, clusterName, args)
+	cmd.JsonOutput = true
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list the rados namespaces of pool %q. %s", poolName, string(output))
+	}
+
+	var namespaces []radosNamespace
+	if err := json.Unmarshal(output, &namespaces); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal the rados namespaces of pool %q. %s", poolName, string(output))
+	}
+	names := make([]string, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		names = append(names, namespace.Name)
+	}
+	return names, nil
+}
+
+// CreateRadosNamespace creates a RADOS namespace in a pool for its rbd images, if it does not exist
+func CreateRadosNamespace(context *clusterd.Context, clusterName, poolName, namespace string) error {
+	exists, err := radosNamespaceExists(context, clusterName, poolName, namespace)
+	if err != nil || exists {
+		return err
+	}
+
+	logger.Infof("creating rados namespace %q in pool %q", namespace, poolName)
+	args := []string{"namespace", "create", "--pool", poolName, "--namespace", namespace}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create rados namespace %q in pool %q. %s", namespace, poolName, string(output))
+	}
+	return nil
+}
+
+// DeleteRadosNamespace removes a RADOS namespace from a pool if it exists. The removal fails if the namespace still has
+// rbd images.
+func DeleteRadosNamespace(context *clusterd.Context, clusterName, poolName, namespace string) error {
+	exists, err := radosNamespaceExists(context, clusterName, poolName, namespace)
+	if err != nil || !exists {
+		return err
+	}
+
+	logger.Infof("removing rados namespace %q from pool %q", namespace, poolName)
+	args := []string{"namespace", "remove", "--pool", poolName, "--namespace", namespace}
+	cmd := NewRBDCommand(context, clusterName, args)
+	output, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove rados namespace %q from pool %q. %s", namespace, poolName, string(output))
+	}
+	return nil
+}
+
+func radosNamespaceExists(context *clusterd.Context, clusterName, poolName, namespace string) (bool, error) {
+	namespaces, err := ListRadosNamespaces(context, clusterName, poolName)
+	if err != nil {
+		return false, err
+	}
+	for _, name := range namespaces {
+		if name == namespace {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRadosNamespaces(t *testing.T) {
+	namespaces := `[{"name":"tenant1"}]`
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, "rbd", command)
+			if args[0] == "namespace" && args[1] == "ls" {
+				assert.Equal(t, "pool1", args[2])
+				return namespaces, nil
+			}
+			if args[0] == "namespace" && (args[1] == "create" || args[1] == "remove") {
+				commands = append(commands, args[:6])
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	names, err := ListRadosNamespaces(context, "foocluster", "pool1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant1"}, names)
+
+	// only the missing namespaces are created
+	assert.NoError(t, CreateRadosNamespace(context, "foocluster", "pool1", "tenant1"))
+	assert.Empty(t, commands)
+	assert.NoError(t, CreateRadosNamespace(context, "foocluster", "pool1", "tenant2"))
+	assert.Equal(t, [][]string{{"namespace", "create", "--pool", "pool1", "--namespace", "tenant2"}}, commands)
+
+	// only the existing namespaces are removed
+	commands = nil
+	assert.NoError(t, DeleteRadosNamespace(context, "foocluster", "pool1", "tenant2"))
+	assert.Empty(t, commands)
+	assert.NoError(t, DeleteRadosNamespace(context, "foocluster", "pool1", "tenant1"))
+	assert.Equal(t, [][]string{{"namespace", "remove", "--pool", "pool1", "--namespace", "tenant1"}}, commands)
+
+	// no namespace in the pool
+	namespaces = "[]"
+	names, err = ListRadosNamespaces(context, "foocluster", "pool1")
+	assert.NoError(t, err)
+	assert.Empty(t, names)
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// SnapshotScheduleModuleName is the mgr module managing the snapshot schedules of the filesystems
+	SnapshotScheduleModuleName = "snap_schedule"
+	// defaultSnapshotSchedulePath is the directory of the filesystem snapshotted by a schedule without a path
+	defaultSnapshotSchedulePath = "/"
+)
+
+var (
+	// retentionPattern matches a count of snapshots kept for a period of a retention, e.g. 24h
+	retentionPattern = regexp.MustCompile(`(\d+)([mhdwMyn])`)
+	// fsStartTimeLayouts are the formats of the start times of the filesystem snapshot schedules
+	fsStartTimeLayouts = []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02"}
+)
+
+// FilesystemSnapshotSchedule is a schedule listed by 'ceph fs snap-schedule list'
+type FilesystemSnapshotSchedule struct {
+	Path      string         `json:"path"`
+	Schedule  string         `json:"schedule"`
+	Start     string         `json:"start"`
+	Retention map[string]int `json:"retention"`
+}
+
+// ParseSnapshotRetention returns the counts of snapshots kept per period of a retention like 24h4w
+func ParseSnapshotRetention(retention string) (map[string]int, error) {
+	counts := map[string]int{}
+	if retention == "" {
+		return counts, nil
+	}
+	if retentionPattern.ReplaceAllString(retention, "") != "" {
+		return nil, errors.Errorf("invalid snapshot retention %q, expected counts of snapshots per period like 24h4w", retention)
+	}
+	for _, match := range retentionPattern.FindAllStringSubmatch(retention, -1) {
+		count, err := strconv.Atoi(match[1])
+		if err != nil || count < 1 {
+			return nil, errors.Errorf("invalid snapshot retention %q, the counts must be positive", retention)
+		}
+		counts[match[2]] = count
+	}
+	return counts, nil
+}
+
+// ListFilesystemSnapshotSchedules returns the snapshot schedules of all the paths of the filesystem
+func ListFilesystemSnapshotSchedules(context *clusterd.Context, clusterName, fsName string) ([]FilesystemSnapshotSchedule, error) {
+	args := []string{"fs", "snap-schedule", "list", defaultSnapshotSchedulePath, "--recursive=true", "--fs", fsName}
+	buf, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list snapshot schedules of filesystem %q", fsName)
+	}
+	// no schedule is reported as an empty output or object
+	output := strings.TrimSpace(string(buf))
+	if output == "" || output == "{}" {
+		return nil, nil
+	}
+
+	var schedules []FilesystemSnapshotSchedule
+	if err := json.Unmarshal(buf, &schedules); err != nil {
+		return nil, errors.Wrapf(err, "failed to unmarshal snapshot schedules of filesystem %q. raw buffer response: %s", fsName, output)
+	}
+	return schedules, nil
+}
+
+// AddFilesystemSnapshotSchedule adds a snapshot schedule of a path of the filesystem
+func AddFilesystemSnapshotSchedule(context *clusterd.Context, clusterName, fsName, path, interval, startTime string) error {
+	args := []string{"fs", "snap-schedule", "add", path, interval}
+	if startTime != "" {
+		args = append(args, startTime)
+	}
+	args = append(args, "--fs", fsName)
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to add snapshot schedule %q of path %q of filesystem %q", interval, path, fsName)
+	}
+	logger.Infof("added snapshot schedule %q of path %q of filesystem %q", interval, path, fsName)
+	return nil
+}
+
+// RemoveFilesystemSnapshotSchedule removes a snapshot schedule of a path of the filesystem
+func RemoveFilesystemSnapshotSchedule(context *clusterd.Context, clusterName, fsName, path, interval, startTime string) error {
+	args := []string{"fs", "snap-schedule", "remove", path, interval}
+	if startTime != "" {
+		args = append(args, startTime)
+	}
+	args = append(args, "--fs", fsName)
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove snapshot schedule %q of path %q of filesystem %q", interval, path, fsName)
+	}
+	logger.Infof("removed snapshot schedule %q of path %q of filesystem %q", interval, path, fsName)
+	return nil
+}
+
+// AddFilesystemSnapshotRetention keeps the given count of snapshots of the period for a path of the filesystem
+func AddFilesystemSnapshotRetention(context *clusterd.Context, clusterName, fsName, path, period string, count int) error {
+	args := []string{"fs", "snap-schedule", "retention", "add", path, period, strconv.Itoa(count), "--fs", fsName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to add snapshot retention %d%s of path %q of filesystem %q", count, period, path, fsName)
+	}
+	return nil
+}
+
+// RemoveFilesystemSnapshotRetention stops keeping the snapshots of the period for a path of the filesystem
+func RemoveFilesystemSnapshotRetention(context *clusterd.Context, clusterName, fsName, path, period string, count int) error {
+	args := []string{"fs", "snap-schedule", "retention", "remove", path, period, strconv.Itoa(count), "--fs", fsName}
+	if _, err := NewCephCommand(context, clusterName, args).Run(); err != nil {
+		return errors.Wrapf(err, "failed to remove snapshot retention %d%s of path %q of filesystem %q", count, period, path, fsName)
+	}
+	return nil
+}
+
+// ReconcileFilesystemSnapshotSchedules makes the snapshot schedules and retentions of the filesystem match the given
+// schedules, removing the schedules of the filesystem not listed and adding the missing ones
+func ReconcileFilesystemSnapshotSchedules(context *clusterd.Context, clusterName, fsName string, schedules []cephv1.SnapshotScheduleSpec) error {
+	current, err := ListFilesystemSnapshotSchedules(context, clusterName, fsName)
+	if err != nil {
+		return err
+	}
+
+	// the retention is a setting of the path, shared by its schedules
+	desiredRetentions := map[string]map[string]int{}
+	for _, schedule := range schedules {
+		path := snapshotSchedulePath(schedule)
+		counts, err := ParseSnapshotRetention(schedule.Retention)
+		if err != nil {
+			return err
+		}
+		if desiredRetentions[path] == nil {
+			desiredRetentions[path] = map[string]int{}
+		}
+		for period, count := range counts {
+			desiredRetentions[path][period] = count
+		}
+	}
+	currentRetentions := map[string]map[string]int{}
+
+	for _, c := range current {
+		currentRetentions[c.Path] = c.Retention
+		found := false
+		for _, schedule := range schedules {
+			if snapshotSchedulePath(schedule) == c.Path && schedule.Interval == c.Schedule &&
+				sameStartTime(schedule.StartTime, c.Start, fsStartTimeLayouts) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if err := RemoveFilesystemSnapshotSchedule(context, clusterName, fsName, c.Path, c.Schedule, c.Start); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, schedule := range schedules {
+		path := snapshotSchedulePath(schedule)
+		found := false
+		for _, c := range current {
+			if c.Path == path && c.Schedule == schedule.Interval && sameStartTime(schedule.StartTime, c.Start, fsStartTimeLayouts) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			if err := AddFilesystemSnapshotSchedule(context, clusterName, fsName, path, schedule.Interval, schedule.StartTime); err != nil {
+				return err
+			}
+		}
+	}
+
+	// the retentions of the paths still scheduled are reconciled, the others went away with their schedules
+	paths := make([]string, 0, len(desiredRetentions))
+	for path := range desiredRetentions {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		desired := desiredRetentions[path]
+		for _, period := range sortedPeriods(currentRetentions[path]) {
+			count := currentRetentions[path][period]
+			if wanted, ok := desired[period]; !ok || wanted != count {
+				if err := RemoveFilesystemSnapshotRetention(context, clusterName, fsName, path, period, count); err != nil {
+					return err
+				}
+			}
+		}
+		for _, period := range sortedPeriods(desired) {
+			if count, ok := currentRetentions[path][period]; ok && count == desired[period] {
+				continue
+			}
+			if err := AddFilesystemSnapshotRetention(context, clusterName, fsName, path, period, desired[period]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// snapshotSchedulePath returns the path of the filesystem snapshotted by the schedule
+func snapshotSchedulePath(schedule cephv1.SnapshotScheduleSpec) string {
+	if schedule.Path == "" {
+		return defaultSnapshotSchedulePath
+	}
+	return schedule.Path
+}
+
+// sameStartTime returns whether the start time of a schedule matches the start time reported by ceph, a schedule
+// without a start time matching any since ceph then picks the start time
+func sameStartTime(desired, actual string, layouts []string) bool {
+	if desired == "" || desired == actual {
+		return true
+	}
+	for _, layout := range layouts {
+		d, err := time.Parse(layout, desired)
+		if err != nil {
+			continue
+		}
+		for _, l := range layouts {
+			if a, err := time.Parse(l, actual); err == nil {
+				return d.Equal(a)
+			}
+		}
+		return false
+	}
+	return false
+}
+
+// sortedPeriods returns the periods of the retention in order, for the commands to be run in a stable order
+func sortedPeriods(counts map[string]int) []string {
+	periods := make([]string, 0, len(counts))
+	for period := range counts {
+		periods = append(periods, period)
+	}
+	sort.Strings(periods)
+	return periods
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSnapshotRetention(t *testing.T) {
+	counts, err := ParseSnapshotRetention("24h4w")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"h": 24, "w": 4}, counts)
+
+	counts, err = ParseSnapshotRetention("")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(counts))
+
+	_, err = ParseSnapshotRetention("24")
+	assert.Error(t, err)
+	_, err = ParseSnapshotRetention("24x")
+	assert.Error(t, err)
+	_, err = ParseSnapshotRetention("0h")
+	assert.Error(t, err)
+}
+
+func TestReconcileFilesystemSnapshotSchedules(t *testing.T) {
+	listed := `[{"path":"/","schedule":"1h","start":"2020-01-01T00:00:00","retention":{"h":12,"d":7}},
+		{"path":"/volumes","schedule":"1d","start":"2020-01-01T00:00:00","retention":{}}]`
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			assert.Equal(t, "snap-schedule", args[1])
+			if args[2] == "list" {
+				assert.Equal(t, []string{"/", "--recursive=true", "--fs", "myfs"}, args[3:7])
+				return listed, nil
+			}
+			commands = append(commands, args[2:])
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	// the schedule of / is kept with a new retention, the schedule of /volumes is replaced
+	schedules := []cephv1.SnapshotScheduleSpec{
+		{Interval: "1h", Retention: "24h7d"},
+		{Path: "/volumes", Interval: "1w", StartTime: "2020-01-01T14:00:00"},
+	}
+	assert.NoError(t, ReconcileFilesystemSnapshotSchedules(context, "foocluster", "myfs", schedules))
+	assert.Equal(t, []string{"remove", "/volumes", "1d", "2020-01-01T00:00:00", "--fs", "myfs"}, commands[0][:6])
+	assert.Equal(t, []string{"add", "/volumes", "1w", "2020-01-01T14:00:00", "--fs", "myfs"}, commands[1][:6])
+	assert.Equal(t, []string{"retention", "remove", "/", "h", "12", "--fs", "myfs"}, commands[2][:7])
+	assert.Equal(t, []string{"retention", "add", "/", "h", "24", "--fs", "myfs"}, commands[3][:7])
+	assert.Equal(t, 4, len(commands))
+
+	// nothing changes once the schedules match
+	listed = `[{"path":"/","schedule":"1h","start":"2020-01-01T00:00:00","retention":{"h":24,"d":7}},
+		{"path":"/volumes","schedule":"1w","start":"2020-01-01T14:00:00","retention":{}}]`
+	commands = nil
+	assert.NoError(t, ReconcileFilesystemSnapshotSchedules(context, "foocluster", "myfs", schedules))
+	assert.Equal(t, 0, len(commands))
+
+	// no schedule listed
+	listed = `{}`
+	assert.NoError(t, ReconcileFilesystemSnapshotSchedules(context, "foocluster", "myfs", schedules[:1]))
+	assert.Equal(t, []string{"add", "/", "1h", "--fs", "myfs"}, commands[0][:5])
+}
+
+func TestSameStartTime(t *testing.T) {
+	assert.True(t, sameStartTime("", "2020-01-01T00:00:00", fsStartTimeLayouts))
+	assert.True(t, sameStartTime("2020-01-01T14:00", "2020-01-01T14:00:00", fsStartTimeLayouts))
+	assert.False(t, sameStartTime("2020-01-01T15:00:00", "2020-01-01T14:00:00", fsStartTimeLayouts))
+	assert.True(t, sameStartTime("14:00-05:00", "14:00:00-05:00", rbdStartTimeLayouts))
+	assert.False(t, sameStartTime("14:00-05:00", "14:00:00-04:00", rbdStartTimeLayouts))
+}
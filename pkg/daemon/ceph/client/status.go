@@ -59,6 +59,15 @@ type CephStatus struct {
 type HealthStatus struct {
 	Status string                  `json:"status"`
 	Checks map[string]CheckMessage `json:"checks"`
+	// Mutes are the health checks muted with 'ceph health mute'
+	Mutes []HealthMute `json:"mutes,omitempty"`
+}
+
+type HealthMute struct {
+	Code    string `json:"code"`
+	Sticky  bool   `json:"sticky"`
+	Summary string `json:"summary"`
+	Count   int    `json:"count"`
 }
 
 type CheckMessage struct {
@@ -66,6 +75,10 @@ type CheckMessage struct {
 	Summary  struct {
 		Message string `json:"message"`
 	} `json:"summary"`
+	// Detail is only populated by 'ceph health detail'
+	Detail []struct {
+		Message string `json:"message"`
+	} `json:"detail,omitempty"`
 }
 
 type MonMap struct {
@@ -74,6 +87,16 @@ type MonMap struct {
 	CreatedTime  string        `json:"created"`
 	ModifiedTime string        `json:"modified"`
 	Mons         []MonMapEntry `json:"mons"`
+	// NumMons is only reported by the compact monmap of the status from octopus, instead of the mons
+	NumMons int `json:"num_mons"`
+}
+
+// MonCount returns the number of mons of the monmap, whether it lists the mons or only counts them
+func (m MonMap) MonCount() int {
+	if len(m.Mons) > 0 {
+		return len(m.Mons)
+	}
+	return m.NumMons
 }
 
 type MgrMap struct {
@@ -83,6 +106,16 @@ type MgrMap struct {
 	ActiveAddr string       `json:"active_addr"`
 	Available  bool         `json:"available"`
 	Standbys   []MgrStandby `json:"standbys"`
+	// NumStandbys is only reported by the compact mgrmap of the status from octopus, instead of the standbys
+	NumStandbys int `json:"num_standbys"`
+}
+
+// StandbyCount returns the number of standby mgrs of the mgrmap, whether it lists the standbys or only counts them
+func (m MgrMap) StandbyCount() int {
+	if len(m.Standbys) > 0 {
+		return len(m.Standbys)
+	}
+	return m.NumStandbys
 }
 
 type MgrStandby struct {
@@ -144,7 +177,7 @@ type Fsmap struct {
 
 func Status(context *clusterd.Context, clusterName string) (CephStatus, error) {
 	args := []string{"status"}
-	cmd := NewCephCommand(context, clusterName, args)
+	cmd := newMonCommand(context, clusterName, map[string]interface{}{"prefix": "status"}, args)
 	buf, err := cmd.Run()
 	if err != nil {
 		return CephStatus{}, errors.Wrapf(err, "failed to get status. %s", string(buf))
@@ -159,10 +192,7 @@ func Status(context *clusterd.Context, clusterName string) (CephStatus, error) {
 }
 
 func StatusWithUser(context *clusterd.Context, clusterName, userName string) (CephStatus, error) {
-	args := []string{"status", "--format", "json"}
-	command, args := FinalizeCephCommandArgs("ceph", args, context.ConfigDir, clusterName, userName)
-
-	buf, err := context.Executor.ExecuteCommandWithOutput(command, args...)
+	buf, err := runMonCommandWithUser(context, clusterName, userName, map[string]interface{}{"prefix": "status"}, []string{"status"})
 	if err != nil {
 		return CephStatus{}, errors.Wrapf(err, "failed to get status. %s", string(buf))
 	}
@@ -175,6 +205,22 @@ func StatusWithUser(context *clusterd.Context, clusterName, userName string) (Ce
 	return status, nil
 }
 
+// HealthDetailWithUser returns the health of the cluster including the detail messages of every health check
+func HealthDetailWithUser(context *clusterd.Context, clusterName, userName string) (HealthStatus, error) {
+	monCommand := map[string]interface{}{"prefix": "health", "detail": "detail"}
+	buf, err := runMonCommandWithUser(context, clusterName, userName, monCommand, []string{"health", "detail"})
+	if err != nil {
+		return HealthStatus{}, errors.Wrapf(err, "failed to get health detail. %s", string(buf))
+	}
+
+	var health HealthStatus
+	if err := json.Unmarshal([]byte(buf), &health); err != nil {
+		return HealthStatus{}, errors.Wrap(err, "failed to unmarshal health detail response")
+	}
+
+	return health, nil
+}
+
 // IsClusterClean returns msg (string), clean (bool), err (error)
 // msg describes the state of the PGs
 // clean is true if the cluster is clean
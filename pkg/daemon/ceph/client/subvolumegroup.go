@@ -0,0 +1,83 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// SubVolumeGroupPinExport pins a subvolume group to an MDS rank
+	SubVolumeGroupPinExport = "export"
+	// SubVolumeGroupPinDistributed spreads the subvolumes of a group across the MDS ranks
+	SubVolumeGroupPinDistributed = "distributed"
+	// SubVolumeGroupPinRandom pins the subdirectories of a group to random MDS ranks
+	SubVolumeGroupPinRandom = "random"
+)
+
+// CreateSubVolumeGroup creates a subvolume group in a filesystem, nothing is done if the group exists
+func CreateSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName string) error {
+	logger.Infof("creating subvolume group %q in filesystem %q", groupName, fsName)
+	args := []string{"fs", "subvolumegroup", "create", fsName, groupName}
+	output, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to create subvolume group %q in filesystem %q. %s", groupName, fsName, string(output))
+	}
+	return nil
+}
+
+// ResizeSubVolumeGroup sets the quota of a subvolume group in bytes, the quota being removed if the size is zero
+func ResizeSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName string, size int64) error {
+	newSize := "inf"
+	if size > 0 {
+		newSize = strconv.FormatInt(size, 10)
+	}
+	logger.Infof("setting quota of subvolume group %q in filesystem %q to %q", groupName, fsName, newSize)
+	args := []string{"fs", "subvolumegroup", "resize", fsName, groupName, newSize}
+	output, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set quota of subvolume group %q in filesystem %q. %s", groupName, fsName, string(output))
+	}
+	return nil
+}
+
+// PinSubVolumeGroup pins a subvolume group to the MDS ranks with the export, distributed or random policy
+func PinSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName, pinType, pinSetting string) error {
+	logger.Infof("pinning subvolume group %q in filesystem %q with %s pin %q", groupName, fsName, pinType, pinSetting)
+	args := []string{"fs", "subvolumegroup", "pin", fsName, groupName, pinType, pinSetting}
+	output, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to pin subvolume group %q in filesystem %q. %s", groupName, fsName, string(output))
+	}
+	return nil
+}
+
+// DeleteSubVolumeGroup removes a subvolume group from a filesystem if it exists. The removal fails if the group still
+// has subvolumes.
+func DeleteSubVolumeGroup(context *clusterd.Context, clusterName, fsName, groupName string) error {
+	logger.Infof("removing subvolume group %q from filesystem %q", groupName, fsName)
+	// --force ignores a group that does not exist
+	args := []string{"fs", "subvolumegroup", "rm", fsName, groupName, "--force"}
+	output, err := NewCephCommand(context, clusterName, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove subvolume group %q from filesystem %q. %s", groupName, fsName, string(output))
+	}
+	return nil
+}
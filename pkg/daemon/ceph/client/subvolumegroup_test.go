@@ -0,0 +1,51 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubVolumeGroups(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolumegroup" {
+				commands = append(commands, args[2:])
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, CreateSubVolumeGroup(context, "ns", "myfs", "group1"))
+	assert.Equal(t, []string{"create", "myfs", "group1"}, commands[0][:3])
+	assert.NoError(t, ResizeSubVolumeGroup(context, "ns", "myfs", "group1", 1073741824))
+	assert.Equal(t, []string{"resize", "myfs", "group1", "1073741824"}, commands[1][:4])
+	assert.NoError(t, ResizeSubVolumeGroup(context, "ns", "myfs", "group1", 0))
+	assert.Equal(t, []string{"resize", "myfs", "group1", "inf"}, commands[2][:4])
+	assert.NoError(t, PinSubVolumeGroup(context, "ns", "myfs", "group1", SubVolumeGroupPinDistributed, "1"))
+	assert.Equal(t, []string{"pin", "myfs", "group1", "distributed", "1"}, commands[3][:5])
+	assert.NoError(t, DeleteSubVolumeGroup(context, "ns", "myfs", "group1"))
+	assert.Equal(t, []string{"rm", "myfs", "group1", "--force"}, commands[4][:4])
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	optest "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+)
+
+const (
+	// fakePGCount is the number of placement groups of the fake cluster, all active and clean
+	fakePGCount = 32
+)
+
+// OSD is the state of an osd of a fake ceph cluster
+type OSD struct {
+	ID   int
+	Host string
+	Up   bool
+	In   bool
+}
+
+// FakeCephCluster answers the ceph commands run by the operator from the state of a fake cluster, so that the health
+// checkers, or the custom health check settings of a cluster, can be tested without a live cluster. The outputs of
+// `ceph status`, `ceph health detail`, `ceph quorum_status`, `ceph osd dump` and `ceph osd tree` are generated from the
+// state, the outputs of the other commands being set by the tests.
+//
+// The state may be changed with the setters while the checkers run.
+type FakeCephCluster struct {
+	// Health is the health status of the cluster, HEALTH_OK by default
+	Health string
+	// Checks are the health checks failing, keyed by their code, e.g. OSD_DOWN
+	Checks map[string]string
+	// Mons are the names of the mons in quorum
+	Mons []string
+	// OSDs are the osds of the cluster
+	OSDs []OSD
+	// Outputs are the outputs of the commands, keyed by the arguments of the commands before their flags joined with
+	// spaces, e.g. "osd safe-to-destroy 0". A key also matches the commands it is the prefix of, such as
+	// "osd safe-to-destroy" for all the osds. The outputs override the outputs generated from the state.
+	Outputs map[string]string
+	// Errors fail the commands, keyed the same way as the outputs
+	Errors map[string]error
+
+	mutex    sync.Mutex
+	commands []string
+}
+
+// NewFakeCephCluster returns a healthy fake cluster with a mon in quorum and an osd up and in on each of the hosts
+func NewFakeCephCluster(osdHosts ...string) *FakeCephCluster {
+	c := &FakeCephCluster{
+		Health:  "HEALTH_OK",
+		Checks:  map[string]string{},
+		Mons:    []string{"a"},
+		Outputs: map[string]string{},
+		Errors:  map[string]error{},
+	}
+	for i, host := range osdHosts {
+		c.OSDs = append(c.OSDs, OSD{ID: i, Host: host, Up: true, In: true})
+	}
+	return c
+}
+
+// Context returns a context running the commands against the fake cluster, with a fake clientset holding the given
+// number of ready nodes named node0, node1, etc.
+func (c *FakeCephCluster) Context(t *testing.T, nodes int) *clusterd.Context {
+	return &clusterd.Context{
+		Clientset: optest.New(t, nodes),
+		Executor:  c.Executor(),
+	}
+}
+
+// Executor returns an executor running the commands against the fake cluster
+func (c *FakeCephCluster) Executor() *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return c.run(command, args)
+		},
+		MockExecuteCommandWithTimeout: func(timeout time.Duration, command string, args ...string) (string, error) {
+			return c.run(command, args)
+		},
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			return c.run(command, args)
+		},
+		MockExecuteCommandWithOutputFileTimeout: func(timeout time.Duration, command, outfileArg string, args ...string) (string, error) {
+			return c.run(command, args)
+		},
+	}
+}
+
+// SetHealth sets the health status of the cluster and its failing checks
+func (c *FakeCephCluster) SetHealth(health string, checks map[string]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.Health = health
+	c.Checks = checks
+}
+
+// SetOSD adds an osd to the cluster or replaces the osd with the same id
+func (c *FakeCephCluster) SetOSD(osd OSD) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for i := range c.OSDs {
+		if c.OSDs[i].ID == osd.ID {
+			c.OSDs[i] = osd
+			return
+		}
+	}
+	c.OSDs = append(c.OSDs, osd)
+}
+
+// SetOutput sets the output of the commands matching the key
+func (c *FakeCephCluster) SetOutput(key, output string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.Outputs[key] = output
+}
+
+// SetError fails the commands matching the key, or lets them succeed again if the error is nil
+func (c *FakeCephCluster) SetError(key string, err error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if err == nil {
+		delete(c.Errors, key)
+		return
+	}
+	c.Errors[key] = err
+}
+
+// Commands returns the keys of the commands run against the cluster, in order
+func (c *FakeCephCluster) Commands() []string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return append([]string{}, c.commands...)
+}
+
+// CommandCount returns how many commands matching the key were run against the cluster
+func (c *FakeCephCluster) CommandCount(key string) int {
+	count := 0
+	for _, command := range c.Commands() {
+		if command == key || strings.HasPrefix(command, key+" ") {
+			count++
+		}
+	}
+	return count
+}
+
+func (c *FakeCephCluster) run(command string, args []string) (string, error) {
+	key := commandKey(args)
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.commands = append(c.commands, key)
+
+	words := strings.Fields(key)
+	for i := len(words); i > 0; i-- {
+		prefix := strings.Join(words[:i], " ")
+		if err, ok := c.Errors[prefix]; ok {
+			return "", err
+		}
+		if output, ok := c.Outputs[prefix]; ok {
+			return output, nil
+		}
+	}
+	if command != client.CephTool {
+		return "", nil
+	}
+	return c.stateOutput(key)
+}
+
+// commandKey returns the arguments of a command before its flags, the connection flags being appended to the
+// arguments of the ceph commands
+func commandKey(args []string) string {
+	key := []string{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--") {
+			break
+		}
+		key = append(key, arg)
+	}
+	return strings.Join(key, " ")
+}
+
+// stateOutput returns the output of the commands generated from the state of the cluster, none for the other commands
+func (c *FakeCephCluster) stateOutput(key string) (string, error) {
+	var output interface{}
+	switch key {
+	case "status":
+		output = c.status()
+	case "health detail":
+		output = c.healthStatus()
+	case "quorum_status", "mon_status":
+		output = c.quorumStatus()
+	case "osd dump":
+		output = c.osdDump()
+	case "osd tree":
+		output = c.osdTree()
+	default:
+		return "", nil
+	}
+	serialized, err := json.Marshal(output)
+	if err != nil {
+		return "", err
+	}
+	return string(serialized), nil
+}
+
+func (c *FakeCephCluster) healthStatus() client.HealthStatus {
+	health := client.HealthStatus{Status: c.Health, Checks: map[string]client.CheckMessage{}}
+	for code, message := range c.Checks {
+		check := client.CheckMessage{Severity: c.Health}
+		check.Summary.Message = message
+		health.Checks[code] = check
+	}
+	return health
+}
+
+func (c *FakeCephCluster) status() client.CephStatus {
+	status := client.CephStatus{Health: c.healthStatus(), FSID: "fake-fsid", QuorumNames: c.Mons}
+	for i := range c.Mons {
+		status.Quorum = append(status.Quorum, i)
+	}
+	status.MonMap.Mons = c.monMap()
+	status.OsdMap.OsdMap.NumOsd = len(c.OSDs)
+	for _, osd := range c.OSDs {
+		if osd.Up {
+			status.OsdMap.OsdMap.NumUpOsd++
+		}
+		if osd.In {
+			status.OsdMap.OsdMap.NumInOsd++
+		}
+	}
+	if len(c.OSDs) > 0 {
+		status.PgMap.NumPgs = fakePGCount
+		status.PgMap.PgsByState = []client.PgStateEntry{{StateName: "active+clean", Count: fakePGCount}}
+	}
+	return status
+}
+
+func (c *FakeCephCluster) monMap() []client.MonMapEntry {
+	mons := []client.MonMapEntry{}
+	for i, name := range c.Mons {
+		mons = append(mons, client.MonMapEntry{Name: name, Rank: i, Address: fmt.Sprintf("1.2.3.%d:6789/0", i+1)})
+	}
+	return mons
+}
+
+func (c *FakeCephCluster) quorumStatus() client.MonStatusResponse {
+	resp := client.MonStatusResponse{Quorum: []int{}}
+	for i := range c.Mons {
+		resp.Quorum = append(resp.Quorum, i)
+	}
+	resp.MonMap.Mons = c.monMap()
+	return resp
+}
+
+func (c *FakeCephCluster) osdDump() map[string]interface{} {
+	osds := []map[string]int{}
+	for _, osd := range c.OSDs {
+		osds = append(osds, map[string]int{"osd": osd.ID, "up": boolToInt(osd.Up), "in": boolToInt(osd.In)})
+	}
+	return map[string]interface{}{"osds": osds, "flags": "sortbitwise,recovery_deletes,purged_snapdirs,pglog_hardlimit"}
+}
+
+// osdTree returns the tree of the osds under their host, under the default root
+func (c *FakeCephCluster) osdTree() map[string]interface{} {
+	osdsByHost := map[string][]OSD{}
+	hosts := []string{}
+	for _, osd := range c.OSDs {
+		if _, ok := osdsByHost[osd.Host]; !ok {
+			hosts = append(hosts, osd.Host)
+		}
+		osdsByHost[osd.Host] = append(osdsByHost[osd.Host], osd)
+	}
+	sort.Strings(hosts)
+
+	root := map[string]interface{}{"id": -1, "name": "default", "type": "root", "type_id": 10}
+	nodes := []map[string]interface{}{root}
+	hostIDs := []int{}
+	for i, host := range hosts {
+		hostID := -2 - i
+		hostIDs = append(hostIDs, hostID)
+		osdIDs := []int{}
+		for _, osd := range osdsByHost[host] {
+			osdIDs = append(osdIDs, osd.ID)
+		}
+		nodes = append(nodes, map[string]interface{}{"id": hostID, "name": host, "type": "host", "type_id": 1, "children": osdIDs})
+	}
+	root["children"] = hostIDs
+	for _, host := range hosts {
+		for _, osd := range osdsByHost[host] {
+			status := "down"
+			if osd.Up {
+				status = "up"
+			}
+			nodes = append(nodes, map[string]interface{}{
+				"id": osd.ID, "name": fmt.Sprintf("osd.%d", osd.ID), "type": "osd", "type_id": 0,
+				"crush_weight": 1, "exists": 1, "status": status, "reweight": boolToInt(osd.In),
+			})
+		}
+	}
+	return map[string]interface{}{"nodes": nodes, "stray": []interface{}{}}
+}
+
+func boolToInt(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
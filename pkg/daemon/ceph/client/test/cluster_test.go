@@ -0,0 +1,98 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeCephClusterState(t *testing.T) {
+	fake := NewFakeCephCluster("node0", "node1")
+	context := fake.Context(t, 2)
+
+	status, err := client.Status(context, "fake-state")
+	assert.NoError(t, err)
+	assert.Equal(t, "HEALTH_OK", status.Health.Status)
+	assert.Equal(t, []string{"a"}, status.QuorumNames)
+	assert.Equal(t, 2, status.OsdMap.OsdMap.NumUpOsd)
+
+	// the placement groups of the osds are all clean
+	_, clean, err := client.IsClusterClean(context, "fake-state")
+	assert.NoError(t, err)
+	assert.True(t, clean)
+
+	// the state changes are reflected in the outputs
+	fake.SetHealth("HEALTH_WARN", map[string]string{"OSD_DOWN": "1 osds down"})
+	fake.SetOSD(OSD{ID: 1, Host: "node1", Up: false, In: true})
+	status, err = client.Status(context, "fake-state")
+	assert.NoError(t, err)
+	assert.Equal(t, "HEALTH_WARN", status.Health.Status)
+	assert.Equal(t, "1 osds down", status.Health.Checks["OSD_DOWN"].Summary.Message)
+	assert.Equal(t, 1, status.OsdMap.OsdMap.NumUpOsd)
+
+	dump, err := client.GetOSDDump(context, "fake-state")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(dump.OSDs))
+	assert.Equal(t, "0", dump.OSDs[1].Up.String())
+	assert.True(t, dump.IsFlagSet("sortbitwise"))
+
+	tree, err := client.HostTree(context, "fake-state")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, len(tree.Nodes))
+	assert.Equal(t, "node1", tree.Nodes[2].Name)
+	assert.Equal(t, []int{1}, tree.Nodes[2].Children)
+	assert.Equal(t, "down", tree.Nodes[4].Status)
+
+	quorum, err := client.GetMonQuorumStatus(context, "fake-state")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0}, quorum.Quorum)
+	assert.Equal(t, "a", quorum.MonMap.Mons[0].Name)
+}
+
+func TestFakeCephClusterOutputs(t *testing.T) {
+	fake := NewFakeCephCluster("node0")
+	context := fake.Context(t, 1)
+
+	// the outputs set by the tests match the commands they are the prefix of
+	fake.SetOutput("osd safe-to-destroy", `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`)
+	safe, err := client.OsdSafeToDestroy(context, "fake-outputs", 0)
+	assert.NoError(t, err)
+	assert.True(t, safe)
+
+	// the longest key wins
+	fake.SetOutput("osd safe-to-destroy 0", `{"safe_to_destroy":[],"active":[0],"missing_stats":[],"stored_pgs":[]}`)
+	safe, err = client.OsdSafeToDestroy(context, "fake-outputs", 0)
+	assert.NoError(t, err)
+	assert.False(t, safe)
+
+	// the errors fail the commands until cleared
+	fake.SetError("osd dump", errors.New("mock failure"))
+	_, err = client.GetOSDDump(context, "fake-outputs")
+	assert.Error(t, err)
+	fake.SetError("osd dump", nil)
+	_, err = client.GetOSDDump(context, "fake-outputs")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, fake.CommandCount("osd safe-to-destroy"))
+	assert.Equal(t, 0, fake.CommandCount("osd safe-to-destroy 1"))
+	assert.Equal(t, 2, fake.CommandCount("osd dump"))
+	assert.Equal(t, []string{"osd safe-to-destroy 0", "osd safe-to-destroy 0", "osd dump", "osd dump"}, fake.Commands())
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
@@ -37,6 +38,9 @@ import (
 const (
 	pvcDataTypeDevice     = "data"
 	pvcMetadataTypeDevice = "metadata"
+	pvcWalTypeDevice      = "wal"
+	// the wal device of an OSD on PVC is exposed by the operator under this directory
+	pvcWalDevicePath = "/wal/"
 )
 
 var (
@@ -186,15 +190,18 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation string)
 		rawDevice.Type = pvcDataTypeDevice
 		rawDevices = append(rawDevices, rawDevice)
 
-		// We have a metadata device
-		if len(agent.devices) > 1 {
-			rawMetadataDevice, err := clusterd.PopulateDeviceInfo(agent.devices[1].Name, context.Executor)
+		// We have a metadata and/or a wal device
+		for _, device := range agent.devices[1:] {
+			rawMetadataDevice, err := clusterd.PopulateDeviceInfo(device.Name, context.Executor)
 			if err != nil {
-				return errors.Wrapf(err, "failed to get device info for %q", agent.devices[1].Name)
+				return errors.Wrapf(err, "failed to get device info for %q", device.Name)
 			}
 
-			// set it's a metadata device
+			// set whether it's a wal or a metadata device
 			rawMetadataDevice.Type = pvcMetadataTypeDevice
+			if strings.HasPrefix(device.Name, pvcWalDevicePath) {
+				rawMetadataDevice.Type = pvcWalTypeDevice
+			}
 			rawDevices = append(rawDevices, rawMetadataDevice)
 		}
 	} else {
@@ -368,6 +375,10 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Metadata: []int{}}
 		} else if len(desiredDevices) == 1 && desiredDevices[0].Name == "all" {
 			// user has specified all devices, use the current one for data
+			if matched, reason := matchesDeviceSizeAndType(device, desiredDevices[0]); !matched {
+				logger.Infof("skipping device %q: %s", device.Name, reason)
+				continue
+			}
 			deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID}
 		} else if len(desiredDevices) > 0 {
 			var matched bool
@@ -417,14 +428,21 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 				}
 			}
 
+			if err == nil && matched && !agent.pvcBacked {
+				if sizeAndTypeMatched, reason := matchesDeviceSizeAndType(device, matchedDevice); !sizeAndTypeMatched {
+					logger.Infof("skipping device %q: %s", device.Name, reason)
+					continue
+				}
+			}
+
 			if err == nil && matched {
 				// the current device matches the user specifies filter/list, use it for data
 				logger.Infof("device %q is selected by the device filter/name %q", device.Name, matchedDevice.Name)
 				deviceInfo = &DeviceOsdIDEntry{Data: unassignedOSDID, Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks)}
 
 				// set that this is not an OSD but a metadata device
-				if device.Type == pvcMetadataTypeDevice {
-					logger.Infof("metadata device %q is selected by the device filter/name %q", device.Name, matchedDevice.Name)
+				if device.Type == pvcMetadataTypeDevice || device.Type == pvcWalTypeDevice {
+					logger.Infof("%s device %q is selected by the device filter/name %q", device.Type, device.Name, matchedDevice.Name)
 					deviceInfo = &DeviceOsdIDEntry{Config: matchedDevice, PersistentDevicePaths: strings.Fields(device.DevLinks), Metadata: []int{1}}
 				}
 			} else {
@@ -444,6 +462,8 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 					available.Entries[pvcDataTypeDevice] = deviceInfo
 				} else if device.Type == pvcMetadataTypeDevice {
 					available.Entries[pvcMetadataTypeDevice] = deviceInfo
+				} else if device.Type == pvcWalTypeDevice {
+					available.Entries[pvcWalTypeDevice] = deviceInfo
 				}
 			} else {
 				available.Entries[device.Name] = deviceInfo
@@ -454,6 +474,34 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 	return available, nil
 }
 
+// matchesDeviceSizeAndType checks the size and the type of a device against the filters of the desired device,
+// returning the reason why it does not match
+func matchesDeviceSizeAndType(device *sys.LocalDisk, desiredDevice DesiredDevice) (bool, string) {
+	if desiredDevice.MinSize > 0 && device.Size < desiredDevice.MinSize {
+		return false, fmt.Sprintf("size %d is smaller than the minimum size %d", device.Size, desiredDevice.MinSize)
+	}
+	if desiredDevice.MaxSize > 0 && device.Size > desiredDevice.MaxSize {
+		return false, fmt.Sprintf("size %d is larger than the maximum size %d", device.Size, desiredDevice.MaxSize)
+	}
+	if desiredDevice.DeviceType != "" {
+		if deviceType := getDeviceType(device); deviceType != desiredDevice.DeviceType {
+			return false, fmt.Sprintf("device type %q is not the desired device type %q", deviceType, desiredDevice.DeviceType)
+		}
+	}
+	return true, ""
+}
+
+// getDeviceType returns whether a device is an hdd, an ssd or an nvme device
+func getDeviceType(device *sys.LocalDisk) string {
+	if strings.HasPrefix(filepath.Base(device.Name), "nvme") {
+		return rookv1.DeviceTypeNVMe
+	}
+	if device.Rotational {
+		return rookv1.DeviceTypeHDD
+	}
+	return rookv1.DeviceTypeSSD
+}
+
 // releaseLVMDevice deactivates the LV to release the device.
 func releaseLVMDevice(context *clusterd.Context, volumeGroupName string) error {
 	if op, err := context.Executor.ExecuteCommandWithCombinedOutput("lvchange", "-an", "-vv", volumeGroupName); err != nil {
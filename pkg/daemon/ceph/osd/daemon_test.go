@@ -308,6 +308,16 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	assert.Equal(t, -1, mapping.Entries["sda"].Data)
 	assert.Equal(t, -1, mapping.Entries["sdd"].Data)
 
+	// select the sd* devices of a type
+	agent.devices = []DesiredDevice{{Name: "^sd.$", IsFilter: true, DeviceType: "hdd"}}
+	mapping, err = getAvailableDevices(context, agent)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(mapping.Entries))
+	agent.devices = []DesiredDevice{{Name: "^sd.$", IsFilter: true, DeviceType: "ssd"}}
+	mapping, err = getAvailableDevices(context, agent)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(mapping.Entries))
+
 	// select an exact device
 	agent.devices = []DesiredDevice{{Name: "sdd"}}
 	mapping, err = getAvailableDevices(context, agent)
@@ -376,6 +386,30 @@ NAME="sdb1" SIZE="30" TYPE="part" PKNAME="sdb"`, nil
 	assert.Equal(t, 1, len(mapping.Entries), mapping)
 }
 
+func TestMatchesDeviceSizeAndType(t *testing.T) {
+	hdd := &sys.LocalDisk{Name: "sda", Size: 100, Rotational: true}
+	ssd := &sys.LocalDisk{Name: "sdb", Size: 200}
+	nvme := &sys.LocalDisk{Name: "/dev/nvme0n1", Size: 300}
+
+	matched, _ := matchesDeviceSizeAndType(hdd, DesiredDevice{})
+	assert.True(t, matched)
+	matched, _ = matchesDeviceSizeAndType(hdd, DesiredDevice{MinSize: 150})
+	assert.False(t, matched)
+	matched, _ = matchesDeviceSizeAndType(ssd, DesiredDevice{MinSize: 150, MaxSize: 250})
+	assert.True(t, matched)
+	matched, reason := matchesDeviceSizeAndType(nvme, DesiredDevice{MaxSize: 250})
+	assert.False(t, matched)
+	assert.Contains(t, reason, "maximum size")
+
+	assert.Equal(t, "hdd", getDeviceType(hdd))
+	assert.Equal(t, "ssd", getDeviceType(ssd))
+	assert.Equal(t, "nvme", getDeviceType(nvme))
+	matched, _ = matchesDeviceSizeAndType(nvme, DesiredDevice{DeviceType: "ssd"})
+	assert.False(t, matched)
+	matched, _ = matchesDeviceSizeAndType(nvme, DesiredDevice{DeviceType: "nvme"})
+	assert.True(t, matched)
+}
+
 func TestGetVolumeGroupName(t *testing.T) {
 	validLVPath := "/dev/vgName1/lvName2"
 	invalidLVPath1 := "/dev//vgName2"
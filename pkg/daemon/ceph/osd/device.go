@@ -67,10 +67,16 @@ type DesiredDevice struct {
 	Name               string
 	OSDsPerDevice      int
 	MetadataDevice     string
+	WalDevice          string
 	DatabaseSizeMB     int
 	DeviceClass        string
 	IsFilter           bool
 	IsDevicePathFilter bool
+	// MinSize and MaxSize are the bounds in bytes of the size of the devices to select, 0 if not bounded
+	MinSize uint64
+	MaxSize uint64
+	// DeviceType is the type of the devices to select: hdd, ssd or nvme
+	DeviceType string
 }
 
 // DeviceOsdMapping represents the mapping of an OSD on disk
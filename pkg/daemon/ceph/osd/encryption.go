@@ -0,0 +1,185 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+)
+
+const (
+	cryptsetupBinary = "cryptsetup"
+)
+
+// withKeyFile runs a function with the path of a file holding the key, since the executor cannot pass the key to
+// the stdin of cryptsetup. The file is removed once the function returns.
+func withKeyFile(key string, f func(keyFile string) error) error {
+	file, err := ioutil.TempFile("", "dmcrypt-key-")
+	if err != nil {
+		return errors.Wrap(err, "failed to create the key file")
+	}
+	defer os.Remove(file.Name())
+
+	if err := file.Chmod(0600); err != nil {
+		file.Close()
+		return errors.Wrap(err, "failed to restrict the permissions of the key file")
+	}
+	if _, err := file.WriteString(key); err != nil {
+		file.Close()
+		return errors.Wrap(err, "failed to write the key file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "failed to close the key file")
+	}
+	return f(file.Name())
+}
+
+// isLUKS gets whether the block has a LUKS header
+func isLUKS(context *clusterd.Context, block string) bool {
+	return context.Executor.ExecuteCommand(cryptsetupBinary, "isLuks", block) == nil
+}
+
+// isEncryptedBlockOpen gets whether the dm-crypt mapping of the block is active
+func isEncryptedBlockOpen(dmName string) bool {
+	_, err := os.Stat(oposd.EncryptionDMPath(dmName))
+	return err == nil
+}
+
+// encryptBlock formats the block of an osd on a pvc with LUKS, with a new key stored in the key management service.
+// The key of a block that is already formatted is kept.
+func encryptBlock(context *clusterd.Context, kmsConfig *kms.Config, block, keyName string) error {
+	if isLUKS(context, block) {
+		logger.Infof("block %q is already encrypted", block)
+		return nil
+	}
+
+	if err := kmsConfig.EnsureSecret(keyName); err != nil {
+		return errors.Wrapf(err, "failed to store the encryption key %q", keyName)
+	}
+	key, err := kmsConfig.GetSecret(keyName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the encryption key %q", keyName)
+	}
+
+	logger.Infof("encrypting block %q", block)
+	return withKeyFile(key, func(keyFile string) error {
+		op, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, "luksFormat", "--batch-mode", "--type", "luks2", "--key-file", keyFile, block)
+		if err != nil {
+			return errors.Wrapf(err, "failed to format block %q with LUKS. %s", block, op)
+		}
+		return nil
+	})
+}
+
+// OpenEncryptedBlock opens the dm-crypt mapping of the block of an osd with its key from the key management service
+func OpenEncryptedBlock(context *clusterd.Context, kmsConfig *kms.Config, block, dmName, keyName string) error {
+	if isEncryptedBlockOpen(dmName) {
+		logger.Infof("encrypted block %q is already open", dmName)
+		return nil
+	}
+
+	key, err := kmsConfig.GetSecret(keyName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the encryption key %q", keyName)
+	}
+
+	logger.Infof("opening encrypted block %q as %q", block, dmName)
+	return withKeyFile(key, func(keyFile string) error {
+		op, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, "luksOpen", "--allow-discards", "--key-file", keyFile, block, dmName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to open encrypted block %q. %s", block, op)
+		}
+		return nil
+	})
+}
+
+// closeEncryptedBlock closes the dm-crypt mapping of an encrypted block
+func closeEncryptedBlock(context *clusterd.Context, dmName string) error {
+	if !isEncryptedBlockOpen(dmName) {
+		return nil
+	}
+	op, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, "luksClose", dmName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to close encrypted block %q. %s", dmName, op)
+	}
+	return nil
+}
+
+// RotateEncryptionKey replaces the key of the encrypted block of an osd. The new key is added to the LUKS header
+// before being stored in the key management service, and the previous key is only removed from the header once the
+// new key is stored, so that the block can always be opened with the stored key.
+func RotateEncryptionKey(context *clusterd.Context, kmsConfig *kms.Config, block, keyName string) error {
+	oldKey, err := kmsConfig.GetSecret(keyName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the encryption key %q", keyName)
+	}
+	newKey, err := kms.GenerateEncryptionKey()
+	if err != nil {
+		return err
+	}
+
+	err = withKeyFile(oldKey, func(oldKeyFile string) error {
+		return withKeyFile(newKey, func(newKeyFile string) error {
+			op, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, "luksAddKey", "--batch-mode", "--key-file", oldKeyFile, block, newKeyFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to add the new key to encrypted block %q. %s", block, op)
+			}
+			if err := kmsConfig.PutSecret(keyName, newKey); err != nil {
+				return errors.Wrapf(err, "failed to store the new encryption key %q, the previous key is kept", keyName)
+			}
+			op, err = context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, "luksRemoveKey", "--batch-mode", block, oldKeyFile)
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove the previous key of encrypted block %q. %s", block, op)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("rotated the encryption key %q of block %q", keyName, block)
+	return nil
+}
+
+// openEncryptedPVCBlock encrypts the block of the osd on a pvc if it is not encrypted yet and opens it, returning
+// the path of the dm-crypt device to prepare the osd on
+func (a *OsdAgent) openEncryptedPVCBlock(context *clusterd.Context, block string) (string, error) {
+	kmsConfig := kms.NewConfigFromEnv(context, a.cluster.Name)
+	keyName := kms.EncryptionKeyName(a.nodeName)
+	if err := encryptBlock(context, kmsConfig, block, keyName); err != nil {
+		return "", err
+	}
+
+	dmName := oposd.EncryptionDMName(a.nodeName)
+	if err := OpenEncryptedBlock(context, kmsConfig, block, dmName, keyName); err != nil {
+		return "", err
+	}
+	return oposd.EncryptionDMPath(dmName), nil
+}
+
+// closeEncryptedPVCBlock closes the dm-crypt device of the osd on a pvc once prepared, the osd pod opening it again
+func (a *OsdAgent) closeEncryptedPVCBlock(context *clusterd.Context) {
+	if err := closeEncryptedBlock(context, oposd.EncryptionDMName(a.nodeName)); err != nil {
+		logger.Warningf("failed to close the encrypted block of the osd on pvc %q. %v", a.nodeName, err)
+	}
+}
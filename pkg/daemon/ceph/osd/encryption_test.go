@@ -0,0 +1,102 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestEncryptBlock(t *testing.T) {
+	formatted := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommand: func(command string, args ...string) error {
+			if command == cryptsetupBinary && args[0] == "isLuks" && formatted {
+				return nil
+			}
+			return errors.New("not a luks device")
+		},
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, cryptsetupBinary, command)
+			assert.Equal(t, "luksFormat", args[0])
+			assert.Equal(t, "/mnt/pvc1", args[len(args)-1])
+			formatted = true
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+	kmsConfig := kms.NewConfig(context, "ns", nil, "", nil)
+
+	assert.NoError(t, encryptBlock(context, kmsConfig, "/mnt/pvc1", "key1"))
+	assert.True(t, formatted)
+	key, err := kmsConfig.GetSecret("key1")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key)
+
+	// an encrypted block keeps its key
+	assert.NoError(t, encryptBlock(context, kmsConfig, "/mnt/pvc1", "key1"))
+	newKey, err := kmsConfig.GetSecret("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, key, newKey)
+}
+
+func TestRotateEncryptionKey(t *testing.T) {
+	var commands []string
+	var keyFiles []string
+	failAdd := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithCombinedOutput: func(command string, args ...string) (string, error) {
+			commands = append(commands, args[0])
+			if args[0] == "luksAddKey" {
+				if failAdd {
+					return "", errors.New("no key available with this passphrase")
+				}
+				// the block is opened with the stored key to add the new key
+				oldKey, err := ioutil.ReadFile(args[3])
+				assert.NoError(t, err)
+				newKey, err := ioutil.ReadFile(args[5])
+				assert.NoError(t, err)
+				keyFiles = []string{string(oldKey), string(newKey)}
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), Executor: executor}
+	kmsConfig := kms.NewConfig(context, "ns", nil, "", nil)
+	assert.NoError(t, kmsConfig.PutSecret("key1", "old"))
+
+	assert.NoError(t, RotateEncryptionKey(context, kmsConfig, "/mnt/pvc1", "key1"))
+	assert.Equal(t, []string{"luksAddKey", "luksRemoveKey"}, commands)
+	assert.Equal(t, "old", keyFiles[0])
+	key, err := kmsConfig.GetSecret("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, keyFiles[1], key)
+
+	// the stored key is kept if the new key cannot be added
+	failAdd = true
+	assert.Error(t, RotateEncryptionKey(context, kmsConfig, "/mnt/pvc1", "key1"))
+	stored, err := kmsConfig.GetSecret("key1")
+	assert.NoError(t, err)
+	assert.Equal(t, key, stored)
+}
@@ -0,0 +1,283 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package kms stores the encryption keys of the osds in kubernetes secrets or in an external key management service.
+package kms
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-kms")
+
+const (
+	// Provider is the connection detail selecting the key management service
+	Provider = "KMS_PROVIDER"
+	// ProviderVault is the provider of the Hashicorp Vault key management service
+	ProviderVault = "vault"
+	// VaultTokenEnvVarName is the env var of the vault token in the pods of the osds
+	VaultTokenEnvVarName = "VAULT_TOKEN"
+
+	vaultAddressKey      = "VAULT_ADDR"
+	vaultAuthMethodKey   = "VAULT_AUTH_METHOD"
+	vaultBackendPathKey  = "VAULT_BACKEND_PATH"
+	vaultBackendKey      = "VAULT_BACKEND"
+	vaultNamespaceKey    = "VAULT_NAMESPACE"
+	vaultSkipVerifyKey   = "VAULT_SKIP_VERIFY"
+	vaultTokenAuthMethod = "token"
+
+	// tokenSecretKey is the key of the token in the secret of the token of the key management service
+	tokenSecretKey = "token"
+	// encryptionKeySecretKey is the key of the encryption key in its kubernetes secret
+	encryptionKeySecretKey = "dmcrypt-key"
+	encryptionKeyNameFmt   = "rook-ceph-osd-encryption-key-%s"
+	encryptionKeySize      = 32
+)
+
+var (
+	// ErrKeyNotFound is returned when the encryption key is not stored
+	ErrKeyNotFound = errors.New("encryption key not found")
+
+	// connectionDetailKeys are the connection details passed to the pods of the osds
+	connectionDetailKeys = []string{Provider, vaultAddressKey, vaultAuthMethodKey, vaultBackendPathKey, vaultBackendKey, vaultNamespaceKey, vaultSkipVerifyKey}
+)
+
+// Config is the storage of the encryption keys
+type Config struct {
+	context           *clusterd.Context
+	namespace         string
+	connectionDetails map[string]string
+	token             string
+	ownerRef          *metav1.OwnerReference
+}
+
+// NewConfig returns the storage of the encryption keys of the given connection details, the kubernetes secrets in the
+// namespace if no key management service is configured
+func NewConfig(context *clusterd.Context, namespace string, connectionDetails map[string]string, token string, ownerRef *metav1.OwnerReference) *Config {
+	return &Config{
+		context:           context,
+		namespace:         namespace,
+		connectionDetails: connectionDetails,
+		token:             token,
+		ownerRef:          ownerRef,
+	}
+}
+
+// NewConfigFromSpec returns the storage of the encryption keys of the security settings of the cluster, reading
+// the token of the key management service from its secret
+func NewConfigFromSpec(context *clusterd.Context, namespace string, spec cephv1.KeyManagementServiceSpec, ownerRef *metav1.OwnerReference) (*Config, error) {
+	token := ""
+	if spec.IsEnabled() && spec.TokenSecretName != "" {
+		secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(spec.TokenSecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the kms token secret %q", spec.TokenSecretName)
+		}
+		token = string(secret.Data[tokenSecretKey])
+	}
+	return NewConfig(context, namespace, spec.ConnectionDetails, token, ownerRef), nil
+}
+
+// NewConfigFromEnv returns the storage of the encryption keys from the env vars set by the operator in the pods of
+// the osds
+func NewConfigFromEnv(context *clusterd.Context, namespace string) *Config {
+	connectionDetails := map[string]string{}
+	for _, key := range connectionDetailKeys {
+		if value := os.Getenv(key); value != "" {
+			connectionDetails[key] = value
+		}
+	}
+	return NewConfig(context, namespace, connectionDetails, os.Getenv(VaultTokenEnvVarName), nil)
+}
+
+// EnvVars returns the env vars passing the connection details and the token of the key management service to the pods
+// of the osds
+func EnvVars(spec cephv1.KeyManagementServiceSpec) []v1.EnvVar {
+	envVars := []v1.EnvVar{}
+	if !spec.IsEnabled() {
+		return envVars
+	}
+	for _, key := range connectionDetailKeys {
+		if value, ok := spec.ConnectionDetails[key]; ok {
+			envVars = append(envVars, v1.EnvVar{Name: key, Value: value})
+		}
+	}
+	if spec.TokenSecretName != "" {
+		envVars = append(envVars, v1.EnvVar{
+			Name: VaultTokenEnvVarName,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: spec.TokenSecretName},
+					Key:                  tokenSecretKey,
+				},
+			},
+		})
+	}
+	return envVars
+}
+
+// ValidateConnectionDetails ensures the settings of the key management service are supported and its token exists
+func ValidateConnectionDetails(context *clusterd.Context, namespace string, spec cephv1.KeyManagementServiceSpec) error {
+	if !spec.IsEnabled() {
+		return nil
+	}
+	provider := spec.ConnectionDetails[Provider]
+	if provider != ProviderVault {
+		return errors.Errorf("unsupported kms provider %q, only %q is supported", provider, ProviderVault)
+	}
+	if spec.ConnectionDetails[vaultAddressKey] == "" {
+		return errors.Errorf("missing %s for the kms provider %q", vaultAddressKey, provider)
+	}
+	if method := spec.ConnectionDetails[vaultAuthMethodKey]; method != "" && method != vaultTokenAuthMethod {
+		return errors.Errorf("unsupported vault auth method %q, only %q is supported", method, vaultTokenAuthMethod)
+	}
+	if backend := spec.ConnectionDetails[vaultBackendKey]; backend != "" && backend != "v1" && backend != "v2" {
+		return errors.Errorf("unsupported vault backend %q, the kv secrets engine must be \"v1\" or \"v2\"", backend)
+	}
+	if spec.TokenSecretName == "" {
+		return errors.New("missing the tokenSecretName of the kms")
+	}
+
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(spec.TokenSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the kms token secret %q", spec.TokenSecretName)
+	}
+	if len(secret.Data[tokenSecretKey]) == 0 {
+		return errors.Errorf("the kms token secret %q has no %q key", spec.TokenSecretName, tokenSecretKey)
+	}
+	return nil
+}
+
+// EncryptionKeyName returns the name of the encryption key of the osd on a pvc
+func EncryptionKeyName(pvcName string) string {
+	return fmt.Sprintf(encryptionKeyNameFmt, pvcName)
+}
+
+// GenerateEncryptionKey returns a random passphrase for the dm-crypt device of an osd
+func GenerateEncryptionKey() (string, error) {
+	key := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", errors.Wrap(err, "failed to generate the encryption key")
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// IsVault gets whether the keys are stored in vault
+func (c *Config) IsVault() bool {
+	return c.connectionDetails[Provider] == ProviderVault
+}
+
+// GetSecret returns the encryption key of the given name, ErrKeyNotFound if the key is not stored
+func (c *Config) GetSecret(name string) (string, error) {
+	if c.IsVault() {
+		return c.vault().getSecret(name)
+	}
+
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return "", ErrKeyNotFound
+		}
+		return "", errors.Wrapf(err, "failed to get the encryption key secret %q", name)
+	}
+	key, ok := secret.Data[encryptionKeySecretKey]
+	if !ok {
+		return "", ErrKeyNotFound
+	}
+	return string(key), nil
+}
+
+// PutSecret stores the encryption key of the given name, replacing the previous key
+func (c *Config) PutSecret(name, value string) error {
+	if c.IsVault() {
+		return c.vault().putSecret(name, value)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.namespace,
+		},
+		Data: map[string][]byte{encryptionKeySecretKey: []byte(value)},
+		Type: k8sutil.RookType,
+	}
+	if c.ownerRef != nil {
+		k8sutil.SetOwnerRef(&secret.ObjectMeta, c.ownerRef)
+	}
+
+	existing, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get the encryption key secret %q", name)
+		}
+		if _, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Create(secret); err != nil {
+			return errors.Wrapf(err, "failed to create the encryption key secret %q", name)
+		}
+		return nil
+	}
+
+	// the owner of the secret is kept when the key is rotated from the pod of an osd
+	existing.Data = secret.Data
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.namespace).Update(existing); err != nil {
+		return errors.Wrapf(err, "failed to update the encryption key secret %q", name)
+	}
+	return nil
+}
+
+// DeleteSecret removes the encryption key of the given name
+func (c *Config) DeleteSecret(name string) error {
+	if c.IsVault() {
+		return c.vault().deleteSecret(name)
+	}
+
+	err := c.context.Clientset.CoreV1().Secrets(c.namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete the encryption key secret %q", name)
+	}
+	return nil
+}
+
+// EnsureSecret stores a new encryption key of the given name if none is stored yet
+func (c *Config) EnsureSecret(name string) error {
+	_, err := c.GetSecret(name)
+	if err == nil {
+		return nil
+	}
+	if err != ErrKeyNotFound {
+		return err
+	}
+
+	key, err := GenerateEncryptionKey()
+	if err != nil {
+		return err
+	}
+	if err := c.PutSecret(name, key); err != nil {
+		return err
+	}
+	logger.Infof("stored the new encryption key %q", name)
+	return nil
+}
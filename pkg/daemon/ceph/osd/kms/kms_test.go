@@ -0,0 +1,123 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKubernetesSecrets(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	ownerRef := &metav1.OwnerReference{Name: "rook-ceph", UID: "uid"}
+	c := NewConfig(context, "ns", nil, "", ownerRef)
+	name := EncryptionKeyName("set1-data-0")
+	assert.Equal(t, "rook-ceph-osd-encryption-key-set1-data-0", name)
+	assert.False(t, c.IsVault())
+
+	_, err := c.GetSecret(name)
+	assert.Equal(t, ErrKeyNotFound, err)
+
+	// a key is generated once
+	assert.NoError(t, c.EnsureSecret(name))
+	key, err := c.GetSecret(name)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key)
+	assert.NoError(t, c.EnsureSecret(name))
+	same, err := c.GetSecret(name)
+	assert.NoError(t, err)
+	assert.Equal(t, key, same)
+
+	// the key is replaced by a rotation that keeps the owner of the secret
+	assert.NoError(t, NewConfig(context, "ns", nil, "", nil).PutSecret(name, "rotated"))
+	key, err = c.GetSecret(name)
+	assert.NoError(t, err)
+	assert.Equal(t, "rotated", key)
+	secret, err := clientset.CoreV1().Secrets("ns").Get(name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph", secret.OwnerReferences[0].Name)
+
+	assert.NoError(t, c.DeleteSecret(name))
+	assert.NoError(t, c.DeleteSecret(name))
+	_, err = c.GetSecret(name)
+	assert.Equal(t, ErrKeyNotFound, err)
+}
+
+func TestGenerateEncryptionKey(t *testing.T) {
+	key, err := GenerateEncryptionKey()
+	assert.NoError(t, err)
+	other, err := GenerateEncryptionKey()
+	assert.NoError(t, err)
+	assert.Len(t, key, 44)
+	assert.NotEqual(t, key, other)
+}
+
+func TestEnvVars(t *testing.T) {
+	assert.Empty(t, EnvVars(cephv1.KeyManagementServiceSpec{TokenSecretName: "token"}))
+
+	spec := cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{Provider: ProviderVault, vaultAddressKey: "https://vault:8200", "UNKNOWN": "value"},
+		TokenSecretName:   "vault-token",
+	}
+	envVars := EnvVars(spec)
+	assert.Equal(t, 3, len(envVars))
+	assert.Equal(t, v1.EnvVar{Name: Provider, Value: ProviderVault}, envVars[0])
+	assert.Equal(t, v1.EnvVar{Name: vaultAddressKey, Value: "https://vault:8200"}, envVars[1])
+	assert.Equal(t, VaultTokenEnvVarName, envVars[2].Name)
+	assert.Equal(t, "vault-token", envVars[2].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "token", envVars[2].ValueFrom.SecretKeyRef.Key)
+}
+
+func TestValidateConnectionDetails(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "vault-token", Namespace: "ns"}, Data: map[string][]byte{"token": []byte("s.token")}},
+		&v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "empty-token", Namespace: "ns"}},
+	)
+	context := &clusterd.Context{Clientset: clientset}
+	spec := func(tokenSecret string, details ...string) cephv1.KeyManagementServiceSpec {
+		s := cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{}, TokenSecretName: tokenSecret}
+		for i := 0; i+1 < len(details); i += 2 {
+			s.ConnectionDetails[details[i]] = details[i+1]
+		}
+		return s
+	}
+
+	// the kubernetes secrets store the keys by default
+	assert.NoError(t, ValidateConnectionDetails(context, "ns", cephv1.KeyManagementServiceSpec{}))
+
+	assert.NoError(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200")))
+	assert.NoError(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200", vaultAuthMethodKey, "token", vaultBackendKey, "v1")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, "barbican", vaultAddressKey, "https://vault:8200")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, ProviderVault)))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200", vaultAuthMethodKey, "kubernetes")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("vault-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200", vaultBackendKey, "v3")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("", Provider, ProviderVault, vaultAddressKey, "https://vault:8200")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("missing", Provider, ProviderVault, vaultAddressKey, "https://vault:8200")))
+	assert.Error(t, ValidateConnectionDetails(context, "ns", spec("empty-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200")))
+
+	c, err := NewConfigFromSpec(context, "ns", spec("vault-token", Provider, ProviderVault, vaultAddressKey, "https://vault:8200"), nil)
+	assert.NoError(t, err)
+	assert.True(t, c.IsVault())
+	assert.Equal(t, "s.token", c.token)
+}
@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	vaultTimeout            = 30 * time.Second
+	vaultDefaultBackendPath = "secret"
+	vaultTokenHeader        = "X-Vault-Token"
+	vaultNamespaceHeader    = "X-Vault-Namespace"
+)
+
+// vaultClient stores the keys in the kv secrets engine of vault with the http api
+type vaultClient struct {
+	address     string
+	token       string
+	namespace   string
+	backendPath string
+	// kvVersion is the version of the kv secrets engine, v2 storing the data of a secret in a "data" object
+	kvVersion  string
+	httpClient *http.Client
+}
+
+// vaultError is the error returned by the vault api
+type vaultError struct {
+	statusCode int
+	Errors     []string `json:"errors"`
+}
+
+func (e *vaultError) Error() string {
+	return fmt.Sprintf("vault request failed with status %d. %s", e.statusCode, strings.Join(e.Errors, ", "))
+}
+
+func (c *Config) vault() *vaultClient {
+	backendPath := strings.Trim(c.connectionDetails[vaultBackendPathKey], "/")
+	if backendPath == "" {
+		backendPath = vaultDefaultBackendPath
+	}
+	kvVersion := c.connectionDetails[vaultBackendKey]
+	if kvVersion == "" {
+		kvVersion = "v2"
+	}
+
+	httpClient := &http.Client{Timeout: vaultTimeout}
+	if skipVerify, _ := strconv.ParseBool(c.connectionDetails[vaultSkipVerifyKey]); skipVerify {
+		// #nosec G402 the verification is only skipped when requested by the settings of the cluster
+		httpClient.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	return &vaultClient{
+		address:     strings.TrimSuffix(c.connectionDetails[vaultAddressKey], "/"),
+		token:       c.token,
+		namespace:   c.connectionDetails[vaultNamespaceKey],
+		backendPath: backendPath,
+		kvVersion:   kvVersion,
+		httpClient:  httpClient,
+	}
+}
+
+// secretURL returns the url of the data of a secret, or of its metadata to delete all the versions of the secret
+// of a kv v2 engine
+func (v *vaultClient) secretURL(name string, metadata bool) string {
+	if v.kvVersion != "v2" {
+		return fmt.Sprintf("%s/v1/%s/%s", v.address, v.backendPath, name)
+	}
+	if metadata {
+		return fmt.Sprintf("%s/v1/%s/metadata/%s", v.address, v.backendPath, name)
+	}
+	return fmt.Sprintf("%s/v1/%s/data/%s", v.address, v.backendPath, name)
+}
+
+func (v *vaultClient) getSecret(name string) (string, error) {
+	body, err := v.request(http.MethodGet, v.secretURL(name, false), nil)
+	if err != nil {
+		if verr, ok := err.(*vaultError); ok && verr.statusCode == http.StatusNotFound {
+			return "", ErrKeyNotFound
+		}
+		return "", errors.Wrapf(err, "failed to get the encryption key %q from vault", name)
+	}
+
+	var response struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the encryption key %q from vault", name)
+	}
+	data := response.Data
+	if v.kvVersion == "v2" {
+		var versioned struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &versioned); err != nil {
+			return "", errors.Wrapf(err, "failed to parse the encryption key %q from vault", name)
+		}
+		data = versioned.Data
+	}
+
+	var keys map[string]string
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return "", errors.Wrapf(err, "failed to parse the encryption key %q from vault", name)
+	}
+	key, ok := keys[encryptionKeySecretKey]
+	if !ok {
+		// a deleted version of a kv v2 secret has no data
+		return "", ErrKeyNotFound
+	}
+	return key, nil
+}
+
+func (v *vaultClient) putSecret(name, value string) error {
+	var payload interface{} = map[string]string{encryptionKeySecretKey: value}
+	if v.kvVersion == "v2" {
+		payload = map[string]interface{}{"data": payload}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the encryption key")
+	}
+
+	if _, err := v.request(http.MethodPost, v.secretURL(name, false), body); err != nil {
+		return errors.Wrapf(err, "failed to store the encryption key %q in vault", name)
+	}
+	return nil
+}
+
+func (v *vaultClient) deleteSecret(name string) error {
+	_, err := v.request(http.MethodDelete, v.secretURL(name, true), nil)
+	if err != nil {
+		if verr, ok := err.(*vaultError); ok && verr.statusCode == http.StatusNotFound {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete the encryption key %q from vault", name)
+	}
+	return nil
+}
+
+func (v *vaultClient) request(method, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create the vault request %s %s", method, url)
+	}
+	req.Header.Set(vaultTokenHeader, v.token)
+	if v.namespace != "" {
+		req.Header.Set(vaultNamespaceHeader, v.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to send the vault request %s %s", method, url)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read the vault response")
+	}
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		verr := &vaultError{statusCode: resp.StatusCode}
+		// the error messages are best effort
+		_ = json.Unmarshal(respBody, verr)
+		return nil, verr
+	}
+	return respBody, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newVaultServer emulates the kv secrets engine of vault at the "rook" path
+func newVaultServer(t *testing.T, kvVersion string) *httptest.Server {
+	secrets := map[string]json.RawMessage{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(vaultTokenHeader) != "s.token" {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		assert.Equal(t, "team", r.Header.Get(vaultNamespaceHeader))
+
+		name := strings.TrimPrefix(r.URL.Path, "/v1/rook/")
+		if kvVersion == "v2" {
+			if r.Method == http.MethodDelete {
+				assert.True(t, strings.HasPrefix(name, "metadata/"), name)
+				name = strings.TrimPrefix(name, "metadata/")
+			} else {
+				assert.True(t, strings.HasPrefix(name, "data/"), name)
+				name = strings.TrimPrefix(name, "data/")
+			}
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := secrets[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"errors":[]}`))
+				return
+			}
+			// the posted data of a kv v2 secret is already in its "data" object
+			_, _ = w.Write([]byte(`{"data":` + string(data) + `}`))
+		case http.MethodPost:
+			body, err := ioutil.ReadAll(r.Body)
+			assert.NoError(t, err)
+			secrets[name] = body
+			w.WriteHeader(http.StatusNoContent)
+		case http.MethodDelete:
+			delete(secrets, name)
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
+
+func TestVaultSecrets(t *testing.T) {
+	for _, kvVersion := range []string{"v1", "v2"} {
+		t.Run(kvVersion, func(t *testing.T) {
+			server := newVaultServer(t, kvVersion)
+			defer server.Close()
+			details := map[string]string{
+				Provider:            ProviderVault,
+				vaultAddressKey:     server.URL + "/",
+				vaultBackendPathKey: "/rook/",
+				vaultBackendKey:     kvVersion,
+				vaultNamespaceKey:   "team",
+			}
+			c := NewConfig(nil, "ns", details, "s.token", nil)
+			name := EncryptionKeyName("set1-data-0")
+
+			_, err := c.GetSecret(name)
+			assert.Equal(t, ErrKeyNotFound, err)
+
+			assert.NoError(t, c.EnsureSecret(name))
+			key, err := c.GetSecret(name)
+			assert.NoError(t, err)
+			assert.NotEmpty(t, key)
+
+			assert.NoError(t, c.PutSecret(name, "rotated"))
+			key, err = c.GetSecret(name)
+			assert.NoError(t, err)
+			assert.Equal(t, "rotated", key)
+
+			assert.NoError(t, c.DeleteSecret(name))
+			_, err = c.GetSecret(name)
+			assert.Equal(t, ErrKeyNotFound, err)
+
+			// the errors of vault are reported
+			_, err = NewConfig(nil, "ns", details, "wrong", nil).GetSecret(name)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), "permission denied")
+		})
+	}
+}
@@ -40,6 +40,7 @@ const (
 	encryptedFlag        = "--dmcrypt"
 	databaseSizeFlag     = "--block-db-size"
 	dbDeviceFlag         = "--db-devices"
+	walDeviceFlag        = "--wal-devices"
 	cephVolumeCmd        = "ceph-volume"
 	cephVolumeMinDBSize  = 1024 // 1GB
 )
@@ -127,11 +128,24 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 				// I'm leaving this code with an empty metadata device for now
 				metadataBlock = ""
 
+				// the osd of an encrypted block is listed from its dm-crypt device, a block that is not encrypted being
+				// left as is
+				encrypted := a.storeConfig.EncryptedDevice && isLUKS(context, block)
+				if encrypted {
+					block, err = a.openEncryptedPVCBlock(context, block)
+					if err != nil {
+						return nil, errors.Wrap(err, "failed to open the encrypted block")
+					}
+				}
+
 				rawOsds, err = GetCephVolumeRawOSDs(context, a.cluster.Name, a.cluster.FSID, block, metadataBlock, lvBackedPV)
 				if err != nil {
 					logger.Infof("failed to get device already provisioned by ceph-volume raw. %v", err)
 				}
 				osds = append(osds, rawOsds...)
+				if encrypted {
+					a.closeEncryptedPVCBlock(context)
+				}
 			}
 
 			return osds, nil
@@ -161,8 +175,8 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 			// Otherwise lsblk will fail since the block will be '/dev/mnt/set1-0-data-l6p5q'
 			// And thus won't be a block device
 			//
-			// The same goes the metadata block device which is stored in /srv
-			if !strings.HasPrefix(dev, "/mnt") && !strings.HasPrefix(dev, "/srv") {
+			// The same goes the metadata block device which is stored in /srv and the wal block device stored in /wal
+			if !strings.HasPrefix(dev, "/mnt") && !strings.HasPrefix(dev, "/srv") && !strings.HasPrefix(dev, "/wal") {
 				dev = path.Join("/dev", dev)
 			}
 			lvBackedPV, err = sys.IsLV(dev, context.Executor)
@@ -204,6 +218,10 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 	// List THE configured OSD with ceph-volume raw mode
 	if a.cluster.CephVersion.IsAtLeast(cephVolumeRawModeMinCephVersion) && !lvBackedPV {
 		block = fmt.Sprintf("/mnt/%s", a.nodeName)
+		if a.pvcBacked && a.storeConfig.EncryptedDevice {
+			block = oposd.EncryptionDMPath(oposd.EncryptionDMName(a.nodeName))
+			defer a.closeEncryptedPVCBlock(context)
+		}
 		rawOsds, err = GetCephVolumeRawOSDs(context, a.cluster.Name, a.cluster.FSID, block, metadataBlock, lvBackedPV)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to get devices already provisioned by ceph-volume raw")
@@ -245,10 +263,10 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 	// therefore the iteration order of a map is not guaranteed to be the same every time you iterate over it.
 	// So we could first get the metadata device and then the main block in a scenario where a metadata PVC is present
 	for name, device := range devices.Entries {
-		// If this is the metadata device there is nothing to do
+		// If this is the metadata or the wal device there is nothing to do
 		// it'll be used in one of the iterations
-		if name == "metadata" {
-			logger.Debugf("device %q is a metadata device, skipping this iteration it will be used in the next one", device.Config.Name)
+		if name == pvcMetadataTypeDevice || name == pvcWalTypeDevice {
+			logger.Debugf("device %q is a %s device, skipping this iteration it will be used in the next one", device.Config.Name, name)
 			// Don't do this device
 			continue
 		}
@@ -265,6 +283,14 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 			metadataBlockPath = devices.Entries["metadata"].Config.Name
 		}
 
+		// The same goes for a wal device
+		if _, ok := devices.Entries[pvcWalTypeDevice]; ok {
+			metadataDev = true
+			metadataArg = append(metadataArg, []string{"--block.wal",
+				devices.Entries[pvcWalTypeDevice].Config.Name,
+			}...)
+		}
+
 		if device.Data == -1 {
 			logger.Infof("configuring new device %q", device.Config.Name)
 			var err error
@@ -280,17 +306,29 @@ func (a *OsdAgent) initializeBlockPVC(context *clusterd.Context, devices *Device
 				deviceArg = device.Config.Name
 			}
 
+			// ceph-volume raw mode prepares the osd on the dm-crypt device of the block, its key being stored in the
+			// key management service, while the lvm mode encrypts the logical volume itself
+			if a.storeConfig.EncryptedDevice && cephVolumeMode == "raw" {
+				deviceArg, err = a.openEncryptedPVCBlock(context, deviceArg)
+				if err != nil {
+					return "", "", errors.Wrapf(err, "failed to encrypt device %q", device.Config.Name)
+				}
+			}
+
 			immediateExecuteArgs := append(baseArgs, []string{
 				"--data",
 				deviceArg,
 			}...)
+			if a.storeConfig.EncryptedDevice && cephVolumeMode == "lvm" {
+				immediateExecuteArgs = append(immediateExecuteArgs, encryptedFlag)
+			}
 
 			crushDeviceClass := os.Getenv(oposd.CrushDeviceClassVarName)
 			if crushDeviceClass != "" {
 				immediateExecuteArgs = append(immediateExecuteArgs, []string{crushDeviceClassFlag, crushDeviceClass}...)
 			}
 
-			// Add the cli argument for the metadata device
+			// Add the cli arguments for the metadata and wal devices
 			if metadataDev {
 				immediateExecuteArgs = append(immediateExecuteArgs, metadataArg...)
 			}
@@ -431,11 +469,18 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					if deviceOSDCount != metadataDevices[md]["osdsperdevice"] {
 						return errors.Errorf("metadataDevice (%s) has more than 1 osdsPerDevice value set: %s != %s", md, deviceOSDCount, metadataDevices[md]["osdsperdevice"])
 					}
+					// Fail when two devices using the same metadata device have different wal devices
+					if device.Config.WalDevice != metadataDevices[md]["waldevice"] {
+						return errors.Errorf("metadataDevice (%s) has more than 1 walDevice value set: %s != %s", md, device.Config.WalDevice, metadataDevices[md]["waldevice"])
+					}
 				} else {
 					metadataDevices[md] = make(map[string]string)
 					metadataDevices[md]["osdsperdevice"] = deviceOSDCount
-					if device.Config.DeviceClass != "" {
-						metadataDevices[md]["deviceclass"] = device.Config.DeviceClass
+					if deviceClass := desiredDeviceClass(device.Config); deviceClass != "" {
+						metadataDevices[md]["deviceclass"] = deviceClass
+					}
+					if device.Config.WalDevice != "" {
+						metadataDevices[md]["waldevice"] = device.Config.WalDevice
 					}
 					metadataDevices[md]["devices"] = deviceArg
 				}
@@ -462,10 +507,18 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 					deviceArg,
 				}...)
 
-				if device.Config.DeviceClass != "" {
+				if deviceClass := desiredDeviceClass(device.Config); deviceClass != "" {
 					immediateExecuteArgs = append(immediateExecuteArgs, []string{
 						crushDeviceClassFlag,
-						device.Config.DeviceClass,
+						deviceClass,
+					}...)
+				}
+
+				if device.Config.WalDevice != "" {
+					logger.Infof("using %s as walDevice for device %s", device.Config.WalDevice, deviceArg)
+					immediateExecuteArgs = append(immediateExecuteArgs, []string{
+						walDeviceFlag,
+						path.Join("/dev", device.Config.WalDevice),
 					}...)
 				}
 
@@ -520,6 +573,13 @@ func (a *OsdAgent) initializeDevices(context *clusterd.Context, devices *DeviceO
 			path.Join("/dev", md),
 		}...)
 
+		if _, ok := conf["waldevice"]; ok {
+			mdArgs = append(mdArgs, []string{
+				walDeviceFlag,
+				path.Join("/dev", conf["waldevice"]),
+			}...)
+		}
+
 		// Reporting
 		reportArgs := append(mdArgs, []string{
 			"--report",
@@ -573,6 +633,15 @@ func sanitizeOSDsPerDevice(count int) string {
 	return strconv.Itoa(count)
 }
 
+// desiredDeviceClass returns the CRUSH device class of a device, the device filters getting the class of the node
+// from the env
+func desiredDeviceClass(device DesiredDevice) string {
+	if device.DeviceClass != "" {
+		return device.DeviceClass
+	}
+	return os.Getenv(oposd.CrushDeviceClassVarName)
+}
+
 // GetCephVolumeLVMOSDs list OSD prepared with lvm mode
 func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterName string, cephfsid, lv string, skipLVRelease, lvBackedPV bool) ([]oposd.OSDInfo, error) {
 	// lv can be a block device if raw mode is used
@@ -476,6 +476,62 @@ func TestInitializeBlockPVCWithMetadata(t *testing.T) {
 	assert.Equal(t, "/srv/set1-metadata-0-8c7kr", metadataBlockPath)
 }
 
+func TestInitializeDevicesWithWal(t *testing.T) {
+	var batchArgs [][]string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommand = func(command string, args ...string) error {
+		logger.Infof("%s %v", command, args)
+		batchArgs = append(batchArgs, args)
+		return nil
+	}
+	executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
+		logger.Infof("%s %v", command, args)
+		return `{"vg": {"devices": "/dev/sdb"}}`, nil
+	}
+	context := &clusterd.Context{Executor: executor}
+	a := &OsdAgent{cluster: &cephconfig.ClusterInfo{}, nodeName: "node1"}
+
+	// the wal device is passed along the data device
+	devices := &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"sda": {Data: -1, Config: DesiredDevice{Name: "sda", WalDevice: "nvme01"}},
+		},
+	}
+	err := a.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(batchArgs))
+	for _, args := range batchArgs {
+		assert.Contains(t, args, "/dev/sda")
+		assert.Contains(t, args, walDeviceFlag)
+		assert.Contains(t, args, "/dev/nvme01")
+	}
+
+	// the wal device is passed along the metadata device
+	batchArgs = nil
+	devices = &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"sdc": {Data: -1, Config: DesiredDevice{Name: "sdc", MetadataDevice: "sdb", WalDevice: "nvme01"}},
+			"sdd": {Data: -1, Config: DesiredDevice{Name: "sdd", MetadataDevice: "sdb", WalDevice: "nvme01"}},
+		},
+	}
+	err = a.initializeDevices(context, devices)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(batchArgs))
+	for _, args := range batchArgs {
+		assert.Contains(t, args, "/dev/sdc")
+		assert.Contains(t, args, "/dev/sdd")
+		assert.Contains(t, args, dbDeviceFlag)
+		assert.Contains(t, args, "/dev/sdb")
+		assert.Contains(t, args, walDeviceFlag)
+		assert.Contains(t, args, "/dev/nvme01")
+	}
+
+	// the devices sharing a metadata device must share the wal device
+	devices.Entries["sdd"].Config.WalDevice = "nvme02"
+	err = a.initializeDevices(context, devices)
+	assert.NotNil(t, err)
+}
+
 func TestParseCephVolumeLVMResult(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
@@ -563,6 +619,17 @@ func TestSanitizeOSDsPerDevice(t *testing.T) {
 	assert.Equal(t, "2", sanitizeOSDsPerDevice(2))
 }
 
+func TestDesiredDeviceClass(t *testing.T) {
+	assert.Equal(t, "", desiredDeviceClass(DesiredDevice{Name: "sda"}))
+	assert.Equal(t, "nvme-meta", desiredDeviceClass(DesiredDevice{Name: "sda", DeviceClass: "nvme-meta"}))
+
+	// the devices selected by a filter get the class of the node
+	os.Setenv(oposd.CrushDeviceClassVarName, "hdd-archive")
+	defer os.Unsetenv(oposd.CrushDeviceClassVarName)
+	assert.Equal(t, "hdd-archive", desiredDeviceClass(DesiredDevice{Name: "^sd.", IsFilter: true}))
+	assert.Equal(t, "nvme-meta", desiredDeviceClass(DesiredDevice{Name: "sda", DeviceClass: "nvme-meta"}))
+}
+
 func TestGetDatabaseSize(t *testing.T) {
 	assert.Equal(t, 0, getDatabaseSize(0, 0))
 	assert.Equal(t, 2048, getDatabaseSize(4096, 2048))
@@ -263,6 +263,7 @@ func (a *Agent) createAgentDaemonSet(namespace, agentImage, serviceAccount strin
 			}
 		}
 	}
+	k8sutil.ApplyImagePullSecrets(namespace, &ds.Spec.Template.Spec)
 
 	_, err = a.clientset.AppsV1().DaemonSets(namespace).Create(ds)
 	if err != nil {
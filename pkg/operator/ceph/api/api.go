@@ -0,0 +1,201 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package api serves the read-only queries of the ceph clusters of the operator, such as the health, the mon quorum,
+// the osd tree or whether a daemon is ok to stop, so that the kubectl plugins and other tools need not exec ceph
+// commands in the toolbox.
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// Port is the port of the https server of the api
+	Port = 8443
+	// pathPrefix is the prefix of the paths of the queries of a cluster: /v1/clusters/<namespace>/<query>
+	pathPrefix = "/v1/clusters/"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-api")
+
+// okToStopDaemons are the daemons with an ok-to-stop command
+var okToStopDaemons = []string{"mon", "osd", "mds"}
+
+// HealthResponse is the response of the health query
+type HealthResponse struct {
+	FSID   string                  `json:"fsid"`
+	Health cephclient.HealthStatus `json:"health"`
+}
+
+// MonsResponse is the response of the mons query
+type MonsResponse struct {
+	Quorum []string                 `json:"quorum"`
+	Mons   []cephclient.MonMapEntry `json:"mons"`
+}
+
+// OkToStopResponse is the response of the ok-to-stop query of a daemon
+type OkToStopResponse struct {
+	OkToStop bool   `json:"okToStop"`
+	Message  string `json:"message,omitempty"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves the queries of the clusters to the callers allowed to get the CephCluster of their namespace
+type Handler struct {
+	context    *clusterd.Context
+	authorizer *authorizer
+}
+
+// NewHandler returns the handler of the queries of the clusters
+func NewHandler(context *clusterd.Context) *Handler {
+	return &Handler{context: context, authorizer: &authorizer{clientset: context.Clientset}}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, errors.Errorf("method %q not allowed", r.Method))
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, pathPrefix) {
+		writeError(w, http.StatusNotFound, errors.Errorf("unknown path %q", r.URL.Path))
+		return
+	}
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, pathPrefix), "/"), "/")
+	if len(parts) < 2 {
+		writeError(w, http.StatusNotFound, errors.Errorf("unknown path %q", r.URL.Path))
+		return
+	}
+	namespace, query := parts[0], parts[1:]
+
+	status, err := h.authorizer.authorize(r, namespace)
+	if err != nil {
+		writeError(w, status, err)
+		return
+	}
+	if status, err := h.checkCluster(namespace); err != nil {
+		writeError(w, status, err)
+		return
+	}
+
+	var response interface{}
+	switch {
+	case len(query) == 1 && query[0] == "health":
+		response, err = h.health(namespace)
+	case len(query) == 1 && query[0] == "mons":
+		response, err = h.mons(namespace)
+	case len(query) == 2 && query[0] == "osds" && query[1] == "tree":
+		response, err = cephclient.HostTree(h.context, namespace)
+	case len(query) == 3 && query[2] == "ok-to-stop":
+		response, status, err = h.okToStop(namespace, query[0], query[1])
+		if status != http.StatusOK {
+			writeError(w, status, err)
+			return
+		}
+	default:
+		writeError(w, http.StatusNotFound, errors.Errorf("unknown query %q", strings.Join(query, "/")))
+		return
+	}
+	if err != nil {
+		logger.Errorf("failed to query %q of cluster %q. %v", strings.Join(query, "/"), namespace, err)
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeResponse(w, http.StatusOK, response)
+}
+
+// checkCluster ensures the namespace has a CephCluster, the operator having the ceph config of the clusters only
+func (h *Handler) checkCluster(namespace string) (int, error) {
+	clusters, err := h.context.RookClientset.CephV1().CephClusters(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrapf(err, "failed to list the ceph clusters of namespace %q", namespace)
+	}
+	if len(clusters.Items) == 0 {
+		return http.StatusNotFound, errors.Errorf("no ceph cluster in namespace %q", namespace)
+	}
+	return http.StatusOK, nil
+}
+
+func (h *Handler) health(namespace string) (*HealthResponse, error) {
+	status, err := cephclient.Status(h.context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthResponse{FSID: status.FSID, Health: status.Health}, nil
+}
+
+func (h *Handler) mons(namespace string) (*MonsResponse, error) {
+	quorumStatus, err := cephclient.GetMonQuorumStatus(h.context, namespace)
+	if err != nil {
+		return nil, err
+	}
+	response := &MonsResponse{Quorum: []string{}, Mons: quorumStatus.MonMap.Mons}
+	for _, mon := range quorumStatus.MonMap.Mons {
+		for _, rank := range quorumStatus.Quorum {
+			if mon.Rank == rank {
+				response.Quorum = append(response.Quorum, mon.Name)
+			}
+		}
+	}
+	return response, nil
+}
+
+// okToStop returns whether the daemon can be stopped without making data unavailable. Ceph fails the command when the
+// daemon is not ok to stop, which is reported in the response rather than as an error.
+func (h *Handler) okToStop(namespace, daemonType, daemonName string) (*OkToStopResponse, int, error) {
+	daemonType = strings.TrimSuffix(daemonType, "s")
+	known := false
+	for _, daemon := range okToStopDaemons {
+		known = known || daemon == daemonType
+	}
+	if !known {
+		return nil, http.StatusNotFound, errors.Errorf("daemon type %q has no ok-to-stop query, only %s", daemonType, strings.Join(okToStopDaemons, ", "))
+	}
+	if daemonType == "osd" {
+		if _, err := strconv.Atoi(daemonName); err != nil {
+			return nil, http.StatusBadRequest, errors.Errorf("invalid osd id %q", daemonName)
+		}
+	}
+	_, err := cephclient.NewCephCommand(h.context, namespace, []string{daemonType, "ok-to-stop", daemonName}).Run()
+	if err != nil {
+		return &OkToStopResponse{OkToStop: false, Message: err.Error()}, http.StatusOK, nil
+	}
+	return &OkToStopResponse{OkToStop: true}, http.StatusOK, nil
+}
+
+func writeResponse(w http.ResponseWriter, status int, response interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		logger.Debugf("failed to write the api response. %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeResponse(w, status, &errorResponse{Error: err.Error()})
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookfake "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const healthStatus = `{"fsid":"811e7dc0-ea13-4951-b000-24a8565d0735","health":{"status":"HEALTH_WARN","checks":{"OSDMAP_FLAGS":{"severity":"HEALTH_WARN","summary":{"message":"noout flag(s) set"}}}}}`
+
+const quorumStatus = `{"quorum":[0,2],"monmap":{"mons":[{"name":"a","rank":0},{"name":"b","rank":1},{"name":"c","rank":2}]}}`
+
+func newTestHandler(t *testing.T) *Handler {
+	clientset := fake.NewSimpleClientset()
+	// the token "admin-token" is valid, and its user can get the clusters of the namespace rook-ceph only
+	clientset.PrependReactor("create", "tokenreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authenticationv1.TokenReview)
+		if review.Spec.Token == "admin-token" {
+			review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "admin"}}
+		}
+		return true, review, nil
+	})
+	clientset.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		review.Status.Allowed = review.Spec.User == "admin" && review.Spec.ResourceAttributes.Namespace != "other"
+		return true, review, nil
+	})
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				return healthStatus, nil
+			case args[0] == "quorum_status":
+				return quorumStatus, nil
+			case args[0] == "osd" && args[1] == "tree":
+				return `{"nodes":[{"id":-1,"name":"default","type":"root"},{"id":0,"name":"osd.0","type":"osd","status":"up"}],"stray":[]}`, nil
+			case args[1] == "ok-to-stop" && args[2] == "1":
+				return "", errors.New("Error EBUSY: unsafe to stop osd(s) at this time (1 PGs are or would become offline)")
+			case args[1] == "ok-to-stop":
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "my-cluster", Namespace: "rook-ceph"}}
+	context := &clusterd.Context{Clientset: clientset, RookClientset: rookfake.NewSimpleClientset(cluster), Executor: executor}
+	return NewHandler(context)
+}
+
+func query(h *Handler, method, path, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestQueries(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := query(h, http.MethodGet, "/v1/clusters/rook-ceph/health", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var health HealthResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &health))
+	assert.Equal(t, "HEALTH_WARN", health.Health.Status)
+	assert.Equal(t, "noout flag(s) set", health.Health.Checks["OSDMAP_FLAGS"].Summary.Message)
+
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/mons", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var mons MonsResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &mons))
+	assert.Equal(t, []string{"a", "c"}, mons.Quorum)
+	assert.Equal(t, 3, len(mons.Mons))
+
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/osds/tree", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"name":"osd.0"`)
+
+	// the daemons not ok to stop are reported in the response
+	var okToStop OkToStopResponse
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/osds/0/ok-to-stop", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &okToStop))
+	assert.True(t, okToStop.OkToStop)
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/osds/1/ok-to-stop", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+	okToStop = OkToStopResponse{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &okToStop))
+	assert.False(t, okToStop.OkToStop)
+	assert.Contains(t, okToStop.Message, "unsafe to stop")
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/mons/a/ok-to-stop", "admin-token")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/osds/a/ok-to-stop", "admin-token")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/rgws/a/ok-to-stop", "admin-token")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/pools", "admin-token")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = query(h, http.MethodPost, "/v1/clusters/rook-ceph/health", "admin-token")
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
+
+func TestAuthorization(t *testing.T) {
+	h := newTestHandler(t)
+
+	w := query(h, http.MethodGet, "/v1/clusters/rook-ceph/health", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	w = query(h, http.MethodGet, "/v1/clusters/rook-ceph/health", "expired-token")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+	// the user cannot get the clusters of the namespace
+	w = query(h, http.MethodGet, "/v1/clusters/other/health", "admin-token")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// no cluster in the namespace
+	w = query(h, http.MethodGet, "/v1/clusters/default/health", "admin-token")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSelfSignedCertificate(t *testing.T) {
+	cert, err := loadCertificate("/does/not/exist")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(cert.Certificate))
+
+	now := time.Date(2020, 10, 15, 0, 0, 0, 0, time.UTC)
+	cert, err = selfSignedCertificate(now)
+	assert.NoError(t, err)
+	assert.NotNil(t, cert.PrivateKey)
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// authorizer authenticates the bearer token of the callers with the Kubernetes API, and allows the callers who can get
+// the CephClusters of the namespace of their query
+type authorizer struct {
+	clientset kubernetes.Interface
+}
+
+func (a *authorizer) authorize(r *http.Request, namespace string) (int, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return http.StatusUnauthorized, errors.New("a bearer token is required")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+
+	review, err := a.clientset.AuthenticationV1().TokenReviews().Create(&authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	})
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, "failed to review the token")
+	}
+	if !review.Status.Authenticated {
+		return http.StatusUnauthorized, errors.New("invalid token")
+	}
+
+	user := review.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, value := range user.Extra {
+		extra[key] = authorizationv1.ExtraValue(value)
+	}
+	access, err := a.clientset.AuthorizationV1().SubjectAccessReviews().Create(&authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "get",
+				Group:     cephv1.CustomResourceGroup,
+				Resource:  "cephclusters",
+			},
+		},
+	})
+	if err != nil {
+		return http.StatusInternalServerError, errors.Wrap(err, "failed to review the access")
+	}
+	if !access.Status.Allowed {
+		return http.StatusForbidden, errors.Errorf("user %q cannot get the ceph clusters of namespace %q", user.Username, namespace)
+	}
+	return http.StatusOK, nil
+}
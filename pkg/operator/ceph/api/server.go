@@ -0,0 +1,110 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package api
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+)
+
+const (
+	// CertDir is where the certificate of the server is mounted from a secret, in the tls.crt and tls.key files
+	CertDir = "/etc/rook-api"
+	// the self-signed certificate generated without a mounted certificate is valid for a year
+	selfSignedValidity = 365 * 24 * time.Hour
+)
+
+// Run serves the api until the stop channel is closed
+func Run(context *clusterd.Context, stopCh chan struct{}) {
+	cert, err := loadCertificate(CertDir)
+	if err != nil {
+		logger.Errorf("failed to load the certificate of the api, the api is not served. %v", err)
+		return
+	}
+	server := &http.Server{
+		Addr:      fmt.Sprintf(":%d", Port),
+		Handler:   NewHandler(context),
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12},
+	}
+	go func() {
+		<-stopCh
+		ctx, cancel := contextWithTimeout()
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Errorf("failed to stop the api server. %v", err)
+		}
+	}()
+
+	logger.Infof("serving the api on port %d", Port)
+	if err := server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+		logger.Errorf("failed to serve the api. %v", err)
+	}
+}
+
+func contextWithTimeout() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 5*time.Second)
+}
+
+// loadCertificate returns the certificate mounted in the directory, or a self-signed certificate if none is mounted
+func loadCertificate(dir string) (tls.Certificate, error) {
+	certFile, keyFile := path.Join(dir, "tls.crt"), path.Join(dir, "tls.key")
+	if _, err := os.Stat(certFile); err == nil {
+		logger.Infof("serving the api with the certificate of %q", dir)
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+	logger.Infof("no certificate in %q, serving the api with a self-signed certificate", dir)
+	return selfSignedCertificate(time.Now())
+}
+
+func selfSignedCertificate(now time.Time) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed to generate the key")
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed to generate the serial number")
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{Organization: []string{"rook"}, CommonName: "rook-ceph-operator"},
+		DNSNames:     []string{"localhost", "rook-ceph-operator"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(selfSignedValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "failed to create the certificate")
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
@@ -36,7 +36,18 @@ import (
 	"k8s.io/client-go/tools/cache"
 )
 
-const ClientSecretName = "-client-key"
+const (
+	ClientSecretName = "-client-key"
+	// ClientKeyringName is the key of the keyring of the client in its secret
+	ClientKeyringName = "keyring"
+	// KeyRotationAnnotation requests the rotation of the key of a client with the OnDemand policy whenever its value changes
+	KeyRotationAnnotation = "ceph.rook.io/rotate-key"
+	// keyRotatedAnnotation records in the secret of the client the rotation applied to its key
+	keyRotatedAnnotation = "ceph.rook.io/key-rotated"
+)
+
+// clientCapTypes are the daemons the caps of a client can be given for
+var clientCapTypes = map[string]bool{"mon": true, "osd": true, "mds": true, "mgr": true}
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-client")
 
@@ -132,38 +143,100 @@ func createClient(context *clusterd.Context, p *cephv1.CephClient) error {
 		}
 	}
 
+	if err := saveClientSecret(context, p, key); err != nil {
+		return err
+	}
+
+	logger.Infof("created client %s", p.Name)
+	return nil
+}
+
+// clientSecretName returns the name of the secret of the keyring of the client
+func clientSecretName(p *cephv1.CephClient) string {
+	return fmt.Sprintf("%s%s", p.Name, ClientSecretName)
+}
+
+// clientSecretNamespace returns the namespace of the secret of the keyring of the client
+func clientSecretNamespace(p *cephv1.CephClient) string {
+	if p.Spec.SecretNamespace != "" {
+		return p.Spec.SecretNamespace
+	}
+	return p.Namespace
+}
+
+// keyRotationRequest returns the rotation of the key requested by the annotation of the client, empty if none
+func keyRotationRequest(p *cephv1.CephClient) string {
+	if p.Spec.KeyRotationPolicy != cephv1.ClientKeyRotationOnDemand {
+		return ""
+	}
+	return p.Annotations[KeyRotationAnnotation]
+}
+
+// saveClientSecret saves the key of the client in its secret, first rotating the key if a new rotation is requested
+func saveClientSecret(context *clusterd.Context, p *cephv1.CephClient, key string) error {
+	secretName := clientSecretName(p)
+	secretNamespace := clientSecretNamespace(p)
+	rotation := keyRotationRequest(p)
+
+	existing, err := context.Clientset.CoreV1().Secrets(secretNamespace).Get(secretName, metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get secret for %q", secretName)
+	}
+	secretExists := err == nil
+
+	// The rotations are relative to the saved key, a new client taking its first key as rotated
+	if secretExists && rotation != "" && rotation != existing.Annotations[keyRotatedAnnotation] {
+		key, err = rotateClientKey(context, p)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rotate the key of client %q", p.Name)
+		}
+	}
+
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("%s%s", p.Name, ClientSecretName),
-			Namespace: p.Namespace,
+			Name:      secretName,
+			Namespace: secretNamespace,
 		},
 		StringData: map[string]string{
-			p.Name: key,
+			p.Name:            key,
+			ClientKeyringName: clientKeyring(p, key),
 		},
 		Type: k8sutil.RookType,
 	}
+	if rotation != "" {
+		secret.Annotations = map[string]string{keyRotatedAnnotation: rotation}
+	}
 
-	secretName := secret.ObjectMeta.Name
-	_, err = context.Clientset.CoreV1().Secrets(p.Namespace).Get(secretName, metav1.GetOptions{})
-	if err != nil {
-		if kerrors.IsNotFound(err) {
-			logger.Debugf("creating secret for %s", secretName)
-			if _, err := context.Clientset.CoreV1().Secrets(p.Namespace).Create(secret); err != nil {
-				return errors.Wrapf(err, "failed to create secret for %q", secretName)
-			}
-			return nil
+	if !secretExists {
+		logger.Debugf("creating secret for %s", secretName)
+		if _, err := context.Clientset.CoreV1().Secrets(secretNamespace).Create(secret); err != nil {
+			return errors.Wrapf(err, "failed to create secret for %q", secretName)
 		}
-		return errors.Wrapf(err, "failed to get secret for %q", secretName)
+		return nil
 	}
 	logger.Debugf("updating secret for %s", secretName)
-	if _, err := context.Clientset.CoreV1().Secrets(p.Namespace).Update(secret); err != nil {
+	if _, err := context.Clientset.CoreV1().Secrets(secretNamespace).Update(secret); err != nil {
 		return errors.Wrapf(err, "failed to update secret for %q", secretName)
 	}
-
-	logger.Infof("created client %s", p.Name)
 	return nil
 }
 
+// clientKeyring returns the keyring of the client with the key
+func clientKeyring(p *cephv1.CephClient, key string) string {
+	return fmt.Sprintf("[client.%s]\n\tkey = %s\n", p.Name, key)
+}
+
+// rotateClientKey replaces the key of the client with a new one, keeping its caps
+func rotateClientKey(context *clusterd.Context, p *cephv1.CephClient) (string, error) {
+	clientEntity, caps, err := genClientEntity(p, context)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate client entity %q", p.Name)
+	}
+
+	// The keyring imported replaces the key and the caps of the client
+	return ceph.AuthRotateKey(context, p.Namespace, clientEntity, caps)
+}
+
 func updateClient(context *clusterd.Context, p *cephv1.CephClient) error {
 	logger.Infof("updating client %s in namespace %s", p.Name, p.Namespace)
 
@@ -177,6 +250,14 @@ func updateClient(context *clusterd.Context, p *cephv1.CephClient) error {
 		return errors.Wrapf(err, "failed to update client %q", p.Name)
 	}
 
+	key, err := ceph.AuthGetKey(context, p.Namespace, clientEntity)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the key of client %q", p.Name)
+	}
+	if err := saveClientSecret(context, p, key); err != nil {
+		return err
+	}
+
 	logger.Infof("updated client %s", p.Name)
 	return nil
 }
@@ -197,7 +278,7 @@ func (c *ClientController) onUpdate(oldObj, newObj interface{}) {
 		logger.Errorf("failed to update client %q. name update not allowed", client.Name)
 		return
 	}
-	if !clientChanged(oldClient.Spec, client.Spec) {
+	if !clientChanged(oldClient, client) {
 		logger.Debugf("client %q not changed", client.Name)
 		return
 	}
@@ -205,6 +286,14 @@ func (c *ClientController) onUpdate(oldObj, newObj interface{}) {
 	logger.Infof("updating client %s", client.Name)
 	if err := updateClient(c.context, client); err != nil {
 		logger.Errorf("failed to update client %q. %v", client.ObjectMeta.Name, err)
+		return
+	}
+
+	// The secret moved to another namespace is removed from the previous one
+	if clientSecretNamespace(oldClient) != clientSecretNamespace(client) {
+		if err := deleteClientSecret(c.context, oldClient); err != nil {
+			logger.Errorf("failed to delete the previous secret of client %q. %v", client.Name, err)
+		}
 	}
 }
 
@@ -212,13 +301,8 @@ func (c *ClientController) ParentClusterChanged(cluster cephv1.ClusterSpec, clus
 	logger.Debugf("No need to update the client after the parent cluster changed")
 }
 
-func clientChanged(old, new cephv1.ClientSpec) bool {
-	for name, cap := range new.Caps {
-		if cap != old.Caps[name] {
-			return true
-		}
-	}
-	return false
+func clientChanged(old, new *cephv1.CephClient) bool {
+	return !reflect.DeepEqual(old.Spec, new.Spec) || keyRotationRequest(old) != keyRotationRequest(new)
 }
 
 func (c *ClientController) onDelete(obj interface{}) {
@@ -241,8 +325,13 @@ func deleteClient(context *clusterd.Context, p *cephv1.CephClient) error {
 	if err := ceph.AuthDelete(context, p.Namespace, clientEntity); err != nil {
 		return errors.Wrapf(err, "failed to delete client %q", p.Name)
 	}
-	secretName := fmt.Sprintf("%s-client-key", p.Name)
-	if err := context.Clientset.CoreV1().Secrets(p.Namespace).Delete(secretName, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+	return deleteClientSecret(context, p)
+}
+
+// deleteClientSecret deletes the secret of the keyring of the client
+func deleteClientSecret(context *clusterd.Context, p *cephv1.CephClient) error {
+	secretName := clientSecretName(p)
+	if err := context.Clientset.CoreV1().Secrets(clientSecretNamespace(p)).Delete(secretName, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
 		return errors.Errorf("failed to remote client %q secret %q", p.Name, secretName)
 	}
 
@@ -281,11 +370,19 @@ func ValidateClientSpec(context *clusterd.Context, namespace string, p *cephv1.C
 	if p.Caps == nil {
 		return errors.New("no caps specified")
 	}
-	for _, cap := range p.Caps {
+	for name, cap := range p.Caps {
+		if !clientCapTypes[name] {
+			return errors.Errorf("invalid caps %q, must be mon, osd, mds or mgr", name)
+		}
 		if cap == "" {
 			return errors.New("no caps specified")
 		}
 	}
+	switch p.KeyRotationPolicy {
+	case "", cephv1.ClientKeyRotationNever, cephv1.ClientKeyRotationOnDemand:
+	default:
+		return errors.Errorf("invalid keyRotationPolicy %q, must be %q or %q", p.KeyRotationPolicy, cephv1.ClientKeyRotationNever, cephv1.ClientKeyRotationOnDemand)
+	}
 
 	return nil
 }
@@ -18,6 +18,7 @@ package client
 
 import (
 	"bytes"
+	"io/ioutil"
 	"strings"
 	"testing"
 
@@ -57,6 +58,20 @@ func TestValidateClient(t *testing.T) {
 	}
 	err = ValidateClient(context, &p)
 	assert.Nil(t, err)
+
+	// the caps must be for the ceph daemons
+	p.Spec.Caps["rgw"] = "allow *"
+	err = ValidateClient(context, &p)
+	assert.NotNil(t, err)
+	delete(p.Spec.Caps, "rgw")
+
+	// the key rotation policy must be known
+	p.Spec.KeyRotationPolicy = "Always"
+	err = ValidateClient(context, &p)
+	assert.NotNil(t, err)
+	p.Spec.KeyRotationPolicy = cephv1.ClientKeyRotationOnDemand
+	err = ValidateClient(context, &p)
+	assert.Nil(t, err)
 }
 
 func TestGenerateClient(t *testing.T) {
@@ -148,6 +163,9 @@ func TestUpdateClient(t *testing.T) {
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
 			logger.Infof("Command: %s %v", command, args)
+			if command == "ceph" && args[1] == "get-key" {
+				return `{"key":"AQC7ilJdAPijOBAABp+YAzg2QupRAWdnIh7w/Q=="}`, nil
+			}
 			return "", nil
 		},
 	}
@@ -209,3 +227,82 @@ func TestDeleteClient(t *testing.T) {
 	err = deleteClient(context, p)
 	assert.Nil(t, err)
 }
+
+func TestClientKeyRotation(t *testing.T) {
+	clientset := testop.New(t, 1)
+	currentKey := "AQC7ilJdAPijOBAABp+YAzg2QupRAWdnIh7w/Q=="
+	imported := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			if command == "ceph" && (args[1] == "get-key" || args[1] == "get-or-create-key") {
+				return `{"key":"` + currentKey + `"}`, nil
+			}
+			if command == "ceph" && args[1] == "caps" {
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command '%v'", args)
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command == "ceph-authtool" && args[0] == "--gen-print-key" {
+				return "AQBnewkeyAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==\n", nil
+			}
+			if command == "ceph" && args[0] == "auth" && args[1] == "import" {
+				content, err := ioutil.ReadFile(args[3])
+				assert.NoError(t, err)
+				imported = string(content)
+				currentKey = "AQBnewkeyAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=="
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected command %s '%v'", command, args)
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+
+	p := &cephv1.CephClient{
+		ObjectMeta: metav1.ObjectMeta{Name: "client1", Namespace: "myns", Annotations: map[string]string{KeyRotationAnnotation: "1"}},
+		Spec: cephv1.ClientSpec{
+			Caps:              map[string]string{"mon": "profile rbd"},
+			SecretNamespace:   "app",
+			KeyRotationPolicy: cephv1.ClientKeyRotationOnDemand,
+		},
+	}
+
+	// the secret is created in the secret namespace, the first key being taken as rotated
+	err := createClient(context, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "", imported)
+	secret, err := clientset.CoreV1().Secrets("app").Get("client1-client-key", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AQC7ilJdAPijOBAABp+YAzg2QupRAWdnIh7w/Q==", secret.StringData["client1"])
+	assert.Equal(t, "[client.client1]\n\tkey = AQC7ilJdAPijOBAABp+YAzg2QupRAWdnIh7w/Q==\n", secret.StringData[ClientKeyringName])
+	assert.Equal(t, "1", secret.Annotations[keyRotatedAnnotation])
+
+	// the key is rotated when the annotation changes
+	old := p.DeepCopy()
+	p.Annotations[KeyRotationAnnotation] = "2"
+	assert.True(t, clientChanged(old, p))
+	err = updateClient(context, p)
+	assert.NoError(t, err)
+	assert.Contains(t, imported, "[client.client1]\n\tkey = AQBnewkeyAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==\n")
+	assert.Contains(t, imported, "\tcaps mon = \"profile rbd\"\n")
+	secret, err = clientset.CoreV1().Secrets("app").Get("client1-client-key", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "AQBnewkeyAAAAAAAAAAAAAAAAAAAAAAAAAAAAA==", secret.StringData["client1"])
+	assert.Equal(t, "2", secret.Annotations[keyRotatedAnnotation])
+
+	// the key is not rotated again
+	imported = ""
+	err = updateClient(context, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "", imported)
+
+	// the annotation is ignored without the OnDemand policy
+	old = p.DeepCopy()
+	p.Spec.KeyRotationPolicy = cephv1.ClientKeyRotationNever
+	p.Annotations[KeyRotationAnnotation] = "3"
+	old.Spec.KeyRotationPolicy = cephv1.ClientKeyRotationNever
+	assert.False(t, clientChanged(old, p))
+	err = updateClient(context, p)
+	assert.NoError(t, err)
+	assert.Equal(t, "", imported)
+}
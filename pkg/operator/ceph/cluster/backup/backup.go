@@ -0,0 +1,243 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package backup maintains the cronjob backing up the maps of the mon store, the keyrings and the config of the
+// cluster to a PVC or a bucket.
+package backup
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	batchv1beta1 "k8s.io/api/batch/v1beta1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AppName is the name of the backup cronjob and of its app label
+	AppName = "rook-ceph-backup"
+	// DefaultSchedule backs up the cluster every day at midnight
+	DefaultSchedule = "@daily"
+	// DefaultRetain is the number of backups kept by default
+	DefaultRetain = 7
+
+	backupDir              = "/var/lib/rook-backup"
+	backupVolumeName       = "backup"
+	rookBinariesVolumeName = "rook-binaries"
+	rookBinariesMountPath  = "/rook"
+	// the jobs of the last backups are kept to report their outcome
+	jobsHistoryLimit = 3
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-backup")
+
+// Reconcile schedules the backups of the cluster when enabled, and removes the backup cronjob when disabled
+func Reconcile(context *clusterd.Context, namespace string, spec *cephv1.ClusterSpec, rookImage string, ownerRef metav1.OwnerReference) error {
+	if !spec.Backup.Enabled {
+		return remove(context, namespace)
+	}
+
+	cronJob := makeCronJob(namespace, spec, rookImage, ownerRef)
+	_, err := context.Clientset.BatchV1beta1().CronJobs(namespace).Create(cronJob)
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create backup cronjob %q", AppName)
+		}
+		_, err = context.Clientset.BatchV1beta1().CronJobs(namespace).Update(cronJob)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update backup cronjob %q", AppName)
+		}
+		logger.Debugf("backup cronjob %q updated", AppName)
+		return nil
+	}
+	logger.Infof("backup cronjob %q created with schedule %q", AppName, cronJob.Spec.Schedule)
+	return nil
+}
+
+func remove(context *clusterd.Context, namespace string) error {
+	propagation := metav1.DeletePropagationBackground
+	err := context.Clientset.BatchV1beta1().CronJobs(namespace).Delete(AppName, &metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete backup cronjob %q", AppName)
+	}
+	logger.Infof("backups disabled, removed backup cronjob %q", AppName)
+	return nil
+}
+
+// makeCronJob returns the cronjob running the rook backup command in the ceph image of the cluster, its ceph config
+// being generated from the mon endpoints with the admin keyring
+func makeCronJob(namespace string, spec *cephv1.ClusterSpec, rookImage string, ownerRef metav1.OwnerReference) *batchv1beta1.CronJob {
+	cfgDir := cephconfig.DefaultConfigDir
+	cfgVolumeName := k8sutil.PathToVolumeName(cfgDir)
+	volumeMounts := []v1.VolumeMount{
+		{Name: cfgVolumeName, MountPath: cfgDir},
+		keyring.VolumeMount().Admin(),
+	}
+	volumes := []v1.Volume{
+		{Name: cfgVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		{Name: rookBinariesVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+		keyring.Volume().Admin(),
+	}
+
+	retain := spec.Backup.Retain
+	if retain == 0 {
+		retain = DefaultRetain
+	}
+	args := []string{"ceph", "backup", "--retain", strconv.Itoa(retain)}
+	env := []v1.EnvVar{}
+	backupMounts := append(volumeMounts, v1.VolumeMount{Name: rookBinariesVolumeName, MountPath: rookBinariesMountPath})
+	if spec.Backup.PersistentVolumeClaim != nil {
+		volumes = append(volumes, v1.Volume{Name: backupVolumeName, VolumeSource: v1.VolumeSource{PersistentVolumeClaim: spec.Backup.PersistentVolumeClaim}})
+		backupMounts = append(backupMounts, v1.VolumeMount{Name: backupVolumeName, MountPath: backupDir})
+		args = append(args, "--backup-dir", backupDir)
+	} else if bucket := spec.Backup.Bucket; bucket != nil {
+		args = append(args, "--bucket-endpoint", bucket.Endpoint, "--bucket-name", bucket.Name, "--bucket-prefix", bucket.Prefix)
+		env = append(env,
+			secretEnvVar("ROOK_BUCKET_ACCESS_KEY", bucket.SecretName, "AccessKey"),
+			secretEnvVar("ROOK_BUCKET_SECRET_KEY", bucket.SecretName, "SecretKey"))
+	}
+
+	labels := controller.AppLabels(AppName, namespace)
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{
+			{
+				Name:         "copy-bins",
+				Args:         []string{"copy-binaries", "--copy-to-dir", rookBinariesMountPath},
+				Image:        rookImage,
+				VolumeMounts: []v1.VolumeMount{{Name: rookBinariesVolumeName, MountPath: rookBinariesMountPath}},
+			},
+			controller.GenerateMinimalCephConfInitContainer(
+				"client.admin",
+				keyring.VolumeMount().AdminKeyringFilePath(),
+				spec.CephVersion.Image,
+				volumeMounts,
+				spec.Backup.Resources,
+				mon.PodSecurityContext(),
+			),
+		},
+		Containers: []v1.Container{
+			{
+				Name:            "backup",
+				Command:         []string{fmt.Sprintf("%s/rook", rookBinariesMountPath)},
+				Args:            args,
+				Image:           spec.CephVersion.Image,
+				VolumeMounts:    backupMounts,
+				Env:             env,
+				Resources:       spec.Backup.Resources,
+				SecurityContext: mon.PodSecurityContext(),
+			},
+		},
+		Volumes:       volumes,
+		RestartPolicy: v1.RestartPolicyOnFailure,
+	}
+	spec.Backup.Placement.ApplyToPodSpec(&podSpec)
+	k8sutil.ApplyImagePullSecrets(namespace, &podSpec)
+
+	schedule := spec.Backup.Schedule
+	if schedule == "" {
+		schedule = DefaultSchedule
+	}
+	historyLimit := int32(jobsHistoryLimit)
+	backoffLimit := int32(2)
+	cronJob := &batchv1beta1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AppName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1beta1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1beta1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &historyLimit,
+			FailedJobsHistoryLimit:     &historyLimit,
+			JobTemplate: batchv1beta1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: batch.JobSpec{
+					BackoffLimit: &backoffLimit,
+					Template: v1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Name: AppName, Labels: labels},
+						Spec:       podSpec,
+					},
+				},
+			},
+		},
+	}
+	k8sutil.SetOwnerRef(&cronJob.ObjectMeta, &ownerRef)
+	return cronJob
+}
+
+func secretEnvVar(name, secretName, key string) v1.EnvVar {
+	return v1.EnvVar{Name: name, ValueFrom: &v1.EnvVarSource{
+		SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: secretName}, Key: key},
+	}}
+}
+
+// GetStatus returns the outcome of the last backups from the jobs of the backup cronjob. The previous status is kept
+// when the jobs cannot be listed or were removed.
+func GetStatus(context *clusterd.Context, namespace string, previous *cephv1.BackupStatus) *cephv1.BackupStatus {
+	jobs, err := context.Clientset.BatchV1().Jobs(namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)})
+	if err != nil {
+		logger.Debugf("failed to list the backup jobs of cluster %q. %v", namespace, err)
+		return previous
+	}
+	status := &cephv1.BackupStatus{}
+	var lastSuccess, lastFailure time.Time
+	if previous != nil && previous.LastBackupTime != "" {
+		// the job of the last successful backup may have been removed after the failed jobs
+		status.LastBackupTime = previous.LastBackupTime
+		lastSuccess, _ = time.Parse(time.RFC3339, previous.LastBackupTime)
+	}
+	failureMessage := ""
+	for _, job := range jobs.Items {
+		if job.Status.Succeeded > 0 && job.Status.CompletionTime != nil && job.Status.CompletionTime.Time.After(lastSuccess) {
+			lastSuccess = job.Status.CompletionTime.Time
+		}
+		for _, condition := range job.Status.Conditions {
+			if condition.Type == batch.JobFailed && condition.Status == v1.ConditionTrue && condition.LastTransitionTime.Time.After(lastFailure) {
+				lastFailure = condition.LastTransitionTime.Time
+				failureMessage = fmt.Sprintf("backup job %q failed: %s", job.Name, condition.Message)
+			}
+		}
+	}
+	if !lastSuccess.IsZero() {
+		status.LastBackupTime = formatTime(lastSuccess)
+	}
+	if !lastFailure.IsZero() && lastFailure.After(lastSuccess) {
+		status.LastFailureTime = formatTime(lastFailure)
+		status.Message = failureMessage
+	}
+	return status
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	namespace := "rook-ceph"
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	ownerRef := metav1.OwnerReference{Name: "my-cluster", UID: "uid"}
+	spec := &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15.2.4"}}
+
+	// nothing to remove when disabled
+	assert.NoError(t, Reconcile(context, namespace, spec, "rook/ceph:master", ownerRef))
+	_, err := clientset.BatchV1beta1().CronJobs(namespace).Get(AppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the backups are written to the PVC with the defaults
+	spec.Backup = cephv1.BackupSpec{
+		Enabled:               true,
+		PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "ceph-backup"},
+	}
+	assert.NoError(t, Reconcile(context, namespace, spec, "rook/ceph:master", ownerRef))
+	cronJob, err := clientset.BatchV1beta1().CronJobs(namespace).Get(AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, ownerRef.UID, cronJob.OwnerReferences[0].UID)
+	assert.Equal(t, DefaultSchedule, cronJob.Spec.Schedule)
+	podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+	assert.Equal(t, 2, len(podSpec.InitContainers))
+	assert.Equal(t, "rook/ceph:master", podSpec.InitContainers[0].Image)
+	container := podSpec.Containers[0]
+	assert.Equal(t, "ceph/ceph:v15.2.4", container.Image)
+	assert.Equal(t, []string{"ceph", "backup", "--retain", "7", "--backup-dir", backupDir}, container.Args)
+	assert.Equal(t, 0, len(container.Env))
+	assert.Equal(t, "ceph-backup", podSpec.Volumes[len(podSpec.Volumes)-1].PersistentVolumeClaim.ClaimName)
+
+	// the backups are uploaded to the bucket with the keys of the secret
+	spec.Backup.PersistentVolumeClaim = nil
+	spec.Backup.Schedule = "0 2 * * *"
+	spec.Backup.Retain = 3
+	spec.Backup.Bucket = &cephv1.BackupBucketSpec{Endpoint: "https://s3.example.com", Name: "backups", Prefix: "rook/", SecretName: "backup-keys"}
+	assert.NoError(t, Reconcile(context, namespace, spec, "rook/ceph:master", ownerRef))
+	cronJob, err = clientset.BatchV1beta1().CronJobs(namespace).Get(AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "0 2 * * *", cronJob.Spec.Schedule)
+	podSpec = cronJob.Spec.JobTemplate.Spec.Template.Spec
+	container = podSpec.Containers[0]
+	assert.Equal(t, []string{"ceph", "backup", "--retain", "3", "--bucket-endpoint", "https://s3.example.com", "--bucket-name", "backups", "--bucket-prefix", "rook/"}, container.Args)
+	assert.Equal(t, 2, len(container.Env))
+	assert.Equal(t, "ROOK_BUCKET_ACCESS_KEY", container.Env[0].Name)
+	assert.Equal(t, "backup-keys", container.Env[0].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "SecretKey", container.Env[1].ValueFrom.SecretKeyRef.Key)
+	for _, volume := range podSpec.Volumes {
+		assert.Nil(t, volume.PersistentVolumeClaim)
+	}
+
+	// the cronjob is removed when disabled
+	spec.Backup.Enabled = false
+	assert.NoError(t, Reconcile(context, namespace, spec, "rook/ceph:master", ownerRef))
+	_, err = clientset.BatchV1beta1().CronJobs(namespace).Get(AppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestGetStatus(t *testing.T) {
+	namespace := "rook-ceph"
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	start := time.Date(2020, 10, 15, 0, 0, 0, 0, time.UTC)
+	addJob := func(name string, hours int, succeeded bool) {
+		job := &batch.Job{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{"app": AppName}}}
+		at := metav1.NewTime(start.Add(time.Duration(hours) * time.Hour))
+		if succeeded {
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &at
+		} else {
+			job.Status.Conditions = []batch.JobCondition{{Type: batch.JobFailed, Status: v1.ConditionTrue, LastTransitionTime: at, Message: "BackoffLimitExceeded"}}
+		}
+		_, err := clientset.BatchV1().Jobs(namespace).Create(job)
+		assert.NoError(t, err)
+	}
+
+	// no backup yet
+	status := GetStatus(context, namespace, nil)
+	assert.Equal(t, &cephv1.BackupStatus{}, status)
+
+	// a failure before the last success is not reported
+	addJob("backup-1", 1, false)
+	addJob("backup-2", 2, true)
+	status = GetStatus(context, namespace, status)
+	assert.Equal(t, "2020-10-15T02:00:00Z", status.LastBackupTime)
+	assert.Equal(t, "", status.LastFailureTime)
+
+	// a failure after the last success is reported
+	addJob("backup-3", 3, false)
+	status = GetStatus(context, namespace, status)
+	assert.Equal(t, "2020-10-15T02:00:00Z", status.LastBackupTime)
+	assert.Equal(t, "2020-10-15T03:00:00Z", status.LastFailureTime)
+	assert.Contains(t, status.Message, "backup-3")
+
+	// the last success is kept once its job is removed from the history
+	assert.NoError(t, clientset.BatchV1().Jobs(namespace).Delete("backup-2", &metav1.DeleteOptions{}))
+	status = GetStatus(context, namespace, status)
+	assert.Equal(t, "2020-10-15T02:00:00Z", status.LastBackupTime)
+	assert.Equal(t, "2020-10-15T03:00:00Z", status.LastFailureTime)
+}
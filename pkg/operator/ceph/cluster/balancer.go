@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	v1 "k8s.io/api/core/v1"
+)
+
+// balancerRevertedReason is the reason of the event emitted when the balancer settings changed outside of the spec
+// are applied again
+const balancerRevertedReason = "BalancerReverted"
+
+// syncBalancer applies the balancer settings of the spec again if they were changed from the toolbox, and returns the
+// state of the balancer for the CR status. The previous state is kept if the balancer cannot be queried.
+func (c *cephStatusChecker) syncBalancer(cephCluster *cephv1.CephCluster, lastChecked string) *cephv1.BalancerStatus {
+	spec := cephCluster.Spec.Mgr.Balancer
+	if spec == nil || cephCluster.Spec.External.Enable {
+		return nil
+	}
+	namespace := c.namespacedName.Namespace
+	balancer, err := cephclient.GetBalancerStatus(c.context, namespace)
+	if err != nil {
+		logger.Debugf("failed to get the balancer status of cluster %q. %v", namespace, err)
+		return cephCluster.Status.Balancer
+	}
+
+	mode := mgr.BalancerMode(spec)
+	if balancerDrifted(mode, balancer) {
+		logger.Infof("balancer of cluster %q changed to mode %q (active %t), applying mode %q of the spec", namespace, balancer.Mode, balancer.Active, mode)
+		if err := cephclient.ApplyBalancerMode(c.context, namespace, mode); err != nil {
+			logger.Errorf("failed to apply balancer mode %q. %v", mode, err)
+		} else {
+			c.reportBalancerReverted(cephCluster, "balancer mode %q (active %t) reverted to mode %q of the spec", balancer.Mode, balancer.Active, mode)
+			balancer.Active = mode != cephv1.BalancerModeOff
+			if balancer.Active {
+				balancer.Mode = mode
+			}
+		}
+	}
+	status := &cephv1.BalancerStatus{Mode: balancer.Mode, Active: balancer.Active, LastChecked: lastChecked}
+
+	if ratio, err := cephclient.GetBalancerMaxMisplaced(c.context, namespace); err != nil {
+		logger.Debugf("failed to get the max misplaced ratio of the balancer of cluster %q. %v", namespace, err)
+	} else {
+		status.MaxMisplaced = ratio
+	}
+	if spec.MaxMisplaced > 0 && status.MaxMisplaced != spec.MaxMisplaced {
+		if err := cephclient.SetBalancerMaxMisplaced(c.context, namespace, spec.MaxMisplaced); err != nil {
+			logger.Errorf("failed to apply the max misplaced ratio %v of the balancer. %v", spec.MaxMisplaced, err)
+		} else {
+			c.reportBalancerReverted(cephCluster, "balancer max misplaced ratio %v reverted to %v of the spec", status.MaxMisplaced, spec.MaxMisplaced)
+			status.MaxMisplaced = spec.MaxMisplaced
+		}
+	}
+
+	if score, err := cephclient.GetBalancerScore(c.context, namespace); err != nil {
+		logger.Debugf("failed to get the balancer score of cluster %q. %v", namespace, err)
+		if cephCluster.Status.Balancer != nil {
+			status.Score = cephCluster.Status.Balancer.Score
+		}
+	} else {
+		status.Score = score
+	}
+	return status
+}
+
+// balancerDrifted returns whether the balancer does not run with the mode of the spec, or runs while turned off
+func balancerDrifted(mode string, balancer *cephclient.BalancerStatus) bool {
+	if mode == cephv1.BalancerModeOff {
+		return balancer.Active
+	}
+	return !balancer.Active || balancer.Mode != mode
+}
+
+func (c *cephStatusChecker) reportBalancerReverted(cephCluster *cephv1.CephCluster, messageFmt string, args ...interface{}) {
+	if c.recorder != nil {
+		c.recorder.Eventf(cephCluster, v1.EventTypeNormal, balancerRevertedReason, messageFmt, args...)
+	}
+}
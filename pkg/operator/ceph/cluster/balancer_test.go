@@ -0,0 +1,97 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestSyncBalancer(t *testing.T) {
+	balancerStatus := `{"active":false,"mode":"none"}`
+	maxMisplaced := "0.050000"
+	applied := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "balancer" && args[1] == "status":
+				return balancerStatus, nil
+			case args[0] == "balancer" && args[1] == "eval":
+				return "current cluster score 0.012000 (lower is better)", nil
+			case args[0] == "balancer" && (args[1] == "mode" || args[1] == "on" || args[1] == "off"):
+				applied = append(applied, args[0]+" "+args[1])
+				return "", nil
+			case args[0] == "config" && args[1] == "get":
+				return maxMisplaced, nil
+			case args[0] == "config" && args[1] == "set":
+				applied = append(applied, args[3]+"="+args[4])
+				return "", nil
+			case args[0] == "osd" && args[1] == "set-require-min-compat-client":
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	recorder := record.NewFakeRecorder(10)
+	c := newCephStatusChecker(&clusterd.Context{Executor: executor}, "rook-ceph", "client.admin", types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, cephv1.CephClusterHealthCheckSpec{}, recorder)
+	cephCluster := &cephv1.CephCluster{}
+
+	// no balancer in the spec
+	assert.Nil(t, c.syncBalancer(cephCluster, "2020-06-01T10:00:00Z"))
+	assert.Empty(t, applied)
+
+	// the balancer turned off from the toolbox is turned on again with the mode of the spec
+	cephCluster.Spec.Mgr.Balancer = &cephv1.BalancerSpec{Mode: cephv1.BalancerModeCrushCompat, MaxMisplaced: 0.02}
+	status := c.syncBalancer(cephCluster, "2020-06-01T10:00:00Z")
+	assert.Equal(t, []string{"balancer mode", "balancer on", "target_max_misplaced_ratio=0.02"}, applied)
+	assert.Equal(t, &cephv1.BalancerStatus{Mode: "crush-compat", Active: true, MaxMisplaced: 0.02, Score: 0.012, LastChecked: "2020-06-01T10:00:00Z"}, status)
+	assert.Equal(t, 2, len(recorder.Events))
+
+	// nothing is applied when in sync
+	applied = []string{}
+	balancerStatus = `{"active":true,"mode":"crush-compat"}`
+	maxMisplaced = "0.020000"
+	cephCluster.Status.Balancer = c.syncBalancer(cephCluster, "2020-06-01T10:01:00Z")
+	assert.Empty(t, applied)
+	assert.Equal(t, 0.02, cephCluster.Status.Balancer.MaxMisplaced)
+
+	// the balancer is turned off as requested
+	cephCluster.Spec.Mgr.Balancer.Mode = cephv1.BalancerModeOff
+	status = c.syncBalancer(cephCluster, "2020-06-01T10:02:00Z")
+	assert.Equal(t, []string{"balancer off"}, applied)
+	assert.False(t, status.Active)
+
+	// the previous state is kept when the balancer cannot be queried
+	balancerStatus = "not json"
+	assert.Equal(t, cephCluster.Status.Balancer, c.syncBalancer(cephCluster, "2020-06-01T10:03:00Z"))
+}
+
+func TestBalancerDrifted(t *testing.T) {
+	assert.False(t, balancerDrifted("upmap", &cephclient.BalancerStatus{Active: true, Mode: "upmap"}))
+	assert.True(t, balancerDrifted("upmap", &cephclient.BalancerStatus{Active: false, Mode: "upmap"}))
+	assert.True(t, balancerDrifted("upmap", &cephclient.BalancerStatus{Active: true, Mode: "crush-compat"}))
+	assert.False(t, balancerDrifted("off", &cephclient.BalancerStatus{Active: false, Mode: "upmap"}))
+	assert.True(t, balancerDrifted("off", &cephclient.BalancerStatus{Active: true, Mode: "upmap"}))
+}
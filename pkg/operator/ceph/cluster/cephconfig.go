@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+const (
+	// cephConfigConfigMapName is the configmap recording the options applied from the cephConfig of the cluster,
+	// so that the options removed from the cephConfig are removed from the mon config store
+	cephConfigConfigMapName = "rook-ceph-applied-config"
+	appliedCephConfigKey    = "config"
+)
+
+// applyCephConfig sets the options of the cephConfig, of the scrub schedule and of the default compression of the
+// cluster in the mon config store, and removes the options previously applied that are no longer in the spec. An
+// option failing to be applied is retried on the next orchestration.
+func (c *cluster) applyCephConfig() error {
+	if c.Info != nil && c.Spec.Storage.Compression != nil {
+		if err := cephclient.ValidateCompressionVersion(c.Spec.Storage.Compression.Algorithm, c.Info.CephVersion); err != nil {
+			return err
+		}
+	}
+	desired, err := desiredCephConfig(*c.Spec)
+	if err != nil {
+		return err
+	}
+	configMap, err := c.getStateConfigMap(cephConfigConfigMapName)
+	if err != nil {
+		return err
+	}
+	previous := map[string]map[string]string{}
+	if data, ok := configMap.Data[appliedCephConfigKey]; ok {
+		if err := json.Unmarshal([]byte(data), &previous); err != nil {
+			return errors.Wrapf(err, "failed to read the ceph config applied from configmap %q", cephConfigConfigMapName)
+		}
+	}
+
+	monStore := opconfig.GetMonStore(c.context, c.Namespace)
+	applied := map[string]map[string]string{}
+	record := func(who, option, value string) {
+		if _, ok := applied[who]; !ok {
+			applied[who] = map[string]string{}
+		}
+		applied[who][option] = value
+	}
+	failures := 0
+
+	for who, options := range desired {
+		for option, value := range options {
+			if err := monStore.Set(who, option, value); err != nil {
+				logger.Errorf("failed to set ceph config option %q of %q. %v", option, who, err)
+				failures++
+				// the previous value is still set and owned by the operator
+				if value, ok := previous[who][option]; ok {
+					record(who, option, value)
+				}
+				continue
+			}
+			record(who, option, value)
+		}
+	}
+
+	for who, options := range previous {
+		for option, value := range options {
+			if _, ok := desired[who][option]; ok {
+				continue
+			}
+			logger.Infof("removing ceph config option %q of %q no longer in the cluster spec", option, who)
+			if err := monStore.Delete(who, option); err != nil {
+				logger.Errorf("failed to remove ceph config option %q of %q. %v", option, who, err)
+				failures++
+				record(who, option, value)
+			}
+		}
+	}
+
+	data, err := json.Marshal(applied)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the ceph config applied")
+	}
+	configMap.Data[appliedCephConfigKey] = string(data)
+	if err := c.saveStateConfigMap(configMap); err != nil {
+		return err
+	}
+	if failures > 0 {
+		return errors.Errorf("failed to apply %d ceph config options", failures)
+	}
+	return nil
+}
+
+// desiredCephConfig returns the options of the cephConfig of the cluster with the options of its scrub schedule and
+// of the default compression of its OSDs
+func desiredCephConfig(spec cephv1.ClusterSpec) (map[string]map[string]string, error) {
+	scrubbing, err := cephv1.ScrubbingConfig(spec.Storage.Scrubbing)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid scrub schedule")
+	}
+	compression, err := cephv1.CompressionConfig(spec.Storage.Compression)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid compression")
+	}
+	desired := map[string]map[string]string{}
+	for who, options := range spec.CephConfig {
+		desired[who] = map[string]string{}
+		for option, value := range options {
+			desired[who][option] = value
+		}
+	}
+	for _, options := range []map[string]string{scrubbing, compression} {
+		if len(options) == 0 {
+			continue
+		}
+		if _, ok := desired[cephv1.OSDConfigSection]; !ok {
+			desired[cephv1.OSDConfigSection] = map[string]string{}
+		}
+		for option, value := range options {
+			desired[cephv1.OSDConfigSection][option] = value
+		}
+	}
+	return desired, nil
+}
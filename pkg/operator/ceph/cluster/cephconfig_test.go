@@ -0,0 +1,100 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestApplyCephConfig(t *testing.T) {
+	store := map[string]string{}
+	failSet := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			switch args[1] {
+			case "set":
+				if failSet {
+					return "", errors.New("unrecognized config option")
+				}
+				store[args[2]+"/"+args[3]] = args[4]
+			case "rm":
+				delete(store, args[2]+"/"+args[3])
+			}
+			return "", nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	c := &cluster{
+		Namespace: "ns",
+		context:   &clusterd.Context{Clientset: clientset, Executor: executor},
+		ownerRef:  metav1.OwnerReference{UID: "uid"},
+		Spec: &cephv1.ClusterSpec{CephConfig: map[string]map[string]string{
+			"global": {"osd_pool_default_size": "3", "mon_max_pg_per_osd": "300"},
+			"osd.0":  {"osd_max_backfills": "2"},
+		}},
+	}
+
+	// the options are set in the mon config store
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/osd_pool_default_size": "3", "global/mon_max_pg_per_osd": "300", "osd.0/osd_max_backfills": "2"}, store)
+	configMap, err := clientset.CoreV1().ConfigMaps("ns").Get(cephConfigConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(configMap.OwnerReferences))
+
+	// the options removed from the spec are removed from the store, the other options being kept
+	store["global/debug_ms"] = "1"
+	c.Spec.CephConfig = map[string]map[string]string{"global": {"osd_pool_default_size": "2"}}
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/osd_pool_default_size": "2", "global/debug_ms": "1"}, store)
+
+	// an option failing to be set is still owned and removed once out of the spec
+	failSet = true
+	assert.Error(t, c.applyCephConfig())
+	failSet = false
+	c.Spec.CephConfig = nil
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/debug_ms": "1"}, store)
+
+	// the scrub schedule is set in the osd section and removed with the schedule
+	beginHour, endHour := 22, 6
+	c.Spec.Storage.Scrubbing = &rookv1.ScrubbingSpec{BeginHour: &beginHour, EndHour: &endHour, MaxInterval: "168h"}
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/debug_ms": "1", "osd/osd_scrub_begin_hour": "22", "osd/osd_scrub_end_hour": "6", "osd/osd_scrub_max_interval": "604800"}, store)
+	c.Spec.Storage.Scrubbing = nil
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/debug_ms": "1"}, store)
+
+	// the default compression of the osds is set in the osd section, the zstd algorithm requiring nautilus
+	c.Spec.Storage.Compression = &rookv1.BluestoreCompressionSpec{Mode: "aggressive", Algorithm: "zstd"}
+	c.Info = &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}}
+	assert.Error(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/debug_ms": "1"}, store)
+	c.Info.CephVersion = cephver.Nautilus
+	assert.NoError(t, c.applyCephConfig())
+	assert.Equal(t, map[string]string{"global/debug_ms": "1", "osd/bluestore_compression_mode": "aggressive", "osd/bluestore_compression_algorithm": "zstd"}, store)
+}
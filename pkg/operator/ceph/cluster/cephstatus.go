@@ -19,25 +19,101 @@ package cluster
 
 import (
 	"context"
+	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/backup"
+	"github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/util/exec"
+	"github.com/rook/rook/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 const (
 	// defaultStatusCheckInterval is the interval to check the status of the ceph cluster
 	defaultStatusCheckInterval = 60 * time.Second
+
+	// mdsClientRecallCheck is the health check raised when clients fail to release their caps
+	mdsClientRecallCheck = "MDS_CLIENT_RECALL"
+	// mdsClientCapsRecallReason is the event reason emitted for each client failing to release its caps
+	mdsClientCapsRecallReason = "MDSClientCapsRecall"
+	// healthWarningEscalatedReason is the event reason emitted for each warning escalated to an error
+	healthWarningEscalatedReason = "HealthWarningEscalated"
+	// healthChangedReason is the event reason emitted when the overall health of the cluster changes
+	healthChangedReason = "CephHealthChanged"
+	// upgradeCompletedReason is the event reason emitted when all the daemons run the ceph version of the cluster
+	upgradeCompletedReason = "CephUpgradeCompleted"
+
+	healthOK   = "HEALTH_OK"
+	healthWarn = "HEALTH_WARN"
+	healthErr  = "HEALTH_ERR"
 )
 
+// mdsClientRecallRegex matches the health detail message of a client failing to release its caps, e.g.
+// "mds.a(mds.0): Client worker1:guest failing to respond to cache pressure client_id: 4236"
+var mdsClientRecallRegex = regexp.MustCompile(`[Cc]lient (\S+) failing to respond to cache pressure(?: client_id: (\d+))?`)
+
+// CephHealthSummary is the outcome of the last ceph status check
+type CephHealthSummary struct {
+	// Namespace is the namespace of the cluster
+	Namespace string
+	// Status is the overall health of the cluster
+	Status string
+	// Checks are the active health checks by code
+	Checks map[string]CephHealthCheck
+	// MutedChecks are the codes of the health checks muted by the admin
+	MutedChecks []string
+	NumOSDs     int
+	NumUpOSDs   int
+	NumDownOSDs int
+	LastChecked time.Time
+}
+
+// CephHealthCheck is a health check raised by ceph
+type CephHealthCheck struct {
+	Severity string
+	Message  string
+}
+
+// newCephHealthSummary builds the health summary from the ceph status
+func newCephHealthSummary(status *cephclient.CephStatus) CephHealthSummary {
+	osdMap := status.OsdMap.OsdMap
+	summary := CephHealthSummary{
+		Status:      status.Health.Status,
+		Checks:      make(map[string]CephHealthCheck, len(status.Health.Checks)),
+		MutedChecks: []string{},
+		NumOSDs:     osdMap.NumOsd,
+		NumUpOSDs:   osdMap.NumUpOsd,
+		NumDownOSDs: osdMap.NumOsd - osdMap.NumUpOsd,
+		LastChecked: time.Now().UTC(),
+	}
+	for code, check := range status.Health.Checks {
+		summary.Checks[code] = CephHealthCheck{Severity: check.Severity, Message: check.Summary.Message}
+	}
+	for _, mute := range status.Health.Mutes {
+		summary.MutedChecks = append(summary.MutedChecks, mute.Code)
+	}
+	sort.Strings(summary.MutedChecks)
+
+	return summary
+}
+
 // cephStatusChecker aggregates the mon/cluster info needed to check the health of the monitors
 type cephStatusChecker struct {
 	context        *clusterd.Context
@@ -46,10 +122,33 @@ type cephStatusChecker struct {
 	cephUser       string
 	client         client.Client
 	namespacedName types.NamespacedName
+	recorder       record.EventRecorder
+	// recallClients are the clients already reported as failing to release their caps
+	recallClients map[string]struct{}
+	// escalatedWarnings are the health check codes reported as errors instead of warnings
+	escalatedWarnings map[string]struct{}
+	// checkCallback is called every time a check completes
+	checkCallback func()
+	// summary is the outcome of the last successful check
+	summary      *CephHealthSummary
+	summaryMutex sync.RWMutex
+	// healthTransitionCallbacks are called when the cluster enters or leaves HEALTH_ERR
+	healthTransitionCallbacks []func(old, new CephHealthSummary)
+	// remediation are the health warnings handled automatically
+	remediation         cephv1.HealthRemediationSpec
+	remediationInterval time.Duration
+	// lastRemediations are the times each PG, daemon or pool was last remediated
+	lastRemediations map[string]time.Time
+	// ownerRef is the owner of the health state configmap keeping the remediations across the restarts of the operator
+	ownerRef *metav1.OwnerReference
+	// savedRemediations are the remediations last saved to the health state configmap
+	savedRemediations remediationState
+	// logger logs the lines of the status health check with the namespace and the name of the cluster
+	logger log.Logger
 }
 
 // newCephStatusChecker creates a new HealthChecker object
-func newCephStatusChecker(context *clusterd.Context, resourceName string, cephUser string, namespacedName types.NamespacedName, healthCheck cephv1.CephClusterHealthCheckSpec) *cephStatusChecker {
+func newCephStatusChecker(context *clusterd.Context, resourceName string, cephUser string, namespacedName types.NamespacedName, healthCheck cephv1.CephClusterHealthCheckSpec, recorder record.EventRecorder) *cephStatusChecker {
 	c := &cephStatusChecker{
 		context:        context,
 		resourceName:   resourceName,
@@ -57,10 +156,39 @@ func newCephStatusChecker(context *clusterd.Context, resourceName string, cephUs
 		cephUser:       cephUser,
 		client:         context.Client,
 		namespacedName: namespacedName,
+		recorder:       recorder,
+		logger:         statusCheckLogger(namespacedName),
+	}
+
+	c.loadConfig(healthCheck)
+
+	return c
+}
+
+// statusCheckLogger returns the logger of the status health check of a cluster
+func statusCheckLogger(namespacedName types.NamespacedName) log.Logger {
+	return log.New(logger, log.Fields{
+		log.NamespaceField: namespacedName.Namespace,
+		log.ClusterField:   namespacedName.Name,
+		log.CheckField:     "status",
+	})
+}
+
+// loadConfig sets the check interval, the escalated warnings and the remediations from the health check spec of the
+// cluster
+func (c *cephStatusChecker) loadConfig(healthCheck cephv1.CephClusterHealthCheckSpec) {
+	c.loadRemediationConfig(healthCheck.Remediation)
+	c.escalatedWarnings = nil
+	if len(healthCheck.EscalatedWarnings) > 0 {
+		c.escalatedWarnings = make(map[string]struct{}, len(healthCheck.EscalatedWarnings))
+		for _, code := range healthCheck.EscalatedWarnings {
+			c.escalatedWarnings[code] = struct{}{}
+		}
 	}
 
 	// allow overriding the check interval with an env var on the operator
 	// Keep the existing behavior
+	c.interval = defaultStatusCheckInterval
 	var checkInterval string
 	checkIntervalCRSetting := healthCheck.DaemonHealth.Status.Interval
 	checkIntervalEnv := os.Getenv("ROOK_CEPH_STATUS_CHECK_INTERVAL")
@@ -75,72 +203,297 @@ func newCephStatusChecker(context *clusterd.Context, resourceName string, cephUs
 	// Set duration
 	if checkInterval != "" {
 		if duration, err := time.ParseDuration(checkInterval); err == nil {
-			logger.Infof("ceph status check interval is %s", checkInterval)
+			c.logger.Infof("ceph status check interval is %s", checkInterval)
 			c.interval = duration
 		}
 	}
-
-	return c
 }
 
 // checkCephStatus periodically checks the health of the cluster
-func (c *cephStatusChecker) checkCephStatus(stopCh chan struct{}) {
+// The settings of the checker are reloaded every time the config channel receives a health check spec.
+func (c *cephStatusChecker) checkCephStatus(stopCh chan struct{}, triggerCh <-chan struct{}, configCh <-chan cephv1.CephClusterHealthCheckSpec) {
+	c.loadRemediationState()
+
 	// check the status immediately before starting the loop
-	c.checkStatus()
+	c.runCheck()
 
 	for {
 		select {
 		case <-stopCh:
-			logger.Infof("stopping monitoring of ceph status")
+			c.logger.Infof("stopping monitoring of ceph status")
 			return
 
 		case <-time.After(c.interval):
-			c.checkStatus()
+			c.runCheck()
+
+		case <-triggerCh:
+			c.logger.Debugf("ceph status check triggered")
+			c.runCheck()
+
+		case healthCheck := <-configCh:
+			c.logger.Infof("reloading the ceph status check settings")
+			c.context = opcontroller.HealthCheckContext(c.context, statusHealthCheck(healthCheck))
+			c.loadConfig(healthCheck)
 		}
 	}
 }
 
+func (c *cephStatusChecker) runCheck() {
+	start := time.Now()
+	c.checkStatus()
+	opcontroller.ObserveHealthCheckDuration(c.namespacedName.Namespace, "status", start)
+	if c.checkCallback != nil {
+		c.checkCallback()
+	}
+}
+
 // checkStatus queries the status of ceph health then updates the CR status
 func (c *cephStatusChecker) checkStatus() {
 	var status cephclient.CephStatus
 	var err error
 
-	logger.Debugf("checking health of cluster")
+	c.logger.Debugf("checking health of cluster")
 
 	// Check ceph's status
 	status, err = cephclient.StatusWithUser(c.context, c.namespacedName.Namespace, c.cephUser)
 	if err != nil {
-		logger.Errorf("failed to get ceph status. %v", err)
+		if exec.IsTimeout(err) {
+			c.logger.Errorf("timed out getting ceph status, ceph may be unreachable. %v", err)
+			return
+		}
+		if exec.IsCircuitOpen(err) {
+			c.logger.Debugf("skipping ceph status check. %v", err)
+			return
+		}
+		c.logger.Errorf("failed to get ceph status. %v", err)
 		return
 	}
 
-	logger.Debugf("cluster status: %+v", status)
-	if err := c.updateCephStatus(&status); err != nil {
-		logger.Errorf("failed to query cluster status in namespace %q. %v", c.namespacedName.Namespace, err)
+	c.logger.Debugf("cluster status: %+v", status)
+	escalated := c.escalateWarnings(&status)
+	summary := newCephHealthSummary(&status)
+	summary.Namespace = c.namespacedName.Namespace
+	opcontroller.SetCephHealthMetric(c.namespacedName.Namespace, summary.Status)
+	c.summaryMutex.Lock()
+	previous := c.summary
+	c.summary = &summary
+	c.summaryMutex.Unlock()
+	if previous != nil && isHealthTransition(previous.Status, summary.Status) {
+		c.reportHealthTransition(*previous, summary)
+	}
+
+	// the detail of the health checks is only queried when ceph reports any check
+	var health *cephclient.HealthStatus
+	if len(status.Health.Checks) > 0 {
+		detail, err := cephclient.HealthDetailWithUser(c.context, c.namespacedName.Namespace, c.cephUser)
+		if err != nil {
+			c.logger.Errorf("failed to get ceph health detail. %v", err)
+		} else {
+			health = &detail
+		}
+	}
+	recallClients := mdsClientsFailingToRecall(health)
+	// the versions of the daemons report the progress of an upgrade, they are left out of the status if unavailable
+	versions, err := cephclient.GetAllCephDaemonVersions(c.context, c.namespacedName.Namespace)
+	if err != nil {
+		c.logger.Debugf("failed to get the versions of the ceph daemons. %v", err)
+	}
+	// the capacity of the device classes is left unchanged in the status if unavailable
+	var deviceClasses []cephv1.DeviceClassStatus
+	if usage, err := cephclient.GetOSDUsage(c.context, c.namespacedName.Namespace); err != nil {
+		c.logger.Debugf("failed to get the usage of the osds. %v", err)
+	} else {
+		deviceClasses = toDeviceClassStatus(usage)
+	}
+	if err := c.updateCephStatus(&status, health, versions, deviceClasses, escalated, recallClients); err != nil {
+		c.logger.Errorf("failed to query cluster status in namespace %q. %v", c.namespacedName.Namespace, err)
 	}
 }
 
+// healthSummary returns the outcome of the last successful check, if any
+func (c *cephStatusChecker) healthSummary() (CephHealthSummary, bool) {
+	c.summaryMutex.RLock()
+	defer c.summaryMutex.RUnlock()
+	if c.summary == nil {
+		return CephHealthSummary{}, false
+	}
+
+	return *c.summary, true
+}
+
+// isHealthTransition returns whether the cluster entered or left HEALTH_ERR
+func isHealthTransition(oldStatus, newStatus string) bool {
+	return (oldStatus == healthErr) != (newStatus == healthErr)
+}
+
+// reportHealthTransition calls the health transition callbacks
+// The callbacks are called once per transition, not at every check while the health is unchanged
+func (c *cephStatusChecker) reportHealthTransition(old, new CephHealthSummary) {
+	c.logger.Infof("ceph health of cluster %q changed from %s to %s", c.namespacedName.Namespace, old.Status, new.Status)
+	for _, callback := range c.healthTransitionCallbacks {
+		callback(old, new)
+	}
+}
+
+// escalateWarnings promotes the configured warnings to errors, along with the overall health
+// It returns the sorted list of the escalated health check codes
+func (c *cephStatusChecker) escalateWarnings(status *cephclient.CephStatus) []string {
+	if len(c.escalatedWarnings) == 0 {
+		return nil
+	}
+
+	escalated := []string{}
+	for code, check := range status.Health.Checks {
+		if _, ok := c.escalatedWarnings[code]; !ok || check.Severity != healthWarn {
+			continue
+		}
+		check.Severity = healthErr
+		status.Health.Checks[code] = check
+		escalated = append(escalated, code)
+	}
+	if len(escalated) == 0 {
+		return nil
+	}
+	sort.Strings(escalated)
+
+	c.logger.Warningf("escalating ceph health warnings %v to %s in cluster %q", escalated, healthErr, c.namespacedName.Namespace)
+	status.Health.Status = healthErr
+
+	return escalated
+}
+
+// mdsClientsFailingToRecall returns the sorted list of clients failing to respond to the mds cache pressure from
+// the health detail
+func mdsClientsFailingToRecall(health *cephclient.HealthStatus) []string {
+	if health == nil {
+		return nil
+	}
+	if _, ok := health.Checks[mdsClientRecallCheck]; !ok {
+		return nil
+	}
+
+	clients := []string{}
+	for _, detail := range health.Checks[mdsClientRecallCheck].Detail {
+		match := mdsClientRecallRegex.FindStringSubmatch(detail.Message)
+		if match == nil {
+			logger.Debugf("skipping unexpected %s detail message %q", mdsClientRecallCheck, detail.Message)
+			continue
+		}
+		client := match[1]
+		if match[2] != "" {
+			client = fmt.Sprintf("%s (client_id %s)", match[1], match[2])
+		}
+		clients = append(clients, client)
+	}
+	sort.Strings(clients)
+
+	return clients
+}
+
 // updateStatus updates an object with a given status
-func (c *cephStatusChecker) updateCephStatus(status *cephclient.CephStatus) error {
+func (c *cephStatusChecker) updateCephStatus(status *cephclient.CephStatus, health *cephclient.HealthStatus, versions *cephclient.CephDaemonsVersions, deviceClasses []cephv1.DeviceClassStatus, escalated, recallClients []string) error {
 	cephCluster := &cephv1.CephCluster{}
 	err := c.client.Get(context.TODO(), c.namespacedName, cephCluster)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
-			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			c.logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
 			return nil
 		}
 		return errors.Wrapf(err, "failed to retrieve ceph cluster %q to update status to %+v", c.namespacedName.Name, status)
 	}
 
+	previousHealth := ""
+	if cephCluster.Status.CephStatus != nil {
+		previousHealth = cephCluster.Status.CephStatus.Health
+	}
 	cephCluster.Status.CephStatus = toCustomResourceStatus(cephCluster.Status, status)
+	addHealthDetails(cephCluster.Status.CephStatus, health)
+	cephCluster.Status.DaemonHealth = toDaemonHealthStatus(status, cephCluster.Status.CephStatus.LastChecked)
+	if deviceClasses != nil {
+		cephCluster.Status.DeviceClasses = deviceClasses
+	}
+	cephCluster.Status.Balancer = c.syncBalancer(cephCluster, cephCluster.Status.CephStatus.LastChecked)
+	if cephCluster.Spec.Backup.Enabled && !cephCluster.Spec.External.Enable {
+		cephCluster.Status.Backup = backup.GetStatus(c.context, c.namespacedName.Namespace, cephCluster.Status.Backup)
+	} else {
+		cephCluster.Status.Backup = nil
+	}
+	config.SetStatusCondition(&cephCluster.Status.Conditions, toHealthyCondition(cephCluster.Status.CephStatus.Health))
+	previousUpgrade := cephCluster.Status.Upgrade
+	if versions != nil && cephCluster.Status.CephVersion != nil {
+		cephCluster.Status.Upgrade = toUpgradeStatus(cephCluster.Status.CephVersion.Version, versions, cephCluster.Status.CephStatus.LastChecked)
+	}
+	if len(recallClients) > 0 {
+		// name the clients in the status so they can be evicted without digging into the health detail
+		recall := cephCluster.Status.CephStatus.Details[mdsClientRecallCheck]
+		recall.Message = fmt.Sprintf("%s: %s", recall.Message, strings.Join(recallClients, ", "))
+		cephCluster.Status.CephStatus.Details[mdsClientRecallCheck] = recall
+	}
 	if err := opcontroller.UpdateStatus(c.client, cephCluster); err != nil {
 		return errors.Wrapf(err, "failed to update cluster %q status", c.namespacedName.Namespace)
 	}
+	c.reportHealthChange(cephCluster, previousHealth, cephCluster.Status.CephStatus.Health)
+	c.reportEscalatedWarnings(cephCluster, status, escalated)
+	c.reportMDSClientsFailingToRecall(cephCluster, recallClients)
+	c.reportUpgradeCompleted(cephCluster, previousUpgrade, cephCluster.Status.Upgrade)
+	c.syncClusterReport(cephCluster, status, versions, time.Now().UTC())
+	c.remediateHealth(cephCluster, health)
+	c.saveRemediationState()
 
-	logger.Debugf("ceph cluster %q status updated to %+v", c.namespacedName.Name, status)
+	c.logger.Debugf("ceph cluster %q status updated to %+v", c.namespacedName.Name, status)
 	return nil
 }
 
+// reportHealthChange emits an event on the cluster when its overall health changed since the last check
+// Entering HEALTH_WARN or HEALTH_ERR is a warning, recovering is a normal event.
+func (c *cephStatusChecker) reportHealthChange(cephCluster *cephv1.CephCluster, previousHealth, health string) {
+	if c.recorder == nil || previousHealth == "" || previousHealth == health {
+		return
+	}
+	eventType := v1.EventTypeNormal
+	if health == healthWarn || health == healthErr {
+		eventType = v1.EventTypeWarning
+	}
+	c.recorder.Eventf(cephCluster, eventType, healthChangedReason, "ceph health changed from %s to %s", previousHealth, health)
+}
+
+// reportEscalatedWarnings emits an event on the cluster for each warning escalated to an error
+func (c *cephStatusChecker) reportEscalatedWarnings(cephCluster *cephv1.CephCluster, status *cephclient.CephStatus, escalated []string) {
+	if c.recorder == nil {
+		return
+	}
+	for _, code := range escalated {
+		c.recorder.Eventf(cephCluster, v1.EventTypeWarning, healthWarningEscalatedReason, "ceph health warning %s escalated to %s: %s", code, healthErr, status.Health.Checks[code].Summary.Message)
+	}
+}
+
+// reportMDSClientsFailingToRecall emits an event on the cluster for each client newly failing to release its caps
+func (c *cephStatusChecker) reportMDSClientsFailingToRecall(cephCluster *cephv1.CephCluster, recallClients []string) {
+	current := make(map[string]struct{}, len(recallClients))
+	for _, client := range recallClients {
+		current[client] = struct{}{}
+		if _, ok := c.recallClients[client]; ok {
+			continue
+		}
+		c.logger.Warningf("mds client %s is failing to respond to cache pressure in cluster %q", client, c.namespacedName.Namespace)
+		if c.recorder != nil {
+			c.recorder.Eventf(cephCluster, v1.EventTypeWarning, mdsClientCapsRecallReason, "mds client %s is failing to respond to cache pressure", client)
+		}
+	}
+	c.recallClients = current
+}
+
+// reportUpgradeCompleted emits an event on the cluster when the last daemons running another version were upgraded
+func (c *cephStatusChecker) reportUpgradeCompleted(cephCluster *cephv1.CephCluster, previous, current *cephv1.UpgradeStatus) {
+	if previous == nil || current == nil || previous.Complete || !current.Complete || previous.TargetVersion != current.TargetVersion {
+		return
+	}
+	c.logger.Infof("all the ceph daemons of cluster %q run version %q", c.namespacedName.Namespace, current.TargetVersion)
+	if c.recorder != nil {
+		c.recorder.Eventf(cephCluster, v1.EventTypeNormal, upgradeCompletedReason, "all the ceph daemons run version %s", current.TargetVersion)
+	}
+}
+
 // toCustomResourceStatus converts the ceph status to the struct expected for the CephCluster CR status
 func toCustomResourceStatus(currentStatus cephv1.ClusterStatus, newStatus *cephclient.CephStatus) *cephv1.CephStatus {
 	s := &cephv1.CephStatus{
@@ -150,8 +503,9 @@ func toCustomResourceStatus(currentStatus cephv1.ClusterStatus, newStatus *cephc
 	}
 	for name, message := range newStatus.Health.Checks {
 		s.Details[name] = cephv1.CephHealthMessage{
-			Severity: message.Severity,
-			Message:  message.Summary.Message,
+			Severity:    message.Severity,
+			Message:     message.Summary.Message,
+			Remediation: healthRemediation(name),
 		}
 	}
 	if currentStatus.CephStatus != nil {
@@ -165,6 +519,106 @@ func toCustomResourceStatus(currentStatus cephv1.ClusterStatus, newStatus *cephc
 	return s
 }
 
+// toHealthyCondition reports whether the ceph health is HEALTH_OK, so that a degraded cluster can be told apart
+// from a cluster still being orchestrated or failing to be orchestrated
+func toHealthyCondition(health string) cephv1.Condition {
+	if health == healthOK {
+		return cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionTrue, Reason: "ClusterHealthy", Message: "Ceph health is HEALTH_OK"}
+	}
+	return cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionFalse, Reason: "ClusterDegraded", Message: fmt.Sprintf("Ceph health is %s", health)}
+}
+
+// toDaemonHealthStatus summarizes the daemons of the ceph status for the CephCluster CR status
+// From octopus the status only reports a compact monmap and mgrmap, with the counts of the mons and the standby mgrs
+// but without the name of the active mgr.
+func toDaemonHealthStatus(status *cephclient.CephStatus, lastChecked string) *cephv1.DaemonHealthStatus {
+	osdMap := status.OsdMap.OsdMap
+	inQuorum := len(status.QuorumNames)
+	if inQuorum == 0 {
+		inQuorum = len(status.Quorum)
+	}
+	return &cephv1.DaemonHealthStatus{
+		Mon: cephv1.MonHealthStatus{
+			Count:    status.MonMap.MonCount(),
+			InQuorum: inQuorum,
+		},
+		Mgr: cephv1.MgrHealthStatus{
+			Active:    status.MgrMap.ActiveName,
+			Available: status.MgrMap.Available,
+			Standbys:  status.MgrMap.StandbyCount(),
+		},
+		OSD: cephv1.OSDHealthStatus{
+			Count: osdMap.NumOsd,
+			Up:    osdMap.NumUpOsd,
+			Down:  osdMap.NumOsd - osdMap.NumUpOsd,
+			In:    osdMap.NumInOsd,
+			Out:   osdMap.NumOsd - osdMap.NumInOsd,
+		},
+		LastChecked: lastChecked,
+	}
+}
+
+// toDeviceClassStatus summarizes the capacity of the osds of each device class for the CephCluster CR status
+func toDeviceClassStatus(usage *cephclient.OSDUsage) []cephv1.DeviceClassStatus {
+	deviceClasses := []cephv1.DeviceClassStatus{}
+	for _, class := range usage.ByDeviceClass() {
+		deviceClasses = append(deviceClasses, cephv1.DeviceClassStatus{
+			Name:           class.Name,
+			OSDs:           class.OSDs,
+			BytesTotal:     class.KB * 1024,
+			BytesUsed:      class.UsedKB * 1024,
+			BytesAvailable: class.AvailKB * 1024,
+		})
+	}
+	return deviceClasses
+}
+
+// toUpgradeStatus counts the daemons of each type already running the target version, listed in the order of the
+// upgrade of the cluster so that the daemon type being upgraded is the first one not fully upgraded
+func toUpgradeStatus(targetVersion string, versions *cephclient.CephDaemonsVersions, lastChecked string) *cephv1.UpgradeStatus {
+	upgrade := &cephv1.UpgradeStatus{TargetVersion: targetVersion, Complete: true, LastChecked: lastChecked}
+	target, err := opcontroller.ExtractCephVersionFromLabel(targetVersion)
+	if err != nil {
+		logger.Debugf("failed to parse the ceph version %q of the cluster. %v", targetVersion, err)
+		return nil
+	}
+
+	daemons := []struct {
+		daemonType string
+		versions   map[string]int
+	}{
+		{"mon", versions.Mon},
+		{"mgr", versions.Mgr},
+		{"osd", versions.Osd},
+		{"mds", versions.Mds},
+		{"rgw", versions.Rgw},
+		{"rbd-mirror", versions.RbdMirror},
+	}
+	for _, daemon := range daemons {
+		if len(daemon.versions) == 0 {
+			continue
+		}
+		status := cephv1.DaemonUpgradeStatus{Type: daemon.daemonType}
+		for versionString, count := range daemon.versions {
+			status.Total += count
+			version, err := cephver.ExtractCephVersion(versionString)
+			if err != nil {
+				logger.Debugf("failed to parse the %s version %q. %v", daemon.daemonType, versionString, err)
+				continue
+			}
+			if cephver.IsIdentical(*version, *target) {
+				status.Upgraded += count
+			}
+		}
+		if status.Upgraded != status.Total {
+			upgrade.Complete = false
+		}
+		upgrade.Daemons = append(upgrade.Daemons, status)
+	}
+
+	return upgrade
+}
+
 func formatTime(t time.Time) string {
 	return t.Format(time.RFC3339)
 }
@@ -18,15 +18,23 @@ limitations under the License.
 package cluster
 
 import (
+	"encoding/json"
 	"reflect"
 	"testing"
 	"time"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/util/exec"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestCephStatus(t *testing.T) {
@@ -111,14 +119,335 @@ func TestNewCephStatusChecker(t *testing.T) {
 		args args
 		want *cephStatusChecker
 	}{
-		{"default-interval", args{c, n, u, nsName, cephv1.CephClusterHealthCheckSpec{}}, &cephStatusChecker{c, n, defaultStatusCheckInterval, u, c.Client, nsName}},
-		{"default-interval", args{c, n, u, nsName, cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: "10s"}}}}, &cephStatusChecker{c, n, time10s, u, c.Client, nsName}},
+		{"default-interval", args{c, n, u, nsName, cephv1.CephClusterHealthCheckSpec{}}, &cephStatusChecker{context: c, resourceName: n, interval: defaultStatusCheckInterval, cephUser: u, client: c.Client, namespacedName: nsName, remediationInterval: defaultRemediationInterval, lastRemediations: map[string]time.Time{}, logger: statusCheckLogger(nsName)}},
+		{"default-interval", args{c, n, u, nsName, cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Status: cephv1.HealthCheckSpec{Interval: "10s"}}}}, &cephStatusChecker{context: c, resourceName: n, interval: time10s, cephUser: u, client: c.Client, namespacedName: nsName, remediationInterval: defaultRemediationInterval, lastRemediations: map[string]time.Time{}, logger: statusCheckLogger(nsName)}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := newCephStatusChecker(tt.args.context, tt.args.resourceName, tt.args.cephUser, tt.args.namespacedName, tt.args.healthCheck); !reflect.DeepEqual(got, tt.want) {
+			if got := newCephStatusChecker(tt.args.context, tt.args.resourceName, tt.args.cephUser, tt.args.namespacedName, tt.args.healthCheck, nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("newCephStatusChecker() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestEscalateWarnings(t *testing.T) {
+	crashMsg := cephclient.CheckMessage{Severity: "HEALTH_WARN"}
+	crashMsg.Summary.Message = "1 daemons have recently crashed"
+	osdDownMsg := cephclient.CheckMessage{Severity: "HEALTH_WARN"}
+	osdDownMsg.Summary.Message = "1 osd down"
+	newStatus := func() *cephclient.CephStatus {
+		return &cephclient.CephStatus{
+			Health: cephclient.HealthStatus{
+				Status: "HEALTH_WARN",
+				Checks: map[string]cephclient.CheckMessage{"RECENT_CRASH": crashMsg, "OSD_DOWN": osdDownMsg},
+			},
+		}
+	}
+
+	// no escalation configured, the status is unchanged
+	c := newCephStatusChecker(&clusterd.Context{}, "rook-ceph", "client.admin", types.NamespacedName{}, cephv1.CephClusterHealthCheckSpec{}, nil)
+	status := newStatus()
+	assert.Nil(t, c.escalateWarnings(status))
+	assert.Equal(t, "HEALTH_WARN", status.Health.Status)
+	assert.Equal(t, "HEALTH_WARN", status.Health.Checks["RECENT_CRASH"].Severity)
+
+	// the escalated code is absent, the status is unchanged
+	c = newCephStatusChecker(&clusterd.Context{}, "rook-ceph", "client.admin", types.NamespacedName{}, cephv1.CephClusterHealthCheckSpec{EscalatedWarnings: []string{"POOL_FULL"}}, nil)
+	status = newStatus()
+	assert.Nil(t, c.escalateWarnings(status))
+	assert.Equal(t, "HEALTH_WARN", status.Health.Status)
+
+	// the escalated code is present, only this check and the overall health are promoted
+	c = newCephStatusChecker(&clusterd.Context{}, "rook-ceph", "client.admin", types.NamespacedName{}, cephv1.CephClusterHealthCheckSpec{EscalatedWarnings: []string{"RECENT_CRASH"}}, nil)
+	status = newStatus()
+	assert.Equal(t, []string{"RECENT_CRASH"}, c.escalateWarnings(status))
+	assert.Equal(t, "HEALTH_ERR", status.Health.Status)
+	assert.Equal(t, "HEALTH_ERR", status.Health.Checks["RECENT_CRASH"].Severity)
+	assert.Equal(t, "HEALTH_WARN", status.Health.Checks["OSD_DOWN"].Severity)
+
+	aggregateStatus := toCustomResourceStatus(cephv1.ClusterStatus{}, status)
+	assert.Equal(t, "HEALTH_ERR", aggregateStatus.Health)
+	assert.Equal(t, "HEALTH_ERR", aggregateStatus.Details["RECENT_CRASH"].Severity)
+
+	// an escalated event is emitted
+	recorder := record.NewFakeRecorder(10)
+	c.recorder = recorder
+	c.reportEscalatedWarnings(&cephv1.CephCluster{}, status, []string{"RECENT_CRASH"})
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Contains(t, <-recorder.Events, "RECENT_CRASH")
+}
+
+func TestMDSClientsFailingToRecall(t *testing.T) {
+	healthDetail := `{"checks":{"MDS_CLIENT_RECALL":{"severity":"HEALTH_WARN","summary":{"message":"2 clients failing to respond to cache pressure"},"detail":[
+		{"message":"mds.a(mds.0): Client worker2:guest failing to respond to cache pressure client_id: 4237"},
+		{"message":"mds.a(mds.0): Client worker1:guest failing to respond to cache pressure client_id: 4236"}]}},"status":"HEALTH_WARN"}`
+	var health cephclient.HealthStatus
+	assert.NoError(t, json.Unmarshal([]byte(healthDetail), &health))
+
+	// no health detail or no recall check
+	assert.Nil(t, mdsClientsFailingToRecall(nil))
+	assert.Nil(t, mdsClientsFailingToRecall(&cephclient.HealthStatus{Status: "HEALTH_OK"}))
+
+	// the clients are parsed from the health detail
+	clients := mdsClientsFailingToRecall(&health)
+	assert.Equal(t, []string{"worker1:guest (client_id 4236)", "worker2:guest (client_id 4237)"}, clients)
+}
+
+func TestReportMDSClientsFailingToRecall(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &cephStatusChecker{
+		namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"},
+		recorder:       recorder,
+	}
+	cephCluster := &cephv1.CephCluster{}
+
+	// an event is emitted for each new client
+	c.reportMDSClientsFailingToRecall(cephCluster, []string{"worker1:guest"})
+	assert.Equal(t, 1, len(recorder.Events))
+	<-recorder.Events
+
+	// clients already reported are not reported again
+	c.reportMDSClientsFailingToRecall(cephCluster, []string{"worker1:guest", "worker2:guest"})
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Contains(t, <-recorder.Events, "worker2:guest")
+
+	// once the warning clears, a client failing again is reported again
+	c.reportMDSClientsFailingToRecall(cephCluster, nil)
+	assert.Equal(t, 0, len(c.recallClients))
+	c.reportMDSClientsFailingToRecall(cephCluster, []string{"worker1:guest"})
+	assert.Equal(t, 1, len(recorder.Events))
+}
+
+func TestReportHealthChange(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	c := &cephStatusChecker{
+		namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"},
+		recorder:       recorder,
+	}
+	cephCluster := &cephv1.CephCluster{}
+
+	// the first check and an unchanged health are not reported
+	c.reportHealthChange(cephCluster, "", "HEALTH_OK")
+	c.reportHealthChange(cephCluster, "HEALTH_OK", "HEALTH_OK")
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// degrading is a warning
+	c.reportHealthChange(cephCluster, "HEALTH_OK", "HEALTH_WARN")
+	assert.Equal(t, "Warning CephHealthChanged ceph health changed from HEALTH_OK to HEALTH_WARN", <-recorder.Events)
+	c.reportHealthChange(cephCluster, "HEALTH_WARN", "HEALTH_ERR")
+	assert.Equal(t, "Warning CephHealthChanged ceph health changed from HEALTH_WARN to HEALTH_ERR", <-recorder.Events)
+
+	// recovering is normal
+	c.reportHealthChange(cephCluster, "HEALTH_ERR", "HEALTH_OK")
+	assert.Equal(t, "Normal CephHealthChanged ceph health changed from HEALTH_ERR to HEALTH_OK", <-recorder.Events)
+}
+
+const (
+	healthOKStatusFixture = `{"fsid":"613975f3-3025-4802-9de1-a2280b950e75","health":{"checks":{},"status":"HEALTH_OK"},
+		"osdmap":{"osdmap":{"epoch":14,"num_osds":3,"num_up_osds":3,"num_in_osds":3,"full":false,"nearfull":false,"num_remapped_pgs":0}}}`
+	healthWarnMutedStatusFixture = `{"fsid":"613975f3-3025-4802-9de1-a2280b950e75","health":{"checks":{
+		"OSD_DOWN":{"severity":"HEALTH_WARN","summary":{"message":"1 osds down"}},
+		"PG_DEGRADED":{"severity":"HEALTH_WARN","summary":{"message":"Degraded data redundancy: 24/72 objects degraded"}}},
+		"mutes":[{"code":"RECENT_CRASH","sticky":false,"summary":"2 daemons have recently crashed","count":2},
+		{"code":"MON_DISK_LOW","sticky":true,"summary":"mon a is low on available space","count":1}],
+		"status":"HEALTH_WARN"},
+		"osdmap":{"osdmap":{"epoch":16,"num_osds":3,"num_up_osds":2,"num_in_osds":3,"full":false,"nearfull":false,"num_remapped_pgs":0}}}`
+)
+
+func TestNewCephHealthSummary(t *testing.T) {
+	var status cephclient.CephStatus
+	err := json.Unmarshal([]byte(healthOKStatusFixture), &status)
+	assert.NoError(t, err)
+	summary := newCephHealthSummary(&status)
+	assert.Equal(t, "HEALTH_OK", summary.Status)
+	assert.Equal(t, 0, len(summary.Checks))
+	assert.Equal(t, 0, len(summary.MutedChecks))
+	assert.Equal(t, 3, summary.NumOSDs)
+	assert.Equal(t, 3, summary.NumUpOSDs)
+	assert.Equal(t, 0, summary.NumDownOSDs)
+	assert.False(t, summary.LastChecked.IsZero())
+
+	status = cephclient.CephStatus{}
+	err = json.Unmarshal([]byte(healthWarnMutedStatusFixture), &status)
+	assert.NoError(t, err)
+	summary = newCephHealthSummary(&status)
+	assert.Equal(t, "HEALTH_WARN", summary.Status)
+	assert.Equal(t, 2, len(summary.Checks))
+	assert.Equal(t, CephHealthCheck{Severity: "HEALTH_WARN", Message: "1 osds down"}, summary.Checks["OSD_DOWN"])
+	assert.Equal(t, []string{"MON_DISK_LOW", "RECENT_CRASH"}, summary.MutedChecks)
+	assert.Equal(t, 3, summary.NumOSDs)
+	assert.Equal(t, 2, summary.NumUpOSDs)
+	assert.Equal(t, 1, summary.NumDownOSDs)
+}
+
+func TestToDaemonHealthStatus(t *testing.T) {
+	var status cephclient.CephStatus
+	err := json.Unmarshal([]byte(`{"health":{"status":"HEALTH_WARN"},"quorum":[0,1],
+		"monmap":{"mons":[{"name":"a","rank":0},{"name":"b","rank":1},{"name":"c","rank":2}]},
+		"mgrmap":{"active_name":"a","available":true,"standbys":[{"gid":4120,"name":"b"}]},
+		"osdmap":{"osdmap":{"num_osds":4,"num_up_osds":3,"num_in_osds":2}}}`), &status)
+	assert.NoError(t, err)
+
+	daemonHealth := toDaemonHealthStatus(&status, "2020-06-01T10:00:00Z")
+	assert.Equal(t, cephv1.MonHealthStatus{Count: 3, InQuorum: 2}, daemonHealth.Mon)
+	assert.Equal(t, cephv1.MgrHealthStatus{Active: "a", Available: true, Standbys: 1}, daemonHealth.Mgr)
+	assert.Equal(t, cephv1.OSDHealthStatus{Count: 4, Up: 3, Down: 1, In: 2, Out: 2}, daemonHealth.OSD)
+	assert.Equal(t, "2020-06-01T10:00:00Z", daemonHealth.LastChecked)
+
+	// the compact monmap and mgrmap of octopus only count the mons and the standbys
+	status = cephclient.CephStatus{}
+	err = json.Unmarshal([]byte(`{"health":{"status":"HEALTH_OK"},"quorum":[0,1,2],"quorum_names":["a","b","c"],
+		"monmap":{"epoch":3,"min_mon_release_name":"octopus","num_mons":3},
+		"mgrmap":{"available":true,"num_standbys":1,"modules":["iostat","prometheus","restful"]},
+		"osdmap":{"osdmap":{"num_osds":3,"num_up_osds":3,"num_in_osds":3}}}`), &status)
+	assert.NoError(t, err)
+
+	daemonHealth = toDaemonHealthStatus(&status, "2020-06-01T10:00:00Z")
+	assert.Equal(t, cephv1.MonHealthStatus{Count: 3, InQuorum: 3}, daemonHealth.Mon)
+	assert.Equal(t, cephv1.MgrHealthStatus{Available: true, Standbys: 1}, daemonHealth.Mgr)
+	assert.Equal(t, cephv1.OSDHealthStatus{Count: 3, Up: 3, In: 3}, daemonHealth.OSD)
+}
+
+func TestToDeviceClassStatus(t *testing.T) {
+	var usage cephclient.OSDUsage
+	err := json.Unmarshal([]byte(`{"nodes":[
+		{"id":0,"device_class":"ssd","kb":1024,"kb_used":256,"kb_avail":768},
+		{"id":1,"device_class":"nvme-meta","kb":2048,"kb_used":1024,"kb_avail":1024},
+		{"id":2,"device_class":"ssd","kb":1024,"kb_used":0,"kb_avail":1024}]}`), &usage)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []cephv1.DeviceClassStatus{
+		{Name: "nvme-meta", OSDs: 1, BytesTotal: 2097152, BytesUsed: 1048576, BytesAvailable: 1048576},
+		{Name: "ssd", OSDs: 2, BytesTotal: 2097152, BytesUsed: 262144, BytesAvailable: 1835008},
+	}, toDeviceClassStatus(&usage))
+	assert.Equal(t, []cephv1.DeviceClassStatus{}, toDeviceClassStatus(&cephclient.OSDUsage{}))
+}
+
+func TestToUpgradeStatus(t *testing.T) {
+	var versions cephclient.CephDaemonsVersions
+	err := json.Unmarshal([]byte(`{
+		"mon":{"ceph version 15.2.4 (7447c15c6ff58d7fce91843b705a268a1917325c) octopus (stable)":3},
+		"mgr":{"ceph version 15.2.4 (7447c15c6ff58d7fce91843b705a268a1917325c) octopus (stable)":1},
+		"osd":{"ceph version 14.2.10 (b340acf629a010a74d90da5782a2c5fe0b54ac20) nautilus (stable)":4,
+			"ceph version 15.2.4 (7447c15c6ff58d7fce91843b705a268a1917325c) octopus (stable)":2}}`), &versions)
+	assert.NoError(t, err)
+
+	upgrade := toUpgradeStatus("15.2.4-0", &versions, "2020-06-01T10:00:00Z")
+	assert.Equal(t, "15.2.4-0", upgrade.TargetVersion)
+	assert.False(t, upgrade.Complete)
+	assert.Equal(t, []cephv1.DaemonUpgradeStatus{
+		{Type: "mon", Upgraded: 3, Total: 3},
+		{Type: "mgr", Upgraded: 1, Total: 1},
+		{Type: "osd", Upgraded: 2, Total: 6},
+	}, upgrade.Daemons)
+	assert.Equal(t, "2020-06-01T10:00:00Z", upgrade.LastChecked)
+
+	delete(versions.Osd, "ceph version 14.2.10 (b340acf629a010a74d90da5782a2c5fe0b54ac20) nautilus (stable)")
+	upgrade = toUpgradeStatus("15.2.4-0", &versions, "")
+	assert.True(t, upgrade.Complete)
+
+	// the completion is reported once per target version
+	recorder := record.NewFakeRecorder(10)
+	c := &cephStatusChecker{namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, recorder: recorder}
+	c.reportUpgradeCompleted(&cephv1.CephCluster{}, nil, upgrade)
+	c.reportUpgradeCompleted(&cephv1.CephCluster{}, upgrade, upgrade)
+	assert.Equal(t, 0, len(recorder.Events))
+	c.reportUpgradeCompleted(&cephv1.CephCluster{}, &cephv1.UpgradeStatus{TargetVersion: "15.2.4-0"}, upgrade)
+	assert.Equal(t, "Normal CephUpgradeCompleted all the ceph daemons run version 15.2.4-0", <-recorder.Events)
+
+	assert.Nil(t, toUpgradeStatus("invalid", &versions, ""))
+}
+
+func TestHealthSummaryStored(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return healthWarnMutedStatusFixture, nil
+			}
+			return "", nil
+		},
+	}
+	c := newCephStatusChecker(&clusterd.Context{Executor: executor}, "rook-ceph", "client.admin", types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, cephv1.CephClusterHealthCheckSpec{}, nil)
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	c.client = fake.NewFakeClientWithScheme(s)
+
+	// no check yet
+	_, ok := c.healthSummary()
+	assert.False(t, ok)
+
+	// the summary is stored even if the cluster CR is not found
+	c.checkStatus()
+	summary, ok := c.healthSummary()
+	assert.True(t, ok)
+	assert.Equal(t, "HEALTH_WARN", summary.Status)
+	assert.Equal(t, 1, summary.NumDownOSDs)
+}
+
+func TestCheckStatusCommandTimeout(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			// a hung mon never answers
+			time.Sleep(2 * time.Second)
+			return healthWarnMutedStatusFixture, nil
+		},
+	}
+	healthCheck := cephv1.CephClusterHealthCheckSpec{CommandTimeout: "50ms"}
+	context := opcontroller.HealthCheckContext(&clusterd.Context{Executor: executor}, healthCheck)
+	c := newCephStatusChecker(context, "rook-ceph", "client.admin", types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, healthCheck, nil)
+
+	// the command fails with a timeout instead of blocking
+	start := time.Now()
+	_, err := cephclient.StatusWithUser(c.context, "rook-ceph", "client.admin")
+	assert.Error(t, err)
+	assert.True(t, exec.IsTimeout(err))
+	assert.True(t, time.Since(start) < time.Second)
+
+	// the check returns promptly and counts as failed
+	start = time.Now()
+	c.checkStatus()
+	assert.True(t, time.Since(start) < time.Second)
+	_, ok := c.healthSummary()
+	assert.False(t, ok)
+}
+
+func TestHealthTransition(t *testing.T) {
+	health := "HEALTH_OK"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return `{"health":{"status":"` + health + `"}}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := newCephStatusChecker(&clusterd.Context{Executor: executor}, "rook-ceph", "client.admin", types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, cephv1.CephClusterHealthCheckSpec{}, nil)
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	c.client = fake.NewFakeClientWithScheme(s)
+	transitions := []string{}
+	c.healthTransitionCallbacks = []func(old, new CephHealthSummary){
+		func(old, new CephHealthSummary) {
+			assert.Equal(t, "rook-ceph", new.Namespace)
+			transitions = append(transitions, old.Status+"->"+new.Status)
+		},
+	}
+
+	for _, health = range []string{"HEALTH_OK", "HEALTH_WARN", "HEALTH_ERR", "HEALTH_ERR", "HEALTH_WARN", "HEALTH_OK", "HEALTH_OK", "HEALTH_ERR"} {
+		c.checkStatus()
+	}
+	assert.Equal(t, []string{"HEALTH_WARN->HEALTH_ERR", "HEALTH_ERR->HEALTH_WARN", "HEALTH_OK->HEALTH_ERR"}, transitions)
+
+	// a failed check does not reset the previous health
+	transitions = []string{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return "", errors.New("no cluster")
+	}
+	c.checkStatus()
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		return `{"health":{"status":"HEALTH_ERR"}}`, nil
+	}
+	c.checkStatus()
+	assert.Equal(t, 0, len(transitions))
+}
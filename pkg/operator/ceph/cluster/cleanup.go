@@ -18,6 +18,7 @@ package cluster
 
 import (
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/pkg/errors"
@@ -50,6 +51,10 @@ var (
 	monitorSecret   = "ROOK_MON_SECRET"
 	clusterFSID     = "ROOK_CLUSTER_FSID"
 	clusterName     = "ROOK_CLUSTER_NAME"
+	osdIDEnvVar     = "ROOK_OSD_ID"
+	sanitizeMethod  = "ROOK_SANITIZE_METHOD"
+	sanitizeSource  = "ROOK_SANITIZE_DATA_SOURCE"
+	sanitizeCount   = "ROOK_SANITIZE_ITERATION"
 )
 
 func (c *ClusterController) startClusterCleanUp(stopCleanupCh chan struct{}, cluster *cephv1.CephCluster, cephHosts []string, monSecret, clusterFSID string) {
@@ -68,29 +73,56 @@ func (c *ClusterController) startCleanUpJobs(cluster *cephv1.CephCluster, cephHo
 		logger.Infof("starting clean up job on node %q", hostName)
 		jobName := k8sutil.TruncateNodeName("cluster-cleanup-job-%s", hostName)
 		podSpec := c.cleanUpJobTemplateSpec(cluster, monSecret, clusterFSID)
-		podSpec.Spec.NodeSelector = map[string]string{v1.LabelHostname: hostName}
-		labels := controller.AppLabels(CleanupAppName, cluster.Namespace)
-		labels[CleanupAppName] = "true"
-		job := &batch.Job{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      jobName,
-				Namespace: cluster.Namespace,
-				Labels:    labels,
-			},
-			Spec: batch.JobSpec{
-				Template: podSpec,
-			},
+		if err := c.runCleanUpJob(cluster, jobName, hostName, podSpec); err != nil {
+			logger.Errorf("failed to run cluster clean up job on node %q. %v", hostName, err)
 		}
+	}
+}
 
-		// Apply annotations
-		cephv1.GetCleanupAnnotations(cluster.Spec.Annotations).ApplyToObjectMeta(&job.ObjectMeta)
+// startOSDCleanUpJob wipes the disk of a removed osd on its node, if the cleanup policy of the cluster allows it
+func (c *ClusterController) startOSDCleanUpJob(cluster *cluster, osdID int, hostName string) {
+	if !cluster.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
+		logger.Infof("not wiping the disk of removed osd.%d since the cleanup policy of cluster %q is not confirmed", osdID, cluster.Namespace)
+		return
+	}
 
-		if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
-			logger.Errorf("failed to run cluster clean up job on node %q. %v", hostName, err)
-		}
+	logger.Infof("starting clean up job of osd.%d on node %q", osdID, hostName)
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: cluster.crdName, Namespace: cluster.Namespace},
+		Spec:       *cluster.Spec,
+	}
+	jobName := k8sutil.TruncateNodeName(fmt.Sprintf("osd-%d-cleanup-job-%%s", osdID), hostName)
+	podSpec := c.osdCleanUpJobTemplateSpec(cephCluster, osdID, cluster.Info.FSID)
+	if err := c.runCleanUpJob(cephCluster, jobName, hostName, podSpec); err != nil {
+		logger.Errorf("failed to run clean up job of osd.%d on node %q. %v", osdID, hostName, err)
 	}
 }
 
+func (c *ClusterController) runCleanUpJob(cluster *cephv1.CephCluster, jobName, hostName string, podSpec v1.PodTemplateSpec) error {
+	podSpec.Spec.NodeSelector = map[string]string{v1.LabelHostname: hostName}
+	labels := controller.AppLabels(CleanupAppName, cluster.Namespace)
+	labels[CleanupAppName] = "true"
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      jobName,
+			Namespace: cluster.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: podSpec,
+		},
+	}
+
+	// Apply annotations
+	cephv1.GetCleanupAnnotations(cluster.Spec.Annotations).ApplyToObjectMeta(&job.ObjectMeta)
+	cephv1.GetCleanupAnnotations(cluster.Spec.Annotations).ApplyToObjectMeta(&job.Spec.Template.ObjectMeta)
+	cephv1.GetCleanupLabels(cluster.Spec.Labels).ApplyToObjectMeta(&job.ObjectMeta)
+	cephv1.GetCleanupLabels(cluster.Spec.Labels).ApplyToObjectMeta(&job.Spec.Template.ObjectMeta)
+	controller.ApplyDaemonOverrides(&job.Spec.Template.Spec, cluster.Spec.Env, cluster.Spec.VolumeMounts, cephv1.KeyCleanup)
+
+	return k8sutil.RunReplaceableJob(c.context.Clientset, job, true)
+}
+
 func (c *ClusterController) cleanUpJobContainer(cluster *cephv1.CephCluster, monSecret, cephFSID string) v1.Container {
 	volumeMounts := []v1.VolumeMount{}
 	envVars := []v1.EnvVar{}
@@ -107,6 +139,7 @@ func (c *ClusterController) cleanUpJobContainer(cluster *cephv1.CephCluster, mon
 			{Name: clusterName, Value: cluster.Name},
 			{Name: "ROOK_LOG_LEVEL", Value: "DEBUG"},
 		}...)
+		envVars = append(envVars, sanitizeEnvVars(cluster.Spec.CleanupPolicy.SanitizeDisks)...)
 	}
 
 	return v1.Container{
@@ -138,6 +171,8 @@ func (c *ClusterController) cleanUpJobTemplateSpec(cluster *cephv1.CephCluster,
 			Volumes:           volumes,
 			RestartPolicy:     v1.RestartPolicyOnFailure,
 			PriorityClassName: cephv1.GetCleanupPriorityClassName(cluster.Spec.PriorityClassNames),
+			// the cluster is being deleted, the secrets are taken from the spec rather than from the running cluster
+			ImagePullSecrets: cluster.Spec.ImagePullSecrets,
 		},
 	}
 
@@ -148,6 +183,29 @@ func (c *ClusterController) cleanUpJobTemplateSpec(cluster *cephv1.CephCluster,
 	return podSpec
 }
 
+// osdCleanUpJobTemplateSpec returns the pod of the job wiping the disk of a single osd
+// The dataDirHostPath is not passed to the job, so that the data of the other daemons of the node is kept.
+func (c *ClusterController) osdCleanUpJobTemplateSpec(cluster *cephv1.CephCluster, osdID int, cephFSID string) v1.PodTemplateSpec {
+	podSpec := c.cleanUpJobTemplateSpec(cluster, "", cephFSID)
+	podSpec.Spec.Containers[0].Env = []v1.EnvVar{
+		{Name: clusterFSID, Value: cephFSID},
+		{Name: clusterName, Value: cluster.Name},
+		{Name: osdIDEnvVar, Value: strconv.Itoa(osdID)},
+		{Name: "ROOK_LOG_LEVEL", Value: "DEBUG"},
+	}
+	podSpec.Spec.Containers[0].Env = append(podSpec.Spec.Containers[0].Env, sanitizeEnvVars(cluster.Spec.CleanupPolicy.SanitizeDisks)...)
+	return podSpec
+}
+
+// sanitizeEnvVars returns the settings of the sanitizing of the disks passed to the cleanup jobs
+func sanitizeEnvVars(sanitizeDisks cephv1.SanitizeDisksSpec) []v1.EnvVar {
+	return []v1.EnvVar{
+		{Name: sanitizeMethod, Value: string(sanitizeDisks.GetMethod())},
+		{Name: sanitizeSource, Value: string(sanitizeDisks.GetDataSource())},
+		{Name: sanitizeCount, Value: strconv.Itoa(int(sanitizeDisks.GetIteration()))},
+	}
+}
+
 func (c *ClusterController) waitForCephDaemonCleanUp(stopCleanupCh chan struct{}, cluster *cephv1.CephCluster, retryInterval time.Duration) error {
 	logger.Infof("waiting for all the ceph daemons to be cleaned up in the cluster %q", cluster.Namespace)
 	for {
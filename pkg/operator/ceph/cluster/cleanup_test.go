@@ -40,6 +40,10 @@ func TestCleanUpJobSpec(t *testing.T) {
 			DataDirHostPath: expectedHostPath,
 			CleanupPolicy: cephv1.CleanupPolicySpec{
 				Confirmation: "yes-really-destroy-data",
+				SanitizeDisks: cephv1.SanitizeDisksSpec{
+					Method:    cephv1.SanitizeMethodComplete,
+					Iteration: 2,
+				},
 			},
 		},
 	}
@@ -64,4 +68,41 @@ func TestCleanUpJobSpec(t *testing.T) {
 	podTemplateSpec := controller.cleanUpJobTemplateSpec(cluster, "monSecret", "28b87851-8dc1-46c8-b1ec-90ec51a47c89")
 	assert.Equal(t, expectedHostPath, podTemplateSpec.Spec.Containers[0].Env[0].Value)
 	assert.Equal(t, expectedNamespace, podTemplateSpec.Spec.Containers[0].Env[1].Value)
+
+	// the sanitizing settings are passed to the job, with the default data source
+	env := map[string]string{}
+	for _, e := range podTemplateSpec.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	assert.Equal(t, "complete", env[sanitizeMethod])
+	assert.Equal(t, "zero", env[sanitizeSource])
+	assert.Equal(t, "2", env[sanitizeCount])
+}
+
+func TestOSDCleanUpJobSpec(t *testing.T) {
+	cluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "rook-ceph",
+			Namespace: "test-rook-ceph",
+		},
+		Spec: cephv1.ClusterSpec{
+			DataDirHostPath: "var/lib/rook",
+		},
+	}
+	context := &clusterd.Context{
+		Clientset:     testop.New(t, 3),
+		RookClientset: rookfake.NewSimpleClientset(),
+	}
+	controller := NewClusterController(context, "", &attachment.MockAttachment{}, []func() error{}, []func() error{})
+	podTemplateSpec := controller.osdCleanUpJobTemplateSpec(cluster, 3, "28b87851-8dc1-46c8-b1ec-90ec51a47c89")
+
+	// only the disk of the osd is wiped, not the dataDirHostPath
+	env := podTemplateSpec.Spec.Containers[0].Env
+	for _, e := range env {
+		assert.NotEqual(t, dataDirHostPath, e.Name)
+	}
+	assert.Equal(t, "28b87851-8dc1-46c8-b1ec-90ec51a47c89", env[0].Value)
+	assert.Equal(t, "rook-ceph", env[1].Value)
+	assert.Equal(t, osdIDEnvVar, env[2].Name)
+	assert.Equal(t, "3", env[2].Value)
 }
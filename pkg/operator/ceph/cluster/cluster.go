@@ -18,10 +18,13 @@ limitations under the License.
 package cluster
 
 import (
+	gocontext "context"
 	"fmt"
 	"path"
+	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 
@@ -29,28 +32,39 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/backup"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/toolbox"
 	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 const (
 	detectVersionName = "rook-ceph-detect-version"
+
+	orchestrationCompleted = "Orchestration completed"
 )
 
+// orchestrationSteps are the steps of the orchestration of a local cluster reported in the CephCluster status
+var orchestrationSteps = []string{"Configuring Ceph Mons", "Configuring Ceph Mgr(s)", "Configuring Ceph OSDs"}
+
 type cluster struct {
 	Info                 *cephconfig.ClusterInfo
 	context              *clusterd.Context
 	Namespace            string
 	Spec                 *cephv1.ClusterSpec
+	annotations          map[string]string
 	crdName              string
 	condition            *cephv1.ClusterStatus
 	mons                 *mon.Cluster
@@ -64,14 +78,56 @@ type cluster struct {
 	isUpgrade            bool
 	watchersActivated    bool
 	monitoringChannels   map[string]*clusterHealth
+	// cephUser is the ceph user the watchers of the cluster were started with, e.g. the user of an external cluster
+	cephUser string
+	// externalRefreshRunning is whether the connection info of the external cluster is being refreshed
+	externalRefreshRunning bool
+	// keyRotationRunning is whether the goroutine rotating the keys on schedule is running
+	keyRotationRunning bool
+	keyRotationMux     sync.Mutex
+	// ctx is cancelled when the cluster is stopped, failing the ceph commands of its goroutines
+	ctx    gocontext.Context
+	cancel gocontext.CancelFunc
+	// uid is the UID of the CephCluster, a CephCluster recreated in the namespace having another one
+	uid types.UID
+	// monitoringMux guards the monitoring channels, the osd checker and the flags of the goroutines of the cluster,
+	// which are updated by its reconciles and read by the health check triggers
+	monitoringMux sync.Mutex
+	// osdChecker is the osd health checker of the cluster, if running
+	osdChecker *osd.OSDHealthMonitor
 }
 
 type clusterHealth struct {
 	stopChan          chan struct{}
 	monitoringRunning bool
+	// cephUser is the user the goroutine is running the ceph commands with
+	cephUser string
+	// triggerChan requests the goroutine to run a check immediately
+	triggerChan chan struct{}
+	// configChan pushes the updated health check settings to the goroutine
+	configChan chan cephv1.CephClusterHealthCheckSpec
+	// healthCheck are the health check settings the goroutine was last given
+	healthCheck cephv1.CephClusterHealthCheckSpec
+	// lastCheck is the time the goroutine last completed a check
+	lastCheck      time.Time
+	lastCheckMutex sync.Mutex
+}
+
+// checkDone records the completion of a check by the goroutine
+func (h *clusterHealth) checkDone() {
+	h.lastCheckMutex.Lock()
+	defer h.lastCheckMutex.Unlock()
+	h.lastCheck = time.Now()
+}
+
+func (h *clusterHealth) getLastCheck() time.Time {
+	h.lastCheckMutex.Lock()
+	defer h.lastCheckMutex.Unlock()
+	return h.lastCheck
 }
 
 func newCluster(c *cephv1.CephCluster, context *clusterd.Context, csiMutex *sync.Mutex, ownerRef *metav1.OwnerReference) *cluster {
+	ctx, cancel := gocontext.WithCancel(gocontext.Background())
 	return &cluster{
 		// at this phase of the cluster creation process, the identity components of the cluster are
 		// not yet established. we reserve this struct which is filled in as soon as the cluster's
@@ -79,15 +135,37 @@ func newCluster(c *cephv1.CephCluster, context *clusterd.Context, csiMutex *sync
 		Info:               nil,
 		Namespace:          c.Namespace,
 		Spec:               &c.Spec,
+		annotations:        c.Annotations,
 		context:            context,
 		crdName:            c.Name,
+		uid:                c.UID,
 		stopCh:             make(chan struct{}),
+		ctx:                ctx,
+		cancel:             cancel,
 		monitoringChannels: make(map[string]*clusterHealth),
 		ownerRef:           *ownerRef,
-		mons:               mon.New(context, c.Namespace, c.Spec.DataDirHostPath, c.Spec.Network, *ownerRef, csiMutex),
+		// the mon health checker must not keep on running ceph commands once the cluster is stopped
+		mons: mon.New(controller.StoppableContext(context, ctx), c.Namespace, c.Spec.DataDirHostPath, c.Spec.Network, *ownerRef, csiMutex),
 	}
 }
 
+// stop closes the stop channel of the goroutines of the cluster and cancels their running ceph commands
+// The watchers and goroutines are started again if the cluster is orchestrated afterwards.
+func (c *cluster) stop() {
+	c.monitoringMux.Lock()
+	defer c.monitoringMux.Unlock()
+	if !c.closedStopCh {
+		close(c.stopCh)
+		c.closedStopCh = true
+	}
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.watchersActivated = false
+	c.externalRefreshRunning = false
+	c.keyRotationRunning = false
+}
+
 func (c *cluster) createInstance(rookImage string, cephVersion cephver.CephVersion) error {
 	var err error
 
@@ -115,6 +193,8 @@ func (c *cluster) createInstance(rookImage string, cephVersion cephver.CephVersi
 }
 
 func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVersion, spec *cephv1.ClusterSpec) error {
+	start := time.Now()
+
 	// Create a configmap for overriding ceph config settings
 	// These settings should only be modified by a user after they are initialized
 	err := populateConfigOverrideConfigMap(c.context, c.Namespace, c.ownerRef)
@@ -122,7 +202,13 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		return errors.Wrap(err, "failed to populate config override config map")
 	}
 
+	// The mon quorum is restored before the mons are started, the mons waiting for a quorum when they start
+	if err := c.restoreMonQuorumIfRequested(rookImage, cephVersion); err != nil {
+		return err
+	}
+
 	// Start the mon pods
+	c.reportProgress(0)
 	clusterInfo, err := c.mons.Start(c.Info, rookImage, cephVersion, *c.Spec)
 	if err != nil {
 		return errors.Wrap(err, "failed to start ceph monitors")
@@ -141,6 +227,26 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		return errors.Wrap(err, "failed to execute post actions after all the ceph monitors started")
 	}
 
+	// The ceph config of the cluster is applied before the daemons are updated
+	if err := c.applyCephConfig(); err != nil {
+		logger.Errorf("failed to apply the ceph config of cluster %q, retrying on the next orchestration. %v", c.Namespace, err)
+	}
+
+	// The keys are rotated before the daemons using them are updated
+	if err := c.rotateKeysIfNeeded(); err != nil {
+		logger.Errorf("failed to rotate the keys of cluster %q, retrying on the next orchestration. %v", c.Namespace, err)
+	}
+
+	// The toolbox runs the ceph image of the cluster, and is updated with it
+	if err := toolbox.Reconcile(c.context, c.Namespace, spec, c.ownerRef); err != nil {
+		logger.Errorf("failed to reconcile the toolbox of cluster %q, retrying on the next orchestration. %v", c.Namespace, err)
+	}
+
+	// The backups run the ceph image of the cluster with the rook binary of the operator
+	if err := backup.Reconcile(c.context, c.Namespace, spec, rookImage, c.ownerRef); err != nil {
+		logger.Errorf("failed to reconcile the backups of cluster %q, retrying on the next orchestration. %v", c.Namespace, err)
+	}
+
 	// If this is an upgrade, notify all the child controllers
 	if c.isUpgrade {
 		logger.Info("upgrade in progress, notifying child CRs")
@@ -151,6 +257,7 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 	}
 
 	// Start Ceph manager
+	c.reportProgress(1)
 	mgrs := mgr.New(c.Info,
 		c.context,
 		c.Namespace,
@@ -168,12 +275,22 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		c.Spec.DataDirHostPath,
 		c.Spec.SkipUpgradeChecks,
 		spec.HealthCheck)
+	mgrs.SetLabels(cephv1.GetMgrLabels(spec.Labels))
+	mgrs.SetLogCollector(spec.LogCollector)
+	mgrs.SetTelemetry(spec.Telemetry)
+	mgrs.SetDaemonOverrides(spec.Env, spec.VolumeMounts)
 	err = mgrs.Start()
 	if err != nil {
 		return errors.Wrap(err, "failed to start ceph mgr")
 	}
 
+	// The crash reports are pruned by the mgr crash module
+	if err := crash.ConfigureRetention(c.context, c.Namespace, spec.CrashCollector); err != nil {
+		logger.Warningf("crash reports retention not configured. %v", err)
+	}
+
 	// Start the OSDs
+	c.reportProgress(2)
 	osds := osd.New(c.Info,
 		c.context,
 		c.Namespace,
@@ -192,12 +309,27 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		c.Spec.SkipUpgradeChecks,
 		c.Spec.ContinueUpgradeAfterChecksEvenIfNotHealthy,
 		spec.HealthCheck)
+	osds.SetLabels(cephv1.GetOSDLabels(spec.Labels))
+	osds.SetPreparePlacement(cephv1.GetPrepareOSDPlacement(spec.Placement))
+	osds.SetLogCollector(spec.LogCollector)
+	osds.SetUpdateStrategy(spec.UpdateStrategy)
+	osds.SetDaemonOverrides(spec.Env, spec.VolumeMounts)
+	osds.SetKeyManagementService(spec.Security.KeyManagementService)
+	osds.SetEncryptionKeyRotation(c.annotations[controller.RotateEncryptionKeysAnnotation])
 	err = osds.Start()
 	if err != nil {
 		return errors.Wrap(err, "failed to start ceph osds")
 	}
 
+	// the nodes added before the orchestration started have their osds provisioned
+	if c.context.Client != nil {
+		if err := clearPendingNodes(c.context.Client, types.NamespacedName{Namespace: c.Namespace, Name: c.crdName}, start); err != nil {
+			logger.Warningf("failed to clear the added nodes of cluster %q. %v", c.Namespace, err)
+		}
+	}
+
 	logger.Infof("done reconciling ceph cluster in namespace %q", c.Namespace)
+	c.reportProgress(len(orchestrationSteps))
 
 	// We should be done updating by now
 	if c.isUpgrade {
@@ -213,8 +345,25 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 	return nil
 }
 
+// reportProgress records the next step of the orchestration in the status of the CephCluster, the progress of an
+// orchestration stuck on a step not changing anymore
+func (c *cluster) reportProgress(completed int) {
+	if c.context.Client == nil {
+		return
+	}
+	step := orchestrationCompleted
+	if completed < len(orchestrationSteps) {
+		step = orchestrationSteps[completed]
+	}
+	config.ProgressExport(c.context, types.NamespacedName{Namespace: c.Namespace, Name: c.crdName}, step, completed, len(orchestrationSteps))
+}
+
 func (c *ClusterController) initializeCluster(cluster *cluster, clusterObj *cephv1.CephCluster) error {
 	cluster.Spec = &clusterObj.Spec
+	cluster.annotations = clusterObj.Annotations
+	// The pods generated for the cluster, starting with the version detection job, pull their images with the
+	// secrets of the cluster
+	k8sutil.SetImagePullSecrets(clusterObj.Namespace, clusterObj.Spec.ImagePullSecrets)
 
 	// Check if the dataDirHostPath is located in the disallowed paths list
 	cleanDataDirHostPath := path.Clean(cluster.Spec.DataDirHostPath)
@@ -282,8 +431,12 @@ func (c *ClusterController) configureLocalCephCluster(cluster *cluster, clusterO
 	cluster.isUpgrade = isUpgrade
 
 	// Set the condition to the cluster object
-	message := config.CheckConditionReady(c.context, c.namespacedName)
-	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, "ClusterProgressing", message)
+	if isUpgrade {
+		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionUpgrading, v1.ConditionTrue, "ClusterUpgrading", fmt.Sprintf("Cluster is upgrading to ceph version %s", cephVersion.String()))
+	} else {
+		message := config.CheckConditionReady(c.context, c.namespacedName)
+		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionTrue, "ClusterProgressing", message)
+	}
 
 	// Run the orchestration
 	err = cluster.createInstance(c.rookImage, *cephVersion)
@@ -357,12 +510,27 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster, clusterO
 		logger.Warningf("mon count should be at least 1, will use default value of %d", mon.DefaultMonCount)
 		cluster.Spec.Mon.Count = mon.DefaultMonCount
 	}
-	if cluster.Spec.Mon.Count%2 == 0 {
-		logger.Warningf("mon count is even (given: %d), should be uneven, continuing", cluster.Spec.Mon.Count)
+	if err := cephv1.ValidateMonCount(*cluster.Spec); err != nil {
+		return err
+	}
+	if err := cephv1.ValidateResourceLimits(*cluster.Spec); err != nil {
+		return err
 	}
+	c.reportSpecWarnings(clusterObj)
 	if len(cluster.Spec.Storage.Directories) != 0 {
 		logger.Warning("running osds on directory is not supported anymore, use devices instead.")
 	}
+	if err := kms.ValidateConnectionDetails(c.context, cluster.Namespace, cluster.Spec.Security.KeyManagementService); err != nil {
+		return errors.Wrap(err, "failed to validate the kms settings")
+	}
+	if cluster.Spec.Security.KeyRotation.Enabled {
+		if _, err := cluster.Spec.Security.KeyRotation.GetPeriod(); err != nil {
+			return errors.Wrap(err, "failed to validate the key rotation settings")
+		}
+	}
+	if err := osd.ValidateTopologyMapping(cluster.Spec.Storage.TopologyMapping); err != nil {
+		return errors.Wrap(err, "failed to validate the topology mapping of the storage")
+	}
 	if cluster.Spec.Network.IsMultus() {
 		_, isPublic := cluster.Spec.Network.Selectors[config.PublicNetworkSelectorKeyName]
 		_, isCluster := cluster.Spec.Network.Selectors[config.ClusterNetworkSelectorKeyName]
@@ -378,13 +546,18 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster, clusterO
 			}
 
 			// Get network attachment definition
-			_, err := c.context.NetworkClient.NetworkAttachmentDefinitions(cluster.Namespace).Get(cluster.Spec.Network.Selectors[selector], metav1.GetOptions{})
+			netDefinition, err := c.context.NetworkClient.NetworkAttachmentDefinitions(cluster.Namespace).Get(cluster.Spec.Network.Selectors[selector], metav1.GetOptions{})
 			if err != nil {
 				if kerrors.IsNotFound(err) {
 					return errors.Wrapf(err, "specified network attachment definition for selector %q does not exist", selector)
 				}
 				return errors.Wrapf(err, "failed to fetch network attachment definition for selector %q", selector)
 			}
+
+			// The ceph network of the selector is the subnet of the network attachment definition
+			if _, err := config.NetworkAttachmentSubnet(*netDefinition); err != nil {
+				return errors.Wrapf(err, "invalid network attachment definition for selector %q", selector)
+			}
 		}
 	}
 
@@ -392,6 +565,34 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster, clusterO
 	return nil
 }
 
+// reportSpecWarnings records the warnings of the cluster settings in the CephCluster status, the admission response of
+// this controller-runtime version not carrying them
+func (c *ClusterController) reportSpecWarnings(clusterObj *cephv1.CephCluster) {
+	if c.client == nil || clusterObj.Name == "" {
+		return
+	}
+	warnings := cephv1.Warnings(*clusterObj)
+	for _, warning := range warnings {
+		logger.Warningf("cephcluster %q: %s", clusterObj.Name, warning)
+	}
+	if len(warnings) == 0 {
+		warnings = nil
+	}
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.client.Get(gocontext.TODO(), types.NamespacedName{Namespace: clusterObj.Namespace, Name: clusterObj.Name}, cephCluster); err != nil {
+		logger.Warningf("failed to get cluster %q to report the warnings of its settings. %v", clusterObj.Name, err)
+		return
+	}
+	if reflect.DeepEqual(cephCluster.Status.Warnings, warnings) {
+		return
+	}
+	cephCluster.Status.Warnings = warnings
+	if err := controller.UpdateStatus(c.client, cephCluster); err != nil {
+		logger.Warningf("failed to report the warnings of the settings of cluster %q. %v", clusterObj.Name, err)
+	}
+}
+
 // postMonStartupActions is a collection of actions to run once the monitors are up and running
 // It gets executed right after the main mon Start() method
 // Basically, it is executed between the monitors and the manager sequence
@@ -18,14 +18,24 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/config"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -33,6 +43,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// externalRefreshInterval is how often the connection info of an external cluster is reloaded
+var externalRefreshInterval = time.Minute
+
 func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error {
 	// Make sure the spec contains all the information we need
 	err := validateExternalClusterSpec(cluster)
@@ -46,21 +59,8 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 	// then populate clusterInfo
 	cluster.Info = mon.PopulateExternalClusterInfo(c.context, c.namespacedName.Namespace)
 
-	// If the user to check the ceph health and status is not the admin,
-	// we validate that ExternalCred has been populated correctly,
-	// then we check if the key (whether admin or not) is encoded in base64
-	if !mon.IsExternalHealthCheckUserAdmin(cluster.Info.AdminSecret) {
-		if !cluster.Info.IsInitializedExternalCred(true) {
-			return errors.New("invalid user health checker credentials")
-		}
-		if !cephconfig.IsKeyringBase64Encoded(cluster.Info.ExternalCred.Secret) {
-			return errors.Errorf("invalid user health checker key %q", cluster.Info.ExternalCred.Username)
-		}
-	} else {
-		// If the client.admin is used
-		if !cephconfig.IsKeyringBase64Encoded(cluster.Info.AdminSecret) {
-			return errors.Errorf("invalid user health checker key %q", client.AdminUsername)
-		}
+	if err := validateExternalClusterCred(cluster.Info); err != nil {
+		return err
 	}
 
 	// Write connection info (ceph config file and keyring) for ceph commands
@@ -96,6 +96,11 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 		}
 	}
 
+	// The consumers of the external cluster rely on its version, detected on every reconcile to notice its upgrades
+	if err := c.detectExternalCephVersion(cluster); err != nil {
+		return err
+	}
+
 	// The cluster Identity must be established at this point
 	if !cluster.Info.IsInitialized(true) {
 		return errors.New("the cluster identity was not established")
@@ -132,6 +137,11 @@ func (c *ClusterController) configureExternalCephCluster(cluster *cluster) error
 		}
 	}
 
+	// Expose the metrics of the mgrs of the external cluster
+	if err := c.configureExternalClusterMonitoring(cluster); err != nil {
+		logger.Errorf("failed to configure the monitoring of the external cluster. %v", err)
+	}
+
 	// Everything went well so let's update the CR's status to "connected"
 	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionConnected, v1.ConditionTrue, "ClusterConnected", "Cluster connected successfully")
 
@@ -172,6 +182,184 @@ func purgeExternalCluster(clientset kubernetes.Interface, namespace string) {
 	}
 }
 
+// detectExternalCephVersion detects the version of the mons of the external cluster and records it in the status of
+// the CephCluster, refusing the versions too old to connect to
+func (c *ClusterController) detectExternalCephVersion(cluster *cluster) error {
+	version, err := client.GetCephMonVersion(c.context, cluster.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to detect the ceph version of the external cluster")
+	}
+	if !version.IsAtLeast(cephver.MinimumExternal) {
+		return errors.Errorf("unsupported external cluster ceph version %q, need at least %q", version.String(), cephver.MinimumExternal.String())
+	}
+	cluster.Info.CephVersion = *version
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.client.Get(context.TODO(), c.namespacedName, cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to get cluster %q to update its external ceph version", c.namespacedName.Name)
+	}
+	versionLabel := opcontroller.GetCephVersionLabel(*version)
+	if cephCluster.Status.ExternalCephVersion == versionLabel {
+		return nil
+	}
+	logger.Infof("cluster %q: external cluster ceph version %q detected", c.namespacedName.Namespace, version.String())
+	cephCluster.Status.ExternalCephVersion = versionLabel
+	if err := opcontroller.UpdateStatus(c.client, cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to update cluster %q external ceph version", c.namespacedName.Name)
+	}
+	return nil
+}
+
+// validateExternalClusterCred validates the credentials of the user checking the health of the external cluster
+func validateExternalClusterCred(clusterInfo *cephconfig.ClusterInfo) error {
+	// If the user to check the ceph health and status is not the admin,
+	// we validate that ExternalCred has been populated correctly,
+	// then we check if the key (whether admin or not) is encoded in base64
+	if !mon.IsExternalHealthCheckUserAdmin(clusterInfo.AdminSecret) {
+		if !clusterInfo.IsInitializedExternalCred(true) {
+			return errors.New("invalid user health checker credentials")
+		}
+		if !cephconfig.IsKeyringBase64Encoded(clusterInfo.ExternalCred.Secret) {
+			return errors.Errorf("invalid user health checker key %q", clusterInfo.ExternalCred.Username)
+		}
+	} else {
+		// If the client.admin is used
+		if !cephconfig.IsKeyringBase64Encoded(clusterInfo.AdminSecret) {
+			return errors.Errorf("invalid user health checker key %q", client.AdminUsername)
+		}
+	}
+	return nil
+}
+
+// startExternalClusterRefresh starts the goroutine refreshing the connection info of the external cluster, once
+func (c *ClusterController) startExternalClusterRefresh(cluster *cluster) {
+	if !cluster.Spec.External.Enable || cluster.externalRefreshRunning {
+		return
+	}
+	cluster.externalRefreshRunning = true
+
+	logger.Infof("enabling the refresh of the external cluster connection info for cluster %q every %s", cluster.Namespace, externalRefreshInterval.String())
+	c.goMonitoring(func() {
+		for {
+			select {
+			case <-cluster.stopCh:
+				logger.Infof("stopping the refresh of the external cluster connection info for cluster %q", cluster.Namespace)
+				return
+			case <-time.After(externalRefreshInterval):
+				if err := c.refreshExternalCluster(cluster); err != nil {
+					logger.Errorf("failed to refresh the connection info of external cluster %q. %v", cluster.Namespace, err)
+				}
+			}
+		}
+	})
+}
+
+// refreshExternalCluster reloads the connection info of the external cluster from its secrets and configmap, and
+// updates the config of the operator and of the daemons connecting to the cluster when its mons or keys changed
+func (c *ClusterController) refreshExternalCluster(cluster *cluster) error {
+	clusterInfo, _, _, err := mon.LoadClusterInfo(c.context, cluster.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to load the external cluster info")
+	}
+	if mon.IsExternalHealthCheckUserAdmin(clusterInfo.AdminSecret) {
+		clusterInfo.ExternalCred = cephconfig.ExternalCred{Username: client.AdminUsername, Secret: clusterInfo.AdminSecret}
+	} else {
+		clusterInfo.ExternalCred, err = mon.ValidateAndLoadExternalClusterSecrets(c.context, cluster.Namespace)
+		if err != nil {
+			return errors.Wrap(err, "failed to load the external cluster credentials")
+		}
+	}
+	if err := validateExternalClusterCred(clusterInfo); err != nil {
+		return err
+	}
+
+	monsChanged := !reflect.DeepEqual(monEndpoints(cluster.Info.Monitors), monEndpoints(clusterInfo.Monitors))
+	keysChanged := cluster.Info.AdminSecret != clusterInfo.AdminSecret || cluster.Info.ExternalCred != clusterInfo.ExternalCred
+	if !monsChanged && !keysChanged {
+		logger.Debugf("connection info of external cluster %q not changed", cluster.Namespace)
+		return nil
+	}
+	if len(clusterInfo.Monitors) == 0 {
+		return errors.New("no mon endpoints found for the external cluster")
+	}
+
+	logger.Infof("connection info of external cluster %q changed (mons changed: %t, keys changed: %t). mons=%+v", cluster.Namespace, monsChanged, keysChanged, monEndpoints(clusterInfo.Monitors))
+	cluster.Info.Monitors = clusterInfo.Monitors
+	cluster.Info.AdminSecret = clusterInfo.AdminSecret
+	cluster.Info.ExternalCred = clusterInfo.ExternalCred
+
+	// The ceph commands of the operator read the connection config
+	if err := mon.WriteConnectionConfig(c.context, cluster.Info); err != nil {
+		return errors.Wrap(err, "failed to write the connection config")
+	}
+
+	// The daemons managed in the external cluster read the mons from the config store when they start
+	if cluster.Spec.CephVersion.Image != "" {
+		err = config.GetStore(c.context, cluster.Namespace, &cluster.ownerRef).CreateOrUpdate(cluster.Info)
+		if err != nil {
+			return errors.Wrap(err, "failed to update the global config")
+		}
+	}
+
+	// The CSI drivers read their secrets and the mons of their config map on every request, they are not restarted
+	if keysChanged && cluster.Info.AdminSecret != mon.AdminSecretName {
+		if err := csi.CreateCSISecrets(c.context, cluster.Namespace, &cluster.ownerRef); err != nil {
+			return errors.Wrap(err, "failed to update csi kubernetes secrets")
+		}
+	}
+	if monsChanged {
+		if err := csi.SaveClusterConfig(c.context.Clientset, cluster.Namespace, cluster.Info, c.csiConfigMutex); err != nil {
+			return errors.Wrap(err, "failed to update csi cluster config")
+		}
+
+		if cluster.Spec.CephVersion.Image != "" {
+			selector := fmt.Sprintf("%s=%s", k8sutil.AppAttr, object.AppName)
+			if err := k8sutil.RestartDeployments(c.context.Clientset, cluster.Namespace, selector); err != nil {
+				return errors.Wrap(err, "failed to restart the rgw pods")
+			}
+		}
+	}
+
+	// The status checker runs its commands with the user
+	if keysChanged {
+		c.StartMonitoring(cluster, cluster.Info.ExternalCred.Username)
+	}
+
+	return nil
+}
+
+// monEndpoints returns the endpoints of the mons by name
+func monEndpoints(monitors map[string]*cephconfig.MonInfo) map[string]string {
+	endpoints := map[string]string{}
+	for name, monitor := range monitors {
+		endpoints[name] = monitor.Endpoint
+	}
+	return endpoints
+}
+
+// configureExternalClusterMonitoring creates the metrics service of the mgr endpoints of the external cluster, the
+// remote mgrs not being reachable from the service of the local mgrs
+func (c *ClusterController) configureExternalClusterMonitoring(cluster *cluster) error {
+	mgrs := mgr.New(cluster.Info,
+		c.context,
+		cluster.Namespace,
+		"",
+		cluster.Spec.CephVersion,
+		rookv1.Placement{},
+		rookv1.Annotations{},
+		cluster.Spec.Network,
+		cluster.Spec.Dashboard,
+		cluster.Spec.Monitoring,
+		cluster.Spec.Mgr,
+		v1.ResourceRequirements{},
+		"",
+		cluster.ownerRef,
+		cluster.Spec.DataDirHostPath,
+		cluster.Spec.SkipUpgradeChecks,
+		cluster.Spec.HealthCheck)
+	return mgrs.ConfigureExternalMetricsEndpoint()
+}
+
 func validateExternalClusterSpec(cluster *cluster) error {
 	if cluster.Spec.CephVersion.Image != "" {
 		if cluster.Spec.DataDirHostPath == "" {
@@ -17,11 +17,28 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
+	"io/ioutil"
+	"os"
+	"sync"
 	"testing"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestValidateExternalClusterSpec(t *testing.T) {
@@ -37,3 +54,145 @@ func TestValidateExternalClusterSpec(t *testing.T) {
 	err = validateExternalClusterSpec(c)
 	assert.NoError(t, err, err)
 }
+
+func TestRefreshExternalCluster(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "external")
+	assert.NoError(t, err)
+	defer os.RemoveAll(configDir)
+	clientset := test.New(t, 1)
+	context := &clusterd.Context{Clientset: clientset, Executor: &exectest.MockExecutor{}, ConfigDir: configDir}
+	namespace := "rook-ceph"
+	key := "AQCvzWBeIV9lFRAAninzm+8XFxbSfTiPwoX50g=="
+	rotatedKey := "AQBvzWBeIV9lFRAAninzm+8XFxbSfTiPwoX50g=="
+
+	monSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.AppName, Namespace: namespace},
+		Data: map[string][]byte{
+			"cluster-name": []byte(namespace),
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("mon-secret"),
+			"admin-secret": []byte(mon.AdminSecretName),
+		},
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Create(monSecret)
+	assert.NoError(t, err)
+	credsSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.OperatorCreds, Namespace: namespace},
+		Data:       map[string][]byte{"userID": []byte("client.healthchecker"), "userKey": []byte(key)},
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Create(credsSecret)
+	assert.NoError(t, err)
+	endpoints := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.EndpointConfigMapName, Namespace: namespace},
+		Data:       map[string]string{mon.EndpointDataKey: "a=10.0.0.1:6789"},
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(endpoints)
+	assert.NoError(t, err)
+	rgw := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-rgw-my-store-a", Namespace: namespace, Labels: map[string]string{k8sutil.AppAttr: "rook-ceph-rgw"}},
+	}
+	_, err = clientset.AppsV1().Deployments(namespace).Create(rgw)
+	assert.NoError(t, err)
+
+	cluster := &cluster{
+		Namespace: namespace,
+		Spec: &cephv1.ClusterSpec{
+			External:    cephv1.ExternalSpec{Enable: true},
+			CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15"},
+			HealthCheck: cephv1.CephClusterHealthCheckSpec{Paused: true},
+		},
+		Info: &cephconfig.ClusterInfo{
+			Name:          namespace,
+			FSID:          "fsid",
+			MonitorSecret: "mon-secret",
+			AdminSecret:   mon.AdminSecretName,
+			ExternalCred:  cephconfig.ExternalCred{Username: "client.healthchecker", Secret: key},
+			Monitors:      map[string]*cephconfig.MonInfo{"a": {Name: "a", Endpoint: "10.0.0.1:6789"}},
+		},
+		monitoringChannels: map[string]*clusterHealth{},
+	}
+	c := &ClusterController{context: context, csiConfigMutex: &sync.Mutex{}}
+
+	// Nothing changed
+	err = c.refreshExternalCluster(cluster)
+	assert.NoError(t, err)
+	d, err := clientset.AppsV1().Deployments(namespace).Get(rgw.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, d.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation])
+
+	// The mons changed, the rgw pods are restarted
+	endpoints.Data[mon.EndpointDataKey] = "a=10.0.0.1:6789,b=10.0.0.2:6789"
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Update(endpoints)
+	assert.NoError(t, err)
+	err = c.refreshExternalCluster(cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.2:6789", cluster.Info.Monitors["b"].Endpoint)
+	d, err = clientset.AppsV1().Deployments(namespace).Get(rgw.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, d.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation])
+
+	// The key of the health checker was rotated
+	credsSecret.Data["userKey"] = []byte(rotatedKey)
+	_, err = clientset.CoreV1().Secrets(namespace).Update(credsSecret)
+	assert.NoError(t, err)
+	err = c.refreshExternalCluster(cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, rotatedKey, cluster.Info.ExternalCred.Secret)
+
+	// An invalid key is not applied
+	credsSecret.Data["userKey"] = []byte("invalid")
+	_, err = clientset.CoreV1().Secrets(namespace).Update(credsSecret)
+	assert.NoError(t, err)
+	err = c.refreshExternalCluster(cluster)
+	assert.Error(t, err)
+	assert.Equal(t, rotatedKey, cluster.Info.ExternalCred.Secret)
+}
+
+func TestDetectExternalCephVersion(t *testing.T) {
+	version := "ceph version 14.2.8 (3a54b2b6d167d4a2a19e003a705696d4fe619afc) nautilus (stable)"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "version" {
+				return version, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	namespacedName := types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace}}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	c := &ClusterController{
+		context:        &clusterd.Context{Executor: executor},
+		client:         fake.NewFakeClientWithScheme(s, cephCluster),
+		namespacedName: namespacedName,
+	}
+	cluster := &cluster{Namespace: namespacedName.Namespace, Info: &cephconfig.ClusterInfo{}}
+
+	err := c.detectExternalCephVersion(cluster)
+	assert.NoError(t, err)
+	assert.Equal(t, 14, cluster.Info.CephVersion.Major)
+	err = c.client.Get(context.TODO(), namespacedName, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "14.2.8-0", cephCluster.Status.ExternalCephVersion)
+
+	// The consumers read the version of the external cluster
+	cephCluster.Spec.External.Enable = true
+	clusterVersion, err := opcontroller.GetClusterCephVersion(*cephCluster)
+	assert.NoError(t, err)
+	assert.True(t, clusterVersion.IsNautilus())
+
+	// The upgrades of the external cluster are recorded
+	version = "ceph version 15.2.4 (7447c15c6ff58d7fce91843b705a268a1917325c) octopus (stable)"
+	err = c.detectExternalCephVersion(cluster)
+	assert.NoError(t, err)
+	err = c.client.Get(context.TODO(), namespacedName, cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "15.2.4-0", cephCluster.Status.ExternalCephVersion)
+
+	// Too old
+	version = "ceph version 10.2.11 (e4b061b47f07f583c92a050d9e84b1813a35671e) jewel (stable)"
+	err = c.detectExternalCephVersion(cluster)
+	assert.Error(t, err)
+	assert.Equal(t, 15, cluster.Info.CephVersion.Major)
+}
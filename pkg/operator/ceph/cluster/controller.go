@@ -21,6 +21,8 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -29,21 +31,25 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
-	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/log"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -65,6 +71,8 @@ const (
 	clusterDeleteMaxRetries    = 15
 	disableHotplugEnv          = "ROOK_DISABLE_DEVICE_HOTPLUG"
 	minStoreResyncPeriod       = 10 * time.Hour // the minimum duration for forced Store resyncs.
+	// reconcileRequestsBufferSize is the number of reconciles the health checkers can request before they are queued
+	reconcileRequestsBufferSize = 10
 )
 
 var (
@@ -94,13 +102,31 @@ type ClusterController struct {
 	volumeAttachment        attachment.Attachment
 	rookImage               string
 	clusterMap              map[string]*cluster
+	clusterMapMux           sync.RWMutex
 	operatorConfigCallbacks []func() error
 	addClusterCallbacks     []func() error
 	csiConfigMutex          *sync.Mutex
 	nodeStore               cache.Store
-	osdChecker              *osd.OSDHealthMonitor
 	client                  client.Client
 	namespacedName          types.NamespacedName
+	// reconcileLogger logs the lines of the current reconcile with the cluster and the reconcile ID
+	reconcileLogger log.Logger
+	recorder        record.EventRecorder
+	// bucketProvisionerMux guards the owner and the stop channel of the cluster-wide bucket provisioner
+	bucketProvisionerMux sync.Mutex
+	// bucketProvisionerOwner is the namespace of the cluster the cluster-wide bucket provisioner runs for, if started
+	bucketProvisionerOwner  string
+	bucketProvisionerStopCh chan struct{}
+	// leaderElections runs the bucket and client provisioners in a single replica of the operator
+	leaderElections *k8sutil.LeaderElections
+	// activeMonitoringGoroutines is the number of health goroutines running across all the clusters
+	activeMonitoringGoroutines int32
+	// monitoringGoroutines tracks the health, key rotation and external refresh goroutines of all the clusters
+	monitoringGoroutines sync.WaitGroup
+	// healthTransitionCallbacks are called when a cluster enters or leaves HEALTH_ERR
+	healthTransitionCallbacks []func(old, new CephHealthSummary)
+	// reconcileRequests queues a reconcile of a CephCluster, e.g. to provision the osds again from the health checker
+	reconcileRequests chan event.GenericEvent
 }
 
 // ReconcileCephCluster reconciles a CephFilesystem object
@@ -114,7 +140,7 @@ type ReconcileCephCluster struct {
 // Add creates a new CephCluster Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager, context *clusterd.Context, clusterController *ClusterController) error {
-	return add(mgr, newReconciler(mgr, context, clusterController), context)
+	return add(mgr, newReconciler(mgr, context, clusterController), context, clusterController.reconcileRequests)
 }
 
 // newReconciler returns a new reconcile.Reconciler
@@ -123,6 +149,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, clusterContro
 	mgrScheme := mgr.GetScheme()
 	cephv1.AddToScheme(mgr.GetScheme())
 
+	// Events are emitted on the CephCluster by the monitoring goroutines
+	clusterController.recorder = mgr.GetEventRecorderFor(controllerName)
+
 	return &ReconcileCephCluster{
 		client:            mgr.GetClient(),
 		scheme:            mgrScheme,
@@ -131,9 +160,9 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context, clusterContro
 	}
 }
 
-func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context, reconcileRequests <-chan event.GenericEvent) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -152,6 +181,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context)
 		return err
 	}
 
+	// Watch for the reconciles requested by the health checkers
+	err = c.Watch(&source.Channel{Source: reconcileRequests}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
 	// Watch all other resources of the Ceph Cluster
 	for _, t := range objectsToWatch {
 		err = c.Watch(
@@ -222,10 +257,13 @@ func add(mgr manager.Manager, r reconcile.Reconciler, context *clusterd.Context)
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephCluster) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephCluster", request).With(log.ClusterField, request.Name)
+	r.clusterController.reconcileLogger = reconcileLogger
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile. %v", err)
+		reconcileLogger.Errorf("failed to reconcile. %v", err)
 	}
 
 	return reconcileResponse, err
@@ -246,7 +284,7 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 	err := r.client.Get(context.TODO(), request.NamespacedName, cephCluster)
 	if err != nil {
 		if kerrors.IsNotFound(err) {
-			logger.Debug("cephCluster resource not found. Ignoring since object must be deleted.")
+			r.clusterController.reconcileLogger.Debug("cephCluster resource not found. Ignoring since object must be deleted.")
 			return reconcile.Result{}, nil
 		}
 		// Error reading the object - requeue the request.
@@ -261,10 +299,18 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 
 	// DELETE: the CR was deleted
 	if !cephCluster.GetDeletionTimestamp().IsZero() {
-		logger.Infof("deleting ceph cluster %q", cephCluster.Name)
+		r.clusterController.reconcileLogger.Infof("deleting ceph cluster %q", cephCluster.Name)
+
+		// Run delete sequence
+		response, ok := r.clusterController.requestClusterDelete(cephCluster)
+		if !ok {
+			// If the cluster cannot be deleted, requeue the request for deletion to see if the conditions
+			// will eventually be satisfied such as the volumes and the dependents being removed
+			return response, nil
+		}
 
-		// Start cluster clean up only if cleanupPolicy is applied to the ceph cluster
-		stopCleanupCh := make(chan struct{})
+		// Start cluster clean up only if cleanupPolicy is applied to the ceph cluster. The hosts are only cleaned
+		// up once the deletion is no longer blocked, after the daemons are gone.
 		if cephCluster.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
 			// Set the deleting status
 			updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeleting)
@@ -277,16 +323,7 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 			if err != nil {
 				return reconcile.Result{}, errors.Wrapf(err, "failed to find valid ceph hosts in the cluster %q", cephCluster.Namespace)
 			}
-			go r.clusterController.startClusterCleanUp(stopCleanupCh, cephCluster, cephHosts, monSecret, clusterFSID)
-		}
-
-		// Run delete sequence
-		response, ok := r.clusterController.requestClusterDelete(cephCluster)
-		if !ok {
-			// If the cluster cannot be deleted, requeue the request for deletion to see if the conditions
-			// will eventually be satisfied such as the volumes being removed
-			close(stopCleanupCh)
-			return response, nil
+			go r.clusterController.startClusterCleanUp(make(chan struct{}), cephCluster, cephHosts, monSecret, clusterFSID)
 		}
 
 		// Remove finalizer
@@ -305,6 +342,13 @@ func (r *ReconcileCephCluster) reconcile(request reconcile.Request) (reconcile.R
 		return reconcile.Result{}, errors.Wrapf(err, "failed to get controller %q owner reference", cephCluster.Name)
 	}
 
+	// PAUSED: the admins took manual control of the cluster
+	if isReconcilePaused(&cephCluster.Spec, cephCluster.Annotations) {
+		r.clusterController.pauseCluster(cephCluster)
+		return reconcile.Result{}, nil
+	}
+	r.clusterController.resumeCluster(cephCluster)
+
 	// Do reconcile here!
 	if err := r.clusterController.onAdd(cephCluster, ref); err != nil {
 		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile cluster %q", cephCluster.Name)
@@ -323,17 +367,39 @@ func NewClusterController(context *clusterd.Context, rookImage string, volumeAtt
 		clusterMap:              make(map[string]*cluster),
 		operatorConfigCallbacks: operatorConfigCallbacks,
 		addClusterCallbacks:     addClusterCallbacks,
-		csiConfigMutex:          &sync.Mutex{},
+		csiConfigMutex:          csi.ConfigMutex,
+		reconcileRequests:       make(chan event.GenericEvent, reconcileRequestsBufferSize),
+		leaderElections:         k8sutil.NewLeaderElections(context.Clientset, os.Getenv(k8sutil.PodNamespaceEnvVar)),
 	}
 }
 
+// LeaderElections returns the leader elections of the provisioners of the clusters, whose health is served by the
+// health endpoints of the operator
+func (c *ClusterController) LeaderElections() *k8sutil.LeaderElections {
+	return c.leaderElections
+}
+
+// OnHealthTransition registers a callback called when the health of a cluster enters or leaves HEALTH_ERR, e.g. to run
+// a custom remediation. The callback runs in the status checker goroutine and must not block.
+// It only applies to the status checkers started after the registration.
+func (c *ClusterController) OnHealthTransition(callback func(old, new CephHealthSummary)) {
+	c.healthTransitionCallbacks = append(c.healthTransitionCallbacks, callback)
+}
+
 func (c *ClusterController) onAdd(clusterObj *cephv1.CephCluster, ref *metav1.OwnerReference) error {
 	if clusterObj.Spec.CleanupPolicy.HasDataDirCleanPolicy() {
-		logger.Infof("skipping orchestration for cluster object %q in namespace %q because its cleanup policy is set", clusterObj.Name, clusterObj.Namespace)
+		c.reconcileLogger.Infof("skipping orchestration for cluster object %q in namespace %q because its cleanup policy is set", clusterObj.Name, clusterObj.Namespace)
 		return nil
 	}
 
-	cluster, ok := c.clusterMap[clusterObj.Namespace]
+	cluster, ok := c.getCluster(clusterObj.Namespace)
+	if ok && cluster.uid != "" && cluster.uid != clusterObj.UID {
+		// the CephCluster was recreated without its deletion being seen, the goroutines of the previous one must stop
+		c.reconcileLogger.Infof("cluster %q in namespace %q was recreated, stopping the goroutines of the previous cluster", clusterObj.Name, clusterObj.Namespace)
+		c.StopMonitoring(cluster)
+		cluster.stop()
+		ok = false
+	}
 	if !ok {
 		// It's a new cluster so let's populate the struct
 		cluster = newCluster(clusterObj, c.context, c.csiConfigMutex, ref)
@@ -343,12 +409,12 @@ func (c *ClusterController) onAdd(clusterObj *cephv1.CephCluster, ref *metav1.Ow
 	// this scope as the clusterMap is authoritative on cluster count and thus involved in the check for CSI resource
 	// deletion. If we ever add additional callback functions, we should tighten this lock.
 	c.csiConfigMutex.Lock()
-	c.clusterMap[cluster.Namespace] = cluster
-	logger.Infof("reconciling ceph cluster in namespace %q", cluster.Namespace)
+	c.setCluster(cluster)
+	c.reconcileLogger.Infof("reconciling ceph cluster in namespace %q", cluster.Namespace)
 
 	for _, callback := range c.addClusterCallbacks {
 		if err := callback(); err != nil {
-			logger.Errorf("%v", err)
+			c.reconcileLogger.Errorf("%v", err)
 		}
 	}
 	c.csiConfigMutex.Unlock()
@@ -357,33 +423,96 @@ func (c *ClusterController) onAdd(clusterObj *cephv1.CephCluster, ref *metav1.Ow
 	return c.initializeCluster(cluster, clusterObj)
 }
 
+// getCluster returns the cluster reconciled in the namespace
+func (c *ClusterController) getCluster(namespace string) (*cluster, bool) {
+	c.clusterMapMux.RLock()
+	defer c.clusterMapMux.RUnlock()
+	cluster, ok := c.clusterMap[namespace]
+	return cluster, ok
+}
+
+// getClusters returns the clusters reconciled, sorted by namespace
+func (c *ClusterController) getClusters() []*cluster {
+	c.clusterMapMux.RLock()
+	defer c.clusterMapMux.RUnlock()
+	clusters := []*cluster{}
+	for _, cluster := range c.clusterMap {
+		clusters = append(clusters, cluster)
+	}
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Namespace < clusters[j].Namespace })
+	return clusters
+}
+
+// setCluster records the cluster reconciled in its namespace
+func (c *ClusterController) setCluster(cluster *cluster) {
+	c.clusterMapMux.Lock()
+	defer c.clusterMapMux.Unlock()
+	c.clusterMap[cluster.Namespace] = cluster
+}
+
+// deleteCluster forgets about the cluster, unless another cluster was recorded in its namespace since then
+func (c *ClusterController) deleteCluster(cluster *cluster) {
+	c.clusterMapMux.Lock()
+	defer c.clusterMapMux.Unlock()
+	if c.clusterMap[cluster.Namespace] == cluster {
+		delete(c.clusterMap, cluster.Namespace)
+	}
+}
+
 func (c *ClusterController) requestClusterDelete(cluster *cephv1.CephCluster) (reconcile.Result, bool) {
 	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionDeleting, v1.ConditionTrue, "ClusterDeleting", "Cluster is deleting")
 
-	if existing, ok := c.clusterMap[cluster.Namespace]; ok && existing.crdName != cluster.Name {
-		logger.Errorf("skipping deletion of cluster cr %q in namespace %q. cluster CR %q already exists in this namespace. only one cluster cr per namespace is supported.",
+	existing, ok := c.getCluster(cluster.Namespace)
+	if ok && existing.crdName != cluster.Name {
+		c.reconcileLogger.Errorf("skipping deletion of cluster cr %q in namespace %q. cluster CR %q already exists in this namespace. only one cluster cr per namespace is supported.",
 			cluster.Name, cluster.Namespace, existing.crdName)
 		return reconcile.Result{}, true
 	}
 
-	logger.Infof("delete event for cluster %q in namespace %q", cluster.Name, cluster.Namespace)
+	c.reconcileLogger.Infof("delete event for cluster %q in namespace %q", cluster.Name, cluster.Namespace)
 
-	if cluster, ok := c.clusterMap[cluster.Namespace]; ok && !cluster.closedStopCh {
-		// close the goroutines watching the health of the cluster (mons, osds, ceph status, etc)
-		close(cluster.stopCh)
-		cluster.closedStopCh = true
-	}
+	// The teardown is ordered: the consumers of the cluster are removed first, while the mons are still monitored
+	// and reachable so that the pools, filesystems and object stores can be deleted from ceph. Only then the health
+	// checkers are stopped and the daemons deleted, the hosts being cleaned up last once the daemons are gone.
+	// The volumes and the dependents of the cluster are not checked if the deletion is forced, their data is then lost
+	if opcontroller.IsForceDeletion(cluster) {
+		c.reconcileLogger.Warningf("force deleting cluster %q in namespace %q regardless of its volumes and dependents", cluster.Name, cluster.Namespace)
+	} else {
+		err := c.checkIfVolumesExist(cluster)
+		if err != nil {
+			message := fmt.Sprintf("CephCluster %q will not be deleted until its volumes are removed", cluster.Name)
+			config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionDeletionIsBlocked, v1.ConditionTrue, "VolumesExist", message)
+			c.reconcileLogger.Errorf("cannot delete cluster. %v", err)
+			return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+		}
 
-	err := c.checkIfVolumesExist(cluster)
-	if err != nil {
-		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionDeleting, v1.ConditionTrue, "ClusterDeleting", "Failed to delete cluster")
-		logger.Errorf("cannot delete cluster. %v", err)
-		return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+		dependents, err := c.clusterDependents(cluster.Namespace)
+		if err != nil {
+			c.reconcileLogger.Errorf("cannot delete cluster. failed to check its dependents. %v", err)
+			return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+		}
+		if len(dependents) > 0 {
+			message := fmt.Sprintf("CephCluster %q will not be deleted until its dependents are removed: %s", cluster.Name, strings.Join(dependents, ", "))
+			config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionDeletionIsBlocked, v1.ConditionTrue, "ObjectHasDependents", message)
+			c.reconcileLogger.Errorf("%s. set the %q annotation to delete the cluster regardless", message, opcontroller.ForceDeletionAnnotation)
+			return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+		}
 	}
 
-	if cluster, ok := c.clusterMap[cluster.Namespace]; ok {
-		delete(c.clusterMap, cluster.Namespace)
+	// only the goroutines of the deleted CephCluster are stopped, not those of a CephCluster recreated since then
+	if ok && existing.uid != "" && existing.uid != cluster.UID {
+		c.reconcileLogger.Infof("cluster %q in namespace %q was recreated, not stopping the goroutines of the new cluster", cluster.Name, cluster.Namespace)
+		ok = false
+	}
+	if ok {
+		// close the goroutines watching the health of the cluster (mons, osds, ceph status, etc)
+		c.StopMonitoring(existing)
+		existing.stop()
+		c.deleteCluster(existing)
+		c.stopBucketProvisioner(existing)
+		cephclient.CloseRadosConnections(cluster.Namespace)
 	}
+	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionDeleting, v1.ConditionTrue, "DeletingDaemons", "Cluster has no dependents left, deleting the ceph daemons")
 
 	// Only valid when the cluster is not external
 	if cluster.Spec.External.Enable {
@@ -394,6 +523,47 @@ func (c *ClusterController) requestClusterDelete(cluster *cephv1.CephCluster) (r
 	return reconcile.Result{}, true
 }
 
+// clusterDependents returns the Ceph CRs consuming the cluster of the namespace, such as "CephBlockPool replicapool"
+func (c *ClusterController) clusterDependents(namespace string) ([]string, error) {
+	dependentLists := map[string]runtime.Object{
+		"CephBlockPool":                &cephv1.CephBlockPoolList{},
+		"CephBlockPoolRadosNamespace":  &cephv1.CephBlockPoolRadosNamespaceList{},
+		"CephFilesystem":               &cephv1.CephFilesystemList{},
+		"CephFilesystemSubVolumeGroup": &cephv1.CephFilesystemSubVolumeGroupList{},
+		"CephObjectStore":              &cephv1.CephObjectStoreList{},
+		"CephObjectStoreUser":          &cephv1.CephObjectStoreUserList{},
+		"CephObjectZone":               &cephv1.CephObjectZoneList{},
+		"CephNFS":                      &cephv1.CephNFSList{},
+		"CephClient":                   &cephv1.CephClientList{},
+		"CephRBDMirror":                &cephv1.CephRBDMirrorList{},
+		"CephFilesystemMirror":         &cephv1.CephFilesystemMirrorList{},
+		"CephBucketTopic":              &cephv1.CephBucketTopicList{},
+		"CephBucketNotification":       &cephv1.CephBucketNotificationList{},
+		"CephCOSIDriver":               &cephv1.CephCOSIDriverList{},
+	}
+
+	dependents := []string{}
+	for kind, list := range dependentLists {
+		if err := c.client.List(context.TODO(), list, client.InNamespace(namespace)); err != nil {
+			return nil, errors.Wrapf(err, "failed to list %s CRs", kind)
+		}
+		objects, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read %s CRs", kind)
+		}
+		for _, object := range objects {
+			accessor, err := meta.Accessor(object)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read %s CR", kind)
+			}
+			dependents = append(dependents, fmt.Sprintf("%s %s", kind, accessor.GetName()))
+		}
+	}
+
+	sort.Strings(dependents)
+	return dependents, nil
+}
+
 func (c *ClusterController) checkIfVolumesExist(cluster *cephv1.CephCluster) error {
 	if csi.CSIEnabled() {
 		err := c.csiVolumesAllowForDeletion(cluster)
@@ -17,17 +17,24 @@ limitations under the License.
 package cluster
 
 import (
+	"context"
 	"os"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	rookalpha "github.com/rook/rook/pkg/apis/rook.io/v1alpha2"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestClusterDeleteFlexEnabled(t *testing.T) {
@@ -142,3 +149,108 @@ func TestClusterDeleteFlexDisabled(t *testing.T) {
 	// Ensure that the listing of volume attachments was never called.
 	assert.Equal(t, 0, listCount)
 }
+
+func TestClusterDependents(t *testing.T) {
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	otherPool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "otherpool", Namespace: "other-ceph"}}
+	store := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"}}
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{ObjectMeta: metav1.ObjectMeta{Name: "namespace-a", Namespace: "rook-ceph"}}
+	subVolumeGroup := &cephv1.CephFilesystemSubVolumeGroup{ObjectMeta: metav1.ObjectMeta{Name: "group-a", Namespace: "rook-ceph"}}
+	cosiDriver := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "ceph-cosi-driver", Namespace: "rook-ceph"}}
+	c := &ClusterController{client: fake.NewFakeClientWithScheme(scheme.Scheme)}
+
+	// no dependents
+	dependents, err := c.clusterDependents("rook-ceph")
+	assert.NoError(t, err)
+	assert.Empty(t, dependents)
+
+	// only the dependents in the namespace of the cluster are returned
+	c.client = fake.NewFakeClientWithScheme(scheme.Scheme, pool, otherPool, store)
+	dependents, err = c.clusterDependents("rook-ceph")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CephBlockPool replicapool", "CephObjectStore my-store"}, dependents)
+
+	// the rados namespaces, subvolume groups and cosi drivers depend on the cluster too
+	c.client = fake.NewFakeClientWithScheme(scheme.Scheme, radosNamespace, subVolumeGroup, cosiDriver)
+	dependents, err = c.clusterDependents("rook-ceph")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"CephBlockPoolRadosNamespace namespace-a", "CephCOSIDriver ceph-cosi-driver", "CephFilesystemSubVolumeGroup group-a"}, dependents)
+}
+
+func TestDeleteClusterAfterDependents(t *testing.T) {
+	os.Setenv("ROOK_ENABLE_FLEX_DRIVER", "false")
+	defer os.Unsetenv("ROOK_ENABLE_FLEX_DRIVER")
+	nsName := types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph", UID: "uid"}}
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, cephCluster.DeepCopy(), pool)
+	clusterContext := &clusterd.Context{Client: client}
+	c := &ClusterController{context: clusterContext, client: client, clusterMap: make(map[string]*cluster), namespacedName: nsName}
+	existing := &cluster{Namespace: "rook-ceph", crdName: "rook-ceph", uid: "uid", stopCh: make(chan struct{})}
+	c.setCluster(existing)
+
+	// the deletion is blocked by the pool, the cluster is still monitored so that the pool can be deleted from ceph
+	_, ok := c.requestClusterDelete(cephCluster)
+	assert.False(t, ok)
+	assert.False(t, existing.closedStopCh)
+	_, found := c.getCluster("rook-ceph")
+	assert.True(t, found)
+	updated := &cephv1.CephCluster{}
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.True(t, isConditionTrue(updated, cephv1.ConditionDeletionIsBlocked))
+
+	// once the pool is gone the cluster goroutines are stopped and the daemons deleted
+	assert.NoError(t, client.Delete(context.TODO(), pool))
+	_, ok = c.requestClusterDelete(cephCluster)
+	assert.True(t, ok)
+	assert.True(t, existing.closedStopCh)
+	_, found = c.getCluster("rook-ceph")
+	assert.False(t, found)
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.Equal(t, cephv1.ConditionDeleting, updated.Status.Phase)
+}
+
+func TestPreClusterStartValidationMonCount(t *testing.T) {
+	c := &ClusterController{context: &clusterd.Context{}}
+	validate := func(mon cephv1.MonSpec) (*cluster, error) {
+		cluster := &cluster{Namespace: "rook-ceph", Spec: &cephv1.ClusterSpec{Mon: mon}}
+		return cluster, c.preClusterStartValidation(cluster, &cephv1.CephCluster{})
+	}
+
+	// the default count is used when not set
+	cluster, err := validate(cephv1.MonSpec{})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, cluster.Spec.Mon.Count)
+
+	// the counts rejected by the admission controller are rejected by the operator too
+	_, err = validate(cephv1.MonSpec{Count: 4})
+	assert.Error(t, err)
+	_, err = validate(cephv1.MonSpec{Count: 11})
+	assert.Error(t, err)
+	_, err = validate(cephv1.MonSpec{Count: 2, AllowMultiplePerNode: true})
+	assert.NoError(t, err)
+}
+
+func TestReportSpecWarnings(t *testing.T) {
+	nsName := types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"},
+		Spec:       cephv1.ClusterSpec{Storage: rookv1.StorageScopeSpec{Selection: rookv1.Selection{DeviceFilter: "^sd."}}},
+	}
+	client := fake.NewFakeClientWithScheme(scheme.Scheme, cephCluster.DeepCopy())
+	c := &ClusterController{client: client}
+
+	// the daemons without requests are reported in the status
+	c.reportSpecWarnings(cephCluster)
+	updated := &cephv1.CephCluster{}
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.Equal(t, 3, len(updated.Status.Warnings))
+	assert.Contains(t, updated.Status.Warnings[0], "resources:mon has no requests")
+
+	// the warnings are cleared once the requests are set
+	requests := corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")}}
+	cephCluster.Spec.Resources = rookv1.ResourceSpec{"mon": requests, "mgr": requests, "osd": requests}
+	c.reportSpecWarnings(cephCluster)
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.Empty(t, updated.Status.Warnings)
+}
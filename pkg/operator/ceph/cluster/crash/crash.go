@@ -113,12 +113,18 @@ func (r *ReconcileNode) createOrUpdateCephCrash(node corev1.Node, tolerations []
 				Containers: []corev1.Container{
 					getCrashDaemonContainer(cephCluster, *cephVersion),
 				},
-				Tolerations:   tolerations,
-				RestartPolicy: corev1.RestartPolicyAlways,
-				HostNetwork:   cephCluster.Spec.Network.IsHost(),
-				Volumes:       volumes,
+				Tolerations:       tolerations,
+				RestartPolicy:     corev1.RestartPolicyAlways,
+				HostNetwork:       cephCluster.Spec.Network.IsHost(),
+				Volumes:           volumes,
+				PriorityClassName: cephv1.GetCrashCollectorPriorityClassName(cephCluster.Spec.PriorityClassNames),
+				ImagePullSecrets:  k8sutil.GetImagePullSecrets(cephCluster.Namespace),
 			},
 		}
+		controller.ApplyDaemonOverrides(&deploy.Spec.Template.Spec, cephCluster.Spec.Env, cephCluster.Spec.VolumeMounts, cephv1.KeyCrashCollector)
+		cephv1.GetCrashCollectorAnnotations(cephCluster.Spec.Annotations).ApplyToObjectMeta(&deploy.ObjectMeta)
+		cephv1.GetCrashCollectorAnnotations(cephCluster.Spec.Annotations).ApplyToObjectMeta(&deploy.Spec.Template.ObjectMeta)
+		cephv1.GetCrashCollectorLabels(cephCluster.Spec.Labels).ApplyToObjectMeta(&deploy.Spec.Template.ObjectMeta)
 
 		return nil
 	}
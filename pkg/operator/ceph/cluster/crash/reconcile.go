@@ -135,6 +135,7 @@ func (r *ReconcileNode) reconcile(request reconcile.Request) (reconcile.Result,
 		if len(cephClusters.Items) > 1 {
 			logger.Errorf("more than one CephCluster found in the namespace %q, choosing the first one %q", namespace, cephCluster.GetName())
 		}
+		k8sutil.SetImagePullSecrets(namespace, cephCluster.Spec.ImagePullSecrets)
 
 		// If the crash controller is disabled in the spec let's do a noop
 		if cephCluster.Spec.CrashCollector.Disable {
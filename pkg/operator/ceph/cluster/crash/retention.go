@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+// retainIntervalOption is the number of seconds the mgr crash module keeps the crash reports
+const retainIntervalOption = "mgr/crash/retain_interval"
+
+// ConfigureRetention sets how long the crash reports are kept by the mgr crash module, or restores the ceph
+// default when the retention is not set
+func ConfigureRetention(context *clusterd.Context, namespace string, spec cephv1.CrashCollectorSpec) error {
+	monStore := config.GetMonStore(context, namespace)
+	if spec.DaysToRetain == 0 {
+		if err := monStore.Delete("mgr", retainIntervalOption); err != nil {
+			return errors.Wrap(err, "failed to reset the retention of the crash reports")
+		}
+		return nil
+	}
+
+	interval := time.Duration(spec.DaysToRetain) * 24 * time.Hour
+	if err := monStore.Set("mgr", retainIntervalOption, strconv.Itoa(int(interval.Seconds()))); err != nil {
+		return errors.Wrapf(err, "failed to keep the crash reports for %d days", spec.DaysToRetain)
+	}
+	logger.Debugf("crash reports of cluster %q are kept for %d days", namespace, spec.DaysToRetain)
+	return nil
+}
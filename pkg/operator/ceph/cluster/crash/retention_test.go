@@ -0,0 +1,48 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package crash
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureRetention(t *testing.T) {
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			commands = append(commands, strings.Join(args[:4], " "))
+			if args[1] == "set" {
+				commands[len(commands)-1] += " " + args[4]
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	assert.NoError(t, ConfigureRetention(context, "ns", cephv1.CrashCollectorSpec{DaysToRetain: 7}))
+	assert.NoError(t, ConfigureRetention(context, "ns", cephv1.CrashCollectorSpec{}))
+	assert.Equal(t, []string{
+		"config set mgr mgr/crash/retain_interval 604800",
+		"config rm mgr mgr/crash/retain_interval",
+	}, commands)
+}
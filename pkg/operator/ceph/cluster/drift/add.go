@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	appsv1 "k8s.io/api/apps/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// Add adds a controller by kind of the objects whose drift is handled
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	for _, kind := range managedKinds {
+		if err := add(mgr, context, kind); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func add(mgr manager.Manager, context *clusterd.Context, kind managedKind) error {
+	controllerName := fmt.Sprintf("ceph-%s-drift-controller", strings.ToLower(kind.kind))
+	r := &ReconcileDrift{
+		client:   mgr.GetClient(),
+		context:  context,
+		recorder: mgr.GetEventRecorderFor(controllerName),
+		kind:     kind,
+	}
+
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return errors.Wrapf(err, "failed to create a new %q", controllerName)
+	}
+	logger.Infof("%s successfully started", controllerName)
+
+	// Only the changes of the objects applied by the operator are reconciled. The objects seen at the start of the
+	// operator are not, the changes made while the operator was stopped being left to the reconcile of the cluster.
+	changePredicate := predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !isManaged(e.ObjectNew) {
+				return false
+			}
+			// the status of the deployments is updated by their pods, only their spec changes bump their generation
+			if _, ok := e.ObjectNew.(*appsv1.Deployment); ok {
+				return e.MetaOld.GetGeneration() != e.MetaNew.GetGeneration()
+			}
+			return e.MetaOld.GetResourceVersion() != e.MetaNew.GetResourceVersion()
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return isManaged(e.Object)
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+	err = c.Watch(&source.Kind{Type: kind.newObject()}, &handler.EnqueueRequestForObject{}, changePredicate)
+	if err != nil {
+		return errors.Wrapf(err, "failed to watch for %s changes", strings.ToLower(kind.kind))
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package drift reverts or reports the changes made out of band to the deployments, services and configmaps of the
+// clusters, as soon as they are seen rather than on the next reconcile of the cluster.
+package drift
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/util/log"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	driftRevertedReason = "DriftReverted"
+	driftDetectedReason = "DriftDetected"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-drift-controller")
+
+// managedKind is a kind of the objects created by the operator whose drift is handled
+type managedKind struct {
+	kind      string
+	newObject func() runtime.Object
+	patch     func(clientset kubernetes.Interface, namespace, name string, data []byte) error
+}
+
+var managedKinds = []managedKind{
+	{
+		kind:      "Deployment",
+		newObject: func() runtime.Object { return &appsv1.Deployment{} },
+		patch: func(clientset kubernetes.Interface, namespace, name string, data []byte) error {
+			_, err := clientset.AppsV1().Deployments(namespace).Patch(name, types.StrategicMergePatchType, data)
+			return err
+		},
+	},
+	{
+		kind:      "Service",
+		newObject: func() runtime.Object { return &corev1.Service{} },
+		patch: func(clientset kubernetes.Interface, namespace, name string, data []byte) error {
+			_, err := clientset.CoreV1().Services(namespace).Patch(name, types.StrategicMergePatchType, data)
+			return err
+		},
+	},
+	{
+		kind:      "ConfigMap",
+		newObject: func() runtime.Object { return &corev1.ConfigMap{} },
+		patch: func(clientset kubernetes.Interface, namespace, name string, data []byte) error {
+			_, err := clientset.CoreV1().ConfigMaps(namespace).Patch(name, types.StrategicMergePatchType, data)
+			return err
+		},
+	},
+}
+
+// changes returns the patch reverting the fields of the object changed since the operator applied it, nil when none
+// changed. Only the fields applied by the operator are compared, the fields defaulted or added by others being left
+// alone. The metadata, the status and the replicas of the deployments, scaled down during the maintenances, are ignored.
+func (k managedKind) changes(current runtime.Object) ([]byte, error) {
+	original, err := patch.DefaultAnnotator.GetOriginalConfiguration(current)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the last applied configuration")
+	}
+	if len(original) == 0 {
+		return nil, nil
+	}
+	applied := k.newObject()
+	if err := json.Unmarshal(original, applied); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the last applied configuration")
+	}
+
+	result, err := patch.DefaultPatchMaker.Calculate(current, applied)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to calculate the changes")
+	}
+	if result.IsEmpty() {
+		return nil, nil
+	}
+
+	changes := map[string]interface{}{}
+	if err := json.Unmarshal(result.Patch, &changes); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the changes")
+	}
+	delete(changes, "metadata")
+	delete(changes, "status")
+	if spec, ok := changes["spec"].(map[string]interface{}); ok && k.kind == "Deployment" {
+		delete(spec, "replicas")
+		if len(spec) == 0 {
+			delete(changes, "spec")
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return json.Marshal(changes)
+}
+
+// isManaged returns whether the object was applied by the operator for a ceph custom resource
+func isManaged(obj runtime.Object) bool {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	owned := false
+	for _, ref := range accessor.GetOwnerReferences() {
+		if ref.APIVersion == cephv1.CustomResourceGroup+"/"+cephv1.Version {
+			owned = true
+			break
+		}
+	}
+	if !owned {
+		return false
+	}
+	original, err := patch.DefaultAnnotator.GetOriginalConfiguration(obj)
+	return err == nil && len(original) > 0
+}
+
+// ReconcileDrift reverts or reports the changes made out of band to the objects of a kind
+type ReconcileDrift struct {
+	client   client.Client
+	context  *clusterd.Context
+	recorder record.EventRecorder
+	kind     managedKind
+}
+
+// Reconcile compares an object with its last applied configuration and handles its changes with the drift policy of
+// the cluster in its namespace
+func (r *ReconcileDrift) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileLogger := opcontroller.ReconcileLogger(logger, r.kind.kind, request)
+	reconcileLogger.Debug("reconciling")
+	result, err := r.reconcile(request, reconcileLogger)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile. %v", err)
+	}
+	return result, err
+}
+
+func (r *ReconcileDrift) reconcile(request reconcile.Request, reconcileLogger log.Logger) (reconcile.Result, error) {
+	cephClusters := &cephv1.CephClusterList{}
+	if err := r.client.List(context.TODO(), cephClusters, client.InNamespace(request.Namespace)); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to list the ceph clusters in namespace %q", request.Namespace)
+	}
+	if len(cephClusters.Items) == 0 {
+		return reconcile.Result{}, nil
+	}
+	cephCluster := &cephClusters.Items[0]
+	if cephCluster.DeletionTimestamp != nil {
+		return reconcile.Result{}, nil
+	}
+	// the admins took manual control of the cluster, the changes are theirs
+	if cephCluster.Spec.ReconcileStrategy == cephv1.ReconcileStrategyPaused || cephCluster.Annotations[opcontroller.PausedAnnotation] == "true" {
+		reconcileLogger.Debug("reconcile of the cluster is paused, ignoring the changes")
+		return reconcile.Result{}, nil
+	}
+
+	current := r.kind.newObject()
+	if err := r.client.Get(context.TODO(), request.NamespacedName, current); err != nil {
+		if kerrors.IsNotFound(err) {
+			return reconcile.Result{}, r.setDriftedResource(cephCluster, request.Name, nil)
+		}
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get %s %q", r.kind.kind, request.Name)
+	}
+	accessor, err := meta.Accessor(current)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get the metadata of %s %q", r.kind.kind, request.Name)
+	}
+	if opcontroller.IsDoNotReconcile(accessor.GetLabels()) {
+		reconcileLogger.Debugf("%s has the \"do_not_reconcile\" label, ignoring the changes", r.kind.kind)
+		return reconcile.Result{}, nil
+	}
+
+	var changes []byte
+	if cephCluster.Spec.DriftPolicy != "" && isManaged(current) {
+		changes, err = r.kind.changes(current)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to compare %s %q with its last applied configuration", r.kind.kind, request.Name)
+		}
+	}
+	if changes == nil {
+		return reconcile.Result{}, r.setDriftedResource(cephCluster, request.Name, nil)
+	}
+
+	if cephCluster.Spec.DriftPolicy == cephv1.DriftPolicyRevert {
+		reconcileLogger.Infof("reverting the changes made out of band: %s", string(changes))
+		if err := r.kind.patch(r.context.Clientset, request.Namespace, request.Name, changes); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to revert the changes of %s %q", r.kind.kind, request.Name)
+		}
+		r.recorder.Eventf(cephCluster, corev1.EventTypeNormal, driftRevertedReason, "reverted the changes made out of band to %s %s", r.kind.kind, request.Name)
+		return reconcile.Result{}, r.setDriftedResource(cephCluster, request.Name, nil)
+	}
+
+	reconcileLogger.Warningf("changes made out of band: %s", string(changes))
+	return reconcile.Result{}, r.setDriftedResource(cephCluster, request.Name, changes)
+}
+
+// setDriftedResource updates the drifted resources in the status of the cluster, a warning event being recorded when
+// an object is first reported
+func (r *ReconcileDrift) setDriftedResource(cephCluster *cephv1.CephCluster, name string, changes []byte) error {
+	resources := setDriftedResource(cephCluster.Status.DriftedResources, r.kind.kind, name, string(changes), time.Now())
+	if reflect.DeepEqual(resources, cephCluster.Status.DriftedResources) {
+		return nil
+	}
+	if changes != nil && findDriftedResource(cephCluster.Status.DriftedResources, r.kind.kind, name) < 0 {
+		r.recorder.Eventf(cephCluster, corev1.EventTypeWarning, driftDetectedReason, "%s %s was changed out of band", r.kind.kind, name)
+	}
+	cephCluster.Status.DriftedResources = resources
+	if err := opcontroller.UpdateStatus(r.client, cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to update the drifted resources of cluster %q", cephCluster.Name)
+	}
+	return nil
+}
+
+func findDriftedResource(resources []cephv1.DriftedResource, kind, name string) int {
+	for i, resource := range resources {
+		if resource.Kind == kind && resource.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// setDriftedResource returns the drifted resources with the changes of an object, the object being removed when it
+// has no changes. The time the object was first reported is kept.
+func setDriftedResource(resources []cephv1.DriftedResource, kind, name, changes string, now time.Time) []cephv1.DriftedResource {
+	i := findDriftedResource(resources, kind, name)
+	updated := []cephv1.DriftedResource{}
+	for j, resource := range resources {
+		if j != i {
+			updated = append(updated, resource)
+		} else if changes != "" {
+			resource.Changes = changes
+			updated = append(updated, resource)
+		}
+	}
+	if i < 0 && changes != "" {
+		updated = append(updated, cephv1.DriftedResource{Kind: kind, Name: name, Since: now.UTC().Format(time.RFC3339), Changes: changes})
+	}
+	if len(updated) == 0 {
+		return nil
+	}
+	return updated
+}
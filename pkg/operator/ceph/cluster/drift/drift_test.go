@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package drift
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestChanges(t *testing.T) {
+	replicas := int32(1)
+	d := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "rook-ceph-mgr-a",
+			Namespace:       "rook-ceph",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "ceph.rook.io/v1", Kind: "CephCluster", Name: "rook-ceph"}},
+		},
+	}
+	d.Spec.Replicas = &replicas
+	d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "mgr", Image: "ceph/ceph:v15.2.4"}}
+	assert.NoError(t, patch.DefaultAnnotator.SetLastAppliedAnnotation(d))
+	assert.True(t, isManaged(d))
+	kind := managedKinds[0]
+
+	// nothing changed
+	changes, err := kind.changes(d)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+
+	// the replicas are scaled down during the maintenances
+	scaled := d.DeepCopy()
+	replicas = 0
+	scaled.Spec.Replicas = &replicas
+	changes, err = kind.changes(scaled)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+
+	// the image is reverted
+	changed := d.DeepCopy()
+	changed.Spec.Template.Spec.Containers[0].Image = "ceph/ceph:v15.2.5"
+	changes, err = kind.changes(changed)
+	assert.NoError(t, err)
+	assert.True(t, strings.Contains(string(changes), "ceph/ceph:v15.2.4"))
+
+	// the objects not applied by the operator are left alone
+	other := changed.DeepCopy()
+	other.OwnerReferences = nil
+	assert.False(t, isManaged(other))
+	other.Annotations = nil
+	changes, err = kind.changes(other)
+	assert.NoError(t, err)
+	assert.Nil(t, changes)
+}
+
+func TestSetDriftedResource(t *testing.T) {
+	first := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	later := first.Add(time.Hour)
+
+	resources := setDriftedResource(nil, "Deployment", "rook-ceph-mgr-a", "a", first)
+	assert.Equal(t, []cephv1.DriftedResource{{Kind: "Deployment", Name: "rook-ceph-mgr-a", Since: "2020-10-01T00:00:00Z", Changes: "a"}}, resources)
+
+	// the first time the object was reported is kept
+	resources = setDriftedResource(resources, "Service", "rook-ceph-mgr", "b", later)
+	resources = setDriftedResource(resources, "Deployment", "rook-ceph-mgr-a", "c", later)
+	assert.Equal(t, 2, len(resources))
+	assert.Equal(t, "2020-10-01T00:00:00Z", resources[0].Since)
+	assert.Equal(t, "c", resources[0].Changes)
+	assert.Equal(t, "Service", resources[1].Kind)
+
+	// the objects without changes are removed
+	resources = setDriftedResource(resources, "Deployment", "rook-ceph-mgr-a", "", later)
+	resources = setDriftedResource(resources, "Service", "rook-ceph-mgr", "", later)
+	assert.Nil(t, resources)
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"strings"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	// maxHealthDetails is the number of detail messages of a health check kept in the CR status, a check such as
+	// PG_DEGRADED listing every PG
+	maxHealthDetails = 10
+
+	healthChecksDocURL = "https://docs.ceph.com/en/latest/rados/operations/health-checks/#%s"
+)
+
+// healthRemediations are the hints reported in the CR status for the most common health checks
+var healthRemediations = map[string]string{
+	"MON_DOWN":                 "Check the mon pods and their nodes, the operator fails over a mon down longer than the mon health check timeout",
+	"MON_CLOCK_SKEW":           "Synchronize the clocks of the nodes running the mons with NTP",
+	"MON_DISK_LOW":             "Free up space on the mon data dir under dataDirHostPath or the PVs of the mons",
+	"MON_DISK_CRIT":            "Free up space on the mon data dir under dataDirHostPath or the PVs of the mons before the mons shut down",
+	"MGR_DOWN":                 "Check the mgr pod and its logs",
+	"OSD_DOWN":                 "Check the osd pods and their nodes, the osds are marked out after mon_osd_down_out_interval",
+	"OSD_HOST_DOWN":            "Check the node of the osds and its network",
+	"OSD_NEARFULL":             "Add osds or delete data before the osds are full, see the usage with 'ceph osd df'",
+	"OSD_BACKFILLFULL":         "Add osds or delete data, the data is no longer rebalanced to the backfillfull osds",
+	"OSD_FULL":                 "Add osds or delete data, the writes to the cluster are blocked",
+	"POOL_NEARFULL":            "Raise the quota of the pool or delete data from the pool",
+	"POOL_FULL":                "Raise the quota of the pool or delete data from the pool, its writes are blocked",
+	"PG_AVAILABILITY":          "Check the osds down with 'ceph osd tree', the IO to the inactive PGs is blocked",
+	"PG_DEGRADED":              "Wait for the recovery or bring back the osds down, the data has fewer replicas than requested",
+	"PG_DAMAGED":               "Repair the inconsistent PGs with 'ceph pg repair' after checking 'rados list-inconsistent-obj'",
+	"OSD_SCRUB_ERRORS":         "Repair the inconsistent PGs with 'ceph pg repair' after checking 'rados list-inconsistent-obj'",
+	"TOO_FEW_PGS":              "Raise the pg count of the pools or enable the pg autoscaler with pgAutoscaleMode",
+	"POOL_TOO_FEW_PGS":         "Raise the pg count of the pool or enable the pg autoscaler with pgAutoscaleMode",
+	"TOO_MANY_PGS":             "Add osds or lower the pg count of the pools",
+	"SLOW_OPS":                 "Check the osds and mons reported, a slow disk or network usually causes slow operations",
+	"POOL_NO_REDUNDANCY":       "Set a replica size greater than 1 on the pool unless the data can be lost",
+	"RECENT_CRASH":             "Inspect the crashes with 'ceph crash ls' and archive them with 'ceph crash archive-all'",
+	"MDS_CLIENT_RECALL":        "Evict the clients failing to release their caps, named in the message",
+	"FS_DEGRADED":              "Check the mds pods of the filesystem",
+	"MDS_ALL_DOWN":             "Check the mds pods of the filesystem, the filesystem is offline",
+	"MDS_INSUFFICIENT_STANDBY": "Raise the activeCount or enable activeStandby in the metadata server settings of the filesystem",
+	"AUTH_INSECURE_GLOBAL_ID_RECLAIM_ALLOWED": "Disable auth_allow_insecure_global_id_reclaim once all the clients are updated",
+}
+
+// healthRemediation returns the hint to resolve a health check, by default a link to the ceph documentation of the check
+func healthRemediation(code string) string {
+	if remediation, ok := healthRemediations[code]; ok {
+		return remediation
+	}
+	return fmt.Sprintf("See "+healthChecksDocURL, strings.ToLower(code))
+}
+
+// addHealthDetails adds the detail messages of the health checks to the CR status, at most maxHealthDetails per check
+func addHealthDetails(status *cephv1.CephStatus, health *cephclient.HealthStatus) {
+	if health == nil {
+		return
+	}
+	for code, check := range health.Checks {
+		message, ok := status.Details[code]
+		if !ok || len(check.Detail) == 0 {
+			continue
+		}
+		message.Detail = []string{}
+		for i, detail := range check.Detail {
+			if i == maxHealthDetails {
+				message.Detail = append(message.Detail, fmt.Sprintf("... and %d more", len(check.Detail)-maxHealthDetails))
+				break
+			}
+			message.Detail = append(message.Detail, detail.Message)
+		}
+		status.Details[code] = message
+	}
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthRemediation(t *testing.T) {
+	assert.Contains(t, healthRemediation("OSD_NEARFULL"), "Add osds")
+	assert.Equal(t, "See https://docs.ceph.com/en/latest/rados/operations/health-checks/#pool_app_not_enabled", healthRemediation("POOL_APP_NOT_ENABLED"))
+}
+
+func TestAddHealthDetails(t *testing.T) {
+	healthDetail := `{"status":"HEALTH_WARN","checks":{
+		"MON_DISK_LOW":{"severity":"HEALTH_WARN","summary":{"message":"mon a is low on available space"},"detail":[{"message":"mon.a has 20% avail"}]},
+		"OSD_NEARFULL":{"severity":"HEALTH_WARN","summary":{"message":"1 nearfull osd(s)"}}}}`
+	status := &cephclient.CephStatus{}
+	assert.NoError(t, json.Unmarshal([]byte(healthDetail), &status.Health))
+	crStatus := toCustomResourceStatus(cephv1.ClusterStatus{}, status)
+
+	// the details are optional, the remediation is set for every check
+	addHealthDetails(crStatus, nil)
+	assert.Nil(t, crStatus.Details["MON_DISK_LOW"].Detail)
+	assert.Contains(t, crStatus.Details["MON_DISK_LOW"].Remediation, "Free up space")
+
+	addHealthDetails(crStatus, &status.Health)
+	assert.Equal(t, []string{"mon.a has 20% avail"}, crStatus.Details["MON_DISK_LOW"].Detail)
+	assert.Equal(t, "HEALTH_WARN", crStatus.Details["MON_DISK_LOW"].Severity)
+	assert.Nil(t, crStatus.Details["OSD_NEARFULL"].Detail)
+
+	// the details of a check are truncated
+	degraded := cephclient.CheckMessage{Severity: "HEALTH_WARN"}
+	for i := 0; i < 25; i++ {
+		degraded.Detail = append(degraded.Detail, struct {
+			Message string `json:"message"`
+		}{Message: fmt.Sprintf("pg 1.%d is active+undersized+degraded", i)})
+	}
+	status.Health.Checks["PG_DEGRADED"] = degraded
+	crStatus = toCustomResourceStatus(cephv1.ClusterStatus{}, status)
+	addHealthDetails(crStatus, &status.Health)
+	details := crStatus.Details["PG_DEGRADED"].Detail
+	assert.Equal(t, maxHealthDetails+1, len(details))
+	assert.Equal(t, "pg 1.0 is active+undersized+degraded", details[0])
+	assert.Equal(t, "... and 15 more", details[maxHealthDetails])
+}
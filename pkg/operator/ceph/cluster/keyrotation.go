@@ -0,0 +1,384 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/ceph/file/mds"
+	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
+	"github.com/rook/rook/pkg/operator/ceph/nfs"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// keyRotationConfigMapName is the configmap recording the last rotation of the keys
+	keyRotationConfigMapName = "rook-ceph-key-rotation"
+	lastRotationKey          = "lastRotation"
+	rotationRequestKey       = "rotationRequest"
+	keyringSecretSuffix      = "-keyring"
+	keyringSecretKey         = "keyring"
+)
+
+var (
+	// keyRotationCheckInterval is how often the goroutine checks whether the keys are due for a rotation
+	keyRotationCheckInterval = time.Hour
+
+	// keyRotationRestartOrder is the order the daemons are restarted in once their keys are rotated, the daemons of
+	// the other deployments mounting a rotated keyring being restarted last
+	keyRotationRestartOrder = []string{mgr.AppName, mds.AppName, mirror.AppName, object.AppName, rbd.AppName, nfs.AppName, crash.AppName}
+
+	// keyRotationDaemonTypes are the ceph daemon types of the apps whose daemons are checked before and after their
+	// restart, the other deployments being restarted without checks
+	keyRotationDaemonTypes = map[string]string{
+		mgr.AppName:    config.MgrType,
+		mds.AppName:    config.MdsType,
+		mirror.AppName: config.FilesystemMirrorType,
+		object.AppName: config.RgwType,
+		rbd.AppName:    config.RbdMirrorType,
+		nfs.AppName:    "nfs",
+	}
+
+	// updateDeploymentAndWait can be overridden for unit tests. Do not alter this for runtime operation.
+	updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
+)
+
+// isRotatedEntity gets whether the key of the ceph user is rotated with the daemon keyrings. The key of the admin is
+// rotated on its own, the keys of the mons and the osds are kept since they are stored with their data, and the
+// bootstrap keys are only used to create the daemons.
+func isRotatedEntity(entity string) bool {
+	return entity != "mon." &&
+		entity != client.AdminUsername &&
+		!strings.HasPrefix(entity, "osd.") &&
+		!strings.HasPrefix(entity, "client.bootstrap")
+}
+
+// rotateKeysIfNeeded rotates the keys of the cluster when the annotation of the CephCluster requests a new rotation
+// or when the period of the scheduled rotation has elapsed since the last rotation
+func (c *cluster) rotateKeysIfNeeded() error {
+	c.keyRotationMux.Lock()
+	defer c.keyRotationMux.Unlock()
+
+	configMap, err := c.getStateConfigMap(keyRotationConfigMapName)
+	if err != nil {
+		return err
+	}
+	_, started := configMap.Data[lastRotationKey]
+	due, err := c.keyRotationDue(configMap.Data, time.Now())
+	if err != nil {
+		return err
+	}
+	if !due {
+		if _, ok := configMap.Data[lastRotationKey]; ok && !started {
+			return c.saveStateConfigMap(configMap)
+		}
+		return nil
+	}
+
+	logger.Infof("rotating the keys of cluster %q", c.Namespace)
+	if err := c.rotateKeys(); err != nil {
+		return err
+	}
+
+	configMap.Data[lastRotationKey] = time.Now().Format(time.RFC3339)
+	configMap.Data[rotationRequestKey] = c.annotations[controller.RotateKeysAnnotation]
+	if err := c.saveStateConfigMap(configMap); err != nil {
+		return err
+	}
+	logger.Infof("rotated the keys of cluster %q", c.Namespace)
+	return nil
+}
+
+// keyRotationDue gets whether the keys must be rotated given the state of the last rotation. When the scheduled
+// rotation is enabled, the time it is first checked is recorded as the start of the period.
+func (c *cluster) keyRotationDue(state map[string]string, now time.Time) (bool, error) {
+	request := c.annotations[controller.RotateKeysAnnotation]
+	if request != "" && request != state[rotationRequestKey] {
+		logger.Infof("keys rotation %q requested for cluster %q", request, c.Namespace)
+		return true, nil
+	}
+
+	keyRotation := c.Spec.Security.KeyRotation
+	if !keyRotation.Enabled {
+		return false, nil
+	}
+	period, err := keyRotation.GetPeriod()
+	if err != nil {
+		return false, err
+	}
+	lastRotation, ok := state[lastRotationKey]
+	if !ok {
+		state[lastRotationKey] = now.Format(time.RFC3339)
+		return false, nil
+	}
+	last, err := time.Parse(time.RFC3339, lastRotation)
+	if err != nil {
+		logger.Warningf("invalid time of the last keys rotation %q, rotating the keys. %v", lastRotation, err)
+		return true, nil
+	}
+	return now.Sub(last) >= period, nil
+}
+
+// rotateKeys rotates the key of the admin first, then the keys of the daemons, restarts the daemons reading the
+// rotated keyrings, and finally rotates the CSI users and restarts the CSI provisioners
+func (c *cluster) rotateKeys() error {
+	if err := c.mons.RotateAdminKey(); err != nil {
+		return errors.Wrap(err, "failed to rotate the admin key")
+	}
+	rotatedSecrets := map[string]bool{
+		keyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef).Admin().SecretName(): true,
+	}
+
+	if err := c.rotateDaemonKeys(rotatedSecrets); err != nil {
+		return errors.Wrap(err, "failed to rotate the daemon keys")
+	}
+
+	if err := c.restartKeyringConsumers(rotatedSecrets); err != nil {
+		return err
+	}
+
+	if err := csi.RotateCSIKeys(c.context, c.Namespace, &c.ownerRef); err != nil {
+		return errors.Wrap(err, "failed to rotate the csi keys")
+	}
+	return nil
+}
+
+// rotateDaemonKeys rotates the keys of the users of the keyring secrets of the daemons and updates the secrets with
+// the new keys, recording the names of the updated secrets
+func (c *cluster) rotateDaemonKeys(rotatedSecrets map[string]bool) error {
+	secrets, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the keyring secrets")
+	}
+
+	// a user found in several keyrings gets a single new key
+	keys := map[string]string{}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		data, ok := secret.Data[keyringSecretKey]
+		if !strings.HasSuffix(secret.Name, keyringSecretSuffix) || !ok {
+			continue
+		}
+
+		rotatedKeyring, changed, err := c.rotateKeyring(string(data), keys)
+		if err != nil {
+			return errors.Wrapf(err, "failed to rotate the keys of secret %q", secret.Name)
+		}
+		if !changed {
+			continue
+		}
+		secret.Data[keyringSecretKey] = []byte(rotatedKeyring)
+		if _, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret); err != nil {
+			return errors.Wrapf(err, "failed to update secret %q with the rotated keys", secret.Name)
+		}
+		rotatedSecrets[secret.Name] = true
+	}
+	return nil
+}
+
+// rotateKeyring replaces the keys of the rotated users of the keyring, returning whether any key was replaced
+func (c *cluster) rotateKeyring(keyringData string, keys map[string]string) (string, bool, error) {
+	lines := strings.Split(keyringData, "\n")
+	entity := ""
+	changed := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			entity = strings.TrimSuffix(strings.TrimPrefix(trimmed, "["), "]")
+			continue
+		}
+		if entity == "" || !isRotatedEntity(entity) || !strings.HasPrefix(trimmed, "key") {
+			continue
+		}
+		if parts := strings.SplitN(trimmed, "=", 2); len(parts) != 2 || strings.TrimSpace(parts[0]) != "key" {
+			continue
+		}
+
+		key, ok := keys[entity]
+		if !ok {
+			var err error
+			key, err = client.AuthRotateKey(c.context, c.Namespace, entity, nil)
+			if err != nil {
+				return "", false, err
+			}
+			keys[entity] = key
+		}
+		indent := line[:strings.Index(line, "key")]
+		lines[i] = indent + "key = " + key
+		changed = true
+	}
+	return strings.Join(lines, "\n"), changed, nil
+}
+
+// restartKeyringConsumers restarts the deployments mounting a rotated keyring secret one at a time, in the order of
+// their daemons, waiting for each deployment to run again before restarting the next one
+func (c *cluster) restartKeyringConsumers(rotatedSecrets map[string]bool) error {
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the deployments to restart")
+	}
+
+	consumers := map[string][]*apps.Deployment{}
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if mountsSecret(d.Spec.Template.Spec, rotatedSecrets) {
+			app := d.Labels[k8sutil.AppAttr]
+			consumers[app] = append(consumers[app], d)
+		}
+	}
+
+	restart := func(app string) error {
+		for _, d := range consumers[app] {
+			if err := c.restartKeyringConsumer(d); err != nil {
+				return errors.Wrapf(err, "failed to restart deployment %q with the rotated keys", d.Name)
+			}
+		}
+		delete(consumers, app)
+		return nil
+	}
+	for _, app := range keyRotationRestartOrder {
+		if err := restart(app); err != nil {
+			return err
+		}
+	}
+	others := []string{}
+	for app := range consumers {
+		others = append(others, app)
+	}
+	sort.Strings(others)
+	for _, app := range others {
+		if err := restart(app); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// restartKeyringConsumer restarts the pods of a deployment and waits for them to run again. The ceph daemons are only
+// stopped once ceph reports they are ok to stop, as during an upgrade.
+func (c *cluster) restartKeyringConsumer(d *apps.Deployment) error {
+	restarted := d.DeepCopy()
+	if restarted.Spec.Template.Annotations == nil {
+		restarted.Spec.Template.Annotations = map[string]string{}
+	}
+	restarted.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation] = time.Now().Format(time.RFC3339)
+	logger.Infof("restarting deployment %q with the rotated keys", d.Name)
+
+	daemonType, ok := keyRotationDaemonTypes[d.Labels[k8sutil.AppAttr]]
+	if !ok {
+		_, err := k8sutil.UpdateDeploymentAndWait(c.context, restarted, c.Namespace, func(action string) error { return nil })
+		return err
+	}
+	return updateDeploymentAndWait(c.context, restarted, c.Namespace, daemonType, d.Labels["ceph_daemon_id"], c.Spec.SkipUpgradeChecks, c.Spec.ContinueUpgradeAfterChecksEvenIfNotHealthy)
+}
+
+// mountsSecret gets whether the pod mounts one of the secrets
+func mountsSecret(spec v1.PodSpec, secrets map[string]bool) bool {
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil && secrets[volume.Secret.SecretName] {
+			return true
+		}
+	}
+	return false
+}
+
+// getStateConfigMap returns the configmap recording the state of an operation of the operator on the cluster,
+// which is created by saveStateConfigMap the first time the state is saved
+func (c *cluster) getStateConfigMap(name string) (*v1.ConfigMap, error) {
+	configMap, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "failed to get configmap %q", name)
+		}
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: c.Namespace,
+			},
+		}
+		k8sutil.SetOwnerRef(&configMap.ObjectMeta, &c.ownerRef)
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
+	return configMap, nil
+}
+
+func (c *cluster) saveStateConfigMap(configMap *v1.ConfigMap) error {
+	if configMap.ResourceVersion == "" {
+		_, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(configMap)
+		if err == nil {
+			return nil
+		}
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create configmap %q", configMap.Name)
+		}
+	}
+	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Update(configMap); err != nil {
+		return errors.Wrapf(err, "failed to update configmap %q", configMap.Name)
+	}
+	return nil
+}
+
+// startKeyRotation starts the goroutine rotating the keys of the cluster once the period of the scheduled rotation
+// has elapsed. The rotation is skipped while an orchestration runs, the orchestration rotating the keys itself.
+func (c *ClusterController) startKeyRotation(cluster *cluster) {
+	if cluster.Spec.External.Enable || !cluster.Spec.Security.KeyRotation.Enabled || cluster.keyRotationRunning {
+		return
+	}
+	cluster.keyRotationRunning = true
+
+	logger.Infof("enabling the rotation of the keys of cluster %q", cluster.Namespace)
+	c.goMonitoring(func() {
+		for {
+			select {
+			case <-cluster.stopCh:
+				logger.Infof("stopping the rotation of the keys of cluster %q", cluster.Namespace)
+				return
+			case <-time.After(keyRotationCheckInterval):
+				if cluster.isOrchestrationRunning() {
+					logger.Debugf("orchestration of cluster %q running, skipping the keys rotation check", cluster.Namespace)
+					continue
+				}
+				if isReconcilePaused(cluster.Spec, cluster.annotations) {
+					logger.Debugf("reconcile of cluster %q paused, skipping the keys rotation check", cluster.Namespace)
+					continue
+				}
+				if err := cluster.rotateKeysIfNeeded(); err != nil {
+					logger.Errorf("failed to rotate the keys of cluster %q. %v", cluster.Namespace, err)
+				}
+			}
+		}
+	})
+}
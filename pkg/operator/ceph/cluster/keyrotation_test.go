@@ -0,0 +1,235 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKeyRotationDue(t *testing.T) {
+	c := &cluster{Namespace: "ns", Spec: &cephv1.ClusterSpec{}}
+	now := time.Now()
+
+	// nothing to rotate
+	state := map[string]string{}
+	due, err := c.keyRotationDue(state, now)
+	assert.NoError(t, err)
+	assert.False(t, due)
+	assert.Empty(t, state)
+
+	// the annotation requests a rotation once for each value
+	c.annotations = map[string]string{controller.RotateKeysAnnotation: "1"}
+	due, err = c.keyRotationDue(state, now)
+	assert.NoError(t, err)
+	assert.True(t, due)
+	state[rotationRequestKey] = "1"
+	due, err = c.keyRotationDue(state, now)
+	assert.NoError(t, err)
+	assert.False(t, due)
+
+	// the scheduled rotation starts its period the first time it is checked
+	c.Spec.Security.KeyRotation = cephv1.KeyRotationSpec{Enabled: true, Period: "24h"}
+	due, err = c.keyRotationDue(state, now)
+	assert.NoError(t, err)
+	assert.False(t, due)
+	assert.Equal(t, now.Format(time.RFC3339), state[lastRotationKey])
+	due, err = c.keyRotationDue(state, now.Add(23*time.Hour))
+	assert.NoError(t, err)
+	assert.False(t, due)
+	due, err = c.keyRotationDue(state, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.True(t, due)
+
+	c.Spec.Security.KeyRotation.Period = "monthly"
+	_, err = c.keyRotationDue(state, now)
+	assert.Error(t, err)
+}
+
+func TestRotateKeys(t *testing.T) {
+	configDir, err := ioutil.TempDir("", "keyrotation")
+	assert.NoError(t, err)
+	defer os.RemoveAll(configDir)
+	namespace := "rook-ceph"
+	clientset := fake.NewSimpleClientset()
+
+	// the keys of the ceph users, replaced on import
+	keys := map[string]string{"client.admin": "adminkey", "mgr.a": "mgrkey", "client.rgw.my.store.a": "rgwkey"}
+	newKeys := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get" {
+				return `[{"entity":"` + args[2] + `","caps":{"mon":"allow profile mgr"}}]`, nil
+			}
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				if _, ok := keys[args[2]]; !ok {
+					keys[args[2]] = "csikey-" + args[2]
+				}
+				return `{"key":"` + keys[args[2]] + `"}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %v", args)
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command == "ceph-authtool" {
+				newKeys++
+				return fmt.Sprintf("newkey%d\n", newKeys), nil
+			}
+			if args[0] == "auth" && args[1] == "import" {
+				content, err := ioutil.ReadFile(args[3])
+				assert.NoError(t, err)
+				lines := strings.Split(string(content), "\n")
+				entity := strings.Trim(lines[0], "[]")
+				assert.Contains(t, string(content), "\tcaps mon = \"allow profile mgr\"\n")
+				keys[entity] = strings.TrimPrefix(lines[1], "\tkey = ")
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected command %s %v", command, args)
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor, ConfigDir: configDir}
+
+	monSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.AppName, Namespace: namespace},
+		Data:       map[string][]byte{mon.AdminSecretName: []byte("adminkey")},
+	}
+	_, err = clientset.CoreV1().Secrets(namespace).Create(monSecret)
+	assert.NoError(t, err)
+	for name, keyring := range map[string]string{
+		"rook-ceph-mgr-a-keyring":           "[mgr.a]\n\tkey = mgrkey\n",
+		"rook-ceph-rgw-my-store-a-keyring":  "[client.rgw.my.store.a]\nkey = rgwkey\n",
+		"rook-ceph-osd-keyring":             "[osd.0]\n\tkey = osdkey\n",
+		"rook-ceph-bootstrap-osd-keyring":   "[client.bootstrap-osd]\n\tkey = bootstrapkey\n",
+		"rook-ceph-unrelated-keyring-token": "[mgr.a]\n\tkey = mgrkey\n",
+	} {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Data:       map[string][]byte{"keyring": []byte(keyring)},
+		}
+		_, err = clientset.CoreV1().Secrets(namespace).Create(secret)
+		assert.NoError(t, err)
+	}
+	deployment := func(name, app, id, secret string) {
+		d := &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: map[string]string{k8sutil.AppAttr: app, "ceph_daemon_id": id}},
+		}
+		d.Spec.Template.Spec.Volumes = []v1.Volume{{Name: "keyring", VolumeSource: v1.VolumeSource{Secret: &v1.SecretVolumeSource{SecretName: secret}}}}
+		_, err := clientset.AppsV1().Deployments(namespace).Create(d)
+		assert.NoError(t, err)
+	}
+	deployment("rook-ceph-rgw-my-store-a", "rook-ceph-rgw", "my-store-a", "rook-ceph-rgw-my-store-a-keyring")
+	deployment("rook-ceph-mgr-a", "rook-ceph-mgr", "a", "rook-ceph-mgr-a-keyring")
+	deployment("rook-ceph-osd-0", "rook-ceph-osd", "0", "rook-ceph-osd-keyring")
+	// the daemons are restarted one at a time after the ok-to-stop checks
+	defer func() { updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait }()
+	var restartedDaemons []string
+	stub, restarted := testopk8s.UpdateDeploymentAndWaitStub()
+	updateDeploymentAndWait = func(context *clusterd.Context, d *apps.Deployment, namespace, daemonType, daemonName string, skipUpgradeChecks, continueUpgradeAfterChecksEvenIfNotHealthy bool) error {
+		restartedDaemons = append(restartedDaemons, daemonType+"."+daemonName)
+		return stub(context, d, namespace, daemonType, daemonName, skipUpgradeChecks, continueUpgradeAfterChecksEvenIfNotHealthy)
+	}
+
+	ownerRef := metav1.OwnerReference{UID: "uid"}
+	c := &cluster{
+		Namespace:   namespace,
+		Spec:        &cephv1.ClusterSpec{},
+		annotations: map[string]string{controller.RotateKeysAnnotation: "1"},
+		context:     context,
+		ownerRef:    ownerRef,
+		mons:        mon.New(context, namespace, "", cephv1.NetworkSpec{}, ownerRef, &sync.Mutex{}),
+	}
+	c.mons.ClusterInfo = &cephconfig.ClusterInfo{Name: namespace, FSID: "fsid", MonitorSecret: "monkey", AdminSecret: "adminkey"}
+	c.Info = c.mons.ClusterInfo
+
+	assert.NoError(t, c.rotateKeysIfNeeded())
+
+	// the admin key is rotated first, and stored in the mon secret and the config of the operator
+	assert.Equal(t, "newkey1", keys["client.admin"])
+	assert.Equal(t, "newkey1", c.Info.AdminSecret)
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(mon.AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "newkey1", string(secret.Data[mon.AdminSecretName]))
+	secret, err = clientset.CoreV1().Secrets(namespace).Get("rook-ceph-admin-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, secret.StringData["keyring"], "key = newkey1")
+	secret, err = clientset.CoreV1().Secrets(namespace).Get("rook-ceph-mons-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, secret.StringData["keyring"], "key = newkey1")
+
+	// the keys of the daemons are rotated in their secrets, the osd and bootstrap keys being kept
+	secret, err = clientset.CoreV1().Secrets(namespace).Get("rook-ceph-mgr-a-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[mgr.a]\n\tkey = "+keys["mgr.a"]+"\n", string(secret.Data["keyring"]))
+	assert.NotEqual(t, "mgrkey", keys["mgr.a"])
+	secret, err = clientset.CoreV1().Secrets(namespace).Get("rook-ceph-rgw-my-store-a-keyring", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[client.rgw.my.store.a]\nkey = "+keys["client.rgw.my.store.a"]+"\n", string(secret.Data["keyring"]))
+	assert.NotEqual(t, "rgwkey", keys["client.rgw.my.store.a"])
+	for name, keyring := range map[string]string{
+		"rook-ceph-osd-keyring":             "[osd.0]\n\tkey = osdkey\n",
+		"rook-ceph-bootstrap-osd-keyring":   "[client.bootstrap-osd]\n\tkey = bootstrapkey\n",
+		"rook-ceph-unrelated-keyring-token": "[mgr.a]\n\tkey = mgrkey\n",
+	} {
+		secret, err = clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, keyring, string(secret.Data["keyring"]))
+	}
+
+	// the csi drivers get a new generation of their users
+	secret, err = clientset.CoreV1().Secrets(namespace).Get(csi.CsiRBDNodeSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "csi-rbd-node-2", string(secret.Data["userID"]))
+	assert.Equal(t, "csikey-client.csi-rbd-node-2", string(secret.Data["userKey"]))
+	secret, err = clientset.CoreV1().Secrets(namespace).Get(csi.CsiCephFSProvisionerSecret, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "csi-cephfs-provisioner-2", string(secret.Data["adminID"]))
+
+	// the mgr is restarted before the rgw, the osd keeping its key is not restarted
+	assert.Equal(t, []string{"rook-ceph-mgr-a", "rook-ceph-rgw-my-store-a"}, testopk8s.DeploymentNamesUpdated(restarted))
+	assert.Equal(t, []string{"mgr.a", "rgw.my-store-a"}, restartedDaemons)
+	assert.NotEmpty(t, (*restarted)[0].Spec.Template.Annotations[k8sutil.RestartedAtAnnotation])
+
+	// the rotation is recorded and not run again
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(keyRotationConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", configMap.Data[rotationRequestKey])
+	assert.NotEmpty(t, configMap.Data[lastRotationKey])
+	testopk8s.ClearDeploymentsUpdated(restarted)
+	assert.NoError(t, c.rotateKeysIfNeeded())
+	assert.Equal(t, "newkey1", keys["client.admin"])
+	assert.Empty(t, *restarted)
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// logLevelSetting is the log level of the operator, the ROOK_LOG_LEVEL env var of the operator being
+	// restored when removed from the operator settings
+	logLevelSetting = "ROOK_LOG_LEVEL"
+	// packageLogLevelsSetting overrides the log level of the operator for some packages, such as "op-osd=DEBUG,op-mon=TRACE"
+	packageLogLevelsSetting = "ROOK_PACKAGE_LOG_LEVELS"
+
+	rookRepo        = "github.com/rook/rook"
+	defaultLogLevel = "INFO"
+)
+
+var (
+	// appliedLogLevels are the log levels last applied, so that the levels are only set again when changed
+	appliedLogLevels     string
+	appliedLogLevelsLock sync.Mutex
+)
+
+// applyLogLevels sets the log levels of the operator from its settings without restarting the operator. The packages
+// missing from the package levels are reset to the global level.
+func applyLogLevels(cm *v1.ConfigMap) error {
+	level, ok := cm.Data[logLevelSetting]
+	if !ok || level == "" {
+		level = os.Getenv(logLevelSetting)
+	}
+	if level == "" {
+		level = defaultLogLevel
+	}
+	packageLevels := strings.TrimSpace(cm.Data[packageLogLevelsSetting])
+
+	appliedLogLevelsLock.Lock()
+	defer appliedLogLevelsLock.Unlock()
+	levels := level + ";" + packageLevels
+	if levels == appliedLogLevels {
+		return nil
+	}
+
+	globalLevel, err := capnslog.ParseLevel(strings.ToUpper(level))
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse operator setting %q", logLevelSetting)
+	}
+	repo, err := capnslog.GetRepoLogger(rookRepo)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the loggers of %q", rookRepo)
+	}
+	packages, err := parsePackageLogLevels(repo, packageLevels)
+	if err != nil {
+		return errors.Wrapf(err, "failed to parse operator setting %q", packageLogLevelsSetting)
+	}
+
+	capnslog.SetGlobalLogLevel(globalLevel)
+	repo.SetLogLevel(packages)
+	appliedLogLevels = levels
+	logger.Infof("operator log level set to %q with package log levels %q", level, packageLevels)
+	return nil
+}
+
+// parsePackageLogLevels parses the log levels of the packages such as "op-osd=DEBUG,op-mon=TRACE", the packages
+// being the names of the loggers of the operator
+func parsePackageLogLevels(repo capnslog.RepoLogger, packageLevels string) (map[string]capnslog.LogLevel, error) {
+	packages := map[string]capnslog.LogLevel{}
+	if packageLevels == "" {
+		return packages, nil
+	}
+	for _, entry := range strings.Split(packageLevels, ",") {
+		pair := strings.SplitN(strings.TrimSpace(entry), "=", 2)
+		if len(pair) != 2 {
+			return nil, errors.Errorf("invalid package log level %q, expected <package>=<level>", entry)
+		}
+		pkg := strings.TrimSpace(pair[0])
+		if _, ok := repo[pkg]; !ok {
+			return nil, errors.Errorf("unknown package %q", pkg)
+		}
+		level, err := capnslog.ParseLevel(strings.ToUpper(strings.TrimSpace(pair[1])))
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid log level of package %q", pkg)
+		}
+		packages[pkg] = level
+	}
+	return packages, nil
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestApplyLogLevels(t *testing.T) {
+	defer capnslog.SetGlobalLogLevel(capnslog.INFO)
+	osdLogger := capnslog.NewPackageLogger(rookRepo, "op-osd")
+	monLogger := capnslog.NewPackageLogger(rookRepo, "op-mon")
+
+	// the global level
+	cm := &v1.ConfigMap{Data: map[string]string{logLevelSetting: "debug"}}
+	assert.NoError(t, applyLogLevels(cm))
+	assert.True(t, osdLogger.LevelAt(capnslog.DEBUG))
+	assert.True(t, monLogger.LevelAt(capnslog.DEBUG))
+	assert.False(t, monLogger.LevelAt(capnslog.TRACE))
+
+	// a package overriding the global level
+	cm.Data[logLevelSetting] = "INFO"
+	cm.Data[packageLogLevelsSetting] = "op-osd=TRACE"
+	assert.NoError(t, applyLogLevels(cm))
+	assert.True(t, osdLogger.LevelAt(capnslog.TRACE))
+	assert.False(t, monLogger.LevelAt(capnslog.DEBUG))
+
+	// the packages removed are reset to the default level
+	delete(cm.Data, logLevelSetting)
+	delete(cm.Data, packageLogLevelsSetting)
+	assert.NoError(t, applyLogLevels(cm))
+	assert.False(t, osdLogger.LevelAt(capnslog.DEBUG))
+	assert.True(t, osdLogger.LevelAt(capnslog.INFO))
+
+	// invalid levels are not applied
+	cm.Data[packageLogLevelsSetting] = "op-osd=LOUD"
+	assert.Error(t, applyLogLevels(cm))
+	cm.Data[packageLogLevelsSetting] = "op-unknown=DEBUG"
+	assert.Error(t, applyLogLevels(cm))
+	cm.Data[packageLogLevelsSetting] = "op-osd"
+	assert.Error(t, applyLogLevels(cm))
+	assert.False(t, osdLogger.LevelAt(capnslog.DEBUG))
+}
@@ -52,23 +52,13 @@ var (
 func (c *Cluster) configureDashboardService() error {
 	dashboardService := c.makeDashboardService(AppName)
 	if c.dashboard.Enabled {
-		// expose the dashboard service
-		if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(dashboardService); err != nil {
-			if !kerrors.IsAlreadyExists(err) {
-				return errors.Wrap(err, "failed to create dashboard mgr service")
-			}
-			logger.Infof("dashboard service already exists")
-			original, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get(dashboardService.Name, metav1.GetOptions{})
-			if err != nil {
-				return errors.Wrap(err, "failed to get dashboard service")
-			}
-			if original.Spec.Ports[0].Port != int32(c.dashboardPort()) {
-				logger.Infof("dashboard port changed. updating service")
-				original.Spec.Ports[0].Port = int32(c.dashboardPort())
-				if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Update(original); err != nil {
-					return errors.Wrap(err, "failed to update dashboard mgr service")
-				}
-			}
+		// expose the dashboard service, updated with the port and the service settings of the dashboard
+		svc, err := k8sutil.CreateOrUpdateService(c.context.Clientset, c.Namespace, dashboardService)
+		if err != nil {
+			return errors.Wrap(err, "failed to create or update dashboard mgr service")
+		}
+		if addresses := k8sutil.LoadBalancerAddresses(svc); len(addresses) > 0 {
+			logger.Infof("dashboard service exposed on %v port %d", addresses, c.dashboardPort())
 		} else {
 			logger.Infof("dashboard service started")
 		}
@@ -156,26 +146,26 @@ func (c *Cluster) initializeSecureDashboard() (bool, error) {
 	// we need to wait a short period after enabling the module before we can call the `ceph dashboard` commands.
 	time.Sleep(dashboardInitWaitTime)
 
-	password, err := c.getOrGenerateDashboardPassword()
+	password, err := c.getDashboardPassword()
 	if err != nil {
-		return false, errors.Wrap(err, "failed to generate a password for the ceph dashboard")
+		return false, errors.Wrap(err, "failed to get a password for the ceph dashboard")
 	}
 
+	hasChanged := true
 	if c.dashboard.SSL {
 		alreadyCreated, err := c.createSelfSignedCert()
 		if err != nil {
 			return false, errors.Wrap(err, "failed to create a self signed cert for the ceph dashboard")
 		}
-		if alreadyCreated {
-			return false, nil
-		}
+		hasChanged = !alreadyCreated
 	}
 
+	// the credentials are set at every orchestration so a password changed in the secret is applied
 	if err := c.setLoginCredentials(password); err != nil {
 		return false, errors.Wrap(err, "failed to set login credentials for the ceph dashboard")
 	}
 
-	return true, nil
+	return hasChanged, nil
 }
 
 func (c *Cluster) createSelfSignedCert() (bool, error) {
@@ -228,6 +218,29 @@ func (c *Cluster) setLoginCredentials(password string) error {
 	return nil
 }
 
+// getDashboardPassword returns the admin password from the secret of the dashboard settings if any,
+// or the generated password otherwise
+func (c *Cluster) getDashboardPassword() (string, error) {
+	ref := c.dashboard.AdminPasswordSecret
+	if ref == nil || ref.Name == "" {
+		return c.getOrGenerateDashboardPassword()
+	}
+
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get dashboard admin password secret %q", ref.Name)
+	}
+	key := ref.Key
+	if key == "" {
+		key = passwordKeyName
+	}
+	password, ok := secret.Data[key]
+	if !ok || len(password) == 0 {
+		return "", errors.Errorf("key %q not found in dashboard admin password secret %q", key, ref.Name)
+	}
+	return string(password), nil
+}
+
 func (c *Cluster) getOrGenerateDashboardPassword() (string, error) {
 	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(dashboardPasswordName, metav1.GetOptions{})
 	if err == nil {
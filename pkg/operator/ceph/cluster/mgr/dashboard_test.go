@@ -28,6 +28,7 @@ import (
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -71,11 +72,46 @@ func TestGetOrGeneratePassword(t *testing.T) {
 	assert.Equal(t, password, retrievedPassword)
 }
 
+func TestGetDashboardPassword(t *testing.T) {
+	clientset := test.New(t, 3)
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "myns"}
+
+	// The password is generated without a secret in the settings
+	password, err := c.getDashboardPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, passwordLength, len(password))
+
+	// The password is read from the secret of the settings
+	c.dashboard.AdminPasswordSecret = &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "admin-secret"}}
+	_, err = c.getDashboardPassword()
+	assert.Error(t, err)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "admin-secret", Namespace: c.Namespace},
+		Data:       map[string][]byte{"password": []byte("mypassword"), "other": []byte("otherpassword")},
+	}
+	_, err = clientset.CoreV1().Secrets(c.Namespace).Create(secret)
+	assert.NoError(t, err)
+	password, err = c.getDashboardPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "mypassword", password)
+
+	c.dashboard.AdminPasswordSecret.Key = "other"
+	password, err = c.getDashboardPassword()
+	assert.NoError(t, err)
+	assert.Equal(t, "otherpassword", password)
+
+	c.dashboard.AdminPasswordSecret.Key = "missing"
+	_, err = c.getDashboardPassword()
+	assert.Error(t, err)
+}
+
 func TestStartSecureDashboard(t *testing.T) {
 	enables := 0
 	disables := 0
 	moduleRetries := 0
 	exitCodeResponse := 0
+	logins := 0
+	certCreated := false
 	clientset := test.New(t, 3)
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
@@ -95,6 +131,14 @@ func TestStartSecureDashboard(t *testing.T) {
 					moduleRetries++
 					return "", errors.New("test failure")
 				}
+				if certCreated {
+					exitCodeResponse = certAlreadyConfiguredErrorCode
+					return "", errors.New("cert already configured")
+				}
+				certCreated = true
+			}
+			if args[0] == "dashboard" && args[1] == "set-login-credentials" {
+				logins++
 			}
 			return "", nil
 		},
@@ -125,6 +169,14 @@ func TestStartSecureDashboard(t *testing.T) {
 	assert.Equal(t, 2, enables)
 	assert.Equal(t, 1, disables)
 	assert.Equal(t, 2, moduleRetries)
+	assert.Equal(t, 1, logins)
+
+	// the credentials are set again without restarting the dashboard when the cert already exists
+	err = c.configureDashboardModules()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, logins)
+	assert.Equal(t, 3, enables)
+	assert.Equal(t, 1, disables)
 
 	svc, err := c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
 	assert.Nil(t, err)
@@ -136,7 +188,7 @@ func TestStartSecureDashboard(t *testing.T) {
 	assert.Nil(t, err)
 	err = c.configureDashboardModules()
 	assert.NoError(t, err)
-	assert.Equal(t, 2, enables)
+	assert.Equal(t, 3, enables)
 	assert.Equal(t, 2, disables)
 
 	svc, err = c.context.Clientset.CoreV1().Services(c.Namespace).Get("rook-ceph-mgr-dashboard", metav1.GetOptions{})
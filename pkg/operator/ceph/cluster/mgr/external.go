@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ExternalMgrAppName is the name of the service exposing the metrics of the mgrs of an external cluster
+	ExternalMgrAppName = "rook-ceph-mgr-external"
+)
+
+// ConfigureExternalMetricsEndpoint exposes the prometheus endpoints of the mgrs of an external cluster with a service
+// in the namespace of the cluster, scraped by the service monitor if monitoring is enabled. The service is removed
+// when no endpoint is configured.
+func (c *Cluster) ConfigureExternalMetricsEndpoint() error {
+	if len(c.monitoringSpec.ExternalMgrEndpoints) == 0 {
+		logger.Debugf("no external mgr metrics endpoint configured")
+		if err := k8sutil.DeleteService(c.context.Clientset, c.Namespace, ExternalMgrAppName); err != nil {
+			return errors.Wrap(err, "failed to delete the external mgr metrics service")
+		}
+		err := c.context.Clientset.CoreV1().Endpoints(c.Namespace).Delete(ExternalMgrAppName, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to delete the external mgr metrics endpoints")
+		}
+		return nil
+	}
+
+	service := c.makeExternalMetricsService()
+	if _, err := k8sutil.CreateOrUpdateService(c.context.Clientset, c.Namespace, service); err != nil {
+		return errors.Wrap(err, "failed to create the external mgr metrics service")
+	}
+	if _, err := k8sutil.CreateOrUpdateEndpoint(c.context.Clientset, c.Namespace, c.makeExternalMetricsEndpoints()); err != nil {
+		return errors.Wrap(err, "failed to create the external mgr metrics endpoints")
+	}
+	logger.Infof("external mgr metrics service configured with endpoints %v", c.monitoringSpec.ExternalMgrEndpoints)
+
+	c.configureMonitoring(service)
+	return nil
+}
+
+func (c *Cluster) externalMetricsPort() int32 {
+	if c.monitoringSpec.ExternalMgrPrometheusPort != 0 {
+		return int32(c.monitoringSpec.ExternalMgrPrometheusPort)
+	}
+	return int32(metricsPort)
+}
+
+// makeExternalMetricsService returns the service without selector of the external mgr endpoints, labeled like the
+// metrics service of the local mgrs for the service monitor
+func (c *Cluster) makeExternalMetricsService() *v1.Service {
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ExternalMgrAppName,
+			Namespace: c.Namespace,
+			Labels:    controller.AppLabels(AppName, c.Namespace),
+		},
+		Spec: v1.ServiceSpec{
+			Type: v1.ServiceTypeClusterIP,
+			Ports: []v1.ServicePort{
+				{
+					Name:     "http-metrics",
+					Port:     c.externalMetricsPort(),
+					Protocol: v1.ProtocolTCP,
+				},
+			},
+		},
+	}
+
+	k8sutil.SetOwnerRef(&svc.ObjectMeta, &c.ownerRef)
+	return svc
+}
+
+func (c *Cluster) makeExternalMetricsEndpoints() *v1.Endpoints {
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      ExternalMgrAppName,
+			Namespace: c.Namespace,
+			Labels:    controller.AppLabels(AppName, c.Namespace),
+		},
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: c.monitoringSpec.ExternalMgrEndpoints,
+				Ports: []v1.EndpointPort{
+					{
+						Name:     "http-metrics",
+						Port:     c.externalMetricsPort(),
+						Protocol: v1.ProtocolTCP,
+					},
+				},
+			},
+		},
+	}
+
+	k8sutil.SetOwnerRef(&endpoints.ObjectMeta, &c.ownerRef)
+	return endpoints
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConfigureExternalMetricsEndpoint(t *testing.T) {
+	clientset := testop.New(t, 1)
+	c := &Cluster{
+		Namespace:   "ns",
+		context:     &clusterd.Context{Clientset: clientset},
+		clusterInfo: &cephconfig.ClusterInfo{},
+		monitoringSpec: cephv1.MonitoringSpec{
+			ExternalMgrEndpoints: []v1.EndpointAddress{{IP: "192.168.0.2"}, {IP: "192.168.0.3"}},
+		},
+	}
+
+	assert.NoError(t, c.ConfigureExternalMetricsEndpoint())
+	svc, err := clientset.CoreV1().Services("ns").Get(ExternalMgrAppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Nil(t, svc.Spec.Selector)
+	assert.Equal(t, "rook-ceph-mgr", svc.Labels["app"])
+	assert.Equal(t, int32(metricsPort), svc.Spec.Ports[0].Port)
+	endpoints, err := clientset.CoreV1().Endpoints("ns").Get(ExternalMgrAppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, c.monitoringSpec.ExternalMgrEndpoints, endpoints.Subsets[0].Addresses)
+
+	// The port of the endpoints is updated
+	c.monitoringSpec.ExternalMgrPrometheusPort = 9000
+	assert.NoError(t, c.ConfigureExternalMetricsEndpoint())
+	endpoints, err = clientset.CoreV1().Endpoints("ns").Get(ExternalMgrAppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(9000), endpoints.Subsets[0].Ports[0].Port)
+
+	// The service is removed without endpoints
+	c.monitoringSpec.ExternalMgrEndpoints = nil
+	assert.NoError(t, c.ConfigureExternalMetricsEndpoint())
+	_, err = clientset.CoreV1().Services("ns").Get(ExternalMgrAppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().Endpoints("ns").Get(ExternalMgrAppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
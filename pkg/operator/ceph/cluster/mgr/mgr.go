@@ -18,8 +18,10 @@ limitations under the License.
 package mgr
 
 import (
+	"context"
 	"fmt"
 	"path"
+	"reflect"
 	"strconv"
 	"strings"
 
@@ -40,6 +42,7 @@ import (
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-mgr")
@@ -69,6 +72,7 @@ type Cluster struct {
 	Replicas          int
 	placement         rookv1.Placement
 	annotations       rookv1.Annotations
+	labels            rookv1.Labels
 	context           *clusterd.Context
 	dataDir           string
 	Network           cephv1.NetworkSpec
@@ -85,6 +89,10 @@ type Cluster struct {
 	skipUpgradeChecks bool
 	appliedHttpBind   bool
 	healthCheck       cephv1.CephClusterHealthCheckSpec
+	logCollector      cephv1.LogCollectorSpec
+	telemetry         *cephv1.TelemetrySpec
+	env               cephv1.DaemonEnvSpec
+	volumeMounts      cephv1.DaemonVolumeMountsSpec
 }
 
 // New creates an instance of the mgr
@@ -130,6 +138,28 @@ func New(
 	}
 }
 
+// SetLabels sets the labels added to the mgr deployments and pods
+func (c *Cluster) SetLabels(labels rookv1.Labels) {
+	c.labels = labels
+}
+
+// SetLogCollector sets the rotation of the log files of the mgrs, the sidecar being added when enabled
+func (c *Cluster) SetLogCollector(logCollector cephv1.LogCollectorSpec) {
+	c.logCollector = logCollector
+}
+
+// SetDaemonOverrides sets the environment variables and the extra volumes of the cluster spec, the mgr ones being added
+// to the containers of the mgr pods
+func (c *Cluster) SetDaemonOverrides(env cephv1.DaemonEnvSpec, volumeMounts cephv1.DaemonVolumeMountsSpec) {
+	c.env = env
+	c.volumeMounts = volumeMounts
+}
+
+// SetTelemetry sets the telemetry settings applied to the telemetry module, the module being left as is when nil
+func (c *Cluster) SetTelemetry(telemetry *cephv1.TelemetrySpec) {
+	c.telemetry = telemetry
+}
+
 var updateDeploymentAndWait = mon.UpdateCephDeploymentAndWait
 
 func (c *Cluster) getDaemonIDs() []string {
@@ -200,38 +230,80 @@ func (c *Cluster) Start() error {
 
 	// create the metrics service
 	service := c.makeMetricsService(AppName)
-	if _, err := c.context.Clientset.CoreV1().Services(c.Namespace).Create(service); err != nil {
-		if !kerrors.IsAlreadyExists(err) {
-			return errors.Wrap(err, "failed to create mgr service")
-		}
-		logger.Infof("mgr metrics service already exists")
-	} else {
-		logger.Infof("mgr metrics service started")
+	if _, err := k8sutil.CreateOrUpdateService(c.context.Clientset, c.Namespace, service); err != nil {
+		return errors.Wrap(err, "failed to create mgr service")
 	}
+	logger.Infof("mgr metrics service started")
 
-	// enable monitoring if `monitoring: enabled: true`
-	if c.monitoringSpec.Enabled {
-		logger.Infof("starting monitoring deployment")
-		// servicemonitor takes some metadata from the service for easy mapping
-		if err := c.enableServiceMonitor(service); err != nil {
-			logger.Errorf("failed to enable service monitor. %v", err)
-		} else {
-			logger.Infof("servicemonitor enabled")
+	c.configureMonitoring(service)
+	return nil
+}
+
+// configureMonitoring deploys the service monitor and the prometheus rules if `monitoring: enabled: true`,
+// or removes them otherwise
+func (c *Cluster) configureMonitoring(service *v1.Service) {
+	namespace := c.monitoringNamespace()
+	serviceMonitorName := c.serviceMonitorName(service, namespace)
+	ruleName := c.prometheusRuleName()
+
+	if !c.monitoringSpec.Enabled {
+		// the monitoring resources are not garbage collected with the cluster outside of its namespace
+		if err := k8sutil.DeleteServiceMonitor(namespace, serviceMonitorName); err != nil {
+			logger.Debugf("failed to remove service monitor. %v", err)
 		}
-		// namespace in which the prometheusRule should be deployed
-		// if left empty, it will be deployed in current namespace
-		namespace := c.monitoringSpec.RulesNamespace
-		if namespace == "" {
-			namespace = c.Namespace
+		if err := k8sutil.RemovePrometheusRuleOwner(namespace, ruleName, c.ownerRef); err != nil {
+			logger.Debugf("failed to remove prometheus rule. %v", err)
 		}
-		if err := c.deployPrometheusRule(prometheusRuleName, namespace); err != nil {
-			logger.Errorf("failed to deploy prometheus rule. %v", err)
-		} else {
-			logger.Infof("prometheusRule deployed")
+		return
+	}
+
+	logger.Infof("starting monitoring deployment")
+	// servicemonitor takes some metadata from the service for easy mapping
+	if err := c.enableServiceMonitor(service, namespace, serviceMonitorName); err != nil {
+		logger.Errorf("failed to enable service monitor. %v", err)
+	} else {
+		logger.Infof("servicemonitor enabled")
+	}
+	if namespace != c.Namespace {
+		// remove the service monitor created in the namespace of the cluster by previous versions
+		if err := k8sutil.DeleteServiceMonitor(c.Namespace, service.GetName()); err != nil {
+			logger.Warningf("failed to remove service monitor %q in namespace %q. %v", service.GetName(), c.Namespace, err)
 		}
-		logger.Debugf("ended monitoring deployment")
 	}
-	return nil
+
+	if c.monitoringSpec.DisablePrometheusRules {
+		if err := k8sutil.RemovePrometheusRuleOwner(namespace, ruleName, c.ownerRef); err != nil {
+			logger.Errorf("failed to remove prometheus rule. %v", err)
+		}
+	} else if err := c.deployPrometheusRule(ruleName, namespace); err != nil {
+		logger.Errorf("failed to deploy prometheus rule. %v", err)
+	} else {
+		logger.Infof("prometheusRule deployed")
+	}
+	logger.Debugf("ended monitoring deployment")
+}
+
+// monitoringNamespace returns the namespace in which the service monitor and the prometheusRule should be
+// deployed, the namespace of the cluster if not set
+func (c *Cluster) monitoringNamespace() string {
+	if c.monitoringSpec.RulesNamespace != "" {
+		return c.monitoringSpec.RulesNamespace
+	}
+	return c.Namespace
+}
+
+// serviceMonitorName returns the name of the service monitor, which is suffixed with the namespace of the
+// cluster outside of its namespace since several clusters may share the rules namespace
+func (c *Cluster) serviceMonitorName(service *v1.Service, namespace string) string {
+	if namespace == c.Namespace {
+		return service.GetName()
+	}
+	return fmt.Sprintf("%s-%s", service.GetName(), c.Namespace)
+}
+
+func (c *Cluster) prometheusRuleName() string {
+	version := strconv.Itoa(c.clusterInfo.CephVersion.Major)
+	return strings.Replace(prometheusRuleName, "VERSION", version, 1)
 }
 
 func (c *Cluster) configureModules(daemonIDs []string) {
@@ -243,12 +315,18 @@ func (c *Cluster) configureModules(daemonIDs []string) {
 	// "crash" is part of the "always_on_modules" list as of Octopus
 	if !c.clusterInfo.CephVersion.IsAtLeastOctopus() {
 		startModuleConfiguration("crash", c.enableCrashModule)
-	} else {
+	}
+	if c.mgrSpec.Balancer != nil {
+		startModuleConfiguration("balancer", c.configureBalancer)
+	} else if c.clusterInfo.CephVersion.IsAtLeastOctopus() {
 		// The balancer module must be configured on Octopus
 		// It is a bit confusing but as of Octopus modules that are in the "always_on_modules" list
 		// are "just" enabled, but still they must be configured to work properly
 		startModuleConfiguration("balancer", c.enableBalancerModule)
 	}
+	if c.telemetry != nil {
+		startModuleConfiguration("telemetry", c.configureTelemetry)
+	}
 	startModuleConfiguration("mgr module(s) from the spec", c.configureMgrModules)
 }
 
@@ -297,57 +375,122 @@ func (c *Cluster) enableBalancerModule() error {
 	return nil
 }
 
+// configureBalancer applies the mode and the misplaced ratio of the balancer of the spec, the ceph status checker
+// applying them again if changed from the toolbox
+func (c *Cluster) configureBalancer() error {
+	mode := BalancerMode(c.mgrSpec.Balancer)
+	if err := client.ApplyBalancerMode(c.context, c.Namespace, mode); err != nil {
+		return errors.Wrapf(err, "failed to apply balancer mode %q", mode)
+	}
+	if c.mgrSpec.Balancer.MaxMisplaced > 0 {
+		if err := client.SetBalancerMaxMisplaced(c.context, c.Namespace, c.mgrSpec.Balancer.MaxMisplaced); err != nil {
+			return errors.Wrap(err, "failed to set the max misplaced ratio of the balancer")
+		}
+	}
+	return nil
+}
+
+// BalancerMode returns the mode of the balancer of the spec, upmap by default
+func BalancerMode(balancer *cephv1.BalancerSpec) string {
+	if balancer == nil || balancer.Mode == "" {
+		return balancerModuleMode
+	}
+	return balancer.Mode
+}
+
 func (c *Cluster) configureMgrModules() error {
-	// Enable mgr modules from the spec
+	// Enable mgr modules from the spec, a failing module not preventing the configuration of the others
+	statuses := []cephv1.MgrModuleStatus{}
+	failed := []string{}
 	for _, module := range c.mgrSpec.Modules {
-		if module.Name == "" {
-			return errors.New("name not specified for the mgr module configuration")
-		}
-		if wellKnownModule(module.Name) {
-			return errors.Errorf("cannot configure mgr module %q that is configured with other cluster settings", module.Name)
-		}
-		minVersion, versionOK := c.moduleMeetsMinVersion(module.Name)
-		if !versionOK {
-			return errors.Errorf("module %q cannot be configured because it requires at least Ceph version %q", module.Name, minVersion.String())
+		status := cephv1.MgrModuleStatus{Name: module.Name, Enabled: module.Enabled, Configured: true}
+		if err := c.configureMgrModule(module); err != nil {
+			logger.Errorf("failed to configure mgr module %q. %v", module.Name, err)
+			status.Configured = false
+			status.Message = err.Error()
+			failed = append(failed, module.Name)
 		}
+		statuses = append(statuses, status)
+	}
+	c.updateModulesStatus(statuses)
 
-		if module.Enabled {
-			if module.Name == balancerModuleName {
-				// Configure balancer module mode
-				err := client.ConfigureBalancerModule(c.context, c.Namespace, balancerModuleMode)
-				if err != nil {
-					return errors.Wrapf(err, "failed to configure module %q", module.Name)
-				}
-			}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to configure mgr modules %v", failed)
+	}
+	return nil
+}
 
-			if err := client.MgrEnableModule(c.context, c.Namespace, module.Name, false); err != nil {
-				return errors.Wrapf(err, "failed to enable mgr module %q", module.Name)
-			}
+func (c *Cluster) configureMgrModule(module cephv1.Module) error {
+	if module.Name == "" {
+		return errors.New("name not specified for the mgr module configuration")
+	}
+	if wellKnownModule(module.Name) {
+		return errors.Errorf("cannot configure mgr module %q that is configured with other cluster settings", module.Name)
+	}
+	minVersion, versionOK := c.moduleMeetsMinVersion(module.Name)
+	if !versionOK {
+		return errors.Errorf("module %q cannot be configured because it requires at least Ceph version %q", module.Name, minVersion.String())
+	}
 
-			// Configure special settings for individual modules that are enabled
-			if module.Name == PgautoscalerModuleName {
-				monStore := config.GetMonStore(c.context, c.Namespace)
-				// Ceph Octopus will have that option enabled
-				err := monStore.Set("global", "osd_pool_default_pg_autoscale_mode", "on")
-				if err != nil {
-					return errors.Wrap(err, "failed to enable pg autoscale mode for newly created pools")
-				}
-				err = monStore.Set("global", "mon_pg_warn_min_per_osd", "0")
-				if err != nil {
-					return errors.Wrap(err, "failed to set minimal number PGs per (in) osd before we warn the admin to")
-				}
-			}
+	if !module.Enabled {
+		if err := client.MgrDisableModule(c.context, c.Namespace, module.Name); err != nil {
+			return errors.Wrapf(err, "failed to disable mgr module %q", module.Name)
+		}
+		return nil
+	}
 
-		} else {
-			if err := client.MgrDisableModule(c.context, c.Namespace, module.Name); err != nil {
-				return errors.Wrapf(err, "failed to disable mgr module %q", module.Name)
-			}
+	if module.Name == balancerModuleName {
+		// Configure balancer module mode
+		err := client.ConfigureBalancerModule(c.context, c.Namespace, balancerModuleMode)
+		if err != nil {
+			return errors.Wrapf(err, "failed to configure module %q", module.Name)
+		}
+	}
+
+	if err := client.MgrEnableModule(c.context, c.Namespace, module.Name, false); err != nil {
+		return errors.Wrapf(err, "failed to enable mgr module %q", module.Name)
+	}
+
+	// Configure special settings for individual modules that are enabled
+	if module.Name == PgautoscalerModuleName {
+		monStore := config.GetMonStore(c.context, c.Namespace)
+		// Ceph Octopus will have that option enabled
+		err := monStore.Set("global", "osd_pool_default_pg_autoscale_mode", "on")
+		if err != nil {
+			return errors.Wrap(err, "failed to enable pg autoscale mode for newly created pools")
+		}
+		err = monStore.Set("global", "mon_pg_warn_min_per_osd", "0")
+		if err != nil {
+			return errors.Wrap(err, "failed to set minimal number PGs per (in) osd before we warn the admin to")
 		}
 	}
 
 	return nil
 }
 
+// updateModulesStatus records the outcome of the configuration of the modules of the spec in the CephCluster status
+func (c *Cluster) updateModulesStatus(statuses []cephv1.MgrModuleStatus) {
+	if c.context.Client == nil {
+		return
+	}
+	cephCluster := &cephv1.CephCluster{}
+	err := c.context.Client.Get(context.TODO(), types.NamespacedName{Namespace: c.Namespace, Name: c.ownerRef.Name}, cephCluster)
+	if err != nil {
+		logger.Warningf("failed to get the ceph cluster %q to report the mgr modules status. %v", c.Namespace, err)
+		return
+	}
+	if len(statuses) == 0 {
+		statuses = nil
+	}
+	if reflect.DeepEqual(cephCluster.Status.MgrModules, statuses) {
+		return
+	}
+	cephCluster.Status.MgrModules = statuses
+	if err := controller.UpdateStatus(c.context.Client, cephCluster); err != nil {
+		logger.Warningf("failed to report the mgr modules status of cluster %q. %v", c.Namespace, err)
+	}
+}
+
 func (c *Cluster) moduleMeetsMinVersion(name string) (*cephver.CephVersion, bool) {
 	minVersions := map[string]cephver.CephVersion{
 		// Put the modules here, example:
@@ -372,17 +515,17 @@ func wellKnownModule(name string) bool {
 }
 
 // add a servicemonitor that allows prometheus to scrape from the monitoring endpoint of the cluster
-func (c *Cluster) enableServiceMonitor(service *v1.Service) error {
-	name := service.GetName()
-	namespace := service.GetNamespace()
+func (c *Cluster) enableServiceMonitor(service *v1.Service, namespace, name string) error {
 	serviceMonitor, err := k8sutil.GetServiceMonitor(path.Join(monitoringPath, serviceMonitorFile))
 	if err != nil {
 		return errors.Wrap(err, "service monitor could not be enabled")
 	}
 	serviceMonitor.SetName(name)
 	serviceMonitor.SetNamespace(namespace)
-	k8sutil.SetOwnerRef(&serviceMonitor.ObjectMeta, &c.ownerRef)
-	serviceMonitor.Spec.NamespaceSelector.MatchNames = []string{namespace}
+	if namespace == c.Namespace {
+		k8sutil.SetOwnerRef(&serviceMonitor.ObjectMeta, &c.ownerRef)
+	}
+	serviceMonitor.Spec.NamespaceSelector.MatchNames = []string{service.GetNamespace()}
 	serviceMonitor.Spec.Selector.MatchLabels = service.GetLabels()
 	if _, err := k8sutil.CreateOrUpdateServiceMonitor(serviceMonitor); err != nil {
 		return errors.Wrap(err, "service monitor could not be enabled")
@@ -392,10 +535,7 @@ func (c *Cluster) enableServiceMonitor(service *v1.Service) error {
 
 // deploy prometheusRule that adds alerting and/or recording rules to the cluster
 func (c *Cluster) deployPrometheusRule(name, namespace string) error {
-	version := strconv.Itoa(c.clusterInfo.CephVersion.Major)
-	name = strings.Replace(name, "VERSION", version, 1)
-	prometheusRuleFile := name + ".yaml"
-	prometheusRuleFile = path.Join(monitoringPath, prometheusRuleFile)
+	prometheusRuleFile := path.Join(monitoringPath, name+".yaml")
 	prometheusRule, err := k8sutil.GetPrometheusRule(prometheusRuleFile)
 	if err != nil {
 		return errors.Wrap(err, "prometheus rule could not be deployed")
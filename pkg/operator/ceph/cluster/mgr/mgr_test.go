@@ -17,9 +17,11 @@ limitations under the License.
 package mgr
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -36,6 +38,9 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestStartMGR(t *testing.T) {
@@ -209,6 +214,106 @@ func TestConfigureModules(t *testing.T) {
 	assert.Equal(t, 0, len(configSettings))
 }
 
+func TestConfigureBalancer(t *testing.T) {
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("Command: %s %v", command, args)
+			// the args without the connection and format flags
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					commands = append(commands, strings.Join(args[:i], " "))
+				}
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{},
+		context:     &clusterd.Context{Executor: executor},
+		Namespace:   "ns",
+		mgrSpec:     cephv1.MgrSpec{Balancer: &cephv1.BalancerSpec{Mode: cephv1.BalancerModeCrushCompat, MaxMisplaced: 0.02}},
+	}
+	assert.NoError(t, c.configureBalancer())
+	assert.Equal(t, []string{
+		"osd set-require-min-compat-client luminous --yes-i-really-mean-it",
+		"balancer mode crush-compat",
+		"balancer on",
+		"config set mgr target_max_misplaced_ratio 0.02",
+	}, commands)
+
+	// the balancer is turned off without changing its mode
+	commands = []string{}
+	c.mgrSpec.Balancer = &cephv1.BalancerSpec{Mode: cephv1.BalancerModeOff}
+	assert.NoError(t, c.configureBalancer())
+	assert.Equal(t, []string{"balancer off"}, commands)
+
+	assert.Equal(t, "upmap", BalancerMode(nil))
+	assert.Equal(t, "upmap", BalancerMode(&cephv1.BalancerSpec{}))
+}
+
+func TestConfigureModulesStatus(t *testing.T) {
+	enabled := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "mgr" && args[1] == "module" {
+				if args[3] == "failing" {
+					return "", errors.NewBadRequest("module not found")
+				}
+				enabled = append(enabled, args[3])
+			}
+			return "", nil
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "ns"}}
+	cl := fake.NewFakeClientWithScheme(s, cephCluster)
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{},
+		context:     &clusterd.Context{Executor: executor, Client: cl},
+		Namespace:   "ns",
+		ownerRef:    metav1.OwnerReference{Name: "rook-ceph"},
+	}
+
+	// the failing modules do not prevent the configuration of the others and are reported in the status
+	c.mgrSpec.Modules = []cephv1.Module{
+		{Name: "failing", Enabled: false},
+		{Name: "prometheus", Enabled: false},
+		{Name: "mymodule", Enabled: true},
+	}
+	assert.Error(t, c.configureMgrModules())
+	assert.Equal(t, []string{"mymodule"}, enabled)
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "rook-ceph", Namespace: "ns"}, cephCluster))
+	modules := cephCluster.Status.MgrModules
+	assert.Equal(t, 3, len(modules))
+	assert.False(t, modules[0].Configured)
+	assert.Contains(t, modules[0].Message, "module not found")
+	assert.False(t, modules[1].Configured)
+	assert.Contains(t, modules[1].Message, "configured with other cluster settings")
+	assert.Equal(t, cephv1.MgrModuleStatus{Name: "mymodule", Enabled: true, Configured: true}, modules[2])
+
+	// the status is cleared with the modules
+	c.mgrSpec.Modules = nil
+	assert.NoError(t, c.configureMgrModules())
+	cephCluster = &cephv1.CephCluster{}
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Name: "rook-ceph", Namespace: "ns"}, cephCluster))
+	assert.Nil(t, cephCluster.Status.MgrModules)
+}
+
+func TestMonitoringNames(t *testing.T) {
+	c := &Cluster{Namespace: "ns", clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.CephVersion{Major: 15}}}
+	service := c.makeMetricsService(AppName)
+	assert.Equal(t, "ns", c.monitoringNamespace())
+	assert.Equal(t, "rook-ceph-mgr", c.serviceMonitorName(service, c.monitoringNamespace()))
+	assert.Equal(t, "prometheus-ceph-v15-rules", c.prometheusRuleName())
+
+	// The service monitor is named after the cluster in a namespace shared by several clusters
+	c.monitoringSpec.RulesNamespace = "monitoring"
+	assert.Equal(t, "monitoring", c.monitoringNamespace())
+	assert.Equal(t, "rook-ceph-mgr-ns", c.serviceMonitorName(service, c.monitoringNamespace()))
+}
+
 func TestMgrDaemons(t *testing.T) {
 	c := &Cluster{Replicas: 3}
 	daemons := c.getDaemonIDs()
@@ -58,30 +58,38 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 			ServiceAccountName: serviceAccountName,
 			RestartPolicy:      v1.RestartPolicyAlways,
 			Volumes:            controller.DaemonVolumes(mgrConfig.DataPathMap, mgrConfig.ResourceName),
-			HostNetwork:        c.Network.IsHost(),
+			HostNetwork:        c.Network.IsHostFor(cephv1.KeyMgr),
 			PriorityClassName:  c.priorityClassName,
 		},
 	}
 
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
+	k8sutil.ApplyImagePullSecrets(c.Namespace, &podSpec.Spec)
 
 	podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, []v1.Container{
 		c.makeSetServerAddrInitContainer(mgrConfig, "dashboard"),
 		c.makeSetServerAddrInitContainer(mgrConfig, "prometheus"),
 	}...)
 
+	if c.logCollector.Enabled {
+		podSpec.Spec.Containers = append(podSpec.Spec.Containers,
+			controller.LogCollectorContainer(fmt.Sprintf("%s.%s", config.MgrType, mgrConfig.DaemonID), c.cephVersion.Image, c.logCollector, mon.PodSecurityContext()))
+	}
+
 	// ceph config set commands want admin keyring
 	podSpec.Spec.Volumes = append(podSpec.Spec.Volumes,
 		keyring.Volume().Admin())
-	if c.Network.IsHost() {
+	controller.ApplyDaemonOverrides(&podSpec.Spec, c.env, c.volumeMounts, cephv1.KeyMgr)
+	if c.Network.IsHostFor(cephv1.KeyMgr) {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.Network.NetworkSpec.IsMultus() {
-		k8sutil.ApplyMultus(c.Network.NetworkSpec, &podSpec.ObjectMeta)
+		config.ApplyPublicNetwork(c.Network.NetworkSpec, &podSpec.ObjectMeta)
 	}
 
 	c.annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.applyPrometheusAnnotations(&podSpec.ObjectMeta)
+	c.labels.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.placement.ApplyToPodSpec(&podSpec.Spec)
 
 	replicas := int32(1)
@@ -104,6 +112,8 @@ func (c *Cluster) makeDeployment(mgrConfig *mgrConfig) *apps.Deployment {
 		},
 	}
 	k8sutil.AddRookVersionLabelToDeployment(d)
+	c.annotations.ApplyToObjectMeta(&d.ObjectMeta)
+	c.labels.ApplyToObjectMeta(&d.ObjectMeta)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, d)
 	k8sutil.SetOwnerRef(&d.ObjectMeta, &c.ownerRef)
 	return d
@@ -240,7 +250,7 @@ func (c *Cluster) makeMgrDaemonContainer(mgrConfig *mgrConfig) v1.Container {
 	container = config.ConfigureLivenessProbe(rookcephv1.KeyMgr, container, c.healthCheck)
 
 	// If host networking is enabled, we don't need a bind addr that is different from the public addr
-	if !c.Network.IsHost() {
+	if !c.Network.IsHostFor(cephv1.KeyMgr) {
 		// Opposite of the above, --public-bind-addr will *not* still advertise on the previous
 		// port, which makes sense because this is the pod IP, which changes with every new pod.
 		container.Args = append(container.Args,
@@ -311,6 +321,7 @@ func (c *Cluster) makeDashboardService(name string) *v1.Service {
 			},
 		},
 	}
+	controller.ApplyServiceSpec(svc, c.dashboard.Service)
 	k8sutil.SetOwnerRef(&svc.ObjectMeta, &c.ownerRef)
 	return svc
 }
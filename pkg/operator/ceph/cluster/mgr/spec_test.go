@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	optest "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
@@ -70,6 +71,7 @@ func TestPodSpec(t *testing.T) {
 		ResourceName: "rook-ceph-mgr-a",
 		DataPathMap:  config.NewStatelessDaemonDataPathMap(config.MgrType, "a", "rook-ceph", "/var/lib/rook/"),
 	}
+	c.SetLabels(rookv1.Labels{"my-label": "value", k8sutil.AppAttr: "overridden"})
 
 	d := c.makeDeployment(&mgrTestConfig)
 
@@ -86,6 +88,11 @@ func TestPodSpec(t *testing.T) {
 		"my-priority-class")
 	assert.Equal(t, 2, len(d.Spec.Template.Annotations))
 
+	// the labels of the cluster are added without replacing the labels of rook
+	assert.Equal(t, "value", d.Labels["my-label"])
+	assert.Equal(t, "value", d.Spec.Template.Labels["my-label"])
+	assert.Equal(t, AppName, d.Spec.Template.Labels[k8sutil.AppAttr])
+	assert.NotContains(t, d.Spec.Selector.MatchLabels, "my-label")
 }
 
 func TestServiceSpec(t *testing.T) {
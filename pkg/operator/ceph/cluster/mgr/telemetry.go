@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+)
+
+const telemetryModuleName = "telemetry"
+
+// defaultTelemetryChannels are the channels sent by ceph when none are set
+var defaultTelemetryChannels = []string{cephv1.TelemetryChannelBasic, cephv1.TelemetryChannelCrash, cephv1.TelemetryChannelDevice}
+
+// configureTelemetry applies the channels and the contact info of the telemetry settings, then turns the telemetry
+// on or off. The settings are applied again on every orchestration, the telemetry being only turned on or off when
+// it changed.
+func (c *Cluster) configureTelemetry() error {
+	if c.telemetry.Enabled && !c.clusterInfo.CephVersion.IsAtLeastOctopus() {
+		// the telemetry module is always on as of Octopus
+		if err := client.MgrEnableModule(c.context, c.Namespace, telemetryModuleName, false); err != nil {
+			return errors.Wrapf(err, "failed to enable mgr module %q", telemetryModuleName)
+		}
+	}
+
+	monStore := config.GetMonStore(c.context, c.Namespace)
+	if err := monStore.SetAll(telemetryOptions(c.telemetry)...); err != nil {
+		return errors.Wrap(err, "failed to set the telemetry channels")
+	}
+	ident := []struct{ name, value string }{
+		{"contact", c.telemetry.Contact},
+		{"description", c.telemetry.Description},
+		{"organization", c.telemetry.Organization},
+	}
+	for _, setting := range ident {
+		option := fmt.Sprintf("mgr/%s/%s", telemetryModuleName, setting.name)
+		var err error
+		if setting.value == "" {
+			err = monStore.Delete("mgr", option)
+		} else {
+			err = monStore.Set("mgr", option, setting.value)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to apply the telemetry %s", setting.name)
+		}
+	}
+
+	status, err := client.GetTelemetryStatus(c.context, c.Namespace)
+	if err != nil {
+		if !c.telemetry.Enabled {
+			// the module of the releases before Octopus is not enabled, the telemetry being off
+			logger.Debugf("failed to get the telemetry status, the telemetry module is not enabled. %v", err)
+			return nil
+		}
+		return err
+	}
+	if status.Enabled == c.telemetry.Enabled {
+		return nil
+	}
+	logger.Infof("turning the telemetry of cluster %q on: %t", c.Namespace, c.telemetry.Enabled)
+	return client.SetTelemetry(c.context, c.Namespace, c.telemetry.Enabled)
+}
+
+// telemetryOptions returns the options turning the channels of the telemetry settings on and the others off
+func telemetryOptions(telemetry *cephv1.TelemetrySpec) []config.Option {
+	channels := telemetry.Channels
+	if len(channels) == 0 {
+		channels = defaultTelemetryChannels
+	}
+	options := []config.Option{}
+	for _, channel := range cephv1.TelemetryChannels {
+		enabled := false
+		for _, c := range channels {
+			enabled = enabled || c == channel
+		}
+		option := fmt.Sprintf("mgr/%s/channel_%s", telemetryModuleName, channel)
+		options = append(options, config.Option{Who: "mgr", Option: option, Value: strconv.FormatBool(enabled)})
+	}
+	return options
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mgr
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureTelemetry(t *testing.T) {
+	commands := []string{}
+	telemetryOn := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			// the args without the connection and format flags
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--connect-timeout") {
+					commands = append(commands, strings.Join(args[:i], " "))
+				}
+			}
+			if args[0] == "telemetry" && args[1] == "status" {
+				if telemetryOn {
+					return `{"enabled": true, "channel_basic": true}`, nil
+				}
+				return `{"enabled": false, "channel_basic": true}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &Cluster{
+		clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Octopus},
+		context:     &clusterd.Context{Executor: executor},
+		Namespace:   "ns",
+		telemetry: &cephv1.TelemetrySpec{
+			Enabled:      true,
+			Channels:     []string{cephv1.TelemetryChannelBasic, cephv1.TelemetryChannelIdent},
+			Contact:      "storage@example.com",
+			Organization: "Example",
+		},
+	}
+	assert.NoError(t, c.configureTelemetry())
+	assert.Equal(t, []string{
+		"config set mgr mgr/telemetry/channel_basic true",
+		"config set mgr mgr/telemetry/channel_crash false",
+		"config set mgr mgr/telemetry/channel_device false",
+		"config set mgr mgr/telemetry/channel_ident true",
+		"config set mgr mgr/telemetry/contact storage@example.com",
+		"config rm mgr mgr/telemetry/description",
+		"config set mgr mgr/telemetry/organization Example",
+		"telemetry status",
+		"telemetry on --license sharing-1-0",
+	}, commands)
+
+	// the telemetry already on is not turned on again
+	commands = []string{}
+	telemetryOn = true
+	assert.NoError(t, c.configureTelemetry())
+	assert.Equal(t, "telemetry status", commands[len(commands)-1])
+
+	// the telemetry is turned off with the default channels
+	commands = []string{}
+	c.telemetry = &cephv1.TelemetrySpec{}
+	assert.NoError(t, c.configureTelemetry())
+	assert.Contains(t, commands, "config set mgr mgr/telemetry/channel_crash true")
+	assert.Contains(t, commands, "config set mgr mgr/telemetry/channel_ident false")
+	assert.Equal(t, "telemetry off", commands[len(commands)-1])
+}
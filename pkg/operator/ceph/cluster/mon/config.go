@@ -32,6 +32,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
@@ -65,6 +66,56 @@ func (c *Cluster) genMonSharedKeyring() string {
 	)
 }
 
+// RotateAdminKey replaces the key of the admin. The new key is stored in the mon secret before it is imported, so
+// that the operator always loads the key of the admin from the secret, the previous key being restored if the import
+// fails. The keyrings of the mons and of the admin are updated with the new key.
+func (c *Cluster) RotateAdminKey() error {
+	key, err := client.GenerateAuthKey(c.context)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the admin key")
+	}
+	previousKey := c.ClusterInfo.AdminSecret
+
+	if err := c.storeAdminSecret(key); err != nil {
+		return err
+	}
+	// the import runs with the previous key the connection config still holds
+	if err := client.AuthImportKey(c.context, c.Namespace, client.AdminUsername, key, nil); err != nil {
+		if rErr := c.storeAdminSecret(previousKey); rErr != nil {
+			logger.Errorf("failed to restore the previous admin key. %v", rErr)
+		}
+		return errors.Wrap(err, "failed to import the admin key")
+	}
+
+	c.ClusterInfo.AdminSecret = key
+	if err := WriteConnectionConfig(c.context, c.ClusterInfo); err != nil {
+		return errors.Wrap(err, "failed to write the connection config with the admin key")
+	}
+	k := keyring.GetSecretStore(c.context, c.Namespace, &c.ownerRef)
+	if err := k.CreateOrUpdate(keyringStoreName, c.genMonSharedKeyring()); err != nil {
+		return errors.Wrap(err, "failed to save mon keyring secret")
+	}
+	if err := k.Admin().CreateOrUpdate(c.ClusterInfo); err != nil {
+		return errors.Wrap(err, "failed to save admin keyring secret")
+	}
+
+	logger.Infof("rotated the admin key of cluster %q", c.Namespace)
+	return nil
+}
+
+// storeAdminSecret sets the key of the admin in the mon secret
+func (c *Cluster) storeAdminSecret(key string) error {
+	secret, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Get(AppName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrap(err, "failed to get mon secrets")
+	}
+	secret.Data[AdminSecretName] = []byte(key)
+	if _, err := c.context.Clientset.CoreV1().Secrets(c.Namespace).Update(secret); err != nil {
+		return errors.Wrap(err, "failed to update the admin key of the mon secrets")
+	}
+	return nil
+}
+
 // return mon data dir path relative to the dataDirHostPath given a mon's name
 func dataDirRelativeHostPath(monName string) string {
 	monHostDir := monName // support legacy case where the mon name is "mon#" and not a lettered ID
@@ -87,6 +138,7 @@ func CreateOrLoadClusterInfo(context *clusterd.Context, namespace string, ownerR
 	maxMonID := -1
 	monMapping := &Mapping{
 		Node: map[string]*NodeInfo{},
+		Zone: map[string]string{},
 	}
 
 	secrets, err := context.Clientset.CoreV1().Secrets(namespace).Get(AppName, metav1.GetOptions{})
@@ -132,9 +184,7 @@ func ValidateAndLoadExternalClusterSecrets(context *clusterd.Context, namespace
 
 	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(OperatorCreds, metav1.GetOptions{})
 	if err != nil {
-		if !kerrors.IsNotFound(err) {
-			return externalCred, errors.Wrap(err, "failed to get external user secret")
-		}
+		return externalCred, errors.Wrap(err, "failed to get external user secret")
 	}
 	// Populate external credential
 	externalCred.Username = string(secret.Data["userID"])
@@ -187,6 +237,7 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 	maxMonID := -1
 	monMapping := &Mapping{
 		Node: map[string]*NodeInfo{},
+		Zone: map[string]string{},
 	}
 
 	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
@@ -18,6 +18,7 @@ package mon
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -28,23 +29,49 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephutil "github.com/rook/rook/pkg/daemon/ceph/util"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 var (
 	// HealthCheckInterval is the interval to check if the mons are in quorum
 	HealthCheckInterval = 45 * time.Second
-	// MonOutTimeout is the duration to wait before removing/failover to a new mon pod
+	// MonOutTimeout is the default duration to wait before removing/failover to a new mon pod
 	MonOutTimeout = 600 * time.Second
 )
 
+const (
+	// monFailoverReason is the event reason emitted when an unhealthy mon is replaced by a new mon
+	monFailoverReason = "MonFailover"
+	// monRemovedReason is the event reason emitted when an unhealthy mon is removed without being replaced
+	monRemovedReason = "MonRemoved"
+	// monHealthStateKey is the key of the state of the mon health checker in the health state configmap
+	monHealthStateKey = "mon"
+)
+
 // HealthChecker aggregates the mon/cluster info needed to check the health of the monitors
 type HealthChecker struct {
-	monCluster  *Cluster
-	clusterSpec *cephv1.ClusterSpec
-	interval    time.Duration
+	monCluster    *Cluster
+	clusterSpec   *cephv1.ClusterSpec
+	interval      time.Duration
+	checkCallback func()
+	// savedState is the state last saved to the health state configmap
+	savedState monHealthState
+	// logger logs the lines of the mon health check with the namespace and the name of the cluster
+	logger log.Logger
+}
+
+// monHealthState is the state of the mon health checker kept across the restarts of the operator
+type monHealthState struct {
+	// DownSince are the times the mons were first found out of quorum, from which their failover timeout runs
+	DownSince map[string]time.Time `json:"downSince,omitempty"`
+	// LastFailover is the time the last unhealthy mon was failed over or removed
+	LastFailover *time.Time `json:"lastFailover,omitempty"`
 }
 
 // NewHealthChecker creates a new HealthChecker object
@@ -53,55 +80,181 @@ func NewHealthChecker(monCluster *Cluster, clusterSpec *cephv1.ClusterSpec) *Hea
 		monCluster:  monCluster,
 		clusterSpec: clusterSpec,
 		interval:    HealthCheckInterval,
+		logger: log.New(logger, log.Fields{
+			log.NamespaceField: monCluster.Namespace,
+			log.ClusterField:   monCluster.ownerRef.Name,
+			log.CheckField:     "mon",
+		}),
 	}
+	// the mons failed over by the health check log with the fields of the check
+	monCluster.healthLogger = h.logger
+
+	h.updateConfig(clusterSpec.HealthCheck)
+
+	return h
+}
+
+// updateConfig reloads the settings of the checker from the health check spec of the cluster
+func (hc *HealthChecker) updateConfig(healthCheck cephv1.CephClusterHealthCheckSpec) {
+	monCluster := hc.monCluster
+
+	// the quorum status must not block the health checker on a hung mon
+	monCluster.healthContext = controller.HealthCheckContext(monCluster.context, healthCheck)
 
-	monCRDTimeoutSetting := clusterSpec.HealthCheck.DaemonHealth.Monitor.Timeout
+	// a mon must be out of quorum for the whole grace period across consecutive checks before it is failed over
+	monCluster.monOutTimeout = MonOutTimeout
+	monCRDTimeoutSetting := healthCheck.DaemonHealth.Monitor.Timeout
 	if monCRDTimeoutSetting != "" {
 		if monTimeout, err := time.ParseDuration(monCRDTimeoutSetting); err == nil {
-			MonOutTimeout = monTimeout
+			hc.logger.Infof("ceph mon failover grace period in namespace %q is %q", monCluster.Namespace, monCRDTimeoutSetting)
+			monCluster.monOutTimeout = monTimeout
 		}
 	}
 
-	checkInterval := clusterSpec.HealthCheck.DaemonHealth.Monitor.Interval
+	// the failover can be suspended while a mon is down on purpose
+	monCluster.failoverDisabled = healthCheck.DaemonHealth.Monitor.DisableFailover
+	if monCluster.failoverDisabled {
+		hc.logger.Infof("ceph mon failover in namespace %q is disabled", monCluster.Namespace)
+	}
+
+	hc.interval = HealthCheckInterval
+	checkInterval := healthCheck.DaemonHealth.Monitor.Interval
 	// allow overriding the check interval
 	if checkInterval != "" {
 		if duration, err := time.ParseDuration(checkInterval); err == nil {
-			logger.Infof("ceph mon status in namespace %q check interval %q", monCluster.Namespace, checkInterval)
-			h.interval = duration
+			hc.logger.Infof("ceph mon status in namespace %q check interval %q", monCluster.Namespace, checkInterval)
+			hc.interval = duration
 		}
 	}
-
-	return h
 }
 
-// Check periodically checks the health of the monitors
-func (hc *HealthChecker) Check(stopCh chan struct{}) {
+// Check periodically checks the health of the monitors, or immediately when triggered
+// The settings of the checker are reloaded every time the config channel receives a health check spec.
+func (hc *HealthChecker) Check(stopCh chan struct{}, triggerCh <-chan struct{}, configCh <-chan cephv1.CephClusterHealthCheckSpec) {
 	// Populate spec with clusterSpec
 	if hc.clusterSpec.External.Enable {
 		hc.monCluster.spec = *hc.clusterSpec
 	}
+	hc.loadState()
 
 	for {
 		select {
 		case <-stopCh:
-			logger.Infof("stopping monitoring of mons in namespace %q", hc.monCluster.Namespace)
+			hc.logger.Infof("stopping monitoring of mons in namespace %q", hc.monCluster.Namespace)
 			return
 
 		case <-time.After(hc.interval):
-			logger.Debugf("checking health of mons")
-			err := hc.monCluster.checkHealth()
-			if err != nil {
-				logger.Warningf("failed to check mon health. %v", err)
-			}
+			hc.checkHealth()
+
+		case <-triggerCh:
+			hc.logger.Debugf("mon health check triggered")
+			hc.checkHealth()
+
+		case healthCheck := <-configCh:
+			hc.logger.Infof("reloading the mon health check settings in namespace %q", hc.monCluster.Namespace)
+			hc.monCluster.acquireOrchestrationLock()
+			hc.updateConfig(healthCheck)
+			hc.monCluster.releaseOrchestrationLock()
+		}
+	}
+}
+
+// SetEventRecorder sets the recorder of the events on the CephCluster when a mon is failed over
+func (hc *HealthChecker) SetEventRecorder(recorder record.EventRecorder) {
+	hc.monCluster.recorder = recorder
+}
+
+// SetCheckCallback sets a function called every time a check completes
+func (hc *HealthChecker) SetCheckCallback(callback func()) {
+	hc.checkCallback = callback
+}
+
+func (hc *HealthChecker) checkHealth() {
+	hc.logger.Debugf("checking health of mons")
+	defer controller.ObserveHealthCheckDuration(hc.monCluster.Namespace, "mon", time.Now())
+	err := hc.monCluster.checkHealth()
+	if err != nil {
+		hc.logger.Warningf("failed to check mon health. %v", err)
+	}
+	hc.saveState()
+	if hc.checkCallback != nil {
+		hc.checkCallback()
+	}
+}
+
+// loadState restores the times the mons went out of quorum before the restart of the operator, so that their failover
+// timeouts resume instead of starting over
+func (hc *HealthChecker) loadState() {
+	c := hc.monCluster
+	state := monHealthState{}
+	if err := controller.LoadHealthState(c.context.Clientset, c.Namespace, monHealthStateKey, &state); err != nil {
+		hc.logger.Warningf("failed to load the mon health state in namespace %q. %v", c.Namespace, err)
+		return
+	}
+
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+	for name, since := range state.DownSince {
+		if _, ok := c.monTimeoutList[name]; !ok {
+			hc.logger.Infof("mon %q has been out of quorum since %s", name, since.String())
+			c.monTimeoutList[name] = since
+		}
+	}
+	if state.LastFailover != nil && c.lastMonFailover.IsZero() {
+		hc.logger.Infof("the last mon failover in namespace %q was at %s", c.Namespace, state.LastFailover.String())
+		c.lastMonFailover = *state.LastFailover
+	}
+	hc.savedState = state
+}
+
+// saveState saves the times the mons went out of quorum and the time of the last failover when they changed
+func (hc *HealthChecker) saveState() {
+	c := hc.monCluster
+	state := monHealthState{}
+	c.acquireOrchestrationLock()
+	if len(c.monTimeoutList) > 0 {
+		state.DownSince = make(map[string]time.Time, len(c.monTimeoutList))
+		for name, since := range c.monTimeoutList {
+			state.DownSince[name] = since
 		}
 	}
+	if !c.lastMonFailover.IsZero() {
+		lastFailover := c.lastMonFailover
+		state.LastFailover = &lastFailover
+	}
+	c.releaseOrchestrationLock()
+
+	if reflect.DeepEqual(state, hc.savedState) {
+		return
+	}
+	if err := controller.SaveHealthState(c.context.Clientset, c.Namespace, monHealthStateKey, state, &c.ownerRef); err != nil {
+		hc.logger.Warningf("failed to save the mon health state in namespace %q. %v", c.Namespace, err)
+		return
+	}
+	hc.savedState = state
+}
+
+// recordEvent records an event on the CephCluster, if an event recorder is set
+func (c *Cluster) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Eventf(controller.ClusterEventObject(c.ownerRef, c.Namespace), eventType, reason, messageFmt, args...)
+}
+
+// healthCheckContext returns the context of the ceph commands querying the health of the mons
+func (c *Cluster) healthCheckContext() *clusterd.Context {
+	if c.healthContext != nil {
+		return c.healthContext
+	}
+	return c.context
 }
 
 func (c *Cluster) checkHealth() error {
 	c.acquireOrchestrationLock()
 	defer c.releaseOrchestrationLock()
 
-	logger.Debugf("Checking health for mons in cluster. %s", c.ClusterInfo.Name)
+	c.healthLogger.Debugf("Checking health for mons in cluster. %s", c.ClusterInfo.Name)
 
 	// For an external connection we use a special function to get the status
 	if c.spec.External.Enable {
@@ -109,12 +262,12 @@ func (c *Cluster) checkHealth() error {
 		var quorumStatus client.MonStatusResponse
 		// backward compatibility for existing deployments on 1.2 that are using the admin key
 		if c.ClusterInfo.AdminSecret != AdminSecretName {
-			quorumStatus, err = client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name)
+			quorumStatus, err = client.GetMonQuorumStatus(c.healthCheckContext(), c.ClusterInfo.Name)
 			if err != nil {
 				return errors.Wrap(err, "failed to get external mon quorum status")
 			}
 		} else {
-			quorumStatus, err = client.GetMonQuorumStatusHealth(c.context, c.ClusterInfo.Name, c.ClusterInfo.ExternalCred.Username)
+			quorumStatus, err = client.GetMonQuorumStatusHealth(c.healthCheckContext(), c.ClusterInfo.Name, c.ClusterInfo.ExternalCred.Username)
 			if err != nil {
 				return errors.Wrap(err, "failed to get external mon quorum status")
 			}
@@ -125,16 +278,16 @@ func (c *Cluster) checkHealth() error {
 
 	// connect to the mons
 	// get the status and check for quorum
-	quorumStatus, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name)
+	quorumStatus, err := client.GetMonQuorumStatus(c.healthCheckContext(), c.ClusterInfo.Name)
 	if err != nil {
 		return errors.Wrap(err, "failed to get mon quorum status")
 	}
-	logger.Debugf("Mon quorum status: %+v", quorumStatus)
+	c.healthLogger.Debugf("Mon quorum status: %+v", quorumStatus)
 
 	// Use a local mon count in case the user updates the crd in another goroutine.
 	// We need to complete a health check with a consistent value.
 	desiredMonCount := c.spec.Mon.Count
-	logger.Debugf("targeting the mon count %d", desiredMonCount)
+	c.healthLogger.Debugf("targeting the mon count %d", desiredMonCount)
 
 	// Source of truth of which mons should exist is our *clusterInfo*
 	monsNotFound := map[string]interface{}{}
@@ -155,12 +308,12 @@ func (c *Cluster) checkHealth() error {
 			// when the mon isn't in the clusterInfo, but is in quorum and there are
 			// enough mons, remove it else remove it on the next run
 			if inQuorum && len(quorumStatus.MonMap.Mons) > desiredMonCount {
-				logger.Warningf("mon %q not in source of truth but in quorum, removing", mon.Name)
+				c.healthLogger.Warningf("mon %q not in source of truth but in quorum, removing", mon.Name)
 				if err := c.removeMon(mon.Name); err != nil {
-					logger.Warningf("failed to remove mon %q. %v", mon.Name, err)
+					c.healthLogger.Warningf("failed to remove mon %q. %v", mon.Name, err)
 				}
 			} else {
-				logger.Warningf(
+				c.healthLogger.Warningf(
 					"mon %q not in source of truth and not in quorum, not enough mons to remove now (wanted: %d, current: %d)",
 					mon.Name,
 					desiredMonCount,
@@ -170,16 +323,16 @@ func (c *Cluster) checkHealth() error {
 		}
 
 		if inQuorum {
-			logger.Debugf("mon %q found in quorum", mon.Name)
+			c.healthLogger.Debugf("mon %q found in quorum", mon.Name)
 			// delete the "timeout" for a mon if the pod is in quorum again
 			if _, ok := c.monTimeoutList[mon.Name]; ok {
 				delete(c.monTimeoutList, mon.Name)
-				logger.Infof("mon %q is back in quorum, removed from mon out timeout list", mon.Name)
+				c.healthLogger.Infof("mon %q is back in quorum, removed from mon out timeout list", mon.Name)
 			}
 			continue
 		}
 
-		logger.Debugf("mon %q NOT found in quorum. Mon quorum status: %+v", mon.Name, quorumStatus)
+		c.healthLogger.Debugf("mon %q NOT found in quorum. Mon quorum status: %+v", mon.Name, quorumStatus)
 		allMonsInQuorum = false
 
 		// If not yet set, add the current time, for the timeout
@@ -190,16 +343,21 @@ func (c *Cluster) checkHealth() error {
 
 		// when the timeout for the mon has been reached, continue to the
 		// normal failover/delete mon pod part of the code
-		if time.Since(c.monTimeoutList[mon.Name]) <= MonOutTimeout {
-			timeToFailover := int(MonOutTimeout.Seconds() - time.Since(c.monTimeoutList[mon.Name]).Seconds())
-			logger.Warningf("mon %q not found in quorum, waiting for timeout (%d seconds left) before failover", mon.Name, timeToFailover)
+		if time.Since(c.monTimeoutList[mon.Name]) <= c.monOutTimeout {
+			timeToFailover := int(c.monOutTimeout.Seconds() - time.Since(c.monTimeoutList[mon.Name]).Seconds())
+			c.healthLogger.Warningf("mon %q not found in quorum, waiting for timeout (%d seconds left) before failover", mon.Name, timeToFailover)
 
 			// Restart the mon if it is stuck on a failed node
 			c.restartMonIfStuckTerminating(mon.Name)
 			continue
 		}
 
-		logger.Warningf("mon %q NOT found in quorum and timeout exceeded, mon will be failed over", mon.Name)
+		if c.failoverDisabled {
+			c.healthLogger.Warningf("mon %q NOT found in quorum and timeout exceeded, but the mon failover is disabled", mon.Name)
+			continue
+		}
+
+		c.healthLogger.Warningf("mon %q NOT found in quorum and timeout exceeded, mon will be failed over", mon.Name)
 		c.failMon(len(quorumStatus.MonMap.Mons), desiredMonCount, mon.Name)
 		// only deal with one unhealthy mon per health check
 		return nil
@@ -208,7 +366,11 @@ func (c *Cluster) checkHealth() error {
 	// after all unhealthy mons have been removed or failed over
 	// handle all mons that haven't been in the Ceph mon map
 	for mon := range monsNotFound {
-		logger.Warningf("mon %s NOT found in ceph mon map, failover", mon)
+		if c.failoverDisabled {
+			c.healthLogger.Warningf("mon %s NOT found in ceph mon map, but the mon failover is disabled", mon)
+			continue
+		}
+		c.healthLogger.Warningf("mon %s NOT found in ceph mon map, failover", mon)
 		c.failMon(len(c.ClusterInfo.Monitors), desiredMonCount, mon)
 		// only deal with one "not found in ceph mon map" mon per health check
 		return nil
@@ -216,50 +378,60 @@ func (c *Cluster) checkHealth() error {
 
 	// create/start new mons when there are fewer mons than the desired count in the CRD
 	if len(quorumStatus.MonMap.Mons) < desiredMonCount {
-		logger.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(quorumStatus.MonMap.Mons), desiredMonCount)
+		c.healthLogger.Infof("adding mons. currently %d mons are in quorum and the desired count is %d.", len(quorumStatus.MonMap.Mons), desiredMonCount)
 		return c.startMons(desiredMonCount)
 	}
 
 	// remove extra mons if the desired count has decreased in the CRD and all the mons are currently healthy
 	if allMonsInQuorum && len(quorumStatus.MonMap.Mons) > desiredMonCount {
 		if desiredMonCount < 2 && len(quorumStatus.MonMap.Mons) == 2 {
-			logger.Warningf("cannot reduce mon quorum size from 2 to 1")
+			c.healthLogger.Warningf("cannot reduce mon quorum size from 2 to 1")
 		} else {
-			logger.Infof("removing an extra mon. currently %d are in quorum and only %d are desired", len(quorumStatus.MonMap.Mons), desiredMonCount)
+			c.healthLogger.Infof("removing an extra mon. currently %d are in quorum and only %d are desired", len(quorumStatus.MonMap.Mons), desiredMonCount)
 			return c.removeMon(quorumStatus.MonMap.Mons[0].Name)
 		}
 	}
 
 	// remove any pending/not needed mon canary deployment if everything is ok
 	if allMonsInQuorum && len(quorumStatus.MonMap.Mons) == desiredMonCount {
-		logger.Debug("mon cluster is healthy, removing any existing canary deployment")
+		c.healthLogger.Debug("mon cluster is healthy, removing any existing canary deployment")
 		c.removeCanaryDeployments()
 	}
 
+	// keep the csi config in sync with the mons in case it was changed or deleted since the last mon update
+	if err := csi.SaveClusterConfig(c.context.Clientset, c.Namespace, c.ClusterInfo, c.csiConfigMutex); err != nil {
+		c.healthLogger.Warningf("failed to update csi cluster config. %v", err)
+	}
+
 	return nil
 }
 
 // failMon compares the monCount against desiredMonCount
 func (c *Cluster) failMon(monCount, desiredMonCount int, name string) {
+	controller.IncMonFailoverMetric(c.Namespace)
+	c.lastMonFailover = time.Now()
 	if monCount > desiredMonCount {
 		// no need to create a new mon since we have an extra
+		c.recordEvent(v1.EventTypeWarning, monRemovedReason, "removing unhealthy mon %q, %d mons are left for a desired count of %d", name, monCount-1, desiredMonCount)
 		if err := c.removeMon(name); err != nil {
-			logger.Errorf("failed to remove mon %q. %v", name, err)
+			c.healthLogger.Errorf("failed to remove mon %q. %v", name, err)
 		}
 	} else {
 		// bring up a new mon to replace the unhealthy mon
+		c.recordEvent(v1.EventTypeWarning, monFailoverReason, "failing over unhealthy mon %q", name)
 		if err := c.failoverMon(name); err != nil {
-			logger.Errorf("failed to failover mon %q. %v", name, err)
+			c.healthLogger.Errorf("failed to failover mon %q. %v", name, err)
 		}
 	}
 }
 
 func (c *Cluster) failoverMon(name string) error {
-	logger.Infof("Failing over monitor %q", name)
+	c.healthLogger.Infof("Failing over monitor %q", name)
 
-	// Start a new monitor
+	// Start a new monitor, in the zone of the failed mon of a stretch cluster
 	m := c.newMonConfig(c.maxMonID + 1)
-	logger.Infof("starting new mon: %+v", m)
+	m.Zone = c.mapping.Zone[name]
+	c.healthLogger.Infof("starting new mon: %+v", m)
 
 	mConf := []*monConfig{m}
 
@@ -268,7 +440,7 @@ func (c *Cluster) failoverMon(name string) error {
 		return errors.Wrap(err, "failed to place new mon on a node")
 	}
 
-	if c.Network.IsHost() {
+	if c.Network.IsHostFor(cephv1.KeyMon) {
 		node, ok := c.mapping.Node[m.DaemonName]
 		if !ok {
 			return errors.Errorf("mon %s doesn't exist in assignment map", m.DaemonName)
@@ -297,47 +469,65 @@ func (c *Cluster) failoverMon(name string) error {
 
 // make a best effort to remove the mon and all its resources
 func (c *Cluster) removeMon(daemonName string) error {
-	logger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
-
-	resourceName := resourceName(daemonName)
+	c.healthLogger.Infof("ensuring removal of unhealthy monitor %s", daemonName)
 
 	// Remove the mon pod if it is still there
+	c.removeMonDeployment(daemonName)
+
+	// Remove the bad monitor from quorum
+	if err := removeMonitorFromQuorum(c.context, c.ClusterInfo.Name, daemonName); err != nil {
+		c.healthLogger.Errorf("failed to remove mon %q from quorum. %v", daemonName, err)
+	}
+
+	return c.removeMonResources(daemonName)
+}
+
+func monDeleteOptions() *metav1.DeleteOptions {
 	var gracePeriod int64
 	propagation := metav1.DeletePropagationForeground
-	options := &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
-	if err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Delete(resourceName, options); err != nil {
+	return &metav1.DeleteOptions{GracePeriodSeconds: &gracePeriod, PropagationPolicy: &propagation}
+}
+
+// removeMonDeployment deletes the deployment of the mon, if still there
+func (c *Cluster) removeMonDeployment(daemonName string) {
+	resourceName := resourceName(daemonName)
+	if err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Delete(resourceName, monDeleteOptions()); err != nil {
 		if kerrors.IsNotFound(err) {
-			logger.Infof("dead mon %s was already gone", resourceName)
+			c.healthLogger.Infof("dead mon %s was already gone", resourceName)
 		} else {
-			logger.Errorf("failed to remove dead mon deployment %q. %v", resourceName, err)
+			c.healthLogger.Errorf("failed to remove dead mon deployment %q. %v", resourceName, err)
 		}
 	}
+}
+
+// removeMonResources removes the mon from the cluster info and deletes its service and its PVC, the mon config
+// being saved without the mon
+func (c *Cluster) removeMonResources(daemonName string) error {
+	resourceName := resourceName(daemonName)
+	options := monDeleteOptions()
 
-	// Remove the bad monitor from quorum
-	if err := removeMonitorFromQuorum(c.context, c.ClusterInfo.Name, daemonName); err != nil {
-		logger.Errorf("failed to remove mon %q from quorum. %v", daemonName, err)
-	}
 	delete(c.ClusterInfo.Monitors, daemonName)
 	// check if a mapping exists for the mon
 	if _, ok := c.mapping.Node[daemonName]; ok {
 		delete(c.mapping.Node, daemonName)
 	}
+	delete(c.mapping.Zone, daemonName)
 
 	// Remove the service endpoint
 	if err := c.context.Clientset.CoreV1().Services(c.Namespace).Delete(resourceName, options); err != nil {
 		if kerrors.IsNotFound(err) {
-			logger.Infof("dead mon service %s was already gone", resourceName)
+			c.healthLogger.Infof("dead mon service %s was already gone", resourceName)
 		} else {
-			logger.Errorf("failed to remove dead mon service %q. %v", resourceName, err)
+			c.healthLogger.Errorf("failed to remove dead mon service %q. %v", resourceName, err)
 		}
 	}
 
 	// Remove the PVC backing the mon if it existed
 	if err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(resourceName, &metav1.DeleteOptions{}); err != nil {
 		if kerrors.IsNotFound(err) {
-			logger.Infof("mon pvc did not exist %q", resourceName)
+			c.healthLogger.Infof("mon pvc did not exist %q", resourceName)
 		} else {
-			logger.Errorf("failed to remove dead mon pvc %q. %v", resourceName, err)
+			c.healthLogger.Errorf("failed to remove dead mon pvc %q. %v", resourceName, err)
 		}
 	}
 
@@ -393,15 +583,15 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 		oldClusterInfoMonitors[mon.Name] = mon
 		delete(c.ClusterInfo.Monitors, monName)
 	}
-	logger.Debugf("ClusterInfo is now Empty, refilling it from status.MonMap.Mons")
+	c.healthLogger.Debugf("ClusterInfo is now Empty, refilling it from status.MonMap.Mons")
 
 	monCount := len(status.MonMap.Mons)
 	if monCount%2 == 0 {
-		logger.Warningf("external cluster mon count is even (%d), should be uneven, continuing.", monCount)
+		c.healthLogger.Warningf("external cluster mon count is even (%d), should be uneven, continuing.", monCount)
 	}
 
 	if monCount == 1 {
-		logger.Warning("external cluster mon count is 1, consider adding new monitors.")
+		c.healthLogger.Warning("external cluster mon count is 1, consider adding new monitors.")
 	}
 
 	// Iterate over the mons first and compare it with ClusterInfo
@@ -420,25 +610,25 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 				// find IP and Port of that Mon
 				monIP := cephutil.GetIPFromEndpoint(endpoint)
 				monPort := cephutil.GetPortFromEndpoint(endpoint)
-				logger.Infof("new external mon %q found: %s, adding it", mon.Name, endpoint)
+				c.healthLogger.Infof("new external mon %q found: %s, adding it", mon.Name, endpoint)
 				c.ClusterInfo.Monitors[mon.Name] = cephconfig.NewMonInfo(mon.Name, monIP, monPort)
 			} else {
-				logger.Debugf("mon %q is not in quorum and not in ClusterInfo", mon.Name)
+				c.healthLogger.Debugf("mon %q is not in quorum and not in ClusterInfo", mon.Name)
 			}
 			changed = true
 		} else {
 			// mon is in ClusterInfo
-			logger.Debugf("mon %q is in ClusterInfo, let's test if it's in quorum", mon.Name)
+			c.healthLogger.Debugf("mon %q is in ClusterInfo, let's test if it's in quorum", mon.Name)
 			if !inQuorum {
 				// this mon was in clusterInfo but is not part of the quorum anymore
 				// thus don't add it again to ClusterInfo
-				logger.Infof("monitor %q is not part of the external cluster monitor quorum, removing it", mon.Name)
+				c.healthLogger.Infof("monitor %q is not part of the external cluster monitor quorum, removing it", mon.Name)
 				changed = true
 			} else {
 				// this mon was in clusterInfo and is still in the quorum
 				// add it again
 				c.ClusterInfo.Monitors[mon.Name] = oldClusterInfoMonitors[mon.Name]
-				logger.Debugf("everything is fine mon %q in the clusterInfo and its quorum status is %v", mon.Name, inQuorum)
+				c.healthLogger.Debugf("everything is fine mon %q in the clusterInfo and its quorum status is %v", mon.Name, inQuorum)
 			}
 		}
 	}
@@ -455,7 +645,7 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 		}
 	}
 
-	logger.Debugf("ClusterInfo.Monitors is %+v", c.ClusterInfo.Monitors)
+	c.healthLogger.Debugf("ClusterInfo.Monitors is %+v", c.ClusterInfo.Monitors)
 	return changed, nil
 }
 
@@ -463,7 +653,7 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 // If the pod is stuck in terminating state, go ahead and force delete the pod so K8s
 // will allow the mon to be restarted on another node.
 func (c *Cluster) restartMonIfStuckTerminating(monName string) error {
-	logger.Debugf("Checking for a stuck mon %q pod", monName)
+	c.healthLogger.Debugf("Checking for a stuck mon %q pod", monName)
 	labels := fmt.Sprintf("app=%s,mon=%s", AppName, monName)
 	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(metav1.ListOptions{LabelSelector: labels})
 	if err != nil {
@@ -471,7 +661,7 @@ func (c *Cluster) restartMonIfStuckTerminating(monName string) error {
 	}
 	for _, pod := range pods.Items {
 		if err := k8sutil.ForceDeletePodIfStuck(c.context, pod); err != nil {
-			logger.Warningf("skipping forced restart of mon %q. %v", monName, err)
+			c.healthLogger.Warningf("skipping forced restart of mon %q. %v", monName, err)
 		}
 	}
 	return nil
@@ -17,6 +17,7 @@ limitations under the License.
 package mon
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -30,6 +31,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
 	"github.com/rook/rook/pkg/operator/test"
@@ -40,6 +42,7 @@ import (
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestCheckHealth(t *testing.T) {
@@ -98,6 +101,62 @@ func TestCheckHealth(t *testing.T) {
 	}
 }
 
+func TestFailoverMonOnPVC(t *testing.T) {
+	var deploymentsUpdated *[]*apps.Deployment
+	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
+
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			return clienttest.MonInQuorumResponse(), nil
+		},
+	}
+	clientset := test.New(t, 1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{})
+	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 1}, "myversion")
+	c.spec.Mon.VolumeClaimTemplate = &v1.PersistentVolumeClaim{}
+	c.waitForStart = false
+	c.maxMonID = 0
+	defer testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+
+	// the mons on pvc are placed by the native scheduler
+	c.mapping.Node["a"] = nil
+	_, err := clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(&v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: c.Namespace},
+	})
+	assert.NoError(t, err)
+	scheduleMonitor = func(c *Cluster, mon *monConfig) (SchedulingResult, error) {
+		node, _ := clientset.CoreV1().Nodes().Get("node0", metav1.GetOptions{})
+		return SchedulingResult{Node: node}, nil
+	}
+
+	err = c.failoverMon("a")
+	assert.NoError(t, err)
+
+	// the new mon gets its own pvc and the pvc of the failed mon is removed
+	_, err = clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get("rook-ceph-mon-b", metav1.GetOptions{})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the new mon mounts its pvc instead of a host path and has no node selector
+	d, err := clientset.AppsV1().Deployments(c.Namespace).Get("rook-ceph-mon-b", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.True(t, controller.DaemonVolumesContainsPVC(d.Spec.Template.Spec.Volumes))
+	assert.Nil(t, d.Spec.Template.Spec.NodeSelector)
+	node, ok := c.mapping.Node["b"]
+	assert.True(t, ok)
+	assert.Nil(t, node)
+	_, ok = c.mapping.Node["a"]
+	assert.False(t, ok)
+}
+
 func TestCheckHealthNotFound(t *testing.T) {
 	var deploymentsUpdated *[]*apps.Deployment
 	updateDeploymentAndWait, deploymentsUpdated = testopk8s.UpdateDeploymentAndWaitStub()
@@ -119,6 +178,8 @@ func TestCheckHealthNotFound(t *testing.T) {
 	setCommonMonProperties(c, 2, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
 	c.waitForStart = false
 	defer os.RemoveAll(c.context.ConfigDir)
+	recorder := record.NewFakeRecorder(10)
+	NewHealthChecker(c, &cephv1.ClusterSpec{}).SetEventRecorder(recorder)
 
 	c.mapping.Node["a"] = &NodeInfo{
 		Name: "node0",
@@ -144,6 +205,9 @@ func TestCheckHealthNotFound(t *testing.T) {
 	// No updates in unit tests w/ workaround
 	assert.ElementsMatch(t, []string{}, testopk8s.DeploymentNamesUpdated(deploymentsUpdated))
 	testopk8s.ClearDeploymentsUpdated(deploymentsUpdated)
+	// the failover is reported on the cluster
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Equal(t, `Warning MonFailover failing over unhealthy mon "b"`, <-recorder.Events)
 
 	// recheck that the "not found" mon has been replaced with a new one
 	cm, err = c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Get(EndpointConfigMapName, metav1.GetOptions{})
@@ -369,7 +433,7 @@ func TestNewHealthChecker(t *testing.T) {
 	c := &Cluster{}
 	clusterSpec := &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{}}
 	time10s, _ := time.ParseDuration("10s")
-	clusterSpec10s := &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Monitor: cephv1.HealthCheckSpec{Interval: "10s"}}}}
+	clusterSpec10s := &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Monitor: cephv1.MonHealthCheckSpec{HealthCheckSpec: cephv1.HealthCheckSpec{Interval: "10s"}}}}}
 
 	type args struct {
 		monCluster  *Cluster
@@ -380,8 +444,8 @@ func TestNewHealthChecker(t *testing.T) {
 		args args
 		want *HealthChecker
 	}{
-		{"default-interval", args{c, clusterSpec}, &HealthChecker{c, clusterSpec, HealthCheckInterval}},
-		{"10s-interval", args{c, clusterSpec10s}, &HealthChecker{c, clusterSpec10s, time10s}},
+		{"default-interval", args{c, clusterSpec}, &HealthChecker{monCluster: c, clusterSpec: clusterSpec, interval: HealthCheckInterval}},
+		{"10s-interval", args{c, clusterSpec10s}, &HealthChecker{monCluster: c, clusterSpec: clusterSpec10s, interval: time10s}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -391,3 +455,153 @@ func TestNewHealthChecker(t *testing.T) {
 		})
 	}
 }
+
+func TestCheckHealthFailoverGracePeriod(t *testing.T) {
+	// mon c is out of quorum unless it is flagged as recovered
+	monCRecovered := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			resp := client.MonStatusResponse{Quorum: []int{0, 1}}
+			resp.MonMap.Mons = []client.MonMapEntry{
+				{Name: "a", Rank: 0, Address: "1.2.3.1"},
+				{Name: "b", Rank: 1, Address: "1.2.3.2"},
+				{Name: "c", Rank: 2, Address: "1.2.3.3"},
+			}
+			if monCRecovered {
+				resp.Quorum = append(resp.Quorum, 2)
+			}
+			serialized, _ := json.Marshal(resp)
+			return string(serialized), nil
+		},
+	}
+	clientset := test.New(t, 1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.waitForStart = false
+
+	// the grace period is threaded from the health check settings
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Timeout = "200ms"
+	NewHealthChecker(c, spec)
+	assert.Equal(t, 200*time.Millisecond, c.monOutTimeout)
+
+	// mon c is briefly down, the grace period starts
+	err := c.checkHealth()
+	assert.Nil(t, err)
+	_, ok := c.monTimeoutList["c"]
+	assert.True(t, ok)
+
+	// mon c recovers before the next check, by which time the initial grace period has expired
+	c.monTimeoutList["c"] = time.Now().Add(-time.Second)
+	monCRecovered = true
+	err = c.checkHealth()
+	assert.Nil(t, err)
+	_, ok = c.monTimeoutList["c"]
+	assert.False(t, ok)
+
+	// mon c is down again, the grace period starts over
+	monCRecovered = false
+	err = c.checkHealth()
+	assert.Nil(t, err)
+	since, ok := c.monTimeoutList["c"]
+	assert.True(t, ok)
+	assert.True(t, time.Since(since) < c.monOutTimeout)
+
+	// no failover happened
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+	_, ok = c.ClusterInfo.Monitors["c"]
+	assert.True(t, ok)
+
+	// the default grace period applies when not set
+	NewHealthChecker(c, &cephv1.ClusterSpec{})
+	assert.Equal(t, MonOutTimeout, c.monOutTimeout)
+}
+
+func TestCheckHealthFailoverDisabled(t *testing.T) {
+	// mon c is out of quorum
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			resp := client.MonStatusResponse{Quorum: []int{0, 1}}
+			resp.MonMap.Mons = []client.MonMapEntry{
+				{Name: "a", Rank: 0, Address: "1.2.3.1"},
+				{Name: "b", Rank: 1, Address: "1.2.3.2"},
+				{Name: "c", Rank: 2, Address: "1.2.3.3"},
+			}
+			serialized, _ := json.Marshal(resp)
+			return string(serialized), nil
+		},
+	}
+	clientset := test.New(t, 1)
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset: clientset,
+		ConfigDir: configDir,
+		Executor:  executor,
+	}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.waitForStart = false
+	recorder := record.NewFakeRecorder(10)
+
+	// the grace period expires immediately but the failover is disabled
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Timeout = "0s"
+	spec.HealthCheck.DaemonHealth.Monitor.DisableFailover = true
+	NewHealthChecker(c, spec).SetEventRecorder(recorder)
+	assert.True(t, c.failoverDisabled)
+
+	err := c.checkHealth()
+	assert.Nil(t, err)
+	err = c.checkHealth()
+	assert.Nil(t, err)
+
+	// no failover happened
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+	_, ok := c.ClusterInfo.Monitors["c"]
+	assert.True(t, ok)
+	_, ok = c.monTimeoutList["c"]
+	assert.True(t, ok)
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the failover is enabled by default
+	NewHealthChecker(c, &cephv1.ClusterSpec{})
+	assert.False(t, c.failoverDisabled)
+}
+
+func TestMonHealthState(t *testing.T) {
+	clientset := test.New(t, 1)
+	context := &clusterd.Context{Clientset: clientset}
+	c := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{Name: "rook-ceph"}, &sync.Mutex{})
+	hc := NewHealthChecker(c, &cephv1.ClusterSpec{})
+
+	// nothing to save while the mons are in quorum
+	hc.saveState()
+	_, err := clientset.CoreV1().ConfigMaps("ns").Get(controller.HealthStateConfigMapName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	since := time.Now().Add(-5 * time.Minute).Round(time.Second)
+	c.monTimeoutList["c"] = since
+	c.lastMonFailover = since
+	hc.saveState()
+
+	// the timeout of mon c resumes after the restart of the operator
+	restarted := New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{Name: "rook-ceph"}, &sync.Mutex{})
+	NewHealthChecker(restarted, &cephv1.ClusterSpec{}).loadState()
+	assert.True(t, since.Equal(restarted.monTimeoutList["c"]))
+	assert.True(t, since.Equal(restarted.lastMonFailover))
+
+	// mon c back in quorum is removed from the state
+	delete(c.monTimeoutList, "c")
+	hc.saveState()
+	restarted = New(context, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{Name: "rook-ceph"}, &sync.Mutex{})
+	NewHealthChecker(restarted, &cephv1.ClusterSpec{}).loadState()
+	assert.Equal(t, 0, len(restarted.monTimeoutList))
+}
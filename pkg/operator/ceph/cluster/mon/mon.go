@@ -41,11 +41,13 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/csi"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/log"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
@@ -125,6 +127,12 @@ type Cluster struct {
 	monPodRetryInterval time.Duration
 	monPodTimeout       time.Duration
 	monTimeoutList      map[string]time.Time
+	lastMonFailover     time.Time
+	monOutTimeout       time.Duration
+	failoverDisabled    bool
+	healthContext       *clusterd.Context
+	healthLogger        log.Logger
+	recorder            record.EventRecorder
 	mapping             *Mapping
 	ownerRef            metav1.OwnerReference
 	csiConfigMutex      *sync.Mutex
@@ -144,11 +152,15 @@ type monConfig struct {
 	// DataPathMap is the mapping relationship between mon data stored on the host and mon data
 	// stored in containers.
 	DataPathMap *config.DataPathMap
+	// Zone is the zone where the mon runs, empty if the mons are not spread across zones
+	Zone string
 }
 
 // Mapping is mon node and port mapping
 type Mapping struct {
 	Node map[string]*NodeInfo `json:"node"`
+	// Zone is the zone of each mon spread across the zones of a stretch cluster or the mon zones
+	Zone map[string]string `json:"zone,omitempty"`
 }
 
 // NodeInfo contains name and address of a node
@@ -175,9 +187,11 @@ func New(context *clusterd.Context, namespace, dataDirHostPath string, network c
 		monPodRetryInterval: 6 * time.Second,
 		monPodTimeout:       5 * time.Minute,
 		monTimeoutList:      map[string]time.Time{},
+		monOutTimeout:       MonOutTimeout,
 		Network:             network,
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
+			Zone: map[string]string{},
 		},
 		ownerRef:       ownerRef,
 		csiConfigMutex: csiConfigMutex,
@@ -196,7 +210,7 @@ func (c *Cluster) Start(clusterInfo *cephconfig.ClusterInfo, rookVersion string,
 	c.spec = spec
 
 	// fail if we were instructed to deploy more than one mon on the same machine with host networking
-	if c.Network.IsHost() && c.spec.Mon.AllowMultiplePerNode && c.spec.Mon.Count > 1 {
+	if c.Network.IsHostFor(cephv1.KeyMon) && c.spec.Mon.AllowMultiplePerNode && c.spec.Mon.Count > 1 {
 		return nil, errors.Errorf("refusing to deploy %d monitors on the same host since hostNetwork is %+v and allowMultiplePerNode is %t. only one monitor per node is allowed", c.spec.Mon.Count, c.Network, c.spec.Mon.AllowMultiplePerNode)
 	}
 
@@ -234,7 +248,7 @@ func (c *Cluster) startMons(targetCount int) error {
 	// only once and do it as early as possible in the mon orchestration.
 	setConfigsNeedsRetry := false
 	if existingCount > 0 {
-		err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network)
+		err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network, c.spec.LogCollector)
 		if err != nil {
 			// If we fail here, it could be because the mons are not healthy, and this might be
 			// fixed by updating the mon deployments. Instead of returning error here, log a
@@ -255,7 +269,7 @@ func (c *Cluster) startMons(targetCount int) error {
 			// values in the config database. Do this only when the existing count is zero so that
 			// this is only done once when the cluster is created.
 			if existingCount == 0 {
-				err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network)
+				err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network, c.spec.LogCollector)
 				if err != nil {
 					return errors.Wrap(err, "failed to set Rook and/or user-defined Ceph config options after creating the first mon")
 				}
@@ -263,7 +277,7 @@ func (c *Cluster) startMons(targetCount int) error {
 				// Or if we need to retry, only do this when we are on the first iteration of the
 				// loop. This could be in the same if statement as above, but separate it to get a
 				// different error message.
-				err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network)
+				err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network, c.spec.LogCollector)
 				if err != nil {
 					return errors.Wrap(err, "failed to set Rook and/or user-defined Ceph config options after updating the existing mons")
 				}
@@ -277,13 +291,20 @@ func (c *Cluster) startMons(targetCount int) error {
 		}
 
 		if setConfigsNeedsRetry {
-			err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network)
+			err := config.SetDefaultConfigs(c.context, c.Namespace, c.ClusterInfo, c.spec.Network, c.spec.LogCollector)
 			if err != nil {
 				return errors.Wrap(err, "failed to set Rook and/or user-defined Ceph config options after forcefully updating the existing mons")
 			}
 		}
 	}
 
+	// the stretch mode can only be enabled once all the mons are running in their zones
+	if c.spec.Mon.IsStretchCluster() {
+		if err := c.configureStretchCluster(mons); err != nil {
+			return errors.Wrap(err, "failed to configure the stretch cluster")
+		}
+	}
+
 	logger.Debugf("mon endpoints used are: %s", FlattenMonEndpoints(c.ClusterInfo.Monitors))
 	return nil
 }
@@ -373,6 +394,7 @@ func (c *Cluster) initMonConfig(size int) (int, []*monConfig) {
 			Port:         cephutil.GetPortFromEndpoint(monitor.Endpoint),
 			DataPathMap: config.NewStatefulDaemonDataPathMap(
 				c.dataDirHostPath, dataDirRelativeHostPath(monitor.Name), config.MonType, monitor.Name, c.Namespace),
+			Zone: c.mapping.Zone[monitor.Name],
 		})
 	}
 
@@ -432,7 +454,7 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 	d.Spec.Template.Spec.Containers[0].LivenessProbe = nil
 
 	// setup affinity settings for pod scheduling
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.getMonPlacement(mon.Zone)
 	k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
 		map[string]string{k8sutil.AppAttr: AppName}, nil)
 
@@ -541,7 +563,7 @@ func realScheduleMonitor(c *Cluster, mon *monConfig) (SchedulingResult, error) {
 
 func (c *Cluster) initMonIPs(mons []*monConfig) error {
 	for _, m := range mons {
-		if c.Network.IsHost() {
+		if c.Network.IsHostFor(cephv1.KeyMon) {
 			logger.Infof("setting mon endpoints for hostnetwork mode")
 			node, ok := c.mapping.Node[m.DaemonName]
 			if !ok {
@@ -608,11 +630,25 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 			continue
 		}
 
+		// the mons of a stretch cluster or with zones are spread across the zones, the zone of the mon restricts its placement
+		if c.spec.Mon.HasZones() && mon.Zone == "" {
+			zone, err := c.findAvailableZone()
+			if err != nil {
+				return errors.Wrapf(err, "assignmon: failed to find a zone for mon %s", mon.DaemonName)
+			}
+			mon.Zone = zone
+			logger.Infof("assignmon: mon %s assigned to zone %s", mon.DaemonName, zone)
+		}
+
 		// determine a placement for the monitor. note that this scheduling is
 		// performed even when a node selector is not required. this may be
 		// non-optimal, but it is convenient to catch some failures early,
 		// before a decision is stored in the node mapping.
-		result, err := scheduleMonitor(c, mon)
+		schedule := scheduleMonitor
+		if c.spec.Mon.DisableCanary {
+			schedule = scheduleMonitorFromNodes
+		}
+		result, err := schedule(c, mon)
 
 		if err != nil {
 			return errors.Wrap(err, "assignmon: error scheduling monitor")
@@ -627,9 +663,9 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 		// placement is not being made. otherwise, the node choice will map
 		// directly to a node selector on the monitor pod.
 		var nodeInfo *NodeInfo = nil
-		if c.Network.IsHost() || c.spec.Mon.VolumeClaimTemplate == nil {
+		if c.Network.IsHostFor(cephv1.KeyMon) || c.spec.Mon.VolumeClaimTemplate == nil {
 			logger.Infof("assignmon: mon %s assigned to node %s", mon.DaemonName, nodeChoice.Name)
-			nodeInfo, err = getNodeInfoFromNode(*nodeChoice)
+			nodeInfo, err = getNodeInfoFromNode(*nodeChoice, c.Network.IsIPv6())
 			if err != nil {
 				return errors.Wrapf(err, "assignmon: couldn't get node info for node %s", nodeChoice.Name)
 			}
@@ -638,6 +674,9 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 		}
 
 		c.mapping.Node[mon.DaemonName] = nodeInfo
+		if mon.Zone != "" {
+			c.mapping.Zone[mon.DaemonName] = mon.Zone
+		}
 	}
 
 	logger.Debug("assignmons: mons have been scheduled")
@@ -769,6 +808,10 @@ func (c *Cluster) saveMonConfig() error {
 		MappingKey:      string(monMapping),
 		csi.ConfigKey:   csiConfigValue,
 	}
+	// the applied endpoints are kept in an annotation, to detect the changes made out of band
+	if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(configMap); err != nil {
+		logger.Warningf("failed to set the last applied annotation on config map %q. %v", configMap.Name, err)
+	}
 
 	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(configMap); err != nil {
 		if !kerrors.IsAlreadyExists(err) {
@@ -887,14 +930,14 @@ func (c *Cluster) startMon(m *monConfig, node *NodeInfo) error {
 	}
 
 	// placement settings from the CRD
-	p := cephv1.GetMonPlacement(c.spec.Placement)
+	p := c.getMonPlacement(m.Zone)
 
 	if deploymentExists {
 		// the existing deployment may have a node selector. if the cluster
 		// isn't using host networking and the deployment is using pvc storage,
 		// then the node selector can be removed. this may happen after
 		// upgrading the cluster with the k8s scheduling support for monitors.
-		if c.Network.IsHost() || !pvcExists {
+		if c.Network.IsHostFor(cephv1.KeyMon) || !pvcExists {
 			p.PodAffinity = nil
 			p.PodAntiAffinity = nil
 			k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, p, requiredDuringScheduling(&c.spec), PreferredDuringScheduling,
@@ -1050,7 +1093,7 @@ func monFoundInQuorum(name string, monQuorumStatusResp client.MonStatusResponse)
 }
 
 func requiredDuringScheduling(spec *cephv1.ClusterSpec) bool {
-	return spec.Network.IsHost() || !spec.Mon.AllowMultiplePerNode
+	return spec.Network.IsHostFor(cephv1.KeyMon) || !spec.Mon.AllowMultiplePerNode
 }
 
 func (c *Cluster) acquireOrchestrationLock() {
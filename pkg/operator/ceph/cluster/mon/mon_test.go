@@ -124,6 +124,7 @@ func newCluster(context *clusterd.Context, namespace string, network cephv1.Netw
 		monTimeoutList:      map[string]time.Time{},
 		mapping: &Mapping{
 			Node: map[string]*NodeInfo{},
+			Zone: map[string]string{},
 		},
 		ownerRef: metav1.OwnerReference{},
 	}
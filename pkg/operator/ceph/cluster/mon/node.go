@@ -17,8 +17,12 @@ limitations under the License.
 package mon
 
 import (
+	"net"
+
 	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // NodeUsage is a mapping between a Node and computed metadata about the node
@@ -45,15 +49,22 @@ func getNodeNameFromHostname(nodes *v1.NodeList, hostname string) (string, bool)
 	return "", false
 }
 
-func getNodeInfoFromNode(n v1.Node) (*NodeInfo, error) {
+// getNodeInfoFromNode returns the info of a node with its internal IP in the ip family of the cluster if any, or its
+// first internal IP otherwise
+func getNodeInfoFromNode(n v1.Node, ipv6 bool) (*NodeInfo, error) {
 	nr := &NodeInfo{
 		Name:     n.Name,
 		Hostname: n.Labels[v1.LabelHostname],
 	}
 
 	for _, ip := range n.Status.Addresses {
-		if ip.Type == v1.NodeInternalIP {
-			logger.Debugf("using internal IP %s for node %s", ip.Address, n.Name)
+		if ip.Type != v1.NodeInternalIP {
+			continue
+		}
+		if nr.Address == "" {
+			nr.Address = ip.Address
+		}
+		if parsed := net.ParseIP(ip.Address); parsed != nil && (parsed.To4() == nil) == ipv6 {
 			nr.Address = ip.Address
 			break
 		}
@@ -61,5 +72,64 @@ func getNodeInfoFromNode(n v1.Node) (*NodeInfo, error) {
 	if nr.Address == "" {
 		return nil, errors.Errorf("failed to find any internal IP on node %s", nr.Name)
 	}
+	logger.Debugf("using internal IP %s for node %s", nr.Address, n.Name)
 	return nr, nil
 }
+
+// scheduleMonitorFromNodes selects the node of a mon from the labels of the nodes, without scheduling a canary pod.
+// The valid node running the fewest mons is selected, a node already running a mon being only selected when multiple
+// mons are allowed per node.
+func scheduleMonitorFromNodes(c *Cluster, mon *monConfig) (SchedulingResult, error) {
+	result := SchedulingResult{}
+
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return result, errors.Wrap(err, "sched-mon: failed to list nodes")
+	}
+	usage, err := c.getNodeUsage(nodes, mon)
+	if err != nil {
+		return result, err
+	}
+
+	var selected *NodeUsage
+	for i := range usage {
+		if !usage[i].MonValid || (usage[i].MonCount > 0 && !c.spec.Mon.AllowMultiplePerNode) {
+			continue
+		}
+		if selected == nil || usage[i].MonCount < selected.MonCount {
+			selected = &usage[i]
+		}
+	}
+	if selected == nil {
+		return result, errors.Errorf("sched-mon: no node is available for mon %s", mon.DaemonName)
+	}
+
+	result.Node = selected.Node
+	logger.Infof("sched-mon: mon %s placed on node %s without a canary", mon.DaemonName, selected.Node.Name)
+	return result, nil
+}
+
+// getNodeUsage returns the number of mons assigned to each node, and whether the node matches the placement of the mon
+func (c *Cluster) getNodeUsage(nodes *v1.NodeList, mon *monConfig) ([]NodeUsage, error) {
+	monCount := map[string]int{}
+	for _, node := range c.mapping.Node {
+		if node != nil {
+			monCount[node.Name]++
+		}
+	}
+
+	placement := c.getMonPlacement(mon.Zone)
+	usage := []NodeUsage{}
+	for i := range nodes.Items {
+		valid, err := k8sutil.ValidNode(nodes.Items[i], placement)
+		if err != nil {
+			return nil, errors.Wrapf(err, "sched-mon: failed to check the placement of node %s", nodes.Items[i].Name)
+		}
+		usage = append(usage, NodeUsage{
+			Node:     &nodes.Items[i],
+			MonCount: monCount[nodes.Items[i].Name],
+			MonValid: valid,
+		})
+	}
+	return usage, nil
+}
@@ -211,12 +211,61 @@ func TestGetNodeInfoFromNode(t *testing.T) {
 	}
 
 	var info *NodeInfo
-	info, err = getNodeInfoFromNode(*node)
+	info, err = getNodeInfoFromNode(*node, false)
 	assert.NotNil(t, err)
 
 	node.Status.Addresses[0].Type = v1.NodeInternalIP
 	node.Status.Addresses[0].Address = "172.17.0.1"
-	info, err = getNodeInfoFromNode(*node)
+	info, err = getNodeInfoFromNode(*node, false)
 	assert.Nil(t, err)
 	assert.Equal(t, "172.17.0.1", info.Address)
+
+	// the internal IP of the ip family of the cluster is preferred on a dual stack node
+	node.Status.Addresses = append(node.Status.Addresses, v1.NodeAddress{Type: v1.NodeInternalIP, Address: "fd00::1"})
+	info, err = getNodeInfoFromNode(*node, true)
+	assert.Nil(t, err)
+	assert.Equal(t, "fd00::1", info.Address)
+	info, err = getNodeInfoFromNode(*node, false)
+	assert.Nil(t, err)
+	assert.Equal(t, "172.17.0.1", info.Address)
+}
+
+func TestScheduleMonitorFromNodes(t *testing.T) {
+	clientset := test.New(t, 3)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "myversion")
+	c.spec.Mon.DisableCanary = true
+
+	// each mon is placed on a node without mon
+	used := map[string]bool{}
+	for _, name := range []string{"a", "b", "c"} {
+		result, err := scheduleMonitorFromNodes(c, &monConfig{DaemonName: name})
+		assert.NoError(t, err)
+		assert.Empty(t, result.CanaryDeployment)
+		assert.False(t, used[result.Node.Name])
+		used[result.Node.Name] = true
+		c.mapping.Node[name] = &NodeInfo{Name: result.Node.Name}
+	}
+	_, err := scheduleMonitorFromNodes(c, &monConfig{DaemonName: "d"})
+	assert.Error(t, err)
+
+	// the node with the fewest mons is selected when multiple mons are allowed per node
+	c.spec.Mon.AllowMultiplePerNode = true
+	c.mapping.Node["d"] = &NodeInfo{Name: "node0"}
+	c.mapping.Node["e"] = &NodeInfo{Name: "node1"}
+	result, err := scheduleMonitorFromNodes(c, &monConfig{DaemonName: "f"})
+	assert.NoError(t, err)
+	assert.Equal(t, "node2", result.Node.Name)
+
+	// only the nodes of the zone of the mon are selected
+	node, _ := clientset.CoreV1().Nodes().Get("node1", metav1.GetOptions{})
+	node.Labels = map[string]string{"topology.kubernetes.io/zone": "x"}
+	_, err = clientset.CoreV1().Nodes().Update(node)
+	assert.NoError(t, err)
+	c.spec.Mon.Zones = []cephv1.MonZoneSpec{{Name: "x"}, {Name: "y"}}
+	result, err = scheduleMonitorFromNodes(c, &monConfig{DaemonName: "f", Zone: "x"})
+	assert.NoError(t, err)
+	assert.Equal(t, "node1", result.Node.Name)
+	_, err = scheduleMonitorFromNodes(c, &monConfig{DaemonName: "f", Zone: "y"})
+	assert.Error(t, err)
 }
@@ -0,0 +1,166 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// monQuorumRestoredReason is the event reason emitted when the quorum is rebuilt from a single mon
+	monQuorumRestoredReason = "MonQuorumRestored"
+	// restoreQuorumContainerName is the init container rewriting the monmap of the surviving mon
+	restoreQuorumContainerName = "restore-quorum"
+	restoreQuorumMonmapPath    = "/tmp/monmap"
+)
+
+// RestoreQuorum rebuilds the quorum of the mons from the single mon surviving the loss of the majority of the mons.
+// The lost mons are removed first so that they cannot form a quorum with a stale monmap, then the monmap of the
+// surviving mon is rewritten to contain only itself by an init container of its pod. The operator grows the quorum
+// back to the mon count when the mons are started next.
+// The quorum is only restored if the mons are out of quorum and the mon is one of the mons of the cluster.
+func (c *Cluster) RestoreQuorum(rookVersion string, cephVersion cephver.CephVersion, spec cephv1.ClusterSpec, monID string) error {
+	// Only one goroutine can orchestrate the mons at a time
+	c.acquireOrchestrationLock()
+	defer c.releaseOrchestrationLock()
+
+	c.rookVersion = rookVersion
+	c.spec = spec
+	if err := c.initClusterInfo(cephVersion); err != nil {
+		return errors.Wrap(err, "failed to initialize ceph cluster info")
+	}
+
+	if _, ok := c.ClusterInfo.Monitors[monID]; !ok {
+		return errors.Errorf("refusing to restore the mon quorum from mon %q, it is not a mon of the cluster", monID)
+	}
+	if status, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name); err == nil && len(status.Quorum) > 0 {
+		return errors.Errorf("refusing to restore the mon quorum from mon %q, the mons are in quorum", monID)
+	}
+
+	lostMons := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		if name != monID {
+			lostMons = append(lostMons, name)
+		}
+	}
+	sort.Strings(lostMons)
+	logger.Warningf("restoring the mon quorum from mon %q, removing mons %v", monID, lostMons)
+
+	deployment, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(resourceName(monID), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of mon %q", monID)
+	}
+	original := deployment.DeepCopy()
+
+	// the mon config is saved with the surviving mon only, its pod then starting with the new mon host
+	for _, name := range lostMons {
+		c.removeMonDeployment(name)
+		if err := c.removeMonResources(name); err != nil {
+			return errors.Wrapf(err, "failed to remove lost mon %q", name)
+		}
+	}
+
+	restoreContainer, err := makeRestoreQuorumInitContainer(deployment, lostMons)
+	if err != nil {
+		return err
+	}
+	deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, restoreContainer)
+	if err := updateMonDeploymentWithoutChecks(c, deployment); err != nil {
+		return errors.Wrapf(err, "failed to rewrite the monmap of mon %q", monID)
+	}
+
+	if err := waitForQuorumWithMons(c.context, c.ClusterInfo.Name, []string{monID}, 5, true); err != nil {
+		return errors.Wrapf(err, "failed to wait for mon %q to form a quorum", monID)
+	}
+
+	// the init container is removed so that the monmap is not rewritten when the mon restarts
+	original.ResourceVersion = ""
+	if err := updateMonDeploymentWithoutChecks(c, original); err != nil {
+		return errors.Wrapf(err, "failed to restore the deployment of mon %q", monID)
+	}
+
+	c.recordEvent(v1.EventTypeNormal, monQuorumRestoredReason, "restored the mon quorum from mon %s, removed mons %s", monID, strings.Join(lostMons, ","))
+	logger.Infof("restored the mon quorum from mon %q", monID)
+	return nil
+}
+
+// updateMonDeploymentWithoutChecks updates the deployment of a mon and waits for its pod to be ready, the mon
+// cannot be checked to be ok to stop while the mons are out of quorum
+var updateMonDeploymentWithoutChecks = func(c *Cluster, d *apps.Deployment) error {
+	_, err := k8sutil.UpdateDeploymentAndWait(c.context, d, c.Namespace, func(action string) error { return nil })
+	return err
+}
+
+// makeRestoreQuorumInitContainer returns the init container extracting the monmap of the mon, removing the lost
+// mons from the monmap and injecting it back into the mon, with the flags of the mon container
+func makeRestoreQuorumInitContainer(deployment *apps.Deployment, lostMons []string) (v1.Container, error) {
+	var monContainer *v1.Container
+	for i, container := range deployment.Spec.Template.Spec.Containers {
+		if container.Name == "mon" {
+			monContainer = &deployment.Spec.Template.Spec.Containers[i]
+			break
+		}
+	}
+	if monContainer == nil {
+		return v1.Container{}, errors.Errorf("mon container not found in deployment %q", deployment.Name)
+	}
+
+	monCommand := []string{cephMonCommand}
+	for _, arg := range monContainer.Args {
+		if arg != "--foreground" {
+			monCommand = append(monCommand, shellQuote(arg))
+		}
+	}
+	ceph := strings.Join(monCommand, " ")
+	script := []string{
+		"set -e",
+		fmt.Sprintf("%s --extract-monmap=%s", ceph, restoreQuorumMonmapPath),
+		fmt.Sprintf("monmaptool --print %s", restoreQuorumMonmapPath),
+	}
+	// the mons already removed from the monmap are ignored if the init container runs again
+	for _, name := range lostMons {
+		script = append(script, fmt.Sprintf("monmaptool %s --rm %s || true", restoreQuorumMonmapPath, shellQuote(name)))
+	}
+	script = append(script, fmt.Sprintf("%s --inject-monmap=%s", ceph, restoreQuorumMonmapPath))
+
+	return v1.Container{
+		Name:            restoreQuorumContainerName,
+		Command:         []string{"/bin/bash", "-c", strings.Join(script, "\n")},
+		Image:           monContainer.Image,
+		VolumeMounts:    monContainer.VolumeMounts,
+		SecurityContext: monContainer.SecurityContext,
+		Env:             monContainer.Env,
+		EnvFrom:         monContainer.EnvFrom,
+		Resources:       monContainer.Resources,
+	}, nil
+}
+
+// shellQuote quotes a word for bash, the kubelet still expanding the $(VAR) references to the env vars
+func shellQuote(word string) string {
+	return "'" + strings.Replace(word, "'", `'\''`, -1) + "'"
+}
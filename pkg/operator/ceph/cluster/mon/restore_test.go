@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRestoreQuorumGuards(t *testing.T) {
+	namespace := "ns"
+	context, err := newTestStartCluster(t, namespace)
+	assert.NoError(t, err)
+	c := newCluster(context, namespace, cephv1.NetworkSpec{}, true, v1.ResourceRequirements{})
+	_, err = c.Start(c.ClusterInfo, c.rookVersion, cephver.Nautilus, c.spec)
+	assert.NoError(t, err)
+
+	// the quorum is not restored from an unknown mon nor while the mons are in quorum
+	err = c.RestoreQuorum(c.rookVersion, cephver.Nautilus, c.spec, "z")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "not a mon of the cluster")
+	err = c.RestoreQuorum(c.rookVersion, cephver.Nautilus, c.spec, "a")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "the mons are in quorum")
+	assert.Equal(t, 3, len(c.ClusterInfo.Monitors))
+}
+
+func TestMakeRestoreQuorumInitContainer(t *testing.T) {
+	namespace := "ns"
+	context, err := newTestStartCluster(t, namespace)
+	assert.NoError(t, err)
+	c := newCluster(context, namespace, cephv1.NetworkSpec{}, true, v1.ResourceRequirements{})
+	_, err = c.Start(c.ClusterInfo, c.rookVersion, cephver.Nautilus, c.spec)
+	assert.NoError(t, err)
+
+	d, err := context.Clientset.AppsV1().Deployments(namespace).Get("rook-ceph-mon-a", metav1.GetOptions{})
+	assert.NoError(t, err)
+	container, err := makeRestoreQuorumInitContainer(d, []string{"b", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, "restore-quorum", container.Name)
+	assert.Equal(t, d.Spec.Template.Spec.Containers[0].Image, container.Image)
+	assert.Equal(t, d.Spec.Template.Spec.Containers[0].VolumeMounts, container.VolumeMounts)
+
+	script := strings.Split(container.Command[2], "\n")
+	assert.Equal(t, 6, len(script))
+	assert.True(t, strings.HasPrefix(script[1], "ceph-mon '"))
+	assert.True(t, strings.HasSuffix(script[1], " --extract-monmap=/tmp/monmap"))
+	assert.NotContains(t, script[1], "--foreground")
+	assert.Equal(t, "monmaptool /tmp/monmap --rm 'b' || true", script[3])
+	assert.Equal(t, "monmaptool /tmp/monmap --rm 'c' || true", script[4])
+	assert.True(t, strings.HasSuffix(script[5], " --inject-monmap=/tmp/monmap"))
+
+	d.Spec.Template.Spec.Containers[0].Name = "other"
+	_, err = makeRestoreQuorumInitContainer(d, []string{"b"})
+	assert.Error(t, err)
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package mon
 
 import (
+	"net"
 	"strconv"
 
 	"github.com/pkg/errors"
@@ -48,6 +49,11 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 		},
 	}
 	k8sutil.SetOwnerRef(&svcDef.ObjectMeta, &c.ownerRef)
+	if c.Network.IPFamily != "" {
+		// the mons are reached on the cluster IP of the family of their addresses, in a dual stack kubernetes cluster
+		ipFamily := v1.IPFamily(c.Network.IPFamily)
+		svcDef.Spec.IPFamily = &ipFamily
+	}
 
 	// If deploying Nautilus or newer we need a new port for the monitor service
 	addServicePort(svcDef, "tcp-msgr2", DefaultMsgr2Port)
@@ -65,7 +71,7 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 	// mon endpoint are not actually like, they remain with the mgrs1 format
 	// however it's interesting to show that monitors can be addressed via 2 different ports
 	// in the end the service has msgr1 and msgr2 ports configured so it's not entirely wrong
-	logger.Infof("mon %q endpoint are [v2:%s:%s,v1:%s:%d]", mon.DaemonName, s.Spec.ClusterIP, strconv.Itoa(int(DefaultMsgr2Port)), s.Spec.ClusterIP, mon.Port)
+	logger.Infof("mon %q endpoint are [v2:%s,v1:%s]", mon.DaemonName, net.JoinHostPort(s.Spec.ClusterIP, strconv.Itoa(int(DefaultMsgr2Port))), net.JoinHostPort(s.Spec.ClusterIP, strconv.Itoa(int(mon.Port))))
 
 	return s.Spec.ClusterIP, nil
 }
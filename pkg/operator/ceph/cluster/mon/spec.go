@@ -18,8 +18,10 @@ package mon
 
 import (
 	"fmt"
+	"net"
 	"os"
 	"path"
+	"strconv"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
@@ -69,6 +71,7 @@ func (c *Cluster) makeDeployment(monConfig *monConfig, canary bool) *apps.Deploy
 	}
 	k8sutil.AddRookVersionLabelToDeployment(d)
 	cephv1.GetMonAnnotations(c.spec.Annotations).ApplyToObjectMeta(&d.ObjectMeta)
+	cephv1.GetMonLabels(c.spec.Labels).ApplyToObjectMeta(&d.ObjectMeta)
 	controller.AddCephVersionLabelToDeployment(c.ClusterInfo.CephVersion, d)
 	k8sutil.SetOwnerRef(&d.ObjectMeta, &c.ownerRef)
 
@@ -111,6 +114,7 @@ func (c *Cluster) makeDeploymentPVC(m *monConfig, canary bool) (*v1.PersistentVo
 	}
 	k8sutil.AddRookVersionLabelToObjectMeta(&pvc.ObjectMeta)
 	cephv1.GetMonAnnotations(c.spec.Annotations).ApplyToObjectMeta(&pvc.ObjectMeta)
+	cephv1.GetMonLabels(c.spec.Labels).ApplyToObjectMeta(&pvc.ObjectMeta)
 	controller.AddCephVersionLabelToObjectMeta(c.ClusterInfo.CephVersion, &pvc.ObjectMeta)
 	k8sutil.SetOwnerRef(&pvc.ObjectMeta, &c.ownerRef)
 
@@ -151,14 +155,21 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, canary bool, PVCName string)
 		// we decide later whether to use a PVC volume or host volumes for mons, so only populate
 		// the base volumes at this point.
 		Volumes:           controller.DaemonVolumesBase(monConfig.DataPathMap, keyringStoreName),
-		HostNetwork:       c.Network.IsHost(),
+		HostNetwork:       c.Network.IsHostFor(cephv1.KeyMon),
 		PriorityClassName: cephv1.GetMonPriorityClassName(c.spec.PriorityClassNames),
 	}
 
+	if c.spec.LogCollector.Enabled {
+		podSpec.Containers = append(podSpec.Containers,
+			controller.LogCollectorContainer(fmt.Sprintf("%s.%s", config.MonType, monConfig.DaemonName), c.spec.CephVersion.Image, c.spec.LogCollector, PodSecurityContext()))
+	}
+	controller.ApplyDaemonOverrides(&podSpec, c.spec.Env, c.spec.VolumeMounts, cephv1.KeyMon)
+
 	// Replace default unreachable node toleration
 	if c.spec.Mon.VolumeClaimTemplate != nil {
 		k8sutil.AddUnreachableNodeToleration(&podSpec)
 	}
+	k8sutil.ApplyImagePullSecrets(c.Namespace, &podSpec)
 
 	pod := &v1.Pod{
 		ObjectMeta: metav1.ObjectMeta{
@@ -169,11 +180,12 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, canary bool, PVCName string)
 		Spec: podSpec,
 	}
 	cephv1.GetMonAnnotations(c.spec.Annotations).ApplyToObjectMeta(&pod.ObjectMeta)
+	cephv1.GetMonLabels(c.spec.Labels).ApplyToObjectMeta(&pod.ObjectMeta)
 
-	if c.Network.IsHost() {
+	if c.Network.IsHostFor(cephv1.KeyMon) {
 		pod.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.Network.NetworkSpec.IsMultus() {
-		k8sutil.ApplyMultus(c.Network.NetworkSpec, &pod.ObjectMeta)
+		config.ApplyPublicNetwork(c.Network.NetworkSpec, &pod.ObjectMeta)
 	}
 
 	return pod
@@ -235,10 +247,10 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 
 	// Handle the non-default port for host networking. If host networking is not being used,
 	// the service created elsewhere will handle the non-default port redirection to the default port inside the container.
-	if c.Network.IsHost() && monConfig.Port != DefaultMsgr1Port {
+	if c.Network.IsHostFor(cephv1.KeyMon) && monConfig.Port != DefaultMsgr1Port {
 		logger.Warningf("Starting mon %s with host networking on a non-default port %d. The mon must be failed over before enabling msgr2.",
 			monConfig.DaemonName, monConfig.Port)
-		publicAddr = fmt.Sprintf("%s:%d", publicAddr, monConfig.Port)
+		publicAddr = net.JoinHostPort(publicAddr, strconv.Itoa(int(monConfig.Port)))
 	}
 
 	container := v1.Container{
@@ -282,13 +294,22 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 	container = config.ConfigureLivenessProbe(cephv1.KeyMon, container, c.spec.HealthCheck)
 
 	// If host networking is enabled, we don't need a bind addr that is different from the public addr
-	if !c.Network.IsHost() {
+	if !c.Network.IsHostFor(cephv1.KeyMon) {
 		// Opposite of the above, --public-bind-addr will *not* still advertise on the previous
 		// port, which makes sense because this is the pod IP, which changes with every new pod.
 		container.Args = append(container.Args,
 			config.NewFlag("public-bind-addr", controller.ContainerEnvVarReference(podIPEnvVar)))
 	}
 
+	// The mons bind to the addresses of the ip family before the settings of the other daemons are stored
+	container.Args = append(container.Args, config.NetworkBindFlags(c.Network)...)
+
+	// The mons of a stretch cluster report their zone, the stretch mode requires the location of the new mons
+	if monConfig.Zone != "" && c.spec.Mon.IsStretchCluster() {
+		container.Args = append(container.Args,
+			config.NewFlag("set-crush-location", fmt.Sprintf("%s=%s", c.spec.Mon.StretchCluster.GetFailureDomainType(), monConfig.Zone)))
+	}
+
 	// Add messenger 2 port
 	addContainerPort(container, "tcp-msgr2", 3300)
 
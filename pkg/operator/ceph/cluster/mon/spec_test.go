@@ -66,10 +66,16 @@ func testPodSpec(t *testing.T, monID string, pvc bool) {
 	c.spec.PriorityClassNames = map[rookv1.KeyType]string{
 		cephv1.KeyMon: "my-priority-class",
 	}
+	c.spec.Labels = rookv1.LabelsSpec{
+		cephv1.KeyMon: {"my-label": "value"},
+	}
 	monConfig := testGenMonConfig(monID)
 
 	d := c.makeDeployment(monConfig, false)
 	assert.NotNil(t, d)
+	assert.Equal(t, "value", d.Labels["my-label"])
+	assert.Equal(t, "value", d.Spec.Template.Labels["my-label"])
+	assert.NotContains(t, d.Spec.Selector.MatchLabels, "my-label")
 
 	if pvc {
 		d.Spec.Template.Spec.Volumes = append(
@@ -172,3 +178,24 @@ func TestRequiredDuringScheduling(t *testing.T) {
 	testRequiredDuringScheduling(t, true, true, true)
 	testRequiredDuringScheduling(t, false, true, false)
 }
+
+func TestMonIPv6Container(t *testing.T) {
+	c := New(
+		&clusterd.Context{},
+		"ns",
+		"/var/lib/rook",
+		cephv1.NetworkSpec{HostNetwork: true, IPFamily: cephv1.IPv6},
+		metav1.OwnerReference{},
+		&sync.Mutex{},
+	)
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3}, "rook/rook:myversion")
+	monConfig := testGenMonConfig("a")
+	monConfig.PublicIP = "fd00::1"
+	monConfig.Port = 6790
+
+	// The ipv6 address of the mon is bracketed with its port, and the mon binds to ipv6 only
+	container := c.makeMonDaemonContainer(monConfig)
+	assert.Contains(t, container.Args, "--public-addr=[fd00::1]:6790")
+	assert.Contains(t, container.Args, "--ms-bind-ipv4=false")
+	assert.Contains(t, container.Args, "--ms-bind-ipv6=true")
+}
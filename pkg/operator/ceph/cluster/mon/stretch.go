@@ -0,0 +1,155 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// stretchCrushRuleName is the CRUSH rule replicating the data across the two data zones of a stretch cluster
+	stretchCrushRuleName = "stretch_rule"
+	// connectivityElectionStrategy lets the mons elect their leader from their connectivity, as the stretch mode requires
+	connectivityElectionStrategy = "connectivity"
+)
+
+// getMonPlacement returns the placement of a mon, restricted to the nodes of its zone in a stretch cluster or when
+// the mons are spread across zones
+func (c *Cluster) getMonPlacement(zone string) rookv1.Placement {
+	p := cephv1.GetMonPlacement(c.spec.Placement)
+	if zone == "" || !c.spec.Mon.HasZones() {
+		return p
+	}
+
+	zoneRequirement := v1.NodeSelectorRequirement{
+		Key:      c.spec.Mon.GetZoneLabel(),
+		Operator: v1.NodeSelectorOpIn,
+		Values:   []string{zone},
+	}
+
+	// the node selector terms are ORed, so the zone is required by each of them
+	nodeAffinity := p.NodeAffinity.DeepCopy()
+	if nodeAffinity == nil {
+		nodeAffinity = &v1.NodeAffinity{}
+	}
+	if nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &v1.NodeSelector{}
+	}
+	terms := nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) == 0 {
+		terms = []v1.NodeSelectorTerm{{}}
+	}
+	for i := range terms {
+		terms[i].MatchExpressions = append(terms[i].MatchExpressions, zoneRequirement)
+	}
+	nodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+	p.NodeAffinity = nodeAffinity
+
+	return p
+}
+
+// findAvailableZone returns the zone of a stretch cluster with the most mons left to assign, two mons run in each data
+// zone and the tiebreaker mon in the arbiter zone. When the mons are spread across the mon zones, the zone with the
+// fewest mons is returned.
+func (c *Cluster) findAvailableZone() (string, error) {
+	assigned := map[string]int{}
+	for _, zone := range c.mapping.Zone {
+		assigned[zone]++
+	}
+
+	if !c.spec.Mon.IsStretchCluster() {
+		available := ""
+		for _, zone := range c.spec.Mon.Zones {
+			if available == "" || assigned[zone.Name] < assigned[available] {
+				available = zone.Name
+			}
+		}
+		if available == "" {
+			return "", errors.New("no mon zone is defined")
+		}
+		return available, nil
+	}
+
+	available := ""
+	mostLeft := 0
+	for _, zone := range c.spec.Mon.StretchCluster.Zones {
+		desired := (c.spec.Mon.Count - 1) / 2
+		if zone.Arbiter {
+			desired = 1
+		}
+		if left := desired - assigned[zone.Name]; left > mostLeft {
+			available = zone.Name
+			mostLeft = left
+		}
+	}
+	if available == "" {
+		return "", errors.Errorf("all the zones already have their mons. %v", assigned)
+	}
+
+	return available, nil
+}
+
+// configureStretchCluster sets the location of the mons, creates the stretch CRUSH rule and enables the stretch mode,
+// unless the stretch mode is already enabled
+func (c *Cluster) configureStretchCluster(mons []*monConfig) error {
+	if !c.ClusterInfo.CephVersion.IsAtLeastPacific() {
+		return errors.Errorf("the stretch mode requires ceph pacific or newer, the ceph version is %q", c.ClusterInfo.CephVersion.String())
+	}
+
+	status, err := client.GetMonQuorumStatus(c.context, c.ClusterInfo.Name)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the mon quorum status")
+	}
+	if status.MonMap.StretchMode {
+		logger.Debug("stretch mode is already enabled")
+		return nil
+	}
+
+	stretch := c.spec.Mon.StretchCluster
+	bucketType := stretch.GetFailureDomainType()
+	tiebreaker := ""
+	for _, m := range mons {
+		if m.Zone == "" {
+			return errors.Errorf("mon %q is not assigned to a zone", m.DaemonName)
+		}
+		if m.Zone == stretch.GetArbiterZone() {
+			tiebreaker = m.DaemonName
+		}
+	}
+	if tiebreaker == "" {
+		return errors.Errorf("no mon is running in the arbiter zone %q", stretch.GetArbiterZone())
+	}
+
+	logger.Infof("enabling the stretch mode with the tiebreaker mon %q", tiebreaker)
+	if err := client.SetMonElectionStrategy(c.context, c.ClusterInfo.Name, connectivityElectionStrategy); err != nil {
+		return err
+	}
+	for _, m := range mons {
+		if err := client.SetMonLocation(c.context, c.ClusterInfo.Name, m.DaemonName, bucketType, m.Zone); err != nil {
+			return err
+		}
+	}
+	if err := client.CreateStretchCrushRule(c.context, c.ClusterInfo.Name, stretchCrushRuleName, bucketType); err != nil {
+		return errors.Wrap(err, "failed to create the stretch crush rule")
+	}
+
+	return client.EnableStretchMode(c.context, c.ClusterInfo.Name, tiebreaker, stretchCrushRuleName, bucketType)
+}
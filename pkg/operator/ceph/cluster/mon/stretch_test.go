@@ -0,0 +1,179 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newStretchCluster(context *clusterd.Context) *Cluster {
+	c := newCluster(context, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	c.spec.Mon.Count = 5
+	c.spec.Mon.StretchCluster = &cephv1.StretchClusterSpec{
+		Zones: []cephv1.StretchClusterZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "arbiter", Arbiter: true}},
+	}
+	return c
+}
+
+func TestFindAvailableZone(t *testing.T) {
+	c := newStretchCluster(&clusterd.Context{})
+
+	// the mons alternate between the data zones before the arbiter zone gets its mon
+	expected := []string{"a", "b", "a", "b", "arbiter"}
+	for i, zone := range expected {
+		found, err := c.findAvailableZone()
+		assert.NoError(t, err)
+		assert.Equal(t, zone, found)
+		c.mapping.Zone[string(rune('a'+i))] = found
+	}
+
+	_, err := c.findAvailableZone()
+	assert.Error(t, err)
+
+	// the zone of a removed mon is available again
+	delete(c.mapping.Zone, "b")
+	found, err := c.findAvailableZone()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", found)
+}
+
+func TestFindAvailableMonZone(t *testing.T) {
+	c := newCluster(&clusterd.Context{}, "ns", cephv1.NetworkSpec{}, false, v1.ResourceRequirements{})
+	_, err := c.findAvailableZone()
+	assert.Error(t, err)
+
+	// the mons are spread evenly across the zones
+	c.spec.Mon.Count = 5
+	c.spec.Mon.Zones = []cephv1.MonZoneSpec{{Name: "x"}, {Name: "y"}, {Name: "z"}}
+	expected := []string{"x", "y", "z", "x", "y"}
+	for i, zone := range expected {
+		found, err := c.findAvailableZone()
+		assert.NoError(t, err)
+		assert.Equal(t, zone, found)
+		c.mapping.Zone[string(rune('a'+i))] = found
+	}
+
+	// the mons of a zone are restricted to the nodes of the zone
+	c.spec.Mon.FailureDomainLabel = "topology.rook.io/rack"
+	p := c.getMonPlacement("z")
+	terms := p.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, []v1.NodeSelectorRequirement{
+		{Key: "topology.rook.io/rack", Operator: v1.NodeSelectorOpIn, Values: []string{"z"}},
+	}, terms[0].MatchExpressions)
+}
+
+func TestGetMonPlacement(t *testing.T) {
+	c := newStretchCluster(&clusterd.Context{})
+
+	// the mons without zone keep the placement of the spec
+	p := c.getMonPlacement("")
+	assert.Nil(t, p.NodeAffinity)
+
+	p = c.getMonPlacement("a")
+	terms := p.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, 1, len(terms))
+	assert.Equal(t, []v1.NodeSelectorRequirement{
+		{Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"a"}},
+	}, terms[0].MatchExpressions)
+
+	// the zone is required by every term of the node affinity of the spec, which is not modified
+	c.spec.Mon.StretchCluster.FailureDomainLabel = "topology.rook.io/datacenter"
+	c.spec.Placement = rookv1.PlacementSpec{cephv1.KeyMon: rookv1.Placement{NodeAffinity: &v1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{NodeSelectorTerms: []v1.NodeSelectorTerm{
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "role", Operator: v1.NodeSelectorOpIn, Values: []string{"mon"}}}},
+			{MatchExpressions: []v1.NodeSelectorRequirement{{Key: "role", Operator: v1.NodeSelectorOpIn, Values: []string{"any"}}}},
+		}},
+	}}}
+	p = c.getMonPlacement("b")
+	terms = p.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, 2, len(terms))
+	for _, term := range terms {
+		assert.Equal(t, 2, len(term.MatchExpressions))
+		assert.Equal(t, "topology.rook.io/datacenter", term.MatchExpressions[1].Key)
+		assert.Equal(t, []string{"b"}, term.MatchExpressions[1].Values)
+	}
+	specTerms := c.spec.Placement[cephv1.KeyMon].NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, 1, len(specTerms[0].MatchExpressions))
+}
+
+func TestConfigureStretchCluster(t *testing.T) {
+	stretchMode := false
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			switch {
+			case args[0] == "quorum_status":
+				if stretchMode {
+					return `{"monmap":{"stretch_mode":true}}`, nil
+				}
+				return `{"monmap":{"stretch_mode":false}}`, nil
+			case args[0] == "osd" && args[1] == "crush" && args[2] == "dump":
+				return `{"rules":[{"rule_id":1,"rule_name":"stretch_rule"}]}`, nil
+			}
+			cmd := []string{}
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				cmd = append(cmd, arg)
+			}
+			commands = append(commands, strings.Join(cmd, " "))
+			return "", nil
+		},
+	}
+	c := newStretchCluster(&clusterd.Context{Executor: executor})
+	c.ClusterInfo = &cephconfig.ClusterInfo{Name: "ns", CephVersion: cephver.Pacific}
+	mons := []*monConfig{{DaemonName: "a", Zone: "a"}, {DaemonName: "b", Zone: "b"}, {DaemonName: "c", Zone: "arbiter"}}
+
+	err := c.configureStretchCluster(mons)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"mon set election_strategy connectivity",
+		"mon set_location a zone=a",
+		"mon set_location b zone=b",
+		"mon set_location c zone=arbiter",
+		"mon enable_stretch_mode c stretch_rule zone",
+	}, commands)
+
+	// nothing to do once the stretch mode is enabled
+	stretchMode = true
+	commands = []string{}
+	err = c.configureStretchCluster(mons)
+	assert.NoError(t, err)
+	assert.Empty(t, commands)
+
+	// the stretch mode needs a tiebreaker mon
+	stretchMode = false
+	err = c.configureStretchCluster(mons[0:2])
+	assert.Error(t, err)
+	assert.Empty(t, commands)
+
+	// the stretch mode is not available before pacific
+	c.ClusterInfo.CephVersion = cephver.Octopus
+	err = c.configureStretchCluster(mons)
+	assert.Error(t, err)
+}
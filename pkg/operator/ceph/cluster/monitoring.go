@@ -18,20 +18,100 @@ limitations under the License.
 package cluster
 
 import (
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephclient "github.com/rook/rook/pkg/operator/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/ceph/object/bucket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+const (
+	// stopWatchTimeout is how long the operator waits for the monitoring goroutines to return when stopping
+	stopWatchTimeout = 30 * time.Second
+
+	// the values of the CephCluster annotations enabling or disabling the health check of a daemon
+	monitoringAnnotationEnabled  = "enabled"
+	monitoringAnnotationDisabled = "disabled"
+
+	// the names of the leases of the provisioners in the namespace of the operator
+	clientProvisionerLease = "rook-ceph-client-provisioner-"
+	bucketProvisionerLease = "rook-ceph-bucket-provisioner"
 )
 
+// monitoringDaemons are the daemons monitored by a health checker goroutine
+var monitoringDaemons = []string{"mon", "osd", "status"}
+
+// configureCephMonitoring starts the health goroutines and the watchers of the cluster
+// The goroutines of a cluster are only ever started and stopped under its monitoring lock, so that the concurrent
+// reconciles of several clusters, or the health check triggers, never race on the goroutines of a cluster.
 func (c *ClusterController) configureCephMonitoring(cluster *cluster, cephUser string) {
+	cluster.monitoringMux.Lock()
+	defer cluster.monitoringMux.Unlock()
+
+	c.configureCephMonitoringForDaemons(cluster, cephUser, monitoringDaemons)
+	c.startWatchers(cluster, cephUser)
+	c.startExternalClusterRefresh(cluster)
+	c.startKeyRotation(cluster)
+
+	// the osds to remove and the node maintenance setting are read from the CephCluster on every orchestration
+	if cluster.osdChecker != nil {
+		cluster.osdChecker.SetOSDsToRemove(osd.OSDsToRemove(cluster.annotations))
+		cluster.osdChecker.SetNodeMaintenance(cluster.Spec.DisruptionManagement.ManageNodeMaintenance)
+		cluster.osdChecker.SetRemovedNodes(cluster.Spec.Storage)
+	}
+}
+
+// StartMonitoring starts the health goroutines of all the enabled daemons of the cluster
+// The goroutines of the daemons disabled since the last call are stopped.
+func (c *ClusterController) StartMonitoring(cluster *cluster, cephUser string) {
+	cluster.monitoringMux.Lock()
+	defer cluster.monitoringMux.Unlock()
+	c.configureCephMonitoringForDaemons(cluster, cephUser, monitoringDaemons)
+}
+
+// StopMonitoring stops all the health goroutines of the cluster and forgets about them
+func (c *ClusterController) StopMonitoring(cluster *cluster) {
+	cluster.monitoringMux.Lock()
+	defer cluster.monitoringMux.Unlock()
+	for daemon, health := range cluster.monitoringChannels {
+		if health.monitoringRunning {
+			logger.Infof("stopping ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
+			close(health.stopChan)
+			health.monitoringRunning = false
+		}
+	}
+	cluster.monitoringChannels = make(map[string]*clusterHealth)
+	cluster.osdChecker = nil
+	controller.DeleteCephHealthMetric(cluster.Namespace)
+}
+
+// configureCephMonitoringForDaemons starts or stops the monitoring of the given daemons only
+// The monitoring lock of the cluster must be held.
+func (c *ClusterController) configureCephMonitoringForDaemons(cluster *cluster, cephUser string, daemons []string) {
 	var isDisabled bool
-	daemons := []string{"mon", "osd", "status"}
+
+	if cluster.Spec.HealthCheck.Paused {
+		logger.Infof("health checks are paused for cluster %q", cluster.Namespace)
+	}
 
 	for _, daemon := range daemons {
+		if !isMonitoringDaemon(daemon) {
+			logger.Warningf("skipping unknown ceph %s health check for cluster %q", daemon, cluster.Namespace)
+			continue
+		}
+
 		// Is the monitoring enabled for that daemon?
-		isDisabled = isMonitoringDisabled(daemon, cluster.Spec)
+		isDisabled = isMonitoringDisabled(daemon, cluster.Spec, cluster.annotations)
 		if health, ok := cluster.monitoringChannels[daemon]; ok {
 			if health.monitoringRunning {
 				// If the goroutine was running but the CR was updated to stop the monitoring we need to close the channel
@@ -40,12 +120,22 @@ func (c *ClusterController) configureCephMonitoring(cluster *cluster, cephUser s
 					close(cluster.monitoringChannels[daemon].stopChan)
 					// Set monitoring to false since it's not running anymore
 					cluster.monitoringChannels[daemon].monitoringRunning = false
+				} else if daemon == "status" && health.cephUser != cephUser && isMonitoringReady(cluster, daemon, cephUser) {
+					// The running goroutine would keep on using the credentials of the previous user
+					logger.Infof("ceph user changed from %q to %q, restarting ceph %s health go routine for cluster %q", health.cephUser, cephUser, daemon, cluster.Namespace)
+					close(health.stopChan)
+					health.stopChan = make(chan struct{})
+					c.startMonitoringCheck(cluster, daemon, cephUser)
 				} else {
 					logger.Debugf("ceph %s health go routine is already running for cluster %q", daemon, cluster.Namespace)
+					updateMonitoringConfig(cluster, daemon)
 				}
 			} else {
 				// if not already running and not disabled, we run it
-				if !isDisabled {
+				if !isDisabled && isMonitoringReady(cluster, daemon, cephUser) {
+					// The previous channel was closed when the monitoring was stopped
+					cluster.monitoringChannels[daemon].stopChan = make(chan struct{})
+
 					// Run the go routine
 					c.startMonitoringCheck(cluster, daemon, cephUser)
 
@@ -58,7 +148,7 @@ func (c *ClusterController) configureCephMonitoring(cluster *cluster, cephUser s
 			// So we check the desired state from the CR and run it if necessary
 			//
 			// If the mon monitoring is enabled
-			if !isDisabled {
+			if !isDisabled && isMonitoringReady(cluster, daemon, cephUser) {
 				cluster.monitoringChannels[daemon] = &clusterHealth{
 					stopChan:          make(chan struct{}),
 					monitoringRunning: true, // Set the flag to indicate monitoring is running
@@ -69,31 +159,184 @@ func (c *ClusterController) configureCephMonitoring(cluster *cluster, cephUser s
 			}
 		}
 	}
+}
 
-	// Start watchers
+// startWatchers starts the client and bucket watchers of the cluster once
+// The monitoring lock of the cluster must be held.
+func (c *ClusterController) startWatchers(cluster *cluster, cephUser string) {
 	if cluster.watchersActivated == true {
 		logger.Debugf("cluster is already being watched by bucket and client provisioner for cluster %q", cluster.Namespace)
 		return
 	}
 
+	// The provisioners run in the replica of the operator holding their lease, so that the clients and the buckets
+	// are not provisioned twice when the operator runs with several replicas
 	// Start client CRD watcher
-	clientController := cephclient.NewClientController(c.context, cluster.Namespace)
-	clientController.StartWatch(cluster.stopCh)
+	err := c.leaderElections.Run(clientProvisionerLease+cluster.Namespace, cluster.stopCh, func(stopCh chan struct{}) {
+		clientController := cephclient.NewClientController(c.context, cluster.Namespace)
+		clientController.StartWatch(stopCh)
+	})
+	if err != nil {
+		logger.Errorf("failed to start the client provisioner of cluster %q. %v", cluster.Namespace, err)
+	}
 
 	// Start the object bucket provisioner
+	c.startBucketProvisioner(cluster, cephUser)
+
+	// enable the cluster watcher once
+	cluster.watchersActivated = true
+	cluster.cephUser = cephUser
+}
+
+// startBucketProvisioner starts the object bucket provisioner of the cluster, unless the cluster-wide provisioner
+// already runs for another cluster
+func (c *ClusterController) startBucketProvisioner(cluster *cluster, cephUser string) {
+	stopCh, ok := c.bucketProvisionerStop(cluster)
+	if !ok {
+		return
+	}
+	err := c.leaderElections.Run(c.bucketProvisionerLease(cluster), stopCh, func(stopCh chan struct{}) {
+		c.runBucketProvisioner(cluster, cephUser, stopCh)
+	})
+	if err != nil {
+		logger.Errorf("failed to start the bucket provisioner of cluster %q. %v", cluster.Namespace, err)
+	}
+}
+
+// runBucketProvisioner runs the object bucket provisioner until the stop channel is closed
+func (c *ClusterController) runBucketProvisioner(cluster *cluster, cephUser string, stopCh chan struct{}) {
 	bucketProvisioner := bucket.NewProvisioner(c.context, cluster.Namespace, cephUser)
 	// If cluster is external, pass down the user to the bucket controller
 
 	// note: the error return below is ignored and is expected to be removed from the
 	//   bucket library's `NewProvisioner` function
 	bucketController, _ := bucket.NewBucketController(c.context.KubeConfig, bucketProvisioner)
-	go bucketController.Run(cluster.stopCh)
+	go bucketController.Run(stopCh)
 
-	// enable the cluster watcher once
-	cluster.watchersActivated = true
+	// Apply the quota and lifecycle updates of the claims, the bucket library only provisions them
+	claimWatcher, err := bucket.NewClaimWatcher(c.context.KubeConfig, bucketProvisioner)
+	if err != nil {
+		logger.Errorf("failed to start the bucket claim watcher. %v", err)
+	} else {
+		go claimWatcher.Run(stopCh)
+	}
+}
+
+// bucketProvisionerLease returns the name of the lease of the bucket provisioner of the cluster, the cluster-wide
+// provisioner having a single lease
+func (c *ClusterController) bucketProvisionerLease(cluster *cluster) string {
+	if object.IsObjectBucketProvisionerNamespaced(c.context) {
+		return bucketProvisionerLease + "-" + cluster.Namespace
+	}
+	return bucketProvisionerLease
+}
+
+// bucketProvisionerStop returns the stop channel of the bucket provisioner to start for the cluster, if any
+// A namespaced provisioner runs with the cluster, while the cluster-wide provisioner is started only once for all
+// the clusters and runs with the cluster it was started for, see stopBucketProvisioner
+func (c *ClusterController) bucketProvisionerStop(cluster *cluster) (chan struct{}, bool) {
+	if object.IsObjectBucketProvisionerNamespaced(c.context) {
+		return cluster.stopCh, true
+	}
+
+	c.bucketProvisionerMux.Lock()
+	defer c.bucketProvisionerMux.Unlock()
+	if c.bucketProvisionerOwner != "" {
+		logger.Debugf("cluster-wide bucket provisioner is already running for cluster %q, not starting one for cluster %q", c.bucketProvisionerOwner, cluster.Namespace)
+		return nil, false
+	}
+	c.bucketProvisionerOwner = cluster.Namespace
+	c.bucketProvisionerStopCh = make(chan struct{})
+
+	return c.bucketProvisionerStopCh, true
+}
+
+// stopBucketProvisioner stops the cluster-wide bucket provisioner when it runs for the deleted cluster, since it
+// provisions the buckets with the ceph user of that cluster. It is then started again for another watched cluster,
+// if any, so that the bucket claims of the other clusters are still provisioned.
+func (c *ClusterController) stopBucketProvisioner(cluster *cluster) {
+	c.bucketProvisionerMux.Lock()
+	if c.bucketProvisionerOwner != cluster.Namespace {
+		c.bucketProvisionerMux.Unlock()
+		return
+	}
+	logger.Infof("stopping the cluster-wide bucket provisioner of deleted cluster %q", cluster.Namespace)
+	close(c.bucketProvisionerStopCh)
+	c.bucketProvisionerOwner = ""
+	c.bucketProvisionerMux.Unlock()
+
+	for _, other := range c.getClusters() {
+		if other.Namespace == cluster.Namespace {
+			continue
+		}
+		other.monitoringMux.Lock()
+		watched := other.watchersActivated
+		if watched {
+			logger.Infof("starting the cluster-wide bucket provisioner for cluster %q", other.Namespace)
+			c.startBucketProvisioner(other, other.cephUser)
+		}
+		other.monitoringMux.Unlock()
+		if watched {
+			return
+		}
+	}
+}
+
+func isMonitoringDaemon(daemon string) bool {
+	for _, d := range monitoringDaemons {
+		if d == daemon {
+			return true
+		}
+	}
+
+	return false
 }
 
-func isMonitoringDisabled(daemon string, clusterSpec *cephv1.ClusterSpec) bool {
+// isMonitoringReady returns whether the prerequisites of the daemon health checker are met
+// Otherwise the start is deferred to the next reconcile, rather than having the goroutine spin on errors
+func isMonitoringReady(cluster *cluster, daemon string, cephUser string) bool {
+	switch daemon {
+	case "mon":
+		if cluster.mons == nil {
+			logger.Debugf("deferring ceph %s health go routine for cluster %q, the mons are not initialized yet", daemon, cluster.Namespace)
+			return false
+		}
+
+	case "status":
+		if cephUser == "" {
+			logger.Debugf("deferring ceph %s health go routine for cluster %q, the ceph user is not known yet", daemon, cluster.Namespace)
+			return false
+		}
+	}
+
+	return true
+}
+
+// isMonitoringDisabled returns whether the health check of the daemon must not run
+// Pausing the health checks overrides everything, then the annotation of the daemon on the CephCluster
+// overrides its spec setting
+func isMonitoringDisabled(daemon string, clusterSpec *cephv1.ClusterSpec, annotations map[string]string) bool {
+	// Pausing the health checks overrides the individual daemon settings
+	if clusterSpec.HealthCheck.Paused {
+		return true
+	}
+
+	// The health checkers remediating the daemons do not run while the reconcile of the cluster is paused
+	if daemon != "status" && isReconcilePaused(clusterSpec, annotations) {
+		return true
+	}
+
+	if value, ok := annotations[controller.MonitoringAnnotationPrefix+daemon]; ok {
+		switch value {
+		case monitoringAnnotationDisabled:
+			return true
+		case monitoringAnnotationEnabled:
+			return false
+		default:
+			logger.Warningf("ignoring annotation %q with invalid value %q, expecting %q or %q", controller.MonitoringAnnotationPrefix+daemon, value, monitoringAnnotationEnabled, monitoringAnnotationDisabled)
+		}
+	}
+
 	switch daemon {
 	case "mon":
 		return clusterSpec.HealthCheck.DaemonHealth.Monitor.Disabled
@@ -109,20 +352,182 @@ func isMonitoringDisabled(daemon string, clusterSpec *cephv1.ClusterSpec) bool {
 }
 
 func (c *ClusterController) startMonitoringCheck(cluster *cluster, daemon string, cephUser string) {
+	check := c.newMonitoringCheck(cluster, daemon, cephUser, cluster.monitoringChannels[daemon].checkDone)
+	if check == nil {
+		return
+	}
+
+	logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
+	cluster.monitoringChannels[daemon].cephUser = cephUser
+	cluster.monitoringChannels[daemon].triggerChan = make(chan struct{}, 1)
+	cluster.monitoringChannels[daemon].configChan = make(chan cephv1.CephClusterHealthCheckSpec, 1)
+	cluster.monitoringChannels[daemon].healthCheck = *cluster.Spec.HealthCheck.DeepCopy()
+	health := cluster.monitoringChannels[daemon]
+	stopChan, triggerChan, configChan := health.stopChan, health.triggerChan, health.configChan
+	c.goMonitoring(func() {
+		check(stopChan, triggerChan, configChan)
+	})
+}
+
+// goMonitoring runs a monitoring goroutine of a cluster, tracked until it returns
+func (c *ClusterController) goMonitoring(run func()) {
+	atomic.AddInt32(&c.activeMonitoringGoroutines, 1)
+	c.monitoringGoroutines.Add(1)
+	go func() {
+		defer c.monitoringGoroutines.Done()
+		defer atomic.AddInt32(&c.activeMonitoringGoroutines, -1)
+		run()
+	}()
+}
+
+// WaitForMonitoringGoroutines waits for all the stopped monitoring goroutines to return, and returns false if some are
+// still running after the timeout
+func (c *ClusterController) WaitForMonitoringGoroutines(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		c.monitoringGoroutines.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// updateMonitoringConfig pushes the health check settings of the cluster to the running goroutine of the daemon
+// if they changed, so the goroutine reloads them without being restarted
+func updateMonitoringConfig(cluster *cluster, daemon string) {
+	health := cluster.monitoringChannels[daemon]
+	if health.configChan == nil || reflect.DeepEqual(health.healthCheck, cluster.Spec.HealthCheck) {
+		return
+	}
+
+	healthCheck := *cluster.Spec.HealthCheck.DeepCopy()
+	logger.Infof("health check settings changed, updating the ceph %s health go routine for cluster %q", daemon, cluster.Namespace)
+	for {
+		select {
+		case health.configChan <- healthCheck:
+			health.healthCheck = healthCheck
+			return
+		default:
+			// the goroutine did not pick the previous settings yet, replace them with the latest ones
+			select {
+			case <-health.configChan:
+			default:
+			}
+		}
+	}
+}
+
+// ActiveMonitoringGoroutines returns the number of health goroutines currently running across all the clusters
+func (c *ClusterController) ActiveMonitoringGoroutines() int {
+	return int(atomic.LoadInt32(&c.activeMonitoringGoroutines))
+}
+
+// newMonitoringCheck returns the monitoring loop of the daemon, running until the stop channel is closed
+// A check runs immediately whenever the trigger channel receives, and the callback is called after every check.
+// The settings are reloaded whenever the config channel receives.
+func (c *ClusterController) newMonitoringCheck(cluster *cluster, daemon string, cephUser string, checkCallback func()) func(stopCh chan struct{}, triggerCh <-chan struct{}, configCh <-chan cephv1.CephClusterHealthCheckSpec) {
 	switch daemon {
 	case "mon":
 		healthChecker := mon.NewHealthChecker(cluster.mons, cluster.Spec)
-		logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
-		go healthChecker.Check(cluster.monitoringChannels[daemon].stopChan)
+		healthChecker.SetCheckCallback(checkCallback)
+		healthChecker.SetEventRecorder(c.recorder)
+		return healthChecker.Check
 
 	case "osd":
-		c.osdChecker = osd.NewOSDHealthMonitor(c.context, cluster.Namespace, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove, cluster.Spec.HealthCheck)
-		logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
-		go c.osdChecker.Start(cluster.monitoringChannels[daemon].stopChan)
+		osdChecker := osd.NewOSDHealthMonitor(controller.HealthCheckContext(controller.StoppableContext(c.context, cluster.ctx), cluster.Spec.HealthCheck), cluster.Namespace, cluster.Spec.RemoveOSDsIfOutAndSafeToRemove, cluster.Spec.HealthCheck)
+		osdChecker.SetCheckCallback(checkCallback)
+		osdChecker.SetEventRecorder(c.recorder, controller.ClusterEventObject(cluster.ownerRef, cluster.Namespace))
+		osdChecker.SetOwnerRef(cluster.ownerRef)
+		osdChecker.SetReprovisionCallback(func() { c.reprovisionOSDs(cluster) })
+		osdChecker.SetOSDsToRemove(osd.OSDsToRemove(cluster.annotations))
+		osdChecker.SetNodeMaintenance(cluster.Spec.DisruptionManagement.ManageNodeMaintenance)
+		osdChecker.SetWipeCallback(func(osdID int, nodeName string) { c.startOSDCleanUpJob(cluster, osdID, nodeName) })
+		osdChecker.SetRemovedNodes(cluster.Spec.Storage)
+		osdChecker.SetTopologyCallback(func(changes []cephv1.TopologyChange) {
+			if err := setRemovedNodes(c.client, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.crdName}, changes); err != nil {
+				logger.Warningf("failed to report the deleted osd nodes of cluster %q. %v", cluster.Namespace, err)
+			}
+		})
+		cluster.osdChecker = osdChecker
+		return osdChecker.Start
 
 	case "status":
-		cephChecker := newCephStatusChecker(c.context, cluster.Namespace, cephUser, c.namespacedName, cluster.Spec.HealthCheck)
-		logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
-		go cephChecker.checkCephStatus(cluster.monitoringChannels[daemon].stopChan)
+		cephChecker := newCephStatusChecker(controller.HealthCheckContext(controller.StoppableContext(c.context, cluster.ctx), statusHealthCheck(cluster.Spec.HealthCheck)), cluster.Namespace, cephUser, c.namespacedName, cluster.Spec.HealthCheck, c.recorder)
+		cephChecker.checkCallback = checkCallback
+		cephChecker.healthTransitionCallbacks = c.healthTransitionCallbacks
+		cephChecker.ownerRef = &cluster.ownerRef
+		return cephChecker.checkCephStatus
+	}
+
+	return nil
+}
+
+// reprovisionOSDs requeues a reconcile of the cluster once an out osd was purged, so that the osd prepare job of its
+// node or PVC adds the replacement disk back as a new osd. The reconcile is skipped once the cluster is deleted.
+func (c *ClusterController) reprovisionOSDs(cluster *cluster) {
+	logger.Infof("requesting a reconcile of cluster %q to reprovision the osds after an osd was purged", cluster.Namespace)
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: cluster.crdName, Namespace: cluster.Namespace}}
+	select {
+	case c.reconcileRequests <- event.GenericEvent{Meta: cephCluster, Object: cephCluster}:
+	default:
+		// reconciles are already queued, they provision the osds as well
+		logger.Debugf("reconcile of cluster %q already requested", cluster.Namespace)
+	}
+}
+
+// statusHealthCheck returns the health check settings of the ceph status checker
+// The timeout of the status check overrides the timeout of its ceph commands
+func statusHealthCheck(healthCheck cephv1.CephClusterHealthCheckSpec) cephv1.CephClusterHealthCheckSpec {
+	if timeout := healthCheck.DaemonHealth.Status.Timeout; timeout != "" {
+		healthCheck.CommandTimeout = timeout
+	}
+	return healthCheck
+}
+
+// RunHealthCheckNow triggers an immediate health check of a daemon monitored in the cluster of the namespace
+func (c *ClusterController) RunHealthCheckNow(namespace, daemon string) error {
+	cluster, ok := c.getCluster(namespace)
+	if !ok {
+		return errors.Errorf("failed to trigger ceph %s health check, cluster %q not found", daemon, namespace)
 	}
+	cluster.monitoringMux.Lock()
+	defer cluster.monitoringMux.Unlock()
+	health, ok := cluster.monitoringChannels[daemon]
+	if !ok || !health.monitoringRunning {
+		return errors.Errorf("failed to trigger ceph %s health check, %s is not monitored in cluster %q", daemon, daemon, namespace)
+	}
+
+	select {
+	case health.triggerChan <- struct{}{}:
+		logger.Infof("triggered ceph %s health check for cluster %q", daemon, namespace)
+	default:
+		// a check is already pending, it will run with the latest state anyway
+		logger.Debugf("ceph %s health check already triggered for cluster %q", daemon, namespace)
+	}
+
+	return nil
+}
+
+// LastCheckTimes returns the time of the last completed check of each daemon monitored in the cluster of the namespace
+func (c *ClusterController) LastCheckTimes(namespace string) map[string]time.Time {
+	lastChecks := map[string]time.Time{}
+	cluster, ok := c.getCluster(namespace)
+	if !ok {
+		return lastChecks
+	}
+	cluster.monitoringMux.Lock()
+	defer cluster.monitoringMux.Unlock()
+
+	for daemon, health := range cluster.monitoringChannels {
+		if lastCheck := health.getLastCheck(); !lastCheck.IsZero() {
+			lastChecks[daemon] = lastCheck
+		}
+	}
+
+	return lastChecks
 }
@@ -18,29 +18,671 @@ limitations under the License.
 package cluster
 
 import (
+	gocontext "context"
+	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+	crfake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
 func TestIsMonitoringDisabled(t *testing.T) {
 	type args struct {
 		daemon      string
 		clusterSpec *cephv1.ClusterSpec
+		annotations map[string]string
 	}
+	specDisabled := &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{ObjectStorageDaemon: cephv1.HealthCheckSpec{Disabled: true}}}}
 	tests := []struct {
 		name string
 		args args
 		want bool
 	}{
-		{"isDisabled", args{"mon", &cephv1.ClusterSpec{}}, false},
-		{"isEnabled", args{"mon", &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Monitor: cephv1.HealthCheckSpec{Disabled: true}}}}}, true},
+		{"isDisabled", args{"mon", &cephv1.ClusterSpec{}, nil}, false},
+		{"isEnabled", args{"mon", &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Monitor: cephv1.MonHealthCheckSpec{HealthCheckSpec: cephv1.HealthCheckSpec{Disabled: true}}}}}, nil}, true},
+		{"isPaused", args{"osd", &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{Paused: true}}, nil}, true},
+		{"specDisabled", args{"osd", specDisabled, map[string]string{}}, true},
+		{"annotationDisabled", args{"osd", &cephv1.ClusterSpec{}, map[string]string{"ceph.rook.io/monitoring-osd": "disabled"}}, true},
+		{"annotationOtherDaemon", args{"mon", &cephv1.ClusterSpec{}, map[string]string{"ceph.rook.io/monitoring-osd": "disabled"}}, false},
+		{"annotationEnabledOverridesSpec", args{"osd", specDisabled, map[string]string{"ceph.rook.io/monitoring-osd": "enabled"}}, false},
+		{"annotationInvalidUsesSpec", args{"osd", specDisabled, map[string]string{"ceph.rook.io/monitoring-osd": "off"}}, true},
+		{"pausedOverridesAnnotation", args{"osd", &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{Paused: true}}, map[string]string{"ceph.rook.io/monitoring-osd": "enabled"}}, true},
+		{"reconcilePausedStopsRemediation", args{"mon", &cephv1.ClusterSpec{ReconcileStrategy: cephv1.ReconcileStrategyPaused}, nil}, true},
+		{"reconcilePausedAnnotation", args{"osd", &cephv1.ClusterSpec{}, map[string]string{"ceph.rook.io/paused": "true"}}, true},
+		{"reconcilePausedKeepsStatus", args{"status", &cephv1.ClusterSpec{ReconcileStrategy: cephv1.ReconcileStrategyPaused}, nil}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := isMonitoringDisabled(tt.args.daemon, tt.args.clusterSpec); got != tt.want {
+			if got := isMonitoringDisabled(tt.args.daemon, tt.args.clusterSpec, tt.args.annotations); got != tt.want {
 				t.Errorf("isMonitoringEnabled() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func TestConfigureCephMonitoringPaused(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context}
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               spec,
+		mons:               &mon.Cluster{Namespace: "rook-ceph"},
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+	isRunning := func(daemon string) bool {
+		health, ok := cluster.monitoringChannels[daemon]
+		return ok && health.monitoringRunning
+	}
+
+	// paused at the first deployment, nothing is started
+	spec.HealthCheck.Paused = true
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.Equal(t, 0, len(cluster.monitoringChannels))
+
+	// unpaused, the daemons are started according to their own settings
+	spec.HealthCheck.Paused = false
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.True(t, isRunning("mon"))
+	assert.False(t, isRunning("osd"))
+	assert.True(t, isRunning("status"))
+	monStopChan := cluster.monitoringChannels["mon"].stopChan
+
+	// paused again, all the running goroutines are stopped
+	spec.HealthCheck.Paused = true
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.False(t, isRunning("mon"))
+	assert.False(t, isRunning("osd"))
+	assert.False(t, isRunning("status"))
+	_, open := <-monStopChan
+	assert.False(t, open)
+
+	// unpaused, the daemons are restored
+	spec.HealthCheck.Paused = false
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.True(t, isRunning("mon"))
+	assert.False(t, isRunning("osd"))
+	assert.True(t, isRunning("status"))
+	assert.NotEqual(t, monStopChan, cluster.monitoringChannels["mon"].stopChan)
+
+	// stop the goroutines
+	spec.HealthCheck.Paused = true
+	c.configureCephMonitoring(cluster, "client.admin")
+}
+
+func TestConfigureCephMonitoringUserChange(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context}
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Disabled = true
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               spec,
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+	assert.Equal(t, "client.admin", cluster.monitoringChannels["status"].cephUser)
+	stopChan := cluster.monitoringChannels["status"].stopChan
+
+	// same user, the goroutine keeps on running
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.Equal(t, stopChan, cluster.monitoringChannels["status"].stopChan)
+
+	// the user changed, the goroutine is recycled
+	c.configureCephMonitoring(cluster, "client.healthchecker")
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+	assert.Equal(t, "client.healthchecker", cluster.monitoringChannels["status"].cephUser)
+	assert.NotEqual(t, stopChan, cluster.monitoringChannels["status"].stopChan)
+	_, open := <-stopChan
+	assert.False(t, open)
+
+	// stop the goroutine
+	close(cluster.monitoringChannels["status"].stopChan)
+}
+
+func TestRunHealthCheckNow(t *testing.T) {
+	statusChecks := make(chan struct{}, 10)
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "status" {
+				statusChecks <- struct{}{}
+			}
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Disabled = true
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	cephCluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               spec,
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+	c := &ClusterController{context: context, clusterMap: map[string]*cluster{"rook-ceph": cephCluster}}
+
+	// unknown cluster
+	err := c.RunHealthCheckNow("other", "status")
+	assert.Error(t, err)
+
+	// the daemon is not monitored yet
+	err = c.RunHealthCheckNow("rook-ceph", "status")
+	assert.Error(t, err)
+
+	// the status checker runs a first check when starting
+	c.configureCephMonitoring(cephCluster, "client.admin")
+	<-statusChecks
+
+	// a triggered check runs without waiting for the interval
+	err = c.RunHealthCheckNow("rook-ceph", "status")
+	assert.NoError(t, err)
+	select {
+	case <-statusChecks:
+	case <-time.After(10 * time.Second):
+		assert.Fail(t, "triggered status check did not run")
+	}
+
+	// a disabled daemon cannot be triggered
+	err = c.RunHealthCheckNow("rook-ceph", "osd")
+	assert.Error(t, err)
+
+	// stop the goroutine
+	close(cephCluster.monitoringChannels["status"].stopChan)
+}
+
+func TestLastCheckTimes(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Disabled = true
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	cephCluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               spec,
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+	c := &ClusterController{context: context, clusterMap: map[string]*cluster{"rook-ceph": cephCluster}}
+	waitForCheckAfter := func(after time.Time) time.Time {
+		for i := 0; i < 100; i++ {
+			if lastCheck, ok := c.LastCheckTimes("rook-ceph")["status"]; ok && lastCheck.After(after) {
+				return lastCheck
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.Fail(t, "status check did not complete")
+		return after
+	}
+
+	// no check yet
+	assert.Equal(t, 0, len(c.LastCheckTimes("rook-ceph")))
+	assert.Equal(t, 0, len(c.LastCheckTimes("other")))
+
+	// the status checker runs a first check when starting
+	c.configureCephMonitoring(cephCluster, "client.admin")
+	firstCheck := waitForCheckAfter(time.Time{})
+
+	// the timestamp advances after the next check
+	err := c.RunHealthCheckNow("rook-ceph", "status")
+	assert.NoError(t, err)
+	secondCheck := waitForCheckAfter(firstCheck)
+	assert.True(t, secondCheck.After(firstCheck))
+
+	// the disabled daemons have no check time
+	_, ok := c.LastCheckTimes("rook-ceph")["osd"]
+	assert.False(t, ok)
+
+	// stop the goroutine
+	close(cephCluster.monitoringChannels["status"].stopChan)
+}
+
+func TestConfigureCephMonitoringForDaemons(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context}
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               &cephv1.ClusterSpec{},
+		mons:               &mon.Cluster{Namespace: "rook-ceph"},
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+
+	// first deployment of a single daemon
+	c.configureCephMonitoringForDaemons(cluster, "client.admin", []string{"status", "unknown"})
+	assert.Equal(t, 1, len(cluster.monitoringChannels))
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+
+	// the other daemons are left untouched
+	cluster.Spec.HealthCheck.DaemonHealth.Status.Disabled = true
+	c.configureCephMonitoringForDaemons(cluster, "client.admin", []string{"mon"})
+	assert.Equal(t, 2, len(cluster.monitoringChannels))
+	assert.True(t, cluster.monitoringChannels["mon"].monitoringRunning)
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+
+	// stop the goroutines
+	c.configureCephMonitoringForDaemons(cluster, "client.admin", []string{"status"})
+	assert.False(t, cluster.monitoringChannels["status"].monitoringRunning)
+	cluster.Spec.HealthCheck.DaemonHealth.Monitor.Disabled = true
+	c.configureCephMonitoringForDaemons(cluster, "client.admin", []string{"mon"})
+	assert.False(t, cluster.monitoringChannels["mon"].monitoringRunning)
+}
+
+func TestConfigureCephMonitoringNotReady(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context}
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               spec,
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+
+	// neither the mons nor the ceph user are ready, no goroutine is started
+	c.configureCephMonitoring(cluster, "")
+	assert.Equal(t, 0, len(cluster.monitoringChannels))
+
+	// the ceph user is ready, only the status goroutine is started
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.Equal(t, 1, len(cluster.monitoringChannels))
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+
+	// the mons are ready at the next reconcile
+	cluster.mons = &mon.Cluster{Namespace: "rook-ceph"}
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.True(t, cluster.monitoringChannels["mon"].monitoringRunning)
+
+	// stop the goroutines
+	spec.HealthCheck.Paused = true
+	c.configureCephMonitoring(cluster, "client.admin")
+}
+
+func TestBucketProvisionerStop(t *testing.T) {
+	k8s := fake.NewSimpleClientset()
+	testNamespace := "rook-ceph"
+	os.Setenv(k8sutil.PodNamespaceEnvVar, testNamespace)
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-operator-config", Namespace: testNamespace},
+		Data:       map[string]string{"ROOK_OBC_WATCH_OPERATOR_NAMESPACE": "true"},
+	}
+	_, err := k8s.CoreV1().ConfigMaps(testNamespace).Create(cm)
+	assert.NoError(t, err)
+	context := &clusterd.Context{Clientset: k8s}
+	c := NewClusterController(context, "", nil, nil, nil)
+	cluster1 := &cluster{Namespace: "cluster1", stopCh: make(chan struct{})}
+	cluster2 := &cluster{Namespace: "cluster2", stopCh: make(chan struct{})}
+
+	// namespaced, each cluster runs its provisioner
+	stopCh, ok := c.bucketProvisionerStop(cluster1)
+	assert.True(t, ok)
+	assert.Equal(t, cluster1.stopCh, stopCh)
+	stopCh, ok = c.bucketProvisionerStop(cluster2)
+	assert.True(t, ok)
+	assert.Equal(t, cluster2.stopCh, stopCh)
+	assert.Empty(t, c.bucketProvisionerOwner)
+
+	// cluster-wide, a single provisioner runs for all the clusters
+	cm.Data["ROOK_OBC_WATCH_OPERATOR_NAMESPACE"] = "false"
+	_, err = k8s.CoreV1().ConfigMaps(testNamespace).Update(cm)
+	assert.NoError(t, err)
+	stopCh, ok = c.bucketProvisionerStop(cluster1)
+	assert.True(t, ok)
+	assert.Equal(t, c.bucketProvisionerStopCh, stopCh)
+	assert.Equal(t, "cluster1", c.bucketProvisionerOwner)
+	_, ok = c.bucketProvisionerStop(cluster2)
+	assert.False(t, ok)
+	_, ok = c.bucketProvisionerStop(cluster1)
+	assert.False(t, ok)
+
+	// the cluster-wide provisioner is only stopped with the cluster it runs for
+	c.stopBucketProvisioner(cluster2)
+	assert.Equal(t, "cluster1", c.bucketProvisionerOwner)
+	c.stopBucketProvisioner(cluster1)
+	assert.Empty(t, c.bucketProvisionerOwner)
+	_, open := <-stopCh
+	assert.False(t, open)
+
+	// another cluster can then start it with its own stop channel
+	newStopCh, ok := c.bucketProvisionerStop(cluster2)
+	assert.True(t, ok)
+	assert.NotEqual(t, stopCh, newStopCh)
+	assert.Equal(t, "cluster2", c.bucketProvisionerOwner)
+}
+
+func TestReprovisionOSDs(t *testing.T) {
+	c := &ClusterController{reconcileRequests: make(chan event.GenericEvent, 1)}
+	cluster := &cluster{Namespace: "rook-ceph", crdName: "my-cluster"}
+
+	// the reconcile of the cluster is requested, a pending request is not duplicated
+	c.reprovisionOSDs(cluster)
+	c.reprovisionOSDs(cluster)
+	request := <-c.reconcileRequests
+	assert.Equal(t, "my-cluster", request.Meta.GetName())
+	assert.Equal(t, "rook-ceph", request.Meta.GetNamespace())
+	assert.Equal(t, 0, len(c.reconcileRequests))
+
+	// the request does not block when the controller does not queue the reconciles
+	c.reconcileRequests = nil
+	c.reprovisionOSDs(cluster)
+}
+
+func TestActiveMonitoringGoroutines(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context, clusterMap: make(map[string]*cluster)}
+	assert.Equal(t, 0, c.ActiveMonitoringGoroutines())
+
+	// create the clusters, each one runs the mon, osd and status goroutines
+	for _, namespace := range []string{"cluster1", "cluster2", "cluster3"} {
+		cluster := &cluster{
+			Namespace:          namespace,
+			context:            context,
+			Spec:               &cephv1.ClusterSpec{},
+			mons:               &mon.Cluster{Namespace: namespace},
+			stopCh:             make(chan struct{}),
+			watchersActivated:  true,
+			monitoringChannels: make(map[string]*clusterHealth),
+		}
+		c.clusterMap[namespace] = cluster
+		c.configureCephMonitoring(cluster, "client.admin")
+	}
+	assert.Equal(t, 9, c.ActiveMonitoringGoroutines())
+
+	// stopping one daemon stops its goroutine only
+	cluster := c.clusterMap["cluster1"]
+	cluster.Spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Disabled = true
+	c.configureCephMonitoring(cluster, "client.admin")
+	waitForMonitoringGoroutines(t, c, 8)
+
+	// delete the clusters
+	c.StopWatch()
+	waitForMonitoringGoroutines(t, c, 0)
+}
+
+func waitForMonitoringGoroutines(t *testing.T, c *ClusterController, expected int) {
+	for i := 0; i < 50 && c.ActiveMonitoringGoroutines() != expected; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	assert.Equal(t, expected, c.ActiveMonitoringGoroutines())
+}
+
+func TestStartStopMonitoring(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context}
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            context,
+		Spec:               &cephv1.ClusterSpec{},
+		mons:               &mon.Cluster{Namespace: "rook-ceph"},
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+
+	c.StartMonitoring(cluster, "client.admin")
+	assert.Equal(t, 3, len(cluster.monitoringChannels))
+	assert.Equal(t, 3, c.ActiveMonitoringGoroutines())
+	healths := []*clusterHealth{}
+	for _, health := range cluster.monitoringChannels {
+		assert.True(t, health.monitoringRunning)
+		healths = append(healths, health)
+	}
+	// the watchers are not started by the monitoring
+	assert.False(t, cluster.watchersActivated)
+
+	c.StopMonitoring(cluster)
+	assert.Equal(t, 0, len(cluster.monitoringChannels))
+	for _, health := range healths {
+		assert.False(t, health.monitoringRunning)
+	}
+	waitForMonitoringGoroutines(t, c, 0)
+
+	// stopping twice is a no-op, and the monitoring can start again
+	c.StopMonitoring(cluster)
+	c.StartMonitoring(cluster, "client.admin")
+	assert.Equal(t, 3, len(cluster.monitoringChannels))
+	c.StopMonitoring(cluster)
+	waitForMonitoringGoroutines(t, c, 0)
+}
+
+func TestStopClusterGoroutines(t *testing.T) {
+	var executed int32
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			atomic.AddInt32(&executed, 1)
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context, clusterMap: make(map[string]*cluster)}
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"}}
+	cluster := newCluster(cephCluster, context, &sync.Mutex{}, &metav1.OwnerReference{})
+	cluster.watchersActivated = true
+	c.clusterMap[cluster.Namespace] = cluster
+
+	c.StartMonitoring(cluster, "client.admin")
+	assert.Equal(t, 3, c.ActiveMonitoringGoroutines())
+
+	// all the goroutines return once the cluster is stopped
+	c.StopMonitoring(cluster)
+	cluster.stop()
+	assert.True(t, c.WaitForMonitoringGoroutines(5*time.Second))
+	assert.Equal(t, 0, c.ActiveMonitoringGoroutines())
+	assert.True(t, cluster.closedStopCh)
+	assert.False(t, cluster.watchersActivated)
+
+	// the commands of the stopped cluster are not run anymore
+	atomic.StoreInt32(&executed, 0)
+	_, err := opcontroller.StoppableContext(context, cluster.ctx).Executor.ExecuteCommandWithOutput("ceph", "status")
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&executed))
+
+	// stopping twice is a no-op
+	cluster.stop()
+}
+
+func TestConcurrentClusterMonitoring(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	context := &clusterd.Context{Executor: executor}
+	c := &ClusterController{context: context, clusterMap: make(map[string]*cluster)}
+
+	// the clusters are reconciled and their health checks triggered concurrently
+	namespaces := []string{"cluster1", "cluster2", "cluster3"}
+	var wg sync.WaitGroup
+	for _, namespace := range namespaces {
+		cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: namespace, UID: types.UID(namespace)}}
+		cluster := newCluster(cephCluster, context, &sync.Mutex{}, &metav1.OwnerReference{})
+		cluster.watchersActivated = true
+		c.setCluster(cluster)
+		for i := 0; i < 3; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				c.configureCephMonitoring(cluster, "client.admin")
+			}()
+			go func(namespace string) {
+				defer wg.Done()
+				_ = c.RunHealthCheckNow(namespace, "status")
+				c.LastCheckTimes(namespace)
+			}(namespace)
+		}
+	}
+	wg.Wait()
+	assert.Equal(t, 9, c.ActiveMonitoringGoroutines())
+
+	// stopping a cluster stops exactly its goroutines
+	cluster1, ok := c.getCluster("cluster1")
+	assert.True(t, ok)
+	assert.NotNil(t, cluster1.osdChecker)
+	c.StopMonitoring(cluster1)
+	cluster1.stop()
+	c.deleteCluster(cluster1)
+	waitForMonitoringGoroutines(t, c, 6)
+	_, ok = c.getCluster("cluster1")
+	assert.False(t, ok)
+	cluster2, _ := c.getCluster("cluster2")
+	assert.Equal(t, 3, len(cluster2.monitoringChannels))
+	assert.NotNil(t, cluster2.osdChecker)
+
+	// a cluster replaced in its namespace is not forgotten when deleting the previous one
+	recreated := newCluster(&cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "cluster2", UID: "new"}}, context, &sync.Mutex{}, &metav1.OwnerReference{})
+	c.setCluster(recreated)
+	c.deleteCluster(cluster2)
+	current, ok := c.getCluster("cluster2")
+	assert.True(t, ok)
+	assert.Equal(t, types.UID("new"), current.uid)
+
+	c.StopMonitoring(cluster2)
+	c.StopWatch()
+	assert.Equal(t, 0, c.ActiveMonitoringGoroutines())
+}
+
+func TestUpdateMonitoringConfig(t *testing.T) {
+	spec := &cephv1.ClusterSpec{}
+	cluster := &cluster{
+		Namespace: "rook-ceph",
+		Spec:      spec,
+		monitoringChannels: map[string]*clusterHealth{
+			"osd": {monitoringRunning: true, configChan: make(chan cephv1.CephClusterHealthCheckSpec, 1)},
+		},
+	}
+	configChan := cluster.monitoringChannels["osd"].configChan
+
+	// same settings, nothing is pushed
+	updateMonitoringConfig(cluster, "osd")
+	assert.Equal(t, 0, len(configChan))
+
+	// the interval changed, the new settings are pushed
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Interval = "10s"
+	updateMonitoringConfig(cluster, "osd")
+	assert.Equal(t, 1, len(configChan))
+
+	// the settings changed again before the goroutine picked them, only the latest are pending
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Interval = "20s"
+	updateMonitoringConfig(cluster, "osd")
+	assert.Equal(t, 1, len(configChan))
+	healthCheck := <-configChan
+	assert.Equal(t, "20s", healthCheck.DaemonHealth.ObjectStorageDaemon.Interval)
+	assert.Equal(t, "20s", cluster.monitoringChannels["osd"].healthCheck.DaemonHealth.ObjectStorageDaemon.Interval)
+
+	// no goroutine started yet
+	cluster.monitoringChannels["mon"] = &clusterHealth{}
+	updateMonitoringConfig(cluster, "mon")
+}
+
+func TestConfigureCephMonitoringFakeCluster(t *testing.T) {
+	fakeCluster := clienttest.NewFakeCephCluster("node0", "node1")
+	fakeCluster.SetHealth("HEALTH_WARN", map[string]string{"OSD_DOWN": "1 osds down"})
+	fakeCluster.SetOSD(clienttest.OSD{ID: 1, Host: "node1", Up: false, In: false})
+	context := fakeCluster.Context(t, 2)
+	namespacedName := types.NamespacedName{Namespace: "rook-ceph", Name: "rook-ceph"}
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	context.Client = crfake.NewFakeClientWithScheme(s, &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace}})
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Disabled = true
+	cephCluster := &cluster{
+		Namespace:          namespacedName.Namespace,
+		crdName:            namespacedName.Name,
+		context:            context,
+		Spec:               spec,
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+	c := &ClusterController{context: context, client: context.Client, namespacedName: namespacedName, clusterMap: map[string]*cluster{"rook-ceph": cephCluster}}
+	waitForCheck := func(daemon string) {
+		for i := 0; i < 100; i++ {
+			if _, ok := c.LastCheckTimes("rook-ceph")[daemon]; ok {
+				return
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		assert.Fail(t, "ceph "+daemon+" check did not complete")
+	}
+
+	// the status checker reports the health of the fake cluster in the CephCluster
+	c.configureCephMonitoring(cephCluster, "client.admin")
+	assert.NotNil(t, cephCluster.osdChecker)
+	waitForCheck("status")
+	assert.True(t, fakeCluster.CommandCount("status") > 0)
+	updated := &cephv1.CephCluster{}
+	assert.NoError(t, c.client.Get(gocontext.TODO(), namespacedName, updated))
+	assert.Equal(t, "HEALTH_WARN", updated.Status.CephStatus.Health)
+	assert.Equal(t, "1 osds down", updated.Status.CephStatus.Details["OSD_DOWN"].Message)
+
+	// the out osd is left in the cluster since the removal of the out osds is not enabled
+	assert.NoError(t, c.RunHealthCheckNow("rook-ceph", "osd"))
+	waitForCheck("osd")
+	assert.True(t, fakeCluster.CommandCount("osd dump") > 0)
+	assert.Equal(t, 0, fakeCluster.CommandCount("osd purge"))
+
+	c.StopMonitoring(cephCluster)
+	waitForMonitoringGoroutines(t, c, 0)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+)
+
+const (
+	// monRestoreConfigMapName is the configmap recording the last restore of the mon quorum
+	monRestoreConfigMapName = "rook-ceph-mon-quorum-restore"
+	restoreRequestKey       = "restoreRequest"
+)
+
+// restoreMonQuorumIfRequested restores the mon quorum from the mon named by the annotation of the CephCluster, once
+// per request. The last request is forgotten when the annotation is removed, so that the quorum can be restored again
+// from the same mon.
+func (c *cluster) restoreMonQuorumIfRequested(rookImage string, cephVersion cephver.CephVersion) error {
+	configMap, err := c.getStateConfigMap(monRestoreConfigMapName)
+	if err != nil {
+		return err
+	}
+
+	monID := c.annotations[controller.RestoreMonQuorumAnnotation]
+	lastRequest, restored := configMap.Data[restoreRequestKey]
+	if monID == "" {
+		if restored {
+			delete(configMap.Data, restoreRequestKey)
+			return c.saveStateConfigMap(configMap)
+		}
+		return nil
+	}
+	if restored && lastRequest == monID {
+		logger.Debugf("mon quorum of cluster %q already restored from mon %q", c.Namespace, monID)
+		return nil
+	}
+
+	logger.Warningf("restoring the mon quorum of cluster %q from mon %q", c.Namespace, monID)
+	if err := c.mons.RestoreQuorum(rookImage, cephVersion, *c.Spec, monID); err != nil {
+		return errors.Wrapf(err, "failed to restore the mon quorum from mon %q", monID)
+	}
+
+	configMap.Data[restoreRequestKey] = monID
+	return c.saveStateConfigMap(configMap)
+}
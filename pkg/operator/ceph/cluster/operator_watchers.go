@@ -42,11 +42,21 @@ func (c *ClusterController) StartOperatorSettingsWatch(namespace string, stopCh
 }
 
 // StopWatch stop watchers
+// The goroutines of the clusters are given some time to return so that none keeps on running ceph commands.
 func (c *ClusterController) StopWatch() {
-	for _, cluster := range c.clusterMap {
-		close(cluster.stopCh)
-	}
+	c.clusterMapMux.Lock()
+	clusters := c.clusterMap
 	c.clusterMap = make(map[string]*cluster)
+	c.clusterMapMux.Unlock()
+
+	for _, cluster := range clusters {
+		c.StopMonitoring(cluster)
+		cluster.stop()
+	}
+
+	if !c.WaitForMonitoringGoroutines(stopWatchTimeout) {
+		logger.Warningf("%d monitoring goroutines still running %s after stopping the watchers", c.ActiveMonitoringGoroutines(), stopWatchTimeout.String())
+	}
 }
 
 func (c *ClusterController) operatorConfigChange(obj interface{}) {
@@ -57,6 +67,9 @@ func (c *ClusterController) operatorConfigChange(obj interface{}) {
 	}
 
 	logger.Infof("ConfigMap %q changes detected. Updating configurations", cm.Name)
+	if err := applyLogLevels(cm); err != nil {
+		logger.Errorf("failed to set the operator log levels. %v", err)
+	}
 	for _, callback := range c.operatorConfigCallbacks {
 		if err := callback(); err != nil {
 			logger.Errorf("%v", err)
@@ -20,7 +20,9 @@ import (
 	"fmt"
 	"strconv"
 
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	v1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -28,6 +30,9 @@ const (
 	keyringTemplate = `[osd.%s]
 key = %s
 `
+	// share of the memory limit of the osd pod used as memory target of the osd
+	osdMemoryTargetFactor = 0.8
+	osdMemoryTargetOption = "osd_memory_target"
 )
 
 func (c *Cluster) generateKeyring(osdID int) (string, error) {
@@ -47,3 +52,16 @@ func (c *Cluster) generateKeyring(osdID int) (string, error) {
 	keyring := fmt.Sprintf(keyringTemplate, osdIDStr, key)
 	return keyring, s.CreateOrUpdate(deploymentName, keyring)
 }
+
+// setMemoryTarget sets the memory target of the osd from the memory limit of its pod, since the default
+// memory target of ceph ignores the limit and gets the osd OOM-killed. The default is restored without limit.
+func (c *Cluster) setMemoryTarget(osdID int, resources v1.ResourceRequirements) error {
+	monStore := opconfig.GetMonStore(c.context, c.Namespace)
+	who := fmt.Sprintf("osd.%d", osdID)
+
+	if resources.Limits.Memory().IsZero() {
+		return monStore.Delete(who, osdMemoryTargetOption)
+	}
+	osdMemoryTarget := float64(resources.Limits.Memory().Value()) * osdMemoryTargetFactor
+	return monStore.Set(who, osdMemoryTargetOption, strconv.FormatInt(int64(osdMemoryTarget), 10))
+}
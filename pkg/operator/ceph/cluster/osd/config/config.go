@@ -44,6 +44,7 @@ const (
 	OSDsPerDeviceKey   = "osdsPerDevice"
 	EncryptedDeviceKey = "encryptedDevice"
 	MetadataDeviceKey  = "metadataDevice"
+	WalDeviceKey       = "walDevice"
 	DeviceClassKey     = "deviceClass"
 )
 
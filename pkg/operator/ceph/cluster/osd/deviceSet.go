@@ -59,7 +59,11 @@ func (c *Cluster) prepareStorageClassDeviceSets(config *provisionConfig) []rookv
 				if pvcTemplate.Name == bluestorePVCData {
 					pvcSize := pvc.Spec.Resources.Requests[v1.ResourceStorage]
 					dataSize = pvcSize.String()
-					crushDeviceClass = pvcTemplate.Annotations["crushDeviceClass"]
+					// the deviceClass of the set takes precedence over the annotation of its data template
+					crushDeviceClass = storageClassDeviceSet.DeviceClass
+					if crushDeviceClass == "" {
+						crushDeviceClass = pvcTemplate.Annotations["crushDeviceClass"]
+					}
 				}
 				pvcSources[pvcTemplate.Name] = v1.PersistentVolumeClaimVolumeSource{
 					ClaimName: pvc.GetName(),
@@ -72,6 +76,7 @@ func (c *Cluster) prepareStorageClassDeviceSets(config *provisionConfig) []rookv
 				Name:                storageClassDeviceSet.Name,
 				Resources:           storageClassDeviceSet.Resources,
 				Placement:           storageClassDeviceSet.Placement,
+				PreparePlacement:    storageClassDeviceSet.PreparePlacement,
 				Config:              storageClassDeviceSet.Config,
 				Size:                dataSize,
 				PVCSources:          pvcSources,
@@ -0,0 +1,238 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	encryptionDMNameFmt                = "%s-block-dmcrypt"
+	encryptionDMDir                    = "/dev/mapper"
+	encryptionOpenInitContainer        = "encryption-open"
+	blockPVCMapperEncryptionContainer  = "blkdevmapper-encryption"
+	encryptionTmpBlockName             = "block-tmp"
+	keyRotationAppName                 = "rook-ceph-osd-key-rotation"
+	keyRotationAppNameFmt              = "rook-ceph-osd-key-rotation-%s"
+	keyRotationContainer               = "rotate"
+	encryptionKeyRotationAnnotationKey = "ceph.rook.io/encryption-key-rotation"
+)
+
+// EncryptionDMName returns the name of the dm-crypt device of the block of the osd on a pvc
+func EncryptionDMName(pvcName string) string {
+	return fmt.Sprintf(encryptionDMNameFmt, pvcName)
+}
+
+// EncryptionDMPath returns the path of the dm-crypt device of the given name
+func EncryptionDMPath(dmName string) string {
+	return path.Join(encryptionDMDir, dmName)
+}
+
+// SetKeyManagementService sets the key management service storing the encryption keys of the osds on pvcs, the keys
+// being stored in kubernetes secrets when none is configured
+func (c *Cluster) SetKeyManagementService(spec cephv1.KeyManagementServiceSpec) {
+	c.kms = spec
+}
+
+// SetEncryptionKeyRotation sets the value of the annotation of the CephCluster requesting the rotation of the
+// encryption keys, the keys being rotated once for each new value
+func (c *Cluster) SetEncryptionKeyRotation(token string) {
+	c.keyRotation = token
+}
+
+func (osdProps osdProperties) encrypted() bool {
+	return osdProps.onPVC() && osdProps.storeConfig.EncryptedDevice
+}
+
+// ensureEncryptionKey stores the encryption key of the osd on the pvc before it is prepared, so that the key stored
+// in a kubernetes secret is owned by the cluster
+func (c *Cluster) ensureEncryptionKey(pvcName string) error {
+	kmsConfig, err := kms.NewConfigFromSpec(c.context, c.Namespace, c.kms, &c.ownerRef)
+	if err != nil {
+		return errors.Wrap(err, "failed to configure the key management service")
+	}
+	return kmsConfig.EnsureSecret(kms.EncryptionKeyName(pvcName))
+}
+
+// encryptionEnvVars returns the env vars of the containers opening the encrypted block of an osd on a pvc
+func (c *Cluster) encryptionEnvVars() []v1.EnvVar {
+	return append([]v1.EnvVar{opmon.ClusterNameEnvVar(c.Namespace)}, kms.EnvVars(c.kms)...)
+}
+
+// getEncryptionOpenInitContainer opens the dm-crypt device of the block copied by the blkdevmapper container, the
+// kernel creating the device on the /dev of the host
+func (c *Cluster) getEncryptionOpenInitContainer(mountPath string, osdProps osdProperties) v1.Container {
+	return v1.Container{
+		Name:    encryptionOpenInitContainer,
+		Image:   c.cephVersion.Image,
+		Command: []string{path.Join(rookBinariesMountPath, "rook")},
+		Args: []string{
+			"ceph", "osd", "encryption", "open",
+			"--device", path.Join(mountPath, encryptionTmpBlockName),
+			"--pvc-name", osdProps.pvc.ClaimName,
+		},
+		VolumeMounts: []v1.VolumeMount{
+			getPvcOSDBridgeMountActivate(mountPath, osdProps.pvc.ClaimName),
+			{Name: "devices", MountPath: "/dev"},
+			{Name: rookBinariesVolumeName, MountPath: rookBinariesMountPath},
+		},
+		Env:             c.encryptionEnvVars(),
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+// getPVCEncryptionInitContainerActivate copies the dm-crypt device of the block to the path of the block of the osd
+func (c *Cluster) getPVCEncryptionInitContainerActivate(mountPath string, osdProps osdProperties) v1.Container {
+	return v1.Container{
+		Name:  blockPVCMapperEncryptionContainer,
+		Image: c.cephVersion.Image,
+		Command: []string{
+			"cp",
+		},
+		Args: []string{"-a", EncryptionDMPath(EncryptionDMName(osdProps.pvc.ClaimName)), path.Join(mountPath, "block")},
+		VolumeMounts: []v1.VolumeMount{
+			getPvcOSDBridgeMountActivate(mountPath, osdProps.pvc.ClaimName),
+			{Name: "devices", MountPath: "/dev"},
+		},
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+// rotateEncryptionKeys starts a job rotating the key of each running encrypted osd on a pvc when the annotation of
+// the CephCluster requests a new rotation. The job of an osd is only replaced once the annotation changes.
+func (c *Cluster) rotateEncryptionKeys() {
+	if c.keyRotation == "" {
+		return
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s", k8sutil.AppAttr, AppName, OSDOverPVCLabelKey)}
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(listOpts)
+	if err != nil {
+		logger.Errorf("failed to list the osd pods to rotate their encryption keys. %v", err)
+		return
+	}
+
+	for _, pod := range pods.Items {
+		pvcName := pod.Labels[OSDOverPVCLabelKey]
+		if pod.Status.Phase != v1.PodRunning || pod.Spec.NodeName == "" {
+			logger.Infof("skipping the rotation of the encryption key of the osd on pvc %q until it runs", pvcName)
+			continue
+		}
+		osdProps, err := c.getOSDPropsForPVC(pvcName)
+		if err != nil {
+			logger.Errorf("failed to get the properties of the osd on pvc %q. %v", pvcName, err)
+			continue
+		}
+		if !osdProps.encrypted() {
+			continue
+		}
+		if err := c.rotateEncryptionKey(osdProps, pod.Spec.NodeName); err != nil {
+			logger.Errorf("failed to rotate the encryption key of the osd on pvc %q. %v", pvcName, err)
+		}
+	}
+}
+
+func (c *Cluster) rotateEncryptionKey(osdProps osdProperties, nodeName string) error {
+	job := c.makeKeyRotationJob(osdProps, nodeName)
+	existingJob, err := c.context.Clientset.BatchV1().Jobs(c.Namespace).Get(job.Name, metav1.GetOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the key rotation job %q", job.Name)
+	}
+	if err == nil && existingJob.Annotations[encryptionKeyRotationAnnotationKey] == c.keyRotation {
+		logger.Debugf("encryption key of the osd on pvc %q already rotated for %q", osdProps.pvc.ClaimName, c.keyRotation)
+		return nil
+	}
+
+	logger.Infof("rotating the encryption key of the osd on pvc %q", osdProps.pvc.ClaimName)
+	return k8sutil.RunReplaceableJob(c.context.Clientset, job, true)
+}
+
+// makeKeyRotationJob returns the job rotating the encryption key of the osd on the pvc. The job runs on the node of
+// the osd since the pvc is already attached to it.
+func (c *Cluster) makeKeyRotationJob(osdProps osdProperties, nodeName string) *batch.Job {
+	copyBinariesVolume, copyBinariesContainer := c.getCopyBinariesContainer()
+	volumes := append(getPVCOSDVolumes(&osdProps), copyBinariesVolume)
+
+	rotateContainer := v1.Container{
+		Name:    keyRotationContainer,
+		Image:   c.cephVersion.Image,
+		Command: []string{path.Join(rookBinariesMountPath, "rook")},
+		Args: []string{
+			"ceph", "osd", "encryption", "rotate-key",
+			"--device", fmt.Sprintf("/mnt/%s", osdProps.pvc.ClaimName),
+			"--pvc-name", osdProps.pvc.ClaimName,
+		},
+		VolumeMounts: []v1.VolumeMount{
+			getPvcOSDBridgeMount(osdProps.pvc.ClaimName),
+			copyBinariesContainer.VolumeMounts[0],
+		},
+		Env:             c.encryptionEnvVars(),
+		SecurityContext: PrivilegedContext(),
+	}
+
+	labels := map[string]string{
+		k8sutil.AppAttr:     keyRotationAppName,
+		k8sutil.ClusterAttr: c.Namespace,
+		OSDOverPVCLabelKey:  osdProps.pvc.ClaimName,
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        k8sutil.TruncateNodeName(keyRotationAppNameFmt, osdProps.pvc.ClaimName),
+			Namespace:   c.Namespace,
+			Labels:      labels,
+			Annotations: map[string]string{encryptionKeyRotationAnnotationKey: c.keyRotation},
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: v1.PodSpec{
+					ServiceAccountName: serviceAccountName,
+					NodeSelector:       map[string]string{v1.LabelHostname: nodeName},
+					InitContainers: []v1.Container{
+						*copyBinariesContainer,
+						c.getPVCInitContainer(osdProps),
+					},
+					Containers:        []v1.Container{rotateContainer},
+					RestartPolicy:     v1.RestartPolicyOnFailure,
+					Volumes:           volumes,
+					PriorityClassName: c.priorityClassName,
+				},
+			},
+		},
+	}
+	osdProps.placement.ApplyToPodSpec(&job.Spec.Template.Spec)
+	k8sutil.ApplyImagePullSecrets(c.Namespace, &job.Spec.Template.Spec)
+
+	k8sutil.AddRookVersionLabelToJob(job)
+	controller.AddCephVersionLabelToJob(c.clusterInfo.CephVersion, job)
+	k8sutil.SetOwnerRef(&job.ObjectMeta, &c.ownerRef)
+	return job
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newEncryptionTestCluster(clientset *fake.Clientset) *Cluster {
+	clusterInfo := &cephconfig.ClusterInfo{CephVersion: cephver.Octopus}
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	c := New(clusterInfo, context, "ns", "rook/rook:myversion", cephv1.CephVersionSpec{Image: "ceph/ceph:v15"},
+		rookv1.StorageScopeSpec{}, cephv1.DriveGroupsSpec{}, "", rookv1.Placement{}, rookv1.Annotations{}, cephv1.NetworkSpec{},
+		v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", metav1.OwnerReference{UID: "uid"}, false, false, cephv1.CephClusterHealthCheckSpec{})
+	c.SetKeyManagementService(cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{kms.Provider: kms.ProviderVault, "VAULT_ADDR": "https://vault:8200"},
+		TokenSecretName:   "vault-token",
+	})
+	return c
+}
+
+func TestEncryptedPVCDeployment(t *testing.T) {
+	c := newEncryptionTestCluster(fake.NewSimpleClientset())
+	osdProps := osdProperties{
+		crushHostname: "mypvc",
+		pvc:           v1.PersistentVolumeClaimVolumeSource{ClaimName: "mypvc"},
+		storeConfig:   config.StoreConfig{EncryptedDevice: true},
+		portable:      true,
+	}
+	provisionConfig := &provisionConfig{DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, "/var/lib/rook")}
+
+	deployment, err := c.makeDeployment(osdProps, OSDInfo{ID: 0, CVMode: "raw"}, provisionConfig)
+	assert.NoError(t, err)
+	initContainers := deployment.Spec.Template.Spec.InitContainers
+	assert.Equal(t, 7, len(initContainers))
+	assert.Equal(t, "copy-bins", initContainers[0].Name)
+	assert.Equal(t, "blkdevmapper", initContainers[1].Name)
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-0/block-tmp", initContainers[1].Args[2])
+	assert.Equal(t, "encryption-open", initContainers[2].Name)
+	assert.Equal(t, []string{"ceph", "osd", "encryption", "open", "--device", "/var/lib/ceph/osd/ceph-0/block-tmp", "--pvc-name", "mypvc"}, initContainers[2].Args)
+	verifyEnvVar(t, initContainers[2].Env, "VAULT_ADDR", "https://vault:8200", true)
+	verifyEnvVar(t, initContainers[2].Env, "ROOK_CLUSTER_NAME", "ns", true)
+	assert.Equal(t, "blkdevmapper-encryption", initContainers[3].Name)
+	assert.Equal(t, []string{"-a", "/dev/mapper/mypvc-block-dmcrypt", "/var/lib/ceph/osd/ceph-0/block"}, initContainers[3].Args)
+	assert.Equal(t, "activate", initContainers[4].Name)
+	assert.Equal(t, 0, len(initContainers[4].VolumeDevices))
+	assert.Equal(t, "expand-bluefs", initContainers[5].Name)
+
+	// the prepare job gets the connection details of the kms
+	job, err := c.makeJob(osdProps, provisionConfig)
+	assert.NoError(t, err)
+	provision := job.Spec.Template.Spec.Containers[0]
+	verifyEnvVar(t, provision.Env, "ROOK_ENCRYPTED_DEVICE", "true", true)
+	verifyEnvVar(t, provision.Env, kms.Provider, kms.ProviderVault, true)
+	assert.True(t, job.Spec.Template.Spec.HostIPC)
+
+	// an osd that is not encrypted keeps its block from the pvc
+	osdProps.storeConfig.EncryptedDevice = false
+	deployment, err = c.makeDeployment(osdProps, OSDInfo{ID: 0, CVMode: "raw"}, provisionConfig)
+	assert.NoError(t, err)
+	initContainers = deployment.Spec.Template.Spec.InitContainers
+	assert.Equal(t, 4, len(initContainers))
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-0/block", initContainers[0].Args[2])
+	assert.Equal(t, 1, len(initContainers[1].VolumeDevices))
+}
+
+func TestRotateEncryptionKeys(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := newEncryptionTestCluster(clientset)
+	c.ValidStorage.VolumeSources = []rookv1.VolumeSource{
+		{
+			Name:       "set1",
+			PVCSources: map[string]v1.PersistentVolumeClaimVolumeSource{bluestorePVCData: {ClaimName: "pvc1"}},
+			Config:     map[string]string{config.EncryptedDeviceKey: "true"},
+			Portable:   true,
+		},
+		{
+			Name:       "set2",
+			PVCSources: map[string]v1.PersistentVolumeClaimVolumeSource{bluestorePVCData: {ClaimName: "pvc2"}},
+			Portable:   true,
+		},
+	}
+	for _, pvc := range []string{"pvc1", "pvc2"} {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "osd-" + pvc,
+				Labels: map[string]string{k8sutil.AppAttr: AppName, OSDOverPVCLabelKey: pvc},
+			},
+			Spec:   v1.PodSpec{NodeName: "node1"},
+			Status: v1.PodStatus{Phase: v1.PodRunning},
+		}
+		_, err := clientset.CoreV1().Pods(c.Namespace).Create(pod)
+		assert.NoError(t, err)
+	}
+
+	// no rotation is requested
+	c.rotateEncryptionKeys()
+	jobs, _ := clientset.BatchV1().Jobs(c.Namespace).List(metav1.ListOptions{})
+	assert.Equal(t, 0, len(jobs.Items))
+
+	// only the encrypted osd has its key rotated, on the node of the osd
+	c.SetEncryptionKeyRotation("1")
+	c.rotateEncryptionKeys()
+	jobs, _ = clientset.BatchV1().Jobs(c.Namespace).List(metav1.ListOptions{})
+	assert.Equal(t, 1, len(jobs.Items))
+	job := jobs.Items[0]
+	assert.Equal(t, "rook-ceph-osd-key-rotation-pvc1", job.Name)
+	assert.Equal(t, "1", job.Annotations[encryptionKeyRotationAnnotationKey])
+	assert.Equal(t, "node1", job.Spec.Template.Spec.NodeSelector[v1.LabelHostname])
+	rotate := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"ceph", "osd", "encryption", "rotate-key", "--device", "/mnt/pvc1", "--pvc-name", "pvc1"}, rotate.Args)
+	verifyEnvVar(t, rotate.Env, "VAULT_ADDR", "https://vault:8200", true)
+
+	// the job is kept until another rotation is requested
+	job.Status.Succeeded = 1
+	_, err := clientset.BatchV1().Jobs(c.Namespace).Update(&job)
+	assert.NoError(t, err)
+	c.rotateEncryptionKeys()
+	existing, err := clientset.BatchV1().Jobs(c.Namespace).Get(job.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), existing.Status.Succeeded)
+
+	c.SetEncryptionKeyRotation("2")
+	c.rotateEncryptionKeys()
+	existing, err = clientset.BatchV1().Jobs(c.Namespace).Get(job.Name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "2", existing.Annotations[encryptionKeyRotationAnnotationKey])
+}
+
+func TestEnsureEncryptionKey(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := newEncryptionTestCluster(clientset)
+	c.SetKeyManagementService(cephv1.KeyManagementServiceSpec{})
+
+	// without kms the key is stored in a secret owned by the cluster
+	assert.NoError(t, c.ensureEncryptionKey("pvc1"))
+	secret, err := clientset.CoreV1().Secrets(c.Namespace).Get(kms.EncryptionKeyName("pvc1"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(secret.OwnerReferences))
+	key := secret.Data["dmcrypt-key"]
+	assert.NotEmpty(t, key)
+
+	// the key is kept
+	assert.NoError(t, c.ensureEncryptionKey("pvc1"))
+	secret, err = clientset.CoreV1().Secrets(c.Namespace).Get(kms.EncryptionKeyName("pvc1"), metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, key, secret.Data["dmcrypt-key"])
+}
@@ -18,21 +18,38 @@ package osd
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 )
 
 const (
-	upStatus  = 1
-	inStatus  = 1
-	graceTime = 60 * time.Minute
+	upStatus        = 1
+	inStatus        = 1
+	graceTime       = 60 * time.Minute
+	healthErrStatus = "HEALTH_ERR"
+
+	// osdOutReason is the event reason emitted when an osd is first seen marked out
+	osdOutReason = "OSDMarkedOut"
+	// osdRemovedReason is the event reason emitted when the deployment of an out osd is removed
+	osdRemovedReason = "OSDRemoved"
+	// osdPurgedReason is the event reason emitted when a removed osd is purged from the cluster
+	osdPurgedReason = "OSDPurged"
+	// osdHealthStateKey is the key of the state of the osd health monitor in the health state configmap
+	osdHealthStateKey = "osd"
 )
 
 var (
@@ -45,6 +62,37 @@ type OSDHealthMonitor struct {
 	namespace                      string
 	removeOSDsIfOUTAndSafeToRemove bool
 	interval                       time.Duration
+	// gracePeriod is the duration an out OSD is kept before its removal, once it is safe to destroy
+	gracePeriod   time.Duration
+	checkCallback func()
+	// reprovisionCallback is called once an osd is purged, so that its replacement disk is provisioned
+	reprovisionCallback func()
+	// osdsToRemove are the osds to decommission, as requested by the annotation of the CephCluster
+	osdsToRemove map[int]struct{}
+	removalMutex sync.Mutex
+	// wipeCallback is called once a decommissioned osd is purged, to wipe its disk
+	wipeCallback func(osdID int, nodeName string)
+	// recorder records the events on eventObject, the CephCluster of the osds
+	recorder    record.EventRecorder
+	eventObject runtime.Object
+	// outOSDs are the osds already reported as marked out, with the time they were first found out
+	outOSDs map[int]time.Time
+	// ownerRef is the owner of the health state configmap keeping the out osds across the restarts of the operator
+	ownerRef *metav1.OwnerReference
+	// savedState is the state last saved to the health state configmap
+	savedState osdHealthState
+	// nodeMaintenance enables holding noout on the osds of the cordoned nodes
+	nodeMaintenance bool
+	// useAllNodes enables handling the osds of the nodes deleted from the cluster, which are removed once their node
+	// has been deleted for removedNodeGracePeriod if set
+	useAllNodes            bool
+	removedNodeGracePeriod time.Duration
+	// removedNodes are the deleted nodes still having osds, with the time they were first found deleted
+	removedNodes map[string]time.Time
+	// topologyCallback is called with the osds of the deleted nodes after every check
+	topologyCallback func(changes []cephv1.TopologyChange)
+	// logger logs the lines of the osd health check with the namespace and the name of the cluster
+	logger log.Logger
 }
 
 // NewOSDHealthMonitor instantiates OSD monitoring
@@ -54,39 +102,102 @@ func NewOSDHealthMonitor(context *clusterd.Context, namespace string, removeOSDs
 		namespace:                      namespace,
 		removeOSDsIfOUTAndSafeToRemove: removeOSDsIfOUTAndSafeToRemove,
 		interval:                       defaultHealthCheckInterval,
+		gracePeriod:                    graceTime,
+		outOSDs:                        map[int]time.Time{},
+		removedNodes:                   map[string]time.Time{},
+		logger:                         log.New(logger, log.Fields{log.NamespaceField: namespace, log.CheckField: "osd"}),
 	}
 
+	h.loadConfig(healthCheck)
+
+	return h
+}
+
+// loadConfig sets the check interval and the removal grace period from the health check spec of the cluster
+func (m *OSDHealthMonitor) loadConfig(healthCheck cephv1.CephClusterHealthCheckSpec) {
 	// allow overriding the check interval
+	m.interval = defaultHealthCheckInterval
 	checkInterval := healthCheck.DaemonHealth.ObjectStorageDaemon.Interval
 	if checkInterval != "" {
 		if duration, err := time.ParseDuration(checkInterval); err == nil {
-			logger.Infof("ceph osd status in namespace %q check interval %q", h.namespace, checkInterval)
-			h.interval = duration
+			m.logger.Infof("ceph osd status in namespace %q check interval %q", m.namespace, checkInterval)
+			m.interval = duration
 		}
 	}
 
-	return h
+	// allow overriding the grace period before removing an out osd
+	m.gracePeriod = graceTime
+	timeout := healthCheck.DaemonHealth.ObjectStorageDaemon.Timeout
+	if timeout != "" {
+		if duration, err := time.ParseDuration(timeout); err == nil {
+			m.logger.Infof("ceph osd removal grace period in namespace %q is %q", m.namespace, timeout)
+			m.gracePeriod = duration
+		}
+	}
 }
 
-// Start runs monitoring logic for osds status at set intervals
-func (m *OSDHealthMonitor) Start(stopCh chan struct{}) {
+// Start runs monitoring logic for osds status at set intervals, or immediately when triggered
+// The settings of the monitor are reloaded every time the config channel receives a health check spec.
+func (m *OSDHealthMonitor) Start(stopCh chan struct{}, triggerCh <-chan struct{}, configCh <-chan cephv1.CephClusterHealthCheckSpec) {
+	m.loadState()
 
 	for {
 		select {
 		case <-time.After(m.interval):
-			logger.Debug("checking osd processes status.")
-			err := m.checkOSDHealth()
-			if err != nil {
-				logger.Debugf("failed OSD status check. %v", err)
-			}
+			m.checkOSDs()
+
+		case <-triggerCh:
+			m.logger.Debug("osd health check triggered")
+			m.checkOSDs()
+
+		case healthCheck := <-configCh:
+			m.logger.Infof("reloading the osd health check settings in namespace %s", m.namespace)
+			m.context = controller.HealthCheckContext(m.context, healthCheck)
+			m.loadConfig(healthCheck)
 
 		case <-stopCh:
-			logger.Infof("stopping monitoring of OSDs in namespace %s", m.namespace)
+			m.logger.Infof("stopping monitoring of OSDs in namespace %s", m.namespace)
 			return
 		}
 	}
 }
 
+// SetEventRecorder sets the recorder of the events on the object when osds are marked out or removed
+func (m *OSDHealthMonitor) SetEventRecorder(recorder record.EventRecorder, object runtime.Object) {
+	m.recorder = recorder
+	m.eventObject = object
+}
+
+// SetOwnerRef sets the owner of the health state configmap, the CephCluster of the osds, which also names the cluster
+// in the log lines of the check
+func (m *OSDHealthMonitor) SetOwnerRef(ownerRef metav1.OwnerReference) {
+	m.ownerRef = &ownerRef
+	m.logger = m.logger.With(log.ClusterField, ownerRef.Name)
+}
+
+// SetCheckCallback sets a function called every time a check completes
+func (m *OSDHealthMonitor) SetCheckCallback(callback func()) {
+	m.checkCallback = callback
+}
+
+// SetReprovisionCallback sets a function called after an out osd is purged, to provision the osds again
+func (m *OSDHealthMonitor) SetReprovisionCallback(callback func()) {
+	m.reprovisionCallback = callback
+}
+
+func (m *OSDHealthMonitor) checkOSDs() {
+	m.logger.Debug("checking osd processes status.")
+	defer controller.ObserveHealthCheckDuration(m.namespace, "osd", time.Now())
+	err := m.checkOSDHealth()
+	if err != nil {
+		m.logger.Debugf("failed OSD status check. %v", err)
+	}
+	m.saveState()
+	if m.checkCallback != nil {
+		m.checkCallback()
+	}
+}
+
 // Update updates the removeOSDsIfOUTAndSafeToRemove
 func (m *OSDHealthMonitor) Update(removeOSDsIfOUTAndSafeToRemove bool) {
 	m.removeOSDsIfOUTAndSafeToRemove = removeOSDsIfOUTAndSafeToRemove
@@ -99,6 +210,22 @@ func (m *OSDHealthMonitor) checkOSDHealth() error {
 		return err
 	}
 
+	// the osds on nodes under maintenance are held in noout and never removed
+	underMaintenance, err := m.reconcileNodeMaintenance()
+	if err != nil {
+		m.logger.Warningf("failed to check the maintenance of the osd nodes. %v", err)
+	}
+
+	// the osds of the nodes deleted from the cluster are removed after the grace period
+	onRemovedNodes, err := m.reconcileRemovedNodes()
+	if err != nil {
+		m.logger.Warningf("failed to check the deleted osd nodes. %v", err)
+	}
+
+	// the overall health is only queried once an osd is a candidate for removal
+	healthChecked, inError := false, false
+	outOSDs := map[int]time.Time{}
+
 	for _, osdStatus := range osdDump.OSDs {
 		id64, err := osdStatus.OSD.Int64()
 		if err != nil {
@@ -106,38 +233,149 @@ func (m *OSDHealthMonitor) checkOSDHealth() error {
 		}
 		id := int(id64)
 
-		logger.Debugf("validating status of osd.%d", id)
+		m.logger.Debugf("validating status of osd.%d", id)
 
 		status, in, err := osdDump.StatusByID(int64(id))
 		if err != nil {
 			return err
 		}
 
+		if m.isRemovalRequested(id) {
+			if err := m.removeOSD(id, status == upStatus, in == inStatus, true); err != nil {
+				m.logger.Errorf("failed to remove osd.%d. %v", id, err)
+			}
+			continue
+		}
+
+		// the disk of an osd whose node was deleted is not wiped, no job can run on the node
+		if _, ok := onRemovedNodes[id]; ok {
+			if err := m.removeOSD(id, status == upStatus, in == inStatus, false); err != nil {
+				m.logger.Errorf("failed to remove osd.%d of a deleted node. %v", id, err)
+			}
+			continue
+		}
+
 		if status == upStatus {
-			logger.Debugf("osd.%d is healthy.", id)
+			m.logger.Debugf("osd.%d is healthy.", id)
 			continue
 		}
 
-		logger.Debugf("osd.%d is marked 'DOWN'", id)
+		m.logger.Debugf("osd.%d is marked 'DOWN'", id)
 
 		// check if the down osd is stuck terminating
 		if err := m.restartOSDIfStuck(id); err != nil {
-			logger.Warningf("failed to restart OSD %d. %v", id, err)
+			m.logger.Warningf("failed to restart OSD %d. %v", id, err)
 		}
 
 		if in != inStatus {
-			logger.Debugf("osd.%d is marked 'OUT'", id)
+			m.logger.Debugf("osd.%d is marked 'OUT'", id)
+			if since, ok := m.outOSDs[id]; ok {
+				outOSDs[id] = since
+			} else {
+				outOSDs[id] = time.Now()
+				controller.IncOSDOutMetric(m.namespace)
+				m.recordEvent(v1.EventTypeWarning, osdOutReason, "osd.%d is down and marked out", id)
+			}
 			if m.removeOSDsIfOUTAndSafeToRemove {
+				if _, ok := underMaintenance[id]; ok {
+					m.logger.Infof("deferring the removal of osd.%d while its node is under maintenance", id)
+					continue
+				}
+				if !healthChecked {
+					inError = m.isClusterInError()
+					healthChecked = true
+				}
+				if inError {
+					m.logger.Infof("deferring the removal of osd.%d until the cluster is not in %s anymore", id, healthErrStatus)
+					continue
+				}
 				if err := m.removeOSDDeploymentIfSafeToDestroy(id); err != nil {
-					logger.Errorf("error handling marked out osd osd.%d. %v", id, err)
+					m.logger.Errorf("error handling marked out osd osd.%d. %v", id, err)
 				}
 			}
 		}
 	}
+	m.outOSDs = outOSDs
 
 	return nil
 }
 
+// osdHealthState is the state of the osd health monitor kept across the restarts of the operator
+type osdHealthState struct {
+	// OutSince are the times the osds were first found down and out, so that they are not reported again
+	OutSince map[int]time.Time `json:"outSince,omitempty"`
+	// RemovedNodesSince are the times the nodes of osds were first found deleted, the grace period of their osds
+	// starting then
+	RemovedNodesSince map[string]time.Time `json:"removedNodesSince,omitempty"`
+}
+
+// loadState restores the osds found out before the restart of the operator
+func (m *OSDHealthMonitor) loadState() {
+	state := osdHealthState{}
+	if err := controller.LoadHealthState(m.context.Clientset, m.namespace, osdHealthStateKey, &state); err != nil {
+		m.logger.Warningf("failed to load the osd health state in namespace %q. %v", m.namespace, err)
+		return
+	}
+	for id, since := range state.OutSince {
+		if _, ok := m.outOSDs[id]; !ok {
+			m.logger.Infof("osd.%d has been out since %s", id, since.String())
+			m.outOSDs[id] = since
+		}
+	}
+	for node, since := range state.RemovedNodesSince {
+		if _, ok := m.removedNodes[node]; !ok {
+			m.logger.Infof("node %q has been deleted since %s", node, since.String())
+			m.removedNodes[node] = since
+		}
+	}
+	m.savedState = state
+}
+
+// saveState saves the out osds when they changed
+func (m *OSDHealthMonitor) saveState() {
+	state := osdHealthState{}
+	if len(m.outOSDs) > 0 {
+		state.OutSince = make(map[int]time.Time, len(m.outOSDs))
+		for id, since := range m.outOSDs {
+			state.OutSince[id] = since
+		}
+	}
+	if len(m.removedNodes) > 0 {
+		state.RemovedNodesSince = make(map[string]time.Time, len(m.removedNodes))
+		for node, since := range m.removedNodes {
+			state.RemovedNodesSince[node] = since
+		}
+	}
+	if reflect.DeepEqual(state, m.savedState) {
+		return
+	}
+	if err := controller.SaveHealthState(m.context.Clientset, m.namespace, osdHealthStateKey, state, m.ownerRef); err != nil {
+		m.logger.Warningf("failed to save the osd health state in namespace %q. %v", m.namespace, err)
+		return
+	}
+	m.savedState = state
+}
+
+// recordEvent records an event on the CephCluster, if an event recorder is set
+func (m *OSDHealthMonitor) recordEvent(eventType, reason, messageFmt string, args ...interface{}) {
+	if m.recorder == nil || m.eventObject == nil {
+		return
+	}
+	m.recorder.Eventf(m.eventObject, eventType, reason, messageFmt, args...)
+}
+
+// isClusterInError returns whether the cluster is in HEALTH_ERR, in which case no capacity must be removed
+// The cluster is also considered in error if its health cannot be retrieved
+func (m *OSDHealthMonitor) isClusterInError() bool {
+	status, err := client.Status(m.context, m.namespace)
+	if err != nil {
+		m.logger.Warningf("failed to get the health of cluster %q. %v", m.namespace, err)
+		return true
+	}
+
+	return status.Health.Status == healthErrStatus
+}
+
 func (m *OSDHealthMonitor) removeOSDDeploymentIfSafeToDestroy(outOSDid int) error {
 	label := fmt.Sprintf("ceph-osd-id=%d", outOSDid)
 	dp, err := k8sutil.GetDeployments(m.context.Clientset, m.namespace, label)
@@ -155,13 +393,23 @@ func (m *OSDHealthMonitor) removeOSDDeploymentIfSafeToDestroy(outOSDid int) erro
 
 		if safeToDestroyOSD {
 			podCreationTimestamp := dp.Items[0].GetCreationTimestamp()
-			podDeletionTimeStamp := podCreationTimestamp.Add(graceTime)
+			podDeletionTimeStamp := podCreationTimestamp.Add(m.gracePeriod)
 			currentTime := time.Now().UTC()
 			if podDeletionTimeStamp.Before(currentTime) {
-				logger.Infof("osd.%d is 'safe-to-destroy'. removing the osd deployment.", outOSDid)
+				m.logger.Infof("osd.%d is 'safe-to-destroy'. removing the osd deployment.", outOSDid)
 				if err := k8sutil.DeleteDeployment(m.context.Clientset, dp.Items[0].Namespace, dp.Items[0].Name); err != nil {
 					return errors.Wrapf(err, "failed to delete osd deployment %s", dp.Items[0].Name)
 				}
+				m.recordEvent(v1.EventTypeNormal, osdRemovedReason, "removed the deployment of osd.%d, out and safe to destroy", outOSDid)
+
+				// purge the osd so that the prepare job adds the replacement disk back as a new osd
+				if err := client.PurgeOSD(m.context, m.namespace, outOSDid); err != nil {
+					return err
+				}
+				m.recordEvent(v1.EventTypeNormal, osdPurgedReason, "purged osd.%d, its disk can be replaced", outOSDid)
+				if m.reprovisionCallback != nil {
+					m.reprovisionCallback()
+				}
 			}
 		}
 	}
@@ -179,7 +427,7 @@ func (m *OSDHealthMonitor) restartOSDIfStuck(osdID int) error {
 	}
 	for _, pod := range pods.Items {
 		if err := k8sutil.ForceDeletePodIfStuck(m.context, pod); err != nil {
-			logger.Warningf("skipping restart of OSD %d. %v", osdID, err)
+			m.logger.Warningf("skipping restart of OSD %d. %v", osdID, err)
 		}
 	}
 	return nil
@@ -26,11 +26,13 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testexec "github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/log"
 	"github.com/stretchr/testify/assert"
 	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 )
 
 func TestOSDHealthCheck(t *testing.T) {
@@ -38,6 +40,7 @@ func TestOSDHealthCheck(t *testing.T) {
 	cluster := "fake"
 
 	var execCount = 0
+	purgeCount := 0
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
 			return "{\"key\":\"mysecurekey\", \"osdid\":3.0}", nil
@@ -46,12 +49,16 @@ func TestOSDHealthCheck(t *testing.T) {
 	executor.MockExecuteCommandWithOutputFile = func(command string, outFileArg string, args ...string) (string, error) {
 		logger.Infof("ExecuteCommandWithOutputFile: %s %v", command, args)
 		execCount++
-		if args[1] == "dump" {
+		if args[0] == "status" {
+			return `{"health":{"status":"HEALTH_OK"}}`, nil
+		} else if args[1] == "dump" {
 			// Mock executor for OSD Dump command, returning an osd in Down state
 			return `{"OSDs": [{"OSD": 0, "Up": 0, "In": 0}]}`, nil
 		} else if args[1] == "safe-to-destroy" {
 			// Mock executor for OSD Dump command, returning an osd in Down state
 			return `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
+		} else if args[1] == "purge" {
+			purgeCount++
 		}
 		return "", nil
 	}
@@ -90,26 +97,139 @@ func TestOSDHealthCheck(t *testing.T) {
 
 	// Initializing an OSD monitoring
 	osdMon := NewOSDHealthMonitor(context, cluster, true, cephv1.CephClusterHealthCheckSpec{})
+	recorder := record.NewFakeRecorder(10)
+	osdMon.SetEventRecorder(recorder, &v1.ObjectReference{Name: cluster, Namespace: cluster})
+	reprovisionCount := 0
+	osdMon.SetReprovisionCallback(func() { reprovisionCount++ })
 
 	// Run OSD monitoring routine
 	err := osdMon.checkOSDHealth()
 	assert.Nil(t, err)
-	// After creating an OSD, the dump has 1 mocked cmd and safe to destroy has 1 mocked cmd
-	assert.Equal(t, 2, execCount)
+	// After creating an OSD, the dump, the status, the safe to destroy and the purge have 1 mocked cmd each
+	assert.Equal(t, 4, execCount)
+	assert.Equal(t, 1, purgeCount)
+	assert.Equal(t, 1, reprovisionCount)
+
+	// the osd marked out, its removal and its purge are reported
+	assert.Equal(t, 3, len(recorder.Events))
+	assert.Equal(t, "Warning OSDMarkedOut osd.0 is down and marked out", <-recorder.Events)
+	assert.Equal(t, "Normal OSDRemoved removed the deployment of osd.0, out and safe to destroy", <-recorder.Events)
+	assert.Equal(t, "Normal OSDPurged purged osd.0, its disk can be replaced", <-recorder.Events)
+
+	// an osd still out is not reported again
+	err = osdMon.checkOSDHealth()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(recorder.Events))
 
 	// Check if the osd deployment was deleted
 	dp, _ = context.Clientset.AppsV1().Deployments(cluster).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%v=%d", OsdIdLabelKey, 0)})
 	assert.Equal(t, 0, len(dp.Items))
 }
 
+func TestOSDHealthCheckClusterInError(t *testing.T) {
+	clientset := testexec.New(t, 2)
+	cluster := "fake"
+
+	safeToDestroyCalled := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("ExecuteCommandWithOutputFile: %s %v", command, args)
+			if args[0] == "status" {
+				return `{"health":{"status":"HEALTH_ERR"}}`, nil
+			} else if args[1] == "dump" {
+				// Mock executor for OSD Dump command, returning an osd in Down state
+				return `{"OSDs": [{"OSD": 0, "Up": 0, "In": 0}]}`, nil
+			} else if args[1] == "safe-to-destroy" {
+				safeToDestroyCalled = true
+				return `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Executor:  executor,
+		Clientset: clientset,
+	}
+
+	deployment := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osd0",
+			Namespace: cluster,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     AppName,
+				k8sutil.ClusterAttr: cluster,
+				OsdIdLabelKey:       "0",
+			},
+		},
+	}
+	_, err := context.Clientset.AppsV1().Deployments(cluster).Create(deployment)
+	assert.NoError(t, err)
+
+	// The cluster is in HEALTH_ERR, the out osd is not removed
+	osdMon := NewOSDHealthMonitor(context, cluster, true, cephv1.CephClusterHealthCheckSpec{})
+	err = osdMon.checkOSDHealth()
+	assert.Nil(t, err)
+	assert.False(t, safeToDestroyCalled)
+
+	dp, _ := context.Clientset.AppsV1().Deployments(cluster).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%v=%d", OsdIdLabelKey, 0)})
+	assert.Equal(t, 1, len(dp.Items))
+}
+
 func TestMonitorStart(t *testing.T) {
 	stopCh := make(chan struct{})
-	osdMon := NewOSDHealthMonitor(&clusterd.Context{}, "cluster", true, cephv1.CephClusterHealthCheckSpec{})
+	osdMon := NewOSDHealthMonitor(&clusterd.Context{Clientset: testexec.New(t, 1)}, "cluster", true, cephv1.CephClusterHealthCheckSpec{})
 	logger.Infof("starting osd monitor")
-	go osdMon.Start(stopCh)
+	go osdMon.Start(stopCh, nil, nil)
 	close(stopCh)
 }
 
+func TestMonitorReloadConfig(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	healthCheck := cephv1.CephClusterHealthCheckSpec{}
+	healthCheck.DaemonHealth.ObjectStorageDaemon.Interval = "1h"
+	osdMon := NewOSDHealthMonitor(&clusterd.Context{Clientset: testexec.New(t, 1), Executor: &exectest.MockExecutor{}}, "cluster", false, healthCheck)
+	checked := make(chan struct{}, 1)
+	osdMon.SetCheckCallback(func() {
+		select {
+		case checked <- struct{}{}:
+		default:
+		}
+	})
+	configCh := make(chan cephv1.CephClusterHealthCheckSpec, 1)
+	go osdMon.Start(stopCh, nil, configCh)
+
+	// the monitor checks with the new interval without being restarted
+	healthCheck.DaemonHealth.ObjectStorageDaemon.Interval = "10ms"
+	configCh <- healthCheck
+	select {
+	case <-checked:
+	case <-time.After(5 * time.Second):
+		assert.Fail(t, "osd monitor did not reload its check interval")
+	}
+}
+
+func TestOSDHealthState(t *testing.T) {
+	context := &clusterd.Context{Clientset: testexec.New(t, 1)}
+	osdMon := NewOSDHealthMonitor(context, "ns", false, cephv1.CephClusterHealthCheckSpec{})
+	osdMon.SetOwnerRef(metav1.OwnerReference{Name: "rook-ceph"})
+	since := time.Now().Add(-time.Hour).Round(time.Second)
+	osdMon.outOSDs[3] = since
+	osdMon.saveState()
+
+	// the out osd is not reported again after the restart of the operator
+	restarted := NewOSDHealthMonitor(context, "ns", false, cephv1.CephClusterHealthCheckSpec{})
+	restarted.loadState()
+	assert.True(t, since.Equal(restarted.outOSDs[3]))
+
+	// the osd back in is removed from the state
+	delete(osdMon.outOSDs, 3)
+	osdMon.saveState()
+	restarted = NewOSDHealthMonitor(context, "ns", false, cephv1.CephClusterHealthCheckSpec{})
+	restarted.loadState()
+	assert.Equal(t, 0, len(restarted.outOSDs))
+}
+
 func TestOSDRestartIfStuck(t *testing.T) {
 	clientset := testexec.New(t, 1)
 	namespace := "test"
@@ -185,8 +305,9 @@ func TestNewOSDHealthMonitor(t *testing.T) {
 		args args
 		want *OSDHealthMonitor
 	}{
-		{"default-interval", args{c, ns, false, cephv1.CephClusterHealthCheckSpec{}}, &OSDHealthMonitor{c, ns, false, defaultHealthCheckInterval}},
-		{"10s-interval", args{c, ns, false, cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{ObjectStorageDaemon: cephv1.HealthCheckSpec{Interval: "10s"}}}}, &OSDHealthMonitor{c, ns, false, time10s}},
+		{"default-interval", args{c, ns, false, cephv1.CephClusterHealthCheckSpec{}}, &OSDHealthMonitor{context: c, namespace: ns, removeOSDsIfOUTAndSafeToRemove: false, interval: defaultHealthCheckInterval, gracePeriod: graceTime, outOSDs: map[int]time.Time{}, removedNodes: map[string]time.Time{}, logger: log.New(logger, log.Fields{log.NamespaceField: ns, log.CheckField: "osd"})}},
+		{"10s-interval", args{c, ns, false, cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{ObjectStorageDaemon: cephv1.HealthCheckSpec{Interval: "10s"}}}}, &OSDHealthMonitor{context: c, namespace: ns, removeOSDsIfOUTAndSafeToRemove: false, interval: time10s, gracePeriod: graceTime, outOSDs: map[int]time.Time{}, removedNodes: map[string]time.Time{}, logger: log.New(logger, log.Fields{log.NamespaceField: ns, log.CheckField: "osd"})}},
+		{"10s-timeout", args{c, ns, false, cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{ObjectStorageDaemon: cephv1.HealthCheckSpec{Timeout: "10s"}}}}, &OSDHealthMonitor{context: c, namespace: ns, removeOSDsIfOUTAndSafeToRemove: false, interval: defaultHealthCheckInterval, gracePeriod: time10s, outOSDs: map[int]time.Time{}, removedNodes: map[string]time.Time{}, logger: log.New(logger, log.Fields{log.NamespaceField: ns, log.CheckField: "osd"})}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
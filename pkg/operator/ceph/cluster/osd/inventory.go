@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/operator/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	apps "k8s.io/api/apps/v1"
+)
+
+// hasCandidateDevices checks the device inventory published by the discovery daemon to know whether the
+// storage selection of a node without osds can pick a device. When the inventory of the node is not
+// available, or the node lists its devices, the prepare job is always run.
+func (c *Cluster) hasCandidateDevices(node *rookv1.Node, osdNodes map[string][]*apps.Deployment) bool {
+	if len(osdNodes[node.Name]) > 0 || len(node.Devices) > 0 {
+		return true
+	}
+	if !node.Selection.GetUseAllDevices() && node.Selection.DeviceFilter == "" && node.Selection.DevicePathFilter == "" {
+		return true
+	}
+
+	devices, found, err := discover.GetNodeDevices(c.context, os.Getenv(k8sutil.PodNamespaceEnvVar), node.Name)
+	if err != nil {
+		logger.Warningf("failed to get the discovered devices of node %q. %v", node.Name, err)
+		return true
+	}
+	if !found {
+		return true
+	}
+
+	for _, device := range devices {
+		if !isCandidateDevice(device) {
+			continue
+		}
+		matched, err := matchesDeviceSelection(device, node.Selection)
+		if err != nil {
+			logger.Warningf("failed to match the discovered device %q of node %q. %v", device.Name, node.Name, err)
+			return true
+		}
+		if matched {
+			return true
+		}
+	}
+	return false
+}
+
+// isCandidateDevice returns whether a discovered device may be picked by the prepare job, either because it
+// is empty or because it may already hold an osd
+func isCandidateDevice(device sys.LocalDisk) bool {
+	return device.Empty || device.Type == sys.LVMType || device.Filesystem == "LVM2_member" || strings.HasPrefix(device.Filesystem, "ceph")
+}
+
+// matchesDeviceSelection matches a discovered device the same way the prepare job matches the devices of the node
+func matchesDeviceSelection(device sys.LocalDisk, selection rookv1.Selection) (bool, error) {
+	if selection.DeviceFilter != "" {
+		return regexp.MatchString(selection.DeviceFilter, device.Name)
+	}
+	if selection.DevicePathFilter != "" {
+		for _, pathname := range append(strings.Fields(device.DevLinks), filepath.Join("/dev", device.Name)) {
+			matched, err := regexp.MatchString(selection.DevicePathFilter, pathname)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	}
+	return selection.GetUseAllDevices(), nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"os"
+	"testing"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHasCandidateDevices(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-system")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	clientset := fake.NewSimpleClientset()
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}}
+	useAllDevices := true
+	node := &rookv1.Node{Name: "node1", Selection: rookv1.Selection{UseAllDevices: &useAllDevices}}
+
+	// the prepare job runs without the inventory of the node
+	assert.True(t, c.hasCandidateDevices(node, nil))
+
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "local-device-node1",
+			Namespace: "rook-system",
+			Labels:    map[string]string{k8sutil.AppAttr: discoverDaemon.AppName, discoverDaemon.NodeAttr: "node1"},
+		},
+		Data: map[string]string{discoverDaemon.LocalDiskCMData: `[{"name":"sda","type":"disk","filesystem":"ext4","empty":false},{"name":"sdb","devLinks":"/dev/disk/by-id/ata-disk2","type":"disk","empty":true}]`},
+	}
+	_, err := clientset.CoreV1().ConfigMaps("rook-system").Create(cm)
+	assert.NoError(t, err)
+
+	// all the empty devices are candidates
+	assert.True(t, c.hasCandidateDevices(node, nil))
+
+	// the device filter matches the name of the device
+	node.Selection = rookv1.Selection{DeviceFilter: "^sd[b-d]"}
+	assert.True(t, c.hasCandidateDevices(node, nil))
+	node.Selection = rookv1.Selection{DeviceFilter: "^sda"}
+	assert.False(t, c.hasCandidateDevices(node, nil))
+
+	// the device path filter matches the links of the device
+	node.Selection = rookv1.Selection{DevicePathFilter: "^/dev/disk/by-id/ata-.*"}
+	assert.True(t, c.hasCandidateDevices(node, nil))
+	node.Selection = rookv1.Selection{DevicePathFilter: "^/dev/disk/by-path/pci-.*"}
+	assert.False(t, c.hasCandidateDevices(node, nil))
+
+	// the nodes running osds or listing their devices always get a prepare job
+	osdNodes := map[string][]*apps.Deployment{"node1": {{}}}
+	assert.True(t, c.hasCandidateDevices(node, osdNodes))
+	node.Devices = []rookv1.Device{{Name: "sdc"}}
+	assert.True(t, c.hasCandidateDevices(node, nil))
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maintenanceNoOutAnnotation is set on the deployment of an osd while the operator holds noout on the osd because
+	// its node is under maintenance, so that the flag is cleared even if the operator restarted in the meantime
+	maintenanceNoOutAnnotation = "ceph.rook.io/maintenance-noout"
+
+	nooutFlag = "noout"
+
+	// osdMaintenanceReason is the event reason emitted when noout is set on an osd whose node is under maintenance
+	osdMaintenanceReason = "OSDNodeMaintenance"
+	// osdMaintenanceDoneReason is the event reason emitted when noout is cleared once the node of an osd is back
+	osdMaintenanceDoneReason = "OSDNodeMaintenanceDone"
+)
+
+// SetNodeMaintenance enables setting noout on the osds of the cordoned nodes, whose removal is then deferred
+func (m *OSDHealthMonitor) SetNodeMaintenance(enabled bool) {
+	m.nodeMaintenance = enabled
+}
+
+// reconcileNodeMaintenance sets noout on the osds whose node is cordoned and clears it on the osds whose node is
+// schedulable again. It returns the osds under maintenance.
+func (m *OSDHealthMonitor) reconcileNodeMaintenance() (map[int]struct{}, error) {
+	underMaintenance := map[int]struct{}{}
+	deployments, err := k8sutil.GetDeployments(m.context.Clientset, m.namespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName))
+	if err != nil {
+		return underMaintenance, errors.Wrap(err, "failed to list the osd deployments")
+	}
+
+	cordonedNodes := map[string]struct{}{}
+	if m.nodeMaintenance {
+		cordonedNodes, err = m.cordonedNodes()
+		if err != nil {
+			return underMaintenance, err
+		}
+	}
+
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			continue
+		}
+		_, nooutSet := d.Annotations[maintenanceNoOutAnnotation]
+		nodeName := ""
+		if len(cordonedNodes) > 0 || nooutSet {
+			nodeName = m.osdNodeName(d)
+		}
+		_, cordoned := cordonedNodes[nodeName]
+
+		if cordoned {
+			underMaintenance[osdID] = struct{}{}
+			if nooutSet {
+				continue
+			}
+			logger.Infof("node %q of osd.%d is under maintenance, setting noout on the osd", nodeName, osdID)
+			if err := client.SetFlagOnCrushUnit(m.context, m.namespace, fmt.Sprintf("osd.%d", osdID), nooutFlag); err != nil {
+				logger.Errorf("failed to set noout on osd.%d. %v", osdID, err)
+				continue
+			}
+			if err := m.setMaintenanceAnnotation(d, true); err != nil {
+				logger.Errorf("failed to record the maintenance of osd.%d. %v", osdID, err)
+			}
+			m.recordEvent(v1.EventTypeNormal, osdMaintenanceReason, "set noout on osd.%d, node %q is under maintenance", osdID, nodeName)
+			continue
+		}
+
+		if nooutSet {
+			logger.Infof("node %q of osd.%d is not under maintenance anymore, clearing noout on the osd", nodeName, osdID)
+			if err := client.UnsetFlagOnCrushUnit(m.context, m.namespace, fmt.Sprintf("osd.%d", osdID), nooutFlag); err != nil {
+				logger.Errorf("failed to clear noout on osd.%d. %v", osdID, err)
+				continue
+			}
+			if err := m.setMaintenanceAnnotation(d, false); err != nil {
+				logger.Errorf("failed to record the end of the maintenance of osd.%d. %v", osdID, err)
+			}
+			m.recordEvent(v1.EventTypeNormal, osdMaintenanceDoneReason, "cleared noout on osd.%d, node %q is back", osdID, nodeName)
+		}
+	}
+
+	return underMaintenance, nil
+}
+
+// cordonedNodes returns the names and the hostnames of the unschedulable nodes
+func (m *OSDHealthMonitor) cordonedNodes() (map[string]struct{}, error) {
+	nodes, err := m.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the nodes")
+	}
+	cordoned := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		if !node.Spec.Unschedulable {
+			continue
+		}
+		cordoned[node.Name] = struct{}{}
+		if hostname, ok := node.Labels[v1.LabelHostname]; ok {
+			cordoned[hostname] = struct{}{}
+		}
+	}
+	return cordoned, nil
+}
+
+// osdNodeName returns the node an osd runs on. The osds on nodes are pinned with the hostname selector of their
+// deployment, while the osds on pvcs are found from the node of their pod.
+func (m *OSDHealthMonitor) osdNodeName(d *apps.Deployment) string {
+	if hostname, ok := d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]; ok {
+		return hostname
+	}
+	pods, err := m.context.Clientset.CoreV1().Pods(m.namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OsdIdLabelKey, d.Labels[OsdIdLabelKey])})
+	if err != nil {
+		logger.Warningf("failed to get the pod of osd %s. %v", d.Labels[OsdIdLabelKey], err)
+		return ""
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			return pod.Spec.NodeName
+		}
+	}
+	return ""
+}
+
+func (m *OSDHealthMonitor) setMaintenanceAnnotation(d *apps.Deployment, set bool) error {
+	if set {
+		if d.Annotations == nil {
+			d.Annotations = map[string]string{}
+		}
+		d.Annotations[maintenanceNoOutAnnotation] = "true"
+	} else {
+		delete(d.Annotations, maintenanceNoOutAnnotation)
+	}
+	_, err := m.context.Clientset.AppsV1().Deployments(m.namespace).Update(d)
+	return err
+}
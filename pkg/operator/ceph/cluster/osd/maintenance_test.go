@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeMaintenance(t *testing.T) {
+	clientset := testexec.New(t, 2)
+	namespace := "ns"
+	purgeCount := 0
+	flags := map[string]bool{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			switch {
+			case args[0] == "status":
+				return `{"health":{"status":"HEALTH_OK"}}`, nil
+			case args[1] == "dump":
+				return `{"OSDs": [{"OSD": 0, "Up": 0, "In": 0}, {"OSD": 1, "Up": 1, "In": 1}]}`, nil
+			case args[1] == "safe-to-destroy":
+				return `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`, nil
+			case args[1] == "purge":
+				purgeCount++
+			case args[1] == "set-group":
+				flags[args[3]] = true
+			case args[1] == "unset-group":
+				delete(flags, args[3])
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Clientset: clientset}
+
+	for i, node := range []string{"node0", "node1"} {
+		d := &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "osd" + node,
+				Namespace: namespace,
+				Labels:    map[string]string{k8sutil.AppAttr: AppName, OsdIdLabelKey: fmt.Sprintf("%d", i)},
+			},
+		}
+		d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: node}
+		_, err := clientset.AppsV1().Deployments(namespace).Create(d)
+		assert.NoError(t, err)
+	}
+	cordon := func(name string, unschedulable bool) {
+		node, err := clientset.CoreV1().Nodes().Get(name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		node.Spec.Unschedulable = unschedulable
+		_, err = clientset.CoreV1().Nodes().Update(node)
+		assert.NoError(t, err)
+	}
+
+	osdMon := NewOSDHealthMonitor(context, namespace, true, cephv1.CephClusterHealthCheckSpec{})
+	osdMon.SetNodeMaintenance(true)
+	cordon("node0", true)
+
+	// The out osd of the cordoned node is held in noout and not removed
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Equal(t, map[string]bool{"osd.0": true}, flags)
+	assert.Equal(t, 0, purgeCount)
+	d, err := clientset.AppsV1().Deployments(namespace).Get("osdnode0", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "true", d.Annotations[maintenanceNoOutAnnotation])
+
+	// noout is cleared when the node is back, even if the setting was disabled since
+	cordon("node0", false)
+	osdMon.SetNodeMaintenance(false)
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Empty(t, flags)
+	// the out osd is removed once its node is not under maintenance anymore
+	assert.Equal(t, 1, purgeCount)
+}
@@ -70,6 +70,7 @@ const (
 	portableKey                         = "portable"
 	cephOsdPodMinimumMemory      uint64 = 2048 // minimum amount of memory in MB to run the pod
 	bluestorePVCMetadata                = "metadata"
+	bluestorePVCWal                     = "wal"
 	bluestorePVCData                    = "data"
 )
 
@@ -79,7 +80,9 @@ type Cluster struct {
 	context                                    *clusterd.Context
 	Namespace                                  string
 	placement                                  rookv1.Placement
+	preparePlacement                           rookv1.Placement
 	annotations                                rookv1.Annotations
+	labels                                     rookv1.Labels
 	Keyring                                    string
 	rookVersion                                string
 	cephVersion                                cephv1.CephVersionSpec
@@ -96,6 +99,12 @@ type Cluster struct {
 	skipUpgradeChecks                          bool
 	continueUpgradeAfterChecksEvenIfNotHealthy bool
 	healthCheck                                cephv1.CephClusterHealthCheckSpec
+	kms                                        cephv1.KeyManagementServiceSpec
+	keyRotation                                string
+	logCollector                               cephv1.LogCollectorSpec
+	updateStrategy                             *cephv1.UpdateStrategySpec
+	env                                        cephv1.DaemonEnvSpec
+	volumeMounts                               cephv1.DaemonVolumeMountsSpec
 }
 
 // New creates an instance of the OSD manager
@@ -124,6 +133,7 @@ func New(
 		context:           context,
 		Namespace:         namespace,
 		placement:         placement,
+		preparePlacement:  placement,
 		annotations:       annotations,
 		rookVersion:       rookVersion,
 		cephVersion:       cephVersion,
@@ -142,6 +152,34 @@ func New(
 	}
 }
 
+// SetLabels sets the labels added to the osd deployments and pods and to the osd prepare jobs
+func (c *Cluster) SetLabels(labels rookv1.Labels) {
+	c.labels = labels
+}
+
+// SetPreparePlacement sets the placement of the osd prepare pods on the nodes, the placement of the osds by default
+func (c *Cluster) SetPreparePlacement(placement rookv1.Placement) {
+	c.preparePlacement = placement
+}
+
+// SetLogCollector sets the rotation of the log files of the osds, the sidecar being added when enabled
+func (c *Cluster) SetLogCollector(logCollector cephv1.LogCollectorSpec) {
+	c.logCollector = logCollector
+}
+
+// SetUpdateStrategy sets the rollout of the changes of the osd deployments, the osds being updated one after the other
+// when not set
+func (c *Cluster) SetUpdateStrategy(strategy *cephv1.UpdateStrategySpec) {
+	c.updateStrategy = strategy
+}
+
+// SetDaemonOverrides sets the environment variables and the extra volumes of the cluster spec, the osd ones being added
+// to the containers of the osd pods and the prepareosd ones to the containers of the osd prepare pods
+func (c *Cluster) SetDaemonOverrides(env cephv1.DaemonEnvSpec, volumeMounts cephv1.DaemonVolumeMountsSpec) {
+	c.env = env
+	c.volumeMounts = volumeMounts
+}
+
 // OSDInfo represent all the properties of a given OSD
 type OSDInfo struct {
 	ID             int    `json:"id"`
@@ -172,11 +210,13 @@ type osdProperties struct {
 	devices             []rookv1.Device
 	pvc                 v1.PersistentVolumeClaimVolumeSource
 	metadataPVC         v1.PersistentVolumeClaimVolumeSource
+	walPVC              v1.PersistentVolumeClaimVolumeSource
 	pvcSize             string
 	selection           rookv1.Selection
 	resources           v1.ResourceRequirements
 	storeConfig         osdconfig.StoreConfig
 	placement           rookv1.Placement
+	preparePlacement    *rookv1.Placement
 	metadataDevice      string
 	location            string
 	portable            bool
@@ -188,6 +228,15 @@ type osdProperties struct {
 	driveGroups cephv1.DriveGroupsSpec
 }
 
+// nodeDeviceClass returns the CRUSH device class of the OSDs of a resolved node, the deviceClass of the selection
+// taking precedence over the deviceClass of the config
+func nodeDeviceClass(n *rookv1.Node) string {
+	if n.Selection.DeviceClass != "" {
+		return n.Selection.DeviceClass
+	}
+	return n.Config[osdconfig.DeviceClassKey]
+}
+
 func (osdProps osdProperties) onPVC() bool {
 	return osdProps.pvc.ClaimName != ""
 }
@@ -196,6 +245,10 @@ func (osdProps osdProperties) onPVCWithMetadata() bool {
 	return osdProps.metadataPVC.ClaimName != ""
 }
 
+func (osdProps osdProperties) onPVCWithWal() bool {
+	return osdProps.walPVC.ClaimName != ""
+}
+
 // Start the osd management
 func (c *Cluster) Start() error {
 	config := c.newProvisionConfig()
@@ -218,6 +271,9 @@ func (c *Cluster) Start() error {
 	logger.Infof("start provisioning the osds on nodes, if needed")
 	c.startProvisioningOverNodes(config)
 
+	// the deployments of the osds deferred to the waves of the update strategy are updated once all the osds started
+	c.updateOSDsByWaves(config)
+
 	if len(config.errorMessages) > 0 {
 		return errors.Errorf("%d failures encountered while running osds in namespace %s: %+v",
 			len(config.errorMessages), c.Namespace, strings.Join(config.errorMessages, "\n"))
@@ -228,6 +284,9 @@ func (c *Cluster) Start() error {
 	// This should only run before Octopus
 	c.applyUpgradeOSDFunctionality()
 
+	// The encryption keys are rotated once the osds are running, a failure being retried at the next rotation
+	c.rotateEncryptionKeys()
+
 	logger.Infof("completed running osds in namespace %s", c.Namespace)
 	return nil
 }
@@ -271,23 +330,27 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			logger.Infof("OSD will have its main bluestore block on %q", dataSource.ClaimName)
 		}
 
+		walSource, walOK := volume.PVCSources[bluestorePVCWal]
+		if walOK {
+			logger.Infof("OSD will have its wal device on %q", walSource.ClaimName)
+		}
+
 		osdProps := osdProperties{
 			crushHostname:    dataSource.ClaimName,
 			pvc:              dataSource,
 			metadataPVC:      metadataSource,
+			walPVC:           walSource,
 			resources:        volume.Resources,
 			placement:        volume.Placement,
+			preparePlacement: volume.PreparePlacement,
 			portable:         volume.Portable,
 			crushDeviceClass: volume.CrushDeviceClass,
 			schedulerName:    volume.SchedulerName,
+			storeConfig:      osdconfig.ToStoreConfig(volume.Config),
 		}
 
 		logger.Debugf("osdProps are %+v", osdProps)
 
-		// Update the orchestration status of this pvc to the starting state
-		status := OrchestrationStatus{Status: OrchestrationStatusStarting, PvcBackedOSD: true}
-		c.updateOSDStatus(osdProps.crushHostname, status)
-
 		// Skip OSD prepare if deployment already exists for the PVC
 		listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s=%s",
 			k8sutil.AppAttr, AppName,
@@ -308,30 +371,48 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 				continue
 			}
 			// Update the orchestration status of this pvc to the completed state
-			status = OrchestrationStatus{OSDs: osds, Status: OrchestrationStatusCompleted, PvcBackedOSD: true}
+			status := OrchestrationStatus{OSDs: osds, Status: OrchestrationStatusCompleted, PvcBackedOSD: true}
 			c.updateOSDStatus(osdProps.crushHostname, status)
 			continue
 		}
 
-		job, err := c.makeJob(osdProps, config)
-		if err != nil {
-			message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", osdProps.crushHostname, err)
+		c.queuePrepareJob(config, c.pvcNodeName(dataSource.ClaimName), func() { c.startPVCPrepareJob(osdProps, config) })
+	}
+	c.runPrepareJobs(config)
+}
+
+func (c *Cluster) startPVCPrepareJob(osdProps osdProperties, config *provisionConfig) {
+	// Update the orchestration status of this pvc to the starting state
+	status := OrchestrationStatus{Status: OrchestrationStatusStarting, PvcBackedOSD: true}
+	c.updateOSDStatus(osdProps.crushHostname, status)
+
+	if osdProps.encrypted() {
+		if err := c.ensureEncryptionKey(osdProps.pvc.ClaimName); err != nil {
+			message := fmt.Sprintf("failed to store the encryption key of the osd on pvc %q. %v", osdProps.crushHostname, err)
 			config.addError(message)
-			status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message, PvcBackedOSD: true}
+			status = OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message, PvcBackedOSD: true}
 			c.updateOSDStatus(osdProps.crushHostname, status)
+			return
 		}
+	}
 
-		if !c.runJob(job, osdProps.crushHostname, config, "provision") {
-			status := OrchestrationStatus{
-				Status:       OrchestrationStatusCompleted,
-				Message:      fmt.Sprintf("failed to start osd provisioning on pvc %s", osdProps.crushHostname),
-				PvcBackedOSD: true,
-			}
-			c.updateOSDStatus(osdProps.crushHostname, status)
+	job, err := c.makeJob(osdProps, config)
+	if err != nil {
+		message := fmt.Sprintf("failed to create prepare job for pvc %s: %v", osdProps.crushHostname, err)
+		config.addError(message)
+		status = OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message, PvcBackedOSD: true}
+		c.updateOSDStatus(osdProps.crushHostname, status)
+		return
+	}
+
+	if !c.runJob(job, osdProps.crushHostname, config, "provision") {
+		status = OrchestrationStatus{
+			Status:       OrchestrationStatusCompleted,
+			Message:      fmt.Sprintf("failed to start osd provisioning on pvc %s", osdProps.crushHostname),
+			PvcBackedOSD: true,
 		}
+		c.updateOSDStatus(osdProps.crushHostname, status)
 	}
-	logger.Infof("start osds after provisioning is completed, if needed")
-	c.completeProvision(config)
 }
 
 func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
@@ -349,8 +430,7 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 		c.startNodeStorageProvisioners(config)
 	}
 
-	logger.Infof("start osds after provisioning is completed, if needed")
-	c.completeProvision(config)
+	c.runPrepareJobs(config)
 }
 
 func (c *Cluster) startNodeStorageProvisioners(config *provisionConfig) {
@@ -390,6 +470,13 @@ func (c *Cluster) startNodeStorageProvisioners(config *provisionConfig) {
 		return
 	}
 
+	// the nodes running osds always get a prepare job to update their osds
+	osdNodes, err := c.discoverStorageNodes()
+	if err != nil {
+		config.addError("failed to discover the nodes running osds. %v", err)
+		return
+	}
+
 	// start with nodes currently in the storage spec
 	for _, node := range c.ValidStorage.Nodes {
 		// fully resolve the storage config and resources for this node
@@ -404,18 +491,24 @@ func (c *Cluster) startNodeStorageProvisioners(config *provisionConfig) {
 			continue
 		}
 
+		if !c.hasCandidateDevices(n, osdNodes) {
+			logger.Infof("skipping osd provisioning on node %q since none of its discovered devices match the storage selection", n.Name)
+			continue
+		}
+
 		// create the job that prepares osds on the node
 		storeConfig := osdconfig.ToStoreConfig(n.Config)
 		metadataDevice := osdconfig.MetadataDevice(n.Config)
 		osdProps := osdProperties{
-			crushHostname:  n.Name,
-			devices:        n.Devices,
-			selection:      n.Selection,
-			resources:      n.Resources,
-			storeConfig:    storeConfig,
-			metadataDevice: metadataDevice,
+			crushHostname:    n.Name,
+			devices:          n.Devices,
+			selection:        n.Selection,
+			resources:        n.Resources,
+			storeConfig:      storeConfig,
+			metadataDevice:   metadataDevice,
+			crushDeviceClass: nodeDeviceClass(n),
 		}
-		c.makeAndRunJob(n.Name, "provision", osdProps, config)
+		c.queuePrepareJob(config, n.Name, func() { c.makeAndRunJob(n.Name, "provision", osdProps, config) })
 	}
 }
 
@@ -467,7 +560,7 @@ func (c *Cluster) startNodeDriveGroupProvisioners(config *provisionConfig) {
 			crushHostname: normalizedHostname,
 			driveGroups:   groups,
 		}
-		c.makeAndRunJob(normalizedHostname, "provision drive groups", osdProps, config)
+		c.queuePrepareJob(config, normalizedHostname, func() { c.makeAndRunJob(normalizedHostname, "provision drive groups", osdProps, config) })
 	}
 
 	// With Drive Groups, any node *could* be valid, and we need to do this so nodes resolve when
@@ -533,6 +626,10 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 			continue
 		}
 
+		if err := c.setMemoryTarget(osd.ID, osdProps.resources); err != nil {
+			logger.Warningf("failed to set the memory target of osd %d. %v", osd.ID, err)
+		}
+
 		dp, err := c.makeDeployment(osdProps, osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for pvc %q. %v", osdProps.crushHostname, err)
@@ -552,9 +649,10 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
 				logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
+				if err = c.updateCrushLocation(dp, osd); err != nil {
+					logger.Errorf("failed to update the CRUSH location of osd %d. %v", osd.ID, err)
 				}
+				c.updateOSDDeployment(dp, osd, config)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for pvc %q, osd %v. %v", osdProps.pvc.ClaimName, osd, createErr)
@@ -562,15 +660,22 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 			}
 		}
 
-		if createErr != nil && kerrors.IsAlreadyExists(createErr) {
-			if err = updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-				logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-			}
-		}
 		logger.Infof("started deployment for osd %d on pvc", osd.ID)
 	}
 }
 
+// updateOSDDeployment updates the deployment of an osd after checking the osd is ok to stop, or defers the update to
+// the waves of the update strategy
+func (c *Cluster) updateOSDDeployment(dp *apps.Deployment, osd OSDInfo, config *provisionConfig) {
+	if c.updateByWaves() {
+		config.osdUpdates = append(config.osdUpdates, dp)
+		return
+	}
+	if err := updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+		logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
+	}
+}
+
 func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig, configMap *v1.ConfigMap, status *OrchestrationStatus) {
 
 	osds := status.OSDs
@@ -586,12 +691,13 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 	metadataDevice := osdconfig.MetadataDevice(n.Config)
 
 	osdProps := osdProperties{
-		crushHostname:  n.Name,
-		devices:        n.Devices,
-		selection:      n.Selection,
-		resources:      n.Resources,
-		storeConfig:    storeConfig,
-		metadataDevice: metadataDevice,
+		crushHostname:    n.Name,
+		devices:          n.Devices,
+		selection:        n.Selection,
+		resources:        n.Resources,
+		storeConfig:      storeConfig,
+		metadataDevice:   metadataDevice,
+		crushDeviceClass: nodeDeviceClass(n),
 	}
 
 	// start osds
@@ -607,6 +713,10 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 			continue
 		}
 
+		if err := c.setMemoryTarget(osd.ID, osdProps.resources); err != nil {
+			logger.Warningf("failed to set the memory target of osd %d. %v", osd.ID, err)
+		}
+
 		dp, err := c.makeDeployment(osdProps, osd, config)
 		if err != nil {
 			errMsg := fmt.Sprintf("failed to create deployment for node %s: %v", n.Name, err)
@@ -626,9 +736,10 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
 				logger.Debugf("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, dp, c.Namespace, opconfig.OsdType, strconv.Itoa(osd.ID), c.skipUpgradeChecks, c.continueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
+				if err = c.updateCrushLocation(dp, osd); err != nil {
+					logger.Errorf("failed to update the CRUSH location of osd %d. %v", osd.ID, err)
 				}
+				c.updateOSDDeployment(dp, osd, config)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for node %q, osd %+v. %v", n.Name, osd, createErr)
@@ -701,16 +812,24 @@ func (c *Cluster) getOSDPropsForPVC(pvcName string) (osdProperties, error) {
 				logger.Infof("OSD will have its main bluestore block on %q", dataSource.ClaimName)
 			}
 
+			walSource, walOK := volumeSource.PVCSources[bluestorePVCWal]
+			if walOK {
+				logger.Infof("OSD will have its wal device on %q", walSource.ClaimName)
+			}
+
 			osdProps := osdProperties{
 				crushHostname:       dataSource.ClaimName,
 				pvc:                 dataSource,
 				metadataPVC:         metadataSource,
+				walPVC:              walSource,
 				resources:           volumeSource.Resources,
 				placement:           volumeSource.Placement,
+				preparePlacement:    volumeSource.PreparePlacement,
 				portable:            volumeSource.Portable,
 				tuneSlowDeviceClass: volumeSource.TuneSlowDeviceClass,
 				pvcSize:             volumeSource.Size,
 				schedulerName:       volumeSource.SchedulerName,
+				storeConfig:         osdconfig.ToStoreConfig(volumeSource.Config),
 			}
 			// If OSD isn't portable, we're getting the host name either from the osd deployment that was already initialized
 			// or from the osd prepare job from initial creation.
@@ -807,19 +926,11 @@ func (c *Cluster) getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 		osd.CVMode = "lvm"
 	}
 
-	locationFound := false
-	for _, a := range container.Args {
-		locationPrefix := "--crush-location="
-		if strings.HasPrefix(a, locationPrefix) {
-			locationFound = true
-			// Extract the same CRUSH location as originally determined by the OSD prepare pod
-			// by cutting off the prefix: --crush-location=
-			osd.Location = a[len(locationPrefix):]
-		}
-	}
-
+	// Extract the same CRUSH location as originally determined by the OSD prepare pod
+	location, locationFound := getLocationFromArgs(container.Args)
+	osd.Location = location
 	if !locationFound {
-		location, err := getLocationFromPod(c.context.Clientset, d)
+		location, err := getLocationFromPod(c.context.Clientset, d, c.DesiredStorage.TopologyMapping)
 		if err != nil {
 			logger.Errorf("failed to get location. %v", err)
 		} else {
@@ -834,7 +945,63 @@ func (c *Cluster) getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 	return []OSDInfo{osd}, nil
 }
 
-func getLocationFromPod(clientset kubernetes.Interface, d *apps.Deployment) (string, error) {
+// getLocationFromArgs returns the CRUSH location of the --crush-location arg of an osd, and whether the arg was found
+func getLocationFromArgs(args []string) (string, bool) {
+	locationPrefix := "--crush-location="
+	location := ""
+	locationFound := false
+	for _, a := range args {
+		if strings.HasPrefix(a, locationPrefix) {
+			locationFound = true
+			// cut off the prefix: --crush-location=
+			location = a[len(locationPrefix):]
+		}
+	}
+	return location, locationFound
+}
+
+// updateCrushLocation moves the host of an osd in the CRUSH map when the topology of its node changed
+// since the osd deployment was created. The osd only places itself in the CRUSH map when it does not
+// belong to its host yet, so the host itself must be moved to the new location.
+func (c *Cluster) updateCrushLocation(dp *apps.Deployment, osd OSDInfo) error {
+	existing, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(dp.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get osd deployment %q", dp.Name)
+	}
+	if len(existing.Spec.Template.Spec.Containers) == 0 {
+		return nil
+	}
+	previousLocation, _ := getLocationFromArgs(existing.Spec.Template.Spec.Containers[0].Args)
+	if previousLocation == "" || osd.Location == "" || previousLocation == osd.Location {
+		return nil
+	}
+
+	previousHost, _ := splitCrushLocationHost(previousLocation)
+	host, location := splitCrushLocationHost(osd.Location)
+	if host == "" || host != previousHost {
+		// a new host is placed in the CRUSH map by the osd
+		return nil
+	}
+
+	logger.Infof("moving host %q of osd %d in the CRUSH map from %q to %q", host, osd.ID, previousLocation, osd.Location)
+	return client.MoveCrushBucket(c.context, c.clusterInfo.Name, host, location)
+}
+
+// splitCrushLocationHost returns the host of a CRUSH location and the location of the host
+func splitCrushLocationHost(location string) (string, []string) {
+	host := ""
+	hostLocation := []string{}
+	for _, pair := range strings.Fields(location) {
+		if strings.HasPrefix(pair, "host=") {
+			host = strings.TrimPrefix(pair, "host=")
+			continue
+		}
+		hostLocation = append(hostLocation, pair)
+	}
+	return host, hostLocation
+}
+
+func getLocationFromPod(clientset kubernetes.Interface, d *apps.Deployment, topologyMapping map[string]string) (string, error) {
 	pods, err := clientset.CoreV1().Pods(d.Namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OsdIdLabelKey, d.Labels[OsdIdLabelKey])})
 	if err != nil || len(pods.Items) == 0 {
 		return "", err
@@ -851,10 +1018,10 @@ func getLocationFromPod(clientset kubernetes.Interface, d *apps.Deployment) (str
 			hostName = pvcName
 		}
 	}
-	return GetLocationWithNode(clientset, nodeName, hostName)
+	return GetLocationWithNode(clientset, nodeName, hostName, topologyMapping)
 }
 
-func GetLocationWithNode(clientset kubernetes.Interface, nodeName string, crushHostname string) (string, error) {
+func GetLocationWithNode(clientset kubernetes.Interface, nodeName string, crushHostname string, topologyMapping map[string]string) (string, error) {
 
 	node, err := getNode(clientset, nodeName)
 	if err != nil {
@@ -876,7 +1043,7 @@ func GetLocationWithNode(clientset kubernetes.Interface, nodeName string, crushH
 	locArgs := []string{"root=default", fmt.Sprintf("host=%s", hostName)}
 
 	nodeLabels := node.GetLabels()
-	UpdateLocationWithNodeLabels(&locArgs, nodeLabels)
+	UpdateLocationWithNodeLabels(&locArgs, nodeLabels, topologyMapping)
 
 	loc := strings.Join(locArgs, " ")
 	logger.Infof("CRUSH location=%s", loc)
@@ -904,9 +1071,9 @@ func getNode(clientset kubernetes.Interface, nodeName string) (*corev1.Node, err
 	return node, nil
 }
 
-func UpdateLocationWithNodeLabels(location *[]string, nodeLabels map[string]string) {
+func UpdateLocationWithNodeLabels(location *[]string, nodeLabels map[string]string, topologyMapping map[string]string) {
 
-	topology := ExtractOSDTopologyFromLabels(nodeLabels)
+	topology := ExtractOSDTopologyFromLabelsWithMapping(nodeLabels, topologyMapping)
 
 	keys := make([]string, 0, len(topology))
 	for k := range topology {
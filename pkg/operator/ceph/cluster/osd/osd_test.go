@@ -34,6 +34,7 @@ import (
 	apps "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -43,17 +44,21 @@ import (
 
 func TestOSDProperties(t *testing.T) {
 	osdProps := []osdProperties{
+		{pvc: v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"},
+			metadataPVC: v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"},
+			walPVC:      v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"}},
 		{pvc: v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"},
 			metadataPVC: v1.PersistentVolumeClaimVolumeSource{ClaimName: "claim"}},
 		{pvc: v1.PersistentVolumeClaimVolumeSource{ClaimName: ""},
 			metadataPVC: v1.PersistentVolumeClaimVolumeSource{ClaimName: ""}},
 	}
-	expected := [][2]bool{
-		{true, true},
-		{false, false},
+	expected := [][3]bool{
+		{true, true, true},
+		{true, true, false},
+		{false, false, false},
 	}
 	for i, p := range osdProps {
-		actual := [2]bool{p.onPVC(), p.onPVCWithMetadata()}
+		actual := [3]bool{p.onPVC(), p.onPVCWithMetadata(), p.onPVCWithWal()}
 		assert.Equal(t, expected[i], actual, "detected a problem in `expected[%d]`", i)
 	}
 }
@@ -392,3 +397,67 @@ func TestGetOSDInfo(t *testing.T) {
 	assert.Equal(t, 0, len(osds3))
 	assert.NotNil(t, err)
 }
+
+func TestUpdateCrushLocation(t *testing.T) {
+	var moves [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "move" {
+				moves = append(moves, args[3:6])
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %v", args)
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	c := New(&cephconfig.ClusterInfo{Name: "ns"}, &clusterd.Context{Clientset: clientset, Executor: executor}, "ns", "myversion", cephv1.CephVersionSpec{},
+		rookv1.StorageScopeSpec{}, cephv1.DriveGroupsSpec{}, "", rookv1.Placement{}, rookv1.Annotations{}, cephv1.NetworkSpec{},
+		v1.ResourceRequirements{}, v1.ResourceRequirements{}, "", metav1.OwnerReference{}, false, false, cephv1.CephClusterHealthCheckSpec{})
+	osdProp := osdProperties{
+		crushHostname: "node1",
+		pvc:           v1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc"},
+	}
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.Namespace, c.dataDirHostPath),
+	}
+	osd := OSDInfo{ID: 1, UUID: "osd-uuid", BlockPath: "/dev/sdb", CVMode: "raw", Location: "root=default host=node1 zone=zone1"}
+	existing, err := c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.NoError(t, err)
+	_, err = clientset.AppsV1().Deployments(c.Namespace).Create(existing)
+	assert.NoError(t, err)
+
+	// the location did not change
+	assert.NoError(t, c.updateCrushLocation(existing, osd))
+	assert.Empty(t, moves)
+
+	// the host of the osd is moved to the new zone
+	osd.Location = "root=default host=node1 zone=zone2"
+	assert.NoError(t, c.updateCrushLocation(existing, osd))
+	assert.Equal(t, [][]string{{"node1", "root=default", "zone=zone2"}}, moves)
+
+	// a new host is placed by the osd itself
+	moves = nil
+	osd.Location = "root=default host=node2 zone=zone2"
+	assert.NoError(t, c.updateCrushLocation(existing, osd))
+	assert.Empty(t, moves)
+}
+
+func TestSetMemoryTarget(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			commands = append(commands, args)
+			return "", nil
+		},
+	}
+	c := &Cluster{context: &clusterd.Context{Executor: executor}, Namespace: "ns"}
+
+	// the memory target is a share of the memory limit
+	resources := v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("4Gi")}}
+	assert.NoError(t, c.setMemoryTarget(2, resources))
+	assert.Equal(t, []string{"config", "set", "osd.2", "osd_memory_target", "3435973836"}, commands[0][:5])
+
+	// the default of ceph is restored without limit
+	assert.NoError(t, c.setMemoryTarget(2, v1.ResourceRequirements{}))
+	assert.Equal(t, []string{"config", "rm", "osd.2", "osd_memory_target"}, commands[1][:4])
+}
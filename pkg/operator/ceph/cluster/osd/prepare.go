@@ -0,0 +1,128 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"time"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// prepareJob is a prepare job waiting to be started
+type prepareJob struct {
+	// node is the node where the job runs, empty for the pvcs not bound to a local volume
+	node  string
+	start func()
+}
+
+// queuePrepareJob adds a prepare job to the jobs started by the next call to runPrepareJobs
+func (c *Cluster) queuePrepareJob(config *provisionConfig, node string, start func()) {
+	config.prepareJobs = append(config.prepareJobs, prepareJob{node: node, start: start})
+}
+
+// runPrepareJobs starts the queued prepare jobs by batches, the osds of a batch being started before the next batch.
+// Without the prepare settings of the storage, all the jobs are started in a single batch.
+func (c *Cluster) runPrepareJobs(config *provisionConfig) {
+	pending := config.prepareJobs
+	config.prepareJobs = nil
+	prepare := c.DesiredStorage.Prepare
+	if prepare == nil {
+		prepare = &rookv1.PrepareSpec{}
+	}
+
+	for {
+		var batch []prepareJob
+		batch, pending = nextPrepareBatch(pending, prepare)
+		if len(pending) > 0 {
+			logger.Infof("starting %d osd prepare jobs, %d jobs left for the next batches", len(batch), len(pending))
+		}
+		for _, job := range batch {
+			job.start()
+		}
+
+		logger.Infof("start osds after provisioning is completed, if needed")
+		c.completeProvision(config)
+		if len(pending) == 0 {
+			return
+		}
+
+		if delay := prepareBatchDelay(prepare); delay > 0 {
+			logger.Infof("waiting %s before the next batch of osd prepare jobs", delay.String())
+			time.Sleep(delay)
+		}
+	}
+}
+
+// nextPrepareBatch returns the jobs of the next batch within the limits of the jobs of a batch and of the jobs of a
+// node, and the jobs left for the following batches
+func nextPrepareBatch(jobs []prepareJob, prepare *rookv1.PrepareSpec) ([]prepareJob, []prepareJob) {
+	batch := []prepareJob{}
+	pending := []prepareJob{}
+	nodeJobs := map[string]int{}
+	for _, job := range jobs {
+		if prepare.MaxConcurrentJobs > 0 && len(batch) >= prepare.MaxConcurrentJobs {
+			pending = append(pending, job)
+			continue
+		}
+		if prepare.MaxConcurrentJobsPerNode > 0 && job.node != "" && nodeJobs[job.node] >= prepare.MaxConcurrentJobsPerNode {
+			pending = append(pending, job)
+			continue
+		}
+		nodeJobs[job.node]++
+		batch = append(batch, job)
+	}
+	return batch, pending
+}
+
+func prepareBatchDelay(prepare *rookv1.PrepareSpec) time.Duration {
+	if prepare.BatchDelay == "" {
+		return 0
+	}
+	delay, err := time.ParseDuration(prepare.BatchDelay)
+	if err != nil {
+		logger.Warningf("invalid batch delay %q of the osd prepare jobs. %v", prepare.BatchDelay, err)
+		return 0
+	}
+	return delay
+}
+
+// pvcNodeName returns the node of the local volume bound to a pvc, the prepare jobs of the pvcs of a node counting
+// for the jobs of the node. The other pvcs have no node until their prepare pod is scheduled.
+func (c *Cluster) pvcNodeName(claimName string) string {
+	prepare := c.DesiredStorage.Prepare
+	if prepare == nil || prepare.MaxConcurrentJobsPerNode == 0 {
+		return ""
+	}
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(claimName, metav1.GetOptions{})
+	if err != nil || pvc.Spec.VolumeName == "" {
+		return ""
+	}
+	pv, err := c.context.Clientset.CoreV1().PersistentVolumes().Get(pvc.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+		return ""
+	}
+	for _, term := range pv.Spec.NodeAffinity.Required.NodeSelectorTerms {
+		for _, expr := range term.MatchExpressions {
+			if expr.Key == v1.LabelHostname && expr.Operator == v1.NodeSelectorOpIn && len(expr.Values) == 1 {
+				return expr.Values[0]
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+	"time"
+
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNextPrepareBatch(t *testing.T) {
+	jobs := []prepareJob{{node: "a"}, {node: "a"}, {node: "b"}, {node: ""}, {node: ""}, {node: "a"}}
+
+	// no limits
+	batch, pending := nextPrepareBatch(jobs, &rookv1.PrepareSpec{})
+	assert.Equal(t, 6, len(batch))
+	assert.Equal(t, 0, len(pending))
+
+	// limit of the jobs of a batch
+	batch, pending = nextPrepareBatch(jobs, &rookv1.PrepareSpec{MaxConcurrentJobs: 4})
+	assert.Equal(t, 4, len(batch))
+	assert.Equal(t, 2, len(pending))
+
+	// limit of the jobs of a node, the jobs without node not being limited
+	batch, pending = nextPrepareBatch(jobs, &rookv1.PrepareSpec{MaxConcurrentJobsPerNode: 1})
+	assert.Equal(t, []prepareJob{{node: "a"}, {node: "b"}, {node: ""}, {node: ""}}, batch)
+	assert.Equal(t, []prepareJob{{node: "a"}, {node: "a"}}, pending)
+	batch, pending = nextPrepareBatch(pending, &rookv1.PrepareSpec{MaxConcurrentJobsPerNode: 1})
+	assert.Equal(t, 1, len(batch))
+	assert.Equal(t, 1, len(pending))
+
+	// both limits
+	batch, pending = nextPrepareBatch(jobs, &rookv1.PrepareSpec{MaxConcurrentJobs: 2, MaxConcurrentJobsPerNode: 1})
+	assert.Equal(t, []prepareJob{{node: "a"}, {node: "b"}}, batch)
+	assert.Equal(t, 4, len(pending))
+}
+
+func TestPrepareBatchDelay(t *testing.T) {
+	assert.Equal(t, time.Duration(0), prepareBatchDelay(&rookv1.PrepareSpec{}))
+	assert.Equal(t, 30*time.Second, prepareBatchDelay(&rookv1.PrepareSpec{BatchDelay: "30s"}))
+	assert.Equal(t, time.Duration(0), prepareBatchDelay(&rookv1.PrepareSpec{BatchDelay: "soon"}))
+}
+
+func TestPVCNodeName(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "set1-data-0", Namespace: "ns"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "local-pv"},
+	}
+	_, err := clientset.CoreV1().PersistentVolumeClaims("ns").Create(pvc)
+	assert.NoError(t, err)
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "local-pv"},
+		Spec: v1.PersistentVolumeSpec{
+			NodeAffinity: &v1.VolumeNodeAffinity{
+				Required: &v1.NodeSelector{
+					NodeSelectorTerms: []v1.NodeSelectorTerm{{
+						MatchExpressions: []v1.NodeSelectorRequirement{{Key: v1.LabelHostname, Operator: v1.NodeSelectorOpIn, Values: []string{"node1"}}},
+					}},
+				},
+			},
+		},
+	}
+	_, err = clientset.CoreV1().PersistentVolumes().Create(pv)
+	assert.NoError(t, err)
+
+	// the node is only needed with a limit of the jobs of a node
+	assert.Equal(t, "", c.pvcNodeName("set1-data-0"))
+
+	c.DesiredStorage.Prepare = &rookv1.PrepareSpec{MaxConcurrentJobsPerNode: 1}
+	assert.Equal(t, "node1", c.pvcNodeName("set1-data-0"))
+	assert.Equal(t, "", c.pvcNodeName("missing"))
+}
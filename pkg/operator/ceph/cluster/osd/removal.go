@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// osdRemovalReason is the event reason emitted at each step of the removal of an osd requested by the annotation
+	osdRemovalReason = "OSDRemoval"
+)
+
+// OSDsToRemove returns the ids of the osds requested to be removed by the annotation of the CephCluster
+func OSDsToRemove(annotations map[string]string) []int {
+	value, ok := annotations[controller.RemoveOSDsAnnotation]
+	if !ok {
+		return nil
+	}
+
+	var ids []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		id, err := strconv.Atoi(field)
+		if err != nil || id < 0 {
+			logger.Warningf("ignoring invalid osd id %q in annotation %q", field, controller.RemoveOSDsAnnotation)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SetOSDsToRemove sets the osds to decommission, replacing the previous ones
+func (m *OSDHealthMonitor) SetOSDsToRemove(ids []int) {
+	m.removalMutex.Lock()
+	defer m.removalMutex.Unlock()
+	m.osdsToRemove = map[int]struct{}{}
+	for _, id := range ids {
+		m.osdsToRemove[id] = struct{}{}
+	}
+}
+
+// SetWipeCallback sets a function called with the id and the node of a decommissioned osd, to wipe its disk
+func (m *OSDHealthMonitor) SetWipeCallback(callback func(osdID int, nodeName string)) {
+	m.wipeCallback = callback
+}
+
+func (m *OSDHealthMonitor) isRemovalRequested(osdID int) bool {
+	m.removalMutex.Lock()
+	defer m.removalMutex.Unlock()
+	_, ok := m.osdsToRemove[osdID]
+	return ok
+}
+
+// removeOSD runs the next step of the decommissioning of an osd, the steps being run by the successive checks:
+// the osd is marked out, then its daemon is stopped once its data is rebalanced, and finally its deployment
+// is removed, the osd purged and its disk wiped if requested once it is safe to destroy.
+func (m *OSDHealthMonitor) removeOSD(osdID int, up, in, wipe bool) error {
+	if in {
+		logger.Infof("marking osd.%d out for its removal", osdID)
+		if _, err := client.OSDOut(m.context, m.namespace, osdID); err != nil {
+			return errors.Wrapf(err, "failed to mark osd.%d out", osdID)
+		}
+		m.recordEvent(v1.EventTypeNormal, osdRemovalReason, "marked osd.%d out for its removal", osdID)
+		return nil
+	}
+
+	label := fmt.Sprintf("%s=%d", OsdIdLabelKey, osdID)
+	dp, err := k8sutil.GetDeployments(m.context.Clientset, m.namespace, label)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get osd deployment of osd id %d", osdID)
+	}
+
+	if up {
+		// the daemon must be stopped for the osd to be safe to destroy, but not before its data moved to other osds
+		message, clean, err := client.IsClusterClean(m.context, m.namespace)
+		if err != nil {
+			return errors.Wrap(err, "failed to check if the pgs are clean")
+		}
+		if !clean {
+			logger.Infof("waiting for the data of osd.%d to be rebalanced before its removal. %s", osdID, message)
+			return nil
+		}
+
+		// the deployment is only scaled down, it tells the node of the osd until the osd is purged
+		replicas := int32(0)
+		for i := range dp.Items {
+			logger.Infof("stopping osd.%d for its removal", osdID)
+			dp.Items[i].Spec.Replicas = &replicas
+			if _, err := m.context.Clientset.AppsV1().Deployments(m.namespace).Update(&dp.Items[i]); err != nil {
+				return errors.Wrapf(err, "failed to scale down osd deployment %s", dp.Items[i].Name)
+			}
+		}
+		return nil
+	}
+
+	safeToDestroy, err := client.OsdSafeToDestroy(m.context, m.namespace, osdID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if osd.%d is safe to destroy", osdID)
+	}
+	if !safeToDestroy {
+		logger.Infof("waiting for osd.%d to be safe to destroy before its removal", osdID)
+		return nil
+	}
+
+	// the node of the osd is empty for an osd on PVC
+	nodeName := ""
+	for _, d := range dp.Items {
+		nodeName = d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		if err := k8sutil.DeleteDeployment(m.context.Clientset, d.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete osd deployment %s", d.Name)
+		}
+	}
+
+	if err := client.PurgeOSD(m.context, m.namespace, osdID); err != nil {
+		return err
+	}
+	m.recordEvent(v1.EventTypeNormal, osdRemovalReason, "purged osd.%d", osdID)
+
+	if wipe && nodeName != "" && m.wipeCallback != nil {
+		m.wipeCallback(osdID, nodeName)
+	}
+	return nil
+}
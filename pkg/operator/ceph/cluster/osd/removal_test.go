@@ -0,0 +1,136 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestOSDsToRemove(t *testing.T) {
+	assert.Nil(t, OSDsToRemove(nil))
+	assert.Nil(t, OSDsToRemove(map[string]string{"foo": "3"}))
+	assert.Equal(t, []int{3}, OSDsToRemove(map[string]string{controller.RemoveOSDsAnnotation: "3"}))
+	assert.Equal(t, []int{3, 5}, OSDsToRemove(map[string]string{controller.RemoveOSDsAnnotation: "3, 5,"}))
+	// the invalid ids are ignored
+	assert.Equal(t, []int{5}, OSDsToRemove(map[string]string{controller.RemoveOSDsAnnotation: "osd.3,-1,5"}))
+}
+
+func TestRemoveOSD(t *testing.T) {
+	clientset := testexec.New(t, 2)
+	cluster := "fake"
+
+	osdDump := `{"OSDs": [{"OSD": 0, "Up": 1, "In": 1}]}`
+	outCount, purgeCount := 0, 0
+	safeToDestroy := `{"safe_to_destroy":[],"active":[0],"missing_stats":[],"stored_pgs":[]}`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			logger.Infof("ExecuteCommandWithOutputFile: %s %v", command, args)
+			if args[0] == "status" {
+				return `{"health":{"status":"HEALTH_OK"},"pgmap":{"num_pgs":0}}`, nil
+			} else if args[1] == "dump" {
+				return osdDump, nil
+			} else if args[1] == "out" {
+				outCount++
+			} else if args[1] == "safe-to-destroy" {
+				return safeToDestroy, nil
+			} else if args[1] == "purge" {
+				purgeCount++
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{
+		Executor:  executor,
+		Clientset: clientset,
+	}
+
+	replicas := int32(1)
+	deployment := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "osd0",
+			Namespace: cluster,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     AppName,
+				k8sutil.ClusterAttr: cluster,
+				OsdIdLabelKey:       "0",
+			},
+		},
+		Spec: apps.DeploymentSpec{
+			Replicas: &replicas,
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{NodeSelector: map[string]string{v1.LabelHostname: "node0"}},
+			},
+		},
+	}
+	_, err := context.Clientset.AppsV1().Deployments(cluster).Create(deployment)
+	assert.NoError(t, err)
+	getDeployments := func() []apps.Deployment {
+		dp, _ := context.Clientset.AppsV1().Deployments(cluster).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("%v=%d", OsdIdLabelKey, 0)})
+		return dp.Items
+	}
+
+	osdMon := NewOSDHealthMonitor(context, cluster, false, cephv1.CephClusterHealthCheckSpec{})
+	recorder := record.NewFakeRecorder(10)
+	osdMon.SetEventRecorder(recorder, &v1.ObjectReference{Name: cluster, Namespace: cluster})
+	wiped := map[int]string{}
+	osdMon.SetWipeCallback(func(osdID int, nodeName string) { wiped[osdID] = nodeName })
+
+	// the osd is left alone until its removal is requested
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Equal(t, 0, outCount)
+
+	// the osd in is marked out first
+	osdMon.SetOSDsToRemove([]int{0})
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Equal(t, 1, outCount)
+	assert.Equal(t, "Normal OSDRemoval marked osd.0 out for its removal", <-recorder.Events)
+
+	// the osd out is stopped once the pgs are clean
+	osdDump = `{"OSDs": [{"OSD": 0, "Up": 1, "In": 0}]}`
+	assert.NoError(t, osdMon.checkOSDHealth())
+	dp := getDeployments()
+	assert.Equal(t, 1, len(dp))
+	assert.Equal(t, int32(0), *dp[0].Spec.Replicas)
+
+	// the osd down is not removed until it is safe to destroy
+	osdDump = `{"OSDs": [{"OSD": 0, "Up": 0, "In": 0}]}`
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Equal(t, 1, len(getDeployments()))
+	assert.Equal(t, 0, purgeCount)
+
+	// the osd safe to destroy is purged and its disk wiped
+	safeToDestroy = `{"safe_to_destroy":[0],"active":[],"missing_stats":[],"stored_pgs":[]}`
+	assert.NoError(t, osdMon.checkOSDHealth())
+	assert.Equal(t, 0, len(getDeployments()))
+	assert.Equal(t, 1, purgeCount)
+	assert.Equal(t, map[int]string{0: "node0"}, wiped)
+	assert.Equal(t, "Normal OSDRemoval purged osd.0", <-recorder.Events)
+	assert.Equal(t, 0, len(recorder.Events))
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// osdNodeRemovedReason is the event reason emitted when the node of osds is found deleted from the cluster
+	osdNodeRemovedReason = "OSDNodeRemoved"
+)
+
+// SetRemovedNodes sets how the osds of the nodes deleted from the kubernetes cluster are handled. They are only
+// handled when all the nodes are used, and removed once their node has been deleted for the grace period if set.
+func (m *OSDHealthMonitor) SetRemovedNodes(storage rookv1.StorageScopeSpec) {
+	m.removalMutex.Lock()
+	defer m.removalMutex.Unlock()
+	m.useAllNodes = storage.UseAllNodes
+	m.removedNodeGracePeriod = 0
+	if storage.RemovedNodeGracePeriod != "" {
+		period, err := time.ParseDuration(storage.RemovedNodeGracePeriod)
+		if err != nil {
+			m.logger.Warningf("invalid grace period %q of the osds of the removed nodes. %v", storage.RemovedNodeGracePeriod, err)
+			return
+		}
+		m.removedNodeGracePeriod = period
+	}
+}
+
+// SetTopologyCallback sets a function called after every check with the osds of the deleted nodes, to report them
+func (m *OSDHealthMonitor) SetTopologyCallback(callback func(changes []cephv1.TopologyChange)) {
+	m.topologyCallback = callback
+}
+
+// reconcileRemovedNodes finds the osds whose node was deleted from the kubernetes cluster. The osds are reported as
+// pending removal and returned once their node has been deleted for the grace period, to be removed. The osds on
+// pvcs are not pinned to a node and are left alone.
+func (m *OSDHealthMonitor) reconcileRemovedNodes() (map[int]struct{}, error) {
+	m.removalMutex.Lock()
+	useAllNodes, gracePeriod := m.useAllNodes, m.removedNodeGracePeriod
+	m.removalMutex.Unlock()
+
+	toRemove := map[int]struct{}{}
+	if !useAllNodes {
+		m.removedNodes = map[string]time.Time{}
+		m.reportTopologyChanges(nil)
+		return toRemove, nil
+	}
+
+	deployments, err := k8sutil.GetDeployments(m.context.Clientset, m.namespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName))
+	if err != nil {
+		return toRemove, errors.Wrap(err, "failed to list the osd deployments")
+	}
+	nodes, err := m.context.Clientset.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return toRemove, errors.Wrap(err, "failed to list the nodes")
+	}
+	existing := map[string]struct{}{}
+	for _, node := range nodes.Items {
+		existing[node.Name] = struct{}{}
+		if hostname, ok := node.Labels[v1.LabelHostname]; ok {
+			existing[hostname] = struct{}{}
+		}
+	}
+
+	osdsByNode := map[string][]int{}
+	for _, d := range deployments.Items {
+		hostname, ok := d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		if !ok {
+			continue
+		}
+		if _, ok := existing[hostname]; ok {
+			continue
+		}
+		osdID, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			continue
+		}
+		osdsByNode[hostname] = append(osdsByNode[hostname], osdID)
+	}
+
+	removedNodes := map[string]time.Time{}
+	changes := []cephv1.TopologyChange{}
+	for node, osds := range osdsByNode {
+		sort.Ints(osds)
+		since, ok := m.removedNodes[node]
+		if !ok {
+			since = time.Now()
+			m.logger.Warningf("node %q of osds %v was deleted from the cluster", node, osds)
+			m.recordEvent(v1.EventTypeWarning, osdNodeRemovedReason, "node %q of osds %v was deleted from the cluster", node, osds)
+		}
+		removedNodes[node] = since
+		changes = append(changes, cephv1.TopologyChange{Node: node, Change: cephv1.TopologyChangeRemove, OSDs: osds, Since: since.UTC().Format(time.RFC3339)})
+
+		if gracePeriod == 0 {
+			continue
+		}
+		if time.Since(since) < gracePeriod {
+			m.logger.Infof("waiting until %s to remove the osds %v of the deleted node %q", since.Add(gracePeriod).UTC().Format(time.RFC3339), osds, node)
+			continue
+		}
+		for _, osdID := range osds {
+			toRemove[osdID] = struct{}{}
+		}
+	}
+	m.removedNodes = removedNodes
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Node < changes[j].Node })
+	m.reportTopologyChanges(changes)
+	return toRemove, nil
+}
+
+func (m *OSDHealthMonitor) reportTopologyChanges(changes []cephv1.TopologyChange) {
+	if m.topologyCallback != nil {
+		m.topologyCallback(changes)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testexec "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestReconcileRemovedNodes(t *testing.T) {
+	namespace := "ns"
+	context := &clusterd.Context{Clientset: testexec.New(t, 2)}
+	for id, node := range []string{"node0", "node1", "node2", "node2"} {
+		d := &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "rook-ceph-osd-" + strconv.Itoa(id),
+				Namespace: namespace,
+				Labels:    map[string]string{k8sutil.AppAttr: AppName, OsdIdLabelKey: strconv.Itoa(id)},
+			},
+		}
+		d.Spec.Template.Spec.NodeSelector = map[string]string{v1.LabelHostname: node}
+		_, err := context.Clientset.AppsV1().Deployments(namespace).Create(d)
+		assert.NoError(t, err)
+	}
+
+	m := NewOSDHealthMonitor(context, namespace, false, cephv1.CephClusterHealthCheckSpec{})
+	recorder := record.NewFakeRecorder(10)
+	m.SetEventRecorder(recorder, &v1.ObjectReference{Name: namespace, Namespace: namespace})
+	var reported []cephv1.TopologyChange
+	m.SetTopologyCallback(func(changes []cephv1.TopologyChange) { reported = changes })
+
+	// the removed nodes are ignored when all the nodes are not used
+	toRemove, err := m.reconcileRemovedNodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(toRemove))
+	assert.Nil(t, reported)
+
+	// the osds of the deleted node are reported, but not removed without a grace period
+	m.SetRemovedNodes(rookv1.StorageScopeSpec{UseAllNodes: true})
+	toRemove, err = m.reconcileRemovedNodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(toRemove))
+	assert.Equal(t, 1, len(reported))
+	assert.Equal(t, "node2", reported[0].Node)
+	assert.Equal(t, cephv1.TopologyChangeRemove, reported[0].Change)
+	assert.Equal(t, []int{2, 3}, reported[0].OSDs)
+	assert.Equal(t, "Warning OSDNodeRemoved node \"node2\" of osds [2 3] was deleted from the cluster", <-recorder.Events)
+
+	// the osds are kept during the grace period and the node is not reported again
+	m.SetRemovedNodes(rookv1.StorageScopeSpec{UseAllNodes: true, RemovedNodeGracePeriod: "1h"})
+	toRemove, err = m.reconcileRemovedNodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(toRemove))
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the osds are removed once the grace period elapsed
+	m.removedNodes["node2"] = time.Now().Add(-2 * time.Hour)
+	toRemove, err = m.reconcileRemovedNodes()
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]struct{}{2: {}, 3: {}}, toRemove)
+
+	// the node is no longer reported once it is back
+	_, err = context.Clientset.CoreV1().Nodes().Create(&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}})
+	assert.NoError(t, err)
+	toRemove, err = m.reconcileRemovedNodes()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(toRemove))
+	assert.Equal(t, 0, len(reported))
+	assert.Equal(t, 0, len(m.removedNodes))
+}
@@ -18,6 +18,7 @@ limitations under the License.
 package osd
 
 import (
+	"encoding/json"
 	"fmt"
 	"path"
 	"strconv"
@@ -25,6 +26,8 @@ import (
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	opmon "github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
@@ -44,6 +47,7 @@ const (
 	osdsPerDeviceEnvVarName             = "ROOK_OSDS_PER_DEVICE"
 	encryptedDeviceEnvVarName           = "ROOK_ENCRYPTED_DEVICE"
 	osdMetadataDeviceEnvVarName         = "ROOK_METADATA_DEVICE"
+	TopologyMappingEnvVarName           = "ROOK_TOPOLOGY_MAPPING"
 	pvcBackedOSDVarName                 = "ROOK_PVC_BACKED_OSD"
 	blockPathVarName                    = "ROOK_BLOCK_PATH"
 	cvModeVarName                       = "ROOK_CV_MODE"
@@ -55,6 +59,7 @@ const (
 	activateOSDMountPath                = "/var/lib/ceph/osd/ceph-"
 	blockPVCMapperInitContainer         = "blkdevmapper"
 	blockPVCMetadataMapperInitContainer = "blkdevmapper-metadata"
+	blockPVCWalMapperInitContainer      = "blkdevmapper-wal"
 	activatePVCOSDInitContainer         = "activate"
 	expandPVCOSDInitContainer           = "expand-bluefs"
 	// CephDeviceSetLabelKey is the Rook device set label key
@@ -138,6 +143,9 @@ func (c *Cluster) makeJob(osdProps osdProperties, provisionConfig *provisionConf
 		if osdProps.onPVCWithMetadata() {
 			podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, c.getPVCMetadataInitContainer("/srv", osdProps))
 		}
+		if osdProps.onPVCWithWal() {
+			podSpec.Spec.InitContainers = append(podSpec.Spec.InitContainers, c.getPVCWalInitContainer("/wal", osdProps))
+		}
 	}
 
 	job := &batch.Job{
@@ -189,6 +197,9 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	}
 
 	dataDir := k8sutil.DataDir
+	// The dm-crypt device of an encrypted osd on pvc in raw mode is opened on the /dev of the host
+	encryptedRaw := osdProps.encrypted() && osd.CVMode == "raw"
+
 	// Create volume config for /dev so the pod can access devices on the host
 	// Only valid when running OSD with LVM mode
 	if osd.CVMode == "lvm" {
@@ -196,6 +207,9 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		volumes = append(volumes, devVolume)
 		devMount := v1.VolumeMount{Name: "devices", MountPath: "/dev"}
 		volumeMounts = append(volumeMounts, devMount)
+	} else if encryptedRaw {
+		devVolume := v1.Volume{Name: "devices", VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: "/dev"}}}
+		volumes = append(volumes, devVolume)
 	}
 
 	// If the OSD runs on PVC
@@ -266,7 +280,8 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 			fmt.Sprintf("--crush-location=%s", osd.Location),
 		}
 	} else if osdProps.onPVC() && osd.CVMode == "raw" {
-		doBinaryCopyInit = false
+		// the rook binary opens the encrypted block in an init container
+		doBinaryCopyInit = encryptedRaw
 		doConfigInit = false
 		command = []string{"ceph-osd"}
 		args = []string{
@@ -370,10 +385,19 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	}
 
 	if osdProps.onPVC() && osd.CVMode == "raw" {
-		initContainers = append(initContainers, c.getPVCInitContainerActivate(osdDataDirPath, osdProps))
+		if encryptedRaw {
+			initContainers = append(initContainers, c.getPVCInitContainerActivate(osdDataDirPath, encryptionTmpBlockName, osdProps))
+			initContainers = append(initContainers, c.getEncryptionOpenInitContainer(osdDataDirPath, osdProps))
+			initContainers = append(initContainers, c.getPVCEncryptionInitContainerActivate(osdDataDirPath, osdProps))
+		} else {
+			initContainers = append(initContainers, c.getPVCInitContainerActivate(osdDataDirPath, "block", osdProps))
+		}
 		if osdProps.onPVCWithMetadata() {
 			initContainers = append(initContainers, c.getPVCMetadataInitContainerActivate(osdDataDirPath, osdProps))
 		}
+		if osdProps.onPVCWithWal() {
+			initContainers = append(initContainers, c.getPVCWalInitContainerActivate(osdDataDirPath, osdProps))
+		}
 		initContainers = append(initContainers, c.getActivatePVCInitContainer(osdProps, osdID))
 		initContainers = append(initContainers, c.getExpandPVCInitContainer(osdProps, osdID))
 	}
@@ -415,7 +439,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		Spec: v1.PodSpec{
 			RestartPolicy:      v1.RestartPolicyAlways,
 			ServiceAccountName: serviceAccountName,
-			HostNetwork:        c.Network.IsHost(),
+			HostNetwork:        c.Network.IsHostFor(cephv1.KeyOSD),
 			HostPID:            hostPID,
 			HostIPC:            hostIPC,
 			PriorityClassName:  c.priorityClassName,
@@ -441,7 +465,13 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	// If the liveness probe is enabled
 	podTemplateSpec.Spec.Containers[0] = opconfig.ConfigureLivenessProbe(cephv1.KeyOSD, podTemplateSpec.Spec.Containers[0], c.healthCheck)
 
-	if c.Network.IsHost() {
+	if c.logCollector.Enabled {
+		podTemplateSpec.Spec.Containers = append(podTemplateSpec.Spec.Containers,
+			controller.LogCollectorContainer(fmt.Sprintf("%s.%s", opconfig.OsdType, osdID), c.cephVersion.Image, c.logCollector, opmon.PodSecurityContext()))
+	}
+	controller.ApplyDaemonOverrides(&podTemplateSpec.Spec, c.env, c.volumeMounts, cephv1.KeyOSD)
+
+	if c.Network.IsHostFor(cephv1.KeyOSD) {
 		podTemplateSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.Network.NetworkSpec.IsMultus() {
 		k8sutil.ApplyMultus(c.Network.NetworkSpec, &podTemplateSpec.ObjectMeta)
@@ -479,10 +509,13 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	if osdProps.onPVC() && osdProps.portable {
 		k8sutil.AddUnreachableNodeToleration(&deployment.Spec.Template.Spec)
 	}
+	k8sutil.ApplyImagePullSecrets(c.Namespace, &deployment.Spec.Template.Spec)
 
 	k8sutil.AddRookVersionLabelToDeployment(deployment)
 	c.annotations.ApplyToObjectMeta(&deployment.ObjectMeta)
 	c.annotations.ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
+	c.labels.ApplyToObjectMeta(&deployment.ObjectMeta)
+	c.labels.ApplyToObjectMeta(&deployment.Spec.Template.ObjectMeta)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	controller.AddCephVersionLabelToDeployment(c.clusterInfo.CephVersion, deployment)
 	k8sutil.SetOwnerRef(&deployment.ObjectMeta, &c.ownerRef)
@@ -585,18 +618,22 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 		},
 		RestartPolicy:     restart,
 		Volumes:           volumes,
-		HostNetwork:       c.Network.IsHost(),
+		HostNetwork:       c.Network.IsHostFor(cephv1.KeyOSD),
 		PriorityClassName: c.priorityClassName,
 		SchedulerName:     osdProps.schedulerName,
 	}
-	if c.Network.IsHost() {
+	if c.Network.IsHostFor(cephv1.KeyOSD) {
 		podSpec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	}
+	controller.ApplyDaemonOverrides(&podSpec, c.env, c.volumeMounts, cephv1.KeyOSDPrepare)
 	if !osdProps.onPVC() {
-		c.placement.ApplyToPodSpec(&podSpec)
+		c.preparePlacement.ApplyToPodSpec(&podSpec)
+	} else if osdProps.preparePlacement != nil {
+		osdProps.preparePlacement.ApplyToPodSpec(&podSpec)
 	} else {
 		osdProps.placement.ApplyToPodSpec(&podSpec)
 	}
+	k8sutil.ApplyImagePullSecrets(c.Namespace, &podSpec)
 
 	podMeta := metav1.ObjectMeta{
 		Name: AppName,
@@ -609,6 +646,7 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 	}
 
 	c.annotations.ApplyToObjectMeta(&podMeta)
+	c.labels.ApplyToObjectMeta(&podMeta)
 
 	// ceph-volume --dmcrypt uses cryptsetup that synchronizes with udev on
 	// host through semaphore
@@ -648,7 +686,9 @@ func (c *Cluster) getPVCInitContainer(osdProps osdProperties) v1.Container {
 	}
 }
 
-func (c *Cluster) getPVCInitContainerActivate(mountPath string, osdProps osdProperties) v1.Container {
+// getPVCInitContainerActivate copies the block of the pvc to the given target in the bridge mount, the target of an
+// encrypted block being opened with dm-crypt afterwards
+func (c *Cluster) getPVCInitContainerActivate(mountPath, target string, osdProps osdProperties) v1.Container {
 
 	return v1.Container{
 		Name:  blockPVCMapperInitContainer,
@@ -656,7 +696,7 @@ func (c *Cluster) getPVCInitContainerActivate(mountPath string, osdProps osdProp
 		Command: []string{
 			"cp",
 		},
-		Args: []string{"-a", fmt.Sprintf("/%s", osdProps.pvc.ClaimName), path.Join(mountPath, "block")},
+		Args: []string{"-a", fmt.Sprintf("/%s", osdProps.pvc.ClaimName), path.Join(mountPath, target)},
 		VolumeDevices: []v1.VolumeDevice{
 			{
 				Name:       osdProps.pvc.ClaimName,
@@ -726,6 +766,52 @@ func (c *Cluster) getPVCMetadataInitContainerActivate(mountPath string, osdProps
 	}
 }
 
+func (c *Cluster) getPVCWalInitContainer(mountPath string, osdProps osdProperties) v1.Container {
+	return v1.Container{
+		Name:  blockPVCWalMapperInitContainer,
+		Image: c.cephVersion.Image,
+		Command: []string{
+			"cp",
+		},
+		Args: []string{"-a", fmt.Sprintf("/%s", osdProps.walPVC.ClaimName), fmt.Sprintf("/wal/%s", osdProps.walPVC.ClaimName)},
+		VolumeDevices: []v1.VolumeDevice{
+			{
+				Name:       osdProps.walPVC.ClaimName,
+				DevicePath: fmt.Sprintf("/%s", osdProps.walPVC.ClaimName),
+			},
+		},
+		VolumeMounts: []v1.VolumeMount{
+			{
+				MountPath: "/wal",
+				Name:      fmt.Sprintf("%s-bridge", osdProps.walPVC.ClaimName),
+			},
+		},
+		SecurityContext: opmon.PodSecurityContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+func (c *Cluster) getPVCWalInitContainerActivate(mountPath string, osdProps osdProperties) v1.Container {
+	return v1.Container{
+		Name:  blockPVCWalMapperInitContainer,
+		Image: c.cephVersion.Image,
+		Command: []string{
+			"cp",
+		},
+		Args: []string{"-a", fmt.Sprintf("/%s", osdProps.walPVC.ClaimName), path.Join(mountPath, "block.wal")},
+		VolumeDevices: []v1.VolumeDevice{
+			{
+				Name:       osdProps.walPVC.ClaimName,
+				DevicePath: fmt.Sprintf("/%s", osdProps.walPVC.ClaimName),
+			},
+		},
+		// Like the metadata block, the wal block is copied into the "main" empty dir passed along every init container
+		VolumeMounts:    []v1.VolumeMount{getPvcOSDBridgeMountActivate(mountPath, osdProps.pvc.ClaimName)},
+		SecurityContext: opmon.PodSecurityContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
 func (c *Cluster) getActivatePVCInitContainer(osdProps osdProperties, osdID string) v1.Container {
 	osdDataPath := activateOSDMountPath + osdID
 	osdDataBlockPath := path.Join(osdDataPath, "block")
@@ -748,6 +834,11 @@ func (c *Cluster) getActivatePVCInitContainer(osdProps osdProperties, osdID stri
 		Resources:       osdProps.resources,
 	}
 
+	// The block of an encrypted osd is the dm-crypt device copied in the bridge mount
+	if osdProps.encrypted() {
+		container.VolumeDevices = nil
+	}
+
 	return container
 }
 
@@ -842,6 +933,15 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		}
 	}
 
+	// the prepare job finds the CRUSH location of the osds with the topology mapping
+	if len(c.DesiredStorage.TopologyMapping) > 0 {
+		mapping, err := json.Marshal(c.DesiredStorage.TopologyMapping)
+		if err != nil {
+			return v1.Container{}, errors.Wrap(err, "failed to marshal the topology mapping")
+		}
+		envVars = append(envVars, v1.EnvVar{Name: TopologyMappingEnvVarName, Value: string(mapping)})
+	}
+
 	// only 1 of device list, device filter, device path filter and use all devices can be specified.  We prioritize in that order.
 	if len(osdProps.devices) > 0 {
 		deviceNames := make([]string, len(osdProps.devices))
@@ -859,7 +959,7 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 			} else {
 				devSuffix += ":"
 			}
-			if deviceClass, ok := device.Config[config.DeviceClassKey]; ok {
+			if deviceClass := resolveDeviceClass(device, osdProps.crushDeviceClass); deviceClass != "" {
 				logger.Infof("osd %s requested with deviceClass %s (node %s)", device.Name, deviceClass, osdProps.crushHostname)
 				devSuffix += ":" + deviceClass
 			} else {
@@ -871,6 +971,12 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 			} else {
 				devSuffix += ":"
 			}
+			if wal, ok := device.Config[config.WalDeviceKey]; ok {
+				logger.Infof("osd %s requested with walDevice %s (node %s)", device.Name, wal, osdProps.crushHostname)
+				devSuffix += ":" + wal
+			} else {
+				devSuffix += ":"
+			}
 			deviceID := device.Name
 			if device.FullPath != "" {
 				deviceID = device.FullPath
@@ -885,6 +991,13 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 	} else if osdProps.selection.GetUseAllDevices() {
 		envVars = append(envVars, deviceFilterEnvVar("all"))
 	}
+	if !osdProps.onPVC() {
+		envVars = append(envVars, deviceSizeAndTypeEnvVars(osdProps.selection)...)
+		// the devices selected by the filters get the device class of the node
+		if osdProps.crushDeviceClass != "" {
+			envVars = append(envVars, crushDeviceClassEnvVar(osdProps.crushDeviceClass))
+		}
+	}
 	envVars = append(envVars, v1.EnvVar{Name: "ROOK_CEPH_VERSION", Value: c.clusterInfo.CephVersion.CephVersionFormatted()})
 
 	if osdProps.metadataDevice != "" {
@@ -906,9 +1019,17 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 			volumeMounts = append(volumeMounts, getPvcMetadataOSDBridgeMount(osdProps.metadataPVC.ClaimName))
 			dev = append(dev, fmt.Sprintf("/srv/%s", osdProps.metadataPVC.ClaimName))
 		}
+		if osdProps.onPVCWithWal() {
+			volumeMounts = append(volumeMounts, getPvcWalOSDBridgeMount(osdProps.walPVC.ClaimName))
+			dev = append(dev, fmt.Sprintf("/wal/%s", osdProps.walPVC.ClaimName))
+		}
 		envVars = append(envVars, dataDevicesEnvVar(strings.Join(dev, ",")))
 		envVars = append(envVars, pvcBackedOSDEnvVar("true"))
 		envVars = append(envVars, crushDeviceClassEnvVar(osdProps.crushDeviceClass))
+		// The encryption key of the osd is stored in the key management service, if any
+		if osdProps.encrypted() {
+			envVars = append(envVars, kms.EnvVars(c.kms)...)
+		}
 	}
 
 	// run privileged always since we always mount /dev
@@ -948,6 +1069,10 @@ func getPvcMetadataOSDBridgeMount(claimName string) v1.VolumeMount {
 	return v1.VolumeMount{Name: fmt.Sprintf("%s-bridge", claimName), MountPath: "/srv"}
 }
 
+func getPvcWalOSDBridgeMount(claimName string) v1.VolumeMount {
+	return v1.VolumeMount{Name: fmt.Sprintf("%s-bridge", claimName), MountPath: "/wal"}
+}
+
 func (c *Cluster) skipVolumeForDirectory(path string) bool {
 	// If attempting to add a directory at /var/lib/rook, we need to skip the volume and volume mount
 	// since the dataDirHostPath is always mounting at /var/lib/rook
@@ -1000,6 +1125,29 @@ func getPVCOSDVolumes(osdProps *osdProperties) []v1.Volume {
 		volumes = append(volumes, metadataPVCVolume...)
 	}
 
+	// If we have a wal PVC let's add it
+	if osdProps.onPVCWithWal() {
+		walPVCVolume := []v1.Volume{
+			{
+				Name: osdProps.walPVC.ClaimName,
+				VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &osdProps.walPVC,
+				},
+			},
+			{
+				// Same bridge mount as for the data and metadata PVCs
+				Name: fmt.Sprintf("%s-bridge", osdProps.walPVC.ClaimName),
+				VolumeSource: v1.VolumeSource{
+					EmptyDir: &v1.EmptyDirVolumeSource{
+						Medium: "Memory",
+					},
+				},
+			},
+		}
+
+		volumes = append(volumes, walPVCVolume...)
+	}
+
 	logger.Debugf("volumes are %+v", volumes)
 
 	return volumes
@@ -1037,6 +1185,20 @@ func devicePathFilterEnvVar(filter string) v1.EnvVar {
 	return v1.EnvVar{Name: "ROOK_DATA_DEVICE_PATH_FILTER", Value: filter}
 }
 
+func deviceSizeAndTypeEnvVars(selection rookv1.Selection) []v1.EnvVar {
+	envVars := []v1.EnvVar{}
+	if selection.MinSize != "" {
+		envVars = append(envVars, v1.EnvVar{Name: "ROOK_DATA_DEVICE_MIN_SIZE", Value: selection.MinSize})
+	}
+	if selection.MaxSize != "" {
+		envVars = append(envVars, v1.EnvVar{Name: "ROOK_DATA_DEVICE_MAX_SIZE", Value: selection.MaxSize})
+	}
+	if selection.DeviceType != "" {
+		envVars = append(envVars, v1.EnvVar{Name: "ROOK_DATA_DEVICE_TYPE", Value: selection.DeviceType})
+	}
+	return envVars
+}
+
 func metadataDeviceEnvVar(metadataDevice string) v1.EnvVar {
 	return v1.EnvVar{Name: osdMetadataDeviceEnvVarName, Value: metadataDevice}
 }
@@ -1065,6 +1227,18 @@ func lvBackedPVEnvVar(lvBackedPV string) v1.EnvVar {
 	return v1.EnvVar{Name: lvBackedPVVarName, Value: lvBackedPV}
 }
 
+// resolveDeviceClass returns the CRUSH device class of a device, the deviceClass of the device taking precedence over
+// its config and over the device class of the node
+func resolveDeviceClass(device rookv1.Device, nodeDeviceClass string) string {
+	if device.DeviceClass != "" {
+		return device.DeviceClass
+	}
+	if deviceClass := device.Config[config.DeviceClassKey]; deviceClass != "" {
+		return deviceClass
+	}
+	return nodeDeviceClass
+}
+
 func crushDeviceClassEnvVar(crushDeviceClass string) v1.EnvVar {
 	return v1.EnvVar{Name: CrushDeviceClassVarName, Value: crushDeviceClass}
 }
@@ -1073,7 +1247,7 @@ func osdOnSDNFlag(network cephv1.NetworkSpec) []string {
 	var args []string
 	// OSD fails to find the right IP to bind to when running on SDN
 	// for more details: https://github.com/rook/rook/issues/3140
-	if !network.IsHost() {
+	if !network.IsHostFor(cephv1.KeyOSD) {
 		args = append(args, "--ms-learn-addr-from-peer=false")
 	}
 
@@ -26,6 +26,7 @@ import (
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -64,6 +65,47 @@ func TestPodContainer(t *testing.T) {
 	assert.Equal(t, "ceph", container.Args[2])
 	assert.Equal(t, "osd", container.Args[3])
 	assert.Equal(t, "provision", container.Args[4])
+	assert.Empty(t, c.Spec.ImagePullSecrets)
+
+	// the prepare pods pull the images with the secrets of the cluster
+	k8sutil.SetImagePullSecrets(cluster.Namespace, []v1.LocalObjectReference{{Name: "registry"}})
+	defer k8sutil.SetImagePullSecrets(cluster.Namespace, nil)
+	c, err = cluster.provisionPodTemplateSpec(osdProps, v1.RestartPolicyAlways, dataPathMap)
+	assert.NoError(t, err)
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "registry"}}, c.Spec.ImagePullSecrets)
+}
+
+func TestOsdPreparePlacement(t *testing.T) {
+	osdPlacement := rookv1.Placement{Tolerations: []v1.Toleration{{Key: "storage", Operator: v1.TolerationOpExists}}}
+	spread := []v1.TopologySpreadConstraint{{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: v1.DoNotSchedule}}
+	cluster := &Cluster{Namespace: "myosd", rookVersion: "23", cephVersion: cephv1.CephVersionSpec{}, clusterInfo: &cephconfig.ClusterInfo{},
+		placement: osdPlacement, preparePlacement: rookv1.Placement{TopologySpreadConstraints: spread}}
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(cluster.Namespace, "/var/lib/rook"),
+	}
+
+	// the prepare pods on the nodes have the prepare placement
+	c, err := cluster.provisionPodTemplateSpec(osdProperties{crushHostname: "node"}, v1.RestartPolicyOnFailure, dataPathMap)
+	assert.NoError(t, err)
+	assert.Equal(t, spread, c.Spec.TopologySpreadConstraints)
+	assert.Empty(t, c.Spec.Tolerations)
+
+	// the prepare pods on pvc have the placement of the device set unless a prepare placement is set
+	osdProps := osdProperties{
+		crushHostname: "pvc1",
+		pvc:           v1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc1"},
+		placement:     osdPlacement,
+	}
+	c, err = cluster.provisionPodTemplateSpec(osdProps, v1.RestartPolicyOnFailure, dataPathMap)
+	assert.NoError(t, err)
+	assert.Equal(t, osdPlacement.Tolerations, c.Spec.Tolerations)
+	assert.Empty(t, c.Spec.TopologySpreadConstraints)
+
+	osdProps.preparePlacement = &rookv1.Placement{TopologySpreadConstraints: spread}
+	c, err = cluster.provisionPodTemplateSpec(osdProps, v1.RestartPolicyOnFailure, dataPathMap)
+	assert.NoError(t, err)
+	assert.Equal(t, spread, c.Spec.TopologySpreadConstraints)
+	assert.Empty(t, c.Spec.Tolerations)
 }
 
 func TestDaemonset(t *testing.T) {
@@ -91,6 +133,8 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	c := New(clusterInfo, &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}, "ns", "rook/rook:myversion", cephVersion,
 		storageSpec, driveGroups, dataDir, rookv1.Placement{}, rookv1.Annotations{}, cephv1.NetworkSpec{}, v1.ResourceRequirements{}, v1.ResourceRequirements{}, "my-priority-class", metav1.OwnerReference{}, false, false, cephv1.CephClusterHealthCheckSpec{})
 
+	c.SetLabels(rookv1.Labels{"my-label": "value"})
+
 	devMountNeeded := deviceName != "" || allDevices
 
 	n := c.DesiredStorage.ResolveNode(storageSpec.Nodes[0].Name)
@@ -141,6 +185,9 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.Equal(t, AppName, deployment.Spec.Template.ObjectMeta.Labels["app"])
 	assert.Equal(t, c.Namespace, deployment.Spec.Template.ObjectMeta.Labels["rook_cluster"])
 	assert.Equal(t, 0, len(deployment.Spec.Template.ObjectMeta.Annotations))
+	assert.Equal(t, "value", deployment.Labels["my-label"])
+	assert.Equal(t, "value", deployment.Spec.Template.ObjectMeta.Labels["my-label"])
+	assert.NotContains(t, deployment.Spec.Selector.MatchLabels, "my-label")
 
 	assert.Equal(t, 2, len(deployment.Spec.Template.Spec.InitContainers))
 	initCont := deployment.Spec.Template.Spec.InitContainers[0]
@@ -223,6 +270,24 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.Equal(t, 1, len(blkInitCont.VolumeDevices))
 	blkMetaInitCont := deployment.Spec.Template.Spec.InitContainers[2]
 	assert.Equal(t, 1, len(blkMetaInitCont.VolumeDevices))
+
+	// Test OSD on PVC with RAW, metadata and wal devices
+	osdProp.walPVC = v1.PersistentVolumeClaimVolumeSource{ClaimName: "mypvc-wal"}
+	deployment, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.Nil(t, err)
+	assert.NotNil(t, deployment)
+	assert.Equal(t, 6, len(deployment.Spec.Template.Spec.InitContainers))
+	assert.Equal(t, "blkdevmapper", deployment.Spec.Template.Spec.InitContainers[0].Name)
+	assert.Equal(t, "blkdevmapper-metadata", deployment.Spec.Template.Spec.InitContainers[1].Name)
+	assert.Equal(t, "blkdevmapper-wal", deployment.Spec.Template.Spec.InitContainers[2].Name)
+	assert.Equal(t, "activate", deployment.Spec.Template.Spec.InitContainers[3].Name)
+	blkWalInitCont := deployment.Spec.Template.Spec.InitContainers[2]
+	assert.Equal(t, 1, len(blkWalInitCont.VolumeDevices))
+	assert.Equal(t, "/var/lib/ceph/osd/ceph-0/block.wal", blkWalInitCont.Args[2])
+	volumes := getPVCOSDVolumes(&osdProp)
+	assert.Equal(t, 6, len(volumes))
+	assert.Equal(t, "mypvc-wal", volumes[4].Name)
+	assert.Equal(t, "mypvc-wal-bridge", volumes[5].Name)
 }
 
 func verifyEnvVar(t *testing.T, envVars []v1.EnvVar, expectedName, expectedValue string, expectedFound bool) {
@@ -248,7 +313,7 @@ func TestStorageSpecConfig(t *testing.T) {
 					"walSizeMB":      "20",
 					"metadataDevice": "nvme093",
 				},
-				Selection: rookv1.Selection{},
+				Selection: rookv1.Selection{MinSize: "100Gi", DeviceType: rookv1.DeviceTypeSSD, DeviceClass: "nvme-meta"},
 				Resources: v1.ResourceRequirements{
 					Limits: v1.ResourceList{
 						v1.ResourceCPU:    *resource.NewQuantity(1024.0, resource.BinarySI),
@@ -276,12 +341,13 @@ func TestStorageSpecConfig(t *testing.T) {
 	metadataDevice := config.MetadataDevice(storageSpec.Nodes[0].Config)
 
 	osdProp := osdProperties{
-		crushHostname:  n.Name,
-		devices:        n.Devices,
-		selection:      n.Selection,
-		resources:      c.DesiredStorage.Nodes[0].Resources,
-		storeConfig:    storeConfig,
-		metadataDevice: metadataDevice,
+		crushHostname:    n.Name,
+		devices:          n.Devices,
+		selection:        n.Selection,
+		resources:        c.DesiredStorage.Nodes[0].Resources,
+		storeConfig:      storeConfig,
+		metadataDevice:   metadataDevice,
+		crushDeviceClass: nodeDeviceClass(n),
 	}
 
 	dataPathMap := &provisionConfig{
@@ -299,6 +365,20 @@ func TestStorageSpecConfig(t *testing.T) {
 	verifyEnvVar(t, container.Env, "ROOK_OSD_DATABASE_SIZE", "10", true)
 	verifyEnvVar(t, container.Env, "ROOK_OSD_WAL_SIZE", "20", true)
 	verifyEnvVar(t, container.Env, "ROOK_METADATA_DEVICE", "nvme093", true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_MIN_SIZE", "100Gi", true)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_MAX_SIZE", "", false)
+	verifyEnvVar(t, container.Env, "ROOK_DATA_DEVICE_TYPE", "ssd", true)
+	verifyEnvVar(t, container.Env, CrushDeviceClassVarName, "nvme-meta", true)
+
+	// the class of each device of the list, the device taking precedence over its config and over the node
+	osdProp.devices = []rookv1.Device{
+		{Name: "sdb", DeviceClass: "ssd", Config: map[string]string{config.DeviceClassKey: "hdd"}},
+		{Name: "sdc", Config: map[string]string{config.DeviceClassKey: "hdd"}},
+		{Name: "sdd"},
+	}
+	job, err = c.makeJob(osdProp, dataPathMap)
+	assert.Nil(t, err)
+	verifyEnvVar(t, job.Spec.Template.Spec.Containers[0].Env, "ROOK_DATA_DEVICES", "sdb:1::ssd::,sdc:1::hdd::,sdd:1::nvme-meta::", true)
 }
 
 func TestHostNetwork(t *testing.T) {
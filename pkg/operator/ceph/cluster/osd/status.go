@@ -25,6 +25,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,6 +49,8 @@ const (
 type provisionConfig struct {
 	errorMessages []string
 	DataPathMap   *config.DataPathMap // location to store data in container
+	prepareJobs   []prepareJob        // prepare jobs waiting to be started by batches
+	osdUpdates    []*apps.Deployment  // osd deployments waiting to be updated by waves
 }
 
 func (c *Cluster) newProvisionConfig() *provisionConfig {
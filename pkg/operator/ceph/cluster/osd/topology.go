@@ -20,6 +20,7 @@ limitations under the License.
 package osd
 
 import (
+	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 )
@@ -39,7 +40,18 @@ var (
 
 // ExtractTopologyFromLabels extracts rook topology from labels and returns a map from topology type to value
 func ExtractOSDTopologyFromLabels(labels map[string]string) map[string]string {
+	return ExtractOSDTopologyFromLabelsWithMapping(labels, nil)
+}
+
+// ExtractOSDTopologyFromLabelsWithMapping extracts rook topology from labels, the labels of the topology
+// mapping overriding the standard topology labels of their CRUSH bucket type
+func ExtractOSDTopologyFromLabelsWithMapping(labels map[string]string, topologyMapping map[string]string) map[string]string {
 	topology := k8sutil.ExtractTopologyFromLabels(labels, CRUSHTopologyLabels)
+	for topologyType, label := range topologyMapping {
+		if value, ok := labels[label]; ok && value != "" {
+			topology[topologyType] = value
+		}
+	}
 
 	// Ensure the topology names are normalized for CRUSH
 	for name, value := range topology {
@@ -47,3 +59,29 @@ func ExtractOSDTopologyFromLabels(labels map[string]string) map[string]string {
 	}
 	return topology
 }
+
+// ValidateTopologyMapping checks that the topology mapping only maps the supported CRUSH bucket types
+// above the host to node labels
+func ValidateTopologyMapping(topologyMapping map[string]string) error {
+	for topologyType, label := range topologyMapping {
+		if topologyType == "host" {
+			return errors.New("the host of the osds cannot be mapped to a node label")
+		}
+		if !isCRUSHMapLevel(topologyType) {
+			return errors.Errorf("unsupported CRUSH bucket type %q in topology mapping, supported types are %v", topologyType, CRUSHMapLevelsOrdered[1:])
+		}
+		if label == "" {
+			return errors.Errorf("no node label mapped to CRUSH bucket type %q", topologyType)
+		}
+	}
+	return nil
+}
+
+func isCRUSHMapLevel(topologyType string) bool {
+	for _, level := range CRUSHMapLevelsOrdered {
+		if level == topologyType {
+			return true
+		}
+	}
+	return false
+}
@@ -57,3 +57,29 @@ func TestTopologyLabels(t *testing.T) {
 	assert.Equal(t, "r-row", topology["row"])
 	assert.Equal(t, "d-datacenter", topology["datacenter"])
 }
+
+func TestTopologyMapping(t *testing.T) {
+	nodeLabels := map[string]string{
+		"topology.kubernetes.io/zone": "zone1",
+		"topology.rook.io/rack":       "rack1",
+		"example.com/rack":            "my.rack",
+		"example.com/zone":            "",
+	}
+	topologyMapping := map[string]string{
+		"rack": "example.com/rack",
+		"zone": "example.com/zone",
+		"room": "example.com/room",
+	}
+	topology := ExtractOSDTopologyFromLabelsWithMapping(nodeLabels, topologyMapping)
+	assert.Equal(t, 2, len(topology))
+	assert.Equal(t, "my-rack", topology["rack"])
+	assert.Equal(t, "zone1", topology["zone"])
+}
+
+func TestValidateTopologyMapping(t *testing.T) {
+	assert.NoError(t, ValidateTopologyMapping(nil))
+	assert.NoError(t, ValidateTopologyMapping(map[string]string{"rack": "example.com/rack", "region": "example.com/region"}))
+	assert.Error(t, ValidateTopologyMapping(map[string]string{"host": "example.com/host"}))
+	assert.Error(t, ValidateTopologyMapping(map[string]string{"shelf": "example.com/shelf"}))
+	assert.Error(t, ValidateTopologyMapping(map[string]string{"rack": ""}))
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultWaveFailureDomain = "host"
+	defaultWaveTimeout       = 10 * time.Minute
+)
+
+// waveCheckInterval is how often the osds of a wave and the PGs are checked, overridden by the unit tests
+var waveCheckInterval = 10 * time.Second
+
+func (c *Cluster) updateByWaves() bool {
+	return c.updateStrategy != nil && c.updateStrategy.Type == cephv1.UpdateStrategyWaves
+}
+
+// updateOSDsByWaves updates the changed osd deployments by waves of failure domains. Before each wave the PGs must be
+// clean, and the osds of a wave must be ready before the next wave, so that only the PGs of the failure domains of a
+// wave are degraded at once.
+func (c *Cluster) updateOSDsByWaves(config *provisionConfig) {
+	updates := config.osdUpdates
+	config.osdUpdates = nil
+	if len(updates) == 0 {
+		return
+	}
+
+	changed := []*apps.Deployment{}
+	for _, d := range updates {
+		current, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(d.Name, metav1.GetOptions{})
+		if err != nil {
+			config.addError("failed to get osd deployment %q. %v", d.Name, err)
+			continue
+		}
+		patchResult, err := patch.DefaultPatchMaker.Calculate(current, d)
+		if err != nil {
+			config.addError("failed to calculate the changes of osd deployment %q. %v", d.Name, err)
+			continue
+		}
+		if patchResult.IsEmpty() {
+			logger.Debugf("deployment %q did not change, nothing to update", d.Name)
+			continue
+		}
+		changed = append(changed, d)
+	}
+	if len(changed) == 0 {
+		return
+	}
+
+	failureDomain := c.updateStrategy.FailureDomain
+	if failureDomain == "" {
+		failureDomain = defaultWaveFailureDomain
+	}
+	timeout := defaultWaveTimeout
+	if c.updateStrategy.WaveTimeout != "" {
+		if t, err := time.ParseDuration(c.updateStrategy.WaveTimeout); err == nil {
+			timeout = t
+		}
+	}
+	waves := osdWaves(changed, failureDomain, c.updateStrategy.WaveSize)
+	logger.Infof("updating %d osd deployments in %d waves of %s failure domains", len(changed), len(waves), failureDomain)
+
+	for i, wave := range waves {
+		if err := c.waitForCleanPGs(timeout); err != nil {
+			if !c.continueUpgradeAfterChecksEvenIfNotHealthy {
+				config.addError("stopped the osd updates before wave %d/%d. %v", i+1, len(waves), err)
+				return
+			}
+			logger.Warningf("starting wave %d/%d of the osd updates since 'continueUpgradeAfterChecksEvenIfNotHealthy' is true. %v", i+1, len(waves), err)
+		}
+
+		logger.Infof("updating wave %d/%d of %d osd deployments", i+1, len(waves), len(wave))
+		updated := []*apps.Deployment{}
+		for _, d := range wave {
+			u, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(d)
+			if err != nil {
+				config.addError("failed to update osd deployment %q. %v", d.Name, err)
+				continue
+			}
+			updated = append(updated, u)
+		}
+		for _, d := range updated {
+			if err := c.waitForOSDDeployment(d, timeout); err != nil {
+				config.addError("stopped the osd updates after wave %d/%d. %v", i+1, len(waves), err)
+				return
+			}
+		}
+	}
+	logger.Infof("finished updating %d osd deployments by waves", len(changed))
+}
+
+// osdWaves groups the osd deployments by the failure domain of their CRUSH location, the waves holding the osds of
+// waveSize failure domains. The osds without the failure domain in their location are a failure domain of their own.
+func osdWaves(deployments []*apps.Deployment, failureDomain string, waveSize int) [][]*apps.Deployment {
+	if waveSize <= 0 {
+		waveSize = 1
+	}
+	domains := map[string][]*apps.Deployment{}
+	for _, d := range deployments {
+		domain := "osd:" + d.Name
+		if len(d.Spec.Template.Spec.Containers) > 0 {
+			location, _ := getLocationFromArgs(d.Spec.Template.Spec.Containers[0].Args)
+			for _, pair := range strings.Fields(location) {
+				if strings.HasPrefix(pair, failureDomain+"=") {
+					domain = pair
+				}
+			}
+		}
+		domains[domain] = append(domains[domain], d)
+	}
+
+	names := []string{}
+	for name := range domains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	waves := [][]*apps.Deployment{}
+	for i := 0; i < len(names); i += waveSize {
+		wave := []*apps.Deployment{}
+		for j := i; j < i+waveSize && j < len(names); j++ {
+			wave = append(wave, domains[names[j]]...)
+		}
+		waves = append(waves, wave)
+	}
+	return waves
+}
+
+// waitForCleanPGs waits for all the PGs of the cluster to be clean
+func (c *Cluster) waitForCleanPGs(timeout time.Duration) error {
+	msg := ""
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(waveCheckInterval) {
+		var clean bool
+		var err error
+		msg, clean, err = client.IsClusterClean(c.context, c.clusterInfo.Name)
+		if err != nil {
+			logger.Warningf("failed to check the PGs are clean. %v", err)
+			continue
+		}
+		if clean {
+			return nil
+		}
+		logger.Infof("waiting for the PGs to be clean before the next wave of osd updates. %s", msg)
+	}
+	return errors.Errorf("the PGs were not clean after %s. %s", timeout.String(), msg)
+}
+
+// waitForOSDDeployment waits for the pods of an updated osd deployment to be ready
+func (c *Cluster) waitForOSDDeployment(updated *apps.Deployment, timeout time.Duration) error {
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(waveCheckInterval) {
+		d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(updated.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get osd deployment %q", updated.Name)
+		}
+		replicas := int32(1)
+		if d.Spec.Replicas != nil {
+			replicas = *d.Spec.Replicas
+		}
+		if d.Status.ObservedGeneration >= updated.Generation && d.Status.UpdatedReplicas == replicas && d.Status.ReadyReplicas == replicas {
+			logger.Infof("finished waiting for updated deployment %q", d.Name)
+			return nil
+		}
+		logger.Debugf("deployment %q status=%+v", d.Name, d.Status)
+	}
+	return errors.Errorf("gave up waiting for deployment %q to update", updated.Name)
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newWaveDeployment(name, location string) *apps.Deployment {
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ns"}}
+	container := v1.Container{Name: "osd"}
+	if location != "" {
+		container.Args = []string{"--foreground", "--crush-location=" + location}
+	}
+	d.Spec.Template.Spec.Containers = []v1.Container{container}
+	return d
+}
+
+func TestOSDWaves(t *testing.T) {
+	deployments := []*apps.Deployment{
+		newWaveDeployment("rook-ceph-osd-0", "root=default rack=r1 host=node1"),
+		newWaveDeployment("rook-ceph-osd-1", "root=default rack=r1 host=node2"),
+		newWaveDeployment("rook-ceph-osd-2", "root=default rack=r2 host=node3"),
+		newWaveDeployment("rook-ceph-osd-3", "root=default rack=r2 host=node3"),
+		newWaveDeployment("rook-ceph-osd-4", ""),
+	}
+	names := func(wave []*apps.Deployment) []string {
+		n := []string{}
+		for _, d := range wave {
+			n = append(n, d.Name)
+		}
+		return n
+	}
+
+	// a wave by host, the osds without location being on their own
+	waves := osdWaves(deployments, "host", 0)
+	assert.Equal(t, 4, len(waves))
+	assert.Equal(t, []string{"rook-ceph-osd-0"}, names(waves[0]))
+	assert.Equal(t, []string{"rook-ceph-osd-2", "rook-ceph-osd-3"}, names(waves[2]))
+	assert.Equal(t, []string{"rook-ceph-osd-4"}, names(waves[3]))
+
+	// a wave by rack
+	waves = osdWaves(deployments, "rack", 1)
+	assert.Equal(t, 3, len(waves))
+	assert.Equal(t, []string{"rook-ceph-osd-0", "rook-ceph-osd-1"}, names(waves[0]))
+	assert.Equal(t, []string{"rook-ceph-osd-2", "rook-ceph-osd-3"}, names(waves[1]))
+
+	// two hosts by wave
+	waves = osdWaves(deployments, "host", 2)
+	assert.Equal(t, 2, len(waves))
+	assert.Equal(t, []string{"rook-ceph-osd-0", "rook-ceph-osd-1"}, names(waves[0]))
+	assert.Equal(t, []string{"rook-ceph-osd-2", "rook-ceph-osd-3", "rook-ceph-osd-4"}, names(waves[1]))
+}
+
+func TestWaitForOSDDeployment(t *testing.T) {
+	defer func(interval time.Duration) { waveCheckInterval = interval }(waveCheckInterval)
+	waveCheckInterval = time.Millisecond
+	clientset := fake.NewSimpleClientset()
+	c := &Cluster{context: &clusterd.Context{Clientset: clientset}, Namespace: "ns"}
+
+	d := newWaveDeployment("rook-ceph-osd-0", "")
+	d.Generation = 2
+	d.Status = apps.DeploymentStatus{ObservedGeneration: 1, UpdatedReplicas: 1, ReadyReplicas: 1}
+	_, err := clientset.AppsV1().Deployments("ns").Create(d)
+	assert.NoError(t, err)
+
+	// the new generation is not observed yet
+	assert.Error(t, c.waitForOSDDeployment(d, 10*time.Millisecond))
+
+	d.Status.ObservedGeneration = 2
+	_, err = clientset.AppsV1().Deployments("ns").Update(d)
+	assert.NoError(t, err)
+	assert.NoError(t, c.waitForOSDDeployment(d, 10*time.Millisecond))
+}
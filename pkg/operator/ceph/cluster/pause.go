@@ -0,0 +1,71 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+)
+
+// remediationDaemons are the daemons whose health checkers remediate the cluster, failing over the mons and removing
+// the osds, and are stopped while the cluster is paused
+var remediationDaemons = []string{"mon", "osd"}
+
+// isReconcilePaused returns whether the reconcile of the cluster is paused by its reconcile strategy or its annotation
+func isReconcilePaused(clusterSpec *cephv1.ClusterSpec, annotations map[string]string) bool {
+	return clusterSpec.ReconcileStrategy == cephv1.ReconcileStrategyPaused || annotations[opcontroller.PausedAnnotation] == "true"
+}
+
+// pauseCluster stops the health checkers remediating the cluster and reports the cluster as paused. The ceph status
+// keeps on being reported by the status health checker.
+func (c *ClusterController) pauseCluster(cephCluster *cephv1.CephCluster) {
+	logger.Infof("reconcile of cluster %q in namespace %q is paused", cephCluster.Name, cephCluster.Namespace)
+
+	if cluster, ok := c.getCluster(cephCluster.Namespace); ok && (cluster.uid == "" || cluster.uid == cephCluster.UID) {
+		cluster.monitoringMux.Lock()
+		cluster.Spec = &cephCluster.Spec
+		cluster.annotations = cephCluster.Annotations
+		c.configureCephMonitoringForDaemons(cluster, "", remediationDaemons)
+		cluster.monitoringMux.Unlock()
+	}
+
+	if !isConditionTrue(cephCluster, cephv1.ConditionPaused) {
+		config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionPaused, v1.ConditionTrue, "ReconcilePaused", "Reconcile and health remediation are paused")
+	}
+}
+
+// resumeCluster clears the paused condition of a cluster whose reconcile was paused, the reconcile then restarting
+// the health checkers
+func (c *ClusterController) resumeCluster(cephCluster *cephv1.CephCluster) {
+	if !isConditionTrue(cephCluster, cephv1.ConditionPaused) {
+		return
+	}
+
+	logger.Infof("reconcile of cluster %q in namespace %q is resumed", cephCluster.Name, cephCluster.Namespace)
+	config.ConditionExport(c.context, c.namespacedName, cephv1.ConditionPaused, v1.ConditionFalse, "ReconcileResumed", "Reconcile and health remediation are resumed")
+}
+
+func isConditionTrue(cephCluster *cephv1.CephCluster, conditionType cephv1.ConditionType) bool {
+	for _, condition := range cephCluster.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestIsReconcilePaused(t *testing.T) {
+	assert.False(t, isReconcilePaused(&cephv1.ClusterSpec{}, nil))
+	assert.True(t, isReconcilePaused(&cephv1.ClusterSpec{ReconcileStrategy: cephv1.ReconcileStrategyPaused}, nil))
+	assert.True(t, isReconcilePaused(&cephv1.ClusterSpec{}, map[string]string{"ceph.rook.io/paused": "true"}))
+	assert.False(t, isReconcilePaused(&cephv1.ClusterSpec{}, map[string]string{"ceph.rook.io/paused": "false"}))
+}
+
+func TestPauseCluster(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			return "", errors.New("no cluster")
+		},
+	}
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"}}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	client := fake.NewFakeClientWithScheme(s, cephCluster.DeepCopy())
+	clusterContext := &clusterd.Context{Executor: executor, Client: client}
+	nsName := types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}
+	c := &ClusterController{context: clusterContext, clusterMap: make(map[string]*cluster), namespacedName: nsName}
+	cluster := &cluster{
+		Namespace:          "rook-ceph",
+		context:            clusterContext,
+		Spec:               &cephv1.ClusterSpec{},
+		mons:               &mon.Cluster{Namespace: "rook-ceph"},
+		stopCh:             make(chan struct{}),
+		watchersActivated:  true,
+		monitoringChannels: make(map[string]*clusterHealth),
+	}
+	c.setCluster(cluster)
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.Equal(t, 3, c.ActiveMonitoringGoroutines())
+
+	// the mon and osd health checkers stop, the status is still checked
+	cephCluster.Annotations = map[string]string{"ceph.rook.io/paused": "true"}
+	c.pauseCluster(cephCluster)
+	waitForMonitoringGoroutines(t, c, 1)
+	assert.False(t, cluster.monitoringChannels["mon"].monitoringRunning)
+	assert.False(t, cluster.monitoringChannels["osd"].monitoringRunning)
+	assert.True(t, cluster.monitoringChannels["status"].monitoringRunning)
+
+	updated := &cephv1.CephCluster{}
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.Equal(t, cephv1.ConditionPaused, updated.Status.Phase)
+	assert.True(t, isConditionTrue(updated, cephv1.ConditionPaused))
+
+	// resuming clears the condition and the health checkers start again
+	updated.Annotations = nil
+	c.resumeCluster(updated)
+	assert.NoError(t, client.Get(context.TODO(), nsName, updated))
+	assert.False(t, isConditionTrue(updated, cephv1.ConditionPaused))
+	cluster.annotations = nil
+	c.configureCephMonitoring(cluster, "client.admin")
+	assert.Equal(t, 3, c.ActiveMonitoringGoroutines())
+
+	c.StopWatch()
+	assert.Equal(t, 0, c.ActiveMonitoringGoroutines())
+}
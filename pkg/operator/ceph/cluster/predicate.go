@@ -38,6 +38,9 @@ func predicateForNodeWatcher(client client.Client, context *clusterd.Context) pr
 
 		UpdateFunc: func(e event.UpdateEvent) bool {
 			clientCluster := newClientCluster(client, e.MetaNew.GetNamespace(), context)
+			if clientCluster.onK8sNodeTopologyUpdate(e.ObjectOld, e.ObjectNew) {
+				return true
+			}
 			return clientCluster.onK8sNode(e.ObjectNew)
 		},
 
@@ -73,7 +76,12 @@ func predicateForHotPlugCMWatcher(client client.Client) predicate.Funcs {
 		},
 
 		CreateFunc: func(e event.CreateEvent) bool {
-			return false
+			if !isHotPlugCM(e.Object) {
+				return false
+			}
+
+			clientCluster := newClientCluster(client, e.Meta.GetNamespace(), &clusterd.Context{})
+			return clientCluster.onDeviceCMCreate(e.Object)
 		},
 
 		GenericFunc: func(e event.GenericEvent) bool {
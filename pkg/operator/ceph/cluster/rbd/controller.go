@@ -74,6 +74,8 @@ type ReconcileCephRBDMirror struct {
 	context         *clusterd.Context
 	cephClusterSpec *cephv1.ClusterSpec
 	clusterInfo     *cephconfig.ClusterInfo
+	// peerSecretVersions are the resource versions of the peer secrets whose tokens were imported
+	peerSecretVersions map[types.NamespacedName]string
 }
 
 // Add creates a new cephRBDMirror Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -97,7 +99,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -108,6 +110,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch the peer secrets, which are not owned by the rbd mirror, to import their tokens again when they change
+	err = c.Watch(&source.Kind{Type: &v1.Secret{TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: v1.SchemeGroupVersion.String()}}}, enqueueByPeerSecret(mgr.GetClient()))
+	if err != nil {
+		return err
+	}
+
 	// Watch all other resources
 	for _, t := range objectsToWatch {
 		err = c.Watch(&source.Kind{Type: t}, &handler.EnqueueRequestForOwner{
@@ -127,10 +135,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephRBDMirror) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephRBDMirror", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -186,6 +196,12 @@ func (r *ReconcileCephRBDMirror) reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, errors.Wrap(err, "failed to create ceph rbd mirror deployments")
 	}
 
+	// Import the bootstrap peer tokens of the remote clusters
+	if err := r.reconcilePeers(cephRBDMirror); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.FailedStatus)
+		return reconcile.Result{}, errors.Wrap(err, "failed to add the rbd mirror peers")
+	}
+
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
 
@@ -197,3 +197,52 @@ func TestCephRBDMirrorController(t *testing.T) {
 	assert.Equal(t, "Ready", fs.Status.Phase, fs)
 	logger.Info("PHASE 3 DONE")
 }
+
+func TestReconcilePeers(t *testing.T) {
+	namespace := "rook-ceph"
+	imported := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command == "rbd" && args[0] == "mirror" && args[4] == "import" {
+				assert.Equal(t, "pool1", args[5])
+				imported++
+			}
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{Executor: executor, Clientset: test.New(t, 1)}
+	r := &ReconcileCephRBDMirror{context: c}
+
+	rbdMirror := &cephv1.CephRBDMirror{ObjectMeta: metav1.ObjectMeta{Name: "my-mirror", Namespace: namespace}}
+	rbdMirror.Spec.Peers.SecretNames = []string{"peer-secret"}
+
+	// a missing secret fails the reconcile
+	assert.Error(t, r.reconcilePeers(rbdMirror))
+
+	// a secret without a token fails the reconcile
+	peerSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "peer-secret", Namespace: namespace, ResourceVersion: "1"},
+		Data:       map[string][]byte{"pool": []byte("pool1")},
+	}
+	_, err := c.Clientset.CoreV1().Secrets(namespace).Create(peerSecret)
+	assert.NoError(t, err)
+	assert.Error(t, r.reconcilePeers(rbdMirror))
+	assert.Equal(t, 0, imported)
+
+	// the token is imported once
+	peerSecret.Data["token"] = []byte("token")
+	peerSecret.ResourceVersion = "2"
+	_, err = c.Clientset.CoreV1().Secrets(namespace).Update(peerSecret)
+	assert.NoError(t, err)
+	assert.NoError(t, r.reconcilePeers(rbdMirror))
+	assert.Equal(t, 1, imported)
+	assert.NoError(t, r.reconcilePeers(rbdMirror))
+	assert.Equal(t, 1, imported)
+
+	// the token is imported again once the secret changed
+	peerSecret.ResourceVersion = "3"
+	_, err = c.Clientset.CoreV1().Secrets(namespace).Update(peerSecret)
+	assert.NoError(t, err)
+	assert.NoError(t, r.reconcilePeers(rbdMirror))
+	assert.Equal(t, 2, imported)
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package rbd
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// peerTokenKey is the key of the bootstrap peer token in a peer secret, as in the secret created by the pool controller
+	peerTokenKey = "token"
+	// peerPoolKey is the key of the name of the pool of the bootstrap peer token in a peer secret
+	peerPoolKey = "pool"
+)
+
+// reconcilePeers imports the bootstrap peer tokens of the peer secrets of the rbd mirror, the tokens of a secret being
+// imported again only once the secret changed
+func (r *ReconcileCephRBDMirror) reconcilePeers(cephRBDMirror *cephv1.CephRBDMirror) error {
+	if r.peerSecretVersions == nil {
+		r.peerSecretVersions = map[types.NamespacedName]string{}
+	}
+
+	for _, secretName := range cephRBDMirror.Spec.Peers.SecretNames {
+		name := types.NamespacedName{Name: secretName, Namespace: cephRBDMirror.Namespace}
+		secret, err := r.context.Clientset.CoreV1().Secrets(name.Namespace).Get(name.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get peer secret %q", secretName)
+		}
+		if r.peerSecretVersions[name] == secret.ResourceVersion {
+			logger.Debugf("bootstrap peer token of secret %q already imported", secretName)
+			continue
+		}
+
+		token, ok := secret.Data[peerTokenKey]
+		if !ok || len(token) == 0 {
+			return errors.Errorf("peer secret %q has no %q key", secretName, peerTokenKey)
+		}
+		pool, ok := secret.Data[peerPoolKey]
+		if !ok || len(pool) == 0 {
+			return errors.Errorf("peer secret %q has no %q key", secretName, peerPoolKey)
+		}
+
+		if err := cephclient.ImportRBDMirrorBootstrapPeer(r.context, cephRBDMirror.Namespace, string(pool), token); err != nil {
+			return errors.Wrapf(err, "failed to import bootstrap peer token of secret %q", secretName)
+		}
+		r.peerSecretVersions[name] = secret.ResourceVersion
+		logger.Infof("imported bootstrap peer token of secret %q for pool %q", secretName, string(pool))
+	}
+
+	return nil
+}
+
+// enqueueByPeerSecret returns a handler enqueuing the rbd mirrors referencing a changed secret as a peer secret
+func enqueueByPeerSecret(c client.Client) handler.EventHandler {
+	return &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			rbdMirrors := &cephv1.CephRBDMirrorList{}
+			if err := c.List(context.TODO(), rbdMirrors, client.InNamespace(obj.Meta.GetNamespace())); err != nil {
+				logger.Errorf("failed to list rbd mirrors of secret %q. %v", obj.Meta.GetName(), err)
+				return []reconcile.Request{}
+			}
+
+			requests := []reconcile.Request{}
+			for _, rbdMirror := range rbdMirrors.Items {
+				for _, secretName := range rbdMirror.Spec.Peers.SecretNames {
+					if secretName == obj.Meta.GetName() {
+						name := types.NamespacedName{Name: rbdMirror.Name, Namespace: rbdMirror.Namespace}
+						requests = append(requests, reconcile.Request{NamespacedName: name})
+						break
+					}
+				}
+			}
+			return requests
+		}),
+	}
+}
@@ -46,13 +46,19 @@ func (r *ReconcileCephRBDMirror) makeDeployment(daemonConfig *daemonConfig, rbdM
 			PriorityClassName: rbdMirror.Spec.PriorityClassName,
 		},
 	}
+	if r.cephClusterSpec.LogCollector.Enabled {
+		podSpec.Spec.Containers = append(podSpec.Spec.Containers,
+			controller.LogCollectorContainer(fullDaemonName(daemonConfig.DaemonID), r.cephClusterSpec.CephVersion.Image, r.cephClusterSpec.LogCollector, mon.PodSecurityContext()))
+	}
+
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
+	k8sutil.ApplyImagePullSecrets(rbdMirror.Namespace, &podSpec.Spec)
 
 	if r.cephClusterSpec.Network.IsHost() {
 		podSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if r.cephClusterSpec.Network.IsMultus() {
-		k8sutil.ApplyMultus(r.cephClusterSpec.Network.NetworkSpec, &podSpec.ObjectMeta)
+		config.ApplyPublicNetwork(r.cephClusterSpec.Network.NetworkSpec, &podSpec.ObjectMeta)
 	}
 	rbdMirror.Spec.Placement.ApplyToPodSpec(&podSpec.Spec)
 
@@ -19,21 +19,30 @@ package cluster
 import (
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/drift"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/clusterdisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinedisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinelabel"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/nodedrain"
 	"github.com/rook/rook/pkg/operator/ceph/file"
+	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
+	"github.com/rook/rook/pkg/operator/ceph/file/subvolumegroup"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
 	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/ceph/object/cosi"
+	"github.com/rook/rook/pkg/operator/ceph/object/notification"
 	"github.com/rook/rook/pkg/operator/ceph/object/realm"
+	"github.com/rook/rook/pkg/operator/ceph/object/topic"
 	objectuser "github.com/rook/rook/pkg/operator/ceph/object/user"
 	"github.com/rook/rook/pkg/operator/ceph/object/zone"
 	"github.com/rook/rook/pkg/operator/ceph/object/zonegroup"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/ceph/pool/radosnamespace"
 
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -58,15 +67,22 @@ var MachineDisruptionBudgetAddToManagerFuncs = []func(manager.Manager, *controll
 // AddToManagerFuncs is a list of functions to add all Controllers to the Manager (entrypoint for controller)
 var AddToManagerFuncs = []func(manager.Manager, *clusterd.Context) error{
 	crash.Add,
+	drift.Add,
 	pool.Add,
+	radosnamespace.Add,
 	objectuser.Add,
 	realm.Add,
 	zonegroup.Add,
 	zone.Add,
 	object.Add,
+	topic.Add,
+	notification.Add,
+	cosi.Add,
 	file.Add,
+	subvolumegroup.Add,
 	nfs.Add,
 	rbd.Add,
+	mirror.Add,
 }
 
 // AddToManager adds all the registered controllers to the passed manager.
@@ -77,6 +93,11 @@ func AddToManager(m manager.Manager, c *controllerconfig.Context, clusterControl
 		return errors.New("nil context passed")
 	}
 
+	// The rate limiting of the reconciles applies to all the controllers
+	opcontroller.SetReconcileSettings(opcontroller.LoadReconcileSettings(c.ClusterdContext.Clientset))
+	// The timeout and the circuit breaker of the ceph commands apply to the controllers and the health checkers
+	cephclient.SetCommandSettings(opcontroller.LoadCommandSettings(c.ClusterdContext.Clientset))
+
 	// Run CephCluster CR
 	if err := Add(m, c.ClusterdContext, clusterController); err != nil {
 		return err
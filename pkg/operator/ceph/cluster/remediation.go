@@ -0,0 +1,325 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// defaultRemediationInterval is the minimum duration between two remediations of a same target
+	defaultRemediationInterval = time.Hour
+	// healthRemediatedReason is the event reason emitted for each automatic remediation
+	healthRemediatedReason = "HealthRemediated"
+	// healthRemediationFailedReason is the event reason emitted when an automatic remediation fails
+	healthRemediationFailedReason = "HealthRemediationFailed"
+
+	pgDamagedCheck         = "PG_DAMAGED"
+	recentCrashCheck       = "RECENT_CRASH"
+	poolAppNotEnabledCheck = "POOL_APP_NOT_ENABLED"
+
+	// remediationStateKey is the key of the state of the remediations in the health state configmap
+	remediationStateKey = "status"
+)
+
+var (
+	// inconsistentPGRegex matches the PG_DAMAGED detail of an inconsistent PG, e.g.
+	// "pg 2.5 is active+clean+inconsistent, acting [1,0,2]"
+	inconsistentPGRegex = regexp.MustCompile(`^pg (\S+) is \S*inconsistent`)
+	// poolAppNotEnabledRegex matches the POOL_APP_NOT_ENABLED detail of a pool, e.g.
+	// "application not enabled on pool 'replicapool'"
+	poolAppNotEnabledRegex = regexp.MustCompile(`application not enabled on pool '([^']+)'`)
+
+	// restartableCrashedDaemons are the daemon types whose pods are restarted after crashing, named by the label of
+	// their pods holding the daemon id
+	restartableCrashedDaemons = []string{"mon", "mgr", "osd", "mds"}
+)
+
+// loadRemediationConfig sets the health warnings handled automatically from the health check spec of the cluster
+func (c *cephStatusChecker) loadRemediationConfig(remediation cephv1.HealthRemediationSpec) {
+	c.remediation = remediation
+	c.remediationInterval = defaultRemediationInterval
+	if remediation.MinInterval != "" {
+		if interval, err := time.ParseDuration(remediation.MinInterval); err == nil && interval > 0 {
+			c.remediationInterval = interval
+		} else {
+			c.logger.Warningf("invalid health remediation interval %q, using the default of %s", remediation.MinInterval, c.remediationInterval.String())
+		}
+	}
+	if c.lastRemediations == nil {
+		c.lastRemediations = map[string]time.Time{}
+	}
+}
+
+// remediateHealth handles the health warnings selected in the remediation settings of the cluster
+// Each PG, daemon or pool is remediated at most once per remediation interval.
+func (c *cephStatusChecker) remediateHealth(cephCluster *cephv1.CephCluster, health *cephclient.HealthStatus) {
+	if health == nil {
+		return
+	}
+	if c.remediation.RepairInconsistentPGs {
+		if check, ok := health.Checks[pgDamagedCheck]; ok {
+			c.repairInconsistentPGs(cephCluster, check)
+		}
+	}
+	if c.remediation.RestartCrashedDaemons > 0 {
+		if _, ok := health.Checks[recentCrashCheck]; ok {
+			c.restartCrashedDaemons(cephCluster)
+		}
+	}
+	if c.remediation.EnablePoolApplications {
+		if check, ok := health.Checks[poolAppNotEnabledCheck]; ok {
+			c.enablePoolApplications(cephCluster, check)
+		}
+	}
+}
+
+// allowRemediation returns whether the target was not remediated within the remediation interval, and records the
+// remediation if allowed
+func (c *cephStatusChecker) allowRemediation(target string) bool {
+	now := time.Now()
+	if last, ok := c.lastRemediations[target]; ok && now.Sub(last) < c.remediationInterval {
+		c.logger.Debugf("skipping remediation of %s, last remediated at %s", target, last.String())
+		return false
+	}
+	c.lastRemediations[target] = now
+	return true
+}
+
+// remediationState is the state of the health remediations kept across the restarts of the operator
+type remediationState struct {
+	// LastRemediations are the times each PG, daemon or pool was last remediated
+	LastRemediations map[string]time.Time `json:"lastRemediations,omitempty"`
+}
+
+// loadRemediationState restores the remediations done before the restart of the operator, so that a PG, daemon or pool
+// is still remediated at most once per remediation interval
+func (c *cephStatusChecker) loadRemediationState() {
+	state := remediationState{}
+	if err := opcontroller.LoadHealthState(c.context.Clientset, c.namespacedName.Namespace, remediationStateKey, &state); err != nil {
+		c.logger.Warningf("failed to load the health remediation state of cluster %q. %v", c.namespacedName.Namespace, err)
+		return
+	}
+	for target, last := range state.LastRemediations {
+		if _, ok := c.lastRemediations[target]; !ok {
+			c.lastRemediations[target] = last
+		}
+	}
+	c.savedRemediations = state
+}
+
+// saveRemediationState saves the remediations still within the remediation interval when they changed
+func (c *cephStatusChecker) saveRemediationState() {
+	state := remediationState{}
+	now := time.Now()
+	for target, last := range c.lastRemediations {
+		if now.Sub(last) >= c.remediationInterval {
+			delete(c.lastRemediations, target)
+			continue
+		}
+		if state.LastRemediations == nil {
+			state.LastRemediations = map[string]time.Time{}
+		}
+		state.LastRemediations[target] = last
+	}
+	if reflect.DeepEqual(state, c.savedRemediations) {
+		return
+	}
+	if err := opcontroller.SaveHealthState(c.context.Clientset, c.namespacedName.Namespace, remediationStateKey, state, c.ownerRef); err != nil {
+		c.logger.Warningf("failed to save the health remediation state of cluster %q. %v", c.namespacedName.Namespace, err)
+		return
+	}
+	c.savedRemediations = state
+}
+
+// reportRemediation logs and emits an event for a remediation, as a warning if it failed
+func (c *cephStatusChecker) reportRemediation(cephCluster *cephv1.CephCluster, message string, err error) {
+	if err != nil {
+		c.logger.Errorf("failed to %s in cluster %q. %v", message, c.namespacedName.Namespace, err)
+		if c.recorder != nil {
+			c.recorder.Eventf(cephCluster, v1.EventTypeWarning, healthRemediationFailedReason, "failed to %s: %v", message, err)
+		}
+		return
+	}
+	c.logger.Infof("health remediation: %s in cluster %q", message, c.namespacedName.Namespace)
+	if c.recorder != nil {
+		c.recorder.Event(cephCluster, v1.EventTypeNormal, healthRemediatedReason, message)
+	}
+}
+
+// repairInconsistentPGs repairs the inconsistent PGs listed in the PG_DAMAGED health detail
+func (c *cephStatusChecker) repairInconsistentPGs(cephCluster *cephv1.CephCluster, check cephclient.CheckMessage) {
+	for _, detail := range check.Detail {
+		match := inconsistentPGRegex.FindStringSubmatch(detail.Message)
+		if match == nil || !c.allowRemediation("pg/"+match[1]) {
+			continue
+		}
+		err := cephclient.RepairPG(c.context, c.namespacedName.Namespace, match[1])
+		c.reportRemediation(cephCluster, fmt.Sprintf("repair inconsistent pg %s", match[1]), err)
+	}
+}
+
+// restartCrashedDaemons restarts the pod of each daemon with at least the configured number of new crashes, and
+// archives its crashes so that they are not counted again
+func (c *cephStatusChecker) restartCrashedDaemons(cephCluster *cephv1.CephCluster) {
+	crashes, err := cephclient.GetNewCrashes(c.context, c.namespacedName.Namespace)
+	if err != nil {
+		c.logger.Errorf("failed to get the crashes of cluster %q. %v", c.namespacedName.Namespace, err)
+		return
+	}
+
+	crashesByDaemon := map[string][]string{}
+	for _, crash := range crashes {
+		crashesByDaemon[crash.Entity] = append(crashesByDaemon[crash.Entity], crash.ID)
+	}
+	daemons := make([]string, 0, len(crashesByDaemon))
+	for daemon := range crashesByDaemon {
+		daemons = append(daemons, daemon)
+	}
+	sort.Strings(daemons)
+
+	for _, daemon := range daemons {
+		crashIDs := crashesByDaemon[daemon]
+		if len(crashIDs) < c.remediation.RestartCrashedDaemons {
+			continue
+		}
+		daemonType, daemonID, ok := splitCrashedDaemon(daemon)
+		if !ok || !c.allowRemediation("daemon/"+daemon) {
+			continue
+		}
+		err := c.restartDaemonPods(daemonType, daemonID)
+		if err == nil {
+			for _, crashID := range crashIDs {
+				if archiveErr := cephclient.ArchiveCrash(c.context, c.namespacedName.Namespace, crashID); archiveErr != nil {
+					c.logger.Warningf("failed to archive crash %q of %s. %v", crashID, daemon, archiveErr)
+				}
+			}
+		}
+		c.reportRemediation(cephCluster, fmt.Sprintf("restart %s after %d crashes", daemon, len(crashIDs)), err)
+	}
+}
+
+// splitCrashedDaemon returns the type and the id of a crashed daemon such as "osd.1", if its pods can be restarted
+func splitCrashedDaemon(daemon string) (string, string, bool) {
+	parts := strings.SplitN(daemon, ".", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", "", false
+	}
+	for _, daemonType := range restartableCrashedDaemons {
+		if parts[0] == daemonType {
+			return parts[0], parts[1], true
+		}
+	}
+	return "", "", false
+}
+
+// restartDaemonPods deletes the pods of a daemon, its deployment starting a new pod
+func (c *cephStatusChecker) restartDaemonPods(daemonType, daemonID string) error {
+	selector := fmt.Sprintf("%s=%s", daemonType, daemonID)
+	pods, err := c.context.Clientset.CoreV1().Pods(c.namespacedName.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pods of %s.%s", daemonType, daemonID)
+	}
+	if len(pods.Items) == 0 {
+		return errors.Errorf("no pod found for %s.%s", daemonType, daemonID)
+	}
+	for _, pod := range pods.Items {
+		if err := c.context.Clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to delete pod %q", pod.Name)
+		}
+	}
+	return nil
+}
+
+// enablePoolApplications tags the pools listed in the POOL_APP_NOT_ENABLED health detail with the application of
+// the Ceph CR they belong to. The pools not created by a Ceph CR are left for the admin to tag.
+func (c *cephStatusChecker) enablePoolApplications(cephCluster *cephv1.CephCluster, check cephclient.CheckMessage) {
+	for _, detail := range check.Detail {
+		match := poolAppNotEnabledRegex.FindStringSubmatch(detail.Message)
+		if match == nil {
+			continue
+		}
+		pool := match[1]
+		application, err := c.poolApplication(pool)
+		if err != nil {
+			c.logger.Errorf("failed to find the application of pool %q. %v", pool, err)
+			continue
+		}
+		if application == "" {
+			c.logger.Debugf("pool %q does not belong to a Ceph CR, not tagging its application", pool)
+			continue
+		}
+		if !c.allowRemediation("pool/" + pool) {
+			continue
+		}
+		err = cephclient.EnablePoolApplication(c.context, c.namespacedName.Namespace, pool, application)
+		c.reportRemediation(cephCluster, fmt.Sprintf("enable application %s on pool %s", application, pool), err)
+	}
+}
+
+// poolApplication returns the application of a pool from the Ceph CR it belongs to: rbd for a CephBlockPool, cephfs
+// for the pools of a CephFilesystem and rgw for the pools of a CephObjectStore
+func (c *cephStatusChecker) poolApplication(pool string) (string, error) {
+	ctx := context.TODO()
+	inNamespace := client.InNamespace(c.namespacedName.Namespace)
+
+	blockPools := &cephv1.CephBlockPoolList{}
+	if err := c.client.List(ctx, blockPools, inNamespace); err != nil {
+		return "", errors.Wrap(err, "failed to list the CephBlockPools")
+	}
+	for _, blockPool := range blockPools.Items {
+		if blockPool.Name == pool {
+			return "rbd", nil
+		}
+	}
+
+	filesystems := &cephv1.CephFilesystemList{}
+	if err := c.client.List(ctx, filesystems, inNamespace); err != nil {
+		return "", errors.Wrap(err, "failed to list the CephFilesystems")
+	}
+	for _, filesystem := range filesystems.Items {
+		if strings.HasPrefix(pool, filesystem.Name+"-") {
+			return "cephfs", nil
+		}
+	}
+
+	objectStores := &cephv1.CephObjectStoreList{}
+	if err := c.client.List(ctx, objectStores, inNamespace); err != nil {
+		return "", errors.Wrap(err, "failed to list the CephObjectStores")
+	}
+	for _, objectStore := range objectStores.Items {
+		if pool == ".rgw.root" || strings.HasPrefix(pool, objectStore.Name+".") {
+			return "rgw", nil
+		}
+	}
+
+	return "", nil
+}
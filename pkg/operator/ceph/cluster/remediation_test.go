@@ -0,0 +1,146 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSplitCrashedDaemon(t *testing.T) {
+	daemonType, daemonID, ok := splitCrashedDaemon("osd.1")
+	assert.True(t, ok)
+	assert.Equal(t, "osd", daemonType)
+	assert.Equal(t, "1", daemonID)
+
+	_, daemonID, ok = splitCrashedDaemon("mds.myfs-a")
+	assert.True(t, ok)
+	assert.Equal(t, "myfs-a", daemonID)
+
+	_, _, ok = splitCrashedDaemon("client.rgw.my.store.a")
+	assert.False(t, ok)
+	_, _, ok = splitCrashedDaemon("osd.")
+	assert.False(t, ok)
+}
+
+func TestRemediateHealth(t *testing.T) {
+	healthDetail := `{"status":"HEALTH_ERR","checks":{
+		"PG_DAMAGED":{"severity":"HEALTH_ERR","summary":{"message":"Possible data damage: 1 pg inconsistent"},"detail":[{"message":"pg 2.5 is active+clean+inconsistent, acting [1,0,2]"}]},
+		"RECENT_CRASH":{"severity":"HEALTH_WARN","summary":{"message":"2 daemons have recently crashed"}},
+		"POOL_APP_NOT_ENABLED":{"severity":"HEALTH_WARN","summary":{"message":"application not enabled on 2 pool(s)"},"detail":[
+			{"message":"application not enabled on pool 'replicapool'"},{"message":"application not enabled on pool 'manual'"}]}}}`
+	var health cephclient.HealthStatus
+	assert.NoError(t, json.Unmarshal([]byte(healthDetail), &health))
+
+	commands := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			cmd := []string{}
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				cmd = append(cmd, arg)
+			}
+			commands = append(commands, strings.Join(cmd, " "))
+			if args[0] == "crash" && args[1] == "ls-new" {
+				return `[{"crash_id":"c1","entity_name":"osd.1"},{"crash_id":"c2","entity_name":"osd.1"},{"crash_id":"c3","entity_name":"mon.a"}]`, nil
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 1)
+	osdPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1-abc", Namespace: "rook-ceph", Labels: map[string]string{"osd": "1"}}}
+	_, err := clientset.CoreV1().Pods("rook-ceph").Create(osdPod)
+	assert.NoError(t, err)
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "rook-ceph"}}
+	recorder := record.NewFakeRecorder(10)
+	c := &cephStatusChecker{
+		context:        &clusterd.Context{Executor: executor, Clientset: clientset},
+		client:         fake.NewFakeClientWithScheme(scheme.Scheme, pool),
+		namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"},
+		recorder:       recorder,
+	}
+	cephCluster := &cephv1.CephCluster{}
+
+	// nothing is remediated unless enabled
+	c.loadRemediationConfig(cephv1.HealthRemediationSpec{})
+	c.remediateHealth(cephCluster, &health)
+	assert.Empty(t, commands)
+
+	c.loadRemediationConfig(cephv1.HealthRemediationSpec{RepairInconsistentPGs: true, RestartCrashedDaemons: 2, EnablePoolApplications: true})
+	assert.Equal(t, time.Hour, c.remediationInterval)
+	c.remediateHealth(cephCluster, &health)
+	// the osd crashed twice is restarted and its crashes archived, the pool of the CephBlockPool is tagged
+	assert.Equal(t, []string{"pg repair 2.5", "crash ls-new", "crash archive c1", "crash archive c2", "osd pool application enable replicapool rbd"}, commands)
+	pods, err := clientset.CoreV1().Pods("rook-ceph").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, pods.Items)
+	assert.Equal(t, 3, len(recorder.Events))
+	assert.Equal(t, "Normal HealthRemediated repair inconsistent pg 2.5", <-recorder.Events)
+	assert.Equal(t, "Normal HealthRemediated restart osd.1 after 2 crashes", <-recorder.Events)
+	assert.Equal(t, "Normal HealthRemediated enable application rbd on pool replicapool", <-recorder.Events)
+
+	// the remediations are rate limited
+	commands = []string{}
+	c.remediateHealth(cephCluster, &health)
+	assert.Equal(t, []string{"crash ls-new"}, commands)
+	assert.Equal(t, 0, len(recorder.Events))
+}
+
+func TestRemediationState(t *testing.T) {
+	clientset := testop.New(t, 1)
+	ownerRef := metav1.OwnerReference{Name: "rook-ceph", UID: "uid"}
+	c := &cephStatusChecker{
+		context:        &clusterd.Context{Clientset: clientset},
+		namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"},
+		ownerRef:       &ownerRef,
+	}
+	c.loadRemediationConfig(cephv1.HealthRemediationSpec{})
+
+	// the remediations within the interval are saved, the older ones are dropped
+	now := time.Now()
+	c.lastRemediations["pg/2.5"] = now
+	c.lastRemediations["pool/old"] = now.Add(-2 * time.Hour)
+	c.saveRemediationState()
+	assert.Equal(t, 1, len(c.lastRemediations))
+
+	// a new checker after a restart of the operator restores them
+	restarted := &cephStatusChecker{
+		context:        &clusterd.Context{Clientset: clientset},
+		namespacedName: types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"},
+	}
+	restarted.loadRemediationConfig(cephv1.HealthRemediationSpec{})
+	restarted.loadRemediationState()
+	assert.Equal(t, 1, len(restarted.lastRemediations))
+	assert.True(t, now.Equal(restarted.lastRemediations["pg/2.5"]))
+}
@@ -0,0 +1,195 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// clusterReportName is the configmap of the report of the cluster
+	clusterReportName = "rook-ceph-cluster-report"
+	clusterReportKey  = "report.json"
+	healthHistoryKey  = "health-history.json"
+	// clusterReportInterval is how often the report is generated again
+	clusterReportInterval = 7 * 24 * time.Hour
+	// maxHealthHistory is the number of health changes kept in the history
+	maxHealthHistory = 100
+)
+
+// clusterReport summarizes the cluster for the support cases
+type clusterReport struct {
+	Generated      string                              `json:"generated"`
+	CephVersion    string                              `json:"cephVersion,omitempty"`
+	DaemonVersions *cephclient.CephDaemonsVersions     `json:"daemonVersions,omitempty"`
+	Capacity       reportCapacity                      `json:"capacity"`
+	DeviceClasses  []cephv1.DeviceClassStatus          `json:"deviceClasses,omitempty"`
+	Health         string                              `json:"health"`
+	HealthChecks   map[string]cephv1.CephHealthMessage `json:"healthChecks,omitempty"`
+	// HealthHistory are the health changes since the previous report
+	HealthHistory []healthChange `json:"healthHistory"`
+}
+
+type reportCapacity struct {
+	BytesTotal     uint64 `json:"bytesTotal"`
+	BytesUsed      uint64 `json:"bytesUsed"`
+	BytesAvailable uint64 `json:"bytesAvailable"`
+	NumOSDs        int    `json:"numOSDs"`
+	NumPGs         int    `json:"numPGs"`
+}
+
+// healthChange is a change of the health of the cluster, with the health checks raised at the time
+type healthChange struct {
+	Time   string   `json:"time"`
+	Health string   `json:"health"`
+	Checks []string `json:"checks,omitempty"`
+}
+
+// syncClusterReport records the health changes of the cluster in the report configmap, and generates the report of
+// the cluster again once a week when the report is enabled in the telemetry settings
+func (c *cephStatusChecker) syncClusterReport(cephCluster *cephv1.CephCluster, status *cephclient.CephStatus, versions *cephclient.CephDaemonsVersions, now time.Time) {
+	telemetry := cephCluster.Spec.Telemetry
+	if telemetry == nil || !telemetry.Report || cephCluster.Spec.External.Enable {
+		return
+	}
+	if err := c.updateClusterReport(cephCluster, status, versions, now); err != nil {
+		logger.Errorf("failed to update the report of cluster %q. %v", c.namespacedName.Namespace, err)
+	}
+}
+
+func (c *cephStatusChecker) updateClusterReport(cephCluster *cephv1.CephCluster, status *cephclient.CephStatus, versions *cephclient.CephDaemonsVersions, now time.Time) error {
+	namespace := c.namespacedName.Namespace
+	configMaps := c.context.Clientset.CoreV1().ConfigMaps(namespace)
+	configMap, err := configMaps.Get(clusterReportName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get configmap %q", clusterReportName)
+		}
+		configMap = &v1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: clusterReportName, Namespace: namespace}}
+		ownerRef := opcontroller.ClusterOwnerRef(cephCluster.Name, string(cephCluster.UID))
+		k8sutil.SetOwnerRef(&configMap.ObjectMeta, &ownerRef)
+		if configMap, err = configMaps.Create(configMap); err != nil {
+			return errors.Wrapf(err, "failed to create configmap %q", clusterReportName)
+		}
+	}
+	data := map[string]string{}
+	for key, value := range configMap.Data {
+		data[key] = value
+	}
+
+	history := []healthChange{}
+	if raw, ok := data[healthHistoryKey]; ok {
+		if err := json.Unmarshal([]byte(raw), &history); err != nil {
+			logger.Warningf("failed to parse the health history of cluster %q, starting a new history. %v", namespace, err)
+			history = []healthChange{}
+		}
+	}
+	if len(history) == 0 || history[len(history)-1].Health != status.Health.Status {
+		checks := []string{}
+		for code := range status.Health.Checks {
+			checks = append(checks, code)
+		}
+		sort.Strings(checks)
+		history = append(history, healthChange{Time: formatTime(now), Health: status.Health.Status, Checks: checks})
+		if len(history) > maxHealthHistory {
+			history = history[len(history)-maxHealthHistory:]
+		}
+		raw, err := json.Marshal(history)
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the health history")
+		}
+		data[healthHistoryKey] = string(raw)
+	}
+
+	if clusterReportDue(data[clusterReportKey], now) {
+		report := newClusterReport(cephCluster, status, versions, history, now)
+		raw, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "failed to marshal the cluster report")
+		}
+		data[clusterReportKey] = string(raw)
+		logger.Infof("generated the report of cluster %q in configmap %q", namespace, clusterReportName)
+	}
+
+	if reflect.DeepEqual(data, configMap.Data) {
+		return nil
+	}
+	configMap.Data = data
+	if _, err := configMaps.Update(configMap); err != nil {
+		return errors.Wrapf(err, "failed to update configmap %q", clusterReportName)
+	}
+	return nil
+}
+
+// clusterReportDue returns whether the report is missing or was generated over a week ago
+func clusterReportDue(raw string, now time.Time) bool {
+	if raw == "" {
+		return true
+	}
+	var report clusterReport
+	if err := json.Unmarshal([]byte(raw), &report); err != nil {
+		return true
+	}
+	generated, err := time.Parse(time.RFC3339, report.Generated)
+	if err != nil {
+		return true
+	}
+	return now.Sub(generated) >= clusterReportInterval
+}
+
+func newClusterReport(cephCluster *cephv1.CephCluster, status *cephclient.CephStatus, versions *cephclient.CephDaemonsVersions, history []healthChange, now time.Time) *clusterReport {
+	report := &clusterReport{
+		Generated:      formatTime(now),
+		DaemonVersions: versions,
+		Capacity: reportCapacity{
+			BytesTotal:     status.PgMap.TotalBytes,
+			BytesUsed:      status.PgMap.UsedBytes,
+			BytesAvailable: status.PgMap.AvailableBytes,
+			NumOSDs:        status.OsdMap.OsdMap.NumOsd,
+			NumPGs:         status.PgMap.NumPgs,
+		},
+		DeviceClasses: cephCluster.Status.DeviceClasses,
+		Health:        status.Health.Status,
+		HealthChecks:  map[string]cephv1.CephHealthMessage{},
+		HealthHistory: []healthChange{},
+	}
+	if cephCluster.Status.CephVersion != nil {
+		report.CephVersion = cephCluster.Status.CephVersion.Version
+	}
+	for code, check := range status.Health.Checks {
+		report.HealthChecks[code] = cephv1.CephHealthMessage{Severity: check.Severity, Message: check.Summary.Message}
+	}
+	since := now.Add(-clusterReportInterval)
+	for _, change := range history {
+		if t, err := time.Parse(time.RFC3339, change.Time); err == nil && t.After(since) {
+			report.HealthHistory = append(report.HealthHistory, change)
+		}
+	}
+	return report
+}
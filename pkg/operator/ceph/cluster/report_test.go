@@ -0,0 +1,93 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSyncClusterReport(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	c := newCephStatusChecker(&clusterd.Context{Clientset: clientset}, "rook-ceph", "client.admin", types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}, cephv1.CephClusterHealthCheckSpec{}, nil)
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph", UID: "uid"}}
+	cephCluster.Status.CephVersion = &cephv1.ClusterVersion{Version: "15.2.4-0"}
+	status := &cephclient.CephStatus{}
+	status.Health.Status = "HEALTH_OK"
+	status.PgMap.TotalBytes = 3000
+	status.PgMap.UsedBytes = 1000
+	status.OsdMap.OsdMap.NumOsd = 3
+	versions := &cephclient.CephDaemonsVersions{Overall: map[string]int{"ceph version 15.2.4": 7}}
+	now := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	getReport := func() (*clusterReport, []healthChange) {
+		cm, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(clusterReportName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		var report clusterReport
+		assert.NoError(t, json.Unmarshal([]byte(cm.Data[clusterReportKey]), &report))
+		var history []healthChange
+		assert.NoError(t, json.Unmarshal([]byte(cm.Data[healthHistoryKey]), &history))
+		return &report, history
+	}
+
+	// no report unless enabled
+	c.syncClusterReport(cephCluster, status, versions, now)
+	cephCluster.Spec.Telemetry = &cephv1.TelemetrySpec{Enabled: true}
+	c.syncClusterReport(cephCluster, status, versions, now)
+	_, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(clusterReportName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the report is generated with the first check
+	cephCluster.Spec.Telemetry.Report = true
+	c.syncClusterReport(cephCluster, status, versions, now)
+	report, history := getReport()
+	assert.Equal(t, "2020-10-01T00:00:00Z", report.Generated)
+	assert.Equal(t, "15.2.4-0", report.CephVersion)
+	assert.Equal(t, 7, report.DaemonVersions.Overall["ceph version 15.2.4"])
+	assert.Equal(t, uint64(3000), report.Capacity.BytesTotal)
+	assert.Equal(t, 3, report.Capacity.NumOSDs)
+	assert.Equal(t, "HEALTH_OK", report.Health)
+	assert.Equal(t, 1, len(history))
+
+	// the health changes are recorded, the report being generated again a week later
+	status.Health.Status = "HEALTH_WARN"
+	status.Health.Checks = map[string]cephclient.CheckMessage{"OSD_DOWN": {Severity: "HEALTH_WARN"}}
+	c.syncClusterReport(cephCluster, status, versions, now.Add(time.Hour))
+	c.syncClusterReport(cephCluster, status, versions, now.Add(2*time.Hour))
+	report, history = getReport()
+	assert.Equal(t, "2020-10-01T00:00:00Z", report.Generated)
+	assert.Equal(t, 2, len(history))
+	assert.Equal(t, []string{"OSD_DOWN"}, history[1].Checks)
+
+	c.syncClusterReport(cephCluster, status, versions, now.Add(clusterReportInterval))
+	report, _ = getReport()
+	assert.Equal(t, "2020-10-08T00:00:00Z", report.Generated)
+	assert.Equal(t, "HEALTH_WARN", report.Health)
+	assert.Equal(t, "HEALTH_WARN", report.HealthChecks["OSD_DOWN"].Severity)
+	// the changes of the last week only
+	assert.Equal(t, 1, len(report.HealthHistory))
+	assert.Equal(t, "HEALTH_WARN", report.HealthHistory[0].Health)
+}
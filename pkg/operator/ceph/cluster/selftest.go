@@ -0,0 +1,167 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cluster to manage a Ceph cluster.
+package cluster
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	selfTestNamespace = "rook-ceph-self-test"
+	// selfTestInterval is the check interval of the checkers during the self-test
+	selfTestInterval = "100ms"
+)
+
+// healthResult is the outcome of a single iteration of a checker during the self-test
+type healthResult struct {
+	daemon  string
+	command string
+}
+
+// selfTestExecutor records the ceph commands run by a checker and fails them since there is no cluster to talk to
+type selfTestExecutor struct {
+	daemon  string
+	results chan<- healthResult
+}
+
+func (e *selfTestExecutor) record(command string, arg ...string) error {
+	result := healthResult{daemon: e.daemon, command: strings.Join(append([]string{command}, arg...), " ")}
+	// only the first iterations are of interest, don't block the checker once the results are collected
+	select {
+	case e.results <- result:
+	default:
+	}
+	return errors.Errorf("self-test of %s health checker, not running %q", e.daemon, result.command)
+}
+
+func (e *selfTestExecutor) ExecuteCommand(command string, arg ...string) error {
+	return e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithEnv(env []string, command string, arg ...string) error {
+	return e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithOutput(command string, arg ...string) (string, error) {
+	return "", e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...string) (string, error) {
+	return "", e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithOutputFile(command, outfileArg string, arg ...string) (string, error) {
+	return "", e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithOutputFileTimeout(timeout time.Duration, command, outfileArg string, arg ...string) (string, error) {
+	return "", e.record(command, arg...)
+}
+
+func (e *selfTestExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command string, arg ...string) (string, error) {
+	return "", e.record(command, arg...)
+}
+
+// SelfTest starts the health checker of every monitored daemon against a fake executor, waits for each of them
+// to run a check and stops them. An error is returned if a checker does not run or does not stop before the
+// context is done.
+func (c *ClusterController) SelfTest(ctx context.Context) error {
+	results := make(chan healthResult, len(monitoringDaemons))
+	stopCh := make(chan struct{})
+	var wg sync.WaitGroup
+	pending := map[string]struct{}{}
+
+	spec := &cephv1.ClusterSpec{}
+	spec.HealthCheck.DaemonHealth.Monitor.Interval = selfTestInterval
+	spec.HealthCheck.DaemonHealth.ObjectStorageDaemon.Interval = selfTestInterval
+	spec.HealthCheck.DaemonHealth.Status.Interval = selfTestInterval
+
+	for _, daemon := range monitoringDaemons {
+		// each checker runs with its own executor so the results can be told apart
+		daemonContext := &clusterd.Context{Executor: &selfTestExecutor{daemon: daemon, results: results}}
+		selfTestController := &ClusterController{
+			context:        daemonContext,
+			namespacedName: types.NamespacedName{Name: selfTestNamespace, Namespace: selfTestNamespace},
+		}
+		selfTestCluster := &cluster{
+			Namespace: selfTestNamespace,
+			context:   daemonContext,
+			Spec:      spec,
+			mons:      mon.New(daemonContext, selfTestNamespace, "", cephv1.NetworkSpec{}, metav1.OwnerReference{}, &sync.Mutex{}),
+		}
+		selfTestCluster.mons.ClusterInfo = &cephconfig.ClusterInfo{Name: selfTestNamespace}
+
+		check := selfTestController.newMonitoringCheck(selfTestCluster, daemon, "client.admin", nil)
+		if check == nil {
+			close(stopCh)
+			return errors.Errorf("failed to start %s health checker", daemon)
+		}
+		pending[daemon] = struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check(stopCh, nil, nil)
+		}()
+	}
+
+	// wait for every checker to run a check
+	var err error
+	for len(pending) > 0 && err == nil {
+		select {
+		case result := <-results:
+			if _, ok := pending[result.daemon]; ok {
+				logger.Infof("self-test of %s health checker ran %q", result.daemon, result.command)
+				delete(pending, result.daemon)
+			}
+		case <-ctx.Done():
+			daemons := []string{}
+			for daemon := range pending {
+				daemons = append(daemons, daemon)
+			}
+			sort.Strings(daemons)
+			err = errors.Errorf("health checkers %v did not run a check. %v", daemons, ctx.Err())
+		}
+	}
+
+	// stop the checkers and make sure none of them leaks
+	close(stopCh)
+	stopped := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return errors.Errorf("health checkers did not stop. %v", ctx.Err())
+	}
+
+	return err
+}
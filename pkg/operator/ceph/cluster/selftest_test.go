@@ -0,0 +1,58 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cluster to manage a Ceph cluster.
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSelfTest(t *testing.T) {
+	c := &ClusterController{}
+
+	// all the checkers run a check and stop
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := c.SelfTest(ctx)
+	assert.NoError(t, err)
+
+	// the checkers cannot run a check once the context is done
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = c.SelfTest(ctx)
+	assert.Error(t, err)
+}
+
+func TestSelfTestExecutor(t *testing.T) {
+	results := make(chan healthResult, 1)
+	e := &selfTestExecutor{daemon: "status", results: results}
+
+	_, err := e.ExecuteCommandWithOutput("ceph", "status", "--format", "json")
+	assert.Error(t, err)
+	assert.Equal(t, healthResult{daemon: "status", command: "ceph status --format json"}, <-results)
+
+	// the executor does not block once the results are not collected anymore
+	_, err = e.ExecuteCommandWithOutput("ceph", "status")
+	assert.Error(t, err)
+	_, err = e.ExecuteCommandWithOutput("ceph", "status")
+	assert.Error(t, err)
+	assert.Equal(t, 1, len(results))
+}
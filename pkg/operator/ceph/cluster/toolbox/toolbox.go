@@ -0,0 +1,153 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package toolbox maintains the toolbox deployment running the ceph commands on the cluster.
+package toolbox
+
+import (
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AppName is the name of the toolbox deployment and of its app label
+	AppName = "rook-ceph-tools"
+	// the toolbox waits for the commands run with kubectl exec, and exits as soon as it is stopped
+	toolboxScript = `trap 'exit 0' TERM; while true; do sleep 10 & wait $!; done`
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", "op-toolbox")
+
+// Reconcile deploys the toolbox of the cluster when enabled, and removes the toolbox deployed by the operator when
+// disabled. A toolbox deployed from the example manifest without the owner of the cluster is never removed.
+func Reconcile(context *clusterd.Context, namespace string, spec *cephv1.ClusterSpec, ownerRef metav1.OwnerReference) error {
+	if !spec.Toolbox.Enabled {
+		return remove(context, namespace, ownerRef)
+	}
+
+	d := makeDeployment(namespace, spec, ownerRef)
+	_, err := context.Clientset.AppsV1().Deployments(namespace).Create(d)
+	if err != nil {
+		if !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create toolbox deployment %q", AppName)
+		}
+		_, err = context.Clientset.AppsV1().Deployments(namespace).Update(d)
+		if err != nil {
+			return errors.Wrapf(err, "failed to update toolbox deployment %q", AppName)
+		}
+		logger.Debugf("toolbox deployment %q updated", AppName)
+		return nil
+	}
+	logger.Infof("toolbox deployment %q created", AppName)
+	return nil
+}
+
+func remove(context *clusterd.Context, namespace string, ownerRef metav1.OwnerReference) error {
+	d, err := context.Clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get toolbox deployment %q", AppName)
+	}
+	if !ownedBy(d, ownerRef) {
+		logger.Debugf("toolbox deployment %q not deployed by the operator, keeping it", AppName)
+		return nil
+	}
+	logger.Infof("toolbox disabled, removing toolbox deployment %q", AppName)
+	return k8sutil.DeleteDeployment(context.Clientset, namespace, AppName)
+}
+
+func ownedBy(d *apps.Deployment, ownerRef metav1.OwnerReference) bool {
+	for _, ref := range d.OwnerReferences {
+		if ref.UID == ownerRef.UID {
+			return true
+		}
+	}
+	return false
+}
+
+// makeDeployment returns the toolbox deployment running the ceph image of the cluster, its ceph config being
+// generated from the mon endpoints with the admin keyring
+func makeDeployment(namespace string, spec *cephv1.ClusterSpec, ownerRef metav1.OwnerReference) *apps.Deployment {
+	cfgDir := cephconfig.DefaultConfigDir
+	cfgVolumeName := k8sutil.PathToVolumeName(cfgDir)
+	cfgVolume := v1.Volume{Name: cfgVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}
+	volumeMounts := []v1.VolumeMount{
+		{Name: cfgVolumeName, MountPath: cfgDir},
+		keyring.VolumeMount().Admin(),
+	}
+
+	labels := controller.AppLabels(AppName, namespace)
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{
+			controller.GenerateMinimalCephConfInitContainer(
+				"client.admin",
+				keyring.VolumeMount().AdminKeyringFilePath(),
+				spec.CephVersion.Image,
+				volumeMounts,
+				spec.Toolbox.Resources,
+				mon.PodSecurityContext(),
+			),
+		},
+		Containers: []v1.Container{
+			{
+				Name:            AppName,
+				Command:         []string{"/bin/bash", "-c", toolboxScript},
+				Image:           spec.CephVersion.Image,
+				VolumeMounts:    volumeMounts,
+				Resources:       spec.Toolbox.Resources,
+				SecurityContext: mon.PodSecurityContext(),
+			},
+		},
+		Volumes:       []v1.Volume{cfgVolume, keyring.Volume().Admin()},
+		RestartPolicy: v1.RestartPolicyAlways,
+		DNSPolicy:     v1.DNSClusterFirstWithHostNet,
+	}
+	spec.Toolbox.Placement.ApplyToPodSpec(&podSpec)
+	k8sutil.ApplyImagePullSecrets(namespace, &podSpec)
+
+	replicas := int32(1)
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AppName,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Name: AppName, Labels: labels},
+				Spec:       podSpec,
+			},
+			Replicas: &replicas,
+		},
+	}
+	k8sutil.AddRookVersionLabelToDeployment(d)
+	k8sutil.SetOwnerRef(&d.ObjectMeta, &ownerRef)
+	return d
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package toolbox
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReconcile(t *testing.T) {
+	namespace := "rook-ceph"
+	clientset := fake.NewSimpleClientset()
+	context := &clusterd.Context{Clientset: clientset}
+	ownerRef := metav1.OwnerReference{Name: "my-cluster", UID: "uid"}
+	spec := &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15.2.4"}}
+
+	// nothing to remove when disabled
+	assert.NoError(t, Reconcile(context, namespace, spec, ownerRef))
+	_, err := clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the toolbox runs the ceph image with the resources and the placement of its spec
+	spec.Toolbox = cephv1.ToolboxSpec{
+		Enabled: true,
+		Resources: v1.ResourceRequirements{
+			Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")},
+		},
+		Placement: rookv1.Placement{
+			Tolerations: []v1.Toleration{{Key: "storage", Operator: v1.TolerationOpExists}},
+		},
+	}
+	assert.NoError(t, Reconcile(context, namespace, spec, ownerRef))
+	d, err := clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, ownerRef.UID, d.OwnerReferences[0].UID)
+	assert.Equal(t, AppName, d.Spec.Template.Labels["app"])
+	podSpec := d.Spec.Template.Spec
+	assert.Equal(t, 1, len(podSpec.InitContainers))
+	assert.Equal(t, "ceph/ceph:v15.2.4", podSpec.InitContainers[0].Image)
+	assert.Equal(t, 1, len(podSpec.Containers))
+	assert.Equal(t, "ceph/ceph:v15.2.4", podSpec.Containers[0].Image)
+	assert.Equal(t, "256Mi", podSpec.Containers[0].Resources.Limits.Memory().String())
+	assert.Equal(t, "storage", podSpec.Tolerations[0].Key)
+	assert.Equal(t, "rook-ceph-admin-keyring", podSpec.Volumes[1].Secret.SecretName)
+
+	// the image follows the ceph version of the cluster
+	spec.CephVersion.Image = "ceph/ceph:v15.2.5"
+	assert.NoError(t, Reconcile(context, namespace, spec, ownerRef))
+	d, err = clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "ceph/ceph:v15.2.5", d.Spec.Template.Spec.Containers[0].Image)
+
+	// the toolbox of the operator is removed when disabled
+	spec.Toolbox.Enabled = false
+	assert.NoError(t, Reconcile(context, namespace, spec, ownerRef))
+	_, err = clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// a toolbox not deployed by the operator is kept
+	_, err = clientset.AppsV1().Deployments(namespace).Create(&apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: AppName, Namespace: namespace}})
+	assert.NoError(t, err)
+	assert.NoError(t, Reconcile(context, namespace, spec, ownerRef))
+	_, err = clientset.AppsV1().Deployments(namespace).Get(AppName, metav1.GetOptions{})
+	assert.NoError(t, err)
+}
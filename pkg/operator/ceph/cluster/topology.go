@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// addPendingNode reports a node added to the kubernetes cluster whose osds are to be provisioned by the next
+// orchestration, the time the node was first reported being kept
+func addPendingNode(c client.Client, namespacedName types.NamespacedName, nodeName string) error {
+	return setPendingTopologyChanges(c, namespacedName, cephv1.TopologyChangeAdd, func(changes []cephv1.TopologyChange) []cephv1.TopologyChange {
+		for _, change := range changes {
+			if change.Node == nodeName {
+				return changes
+			}
+		}
+		return append(changes, cephv1.TopologyChange{Node: nodeName, Change: cephv1.TopologyChangeAdd, Since: time.Now().UTC().Format(time.RFC3339)})
+	})
+}
+
+// clearPendingNodes removes the added nodes reported before the start of an orchestration, whose osds it provisioned
+func clearPendingNodes(c client.Client, namespacedName types.NamespacedName, start time.Time) error {
+	return setPendingTopologyChanges(c, namespacedName, cephv1.TopologyChangeAdd, func(changes []cephv1.TopologyChange) []cephv1.TopologyChange {
+		pending := []cephv1.TopologyChange{}
+		for _, change := range changes {
+			// the nodes reported in the second the orchestration started are provisioned by the next one
+			since, err := time.Parse(time.RFC3339, change.Since)
+			if err == nil && since.Before(start.Truncate(time.Second)) {
+				continue
+			}
+			pending = append(pending, change)
+		}
+		return pending
+	})
+}
+
+// setRemovedNodes reports the nodes deleted from the kubernetes cluster whose osds are to be removed
+func setRemovedNodes(c client.Client, namespacedName types.NamespacedName, removed []cephv1.TopologyChange) error {
+	return setPendingTopologyChanges(c, namespacedName, cephv1.TopologyChangeRemove, func([]cephv1.TopologyChange) []cephv1.TopologyChange {
+		return removed
+	})
+}
+
+// setPendingTopologyChanges updates the pending topology changes of a kind in the status of the CephCluster, the
+// status being only updated when they changed
+func setPendingTopologyChanges(c client.Client, namespacedName types.NamespacedName, kind string, update func(changes []cephv1.TopologyChange) []cephv1.TopologyChange) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.Get(context.TODO(), namespacedName, cephCluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get ceph cluster %q", namespacedName.Name)
+	}
+
+	changes := []cephv1.TopologyChange{}
+	pending := []cephv1.TopologyChange{}
+	for _, change := range cephCluster.Status.PendingTopologyChanges {
+		if change.Change == kind {
+			changes = append(changes, change)
+		} else {
+			pending = append(pending, change)
+		}
+	}
+	pending = append(pending, update(changes)...)
+	sort.Slice(pending, func(i, j int) bool {
+		if pending[i].Change != pending[j].Change {
+			return pending[i].Change < pending[j].Change
+		}
+		return pending[i].Node < pending[j].Node
+	})
+	if len(pending) == 0 {
+		pending = nil
+	}
+	if reflect.DeepEqual(pending, cephCluster.Status.PendingTopologyChanges) {
+		return nil
+	}
+
+	cephCluster.Status.PendingTopologyChanges = pending
+	if err := opcontroller.UpdateStatus(c, cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to update the pending topology changes of ceph cluster %q", namespacedName.Name)
+	}
+	return nil
+}
@@ -39,6 +39,13 @@ func (c *cluster) unsetOrchestrationStatus() {
 	c.orchestrationRunning = false
 }
 
+// isOrchestrationRunning gets whether an orchestration of the cluster is running
+func (c *cluster) isOrchestrationRunning() bool {
+	c.orchMux.Lock()
+	defer c.orchMux.Unlock()
+	return c.orchestrationRunning
+}
+
 // checkSetOrchestrationStatus is responsible to do orchestration as long as there is a request needed
 func (c *cluster) checkSetOrchestrationStatus() bool {
 	c.orchMux.Lock()
@@ -18,6 +18,7 @@ limitations under the License.
 package cluster
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/pkg/errors"
@@ -31,7 +32,13 @@ import (
 )
 
 func (c *ClusterController) detectAndValidateCephVersion(cluster *cluster) (*cephver.CephVersion, bool, error) {
-	version, err := cluster.detectCephVersion(c.rookImage, cluster.Spec.CephVersion.Image, detectCephVersionTimeout)
+	var version *cephver.CephVersion
+	var err error
+	if cluster.Spec.CephVersion.SkipImageVersionDetection {
+		version, err = declaredCephVersion(cluster.Spec.CephVersion)
+	} else {
+		version, err = cluster.detectCephVersion(c.rookImage, cluster.Spec.CephVersion.Image, detectCephVersionTimeout)
+	}
 	if err != nil {
 		return nil, false, err
 	}
@@ -151,6 +158,19 @@ func (c *cluster) detectCephVersion(rookImage, cephImage string, timeout time.Du
 	return version, nil
 }
 
+// declaredCephVersion returns the ceph version declared for the image, without running the version detection job
+func declaredCephVersion(spec cephv1.CephVersionSpec) (*cephver.CephVersion, error) {
+	if spec.Version == "" {
+		return nil, errors.Errorf("the ceph version of image %q must be declared to skip the image version detection", spec.Image)
+	}
+	version, err := cephver.ExtractCephVersion(fmt.Sprintf("ceph version %s", spec.Version))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the declared ceph version %q", spec.Version)
+	}
+	logger.Infof("skipping the ceph version detection of image %q, using the declared ceph version %q", spec.Image, version.String())
+	return version, nil
+}
+
 func (c *cluster) validateCephVersion(version *cephver.CephVersion) error {
 	if !c.Spec.External.Enable {
 		if !version.IsAtLeast(cephver.Minimum) {
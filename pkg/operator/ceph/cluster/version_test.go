@@ -154,3 +154,25 @@ func testSpec(t *testing.T) *cluster {
 	}
 	return &cluster{Spec: &cephv1.ClusterSpec{}, context: context}
 }
+
+func TestDeclaredCephVersion(t *testing.T) {
+	spec := cephv1.CephVersionSpec{Image: "registry.example.com/ceph/ceph:v15.2.4", SkipImageVersionDetection: true}
+	_, err := declaredCephVersion(spec)
+	assert.Error(t, err)
+
+	spec.Version = "15.2.4"
+	v, err := declaredCephVersion(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 15, Minor: 2, Extra: 4}, *v)
+	assert.True(t, v.IsOctopus())
+
+	// the build of the downstream releases
+	spec.Version = "14.2.8-59"
+	v, err = declaredCephVersion(spec)
+	assert.NoError(t, err)
+	assert.Equal(t, cephver.CephVersion{Major: 14, Minor: 2, Extra: 8, Build: 59}, *v)
+
+	spec.Version = "octopus"
+	_, err = declaredCephVersion(spec)
+	assert.Error(t, err)
+}
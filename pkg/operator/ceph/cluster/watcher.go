@@ -19,14 +19,20 @@ package cluster
 
 import (
 	"context"
+	"encoding/json"
+	"reflect"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -102,6 +108,9 @@ func (c *clientCluster) onK8sNode(object runtime.Object) bool {
 			logger.Debugf("node watcher: node %q is already an OSD node with %q", nodeName, osds)
 		} else {
 			logger.Infof("node watcher: adding node %q to cluster %q", node.Labels[v1.LabelHostname], cluster.Namespace)
+			if err := addPendingNode(c.client, types.NamespacedName{Namespace: cluster.Namespace, Name: cluster.Name}, nodeName); err != nil {
+				logger.Warningf("node watcher: failed to report the addition of node %q. %v", nodeName, err)
+			}
 			return true
 		}
 	}
@@ -109,6 +118,46 @@ func (c *clientCluster) onK8sNode(object runtime.Object) bool {
 	return false
 }
 
+// onK8sNodeTopologyUpdate is triggered when the topology labels of a node running osds are updated
+// so that the osds of the node are moved to their new location in the CRUSH map
+func (c *clientCluster) onK8sNodeTopologyUpdate(oldObj, newObj runtime.Object) bool {
+	oldNode, ok := oldObj.(*v1.Node)
+	if !ok {
+		return false
+	}
+	newNode, ok := newObj.(*v1.Node)
+	if !ok {
+		return false
+	}
+
+	cluster := c.getCephCluster()
+	if cluster.Status.Phase != cephv1.ConditionReady {
+		logger.Debugf("node watcher: cluster %q is not ready. skipping topology update", cluster.Namespace)
+		return false
+	}
+
+	topologyMapping := cluster.Spec.Storage.TopologyMapping
+	oldTopology := osd.ExtractOSDTopologyFromLabelsWithMapping(oldNode.Labels, topologyMapping)
+	newTopology := osd.ExtractOSDTopologyFromLabelsWithMapping(newNode.Labels, topologyMapping)
+	if reflect.DeepEqual(oldTopology, newTopology) {
+		return false
+	}
+
+	nodeName := newNode.Name
+	hostname, ok := newNode.Labels[v1.LabelHostname]
+	if ok && hostname != "" {
+		nodeName = hostname
+	}
+	osds, err := cephclient.GetOSDOnHost(c.context, cluster.Namespace, nodeName)
+	if err != nil || osds == "" {
+		logger.Debugf("node watcher: no osds on node %q to update the topology of", nodeName)
+		return false
+	}
+
+	logger.Infof("node watcher: topology of node %q changed from %v to %v, updating the CRUSH location of its osds", nodeName, oldTopology, newTopology)
+	return true
+}
+
 // onDeviceCMUpdate is trigger when the hot plug config map is updated
 func (c *clientCluster) onDeviceCMUpdate(oldObj, newObj runtime.Object) bool {
 	oldCm, ok := oldObj.(*v1.ConfigMap)
@@ -163,6 +212,68 @@ func (c *clientCluster) onDeviceCMUpdate(oldObj, newObj runtime.Object) bool {
 	return true
 }
 
+// onDeviceCMCreate is triggered when the hot plug config map of a node is created, so that the empty
+// devices of a node without osds are provisioned without waiting for the next orchestration
+func (c *clientCluster) onDeviceCMCreate(obj runtime.Object) bool {
+	cm, ok := obj.(*v1.ConfigMap)
+	if !ok {
+		return false
+	}
+
+	var devices []sys.LocalDisk
+	if err := json.Unmarshal([]byte(cm.Data[discoverDaemon.LocalDiskCMData]), &devices); err != nil {
+		logger.Debugf("hot-plug cm watcher: failed to read the devices of cm %q. %v", cm.Name, err)
+		return false
+	}
+	hasEmptyDevice := false
+	for _, device := range devices {
+		if device.Empty {
+			hasEmptyDevice = true
+			break
+		}
+	}
+	if !hasEmptyDevice {
+		logger.Debugf("hot-plug cm watcher: no empty device in cm %q. skipping orchestration", cm.Name)
+		return false
+	}
+
+	cluster := c.getCephCluster()
+	if cluster.Status.Phase != cephv1.ConditionReady {
+		logger.Debugf("hot-plug cm watcher: cluster %q is not ready. skipping orchestration.", cluster.Namespace)
+		return false
+	}
+
+	if len(cluster.Spec.Storage.StorageClassDeviceSets) > 0 {
+		logger.Info("hot-plug cm watcher: skip orchestration on device config map creation for OSDs on PVC")
+		return false
+	}
+
+	// the device configmaps are all seen as created when the operator restarts, the nodes which already
+	// run osds are reconciled with the cluster
+	nodeName := cm.Labels[discoverDaemon.NodeAttr]
+	node := &v1.Node{}
+	if err := c.client.Get(context.TODO(), types.NamespacedName{Name: nodeName}, node); err == nil {
+		if hostname, ok := node.Labels[v1.LabelHostname]; ok && hostname != "" {
+			nodeName = hostname
+		}
+	}
+	deployments := &appsv1.DeploymentList{}
+	err := c.client.List(context.TODO(), deployments, client.InNamespace(cluster.Namespace), client.MatchingLabels{k8sutil.AppAttr: osd.AppName})
+	if err != nil {
+		logger.Debugf("hot-plug cm watcher: failed to list the osds of cluster %q. %v", cluster.Namespace, err)
+		return false
+	}
+	for _, d := range deployments.Items {
+		if d.Spec.Template.Spec.NodeSelector[v1.LabelHostname] == nodeName {
+			logger.Debugf("hot-plug cm watcher: node %q already runs osds. skipping orchestration", nodeName)
+			return false
+		}
+	}
+
+	logger.Infof("hot-plug cm watcher: running orchestration for namespace %q after devices were discovered on node %q", cluster.Namespace, nodeName)
+	return true
+}
+
 func (c *clientCluster) getCephCluster() *cephv1.CephCluster {
 	clusterList := &cephv1.CephClusterList{}
 
@@ -21,11 +21,16 @@ import (
 	"testing"
 
 	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -123,3 +128,87 @@ func TestOnDeviceCMUpdate(t *testing.T) {
 	b = clientCluster.onDeviceCMUpdate(oldCM, newCM)
 	assert.True(t, b)
 }
+
+func TestOnDeviceCMCreate(t *testing.T) {
+	ns := "rook-ceph"
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	s.AddKnownTypes(corev1.SchemeGroupVersion, &corev1.Node{})
+	s.AddKnownTypes(appsv1.SchemeGroupVersion, &appsv1.Deployment{}, &appsv1.DeploymentList{})
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: ns, Namespace: ns},
+		Status:     cephv1.ClusterStatus{Phase: k8sutil.ReadyStatus},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{corev1.LabelHostname: "host1"}},
+	}
+	clientCluster := newClientCluster(fake.NewFakeClientWithScheme(s, cephCluster, node), ns, &clusterd.Context{})
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "local-device-node1",
+			Namespace: ns,
+			Labels:    map[string]string{discoverDaemon.NodeAttr: "node1"},
+		},
+		Data: map[string]string{discoverDaemon.LocalDiskCMData: `[{"name":"sda","type":"disk","filesystem":"ext4","empty":false}]`},
+	}
+
+	// no empty device was discovered
+	assert.False(t, clientCluster.onDeviceCMCreate(cm))
+
+	// an empty device is discovered on a node without osds
+	cm.Data[discoverDaemon.LocalDiskCMData] = `[{"name":"sda","type":"disk","filesystem":"ext4","empty":false},{"name":"sdb","type":"disk","empty":true}]`
+	assert.True(t, clientCluster.onDeviceCMCreate(cm))
+
+	// the node already runs osds
+	osdDeployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Namespace: ns, Labels: map[string]string{k8sutil.AppAttr: osd.AppName}},
+	}
+	osdDeployment.Spec.Template.Spec.NodeSelector = map[string]string{corev1.LabelHostname: "host1"}
+	clientCluster.client = fake.NewFakeClientWithScheme(s, cephCluster, node, osdDeployment)
+	assert.False(t, clientCluster.onDeviceCMCreate(cm))
+
+	// the cluster is not ready
+	cephCluster.Status.Phase = ""
+	clientCluster.client = fake.NewFakeClientWithScheme(s, cephCluster, node)
+	assert.False(t, clientCluster.onDeviceCMCreate(cm))
+}
+
+func TestOnK8sNodeTopologyUpdate(t *testing.T) {
+	ns := "rook-ceph"
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: ns, Namespace: ns},
+		Status:     cephv1.ClusterStatus{Phase: cephv1.ConditionReady},
+	}
+	cephCluster.Spec.Storage.TopologyMapping = map[string]string{"rack": "example.com/rack"}
+	osds := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "ls" {
+				return osds, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %v", args)
+		},
+	}
+	client := fake.NewFakeClientWithScheme(s, cephCluster)
+	clientCluster := newClientCluster(client, ns, &clusterd.Context{Executor: executor})
+
+	oldNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Labels: map[string]string{"example.com/rack": "rack1"}}}
+	newNode := oldNode.DeepCopy()
+	newNode.Labels["unrelated"] = "label"
+
+	// the topology did not change
+	osds = "osd.0"
+	assert.False(t, clientCluster.onK8sNodeTopologyUpdate(oldNode, newNode))
+
+	// the topology changed on a node without osds
+	newNode.Labels["example.com/rack"] = "rack2"
+	osds = ""
+	assert.False(t, clientCluster.onK8sNodeTopologyUpdate(oldNode, newNode))
+
+	// the topology changed on a node with osds
+	osds = "osd.0"
+	assert.True(t, clientCluster.onK8sNodeTopologyUpdate(oldNode, newNode))
+}
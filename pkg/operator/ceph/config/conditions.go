@@ -31,7 +31,6 @@ import (
 )
 
 var (
-	conditions   *[]cephv1.Condition
 	conditionMap = make(map[cephv1.ConditionType]v1.ConditionStatus)
 )
 
@@ -58,29 +57,13 @@ func setCondition(c *clusterd.Context, namespaceName types.NamespacedName, newCo
 		return
 	}
 
-	if conditions == nil {
-		conditions = &cluster.Status.Conditions
-		if cluster.Status.Conditions != nil {
-			conditionMapping(*conditions)
-		}
-	}
+	// the conditions are always read from the cluster, they are also updated by the health checks
+	conditionMapping(cluster.Status.Conditions)
 	conditionMap[newCondition.Type] = newCondition.Status
-	existingCondition := findStatusCondition(*conditions, newCondition.Type)
-	if existingCondition == nil {
-		newCondition.LastTransitionTime = metav1.NewTime(time.Now())
-		newCondition.LastHeartbeatTime = metav1.NewTime(time.Now())
-		*conditions = append(*conditions, newCondition)
-
-	} else if existingCondition.Status != newCondition.Status || existingCondition.Message != newCondition.Message {
-		newCondition.LastTransitionTime = metav1.NewTime(time.Now())
-		existingCondition.Status = newCondition.Status
-		existingCondition.Reason = newCondition.Reason
-		existingCondition.Message = newCondition.Message
-		existingCondition.LastHeartbeatTime = metav1.NewTime(time.Now())
-	}
-	cluster.Status.Conditions = *conditions
+	SetStatusCondition(&cluster.Status.Conditions, newCondition)
 
-	if newCondition.Status == v1.ConditionTrue {
+	// the health of the cluster is reported next to its phase, it does not replace it
+	if newCondition.Status == v1.ConditionTrue && newCondition.Type != cephv1.ConditionHealthy {
 		cluster.Status.Phase = newCondition.Type
 		if state := translatePhasetoState(newCondition.Type); state != "" {
 			cluster.Status.State = state
@@ -98,6 +81,57 @@ func setCondition(c *clusterd.Context, namespaceName types.NamespacedName, newCo
 	}
 }
 
+// SetStatusCondition adds the condition to the conditions, or updates the condition of the same type. The
+// transition time only changes with the status or the message of the condition.
+func SetStatusCondition(conditions *[]cephv1.Condition, newCondition cephv1.Condition) {
+	now := metav1.NewTime(time.Now())
+	existingCondition := findStatusCondition(*conditions, newCondition.Type)
+	if existingCondition == nil {
+		newCondition.LastTransitionTime = now
+		newCondition.LastHeartbeatTime = now
+		*conditions = append(*conditions, newCondition)
+		return
+	}
+
+	if existingCondition.Status != newCondition.Status || existingCondition.Message != newCondition.Message {
+		existingCondition.LastTransitionTime = now
+	}
+	existingCondition.Status = newCondition.Status
+	existingCondition.Reason = newCondition.Reason
+	existingCondition.Message = newCondition.Message
+	existingCondition.LastHeartbeatTime = now
+}
+
+// ProgressExport records the step of the orchestration being run in the cluster custom resource
+func ProgressExport(c *clusterd.Context, namespaceName types.NamespacedName, step string, completed, total int) {
+	cluster := &cephv1.CephCluster{}
+	err := c.Client.Get(context.TODO(), namespaceName, cluster)
+	if err != nil {
+		logger.Errorf("failed to get CephCluster %q to report the orchestration progress. %v", namespaceName, err)
+		return
+	}
+
+	cluster.Status.Progress = newProgress(step, completed, total)
+	if err := c.Client.Update(context.TODO(), cluster); err != nil {
+		logger.Errorf("failed to update the orchestration progress of cluster %q to %q. %v", namespaceName, step, err)
+		return
+	}
+	logger.Debugf("CephCluster %q orchestration progress: %q (%d/%d)", namespaceName.Namespace, step, completed, total)
+}
+
+func newProgress(step string, completed, total int) *cephv1.OrchestrationProgress {
+	progress := &cephv1.OrchestrationProgress{
+		Step:        step,
+		Completed:   completed,
+		Total:       total,
+		LastUpdated: time.Now().UTC().Format(time.RFC3339),
+	}
+	if total > 0 {
+		progress.Percent = completed * 100 / total
+	}
+	return progress
+}
+
 // translatePhasetoState convert the Phases to corresponding State
 // 1. We still need to set the State in case someone is still using it
 // instead of Phase. If we stopped setting the State it would be a
@@ -128,8 +162,12 @@ func translatePhasetoState(phase cephv1.ConditionType) cephv1.ClusterState {
 	}
 }
 
-// Updating the status of Progressing, Updating or Upgrading to False once cluster is Ready
+// Updating the status of Progressing, Updating or Upgrading to False once cluster is Ready, along with a previous
+// Failure
 func checkConditionFalse(context *clusterd.Context, namespaceName types.NamespacedName) {
+	if conditionMap[cephv1.ConditionFailure] == v1.ConditionTrue {
+		ConditionExport(context, namespaceName, cephv1.ConditionFailure, v1.ConditionFalse, "ClusterRecovered", "Cluster recovered from the failure")
+	}
 	tempConditionList := []cephv1.ConditionType{cephv1.ConditionUpdating, cephv1.ConditionUpgrading, cephv1.ConditionProgressing}
 	var tempCondition cephv1.ConditionType
 	for _, conditionType := range tempConditionList {
@@ -0,0 +1,89 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSetStatusCondition(t *testing.T) {
+	conditions := []cephv1.Condition{}
+	SetStatusCondition(&conditions, cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionTrue, Message: "ok"})
+	assert.Equal(t, 1, len(conditions))
+	transition := metav1.NewTime(conditions[0].LastTransitionTime.Add(-time.Minute))
+	conditions[0].LastTransitionTime = transition
+
+	// an unchanged condition keeps its transition time
+	SetStatusCondition(&conditions, cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionTrue, Message: "ok"})
+	assert.Equal(t, 1, len(conditions))
+	assert.Equal(t, transition, conditions[0].LastTransitionTime)
+
+	SetStatusCondition(&conditions, cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionFalse, Reason: "ClusterDegraded", Message: "warn"})
+	assert.Equal(t, 1, len(conditions))
+	assert.Equal(t, v1.ConditionFalse, conditions[0].Status)
+	assert.Equal(t, "ClusterDegraded", conditions[0].Reason)
+	assert.NotEqual(t, transition, conditions[0].LastTransitionTime)
+}
+
+func TestConditionExport(t *testing.T) {
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{})
+	name := types.NamespacedName{Name: "rook-ceph", Namespace: "rook-ceph"}
+	cluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: name.Name, Namespace: name.Namespace}}
+	c := &clusterd.Context{Client: fake.NewFakeClientWithScheme(s, cluster)}
+	getCluster := func() *cephv1.CephCluster {
+		cluster := &cephv1.CephCluster{}
+		assert.NoError(t, c.Client.Get(context.TODO(), name, cluster))
+		return cluster
+	}
+
+	ConditionExport(c, name, cephv1.ConditionFailure, v1.ConditionTrue, "ClusterFailure", "Failed to create cluster")
+	assert.Equal(t, cephv1.ConditionFailure, getCluster().Status.Phase)
+
+	// the health does not replace the phase, and is kept by the next conditions
+	cluster = getCluster()
+	SetStatusCondition(&cluster.Status.Conditions, cephv1.Condition{Type: cephv1.ConditionHealthy, Status: v1.ConditionTrue})
+	assert.NoError(t, c.Client.Update(context.TODO(), cluster))
+	ConditionExport(c, name, cephv1.ConditionProgressing, v1.ConditionTrue, "ClusterProgressing", "Cluster is creating")
+	cluster = getCluster()
+	assert.Equal(t, cephv1.ConditionProgressing, cluster.Status.Phase)
+	assert.NotNil(t, findStatusCondition(cluster.Status.Conditions, cephv1.ConditionHealthy))
+
+	// the failure and the progression are cleared once ready
+	ConditionExport(c, name, cephv1.ConditionReady, v1.ConditionTrue, "ClusterCreated", "Cluster created successfully")
+	cluster = getCluster()
+	assert.Equal(t, cephv1.ConditionReady, cluster.Status.Phase)
+	assert.Equal(t, v1.ConditionFalse, findStatusCondition(cluster.Status.Conditions, cephv1.ConditionFailure).Status)
+	assert.Equal(t, v1.ConditionFalse, findStatusCondition(cluster.Status.Conditions, cephv1.ConditionProgressing).Status)
+	assert.NoError(t, ErrorMapping())
+
+	ProgressExport(c, name, "Configuring Ceph OSDs", 2, 3)
+	progress := getCluster().Status.Progress
+	assert.Equal(t, "Configuring Ceph OSDs", progress.Step)
+	assert.Equal(t, 66, progress.Percent)
+}
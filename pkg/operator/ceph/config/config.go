@@ -52,6 +52,9 @@ const (
 	// RbdMirrorType defines the rbd-mirror DaemonType
 	RbdMirrorType = "rbd-mirror"
 
+	// FilesystemMirrorType defines the fs-mirror DaemonType
+	FilesystemMirrorType = "fs-mirror"
+
 	// CrashType defines the crash collector DaemonType
 	CrashType = "crashcollector"
 
@@ -105,6 +108,7 @@ func SetDefaultConfigs(
 	namespace string,
 	clusterInfo *cephconfig.ClusterInfo,
 	networkSpec cephv1.NetworkSpec,
+	logCollector cephv1.LogCollectorSpec,
 ) error {
 	// ceph.conf is never used. All configurations are made in the centralized mon config database,
 	// or they are specified on the commandline when daemons are called.
@@ -118,12 +122,24 @@ func SetDefaultConfigs(
 		return errors.Wrapf(err, "failed to apply legacy config overrides")
 	}
 
+	if err := monStore.SetAll(LogCollectorConfigs(logCollector)...); err != nil {
+		return errors.Wrap(err, "failed to apply the log collector settings")
+	}
+
+	if err := monStore.SetAll(bindSettings(networkSpec)...); err != nil {
+		return errors.Wrap(err, "failed to apply the ip family settings")
+	}
+
+	if err := monStore.SetAll(connectionSettings(networkSpec, clusterInfo.CephVersion)...); err != nil {
+		return errors.Wrap(err, "failed to apply the connection settings")
+	}
+
 	// Apply Multus if needed
 	if networkSpec.IsMultus() {
 		logger.Info("configuring ceph network(s) with multus")
 		cephNetworks, err := generateNetworkSettings(context, namespace, networkSpec.Selectors)
 		if err != nil {
-			errors.Wrap(err, "failed to generate network settings")
+			return errors.Wrap(err, "failed to generate network settings")
 		}
 
 		// Apply ceph network settings to the mon config store
@@ -18,6 +18,9 @@ limitations under the License.
 package config
 
 import (
+	"path"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/version"
 )
 
@@ -60,7 +63,6 @@ func LoggingFlags() []string {
 func DefaultCentralizedConfigs(cephVersion version.CephVersion) []Option {
 	overrides := []Option{
 		configOverride("global", "mon allow pool delete", "true"),
-		configOverride("global", "log file", ""),
 		configOverride("global", "mon cluster log file", ""),
 	}
 
@@ -83,6 +85,21 @@ func DefaultCentralizedConfigs(cephVersion version.CephVersion) []Option {
 	return overrides
 }
 
+// LogCollectorConfigs returns the options logging the daemons to files under the log dir when the log collector is
+// enabled, the daemons only logging to stderr otherwise
+func LogCollectorConfigs(logCollector cephv1.LogCollectorSpec) []Option {
+	if !logCollector.Enabled {
+		return []Option{
+			configOverride("global", "log file", ""),
+			configOverride("global", "log to file", "false"),
+		}
+	}
+	return []Option{
+		configOverride("global", "log file", path.Join(VarLogCephDir, "$cluster-$name.log")),
+		configOverride("global", "log to file", "true"),
+	}
+}
+
 // DefaultLegacyConfigs need to be added to the Ceph config file until the integration tests can be
 // made to override these options for the Ceph clusters it creates.
 func DefaultLegacyConfigs() []Option {
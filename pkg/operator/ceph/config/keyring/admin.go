@@ -52,3 +52,8 @@ func (a *AdminStore) CreateOrUpdate(c *cephconfig.ClusterInfo) error {
 	keyring := fmt.Sprintf(adminKeyringTemplate, c.AdminSecret)
 	return a.secretStore.CreateOrUpdate(adminKeyringResourceName, keyring)
 }
+
+// SecretName returns the name of the admin keyring secret.
+func (a *AdminStore) SecretName() string {
+	return keyringSecretName(adminKeyringResourceName)
+}
@@ -27,21 +27,25 @@ import (
 type fn func(cephv1.CephClusterHealthCheckSpec) *v1.Probe
 
 // ConfigureLivenessProbe returns the desired liveness probe for a given daemon
+// The probe of the spec is merged into the default probe of the container, so only the timeouts,
+// periods or thresholds to tune need to be set.
 func ConfigureLivenessProbe(daemon rookv1.KeyType, container v1.Container, healthCheck cephv1.CephClusterHealthCheckSpec) v1.Container {
 	// Map of functions
 	probeFnMap := map[rookv1.KeyType]fn{
 		cephv1.KeyMon: cephv1.GetMonLivenessProbe,
 		cephv1.KeyMgr: cephv1.GetMgrLivenessProbe,
 		cephv1.KeyOSD: cephv1.GetOSDLivenessProbe,
+		cephv1.KeyRgw: cephv1.GetRgwLivenessProbe,
+		cephv1.KeyMds: cephv1.GetMdsLivenessProbe,
 	}
 
-	if _, ok := healthCheck.LivenessProbe[daemon]; ok {
-		if !healthCheck.LivenessProbe[daemon].Disabled {
+	if probeSpec, ok := healthCheck.LivenessProbe[daemon]; ok && probeSpec != nil {
+		if !probeSpec.Disabled {
 			probe := probeFnMap[daemon](healthCheck)
 
 			// If the spec value is empty, let's use a default
 			if probe != nil {
-				container.LivenessProbe = probe
+				container.LivenessProbe = mergeProbe(container.LivenessProbe, probe)
 			}
 		} else {
 			container.LivenessProbe = nil
@@ -50,3 +54,33 @@ func ConfigureLivenessProbe(daemon rookv1.KeyType, container v1.Container, healt
 
 	return container
 }
+
+// mergeProbe overrides the settings of the default probe with the ones set in the probe of the spec
+// The handler of the default probe is kept unless the probe of the spec has its own.
+func mergeProbe(defaultProbe, probe *v1.Probe) *v1.Probe {
+	if defaultProbe == nil {
+		return probe
+	}
+
+	merged := defaultProbe.DeepCopy()
+	if probe.Exec != nil || probe.HTTPGet != nil || probe.TCPSocket != nil {
+		merged.Handler = probe.Handler
+	}
+	if probe.InitialDelaySeconds != 0 {
+		merged.InitialDelaySeconds = probe.InitialDelaySeconds
+	}
+	if probe.TimeoutSeconds != 0 {
+		merged.TimeoutSeconds = probe.TimeoutSeconds
+	}
+	if probe.PeriodSeconds != 0 {
+		merged.PeriodSeconds = probe.PeriodSeconds
+	}
+	if probe.SuccessThreshold != 0 {
+		merged.SuccessThreshold = probe.SuccessThreshold
+	}
+	if probe.FailureThreshold != 0 {
+		merged.FailureThreshold = probe.FailureThreshold
+	}
+
+	return merged
+}
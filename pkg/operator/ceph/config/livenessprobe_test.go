@@ -39,6 +39,20 @@ func TestConfigureLivenessProbe(t *testing.T) {
 	}
 	container := v1.Container{LivenessProbe: p}
 	l := map[rookv1.KeyType]*rookv1.ProbeSpec{cephv1.KeyMon: {Disabled: true}}
+	// only the timeouts and thresholds are tuned, the handler of the default probe is kept
+	tuned := map[rookv1.KeyType]*rookv1.ProbeSpec{
+		cephv1.KeyOSD: {Probe: &v1.Probe{TimeoutSeconds: 30, FailureThreshold: 10}},
+		cephv1.KeyRgw: {Probe: &v1.Probe{PeriodSeconds: 60}},
+		cephv1.KeyMds: {Disabled: true},
+	}
+	tunedOSDProbe := p.DeepCopy()
+	tunedOSDProbe.TimeoutSeconds = 30
+	tunedOSDProbe.FailureThreshold = 10
+	tunedRgwProbe := p.DeepCopy()
+	tunedRgwProbe.PeriodSeconds = 60
+	// the handler of the spec replaces the default one
+	execProbe := &v1.Probe{Handler: v1.Handler{Exec: &v1.ExecAction{Command: []string{"true"}}}}
+	replaced := map[rookv1.KeyType]*rookv1.ProbeSpec{cephv1.KeyMgr: {Probe: execProbe}}
 	type args struct {
 		daemon      rookv1.KeyType
 		container   v1.Container
@@ -51,6 +65,11 @@ func TestConfigureLivenessProbe(t *testing.T) {
 	}{
 		{"probe-enabled", args{cephv1.KeyMon, container, cephv1.CephClusterHealthCheckSpec{}}, container},
 		{"probe-disabled", args{cephv1.KeyMon, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: l}}, v1.Container{}},
+		{"probe-tuned", args{cephv1.KeyOSD, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: tuned}}, v1.Container{LivenessProbe: tunedOSDProbe}},
+		{"rgw-probe-tuned", args{cephv1.KeyRgw, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: tuned}}, v1.Container{LivenessProbe: tunedRgwProbe}},
+		{"mds-probe-disabled", args{cephv1.KeyMds, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: tuned}}, v1.Container{}},
+		{"other-daemon-untouched", args{cephv1.KeyMon, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: tuned}}, container},
+		{"probe-replaced", args{cephv1.KeyMgr, container, cephv1.CephClusterHealthCheckSpec{LivenessProbe: replaced}}, v1.Container{LivenessProbe: execProbe}},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
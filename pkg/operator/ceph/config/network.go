@@ -19,9 +19,14 @@ package config
 
 import (
 	"fmt"
+	"strconv"
 
+	netapi "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,16 +42,97 @@ const (
 var (
 	// NetworkSelectors is a slice of ceph network selector key name
 	NetworkSelectors = []string{PublicNetworkSelectorKeyName, ClusterNetworkSelectorKeyName}
+
+	// compressionMinVersion is quincy, the first release compressing the msgr2 connections
+	compressionMinVersion = cephver.CephVersion{Major: 17}
 )
 
+// bindSettings returns the settings binding the daemons to the addresses of the ip family of the network, nil with the
+// IPv4 default of ceph
+func bindSettings(network cephv1.NetworkSpec) []Option {
+	if !network.IsIPv6() && !network.DualStack {
+		return nil
+	}
+	return []Option{
+		configOverride("global", "ms_bind_ipv4", strconv.FormatBool(!network.IsIPv6() || network.DualStack)),
+		configOverride("global", "ms_bind_ipv6", "true"),
+	}
+}
+
+// connectionSettings returns the msgr2 settings of the connections of the network. The secure mode is forced when
+// the encryption is enabled, and the compression of the osd connections is only available from quincy.
+func connectionSettings(network cephv1.NetworkSpec, cephVersion cephver.CephVersion) []Option {
+	mode := "crc secure"
+	if network.IsEncryptionEnabled() {
+		mode = "secure"
+	}
+	settings := []Option{
+		configOverride("global", "ms_cluster_mode", mode),
+		configOverride("global", "ms_service_mode", mode),
+		configOverride("global", "ms_client_mode", mode),
+	}
+
+	if !cephVersion.IsAtLeast(compressionMinVersion) {
+		if network.IsCompressionEnabled() {
+			logger.Warningf("msgr2 compression is not supported by ceph version %q, compression requires quincy or newer", cephVersion.String())
+		}
+		return settings
+	}
+	compressMode := "none"
+	if network.IsCompressionEnabled() {
+		compressMode = "force"
+	}
+	return append(settings, configOverride("global", "ms_osd_compress_mode", compressMode))
+}
+
+// NetworkBindFlags returns the flags binding the mons to the addresses of the ip family of the network, since the
+// mons start before the settings are applied to the config store
+func NetworkBindFlags(network cephv1.NetworkSpec) []string {
+	flags := []string{}
+	for _, setting := range bindSettings(network) {
+		flags = append(flags, NewFlag(setting.Option, setting.Value))
+	}
+	return flags
+}
+
+// ApplyPublicNetwork attaches the pod of a daemon to the multus public network only, the cluster network carrying the
+// replication traffic of the osds
+func ApplyPublicNetwork(network rookv1.NetworkSpec, objectMeta *metav1.ObjectMeta) error {
+	selector, ok := network.Selectors[PublicNetworkSelectorKeyName]
+	if !ok {
+		// the daemon is only on the default network
+		return nil
+	}
+	public := rookv1.NetworkSpec{Provider: network.Provider, Selectors: map[string]string{PublicNetworkSelectorKeyName: selector}}
+	return k8sutil.ApplyMultus(public, objectMeta)
+}
+
+// NetworkAttachmentSubnet returns the subnet of the ipam of a network attachment definition, from its subnet or the
+// range of the whereabouts ipam
+func NetworkAttachmentSubnet(netDefinition netapi.NetworkAttachmentDefinition) (string, error) {
+	netConfig, err := k8sutil.GetNetworkAttachmentConfig(netDefinition)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get the configuration of network attachment definition %q", netDefinition.Name)
+	}
+	if netConfig.Ipam.Subnet != "" {
+		return netConfig.Ipam.Subnet, nil
+	}
+	if netConfig.Ipam.Range != "" {
+		return netConfig.Ipam.Range, nil
+	}
+	return "", errors.Errorf("empty subnet from network attachment definition %q", netDefinition.Name)
+}
+
 func generateNetworkSettings(context *clusterd.Context, namespace string, networkSelectors map[string]string) ([]Option, error) {
 	cephNetworks := []Option{}
 
 	for _, selectorKey := range NetworkSelectors {
-		// This means only "public" was specified and thus we use the same subnet for cluster too
 		if _, ok := networkSelectors[selectorKey]; !ok {
-			cephNetworks = append(cephNetworks, cephNetworks[0])
-			cephNetworks[1].Option = fmt.Sprintf("%s_network", selectorKey)
+			// This means only "public" was specified and thus we use the same subnet for cluster too
+			if selectorKey == ClusterNetworkSelectorKeyName && len(cephNetworks) > 0 {
+				cephNetworks = append(cephNetworks, configOverride("global", fmt.Sprintf("%s_network", selectorKey), cephNetworks[0].Value))
+			}
+			// Otherwise only "cluster" was specified and the public network remains the default network
 			continue
 		}
 
@@ -59,17 +145,11 @@ func generateNetworkSettings(context *clusterd.Context, namespace string, networ
 			return []Option{}, errors.Wrapf(err, "failed to fetch network attachment definition for selector %q", selectorKey)
 		}
 
-		// Get network attachment definition configuration
-		netConfig, err := k8sutil.GetNetworkAttachmentConfig(*netDefinition)
+		subnet, err := NetworkAttachmentSubnet(*netDefinition)
 		if err != nil {
-			return []Option{}, errors.Wrapf(err, "failed to get network attachment definition configuration for selector %q", selectorKey)
-		}
-
-		if netConfig.Ipam.Subnet != "" {
-			cephNetworks = append(cephNetworks, configOverride("global", fmt.Sprintf("%s_network", selectorKey), netConfig.Ipam.Subnet))
-		} else {
-			return []Option{}, errors.Errorf("empty subnet from network attachment definition %q", networkSelectors[selectorKey])
+			return []Option{}, errors.Wrapf(err, "failed to get the subnet for selector %q", selectorKey)
 		}
+		cephNetworks = append(cephNetworks, configOverride("global", fmt.Sprintf("%s_network", selectorKey), subnet))
 	}
 
 	return cephNetworks, nil
@@ -22,12 +22,48 @@ import (
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	fakenetclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	testop "github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+func TestNetworkBindFlags(t *testing.T) {
+	// the ipv4 default of ceph is kept
+	assert.Empty(t, NetworkBindFlags(cephv1.NetworkSpec{}))
+	assert.Empty(t, NetworkBindFlags(cephv1.NetworkSpec{IPFamily: cephv1.IPv4}))
+
+	assert.Equal(t, []string{"--ms-bind-ipv4=false", "--ms-bind-ipv6=true"}, NetworkBindFlags(cephv1.NetworkSpec{IPFamily: cephv1.IPv6}))
+	assert.Equal(t, []string{"--ms-bind-ipv4=true", "--ms-bind-ipv6=true"}, NetworkBindFlags(cephv1.NetworkSpec{IPFamily: cephv1.IPv6, DualStack: true}))
+	assert.Equal(t, []string{"--ms-bind-ipv4=true", "--ms-bind-ipv6=true"}, NetworkBindFlags(cephv1.NetworkSpec{DualStack: true}))
+}
+
+func TestConnectionSettings(t *testing.T) {
+	modes := func(mode string) []Option {
+		return []Option{
+			configOverride("global", "ms_cluster_mode", mode),
+			configOverride("global", "ms_service_mode", mode),
+			configOverride("global", "ms_client_mode", mode),
+		}
+	}
+	quincy := cephver.CephVersion{Major: 17, Minor: 2}
+	enabled := cephv1.NetworkSpec{Connections: &cephv1.ConnectionsSpec{
+		Encryption:  &cephv1.EncryptionSpec{Enabled: true},
+		Compression: &cephv1.CompressionSpec{Enabled: true},
+	}}
+
+	// the defaults of ceph are restored when the settings are disabled
+	assert.Equal(t, modes("crc secure"), connectionSettings(cephv1.NetworkSpec{}, cephver.Octopus))
+	assert.Equal(t, append(modes("crc secure"), configOverride("global", "ms_osd_compress_mode", "none")), connectionSettings(cephv1.NetworkSpec{}, quincy))
+
+	assert.Equal(t, append(modes("secure"), configOverride("global", "ms_osd_compress_mode", "force")), connectionSettings(enabled, quincy))
+	// the compression is skipped before quincy
+	assert.Equal(t, modes("secure"), connectionSettings(enabled, cephver.Pacific))
+}
+
 func TestGenerateNetworkSettings(t *testing.T) {
 	ns := "rook-ceph"
 	clientset := testop.New(t, 1)
@@ -135,4 +171,111 @@ func TestGenerateNetworkSettings(t *testing.T) {
 	cephNetwork, err = generateNetworkSettings(ctx, ns, netSelector)
 	assert.NoError(t, err)
 	assert.ElementsMatch(t, cephNetwork, expectedNetworks, fmt.Sprintf("networks: %+v", cephNetwork))
+
+	//
+	// TEST 4: only the cluster network, the public network remains the default network
+	//
+	expectedNetworks = []Option{
+		{
+			Who:    "global",
+			Option: "cluster_network",
+			Value:  "172.18.0.0/16",
+		},
+	}
+
+	netSelector = map[string]string{
+		"cluster": "cluster-network-attach-def",
+	}
+
+	cephNetwork, err = generateNetworkSettings(ctx, ns, netSelector)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, cephNetwork, expectedNetworks, fmt.Sprintf("networks: %+v", cephNetwork))
+
+	//
+	// TEST 5: the subnet is the range of the whereabouts ipam
+	//
+	expectedNetworks = []Option{
+		{
+			Who:    "global",
+			Option: "public_network",
+			Value:  "10.0.0.0/16",
+		},
+		{
+			Who:    "global",
+			Option: "cluster_network",
+			Value:  "10.0.0.0/16",
+		},
+	}
+
+	netSelector = map[string]string{
+		"public": "whereabouts-network-attach-def",
+	}
+	network3 := &networkv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "whereabouts-network-attach-def",
+			Namespace: ns,
+		},
+		Spec: networkv1.NetworkAttachmentDefinitionSpec{
+			Config: `{
+				"cniVersion": "0.3.0",
+				"type": "macvlan",
+				"master": "eth2",
+				"mode": "bridge",
+				"ipam": {
+				  "type": "whereabouts",
+				  "range": "10.0.0.0/16"
+				}
+			  }`,
+		},
+	}
+
+	// Create whereabouts network definition
+	ctx.NetworkClient.NetworkAttachmentDefinitions(ns).Create(network3)
+
+	cephNetwork, err = generateNetworkSettings(ctx, ns, netSelector)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, cephNetwork, expectedNetworks, fmt.Sprintf("networks: %+v", cephNetwork))
+
+	//
+	// TEST 6: network definition without subnet
+	//
+	netSelector = map[string]string{
+		"public": "dhcp-network-attach-def",
+	}
+	network4 := &networkv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "dhcp-network-attach-def",
+			Namespace: ns,
+		},
+		Spec: networkv1.NetworkAttachmentDefinitionSpec{
+			Config: `{
+				"cniVersion": "0.3.0",
+				"type": "macvlan",
+				"master": "eth2",
+				"mode": "bridge",
+				"ipam": {
+				  "type": "dhcp"
+				}
+			  }`,
+		},
+	}
+
+	// Create dhcp network definition
+	ctx.NetworkClient.NetworkAttachmentDefinitions(ns).Create(network4)
+
+	_, err = generateNetworkSettings(ctx, ns, netSelector)
+	assert.Error(t, err)
+}
+
+func TestApplyPublicNetwork(t *testing.T) {
+	network := rookv1.NetworkSpec{Provider: "multus", Selectors: map[string]string{"public": "public-net", "cluster": "cluster-net"}}
+	objectMeta := &metav1.ObjectMeta{}
+	assert.NoError(t, ApplyPublicNetwork(network, objectMeta))
+	assert.Equal(t, "public-net", objectMeta.Annotations["k8s.v1.cni.cncf.io/networks"])
+
+	// a daemon is not attached to the cluster network
+	network.Selectors = map[string]string{"cluster": "cluster-net"}
+	objectMeta = &metav1.ObjectMeta{}
+	assert.NoError(t, ApplyPublicNetwork(network, objectMeta))
+	assert.Empty(t, objectMeta.Annotations)
 }
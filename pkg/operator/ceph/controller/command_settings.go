@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strconv"
+
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// the operator settings of the timeout and of the circuit breaker of the ceph commands
+	cephCommandTimeoutSetting          = "ROOK_CEPH_COMMAND_TIMEOUT"
+	cephCommandFailureThresholdSetting = "ROOK_CEPH_COMMAND_FAILURE_THRESHOLD"
+	cephCommandBackoffSetting          = "ROOK_CEPH_COMMAND_BACKOFF"
+	cephCommandMaxBackoffSetting       = "ROOK_CEPH_COMMAND_MAX_BACKOFF"
+	// cephBindingsSetting disables the go-ceph bindings of an operator built with them if "false"
+	cephBindingsSetting = "ROOK_CEPH_BINDINGS"
+)
+
+// LoadCommandSettings loads the timeout and the circuit breaker settings of the ceph commands from the operator
+// settings, the invalid settings keeping their default
+func LoadCommandSettings(clientset kubernetes.Interface) cephclient.CommandSettings {
+	settings := cephclient.DefaultCommandSettings()
+	values := operatorSettingValues(clientset)
+
+	parseDuration(values, cephCommandTimeoutSetting, &settings.Timeout)
+	parsePositiveInt(values, cephCommandFailureThresholdSetting, &settings.BreakerThreshold)
+	parseDuration(values, cephCommandBackoffSetting, &settings.BreakerBackoff)
+	parseDuration(values, cephCommandMaxBackoffSetting, &settings.BreakerMaxBackoff)
+	if value, ok := values[cephBindingsSetting]; ok {
+		if enabled, err := strconv.ParseBool(value); err != nil {
+			logger.Warningf("invalid %s %q, using %t", cephBindingsSetting, value, settings.CephBindings)
+		} else {
+			settings.CephBindings = enabled
+		}
+	}
+	if settings.CephBindings && !cephclient.CephBindingsAvailable() {
+		logger.Debugf("the operator is built without the go-ceph bindings, running the ceph CLI")
+	}
+	if settings.BreakerMaxBackoff < settings.BreakerBackoff {
+		logger.Warningf("%s %q is lower than %s %q, using %q", cephCommandMaxBackoffSetting, settings.BreakerMaxBackoff.String(), cephCommandBackoffSetting, settings.BreakerBackoff.String(), settings.BreakerBackoff.String())
+		settings.BreakerMaxBackoff = settings.BreakerBackoff
+	}
+	return settings
+}
@@ -22,9 +22,13 @@ import (
 	"reflect"
 	"time"
 
+	"github.com/coreos/pkg/capnslog"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/exec"
+	"github.com/rook/rook/pkg/util/log"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -46,6 +50,9 @@ var (
 
 	// OperatorCephBaseImageVersion is the ceph version in the operator image
 	OperatorCephBaseImageVersion string
+
+	// HealthCheckCommandTimeout is the default duration after which a ceph command of the health checkers fails
+	HealthCheckCommandTimeout = 30 * time.Second
 )
 
 // IsReadyToReconcile determines if a controller is ready to reconcile or not
@@ -67,6 +74,9 @@ func IsReadyToReconcile(c client.Client, clustercontext *clusterd.Context, names
 	}
 	cephClusterExists = true
 	cephCluster = clusterList.Items[0]
+	// the pods of the controller get the image pull secrets of the cluster, even before the cluster is orchestrated
+	// after a restart of the operator
+	k8sutil.SetImagePullSecrets(namespacedName.Namespace, cephCluster.Spec.ImagePullSecrets)
 
 	logger.Debugf("%q: CephCluster resource %q found in namespace %q", controllerName, cephCluster.Name, namespacedName.Namespace)
 
@@ -82,6 +92,51 @@ func IsReadyToReconcile(c client.Client, clustercontext *clusterd.Context, names
 	return cephCluster, false, cephClusterExists, WaitForRequeueIfCephClusterNotReady
 }
 
+// HealthCheckContext returns a copy of the context whose commands fail after the health check command timeout
+// The health checkers then count a hung ceph command as a failed check instead of blocking forever.
+// A context already returned by HealthCheckContext gets the new timeout, it is not wrapped twice.
+func HealthCheckContext(context *clusterd.Context, healthCheck cephv1.CephClusterHealthCheckSpec) *clusterd.Context {
+	if context == nil || context.Executor == nil {
+		return context
+	}
+	healthExecutor := &exec.TimeoutCommandExecutor{Executor: context.Executor}
+	if timeoutExecutor, ok := context.Executor.(*exec.TimeoutCommandExecutor); ok {
+		healthExecutor.Executor = timeoutExecutor.Executor
+		healthExecutor.Context = timeoutExecutor.Context
+	}
+
+	timeout := HealthCheckCommandTimeout
+	if healthCheck.CommandTimeout != "" {
+		if duration, err := time.ParseDuration(healthCheck.CommandTimeout); err == nil {
+			timeout = duration
+		} else {
+			logger.Warningf("invalid health check command timeout %q, using the default of %s. %v", healthCheck.CommandTimeout, timeout.String(), err)
+		}
+	}
+
+	healthContext := *context
+	healthExecutor.Timeout = timeout
+	healthContext.Executor = healthExecutor
+	return &healthContext
+}
+
+// StoppableContext returns a copy of the context whose commands fail once ctx is done, so that the goroutines of a
+// deleted cluster or of a stopping operator do not keep on running ceph commands.
+func StoppableContext(context *clusterd.Context, ctx context.Context) *clusterd.Context {
+	if context == nil || context.Executor == nil || ctx == nil {
+		return context
+	}
+	stoppableExecutor := &exec.TimeoutCommandExecutor{Executor: context.Executor, Context: ctx}
+	if timeoutExecutor, ok := context.Executor.(*exec.TimeoutCommandExecutor); ok {
+		stoppableExecutor.Executor = timeoutExecutor.Executor
+		stoppableExecutor.Timeout = timeoutExecutor.Timeout
+	}
+
+	stoppableContext := *context
+	stoppableContext.Executor = stoppableExecutor
+	return &stoppableContext
+}
+
 // ClusterOwnerRef represents the owner reference of the CephCluster CR
 func ClusterOwnerRef(clusterName, clusterID string) metav1.OwnerReference {
 	blockOwner := true
@@ -94,6 +149,17 @@ func ClusterOwnerRef(clusterName, clusterID string) metav1.OwnerReference {
 	}
 }
 
+// ClusterEventObject returns a reference to the CephCluster of the owner reference, to record events on it
+func ClusterEventObject(ownerRef metav1.OwnerReference, namespace string) *v1.ObjectReference {
+	return &v1.ObjectReference{
+		APIVersion: ownerRef.APIVersion,
+		Kind:       ownerRef.Kind,
+		Name:       ownerRef.Name,
+		Namespace:  namespace,
+		UID:        ownerRef.UID,
+	}
+}
+
 // ClusterResource operator-kit Custom Resource Definition
 var ClusterResource = k8sutil.CustomResource{
 	Name:       "cephcluster",
@@ -103,3 +169,14 @@ var ClusterResource = k8sutil.CustomResource{
 	Kind:       reflect.TypeOf(cephv1.CephCluster{}).Name(),
 	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
 }
+
+// ReconcileLogger returns the logger of a reconcile of a custom resource, its lines holding the namespace, the kind and
+// the name of the resource, and a new reconcile ID to correlate them
+func ReconcileLogger(logger *capnslog.PackageLogger, kind string, request reconcile.Request) log.Logger {
+	return log.New(logger, log.Fields{
+		log.NamespaceField:   request.Namespace,
+		log.KindField:        kind,
+		log.NameField:        request.Name,
+		log.ReconcileIDField: log.NewReconcileID(),
+	})
+}
@@ -24,10 +24,21 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ForceDeletionAnnotation is the annotation deleting a CR even though its dependents still exist, such as a pool with
+// images or an object store with buckets, the data of the dependents being lost
+// e.g. "rook.io/force-deletion: true"
+const ForceDeletionAnnotation = "rook.io/force-deletion"
+
+// IsForceDeletion returns whether the CR is deleted regardless of its dependents
+func IsForceDeletion(obj metav1.Object) bool {
+	return obj.GetAnnotations()[ForceDeletionAnnotation] == "true"
+}
+
 // contains checks if an item exists in a given list.
 func contains(list []string, s string) bool {
 	for _, v := range list {
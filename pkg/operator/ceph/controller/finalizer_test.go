@@ -76,3 +76,14 @@ func TestRemoveFinalizer(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Empty(t, fakeObject.Finalizers)
 }
+
+func TestIsForceDeletion(t *testing.T) {
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "rook-ceph"}}
+	assert.False(t, IsForceDeletion(pool))
+
+	pool.Annotations = map[string]string{ForceDeletionAnnotation: "false"}
+	assert.False(t, IsForceDeletion(pool))
+
+	pool.Annotations[ForceDeletionAnnotation] = "true"
+	assert.True(t, IsForceDeletion(pool))
+}
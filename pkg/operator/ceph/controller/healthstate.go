@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// HealthStateConfigMapName is the configmap keeping the state of the health checkers of a cluster, such as the times
+// the mons went out of quorum, so that the restarts of the operator do not reset their timers
+const HealthStateConfigMapName = "rook-ceph-health-state"
+
+// LoadHealthState reads the state saved by a health checker under its key, the state being left as is when not saved
+func LoadHealthState(clientset kubernetes.Interface, namespace, key string, state interface{}) error {
+	configMap, err := clientset.CoreV1().ConfigMaps(namespace).Get(HealthStateConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get configmap %q", HealthStateConfigMapName)
+	}
+	raw, ok := configMap.Data[key]
+	if !ok {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(raw), state); err != nil {
+		return errors.Wrapf(err, "failed to parse the %s health state", key)
+	}
+	return nil
+}
+
+// SaveHealthState saves the state of a health checker under its key, the configmap being created with the owner
+// reference of the cluster. The health checkers of a cluster saving their state concurrently, the update is retried
+// on conflicts.
+func SaveHealthState(clientset kubernetes.Interface, namespace, key string, state interface{}, ownerRef *metav1.OwnerReference) error {
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return errors.Wrapf(err, "failed to marshal the %s health state", key)
+	}
+
+	configMaps := clientset.CoreV1().ConfigMaps(namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap, err := configMaps.Get(HealthStateConfigMapName, metav1.GetOptions{})
+		if err != nil {
+			if !kerrors.IsNotFound(err) {
+				return errors.Wrapf(err, "failed to get configmap %q", HealthStateConfigMapName)
+			}
+			configMap = &v1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: HealthStateConfigMapName, Namespace: namespace},
+				Data:       map[string]string{key: string(raw)},
+			}
+			k8sutil.SetOwnerRef(&configMap.ObjectMeta, ownerRef)
+			if _, err := configMaps.Create(configMap); err != nil {
+				if kerrors.IsAlreadyExists(err) {
+					// created by another health checker in the meantime, retry as a conflict
+					return kerrors.NewConflict(v1.Resource("configmaps"), HealthStateConfigMapName, err)
+				}
+				return errors.Wrapf(err, "failed to create configmap %q", HealthStateConfigMapName)
+			}
+			return nil
+		}
+
+		if configMap.Data[key] == string(raw) {
+			return nil
+		}
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data[key] = string(raw)
+		_, err = configMaps.Update(configMap)
+		return err
+	})
+}
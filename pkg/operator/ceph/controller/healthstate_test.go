@@ -0,0 +1,60 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestHealthState(t *testing.T) {
+	type state struct {
+		DownSince map[string]time.Time `json:"downSince,omitempty"`
+	}
+	clientset := fake.NewSimpleClientset()
+	ownerRef := ClusterOwnerRef("rook-ceph", "uid")
+
+	// nothing saved yet
+	loaded := state{}
+	assert.NoError(t, LoadHealthState(clientset, "rook-ceph", "mon", &loaded))
+	assert.Nil(t, loaded.DownSince)
+
+	// the configmap is created with the owner of the cluster
+	since := time.Date(2020, 10, 1, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, SaveHealthState(clientset, "rook-ceph", "mon", state{DownSince: map[string]time.Time{"a": since}}, &ownerRef))
+	configMap, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(HealthStateConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph", configMap.OwnerReferences[0].Name)
+
+	// the states of the checkers are kept side by side
+	assert.NoError(t, SaveHealthState(clientset, "rook-ceph", "osd", map[string]int{"outOSDs": 1}, &ownerRef))
+	assert.NoError(t, LoadHealthState(clientset, "rook-ceph", "mon", &loaded))
+	assert.True(t, since.Equal(loaded.DownSince["a"]))
+	other := map[string]int{}
+	assert.NoError(t, LoadHealthState(clientset, "rook-ceph", "osd", &other))
+	assert.Equal(t, 1, other["outOSDs"])
+
+	// the state is replaced
+	assert.NoError(t, SaveHealthState(clientset, "rook-ceph", "mon", state{}, &ownerRef))
+	loaded = state{}
+	assert.NoError(t, LoadHealthState(clientset, "rook-ceph", "mon", &loaded))
+	assert.Nil(t, loaded.DownSince)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var (
+	// cephHealthStatus is the health of the cluster seen by the last status check, 0 HEALTH_OK, 1 HEALTH_WARN and 2 HEALTH_ERR
+	cephHealthStatus = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "rook_ceph_health_status",
+		Help: "Health of the ceph cluster seen by the operator: 0 for HEALTH_OK, 1 for HEALTH_WARN and 2 for HEALTH_ERR",
+	}, []string{"namespace"})
+
+	osdOutTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_osd_out_total",
+		Help: "Number of times the operator found an osd down and marked out",
+	}, []string{"namespace"})
+
+	monFailoverTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rook_mon_failover_total",
+		Help: "Number of unhealthy mons failed over or removed by the operator",
+	}, []string{"namespace"})
+
+	healthCheckDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "rook_health_check_duration_seconds",
+		Help:    "Duration of the health checks run by the operator",
+		Buckets: prometheus.ExponentialBuckets(0.1, 2, 10),
+	}, []string{"namespace", "daemon"})
+)
+
+func init() {
+	// the metrics are served by the controller-runtime manager of the operator
+	metrics.Registry.MustRegister(cephHealthStatus, osdOutTotal, monFailoverTotal, healthCheckDuration)
+}
+
+// SetCephHealthMetric records the health of the cluster of the namespace
+func SetCephHealthMetric(namespace, health string) {
+	value := 0.0
+	switch health {
+	case "HEALTH_WARN":
+		value = 1
+	case "HEALTH_ERR":
+		value = 2
+	}
+	cephHealthStatus.WithLabelValues(namespace).Set(value)
+}
+
+// DeleteCephHealthMetric stops reporting the health of the cluster of the namespace, once it is not monitored anymore
+func DeleteCephHealthMetric(namespace string) {
+	cephHealthStatus.DeleteLabelValues(namespace)
+}
+
+// IncOSDOutMetric counts an osd found down and marked out in the cluster of the namespace
+func IncOSDOutMetric(namespace string) {
+	osdOutTotal.WithLabelValues(namespace).Inc()
+}
+
+// IncMonFailoverMetric counts an unhealthy mon failed over or removed in the cluster of the namespace
+func IncMonFailoverMetric(namespace string) {
+	monFailoverTotal.WithLabelValues(namespace).Inc()
+}
+
+// ObserveHealthCheckDuration records the duration of a health check of the daemon, started at the given time
+func ObserveHealthCheckDuration(namespace, daemon string, start time.Time) {
+	healthCheckDuration.WithLabelValues(namespace, daemon).Observe(time.Since(start).Seconds())
+}
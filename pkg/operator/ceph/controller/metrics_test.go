@@ -0,0 +1,49 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCephHealthMetric(t *testing.T) {
+	SetCephHealthMetric("metrics-ns", "HEALTH_OK")
+	assert.Equal(t, 0.0, testutil.ToFloat64(cephHealthStatus.WithLabelValues("metrics-ns")))
+	SetCephHealthMetric("metrics-ns", "HEALTH_WARN")
+	assert.Equal(t, 1.0, testutil.ToFloat64(cephHealthStatus.WithLabelValues("metrics-ns")))
+	SetCephHealthMetric("metrics-ns", "HEALTH_ERR")
+	assert.Equal(t, 2.0, testutil.ToFloat64(cephHealthStatus.WithLabelValues("metrics-ns")))
+
+	DeleteCephHealthMetric("metrics-ns")
+	assert.Equal(t, 0, testutil.CollectAndCount(cephHealthStatus))
+}
+
+func TestHealthCheckCounters(t *testing.T) {
+	IncOSDOutMetric("metrics-ns")
+	IncOSDOutMetric("metrics-ns")
+	assert.Equal(t, 2.0, testutil.ToFloat64(osdOutTotal.WithLabelValues("metrics-ns")))
+
+	IncMonFailoverMetric("metrics-ns")
+	assert.Equal(t, 1.0, testutil.ToFloat64(monFailoverTotal.WithLabelValues("metrics-ns")))
+
+	ObserveHealthCheckDuration("metrics-ns", "mon", time.Now())
+	assert.Equal(t, 1, testutil.CollectAndCount(healthCheckDuration))
+}
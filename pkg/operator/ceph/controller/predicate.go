@@ -40,6 +40,28 @@ const (
 	// Unfortunately this is a duplicate of the const EndpointConfigMapName in the mon package, but done to avoid import cycle
 	endpointConfigMapName   = "rook-ceph-mon-endpoints"
 	doNotReconcileLabelName = "do_not_reconcile"
+	// MonitoringAnnotationPrefix prefixes the CephCluster annotations enabling or disabling the health check of a daemon
+	// e.g. "ceph.rook.io/monitoring-osd: disabled"
+	MonitoringAnnotationPrefix = "ceph.rook.io/monitoring-"
+	// RemoveOSDsAnnotation is the CephCluster annotation listing the ids of the osds to decommission, comma separated
+	// e.g. "osd.rook.io/remove: 3,5"
+	RemoveOSDsAnnotation = "osd.rook.io/remove"
+	// RotateEncryptionKeysAnnotation is the CephCluster annotation requesting the rotation of the encryption keys of
+	// the encrypted osds on pvcs, the keys being rotated again each time its value changes
+	// e.g. "osd.rook.io/rotate-encryption-keys: 2020-06-01"
+	RotateEncryptionKeysAnnotation = "osd.rook.io/rotate-encryption-keys"
+	// RotateKeysAnnotation is the CephCluster annotation requesting the rotation of the keys of the admin, the daemons
+	// and the CSI drivers, the keys being rotated again each time its value changes
+	// e.g. "ceph.rook.io/rotate-keys: 2020-06-01"
+	RotateKeysAnnotation = "ceph.rook.io/rotate-keys"
+	// PausedAnnotation is the CephCluster annotation pausing the reconcile and the health remediation of the cluster
+	// like its "paused" reconcile strategy, when set to "true"
+	// e.g. "ceph.rook.io/paused: true"
+	PausedAnnotation = "ceph.rook.io/paused"
+	// RestoreMonQuorumAnnotation is the CephCluster annotation requesting the restore of the mon quorum from the
+	// single surviving mon it names, after the majority of the mons were lost
+	// e.g. "ceph.rook.io/restore-mon-quorum: b"
+	RestoreMonQuorumAnnotation = "ceph.rook.io/restore-mon-quorum"
 )
 
 // WatchControllerPredicate is a special update filter for update events
@@ -67,7 +89,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephObjectStore)
 				logger.Debug("update event on CephObjectStore CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -91,7 +113,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephObjectStoreUser)
 				logger.Debug("update event on CephObjectStoreUser CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -110,7 +132,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephObjectRealm)
 				logger.Debug("update event on CephObjectRealm")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -129,7 +151,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephObjectZoneGroup)
 				logger.Debug("update event on CephObjectZoneGroup")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -148,7 +170,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephObjectZone)
 				logger.Debug("update event on CephObjectZone")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -163,11 +185,111 @@ func WatchControllerPredicate() predicate.Funcs {
 					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
 				}
 
+			case *cephv1.CephBlockPoolRadosNamespace:
+				objNew := e.ObjectNew.(*cephv1.CephBlockPoolRadosNamespace)
+				logger.Debug("update event on CephBlockPoolRadosNamespace CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+
+			case *cephv1.CephFilesystemSubVolumeGroup:
+				objNew := e.ObjectNew.(*cephv1.CephFilesystemSubVolumeGroup)
+				logger.Debug("update event on CephFilesystemSubVolumeGroup CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+
+			case *cephv1.CephCOSIDriver:
+				objNew := e.ObjectNew.(*cephv1.CephCOSIDriver)
+				logger.Debug("update event on CephCOSIDriver CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+
+			case *cephv1.CephBucketTopic:
+				objNew := e.ObjectNew.(*cephv1.CephBucketTopic)
+				logger.Debug("update event on CephBucketTopic CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+
+			case *cephv1.CephBucketNotification:
+				objNew := e.ObjectNew.(*cephv1.CephBucketNotification)
+				logger.Debug("update event on CephBucketNotification CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+
 			case *cephv1.CephBlockPool:
 				objNew := e.ObjectNew.(*cephv1.CephBlockPool)
 				logger.Debug("update event on CephBlockPool CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -186,7 +308,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephFilesystem)
 				logger.Debug("update event on CephFilesystem CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -210,7 +332,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephNFS)
 				logger.Debug("update event on CephNFS CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -234,7 +356,32 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephRBDMirror)
 				logger.Debug("update event on CephRBDMirror CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
+				if isDoNotReconcile {
+					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
+					return false
+				}
+				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
+				if diff != "" {
+					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
+				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
+					logger.Debugf("CR %q is going be deleted", objNew.Name)
+					return true
+				} else if objOld.GetGeneration() != objNew.GetGeneration() {
+					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
+				}
+				// Handling upgrades
+				isUpgrade := isUpgrade(objOld.GetLabels(), objNew.GetLabels())
+				if isUpgrade {
+					return true
+				}
+
+			case *cephv1.CephFilesystemMirror:
+				objNew := e.ObjectNew.(*cephv1.CephFilesystemMirror)
+				logger.Debug("update event on CephFilesystemMirror CR")
+				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -242,6 +389,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				diff := cmp.Diff(objOld.Spec, objNew.Spec, resourceQtyComparer)
 				if diff != "" {
 					logger.Infof("CR has changed for %q. diff=%s", objNew.Name, diff)
+					return true
 				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
 					logger.Debugf("CR %q is going be deleted", objNew.Name)
 					return true
@@ -258,7 +406,7 @@ func WatchControllerPredicate() predicate.Funcs {
 				objNew := e.ObjectNew.(*cephv1.CephCluster)
 				logger.Debug("update event on CephCluster CR")
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(objNew.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(objNew.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objNew.Name)
 					return false
@@ -270,6 +418,24 @@ func WatchControllerPredicate() predicate.Funcs {
 				} else if objOld.GetDeletionTimestamp() != objNew.GetDeletionTimestamp() {
 					logger.Debugf("CR %q is going be deleted", objNew.Name)
 					return true
+				} else if isMonitoringAnnotationChanged(objOld.GetAnnotations(), objNew.GetAnnotations()) {
+					logger.Infof("health check annotations have changed for %q", objNew.Name)
+					return true
+				} else if objOld.GetAnnotations()[RemoveOSDsAnnotation] != objNew.GetAnnotations()[RemoveOSDsAnnotation] {
+					logger.Infof("osds to remove have changed for %q", objNew.Name)
+					return true
+				} else if objOld.GetAnnotations()[RotateEncryptionKeysAnnotation] != objNew.GetAnnotations()[RotateEncryptionKeysAnnotation] {
+					logger.Infof("encryption keys rotation has been requested for %q", objNew.Name)
+					return true
+				} else if objOld.GetAnnotations()[RotateKeysAnnotation] != objNew.GetAnnotations()[RotateKeysAnnotation] {
+					logger.Infof("keys rotation has been requested for %q", objNew.Name)
+					return true
+				} else if objOld.GetAnnotations()[PausedAnnotation] != objNew.GetAnnotations()[PausedAnnotation] {
+					logger.Infof("reconcile pause has changed for %q", objNew.Name)
+					return true
+				} else if objOld.GetAnnotations()[RestoreMonQuorumAnnotation] != objNew.GetAnnotations()[RestoreMonQuorumAnnotation] {
+					logger.Infof("mon quorum restore has been requested for %q", objNew.Name)
+					return true
 				} else if objOld.GetGeneration() != objNew.GetGeneration() {
 					logger.Debugf("skipping resource %q update with unchanged spec", objNew.Name)
 				}
@@ -359,7 +525,7 @@ func WatchPredicateForNonCRDObject(owner runtime.Object, scheme *runtime.Scheme)
 			objectName := object.GetName()
 			if match {
 				// If the labels "do_not_reconcile" is set on the object, let's not reconcile that request
-				isDoNotReconcile := isDoNotReconcile(object.GetLabels())
+				isDoNotReconcile := IsDoNotReconcile(object.GetLabels())
 				if isDoNotReconcile {
 					logger.Debugf("object %q matched on update but %q label is set, doing nothing", doNotReconcileLabelName, objectName)
 					return false
@@ -436,6 +602,22 @@ func isValidEvent(patch []byte, objectName string) bool {
 	return true
 }
 
+// isMonitoringAnnotationChanged returns whether a health check annotation was added, removed or updated
+func isMonitoringAnnotationChanged(oldAnnotations, newAnnotations map[string]string) bool {
+	for key, value := range newAnnotations {
+		if strings.HasPrefix(key, MonitoringAnnotationPrefix) && oldAnnotations[key] != value {
+			return true
+		}
+	}
+	for key := range oldAnnotations {
+		if _, ok := newAnnotations[key]; strings.HasPrefix(key, MonitoringAnnotationPrefix) && !ok {
+			return true
+		}
+	}
+
+	return false
+}
+
 func isUpgrade(oldLabels, newLabels map[string]string) bool {
 	oldLabelVal, oldLabelKeyExist := oldLabels[cephVersionLabelKey]
 	newLabelVal, newLabelKeyExist := newLabels[cephVersionLabelKey]
@@ -526,7 +708,8 @@ func isSecretToIgnoreOnUpdate(obj runtime.Object) bool {
 	return false
 }
 
-func isDoNotReconcile(labels map[string]string) bool {
+// IsDoNotReconcile returns whether the "do_not_reconcile" label is set to "true", the object being left as is
+func IsDoNotReconcile(labels map[string]string) bool {
 	value, ok := labels[doNotReconcileLabelName]
 
 	// Nothing exists
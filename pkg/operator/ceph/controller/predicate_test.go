@@ -47,7 +47,7 @@ func TestObjectChanged(t *testing.T) {
 				Size: oldReplicas,
 			},
 		},
-		Status: &cephv1.Status{
+		Status: &cephv1.CephBlockPoolStatus{
 			Phase: "",
 		},
 	}
@@ -62,7 +62,7 @@ func TestObjectChanged(t *testing.T) {
 				Size: oldReplicas,
 			},
 		},
-		Status: &cephv1.Status{
+		Status: &cephv1.CephBlockPoolStatus{
 			Phase: "",
 		},
 	}
@@ -106,6 +106,30 @@ func TestIsUpgrade(t *testing.T) {
 	assert.True(t, b, fmt.Sprintf("%v,%v", oldLabel, newLabel))
 }
 
+func TestIsMonitoringAnnotationChanged(t *testing.T) {
+	oldAnnotations := map[string]string{"foo": "bar"}
+	newAnnotations := map[string]string{"foo": "baz"}
+
+	// other annotations do nothing
+	assert.False(t, isMonitoringAnnotationChanged(oldAnnotations, newAnnotations))
+
+	// added
+	newAnnotations["ceph.rook.io/monitoring-osd"] = "disabled"
+	assert.True(t, isMonitoringAnnotationChanged(oldAnnotations, newAnnotations))
+
+	// same value do nothing
+	oldAnnotations["ceph.rook.io/monitoring-osd"] = "disabled"
+	assert.False(t, isMonitoringAnnotationChanged(oldAnnotations, newAnnotations))
+
+	// updated
+	newAnnotations["ceph.rook.io/monitoring-osd"] = "enabled"
+	assert.True(t, isMonitoringAnnotationChanged(oldAnnotations, newAnnotations))
+
+	// removed
+	delete(newAnnotations, "ceph.rook.io/monitoring-osd")
+	assert.True(t, isMonitoringAnnotationChanged(oldAnnotations, newAnnotations))
+}
+
 func TestIsValidEvent(t *testing.T) {
 	obj := "rook-ceph-mon-a"
 	valid := []byte(`{
@@ -221,16 +245,16 @@ func TestIsDoNotReconcile(t *testing.T) {
 	}
 
 	// value not present
-	b := isDoNotReconcile(l)
+	b := IsDoNotReconcile(l)
 	assert.False(t, b)
 
 	// good value wrong content
 	l["do_not_reconcile"] = "false"
-	b = isDoNotReconcile(l)
+	b = IsDoNotReconcile(l)
 	assert.False(t, b)
 
 	// good value and good content
 	l["do_not_reconcile"] = "true"
-	b = isDoNotReconcile(l)
+	b = IsDoNotReconcile(l)
 	assert.True(t, b)
 }
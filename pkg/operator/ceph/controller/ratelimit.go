@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"golang.org/x/time/rate"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+const (
+	// the operator settings of the rate limiting of the reconciles of all the controllers
+	reconcileBaseDelaySetting = "ROOK_RECONCILE_BASE_DELAY"
+	reconcileMaxDelaySetting  = "ROOK_RECONCILE_MAX_DELAY"
+	reconcileQPSSetting       = "ROOK_RECONCILE_QPS"
+	reconcileBurstSetting     = "ROOK_RECONCILE_BURST"
+	// maxConcurrentReconcilesSetting is the number of concurrent reconciles of each controller, overridden for a
+	// controller by the setting suffixed with the name of the controller, e.g.
+	// ROOK_MAX_CONCURRENT_RECONCILES_CEPH_OBJECT_CONTROLLER
+	maxConcurrentReconcilesSetting = "ROOK_MAX_CONCURRENT_RECONCILES"
+)
+
+// ReconcileSettings are the rate limiting settings of the reconciles of the controllers. The failed reconciles of a CR
+// are retried with an exponential backoff from the base delay up to the max delay, while the reconciles of all the
+// CRs of a controller are limited to a number of reconciles per second.
+type ReconcileSettings struct {
+	BaseDelay               time.Duration
+	MaxDelay                time.Duration
+	QPS                     float64
+	Burst                   int
+	MaxConcurrentReconciles int
+	// ControllerMaxConcurrentReconciles overrides the concurrent reconciles of the controllers by name
+	ControllerMaxConcurrentReconciles map[string]int
+}
+
+// reconcileSettings are the settings of the controllers added to the manager, loaded with the operator settings
+var reconcileSettings = DefaultReconcileSettings()
+
+// DefaultReconcileSettings returns the default rate limiting of the reconciles, a failing CR being retried after a
+// second and then up to every 10 minutes
+func DefaultReconcileSettings() ReconcileSettings {
+	return ReconcileSettings{
+		BaseDelay:                         time.Second,
+		MaxDelay:                          10 * time.Minute,
+		QPS:                               10,
+		Burst:                             100,
+		MaxConcurrentReconciles:           1,
+		ControllerMaxConcurrentReconciles: map[string]int{},
+	}
+}
+
+// LoadReconcileSettings loads the rate limiting settings of the reconciles from the operator settings, the invalid
+// settings keeping their default. The settings apply to the controllers added to the manager afterwards.
+func LoadReconcileSettings(clientset kubernetes.Interface) ReconcileSettings {
+	settings := DefaultReconcileSettings()
+	values := operatorSettingValues(clientset)
+
+	parseDuration(values, reconcileBaseDelaySetting, &settings.BaseDelay)
+	parseDuration(values, reconcileMaxDelaySetting, &settings.MaxDelay)
+	if value, ok := values[reconcileQPSSetting]; ok {
+		if qps, err := strconv.ParseFloat(value, 64); err != nil || qps <= 0 {
+			logger.Warningf("invalid %s %q, using %v", reconcileQPSSetting, value, settings.QPS)
+		} else {
+			settings.QPS = qps
+		}
+	}
+	parsePositiveInt(values, reconcileBurstSetting, &settings.Burst)
+	parsePositiveInt(values, maxConcurrentReconcilesSetting, &settings.MaxConcurrentReconciles)
+	for key := range values {
+		if strings.HasPrefix(key, maxConcurrentReconcilesSetting+"_") {
+			count := settings.MaxConcurrentReconciles
+			parsePositiveInt(values, key, &count)
+			settings.ControllerMaxConcurrentReconciles[strings.TrimPrefix(key, maxConcurrentReconcilesSetting+"_")] = count
+		}
+	}
+	if settings.MaxDelay < settings.BaseDelay {
+		logger.Warningf("%s %q is lower than %s %q, using %q", reconcileMaxDelaySetting, settings.MaxDelay.String(), reconcileBaseDelaySetting, settings.BaseDelay.String(), settings.BaseDelay.String())
+		settings.MaxDelay = settings.BaseDelay
+	}
+	return settings
+}
+
+// SetReconcileSettings sets the rate limiting settings of the controllers added to the manager afterwards
+func SetReconcileSettings(settings ReconcileSettings) {
+	logger.Infof("reconciles retried after %s up to %s, limited to %v reconciles per second (burst %d)", settings.BaseDelay.String(), settings.MaxDelay.String(), settings.QPS, settings.Burst)
+	reconcileSettings = settings
+}
+
+// ControllerOptions returns the options of a controller, with the rate limiting and the concurrent reconciles of the
+// reconcile settings
+func ControllerOptions(controllerName string, r reconcile.Reconciler) controller.Options {
+	maxConcurrentReconciles := reconcileSettings.MaxConcurrentReconciles
+	if count, ok := reconcileSettings.ControllerMaxConcurrentReconciles[controllerSettingName(controllerName)]; ok {
+		maxConcurrentReconciles = count
+	}
+	return controller.Options{
+		Reconciler:              r,
+		MaxConcurrentReconciles: maxConcurrentReconciles,
+		RateLimiter:             reconcileSettings.rateLimiter(),
+	}
+}
+
+// rateLimiter returns the rate limiter of the reconciles of a controller, the slowest of the per-CR exponential
+// backoff and of the rate of the controller
+func (s ReconcileSettings) rateLimiter() workqueue.RateLimiter {
+	return workqueue.NewMaxOfRateLimiter(
+		workqueue.NewItemExponentialFailureRateLimiter(s.BaseDelay, s.MaxDelay),
+		&workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(s.QPS), s.Burst)},
+	)
+}
+
+// controllerSettingName returns the suffix of the settings of a controller, e.g. CEPH_OBJECT_CONTROLLER for the
+// ceph-object-controller
+func controllerSettingName(controllerName string) string {
+	return strings.ToUpper(strings.Replace(controllerName, "-", "_", -1))
+}
+
+// operatorSettingValues returns the ROOK_ settings of the operator env vars, overridden by the operator configmap
+func operatorSettingValues(clientset kubernetes.Interface) map[string]string {
+	values := map[string]string{}
+	for _, env := range os.Environ() {
+		if parts := strings.SplitN(env, "=", 2); len(parts) == 2 && strings.HasPrefix(parts[0], "ROOK_") {
+			values[parts[0]] = parts[1]
+		}
+	}
+	cm, err := clientset.CoreV1().ConfigMaps(os.Getenv(k8sutil.PodNamespaceEnvVar)).Get(OperatorSettingConfigMapName, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			logger.Warningf("failed to read the operator settings from configmap %q, using the env vars and the defaults. %v", OperatorSettingConfigMapName, err)
+		}
+		return values
+	}
+	for key, value := range cm.Data {
+		values[key] = value
+	}
+	return values
+}
+
+func parseDuration(values map[string]string, key string, duration *time.Duration) {
+	value, ok := values[key]
+	if !ok {
+		return
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed <= 0 {
+		logger.Warningf("invalid %s %q, using %q", key, value, duration.String())
+		return
+	}
+	*duration = parsed
+}
+
+func parsePositiveInt(values map[string]string, key string, result *int) {
+	value, ok := values[key]
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		logger.Warningf("invalid %s %q, using %d", key, value, *result)
+		return
+	}
+	*result = parsed
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestLoadReconcileSettings(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	clientset := fake.NewSimpleClientset()
+
+	// the defaults without settings
+	settings := LoadReconcileSettings(clientset)
+	assert.Equal(t, DefaultReconcileSettings(), settings)
+
+	// the env vars are overridden by the configmap
+	os.Setenv(reconcileBaseDelaySetting, "2s")
+	defer os.Unsetenv(reconcileBaseDelaySetting)
+	os.Setenv(reconcileQPSSetting, "5")
+	defer os.Unsetenv(reconcileQPSSetting)
+	_, err := clientset.CoreV1().ConfigMaps("rook-ceph").Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: OperatorSettingConfigMapName, Namespace: "rook-ceph"},
+		Data: map[string]string{
+			reconcileQPSSetting:                                     "20",
+			reconcileMaxDelaySetting:                                "1m",
+			reconcileBurstSetting:                                   "invalid",
+			"ROOK_MAX_CONCURRENT_RECONCILES":                        "2",
+			"ROOK_MAX_CONCURRENT_RECONCILES_CEPH_OBJECT_CONTROLLER": "4",
+		},
+	})
+	assert.NoError(t, err)
+	settings = LoadReconcileSettings(clientset)
+	assert.Equal(t, 2*time.Second, settings.BaseDelay)
+	assert.Equal(t, time.Minute, settings.MaxDelay)
+	assert.Equal(t, float64(20), settings.QPS)
+	assert.Equal(t, 100, settings.Burst)
+	assert.Equal(t, 2, settings.MaxConcurrentReconciles)
+	assert.Equal(t, map[string]int{"CEPH_OBJECT_CONTROLLER": 4}, settings.ControllerMaxConcurrentReconciles)
+}
+
+func TestControllerOptions(t *testing.T) {
+	defer SetReconcileSettings(DefaultReconcileSettings())
+	settings := DefaultReconcileSettings()
+	settings.MaxConcurrentReconciles = 2
+	settings.ControllerMaxConcurrentReconciles["CEPH_OBJECT_CONTROLLER"] = 4
+	SetReconcileSettings(settings)
+	r := reconcile.Func(func(reconcile.Request) (reconcile.Result, error) { return reconcile.Result{}, nil })
+
+	assert.Equal(t, 4, ControllerOptions("ceph-object-controller", r).MaxConcurrentReconciles)
+	options := ControllerOptions("ceph-block-pool-controller", r)
+	assert.Equal(t, 2, options.MaxConcurrentReconciles)
+
+	// the failures of a CR are retried with an exponential backoff
+	request := reconcile.Request{}
+	assert.Equal(t, time.Second, options.RateLimiter.When(request))
+	assert.Equal(t, 2*time.Second, options.RateLimiter.When(request))
+	assert.Equal(t, 4*time.Second, options.RateLimiter.When(request))
+	options.RateLimiter.Forget(request)
+	assert.Equal(t, time.Second, options.RateLimiter.When(request))
+}
@@ -23,6 +23,8 @@ import (
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
@@ -43,6 +45,13 @@ const (
 	initialDelaySecondsOSDDaemon    int32 = 45
 )
 
+const (
+	// LogCollectorContainerName is the name of the sidecar rotating the log file of a daemon
+	LogCollectorContainerName = "log-collector"
+	// the log file is checked more often than the shortest periodicity so that maxLogSize is enforced
+	logCollectorInterval = "15m"
+)
+
 type daemonConfig struct {
 	daemonType string
 	daemonID   string
@@ -465,6 +474,53 @@ func StoredLogAndCrashVolumeMount(varLogCephDir, varLibCephCrashDir string) []v1
 	}
 }
 
+// LogCollectorContainer returns the sidecar rotating the log file of the daemon with the given ceph name (such as
+// "mon.a" or "osd.0") with logrotate. The file is truncated in place since the daemon cannot be signaled from the
+// sidecar, and the state of logrotate is kept next to the logs so that the periodicity survives the restarts.
+func LogCollectorContainer(cephName, image string, logCollector cephv1.LogCollectorSpec, securityContext *v1.SecurityContext) v1.Container {
+	periodicity := logCollector.Periodicity
+	if periodicity == "" {
+		periodicity = "daily"
+	}
+	maxSize := ""
+	if logCollector.MaxLogSize != nil {
+		maxSize = fmt.Sprintf("maxsize %d", logCollector.MaxLogSize.Value())
+	}
+	logFile := path.Join(config.VarLogCephDir, fmt.Sprintf("ceph-%s.log", cephName))
+	stateFile := path.Join(config.VarLogCephDir, fmt.Sprintf("logrotate-%s.status", cephName))
+
+	script := `
+set -xe
+
+cat << EOF > /tmp/logrotate.conf
+` + logFile + ` {
+    ` + periodicity + `
+    ` + maxSize + `
+    rotate 7
+    compress
+    delaycompress
+    missingok
+    notifempty
+    copytruncate
+}
+EOF
+
+while true; do
+    logrotate --verbose --state ` + stateFile + ` /tmp/logrotate.conf
+    sleep ` + logCollectorInterval + `
+done
+`
+	return v1.Container{
+		Name:    LogCollectorContainerName,
+		Command: []string{"/bin/bash", "-c", script},
+		Image:   image,
+		VolumeMounts: []v1.VolumeMount{
+			{Name: logVolumeName, MountPath: config.VarLogCephDir},
+		},
+		SecurityContext: securityContext,
+	}
+}
+
 // GenerateLivenessProbeExecDaemon makes sure a daemon has a socket and that it can be called and returns 0
 func GenerateLivenessProbeExecDaemon(daemonType, daemonID string) *v1.Probe {
 	confDaemon := getDaemonConfig(daemonType, daemonID)
@@ -517,3 +573,91 @@ func (c *daemonConfig) buildAdminSocketCommand() string {
 
 	return command
 }
+
+// ApplyServiceSpec applies the customization of a service from the settings of the daemons it exposes
+func ApplyServiceSpec(service *v1.Service, spec *cephv1.ServiceSpec) {
+	if spec == nil {
+		return
+	}
+	if spec.Type != "" {
+		service.Spec.Type = spec.Type
+	}
+	if len(spec.Annotations) > 0 {
+		if service.Annotations == nil {
+			service.Annotations = map[string]string{}
+		}
+		for key, value := range spec.Annotations {
+			service.Annotations[key] = value
+		}
+	}
+	service.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
+}
+
+// ApplyDaemonOverrides adds the environment variables and the extra volumes of the daemon from the cluster spec to all
+// the containers of the pod. The variables, the volumes and the mount paths of the daemon itself are kept over the
+// overrides with the same name or path.
+func ApplyDaemonOverrides(podSpec *v1.PodSpec, env cephv1.DaemonEnvSpec, volumeMounts cephv1.DaemonVolumeMountsSpec, key rookv1.KeyType) {
+	mounts := []v1.VolumeMount{}
+	for _, mount := range cephv1.GetDaemonVolumeMounts(volumeMounts, key) {
+		if hasVolume(podSpec.Volumes, mount.Name) {
+			logger.Warningf("skipping extra volume %q of the %s daemons, the daemons already have a volume with the same name", mount.Name, key)
+			continue
+		}
+		podSpec.Volumes = append(podSpec.Volumes, mount.Volume())
+		mounts = append(mounts, mount.VolumeMount())
+	}
+	envVars := cephv1.GetDaemonEnv(env, key)
+	if len(envVars) == 0 && len(mounts) == 0 {
+		return
+	}
+
+	for i := range podSpec.InitContainers {
+		applyContainerOverrides(&podSpec.InitContainers[i], envVars, mounts)
+	}
+	for i := range podSpec.Containers {
+		applyContainerOverrides(&podSpec.Containers[i], envVars, mounts)
+	}
+}
+
+// see ApplyDaemonOverrides
+func applyContainerOverrides(c *v1.Container, envVars []v1.EnvVar, mounts []v1.VolumeMount) {
+	for _, envVar := range envVars {
+		if !hasEnvVar(c.Env, envVar.Name) {
+			c.Env = append(c.Env, envVar)
+		}
+	}
+	for _, mount := range mounts {
+		if hasMountPath(c.VolumeMounts, mount.MountPath) {
+			logger.Warningf("skipping mount of extra volume %q in container %q, the path %q is already mounted", mount.Name, c.Name, mount.MountPath)
+			continue
+		}
+		c.VolumeMounts = append(c.VolumeMounts, mount)
+	}
+}
+
+func hasVolume(volumes []v1.Volume, name string) bool {
+	for _, volume := range volumes {
+		if volume.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasEnvVar(env []v1.EnvVar, name string) bool {
+	for _, envVar := range env {
+		if envVar.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func hasMountPath(mounts []v1.VolumeMount, mountPath string) bool {
+	for _, mount := range mounts {
+		if path.Clean(mount.MountPath) == path.Clean(mountPath) {
+			return true
+		}
+	}
+	return false
+}
@@ -20,6 +20,7 @@ import (
 	"math"
 	"testing"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/config"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -153,3 +154,76 @@ func TestGenerateLivenessProbeExecDaemon(t *testing.T) {
 	probe = GenerateLivenessProbeExecDaemon(config.MonType, "a")
 	assert.Equal(t, initialDelaySecondsNonOSDDaemon, probe.InitialDelaySeconds)
 }
+
+func TestLogCollectorContainer(t *testing.T) {
+	logCollector := cephv1.LogCollectorSpec{Enabled: true}
+	c := LogCollectorContainer("mon.a", "ceph/ceph:v15.2.4", logCollector, &v1.SecurityContext{})
+	assert.Equal(t, LogCollectorContainerName, c.Name)
+	assert.Equal(t, "ceph/ceph:v15.2.4", c.Image)
+	assert.Equal(t, logVolumeName, c.VolumeMounts[0].Name)
+	assert.Equal(t, "/var/log/ceph", c.VolumeMounts[0].MountPath)
+	script := c.Command[2]
+	assert.Contains(t, script, "/var/log/ceph/ceph-mon.a.log {")
+	assert.Contains(t, script, "daily")
+	assert.Contains(t, script, "--state /var/log/ceph/logrotate-mon.a.status")
+	assert.NotContains(t, script, "maxsize")
+
+	maxLogSize := resource.MustParse("500Mi")
+	logCollector.Periodicity = "weekly"
+	logCollector.MaxLogSize = &maxLogSize
+	script = LogCollectorContainer("osd.0", "ceph/ceph:v15.2.4", logCollector, &v1.SecurityContext{}).Command[2]
+	assert.Contains(t, script, "/var/log/ceph/ceph-osd.0.log {")
+	assert.Contains(t, script, "weekly")
+	assert.Contains(t, script, "maxsize 524288000")
+}
+
+func TestApplyDaemonOverrides(t *testing.T) {
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "chown"}},
+		Containers: []v1.Container{
+			{
+				Name:         "mgr",
+				Env:          []v1.EnvVar{{Name: "ROOK_POD_IP", Value: "1.2.3.4"}},
+				VolumeMounts: []v1.VolumeMount{{Name: "ceph-daemon-data", MountPath: "/var/lib/ceph/mgr/ceph-a"}},
+			},
+		},
+		Volumes: []v1.Volume{{Name: "ceph-daemon-data"}},
+	}
+	env := cephv1.DaemonEnvSpec{
+		"all": {{Name: "HTTPS_PROXY", Value: "http://proxy:3128"}},
+		"mgr": {{Name: "ROOK_POD_IP", Value: "5.6.7.8"}},
+	}
+	volumeMounts := cephv1.DaemonVolumeMountsSpec{
+		"mgr": {
+			{Name: "ca", MountPath: "/etc/pki/ca", ReadOnly: true, Secret: &v1.SecretVolumeSource{SecretName: "ca"}},
+			{Name: "ceph-daemon-data", MountPath: "/data", HostPath: &v1.HostPathVolumeSource{Path: "/data"}},
+			{Name: "tools", MountPath: "/var/lib/ceph/mgr/ceph-a/", HostPath: &v1.HostPathVolumeSource{Path: "/opt/tools"}},
+		},
+	}
+
+	// nothing to apply to the other daemons
+	osdPodSpec := *podSpec.DeepCopy()
+	ApplyDaemonOverrides(&osdPodSpec, nil, volumeMounts, cephv1.KeyOSD)
+	assert.Equal(t, podSpec, osdPodSpec)
+
+	ApplyDaemonOverrides(&podSpec, env, volumeMounts, cephv1.KeyMgr)
+
+	// the volume with the name of a volume of the daemon is skipped
+	assert.Equal(t, 3, len(podSpec.Volumes))
+	assert.Equal(t, "ca", podSpec.Volumes[1].Name)
+	assert.Equal(t, "ca", podSpec.Volumes[1].Secret.SecretName)
+	assert.Equal(t, "tools", podSpec.Volumes[2].Name)
+
+	// the variables of the daemon are kept
+	mgr := podSpec.Containers[0]
+	assert.Equal(t, []v1.EnvVar{{Name: "ROOK_POD_IP", Value: "1.2.3.4"}, {Name: "HTTPS_PROXY", Value: "http://proxy:3128"}}, mgr.Env)
+
+	// the volume is not mounted over a path of the daemon
+	assert.Equal(t, 2, len(mgr.VolumeMounts))
+	assert.Equal(t, v1.VolumeMount{Name: "ca", MountPath: "/etc/pki/ca", ReadOnly: true}, mgr.VolumeMounts[1])
+
+	// the init containers get the overrides as well
+	chown := podSpec.InitContainers[0]
+	assert.Equal(t, 2, len(chown.Env))
+	assert.Equal(t, 2, len(chown.VolumeMounts))
+}
@@ -36,6 +36,19 @@ func ValidateCephVersionsBetweenLocalAndExternalClusters(context *clusterd.Conte
 	return *externalVersion, cephver.ValidateCephVersionsBetweenLocalAndExternalClusters(localVersion, *externalVersion)
 }
 
+// GetClusterCephVersion returns the version of the ceph cluster of the CephCluster: the version of the mons of the
+// external cluster when connected to one, since it may run without an image, otherwise the version of its image
+func GetClusterCephVersion(cephCluster cephv1.CephCluster) (*cephver.CephVersion, error) {
+	if cephCluster.Spec.External.Enable {
+		if cephCluster.Status.ExternalCephVersion == "" {
+			return nil, errors.New("the ceph version of the external cluster was not detected yet")
+		}
+		return ExtractCephVersionFromLabel(cephCluster.Status.ExternalCephVersion)
+	}
+
+	return GetImageVersion(cephCluster)
+}
+
 // GetImageVersion returns the CephVersion registered for a specified image (if any) and whether any image was found.
 func GetImageVersion(cephCluster cephv1.CephCluster) (*cephver.CephVersion, error) {
 	// If the Ceph cluster has not yet recorded the image and version for the current image in its spec, then the Crash
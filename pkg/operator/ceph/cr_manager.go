@@ -17,20 +17,37 @@ limitations under the License.
 package operator
 
 import (
+	"os"
+
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
+const (
+	// metricsBindAddressEnvVar overrides the address of the metrics endpoint of the operator, "0" disables it
+	metricsBindAddressEnvVar = "ROOK_OPERATOR_METRICS_BIND_ADDRESS"
+	// defaultMetricsBindAddress is the default address of the metrics endpoint of the operator
+	defaultMetricsBindAddress = ":8080"
+	// healthProbeBindAddressEnvVar overrides the address of the /healthz and /readyz endpoints of the operator, "0"
+	// disables them
+	healthProbeBindAddressEnvVar = "ROOK_OPERATOR_HEALTH_PROBE_BIND_ADDRESS"
+	// defaultHealthProbeBindAddress is the default address of the health endpoints of the operator
+	defaultHealthProbeBindAddress = ":8081"
+)
+
 func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{},
 	mgrErrorCh chan error) {
 	// Set up a manager
 	mgrOpts := manager.Options{
-		LeaderElection: false,
-		Namespace:      namespaceToWatch,
+		LeaderElection:         false,
+		Namespace:              namespaceToWatch,
+		MetricsBindAddress:     metricsBindAddress(),
+		HealthProbeBindAddress: healthProbeBindAddress(),
 	}
 
 	logger.Info("setting up the controller-runtime manager")
@@ -46,6 +63,17 @@ func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{},
 		return
 	}
 
+	// The operator is unhealthy when it fails to renew the lease of a provisioner, the provisioner running in another
+	// replica once the lease expired
+	if err := mgr.AddHealthzCheck("provisioners", o.clusterController.LeaderElections().Check); err != nil {
+		mgrErrorCh <- errors.Wrap(err, "failed to add the health check of the provisioners")
+		return
+	}
+	if err := mgr.AddReadyzCheck("ping", healthz.Ping); err != nil {
+		mgrErrorCh <- errors.Wrap(err, "failed to add the readiness check of the operator")
+		return
+	}
+
 	// options to pass to the controllers
 	controllerOpts := &controllerconfig.Context{
 		RookImage:         o.rookImage,
@@ -66,3 +94,19 @@ func (o *Operator) startManager(namespaceToWatch string, stopCh <-chan struct{},
 		return
 	}
 }
+
+// metricsBindAddress returns the address the metrics of the operator are served on
+func metricsBindAddress() string {
+	if address := os.Getenv(metricsBindAddressEnvVar); address != "" {
+		return address
+	}
+	return defaultMetricsBindAddress
+}
+
+// healthProbeBindAddress returns the address the health endpoints of the operator are served on
+func healthProbeBindAddress() string {
+	if address := os.Getenv(healthProbeBindAddressEnvVar); address != "" {
+		return address
+	}
+	return defaultHealthProbeBindAddress
+}
@@ -33,11 +33,26 @@ import (
 
 var (
 	logger = capnslog.NewPackageLogger("github.com/rook/rook", "ceph-csi")
+
+	// ConfigMutex prevents the csi config map from being updated by several clusters or controllers simultaneously
+	ConfigMutex = &sync.Mutex{}
 )
 
 type csiClusterConfigEntry struct {
 	ClusterID string   `json:"clusterID"`
 	Monitors  []string `json:"monitors"`
+	// RadosNamespace restricts the rbd images of the clusterID to a RADOS namespace of the pools
+	RadosNamespace string `json:"radosNamespace,omitempty"`
+	// CephFS has the cephfs settings of the clusterID
+	CephFS *csiCephFSConfig `json:"cephFS,omitempty"`
+	// Namespace is the namespace of the cluster of a RADOS namespace or subvolume group entry, its monitors
+	// following the cluster
+	Namespace string `json:"namespace,omitempty"`
+}
+
+type csiCephFSConfig struct {
+	// SubvolumeGroup is the subvolume group of the subvolumes of the clusterID
+	SubvolumeGroup string `json:"subvolumeGroup,omitempty"`
 }
 
 type csiClusterConfig []csiClusterConfigEntry
@@ -106,7 +121,10 @@ func UpdateCsiClusterConfig(
 			centry.Monitors = monEndpoints(mons)
 			found = true
 			cc[i] = centry
-			break
+		} else if centry.Namespace == clusterKey {
+			// the RADOS namespaces of the cluster
+			centry.Monitors = monEndpoints(mons)
+			cc[i] = centry
 		}
 	}
 	if !found {
@@ -117,6 +135,68 @@ func UpdateCsiClusterConfig(
 	return formatCsiClusterConfig(cc)
 }
 
+// UpdateCsiRadosNamespaceConfig returns a json-formatted string containing
+// the csi cluster config with the entry of a RADOS namespace of a cluster,
+// added or updated with the monitors of the cluster.
+func UpdateCsiRadosNamespaceConfig(
+	curr, clusterID, clusterNamespace, radosNamespace string, mons map[string]*cephconfig.MonInfo) (string, error) {
+
+	return updateCsiClusterConfigEntry(curr, csiClusterConfigEntry{
+		ClusterID:      clusterID,
+		Monitors:       monEndpoints(mons),
+		RadosNamespace: radosNamespace,
+		Namespace:      clusterNamespace,
+	})
+}
+
+// UpdateCsiSubvolumeGroupConfig returns a json-formatted string containing
+// the csi cluster config with the entry of a subvolume group of a cluster,
+// added or updated with the monitors of the cluster.
+func UpdateCsiSubvolumeGroupConfig(
+	curr, clusterID, clusterNamespace, subvolumeGroup string, mons map[string]*cephconfig.MonInfo) (string, error) {
+
+	return updateCsiClusterConfigEntry(curr, csiClusterConfigEntry{
+		ClusterID: clusterID,
+		Monitors:  monEndpoints(mons),
+		CephFS:    &csiCephFSConfig{SubvolumeGroup: subvolumeGroup},
+		Namespace: clusterNamespace,
+	})
+}
+
+// updateCsiClusterConfigEntry adds or replaces the entry of the same clusterID in the csi cluster config
+func updateCsiClusterConfigEntry(curr string, centry csiClusterConfigEntry) (string, error) {
+	cc, err := parseCsiClusterConfig(curr)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse current csi cluster config")
+	}
+
+	for i := range cc {
+		if cc[i].ClusterID == centry.ClusterID {
+			cc[i] = centry
+			return formatCsiClusterConfig(cc)
+		}
+	}
+	cc = append(cc, centry)
+	return formatCsiClusterConfig(cc)
+}
+
+// RemoveCsiClusterConfig returns a json-formatted string containing
+// the csi cluster config without the entry of the clusterID.
+func RemoveCsiClusterConfig(curr, clusterID string) (string, error) {
+	cc, err := parseCsiClusterConfig(curr)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse current csi cluster config")
+	}
+
+	for i := range cc {
+		if cc[i].ClusterID == clusterID {
+			cc = append(cc[:i], cc[i+1:]...)
+			break
+		}
+	}
+	return formatCsiClusterConfig(cc)
+}
+
 // CreateCsiConfigMap creates an empty config map that will be later used
 // to provide cluster configuration to ceph-csi. If a config map already
 // exists, it will return it.
@@ -154,6 +234,46 @@ func SaveClusterConfig(
 	clientset kubernetes.Interface, clusterNamespace string,
 	clusterInfo *cephconfig.ClusterInfo, l sync.Locker) error {
 
+	return saveCsiConfig(clientset, func(curr string) (string, error) {
+		return UpdateCsiClusterConfig(curr, clusterNamespace, clusterInfo.Monitors)
+	}, l)
+}
+
+// SaveRadosNamespaceConfig adds or updates the entry of a RADOS namespace
+// of a cluster in the config map of ceph-csi. The clusterID identifies the
+// entry and is the value expected in the storage classes of the namespace.
+func SaveRadosNamespaceConfig(
+	clientset kubernetes.Interface, clusterNamespace, clusterID, radosNamespace string,
+	clusterInfo *cephconfig.ClusterInfo, l sync.Locker) error {
+
+	return saveCsiConfig(clientset, func(curr string) (string, error) {
+		return UpdateCsiRadosNamespaceConfig(curr, clusterID, clusterNamespace, radosNamespace, clusterInfo.Monitors)
+	}, l)
+}
+
+// SaveSubvolumeGroupConfig adds or updates the entry of a subvolume group
+// of a cluster in the config map of ceph-csi. The clusterID identifies the
+// entry and is the value expected in the storage classes of the group.
+func SaveSubvolumeGroupConfig(
+	clientset kubernetes.Interface, clusterNamespace, clusterID, subvolumeGroup string,
+	clusterInfo *cephconfig.ClusterInfo, l sync.Locker) error {
+
+	return saveCsiConfig(clientset, func(curr string) (string, error) {
+		return UpdateCsiSubvolumeGroupConfig(curr, clusterID, clusterNamespace, subvolumeGroup, clusterInfo.Monitors)
+	}, l)
+}
+
+// RemoveClusterConfig removes the entry of a clusterID, e.g. of a RADOS
+// namespace or a subvolume group, from the config map of ceph-csi.
+func RemoveClusterConfig(clientset kubernetes.Interface, clusterID string, l sync.Locker) error {
+	return saveCsiConfig(clientset, func(curr string) (string, error) {
+		return RemoveCsiClusterConfig(curr, clusterID)
+	}, l)
+}
+
+// saveCsiConfig updates the contents of the config map of ceph-csi with the
+// update function, creating the config map if needed.
+func saveCsiConfig(clientset kubernetes.Interface, update func(curr string) (string, error), l sync.Locker) error {
 	if !CSIEnabled() {
 		return nil
 	}
@@ -166,11 +286,26 @@ func SaveClusterConfig(
 	}
 	logger.Debugf("Using %+v for CSI ConfigMap Namespace", csiNamespace)
 
-	// fetch current ConfigMap contents
+	// fetch current ConfigMap contents, recreating it if it was deleted
+	// since the operator created it
 	configMap, err := clientset.CoreV1().ConfigMaps(csiNamespace).Get(
 		ConfigName, metav1.GetOptions{})
+	create := false
 	if err != nil {
-		return errors.Wrap(err, "failed to fetch current csi config map")
+		if !k8serrors.IsNotFound(err) {
+			return errors.Wrap(err, "failed to fetch current csi config map")
+		}
+		logger.Warningf("csi config map %q not found, creating it again", ConfigName)
+		configMap = &v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      ConfigName,
+				Namespace: csiNamespace,
+			},
+		}
+		create = true
+	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
 	}
 
 	// update ConfigMap contents for current cluster
@@ -178,13 +313,22 @@ func SaveClusterConfig(
 	if currData == "" {
 		currData = "[]"
 	}
-	newData, err := UpdateCsiClusterConfig(
-		currData, clusterNamespace, clusterInfo.Monitors)
+	newData, err := update(currData)
 	if err != nil {
 		return errors.Wrap(err, "failed to update csi config map data")
 	}
+	if newData == configMap.Data[ConfigKey] && !create {
+		return nil
+	}
 	configMap.Data[ConfigKey] = newData
 
+	if create {
+		if _, err := clientset.CoreV1().ConfigMaps(csiNamespace).Create(configMap); err != nil {
+			return errors.Wrap(err, "failed to create csi config map")
+		}
+		return nil
+	}
+
 	// update ConfigMap with new contents
 	if _, err := clientset.CoreV1().ConfigMaps(csiNamespace).Update(configMap); err != nil {
 		return errors.Wrapf(err, "failed to update csi config map")
@@ -17,10 +17,15 @@ limitations under the License.
 package csi
 
 import (
+	"os"
+	"sync"
 	"testing"
 
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestUpdateCsiClusterConfig(t *testing.T) {
@@ -87,3 +92,79 @@ func TestUpdateCsiClusterConfig(t *testing.T) {
 	_, err = UpdateCsiClusterConfig("qqq", "beta", mons2)
 	assert.Error(t, err)
 }
+
+func TestSaveClusterConfig(t *testing.T) {
+	EnableRBD = true
+	defer func() { EnableRBD = false }()
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	clientset := test.New(t, 1)
+	clusterInfo := &cephconfig.ClusterInfo{
+		Monitors: map[string]*cephconfig.MonInfo{"a": {Name: "a", Endpoint: "1.2.3.4:6789"}},
+	}
+	assert.NoError(t, CreateCsiConfigMap("rook-ceph", clientset, nil))
+	assert.NoError(t, SaveClusterConfig(clientset, "ns", clusterInfo, &sync.Mutex{}))
+	cm, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["1.2.3.4:6789"]}]`, cm.Data[ConfigKey])
+
+	// the config map is created again if it was deleted
+	assert.NoError(t, clientset.CoreV1().ConfigMaps("rook-ceph").Delete(ConfigName, &metav1.DeleteOptions{}))
+	assert.NoError(t, SaveClusterConfig(clientset, "ns", clusterInfo, &sync.Mutex{}))
+	cm, err = clientset.CoreV1().ConfigMaps("rook-ceph").Get(ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["1.2.3.4:6789"]}]`, cm.Data[ConfigKey])
+}
+
+func TestSaveRadosNamespaceConfig(t *testing.T) {
+	EnableRBD = true
+	defer func() { EnableRBD = false }()
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	clientset := test.New(t, 1)
+	clusterInfo := &cephconfig.ClusterInfo{
+		Monitors: map[string]*cephconfig.MonInfo{"a": {Name: "a", Endpoint: "1.2.3.4:6789"}},
+	}
+	assert.NoError(t, CreateCsiConfigMap("rook-ceph", clientset, nil))
+	assert.NoError(t, SaveClusterConfig(clientset, "ns", clusterInfo, &sync.Mutex{}))
+	assert.NoError(t, SaveRadosNamespaceConfig(clientset, "ns", "abc", "tenant", clusterInfo, &sync.Mutex{}))
+	cm, err := clientset.CoreV1().ConfigMaps("rook-ceph").Get(ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["1.2.3.4:6789"]},{"clusterID":"abc","monitors":["1.2.3.4:6789"],"radosNamespace":"tenant","namespace":"ns"}]`, cm.Data[ConfigKey])
+
+	// the monitors of the namespace follow the cluster
+	clusterInfo.Monitors = map[string]*cephconfig.MonInfo{"b": {Name: "b", Endpoint: "5.6.7.8:6789"}}
+	assert.NoError(t, SaveClusterConfig(clientset, "ns", clusterInfo, &sync.Mutex{}))
+	cm, err = clientset.CoreV1().ConfigMaps("rook-ceph").Get(ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["5.6.7.8:6789"]},{"clusterID":"abc","monitors":["5.6.7.8:6789"],"radosNamespace":"tenant","namespace":"ns"}]`, cm.Data[ConfigKey])
+
+	// the entry of the namespace is removed
+	assert.NoError(t, RemoveClusterConfig(clientset, "abc", &sync.Mutex{}))
+	cm, err = clientset.CoreV1().ConfigMaps("rook-ceph").Get(ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["5.6.7.8:6789"]}]`, cm.Data[ConfigKey])
+}
+
+func TestUpdateCsiSubvolumeGroupConfig(t *testing.T) {
+	mons := map[string]*cephconfig.MonInfo{"a": {Name: "a", Endpoint: "1.2.3.4:6789"}}
+	s, err := UpdateCsiSubvolumeGroupConfig(`[{"clusterID":"ns","monitors":["1.2.3.4:6789"]}]`, "abc", "ns", "group1", mons)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["1.2.3.4:6789"]},{"clusterID":"abc","monitors":["1.2.3.4:6789"],"cephFS":{"subvolumeGroup":"group1"},"namespace":"ns"}]`, s)
+
+	// the entry of the group is replaced
+	mons["b"] = &cephconfig.MonInfo{Name: "b", Endpoint: "5.6.7.8:6789"}
+	s, err = UpdateCsiSubvolumeGroupConfig(s, "abc", "ns", "group1", mons)
+	assert.NoError(t, err)
+	cc, err := parseCsiClusterConfig(s)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(cc))
+	assert.Equal(t, "group1", cc[1].CephFS.SubvolumeGroup)
+	assert.Equal(t, 2, len(cc[1].Monitors))
+
+	s, err = RemoveCsiClusterConfig(s, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"ns","monitors":["1.2.3.4:6789"]}]`, s)
+}
@@ -27,10 +27,17 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// validatedImage is the last ceph-csi image of a successful version validation. The version job is not run again
+// when the drivers are reconciled with the same image.
+var validatedImage string
+
 func ValidateAndConfigureDrivers(clientset kubernetes.Interface, namespace, rookImage, securityAccount string, serverVersion *version.Info, ownerRef *metav1.OwnerReference) {
-	if err := validateCSIVersion(clientset, namespace, rookImage, securityAccount, ownerRef); err != nil {
-		logger.Errorf("invalid csi version. %+v", err)
-		return
+	if CSIParam.CSIPluginImage != validatedImage {
+		if err := validateCSIVersion(clientset, namespace, rookImage, securityAccount, ownerRef); err != nil {
+			logger.Errorf("invalid csi version. %+v", err)
+			return
+		}
+		validatedImage = CSIParam.CSIPluginImage
 	}
 
 	if err := startDrivers(clientset, namespace, serverVersion, ownerRef); err != nil {
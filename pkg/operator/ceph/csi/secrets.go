@@ -17,11 +17,18 @@ limitations under the License.
 package csi
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -41,40 +48,29 @@ const (
 	CsiCephFSProvisionerSecret          = "rook-csi-cephfs-provisioner"
 )
 
-func createCSIKeyringRBDNode(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringRBDNodeUsername, cephCSIKeyringRBDNodeCaps())
-	if err != nil {
-		return "", err
-	}
-
-	return key, nil
+// updateDeploymentAndWait can be overridden for unit tests. Do not alter this for runtime operation.
+var updateDeploymentAndWait = k8sutil.UpdateDeploymentAndWait
+
+// csiUser is a ceph user of a CSI driver, whose id and key are stored in a Kubernetes Secret. The rotated users are
+// named after the first user, suffixed with their generation, e.g. csi-rbd-node-2.
+type csiUser struct {
+	name       string
+	secretName string
+	// idKey and keyKey are the keys of the id and of the key of the user expected by the driver in its secret
+	idKey  string
+	keyKey string
+	caps   []string
 }
 
-func createCSIKeyringRBDProvisioner(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringRBDProvisionerUsername, cephCSIKeyringRBDProvisionerCaps())
-	if err != nil {
-		return "", err
+func csiUsers() []csiUser {
+	return []csiUser{
+		// userID is expected for the rbd drivers
+		{name: strings.TrimPrefix(csiKeyringRBDProvisionerUsername, "client."), secretName: CsiRBDProvisionerSecret, idKey: "userID", keyKey: "userKey", caps: cephCSIKeyringRBDProvisionerCaps()},
+		{name: strings.TrimPrefix(csiKeyringRBDNodeUsername, "client."), secretName: CsiRBDNodeSecret, idKey: "userID", keyKey: "userKey", caps: cephCSIKeyringRBDNodeCaps()},
+		// adminID is expected for the cephfs drivers
+		{name: strings.TrimPrefix(csiKeyringCephFSProvisionerUsername, "client."), secretName: CsiCephFSProvisionerSecret, idKey: "adminID", keyKey: "adminKey", caps: cephCSIKeyringCephFSProvisionerCaps()},
+		{name: strings.TrimPrefix(csiKeyringCephFSNodeUsername, "client."), secretName: CsiCephFSNodeSecret, idKey: "adminID", keyKey: "adminKey", caps: cephCSIKeyringCephFSNodeCaps()},
 	}
-
-	return key, nil
-}
-
-func createCSIKeyringCephFSNode(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringCephFSNodeUsername, cephCSIKeyringCephFSNodeCaps())
-	if err != nil {
-		return "", err
-	}
-
-	return key, nil
-}
-
-func createCSIKeyringCephFSProvisioner(s *keyring.SecretStore) (string, error) {
-	key, err := s.GenerateKey(csiKeyringCephFSProvisionerUsername, cephCSIKeyringCephFSProvisionerCaps())
-	if err != nil {
-		return "", err
-	}
-
-	return key, nil
 }
 
 func cephCSIKeyringRBDNodeCaps() []string {
@@ -109,92 +105,124 @@ func cephCSIKeyringCephFSProvisionerCaps() []string {
 	}
 }
 
-func createOrUpdateCSISecret(namespace, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey string, k *keyring.SecretStore, ownerRef *metav1.OwnerReference) error {
-	csiRBDProvisionerSecrets := map[string][]byte{
-		// userID is expected for the rbd provisioner driver
-		"userID":  []byte("csi-rbd-provisioner"),
-		"userKey": []byte(csiRBDProvisionerSecretKey),
+// currentCSIUserID returns the id of the user stored in the secret of the CSI user, or the id of its first generation
+// if the secret does not exist yet
+func currentCSIUserID(context *clusterd.Context, namespace string, user csiUser) (string, error) {
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(user.secretName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return user.name, nil
+		}
+		return "", errors.Wrapf(err, "failed to get secret %q", user.secretName)
+	}
+	id := string(secret.Data[user.idKey])
+	if id != user.name && !strings.HasPrefix(id, user.name+"-") {
+		return user.name, nil
 	}
+	return id, nil
+}
 
-	csiRBDNodeSecrets := map[string][]byte{
-		// userID is expected for the rbd node driver
-		"userID":  []byte("csi-rbd-node"),
-		"userKey": []byte(csiRBDNodeSecretKey),
+// nextCSIUserID returns the id of the next generation of the CSI user
+func nextCSIUserID(user csiUser, id string) string {
+	generation, err := strconv.Atoi(strings.TrimPrefix(id, user.name+"-"))
+	if err != nil || generation < 1 {
+		generation = 1
 	}
+	return fmt.Sprintf("%s-%d", user.name, generation+1)
+}
 
-	csiCephFSProvisionerSecrets := map[string][]byte{
-		// adminID is expected for the cephfs provisioner driver
-		"adminID":  []byte("csi-cephfs-provisioner"),
-		"adminKey": []byte(csiCephFSProvisionerSecretKey),
+func createOrUpdateCSISecret(namespace string, user csiUser, id string, k *keyring.SecretStore, ownerRef *metav1.OwnerReference) error {
+	key, err := k.GenerateKey("client."+id, user.caps)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create csi ceph keyring %q", id)
 	}
 
-	csiCephFSNodeSecrets := map[string][]byte{
-		// adminID is expected for the cephfs node driver
-		"adminID":  []byte("csi-cephfs-node"),
-		"adminKey": []byte(csiCephFSNodeSecretKey),
+	s := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      user.secretName,
+			Namespace: namespace,
+		},
+		Data: map[string][]byte{
+			user.idKey:  []byte(id),
+			user.keyKey: []byte(key),
+		},
+		Type: k8sutil.RookType,
 	}
+	k8sutil.SetOwnerRef(&s.ObjectMeta, ownerRef)
 
-	keyringSecretMap := make(map[string]map[string][]byte)
-	keyringSecretMap[CsiRBDProvisionerSecret] = csiRBDProvisionerSecrets
-	keyringSecretMap[CsiRBDNodeSecret] = csiRBDNodeSecrets
-	keyringSecretMap[CsiCephFSProvisionerSecret] = csiCephFSProvisionerSecrets
-	keyringSecretMap[CsiCephFSNodeSecret] = csiCephFSNodeSecrets
-
-	for secretName, secret := range keyringSecretMap {
-		s := &v1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      secretName,
-				Namespace: namespace,
-			},
-			Data: secret,
-			Type: k8sutil.RookType,
-		}
-		k8sutil.SetOwnerRef(&s.ObjectMeta, ownerRef)
+	// Create Kubernetes Secret
+	if err := k.CreateSecret(s); err != nil {
+		return errors.Wrapf(err, "failed to create kubernetes secret %q for cluster %q", user.secretName, namespace)
+	}
+	return nil
+}
+
+// CreateCSISecrets creates all the Kubernetes CSI Secrets. The users of the secrets already created are kept, so that
+// the users of the CSI keys last rotated are not replaced by their first generation.
+func CreateCSISecrets(context *clusterd.Context, clusterName string, ownerRef *metav1.OwnerReference) error {
+	k := keyring.GetSecretStore(context, clusterName, ownerRef)
 
-		// Create Kubernetes Secret
-		err := k.CreateSecret(s)
+	for _, user := range csiUsers() {
+		id, err := currentCSIUserID(context, clusterName, user)
 		if err != nil {
-			return errors.Wrapf(err, "failed to create kubernetes secret %q for cluster %q", secret, namespace)
+			return errors.Wrap(err, "failed to create kubernetes csi secret")
+		}
+		if err := createOrUpdateCSISecret(clusterName, user, id, k, ownerRef); err != nil {
+			return errors.Wrap(err, "failed to create kubernetes csi secret")
 		}
-
 	}
 
-	logger.Infof("created kubernetes csi secrets for cluster %q", namespace)
+	logger.Infof("created kubernetes csi secrets for cluster %q", clusterName)
 	return nil
 }
 
-// CreateCSISecrets creates all the Kubernetes CSI Secrets
-func CreateCSISecrets(context *clusterd.Context, clusterName string, ownerRef *metav1.OwnerReference) error {
+// RotateCSIKeys creates a new generation of the ceph users of the CSI drivers, stores their ids and keys in the CSI
+// secrets, and restarts the CSI provisioners. The volumes already mapped or mounted keep on using the key they were
+// mapped or mounted with, so the users of the previous generation are not removed, and the CSI plugins, which would
+// stop the fuse and nbd mounts of their node, are not restarted. They read the secrets on every request, so the
+// volumes mapped or mounted from now on use the new users.
+func RotateCSIKeys(context *clusterd.Context, clusterName string, ownerRef *metav1.OwnerReference) error {
 	k := keyring.GetSecretStore(context, clusterName, ownerRef)
 
-	// Create CSI RBD Provisioner Ceph key
-	csiRBDProvisionerSecretKey, err := createCSIKeyringRBDProvisioner(k)
-	if err != nil {
-		return errors.Wrap(err, "failed to create csi rbd provisioner ceph keyring")
-	}
-
-	// Create CSI RBD Node Ceph key
-	csiRBDNodeSecretKey, err := createCSIKeyringRBDNode(k)
-	if err != nil {
-		return errors.Wrap(err, "failed to create csi rbd node ceph keyring")
+	for _, user := range csiUsers() {
+		id, err := currentCSIUserID(context, clusterName, user)
+		if err != nil {
+			return errors.Wrap(err, "failed to rotate csi ceph keyring")
+		}
+		nextID := nextCSIUserID(user, id)
+		if err := createOrUpdateCSISecret(clusterName, user, nextID, k, ownerRef); err != nil {
+			return errors.Wrap(err, "failed to rotate csi ceph keyring")
+		}
+		logger.Infof("rotated csi user %q to %q for cluster %q", id, nextID, clusterName)
 	}
 
-	// Create CSI Cephfs provisioner Ceph key
-	csiCephFSProvisionerSecretKey, err := createCSIKeyringCephFSProvisioner(k)
-	if err != nil {
-		return errors.Wrap(err, "failed to create csi cephfs provisioner ceph keyring")
-	}
+	return restartCSIProvisioners(context)
+}
 
-	// Create CSI Cephfs node Ceph key
-	csiCephFSNodeSecretKey, err := createCSIKeyringCephFSNode(k)
-	if err != nil {
-		return errors.Wrap(err, "failed to create csi cephfs node ceph keyring")
-	}
+// restartCSIProvisioners restarts the deployments of the CSI provisioners one at a time, waiting for each deployment to
+// run again before restarting the next one, so that they close the connections opened with the previous users
+func restartCSIProvisioners(context *clusterd.Context) error {
+	namespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
+	for _, name := range []string{csiRBDProvisioner, csiCephFSProvisioner} {
+		d, err := context.Clientset.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				// the driver is disabled, or its provisioner runs in a statefulset on the older versions of kubernetes
+				logger.Debugf("csi provisioner deployment %q not found, not restarting it", name)
+				continue
+			}
+			return errors.Wrapf(err, "failed to get csi provisioner deployment %q", name)
+		}
 
-	// Create or update Kubernetes CSI secret
-	if err := createOrUpdateCSISecret(clusterName, csiRBDProvisionerSecretKey, csiRBDNodeSecretKey, csiCephFSProvisionerSecretKey, csiCephFSNodeSecretKey, k, ownerRef); err != nil {
-		return errors.Wrap(err, "failed to create kubernetes csi secret")
+		restarted := d.DeepCopy()
+		if restarted.Spec.Template.Annotations == nil {
+			restarted.Spec.Template.Annotations = map[string]string{}
+		}
+		restarted.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation] = time.Now().Format(time.RFC3339)
+		logger.Infof("restarting csi provisioner deployment %q with the rotated keys", name)
+		if _, err := updateDeploymentAndWait(context, restarted, namespace, func(action string) error { return nil }); err != nil {
+			return errors.Wrapf(err, "failed to restart csi provisioner deployment %q", name)
+		}
 	}
-
 	return nil
 }
@@ -17,9 +17,17 @@ limitations under the License.
 package csi
 
 import (
+	"os"
 	"testing"
 
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestCephCSIKeyringRBDNodeCaps(t *testing.T) {
@@ -41,3 +49,63 @@ func TestCephCSIKeyringCephFSProvisionerCaps(t *testing.T) {
 	caps := cephCSIKeyringCephFSProvisionerCaps()
 	assert.Equal(t, caps, []string{"mon", "allow r", "mgr", "allow rw", "osd", "allow rw tag cephfs metadata=*"})
 }
+
+func TestRotateCSIKeys(t *testing.T) {
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	namespace := "my-cluster"
+	clientset := fake.NewSimpleClientset()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "auth" && args[1] == "get-or-create-key" {
+				return `{"key":"key-` + args[2] + `"}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %v", args)
+		},
+	}
+	context := &clusterd.Context{Clientset: clientset, Executor: executor}
+	user := func(secretName, idKey, keyKey string) (string, string) {
+		secret, err := clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+		assert.NoError(t, err)
+		return string(secret.Data[idKey]), string(secret.Data[keyKey])
+	}
+
+	// the secrets are created with the first generation of the users
+	assert.NoError(t, CreateCSISecrets(context, namespace, &metav1.OwnerReference{}))
+	id, key := user(CsiRBDNodeSecret, "userID", "userKey")
+	assert.Equal(t, "csi-rbd-node", id)
+	assert.Equal(t, "key-client.csi-rbd-node", key)
+	id, key = user(CsiCephFSProvisionerSecret, "adminID", "adminKey")
+	assert.Equal(t, "csi-cephfs-provisioner", id)
+	assert.Equal(t, "key-client.csi-cephfs-provisioner", key)
+
+	// the provisioners are restarted one at a time with the new generation of the users
+	for _, name := range []string{csiRBDProvisioner, csiCephFSProvisioner} {
+		_, err := clientset.AppsV1().Deployments("rook-ceph").Create(&apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "rook-ceph"}})
+		assert.NoError(t, err)
+	}
+	defer func() { updateDeploymentAndWait = k8sutil.UpdateDeploymentAndWait }()
+	var restarted []string
+	updateDeploymentAndWait = func(context *clusterd.Context, d *apps.Deployment, namespace string, verifyCallback func(action string) error) (*apps.Deployment, error) {
+		assert.Equal(t, "rook-ceph", namespace)
+		assert.NotEmpty(t, d.Spec.Template.Annotations[k8sutil.RestartedAtAnnotation])
+		restarted = append(restarted, d.Name)
+		return d, nil
+	}
+	for _, generation := range []string{"2", "3"} {
+		restarted = nil
+		assert.NoError(t, RotateCSIKeys(context, namespace, &metav1.OwnerReference{}))
+		id, key = user(CsiRBDNodeSecret, "userID", "userKey")
+		assert.Equal(t, "csi-rbd-node-"+generation, id)
+		assert.Equal(t, "key-client."+id, key)
+		id, _ = user(CsiCephFSNodeSecret, "adminID", "adminKey")
+		assert.Equal(t, "csi-cephfs-node-"+generation, id)
+		assert.Equal(t, []string{csiRBDProvisioner, csiCephFSProvisioner}, restarted)
+	}
+
+	// the rotated users are kept when the secrets are created again
+	assert.NoError(t, CreateCSISecrets(context, namespace, &metav1.OwnerReference{}))
+	id, key = user(CsiRBDProvisionerSecret, "userID", "userKey")
+	assert.Equal(t, "csi-rbd-provisioner-3", id)
+	assert.Equal(t, "key-client.csi-rbd-provisioner-3", key)
+}
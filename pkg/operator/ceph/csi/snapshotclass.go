@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// snapshotClassOwnerAnnotation is the pool or the filesystem a VolumeSnapshotClass was generated for, the
+	// classes created by the admins never being updated or deleted by the operator
+	snapshotClassOwnerAnnotation = "ceph.rook.io/snapshot-class-owner"
+
+	snapshotterSecretNameParam      = "csi.storage.k8s.io/snapshotter-secret-name"
+	snapshotterSecretNamespaceParam = "csi.storage.k8s.io/snapshotter-secret-namespace"
+)
+
+// SnapshotClassKind is the kind of the VolumeSnapshotClasses generated for the pools and the filesystems
+var SnapshotClassKind = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1beta1", Kind: "VolumeSnapshotClass"}
+
+// ReconcileRBDSnapshotClass creates or updates the VolumeSnapshotClass of the rbd images of a pool when enabled, and
+// deletes it when disabled
+func ReconcileRBDSnapshotClass(c client.Client, namespace, poolName string, spec *cephv1.SnapshotClassSpec) error {
+	if spec == nil {
+		return nil
+	}
+	name := snapshotClassName(namespace, poolName, "rbd", spec)
+	owner := fmt.Sprintf("CephBlockPool/%s/%s", namespace, poolName)
+	if !spec.Enabled {
+		return deleteSnapshotClass(c, name, owner)
+	}
+	return createOrUpdateSnapshotClass(c, name, owner, driverName(RBDDriverName, "rbd"), CsiRBDProvisionerSecret, namespace, spec)
+}
+
+// ReconcileCephFSSnapshotClass creates or updates the VolumeSnapshotClass of the volumes of a filesystem when
+// enabled, and deletes it when disabled
+func ReconcileCephFSSnapshotClass(c client.Client, namespace, fsName string, spec *cephv1.SnapshotClassSpec) error {
+	if spec == nil {
+		return nil
+	}
+	name := snapshotClassName(namespace, fsName, "cephfs", spec)
+	owner := fmt.Sprintf("CephFilesystem/%s/%s", namespace, fsName)
+	if !spec.Enabled {
+		return deleteSnapshotClass(c, name, owner)
+	}
+	return createOrUpdateSnapshotClass(c, name, owner, driverName(CephFSDriverName, "cephfs"), CsiCephFSProvisionerSecret, namespace, spec)
+}
+
+// DeleteRBDSnapshotClass deletes the VolumeSnapshotClass generated for a pool, if any
+func DeleteRBDSnapshotClass(c client.Client, namespace, poolName string, spec *cephv1.SnapshotClassSpec) error {
+	if spec == nil {
+		return nil
+	}
+	return deleteSnapshotClass(c, snapshotClassName(namespace, poolName, "rbd", spec), fmt.Sprintf("CephBlockPool/%s/%s", namespace, poolName))
+}
+
+// DeleteCephFSSnapshotClass deletes the VolumeSnapshotClass generated for a filesystem, if any
+func DeleteCephFSSnapshotClass(c client.Client, namespace, fsName string, spec *cephv1.SnapshotClassSpec) error {
+	if spec == nil {
+		return nil
+	}
+	return deleteSnapshotClass(c, snapshotClassName(namespace, fsName, "cephfs", spec), fmt.Sprintf("CephFilesystem/%s/%s", namespace, fsName))
+}
+
+// ValidateSnapshotClass validates the VolumeSnapshotClass settings of a pool or a filesystem
+func ValidateSnapshotClass(spec *cephv1.SnapshotClassSpec) error {
+	if spec == nil {
+		return nil
+	}
+	switch spec.DeletionPolicy {
+	case "", cephv1.SnapshotDeletionPolicyDelete, cephv1.SnapshotDeletionPolicyRetain:
+		return nil
+	default:
+		return errors.Errorf("invalid snapshot class deletion policy %q, must be %q or %q", spec.DeletionPolicy, cephv1.SnapshotDeletionPolicyDelete, cephv1.SnapshotDeletionPolicyRetain)
+	}
+}
+
+func snapshotClassName(namespace, name, driver string, spec *cephv1.SnapshotClassSpec) string {
+	if spec.Name != "" {
+		return spec.Name
+	}
+	return fmt.Sprintf("%s-%s-%s", namespace, name, driver)
+}
+
+// driverName returns the name of the driver, guessed from the namespace of the operator if the drivers were not
+// started yet
+func driverName(name, driver string) string {
+	if name != "" {
+		return name
+	}
+	return fmt.Sprintf("%s.%s.csi.ceph.com", os.Getenv(k8sutil.PodNamespaceEnvVar), driver)
+}
+
+func newSnapshotClass(name string) *unstructured.Unstructured {
+	class := &unstructured.Unstructured{}
+	class.SetGroupVersionKind(SnapshotClassKind)
+	class.SetName(name)
+	return class
+}
+
+func createOrUpdateSnapshotClass(c client.Client, name, owner, driver, secretName, namespace string, spec *cephv1.SnapshotClassSpec) error {
+	deletionPolicy := spec.DeletionPolicy
+	if deletionPolicy == "" {
+		deletionPolicy = cephv1.SnapshotDeletionPolicyDelete
+	}
+
+	class := newSnapshotClass(name)
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name}, class)
+	exists := err == nil
+	if err != nil {
+		if snapshotClassUnsupported(err) {
+			return errors.Errorf("failed to create snapshot class %q, the volume snapshot CRDs are not installed", name)
+		}
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get snapshot class %q", name)
+		}
+	}
+	if exists && class.GetAnnotations()[snapshotClassOwnerAnnotation] != owner {
+		return errors.Errorf("snapshot class %q already exists and was not generated for %q", name, owner)
+	}
+
+	class.SetAnnotations(map[string]string{snapshotClassOwnerAnnotation: owner})
+	class.Object["driver"] = driver
+	class.Object["deletionPolicy"] = string(deletionPolicy)
+	class.Object["parameters"] = map[string]interface{}{
+		"clusterID":                     namespace,
+		snapshotterSecretNameParam:      secretName,
+		snapshotterSecretNamespaceParam: namespace,
+	}
+
+	if exists {
+		if err := c.Update(context.TODO(), class); err != nil {
+			return errors.Wrapf(err, "failed to update snapshot class %q", name)
+		}
+		return nil
+	}
+	if err := c.Create(context.TODO(), class); err != nil {
+		return errors.Wrapf(err, "failed to create snapshot class %q", name)
+	}
+	logger.Infof("created snapshot class %q for %q", name, owner)
+	return nil
+}
+
+func deleteSnapshotClass(c client.Client, name, owner string) error {
+	class := newSnapshotClass(name)
+	err := c.Get(context.TODO(), types.NamespacedName{Name: name}, class)
+	if err != nil {
+		if kerrors.IsNotFound(err) || snapshotClassUnsupported(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get snapshot class %q", name)
+	}
+	if class.GetAnnotations()[snapshotClassOwnerAnnotation] != owner {
+		logger.Debugf("not deleting snapshot class %q not generated for %q", name, owner)
+		return nil
+	}
+	if err := c.Delete(context.TODO(), class); err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete snapshot class %q", name)
+	}
+	logger.Infof("deleted snapshot class %q of %q", name, owner)
+	return nil
+}
+
+// snapshotClassUnsupported returns whether the error is the VolumeSnapshotClass kind being unknown
+func snapshotClassUnsupported(err error) bool {
+	return meta.IsNoMatchError(err) || runtime.IsNotRegisteredError(err)
+}
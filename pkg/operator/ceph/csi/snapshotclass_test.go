@@ -0,0 +1,106 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateSnapshotClass(t *testing.T) {
+	assert.NoError(t, ValidateSnapshotClass(nil))
+	assert.NoError(t, ValidateSnapshotClass(&cephv1.SnapshotClassSpec{Enabled: true}))
+	assert.NoError(t, ValidateSnapshotClass(&cephv1.SnapshotClassSpec{Enabled: true, DeletionPolicy: cephv1.SnapshotDeletionPolicyRetain}))
+	assert.Error(t, ValidateSnapshotClass(&cephv1.SnapshotClassSpec{Enabled: true, DeletionPolicy: "Keep"}))
+}
+
+func TestReconcileSnapshotClass(t *testing.T) {
+	s := runtime.NewScheme()
+	s.AddKnownTypeWithName(SnapshotClassKind, &unstructured.Unstructured{})
+	listKind := SnapshotClassKind
+	listKind.Kind += "List"
+	s.AddKnownTypeWithName(listKind, &unstructured.UnstructuredList{})
+	c := fake.NewFakeClientWithScheme(s)
+	RBDDriverName = "rook-ceph.rbd.csi.ceph.com"
+	defer func() { RBDDriverName = "" }()
+	// the cephfs driver name is guessed from the namespace of the operator
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	getClass := func(name string) (*unstructured.Unstructured, error) {
+		class := newSnapshotClass(name)
+		err := c.Get(context.TODO(), types.NamespacedName{Name: name}, class)
+		return class, err
+	}
+
+	// Nothing is generated without settings
+	assert.NoError(t, ReconcileRBDSnapshotClass(c, "ns", "pool", nil))
+	_, err := getClass("ns-pool-rbd")
+	assert.True(t, kerrors.IsNotFound(err))
+
+	spec := &cephv1.SnapshotClassSpec{Enabled: true}
+	assert.NoError(t, ReconcileRBDSnapshotClass(c, "ns", "pool", spec))
+	class, err := getClass("ns-pool-rbd")
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph.rbd.csi.ceph.com", class.Object["driver"])
+	assert.Equal(t, "Delete", class.Object["deletionPolicy"])
+	params := class.Object["parameters"].(map[string]interface{})
+	assert.Equal(t, "ns", params["clusterID"])
+	assert.Equal(t, CsiRBDProvisionerSecret, params[snapshotterSecretNameParam])
+	assert.Equal(t, "ns", params[snapshotterSecretNamespaceParam])
+
+	// The deletion policy is updated
+	spec.DeletionPolicy = cephv1.SnapshotDeletionPolicyRetain
+	assert.NoError(t, ReconcileRBDSnapshotClass(c, "ns", "pool", spec))
+	class, err = getClass("ns-pool-rbd")
+	assert.NoError(t, err)
+	assert.Equal(t, "Retain", class.Object["deletionPolicy"])
+
+	// A class not generated for the filesystem is left alone
+	fsSpec := &cephv1.SnapshotClassSpec{Enabled: true, Name: "ns-pool-rbd"}
+	assert.Error(t, ReconcileCephFSSnapshotClass(c, "ns", "myfs", fsSpec))
+	fsSpec.Enabled = false
+	assert.NoError(t, ReconcileCephFSSnapshotClass(c, "ns", "myfs", fsSpec))
+	_, err = getClass("ns-pool-rbd")
+	assert.NoError(t, err)
+
+	// The class is deleted when disabled
+	spec.Enabled = false
+	assert.NoError(t, ReconcileRBDSnapshotClass(c, "ns", "pool", spec))
+	_, err = getClass("ns-pool-rbd")
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// The class is deleted with the filesystem
+	fsSpec = &cephv1.SnapshotClassSpec{Enabled: true}
+	assert.NoError(t, ReconcileCephFSSnapshotClass(c, "ns", "myfs", fsSpec))
+	class, err = getClass("ns-myfs-cephfs")
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph.cephfs.csi.ceph.com", class.Object["driver"])
+	assert.NoError(t, DeleteCephFSSnapshotClass(c, "ns", "myfs", fsSpec))
+	_, err = getClass("ns-myfs-cephfs")
+	assert.True(t, kerrors.IsNotFound(err))
+}
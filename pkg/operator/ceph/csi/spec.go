@@ -55,6 +55,7 @@ type Param struct {
 	PluginPriorityClassName      string
 	ProvisionerPriorityClassName string
 	LogLevel                     uint8
+	GRPCTimeout                  uint16
 	CephFSGRPCMetricsPort        uint16
 	CephFSLivenessMetricsPort    uint16
 	RBDGRPCMetricsPort           uint16
@@ -152,6 +153,8 @@ const (
 	operatorDeploymentName = "rook-ceph-operator"
 	// default log level for csi containers
 	defaultLogLevel uint8 = 0
+	// default timeout in seconds of the grpc calls of the csi sidecars to the drivers
+	defaultGRPCTimeout uint16 = 150
 
 	// update strategy
 	rollingUpdate = "RollingUpdate"
@@ -331,6 +334,21 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 		}
 	}
 
+	grpcTimeout, err := k8sutil.GetOperatorSetting(clientset, controllerutil.OperatorSettingConfigMapName, "CSI_GRPC_TIMEOUT_SECONDS", "")
+	if err != nil {
+		// logging a warning and intentionally continuing with the default timeout
+		logger.Warningf("failed to load CSI_GRPC_TIMEOUT_SECONDS. Defaulting to %d. %v", defaultGRPCTimeout, err)
+	}
+	tp.GRPCTimeout = defaultGRPCTimeout
+	if grpcTimeout != "" {
+		timeout, err := strconv.ParseUint(grpcTimeout, 10, 16)
+		if err != nil || timeout == 0 {
+			logger.Errorf("invalid CSI_GRPC_TIMEOUT_SECONDS %q. Defaulting to %d. %v", grpcTimeout, defaultGRPCTimeout, err)
+		} else {
+			tp.GRPCTimeout = uint16(timeout)
+		}
+	}
+
 	if EnableRBD {
 		rbdPlugin, err = templateToDaemonSet("rbdplugin", RBDPluginTemplatePath, tp)
 		if err != nil {
@@ -383,7 +401,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 	pluginTolerations := getToleration(clientset, false)
 	pluginNodeAffinity := getNodeAffinity(clientset, false)
 	if rbdPlugin != nil {
-		applyToPodSpec(&rbdPlugin.Spec.Template.Spec, pluginNodeAffinity, pluginTolerations)
+		applyToPodSpec(namespace, &rbdPlugin.Spec.Template.Spec, pluginNodeAffinity, pluginTolerations)
 		// apply resource request and limit to rbdplugin containers
 		applyResourcesToContainers(clientset, rbdPluginResource, &rbdPlugin.Spec.Template.Spec)
 		k8sutil.SetOwnerRef(&rbdPlugin.ObjectMeta, ownerRef)
@@ -395,7 +413,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 	}
 
 	if rbdProvisionerSTS != nil {
-		applyToPodSpec(&rbdProvisionerSTS.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
+		applyToPodSpec(namespace, &rbdProvisionerSTS.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
 		// apply resource request and limit to rbd provisioner containers
 		applyResourcesToContainers(clientset, rbdProvisionerResource, &rbdProvisionerSTS.Spec.Template.Spec)
 		k8sutil.SetOwnerRef(&rbdProvisionerSTS.ObjectMeta, ownerRef)
@@ -405,7 +423,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 		}
 		k8sutil.AddRookVersionLabelToStatefulSet(rbdProvisionerSTS)
 	} else if rbdProvisionerDeployment != nil {
-		applyToPodSpec(&rbdProvisionerDeployment.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
+		applyToPodSpec(namespace, &rbdProvisionerDeployment.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
 		// apply resource request and limit to rbd provisioner containers
 		applyResourcesToContainers(clientset, rbdProvisionerResource, &rbdProvisionerDeployment.Spec.Template.Spec)
 		k8sutil.SetOwnerRef(&rbdProvisionerDeployment.ObjectMeta, ownerRef)
@@ -431,7 +449,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 	}
 
 	if cephfsPlugin != nil {
-		applyToPodSpec(&cephfsPlugin.Spec.Template.Spec, pluginNodeAffinity, pluginTolerations)
+		applyToPodSpec(namespace, &cephfsPlugin.Spec.Template.Spec, pluginNodeAffinity, pluginTolerations)
 		// apply resource request and limit to cephfs plugin containers
 		applyResourcesToContainers(clientset, cephFSPluginResource, &cephfsPlugin.Spec.Template.Spec)
 		k8sutil.SetOwnerRef(&cephfsPlugin.ObjectMeta, ownerRef)
@@ -443,7 +461,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 	}
 
 	if cephfsProvisionerSTS != nil {
-		applyToPodSpec(&cephfsProvisionerSTS.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
+		applyToPodSpec(namespace, &cephfsProvisionerSTS.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
 		// apply resource request and limit to cephfs provisioner containers
 		applyResourcesToContainers(clientset, cephFSProvisionerResource, &cephfsProvisionerSTS.Spec.Template.Spec)
 		k8sutil.SetOwnerRef(&cephfsProvisionerSTS.ObjectMeta, ownerRef)
@@ -454,7 +472,7 @@ func startDrivers(clientset kubernetes.Interface, namespace string, ver *version
 		k8sutil.AddRookVersionLabelToStatefulSet(cephfsProvisionerSTS)
 
 	} else if cephfsProvisionerDeployment != nil {
-		applyToPodSpec(&cephfsProvisionerDeployment.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
+		applyToPodSpec(namespace, &cephfsProvisionerDeployment.Spec.Template.Spec, provisionerNodeAffinity, provisionerTolerations)
 		// get resource details for cephfs provisioner
 		// apply resource request and limit to cephfs provisioner containers
 		applyResourcesToContainers(clientset, cephFSProvisionerResource, &cephfsProvisionerDeployment.Spec.Template.Spec)
@@ -196,11 +196,12 @@ func getNodeAffinity(clientset kubernetes.Interface, provisioner bool) *corev1.N
 	return v1NodeAffinity
 }
 
-func applyToPodSpec(pod *corev1.PodSpec, n *corev1.NodeAffinity, t []corev1.Toleration) {
+func applyToPodSpec(namespace string, pod *corev1.PodSpec, n *corev1.NodeAffinity, t []corev1.Toleration) {
 	pod.Tolerations = t
 	pod.Affinity = &corev1.Affinity{
 		NodeAffinity: n,
 	}
+	k8sutil.ApplyImagePullSecrets(namespace, pod)
 }
 
 func getPortFromConfig(clientset kubernetes.Interface, env string, defaultPort uint16) (uint16, error) {
@@ -71,6 +71,8 @@ spec:
           image: {{ .AttacherImage }}
         - name: provisioner
           image: {{ .ProvisionerImage }}
+          args:
+            - "--timeout={{ .GRPCTimeout }}s"
         - name: rbdplugin
           image: {{ .CSIPluginImage }}
         - name: cephfsplugin
@@ -112,8 +114,10 @@ func TestStatefulSetTemplate(t *testing.T) {
 		Param:     CSIParam,
 		Namespace: "foo",
 	}
-	_, err = templateToStatefulSet("test-ss", tmp.Name(), tp)
+	tp.GRPCTimeout = 60
+	ss, err := templateToStatefulSet("test-ss", tmp.Name(), tp)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{"--timeout=60s"}, ss.Spec.Template.Spec.Containers[1].Args)
 }
 
 func Test_getPortFromConfig(t *testing.T) {
@@ -17,6 +17,7 @@ limitations under the License.
 package clusterdisruption
 
 import (
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/nodedrain"
 	"github.com/rook/rook/pkg/operator/k8sutil"
@@ -57,7 +58,7 @@ func Add(mgr manager.Manager, context *controllerconfig.Context) error {
 	}
 	reconciler := reconcile.Reconciler(reconcileClusterDisruption)
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, reconciler))
 	if err != nil {
 		return err
 	}
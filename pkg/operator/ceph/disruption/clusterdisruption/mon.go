@@ -45,12 +45,15 @@ func (r *ReconcileClusterDisruption) reconcileMonPDB(cephCluster *cephv1.CephClu
 		logger.Error("mon count should be an odd number, setting effective maxUnvailable to 1")
 		minAvailable = int32(monCount - 1)
 	}
+	namespace := cephCluster.ObjectMeta.Namespace
+	pdbRequest := types.NamespacedName{Name: pdbName, Namespace: namespace}
 	if monCount <= 2 {
 		logger.Error("managePodBudgets is set, but mon-count <= 2. Not creating a disruptionbudget for Mons")
+		if err := r.deleteStaticPDB(pdbRequest); err != nil {
+			return errors.Wrap(err, "could not delete mon pdb")
+		}
 		return nil
 	}
-	namespace := cephCluster.ObjectMeta.Namespace
-	pdbRequest := types.NamespacedName{Name: pdbName, Namespace: namespace}
 	pdb := &policyv1beta1.PodDisruptionBudget{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      pdbName,
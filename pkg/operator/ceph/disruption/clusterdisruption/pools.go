@@ -59,7 +59,9 @@ func (r *ReconcileClusterDisruption) processPools(request reconcile.Request) (*c
 	poolCount += len(cephFilesystemList.Items)
 	for _, cephFilesystem := range cephFilesystemList.Items {
 		poolSpecs = append(poolSpecs, cephFilesystem.Spec.MetadataPool)
-		poolSpecs = append(poolSpecs, cephFilesystem.Spec.DataPools...)
+		for _, dataPool := range cephFilesystem.Spec.DataPools {
+			poolSpecs = append(poolSpecs, dataPool.PoolSpec)
+		}
 
 	}
 
@@ -156,10 +158,15 @@ func (r *ReconcileClusterDisruption) reconcileCephObjectStore(cephObjectStoreLis
 			MatchLabels: map[string]string{"rgw": storeName},
 		}
 
+		request := types.NamespacedName{Name: pdbName, Namespace: namespace}
+
 		rgwCount := objectStore.Spec.Gateway.Instances
 		minAvailable := &intstr.IntOrString{IntVal: rgwCount - 1}
 		if minAvailable.IntVal <= 1 {
-			break
+			if err := r.deleteStaticPDB(request); err != nil {
+				return errors.Wrapf(err, "could not delete cephobjectstore pdb %v", request)
+			}
+			continue
 		}
 		blockOwnerDeletion := false
 		pdb := &policyv1beta1.PodDisruptionBudget{
@@ -182,7 +189,6 @@ func (r *ReconcileClusterDisruption) reconcileCephObjectStore(cephObjectStoreLis
 			},
 		}
 
-		request := types.NamespacedName{Name: pdbName, Namespace: namespace}
 		err := r.reconcileStaticPDB(request, pdb)
 		if err != nil {
 			return errors.Wrapf(err, "could not reconcile cephobjectstore pdb %v", request)
@@ -202,13 +208,18 @@ func (r *ReconcileClusterDisruption) reconcileCephFilesystem(cephFilesystemList
 			MatchLabels: map[string]string{"rook_file_system": fsName},
 		}
 
+		request := types.NamespacedName{Name: pdbName, Namespace: namespace}
+
 		activeCount := filesystem.Spec.MetadataServer.ActiveCount
 		minAvailable := &intstr.IntOrString{IntVal: activeCount - 1}
 		if filesystem.Spec.MetadataServer.ActiveStandby {
 			minAvailable.IntVal++
 		}
 		if minAvailable.IntVal < 1 {
-			break
+			if err := r.deleteStaticPDB(request); err != nil {
+				return errors.Wrapf(err, "could not delete cephfs pdb %v", request)
+			}
+			continue
 		}
 		blockOwnerDeletion := false
 		pdb := &policyv1beta1.PodDisruptionBudget{
@@ -231,7 +242,6 @@ func (r *ReconcileClusterDisruption) reconcileCephFilesystem(cephFilesystemList
 			},
 		}
 
-		request := types.NamespacedName{Name: pdbName, Namespace: namespace}
 		err := r.reconcileStaticPDB(request, pdb)
 		if err != nil {
 			return errors.Wrapf(err, "could not reconcile cephfs pdb %v", request)
@@ -18,6 +18,7 @@ package clusterdisruption
 
 import (
 	"context"
+	"reflect"
 
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -47,12 +48,35 @@ func (r *ReconcileClusterDisruption) updateStaticPDB(request types.NamespacedNam
 	return r.createStaticPDB(request, pdb)
 }
 
+// reconcileStaticPDB creates the pdb, or recreates it when the number of daemons it covers changed
 func (r *ReconcileClusterDisruption) reconcileStaticPDB(request types.NamespacedName, pdb *policyv1beta1.PodDisruptionBudget) error {
-	err := r.client.Get(context.TODO(), request, pdb)
+	existingPDB := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), request, existingPDB)
 	if errors.IsNotFound(err) {
 		return r.createStaticPDB(request, pdb)
 	} else if err != nil {
 		return err
 	}
+	if reflect.DeepEqual(existingPDB.Spec.MinAvailable, pdb.Spec.MinAvailable) && reflect.DeepEqual(existingPDB.Spec.Selector, pdb.Spec.Selector) {
+		return nil
+	}
+	logger.Infof("updating pdb %q", request)
+	return r.updateStaticPDB(request, pdb)
+}
+
+// deleteStaticPDB deletes the pdb if it exists, when the daemons it covered are too few to have one
+func (r *ReconcileClusterDisruption) deleteStaticPDB(request types.NamespacedName) error {
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), request, pdb)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logger.Infof("deleting pdb %q", request)
+	err = r.client.Delete(context.TODO(), pdb)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
 	return nil
 }
@@ -0,0 +1,87 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdisruption
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestReconcileMonPDB(t *testing.T) {
+	r := &ReconcileClusterDisruption{client: fake.NewFakeClientWithScheme(scheme.Scheme)}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph", Namespace: "rook-ceph"},
+		Spec:       cephv1.ClusterSpec{Mon: cephv1.MonSpec{Count: 3}},
+	}
+	request := types.NamespacedName{Name: pdbName, Namespace: "rook-ceph"}
+	getPDB := func() (*policyv1beta1.PodDisruptionBudget, error) {
+		pdb := &policyv1beta1.PodDisruptionBudget{}
+		err := r.client.Get(context.TODO(), request, pdb)
+		return pdb, err
+	}
+
+	assert.NoError(t, r.reconcileMonPDB(cephCluster))
+	pdb, err := getPDB()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), pdb.Spec.MinAvailable.IntVal)
+
+	// The pdb follows the mon count
+	cephCluster.Spec.Mon.Count = 5
+	assert.NoError(t, r.reconcileMonPDB(cephCluster))
+	pdb, err = getPDB()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), pdb.Spec.MinAvailable.IntVal)
+
+	// The pdb is removed without mon quorum to protect
+	cephCluster.Spec.Mon.Count = 1
+	assert.NoError(t, r.reconcileMonPDB(cephCluster))
+	_, err = getPDB()
+	assert.True(t, kerrors.IsNotFound(err))
+	assert.NoError(t, r.reconcileMonPDB(cephCluster))
+}
+
+func TestReconcileCephObjectStorePDB(t *testing.T) {
+	r := &ReconcileClusterDisruption{client: fake.NewFakeClientWithScheme(scheme.Scheme)}
+	stores := &cephv1.CephObjectStoreList{Items: []cephv1.CephObjectStore{
+		{ObjectMeta: metav1.ObjectMeta{Name: "small", Namespace: "rook-ceph"}, Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Instances: 1}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "large", Namespace: "rook-ceph"}, Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Instances: 4}}},
+	}}
+
+	// A store with too few gateways does not prevent the pdb of the others
+	assert.NoError(t, r.reconcileCephObjectStore(stores))
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: "rook-ceph-rgw-large", Namespace: "rook-ceph"}, pdb)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), pdb.Spec.MinAvailable.IntVal)
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: "rook-ceph-rgw-small", Namespace: "rook-ceph"}, pdb)
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// The pdb is removed when the gateways are scaled down
+	stores.Items[1].Spec.Gateway.Instances = 2
+	assert.NoError(t, r.reconcileCephObjectStore(stores))
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: "rook-ceph-rgw-large", Namespace: "rook-ceph"}, pdb)
+	assert.True(t, kerrors.IsNotFound(err))
+}
@@ -20,6 +20,7 @@ import (
 	healthchecking "github.com/openshift/machine-api-operator/pkg/apis/healthchecking/v1alpha1"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -48,7 +49,7 @@ func Add(mgr manager.Manager, context *controllerconfig.Context) error {
 
 	reconciler := reconcile.Reconciler(reconcileMachineDisruption)
 	// create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, reconciler))
 	if err != nil {
 		return err
 	}
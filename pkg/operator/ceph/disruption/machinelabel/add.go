@@ -20,6 +20,7 @@ import (
 	mapiv1 "github.com/openshift/cluster-api/pkg/apis/machine/v1beta1"
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -56,7 +57,7 @@ func Add(mgr manager.Manager, context *controllerconfig.Context) error {
 
 	reconciler := reconcile.Reconciler(reconcileMachineLabel)
 	// create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, reconciler))
 	if err != nil {
 		return errors.Wrapf(err, "could not create controller %q", controllerName)
 	}
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 
@@ -54,7 +55,7 @@ func Add(mgr manager.Manager, context *controllerconfig.Context) error {
 	}
 	reconciler := reconcile.Reconciler(reconcileNode)
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: reconciler})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, reconciler))
 	if err != nil {
 		return err
 	}
@@ -203,9 +203,10 @@ func (r *ReconcileNode) reconcile(request reconcile.Request) (reconcile.Result,
 		deploy.Spec.Template = corev1.PodTemplateSpec{
 			ObjectMeta: metav1.ObjectMeta{Labels: selectorLabels},
 			Spec: corev1.PodSpec{
-				NodeSelector: nodeSelector,
-				Containers:   newDoNothingContainers(r.context.RookImage),
-				Tolerations:  uniqueTolerations.ToList(),
+				NodeSelector:     nodeSelector,
+				Containers:       newDoNothingContainers(r.context.RookImage),
+				Tolerations:      uniqueTolerations.ToList(),
+				ImagePullSecrets: k8sutil.GetImagePullSecrets(r.context.OperatorNamespace),
 			},
 		}
 
@@ -31,6 +31,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	corev1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -39,6 +40,7 @@ import (
 
 	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -74,6 +76,12 @@ type ReconcileCephFilesystem struct {
 	context         *clusterd.Context
 	cephClusterSpec *cephv1.ClusterSpec
 	clusterInfo     *cephconfig.ClusterInfo
+	// peerSecretVersions are the resource versions of the mirroring peer secrets whose tokens were imported
+	peerSecretVersions map[types.NamespacedName]string
+	// recorder records the events of the mds health checkers on the filesystems
+	recorder record.EventRecorder
+	// fsMonitors are the running mds health checkers of the filesystems, by namespaced name
+	fsMonitors map[string]*mdsMonitor
 }
 
 // Add creates a new CephFilesystem Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -89,15 +97,17 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	cephv1.AddToScheme(mgr.GetScheme())
 
 	return &ReconcileCephFilesystem{
-		client:  mgr.GetClient(),
-		scheme:  mgrScheme,
-		context: context,
+		client:     mgr.GetClient(),
+		scheme:     mgrScheme,
+		context:    context,
+		recorder:   mgr.GetEventRecorderFor(controllerName),
+		fsMonitors: make(map[string]*mdsMonitor),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -128,10 +138,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephFilesystem) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephFilesystem", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -165,6 +177,8 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 		// If not, we should wait for it to be ready
 		// This handles the case where the operator is not ready to accept Ceph command but the cluster exists
 		if !cephFilesystem.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			r.stopMonitoring(request.NamespacedName)
+
 			// Remove finalizer
 			err := opcontroller.RemoveFinalizer(r.client, cephFilesystem)
 			if err != nil {
@@ -202,6 +216,15 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	// DELETE: the CR was deleted
 	if !cephFilesystem.GetDeletionTimestamp().IsZero() {
 		logger.Debugf("deleting filesystem %q", cephFilesystem.Name)
+		// the pools are not deleted while files are present, unless the deletion is forced
+		if len(cephFilesystem.Spec.DataPools) != 0 && !cephFilesystem.Spec.PreservePoolsOnDelete && !opcontroller.IsForceDeletion(cephFilesystem) {
+			if err := checkForFilesInFilesystem(r.context, cephFilesystem); err != nil {
+				logger.Errorf("cannot delete filesystem %q, remove its files, set preservePoolsOnDelete or set the %q annotation to delete them with the pools. %v", cephFilesystem.Name, opcontroller.ForceDeletionAnnotation, err)
+				updateStatus(r.client, request.NamespacedName, k8sutil.DeletionBlockedStatus)
+				return opcontroller.WaitForRequeueIfFinalizerBlocked, nil
+			}
+		}
+		r.stopMonitoring(request.NamespacedName)
 		err = r.reconcileDeleteFilesystem(cephFilesystem)
 		if err != nil {
 			return reconcile.Result{}, errors.Wrapf(err, "failed to delete filesystem %q. ", cephFilesystem.Name)
@@ -233,6 +256,9 @@ func (r *ReconcileCephFilesystem) reconcile(request reconcile.Request) (reconcil
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
 
+	// Start the periodic refresh of the health of the mds daemons
+	r.startMonitoring(cephFilesystem)
+
 	// Return and do not requeue
 	logger.Debug("done reconciling")
 	return reconcile.Result{}, nil
@@ -260,6 +286,24 @@ func (r *ReconcileCephFilesystem) reconcileCreateFilesystem(cephFilesystem *ceph
 		return reconcile.Result{}, errors.Wrapf(err, "failed to create filesystem %q", cephFilesystem.Name)
 	}
 
+	// Mirror the snapshots of the filesystem to its peers
+	if cephFilesystem.Spec.Mirroring != nil && cephFilesystem.Spec.Mirroring.Enabled {
+		if err := r.reconcileMirroring(cephFilesystem); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to enable mirroring of filesystem %q", cephFilesystem.Name)
+		}
+	}
+
+	// Schedule the snapshots of the filesystem, the schedules added out of band are left alone without any in the spec
+	if len(cephFilesystem.Spec.SnapshotSchedules) > 0 {
+		if err := r.reconcileSnapshotSchedules(cephFilesystem); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to schedule snapshots of filesystem %q", cephFilesystem.Name)
+		}
+	}
+
+	if err := csi.ReconcileCephFSSnapshotClass(r.client, cephFilesystem.Namespace, cephFilesystem.Name, cephFilesystem.Spec.SnapshotClass); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the snapshot class of filesystem %q", cephFilesystem.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -275,6 +319,10 @@ func (r *ReconcileCephFilesystem) reconcileDeleteFilesystem(cephFilesystem *ceph
 		return err
 	}
 
+	if err := csi.DeleteCephFSSnapshotClass(r.client, cephFilesystem.Namespace, cephFilesystem.Name, cephFilesystem.Spec.SnapshotClass); err != nil {
+		return errors.Wrapf(err, "failed to delete the snapshot class of filesystem %q", cephFilesystem.Name)
+	}
+
 	return nil
 }
 
@@ -292,7 +340,7 @@ func updateStatus(client client.Client, name types.NamespacedName, status string
 	}
 
 	if fs.Status == nil {
-		fs.Status = &cephv1.Status{}
+		fs.Status = &cephv1.CephFilesystemStatus{}
 	}
 
 	fs.Status.Phase = status
@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
@@ -34,7 +35,6 @@ import (
 )
 
 const (
-	dataPoolSuffix     = "data"
 	metaDataPoolSuffix = "metadata"
 )
 
@@ -67,19 +67,7 @@ func createFilesystem(
 		return errors.Wrapf(err, "failed to get filesystem %q", fs.Name)
 	}
 
-	if fs.Spec.MetadataServer.ActiveStandby {
-		if err = client.AllowStandbyReplay(context, fs.Namespace, fs.Name, fs.Spec.MetadataServer.ActiveStandby); err != nil {
-			return errors.Wrapf(err, "failed to set allow_standby_replay to filesystem %q", fs.Name)
-		}
-	}
-
-	// set the number of active mds instances
-	if fs.Spec.MetadataServer.ActiveCount > 1 {
-		if err = client.SetNumMDSRanks(context, fs.Namespace, fs.Name, fs.Spec.MetadataServer.ActiveCount); err != nil {
-			logger.Warningf("failed setting active mds count to %d. %v", fs.Spec.MetadataServer.ActiveCount, err)
-		}
-	}
-
+	// The number of active mds instances and the standby-replay daemons are set by the mds cluster
 	logger.Infof("start running mdses for filesystem %q", fs.Name)
 	c := mds.NewCluster(clusterInfo, context, clusterSpec, fs, filesystem, ownerRefs, dataDirHostPath, scheme)
 	if err := c.Start(); err != nil {
@@ -133,6 +121,17 @@ func deleteFilesystem(
 	return nil
 }
 
+// checkForFilesInFilesystem returns an error if objects are present in the data pools of the filesystem, the files
+// being lost with the pools
+func checkForFilesInFilesystem(context *clusterd.Context, fs *cephv1.CephFilesystem) error {
+	for i := range fs.Spec.DataPools {
+		if err := client.CheckForObjectsInPool(context, fs.Namespace, fs.DataPoolName(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func validateFilesystem(context *clusterd.Context, f *cephv1.CephFilesystem) error {
 	if f.Name == "" {
 		return errors.New("missing name")
@@ -143,6 +142,17 @@ func validateFilesystem(context *clusterd.Context, f *cephv1.CephFilesystem) err
 	if f.Spec.MetadataServer.ActiveCount < 1 {
 		return errors.New("MetadataServer.ActiveCount must be at least 1")
 	}
+	for _, schedule := range f.Spec.SnapshotSchedules {
+		if schedule.Interval == "" {
+			return errors.New("the interval of a snapshot schedule must be specified")
+		}
+		if _, err := client.ParseSnapshotRetention(schedule.Retention); err != nil {
+			return err
+		}
+	}
+	if err := csi.ValidateSnapshotClass(f.Spec.SnapshotClass); err != nil {
+		return err
+	}
 	// No data pool means that we expect the fs to exist already
 	if len(f.Spec.DataPools) == 0 {
 		return nil
@@ -151,7 +161,7 @@ func validateFilesystem(context *clusterd.Context, f *cephv1.CephFilesystem) err
 		return errors.Wrap(err, "invalid metadata pool")
 	}
 	for _, p := range f.Spec.DataPools {
-		if err := pool.ValidatePoolSpec(context, f.Namespace, &p); err != nil {
+		if err := pool.ValidatePoolSpec(context, f.Namespace, &p.PoolSpec); err != nil {
 			return errors.Wrap(err, "Invalid data pool")
 		}
 	}
@@ -179,7 +189,7 @@ func SetPoolSize(f *Filesystem, context *clusterd.Context, spec cephv1.Filesyste
 	dataPoolNames := generateDataPoolNames(f, spec)
 	for i, pool := range spec.DataPools {
 		poolName := dataPoolNames[i]
-		err := client.CreatePoolWithProfile(context, f.Namespace, poolName, pool, "")
+		err := client.CreatePoolWithProfile(context, f.Namespace, poolName, pool.PoolSpec, "")
 		if err != nil {
 			return errors.Wrapf(err, "failed to update datapool  %q", poolName)
 		}
@@ -187,25 +197,48 @@ func SetPoolSize(f *Filesystem, context *clusterd.Context, spec cephv1.Filesyste
 	return nil
 }
 
+// addDataPools adds the data pools added to the spec of an existing filesystem, once the pools are created
+func (f *Filesystem) addDataPools(context *clusterd.Context, fs *client.CephFilesystemDetails, spec cephv1.FilesystemSpec) error {
+	poolNames, err := client.GetPoolNamesByID(context, f.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get pool names")
+	}
+	inFilesystem := map[string]bool{}
+	for _, poolID := range fs.MDSMap.DataPools {
+		inFilesystem[poolNames[poolID]] = true
+	}
+
+	for i, poolName := range generateDataPoolNames(f, spec) {
+		if inFilesystem[poolName] {
+			continue
+		}
+		if spec.DataPools[i].IsErasureCoded() {
+			// An erasure coded data pool used for a filesystem must allow overwrites
+			if err := client.SetPoolProperty(context, f.Namespace, poolName, "allow_ec_overwrites", "true"); err != nil {
+				logger.Warningf("failed to set ec pool property. %v", err)
+			}
+		}
+		if err := client.AddDataPool(context, f.Namespace, f.Name, poolName); err != nil {
+			return err
+		}
+		logger.Infof("added data pool %q to filesystem %q", poolName, f.Name)
+	}
+	return nil
+}
+
 // doFilesystemCreate starts the Ceph file daemons and creates the filesystem in Ceph.
 func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion cephver.CephVersion, spec cephv1.FilesystemSpec) error {
 
-	_, err := client.GetFilesystem(context, f.Namespace, f.Name)
+	fs, err := client.GetFilesystem(context, f.Namespace, f.Name)
 	if err == nil {
 		logger.Infof("filesystem %s already exists", f.Name)
-		// Even if the fs already exists, the num active mdses may have changed
-
-		if err := client.SetNumMDSRanks(context, f.Namespace, f.Name, spec.MetadataServer.ActiveCount); err != nil {
-			logger.Errorf(
-				fmt.Sprintf("failed to set num mds ranks (max_mds) to %d for filesystem %s, still continuing. ", spec.MetadataServer.ActiveCount, f.Name) +
-					"this error is not critical, but mdses may not be as failure tolerant as desired. " +
-					fmt.Sprintf("USER should verify that the number of active mdses is %d with 'ceph fs get %s'", spec.MetadataServer.ActiveCount, f.Name) +
-					fmt.Sprintf(". %v", err),
-			)
-		}
 		if err := SetPoolSize(f, context, spec); err != nil {
 			return errors.Wrap(err, "failed to set pools size")
 		}
+		// the filesystem remains usable with its current data pools
+		if err := f.addDataPools(context, fs, spec); err != nil {
+			logger.Errorf("failed to add the new data pools to filesystem %q. %v", f.Name, err)
+		}
 		return nil
 	}
 	if len(spec.DataPools) == 0 {
@@ -248,7 +281,7 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 		poolName := dataPoolNames[i]
 		if _, poolFound := reversedPoolMap[poolName]; !poolFound {
 			poolsCreated = true
-			err = client.CreatePoolWithProfile(context, f.Namespace, poolName, pool, "")
+			err = client.CreatePoolWithProfile(context, f.Namespace, poolName, pool.PoolSpec, "")
 			if err != nil {
 				return errors.Wrapf(err, "failed to create data pool %q", poolName)
 			}
@@ -261,6 +294,9 @@ func (f *Filesystem) doFilesystemCreate(context *clusterd.Context, cephVersion c
 		}
 	}
 
+	// the default data pool is the first data pool of the filesystem
+	dataPoolNames = defaultDataPoolFirst(dataPoolNames, spec.DefaultDataPoolIndex())
+
 	// create the filesystem ('fs new' needs to be forced in order to reuse pre-existing pools)
 	// if only one pool is created new it wont work (to avoid inconsistencies).
 	if err := client.CreateFilesystem(context, f.Namespace, f.Name, metadataPoolName, dataPoolNames, !poolsCreated); err != nil {
@@ -282,16 +318,27 @@ func downFilesystem(context *clusterd.Context, namespace, filesystemName string)
 	return nil
 }
 
-// generateDataPoolName generates DataPool name by prefixing the filesystem name to the constant DataPoolSuffix
+// generateDataPoolNames generates the data pool names by prefixing the filesystem name to the name of each pool,
+// or to the data suffix and the index of the pools without a name
 func generateDataPoolNames(f *Filesystem, spec cephv1.FilesystemSpec) []string {
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: f.Name}, Spec: spec}
 	var dataPoolNames []string
 	for i := range spec.DataPools {
-		poolName := fmt.Sprintf("%s-%s%d", f.Name, dataPoolSuffix, i)
-		dataPoolNames = append(dataPoolNames, poolName)
+		dataPoolNames = append(dataPoolNames, fs.DataPoolName(i))
 	}
 	return dataPoolNames
 }
 
+// defaultDataPoolFirst returns the data pool names with the default data pool first
+func defaultDataPoolFirst(dataPoolNames []string, defaultIndex int) []string {
+	if defaultIndex == 0 {
+		return dataPoolNames
+	}
+	ordered := []string{dataPoolNames[defaultIndex]}
+	ordered = append(ordered, dataPoolNames[:defaultIndex]...)
+	return append(ordered, dataPoolNames[defaultIndex+1:]...)
+}
+
 // generateMetaDataPoolName generates MetaDataPool name by prefixing the filesystem name to the constant metaDataPoolSuffix
 func generateMetaDataPoolName(f *Filesystem) string {
 	return fmt.Sprintf("%s-%s", f.Name, metaDataPoolSuffix)
@@ -18,15 +18,18 @@ package file
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
+	"strconv"
 	"strings"
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
 	cephtest "github.com/rook/rook/pkg/daemon/ceph/test"
 	"github.com/rook/rook/pkg/operator/ceph/file/mds"
@@ -55,7 +58,7 @@ func TestValidateSpec(t *testing.T) {
 	// missing data pools
 	assert.NotNil(t, validateFilesystem(context, fs))
 	p := cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}}
-	fs.Spec.DataPools = append(fs.Spec.DataPools, p)
+	fs.Spec.DataPools = append(fs.Spec.DataPools, cephv1.NamedPoolSpec{PoolSpec: p})
 
 	// missing metadata pool
 	assert.NotNil(t, validateFilesystem(context, fs))
@@ -67,6 +70,14 @@ func TestValidateSpec(t *testing.T) {
 
 	// valid!
 	assert.Nil(t, validateFilesystem(context, fs))
+
+	// the snapshot schedules need an interval and a valid retention
+	fs.Spec.SnapshotSchedules = []cephv1.SnapshotScheduleSpec{{Path: "/", Retention: "24h"}}
+	assert.NotNil(t, validateFilesystem(context, fs))
+	fs.Spec.SnapshotSchedules[0].Interval = "1h"
+	assert.Nil(t, validateFilesystem(context, fs))
+	fs.Spec.SnapshotSchedules[0].Retention = "24 hours"
+	assert.NotNil(t, validateFilesystem(context, fs))
 }
 
 func TestCreateFilesystem(t *testing.T) {
@@ -100,7 +111,7 @@ func TestCreateFilesystem(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
 		Spec: cephv1.FilesystemSpec{
 			MetadataPool: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}},
-			DataPools:    []cephv1.PoolSpec{{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}}},
+			DataPools:    []cephv1.NamedPoolSpec{{PoolSpec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 1, RequireSafeReplicaSize: false}}}},
 			MetadataServer: cephv1.MetadataServerSpec{
 				ActiveCount: 1,
 				Resources: v1.ResourceRequirements{
@@ -203,13 +214,43 @@ func TestCreateNopoolFilesystem(t *testing.T) {
 		},
 	}
 }
-func contains(arr []string, str string) bool {
-	for _, a := range arr {
-		if a == str {
-			return true
-		}
+func TestFilesystemDataPools(t *testing.T) {
+	spec := cephv1.FilesystemSpec{
+		DataPools: []cephv1.NamedPoolSpec{
+			{PoolSpec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}},
+			{Name: "fast", Default: true, PoolSpec: cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}, DeviceClass: "nvme"}},
+			{Name: "archive", PoolSpec: cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1}}},
+		},
+	}
+	f := newFS("myfs", "ns")
+
+	// the named pools are prefixed with the filesystem name and the default data pool comes first
+	names := generateDataPoolNames(f, spec)
+	assert.Equal(t, []string{"myfs-data0", "myfs-fast", "myfs-archive"}, names)
+	assert.Equal(t, []string{"myfs-fast", "myfs-data0", "myfs-archive"}, defaultDataPoolFirst(names, spec.DefaultDataPoolIndex()))
+	assert.Equal(t, names, defaultDataPoolFirst(names, 0))
+
+	// the pools added to the spec are added to the existing filesystem
+	var commands []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "lspools" {
+				return `[{"poolnum":1,"poolname":"myfs-metadata"},{"poolnum":2,"poolname":"myfs-data0"},{"poolnum":3,"poolname":"myfs-fast"},{"poolnum":4,"poolname":"myfs-archive"}]`, nil
+			}
+			// the args of the command come before the flags of the ceph CLI
+			for i, arg := range args {
+				if strings.HasPrefix(arg, "--") {
+					args = args[:i]
+					break
+				}
+			}
+			commands = append(commands, strings.Join(args, " "))
+			return "", nil
+		},
 	}
-	return false
+	fs := &client.CephFilesystemDetails{MDSMap: client.MDSMap{DataPools: []int{3, 2}}}
+	assert.NoError(t, f.addDataPools(&clusterd.Context{Executor: executor}, fs, spec))
+	assert.Equal(t, []string{"osd pool set myfs-archive allow_ec_overwrites true", "fs add_data_pool myfs myfs-archive"}, commands)
 }
 
 func validateStart(t *testing.T, context *clusterd.Context, fs cephv1.CephFilesystem) {
@@ -221,3 +262,72 @@ func validateStart(t *testing.T, context *clusterd.Context, fs cephv1.CephFilesy
 	assert.Nil(t, err)
 	assert.Equal(t, "rook-ceph-mds-myfs-b", r.Name)
 }
+
+func TestScaleFilesystem(t *testing.T) {
+	mds.UpdateDeploymentAndWait, _ = testopk8s.UpdateDeploymentAndWaitStub()
+
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	maxMDS := 1
+	standbyReplay := ""
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			if contains(args, "fs") && contains(args, "get") {
+				up := `{"mds_0": 1}`
+				if maxMDS == 2 {
+					up = `{"mds_0": 1, "mds_1": 2}`
+				}
+				return fmt.Sprintf(`{"id": 1, "mdsmap": {"fs_name": "myfs", "max_mds": %d, "up": %s}}`, maxMDS, up), nil
+			}
+			if contains(args, "config") && contains(args, "get") {
+				return "{}", nil
+			}
+			for i, arg := range args[:len(args)-1] {
+				if arg == "max_mds" {
+					maxMDS, _ = strconv.Atoi(args[i+1])
+				}
+				if arg == "allow_standby_replay" {
+					standbyReplay = args[i+1]
+				}
+			}
+			return "{\"key\":\"mysecurekey\"}", nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if strings.Contains(command, "ceph-authtool") {
+				err := cephtest.CreateConfigDir(path.Join(configDir, "ns"))
+				assert.Nil(t, err)
+			}
+			return "", nil
+		},
+	}
+	clientset := testop.New(t, 1)
+	context := &clusterd.Context{Executor: executor, ConfigDir: configDir, Clientset: clientset}
+	fs := cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "ns"},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 2, ActiveStandby: true},
+		},
+	}
+	clusterInfo := &cephconfig.ClusterInfo{FSID: "myfsid"}
+
+	// Scale up the active mds of a live filesystem with their standby-replay daemons
+	err := createFilesystem(clusterInfo, context, fs, &cephv1.ClusterSpec{}, metav1.OwnerReference{}, "/var/lib/rook/", scheme.Scheme)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, maxMDS)
+	assert.Equal(t, "true", standbyReplay)
+	deps, err := clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, deps.Items, 4)
+
+	// Scale down, the extraneous daemons being removed once the ranks are stopped
+	fs.Spec.MetadataServer.ActiveCount = 1
+	fs.Spec.MetadataServer.ActiveStandby = false
+	err = createFilesystem(clusterInfo, context, fs, &cephv1.ClusterSpec{}, metav1.OwnerReference{}, "/var/lib/rook/", scheme.Scheme)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, maxMDS)
+	assert.Equal(t, "false", standbyReplay)
+	validateStart(t, context, fs)
+	deps, err = clientset.AppsV1().Deployments("ns").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, deps.Items, 2)
+}
@@ -0,0 +1,405 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// mdsHealthCheckInterval is how often the health of the mds daemons of a filesystem is refreshed by default
+	mdsHealthCheckInterval = time.Minute
+	mdsHealthOK            = "HEALTH_OK"
+	mdsHealthWarn          = "HEALTH_WARN"
+	mdsHealthErr           = "HEALTH_ERR"
+
+	// mdsUnhealthyReason is the reason of the event emitted when the health of the mds daemons degrades
+	mdsUnhealthyReason = "MDSUnhealthy"
+	// mdsHealthyReason is the reason of the event emitted when the health of the mds daemons is back to ok
+	mdsHealthyReason = "MDSHealthy"
+	// mdsRestartedReason is the reason of the event emitted when the pod of a stuck mds is restarted
+	mdsRestartedReason = "MDSRestarted"
+	// mdsRestartFailedReason is the reason of the event emitted when the pod of a stuck mds failed to be restarted
+	mdsRestartFailedReason = "MDSRestartFailed"
+)
+
+// transitionalMDSStates are the states an mds is expected to leave quickly, the mds being stuck if it stays in them
+var transitionalMDSStates = []string{"up:creating", "up:starting", "up:replay", "up:resolve", "up:reconnect", "up:rejoin", "up:clientreplay", "up:stopping"}
+
+// unhealthyMDS is the state of an mds laggy or in a transitional state, and since when it is in that state
+type unhealthyMDS struct {
+	state string
+	since time.Time
+}
+
+// mdsChecker periodically refreshes the health of the mds daemons of a filesystem in its status, and restarts the
+// pods of the mds stuck for longer than the restart threshold
+type mdsChecker struct {
+	context        *clusterd.Context
+	client         client.Client
+	recorder       record.EventRecorder
+	namespacedName types.NamespacedName
+	interval       time.Duration
+	// unhealthy are the mds laggy or in a transitional state, by name
+	unhealthy map[string]unhealthyMDS
+}
+
+// mdsMonitor is a running mds checker of a filesystem, with the settings it was started with
+type mdsMonitor struct {
+	stopCh      chan struct{}
+	healthCheck cephv1.MDSHealthCheckSpec
+}
+
+// newMDSChecker creates a new mds health checker of a filesystem
+func newMDSChecker(context *clusterd.Context, client client.Client, recorder record.EventRecorder, namespacedName types.NamespacedName, healthCheck cephv1.MDSHealthCheckSpec) *mdsChecker {
+	c := &mdsChecker{
+		context:        context,
+		client:         client,
+		recorder:       recorder,
+		namespacedName: namespacedName,
+		interval:       mdsHealthCheckInterval,
+		unhealthy:      map[string]unhealthyMDS{},
+	}
+
+	// allow overriding the check interval
+	if healthCheck.Interval != "" {
+		if duration, err := time.ParseDuration(healthCheck.Interval); err == nil {
+			logger.Infof("mds health check interval for filesystem %q is %q", namespacedName.Name, healthCheck.Interval)
+			c.interval = duration
+		}
+	}
+	return c
+}
+
+// checkFilesystem periodically refreshes the mds health of the filesystem until stopped
+func (c *mdsChecker) checkFilesystem(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring of the mds of filesystem %q", c.namespacedName.Name)
+			return
+
+		case <-time.After(c.interval):
+			logger.Debugf("checking mds health of filesystem %q", c.namespacedName.Name)
+			if err := c.checkMDSHealth(time.Now()); err != nil {
+				logger.Warningf("failed to update mds health of filesystem %q. %v", c.namespacedName.Name, err)
+			}
+		}
+	}
+}
+
+// checkMDSHealth updates the status of the filesystem with the health of its mds daemons, emits an event when the
+// health changes and restarts the mds stuck for longer than the restart threshold
+func (c *mdsChecker) checkMDSHealth(now time.Time) error {
+	fs := &cephv1.CephFilesystem{}
+	err := c.client.Get(context.TODO(), c.namespacedName, fs)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystem resource not found. Ignoring since object must be deleted.")
+			return nil
+		}
+		return errors.Wrapf(err, "failed to retrieve filesystem %q", c.namespacedName.Name)
+	}
+	if fs.Status == nil {
+		fs.Status = &cephv1.CephFilesystemStatus{}
+	}
+
+	health := c.getMDSHealth(fs, now)
+	c.reportHealthChange(fs, fs.Status.MDSHealth, health)
+	c.restartStuckMDS(fs, now)
+
+	fs.Status.MDSHealth = health
+	return opcontroller.UpdateStatus(c.client, fs)
+}
+
+// getMDSHealth returns the health of the mds daemons and the client sessions of the filesystem, with the error in the
+// details if it failed to be retrieved
+func (c *mdsChecker) getMDSHealth(fs *cephv1.CephFilesystem, now time.Time) *cephv1.MDSHealthStatus {
+	health := &cephv1.MDSHealthStatus{
+		LastChecked:    now.UTC().Format(time.RFC3339),
+		WantedStandbys: int(fs.Spec.MetadataServer.ActiveCount),
+	}
+
+	fsMap, err := cephclient.DumpFilesystems(c.context, fs.Namespace)
+	if err != nil {
+		health.Details = err.Error()
+		return health
+	}
+	var mdsMap *cephclient.MDSMap
+	for i := range fsMap.Filesystems {
+		if fsMap.Filesystems[i].MDSMap.FilesystemName == fs.Name {
+			mdsMap = &fsMap.Filesystems[i].MDSMap
+			break
+		}
+	}
+	if mdsMap == nil {
+		health.Details = "filesystem not found in the fs map"
+		return health
+	}
+
+	daemons := []cephclient.MDSInfo{}
+	for _, info := range mdsMap.Info {
+		daemons = append(daemons, info)
+	}
+	// the standby daemons are not assigned to a filesystem, the ones deployed for this filesystem are counted
+	for _, info := range fsMap.Standbys {
+		if isFilesystemDaemon(fs.Name, info.Name) {
+			daemons = append(daemons, info)
+		}
+	}
+	sort.Slice(daemons, func(i, j int) bool { return daemons[i].Name < daemons[j].Name })
+
+	seen := map[string]bool{}
+	for _, info := range daemons {
+		seen[info.Name] = true
+		switch info.State {
+		case "up:active":
+			health.ActiveRanks++
+		case "up:standby", "up:standby-replay":
+			health.Standbys++
+		}
+		if c.trackDaemon(info, now) {
+			health.StuckDaemons = append(health.StuckDaemons, info.Name)
+		}
+		if info.LaggySince != "" {
+			health.LaggyDaemons = append(health.LaggyDaemons, info.Name)
+		}
+	}
+	// forget the daemons gone from the fs map
+	for name := range c.unhealthy {
+		if !seen[name] {
+			delete(c.unhealthy, name)
+		}
+	}
+	health.DamagedRanks = mdsMap.Damaged
+	health.FailedRanks = mdsMap.Failed
+
+	sessions, err := cephclient.GetFilesystemClientCount(c.context, fs.Namespace, fs.Name)
+	if err != nil {
+		health.Details = err.Error()
+	}
+	health.Sessions = sessions
+	health.Health = mdsHealth(health, int(fs.Spec.MetadataServer.ActiveCount))
+	return health
+}
+
+// trackDaemon records since when the mds is laggy or in a transitional state, and returns whether it is stuck, i.e.
+// was already found in the same state by a previous check
+func (c *mdsChecker) trackDaemon(info cephclient.MDSInfo, now time.Time) bool {
+	state := info.State
+	if info.LaggySince != "" {
+		state = "laggy"
+	} else if !contains(transitionalMDSStates, state) {
+		delete(c.unhealthy, info.Name)
+		return false
+	}
+
+	previous, ok := c.unhealthy[info.Name]
+	if !ok || previous.state != state {
+		c.unhealthy[info.Name] = unhealthyMDS{state: state, since: now}
+		return false
+	}
+	return state != "laggy"
+}
+
+// restartStuckMDS restarts the pods of the mds laggy or stuck in a transitional state for longer than the restart
+// threshold of the filesystem, if set
+func (c *mdsChecker) restartStuckMDS(fs *cephv1.CephFilesystem, now time.Time) {
+	if fs.Spec.HealthCheck.MDS.RestartStuckAfter == "" {
+		return
+	}
+	threshold, err := time.ParseDuration(fs.Spec.HealthCheck.MDS.RestartStuckAfter)
+	if err != nil || threshold <= 0 {
+		logger.Warningf("invalid mds restart threshold %q of filesystem %q", fs.Spec.HealthCheck.MDS.RestartStuckAfter, fs.Name)
+		return
+	}
+
+	names := make([]string, 0, len(c.unhealthy))
+	for name := range c.unhealthy {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		mds := c.unhealthy[name]
+		if now.Sub(mds.since) < threshold {
+			continue
+		}
+		// the restarted mds is tracked again from its next state
+		delete(c.unhealthy, name)
+		if err := c.restartMDSPods(name); err != nil {
+			logger.Errorf("failed to restart mds %q of filesystem %q. %v", name, fs.Name, err)
+			c.recordEvent(fs, v1.EventTypeWarning, mdsRestartFailedReason, "failed to restart mds %s %s since %s: %v", name, mds.state, mds.since.UTC().Format(time.RFC3339), err)
+			continue
+		}
+		logger.Infof("restarted mds %q of filesystem %q, %s since %s", name, fs.Name, mds.state, mds.since.UTC().Format(time.RFC3339))
+		c.recordEvent(fs, v1.EventTypeNormal, mdsRestartedReason, "restarted mds %s %s since %s", name, mds.state, mds.since.UTC().Format(time.RFC3339))
+	}
+}
+
+// restartMDSPods deletes the pods of an mds, its deployment starting a new pod
+func (c *mdsChecker) restartMDSPods(name string) error {
+	selector := fmt.Sprintf("mds=%s", name)
+	pods, err := c.context.Clientset.CoreV1().Pods(c.namespacedName.Namespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return errors.Wrapf(err, "failed to list the pods of mds %q", name)
+	}
+	if len(pods.Items) == 0 {
+		return errors.Errorf("no pod found for mds %q", name)
+	}
+	for _, pod := range pods.Items {
+		if err := c.context.Clientset.CoreV1().Pods(pod.Namespace).Delete(pod.Name, &metav1.DeleteOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to delete pod %q", pod.Name)
+		}
+	}
+	return nil
+}
+
+// reportHealthChange emits an event on the filesystem when the health of its mds daemons changes
+func (c *mdsChecker) reportHealthChange(fs *cephv1.CephFilesystem, previous, current *cephv1.MDSHealthStatus) {
+	if current.Health == "" {
+		// the health could not be retrieved
+		return
+	}
+	previousHealth := ""
+	if previous != nil {
+		previousHealth = previous.Health
+	}
+	if previousHealth == current.Health {
+		return
+	}
+	if current.Health == mdsHealthOK {
+		if previousHealth != "" {
+			c.recordEvent(fs, v1.EventTypeNormal, mdsHealthyReason, "mds health changed from %s to %s", previousHealth, current.Health)
+		}
+		return
+	}
+	c.recordEvent(fs, v1.EventTypeWarning, mdsUnhealthyReason, "mds health changed to %s: %s", current.Health, mdsHealthSummary(current))
+}
+
+// recordEvent records an event on the filesystem, if an event recorder is set
+func (c *mdsChecker) recordEvent(fs *cephv1.CephFilesystem, eventType, reason, messageFmt string, args ...interface{}) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Eventf(fs, eventType, reason, messageFmt, args...)
+}
+
+// mdsHealth returns HEALTH_ERR when a rank is damaged or failed or no rank is active, and HEALTH_WARN when an mds is
+// laggy or stuck, or ranks or standbys are missing
+func mdsHealth(health *cephv1.MDSHealthStatus, activeCount int) string {
+	if len(health.DamagedRanks) > 0 || len(health.FailedRanks) > 0 || health.ActiveRanks == 0 {
+		return mdsHealthErr
+	}
+	if len(health.LaggyDaemons) > 0 || len(health.StuckDaemons) > 0 || health.ActiveRanks < activeCount || health.Standbys < health.WantedStandbys {
+		return mdsHealthWarn
+	}
+	return mdsHealthOK
+}
+
+// mdsHealthSummary describes the problems of the mds daemons of a filesystem
+func mdsHealthSummary(health *cephv1.MDSHealthStatus) string {
+	var problems []string
+	if len(health.DamagedRanks) > 0 {
+		problems = append(problems, fmt.Sprintf("damaged ranks %v", health.DamagedRanks))
+	}
+	if len(health.FailedRanks) > 0 {
+		problems = append(problems, fmt.Sprintf("failed ranks %v", health.FailedRanks))
+	}
+	if len(health.LaggyDaemons) > 0 {
+		problems = append(problems, fmt.Sprintf("laggy mds %v", health.LaggyDaemons))
+	}
+	if len(health.StuckDaemons) > 0 {
+		problems = append(problems, fmt.Sprintf("stuck mds %v", health.StuckDaemons))
+	}
+	problems = append(problems, fmt.Sprintf("%d active ranks", health.ActiveRanks))
+	problems = append(problems, fmt.Sprintf("%d/%d standbys", health.Standbys, health.WantedStandbys))
+	return strings.Join(problems, ", ")
+}
+
+// isFilesystemDaemon returns whether the mds is one of the daemons deployed for the filesystem, named
+// <fs>-<letter id>
+func isFilesystemDaemon(fsName, name string) bool {
+	if !strings.HasPrefix(name, fsName+"-") {
+		return false
+	}
+	id := strings.TrimPrefix(name, fsName+"-")
+	return id != "" && strings.Trim(id, "abcdefghijklmnopqrstuvwxyz") == ""
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// startMonitoring starts the mds health checker of the filesystem if not running, restarting it if its settings
+// changed, and stops it if disabled
+func (r *ReconcileCephFilesystem) startMonitoring(fs *cephv1.CephFilesystem) {
+	namespacedName := types.NamespacedName{Namespace: fs.Namespace, Name: fs.Name}
+	healthCheck := fs.Spec.HealthCheck.MDS
+	if healthCheck.Disabled {
+		logger.Debugf("mds health check of filesystem %q is disabled", fs.Name)
+		r.stopMonitoring(namespacedName)
+		return
+	}
+	if r.fsMonitors == nil {
+		r.fsMonitors = make(map[string]*mdsMonitor)
+	}
+	if monitor, ok := r.fsMonitors[namespacedName.String()]; ok {
+		if monitor.healthCheck == healthCheck {
+			logger.Debugf("mds health checker of filesystem %q already running", fs.Name)
+			return
+		}
+		logger.Infof("mds health check settings of filesystem %q changed, restarting the health checker", fs.Name)
+		r.stopMonitoring(namespacedName)
+	}
+
+	monitor := &mdsMonitor{stopCh: make(chan struct{}), healthCheck: healthCheck}
+	r.fsMonitors[namespacedName.String()] = monitor
+	checker := newMDSChecker(r.context, r.client, r.recorder, namespacedName, healthCheck)
+	logger.Infof("starting mds health checker of filesystem %q", fs.Name)
+	go checker.checkFilesystem(monitor.stopCh)
+}
+
+// stopMonitoring stops the mds health checker of the filesystem if running
+func (r *ReconcileCephFilesystem) stopMonitoring(namespacedName types.NamespacedName) {
+	monitor, ok := r.fsMonitors[namespacedName.String()]
+	if !ok {
+		return
+	}
+	close(monitor.stopCh)
+	delete(r.fsMonitors, namespacedName.String())
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMDSHealth(t *testing.T) {
+	health := &cephv1.MDSHealthStatus{ActiveRanks: 2, Standbys: 2, WantedStandbys: 2}
+	assert.Equal(t, "HEALTH_OK", mdsHealth(health, 2))
+	assert.Equal(t, "HEALTH_WARN", mdsHealth(health, 3))
+	health.Standbys = 1
+	assert.Equal(t, "HEALTH_WARN", mdsHealth(health, 2))
+	health.Standbys = 2
+	health.LaggyDaemons = []string{"myfs-a"}
+	assert.Equal(t, "HEALTH_WARN", mdsHealth(health, 2))
+	health.DamagedRanks = []int{1}
+	assert.Equal(t, "HEALTH_ERR", mdsHealth(health, 2))
+	assert.Equal(t, "HEALTH_ERR", mdsHealth(&cephv1.MDSHealthStatus{}, 1))
+}
+
+func TestIsFilesystemDaemon(t *testing.T) {
+	assert.True(t, isFilesystemDaemon("myfs", "myfs-a"))
+	assert.True(t, isFilesystemDaemon("myfs", "myfs-ab"))
+	assert.False(t, isFilesystemDaemon("myfs", "myfs-"))
+	assert.False(t, isFilesystemDaemon("myfs", "myfs-ec-a"))
+	assert.False(t, isFilesystemDaemon("myfs", "otherfs-a"))
+}
+
+func TestCheckMDSHealth(t *testing.T) {
+	namespacedName := types.NamespacedName{Name: "myfs", Namespace: "rook-ceph"}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace},
+		Spec: cephv1.FilesystemSpec{
+			MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1},
+			HealthCheck:    cephv1.FilesystemHealthCheckSpec{MDS: cephv1.MDSHealthCheckSpec{RestartStuckAfter: "10m"}},
+		},
+		Status: &cephv1.CephFilesystemStatus{Phase: "Ready"},
+	}
+	activeState := "up:active"
+	failDump := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "dump" {
+				if failDump {
+					return "", errors.New("timed out")
+				}
+				return `{"epoch":12,"standbys":[{"gid":4200,"name":"myfs-b","rank":-1,"state":"up:standby"},
+					{"gid":4300,"name":"otherfs-b","rank":-1,"state":"up:standby"}],
+					"filesystems":[{"id":1,"mdsmap":{"fs_name":"myfs","max_mds":1,"in":[0],"failed":[],"damaged":[],
+					"info":{"gid_4100":{"gid":4100,"name":"myfs-a","rank":0,"state":"` + activeState + `"}}}}]}`, nil
+			}
+			if args[0] == "fs" && args[1] == "status" {
+				return `{"clients":[{"clients":3,"fs":"myfs"}],"mdsmap":[{"name":"myfs-a","rank":0,"state":"active"}]}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	clientset := testop.New(t, 1)
+	mdsPod := &v1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mds-myfs-a-abc", Namespace: "rook-ceph", Labels: map[string]string{"mds": "myfs-a"}}}
+	_, err := clientset.CoreV1().Pods("rook-ceph").Create(mdsPod)
+	assert.NoError(t, err)
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, fs)
+	cl := fake.NewFakeClientWithScheme(s, fs)
+	recorder := record.NewFakeRecorder(10)
+	c := newMDSChecker(&clusterd.Context{Executor: executor, Clientset: clientset}, cl, recorder, namespacedName, fs.Spec.HealthCheck.MDS)
+	now := time.Now()
+
+	// the ranks, the standbys of the filesystem and the sessions are set in the status
+	assert.NoError(t, c.checkMDSHealth(now))
+	updated := &cephv1.CephFilesystem{}
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Equal(t, "Ready", updated.Status.Phase)
+	health := updated.Status.MDSHealth
+	assert.Equal(t, "HEALTH_OK", health.Health)
+	assert.Equal(t, 1, health.ActiveRanks)
+	assert.Equal(t, 1, health.Standbys)
+	assert.Equal(t, 1, health.WantedStandbys)
+	assert.Equal(t, 3, health.Sessions)
+	assert.Empty(t, health.Details)
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// an mds staying in replay is stuck
+	activeState = "up:replay"
+	assert.NoError(t, c.checkMDSHealth(now.Add(time.Minute)))
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Empty(t, updated.Status.MDSHealth.StuckDaemons)
+	assert.Equal(t, "HEALTH_ERR", updated.Status.MDSHealth.Health)
+	assert.Equal(t, "Warning MDSUnhealthy mds health changed to HEALTH_ERR: 0 active ranks, 1/1 standbys", <-recorder.Events)
+	assert.NoError(t, c.checkMDSHealth(now.Add(2*time.Minute)))
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Equal(t, []string{"myfs-a"}, updated.Status.MDSHealth.StuckDaemons)
+	assert.Equal(t, 0, len(recorder.Events))
+
+	// the pod of the mds is restarted once stuck longer than the threshold
+	assert.NoError(t, c.checkMDSHealth(now.Add(12*time.Minute)))
+	pods, err := clientset.CoreV1().Pods("rook-ceph").List(metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, pods.Items)
+	assert.Equal(t, 1, len(recorder.Events))
+	assert.Contains(t, <-recorder.Events, "Normal MDSRestarted restarted mds myfs-a up:replay since")
+
+	// the recovery is reported
+	activeState = "up:active"
+	assert.NoError(t, c.checkMDSHealth(now.Add(13*time.Minute)))
+	assert.Equal(t, "Normal MDSHealthy mds health changed from HEALTH_ERR to HEALTH_OK", <-recorder.Events)
+
+	// the error of the check is reported in the status
+	failDump = true
+	assert.NoError(t, c.checkMDSHealth(now.Add(14*time.Minute)))
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Contains(t, updated.Status.MDSHealth.Details, "timed out")
+	assert.Empty(t, updated.Status.MDSHealth.Health)
+	assert.Equal(t, 0, len(recorder.Events))
+}
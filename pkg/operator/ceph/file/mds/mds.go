@@ -195,6 +195,11 @@ func (c *Cluster) Start() error {
 
 	}
 
+	// The ranks are set once their daemons are deployed, and before the extraneous daemons are removed
+	if err := c.setActiveRanks(); err != nil {
+		return err
+	}
+
 	if err := c.scaleDownDeployments(replicas, desiredDeployments); err != nil {
 		return errors.Wrap(err, "failed to scale down mds deployments")
 	}
@@ -202,6 +207,31 @@ func (c *Cluster) Start() error {
 	return nil
 }
 
+// setActiveRanks sets the number of active ranks (max_mds) of the filesystem, and whether the standby daemons follow
+// the journal of the active ranks. Ceph assigns a standby-replay daemon to each active rank, and the standby ones take
+// over the stopped ranks when the count is decreased.
+func (c *Cluster) setActiveRanks() error {
+	filesystem, err := client.GetFilesystem(c.context, c.fs.Namespace, c.fs.Name)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get filesystem %q", c.fs.Name)
+	}
+
+	activeCount := c.fs.Spec.MetadataServer.ActiveCount
+	if filesystem.MDSMap.MaxMDS != int(activeCount) {
+		logger.Infof("setting the number of active mds of filesystem %q from %d to %d", c.fs.Name, filesystem.MDSMap.MaxMDS, activeCount)
+		if err := client.SetNumMDSRanks(c.context, c.fs.Namespace, c.fs.Name, activeCount); err != nil {
+			return errors.Wrapf(err, "failed to set the number of active mds of filesystem %q", c.fs.Name)
+		}
+	}
+
+	// The standby-replay daemons go back to standby when disabled
+	if err := client.AllowStandbyReplay(c.context, c.fs.Namespace, c.fs.Name, c.fs.Spec.MetadataServer.ActiveStandby); err != nil {
+		return errors.Wrapf(err, "failed to set allow_standby_replay to filesystem %q", c.fs.Name)
+	}
+
+	return nil
+}
+
 func (c *Cluster) scaleDownDeployments(replicas int32, desiredDeployments map[string]bool) error {
 	// Remove extraneous mds deployments if they exist
 	deps, err := getMdsDeployments(c.context, c.fs.Namespace, c.fs.Name)
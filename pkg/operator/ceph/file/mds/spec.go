@@ -20,6 +20,7 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	"github.com/rook/rook/pkg/operator/ceph/config"
@@ -53,12 +54,19 @@ func (c *Cluster) makeDeployment(mdsConfig *mdsConfig) *apps.Deployment {
 			},
 			RestartPolicy:     v1.RestartPolicyAlways,
 			Volumes:           controller.DaemonVolumes(mdsConfig.DataPathMap, mdsConfig.ResourceName),
-			HostNetwork:       c.clusterSpec.Network.IsHost(),
-			PriorityClassName: c.fs.Spec.MetadataServer.PriorityClassName,
+			HostNetwork:       c.clusterSpec.Network.IsHostFor(cephv1.KeyMds),
+			PriorityClassName: c.priorityClassName(),
 		},
 	}
+	if c.clusterSpec.LogCollector.Enabled {
+		podSpec.Spec.Containers = append(podSpec.Spec.Containers,
+			controller.LogCollectorContainer(fmt.Sprintf("%s.%s", config.MdsType, mdsConfig.DaemonID), c.clusterSpec.CephVersion.Image, c.clusterSpec.LogCollector, mon.PodSecurityContext()))
+	}
+	controller.ApplyDaemonOverrides(&podSpec.Spec, c.clusterSpec.Env, c.clusterSpec.VolumeMounts, cephv1.KeyMds)
+
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec.Spec)
+	k8sutil.ApplyImagePullSecrets(c.fs.Namespace, &podSpec.Spec)
 
 	c.fs.Spec.MetadataServer.Annotations.ApplyToObjectMeta(&podSpec.ObjectMeta)
 	c.fs.Spec.MetadataServer.Placement.ApplyToPodSpec(&podSpec.Spec)
@@ -82,10 +90,10 @@ func (c *Cluster) makeDeployment(mdsConfig *mdsConfig) *apps.Deployment {
 		},
 	}
 
-	if c.clusterSpec.Network.IsHost() {
+	if c.clusterSpec.Network.IsHostFor(cephv1.KeyMds) {
 		d.Spec.Template.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.clusterSpec.Network.NetworkSpec.IsMultus() {
-		k8sutil.ApplyMultus(c.clusterSpec.Network.NetworkSpec, &podSpec.ObjectMeta)
+		config.ApplyPublicNetwork(c.clusterSpec.Network.NetworkSpec, &podSpec.ObjectMeta)
 	}
 
 	k8sutil.AddRookVersionLabelToDeployment(d)
@@ -127,9 +135,21 @@ func (c *Cluster) makeMdsDaemonContainer(mdsConfig *mdsConfig) v1.Container {
 		LivenessProbe:   controller.GenerateLivenessProbeExecDaemon(config.MdsType, mdsConfig.DaemonID),
 	}
 
+	// Apply the liveness probe settings of the cluster
+	container = config.ConfigureLivenessProbe(cephv1.KeyMds, container, c.clusterSpec.HealthCheck)
+
 	return container
 }
 
+// priorityClassName returns the priority class of the mds pods, the priority class of the filesystem overriding the
+// one of the mds of the cluster
+func (c *Cluster) priorityClassName() string {
+	if c.fs.Spec.MetadataServer.PriorityClassName != "" {
+		return c.fs.Spec.MetadataServer.PriorityClassName
+	}
+	return cephv1.GetMdsPriorityClassName(c.clusterSpec.PriorityClassNames)
+}
+
 func (c *Cluster) podLabels(mdsConfig *mdsConfig) map[string]string {
 	labels := controller.PodLabels(AppName, c.fs.Namespace, "mds", mdsConfig.DaemonID)
 	labels["rook_file_system"] = c.fs.Name
@@ -23,6 +23,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/config"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
@@ -102,6 +103,23 @@ func TestPodSpecs(t *testing.T) {
 		"my-priority-class")
 }
 
+func TestPriorityClassName(t *testing.T) {
+	c := &Cluster{
+		fs: cephv1.CephFilesystem{},
+		clusterSpec: &cephv1.ClusterSpec{
+			PriorityClassNames: rookv1.PriorityClassNamesSpec{rookv1.KeyAll: "all-priority-class"},
+		},
+	}
+	assert.Equal(t, "all-priority-class", c.priorityClassName())
+
+	c.clusterSpec.PriorityClassNames[cephv1.KeyMds] = "mds-priority-class"
+	assert.Equal(t, "mds-priority-class", c.priorityClassName())
+
+	// the priority class of the filesystem overrides the one of the cluster
+	c.fs.Spec.MetadataServer.PriorityClassName = "my-priority-class"
+	assert.Equal(t, "my-priority-class", c.priorityClassName())
+}
+
 func TestHostNetwork(t *testing.T) {
 	d, err := testDeploymentObject(t, cephv1.NetworkSpec{HostNetwork: true}) // host network
 	assert.Nil(t, err)
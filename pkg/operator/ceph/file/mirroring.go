@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// fsPeerTokenSecretPrefix is the prefix of the name of the secret with the bootstrap peer token of a filesystem
+	fsPeerTokenSecretPrefix = "fs-peer-token"
+	// peerTokenKey is the key of the bootstrap peer token in a peer secret
+	peerTokenKey = "token"
+)
+
+// reconcileMirroring enables the snapshot mirroring of the filesystem, stores its bootstrap peer token in a secret for
+// the peers of the filesystem, and imports the bootstrap peer tokens of the peer secrets of the filesystem
+func (r *ReconcileCephFilesystem) reconcileMirroring(cephFilesystem *cephv1.CephFilesystem) error {
+	// the snapshot mirroring of the filesystems is only available from pacific
+	if !r.clusterInfo.CephVersion.IsAtLeastPacific() {
+		return errors.Errorf("ceph version %q does not support the filesystem mirroring, it requires ceph pacific or newer", r.clusterInfo.CephVersion.String())
+	}
+
+	if err := cephclient.MgrEnableModule(r.context, cephFilesystem.Namespace, cephclient.FilesystemMirroringModuleName, false); err != nil {
+		return errors.Wrapf(err, "failed to enable mgr module %q", cephclient.FilesystemMirroringModuleName)
+	}
+	if err := cephclient.EnableFilesystemSnapshotMirror(r.context, cephFilesystem.Namespace, cephFilesystem.Name); err != nil {
+		return err
+	}
+
+	// the fsid tells the peers apart, it is unique unlike the namespace of the clusters
+	status, err := cephclient.Status(r.context, cephFilesystem.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the fsid of the cluster")
+	}
+	token, err := cephclient.CreateFSMirrorBootstrapPeer(r.context, cephFilesystem.Namespace, cephFilesystem.Name, status.FSID)
+	if err != nil {
+		return err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", fsPeerTokenSecretPrefix, cephFilesystem.Name),
+			Namespace: cephFilesystem.Namespace,
+		},
+		Data: map[string][]byte{
+			peerTokenKey: token,
+		},
+		Type: k8sutil.RookType,
+	}
+	err = controllerutil.SetControllerReference(cephFilesystem, secret, r.scheme)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference for filesystem %q bootstrap peer token secret", cephFilesystem.Name)
+	}
+	err = opcontroller.CreateOrUpdateObject(r.client, secret)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create or update filesystem %q bootstrap peer token secret", cephFilesystem.Name)
+	}
+
+	return r.reconcileMirroringPeers(cephFilesystem)
+}
+
+// reconcileMirroringPeers imports the bootstrap peer tokens of the peer secrets of the filesystem, the token of a secret
+// being imported again only once the secret changed
+func (r *ReconcileCephFilesystem) reconcileMirroringPeers(cephFilesystem *cephv1.CephFilesystem) error {
+	if cephFilesystem.Spec.Mirroring.Peers == nil {
+		return nil
+	}
+	if r.peerSecretVersions == nil {
+		r.peerSecretVersions = map[types.NamespacedName]string{}
+	}
+
+	for _, secretName := range cephFilesystem.Spec.Mirroring.Peers.SecretNames {
+		name := types.NamespacedName{Name: secretName, Namespace: cephFilesystem.Namespace}
+		secret, err := r.context.Clientset.CoreV1().Secrets(name.Namespace).Get(name.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get peer secret %q", secretName)
+		}
+		if r.peerSecretVersions[name] == secret.ResourceVersion {
+			logger.Debugf("bootstrap peer token of secret %q already imported", secretName)
+			continue
+		}
+
+		token, ok := secret.Data[peerTokenKey]
+		if !ok || len(token) == 0 {
+			return errors.Errorf("peer secret %q has no %q key", secretName, peerTokenKey)
+		}
+		if err := cephclient.ImportFSMirrorBootstrapPeer(r.context, cephFilesystem.Namespace, cephFilesystem.Name, token); err != nil {
+			return errors.Wrapf(err, "failed to import bootstrap peer token of secret %q", secretName)
+		}
+		r.peerSecretVersions[name] = secret.ResourceVersion
+		logger.Infof("imported bootstrap peer token of secret %q for filesystem %q", secretName, cephFilesystem.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileMirroring(t *testing.T) {
+	c := &clusterd.Context{Executor: &exectest.MockExecutor{}, Clientset: test.New(t, 1)}
+	r := &ReconcileCephFilesystem{context: c, clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Octopus}}
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: "rook-ceph"}}
+	fs.Spec.Mirroring = &cephv1.FSMirroringSpec{Enabled: true}
+
+	// the mirroring requires pacific
+	assert.Error(t, r.reconcileMirroring(fs))
+}
+
+func TestReconcileMirroringPeers(t *testing.T) {
+	namespace := "rook-ceph"
+	imported := 0
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "snapshot" && args[4] == "import" {
+				assert.Equal(t, "myfs", args[5])
+				assert.Equal(t, "token", args[6])
+				imported++
+			}
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{Executor: executor, Clientset: test.New(t, 1)}
+	r := &ReconcileCephFilesystem{context: c, clusterInfo: &cephconfig.ClusterInfo{CephVersion: cephver.Pacific}}
+
+	fs := &cephv1.CephFilesystem{ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace}}
+	fs.Spec.Mirroring = &cephv1.FSMirroringSpec{Enabled: true}
+
+	// no peer to import
+	assert.NoError(t, r.reconcileMirroringPeers(fs))
+
+	// a missing secret fails the reconcile
+	fs.Spec.Mirroring.Peers = &cephv1.MirroringPeerSpec{SecretNames: []string{"peer-secret"}}
+	assert.Error(t, r.reconcileMirroringPeers(fs))
+
+	// a secret without a token fails the reconcile
+	peerSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "peer-secret", Namespace: namespace, ResourceVersion: "1"},
+		Data:       map[string][]byte{},
+	}
+	_, err := c.Clientset.CoreV1().Secrets(namespace).Create(peerSecret)
+	assert.NoError(t, err)
+	assert.Error(t, r.reconcileMirroringPeers(fs))
+	assert.Equal(t, 0, imported)
+
+	// the token is imported once
+	peerSecret.Data["token"] = []byte("token")
+	peerSecret.ResourceVersion = "2"
+	_, err = c.Clientset.CoreV1().Secrets(namespace).Update(peerSecret)
+	assert.NoError(t, err)
+	assert.NoError(t, r.reconcileMirroringPeers(fs))
+	assert.Equal(t, 1, imported)
+	assert.NoError(t, r.reconcileMirroringPeers(fs))
+	assert.Equal(t, 1, imported)
+
+	// the token is imported again once the secret changed
+	peerSecret.ResourceVersion = "3"
+	_, err = c.Clientset.CoreV1().Secrets(namespace).Update(peerSecret)
+	assert.NoError(t, err)
+	assert.NoError(t, r.reconcileMirroringPeers(fs))
+	assert.Equal(t, 2, imported)
+}
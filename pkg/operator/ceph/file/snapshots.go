@@ -0,0 +1,36 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package file
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+// reconcileSnapshotSchedules makes the snapshot schedules of the filesystem match the schedules of its spec
+func (r *ReconcileCephFilesystem) reconcileSnapshotSchedules(cephFilesystem *cephv1.CephFilesystem) error {
+	// the snapshot schedules of the filesystems are only available from pacific
+	if !r.clusterInfo.CephVersion.IsAtLeastPacific() {
+		return errors.Errorf("ceph version %q does not support the filesystem snapshot schedules, it requires ceph pacific or newer", r.clusterInfo.CephVersion.String())
+	}
+
+	if err := cephclient.MgrEnableModule(r.context, cephFilesystem.Namespace, cephclient.SnapshotScheduleModuleName, false); err != nil {
+		return errors.Wrapf(err, "failed to enable mgr module %q", cephclient.SnapshotScheduleModuleName)
+	}
+	return cephclient.ReconcileFilesystemSnapshotSchedules(r.context, cephFilesystem.Namespace, cephFilesystem.Name, cephFilesystem.Spec.SnapshotSchedules)
+}
@@ -0,0 +1,273 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package subvolumegroup to manage the subvolume groups of the filesystems, sharing a filesystem between tenants.
+package subvolumegroup
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-filesystem-subvolumegroup-controller"
+	// clusterIDKey is the key of the clusterID of the group in the csi config, in the status info
+	clusterIDKey = "clusterID"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephFilesystemSubVolumeGroupKind = reflect.TypeOf(cephv1.CephFilesystemSubVolumeGroup{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephFilesystemSubVolumeGroupKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephFilesystemSubVolumeGroup reconciles a CephFilesystemSubVolumeGroup object
+type ReconcileCephFilesystemSubVolumeGroup struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+}
+
+// Add creates a new CephFilesystemSubVolumeGroup Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileCephFilesystemSubVolumeGroup{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephFilesystemSubVolumeGroup CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephFilesystemSubVolumeGroup{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephFilesystemSubVolumeGroup object and makes changes based on the
+// state read and what is in the CephFilesystemSubVolumeGroup.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephFilesystemSubVolumeGroup) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephFilesystemSubVolumeGroup", request)
+	reconcileLogger.Debug("reconciling")
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephFilesystemSubVolumeGroup) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephFilesystemSubVolumeGroup instance
+	group := &cephv1.CephFilesystemSubVolumeGroup{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, group)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystemSubVolumeGroup resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephFilesystemSubVolumeGroup")
+	}
+
+	// The CR was just created, initializing status fields
+	if group.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, nil)
+	}
+
+	// The clusterID of the group in the csi config, unique across the clusters and the filesystems
+	clusterID := buildClusterID(group)
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The groups are gone with the filesystems of a deleted CephCluster, only the csi config is left
+		if !group.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			if err := csi.RemoveClusterConfig(r.context.Clientset, clusterID, csi.ConfigMutex); err != nil {
+				return reconcile.Result{}, errors.Wrapf(err, "failed to remove the csi config of subvolume group %q", request.NamespacedName.String())
+			}
+
+			// Remove finalizer
+			err = opcontroller.RemoveFinalizer(r.client, group)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, nil
+		}
+		return reconcileResponse, nil
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, group)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The group is created in a filesystem of the same namespace
+	fsName := types.NamespacedName{Name: group.Spec.FilesystemName, Namespace: group.Namespace}
+	fs := &cephv1.CephFilesystem{}
+	err = r.client.Get(context.TODO(), fsName, fs)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephFilesystem %q", fsName.String())
+	}
+	fsExists := err == nil
+
+	// DELETE: the CR was deleted
+	if !group.GetDeletionTimestamp().IsZero() {
+		// The group is gone with its filesystem. The group is not removed while it still has subvolumes.
+		if fsExists {
+			logger.Infof("deleting subvolume group %q", request.NamespacedName.String())
+			if err := cephclient.DeleteSubVolumeGroup(r.context, group.Namespace, fsName.Name, group.Name); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if err := csi.RemoveClusterConfig(r.context.Clientset, clusterID, csi.ConfigMutex); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to remove the csi config of subvolume group %q", request.NamespacedName.String())
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.client, group)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, nil
+	}
+
+	// validate the group settings against the ceph version of the cluster
+	cephVersion, err := opcontroller.GetClusterCephVersion(cephCluster)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to fetch ceph version from cephcluster %q", cephCluster.Name)
+	}
+	if err := validateSubVolumeGroup(group, *cephVersion); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "invalid subvolume group CR %q spec", group.Name)
+	}
+
+	if !fsExists || fs.Status == nil || fs.Status.Phase != k8sutil.ReadyStatus {
+		logger.Debugf("CephFilesystem %q not ready for subvolume group %q, retrying in %q", fsName.String(), request.NamespacedName.String(), opcontroller.WaitForRequeueIfCephClusterNotReady.RequeueAfter.String())
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+	}
+
+	// CREATE/UPDATE SUBVOLUME GROUP
+	err = createSubVolumeGroup(r.context, group, *cephVersion)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, err
+	}
+
+	// The csi drivers find the group of the subvolumes from the clusterID of the storage class
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, group.Namespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+	}
+	err = csi.SaveSubvolumeGroupConfig(r.context.Clientset, group.Namespace, clusterID, group.Name, clusterInfo, csi.ConfigMutex)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to save the csi config of subvolume group %q", request.NamespacedName.String())
+	}
+
+	// Set Ready status, we are done reconciling
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, map[string]string{clusterIDKey: clusterID})
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// buildClusterID returns the clusterID of the group in the csi config, derived from the namespace of the cluster, the
+// filesystem and the name of the group
+func buildClusterID(group *cephv1.CephFilesystemSubVolumeGroup) string {
+	return k8sutil.Hash(fmt.Sprintf("%s-%s-%s", group.Namespace, group.Spec.FilesystemName, group.Name))
+}
+
+// updateStatus updates a subvolume group with a given status, and its info if known
+func updateStatus(client client.Client, name types.NamespacedName, status string, info map[string]string) {
+	group := &cephv1.CephFilesystemSubVolumeGroup{}
+	if err := client.Get(context.TODO(), name, group); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephFilesystemSubVolumeGroup resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve subvolume group %q to update status to %q. %v", name, status, err)
+		return
+	}
+	if group.Status == nil {
+		group.Status = &cephv1.CephFilesystemSubVolumeGroupStatus{}
+	}
+
+	group.Status.Phase = status
+	if info != nil {
+		group.Status.Info = info
+	}
+	if err := opcontroller.UpdateStatus(client, group); err != nil {
+		logger.Errorf("failed to set subvolume group %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("subvolume group %q status updated to %q", name, status)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subvolumegroup
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestValidateSubVolumeGroup(t *testing.T) {
+	group := &cephv1.CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group1", Namespace: "rook-ceph"},
+		Spec:       cephv1.SubVolumeGroupSpec{FilesystemName: "myfs"},
+	}
+	assert.NoError(t, validateSubVolumeGroup(group, cephver.Nautilus))
+
+	// the filesystem is required
+	group.Spec.FilesystemName = ""
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Nautilus))
+	group.Spec.FilesystemName = "myfs"
+
+	// a single pinning policy requiring pacific
+	one, two := 1, 2
+	random := 0.5
+	group.Spec.Pinning = &cephv1.SubVolumeGroupPinningSpec{Distributed: &one}
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Octopus))
+	assert.NoError(t, validateSubVolumeGroup(group, cephver.Pacific))
+	group.Spec.Pinning.Random = &random
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Pacific))
+	group.Spec.Pinning = &cephv1.SubVolumeGroupPinningSpec{Distributed: &two}
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Pacific))
+	group.Spec.Pinning = &cephv1.SubVolumeGroupPinningSpec{}
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Pacific))
+	group.Spec.Pinning = &cephv1.SubVolumeGroupPinningSpec{Random: &random}
+	pinType, setting, err := pinSetting(group.Spec.Pinning)
+	assert.NoError(t, err)
+	assert.Equal(t, "random", pinType)
+	assert.Equal(t, "0.5", setting)
+	group.Spec.Pinning = nil
+
+	// the quota requires quincy
+	quota := resource.MustParse("10Gi")
+	group.Spec.Quota = &quota
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Pacific))
+	assert.NoError(t, validateSubVolumeGroup(group, cephver.Quincy))
+	quota = resource.MustParse("-1Gi")
+	assert.Error(t, validateSubVolumeGroup(group, cephver.Quincy))
+}
+
+func TestCephFilesystemSubVolumeGroupController(t *testing.T) {
+	csi.EnableCephFS = true
+	defer func() { csi.EnableCephFS = false }()
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	namespace := "rook-ceph"
+	zero := 0
+	group := &cephv1.CephFilesystemSubVolumeGroup{
+		ObjectMeta: metav1.ObjectMeta{Name: "group1", Namespace: namespace},
+		Spec: cephv1.SubVolumeGroupSpec{
+			FilesystemName: "myfs",
+			Pinning:        &cephv1.SubVolumeGroupPinningSpec{Export: &zero},
+		},
+	}
+	fs := &cephv1.CephFilesystem{
+		ObjectMeta: metav1.ObjectMeta{Name: "myfs", Namespace: namespace},
+		Status:     &cephv1.CephFilesystemStatus{Phase: ""},
+	}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:       k8sutil.ReadyStatus,
+			CephVersion: &cephv1.ClusterVersion{Version: "16.2.0-0"},
+			CephStatus:  &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+
+	// the mons of the cluster, for the csi config
+	clientset := testop.New(t, 1)
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.AppName, Namespace: namespace},
+		Data:       map[string][]byte{"cluster-name": []byte(namespace), "fsid": []byte("fsid"), "admin-secret": []byte("adminkey")},
+	})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.EndpointConfigMapName, Namespace: namespace},
+		Data:       map[string]string{mon.EndpointDataKey: "a=1.2.3.4:6789"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, csi.CreateCsiConfigMap(namespace, clientset, nil))
+
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "fs" && args[1] == "subvolumegroup" {
+				commands = append(commands, args[2:])
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephFilesystemSubVolumeGroup{}, &cephv1.CephFilesystem{}, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{group, fs, cephCluster}...)
+	r := &ReconcileCephFilesystemSubVolumeGroup{client: cl, scheme: s, context: &clusterd.Context{Executor: executor, Clientset: clientset}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: group.Name, Namespace: namespace}}
+
+	// the group waits for its filesystem to be ready
+	res, err := r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.Empty(t, commands)
+
+	// the group is created in the filesystem, pinned and added to the csi config
+	fs.Status.Phase = k8sutil.ReadyStatus
+	assert.NoError(t, cl.Update(context.TODO(), fs))
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, 2, len(commands))
+	assert.Equal(t, []string{"create", "myfs", "group1"}, commands[0][:3])
+	assert.Equal(t, []string{"pin", "myfs", "group1", "export", "0"}, commands[1][:5])
+	assert.NoError(t, cl.Get(context.TODO(), req.NamespacedName, group))
+	assert.Equal(t, k8sutil.ReadyStatus, group.Status.Phase)
+	clusterID := group.Status.Info["clusterID"]
+	assert.Equal(t, buildClusterID(group), clusterID)
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"`+clusterID+`","monitors":["1.2.3.4:6789"],"cephFS":{"subvolumeGroup":"group1"},"namespace":"rook-ceph"}]`, cm.Data[csi.ConfigKey])
+
+	// the group is removed from the filesystem and the csi config when deleted
+	commands = nil
+	now := metav1.Now()
+	group.DeletionTimestamp = &now
+	assert.NoError(t, cl.Update(context.TODO(), group))
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, [][]string{{"rm", "myfs", "group1", "--force"}}, [][]string{commands[0][:4]})
+	cm, err = clientset.CoreV1().ConfigMaps(namespace).Get(csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", cm.Data[csi.ConfigKey])
+	deleted := &cephv1.CephFilesystemSubVolumeGroup{}
+	assert.NoError(t, cl.Get(context.TODO(), req.NamespacedName, deleted))
+	assert.Empty(t, deleted.Finalizers)
+}
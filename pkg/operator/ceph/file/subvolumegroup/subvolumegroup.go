@@ -0,0 +1,117 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package subvolumegroup
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+)
+
+// validateSubVolumeGroup checks the settings of the group, and that the cluster supports them
+func validateSubVolumeGroup(group *cephv1.CephFilesystemSubVolumeGroup, cephVersion cephver.CephVersion) error {
+	if group.Spec.FilesystemName == "" {
+		return errors.New("the filesystemName must be set")
+	}
+	if !cephVersion.IsAtLeastNautilus() {
+		return errors.Errorf("ceph version %q does not support the subvolume groups, they require ceph nautilus or newer", cephVersion.String())
+	}
+
+	if pinning := group.Spec.Pinning; pinning != nil {
+		if !cephVersion.IsAtLeastPacific() {
+			return errors.Errorf("the pinning of the subvolume groups requires ceph pacific, the cluster runs %q", cephVersion.String())
+		}
+		if _, _, err := pinSetting(pinning); err != nil {
+			return err
+		}
+	}
+
+	if group.Spec.Quota != nil {
+		if !cephVersion.IsAtLeastQuincy() {
+			return errors.Errorf("the quota of the subvolume groups requires ceph quincy, the cluster runs %q", cephVersion.String())
+		}
+		if group.Spec.Quota.Sign() < 0 {
+			return errors.Errorf("invalid quota %q, it must not be negative", group.Spec.Quota.String())
+		}
+	}
+	return nil
+}
+
+// pinSetting returns the type and the setting of the pin of a group, only one of its policies being allowed
+func pinSetting(pinning *cephv1.SubVolumeGroupPinningSpec) (string, string, error) {
+	var pinType, setting string
+	policies := 0
+	if pinning.Export != nil {
+		if *pinning.Export < -1 {
+			return "", "", errors.Errorf("invalid export pin %d, it must be an MDS rank or -1", *pinning.Export)
+		}
+		pinType, setting = cephclient.SubVolumeGroupPinExport, strconv.Itoa(*pinning.Export)
+		policies++
+	}
+	if pinning.Distributed != nil {
+		if *pinning.Distributed != 0 && *pinning.Distributed != 1 {
+			return "", "", errors.Errorf("invalid distributed pin %d, it must be 0 or 1", *pinning.Distributed)
+		}
+		pinType, setting = cephclient.SubVolumeGroupPinDistributed, strconv.Itoa(*pinning.Distributed)
+		policies++
+	}
+	if pinning.Random != nil {
+		if *pinning.Random < 0 || *pinning.Random > 1 {
+			return "", "", errors.Errorf("invalid random pin %v, it must be between 0 and 1", *pinning.Random)
+		}
+		pinType, setting = cephclient.SubVolumeGroupPinRandom, strconv.FormatFloat(*pinning.Random, 'f', -1, 64)
+		policies++
+	}
+	if policies != 1 {
+		return "", "", errors.New("exactly one of the export, distributed and random pinning policies must be set")
+	}
+	return pinType, setting, nil
+}
+
+// createSubVolumeGroup creates the group in its filesystem, and applies its pinning and its quota
+func createSubVolumeGroup(context *clusterd.Context, group *cephv1.CephFilesystemSubVolumeGroup, cephVersion cephver.CephVersion) error {
+	fsName := group.Spec.FilesystemName
+	if err := cephclient.CreateSubVolumeGroup(context, group.Namespace, fsName, group.Name); err != nil {
+		return err
+	}
+
+	if group.Spec.Pinning != nil {
+		pinType, setting, err := pinSetting(group.Spec.Pinning)
+		if err != nil {
+			return err
+		}
+		if err := cephclient.PinSubVolumeGroup(context, group.Namespace, fsName, group.Name, pinType, setting); err != nil {
+			return err
+		}
+	}
+
+	// the quota removed from the spec is removed from the group
+	if cephVersion.IsAtLeastQuincy() {
+		var size int64
+		if group.Spec.Quota != nil {
+			size = group.Spec.Quota.Value()
+		}
+		if err := cephclient.ResizeSubVolumeGroup(context, group.Namespace, fsName, group.Name, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
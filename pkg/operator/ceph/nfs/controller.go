@@ -96,7 +96,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -127,10 +127,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephNFS) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephNFS", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -201,9 +203,9 @@ func (r *ReconcileCephNFS) reconcile(request reconcile.Request) (reconcile.Resul
 	// DELETE: the CR was deleted
 	if !cephNFS.GetDeletionTimestamp().IsZero() {
 		logger.Infof("deleting ceph nfs %q", cephNFS.Name)
-		err := r.removeServersFromDatabase(cephNFS, 0)
-		if err != nil {
-			return reconcile.Result{}, errors.Wrapf(err, "failed to delete filesystem %q. ", cephNFS.Name)
+		// the servers are removed from the grace db, the deletion not being blocked when it fails
+		if err := r.reconcileGraceMembers(cephNFS, 0); err != nil {
+			logger.Errorf("failed to remove the servers of ceph nfs %q from the grace db. %v", cephNFS.Name, err)
 		}
 
 		// Remove finalizer
@@ -248,38 +250,41 @@ func (r *ReconcileCephNFS) reconcileCreateCephNFS(cephNFS *cephv1.CephNFS) (reco
 		}
 	}
 
-	deployments, err := r.context.Clientset.AppsV1().Deployments(cephNFS.Namespace).List(metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", AppName)})
+	selector := fmt.Sprintf("%s=%s,ceph_nfs=%s", k8sutil.AppAttr, AppName, cephNFS.Name)
+	deployments, err := r.context.Clientset.AppsV1().Deployments(cephNFS.Namespace).List(metav1.ListOptions{LabelSelector: selector})
 	if err != nil {
-		if kerrors.IsNotFound(err) {
-			logger.Infof("creating ceph nfs %q", cephNFS.Name)
-			err := r.upCephNFS(cephNFS, 0)
-			if err != nil {
-				return reconcile.Result{}, errors.Wrapf(err, "failed to create ceph nfs %q", cephNFS.Name)
-			}
-		} else {
-			return reconcile.Result{}, errors.Wrap(err, "failed to list ceph nfs deployments")
-		}
-	} else {
-		// Scale up case (CR value cephNFS.Spec.Server.Active changed)
-		nfsServerListNum := len(deployments.Items)
-		if nfsServerListNum < cephNFS.Spec.Server.Active {
-			logger.Infof("scaling up ceph nfs %q from %d to %d", cephNFS.Name, nfsServerListNum, cephNFS.Spec.Server.Active)
-			err := r.upCephNFS(cephNFS, nfsServerListNum)
-			if err != nil {
-				return reconcile.Result{}, errors.Wrapf(err, "failed to scale up ceph nfs %q", cephNFS.Name)
-			}
+		return reconcile.Result{}, errors.Wrap(err, "failed to list ceph nfs deployments")
+	}
+	nfsServerListNum := len(deployments.Items)
+
+	// Scale down case (CR value cephNFS.Spec.Server.Active changed)
+	if nfsServerListNum > cephNFS.Spec.Server.Active {
+		logger.Infof("scaling down ceph nfs %q from %d to %d", cephNFS.Name, nfsServerListNum, cephNFS.Spec.Server.Active)
+		err := r.downCephNFS(cephNFS, nfsServerListNum)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to scale down ceph nfs %q", cephNFS.Name)
 		}
+	}
 
-		// Scale down case (CR value cephNFS.Spec.Server.Active changed)
-		if nfsServerListNum > cephNFS.Spec.Server.Active {
-			logger.Infof("scaling down ceph nfs %q from %d to %d", cephNFS.Name, nfsServerListNum, cephNFS.Spec.Server.Active)
-			err := r.downCephNFS(cephNFS, nfsServerListNum)
-			if err != nil {
-				return reconcile.Result{}, errors.Wrapf(err, "failed to scale down ceph nfs %q", cephNFS.Name)
-			}
+	// The active servers must be in the grace db before they start
+	if err := r.reconcileGraceMembers(cephNFS, cephNFS.Spec.Server.Active); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the grace db of ceph nfs %q", cephNFS.Name)
+	}
+
+	// Scale up case (CR value cephNFS.Spec.Server.Active changed)
+	if nfsServerListNum < cephNFS.Spec.Server.Active {
+		logger.Infof("scaling up ceph nfs %q from %d to %d", cephNFS.Name, nfsServerListNum, cephNFS.Spec.Server.Active)
+		err := r.upCephNFS(cephNFS, nfsServerListNum)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to scale up ceph nfs %q", cephNFS.Name)
 		}
 	}
 
+	// Export the filesystems and the buckets of the spec
+	if err := r.reconcileExports(cephNFS); err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the exports of ceph nfs %q", cephNFS.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
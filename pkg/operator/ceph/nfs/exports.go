@@ -0,0 +1,364 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	cephconfig "github.com/rook/rook/pkg/daemon/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	maxExportID = 65535
+)
+
+var (
+	exportAccessTypes = map[string]string{"": "RW", "RW": "RW", "RO": "RO", "None": "None"}
+	exportSquashes    = map[string]string{"": "No_Root_Squash", "none": "No_Root_Squash", "root": "Root_Squash", "all": "All_Squash"}
+)
+
+// rgwExportUser is the keys of a user accessing the exported buckets
+type rgwExportUser struct {
+	accessKey string
+	secretKey string
+}
+
+func getExportObjectPrefix(n *cephv1.CephNFS) string {
+	return fmt.Sprintf("export-%s-", n.Name)
+}
+
+func getExportObject(n *cephv1.CephNFS, id int) string {
+	return fmt.Sprintf("%s%d", getExportObjectPrefix(n), id)
+}
+
+func getExportURL(n *cephv1.CephNFS, id int) string {
+	url := fmt.Sprintf("rados://%s/", n.Spec.RADOS.Pool)
+
+	if n.Spec.RADOS.Namespace != "" {
+		url += n.Spec.RADOS.Namespace + "/"
+	}
+
+	return url + getExportObject(n, id)
+}
+
+// validateExports validates the exports of the ganesha servers
+func validateExports(n *cephv1.CephNFS) error {
+	ids := map[int]bool{}
+	pseudoPaths := map[string]bool{}
+	objectStore := ""
+	for _, export := range n.Spec.Exports {
+		if export.ID < 1 || export.ID > maxExportID {
+			return errors.Errorf("invalid export id %d, must be between 1 and %d", export.ID, maxExportID)
+		}
+		if ids[export.ID] {
+			return errors.Errorf("duplicate export id %d", export.ID)
+		}
+		ids[export.ID] = true
+
+		if !path.IsAbs(export.PseudoPath) || export.PseudoPath == "/" {
+			return errors.Errorf("invalid pseudoPath %q of export %d, must be an absolute path other than \"/\"", export.PseudoPath, export.ID)
+		}
+		if pseudoPaths[path.Clean(export.PseudoPath)] {
+			return errors.Errorf("duplicate pseudoPath %q of export %d", export.PseudoPath, export.ID)
+		}
+		pseudoPaths[path.Clean(export.PseudoPath)] = true
+
+		if _, ok := exportAccessTypes[export.AccessType]; !ok {
+			return errors.Errorf("invalid accessType %q of export %d, must be RW, RO or None", export.AccessType, export.ID)
+		}
+		if _, ok := exportSquashes[export.Squash]; !ok {
+			return errors.Errorf("invalid squash %q of export %d, must be none, root or all", export.Squash, export.ID)
+		}
+
+		if (export.CephFS == nil) == (export.RGW == nil) {
+			return errors.Errorf("export %d must be backed by either cephfs or rgw", export.ID)
+		}
+		if export.CephFS != nil {
+			if export.CephFS.Filesystem == "" {
+				return errors.Errorf("missing filesystem of export %d", export.ID)
+			}
+			if export.CephFS.Path != "" && !path.IsAbs(export.CephFS.Path) {
+				return errors.Errorf("invalid path %q of export %d, must be an absolute path", export.CephFS.Path, export.ID)
+			}
+		}
+		if export.RGW != nil {
+			if export.RGW.ObjectStore == "" || export.RGW.Bucket == "" || export.RGW.User == "" {
+				return errors.Errorf("export %d requires the objectStore, the bucket and the user", export.ID)
+			}
+			// A ganesha server runs the rgw library of a single object store
+			if objectStore != "" && objectStore != export.RGW.ObjectStore {
+				return errors.Errorf("the rgw exports must all be in the same object store, found %q and %q", objectStore, export.RGW.ObjectStore)
+			}
+			objectStore = export.RGW.ObjectStore
+		}
+	}
+
+	return nil
+}
+
+// rgwObjectStore returns the object store of the rgw exports, empty if there are none
+func rgwObjectStore(n *cephv1.CephNFS) string {
+	for _, export := range n.Spec.Exports {
+		if export.RGW != nil {
+			return export.RGW.ObjectStore
+		}
+	}
+	return ""
+}
+
+// getExportBlock returns the ganesha EXPORT block of the export
+func getExportBlock(export cephv1.NFSExportSpec, rgwUsers map[string]rgwExportUser) string {
+	var exportPath, fsal string
+	if export.CephFS != nil {
+		exportPath = export.CephFS.Path
+		if exportPath == "" {
+			exportPath = "/"
+		}
+		fsal = `
+		Name = CEPH;
+		User_Id = "` + userID + `";
+		Filesystem = "` + export.CephFS.Filesystem + `";`
+	} else {
+		exportPath = export.RGW.Bucket
+		user := rgwUsers[export.RGW.User]
+		fsal = `
+		Name = RGW;
+		User_Id = "` + export.RGW.User + `";
+		Access_Key_Id = "` + user.accessKey + `";
+		Secret_Access_Key = "` + user.secretKey + `";`
+	}
+
+	return `
+EXPORT {
+	Export_Id = ` + fmt.Sprintf("%d", export.ID) + `;
+	Path = "` + exportPath + `";
+	Pseudo = "` + export.PseudoPath + `";
+	Access_Type = ` + exportAccessTypes[export.AccessType] + `;
+	Squash = ` + exportSquashes[export.Squash] + `;
+	Protocols = 4;
+	Transports = TCP;
+	FSAL {` + fsal + `
+	}
+}
+`
+}
+
+// getServerConfig returns the config of the ganesha servers stored in their RADOS config object, including the exports
+func getServerConfig(n *cephv1.CephNFS, realm, zoneGroup, zone string) string {
+	config := ""
+	if realm != "" {
+		config += `
+RGW {
+	ceph_conf = '` + cephconfig.DefaultConfigFilePath() + `';
+	name = "client.` + userID + `";
+	init_args = "--rgw-realm=` + realm + ` --rgw-zonegroup=` + zoneGroup + ` --rgw-zone=` + zone + `";
+}
+`
+	}
+
+	ids := []int{}
+	for _, export := range n.Spec.Exports {
+		ids = append(ids, export.ID)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		config += fmt.Sprintf("\n%%url \"%s\"", getExportURL(n, id))
+	}
+	if config != "" {
+		config += "\n"
+	}
+	return config
+}
+
+// reconcileExports writes the exports of the ganesha servers in their RADOS objects, and notifies the servers to reload
+// their config when they changed
+func (r *ReconcileCephNFS) reconcileExports(n *cephv1.CephNFS) error {
+	exportObjects, err := r.listExportObjects(n)
+	if err != nil {
+		return err
+	}
+	// Without exports in the spec nor left from it, the config of the servers is not managed by the operator, letting
+	// the exports be managed by other tools such as the dashboard
+	if len(n.Spec.Exports) == 0 && len(exportObjects) == 0 {
+		logger.Debugf("no exports to reconcile for ceph nfs %q", n.Name)
+		return nil
+	}
+
+	realm, zoneGroup, zone, rgwUsers, err := r.getRGWExportSettings(n)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	desiredObjects := map[string]bool{}
+	for _, export := range n.Spec.Exports {
+		if export.CephFS != nil {
+			if _, err := cephclient.GetFilesystem(r.context, n.Namespace, export.CephFS.Filesystem); err != nil {
+				return errors.Wrapf(err, "failed to get filesystem %q of export %d", export.CephFS.Filesystem, export.ID)
+			}
+		}
+
+		object := getExportObject(n, export.ID)
+		desiredObjects[object] = true
+		updated, err := r.putRADOSObjectIfChanged(n, object, getExportBlock(export, rgwUsers))
+		if err != nil {
+			return errors.Wrapf(err, "failed to save export %d", export.ID)
+		}
+		changed = changed || updated
+	}
+
+	// The config of the servers includes the objects of their exports
+	config := getServerConfig(n, realm, zoneGroup, zone)
+	for i := 0; i < n.Spec.Server.Active; i++ {
+		updated, err := r.putRADOSObjectIfChanged(n, getGaneshaConfigObject(getNFSNodeID(n, k8sutil.IndexToName(i))), config)
+		if err != nil {
+			return errors.Wrap(err, "failed to save the ganesha config")
+		}
+		changed = changed || updated
+	}
+
+	for _, object := range exportObjects {
+		if desiredObjects[object] {
+			continue
+		}
+		logger.Infof("removing ganesha export object %q", object)
+		if err := r.context.Executor.ExecuteCommand("rados", append(r.radosArgs(n), "rm", object)...); err != nil {
+			return errors.Wrapf(err, "failed to remove RADOS object %q", object)
+		}
+		changed = true
+	}
+
+	if !changed {
+		logger.Debugf("exports of ceph nfs %q are up to date", n.Name)
+		return nil
+	}
+
+	logger.Infof("reloading the exports of ceph nfs %q", n.Name)
+	for i := 0; i < n.Spec.Server.Active; i++ {
+		object := getGaneshaConfigObject(getNFSNodeID(n, k8sutil.IndexToName(i)))
+		if err := r.context.Executor.ExecuteCommand("rados", append(r.radosArgs(n), "notify", object, object)...); err != nil {
+			return errors.Wrapf(err, "failed to notify ganesha config %q", object)
+		}
+	}
+
+	return nil
+}
+
+// getRGWExportSettings returns the realm, zone group and zone of the object store of the rgw exports, and the keys of
+// the users accessing the exported buckets
+func (r *ReconcileCephNFS) getRGWExportSettings(n *cephv1.CephNFS) (string, string, string, map[string]rgwExportUser, error) {
+	storeName := rgwObjectStore(n)
+	if storeName == "" {
+		return "", "", "", nil, nil
+	}
+
+	store, err := r.context.RookClientset.CephV1().CephObjectStores(n.Namespace).Get(storeName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", "", nil, errors.Wrapf(err, "failed to get object store %q of the rgw exports", storeName)
+	}
+	realm, zoneGroup, zone := store.Name, store.Name, store.Name
+	if store.Spec.IsMultisite() {
+		objectZone, err := r.context.RookClientset.CephV1().CephObjectZones(n.Namespace).Get(store.Spec.Zone.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", "", "", nil, errors.Wrapf(err, "failed to get zone %q of object store %q", store.Spec.Zone.Name, storeName)
+		}
+		objectZoneGroup, err := r.context.RookClientset.CephV1().CephObjectZoneGroups(n.Namespace).Get(objectZone.Spec.ZoneGroup, metav1.GetOptions{})
+		if err != nil {
+			return "", "", "", nil, errors.Wrapf(err, "failed to get zone group %q of object store %q", objectZone.Spec.ZoneGroup, storeName)
+		}
+		realm, zoneGroup, zone = objectZoneGroup.Spec.Realm, objectZoneGroup.Name, objectZone.Name
+	}
+
+	users := map[string]rgwExportUser{}
+	for _, export := range n.Spec.Exports {
+		if export.RGW == nil {
+			continue
+		}
+		if _, ok := users[export.RGW.User]; ok {
+			continue
+		}
+		secretName := fmt.Sprintf("rook-ceph-object-user-%s-%s", storeName, export.RGW.User)
+		secret, err := r.context.Clientset.CoreV1().Secrets(n.Namespace).Get(secretName, metav1.GetOptions{})
+		if err != nil {
+			return "", "", "", nil, errors.Wrapf(err, "failed to get the keys of user %q of export %d", export.RGW.User, export.ID)
+		}
+		users[export.RGW.User] = rgwExportUser{accessKey: string(secret.Data["AccessKey"]), secretKey: string(secret.Data["SecretKey"])}
+	}
+
+	return realm, zoneGroup, zone, users, nil
+}
+
+func (r *ReconcileCephNFS) radosArgs(n *cephv1.CephNFS) []string {
+	return []string{
+		"--pool", n.Spec.RADOS.Pool,
+		"--namespace", n.Spec.RADOS.Namespace,
+		"--conf", cephclient.CephConfFilePath(r.context.ConfigDir, n.Namespace),
+	}
+}
+
+// putRADOSObjectIfChanged writes the content of the RADOS object, returning whether it changed
+func (r *ReconcileCephNFS) putRADOSObjectIfChanged(n *cephv1.CephNFS, object, content string) (bool, error) {
+	// The object is rewritten if it cannot be read, such as when it does not exist yet
+	current, err := r.context.Executor.ExecuteCommandWithOutputFile("rados", object, append(r.radosArgs(n), "get")...)
+	if err == nil && current == content {
+		return false, nil
+	}
+
+	file, err := ioutil.TempFile("", "ganesha-config")
+	if err != nil {
+		return false, errors.Wrap(err, "failed to create the ganesha config file")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.WriteString(content)
+	file.Close()
+	if err != nil {
+		return false, errors.Wrap(err, "failed to write the ganesha config file")
+	}
+
+	logger.Infof("writing ganesha config object %q", object)
+	if err := r.context.Executor.ExecuteCommand("rados", append(r.radosArgs(n), "put", object, file.Name())...); err != nil {
+		return false, errors.Wrapf(err, "failed to write RADOS object %q", object)
+	}
+	return true, nil
+}
+
+// listExportObjects returns the RADOS objects of the exports of the ganesha servers
+func (r *ReconcileCephNFS) listExportObjects(n *cephv1.CephNFS) ([]string, error) {
+	output, err := r.context.Executor.ExecuteCommandWithOutput("rados", append(r.radosArgs(n), "ls")...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the RADOS objects of the exports")
+	}
+
+	objects := []string{}
+	for _, object := range strings.Split(output, "\n") {
+		object = strings.TrimSpace(object)
+		if strings.HasPrefix(object, getExportObjectPrefix(n)) {
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
@@ -0,0 +1,189 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"errors"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func exportsNFS() *cephv1.CephNFS {
+	return &cephv1.CephNFS{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: cephv1.NFSGaneshaSpec{
+			RADOS:  cephv1.GaneshaRADOSSpec{Pool: "nfs-ganesha", Namespace: "nfs-ns"},
+			Server: cephv1.GaneshaServerSpec{Active: 2},
+			Exports: []cephv1.NFSExportSpec{
+				{ID: 1, PseudoPath: "/fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs", Path: "/volumes"}},
+			},
+		},
+	}
+}
+
+func TestValidateExports(t *testing.T) {
+	n := exportsNFS()
+	assert.NoError(t, validateExports(n))
+
+	// No exports
+	n.Spec.Exports = nil
+	assert.NoError(t, validateExports(n))
+
+	invalid := []cephv1.NFSExportSpec{
+		{ID: 0, PseudoPath: "/fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "/", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "/fs", AccessType: "WO", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "/fs", Squash: "nobody", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "/fs"},
+		{ID: 1, PseudoPath: "/fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}, RGW: &cephv1.NFSExportRGWSpec{ObjectStore: "store", Bucket: "b", User: "u"}},
+		{ID: 1, PseudoPath: "/fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs", Path: "volumes"}},
+		{ID: 1, PseudoPath: "/b", RGW: &cephv1.NFSExportRGWSpec{ObjectStore: "store", Bucket: "b"}},
+	}
+	for _, export := range invalid {
+		n.Spec.Exports = []cephv1.NFSExportSpec{export}
+		assert.Error(t, validateExports(n), export)
+	}
+
+	// Duplicate ids and pseudo paths
+	n.Spec.Exports = []cephv1.NFSExportSpec{
+		{ID: 1, PseudoPath: "/fs", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+		{ID: 1, PseudoPath: "/other", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}},
+	}
+	assert.Error(t, validateExports(n))
+	n.Spec.Exports[1] = cephv1.NFSExportSpec{ID: 2, PseudoPath: "/fs/", CephFS: &cephv1.NFSExportCephFSSpec{Filesystem: "myfs"}}
+	assert.Error(t, validateExports(n))
+
+	// The rgw exports must be in the same object store
+	n.Spec.Exports = []cephv1.NFSExportSpec{
+		{ID: 1, PseudoPath: "/a", RGW: &cephv1.NFSExportRGWSpec{ObjectStore: "store-a", Bucket: "a", User: "u"}},
+		{ID: 2, PseudoPath: "/b", RGW: &cephv1.NFSExportRGWSpec{ObjectStore: "store-b", Bucket: "b", User: "u"}},
+	}
+	assert.Error(t, validateExports(n))
+	n.Spec.Exports[1].RGW.ObjectStore = "store-a"
+	assert.NoError(t, validateExports(n))
+}
+
+func TestGetExportBlock(t *testing.T) {
+	n := exportsNFS()
+	block := getExportBlock(n.Spec.Exports[0], nil)
+	assert.Contains(t, block, "Export_Id = 1;")
+	assert.Contains(t, block, `Path = "/volumes";`)
+	assert.Contains(t, block, `Pseudo = "/fs";`)
+	assert.Contains(t, block, "Access_Type = RW;")
+	assert.Contains(t, block, "Squash = No_Root_Squash;")
+	assert.Contains(t, block, "Name = CEPH;")
+	assert.Contains(t, block, `Filesystem = "myfs";`)
+
+	export := cephv1.NFSExportSpec{ID: 2, PseudoPath: "/bucket", AccessType: "RO", Squash: "all", RGW: &cephv1.NFSExportRGWSpec{ObjectStore: "store", Bucket: "my-bucket", User: "my-user"}}
+	block = getExportBlock(export, map[string]rgwExportUser{"my-user": {accessKey: "access", secretKey: "secret"}})
+	assert.Contains(t, block, `Path = "my-bucket";`)
+	assert.Contains(t, block, "Access_Type = RO;")
+	assert.Contains(t, block, "Squash = All_Squash;")
+	assert.Contains(t, block, "Name = RGW;")
+	assert.Contains(t, block, `User_Id = "my-user";`)
+	assert.Contains(t, block, `Access_Key_Id = "access";`)
+	assert.Contains(t, block, `Secret_Access_Key = "secret";`)
+
+	n.Spec.Exports = append(n.Spec.Exports, export)
+	config := getServerConfig(n, "realm", "zonegroup", "zone")
+	assert.Contains(t, config, `init_args = "--rgw-realm=realm --rgw-zonegroup=zonegroup --rgw-zone=zone";`)
+	assert.Contains(t, config, `%url "rados://nfs-ganesha/nfs-ns/export-my-nfs-1"`)
+	assert.Contains(t, config, `%url "rados://nfs-ganesha/nfs-ns/export-my-nfs-2"`)
+
+	n.Spec.Exports = nil
+	assert.Equal(t, "", getServerConfig(n, "", "", ""))
+}
+
+func TestReconcileExports(t *testing.T) {
+	objects := map[string]string{}
+	notified := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if command == "rados" && args[len(args)-1] == "get" {
+				if content, ok := objects[outfile]; ok {
+					return content, nil
+				}
+				return "", errors.New("no such object")
+			}
+			if args[0] == "fs" && args[1] == "get" {
+				return `{"mdsmap":{"fs_name":"myfs"}}`, nil
+			}
+			return "", errors.New("unknown command")
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			names := []string{}
+			for object := range objects {
+				names = append(names, object)
+			}
+			sort.Strings(names)
+			return strings.Join(names, "\n"), nil
+		},
+		MockExecuteCommand: func(command string, args ...string) error {
+			switch args[6] {
+			case "put":
+				content, err := ioutil.ReadFile(args[8])
+				assert.NoError(t, err)
+				objects[args[7]] = string(content)
+			case "rm":
+				delete(objects, args[7])
+			case "notify":
+				notified = append(notified, args[7])
+			default:
+				return errors.New("unknown command")
+			}
+			return nil
+		},
+	}
+	r := &ReconcileCephNFS{context: &clusterd.Context{Executor: executor}}
+
+	// The config of the servers is not managed without exports
+	n := exportsNFS()
+	exports := n.Spec.Exports
+	n.Spec.Exports = nil
+	assert.NoError(t, r.reconcileExports(n))
+	assert.Empty(t, objects)
+	assert.Empty(t, notified)
+
+	n.Spec.Exports = exports
+	assert.NoError(t, r.reconcileExports(n))
+	assert.Contains(t, objects["export-my-nfs-1"], `Pseudo = "/fs";`)
+	assert.Equal(t, "\n%url \"rados://nfs-ganesha/nfs-ns/export-my-nfs-1\"\n", objects["conf-my-nfs.a"])
+	assert.Equal(t, objects["conf-my-nfs.a"], objects["conf-my-nfs.b"])
+	assert.Equal(t, []string{"conf-my-nfs.a", "conf-my-nfs.b"}, notified)
+
+	// The servers are not notified when nothing changed
+	notified = []string{}
+	assert.NoError(t, r.reconcileExports(n))
+	assert.Empty(t, notified)
+
+	// The removed exports are cleaned up
+	n.Spec.Exports = nil
+	assert.NoError(t, r.reconcileExports(n))
+	_, ok := objects["export-my-nfs-1"]
+	assert.False(t, ok)
+	assert.Equal(t, "", objects["conf-my-nfs.a"])
+	assert.Equal(t, []string{"conf-my-nfs.a", "conf-my-nfs.b"}, notified)
+}
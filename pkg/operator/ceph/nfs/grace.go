@@ -0,0 +1,129 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+)
+
+// graceMembers returns the ids of the servers of the CephNFS in the grace database
+func (r *ReconcileCephNFS) graceMembers(n *cephv1.CephNFS) ([]string, error) {
+	// the dump is read from the output, the ceph config is passed as an argument rather than in the environment
+	args := []string{
+		"--cephconf", cephclient.CephConfFilePath(r.context.ConfigDir, n.Namespace),
+		"--pool", n.Spec.RADOS.Pool,
+		"--ns", n.Spec.RADOS.Namespace,
+		"dump",
+	}
+	output, err := r.context.Executor.ExecuteCommandWithOutput(ganeshaRadosGraceCmd, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dump the grace db")
+	}
+	return parseGraceMembers(n, output), nil
+}
+
+// parseGraceMembers parses the dump of the grace database, e.g.
+//
+//	cur=3 rec=0
+//	======================================================
+//	my-nfs.a	E
+//	my-nfs.b	NE
+//
+// The servers of the other CephNFS sharing the RADOS namespace are ignored.
+func parseGraceMembers(n *cephv1.CephNFS, output string) []string {
+	prefix := getNFSNodeID(n, "")
+	members := []string{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], prefix) {
+			continue
+		}
+		id := strings.TrimPrefix(fields[0], prefix)
+		// the ids are letters, a dot belonging to the server of a CephNFS named with the prefix
+		if id == "" || strings.Contains(id, ".") {
+			continue
+		}
+		members = append(members, id)
+	}
+	sort.Strings(members)
+	return members
+}
+
+// reconcileGraceMembers makes the active servers the members of the grace database. The missing servers are added
+// before their deployment is started, ganesha failing to start otherwise, and the servers scaled down without being
+// removed from the database are removed, their need for a grace period blocking the lift of the grace periods.
+func (r *ReconcileCephNFS) reconcileGraceMembers(n *cephv1.CephNFS, active int) error {
+	members, err := r.graceMembers(n)
+	if err != nil {
+		return err
+	}
+
+	isMember := map[string]bool{}
+	for _, id := range members {
+		isMember[id] = true
+	}
+	isActive := map[string]bool{}
+	for i := 0; i < active; i++ {
+		id := k8sutil.IndexToName(i)
+		isActive[id] = true
+		if !isMember[id] {
+			if err := r.addServerToDatabase(n, id); err != nil {
+				return errors.Wrapf(err, "failed to add server %q to database", id)
+			}
+		}
+	}
+	for _, id := range members {
+		if !isActive[id] {
+			logger.Infof("removing orphaned ganesha %q from the grace db of ceph nfs %q", id, n.Name)
+			if err := r.runGaneshaRadosGrace(n, id, "remove"); err != nil {
+				return errors.Wrapf(err, "failed to remove %q from grace db", id)
+			}
+		}
+	}
+
+	return nil
+}
+
+// drainServer stops a server before its removal. A grace period is started on its behalf first, so that the locks and
+// the opens of its clients are not granted to others by the remaining servers while the clients recover on them. The
+// server is then stopped gracefully, its deployment being deleted and its pod waited for, and removed from the grace
+// database, which lets the remaining servers lift the grace period.
+func (r *ReconcileCephNFS) drainServer(n *cephv1.CephNFS, id string) error {
+	logger.Infof("starting a grace period for the clients of ganesha %q", id)
+	if err := r.runGaneshaRadosGrace(n, id, "start"); err != nil {
+		return errors.Wrapf(err, "failed to start a grace period for %q", id)
+	}
+
+	name := instanceName(n, id)
+	logger.Infof("removing deployment %q", name)
+	if err := k8sutil.DeleteDeployment(r.context.Clientset, n.Namespace, name); err != nil {
+		return errors.Wrapf(err, "failed to delete ceph nfs deployment %q", name)
+	}
+
+	logger.Infof("removing ganesha %q from grace db", id)
+	if err := r.runGaneshaRadosGrace(n, id, "remove"); err != nil {
+		return errors.Wrapf(err, "failed to remove %q from grace db", id)
+	}
+
+	return nil
+}
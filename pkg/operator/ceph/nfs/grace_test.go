@@ -0,0 +1,74 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nfs
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const graceDump = `cur=3 rec=0
+======================================================
+my-nfs.a	 E
+my-nfs.c	NE
+my-nfs.b.a	 E
+other.a	 E
+`
+
+func TestParseGraceMembers(t *testing.T) {
+	n := &cephv1.CephNFS{ObjectMeta: metav1.ObjectMeta{Name: "my-nfs"}}
+	assert.Equal(t, []string{"a", "c"}, parseGraceMembers(n, graceDump))
+	assert.Equal(t, []string{}, parseGraceMembers(n, ""))
+
+	// the servers of a CephNFS named with the prefix of another are its own
+	n.Name = "my-nfs.b"
+	assert.Equal(t, []string{"a"}, parseGraceMembers(n, graceDump))
+}
+
+func TestReconcileGraceMembers(t *testing.T) {
+	n := &cephv1.CephNFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-nfs", Namespace: "rook-ceph"},
+		Spec:       cephv1.NFSGaneshaSpec{RADOS: cephv1.GaneshaRADOSSpec{Pool: "foo", Namespace: "nfs-ns"}},
+	}
+	actions := map[string]string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Equal(t, ganeshaRadosGraceCmd, command)
+			assert.Equal(t, "dump", args[6])
+			return graceDump, nil
+		},
+		MockExecuteCommandWithEnv: func(env []string, command string, args ...string) error {
+			actions[args[5]] = args[4]
+			return nil
+		},
+	}
+	r := &ReconcileCephNFS{context: &clusterd.Context{Executor: executor}}
+
+	// b is added and c, scaled down without being removed, is removed
+	assert.NoError(t, r.reconcileGraceMembers(n, 2))
+	assert.Equal(t, map[string]string{"my-nfs.b": "add", "my-nfs.c": "remove"}, actions)
+
+	// all the servers are removed when the CephNFS is deleted
+	actions = map[string]string{}
+	assert.NoError(t, r.reconcileGraceMembers(n, 0))
+	assert.Equal(t, map[string]string{"my-nfs.a": "remove", "my-nfs.c": "remove"}, actions)
+}
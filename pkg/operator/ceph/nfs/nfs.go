@@ -107,12 +107,6 @@ func (r *ReconcileCephNFS) upCephNFS(n *cephv1.CephNFS, oldActive int) error {
 		if err != nil {
 			return errors.Wrap(err, "failed to create ceph nfs service")
 		}
-
-		// Add server to database
-		err = r.addServerToDatabase(n, id)
-		if err != nil {
-			return errors.Wrapf(err, "failed to add server %q to database", id)
-		}
 	}
 
 	return nil
@@ -148,14 +142,6 @@ func (r *ReconcileCephNFS) addServerToDatabase(nfs *cephv1.CephNFS, name string)
 	return nil
 }
 
-func (r *ReconcileCephNFS) removeServerFromDatabase(nfs *cephv1.CephNFS, name string) {
-	logger.Infof("removing ganesha %q from grace db", name)
-
-	if err := r.runGaneshaRadosGrace(nfs, name, "remove"); err != nil {
-		logger.Errorf("failed to remove %q from grace db. %v", name, err)
-	}
-}
-
 func (r *ReconcileCephNFS) runGaneshaRadosGrace(nfs *cephv1.CephNFS, name, action string) error {
 	nodeID := getNFSNodeID(nfs, name)
 	cmd := ganeshaRadosGraceCmd
@@ -191,6 +177,10 @@ func (r *ReconcileCephNFS) createConfigMap(n *cephv1.CephNFS, name string) (stri
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to set owner reference for ceph nfs %q config map", configMap.Name)
 	}
+	// the applied config is kept in an annotation, to detect the changes made out of band
+	if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(configMap); err != nil {
+		logger.Warningf("failed to set the last applied annotation on ganesha config map %q. %v", configMap.Name, err)
+	}
 
 	if _, err := r.context.Clientset.CoreV1().ConfigMaps(n.Namespace).Create(configMap); err != nil {
 		if !kerrors.IsAlreadyExists(err) {
@@ -206,42 +196,32 @@ func (r *ReconcileCephNFS) createConfigMap(n *cephv1.CephNFS, name string) (stri
 	return configMap.Name, nil
 }
 
-// Down scale the ganesha server
+// Down scale the ganesha server, the servers being drained and removed from the grace db in reverse order from how
+// they were started
 func (r *ReconcileCephNFS) downCephNFS(n *cephv1.CephNFS, nfsServerListNum int) error {
-	diffCount := nfsServerListNum - n.Spec.Server.Active
-	for i := 0; i < diffCount; {
-		depIDToRemove := nfsServerListNum - 1
-
-		name := k8sutil.IndexToName(depIDToRemove)
-		depNameToRemove := instanceName(n, name)
-
-		// Remove deployment
-		logger.Infof("removing deployment %q", depNameToRemove)
-		err := r.context.Clientset.AppsV1().Deployments(n.Namespace).Delete(depNameToRemove, &metav1.DeleteOptions{})
-		if err != nil {
-			if !kerrors.IsAlreadyExists(err) {
-				return errors.Wrap(err, "failed to delete ceph nfs deployment")
-			}
+	for i := nfsServerListNum - 1; i >= n.Spec.Server.Active; i-- {
+		name := k8sutil.IndexToName(i)
+		if err := r.drainServer(n, name); err != nil {
+			return errors.Wrapf(err, "failed to drain ganesha %q", name)
 		}
 
-		// Remove from grace db
-		r.removeServerFromDatabase(n, name)
-
-		nfsServerListNum = nfsServerListNum - 1
-		i++
+		// Remove the service, the config and the RADOS config object of the server
+		instance := instanceName(n, name)
+		if err := r.context.Clientset.CoreV1().Services(n.Namespace).Delete(instance, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete ceph nfs service %q", instance)
+		}
+		if err := r.context.Clientset.CoreV1().ConfigMaps(n.Namespace).Delete(instance, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete ganesha config map %q", instance)
+		}
+		config := getGaneshaConfigObject(getNFSNodeID(n, name))
+		if err := r.context.Executor.ExecuteCommand("rados", append(r.radosArgs(n), "rm", config)...); err != nil {
+			logger.Warningf("failed to remove RADOS config object %q of ganesha %q. %v", config, name, err)
+		}
 	}
 
 	return nil
 }
 
-func (r *ReconcileCephNFS) removeServersFromDatabase(n *cephv1.CephNFS, newActive int) error {
-	for i := n.Spec.Server.Active - 1; i >= newActive; i-- {
-		name := k8sutil.IndexToName(i)
-		r.removeServerFromDatabase(n, name)
-	}
-
-	return nil
-}
 func instanceName(n *cephv1.CephNFS, name string) string {
 	return fmt.Sprintf("%s-%s-%s", AppName, n.Name, name)
 }
@@ -268,6 +248,10 @@ func validateGanesha(context *clusterd.Context, n *cephv1.CephNFS) error {
 		return errors.New("at least one active server required")
 	}
 
+	if err := validateExports(n); err != nil {
+		return errors.Wrap(err, "invalid exports")
+	}
+
 	// We cannot run an NFS server if no MDS is running
 	// The existence of the pool provided in n.Spec.RADOS.Pool is necessary otherwise addRADOSConfigFile() will fail
 	_, err := client.GetPoolDetails(context, n.Namespace, n.Spec.RADOS.Pool)
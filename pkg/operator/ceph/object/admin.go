@@ -31,6 +31,8 @@ type Context struct {
 	RunAsUser   string
 	UID         string
 	Endpoint    string
+	// AdminOps calls the admin ops API of the object store instead of running radosgw-admin if set
+	AdminOps *AdminOpsClient
 }
 
 // NewContext creates a new object store context.
@@ -42,7 +44,7 @@ func RunAdminCommandNoRealm(c *Context, args ...string) (string, error) {
 	command, args := client.FinalizeCephCommandArgs("radosgw-admin", args, c.Context.ConfigDir, c.ClusterName, c.RunAsUser)
 
 	// start the rgw admin command
-	output, err := c.Context.Executor.ExecuteCommandWithOutput(command, args...)
+	output, err := client.ExecuteClusterCommandWithOutput(c.Context, c.ClusterName, command, args...)
 	if err != nil {
 		return output, err
 	}
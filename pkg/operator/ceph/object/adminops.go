@@ -0,0 +1,335 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// adminOpsUserName is the system user of the admin ops API of an object store
+	adminOpsUserName = "rook-ceph-internal-admin-ops-user"
+	// adminOpsUserCaps are the capabilities of the admin ops user on the users and the buckets of the object store
+	adminOpsUserCaps  = "users=*;buckets=*;metadata=read;usage=read"
+	adminOpsAccessKey = "AccessKey"
+	adminOpsSecretKey = "SecretKey"
+	adminOpsRegion    = "us-east-1"
+	adminOpsTimeout   = 15 * time.Second
+)
+
+// AdminOpsClient calls the admin ops REST API of the rgw daemons of an object store
+type AdminOpsClient struct {
+	endpoint   string
+	signer     *v4.Signer
+	httpClient *http.Client
+}
+
+// adminOpsError is the error returned by the admin ops API
+type adminOpsError struct {
+	statusCode int
+	Code       string `json:"Code"`
+}
+
+func (e *adminOpsError) Error() string {
+	return fmt.Sprintf("admin ops request failed with status %d and code %q", e.statusCode, e.Code)
+}
+
+// NewAdminOpsClient returns a client of the admin ops API of the rgw endpoint, authenticated with the keys of a system user
+func NewAdminOpsClient(endpoint, accessKey, secretKey string) *AdminOpsClient {
+	if !strings.HasPrefix(endpoint, "http://") && !strings.HasPrefix(endpoint, "https://") {
+		endpoint = "http://" + endpoint
+	}
+	return &AdminOpsClient{
+		endpoint:   strings.TrimSuffix(endpoint, "/"),
+		signer:     v4.NewSigner(credentials.NewStaticCredentials(accessKey, secretKey, "")),
+		httpClient: &http.Client{Timeout: adminOpsTimeout},
+	}
+}
+
+// trustGatewayCerts makes the client of an https endpoint trust the CA bundle or the certificate of the gateway
+func (a *AdminOpsClient) trustGatewayCerts(clientset kubernetes.Interface, namespace string, gateway *cephv1.GatewaySpec) error {
+	if !strings.HasPrefix(a.endpoint, "https://") {
+		return nil
+	}
+	caCerts, err := GetTLSCACerts(clientset, namespace, gateway)
+	if err != nil {
+		return errors.Wrap(err, "failed to get the certificates of the gateway")
+	}
+	httpClient, err := newTLSHTTPClient(adminOpsTimeout, caCerts)
+	if err != nil {
+		return errors.Wrap(err, "failed to trust the certificates of the gateway")
+	}
+	a.httpClient = httpClient
+	return nil
+}
+
+// AdminOpsSecretName returns the name of the secret with the keys of the admin ops user of the object store
+func AdminOpsSecretName(store string) string {
+	return fmt.Sprintf("rook-ceph-object-admin-ops-%s", store)
+}
+
+// reconcileAdminOpsUser creates the system user of the admin ops API of the object store with radosgw-admin, stores its
+// keys in a secret owned by the store, and sets the admin ops client of the context
+func reconcileAdminOpsUser(c *Context, client client.Client, scheme *runtime.Scheme, store *cephv1.CephObjectStore, endpoint string) error {
+	displayName := adminOpsUserName
+	user, rgwerr, err := CreateUser(c, ObjectUser{UserID: adminOpsUserName, DisplayName: &displayName})
+	if err != nil {
+		if rgwerr != ErrorCodeFileExists {
+			return errors.Wrapf(err, "failed to create admin ops user %q. error code %d", adminOpsUserName, rgwerr)
+		}
+		user, _, err = GetUser(c, adminOpsUserName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get admin ops user %q", adminOpsUserName)
+		}
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return errors.Errorf("admin ops user %q has no keys", adminOpsUserName)
+	}
+	if _, err := runAdminCommand(c, "caps", "add", "--uid", adminOpsUserName, "--caps", adminOpsUserCaps); err != nil {
+		return errors.Wrapf(err, "failed to add the capabilities of admin ops user %q", adminOpsUserName)
+	}
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AdminOpsSecretName(store.Name),
+			Namespace: store.Namespace,
+			Labels: map[string]string{
+				"app":               AppName,
+				"rook_cluster":      store.Namespace,
+				"rook_object_store": store.Name,
+			},
+		},
+		StringData: map[string]string{
+			adminOpsAccessKey: *user.AccessKey,
+			adminOpsSecretKey: *user.SecretKey,
+		},
+		Type: k8sutil.RookType,
+	}
+	if err := controllerutil.SetControllerReference(store, secret, scheme); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference of admin ops secret %q", secret.Name)
+	}
+	if err := opcontroller.CreateOrUpdateObject(client, secret); err != nil {
+		return errors.Wrapf(err, "failed to save the keys of admin ops user %q", adminOpsUserName)
+	}
+
+	adminOps := NewAdminOpsClient(endpoint, *user.AccessKey, *user.SecretKey)
+	if err := adminOps.trustGatewayCerts(c.Context.Clientset, store.Namespace, &store.Spec.Gateway); err != nil {
+		return err
+	}
+	c.AdminOps = adminOps
+	return nil
+}
+
+// InitAdminOpsClient sets the admin ops client of the context from the admin ops secret of the object store, the context
+// keeping on running radosgw-admin if the store has no admin ops user, such as the external object stores
+func InitAdminOpsClient(c *Context, clientset kubernetes.Interface, endpoint string) error {
+	secret, err := clientset.CoreV1().Secrets(c.ClusterName).Get(AdminOpsSecretName(c.Name), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debugf("no admin ops user for object store %q, running radosgw-admin", c.Name)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get the admin ops secret of object store %q", c.Name)
+	}
+	accessKey, secretKey := string(secret.Data[adminOpsAccessKey]), string(secret.Data[adminOpsSecretKey])
+	if accessKey == "" || secretKey == "" || endpoint == "" {
+		logger.Debugf("admin ops API of object store %q not available, running radosgw-admin", c.Name)
+		return nil
+	}
+
+	adminOps := NewAdminOpsClient(endpoint, accessKey, secretKey)
+	if strings.HasPrefix(adminOps.endpoint, "https://") {
+		store, err := c.Context.RookClientset.CephV1().CephObjectStores(c.ClusterName).Get(c.Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get object store %q", c.Name)
+		}
+		if err := adminOps.trustGatewayCerts(clientset, c.ClusterName, &store.Spec.Gateway); err != nil {
+			return err
+		}
+	}
+	c.AdminOps = adminOps
+	return nil
+}
+
+// do sends the signed request to the admin ops resource, decoding the json answer in the output if any
+func (a *AdminOpsClient) do(method, resource string, query url.Values, output interface{}) error {
+	query.Set("format", "json")
+	u := fmt.Sprintf("%s/admin/%s?%s", a.endpoint, resource, query.Encode())
+	req, err := http.NewRequest(method, u, nil)
+	if err != nil {
+		return errors.Wrapf(err, "failed to build admin ops request %s %q", method, resource)
+	}
+	if _, err := a.signer.Sign(req, bytes.NewReader(nil), "s3", adminOpsRegion, time.Now()); err != nil {
+		return errors.Wrapf(err, "failed to sign admin ops request %s %q", method, resource)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "failed to send admin ops request %s %q", method, resource)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return errors.Wrapf(err, "failed to read admin ops answer %s %q", method, resource)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		opsErr := &adminOpsError{statusCode: resp.StatusCode}
+		// the error code is not always set
+		_ = json.Unmarshal(body, opsErr)
+		return opsErr
+	}
+	if output != nil && len(body) > 0 {
+		if err := json.Unmarshal(body, output); err != nil {
+			return errors.Wrapf(err, "failed to decode admin ops answer %s %q. %s", method, resource, string(body))
+		}
+	}
+	return nil
+}
+
+// adminOpsErrorCode returns the admin ops error code, empty if the error does not come from the admin ops API
+func adminOpsErrorCode(err error) string {
+	if opsErr, ok := errors.Cause(err).(*adminOpsError); ok {
+		return opsErr.Code
+	}
+	return ""
+}
+
+func (a *AdminOpsClient) getUser(id string) (*ObjectUser, int, error) {
+	var user rgwUserInfo
+	err := a.do(http.MethodGet, "user", url.Values{"uid": {id}}, &user)
+	if err != nil {
+		if adminOpsErrorCode(err) == "NoSuchUser" {
+			return nil, RGWErrorNotFound, errors.New("warn: s3 user not found")
+		}
+		return nil, RGWErrorUnknown, errors.Wrapf(err, "failed to get s3 user %q", id)
+	}
+	return userFromRGW(user), RGWErrorNone, nil
+}
+
+func (a *AdminOpsClient) createUser(user ObjectUser) (*ObjectUser, int, error) {
+	query := url.Values{"uid": {user.UserID}, "display-name": {*user.DisplayName}}
+	if user.Email != nil {
+		query.Set("email", *user.Email)
+	}
+
+	var created rgwUserInfo
+	err := a.do(http.MethodPut, "user", query, &created)
+	if err != nil {
+		switch adminOpsErrorCode(err) {
+		case "UserAlreadyExists":
+			return nil, ErrorCodeFileExists, errors.New("s3 user already exists")
+		case "EmailExists":
+			return nil, RGWErrorBadData, errors.New("email already in use")
+		}
+		return nil, RGWErrorUnknown, errors.Wrap(err, "failed to create s3 user")
+	}
+	return userFromRGW(created), RGWErrorNone, nil
+}
+
+func (a *AdminOpsClient) deleteUser(id string) error {
+	err := a.do(http.MethodDelete, "user", url.Values{"uid": {id}}, nil)
+	if err != nil && adminOpsErrorCode(err) != "NoSuchUser" {
+		return errors.Wrap(err, "failed to delete s3 user")
+	}
+	return nil
+}
+
+func (a *AdminOpsClient) setUserMaxBuckets(id string, max int) error {
+	err := a.do(http.MethodPost, "user", url.Values{"uid": {id}, "max-buckets": {strconv.Itoa(max)}}, nil)
+	if err != nil {
+		return errors.Wrap(err, "failed to set max buckets for user")
+	}
+	return nil
+}
+
+func (a *AdminOpsClient) setUserQuota(id string, maxObjects, maxSize int64) error {
+	query := url.Values{
+		"quota":       {""},
+		"uid":         {id},
+		"quota-type":  {"user"},
+		"max-objects": {strconv.FormatInt(maxObjects, 10)},
+		"max-size":    {strconv.FormatInt(maxSize, 10)},
+		"enabled":     {"true"},
+	}
+	if err := a.do(http.MethodPut, "user", query, nil); err != nil {
+		return errors.Wrap(err, "failed to set the quota of the user")
+	}
+	return nil
+}
+
+func (a *AdminOpsClient) addUserCaps(id, caps string) error {
+	return a.do(http.MethodPut, "user", url.Values{"caps": {""}, "uid": {id}, "user-caps": {caps}}, nil)
+}
+
+func (a *AdminOpsClient) removeUserCaps(id, caps string) error {
+	return a.do(http.MethodDelete, "user", url.Values{"caps": {""}, "uid": {id}, "user-caps": {caps}}, nil)
+}
+
+func (a *AdminOpsClient) getBucket(bucket string) (*ObjectBucket, int, error) {
+	var info struct {
+		rgwBucketStats
+		Owner        string `json:"owner"`
+		CreationTime string `json:"creation_time"`
+	}
+	err := a.do(http.MethodGet, "bucket", url.Values{"bucket": {bucket}, "stats": {"true"}}, &info)
+	if err != nil {
+		if adminOpsErrorCode(err) == "NoSuchBucket" {
+			return nil, RGWErrorNotFound, errors.New("Bucket not found")
+		}
+		return nil, RGWErrorUnknown, errors.Wrap(err, "Failed to get bucket stats")
+	}
+
+	objectBucket := &ObjectBucket{Name: bucket, ObjectBucketMetadata: ObjectBucketMetadata{Owner: info.Owner}, ObjectBucketStats: bucketStatsFromRGW(info.rgwBucketStats)}
+	if createdAt, err := time.Parse(octopusAndAfterTime, info.CreationTime); err == nil {
+		objectBucket.CreatedAt = createdAt
+	}
+	return objectBucket, RGWErrorNone, nil
+}
+
+func (a *AdminOpsClient) deleteBucket(bucket string, purge bool) (int, error) {
+	err := a.do(http.MethodDelete, "bucket", url.Values{"bucket": {bucket}, "purge-objects": {strconv.FormatBool(purge)}}, nil)
+	if err != nil {
+		if adminOpsErrorCode(err) == "NoSuchBucket" {
+			return RGWErrorNotFound, errors.New("Bucket not found")
+		}
+		return RGWErrorUnknown, errors.Wrap(err, "failed to delete bucket")
+	}
+	return RGWErrorNone, nil
+}
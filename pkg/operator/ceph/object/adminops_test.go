@@ -0,0 +1,182 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const adminOpsUserJSON = `{
+	"user_id": "my-user",
+	"display_name": "my display name",
+	"keys": [{"user": "my-user", "access_key": "access", "secret_key": "secret"}],
+	"caps": [{"type": "usage", "perm": "read"}]
+}`
+
+// adminOpsServer records the requests sent to the admin ops API and answers them with the handler
+func adminOpsServer(t *testing.T, requests *[]*http.Request, handler func(r *http.Request) (int, string)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=admin-access/"))
+		*requests = append(*requests, r)
+		status, body := handler(r)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+}
+
+func TestAdminOpsUsers(t *testing.T) {
+	var requests []*http.Request
+	server := adminOpsServer(t, &requests, func(r *http.Request) (int, string) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Query().Get("uid") == "my-user":
+			return http.StatusOK, adminOpsUserJSON
+		case r.Method == http.MethodGet:
+			return http.StatusNotFound, `{"Code": "NoSuchUser"}`
+		case r.Method == http.MethodPut && r.URL.Query().Get("display-name") != "":
+			return http.StatusConflict, `{"Code": "UserAlreadyExists"}`
+		case r.Method == http.MethodDelete && r.URL.Query().Get("uid") == "missing-user":
+			return http.StatusNotFound, `{"Code": "NoSuchUser"}`
+		}
+		return http.StatusOK, ""
+	})
+	defer server.Close()
+
+	// the admin ops client replaces radosgw-admin
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			assert.Fail(t, "unexpected radosgw-admin command", args)
+			return "", nil
+		},
+	}
+	c := NewContext(&clusterd.Context{Executor: executor}, "my-store", "rook-ceph")
+	c.AdminOps = NewAdminOpsClient(server.URL, "admin-access", "admin-secret")
+
+	user, code, err := GetUser(c, "my-user")
+	assert.NoError(t, err)
+	assert.Equal(t, RGWErrorNone, code)
+	assert.Equal(t, "my display name", *user.DisplayName)
+	assert.Equal(t, "access", *user.AccessKey)
+	assert.Equal(t, "secret", *user.SecretKey)
+	assert.Equal(t, map[string]string{"usage": "read"}, user.Caps)
+	assert.Equal(t, "/admin/user", requests[0].URL.Path)
+	assert.Equal(t, "json", requests[0].URL.Query().Get("format"))
+
+	_, code, err = GetUser(c, "missing-user")
+	assert.Error(t, err)
+	assert.Equal(t, RGWErrorNotFound, code)
+
+	displayName := "my display name"
+	_, code, err = CreateUser(c, ObjectUser{UserID: "my-user", DisplayName: &displayName})
+	assert.Error(t, err)
+	assert.Equal(t, ErrorCodeFileExists, code)
+
+	// deleting a missing user succeeds
+	_, err = DeleteUser(c, "missing-user")
+	assert.NoError(t, err)
+
+	requests = nil
+	_, _, err = SetQuotaUserObjectAndSizeMax(c, "my-user", 1000, -1)
+	assert.NoError(t, err)
+	assert.Len(t, requests, 1)
+	query := requests[0].URL.Query()
+	assert.Equal(t, http.MethodPut, requests[0].Method)
+	assert.Equal(t, "user", query.Get("quota-type"))
+	assert.Equal(t, "1000", query.Get("max-objects"))
+	assert.Equal(t, "-1", query.Get("max-size"))
+	assert.Equal(t, "true", query.Get("enabled"))
+
+	// the usage capability is replaced by the users capability
+	requests = nil
+	_, err = SetUserCaps(c, "my-user", map[string]string{"users": "*"})
+	assert.NoError(t, err)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, http.MethodDelete, requests[1].Method)
+	assert.Equal(t, "usage=read", requests[1].URL.Query().Get("user-caps"))
+	assert.Equal(t, http.MethodPut, requests[2].Method)
+	assert.Equal(t, "users=*", requests[2].URL.Query().Get("user-caps"))
+}
+
+func TestAdminOpsBuckets(t *testing.T) {
+	var requests []*http.Request
+	server := adminOpsServer(t, &requests, func(r *http.Request) (int, string) {
+		if r.URL.Query().Get("bucket") != "my-bucket" {
+			return http.StatusNotFound, `{"Code": "NoSuchBucket"}`
+		}
+		if r.Method == http.MethodGet {
+			return http.StatusOK, `{"bucket": "my-bucket", "owner": "my-user", "creation_time": "2020-09-01T10:00:00.000000Z", "usage": {"rgw.main": {"size": 10, "num_objects": 2}}}`
+		}
+		return http.StatusOK, ""
+	})
+	defer server.Close()
+
+	c := NewContext(&clusterd.Context{}, "my-store", "rook-ceph")
+	c.AdminOps = NewAdminOpsClient(server.URL, "admin-access", "admin-secret")
+
+	bucket, code, err := GetBucket(c, "my-bucket")
+	assert.NoError(t, err)
+	assert.Equal(t, RGWErrorNone, code)
+	assert.Equal(t, "my-user", bucket.Owner)
+	assert.Equal(t, uint64(10), bucket.Size)
+	assert.Equal(t, uint64(2), bucket.NumberOfObjects)
+	assert.Equal(t, 2020, bucket.CreatedAt.Year())
+
+	_, code, err = GetBucket(c, "missing-bucket")
+	assert.Error(t, err)
+	assert.Equal(t, RGWErrorNotFound, code)
+
+	code, err = DeleteObjectBucket(c, "my-bucket", true)
+	assert.NoError(t, err)
+	assert.Equal(t, RGWErrorNone, code)
+	assert.Equal(t, "true", requests[len(requests)-1].URL.Query().Get("purge-objects"))
+}
+
+func TestInitAdminOpsClient(t *testing.T) {
+	clientset := test.New(t, 1)
+	c := NewContext(&clusterd.Context{Clientset: clientset}, "my-store", "rook-ceph")
+
+	// No admin ops user, radosgw-admin is run
+	err := InitAdminOpsClient(c, clientset, "http://rook-ceph-rgw-my-store.rook-ceph:80")
+	assert.NoError(t, err)
+	assert.Nil(t, c.AdminOps)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: AdminOpsSecretName("my-store"), Namespace: "rook-ceph"},
+		Data:       map[string][]byte{"AccessKey": []byte("access"), "SecretKey": []byte("secret")},
+	}
+	_, err = clientset.CoreV1().Secrets("rook-ceph").Create(secret)
+	assert.NoError(t, err)
+
+	// No endpoint yet
+	err = InitAdminOpsClient(c, clientset, "")
+	assert.NoError(t, err)
+	assert.Nil(t, c.AdminOps)
+
+	err = InitAdminOpsClient(c, clientset, "http://rook-ceph-rgw-my-store.rook-ceph:80")
+	assert.NoError(t, err)
+	assert.NotNil(t, c.AdminOps)
+	assert.Equal(t, "http://rook-ceph-rgw-my-store.rook-ceph:80", c.AdminOps.endpoint)
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+const (
+	// defaultAutoscaleMinInstances is the minimum number of rgw pods of an autoscaled store
+	defaultAutoscaleMinInstances = 1
+	// defaultAutoscaleTargetCPUUtilization is the average CPU usage of the rgw pods targeted by the autoscaler, in
+	// percent of their CPU request
+	defaultAutoscaleTargetCPUUtilization = 80
+)
+
+// reconcileAutoscaler creates or updates the HorizontalPodAutoscaler of the gateways when the autoscaling is enabled,
+// or deletes it otherwise. The autoscaler scales the CephObjectStore through its scale subresource, setting the
+// instances of the gateway which the operator then reconciles.
+func (c *clusterConfig) reconcileAutoscaler() error {
+	name := instanceName(c.store.Name)
+	autoscalers := c.context.Clientset.AutoscalingV1().HorizontalPodAutoscalers(c.store.Namespace)
+
+	if c.store.Spec.Gateway.Autoscale == nil {
+		err := autoscalers.Delete(name, &metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete autoscaler %q", name)
+		}
+		if err == nil {
+			logger.Infof("deleted autoscaler %q of object store %q", name, c.store.Name)
+		}
+		return nil
+	}
+
+	autoscaler := c.makeAutoscaler(name)
+	if err := controllerutil.SetControllerReference(c.store, autoscaler, c.scheme); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference of autoscaler %q", name)
+	}
+
+	existing, err := autoscalers.Get(name, metav1.GetOptions{})
+	if err != nil {
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get autoscaler %q", name)
+		}
+		if _, err := autoscalers.Create(autoscaler); err != nil {
+			return errors.Wrapf(err, "failed to create autoscaler %q", name)
+		}
+		logger.Infof("created autoscaler %q of object store %q", name, c.store.Name)
+		return nil
+	}
+
+	existing.Spec = autoscaler.Spec
+	if _, err := autoscalers.Update(existing); err != nil {
+		return errors.Wrapf(err, "failed to update autoscaler %q", name)
+	}
+	return nil
+}
+
+// makeAutoscaler returns the HorizontalPodAutoscaler scaling the CephObjectStore from the CPU usage of its rgw pods
+func (c *clusterConfig) makeAutoscaler(name string) *autoscalingv1.HorizontalPodAutoscaler {
+	autoscale := c.store.Spec.Gateway.Autoscale
+	minInstances := autoscale.MinInstances
+	if minInstances == 0 {
+		minInstances = defaultAutoscaleMinInstances
+	}
+	targetCPUUtilization := autoscale.TargetCPUUtilizationPercentage
+	if targetCPUUtilization == 0 {
+		targetCPUUtilization = defaultAutoscaleTargetCPUUtilization
+	}
+
+	return &autoscalingv1.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.store.Namespace,
+			Labels:    getLabels(c.store.Name, c.store.Namespace),
+		},
+		Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+				APIVersion: cephv1.SchemeGroupVersion.String(),
+				Kind:       "CephObjectStore",
+				Name:       c.store.Name,
+			},
+			MinReplicas:                    &minInstances,
+			MaxReplicas:                    autoscale.MaxInstances,
+			TargetCPUUtilizationPercentage: &targetCPUUtilization,
+		},
+	}
+}
+
+// validateAutoscale ensures the autoscaler of the gateways has a range of instances and a CPU request to compute the
+// CPU utilization of the rgw pods from
+func validateAutoscale(gateway cephv1.GatewaySpec) error {
+	autoscale := gateway.Autoscale
+	if autoscale == nil {
+		return nil
+	}
+	if autoscale.MinInstances < 0 || autoscale.TargetCPUUtilizationPercentage < 0 {
+		return errors.New("gateway autoscale minInstances and targetCPUUtilizationPercentage cannot be negative")
+	}
+	minInstances := autoscale.MinInstances
+	if minInstances == 0 {
+		minInstances = defaultAutoscaleMinInstances
+	}
+	if autoscale.MaxInstances < minInstances {
+		return errors.Errorf("gateway autoscale maxInstances %d must be at least the minInstances %d", autoscale.MaxInstances, minInstances)
+	}
+	if gateway.Resources.Requests.Cpu().IsZero() {
+		return errors.Errorf("gateway autoscale requires the gateway resources to request %s, the CPU utilization is a percentage of the request", v1.ResourceCPU)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReconcileAutoscaler(t *testing.T) {
+	clientset := testop.New(t, 1)
+	store := simpleStore()
+	store.UID = "store-uid"
+	c := &clusterConfig{context: &clusterd.Context{Clientset: clientset}, store: store, scheme: scheme.Scheme}
+	autoscalers := clientset.AutoscalingV1().HorizontalPodAutoscalers(store.Namespace)
+
+	// no autoscaler unless enabled
+	assert.NoError(t, c.reconcileAutoscaler())
+	_, err := autoscalers.Get("rook-ceph-rgw-default", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+
+	// the autoscaler scales the object store with the defaults
+	store.Spec.Gateway.Autoscale = &cephv1.GatewayAutoscaleSpec{MaxInstances: 4}
+	assert.NoError(t, c.reconcileAutoscaler())
+	autoscaler, err := autoscalers.Get("rook-ceph-rgw-default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "CephObjectStore", autoscaler.Spec.ScaleTargetRef.Kind)
+	assert.Equal(t, "ceph.rook.io/v1", autoscaler.Spec.ScaleTargetRef.APIVersion)
+	assert.Equal(t, "default", autoscaler.Spec.ScaleTargetRef.Name)
+	assert.Equal(t, int32(1), *autoscaler.Spec.MinReplicas)
+	assert.Equal(t, int32(4), autoscaler.Spec.MaxReplicas)
+	assert.Equal(t, int32(80), *autoscaler.Spec.TargetCPUUtilizationPercentage)
+	assert.Equal(t, "store-uid", string(autoscaler.OwnerReferences[0].UID))
+
+	// the autoscaler is updated with the spec
+	store.Spec.Gateway.Autoscale = &cephv1.GatewayAutoscaleSpec{MinInstances: 2, MaxInstances: 6, TargetCPUUtilizationPercentage: 50}
+	assert.NoError(t, c.reconcileAutoscaler())
+	autoscaler, err = autoscalers.Get("rook-ceph-rgw-default", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), *autoscaler.Spec.MinReplicas)
+	assert.Equal(t, int32(6), autoscaler.Spec.MaxReplicas)
+	assert.Equal(t, int32(50), *autoscaler.Spec.TargetCPUUtilizationPercentage)
+
+	// the autoscaler is deleted when disabled
+	store.Spec.Gateway.Autoscale = nil
+	assert.NoError(t, c.reconcileAutoscaler())
+	_, err = autoscalers.Get("rook-ceph-rgw-default", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+}
+
+func TestValidateAutoscale(t *testing.T) {
+	cpuRequest := v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m")}}
+	tests := []struct {
+		name      string
+		autoscale *cephv1.GatewayAutoscaleSpec
+		resources v1.ResourceRequirements
+		wantErr   bool
+	}{
+		{"disabled", nil, v1.ResourceRequirements{}, false},
+		{"valid", &cephv1.GatewayAutoscaleSpec{MaxInstances: 3}, cpuRequest, false},
+		{"no-cpu-request", &cephv1.GatewayAutoscaleSpec{MaxInstances: 3}, v1.ResourceRequirements{}, true},
+		{"no-max", &cephv1.GatewayAutoscaleSpec{}, cpuRequest, true},
+		{"max-below-min", &cephv1.GatewayAutoscaleSpec{MinInstances: 3, MaxInstances: 2}, cpuRequest, true},
+		{"negative-target", &cephv1.GatewayAutoscaleSpec{MaxInstances: 3, TargetCPUUtilizationPercentage: -1}, cpuRequest, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAutoscale(cephv1.GatewaySpec{Autoscale: tt.autoscale, Resources: tt.resources})
+			assert.Equal(t, tt.wantErr, err != nil, "%v", err)
+		})
+	}
+}
@@ -190,6 +190,10 @@ func ListBuckets(c *Context) ([]ObjectBucket, error) {
 }
 
 func GetBucket(c *Context, bucket string) (*ObjectBucket, int, error) {
+	if c.AdminOps != nil {
+		return c.AdminOps.getBucket(bucket)
+	}
+
 	stat, notFound, err := GetBucketStats(c, bucket)
 	if notFound {
 		return nil, RGWErrorNotFound, errors.New("Bucket not found")
@@ -212,6 +216,10 @@ func GetBucket(c *Context, bucket string) (*ObjectBucket, int, error) {
 }
 
 func DeleteObjectBucket(c *Context, bucketName string, purge bool) (int, error) {
+	if c.AdminOps != nil {
+		return c.AdminOps.deleteBucket(bucketName, purge)
+	}
+
 	options := []string{"bucket", "rm", "--bucket", bucketName}
 	if purge {
 		options = append(options, "--purge-objects")
@@ -0,0 +1,104 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// the keys of the additional config of an object bucket claim
+	maxObjectsKey       = "maxObjects"
+	maxSizeKey          = "maxSize"
+	expirationDaysKey   = "expirationDays"
+	expirationPrefixKey = "expirationPrefix"
+
+	// unlimitedQuota is the quota value removing a limit of the user
+	unlimitedQuota = -1
+	// expirationRuleID is the id of the lifecycle rule expiring the objects of the bucket
+	expirationRuleID = "rook-obc-expiration"
+)
+
+// additionalConfig is the quota and lifecycle settings of the bucket of an object bucket claim
+type additionalConfig struct {
+	// maxObjects is the max number of objects of the bucket owner, unlimitedQuota if not set
+	maxObjects int64
+	// maxSize is the max size in bytes of the objects of the bucket owner, unlimitedQuota if not set
+	maxSize int64
+	// expirationDays is the number of days after which the objects of the bucket expire, 0 if they never expire
+	expirationDays int64
+	// expirationPrefix restricts the expiration to the objects with the prefix
+	expirationPrefix string
+}
+
+// parseAdditionalConfig returns the quota and lifecycle settings of the additional config of an object bucket claim
+func parseAdditionalConfig(data map[string]string) (*additionalConfig, error) {
+	config := &additionalConfig{maxObjects: unlimitedQuota, maxSize: unlimitedQuota}
+
+	if value, ok := data[maxObjectsKey]; ok {
+		maxObjects, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || maxObjects < 0 {
+			return nil, errors.Errorf("invalid %s %q, must be a positive integer", maxObjectsKey, value)
+		}
+		config.maxObjects = maxObjects
+	}
+
+	if value, ok := data[maxSizeKey]; ok {
+		maxSize, err := resource.ParseQuantity(value)
+		if err != nil || maxSize.Sign() < 0 {
+			return nil, errors.Errorf("invalid %s %q, must be a positive quantity such as \"10Gi\"", maxSizeKey, value)
+		}
+		config.maxSize = maxSize.Value()
+	}
+
+	if value, ok := data[expirationDaysKey]; ok {
+		expirationDays, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || expirationDays < 1 {
+			return nil, errors.Errorf("invalid %s %q, must be an integer greater than 0", expirationDaysKey, value)
+		}
+		config.expirationDays = expirationDays
+	}
+
+	if value, ok := data[expirationPrefixKey]; ok {
+		if config.expirationDays == 0 {
+			return nil, errors.Errorf("%s requires %s to be set", expirationPrefixKey, expirationDaysKey)
+		}
+		config.expirationPrefix = value
+	}
+
+	return config, nil
+}
+
+// lifecycleRules returns the lifecycle rules of the bucket, none if its objects never expire
+func (c *additionalConfig) lifecycleRules() []*s3.LifecycleRule {
+	if c.expirationDays == 0 {
+		return nil
+	}
+	return []*s3.LifecycleRule{
+		{
+			ID:         aws.String(expirationRuleID),
+			Status:     aws.String(s3.ExpirationStatusEnabled),
+			Filter:     &s3.LifecycleRuleFilter{Prefix: aws.String(c.expirationPrefix)},
+			Expiration: &s3.LifecycleExpiration{Days: aws.Int64(c.expirationDays)},
+		},
+	}
+}
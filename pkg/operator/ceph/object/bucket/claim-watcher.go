@@ -0,0 +1,103 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bucket
+
+import (
+	"reflect"
+
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	claimClient "github.com/kube-object-storage/lib-bucket-provisioner/pkg/client/clientset/versioned"
+	"github.com/kube-object-storage/lib-bucket-provisioner/pkg/client/informers/externalversions"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	cephObject "github.com/rook/rook/pkg/operator/ceph/object"
+)
+
+// ClaimWatcher applies the changes of the additional config of the bound ObjectBucketClaims to their buckets,
+// the bucket library only provisioning the claims without a bucket
+type ClaimWatcher struct {
+	provisioner     *Provisioner
+	provisionerName string
+	claimClientset  claimClient.Interface
+}
+
+// NewClaimWatcher returns a watcher of the ObjectBucketClaims of the provisioner
+func NewClaimWatcher(cfg *rest.Config, p *Provisioner) (*ClaimWatcher, error) {
+	clientset, err := claimClient.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the object bucket clientset")
+	}
+	return &ClaimWatcher{
+		provisioner:     p,
+		provisionerName: cephObject.GetObjectBucketProvisioner(p.context, p.namespace),
+		claimClientset:  clientset,
+	}, nil
+}
+
+// Run watches the ObjectBucketClaims of all the namespaces until the stop channel is closed
+func (w *ClaimWatcher) Run(stopCh <-chan struct{}) {
+	factory := externalversions.NewSharedInformerFactory(w.claimClientset, 0)
+	informer := factory.Objectbucket().V1alpha1().ObjectBucketClaims().Informer()
+	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			oldOBC, okOld := old.(*bktv1alpha1.ObjectBucketClaim)
+			newOBC, okNew := new.(*bktv1alpha1.ObjectBucketClaim)
+			if !okOld || !okNew || !additionalConfigChanged(oldOBC, newOBC) {
+				return
+			}
+			if err := w.onUpdate(newOBC); err != nil {
+				logger.Errorf("failed to update the bucket of OBC %q in namespace %q. %v", newOBC.Name, newOBC.Namespace, err)
+			}
+		},
+	})
+
+	logger.Infof("ceph bucket provisioner watching the updates of the claims of provisioner %q", w.provisionerName)
+	informer.Run(stopCh)
+}
+
+// additionalConfigChanged returns whether the additional config of a bound claim changed
+func additionalConfigChanged(oldOBC, newOBC *bktv1alpha1.ObjectBucketClaim) bool {
+	if newOBC.DeletionTimestamp != nil || newOBC.Status.Phase != bktv1alpha1.ObjectBucketClaimStatusPhaseBound {
+		// the claims not bound yet get their settings when provisioned
+		return false
+	}
+	return !reflect.DeepEqual(oldOBC.Spec.AdditionalConfig, newOBC.Spec.AdditionalConfig)
+}
+
+func (w *ClaimWatcher) onUpdate(obc *bktv1alpha1.ObjectBucketClaim) error {
+	sc, err := w.provisioner.getStorageClassWithBackoff(obc.Spec.StorageClassName)
+	if err != nil {
+		return err
+	}
+	if sc.Provisioner != w.provisionerName {
+		return nil
+	}
+	if _, isStatic := isStaticBucket(sc); isStatic {
+		logger.Debugf("OBC %q in namespace %q was granted an existing bucket, its settings are not managed", obc.Name, obc.Namespace)
+		return nil
+	}
+
+	ob, err := w.claimClientset.ObjectbucketV1alpha1().ObjectBuckets().Get(obc.Spec.ObjectBucketName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get OB %q", obc.Spec.ObjectBucketName)
+	}
+
+	return w.provisioner.Update(ob, obc.Spec.AdditionalConfig)
+}
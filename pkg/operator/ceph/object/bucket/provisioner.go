@@ -78,6 +78,12 @@ func (p Provisioner) Provision(options *apibkt.BucketOptions) (*bktv1alpha1.Obje
 	}
 	logger.Infof("Provision: creating bucket %q for OBC %q", p.bucketName, options.ObjectBucketClaim.Name)
 
+	// validate the quota and lifecycle settings before creating anything
+	config, err := parseAdditionalConfig(p.additionalConfigData)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid additional config of OBC %q", options.ObjectBucketClaim.Name)
+	}
+
 	// dynamically create a new ceph user
 	p.accessKeyID, p.secretAccessKey, err = p.createCephUser("")
 	if err != nil {
@@ -105,9 +111,60 @@ func (p Provisioner) Provision(options *apibkt.BucketOptions) (*bktv1alpha1.Obje
 	}
 	logger.Infof("set user %q bucket max to %d", p.cephUserName, maxBuckets)
 
+	// the quotas of the user owning the bucket limit the bucket
+	err = p.setUserQuota(config)
+	if err != nil {
+		p.deleteOBCResource(p.bucketName)
+		return nil, err
+	}
+
+	if config.expirationDays > 0 {
+		err = p.setBucketLifecycle(s3svc, config)
+		if err != nil {
+			p.deleteOBCResource(p.bucketName)
+			return nil, err
+		}
+	}
+
 	return p.composeObjectBucket(), nil
 }
 
+// Update applies the quota and lifecycle settings of the additional config of a bound ObjectBucketClaim
+// to its dynamically created bucket. The bucket library only calls the provisioner to create or delete
+// the buckets, Update is called by the ClaimWatcher when the additional config of a claim changes.
+func (p Provisioner) Update(ob *bktv1alpha1.ObjectBucket, additionalConfig map[string]string) error {
+
+	config, err := parseAdditionalConfig(additionalConfig)
+	if err != nil {
+		return errors.Wrapf(err, "invalid additional config of OB %q", ob.Name)
+	}
+
+	err = p.initializeDeleteOrRevoke(ob)
+	if err != nil {
+		return err
+	}
+	logger.Infof("Update: updating the quota and lifecycle of bucket %q for OB %q", p.bucketName, ob.Name)
+
+	err = p.setUserQuota(config)
+	if err != nil {
+		return err
+	}
+
+	user, _, err := cephObject.GetUser(p.objectContext, p.cephUserName)
+	if err != nil {
+		return errors.Wrapf(err, "could not get user (user: %s)", p.cephUserName)
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return errors.Errorf("user %q has no keys", p.cephUserName)
+	}
+	s3svc, err := cephObject.NewS3Agent(*user.AccessKey, *user.SecretKey, p.getObjectStoreEndpoint())
+	if err != nil {
+		return err
+	}
+
+	return p.setBucketLifecycle(s3svc, config)
+}
+
 // Grant attaches to an existing rgw bucket and returns a connection info
 // representing the bucket's endpoint and user access credentials.
 func (p Provisioner) Grant(options *apibkt.BucketOptions) (*bktv1alpha1.ObjectBucket, error) {
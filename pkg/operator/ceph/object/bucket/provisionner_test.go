@@ -20,12 +20,14 @@ import (
 	"fmt"
 	"testing"
 
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
@@ -105,3 +107,77 @@ func TestPopulateDomainAndPort(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph", p.storeDomainName)
 }
+
+func TestParseAdditionalConfig(t *testing.T) {
+	// No settings, no limit
+	config, err := parseAdditionalConfig(map[string]string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(unlimitedQuota), config.maxObjects)
+	assert.Equal(t, int64(unlimitedQuota), config.maxSize)
+	assert.Equal(t, int64(0), config.expirationDays)
+	assert.Nil(t, config.lifecycleRules())
+
+	// All the settings
+	config, err = parseAdditionalConfig(map[string]string{"maxObjects": "1000", "maxSize": "2G", "expirationDays": "7", "expirationPrefix": "logs/"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1000), config.maxObjects)
+	assert.Equal(t, int64(2000000000), config.maxSize)
+	rules := config.lifecycleRules()
+	assert.Len(t, rules, 1)
+	assert.Equal(t, "Enabled", *rules[0].Status)
+	assert.Equal(t, "logs/", *rules[0].Filter.Prefix)
+	assert.Equal(t, int64(7), *rules[0].Expiration.Days)
+
+	// Binary size
+	config, err = parseAdditionalConfig(map[string]string{"maxSize": "1Gi"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1073741824), config.maxSize)
+
+	// Invalid settings
+	for _, data := range []map[string]string{
+		{"maxObjects": "-1"},
+		{"maxObjects": "many"},
+		{"maxSize": "-1Gi"},
+		{"maxSize": "big"},
+		{"expirationDays": "0"},
+		{"expirationPrefix": "logs/"},
+	} {
+		_, err = parseAdditionalConfig(data)
+		assert.Error(t, err, data)
+	}
+}
+
+func TestSetUserQuota(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			commands = append(commands, args)
+			return "", nil
+		},
+	}
+	p := NewProvisioner(&clusterd.Context{Executor: executor}, namespace, client.AdminUsername)
+	p.objectContext = object.NewContext(p.context, store, namespace)
+	p.cephUserName = name
+
+	err := p.setUserQuota(&additionalConfig{maxObjects: 1000, maxSize: unlimitedQuota})
+	assert.NoError(t, err)
+	assert.Len(t, commands, 2)
+	assert.Equal(t, []string{"quota", "set", "--uid", name, "--quota-scope", "user", "--max-objects", "1000", "--max-size", "-1"}, commands[0][:10])
+	assert.Equal(t, []string{"quota", "enable", "--quota-scope", "user", "--uid", name}, commands[1][:6])
+}
+
+func TestAdditionalConfigChanged(t *testing.T) {
+	oldOBC := &bktv1alpha1.ObjectBucketClaim{
+		Spec:   bktv1alpha1.ObjectBucketClaimSpec{AdditionalConfig: map[string]string{"maxObjects": "1000"}},
+		Status: bktv1alpha1.ObjectBucketClaimStatus{Phase: bktv1alpha1.ObjectBucketClaimStatusPhaseBound},
+	}
+	newOBC := oldOBC.DeepCopy()
+	assert.False(t, additionalConfigChanged(oldOBC, newOBC))
+
+	newOBC.Spec.AdditionalConfig["maxObjects"] = "2000"
+	assert.True(t, additionalConfigChanged(oldOBC, newOBC))
+
+	// The claims not bound yet are provisioned with their latest settings
+	newOBC.Status.Phase = bktv1alpha1.ObjectBucketClaimStatusPhasePending
+	assert.False(t, additionalConfigChanged(oldOBC, newOBC))
+}
@@ -63,6 +63,26 @@ func (p *Provisioner) createCephUser(username string) (accKey string, secKey str
 	return *u.AccessKey, *u.SecretKey, nil
 }
 
+// Apply the object and size quotas of the additional config to the Ceph user owning the bucket
+func (p *Provisioner) setUserQuota(config *additionalConfig) error {
+	_, _, err := cephObject.SetQuotaUserObjectAndSizeMax(p.objectContext, p.cephUserName, config.maxObjects, config.maxSize)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set quota of user %q", p.cephUserName)
+	}
+	return nil
+}
+
+// Apply the lifecycle rules of the additional config to the bucket, removing the lifecycle of the bucket
+// if its objects never expire
+func (p *Provisioner) setBucketLifecycle(s3svc *cephObject.S3Agent, config *additionalConfig) error {
+	rules := config.lifecycleRules()
+	if len(rules) == 0 {
+		return s3svc.DeleteBucketLifecycle(p.bucketName)
+	}
+	logger.Infof("expiring the objects of bucket %q after %d days", p.bucketName, config.expirationDays)
+	return s3svc.PutBucketLifecycle(p.bucketName, rules)
+}
+
 // returns "" if unable to generate a unique name.
 func (p *Provisioner) genUserName() (genName string, err error) {
 	const (
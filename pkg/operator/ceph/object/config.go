@@ -23,6 +23,7 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
 )
@@ -51,12 +52,12 @@ func (c *clusterConfig) portString() string {
 
 	port := c.store.Spec.Gateway.Port
 	if port != 0 {
-		if !c.clusterSpec.Network.IsHost() {
+		if !c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw) {
 			port = rgwPortInternalPort
 		}
 		portString = fmt.Sprintf("port=%s", strconv.Itoa(int(port)))
 	}
-	if c.store.Spec.Gateway.SecurePort != 0 && c.store.Spec.Gateway.SSLCertificateRef != "" {
+	if tlsEnabled(&c.store.Spec.Gateway) {
 		certPath := path.Join(certDir, certFilename)
 		// This is the beast backend
 		// Config is: http://docs.ceph.com/docs/master/radosgw/frontends/#id3
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -45,8 +46,10 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
@@ -57,6 +60,9 @@ const (
 
 var waitForRequeueIfObjectStoreNotReady = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
 
+// waitForLoadBalancer requeues the reconcile until the load balancer of the rgw service is provisioned
+var waitForLoadBalancer = reconcile.Result{Requeue: true, RequeueAfter: 30 * time.Second}
+
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
 
 // List of object resources to watch by the controller
@@ -88,6 +94,9 @@ type ReconcileCephObjectStore struct {
 type objectStoreHealth struct {
 	stopChan          chan struct{}
 	monitoringRunning bool
+	// the settings of the running health checker, which is restarted when they change
+	healthCheck cephv1.BucketHealthCheckSpec
+	port        string
 }
 
 // Add creates a new cephObjectStore Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -113,7 +122,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -136,18 +145,71 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		}
 	}
 
+	// Watch for the changes of the certificate and CA bundle secrets of the gateways, which are not owned by the stores
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{TypeMeta: metav1.TypeMeta{Kind: "Secret", APIVersion: corev1.SchemeGroupVersion.String()}}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return tlsSecretRequests(mgr.GetClient(), obj.Meta.GetNamespace(), obj.Meta.GetName())
+		}),
+	}, tlsSecretPredicate())
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
+// tlsSecretRequests returns the requests of the object stores of the namespace whose gateway uses the secret
+func tlsSecretRequests(c client.Client, namespace, name string) []reconcile.Request {
+	stores := &cephv1.CephObjectStoreList{}
+	if err := c.List(context.TODO(), stores, client.InNamespace(namespace)); err != nil {
+		logger.Errorf("failed to list the object stores using secret %q in namespace %q. %v", name, namespace, err)
+		return nil
+	}
+
+	requests := []reconcile.Request{}
+	for _, store := range stores.Items {
+		for _, secretName := range tlsSecretNames(&store.Spec.Gateway) {
+			if secretName == name {
+				logger.Infof("tls secret %q of object store %q changed", name, store.Name)
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: store.Name, Namespace: namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// tlsSecretPredicate passes the secrets whose data changed, the creation of a missing secret unblocking an object store
+func tlsSecretPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return true
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldSecret, okOld := e.ObjectOld.(*corev1.Secret)
+			newSecret, okNew := e.ObjectNew.(*corev1.Secret)
+			return okOld && okNew && !reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
 // Reconcile reads that state of the cluster for a cephObjectStore object and makes changes based on the state read
 // and what is in the cephObjectStore.Spec
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephObjectStore) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephObjectStore", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -240,18 +302,33 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 	if !cephObjectStore.GetDeletionTimestamp().IsZero() {
 		logger.Debugf("deleting store %q", cephObjectStore.Name)
 
-		response, okToDelete := r.verifyObjectBucketCleanup(cephObjectStore)
-		if !okToDelete {
-			// If the object store cannot be deleted, requeue the request for deletion to see if the conditions
-			// will eventually be satisfied such as the object buckets being removed
-			return response, nil
-		}
+		// the dependents of the store are not checked if the deletion is forced, their data is then lost
+		if opcontroller.IsForceDeletion(cephObjectStore) {
+			logger.Warningf("force deleting object store %q along with its buckets and users", cephObjectStore.Name)
+		} else {
+			response, okToDelete := r.verifyObjectBucketCleanup(cephObjectStore)
+			if !okToDelete {
+				// If the object store cannot be deleted, requeue the request for deletion to see if the conditions
+				// will eventually be satisfied such as the object buckets being removed
+				updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeletionIsBlocked, nil)
+				return response, nil
+			}
+
+			response, okToDelete = r.verifyObjectUserCleanup(cephObjectStore)
+			if !okToDelete {
+				// If the object store cannot be deleted, requeue the request for deletion to see if the conditions
+				// will eventually be satisfied such as the object users being removed
+				updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeletionIsBlocked, nil)
+				return response, nil
+			}
 
-		response, okToDelete = r.verifyObjectUserCleanup(cephObjectStore)
-		if !okToDelete {
-			// If the object store cannot be deleted, requeue the request for deletion to see if the conditions
-			// will eventually be satisfied such as the object users being removed
-			return response, nil
+			response, okToDelete = r.verifyBucketCleanup(cephObjectStore)
+			if !okToDelete {
+				// If the object store cannot be deleted, requeue the request for deletion to see if the conditions
+				// will eventually be satisfied such as the buckets created without a claim being removed
+				updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeletionIsBlocked, nil)
+				return response, nil
+			}
 		}
 
 		// Close the channel to stop the healthcheck of the endpoint
@@ -294,6 +371,12 @@ func (r *ReconcileCephObjectStore) reconcile(request reconcile.Request) (reconci
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady, buildStatusInfo(cephObjectStore))
 
+	// the external endpoints are only known once the load balancer of the service is provisioned
+	if r.reconcileEndpoints(cephObjectStore, request.NamespacedName) {
+		logger.Infof("waiting for the load balancer of object store %q service", cephObjectStore.Name)
+		return waitForLoadBalancer, nil
+	}
+
 	// Return and do not requeue
 	logger.Debug("done reconciling")
 	return reconcile.Result{}, nil
@@ -363,10 +446,13 @@ func (r *ReconcileCephObjectStore) reconcileCreateObjectStore(cephObjectStore *c
 		}
 
 		// RECONCILE POOLS
-		logger.Info("reconciling object store pools")
-		err = createPools(objContext, cephObjectStore.Spec)
-		if err != nil {
-			return r.setFailedStatus(namespacedName, "failed to create object pools", err)
+		// The pools of a store in a zone are the pools of the zone, created by the zone controller
+		if !cephObjectStore.Spec.IsMultisite() {
+			logger.Info("reconciling object store pools")
+			err = CreatePools(objContext, cephObjectStore.Spec.MetadataPool, cephObjectStore.Spec.DataPool)
+			if err != nil {
+				return r.setFailedStatus(namespacedName, "failed to create object pools", err)
+			}
 		}
 
 		// RECONCILE REALM
@@ -376,15 +462,36 @@ func (r *ReconcileCephObjectStore) reconcileCreateObjectStore(cephObjectStore *c
 			return r.setFailedStatus(namespacedName, "failed to configure multisite for object store", err)
 		}
 
+		// RECONCILE PLACEMENT TARGETS
+		err = reconcilePlacements(objContext, cephObjectStore.Spec, realmName, zoneGroupName, zoneName)
+		if err != nil {
+			return r.setFailedStatus(namespacedName, "failed to configure the placement targets of object store", err)
+		}
+
+		// RECONCILE HOSTNAMES
+		err = reconcileHostnames(objContext, cephObjectStore.Spec, realmName, zoneGroupName, zoneName)
+		if err != nil {
+			return r.setFailedStatus(namespacedName, "failed to configure the hostnames of object store", err)
+		}
+
 		err = cfg.createOrUpdateStore(realmName, zoneGroupName, zoneName)
 		if err != nil {
 			return reconcile.Result{}, errors.Wrapf(err, "failed to create object store %q", cephObjectStore.Name)
 		}
+
+		// RECONCILE ADMIN OPS USER
+		// The users and the buckets of the store are managed through the admin ops API once its system user exists
+		err = reconcileAdminOpsUser(objContext, r.client, r.scheme, cephObjectStore, adminOpsEndpoint(cephObjectStore))
+		if err != nil {
+			logger.Warningf("failed to reconcile the admin ops user of object store %q, running radosgw-admin. %v", cephObjectStore.Name, err)
+		}
 	}
 
 	// Start monitoring
 	if !cephObjectStore.Spec.HealthCheck.Bucket.Disabled {
 		r.startMonitoring(cephObjectStore, objContext, serviceIP, namespacedName)
+	} else {
+		r.stopMonitoring(cephObjectStore.Name)
 	}
 
 	return reconcile.Result{}, nil
@@ -469,16 +576,37 @@ func (r *ReconcileCephObjectStore) verifyObjectBucketCleanup(objectstore *cephv1
 	return opcontroller.WaitForRequeueIfFinalizerBlocked, false
 }
 
-func (r *ReconcileCephObjectStore) startMonitoring(objectstore *cephv1.CephObjectStore, objContext *Context, serviceIP string, namespacedName types.NamespacedName) {
-	// Start monitoring object store
-	if r.objectStoreChannels[objectstore.Name].monitoringRunning {
-		logger.Debug("external rgw endpoint monitoring go routine already running!")
-		return
+// verifyBucketCleanup returns whether the store has no buckets left in RGW, such as the buckets created with S3
+// directly rather than with a claim. The bucket of the health check is ignored.
+func (r *ReconcileCephObjectStore) verifyBucketCleanup(objectstore *cephv1.CephObjectStore) (reconcile.Result, bool) {
+	// the pools of a store in an external cluster are not deleted along with the store
+	if r.cephClusterSpec.External.Enable {
+		return reconcile.Result{}, true
 	}
 
-	// Set the monitoring flag so we don't start more than one go routine
-	r.objectStoreChannels[objectstore.Name].monitoringRunning = true
+	stats, err := GetBucketsStats(NewContext(r.context, objectstore.Name, objectstore.Namespace))
+	if err != nil {
+		logger.Errorf("failed to delete object store. failed to list the buckets of objectstore %q in namespace %q, set the %q annotation to delete it regardless. %v", objectstore.Name, objectstore.Namespace, opcontroller.ForceDeletionAnnotation, err)
+		return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+	}
+
+	bucketNames := make([]string, 0)
+	for bucket := range stats {
+		if !strings.HasPrefix(bucket, s3HealthCheckBucketName) {
+			bucketNames = append(bucketNames, bucket)
+		}
+	}
+	if len(bucketNames) == 0 {
+		logger.Infof("no buckets left in objectstore %q in namespace %q", objectstore.Name, objectstore.Namespace)
+		return reconcile.Result{}, true
+	}
+
+	sort.Strings(bucketNames)
+	logger.Errorf("failed to delete object store. buckets of objectstore %q in namespace %q are not deleted, set the %q annotation to delete them with the store. remaining buckets: %+v", objectstore.Name, objectstore.Namespace, opcontroller.ForceDeletionAnnotation, bucketNames)
+	return opcontroller.WaitForRequeueIfFinalizerBlocked, false
+}
 
+func (r *ReconcileCephObjectStore) startMonitoring(objectstore *cephv1.CephObjectStore, objContext *Context, serviceIP string, namespacedName types.NamespacedName) {
 	var port string
 
 	if objectstore.Spec.Gateway.Port != 0 {
@@ -487,11 +615,39 @@ func (r *ReconcileCephObjectStore) startMonitoring(objectstore *cephv1.CephObjec
 		port = strconv.Itoa(int(objectstore.Spec.Gateway.SecurePort))
 	}
 
-	rgwChecker := newBucketChecker(r.context, objContext, serviceIP, port, r.client, namespacedName, &objectstore.Spec.HealthCheck)
+	// Start monitoring object store
+	health := r.objectStoreChannels[objectstore.Name]
+	if health.monitoringRunning {
+		if health.port == port && reflect.DeepEqual(health.healthCheck, objectstore.Spec.HealthCheck) {
+			logger.Debug("external rgw endpoint monitoring go routine already running!")
+			return
+		}
+		logger.Infof("restarting rgw healthcheck of object store %q with its new settings", objectstore.Name)
+		r.stopMonitoring(objectstore.Name)
+	}
+
+	// Set the monitoring flag so we don't start more than one go routine
+	health.monitoringRunning = true
+	health.healthCheck = objectstore.Spec.HealthCheck
+	health.port = port
+
+	rgwChecker := newBucketChecker(r.context, objContext, serviceIP, port, &objectstore.Spec.Gateway, r.client, namespacedName, &objectstore.Spec.HealthCheck)
 	logger.Info("starting rgw healthcheck")
 	go rgwChecker.checkObjectStore(r.objectStoreChannels[objectstore.Name].stopChan)
 }
 
+// stopMonitoring stops the health checker of the object store if running, a new one can be started afterwards
+func (r *ReconcileCephObjectStore) stopMonitoring(name string) {
+	health, ok := r.objectStoreChannels[name]
+	if !ok || !health.monitoringRunning {
+		return
+	}
+	logger.Infof("stopping rgw healthcheck of object store %q", name)
+	close(health.stopChan)
+	health.stopChan = make(chan struct{})
+	health.monitoringRunning = false
+}
+
 func (r *ReconcileCephObjectStore) verifyObjectUserCleanup(objectstore *cephv1.CephObjectStore) (reconcile.Result, bool) {
 	cephObjectUsers, err := r.context.RookClientset.CephV1().CephObjectStoreUsers(objectstore.Namespace).List(metav1.ListOptions{})
 	if err != nil {
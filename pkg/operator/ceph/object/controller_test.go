@@ -358,3 +358,79 @@ func TestCephObjectStoreController(t *testing.T) {
 	assert.False(t, okToDelete)
 	logger.Info("PHASE 4 DONE")
 }
+
+func TestVerifyBucketCleanup(t *testing.T) {
+	bucketStats := `[]`
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "bucket" && args[1] == "stats" {
+				return bucketStats, nil
+			}
+			return "", nil
+		},
+	}
+	r := &ReconcileCephObjectStore{context: &clusterd.Context{Executor: executor}, cephClusterSpec: &cephv1.ClusterSpec{}}
+	objectStore := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"}}
+
+	// no buckets
+	_, okToDelete := r.verifyBucketCleanup(objectStore)
+	assert.True(t, okToDelete)
+
+	// the bucket of the health check is ignored
+	bucketStats = `[{"bucket":"rook-ceph-bucket-checker-1234"}]`
+	_, okToDelete = r.verifyBucketCleanup(objectStore)
+	assert.True(t, okToDelete)
+
+	// a bucket created without a claim blocks the deletion
+	bucketStats = `[{"bucket":"rook-ceph-bucket-checker-1234"},{"bucket":"backups"}]`
+	_, okToDelete = r.verifyBucketCleanup(objectStore)
+	assert.False(t, okToDelete)
+
+	// the buckets of an external cluster are not deleted with the store
+	r.cephClusterSpec.External.Enable = true
+	_, okToDelete = r.verifyBucketCleanup(objectStore)
+	assert.True(t, okToDelete)
+}
+
+func TestStartMonitoring(t *testing.T) {
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{Executor: executor}
+	r := &ReconcileCephObjectStore{
+		context:             c,
+		objectStoreChannels: map[string]*objectStoreHealth{"my-store": {stopChan: make(chan struct{})}},
+	}
+	store := &cephv1.CephObjectStore{ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "rook-ceph"}}
+	store.Spec.Gateway.Port = 80
+	objContext := NewContext(c, store.Name, store.Namespace)
+	namespacedName := types.NamespacedName{Name: store.Name, Namespace: store.Namespace}
+
+	r.startMonitoring(store, objContext, "", namespacedName)
+	health := r.objectStoreChannels[store.Name]
+	assert.True(t, health.monitoringRunning)
+	assert.Equal(t, "80", health.port)
+	stopChan := health.stopChan
+
+	// The checker is not restarted without changes
+	r.startMonitoring(store, objContext, "", namespacedName)
+	assert.Equal(t, stopChan, health.stopChan)
+
+	// The checker is restarted with the new interval
+	store.Spec.HealthCheck.Bucket.Interval = "10s"
+	r.startMonitoring(store, objContext, "", namespacedName)
+	assert.True(t, health.monitoringRunning)
+	assert.NotEqual(t, stopChan, health.stopChan)
+	assert.Equal(t, "10s", health.healthCheck.Bucket.Interval)
+	_, open := <-stopChan
+	assert.False(t, open)
+
+	// The checker is stopped when disabled
+	stopChan = health.stopChan
+	r.stopMonitoring(store.Name)
+	assert.False(t, health.monitoringRunning)
+	_, open = <-stopChan
+	assert.False(t, open)
+}
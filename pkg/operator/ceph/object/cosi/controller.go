@@ -0,0 +1,326 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package cosi to deploy the Ceph driver of the Container Object Storage Interface, provisioning the buckets of the
+// BucketClaims in the object stores alongside the object bucket claims.
+package cosi
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-cosi-driver-controller"
+	// cosiAPIGroupVersion is the group version of the COSI API, served once the COSI CRDs and controller are installed
+	cosiAPIGroupVersion = "objectstorage.k8s.io/v1alpha1"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephCOSIDriverKind = reflect.TypeOf(cephv1.CephCOSIDriver{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephCOSIDriverKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephCOSIDriver reconciles a CephCOSIDriver object
+type ReconcileCephCOSIDriver struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+}
+
+// Add creates a new CephCOSIDriver Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) *ReconcileCephCOSIDriver {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileCephCOSIDriver{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r *ReconcileCephCOSIDriver) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephCOSIDriver CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephCOSIDriver{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	// Watch for the object stores and enqueue the drivers, the driver having a user in each object store
+	err = c.Watch(&source.Kind{Type: &cephv1.CephObjectStore{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return r.driverRequests()
+		}),
+	}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// driverRequests returns the requests of the CephCOSIDrivers of all the namespaces
+func (r *ReconcileCephCOSIDriver) driverRequests() []reconcile.Request {
+	drivers := &cephv1.CephCOSIDriverList{}
+	if err := r.client.List(context.TODO(), drivers); err != nil {
+		logger.Errorf("failed to list CephCOSIDrivers. %v", err)
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, driver := range drivers.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: driver.Name, Namespace: driver.Namespace}})
+	}
+	return requests
+}
+
+// Reconcile reads that state of the cluster for a CephCOSIDriver object and makes changes based on the state read
+// and what is in the CephCOSIDriver.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephCOSIDriver) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephCOSIDriver", request)
+	reconcileLogger.Debug("reconciling")
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephCOSIDriver) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephCOSIDriver instance
+	driver := &cephv1.CephCOSIDriver{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, driver)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCOSIDriver resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephCOSIDriver")
+	}
+
+	// The CR was just created, initializing status fields
+	if driver.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created)
+	}
+
+	// The driver is gone with its deployment, owned by the CR
+	if !driver.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	// A single driver serves the object stores of all the namespaces
+	if driver.Name != CephCOSIDriverName {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		return reconcile.Result{}, errors.Errorf("invalid CephCOSIDriver name %q, the driver must be named %q", driver.Name, CephCOSIDriverName)
+	}
+
+	deploy, err := r.shouldDeploy(driver)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		return reconcile.Result{}, err
+	}
+	if !deploy {
+		if err := r.removeDriver(driver); err != nil {
+			updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+			return reconcile.Result{}, err
+		}
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
+		return reconcile.Result{}, nil
+	}
+
+	// CREATE/UPDATE THE DRIVER
+	if err := r.startDriver(driver); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		return reconcile.Result{}, err
+	}
+
+	// The buckets of the BucketClasses are provisioned with the users of the driver in the object stores
+	if err := r.reconcileUsers(); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		return reconcile.Result{}, err
+	}
+
+	// Set Ready status, we are done reconciling
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// shouldDeploy returns whether the driver is deployed, the driver being deployed by default when the COSI API is
+// served by the Kubernetes cluster
+func (r *ReconcileCephCOSIDriver) shouldDeploy(driver *cephv1.CephCOSIDriver) (bool, error) {
+	switch driver.Spec.DeploymentStrategy {
+	case cephv1.COSIDeploymentStrategyNever:
+		return false, nil
+	case cephv1.COSIDeploymentStrategyForce:
+		return true, nil
+	case cephv1.COSIDeploymentStrategyAuto, "":
+		// the driver is deployed once the COSI API is installed, the driver being watched for the object stores
+		if _, err := r.context.Clientset.Discovery().ServerResourcesForGroupVersion(cosiAPIGroupVersion); err != nil {
+			logger.Infof("COSI API %q not available, not deploying the COSI driver. %v", cosiAPIGroupVersion, err)
+			return false, nil
+		}
+		return true, nil
+	default:
+		return false, errors.Errorf("invalid COSI driver deployment strategy %q", driver.Spec.DeploymentStrategy)
+	}
+}
+
+// startDriver creates or updates the deployment of the driver
+func (r *ReconcileCephCOSIDriver) startDriver(driver *cephv1.CephCOSIDriver) error {
+	d := makeDeployment(driver)
+
+	// Set owner ref to the CephCOSIDriver object
+	err := controllerutil.SetControllerReference(driver, d, r.scheme)
+	if err != nil {
+		return errors.Wrapf(err, "failed to set owner reference for COSI driver deployment %q", d.Name)
+	}
+
+	if err := k8sutil.CreateDeployment(r.context.Clientset, d.Name, d.Namespace, d); err != nil {
+		return errors.Wrap(err, "failed to start the COSI driver")
+	}
+	logger.Infof("%q deployment started", d.Name)
+	return nil
+}
+
+// removeDriver deletes the deployment of the driver if it was deployed
+func (r *ReconcileCephCOSIDriver) removeDriver(driver *cephv1.CephCOSIDriver) error {
+	_, err := r.context.Clientset.AppsV1().Deployments(driver.Namespace).Get(CephCOSIDriverName, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get the COSI driver deployment %q", CephCOSIDriverName)
+	}
+	logger.Infof("removing the COSI driver deployment %q", CephCOSIDriverName)
+	if err := k8sutil.DeleteDeployment(r.context.Clientset, driver.Namespace, CephCOSIDriverName); err != nil {
+		return errors.Wrap(err, "failed to remove the COSI driver")
+	}
+	return nil
+}
+
+// reconcileUsers creates the user of the driver in each object store, the user being deleted with its store
+func (r *ReconcileCephCOSIDriver) reconcileUsers() error {
+	stores := &cephv1.CephObjectStoreList{}
+	if err := r.client.List(context.TODO(), stores); err != nil {
+		return errors.Wrap(err, "failed to list the object stores")
+	}
+	for i := range stores.Items {
+		store := &stores.Items[i]
+		if !store.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		user := &cephv1.CephObjectStoreUser{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      cosiUserName(store.Name),
+				Namespace: store.Namespace,
+			},
+			Spec: cephv1.ObjectStoreUserSpec{
+				Store:       store.Name,
+				DisplayName: "COSI driver",
+				Capabilities: &cephv1.ObjectUserCapSpec{
+					User:   "*",
+					Bucket: "*",
+				},
+			},
+		}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: user.Name, Namespace: user.Namespace}, &cephv1.CephObjectStoreUser{})
+		if err == nil {
+			continue
+		}
+		if !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get the COSI user %q of object store %q", user.Name, store.Name)
+		}
+
+		if err := controllerutil.SetControllerReference(store, user, r.scheme); err != nil {
+			return errors.Wrapf(err, "failed to set owner reference for COSI user %q", user.Name)
+		}
+		if err := r.client.Create(context.TODO(), user); err != nil && !kerrors.IsAlreadyExists(err) {
+			return errors.Wrapf(err, "failed to create the COSI user %q of object store %q", user.Name, store.Name)
+		}
+		logger.Infof("created the COSI user %q of object store %q", user.Name, store.Name)
+	}
+	return nil
+}
+
+// updateStatus updates a driver with a given status
+func updateStatus(client client.Client, name types.NamespacedName, status string) {
+	driver := &cephv1.CephCOSIDriver{}
+	if err := client.Get(context.TODO(), name, driver); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCOSIDriver resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve COSI driver %q to update status to %q. %v", name, status, err)
+		return
+	}
+	if driver.Status == nil {
+		driver.Status = &cephv1.Status{}
+	}
+
+	driver.Status.Phase = status
+	if err := opcontroller.UpdateStatus(client, driver); err != nil {
+		logger.Errorf("failed to set COSI driver %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("COSI driver %q status updated to %q", name, status)
+}
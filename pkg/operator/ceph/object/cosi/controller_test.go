@@ -0,0 +1,114 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosi
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestMakeDeployment(t *testing.T) {
+	driver := &cephv1.CephCOSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: CephCOSIDriverName, Namespace: "rook-ceph"},
+		Spec: cephv1.CephCOSIDriverSpec{
+			Resources: v1.ResourceRequirements{Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("256Mi")}},
+		},
+	}
+	d := makeDeployment(driver)
+	assert.Equal(t, CephCOSIDriverName, d.Name)
+	assert.Equal(t, "rook-ceph", d.Namespace)
+	podSpec := d.Spec.Template.Spec
+	assert.Equal(t, cosiServiceAccountName, podSpec.ServiceAccountName)
+	assert.Equal(t, 2, len(podSpec.Containers))
+	assert.Equal(t, defaultCOSIDriverImage, podSpec.Containers[0].Image)
+	assert.Equal(t, "256Mi", podSpec.Containers[0].Resources.Limits.Memory().String())
+	assert.Equal(t, defaultObjectProvisionerImage, podSpec.Containers[1].Image)
+	// the containers share the socket of the driver
+	assert.Equal(t, podSpec.Containers[0].VolumeMounts, podSpec.Containers[1].VolumeMounts)
+
+	driver.Spec.Image = "quay.io/ceph/cosi:latest"
+	assert.Equal(t, "quay.io/ceph/cosi:latest", makeDeployment(driver).Spec.Template.Spec.Containers[0].Image)
+}
+
+func TestCephCOSIDriverController(t *testing.T) {
+	namespace := "rook-ceph"
+	driver := &cephv1.CephCOSIDriver{
+		ObjectMeta: metav1.ObjectMeta{Name: CephCOSIDriverName, Namespace: namespace},
+	}
+	store := &cephv1.CephObjectStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-store", Namespace: "object"},
+	}
+
+	clientset := testop.New(t, 1)
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCOSIDriver{}, &cephv1.CephCOSIDriverList{}, &cephv1.CephObjectStore{}, &cephv1.CephObjectStoreList{}, &cephv1.CephObjectStoreUser{})
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{driver, store}...)
+	r := &ReconcileCephCOSIDriver{client: cl, scheme: s, context: &clusterd.Context{Clientset: clientset}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: driver.Name, Namespace: namespace}}
+	userName := types.NamespacedName{Name: "my-store-cosi", Namespace: "object"}
+
+	// the driver is not deployed while the COSI API is not served
+	res, err := r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	_, err = clientset.AppsV1().Deployments(namespace).Get(CephCOSIDriverName, metav1.GetOptions{})
+	assert.Error(t, err)
+	assert.Error(t, cl.Get(context.TODO(), userName, &cephv1.CephObjectStoreUser{}))
+
+	// the driver is deployed with a user in each object store once the COSI API is served
+	clientset.Fake.Resources = []*metav1.APIResourceList{{GroupVersion: cosiAPIGroupVersion}}
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	_, err = clientset.AppsV1().Deployments(namespace).Get(CephCOSIDriverName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	user := &cephv1.CephObjectStoreUser{}
+	assert.NoError(t, cl.Get(context.TODO(), userName, user))
+	assert.Equal(t, "my-store", user.Spec.Store)
+	assert.Equal(t, "*", user.Spec.Capabilities.Bucket)
+	assert.Equal(t, "my-store", user.OwnerReferences[0].Name)
+	assert.NoError(t, cl.Get(context.TODO(), req.NamespacedName, driver))
+	assert.Equal(t, k8sutil.ReadyStatus, driver.Status.Phase)
+
+	// the driver is removed when never deployed
+	driver.Spec.DeploymentStrategy = cephv1.COSIDeploymentStrategyNever
+	assert.NoError(t, cl.Update(context.TODO(), driver))
+	_, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	_, err = clientset.AppsV1().Deployments(namespace).Get(CephCOSIDriverName, metav1.GetOptions{})
+	assert.Error(t, err)
+
+	// the driver must have the name of its deployment
+	other := &cephv1.CephCOSIDriver{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace}}
+	assert.NoError(t, cl.Create(context.TODO(), other))
+	_, err = r.Reconcile(reconcile.Request{NamespacedName: types.NamespacedName{Name: other.Name, Namespace: namespace}})
+	assert.Error(t, err)
+}
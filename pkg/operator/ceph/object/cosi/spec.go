@@ -0,0 +1,116 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cosi
+
+import (
+	"fmt"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CephCOSIDriverName is the name of the CephCOSIDriver, and of the deployment of the driver
+	CephCOSIDriverName = "ceph-cosi-driver"
+	// cosiDriverPrefix is the prefix of the name of the driver, the driver being named <prefix>.ceph.objectstorage.k8s.io
+	// in the driverName of the BucketClasses
+	cosiDriverPrefix = "ceph"
+
+	defaultCOSIDriverImage        = "quay.io/ceph/cosi:v0.1.1"
+	defaultObjectProvisionerImage = "gcr.io/k8s-staging-sig-storage/objectstorage-sidecar/objectstorage-sidecar:v20221117-v0.1.0-22-g0e67387"
+
+	// cosiServiceAccountName is the service account of the driver, allowed to manage the buckets of the COSI API
+	cosiServiceAccountName = "objectstorage-provisioner"
+	cosiSocketVolumeName   = "socket"
+	cosiSocketMountPath    = "/var/lib/cosi"
+)
+
+// makeDeployment returns the deployment of the COSI driver, the driver serving the provisioner sidecar on a socket
+// shared by the containers of the pod
+func makeDeployment(driver *cephv1.CephCOSIDriver) *apps.Deployment {
+	labels := map[string]string{
+		k8sutil.AppAttr: CephCOSIDriverName,
+	}
+	image := driver.Spec.Image
+	if image == "" {
+		image = defaultCOSIDriverImage
+	}
+	provisionerImage := driver.Spec.ObjectProvisionerImage
+	if provisionerImage == "" {
+		provisionerImage = defaultObjectProvisionerImage
+	}
+	socketMount := v1.VolumeMount{Name: cosiSocketVolumeName, MountPath: cosiSocketMountPath}
+
+	podSpec := v1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   CephCOSIDriverName,
+			Labels: labels,
+		},
+		Spec: v1.PodSpec{
+			ServiceAccountName: cosiServiceAccountName,
+			Containers: []v1.Container{
+				{
+					Name:         CephCOSIDriverName,
+					Image:        image,
+					Args:         []string{fmt.Sprintf("--driver-prefix=%s", cosiDriverPrefix)},
+					VolumeMounts: []v1.VolumeMount{socketMount},
+					Resources:    driver.Spec.Resources,
+				},
+				{
+					Name:         "objectstorage-provisioner-sidecar",
+					Image:        provisionerImage,
+					Args:         []string{"--v=5"},
+					VolumeMounts: []v1.VolumeMount{socketMount},
+				},
+			},
+			Volumes: []v1.Volume{
+				{Name: cosiSocketVolumeName, VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}},
+			},
+			RestartPolicy: v1.RestartPolicyAlways,
+		},
+	}
+	driver.Spec.Placement.ApplyToPodSpec(&podSpec.Spec)
+	k8sutil.ApplyImagePullSecrets(driver.Namespace, &podSpec.Spec)
+
+	replicas := int32(1)
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      CephCOSIDriverName,
+			Namespace: driver.Namespace,
+			Labels:    labels,
+		},
+		Spec: apps.DeploymentSpec{
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: podSpec,
+			Replicas: &replicas,
+		},
+	}
+	k8sutil.AddRookVersionLabelToDeployment(d)
+
+	return d
+}
+
+// cosiUserName returns the name of the CephObjectStoreUser of the COSI driver in an object store, whose secret is
+// set as the objectStoreUserSecretName of the BucketClasses of the store
+func cosiUserName(storeName string) string {
+	return fmt.Sprintf("%s-cosi", storeName)
+}
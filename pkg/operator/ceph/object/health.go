@@ -45,19 +45,21 @@ type bucketChecker struct {
 	interval        time.Duration
 	serviceIP       string
 	port            string
+	gateway         *cephv1.GatewaySpec
 	client          client.Client
 	namespacedName  types.NamespacedName
 	healthCheckSpec *cephv1.BucketHealthCheckSpec
 }
 
 // newbucketChecker creates a new HealthChecker object
-func newBucketChecker(context *clusterd.Context, objContext *Context, serviceIP, port string, client client.Client, namespacedName types.NamespacedName, healthCheckSpec *cephv1.BucketHealthCheckSpec) *bucketChecker {
+func newBucketChecker(context *clusterd.Context, objContext *Context, serviceIP, port string, gateway *cephv1.GatewaySpec, client client.Client, namespacedName types.NamespacedName, healthCheckSpec *cephv1.BucketHealthCheckSpec) *bucketChecker {
 	c := &bucketChecker{
 		context:         context,
 		objContext:      objContext,
 		interval:        defaultStatusCheckInterval,
 		serviceIP:       serviceIP,
 		port:            port,
+		gateway:         gateway,
 		namespacedName:  namespacedName,
 		client:          client,
 		healthCheckSpec: healthCheckSpec,
@@ -143,7 +145,7 @@ func (c *bucketChecker) checkObjectStoreHealth() error {
 
 	// Initiate s3 agent
 	logger.Debugf("initializing s3 connection for object store %q", c.namespacedName.Name)
-	s3client, err := NewS3Agent(s3AccessKey, s3SecretKey, s3endpoint)
+	s3client, err := c.newS3Agent(s3AccessKey, s3SecretKey, s3endpoint)
 	if err != nil {
 		return errors.Wrap(err, "failed to initialize s3 connection")
 	}
@@ -248,13 +250,32 @@ func (c *bucketChecker) testBucketHealth(s3client *S3Agent, bucket string) error
 		return errors.Wrapf(err, "failed to get object %q in bucket %q for object store %q", s3HealthCheckObjectKey, bucket, c.namespacedName.Name)
 	}
 
+	// Delete the object from the bucket
+	logger.Debugf("deleting object %q in bucket %q for object store %q", s3HealthCheckObjectKey, bucket, c.namespacedName.Name)
+	_, err = s3client.DeleteObjectInBucket(bucket, s3HealthCheckObjectKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete object %q in bucket %q for object store %q", s3HealthCheckObjectKey, bucket, c.namespacedName.Name)
+	}
+
 	// Compare the old and the existing object
 	logger.Debugf("comparing objects hash for object store %q", c.namespacedName.Name)
 	oldHash := k8sutil.Hash(s3HealthCheckObjectBody)
 	currentHash := k8sutil.Hash(read)
 	if currentHash != oldHash {
-		return errors.Wrapf(err, "wrong file content, old file hash is %q and new one is %q for object store %q", oldHash, currentHash, c.namespacedName.Name)
+		return errors.Errorf("wrong file content, old file hash is %q and new one is %q for object store %q", oldHash, currentHash, c.namespacedName.Name)
 	}
 
 	return nil
 }
+
+// newS3Agent returns the s3 agent of the endpoint, over https when the gateway only serves on its secure port
+func (c *bucketChecker) newS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
+	if c.gateway.Port != 0 || !tlsEnabled(c.gateway) {
+		return NewS3Agent(accessKey, secretKey, endpoint)
+	}
+	caCerts, err := GetTLSCACerts(c.context.Clientset, c.namespacedName.Namespace, c.gateway)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the certificates of the gateway")
+	}
+	return NewTLSS3Agent(accessKey, secretKey, "https://"+endpoint, caCerts)
+}
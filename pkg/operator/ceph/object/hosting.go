@@ -0,0 +1,194 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	zoneGroupHostnamesKey          = "hostnames"
+	zoneGroupWebsiteHostnamesKey   = "hostnames_s3website"
+	hostingWildcardPrefix          = "*."
+	zoneGroupHostnamesTempFileName = "rgw-zonegroup"
+)
+
+// validateHosting ensures the hostnames of the store are valid DNS names, without the wildcard of the bucket
+// subdomains which is only needed by the DNS records and the ingress
+func validateHosting(hosting *cephv1.ObjectStoreHostingSpec) error {
+	if hosting == nil {
+		return nil
+	}
+	all := append([]string{}, hosting.DNSNames...)
+	if hosting.S3WebsiteDNSName != "" {
+		all = append(all, hosting.S3WebsiteDNSName)
+	}
+	names := map[string]bool{}
+	for _, name := range all {
+		if name == "" {
+			return errors.New("empty dns name")
+		}
+		if strings.HasPrefix(name, hostingWildcardPrefix) {
+			return errors.Errorf("invalid dns name %q, the buckets are served on the subdomains of %q without the wildcard", name, strings.TrimPrefix(name, hostingWildcardPrefix))
+		}
+		if net.ParseIP(name) != nil {
+			return errors.Errorf("invalid dns name %q, the buckets cannot be served on the subdomains of an IP address", name)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return errors.Errorf("invalid dns name %q. %s", name, strings.Join(errs, ", "))
+		}
+		// the s3 website dns name must also differ from the dns names of the s3 API
+		if names[name] {
+			return errors.Errorf("dns name %q is set more than once", name)
+		}
+		names[name] = true
+	}
+	return nil
+}
+
+// hostingFlags returns the rgw flags of the hostnames of the store
+func (c *clusterConfig) hostingFlags() []string {
+	hosting := c.store.Spec.Hosting
+	if hosting == nil {
+		return []string{}
+	}
+	flags := []string{}
+	if len(hosting.DNSNames) > 0 {
+		flags = append(flags, cephconfig.NewFlag("rgw dns name", hosting.DNSNames[0]))
+	}
+	if hosting.S3WebsiteDNSName != "" {
+		flags = append(flags,
+			cephconfig.NewFlag("rgw dns s3website name", hosting.S3WebsiteDNSName),
+			cephconfig.NewFlag("rgw enable static website", "true"))
+	}
+	return flags
+}
+
+// reconcileHostnames sets the hostnames of the store as the hostnames of its zonegroup, rgw only accepting the
+// virtual-hosted-style urls of a single rgw dns name otherwise. The hostnames of a zonegroup of a multisite
+// configuration are shared with the stores of the other zones, the missing hostnames of the store being added to
+// them and no hostname being removed.
+func reconcileHostnames(context *Context, spec cephv1.ObjectStoreSpec, realmName, zoneGroupName, zoneName string) error {
+	if spec.Hosting == nil {
+		return nil
+	}
+	realmArg := fmt.Sprintf("--rgw-realm=%s", realmName)
+	zoneGroupArg := fmt.Sprintf("--rgw-zonegroup=%s", zoneGroupName)
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", zoneName)
+
+	output, err := RunAdminCommandNoRealm(context, "zonegroup", "get", realmArg, zoneGroupArg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get rgw zonegroup %q", zoneGroupName)
+	}
+	zoneGroup := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(output), &zoneGroup); err != nil {
+		return errors.Wrapf(err, "failed to parse rgw zonegroup %q", zoneGroupName)
+	}
+
+	websiteNames := []string{}
+	if spec.Hosting.S3WebsiteDNSName != "" {
+		websiteNames = append(websiteNames, spec.Hosting.S3WebsiteDNSName)
+	}
+	changed := setZoneGroupHostnames(zoneGroup, zoneGroupHostnamesKey, spec.Hosting.DNSNames, spec.IsMultisite())
+	if setZoneGroupHostnames(zoneGroup, zoneGroupWebsiteHostnamesKey, websiteNames, spec.IsMultisite()) {
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	content, err := json.Marshal(zoneGroup)
+	if err != nil {
+		return errors.Wrapf(err, "failed to serialize rgw zonegroup %q", zoneGroupName)
+	}
+	file, err := ioutil.TempFile("", zoneGroupHostnamesTempFileName)
+	if err != nil {
+		return errors.Wrap(err, "failed to create the rgw zonegroup file")
+	}
+	defer os.Remove(file.Name())
+	_, err = file.Write(content)
+	file.Close()
+	if err != nil {
+		return errors.Wrap(err, "failed to write the rgw zonegroup file")
+	}
+
+	infileArg := fmt.Sprintf("--infile=%s", file.Name())
+	if _, err := RunAdminCommandNoRealm(context, "zonegroup", "set", realmArg, zoneGroupArg, infileArg); err != nil {
+		return errors.Wrapf(err, "failed to set the hostnames of rgw zonegroup %q", zoneGroupName)
+	}
+	if _, err := RunAdminCommandNoRealm(context, "period", "update", "--commit", realmArg, zoneGroupArg, zoneArg); err != nil {
+		return errors.Wrap(err, "failed to update period")
+	}
+	logger.Infof("set the hostnames of object store %q to %v", context.Name, spec.Hosting.DNSNames)
+	return nil
+}
+
+// setZoneGroupHostnames sets a list of hostnames of the zonegroup, only adding the missing names if merged, and
+// returns whether the list changed
+func setZoneGroupHostnames(zoneGroup map[string]interface{}, key string, names []string, merge bool) bool {
+	current := []string{}
+	if values, ok := zoneGroup[key].([]interface{}); ok {
+		for _, value := range values {
+			if name, ok := value.(string); ok {
+				current = append(current, name)
+			}
+		}
+	}
+
+	desired := append([]string{}, names...)
+	if merge {
+		desired = append([]string{}, current...)
+		for _, name := range names {
+			if !contains(desired, name) {
+				desired = append(desired, name)
+			}
+		}
+	}
+	if len(desired) == len(current) {
+		same := true
+		for i := range desired {
+			if desired[i] != current[i] {
+				same = false
+			}
+		}
+		if same {
+			return false
+		}
+	}
+
+	zoneGroup[key] = desired
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
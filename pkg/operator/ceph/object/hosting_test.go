@@ -0,0 +1,124 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateHosting(t *testing.T) {
+	tests := []struct {
+		name    string
+		hosting *cephv1.ObjectStoreHostingSpec
+		wantErr bool
+	}{
+		{"none", nil, false},
+		{"dns names", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"s3.example.com", "s3.internal"}, S3WebsiteDNSName: "web.example.com"}, false},
+		{"wildcard", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"*.s3.example.com"}}, true},
+		{"ip address", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"10.0.0.1"}}, true},
+		{"invalid name", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"S3_Example"}}, true},
+		{"empty name", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{""}}, true},
+		{"duplicate name", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"s3.example.com", "s3.example.com"}}, true},
+		{"website name of s3", &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"s3.example.com"}, S3WebsiteDNSName: "s3.example.com"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateHosting(tt.hosting)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHostingFlags(t *testing.T) {
+	store := simpleStore()
+	c := &clusterConfig{store: store}
+	assert.Empty(t, c.hostingFlags())
+
+	store.Spec.Hosting = &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"s3.example.com", "s3.internal"}, S3WebsiteDNSName: "web.example.com"}
+	assert.Equal(t, []string{
+		"--rgw-dns-name=s3.example.com",
+		"--rgw-dns-s3website-name=web.example.com",
+		"--rgw-enable-static-website=true",
+	}, c.hostingFlags())
+}
+
+func TestSetZoneGroupHostnames(t *testing.T) {
+	zoneGroup := map[string]interface{}{"hostnames": []interface{}{"a.example.com"}}
+	assert.False(t, setZoneGroupHostnames(zoneGroup, "hostnames", []string{"a.example.com"}, false))
+	assert.True(t, setZoneGroupHostnames(zoneGroup, "hostnames", []string{"b.example.com"}, false))
+	assert.Equal(t, []string{"b.example.com"}, zoneGroup["hostnames"])
+
+	// the hostnames of the other zones are kept when merging
+	zoneGroup = map[string]interface{}{"hostnames": []interface{}{"a.example.com"}}
+	assert.True(t, setZoneGroupHostnames(zoneGroup, "hostnames", []string{"b.example.com"}, true))
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, zoneGroup["hostnames"])
+	zoneGroup = map[string]interface{}{"hostnames": []interface{}{"a.example.com", "b.example.com"}}
+	assert.False(t, setZoneGroupHostnames(zoneGroup, "hostnames", []string{"b.example.com"}, true))
+}
+
+func TestReconcileHostnames(t *testing.T) {
+	zoneGroup := `{"id":"zg1","name":"store","hostnames":[],"hostnames_s3website":[],"master_zone":"z1"}`
+	commands := []string{}
+	setZoneGroup := map[string]interface{}{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			commands = append(commands, strings.Join(args[:2], " "))
+			if args[0] == "zonegroup" && args[1] == "get" {
+				return zoneGroup, nil
+			}
+			if args[0] == "zonegroup" && args[1] == "set" {
+				for _, arg := range args {
+					if strings.HasPrefix(arg, "--infile=") {
+						content, err := ioutil.ReadFile(strings.TrimPrefix(arg, "--infile="))
+						assert.NoError(t, err)
+						assert.NoError(t, json.Unmarshal(content, &setZoneGroup))
+					}
+				}
+			}
+			return "", nil
+		},
+	}
+	objContext := NewContext(&clusterd.Context{Executor: executor}, "store", "rook-ceph")
+
+	assert.NoError(t, reconcileHostnames(objContext, cephv1.ObjectStoreSpec{}, "store", "store", "store"))
+	assert.Empty(t, commands)
+
+	spec := cephv1.ObjectStoreSpec{Hosting: &cephv1.ObjectStoreHostingSpec{DNSNames: []string{"s3.example.com"}, S3WebsiteDNSName: "web.example.com"}}
+	assert.NoError(t, reconcileHostnames(objContext, spec, "store", "store", "store"))
+	assert.Equal(t, []string{"zonegroup get", "zonegroup set", "period update"}, commands)
+	assert.Equal(t, "zg1", setZoneGroup["id"])
+	assert.Equal(t, []interface{}{"s3.example.com"}, setZoneGroup["hostnames"])
+	assert.Equal(t, []interface{}{"web.example.com"}, setZoneGroup["hostnames_s3website"])
+
+	// the zonegroup is not updated when it has the hostnames
+	zoneGroup = `{"id":"zg1","name":"store","hostnames":["s3.example.com"],"hostnames_s3website":["web.example.com"]}`
+	commands = []string{}
+	assert.NoError(t, reconcileHostnames(objContext, spec, "store", "store", "store"))
+	assert.Equal(t, []string{"zonegroup get"}, commands)
+}
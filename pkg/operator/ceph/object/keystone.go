@@ -0,0 +1,143 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rgwAPIsWithoutSwift are the APIs served by rgw when the Swift API is disabled, the APIs unknown to the running
+// version of rgw being ignored
+const rgwAPIsWithoutSwift = "s3, s3website, admin, sts, iam, pubsub, notifications"
+
+var (
+	// keystoneSecretKeys are the keys of the secret of the Keystone service user, passed to rgw as env vars
+	keystoneSecretKeys = []string{"OS_USERNAME", "OS_PASSWORD", "OS_PROJECT_NAME", "OS_USER_DOMAIN_NAME"}
+	// keystoneImplicitTenants are the values accepted by rgw for the implicit tenants of the Keystone users
+	keystoneImplicitTenants = []string{"", "true", "false", "swift", "s3"}
+)
+
+// validateKeystone ensures the Keystone settings of the store are complete and its service user secret has the
+// credentials of the service user
+func validateKeystone(clientset kubernetes.Interface, namespace string, spec cephv1.ObjectStoreSpec) error {
+	keystone := spec.Auth.Keystone
+	if keystone == nil {
+		if spec.Protocols.S3 != nil && spec.Protocols.S3.AuthUseKeystone != nil && *spec.Protocols.S3.AuthUseKeystone {
+			return errors.New("the s3 authentication with keystone requires the keystone settings")
+		}
+		return nil
+	}
+	if keystone.URL == "" {
+		return errors.New("missing keystone url")
+	}
+	if keystone.ServiceUserSecretName == "" {
+		return errors.New("missing keystone service user secret name")
+	}
+	if len(keystone.AcceptedRoles) == 0 {
+		return errors.New("missing keystone accepted roles")
+	}
+	validImplicitTenants := false
+	for _, value := range keystoneImplicitTenants {
+		if keystone.ImplicitTenants == value {
+			validImplicitTenants = true
+		}
+	}
+	if !validImplicitTenants {
+		return errors.Errorf("invalid keystone implicit tenants %q, must be one of %v", keystone.ImplicitTenants, keystoneImplicitTenants[1:])
+	}
+	if keystone.TokenCacheSize != nil && *keystone.TokenCacheSize < 0 {
+		return errors.Errorf("invalid keystone token cache size %d", *keystone.TokenCacheSize)
+	}
+
+	for _, key := range keystoneSecretKeys {
+		if _, err := getSecretKey(clientset, namespace, keystone.ServiceUserSecretName, key); err != nil {
+			return errors.Wrap(err, "invalid keystone service user secret")
+		}
+	}
+	return nil
+}
+
+// keystoneFlags returns the rgw flags of the Keystone authentication and of the S3 and Swift APIs of the store
+func (c *clusterConfig) keystoneFlags() []string {
+	flags := []string{}
+	spec := c.store.Spec
+
+	if keystone := spec.Auth.Keystone; keystone != nil {
+		flags = append(flags,
+			cephconfig.NewFlag("rgw keystone url", keystone.URL),
+			cephconfig.NewFlag("rgw keystone api version", "3"),
+			cephconfig.NewFlag("rgw keystone admin user", controller.ContainerEnvVarReference("OS_USERNAME")),
+			cephconfig.NewFlag("rgw keystone admin password", controller.ContainerEnvVarReference("OS_PASSWORD")),
+			cephconfig.NewFlag("rgw keystone admin project", controller.ContainerEnvVarReference("OS_PROJECT_NAME")),
+			cephconfig.NewFlag("rgw keystone admin domain", controller.ContainerEnvVarReference("OS_USER_DOMAIN_NAME")),
+			cephconfig.NewFlag("rgw keystone accepted roles", strings.Join(keystone.AcceptedRoles, ",")),
+		)
+		if keystone.ImplicitTenants != "" {
+			flags = append(flags, cephconfig.NewFlag("rgw keystone implicit tenants", keystone.ImplicitTenants))
+		}
+		if keystone.TokenCacheSize != nil {
+			flags = append(flags, cephconfig.NewFlag("rgw keystone token cache size", strconv.Itoa(*keystone.TokenCacheSize)))
+		}
+	}
+
+	if s3 := spec.Protocols.S3; s3 != nil && s3.AuthUseKeystone != nil {
+		flags = append(flags, cephconfig.NewFlag("rgw s3 auth use keystone", strconv.FormatBool(*s3.AuthUseKeystone)))
+	}
+
+	if swift := spec.Protocols.Swift; swift != nil {
+		if swift.Enabled != nil && !*swift.Enabled {
+			flags = append(flags, cephconfig.NewFlag("rgw enable apis", rgwAPIsWithoutSwift))
+		}
+		if swift.AccountInURL != nil {
+			flags = append(flags, cephconfig.NewFlag("rgw swift account in url", strconv.FormatBool(*swift.AccountInURL)))
+		}
+		if swift.URLPrefix != nil {
+			flags = append(flags, cephconfig.NewFlag("rgw swift url prefix", *swift.URLPrefix))
+		}
+	}
+
+	return flags
+}
+
+// keystoneEnvVars returns the env vars of the credentials of the Keystone service user, read from its secret
+func (c *clusterConfig) keystoneEnvVars() []v1.EnvVar {
+	keystone := c.store.Spec.Auth.Keystone
+	if keystone == nil {
+		return nil
+	}
+	envVars := []v1.EnvVar{}
+	for _, key := range keystoneSecretKeys {
+		envVars = append(envVars, v1.EnvVar{
+			Name: key,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{Name: keystone.ServiceUserSecretName},
+					Key:                  key,
+				},
+			},
+		})
+	}
+	return envVars
+}
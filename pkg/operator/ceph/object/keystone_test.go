@@ -0,0 +1,119 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	testop "github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateKeystone(t *testing.T) {
+	clientset := testop.New(t, 1)
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keystone-user", Namespace: "rook-ceph"},
+		Data: map[string][]byte{
+			"OS_USERNAME":         []byte("rgw"),
+			"OS_PASSWORD":         []byte("secret"),
+			"OS_PROJECT_NAME":     []byte("service"),
+			"OS_USER_DOMAIN_NAME": []byte("Default"),
+		},
+	}
+	_, err := clientset.CoreV1().Secrets("rook-ceph").Create(secret)
+	assert.NoError(t, err)
+	keystone := func(url, secretName string, roles []string, implicitTenants string) *cephv1.KeystoneSpec {
+		return &cephv1.KeystoneSpec{URL: url, ServiceUserSecretName: secretName, AcceptedRoles: roles, ImplicitTenants: implicitTenants}
+	}
+	useKeystone := true
+
+	tests := []struct {
+		name    string
+		spec    cephv1.ObjectStoreSpec
+		wantErr bool
+	}{
+		{"no keystone", cephv1.ObjectStoreSpec{}, false},
+		{"keystone", cephv1.ObjectStoreSpec{Auth: cephv1.ObjectStoreAuthSpec{Keystone: keystone("https://keystone:5000", "keystone-user", []string{"member"}, "swift")}}, false},
+		{"missing url", cephv1.ObjectStoreSpec{Auth: cephv1.ObjectStoreAuthSpec{Keystone: keystone("", "keystone-user", []string{"member"}, "")}}, true},
+		{"missing roles", cephv1.ObjectStoreSpec{Auth: cephv1.ObjectStoreAuthSpec{Keystone: keystone("https://keystone:5000", "keystone-user", nil, "")}}, true},
+		{"invalid implicit tenants", cephv1.ObjectStoreSpec{Auth: cephv1.ObjectStoreAuthSpec{Keystone: keystone("https://keystone:5000", "keystone-user", []string{"member"}, "all")}}, true},
+		{"missing secret", cephv1.ObjectStoreSpec{Auth: cephv1.ObjectStoreAuthSpec{Keystone: keystone("https://keystone:5000", "other", []string{"member"}, "")}}, true},
+		{"s3 without keystone", cephv1.ObjectStoreSpec{Protocols: cephv1.ObjectStoreProtocolSpec{S3: &cephv1.S3Spec{AuthUseKeystone: &useKeystone}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateKeystone(clientset, "rook-ceph", tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	// a secret without the password is refused
+	delete(secret.Data, "OS_PASSWORD")
+	_, err = clientset.CoreV1().Secrets("rook-ceph").Update(secret)
+	assert.NoError(t, err)
+	assert.Error(t, validateKeystone(clientset, "rook-ceph", tests[1].spec))
+}
+
+func TestKeystoneFlags(t *testing.T) {
+	store := simpleStore()
+	c := &clusterConfig{store: store}
+	assert.Empty(t, c.keystoneFlags())
+	assert.Empty(t, c.keystoneEnvVars())
+
+	cacheSize := 500
+	useKeystone := true
+	disabled := false
+	prefix := "/"
+	store.Spec.Auth.Keystone = &cephv1.KeystoneSpec{
+		URL:                   "https://keystone:5000",
+		ServiceUserSecretName: "keystone-user",
+		AcceptedRoles:         []string{"admin", "member"},
+		TokenCacheSize:        &cacheSize,
+	}
+	store.Spec.Protocols.S3 = &cephv1.S3Spec{AuthUseKeystone: &useKeystone}
+	store.Spec.Protocols.Swift = &cephv1.SwiftSpec{URLPrefix: &prefix}
+	assert.Equal(t, []string{
+		"--rgw-keystone-url=https://keystone:5000",
+		"--rgw-keystone-api-version=3",
+		"--rgw-keystone-admin-user=$(OS_USERNAME)",
+		"--rgw-keystone-admin-password=$(OS_PASSWORD)",
+		"--rgw-keystone-admin-project=$(OS_PROJECT_NAME)",
+		"--rgw-keystone-admin-domain=$(OS_USER_DOMAIN_NAME)",
+		"--rgw-keystone-accepted-roles=admin,member",
+		"--rgw-keystone-token-cache-size=500",
+		"--rgw-s3-auth-use-keystone=true",
+		"--rgw-swift-url-prefix=/",
+	}, c.keystoneFlags())
+
+	envVars := c.keystoneEnvVars()
+	assert.Equal(t, 4, len(envVars))
+	assert.Equal(t, "OS_PASSWORD", envVars[1].Name)
+	assert.Equal(t, "keystone-user", envVars[1].ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "OS_PASSWORD", envVars[1].ValueFrom.SecretKeyRef.Key)
+
+	// the swift API is removed from the APIs of rgw when disabled
+	store.Spec.Auth.Keystone = nil
+	store.Spec.Protocols = cephv1.ObjectStoreProtocolSpec{Swift: &cephv1.SwiftSpec{Enabled: &disabled}}
+	assert.Equal(t, []string{"--rgw-enable-apis=" + rgwAPIsWithoutSwift}, c.keystoneFlags())
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2016 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AccessKeyName is the key of the access key of the system user of a realm in the realm keys secret
+	AccessKeyName = "access-key"
+	// SecretKeyName is the key of the secret key of the system user of a realm in the realm keys secret
+	SecretKeyName = "secret-key"
+	// realmKeysSecretSuffix is the suffix of the name of the secret with the keys of the system user of a realm
+	realmKeysSecretSuffix = "keys"
+	// systemUserSuffix is the suffix of the uid of the system user of a realm, syncing the zones of the realm
+	systemUserSuffix = "system-user"
+)
+
+// GetRealmKeySecretName returns the name of the secret with the keys of the system user of the realm, the secret
+// being copied to the clusters pulling the realm
+func GetRealmKeySecretName(realmName string) string {
+	return fmt.Sprintf("%s-%s", realmName, realmKeysSecretSuffix)
+}
+
+// GetRealmSystemUserName returns the uid of the system user of the realm
+func GetRealmSystemUserName(realmName string) string {
+	return fmt.Sprintf("%s-%s", realmName, systemUserSuffix)
+}
+
+// GetRealmKeyArgs returns the radosgw-admin arguments with the keys of the system user of the realm
+func GetRealmKeyArgs(context *clusterd.Context, realmName, namespace string) (string, string, error) {
+	secretName := GetRealmKeySecretName(realmName)
+	secret, err := context.Clientset.CoreV1().Secrets(namespace).Get(secretName, metav1.GetOptions{})
+	if err != nil {
+		return "", "", errors.Wrapf(err, "failed to get the keys secret %q of realm %q", secretName, realmName)
+	}
+
+	accessKey, ok := secret.Data[AccessKeyName]
+	if !ok || len(accessKey) == 0 {
+		return "", "", errors.Errorf("keys secret %q of realm %q has no %q key", secretName, realmName, AccessKeyName)
+	}
+	secretKey, ok := secret.Data[SecretKeyName]
+	if !ok || len(secretKey) == 0 {
+		return "", "", errors.Errorf("keys secret %q of realm %q has no %q key", secretName, realmName, SecretKeyName)
+	}
+	return fmt.Sprintf("--access-key=%s", accessKey), fmt.Sprintf("--secret-key=%s", secretKey), nil
+}
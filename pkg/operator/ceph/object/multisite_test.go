@@ -0,0 +1,53 @@
+/*
+Copyright 2016 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"testing"
+
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetRealmKeyArgs(t *testing.T) {
+	context := &clusterd.Context{Clientset: test.New(t, 1)}
+
+	// a missing secret fails
+	_, _, err := GetRealmKeyArgs(context, "realm-a", "rook-ceph")
+	assert.Error(t, err)
+
+	// a secret without a secret key fails
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "realm-a-keys", Namespace: "rook-ceph"},
+		Data:       map[string][]byte{AccessKeyName: []byte("access")},
+	}
+	_, err = context.Clientset.CoreV1().Secrets("rook-ceph").Create(secret)
+	assert.NoError(t, err)
+	_, _, err = GetRealmKeyArgs(context, "realm-a", "rook-ceph")
+	assert.Error(t, err)
+
+	secret.Data[SecretKeyName] = []byte("secret")
+	_, err = context.Clientset.CoreV1().Secrets("rook-ceph").Update(secret)
+	assert.NoError(t, err)
+	accessKeyArg, secretKeyArg, err := GetRealmKeyArgs(context, "realm-a", "rook-ceph")
+	assert.NoError(t, err)
+	assert.Equal(t, "--access-key=access", accessKeyArg)
+	assert.Equal(t, "--secret-key=secret", secretKeyArg)
+}
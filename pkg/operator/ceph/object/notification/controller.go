@@ -0,0 +1,393 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package notification to manage the notifications of the buckets of the object bucket claims.
+package notification
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/coreos/pkg/capnslog"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-bucket-notification-controller"
+	// the keys of the configmap of an object bucket claim
+	bucketNameKey = "BUCKET_NAME"
+	bucketHostKey = "BUCKET_HOST"
+	bucketPortKey = "BUCKET_PORT"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBucketNotificationKind = reflect.TypeOf(cephv1.CephBucketNotification{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBucketNotificationKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// waitForRequeueIfTopicNotReady requeues the object bucket claims whose topics have no arn yet
+var waitForRequeueIfTopicNotReady = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+
+// ReconcileBucketNotification reconciles the notifications of the buckets of the object bucket claims
+type ReconcileBucketNotification struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+	// newS3Client returns the client of the bucket of an object bucket claim, replaced by the tests
+	newS3Client func(accessKey, secretKey, endpoint string) (s3iface.S3API, error)
+}
+
+// Add creates a new CephBucketNotification Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) *ReconcileBucketNotification {
+	// Add the cephv1 and the object bucket schemes to the manager scheme so that the controller knows about them
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+	bktv1alpha1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileBucketNotification{
+		client:      mgr.GetClient(),
+		scheme:      mgrScheme,
+		context:     context,
+		newS3Client: newS3Client,
+	}
+}
+
+func newS3Client(accessKey, secretKey, endpoint string) (s3iface.S3API, error) {
+	agent, err := object.NewS3Agent(accessKey, secretKey, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return agent.Client, nil
+}
+
+func add(mgr manager.Manager, r *ReconcileBucketNotification) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for the object bucket claims labelled with notifications, each of them being reconciled
+	err = c.Watch(&source.Kind{Type: &bktv1alpha1.ObjectBucketClaim{}}, &handler.EnqueueRequestForObject{}, obcPredicate())
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes on the CephBucketNotification CRD object and enqueue the object bucket claims labelled with it
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBucketNotification{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return r.obcRequests(obj.Meta.GetNamespace(), obj.Meta.GetName())
+		}),
+	}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	// Watch for the topics getting an arn or deleted and enqueue the object bucket claims of their notifications
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBucketTopic{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: handler.ToRequestsFunc(func(obj handler.MapObject) []reconcile.Request {
+			return r.topicRequests(obj.Meta.GetNamespace(), obj.Meta.GetName())
+		}),
+	}, topicPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// obcPredicate passes the object bucket claims labelled with notifications, and the ones whose labels were removed
+func obcPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return len(notificationNames(e.Meta.GetLabels())) > 0
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNames := notificationNames(e.MetaOld.GetLabels())
+			newNames := notificationNames(e.MetaNew.GetLabels())
+			if len(oldNames) == 0 && len(newNames) == 0 {
+				return false
+			}
+			if !reflect.DeepEqual(oldNames, newNames) {
+				return true
+			}
+			// the bucket of the claim is known once it is bound
+			oldOBC, okOld := e.ObjectOld.(*bktv1alpha1.ObjectBucketClaim)
+			newOBC, okNew := e.ObjectNew.(*bktv1alpha1.ObjectBucketClaim)
+			return okOld && okNew && oldOBC.Status.Phase != newOBC.Status.Phase
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			// the notifications are gone with the bucket
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+// topicPredicate passes the topics whose arn changed, and the deleted ones
+func topicPredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldTopic, okOld := e.ObjectOld.(*cephv1.CephBucketTopic)
+			newTopic, okNew := e.ObjectNew.(*cephv1.CephBucketTopic)
+			if !okOld || !okNew {
+				return false
+			}
+			return !reflect.DeepEqual(topicARN(oldTopic), topicARN(newTopic)) || oldTopic.GetDeletionTimestamp() != newTopic.GetDeletionTimestamp()
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return true
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
+
+func topicARN(topic *cephv1.CephBucketTopic) *string {
+	if topic.Status == nil {
+		return nil
+	}
+	return topic.Status.ARN
+}
+
+// obcRequests returns the requests of the object bucket claims labelled with the notification
+func (r *ReconcileBucketNotification) obcRequests(namespace, notification string) []reconcile.Request {
+	obcs := &bktv1alpha1.ObjectBucketClaimList{}
+	if err := r.client.List(context.TODO(), obcs, client.InNamespace(namespace)); err != nil {
+		logger.Errorf("failed to list the object bucket claims of notification %q in namespace %q. %v", notification, namespace, err)
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, obc := range obcs.Items {
+		for _, name := range notificationNames(obc.GetLabels()) {
+			if name == notification {
+				requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: obc.Name, Namespace: obc.Namespace}})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// topicRequests returns the requests of the object bucket claims labelled with the notifications of the topic
+func (r *ReconcileBucketNotification) topicRequests(namespace, topic string) []reconcile.Request {
+	notifications := &cephv1.CephBucketNotificationList{}
+	if err := r.client.List(context.TODO(), notifications, client.InNamespace(namespace)); err != nil {
+		logger.Errorf("failed to list the notifications of topic %q in namespace %q. %v", topic, namespace, err)
+		return []reconcile.Request{}
+	}
+
+	requests := []reconcile.Request{}
+	for _, notification := range notifications.Items {
+		if notification.Spec.Topic == topic {
+			requests = append(requests, r.obcRequests(namespace, notification.Name)...)
+		}
+	}
+	return requests
+}
+
+// Reconcile reads that state of the cluster for an ObjectBucketClaim object and sets the notifications of its bucket
+// to the CephBucketNotifications it is labelled with
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileBucketNotification) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephBucketNotification", request)
+	reconcileLogger.Debug("reconciling")
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileBucketNotification) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the ObjectBucketClaim instance
+	obc := &bktv1alpha1.ObjectBucketClaim{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, obc)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("ObjectBucketClaim resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get ObjectBucketClaim")
+	}
+	if !obc.GetDeletionTimestamp().IsZero() {
+		logger.Debugf("ObjectBucketClaim %q is being deleted, its notifications are gone with its bucket", request.NamespacedName.String())
+		return reconcile.Result{}, nil
+	}
+	if obc.Status.Phase != bktv1alpha1.ObjectBucketClaimStatusPhaseBound {
+		logger.Debugf("ObjectBucketClaim %q is not bound yet, its notifications are set once bound", request.NamespacedName.String())
+		return reconcile.Result{}, nil
+	}
+
+	// Build the notifications of the bucket, the ones missing or being deleted are removed from the bucket
+	configs := []*s3.TopicConfiguration{}
+	var applied []types.NamespacedName
+	for _, name := range notificationNames(obc.GetLabels()) {
+		notificationName := types.NamespacedName{Name: name, Namespace: obc.Namespace}
+		notification := &cephv1.CephBucketNotification{}
+		if err := r.client.Get(context.TODO(), notificationName, notification); err != nil {
+			if kerrors.IsNotFound(err) {
+				logger.Infof("CephBucketNotification %q of ObjectBucketClaim %q not found", notificationName.String(), request.NamespacedName.String())
+				continue
+			}
+			return reconcile.Result{}, errors.Wrapf(err, "failed to get CephBucketNotification %q", notificationName.String())
+		}
+		if !notification.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		if err := validateNotification(notification); err != nil {
+			logger.Errorf("invalid notification CR %q spec. %v", notificationName.String(), err)
+			updateStatus(r.client, notificationName, k8sutil.ReconcileFailedStatus)
+			continue
+		}
+
+		topicName := types.NamespacedName{Name: notification.Spec.Topic, Namespace: obc.Namespace}
+		topic := &cephv1.CephBucketTopic{}
+		if err := r.client.Get(context.TODO(), topicName, topic); err != nil {
+			if kerrors.IsNotFound(err) {
+				logger.Infof("CephBucketTopic %q of notification %q not found", topicName.String(), notificationName.String())
+				updateStatus(r.client, notificationName, k8sutil.ReconcileFailedStatus)
+				continue
+			}
+			return reconcile.Result{}, errors.Wrapf(err, "failed to get CephBucketTopic %q", topicName.String())
+		}
+		if !topic.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		arn := topicARN(topic)
+		if arn == nil {
+			logger.Infof("CephBucketTopic %q of notification %q not ready, retrying in %q", topicName.String(), notificationName.String(), waitForRequeueIfTopicNotReady.RequeueAfter.String())
+			return waitForRequeueIfTopicNotReady, nil
+		}
+		configs = append(configs, topicConfiguration(notification, *arn))
+		applied = append(applied, notificationName)
+	}
+
+	bucket, s3Client, err := r.getBucketClient(obc)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get the bucket of ObjectBucketClaim %q", request.NamespacedName.String())
+	}
+	if err := putBucketNotifications(s3Client, bucket, configs); err != nil {
+		for _, name := range applied {
+			updateStatus(r.client, name, k8sutil.ReconcileFailedStatus)
+		}
+		return reconcile.Result{}, err
+	}
+	logger.Infof("set %d notifications of bucket %q of ObjectBucketClaim %q", len(configs), bucket, request.NamespacedName.String())
+
+	for _, name := range applied {
+		updateStatus(r.client, name, k8sutil.ReadyStatus)
+	}
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// getBucketClient returns the bucket of the object bucket claim and a client with the keys of its owner
+func (r *ReconcileBucketNotification) getBucketClient(obc *bktv1alpha1.ObjectBucketClaim) (string, s3iface.S3API, error) {
+	// the configmap and the secret of the bucket have the name of the claim
+	configMap, err := r.context.Clientset.CoreV1().ConfigMaps(obc.Namespace).Get(obc.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get configmap %q", obc.Name)
+	}
+	secret, err := r.context.Clientset.CoreV1().Secrets(obc.Namespace).Get(obc.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", nil, errors.Wrapf(err, "failed to get secret %q", obc.Name)
+	}
+
+	bucket := configMap.Data[bucketNameKey]
+	if bucket == "" {
+		return "", nil, errors.Errorf("no bucket name in configmap %q", obc.Name)
+	}
+	endpoint := fmt.Sprintf("%s:%s", configMap.Data[bucketHostKey], configMap.Data[bucketPortKey])
+	s3Client, err := r.newS3Client(string(secret.Data[bktv1alpha1.AwsKeyField]), string(secret.Data[bktv1alpha1.AwsSecretField]), endpoint)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to initialize s3 connection")
+	}
+	return bucket, s3Client, nil
+}
+
+// updateStatus updates a notification with a given status
+func updateStatus(client client.Client, name types.NamespacedName, status string) {
+	notification := &cephv1.CephBucketNotification{}
+	if err := client.Get(context.TODO(), name, notification); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBucketNotification resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve notification %q to update status to %q. %v", name, status, err)
+		return
+	}
+	if notification.Status == nil {
+		notification.Status = &cephv1.Status{}
+	}
+	if notification.Status.Phase == status {
+		return
+	}
+
+	notification.Status.Phase = status
+	if err := opcontroller.UpdateStatus(client, notification); err != nil {
+		logger.Errorf("failed to set notification %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("notification %q status updated to %q", name, status)
+}
@@ -0,0 +1,187 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	bktv1alpha1 "github.com/kube-object-storage/lib-bucket-provisioner/pkg/apis/objectbucket.io/v1alpha1"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeS3Client struct {
+	s3iface.S3API
+	endpoint string
+	buckets  map[string][]*s3.TopicConfiguration
+}
+
+func (c *fakeS3Client) PutBucketNotificationConfiguration(input *s3.PutBucketNotificationConfigurationInput) (*s3.PutBucketNotificationConfigurationOutput, error) {
+	c.buckets[*input.Bucket] = input.NotificationConfiguration.TopicConfigurations
+	return &s3.PutBucketNotificationConfigurationOutput{}, nil
+}
+
+func TestNotificationNames(t *testing.T) {
+	assert.Nil(t, notificationNames(nil))
+	assert.Nil(t, notificationNames(map[string]string{"app": "my-app"}))
+	assert.Equal(t, []string{"a", "b"}, notificationNames(map[string]string{
+		"bucket-notification-b": "b",
+		"bucket-notification-a": "",
+		"app":                   "my-app",
+	}))
+}
+
+func TestTopicConfiguration(t *testing.T) {
+	notification := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-notification", Namespace: "my-app"},
+		Spec:       cephv1.BucketNotificationSpec{Topic: "my-topic"},
+	}
+	assert.NoError(t, validateNotification(notification))
+
+	// all the events of all the objects are notified by default
+	config := topicConfiguration(notification, "arn:aws:sns:my-store::my-topic")
+	assert.Equal(t, "my-notification", *config.Id)
+	assert.Equal(t, "arn:aws:sns:my-store::my-topic", *config.TopicArn)
+	assert.Equal(t, []*string{}, config.Events)
+	assert.Nil(t, config.Filter)
+	assert.NoError(t, config.Validate())
+
+	notification.Spec.Events = []cephv1.BucketNotificationEvent{"s3:ObjectCreated:*"}
+	notification.Spec.Filter = &cephv1.NotificationFilterSpec{KeyFilters: []cephv1.NotificationKeyFilterRule{{Name: "prefix", Value: "images/"}}}
+	assert.NoError(t, validateNotification(notification))
+	config = topicConfiguration(notification, "arn:aws:sns:my-store::my-topic")
+	assert.Equal(t, []*string{aws.String("s3:ObjectCreated:*")}, config.Events)
+	assert.Equal(t, []*s3.FilterRule{{Name: aws.String("prefix"), Value: aws.String("images/")}}, config.Filter.Key.FilterRules)
+
+	invalid := notification.DeepCopy()
+	invalid.Spec.Topic = ""
+	assert.Error(t, validateNotification(invalid))
+	invalid = notification.DeepCopy()
+	invalid.Spec.Events = []cephv1.BucketNotificationEvent{"ObjectCreated"}
+	assert.Error(t, validateNotification(invalid))
+	invalid = notification.DeepCopy()
+	invalid.Spec.Filter.KeyFilters[0].Name = "contains"
+	assert.Error(t, validateNotification(invalid))
+}
+
+func TestReconcileBucketNotifications(t *testing.T) {
+	namespace := "my-app"
+	obc := &bktv1alpha1.ObjectBucketClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-obc",
+			Namespace: namespace,
+			Labels:    map[string]string{"bucket-notification-my-notification": "my-notification"},
+		},
+		Status: bktv1alpha1.ObjectBucketClaimStatus{Phase: bktv1alpha1.ObjectBucketClaimStatusPhaseBound},
+	}
+	notification := &cephv1.CephBucketNotification{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-notification", Namespace: namespace},
+		Spec:       cephv1.BucketNotificationSpec{Topic: "my-topic"},
+	}
+	topic := &cephv1.CephBucketTopic{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-topic", Namespace: namespace},
+		Spec:       cephv1.BucketTopicSpec{ObjectStoreName: "my-store", ObjectStoreNamespace: "rook-ceph"},
+	}
+
+	clientset := test.New(t, 1)
+	_, err := clientset.CoreV1().ConfigMaps(namespace).Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-obc", Namespace: namespace},
+		Data:       map[string]string{"BUCKET_NAME": "my-bucket", "BUCKET_HOST": "rook-ceph-rgw-my-store.rook-ceph", "BUCKET_PORT": "80"},
+	})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-obc", Namespace: namespace},
+		Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("access"), "AWS_SECRET_ACCESS_KEY": []byte("secret")},
+	})
+	assert.NoError(t, err)
+
+	s3Client := &fakeS3Client{buckets: map[string][]*s3.TopicConfiguration{}}
+	s := runtime.NewScheme()
+	assert.NoError(t, cephv1.AddToScheme(s))
+	assert.NoError(t, bktv1alpha1.AddToScheme(s))
+	newReconciler := func(objects ...runtime.Object) *ReconcileBucketNotification {
+		return &ReconcileBucketNotification{
+			client:  fake.NewFakeClientWithScheme(s, objects...),
+			scheme:  s,
+			context: &clusterd.Context{Clientset: clientset},
+			newS3Client: func(accessKey, secretKey, endpoint string) (s3iface.S3API, error) {
+				assert.Equal(t, "access", accessKey)
+				assert.Equal(t, "secret", secretKey)
+				s3Client.endpoint = endpoint
+				return s3Client, nil
+			},
+		}
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "my-obc", Namespace: namespace}}
+
+	// the notification waits for the arn of its topic
+	r := newReconciler(obc, notification, topic)
+	res, err := r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.Empty(t, s3Client.buckets)
+
+	// the notification is set on the bucket once the topic is created
+	arn := "arn:aws:sns:my-store::my-topic"
+	topic.Status = &cephv1.BucketTopicStatus{ARN: &arn}
+	r = newReconciler(obc.DeepCopy(), notification.DeepCopy(), topic.DeepCopy())
+	res, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, "rook-ceph-rgw-my-store.rook-ceph:80", s3Client.endpoint)
+	assert.Equal(t, 1, len(s3Client.buckets["my-bucket"]))
+	assert.Equal(t, arn, *s3Client.buckets["my-bucket"][0].TopicArn)
+	updated := &cephv1.CephBucketNotification{}
+	assert.NoError(t, r.client.Get(context.TODO(), types.NamespacedName{Name: "my-notification", Namespace: namespace}, updated))
+	assert.Equal(t, k8sutil.ReadyStatus, updated.Status.Phase)
+
+	// the notifications are removed from the bucket once the claim is not labelled with them
+	unlabelled := obc.DeepCopy()
+	unlabelled.Labels = nil
+	r = newReconciler(unlabelled, notification.DeepCopy(), topic.DeepCopy())
+	_, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Empty(t, s3Client.buckets["my-bucket"])
+
+	// the claims not bound yet are skipped
+	delete(s3Client.buckets, "my-bucket")
+	pending := obc.DeepCopy()
+	pending.Status.Phase = bktv1alpha1.ObjectBucketClaimStatusPhasePending
+	r = newReconciler(pending, notification.DeepCopy(), topic.DeepCopy())
+	_, err = r.Reconcile(request)
+	assert.NoError(t, err)
+	assert.Empty(t, s3Client.buckets)
+
+	// the claims of a notification and of its topic are found
+	r = newReconciler(obc.DeepCopy(), notification.DeepCopy(), topic.DeepCopy())
+	assert.Equal(t, []reconcile.Request{request}, r.obcRequests(namespace, "my-notification"))
+	assert.Empty(t, r.obcRequests(namespace, "other-notification"))
+	assert.Equal(t, []reconcile.Request{request}, r.topicRequests(namespace, "my-topic"))
+}
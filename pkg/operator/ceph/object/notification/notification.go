@@ -0,0 +1,108 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package notification
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+)
+
+const (
+	// notificationLabelPrefix prefixes the labels binding an object bucket claim to the notifications of its namespace
+	// e.g. "bucket-notification-my-notification: my-notification"
+	notificationLabelPrefix = "bucket-notification-"
+)
+
+// notificationNames returns the sorted names of the notifications the labels bind an object bucket claim to
+func notificationNames(labels map[string]string) []string {
+	var names []string
+	for key, value := range labels {
+		if !strings.HasPrefix(key, notificationLabelPrefix) {
+			continue
+		}
+		name := value
+		if name == "" {
+			name = strings.TrimPrefix(key, notificationLabelPrefix)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// validateNotification validates the spec of the notification
+func validateNotification(n *cephv1.CephBucketNotification) error {
+	if n.Spec.Topic == "" {
+		return errors.New("missing topic")
+	}
+	for _, event := range n.Spec.Events {
+		if !strings.HasPrefix(string(event), "s3:") {
+			return errors.Errorf("invalid event %q, the events start with 's3:'", event)
+		}
+	}
+	if n.Spec.Filter != nil {
+		for _, rule := range n.Spec.Filter.KeyFilters {
+			switch rule.Name {
+			case "prefix", "suffix", "regex":
+			default:
+				return errors.Errorf("invalid key filter %q. only 'prefix', 'suffix' and 'regex' are supported", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// topicConfiguration returns the configuration of the notification sent to the topic of the arn
+func topicConfiguration(n *cephv1.CephBucketNotification, arn string) *s3.TopicConfiguration {
+	// no event notifies all of them
+	events := []*string{}
+	for _, event := range n.Spec.Events {
+		events = append(events, aws.String(string(event)))
+	}
+
+	config := &s3.TopicConfiguration{
+		Id:       aws.String(n.Name),
+		TopicArn: aws.String(arn),
+		Events:   events,
+	}
+	if n.Spec.Filter != nil && len(n.Spec.Filter.KeyFilters) > 0 {
+		var rules []*s3.FilterRule
+		for _, rule := range n.Spec.Filter.KeyFilters {
+			rules = append(rules, &s3.FilterRule{Name: aws.String(rule.Name), Value: aws.String(rule.Value)})
+		}
+		config.Filter = &s3.NotificationConfigurationFilter{Key: &s3.KeyFilter{FilterRules: rules}}
+	}
+	return config
+}
+
+// putBucketNotifications replaces the notifications of the bucket, none of them removing all the notifications
+func putBucketNotifications(client s3iface.S3API, bucket string, configs []*s3.TopicConfiguration) error {
+	_, err := client.PutBucketNotificationConfiguration(&s3.PutBucketNotificationConfigurationInput{
+		Bucket:                    aws.String(bucket),
+		NotificationConfiguration: &s3.NotificationConfiguration{TopicConfigurations: configs},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the notifications of bucket %q", bucket)
+	}
+	return nil
+}
@@ -221,6 +221,7 @@ func deletePools(context *Context, spec cephv1.ObjectStoreSpec, lastStore bool)
 	}
 
 	pools := append(metadataPools, dataPoolName)
+	pools = append(pools, getPlacementPoolNames(spec)...)
 	if lastStore {
 		pools = append(pools, rootPool)
 	}
@@ -237,22 +238,30 @@ func deletePools(context *Context, spec cephv1.ObjectStoreSpec, lastStore bool)
 	if err != nil {
 		return errors.Wrapf(err, "failed to list erasure code profiles for cluster %s", context.ClusterName)
 	}
-	// cleans up the EC profile for the data pool only. Metadata pools don't support EC (only replication is supported).
-	ecProfileName := client.GetErasureCodeProfileForPool(context.Name)
-	for i := range erasureCodes {
-		if erasureCodes[i] == ecProfileName {
-			if err := ceph.DeleteErasureCodeProfile(context.Context, context.ClusterName, ecProfileName); err != nil {
-				return errors.Wrapf(err, "failed to delete erasure code profile %s for object store %s", ecProfileName, context.Name)
+	// cleans up the EC profile for the data pools only. Metadata pools don't support EC (only replication is supported).
+	ecProfileNames := []string{client.GetErasureCodeProfileForPool(context.Name)}
+	for _, pool := range getPlacementPools(spec) {
+		if pool.dataPoolSpec.IsErasureCoded() {
+			ecProfileNames = append(ecProfileNames, client.GetErasureCodeProfileForPool(poolName(context.Name, pool.dataPool)))
+		}
+	}
+	for _, ecProfileName := range ecProfileNames {
+		for i := range erasureCodes {
+			if erasureCodes[i] == ecProfileName {
+				if err := ceph.DeleteErasureCodeProfile(context.Context, context.ClusterName, ecProfileName); err != nil {
+					return errors.Wrapf(err, "failed to delete erasure code profile %s for object store %s", ecProfileName, context.Name)
+				}
+				break
 			}
-			break
 		}
 	}
 
 	return nil
 }
 
-func createPools(context *Context, spec cephv1.ObjectStoreSpec) error {
-	if emptyPool(spec.DataPool) && emptyPool(spec.MetadataPool) {
+// CreatePools creates the metadata and data pools of an object store or of a zone, named after the name of the context
+func CreatePools(context *Context, metadataPool, dataPool cephv1.PoolSpec) error {
+	if emptyPool(dataPool) && emptyPool(metadataPool) {
 		logger.Info("no pools specified for the object store, checking for their existence...")
 		pools := append(metadataPools, dataPoolName)
 		pools = append(pools, rootPool)
@@ -277,20 +286,20 @@ func createPools(context *Context, spec cephv1.ObjectStoreSpec) error {
 		metadataPoolPGs = ceph.DefaultPGCount
 	}
 
-	if err := createSimilarPools(context, append(metadataPools, rootPool), spec.MetadataPool, metadataPoolPGs, ""); err != nil {
+	if err := createSimilarPools(context, append(metadataPools, rootPool), metadataPool, metadataPoolPGs, ""); err != nil {
 		return errors.Wrap(err, "failed to create metadata pools")
 	}
 
 	ecProfileName := ""
-	if spec.DataPool.IsErasureCoded() {
+	if dataPool.IsErasureCoded() {
 		ecProfileName = client.GetErasureCodeProfileForPool(context.Name)
 		// create a new erasure code profile for the data pool
-		if err := ceph.CreateErasureCodeProfile(context.Context, context.ClusterName, ecProfileName, spec.DataPool); err != nil {
+		if err := ceph.CreateErasureCodeProfile(context.Context, context.ClusterName, ecProfileName, dataPool); err != nil {
 			return errors.Wrapf(err, "failed to create erasure code profile for object store %s", context.Name)
 		}
 	}
 
-	if err := createSimilarPools(context, []string{dataPoolName}, spec.DataPool, ceph.DefaultPGCount, ecProfileName); err != nil {
+	if err := createSimilarPools(context, []string{dataPoolName}, dataPool, ceph.DefaultPGCount, ecProfileName); err != nil {
 		return errors.Wrap(err, "failed to create data pool")
 	}
 
@@ -348,13 +357,19 @@ func poolName(storeName, poolName string) string {
 // GetObjectBucketProvisioner returns the bucket provisioner name appended with operator namespace if OBC is watching on it
 func GetObjectBucketProvisioner(c *clusterd.Context, namespace string) string {
 	provName := bucketProvisionerName
+	if IsObjectBucketProvisionerNamespaced(c) {
+		provName = fmt.Sprintf("%s.%s", namespace, bucketProvisionerName)
+	}
+	return provName
+}
+
+// IsObjectBucketProvisionerNamespaced returns whether each cluster runs its own bucket provisioner
+// Otherwise a single provisioner serves the buckets of all the clusters
+func IsObjectBucketProvisionerNamespaced(c *clusterd.Context) bool {
 	obcWatchOnNamespace, err := k8sutil.GetOperatorSetting(c.Clientset, opcontroller.OperatorSettingConfigMapName, "ROOK_OBC_WATCH_OPERATOR_NAMESPACE", "false")
 	if err != nil {
 		logger.Warningf("failed to verify if obc should watch the operator namespace or all of them, watching all")
-	} else {
-		if strings.EqualFold(obcWatchOnNamespace, "true") {
-			provName = fmt.Sprintf("%s.%s", namespace, bucketProvisionerName)
-		}
+		return false
 	}
-	return provName
+	return strings.EqualFold(obcWatchOnNamespace, "true")
 }
@@ -0,0 +1,285 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	ceph "github.com/rook/rook/pkg/daemon/ceph/client"
+)
+
+const (
+	// defaultPlacement is the placement target of the buckets created without a location constraint
+	defaultPlacement = "default-placement"
+	// standardStorageClass is the storage class of the objects written without a storage class header
+	standardStorageClass = "STANDARD"
+)
+
+// placementPool is a pool of a placement target of the zone of an object store. The storage class is empty for the
+// index, data and extra pools of the placement target itself.
+type placementPool struct {
+	placement    string
+	storageClass string
+	indexPool    string
+	dataPool     string
+	extraPool    string
+	dataPoolSpec cephv1.PoolSpec
+	indexSpec    cephv1.PoolSpec
+}
+
+// zonePlacementPools is the placement part of the output of "radosgw-admin zone get"
+type zonePlacementPools struct {
+	PlacementPools []struct {
+		Key string `json:"key"`
+		Val struct {
+			IndexPool      string `json:"index_pool"`
+			DataExtraPool  string `json:"data_extra_pool"`
+			StorageClasses map[string]struct {
+				DataPool string `json:"data_pool"`
+			} `json:"storage_classes"`
+		} `json:"val"`
+	} `json:"placement_pools"`
+}
+
+// placementPoolPrefix returns the prefix of the names of the pools of a placement target, the pools of the default
+// placement target being the rgw.buckets pools of the store
+func placementPoolPrefix(placement string) string {
+	if placement == defaultPlacement {
+		return "rgw.buckets"
+	}
+	return fmt.Sprintf("rgw.%s", placement)
+}
+
+// storageClassPoolName returns the name of the data pool of a storage class of a placement target
+func storageClassPoolName(placement, storageClass string) string {
+	return fmt.Sprintf("%s.%s.data", placementPoolPrefix(placement), strings.ToLower(storageClass))
+}
+
+// getPlacementPools returns the pools of the storage classes and the placement targets of an object store spec, the
+// pools of each placement target before the pools of its storage classes
+func getPlacementPools(spec cephv1.ObjectStoreSpec) []placementPool {
+	pools := []placementPool{}
+	for _, class := range spec.StorageClasses {
+		pools = append(pools, placementPool{
+			placement:    defaultPlacement,
+			storageClass: class.Name,
+			dataPool:     storageClassPoolName(defaultPlacement, class.Name),
+			dataPoolSpec: class.DataPool,
+		})
+	}
+	for _, target := range spec.PlacementTargets {
+		prefix := placementPoolPrefix(target.Name)
+		pools = append(pools, placementPool{
+			placement:    target.Name,
+			indexPool:    prefix + ".index",
+			dataPool:     prefix + ".data",
+			extraPool:    prefix + ".non-ec",
+			dataPoolSpec: target.DataPool,
+			indexSpec:    target.IndexPool,
+		})
+		for _, class := range target.StorageClasses {
+			pools = append(pools, placementPool{
+				placement:    target.Name,
+				storageClass: class.Name,
+				dataPool:     storageClassPoolName(target.Name, class.Name),
+				dataPoolSpec: class.DataPool,
+			})
+		}
+	}
+	return pools
+}
+
+// getPlacementPoolNames returns the names of the pools of the storage classes and the placement targets of an object
+// store spec, without the name of the store
+func getPlacementPoolNames(spec cephv1.ObjectStoreSpec) []string {
+	names := []string{}
+	for _, pool := range getPlacementPools(spec) {
+		for _, name := range []string{pool.indexPool, pool.extraPool, pool.dataPool} {
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// validatePlacements validates the storage classes and the placement targets of an object store spec
+func validatePlacements(spec cephv1.ObjectStoreSpec) error {
+	if len(spec.StorageClasses) == 0 && len(spec.PlacementTargets) == 0 {
+		return nil
+	}
+	// the placement targets of a zone are shared by the stores of the zone
+	if spec.IsMultisite() {
+		return errors.Errorf("the storage classes and placement targets must not be set with zone %q", spec.Zone.Name)
+	}
+	if err := validateStorageClasses(defaultPlacement, spec.StorageClasses); err != nil {
+		return err
+	}
+
+	targets := map[string]bool{defaultPlacement: true}
+	for _, target := range spec.PlacementTargets {
+		if target.Name == "" {
+			return errors.New("missing name of placement target")
+		}
+		if strings.ContainsAny(target.Name, " ./") {
+			return errors.Errorf("invalid name of placement target %q", target.Name)
+		}
+		if targets[target.Name] {
+			return errors.Errorf("placement target %q is already defined", target.Name)
+		}
+		targets[target.Name] = true
+		if emptyPool(target.IndexPool) || emptyPool(target.DataPool) {
+			return errors.Errorf("the index and data pools of placement target %q must be set", target.Name)
+		}
+		// the bucket indexes are stored in omap, which erasure coded pools do not support
+		if target.IndexPool.IsErasureCoded() {
+			return errors.Errorf("the index pool of placement target %q must be replicated", target.Name)
+		}
+		if err := validateStorageClasses(target.Name, target.StorageClasses); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateStorageClasses(placement string, classes []cephv1.ObjectStorageClassSpec) error {
+	names := map[string]bool{standardStorageClass: true}
+	for _, class := range classes {
+		if class.Name == "" {
+			return errors.Errorf("missing name of storage class of placement target %q", placement)
+		}
+		if strings.ContainsAny(class.Name, " ./") {
+			return errors.Errorf("invalid name of storage class %q of placement target %q", class.Name, placement)
+		}
+		if names[class.Name] {
+			return errors.Errorf("storage class %q of placement target %q is already defined", class.Name, placement)
+		}
+		names[class.Name] = true
+		if emptyPool(class.DataPool) {
+			return errors.Errorf("the data pool of storage class %q of placement target %q must be set", class.Name, placement)
+		}
+	}
+	return nil
+}
+
+// reconcilePlacements creates the pools of the storage classes and the placement targets of an object store, and
+// adds them to the placement targets of its zonegroup and zone. The placement targets and storage classes removed
+// from the spec are left in the zone since buckets and objects may still be stored in them.
+func reconcilePlacements(context *Context, spec cephv1.ObjectStoreSpec, realmName, zoneGroupName, zoneName string) error {
+	pools := getPlacementPools(spec)
+	if len(pools) == 0 {
+		return nil
+	}
+
+	for _, pool := range pools {
+		if err := createPlacementPools(context, pool); err != nil {
+			return err
+		}
+	}
+
+	realmArg := fmt.Sprintf("--rgw-realm=%s", realmName)
+	zoneGroupArg := fmt.Sprintf("--rgw-zonegroup=%s", zoneGroupName)
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", zoneName)
+	output, err := RunAdminCommandNoRealm(context, "zone", "get", realmArg, zoneGroupArg, zoneArg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get rgw zone %q", zoneName)
+	}
+	var zone zonePlacementPools
+	if err := json.Unmarshal([]byte(output), &zone); err != nil {
+		return errors.Wrapf(err, "failed to parse rgw zone %q", zoneName)
+	}
+
+	updatePeriod := false
+	for _, pool := range pools {
+		if zoneHasPlacementPool(zone, context.Name, pool) {
+			continue
+		}
+		placementArg := fmt.Sprintf("--placement-id=%s", pool.placement)
+		zoneGroupArgs := []string{"zonegroup", "placement", "add", realmArg, zoneGroupArg, placementArg}
+		zoneArgs := []string{"zone", "placement", "add", realmArg, zoneGroupArg, zoneArg, placementArg}
+		if pool.storageClass != "" {
+			storageClassArg := fmt.Sprintf("--storage-class=%s", pool.storageClass)
+			zoneGroupArgs = append(zoneGroupArgs, storageClassArg)
+			zoneArgs = append(zoneArgs, storageClassArg, fmt.Sprintf("--data-pool=%s", poolName(context.Name, pool.dataPool)))
+		} else {
+			zoneArgs = append(zoneArgs,
+				fmt.Sprintf("--index-pool=%s", poolName(context.Name, pool.indexPool)),
+				fmt.Sprintf("--data-pool=%s", poolName(context.Name, pool.dataPool)),
+				fmt.Sprintf("--data-extra-pool=%s", poolName(context.Name, pool.extraPool)))
+		}
+
+		if _, err := RunAdminCommandNoRealm(context, zoneGroupArgs...); err != nil {
+			return errors.Wrapf(err, "failed to add placement target %q storage class %q to rgw zonegroup %q", pool.placement, pool.storageClass, zoneGroupName)
+		}
+		if _, err := RunAdminCommandNoRealm(context, zoneArgs...); err != nil {
+			return errors.Wrapf(err, "failed to add placement target %q storage class %q to rgw zone %q", pool.placement, pool.storageClass, zoneName)
+		}
+		logger.Infof("added placement target %q storage class %q to object store %q", pool.placement, pool.storageClass, context.Name)
+		updatePeriod = true
+	}
+
+	if updatePeriod {
+		if _, err := RunAdminCommandNoRealm(context, "period", "update", "--commit", realmArg, zoneGroupArg, zoneArg); err != nil {
+			return errors.Wrap(err, "failed to update period")
+		}
+	}
+	return nil
+}
+
+// createPlacementPools creates the pools of a placement target or of a storage class
+func createPlacementPools(context *Context, pool placementPool) error {
+	if pool.indexPool != "" {
+		if err := createSimilarPools(context, []string{pool.indexPool, pool.extraPool}, pool.indexSpec, ceph.DefaultPGCount, ""); err != nil {
+			return errors.Wrapf(err, "failed to create index pools of placement target %q", pool.placement)
+		}
+	}
+
+	ecProfileName := ""
+	if pool.dataPoolSpec.IsErasureCoded() {
+		ecProfileName = ceph.GetErasureCodeProfileForPool(poolName(context.Name, pool.dataPool))
+		if err := ceph.CreateErasureCodeProfile(context.Context, context.ClusterName, ecProfileName, pool.dataPoolSpec); err != nil {
+			return errors.Wrapf(err, "failed to create erasure code profile for pool %q", pool.dataPool)
+		}
+	}
+	if err := createSimilarPools(context, []string{pool.dataPool}, pool.dataPoolSpec, ceph.DefaultPGCount, ecProfileName); err != nil {
+		return errors.Wrapf(err, "failed to create data pool of placement target %q storage class %q", pool.placement, pool.storageClass)
+	}
+	return nil
+}
+
+// zoneHasPlacementPool returns whether the placement target or the storage class is in the zone with its pools
+func zoneHasPlacementPool(zone zonePlacementPools, storeName string, pool placementPool) bool {
+	for _, placement := range zone.PlacementPools {
+		if placement.Key != pool.placement {
+			continue
+		}
+		storageClass := pool.storageClass
+		if storageClass == "" {
+			if placement.Val.IndexPool != poolName(storeName, pool.indexPool) || placement.Val.DataExtraPool != poolName(storeName, pool.extraPool) {
+				return false
+			}
+			storageClass = standardStorageClass
+		}
+		class, ok := placement.Val.StorageClasses[storageClass]
+		return ok && class.DataPool == poolName(storeName, pool.dataPool)
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	replicatedPool = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	ecPool         = cephv1.PoolSpec{ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1, Plugin: "jerasure"}}
+)
+
+func TestPlacementPoolNames(t *testing.T) {
+	spec := cephv1.ObjectStoreSpec{
+		StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD", DataPool: ecPool}},
+		PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{
+			Name:           "fast",
+			IndexPool:      replicatedPool,
+			DataPool:       replicatedPool,
+			StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "ARCHIVE", DataPool: ecPool}},
+		}},
+	}
+	assert.Equal(t, []string{"rgw.buckets.cold.data", "rgw.fast.index", "rgw.fast.non-ec", "rgw.fast.data", "rgw.fast.archive.data"}, getPlacementPoolNames(spec))
+	assert.Empty(t, getPlacementPoolNames(cephv1.ObjectStoreSpec{}))
+}
+
+func TestValidatePlacements(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    cephv1.ObjectStoreSpec
+		wantErr bool
+	}{
+		{"none", cephv1.ObjectStoreSpec{}, false},
+		{"storage class", cephv1.ObjectStoreSpec{StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD", DataPool: ecPool}}}, false},
+		{"placement target", cephv1.ObjectStoreSpec{PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{Name: "fast", IndexPool: replicatedPool, DataPool: replicatedPool}}}, false},
+		{"standard class", cephv1.ObjectStoreSpec{StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "STANDARD", DataPool: ecPool}}}, true},
+		{"duplicate class", cephv1.ObjectStoreSpec{StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD", DataPool: ecPool}, {Name: "COLD", DataPool: ecPool}}}, true},
+		{"class without pool", cephv1.ObjectStoreSpec{StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD"}}}, true},
+		{"default placement", cephv1.ObjectStoreSpec{PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{Name: "default-placement", IndexPool: replicatedPool, DataPool: replicatedPool}}}, true},
+		{"invalid target name", cephv1.ObjectStoreSpec{PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{Name: "a.b", IndexPool: replicatedPool, DataPool: replicatedPool}}}, true},
+		{"ec index pool", cephv1.ObjectStoreSpec{PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{Name: "cold", IndexPool: ecPool, DataPool: ecPool}}}, true},
+		{"multisite", cephv1.ObjectStoreSpec{Zone: cephv1.ZoneSpec{Name: "zone-a"}, StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD", DataPool: ecPool}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validatePlacements(tt.spec)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReconcilePlacements(t *testing.T) {
+	zone := `{"placement_pools":[{"key":"default-placement","val":{"index_pool":"store.rgw.buckets.index",
+		"storage_classes":{"STANDARD":{"data_pool":"store.rgw.buckets.data"}},"data_extra_pool":"store.rgw.buckets.non-ec"}}]}`
+	adminCommands := []string{}
+	createdPools := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfileArg string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+				return "", errors.New("pool not found")
+			}
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "create" {
+				createdPools = append(createdPools, args[3])
+			}
+			return "", nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			cmd := []string{}
+			for _, arg := range args {
+				if strings.HasPrefix(arg, "--rgw-") || strings.HasPrefix(arg, "--cluster") || strings.HasPrefix(arg, "--conf") || strings.HasPrefix(arg, "--name") || strings.HasPrefix(arg, "--keyring") {
+					continue
+				}
+				cmd = append(cmd, arg)
+			}
+			adminCommands = append(adminCommands, strings.Join(cmd, " "))
+			if args[0] == "zone" && args[1] == "get" {
+				return zone, nil
+			}
+			return "", nil
+		},
+	}
+	objContext := NewContext(&clusterd.Context{Executor: executor}, "store", "rook-ceph")
+
+	// nothing to do without placement targets
+	assert.NoError(t, reconcilePlacements(objContext, cephv1.ObjectStoreSpec{}, "store", "store", "store"))
+	assert.Empty(t, adminCommands)
+
+	spec := cephv1.ObjectStoreSpec{
+		StorageClasses: []cephv1.ObjectStorageClassSpec{{Name: "COLD", DataPool: ecPool}},
+		PlacementTargets: []cephv1.ObjectPlacementTargetSpec{{
+			Name:      "fast",
+			IndexPool: replicatedPool,
+			DataPool:  replicatedPool,
+		}},
+	}
+	assert.NoError(t, reconcilePlacements(objContext, spec, "store", "store", "store"))
+	assert.Equal(t, []string{"store.rgw.buckets.cold.data", "store.rgw.fast.index", "store.rgw.fast.non-ec", "store.rgw.fast.data"}, createdPools)
+	assert.Equal(t, []string{
+		"zone get",
+		"zonegroup placement add --placement-id=default-placement --storage-class=COLD",
+		"zone placement add --placement-id=default-placement --storage-class=COLD --data-pool=store.rgw.buckets.cold.data",
+		"zonegroup placement add --placement-id=fast",
+		"zone placement add --placement-id=fast --index-pool=store.rgw.fast.index --data-pool=store.rgw.fast.data --data-extra-pool=store.rgw.fast.non-ec",
+		"period update --commit",
+	}, adminCommands)
+
+	// the placement targets already in the zone are not added again
+	zone = `{"placement_pools":[{"key":"default-placement","val":{"index_pool":"store.rgw.buckets.index",
+		"storage_classes":{"STANDARD":{"data_pool":"store.rgw.buckets.data"},"COLD":{"data_pool":"store.rgw.buckets.cold.data"}},"data_extra_pool":"store.rgw.buckets.non-ec"}},
+		{"key":"fast","val":{"index_pool":"store.rgw.fast.index","storage_classes":{"STANDARD":{"data_pool":"store.rgw.fast.data"}},"data_extra_pool":"store.rgw.fast.non-ec"}}]}`
+	adminCommands = []string{}
+	assert.NoError(t, reconcilePlacements(objContext, spec, "store", "store", "store"))
+	assert.Equal(t, []string{"zone get"}, adminCommands)
+}
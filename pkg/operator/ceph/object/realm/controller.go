@@ -19,13 +19,16 @@ package realm
 
 import (
 	"context"
+	"crypto/rand"
 	"fmt"
 	"reflect"
 	"syscall"
+	"time"
 
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -38,6 +41,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/object"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/util/exec"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
@@ -46,8 +50,14 @@ import (
 
 const (
 	controllerName = "ceph-object-realm-controller"
+	// accessKeyLength and secretKeyLength are the lengths of the keys of the system user of a realm, as generated by rgw
+	accessKeyLength = 20
+	secretKeyLength = 40
+	keyChars        = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
 )
 
+var waitForRequeueIfRealmNotReady = reconcile.Result{Requeue: true, RequeueAfter: 10 * time.Second}
+
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
 
 var cephObjectRealmKind = reflect.TypeOf(cephv1.CephObjectRealm{}).Name()
@@ -86,7 +96,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -106,10 +116,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectRealm) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephObjectRealm", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile: %v", err)
+		reconcileLogger.Errorf("failed to reconcile: %v", err)
 	}
 
 	return reconcileResponse, err
@@ -162,11 +174,21 @@ func (r *ReconcileObjectRealm) reconcile(request reconcile.Request) (reconcile.R
 	// Start object reconciliation, updating status for this
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReconcilingStatus)
 
+	// CREATE THE KEYS OF THE SYSTEM USER, copied to the clusters pulling the realm
+	if cephObjectRealm.Spec.Pull.Endpoint == "" {
+		if err := r.createRealmKeys(cephObjectRealm); err != nil {
+			return r.setFailedStatus(request.NamespacedName, "failed to create realm keys", err)
+		}
+	}
+
 	// CREATE/UPDATE CEPH REALM
 	reconcileResponse, err = r.createCephRealm(cephObjectRealm)
 	if err != nil {
 		return r.setFailedStatus(request.NamespacedName, "failed to create ceph realm", err)
 	}
+	if reconcileResponse.Requeue {
+		return reconcileResponse, nil
+	}
 
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
@@ -184,6 +206,9 @@ func (r *ReconcileObjectRealm) createCephRealm(realm *cephv1.CephObjectRealm) (r
 
 	if err != nil {
 		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			if realm.Spec.Pull.Endpoint != "" {
+				return r.pullCephRealm(realm)
+			}
 			logger.Debugf("ceph realm %q not found, running `radosgw-admin realm create`", realm.Name)
 			_, err := object.RunAdminCommandNoRealm(objContext, "realm", "create", realmArg)
 			if err != nil {
@@ -198,6 +223,83 @@ func (r *ReconcileObjectRealm) createCephRealm(realm *cephv1.CephObjectRealm) (r
 	return reconcile.Result{}, nil
 }
 
+// pullCephRealm pulls the realm and its current period from the master zone of the realm in another cluster, with the
+// keys of the system user of the realm copied from that cluster
+func (r *ReconcileObjectRealm) pullCephRealm(realm *cephv1.CephObjectRealm) (reconcile.Result, error) {
+	accessKeyArg, secretKeyArg, err := object.GetRealmKeyArgs(r.context, realm.Name, realm.Namespace)
+	if err != nil {
+		if kerrors.IsNotFound(errors.Cause(err)) {
+			logger.Infof("waiting for the keys secret %q of realm %q to pull the realm", object.GetRealmKeySecretName(realm.Name), realm.Name)
+			return waitForRequeueIfRealmNotReady, nil
+		}
+		return reconcile.Result{}, err
+	}
+
+	logger.Infof("pulling ceph realm %q from %q", realm.Name, realm.Spec.Pull.Endpoint)
+	realmArg := fmt.Sprintf("--rgw-realm=%s", realm.Name)
+	urlArg := fmt.Sprintf("--url=%s", realm.Spec.Pull.Endpoint)
+	objContext := object.NewContext(r.context, realm.Name, realm.Namespace)
+	_, err = object.RunAdminCommandNoRealm(objContext, "realm", "pull", realmArg, urlArg, accessKeyArg, secretKeyArg)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to pull ceph realm %q from %q", realm.Name, realm.Spec.Pull.Endpoint)
+	}
+	logger.Infof("pulled ceph realm %q", realm.Name)
+	return reconcile.Result{}, nil
+}
+
+// createRealmKeys creates the secret with the keys of the system user of the realm, unless it already exists since
+// the zones of the realm in the other clusters use the same keys
+func (r *ReconcileObjectRealm) createRealmKeys(realm *cephv1.CephObjectRealm) error {
+	secretName := object.GetRealmKeySecretName(realm.Name)
+	_, err := r.context.Clientset.CoreV1().Secrets(realm.Namespace).Get(secretName, metav1.GetOptions{})
+	if err == nil {
+		return nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get the keys secret %q of realm %q", secretName, realm.Name)
+	}
+
+	accessKey, err := generateKey(accessKeyLength)
+	if err != nil {
+		return err
+	}
+	secretKey, err := generateKey(secretKeyLength)
+	if err != nil {
+		return err
+	}
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      secretName,
+			Namespace: realm.Namespace,
+		},
+		Data: map[string][]byte{
+			object.AccessKeyName: []byte(accessKey),
+			object.SecretKeyName: []byte(secretKey),
+		},
+		Type: k8sutil.RookType,
+	}
+	if err := controllerutil.SetControllerReference(realm, secret, r.scheme); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference for the keys secret %q of realm %q", secretName, realm.Name)
+	}
+	if _, err := r.context.Clientset.CoreV1().Secrets(realm.Namespace).Create(secret); err != nil {
+		return errors.Wrapf(err, "failed to create the keys secret %q of realm %q", secretName, realm.Name)
+	}
+	logger.Infof("created the keys secret %q of realm %q", secretName, realm.Name)
+	return nil
+}
+
+// generateKey returns a random alphanumeric key of the given length
+func generateKey(length int) (string, error) {
+	bytes := make([]byte, length)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", errors.Wrap(err, "failed to generate realm key")
+	}
+	for i, b := range bytes {
+		bytes[i] = keyChars[int(b)%len(keyChars)]
+	}
+	return string(bytes), nil
+}
+
 // validateRealmCR validates the realm arguments
 func validateRealmCR(u *cephv1.CephObjectRealm) error {
 	if u.Name == "" {
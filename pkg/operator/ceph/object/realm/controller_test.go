@@ -0,0 +1,84 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package realm
+
+import (
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCreateRealmKeys(t *testing.T) {
+	c := &clusterd.Context{Clientset: test.New(t, 1)}
+	r := &ReconcileObjectRealm{context: c, scheme: scheme.Scheme}
+	realm := &cephv1.CephObjectRealm{ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "rook-ceph", UID: "uid"}}
+
+	assert.NoError(t, r.createRealmKeys(realm))
+	secret, err := c.Clientset.CoreV1().Secrets("rook-ceph").Get("realm-a-keys", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, accessKeyLength, len(secret.Data[object.AccessKeyName]))
+	assert.Equal(t, secretKeyLength, len(secret.Data[object.SecretKeyName]))
+	assert.Equal(t, "realm-a", secret.OwnerReferences[0].Name)
+
+	// the keys are not generated again
+	assert.NoError(t, r.createRealmKeys(realm))
+	again, err := c.Clientset.CoreV1().Secrets("rook-ceph").Get("realm-a-keys", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, secret.Data, again.Data)
+}
+
+func TestPullCephRealm(t *testing.T) {
+	var pullArgs []string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "realm" && args[1] == "pull" {
+				pullArgs = args[2:6]
+			}
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{Executor: executor, Clientset: test.New(t, 1)}
+	r := &ReconcileObjectRealm{context: c, scheme: scheme.Scheme}
+	realm := &cephv1.CephObjectRealm{ObjectMeta: metav1.ObjectMeta{Name: "realm-a", Namespace: "rook-ceph", UID: "uid"}}
+	realm.Spec.Pull.Endpoint = "http://10.2.105.133:80"
+
+	// the realm is pulled once its keys are copied from the other cluster
+	res, err := r.pullCephRealm(realm)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.Nil(t, pullArgs)
+
+	assert.NoError(t, r.createRealmKeys(realm))
+	secret, err := c.Clientset.CoreV1().Secrets("rook-ceph").Get("realm-a-keys", metav1.GetOptions{})
+	assert.NoError(t, err)
+	res, err = r.pullCephRealm(realm)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, []string{
+		"--rgw-realm=realm-a",
+		"--url=http://10.2.105.133:80",
+		"--access-key=" + string(secret.Data[object.AccessKeyName]),
+		"--secret-key=" + string(secret.Data[object.SecretKeyName]),
+	}, pullArgs)
+}
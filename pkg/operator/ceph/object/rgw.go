@@ -59,6 +59,8 @@ type rgwConfig struct {
 	Realm        string
 	ZoneGroup    string
 	Zone         string
+	// TLSSecretsHash is the hash of the certificate and CA bundle of the gateway, rolling the pods when they change
+	TLSSecretsHash string
 }
 
 const (
@@ -74,6 +76,10 @@ func (c *clusterConfig) createOrUpdateStore(realmName string, zoneGroupName stri
 		return errors.Wrap(err, "failed to start rgw pods")
 	}
 
+	if err := c.reconcileAutoscaler(); err != nil {
+		return errors.Wrap(err, "failed to reconcile the rgw autoscaler")
+	}
+
 	logger.Infof("created object store %q in namespace %q", c.store.Name, c.store.Namespace)
 	return nil
 }
@@ -100,6 +106,11 @@ func (c *clusterConfig) startRGWPods(realmName string, zoneGroupName string, zon
 	}
 	c.ownerRef = ref
 
+	tlsSecretsHash, err := c.tlsSecretsHash()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the tls secrets of object store %q", c.store.Name)
+	}
+
 	// start a new deployment and scale up
 	desiredRgwInstances := int(c.store.Spec.Gateway.Instances)
 	for i := 0; i < desiredRgwInstances; i++ {
@@ -112,11 +123,12 @@ func (c *clusterConfig) startRGWPods(realmName string, zoneGroupName string, zon
 		resourceName := fmt.Sprintf("%s-%s-%s", AppName, c.store.Name, daemonLetterID)
 
 		rgwConfig := &rgwConfig{
-			ResourceName: resourceName,
-			DaemonID:     daemonName,
-			Realm:        realmName,
-			ZoneGroup:    zoneGroupName,
-			Zone:         zoneName,
+			ResourceName:   resourceName,
+			DaemonID:       daemonName,
+			Realm:          realmName,
+			ZoneGroup:      zoneGroupName,
+			Zone:           zoneName,
+			TLSSecretsHash: tlsSecretsHash,
 		}
 
 		// We set the owner reference of the Secret to the Object controller instead of the replicaset
@@ -279,11 +291,13 @@ func (c *clusterConfig) deleteStore() error {
 			}
 		}
 
-		// Delete the realm and pools
-		objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
-		err := deleteRealmAndPools(objContext, c.store.Spec)
-		if err != nil {
-			return errors.Wrap(err, "failed to delete the realm and pools")
+		// Delete the realm and pools, unless they belong to the multisite realm and zone of the store
+		if !c.store.Spec.IsMultisite() {
+			objContext := NewContext(c.context, c.store.Name, c.store.Namespace)
+			err := deleteRealmAndPools(objContext, c.store.Spec)
+			if err != nil {
+				return errors.Wrap(err, "failed to delete the realm and pools")
+			}
 		}
 	}
 
@@ -314,7 +328,12 @@ func instanceName(name string) string {
 }
 
 func (c *clusterConfig) storeLabelSelector() string {
-	return fmt.Sprintf("rook_object_store=%s", c.store.Name)
+	return gatewayLabelSelector(c.store.Name)
+}
+
+// gatewayLabelSelector returns the label selector of the rgw deployments and pods of an object store
+func gatewayLabelSelector(storeName string) string {
+	return fmt.Sprintf("rook_object_store=%s", storeName)
 }
 
 // Validate the object store arguments
@@ -330,6 +349,11 @@ func (r *ReconcileCephObjectStore) validateStore(s *cephv1.CephObjectStore) erro
 		return errors.Errorf("securePort value of %d must be between 0 and 65535", securePort)
 	}
 
+	// The pools of a store in a zone are created by the zone
+	if s.Spec.IsMultisite() && (!emptyPool(s.Spec.MetadataPool) || !emptyPool(s.Spec.DataPool)) {
+		return errors.Errorf("the pools of the object store must not be set with zone %q, they are the pools of the zone", s.Spec.Zone.Name)
+	}
+
 	// Validate the pool settings, but allow for empty pools specs in case they have already been created
 	// such as by the ceph mgr
 	if !emptyPool(s.Spec.MetadataPool) {
@@ -343,6 +367,32 @@ func (r *ReconcileCephObjectStore) validateStore(s *cephv1.CephObjectStore) erro
 		}
 	}
 
+	if err := validatePlacements(s.Spec); err != nil {
+		return err
+	}
+	for _, placementPool := range getPlacementPools(s.Spec) {
+		for _, poolSpec := range []cephv1.PoolSpec{placementPool.indexSpec, placementPool.dataPoolSpec} {
+			if emptyPool(poolSpec) {
+				continue
+			}
+			if err := pool.ValidatePoolSpec(r.context, s.Namespace, &poolSpec); err != nil {
+				return errors.Wrapf(err, "invalid pool spec of placement target %q", placementPool.placement)
+			}
+		}
+	}
+
+	if err := validateHosting(s.Spec.Hosting); err != nil {
+		return err
+	}
+
+	if err := validateKeystone(r.context.Clientset, s.Namespace, s.Spec); err != nil {
+		return err
+	}
+
+	if err := validateAutoscale(s.Spec.Gateway); err != nil {
+		return err
+	}
+
 	// Fail if we detected an external CephCluster CR and the list of endpoints is empty
 	if r.cephClusterSpec.External.Enable {
 		if len(s.Spec.Gateway.ExternalRgwEndpoints) == 0 {
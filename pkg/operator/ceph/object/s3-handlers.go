@@ -36,6 +36,22 @@ type S3Agent struct {
 }
 
 func NewS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
+	return newS3Agent(accessKey, secretKey, endpoint, true, &http.Client{
+		Timeout: time.Second * 15,
+	})
+}
+
+// NewTLSS3Agent returns an s3 agent connecting over https to the endpoint, trusting the PEM certificates in addition to
+// the system ones
+func NewTLSS3Agent(accessKey, secretKey, endpoint string, caCerts []byte) (*S3Agent, error) {
+	httpClient, err := newTLSHTTPClient(time.Second*15, caCerts)
+	if err != nil {
+		return nil, err
+	}
+	return newS3Agent(accessKey, secretKey, endpoint, false, httpClient)
+}
+
+func newS3Agent(accessKey, secretKey, endpoint string, disableSSL bool, httpClient *http.Client) (*S3Agent, error) {
 	const cephRegion = "us-east-1"
 
 	sess, err := session.NewSession(
@@ -45,10 +61,8 @@ func NewS3Agent(accessKey, secretKey, endpoint string) (*S3Agent, error) {
 			WithEndpoint(endpoint).
 			WithS3ForcePathStyle(true).
 			WithMaxRetries(20).
-			WithDisableSSL(true).
-			WithHTTPClient(&http.Client{
-				Timeout: time.Second * 15,
-			}),
+			WithDisableSSL(disableSSL).
+			WithHTTPClient(httpClient),
 	)
 	if err != nil {
 		return nil, err
@@ -154,3 +168,26 @@ func (s *S3Agent) DeleteObjectInBucket(bucketname string, key string) (bool, err
 	}
 	return true, nil
 }
+
+// PutBucketLifecycle replaces the lifecycle rules of the bucket
+func (s *S3Agent) PutBucketLifecycle(bucketname string, rules []*s3.LifecycleRule) error {
+	_, err := s.Client.PutBucketLifecycleConfiguration(&s3.PutBucketLifecycleConfigurationInput{
+		Bucket:                 aws.String(bucketname),
+		LifecycleConfiguration: &s3.BucketLifecycleConfiguration{Rules: rules},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the lifecycle of bucket %q", bucketname)
+	}
+	return nil
+}
+
+// DeleteBucketLifecycle removes all the lifecycle rules of the bucket
+func (s *S3Agent) DeleteBucketLifecycle(bucketname string) error {
+	_, err := s.Client.DeleteBucketLifecycle(&s3.DeleteBucketLifecycleInput{
+		Bucket: aws.String(bucketname),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete the lifecycle of bucket %q", bucketname)
+	}
+	return nil
+}
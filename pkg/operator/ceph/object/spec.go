@@ -63,6 +63,15 @@ func (c *clusterConfig) createDeployment(rgwConfig *rgwConfig) *apps.Deployment
 	return d
 }
 
+// priorityClassName returns the priority class of the rgw pods, the priority class of the gateway overriding the one
+// of the rgw of the cluster
+func (c *clusterConfig) priorityClassName() string {
+	if c.store.Spec.Gateway.PriorityClassName != "" {
+		return c.store.Spec.Gateway.PriorityClassName
+	}
+	return cephv1.GetRGWPriorityClassName(c.clusterSpec.PriorityClassNames)
+}
+
 func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec {
 	podSpec := v1.PodSpec{
 		InitContainers: []v1.Container{
@@ -76,11 +85,17 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 			controller.DaemonVolumes(c.DataPathMap, rgwConfig.ResourceName),
 			c.mimeTypesVolume(),
 		),
-		HostNetwork:       c.clusterSpec.Network.IsHost(),
-		PriorityClassName: c.store.Spec.Gateway.PriorityClassName,
+		HostNetwork:       c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw),
+		PriorityClassName: c.priorityClassName(),
 	}
+	if c.clusterSpec.LogCollector.Enabled {
+		podSpec.Containers = append(podSpec.Containers,
+			controller.LogCollectorContainer(generateCephXUser(rgwConfig.ResourceName), c.clusterSpec.CephVersion.Image, c.clusterSpec.LogCollector, mon.PodSecurityContext()))
+	}
+
 	// Replace default unreachable node toleration
 	k8sutil.AddUnreachableNodeToleration(&podSpec)
+	k8sutil.ApplyImagePullSecrets(c.store.Namespace, &podSpec)
 
 	// Set the ssl cert if specified
 	if c.store.Spec.Gateway.SSLCertificateRef != "" {
@@ -100,9 +115,25 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 		podSpec.Volumes = append(podSpec.Volumes, certVol)
 	}
 
+	// Set the CA bundle trusted by rgw if specified
+	if c.store.Spec.Gateway.CaBundleRef != "" {
+		userReadOnly := int32(0444)
+		caBundleVol := v1.Volume{
+			Name: caBundleVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName: c.store.Spec.Gateway.CaBundleRef,
+					Items: []v1.KeyToPath{
+						{Key: caBundleKeyName, Path: caBundleKeyName, Mode: &userReadOnly},
+					}}}}
+		podSpec.Volumes = append(podSpec.Volumes, caBundleVol)
+	}
+
+	controller.ApplyDaemonOverrides(&podSpec, c.clusterSpec.Env, c.clusterSpec.VolumeMounts, cephv1.KeyRgw)
+
 	// If host networking is not enabled, preferred pod anti-affinity is added to the rgw daemons
 	preferredDuringScheduling := true
-	k8sutil.SetNodeAntiAffinityForPod(&podSpec, c.store.Spec.Gateway.Placement, c.clusterSpec.Network.IsHost(), preferredDuringScheduling, getLabels(c.store.Name, c.store.Namespace),
+	k8sutil.SetNodeAntiAffinityForPod(&podSpec, c.store.Spec.Gateway.Placement, c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw), preferredDuringScheduling, getLabels(c.store.Name, c.store.Namespace),
 		nil)
 
 	podTemplateSpec := v1.PodTemplateSpec{
@@ -113,11 +144,17 @@ func (c *clusterConfig) makeRGWPodSpec(rgwConfig *rgwConfig) v1.PodTemplateSpec
 		Spec: podSpec,
 	}
 	c.store.Spec.Gateway.Annotations.ApplyToObjectMeta(&podTemplateSpec.ObjectMeta)
+	if rgwConfig.TLSSecretsHash != "" {
+		if podTemplateSpec.ObjectMeta.Annotations == nil {
+			podTemplateSpec.ObjectMeta.Annotations = map[string]string{}
+		}
+		podTemplateSpec.ObjectMeta.Annotations[tlsSecretsHashAnnotation] = rgwConfig.TLSSecretsHash
+	}
 
-	if c.clusterSpec.Network.IsHost() {
+	if c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw) {
 		podTemplateSpec.Spec.DNSPolicy = v1.DNSClusterFirstWithHostNet
 	} else if c.clusterSpec.Network.IsMultus() {
-		k8sutil.ApplyMultus(c.Network.NetworkSpec, &podTemplateSpec.ObjectMeta)
+		cephconfig.ApplyPublicNetwork(c.clusterSpec.Network.NetworkSpec, &podTemplateSpec.ObjectMeta)
 	}
 
 	return podTemplateSpec
@@ -152,12 +189,13 @@ func (c *clusterConfig) makeDaemonContainer(rgwConfig *rgwConfig) v1.Container {
 				cephconfig.NewFlag("rgw zonegroup", rgwConfig.ZoneGroup),
 				cephconfig.NewFlag("rgw zone", rgwConfig.Zone),
 			),
+			append(c.keystoneFlags(), c.hostingFlags()...)...,
 		),
 		VolumeMounts: append(
 			controller.DaemonVolumeMounts(c.DataPathMap, rgwConfig.ResourceName),
 			c.mimeTypesVolumeMount(),
 		),
-		Env:             controller.DaemonEnvVars(c.clusterSpec.CephVersion.Image),
+		Env:             append(controller.DaemonEnvVars(c.clusterSpec.CephVersion.Image), c.keystoneEnvVars()...),
 		Resources:       c.store.Spec.Gateway.Resources,
 		LivenessProbe:   c.generateLiveProbe(),
 		SecurityContext: mon.PodSecurityContext(),
@@ -169,6 +207,15 @@ func (c *clusterConfig) makeDaemonContainer(rgwConfig *rgwConfig) v1.Container {
 		container.VolumeMounts = append(container.VolumeMounts, mount)
 	}
 
+	if c.store.Spec.Gateway.CaBundleRef != "" {
+		// Replace the system CA bundle of the image by the one of the secret
+		mount := v1.VolumeMount{Name: caBundleVolumeName, MountPath: caBundleMountPath, SubPath: caBundleKeyName, ReadOnly: true}
+		container.VolumeMounts = append(container.VolumeMounts, mount)
+	}
+
+	// Apply the liveness probe settings of the cluster
+	container = cephconfig.ConfigureLivenessProbe(cephv1.KeyRgw, container, c.clusterSpec.HealthCheck)
+
 	return container
 }
 
@@ -191,7 +238,7 @@ func (c *clusterConfig) generateLiveProbeScheme() v1.URIScheme {
 
 	// If rgw is configured to use a secured port we need get on https://
 	// Only do this when the Non-SSL port is not used
-	if c.store.Spec.Gateway.Port == 0 && c.store.Spec.Gateway.SecurePort != 0 && c.store.Spec.Gateway.SSLCertificateRef != "" {
+	if c.store.Spec.Gateway.Port == 0 && tlsEnabled(&c.store.Spec.Gateway) {
 		uriScheme = v1.URISchemeHTTPS
 	}
 
@@ -204,8 +251,8 @@ func (c *clusterConfig) generateLiveProbePort() intstr.IntOrString {
 	port := intstr.FromInt(int(rgwPortInternalPort))
 
 	// If Host Networking is enabled, the port from the spec must be reflected
-	if c.clusterSpec.Network.IsHost() {
-		if c.store.Spec.Gateway.Port == 0 && c.store.Spec.Gateway.SecurePort != 0 && c.store.Spec.Gateway.SSLCertificateRef != "" {
+	if c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw) {
+		if c.store.Spec.Gateway.Port == 0 && tlsEnabled(&c.store.Spec.Gateway) {
 			port = intstr.FromInt(int(c.store.Spec.Gateway.SecurePort))
 		} else {
 			port = intstr.FromInt(int(c.store.Spec.Gateway.Port))
@@ -228,7 +275,10 @@ func (c *clusterConfig) generateService(cephObjectStore *cephv1.CephObjectStore)
 		},
 	}
 
-	if c.clusterSpec.Network.IsHost() {
+	controller.ApplyServiceSpec(svc, cephObjectStore.Spec.Gateway.Service)
+
+	// the gateways on the host network are reached directly unless the service exposes them outside of the cluster
+	if c.clusterSpec.Network.IsHostFor(cephv1.KeyRgw) && (svc.Spec.Type == "" || svc.Spec.Type == v1.ServiceTypeClusterIP) {
 		svc.Spec.ClusterIP = v1.ClusterIPNone
 	}
 
@@ -292,6 +342,15 @@ func (c *clusterConfig) reconcileService(cephObjectStore *cephv1.CephObjectStore
 		return "", errors.Wrap(err, "failed to set owner reference to ceph object store service")
 	}
 
+	// the cluster IP is immutable, the service is recreated when it changes from or to a headless service
+	existing, err := c.context.Clientset.CoreV1().Services(cephObjectStore.Namespace).Get(service.Name, metav1.GetOptions{})
+	if err == nil && (existing.Spec.ClusterIP == v1.ClusterIPNone) != (service.Spec.ClusterIP == v1.ClusterIPNone) {
+		logger.Infof("recreating object store %q service with type %q", cephObjectStore.Name, service.Spec.Type)
+		if err := k8sutil.DeleteService(c.context.Clientset, cephObjectStore.Namespace, service.Name); err != nil {
+			return "", errors.Wrapf(err, "failed to delete object store %q service", cephObjectStore.Name)
+		}
+	}
+
 	svc, err := k8sutil.CreateOrUpdateService(c.context.Clientset, cephObjectStore.Namespace, service)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to create or update object store %q service", cephObjectStore.Name)
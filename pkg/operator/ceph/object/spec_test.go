@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	cephtest "github.com/rook/rook/pkg/operator/ceph/test"
@@ -131,6 +132,24 @@ func TestSSLPodSpec(t *testing.T) {
 
 }
 
+func TestPriorityClassName(t *testing.T) {
+	store := simpleStore()
+	c := &clusterConfig{
+		store: store,
+		clusterSpec: &cephv1.ClusterSpec{
+			PriorityClassNames: rookv1.PriorityClassNamesSpec{rookv1.KeyAll: "all-priority-class"},
+		},
+	}
+	assert.Equal(t, "all-priority-class", c.priorityClassName())
+
+	c.clusterSpec.PriorityClassNames[cephv1.KeyRgw] = "rgw-priority-class"
+	assert.Equal(t, "rgw-priority-class", c.priorityClassName())
+
+	// the priority class of the gateway overrides the one of the cluster
+	store.Spec.Gateway.PriorityClassName = "my-priority-class"
+	assert.Equal(t, "my-priority-class", c.priorityClassName())
+}
+
 func TestValidateSpec(t *testing.T) {
 	context := &clusterd.Context{Executor: &exectest.MockExecutor{}}
 	r := &ReconcileCephObjectStore{
@@ -233,3 +252,33 @@ func TestGenerateLiveProbe(t *testing.T) {
 	assert.Equal(t, v1.URISchemeHTTP, p.Handler.HTTPGet.Scheme)
 	assert.Equal(t, int32(123), p.Handler.HTTPGet.Port.IntVal)
 }
+
+func TestGenerateService(t *testing.T) {
+	store := simpleStore()
+	store.Spec.Gateway.Port = 80
+	c := &clusterConfig{
+		store: store,
+		clusterSpec: &cephv1.ClusterSpec{
+			Network: cephv1.NetworkSpec{
+				HostNetwork: true,
+			},
+		},
+	}
+
+	// the gateways on the host network have a headless service
+	svc := c.generateService(store)
+	assert.Equal(t, v1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Equal(t, v1.ServiceType(""), svc.Spec.Type)
+
+	// the service exposing the gateways outside of the cluster is not headless
+	store.Spec.Gateway.Service = &cephv1.ServiceSpec{
+		Type:                     v1.ServiceTypeLoadBalancer,
+		Annotations:              map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"},
+		LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+	}
+	svc = c.generateService(store)
+	assert.Equal(t, "", svc.Spec.ClusterIP)
+	assert.Equal(t, v1.ServiceTypeLoadBalancer, svc.Spec.Type)
+	assert.Equal(t, "true", svc.Annotations["service.beta.kubernetes.io/aws-load-balancer-internal"])
+	assert.Equal(t, []string{"10.0.0.0/8"}, svc.Spec.LoadBalancerSourceRanges)
+}
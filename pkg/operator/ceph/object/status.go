@@ -18,11 +18,16 @@ package object
 
 import (
 	"context"
+	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -50,6 +55,11 @@ func updateStatus(client client.Client, namespacedName types.NamespacedName, sta
 
 	objectStore.Status.Phase = status
 	objectStore.Status.Info = info
+	// the autoscaler reads the instances and the pods of the gateways from the status
+	objectStore.Status.Selector = gatewayLabelSelector(objectStore.Name)
+	if status == cephv1.ConditionReady {
+		objectStore.Status.Instances = objectStore.Spec.Gateway.Instances
+	}
 
 	if err := opcontroller.UpdateStatus(client, objectStore); err != nil {
 		logger.Errorf("failed to set object store %q status to %q. %v", namespacedName, status, err)
@@ -79,6 +89,66 @@ func updateStatusBucket(client client.Client, name types.NamespacedName, phase c
 	logger.Debugf("object store %q status updated to %v", name, phase)
 }
 
+// reconcileEndpoints reports the external endpoints of the gateways in the status of the store. It returns whether
+// the load balancer of the service is still being provisioned.
+func (r *ReconcileCephObjectStore) reconcileEndpoints(cephObjectStore *cephv1.CephObjectStore, name types.NamespacedName) bool {
+	service, err := r.context.Clientset.CoreV1().Services(cephObjectStore.Namespace).Get(instanceName(cephObjectStore.Name), metav1.GetOptions{})
+	if err != nil {
+		logger.Warningf("failed to get object store %q service. %v", cephObjectStore.Name, err)
+		return false
+	}
+	endpoints := externalEndpoints(cephObjectStore, service)
+	updateStatusEndpoints(r.client, name, endpoints)
+	return service.Spec.Type == v1.ServiceTypeLoadBalancer && len(endpoints) == 0
+}
+
+// externalEndpoints returns the urls of the gateways on the addresses of the load balancer of the service
+func externalEndpoints(cephObjectStore *cephv1.CephObjectStore, service *v1.Service) []string {
+	endpoints := []string{}
+	for _, address := range k8sutil.LoadBalancerAddresses(service) {
+		if strings.Contains(address, ":") {
+			address = "[" + address + "]"
+		}
+		if cephObjectStore.Spec.Gateway.Port != 0 {
+			endpoints = append(endpoints, buildDNSEndpoint(address, cephObjectStore.Spec.Gateway.Port, false))
+		}
+		if cephObjectStore.Spec.Gateway.SecurePort != 0 {
+			endpoints = append(endpoints, buildDNSEndpoint(address, cephObjectStore.Spec.Gateway.SecurePort, true))
+		}
+	}
+	return endpoints
+}
+
+// updateStatusEndpoints updates the external endpoints of an object store, the status being only updated when they
+// changed
+func updateStatusEndpoints(client client.Client, name types.NamespacedName, endpoints []string) {
+	objectStore := &cephv1.CephObjectStore{}
+	if err := client.Get(context.TODO(), name, objectStore); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephObjectStore resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve object store %q to update its endpoints. %v", name, err)
+		return
+	}
+	if objectStore.Status == nil {
+		objectStore.Status = &cephv1.ObjectStoreStatus{}
+	}
+	if len(endpoints) == 0 {
+		endpoints = nil
+	}
+	if reflect.DeepEqual(endpoints, objectStore.Status.Endpoints) {
+		return
+	}
+
+	objectStore.Status.Endpoints = endpoints
+	if err := opcontroller.UpdateStatus(client, objectStore); err != nil {
+		logger.Errorf("failed to set object store %q endpoints. %v", name, err)
+		return
+	}
+	logger.Infof("object store %q endpoints updated to %v", name, endpoints)
+}
+
 func buildStatusInfo(cephObjectStore *cephv1.CephObjectStore) map[string]string {
 	m := make(map[string]string)
 	m["endpoint"] = buildDNSEndpoint(BuildDomainName(cephObjectStore.Name, cephObjectStore.Namespace), cephObjectStore.Spec.Gateway.Port, false)
@@ -89,3 +159,12 @@ func buildStatusInfo(cephObjectStore *cephv1.CephObjectStore) map[string]string
 
 	return m
 }
+
+// adminOpsEndpoint returns the endpoint of the admin ops API of the object store, the secure one if it has no insecure port
+func adminOpsEndpoint(cephObjectStore *cephv1.CephObjectStore) string {
+	info := buildStatusInfo(cephObjectStore)
+	if cephObjectStore.Spec.Gateway.Port == 0 && info["secureEndpoint"] != "" {
+		return info["secureEndpoint"]
+	}
+	return info["endpoint"]
+}
@@ -21,6 +21,7 @@ import (
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -37,3 +38,22 @@ func TestBuildStatusInfo(t *testing.T) {
 	assert.NotEmpty(t, statusInfo["endpoint"])
 	assert.Equal(t, "http://rook-ceph-rgw-my-store.rook-ceph:80", statusInfo["endpoint"])
 }
+
+func TestExternalEndpoints(t *testing.T) {
+	cephObjectStore := &cephv1.CephObjectStore{}
+	cephObjectStore.Spec.Gateway.Port = 80
+	cephObjectStore.Spec.Gateway.SecurePort = 443
+	service := &v1.Service{}
+
+	// the gateways are only exposed by the load balancer of the service
+	assert.Equal(t, []string{}, externalEndpoints(cephObjectStore, service))
+	service.Spec.Type = v1.ServiceTypeLoadBalancer
+	assert.Equal(t, []string{}, externalEndpoints(cephObjectStore, service))
+
+	service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.2.3.4"}, {Hostname: "rgw.example.com"}, {IP: "fd00::1"}}
+	assert.Equal(t, []string{
+		"http://1.2.3.4:80", "https://1.2.3.4:443",
+		"http://rgw.example.com:80", "https://rgw.example.com:443",
+		"http://[fd00::1]:80", "https://[fd00::1]:443",
+	}, externalEndpoints(cephObjectStore, service))
+}
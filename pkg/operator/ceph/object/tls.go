@@ -0,0 +1,134 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	caBundleVolumeName = "rook-ceph-rgw-ca-bundle"
+	caBundleKeyName    = "cabundle"
+	// caBundleMountPath is the system CA bundle of the ceph image, trusted by rgw for its outgoing TLS connections
+	caBundleMountPath = "/etc/pki/ca-trust/extracted/pem/tls-ca-bundle.pem"
+	// tlsSecretsHashAnnotation rolls the rgw pods when the certificate or the CA bundle of the gateway changes,
+	// rgw reading them only when starting
+	tlsSecretsHashAnnotation = "rook.io/rgw-tls-secrets-hash"
+)
+
+// tlsEnabled returns whether the gateway serves TLS on its secure port
+func tlsEnabled(gateway *cephv1.GatewaySpec) bool {
+	return gateway.SecurePort != 0 && gateway.SSLCertificateRef != ""
+}
+
+// tlsSecretNames returns the names of the secrets of the certificate and CA bundle of the gateway
+func tlsSecretNames(gateway *cephv1.GatewaySpec) []string {
+	names := []string{}
+	if gateway.SSLCertificateRef != "" {
+		names = append(names, gateway.SSLCertificateRef)
+	}
+	if gateway.CaBundleRef != "" {
+		names = append(names, gateway.CaBundleRef)
+	}
+	return names
+}
+
+// getSecretKey returns the value of the key of the secret, failing if the secret or the key is missing
+func getSecretKey(clientset kubernetes.Interface, namespace, name, key string) ([]byte, error) {
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get secret %q", name)
+	}
+	value, ok := secret.Data[key]
+	if !ok || len(value) == 0 {
+		return nil, errors.Errorf("secret %q has no %q key", name, key)
+	}
+	return value, nil
+}
+
+// tlsSecretsHash returns the hash of the certificate and CA bundle of the gateway, empty if it has none
+func (c *clusterConfig) tlsSecretsHash() (string, error) {
+	gateway := &c.store.Spec.Gateway
+	data := map[string]string{}
+	if gateway.SSLCertificateRef != "" {
+		cert, err := getSecretKey(c.context.Clientset, c.store.Namespace, gateway.SSLCertificateRef, certKeyName)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get the rgw certificate")
+		}
+		data[certKeyName] = string(cert)
+	}
+	if gateway.CaBundleRef != "" {
+		caBundle, err := getSecretKey(c.context.Clientset, c.store.Namespace, gateway.CaBundleRef, caBundleKeyName)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get the rgw CA bundle")
+		}
+		data[caBundleKeyName] = string(caBundle)
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var content string
+	for _, key := range keys {
+		content += key + data[key]
+	}
+	return k8sutil.Hash(content), nil
+}
+
+// GetTLSCACerts returns the PEM certificates the clients of the gateway must trust: the CA bundle if set, otherwise
+// the certificate chain of the gateway itself, the private key it contains being ignored by the clients
+func GetTLSCACerts(clientset kubernetes.Interface, namespace string, gateway *cephv1.GatewaySpec) ([]byte, error) {
+	if gateway.CaBundleRef != "" {
+		return getSecretKey(clientset, namespace, gateway.CaBundleRef, caBundleKeyName)
+	}
+	if gateway.SSLCertificateRef != "" {
+		return getSecretKey(clientset, namespace, gateway.SSLCertificateRef, certKeyName)
+	}
+	return nil, nil
+}
+
+// newTLSHTTPClient returns an http client trusting the PEM certificates in addition to the system ones
+func newTLSHTTPClient(timeout time.Duration, caCerts []byte) (*http.Client, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if len(caCerts) > 0 && !pool.AppendCertsFromPEM(caCerts) {
+		return nil, errors.New("no valid PEM certificate to trust")
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+		},
+	}, nil
+}
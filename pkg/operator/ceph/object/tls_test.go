@@ -0,0 +1,139 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package object
+
+import (
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTLSSecretsHash(t *testing.T) {
+	clientset := test.New(t, 1)
+	c := &clusterConfig{
+		context: &clusterd.Context{Clientset: clientset},
+		store:   simpleStore(),
+	}
+
+	// No tls
+	hash, err := c.tlsSecretsHash()
+	assert.NoError(t, err)
+	assert.Equal(t, "", hash)
+
+	// Missing certificate secret
+	c.store.Spec.Gateway.SecurePort = 443
+	c.store.Spec.Gateway.SSLCertificateRef = "my-cert"
+	_, err = c.tlsSecretsHash()
+	assert.Error(t, err)
+
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-cert", Namespace: c.store.Namespace},
+		Data:       map[string][]byte{certKeyName: []byte("cert-1")},
+	}
+	_, err = clientset.CoreV1().Secrets(c.store.Namespace).Create(secret)
+	assert.NoError(t, err)
+	hash, err = c.tlsSecretsHash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, "", hash)
+
+	// The hash changes with the certificate
+	secret.Data[certKeyName] = []byte("cert-2")
+	_, err = clientset.CoreV1().Secrets(c.store.Namespace).Update(secret)
+	assert.NoError(t, err)
+	rotatedHash, err := c.tlsSecretsHash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, rotatedHash)
+
+	// The CA bundle secret must have the cabundle key
+	caBundle := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-ca", Namespace: c.store.Namespace},
+		Data:       map[string][]byte{"ca.crt": []byte("ca")},
+	}
+	_, err = clientset.CoreV1().Secrets(c.store.Namespace).Create(caBundle)
+	assert.NoError(t, err)
+	c.store.Spec.Gateway.CaBundleRef = "my-ca"
+	_, err = c.tlsSecretsHash()
+	assert.Error(t, err)
+
+	caBundle.Data = map[string][]byte{caBundleKeyName: []byte("ca")}
+	_, err = clientset.CoreV1().Secrets(c.store.Namespace).Update(caBundle)
+	assert.NoError(t, err)
+	hash, err = c.tlsSecretsHash()
+	assert.NoError(t, err)
+	assert.NotEqual(t, rotatedHash, hash)
+
+	// The clients trust the CA bundle rather than the certificate
+	caCerts, err := GetTLSCACerts(clientset, c.store.Namespace, &c.store.Spec.Gateway)
+	assert.NoError(t, err)
+	assert.Equal(t, "ca", string(caCerts))
+	c.store.Spec.Gateway.CaBundleRef = ""
+	caCerts, err = GetTLSCACerts(clientset, c.store.Namespace, &c.store.Spec.Gateway)
+	assert.NoError(t, err)
+	assert.Equal(t, "cert-2", string(caCerts))
+}
+
+func TestTLSPodSpec(t *testing.T) {
+	store := simpleStore()
+	store.Spec.Gateway.SecurePort = 443
+	store.Spec.Gateway.SSLCertificateRef = "my-cert"
+	store.Spec.Gateway.CaBundleRef = "my-ca"
+	c := &clusterConfig{
+		clusterInfo: test.CreateConfigDir(1),
+		store:       store,
+		clusterSpec: &cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15"}},
+		DataPathMap: cephconfig.NewStatelessDaemonDataPathMap(cephconfig.RgwType, "default", "rook-ceph", "/var/lib/rook/"),
+	}
+
+	rgwConfig := &rgwConfig{
+		ResourceName:   fmt.Sprintf("%s-%s", AppName, c.store.Name),
+		TLSSecretsHash: "my-hash",
+	}
+	s := c.makeRGWPodSpec(rgwConfig)
+	assert.Equal(t, "my-hash", s.ObjectMeta.Annotations[tlsSecretsHashAnnotation])
+
+	caBundleVolume := false
+	for _, volume := range s.Spec.Volumes {
+		if volume.Name == caBundleVolumeName {
+			caBundleVolume = true
+			assert.Equal(t, "my-ca", volume.Secret.SecretName)
+		}
+	}
+	assert.True(t, caBundleVolume)
+
+	caBundleMount := false
+	for _, mount := range s.Spec.Containers[0].VolumeMounts {
+		if mount.Name == caBundleVolumeName {
+			caBundleMount = true
+			assert.Equal(t, caBundleMountPath, mount.MountPath)
+			assert.Equal(t, caBundleKeyName, mount.SubPath)
+		}
+	}
+	assert.True(t, caBundleMount)
+
+	// No annotation without tls
+	rgwConfig.TLSSecretsHash = ""
+	s = c.makeRGWPodSpec(rgwConfig)
+	_, ok := s.ObjectMeta.Annotations[tlsSecretsHashAnnotation]
+	assert.False(t, ok)
+}
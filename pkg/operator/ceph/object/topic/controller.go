@@ -0,0 +1,271 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package topic to manage the topics of the bucket notifications of an object store.
+package topic
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-bucket-topic-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBucketTopicKind = reflect.TypeOf(cephv1.CephBucketTopic{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBucketTopicKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileBucketTopic reconciles a CephBucketTopic object
+type ReconcileBucketTopic struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+	// newSNSClient returns the client of the topic API of an object store, replaced by the tests
+	newSNSClient func(accessKey, secretKey, endpoint string) (snsiface.SNSAPI, error)
+}
+
+// Add creates a new CephBucketTopic Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileBucketTopic{
+		client:       mgr.GetClient(),
+		scheme:       mgrScheme,
+		context:      context,
+		newSNSClient: newSNSClient,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephBucketTopic CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBucketTopic{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephBucketTopic object and makes changes based on the state read
+// and what is in the CephBucketTopic.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileBucketTopic) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephBucketTopic", request)
+	reconcileLogger.Debug("reconciling")
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileBucketTopic) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephBucketTopic instance
+	topic := &cephv1.CephBucketTopic{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, topic)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBucketTopic resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephBucketTopic")
+	}
+
+	// The CR was just created, initializing status fields
+	if topic.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, nil)
+	}
+
+	// The topic may be in the namespace of an application, its object store in the namespace of the cluster
+	storeName := types.NamespacedName{Name: topic.Spec.ObjectStoreName, Namespace: topic.Spec.ObjectStoreNamespace}
+	if storeName.Namespace == "" {
+		storeName.Namespace = topic.Namespace
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, storeName, controllerName)
+	if !isReadyToReconcile {
+		// The topics are gone with the object store of a deleted CephCluster
+		if !topic.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			// Remove finalizer
+			err = opcontroller.RemoveFinalizer(r.client, topic)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, nil
+		}
+		return reconcileResponse, nil
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, topic)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The topic is created through the endpoint of the object store
+	store := &cephv1.CephObjectStore{}
+	err = r.client.Get(context.TODO(), storeName, store)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephObjectStore %q", storeName.String())
+	}
+	endpoint := store.Status.Info["endpoint"]
+	if endpoint == "" {
+		if !topic.GetDeletionTimestamp().IsZero() {
+			// The topics are gone with the object store
+			err = opcontroller.RemoveFinalizer(r.client, topic)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, nil
+		}
+		logger.Debugf("CephObjectStore %q not ready for topic %q, retrying in %q", storeName.String(), request.NamespacedName.String(), opcontroller.WaitForRequeueIfCephClusterNotReady.RequeueAfter.String())
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+	}
+
+	objContext := object.NewContext(r.context, storeName.Name, storeName.Namespace)
+	// Set the cephx external username if the CephCluster is external
+	if cephCluster.Spec.External.Enable {
+		objContext.RunAsUser = mon.PopulateExternalClusterInfo(r.context, storeName.Namespace).ExternalCred.Username
+	}
+	accessKey, secretKey, err := getTopicUser(objContext)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get the user creating topic %q", request.NamespacedName.String())
+	}
+	snsClient, err := r.newSNSClient(accessKey, secretKey, endpoint)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to initialize the topic client")
+	}
+
+	// DELETE: the CR was deleted
+	if !topic.GetDeletionTimestamp().IsZero() {
+		if topic.Status != nil && topic.Status.ARN != nil {
+			logger.Infof("deleting topic %q", request.NamespacedName.String())
+			if err := deleteTopic(snsClient, *topic.Status.ARN); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.client, topic)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, nil
+	}
+
+	// validate the topic settings
+	err = validateTopic(topic)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "invalid topic CR %q spec", topic.Name)
+	}
+
+	// CREATE/UPDATE TOPIC
+	arn, err := createTopic(snsClient, topic)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, err
+	}
+	logger.Infof("created topic %q with arn %q", request.NamespacedName.String(), arn)
+
+	// Set Ready status, we are done reconciling
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, &arn)
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// updateStatus updates a topic with a given status, and its arn if known
+func updateStatus(client client.Client, name types.NamespacedName, status string, arn *string) {
+	topic := &cephv1.CephBucketTopic{}
+	if err := client.Get(context.TODO(), name, topic); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBucketTopic resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve topic %q to update status to %q. %v", name, status, err)
+		return
+	}
+	if topic.Status == nil {
+		topic.Status = &cephv1.BucketTopicStatus{}
+	}
+
+	topic.Status.Phase = status
+	if arn != nil {
+		topic.Status.ARN = arn
+	}
+	if err := opcontroller.UpdateStatus(client, topic); err != nil {
+		logger.Errorf("failed to set topic %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("topic %q status updated to %q", name, status)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topic
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+)
+
+const (
+	// topicUserName is the object store user creating the topics, the topics being visible to all the users of its tenant
+	topicUserName = "rook-ceph-internal-topic-user"
+	cephRegion    = "us-east-1"
+)
+
+// newSNSClient returns a client of the topic API of the rgw endpoint
+func newSNSClient(accessKey, secretKey, endpoint string) (snsiface.SNSAPI, error) {
+	sess, err := session.NewSession(
+		aws.NewConfig().
+			WithRegion(cephRegion).
+			WithCredentials(credentials.NewStaticCredentials(accessKey, secretKey, "")).
+			WithEndpoint(endpoint).
+			WithMaxRetries(5).
+			WithDisableSSL(true).
+			WithHTTPClient(&http.Client{
+				Timeout: time.Second * 15,
+			}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return sns.New(sess), nil
+}
+
+// getTopicUser returns the keys of the user creating the topics in the object store, creating the user if needed
+func getTopicUser(objContext *object.Context) (string, string, error) {
+	displayName := topicUserName
+	user, rgwerr, err := object.CreateUser(objContext, object.ObjectUser{UserID: topicUserName, DisplayName: &displayName})
+	if err != nil {
+		if rgwerr != object.ErrorCodeFileExists {
+			return "", "", errors.Wrapf(err, "failed to create object user %q. error code %d", topicUserName, rgwerr)
+		}
+		user, _, err = object.GetUser(objContext, topicUserName)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "failed to get object user %q", topicUserName)
+		}
+	}
+	if user.AccessKey == nil || user.SecretKey == nil {
+		return "", "", errors.Errorf("object user %q has no keys", topicUserName)
+	}
+	return *user.AccessKey, *user.SecretKey, nil
+}
+
+// validateTopic validates the spec of the topic
+func validateTopic(t *cephv1.CephBucketTopic) error {
+	if t.Spec.ObjectStoreName == "" {
+		return errors.New("missing object store name")
+	}
+
+	endpoints := 0
+	endpoint := t.Spec.Endpoint
+	if endpoint.HTTP != nil {
+		endpoints++
+		if endpoint.HTTP.URI == "" {
+			return errors.New("missing uri of the http endpoint")
+		}
+	}
+	if endpoint.AMQP != nil {
+		endpoints++
+		if endpoint.AMQP.URI == "" || endpoint.AMQP.Exchange == "" {
+			return errors.New("missing uri or exchange of the amqp endpoint")
+		}
+		switch endpoint.AMQP.AckLevel {
+		case "", "none", "broker", "routable":
+		default:
+			return errors.Errorf("invalid ack level %q of the amqp endpoint. only 'none', 'broker' and 'routable' are supported", endpoint.AMQP.AckLevel)
+		}
+	}
+	if endpoint.Kafka != nil {
+		endpoints++
+		if endpoint.Kafka.URI == "" {
+			return errors.New("missing uri of the kafka endpoint")
+		}
+		switch endpoint.Kafka.AckLevel {
+		case "", "none", "broker":
+		default:
+			return errors.Errorf("invalid ack level %q of the kafka endpoint. only 'none' and 'broker' are supported", endpoint.Kafka.AckLevel)
+		}
+	}
+	if endpoints != 1 {
+		return errors.Errorf("exactly one endpoint of the topic must be set, found %d", endpoints)
+	}
+	return nil
+}
+
+// topicAttributes returns the rgw attributes of the topic
+func topicAttributes(t *cephv1.CephBucketTopic) map[string]*string {
+	attributes := map[string]*string{
+		"persistent": aws.String(strconv.FormatBool(t.Spec.Persistent)),
+	}
+	if t.Spec.OpaqueData != "" {
+		attributes["OpaqueData"] = aws.String(t.Spec.OpaqueData)
+	}
+
+	endpoint := t.Spec.Endpoint
+	switch {
+	case endpoint.HTTP != nil:
+		attributes["push-endpoint"] = aws.String(endpoint.HTTP.URI)
+		attributes["verify-ssl"] = aws.String(strconv.FormatBool(!endpoint.HTTP.DisableVerifySSL))
+	case endpoint.AMQP != nil:
+		attributes["push-endpoint"] = aws.String(endpoint.AMQP.URI)
+		attributes["verify-ssl"] = aws.String(strconv.FormatBool(!endpoint.AMQP.DisableVerifySSL))
+		attributes["amqp-exchange"] = aws.String(endpoint.AMQP.Exchange)
+		if endpoint.AMQP.AckLevel != "" {
+			attributes["amqp-ack-level"] = aws.String(endpoint.AMQP.AckLevel)
+		}
+	case endpoint.Kafka != nil:
+		attributes["push-endpoint"] = aws.String(endpoint.Kafka.URI)
+		attributes["verify-ssl"] = aws.String(strconv.FormatBool(!endpoint.Kafka.DisableVerifySSL))
+		attributes["use-ssl"] = aws.String(strconv.FormatBool(endpoint.Kafka.UseSSL))
+		if endpoint.Kafka.AckLevel != "" {
+			attributes["kafka-ack-level"] = aws.String(endpoint.Kafka.AckLevel)
+		}
+	}
+	return attributes
+}
+
+// createTopic creates the topic, or updates its attributes if it exists, and returns its arn
+func createTopic(client snsiface.SNSAPI, t *cephv1.CephBucketTopic) (string, error) {
+	output, err := client.CreateTopic(&sns.CreateTopicInput{
+		Name:       aws.String(t.Name),
+		Attributes: topicAttributes(t),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create topic %q", t.Name)
+	}
+	if output.TopicArn == nil {
+		return "", errors.Errorf("no arn returned for topic %q", t.Name)
+	}
+	return *output.TopicArn, nil
+}
+
+// deleteTopic deletes the topic of the arn
+func deleteTopic(client snsiface.SNSAPI, arn string) error {
+	if _, err := client.DeleteTopic(&sns.DeleteTopicInput{TopicArn: aws.String(arn)}); err != nil {
+		return errors.Wrapf(err, "failed to delete topic %q", arn)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package topic
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeSNSClient struct {
+	snsiface.SNSAPI
+	created map[string]map[string]*string
+	deleted []string
+}
+
+func (c *fakeSNSClient) CreateTopic(input *sns.CreateTopicInput) (*sns.CreateTopicOutput, error) {
+	c.created[*input.Name] = input.Attributes
+	return &sns.CreateTopicOutput{TopicArn: aws.String("arn:aws:sns:my-store::" + *input.Name)}, nil
+}
+
+func (c *fakeSNSClient) DeleteTopic(input *sns.DeleteTopicInput) (*sns.DeleteTopicOutput, error) {
+	c.deleted = append(c.deleted, *input.TopicArn)
+	return &sns.DeleteTopicOutput{}, nil
+}
+
+func TestValidateTopic(t *testing.T) {
+	topic := &cephv1.CephBucketTopic{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-topic", Namespace: "rook-ceph"},
+		Spec: cephv1.BucketTopicSpec{
+			ObjectStoreName: "my-store",
+			Endpoint:        cephv1.TopicEndpointSpec{HTTP: &cephv1.HTTPEndpointSpec{URI: "http://my-server:8080"}},
+		},
+	}
+	assert.NoError(t, validateTopic(topic))
+
+	// the object store is required
+	invalid := topic.DeepCopy()
+	invalid.Spec.ObjectStoreName = ""
+	assert.Error(t, validateTopic(invalid))
+
+	// exactly one endpoint is required
+	invalid = topic.DeepCopy()
+	invalid.Spec.Endpoint.HTTP = nil
+	assert.Error(t, validateTopic(invalid))
+	invalid = topic.DeepCopy()
+	invalid.Spec.Endpoint.Kafka = &cephv1.KafkaEndpointSpec{URI: "kafka://my-broker:9092"}
+	assert.Error(t, validateTopic(invalid))
+
+	// the amqp endpoint requires an exchange and a known ack level
+	amqp := topic.DeepCopy()
+	amqp.Spec.Endpoint.HTTP = nil
+	amqp.Spec.Endpoint.AMQP = &cephv1.AMQPEndpointSpec{URI: "amqp://my-broker:5672"}
+	assert.Error(t, validateTopic(amqp))
+	amqp.Spec.Endpoint.AMQP.Exchange = "ex1"
+	assert.NoError(t, validateTopic(amqp))
+	amqp.Spec.Endpoint.AMQP.AckLevel = "routable"
+	assert.NoError(t, validateTopic(amqp))
+	amqp.Spec.Endpoint.AMQP.AckLevel = "all"
+	assert.Error(t, validateTopic(amqp))
+
+	// the kafka endpoint does not support the routable ack level
+	kafka := topic.DeepCopy()
+	kafka.Spec.Endpoint.HTTP = nil
+	kafka.Spec.Endpoint.Kafka = &cephv1.KafkaEndpointSpec{URI: "kafka://my-broker:9092", AckLevel: "routable"}
+	assert.Error(t, validateTopic(kafka))
+	kafka.Spec.Endpoint.Kafka.AckLevel = "none"
+	assert.NoError(t, validateTopic(kafka))
+}
+
+func TestCreateTopic(t *testing.T) {
+	client := &fakeSNSClient{created: map[string]map[string]*string{}}
+	topic := &cephv1.CephBucketTopic{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-topic", Namespace: "rook-ceph"},
+		Spec: cephv1.BucketTopicSpec{
+			ObjectStoreName: "my-store",
+			OpaqueData:      "my-data",
+			Persistent:      true,
+			Endpoint: cephv1.TopicEndpointSpec{
+				AMQP: &cephv1.AMQPEndpointSpec{URI: "amqps://my-broker:5671", Exchange: "ex1", DisableVerifySSL: true, AckLevel: "none"},
+			},
+		},
+	}
+
+	arn, err := createTopic(client, topic)
+	assert.NoError(t, err)
+	assert.Equal(t, "arn:aws:sns:my-store::my-topic", arn)
+	assert.Equal(t, map[string]*string{
+		"persistent":     aws.String("true"),
+		"OpaqueData":     aws.String("my-data"),
+		"push-endpoint":  aws.String("amqps://my-broker:5671"),
+		"verify-ssl":     aws.String("false"),
+		"amqp-exchange":  aws.String("ex1"),
+		"amqp-ack-level": aws.String("none"),
+	}, client.created["my-topic"])
+
+	topic.Spec = cephv1.BucketTopicSpec{
+		ObjectStoreName: "my-store",
+		Endpoint:        cephv1.TopicEndpointSpec{Kafka: &cephv1.KafkaEndpointSpec{URI: "kafka://my-broker:9093", UseSSL: true}},
+	}
+	_, err = createTopic(client, topic)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*string{
+		"persistent":    aws.String("false"),
+		"push-endpoint": aws.String("kafka://my-broker:9093"),
+		"verify-ssl":    aws.String("true"),
+		"use-ssl":       aws.String("true"),
+	}, client.created["my-topic"])
+
+	assert.NoError(t, deleteTopic(client, arn))
+	assert.Equal(t, []string{arn}, client.deleted)
+}
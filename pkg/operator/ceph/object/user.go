@@ -18,6 +18,7 @@ package object
 
 import (
 	"encoding/json"
+	"sort"
 	"strconv"
 	"strings"
 	"syscall"
@@ -42,6 +43,8 @@ type ObjectUser struct {
 	Email       *string `json:"email"`
 	AccessKey   *string `json:"accessKey"`
 	SecretKey   *string `json:"secretKey"`
+	// Caps is the permission of each capability type of the user, e.g. "users": "read"
+	Caps map[string]string `json:"caps,omitempty"`
 }
 
 // ListUsers lists the object pool users.
@@ -67,6 +70,10 @@ type rgwUserInfo struct {
 		AccessKey string `json:"access_key"`
 		SecretKey string `json:"secret_key"`
 	}
+	Caps []struct {
+		Type string `json:"type"`
+		Perm string `json:"perm"`
+	} `json:"caps"`
 }
 
 func decodeUser(data string) (*ObjectUser, int, error) {
@@ -76,6 +83,10 @@ func decodeUser(data string) (*ObjectUser, int, error) {
 		return nil, RGWErrorParse, errors.Wrapf(err, "failed to unmarshal json. %s", data)
 	}
 
+	return userFromRGW(user), RGWErrorNone, nil
+}
+
+func userFromRGW(user rgwUserInfo) *ObjectUser {
 	rookUser := ObjectUser{UserID: user.UserID, DisplayName: &user.DisplayName, Email: &user.Email}
 
 	if len(user.Keys) > 0 {
@@ -83,12 +94,22 @@ func decodeUser(data string) (*ObjectUser, int, error) {
 		rookUser.SecretKey = &user.Keys[0].SecretKey
 	}
 
-	return &rookUser, RGWErrorNone, nil
+	if len(user.Caps) > 0 {
+		rookUser.Caps = map[string]string{}
+		for _, userCap := range user.Caps {
+			rookUser.Caps[userCap.Type] = userCap.Perm
+		}
+	}
+
+	return &rookUser
 }
 
 // GetUser returns the user with the given ID.
 func GetUser(c *Context, id string) (*ObjectUser, int, error) {
 	logger.Infof("getting s3 user %q", id)
+	if c.AdminOps != nil {
+		return c.AdminOps.getUser(id)
+	}
 
 	// note: err is set for non-existent user but result output is also empty
 	result, err := runAdminCommand(c, "user", "info", "--uid", id)
@@ -113,6 +134,10 @@ func CreateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 		return nil, RGWErrorBadData, errors.New("displayName is required")
 	}
 
+	if c.AdminOps != nil {
+		return c.AdminOps.createUser(user)
+	}
+
 	args := []string{
 		"user",
 		"create",
@@ -168,6 +193,10 @@ func UpdateUser(c *Context, user ObjectUser) (*ObjectUser, int, error) {
 
 // DeleteUser deletes the user with the given ID.
 func DeleteUser(c *Context, id string, opts ...string) (string, error) {
+	if c.AdminOps != nil && opts == nil {
+		return "", c.AdminOps.deleteUser(id)
+	}
+
 	args := []string{"user", "rm", "--uid", id}
 	if opts != nil {
 		args = append(args, opts...)
@@ -185,6 +214,12 @@ func DeleteUser(c *Context, id string, opts ...string) (string, error) {
 
 func SetQuotaUserBucketMax(c *Context, id string, max int) (string, int, error) {
 	logger.Infof("Setting user %q max buckets to %d", id, max)
+	if c.AdminOps != nil {
+		if err := c.AdminOps.setUserMaxBuckets(id, max); err != nil {
+			return "", RGWErrorUnknown, errors.Wrap(err, "failed setting bucket max")
+		}
+		return "", RGWErrorNone, nil
+	}
 	args := []string{"--quota-scope", "user", "--max-buckets", strconv.Itoa(max)}
 	result, errCode, err := setUserQuota(c, id, args)
 	if errCode != RGWErrorNone {
@@ -193,6 +228,81 @@ func SetQuotaUserBucketMax(c *Context, id string, max int) (string, int, error)
 	return result, errCode, err
 }
 
+// SetQuotaUserObjectAndSizeMax sets and enables the max objects and the max size in bytes of the user, -1 removing a limit
+func SetQuotaUserObjectAndSizeMax(c *Context, id string, maxObjects, maxSize int64) (string, int, error) {
+	logger.Infof("Setting user %q max objects to %d and max size to %d", id, maxObjects, maxSize)
+	if c.AdminOps != nil {
+		if err := c.AdminOps.setUserQuota(id, maxObjects, maxSize); err != nil {
+			return "", RGWErrorUnknown, errors.Wrap(err, "failed setting object and size max")
+		}
+		return "", RGWErrorNone, nil
+	}
+	args := []string{"--quota-scope", "user", "--max-objects", strconv.FormatInt(maxObjects, 10), "--max-size", strconv.FormatInt(maxSize, 10)}
+	result, errCode, err := setUserQuota(c, id, args)
+	if err != nil {
+		return result, errCode, errors.Wrap(err, "failed setting object and size max")
+	}
+
+	// the quota is only enforced once enabled
+	result, err = runAdminCommand(c, "quota", "enable", "--quota-scope", "user", "--uid", id)
+	if err != nil {
+		return result, RGWErrorUnknown, errors.Wrap(err, "failed to enable the quota of the user")
+	}
+	return result, RGWErrorNone, nil
+}
+
+// SetUserCaps sets the permission of each capability type of the user, removing the capabilities of the other types
+func SetUserCaps(c *Context, id string, caps map[string]string) (int, error) {
+	user, errCode, err := GetUser(c, id)
+	if err != nil {
+		return errCode, errors.Wrapf(err, "failed to get the capabilities of user %q", id)
+	}
+
+	var removed, added []string
+	for capType, perm := range user.Caps {
+		if caps[capType] != perm {
+			removed = append(removed, capType+"="+perm)
+		}
+	}
+	for capType, perm := range caps {
+		if user.Caps[capType] != perm {
+			added = append(added, capType+"="+perm)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	if len(removed) > 0 {
+		logger.Infof("removing capabilities %q of user %q", removed, id)
+		if err := removeUserCaps(c, id, strings.Join(removed, ";")); err != nil {
+			return RGWErrorUnknown, errors.Wrapf(err, "failed to remove the capabilities of user %q", id)
+		}
+	}
+	if len(added) > 0 {
+		logger.Infof("adding capabilities %q to user %q", added, id)
+		if err := addUserCaps(c, id, strings.Join(added, ";")); err != nil {
+			return RGWErrorUnknown, errors.Wrapf(err, "failed to add the capabilities of user %q", id)
+		}
+	}
+	return RGWErrorNone, nil
+}
+
+func addUserCaps(c *Context, id, caps string) error {
+	if c.AdminOps != nil {
+		return c.AdminOps.addUserCaps(id, caps)
+	}
+	_, err := runAdminCommand(c, "caps", "add", "--uid", id, "--caps", caps)
+	return err
+}
+
+func removeUserCaps(c *Context, id, caps string) error {
+	if c.AdminOps != nil {
+		return c.AdminOps.removeUserCaps(id, caps)
+	}
+	_, err := runAdminCommand(c, "caps", "rm", "--uid", id, "--caps", caps)
+	return err
+}
+
 func setUserQuota(c *Context, id string, args []string) (string, int, error) {
 	args = append([]string{"quota", "set", "--uid", id}, args...)
 	result, err := runAdminCommand(c, args...)
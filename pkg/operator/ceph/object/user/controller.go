@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
@@ -43,6 +44,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -51,6 +53,8 @@ const (
 	appName             = object.AppName
 	controllerName      = "ceph-object-store-user-controller"
 	cephObjectStoreKind = "CephObjectStoreUser"
+	// unlimitedQuota is the quota value removing a limit of the user
+	unlimitedQuota = -1
 )
 
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
@@ -95,7 +99,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -124,10 +128,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectStoreUser) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephObjectStoreUser", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -221,6 +227,12 @@ func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconci
 		r.objContext.RunAsUser = r.clusterInfo.ExternalCred.Username
 	}
 
+	// Manage the user through the admin ops API of the object store if available
+	err = object.InitAdminOpsClient(r.objContext, r.context.Clientset, r.objContext.Endpoint)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to initialize the admin ops client of object store %q", cephObjectStoreUser.Spec.Store)
+	}
+
 	// DELETE: the CR was deleted
 	if !cephObjectStoreUser.GetDeletionTimestamp().IsZero() {
 		logger.Debugf("deleting pool %q", cephObjectStoreUser.Name)
@@ -229,6 +241,12 @@ func (r *ReconcileObjectStoreUser) reconcile(request reconcile.Request) (reconci
 			return reconcile.Result{}, errors.Wrapf(err, "failed to delete ceph object user %q", cephObjectStoreUser.Name)
 		}
 
+		// The secrets in other namespaces are not garbage collected with the user
+		err = r.deleteUserSecrets(cephObjectStoreUser)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to delete the secrets of ceph object user %q", cephObjectStoreUser.Name)
+		}
+
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.client, cephObjectStoreUser)
 		if err != nil {
@@ -274,9 +292,94 @@ func (r *ReconcileObjectStoreUser) reconcileCephUser(cephObjectStoreUser *cephv1
 		return reconcile.Result{}, errors.Wrapf(err, "failed to create object store user %q", cephObjectStoreUser.Name)
 	}
 
+	err = r.reconcileCephUserQuotas(cephObjectStoreUser)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to set quotas of object store user %q", cephObjectStoreUser.Name)
+	}
+
+	err = r.reconcileCephUserCaps(cephObjectStoreUser)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to set capabilities of object store user %q", cephObjectStoreUser.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
+// reconcileCephUserQuotas sets the quotas of the user spec, the ones not set being unlimited
+func (r *ReconcileObjectStoreUser) reconcileCephUserQuotas(u *cephv1.CephObjectStoreUser) error {
+	quotas := u.Spec.Quotas
+	if quotas == nil {
+		// the quotas are left to radosgw-admin
+		return nil
+	}
+
+	if quotas.MaxBuckets != nil {
+		_, _, err := object.SetQuotaUserBucketMax(r.objContext, r.userConfig.UserID, *quotas.MaxBuckets)
+		if err != nil {
+			return err
+		}
+	}
+
+	maxObjects, maxSize := int64(unlimitedQuota), int64(unlimitedQuota)
+	if quotas.MaxObjects != nil {
+		maxObjects = *quotas.MaxObjects
+	}
+	if quotas.MaxSize != nil {
+		// the size was validated with the user spec
+		size := resource.MustParse(*quotas.MaxSize)
+		maxSize = size.Value()
+	}
+	_, _, err := object.SetQuotaUserObjectAndSizeMax(r.objContext, r.userConfig.UserID, maxObjects, maxSize)
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("set quotas of ceph object user %q", u.Name)
+	return nil
+}
+
+// reconcileCephUserCaps sets the capabilities of the user spec, removing the capabilities not in the spec
+func (r *ReconcileObjectStoreUser) reconcileCephUserCaps(u *cephv1.CephObjectStoreUser) error {
+	if u.Spec.Capabilities == nil {
+		// the capabilities are left to radosgw-admin
+		return nil
+	}
+
+	_, err := object.SetUserCaps(r.objContext, r.userConfig.UserID, userCaps(u.Spec.Capabilities))
+	if err != nil {
+		return err
+	}
+
+	logger.Infof("set capabilities of ceph object user %q", u.Name)
+	return nil
+}
+
+// userCaps returns the permission of each rgw capability type of the user spec
+func userCaps(caps *cephv1.ObjectUserCapSpec) map[string]string {
+	result := map[string]string{}
+	for capType, perm := range map[string]string{
+		"users":    caps.User,
+		"buckets":  caps.Bucket,
+		"metadata": caps.MetaData,
+		"usage":    caps.Usage,
+		"zone":     caps.Zone,
+	} {
+		if perm != "" {
+			result[capType] = capPerm(perm)
+		}
+	}
+	return result
+}
+
+// capPerm returns the permission as reported by rgw, which reports the read and write permissions as "*"
+func capPerm(perm string) string {
+	switch strings.ReplaceAll(perm, " ", "") {
+	case "read,write", "write,read", "*":
+		return "*"
+	}
+	return strings.TrimSpace(perm)
+}
+
 func (r *ReconcileObjectStoreUser) createCephUser(u *cephv1.CephObjectStoreUser) error {
 	logger.Infof("creating ceph object user %q in namespace %q", u.Name, u.Namespace)
 	user, rgwerr, err := object.CreateUser(r.objContext, r.userConfig)
@@ -340,17 +443,11 @@ func (r *ReconcileObjectStoreUser) generateCephUserSecret(u *cephv1.CephObjectSt
 		"Endpoint":  r.objContext.Endpoint,
 	}
 
-	secretName := fmt.Sprintf("rook-ceph-object-user-%s-%s", u.Spec.Store, u.Name)
 	secret := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      secretName,
+			Name:      userSecretName(u),
 			Namespace: u.Namespace,
-			Labels: map[string]string{
-				"app":               appName,
-				"user":              u.Name,
-				"rook_cluster":      u.Namespace,
-				"rook_object_store": u.Spec.Store,
-			},
+			Labels:    userSecretLabels(u),
 		},
 		StringData: secrets,
 		Type:       k8sutil.RookType,
@@ -376,6 +473,13 @@ func (r *ReconcileObjectStoreUser) reconcileCephUserSecret(cephObjectStoreUser *
 	}
 
 	logger.Infof("created ceph object user secret %q", secret.Name)
+
+	// Create the secrets of the user spec, e.g. in the namespaces of the applications
+	err = r.reconcileUserSecrets(cephObjectStoreUser)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the secrets of ceph object user %q", cephObjectStoreUser.Name)
+	}
+
 	return reconcile.Result{}, nil
 }
 
@@ -475,6 +579,29 @@ func (r *ReconcileObjectStoreUser) validateUser(u *cephv1.CephObjectStoreUser) e
 			return errors.New("missing store")
 		}
 	}
+	if quotas := u.Spec.Quotas; quotas != nil {
+		if quotas.MaxObjects != nil && *quotas.MaxObjects < 0 {
+			return errors.Errorf("invalid max objects %d, must not be negative", *quotas.MaxObjects)
+		}
+		if quotas.MaxSize != nil {
+			size, err := resource.ParseQuantity(*quotas.MaxSize)
+			if err != nil || size.Sign() < 0 {
+				return errors.Errorf("invalid max size %q, must be a positive quantity such as \"10Gi\"", *quotas.MaxSize)
+			}
+		}
+	}
+	if u.Spec.Capabilities != nil {
+		for capType, perm := range userCaps(u.Spec.Capabilities) {
+			switch perm {
+			case "read", "write", "*":
+			default:
+				return errors.Errorf("invalid permission %q of capability %q. only 'read', 'write', 'read, write' and '*' are supported", perm, capType)
+			}
+		}
+	}
+	if err := validateUserSecrets(u); err != nil {
+		return err
+	}
 	return nil
 }
 
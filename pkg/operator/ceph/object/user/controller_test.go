@@ -28,6 +28,7 @@ import (
 	"github.com/rook/rook/pkg/operator/test"
 
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/object"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
@@ -296,3 +297,77 @@ func TestCephObjectStoreUserController(t *testing.T) {
 	assert.Equal(t, "Ready", objectUser.Status.Phase, objectUser)
 	logger.Info("PHASE 5 DONE")
 }
+
+func TestValidateUserQuotasAndCaps(t *testing.T) {
+	r := &ReconcileObjectStoreUser{cephClusterSpec: &cephv1.ClusterSpec{}}
+	maxObjects := int64(1000)
+	maxSize := "10Gi"
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: cephv1.ObjectStoreUserSpec{
+			Store:        store,
+			Quotas:       &cephv1.ObjectUserQuotaSpec{MaxObjects: &maxObjects, MaxSize: &maxSize},
+			Capabilities: &cephv1.ObjectUserCapSpec{User: "read", Bucket: "read, write", Usage: "*"},
+		},
+	}
+	assert.NoError(t, r.validateUser(u))
+	assert.Equal(t, map[string]string{"users": "read", "buckets": "*", "usage": "*"}, userCaps(u.Spec.Capabilities))
+
+	// Invalid size
+	maxSize = "big"
+	assert.Error(t, r.validateUser(u))
+	maxSize = "10Gi"
+
+	// Invalid permission
+	u.Spec.Capabilities.Zone = "delete"
+	assert.Error(t, r.validateUser(u))
+}
+
+func TestReconcileCephUserQuotasAndCaps(t *testing.T) {
+	var commands [][]string
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			commands = append(commands, args)
+			if args[0] == "user" {
+				// the user has the usage and zone capabilities
+				return `{"user_id": "my-user", "caps": [{"type": "usage", "perm": "*"}, {"type": "zone", "perm": "read"}]}`, nil
+			}
+			return "", nil
+		},
+	}
+	c := &clusterd.Context{Executor: executor}
+	maxBuckets := 10
+	maxSize := "1Ki"
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: cephv1.ObjectStoreUserSpec{
+			Store:        store,
+			Quotas:       &cephv1.ObjectUserQuotaSpec{MaxBuckets: &maxBuckets, MaxSize: &maxSize},
+			Capabilities: &cephv1.ObjectUserCapSpec{User: "read", Usage: "read, write"},
+		},
+	}
+	r := &ReconcileObjectStoreUser{context: c, objContext: object.NewContext(c, store, namespace), userConfig: generateUserConfig(u)}
+
+	err := r.reconcileCephUserQuotas(u)
+	assert.NoError(t, err)
+	assert.Len(t, commands, 3)
+	assert.Equal(t, []string{"quota", "set", "--uid", name, "--quota-scope", "user", "--max-buckets", "10"}, commands[0][:8])
+	assert.Equal(t, []string{"quota", "set", "--uid", name, "--quota-scope", "user", "--max-objects", "-1", "--max-size", "1024"}, commands[1][:10])
+	assert.Equal(t, []string{"quota", "enable"}, commands[2][:2])
+
+	// The zone capability is removed and the users capability added
+	commands = nil
+	err = r.reconcileCephUserCaps(u)
+	assert.NoError(t, err)
+	assert.Len(t, commands, 3)
+	assert.Equal(t, []string{"caps", "rm", "--uid", name, "--caps", "zone=read"}, commands[1][:6])
+	assert.Equal(t, []string{"caps", "add", "--uid", name, "--caps", "users=read"}, commands[2][:6])
+
+	// The quotas and the capabilities are not managed if not set
+	commands = nil
+	u.Spec.Quotas = nil
+	u.Spec.Capabilities = nil
+	assert.NoError(t, r.reconcileCephUserQuotas(u))
+	assert.NoError(t, r.reconcileCephUserCaps(u))
+	assert.Len(t, commands, 0)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectuser
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// exportedSecretLabel marks the secrets of the keys of a user declared in the secrets of the user spec, which are
+// removed when no longer declared
+const exportedSecretLabel = "rook_object_user_secret"
+
+// userSecretName returns the name of the secret of the keys of the user in its namespace
+func userSecretName(u *cephv1.CephObjectStoreUser) string {
+	return fmt.Sprintf("rook-ceph-object-user-%s-%s", u.Spec.Store, u.Name)
+}
+
+// userSecretLabels returns the labels of the secrets of the keys of the user
+func userSecretLabels(u *cephv1.CephObjectStoreUser) map[string]string {
+	return map[string]string{
+		"app":               appName,
+		"user":              u.Name,
+		"rook_cluster":      u.Namespace,
+		"rook_object_store": u.Spec.Store,
+	}
+}
+
+// exportedSecretKey returns the namespace and the name of a secret of the user spec
+func exportedSecretKey(u *cephv1.CephObjectStoreUser, spec cephv1.ObjectUserSecretSpec) types.NamespacedName {
+	key := types.NamespacedName{Namespace: spec.Namespace, Name: spec.Name}
+	if key.Namespace == "" {
+		key.Namespace = u.Namespace
+	}
+	if key.Name == "" {
+		key.Name = userSecretName(u)
+	}
+	return key
+}
+
+// validateUserSecrets ensures the secrets of the user spec have a known format and do not overwrite each other or the
+// secret of the user
+func validateUserSecrets(u *cephv1.CephObjectStoreUser) error {
+	keys := map[types.NamespacedName]bool{{Namespace: u.Namespace, Name: userSecretName(u)}: true}
+	for _, spec := range u.Spec.Secrets {
+		switch spec.Format {
+		case "", cephv1.ObjectUserSecretFormatRook, cephv1.ObjectUserSecretFormatAWS, cephv1.ObjectUserSecretFormatS3cmd, cephv1.ObjectUserSecretFormatRclone:
+		default:
+			return errors.Errorf("invalid secret format %q. only 'rook', 'aws', 's3cmd' and 'rclone' are supported", spec.Format)
+		}
+		if spec.Namespace != "" {
+			if errs := validation.IsDNS1123Label(spec.Namespace); len(errs) > 0 {
+				return errors.Errorf("invalid secret namespace %q. %s", spec.Namespace, strings.Join(errs, ", "))
+			}
+		}
+		if spec.Name != "" {
+			if errs := validation.IsDNS1123Subdomain(spec.Name); len(errs) > 0 {
+				return errors.Errorf("invalid secret name %q. %s", spec.Name, strings.Join(errs, ", "))
+			}
+		}
+		key := exportedSecretKey(u, spec)
+		if keys[key] {
+			return errors.Errorf("secret %q is declared twice, a secret in the namespace of the user requires a name", key.String())
+		}
+		keys[key] = true
+	}
+	return nil
+}
+
+// secretData returns the keys of the user and the endpoint of the object store in the given secret format
+func secretData(format, store, accessKey, secretKey, endpoint string) (map[string]string, error) {
+	switch format {
+	case "", cephv1.ObjectUserSecretFormatRook:
+		return map[string]string{
+			"AccessKey": accessKey,
+			"SecretKey": secretKey,
+			"Endpoint":  endpoint,
+		}, nil
+
+	case cephv1.ObjectUserSecretFormatAWS:
+		return map[string]string{
+			"AWS_ACCESS_KEY_ID":     accessKey,
+			"AWS_SECRET_ACCESS_KEY": secretKey,
+			"AWS_ENDPOINT_URL":      endpoint,
+		}, nil
+
+	case cephv1.ObjectUserSecretFormatS3cmd:
+		endpointURL, err := url.Parse(endpoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse endpoint %q", endpoint)
+		}
+		config := fmt.Sprintf("[default]\naccess_key = %s\nsecret_key = %s\nhost_base = %s\nhost_bucket = %s\nuse_https = %t\n",
+			accessKey, secretKey, endpointURL.Host, endpointURL.Host, endpointURL.Scheme == "https")
+		return map[string]string{".s3cfg": config}, nil
+
+	case cephv1.ObjectUserSecretFormatRclone:
+		config := fmt.Sprintf("[%s]\ntype = s3\nprovider = Ceph\naccess_key_id = %s\nsecret_access_key = %s\nendpoint = %s\n",
+			store, accessKey, secretKey, endpoint)
+		return map[string]string{"rclone.conf": config}, nil
+	}
+	return nil, errors.Errorf("unknown secret format %q", format)
+}
+
+// reconcileUserSecrets creates or updates the secrets of the user spec, and removes the secrets no longer declared.
+// The secrets in other namespaces have no owner reference and are removed with the user.
+func (r *ReconcileObjectStoreUser) reconcileUserSecrets(u *cephv1.CephObjectStoreUser) error {
+	desired := map[types.NamespacedName]bool{}
+	for _, spec := range u.Spec.Secrets {
+		key := exportedSecretKey(u, spec)
+		data, err := secretData(spec.Format, u.Spec.Store, *r.userConfig.AccessKey, *r.userConfig.SecretKey, r.objContext.Endpoint)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate secret %q", key.String())
+		}
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      key.Name,
+				Namespace: key.Namespace,
+				Labels:    userSecretLabels(u),
+			},
+			StringData: data,
+			Type:       k8sutil.RookType,
+		}
+		secret.Labels[exportedSecretLabel] = "true"
+		if key.Namespace == u.Namespace {
+			if err := controllerutil.SetControllerReference(u, secret, r.scheme); err != nil {
+				return errors.Wrapf(err, "failed to set owner reference for ceph object user %q secret", secret.Name)
+			}
+		}
+
+		// a secret of the same name not created for the user is not overwritten
+		existing := &v1.Secret{}
+		err = r.client.Get(context.TODO(), key, existing)
+		if err == nil && !isUserSecret(u, existing) {
+			return errors.Errorf("secret %q already exists and does not belong to object store user %q", key.String(), u.Name)
+		}
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to get secret %q", key.String())
+		}
+
+		if err := opcontroller.CreateOrUpdateObject(r.client, secret); err != nil {
+			return errors.Wrapf(err, "failed to create or update ceph object user %q secret %q", u.Name, key.String())
+		}
+		desired[key] = true
+	}
+
+	secrets, err := r.listUserSecrets(u)
+	if err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		secret := &secrets.Items[i]
+		if desired[types.NamespacedName{Namespace: secret.Namespace, Name: secret.Name}] {
+			continue
+		}
+		logger.Infof("removing secret %q of ceph object user %q no longer in the spec", secret.Namespace+"/"+secret.Name, u.Name)
+		if err := r.client.Delete(context.TODO(), secret); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete secret %q", secret.Namespace+"/"+secret.Name)
+		}
+	}
+	return nil
+}
+
+// deleteUserSecrets removes the secrets of the user spec, when the user is deleted
+func (r *ReconcileObjectStoreUser) deleteUserSecrets(u *cephv1.CephObjectStoreUser) error {
+	secrets, err := r.listUserSecrets(u)
+	if err != nil {
+		return err
+	}
+	for i := range secrets.Items {
+		if err := r.client.Delete(context.TODO(), &secrets.Items[i]); err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete secret %q", secrets.Items[i].Namespace+"/"+secrets.Items[i].Name)
+		}
+	}
+	return nil
+}
+
+// listUserSecrets lists the secrets of the user spec in all the namespaces
+func (r *ReconcileObjectStoreUser) listUserSecrets(u *cephv1.CephObjectStoreUser) (*v1.SecretList, error) {
+	labels := userSecretLabels(u)
+	labels[exportedSecretLabel] = "true"
+	secrets := &v1.SecretList{}
+	if err := r.client.List(context.TODO(), secrets, client.MatchingLabels(labels)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the secrets of ceph object user %q", u.Name)
+	}
+	return secrets, nil
+}
+
+func isUserSecret(u *cephv1.CephObjectStoreUser, secret *v1.Secret) bool {
+	return secret.Labels["user"] == u.Name && secret.Labels["rook_cluster"] == u.Namespace && secret.Labels[exportedSecretLabel] == "true"
+}
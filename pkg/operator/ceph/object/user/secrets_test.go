@@ -0,0 +1,127 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package objectuser
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/object"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestSecretData(t *testing.T) {
+	endpoint := "http://rook-ceph-rgw-my-store.rook-ceph:80"
+	data, err := secretData("", store, "access", "secret", endpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"AccessKey": "access", "SecretKey": "secret", "Endpoint": endpoint}, data)
+
+	data, err = secretData(cephv1.ObjectUserSecretFormatAWS, store, "access", "secret", endpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"AWS_ACCESS_KEY_ID": "access", "AWS_SECRET_ACCESS_KEY": "secret", "AWS_ENDPOINT_URL": endpoint}, data)
+
+	data, err = secretData(cephv1.ObjectUserSecretFormatS3cmd, store, "access", "secret", endpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, "[default]\naccess_key = access\nsecret_key = secret\nhost_base = rook-ceph-rgw-my-store.rook-ceph:80\n"+
+		"host_bucket = rook-ceph-rgw-my-store.rook-ceph:80\nuse_https = false\n", data[".s3cfg"])
+
+	data, err = secretData(cephv1.ObjectUserSecretFormatRclone, store, "access", "secret", endpoint)
+	assert.NoError(t, err)
+	assert.Equal(t, "[my-store]\ntype = s3\nprovider = Ceph\naccess_key_id = access\nsecret_access_key = secret\n"+
+		"endpoint = http://rook-ceph-rgw-my-store.rook-ceph:80\n", data["rclone.conf"])
+}
+
+func TestValidateUserSecrets(t *testing.T) {
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec: cephv1.ObjectStoreUserSpec{
+			Store: store,
+			Secrets: []cephv1.ObjectUserSecretSpec{
+				{Namespace: "app"},
+				{Namespace: "app", Name: "s3cfg", Format: "s3cmd"},
+				{Name: "my-user-aws", Format: "aws"},
+			},
+		},
+	}
+	assert.NoError(t, validateUserSecrets(u))
+
+	// the secret of the user cannot be overwritten
+	u.Spec.Secrets[2].Name = ""
+	assert.Error(t, validateUserSecrets(u))
+	u.Spec.Secrets[2].Name = "my-user-aws"
+
+	u.Spec.Secrets[1].Format = "boto"
+	assert.Error(t, validateUserSecrets(u))
+	u.Spec.Secrets[1].Format = "s3cmd"
+
+	u.Spec.Secrets[0].Namespace = "App"
+	assert.Error(t, validateUserSecrets(u))
+}
+
+func TestReconcileUserSecrets(t *testing.T) {
+	accessKey, secretKey := "access", "secret"
+	u := &cephv1.CephObjectStoreUser{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, UID: "uid"},
+		Spec: cephv1.ObjectStoreUserSpec{
+			Store: store,
+			Secrets: []cephv1.ObjectUserSecretSpec{
+				{Namespace: "app", Format: "aws"},
+				{Name: "my-user-rclone", Format: "rclone"},
+			},
+		},
+	}
+	// a secret of the application is not overwritten
+	appSecret := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "app-secret", Namespace: "app"}}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephObjectStoreUser{})
+	cl := fake.NewFakeClientWithScheme(s, u, appSecret)
+	r := &ReconcileObjectStoreUser{
+		client:     cl,
+		scheme:     s,
+		objContext: &object.Context{Endpoint: "http://rook-ceph-rgw-my-store.rook-ceph:80"},
+		userConfig: object.ObjectUser{AccessKey: &accessKey, SecretKey: &secretKey},
+	}
+
+	assert.NoError(t, r.reconcileUserSecrets(u))
+	secret := &v1.Secret{}
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: "app", Name: "rook-ceph-object-user-my-store-my-user"}, secret))
+	assert.Equal(t, "access", secret.StringData["AWS_ACCESS_KEY_ID"])
+	assert.Empty(t, secret.OwnerReferences)
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "my-user-rclone"}, secret))
+	assert.Contains(t, secret.StringData["rclone.conf"], "access_key_id = access")
+	assert.Equal(t, 1, len(secret.OwnerReferences))
+
+	// the secrets removed from the spec are deleted
+	u.Spec.Secrets = u.Spec.Secrets[:1]
+	assert.NoError(t, r.reconcileUserSecrets(u))
+	assert.Error(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: namespace, Name: "my-user-rclone"}, secret))
+
+	u.Spec.Secrets = append(u.Spec.Secrets, cephv1.ObjectUserSecretSpec{Namespace: "app", Name: "app-secret"})
+	assert.Error(t, r.reconcileUserSecrets(u))
+
+	// the secrets are deleted with the user, the other secrets being kept
+	assert.NoError(t, r.deleteUserSecrets(u))
+	assert.Error(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: "app", Name: "rook-ceph-object-user-my-store-my-user"}, secret))
+	assert.NoError(t, cl.Get(context.TODO(), types.NamespacedName{Namespace: "app", Name: "app-secret"}, secret))
+}
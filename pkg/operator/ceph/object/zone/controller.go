@@ -89,7 +89,7 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -109,10 +109,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileObjectZone) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephObjectZone", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile: %v", err)
+		reconcileLogger.Errorf("failed to reconcile: %v", err)
 	}
 
 	return reconcileResponse, err
@@ -158,6 +160,9 @@ func (r *ReconcileObjectZone) reconcile(request reconcile.Request) (reconcile.Re
 
 	// validate the zone settings
 	err = validateZoneCR(cephObjectZone)
+	if err == nil {
+		err = validateZonePools(r.context, cephObjectZone)
+	}
 	if err != nil {
 		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
 		return reconcile.Result{}, errors.Wrapf(err, "invalid CephObjectZone CR %q", cephObjectZone.Name)
@@ -178,11 +183,20 @@ func (r *ReconcileObjectZone) reconcile(request reconcile.Request) (reconcile.Re
 		return reconcileResponse, err
 	}
 
+	// Create the pools of the zone, shared by its object stores
+	err = object.CreatePools(object.NewContext(r.context, cephObjectZone.Name, cephObjectZone.Namespace), cephObjectZone.Spec.MetadataPool, cephObjectZone.Spec.DataPool)
+	if err != nil {
+		return r.setFailedStatus(request.NamespacedName, "failed to create the pools of the ceph zone", err)
+	}
+
 	// Create Ceph Zone
 	reconcileResponse, err = r.createCephZone(cephObjectZone, realmName)
 	if err != nil {
 		return r.setFailedStatus(request.NamespacedName, "failed to create ceph zone", err)
 	}
+	if reconcileResponse.Requeue {
+		return reconcileResponse, nil
+	}
 
 	// Set Ready status, we are done reconciling
 	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
@@ -222,12 +236,22 @@ func (r *ReconcileObjectZone) createCephZone(zone *cephv1.CephObjectZone, realmN
 		masterArg = "--master"
 	}
 
+	// the zones sync with the keys of the system user of the realm
+	accessKeyArg, secretKeyArg, err := object.GetRealmKeyArgs(r.context, realmName, zone.Namespace)
+	if err != nil {
+		if kerrors.IsNotFound(errors.Cause(err)) {
+			logger.Infof("waiting for the keys secret %q of realm %q to create zone %q", object.GetRealmKeySecretName(realmName), realmName, zone.Name)
+			return waitForRequeueIfObjectZoneNotReady, nil
+		}
+		return reconcile.Result{}, err
+	}
+
 	// create zone
 	_, err = object.RunAdminCommandNoRealm(objContext, "zone", "get", realmArg, zoneGroupArg, zoneArg)
 	if err != nil {
 		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
 			logger.Debugf("ceph zone %q not found, running `radosgw-admin zone create`", zone.Name)
-			_, err := object.RunAdminCommandNoRealm(objContext, "zone", "create", realmArg, zoneGroupArg, zoneArg, masterArg)
+			_, err := object.RunAdminCommandNoRealm(objContext, "zone", "create", realmArg, zoneGroupArg, zoneArg, masterArg, accessKeyArg, secretKeyArg)
 			if err != nil {
 				return reconcile.Result{}, errors.Wrapf(err, "failed to create ceph zone %q", zone.Name)
 			}
@@ -236,9 +260,45 @@ func (r *ReconcileObjectZone) createCephZone(zone *cephv1.CephObjectZone, realmN
 		}
 	}
 
+	// the system user is created in the cluster of the realm, the clusters pulling the realm get it by the metadata sync
+	realm, err := r.context.RookClientset.CephV1().CephObjectRealms(zone.Namespace).Get(realmName, metav1.GetOptions{})
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephObjectRealm %q", realmName)
+	}
+	if realm.Spec.Pull.Endpoint == "" {
+		if err := createSystemUser(objContext, realmName, zone.Spec.ZoneGroup, zone.Name, accessKeyArg, secretKeyArg); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
 	return reconcile.Result{}, nil
 }
 
+// createSystemUser creates the system user of the realm syncing the zones, unless it already exists
+func createSystemUser(objContext *object.Context, realmName, zoneGroupName, zoneName, accessKeyArg, secretKeyArg string) error {
+	realmArg := fmt.Sprintf("--rgw-realm=%s", realmName)
+	zoneGroupArg := fmt.Sprintf("--rgw-zonegroup=%s", zoneGroupName)
+	zoneArg := fmt.Sprintf("--rgw-zone=%s", zoneName)
+	uid := object.GetRealmSystemUserName(realmName)
+	uidArg := fmt.Sprintf("--uid=%s", uid)
+
+	_, err := object.RunAdminCommandNoRealm(objContext, "user", "info", uidArg, realmArg, zoneGroupArg, zoneArg)
+	if err == nil {
+		return nil
+	}
+	if code, ok := exec.ExitStatus(err); !ok || code != int(syscall.ENOENT) {
+		return errors.Wrapf(err, "radosgw-admin user info failed with code %d", code)
+	}
+
+	logger.Infof("creating system user %q of realm %q", uid, realmName)
+	displayNameArg := fmt.Sprintf("--display-name=%s", uid)
+	_, err = object.RunAdminCommandNoRealm(objContext, "user", "create", uidArg, displayNameArg, "--system", accessKeyArg, secretKeyArg, realmArg, zoneGroupArg, zoneArg)
+	if err != nil {
+		return errors.Wrapf(err, "failed to create system user %q of realm %q", uid, realmName)
+	}
+	return nil
+}
+
 func (r *ReconcileObjectZone) reconcileObjectZoneGroup(zone *cephv1.CephObjectZone) (string, reconcile.Result, error) {
 	// Verify the object zone API object actually exists
 	zoneGroup, err := r.context.RookClientset.CephV1().CephObjectZoneGroups(zone.Namespace).Get(zone.Spec.ZoneGroup, metav1.GetOptions{})
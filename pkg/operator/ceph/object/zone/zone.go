@@ -18,8 +18,12 @@ package zone
 
 import (
 	"encoding/json"
+	"reflect"
+
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/pool"
 )
 
 type masterZoneType struct {
@@ -49,3 +53,18 @@ func validateZoneCR(u *cephv1.CephObjectZone) error {
 	}
 	return nil
 }
+
+// validateZonePools validates the pools of the zone, which may be empty if they were already created
+func validateZonePools(context *clusterd.Context, u *cephv1.CephObjectZone) error {
+	if !reflect.DeepEqual(u.Spec.MetadataPool, cephv1.PoolSpec{}) {
+		if err := pool.ValidatePoolSpec(context, u.Namespace, &u.Spec.MetadataPool); err != nil {
+			return errors.Wrap(err, "invalid metadata pool spec")
+		}
+	}
+	if !reflect.DeepEqual(u.Spec.DataPool, cephv1.PoolSpec{}) {
+		if err := pool.ValidatePoolSpec(context, u.Namespace, &u.Spec.DataPool); err != nil {
+			return errors.Wrap(err, "invalid data pool spec")
+		}
+	}
+	return nil
+}
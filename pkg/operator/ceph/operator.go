@@ -21,6 +21,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	"github.com/pkg/errors"
@@ -28,6 +29,7 @@ import (
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume"
 	"github.com/rook/rook/pkg/daemon/ceph/agent/flexvolume/attachment"
 	"github.com/rook/rook/pkg/operator/ceph/agent"
+	"github.com/rook/rook/pkg/operator/ceph/api"
 	"github.com/rook/rook/pkg/operator/ceph/cluster"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/ceph/csi"
@@ -47,6 +49,9 @@ const (
 	provisionerNameLegacy = "rook.io/block"
 )
 
+// operatorLease is the lease of the active replica of the operator, in the namespace of the operator
+const operatorLease = "rook-ceph-operator"
+
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", "operator")
 
 // The supported configurations for the volume provisioner
@@ -62,8 +67,14 @@ var (
 	// EnableDiscoveryDaemon Whether to enable the daemon for device discovery. If true, the rook-ceph-discover daemonset will be started.
 	EnableDiscoveryDaemon = true
 
+	// EnablePluginAPI Whether to serve the queries of the clusters to the kubectl plugins from the operator
+	EnablePluginAPI = false
+
 	// ImmediateRetryResult Return this for a immediate retry of the reconciliation loop with the same request object.
 	ImmediateRetryResult = reconcile.Result{Requeue: true}
+
+	// driverReconcileInterval is how often the csi and flex drivers are applied again to undo any drift
+	driverReconcileInterval = 10 * time.Minute
 )
 
 // Operator type for managing storage
@@ -113,28 +124,51 @@ func (o *Operator) Run() error {
 		return errors.Errorf("rook operator namespace is not provided. expose it via downward API in the rook operator manifest file using environment variable %q", k8sutil.PodNamespaceEnvVar)
 	}
 
+	// Initialize signal handler
+	signalChan := make(chan os.Signal, 1)
+	stopChan := make(chan struct{})
+	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Only the replica of the operator holding the operator lease runs, the other replicas taking over once the
+	// lease is released by the stopped operator or expires with a failed operator
+	leaderStopChan := make(chan struct{})
+	leaseLostChan, err := o.waitForLeadership(signalChan, leaderStopChan)
+	if err != nil {
+		return err
+	}
+	if leaseLostChan == nil {
+		logger.Info("shutdown signal received, exiting...")
+		return nil
+	}
+
+	// The pods generated in the operator namespace pull their images with the secrets of the operator pod, which
+	// include the image pull secrets of the service account of the operator
+	if operatorPod, err := k8sutil.GetRunningPod(o.context.Clientset); err != nil {
+		logger.Warningf("failed to get the operator pod, no image pull secrets are added to the pods of the operator namespace. %v", err)
+	} else {
+		k8sutil.SetOperatorImagePullSecrets(o.operatorNamespace, operatorPod.Spec.ImagePullSecrets)
+	}
+
 	if EnableDiscoveryDaemon {
 		rookDiscover := discover.New(o.context.Clientset)
 		if err := rookDiscover.Start(o.operatorNamespace, o.rookImage, o.securityAccount, true); err != nil {
+			close(leaderStopChan)
 			return errors.Wrap(err, "failed to start device discovery daemonset")
 		}
 	}
 
 	logger.Debug("checking for admission controller secrets")
-	err := StartControllerIfSecretPresent(o.context, o.rookImage)
+	err = StartControllerIfSecretPresent(o.context, o.rookImage)
 	if err != nil {
+		close(leaderStopChan)
 		return errors.Wrap(err, "failed to start webhook")
 	}
 	serverVersion, err := o.context.Clientset.Discovery().ServerVersion()
 	if err != nil {
+		close(leaderStopChan)
 		return errors.Wrap(err, "failed to get server version")
 	}
 
-	// Initialize signal handler
-	signalChan := make(chan os.Signal, 1)
-	stopChan := make(chan struct{})
-	signal.Notify(signalChan, syscall.SIGINT, syscall.SIGTERM)
-
 	// For Flex Driver, run volume provisioner for each of the supported configurations
 	if EnableFlexDriver {
 		for name, vendor := range provisionerConfigs {
@@ -163,24 +197,61 @@ func (o *Operator) Run() error {
 	mgrErrorChan := make(chan error)
 	go o.startManager(namespaceToWatch, stopChan, mgrErrorChan)
 
+	// Serve the queries of the kubectl plugins
+	if EnablePluginAPI {
+		go api.Run(o.context, stopChan)
+	}
+
 	// Start the operator setting watcher
 	go o.clusterController.StartOperatorSettingsWatch(namespaceToWatch, stopChan)
 
+	// Restore the csi drivers if they are changed or deleted out of the operator
+	go o.reconcileDrivers(stopChan)
+
 	// Signal handler to stop the operator
+	// The lease is released once the monitoring goroutines and the watchers are stopped, so that the replica taking
+	// over never runs them alongside this replica
 	for {
 		select {
 		case <-signalChan:
 			logger.Info("shutdown signal received, exiting...")
 			o.cleanup(stopChan)
+			close(leaderStopChan)
 			return nil
 		case err := <-mgrErrorChan:
 			logger.Errorf("gave up to run the operator. %v", err)
 			o.cleanup(stopChan)
+			close(leaderStopChan)
 			return err
+		case <-leaseLostChan:
+			o.cleanup(stopChan)
+			return errors.Errorf("lost lease %q, another replica of the operator took over", operatorLease)
 		}
 	}
 }
 
+// waitForLeadership waits for the operator to acquire the operator lease, the lease being held until the leader stop
+// channel is closed. It returns the channel closed when the lease is lost, or nil if the operator was stopped first.
+func (o *Operator) waitForLeadership(signalChan chan os.Signal, leaderStopChan chan struct{}) (chan struct{}, error) {
+	acquiredChan := make(chan chan struct{}, 1)
+	err := o.clusterController.LeaderElections().Run(operatorLease, leaderStopChan, func(leaseLostChan chan struct{}) {
+		acquiredChan <- leaseLostChan
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start the leader election of the operator")
+	}
+
+	logger.Infof("waiting to acquire lease %q, the operator is on standby", operatorLease)
+	select {
+	case <-signalChan:
+		close(leaderStopChan)
+		return nil, nil
+	case leaseLostChan := <-acquiredChan:
+		logger.Infof("acquired lease %q, starting the operator", operatorLease)
+		return leaseLostChan, nil
+	}
+}
+
 func (o *Operator) startDrivers() error {
 	if o.delayedDaemonsStarted {
 		return nil
@@ -195,6 +266,20 @@ func (o *Operator) startDrivers() error {
 	return nil
 }
 
+// reconcileDrivers periodically applies the drivers again once the first cluster has started them
+func (o *Operator) reconcileDrivers(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-time.After(driverReconcileInterval):
+			if err := o.updateDrivers(); err != nil {
+				logger.Errorf("failed to reconcile the drivers. %v", err)
+			}
+		}
+	}
+}
+
 func (o *Operator) updateDrivers() error {
 	var err error
 
@@ -18,6 +18,7 @@ package operator
 
 import (
 	"fmt"
+	"os"
 	"testing"
 
 	"github.com/rook/rook/pkg/clusterd"
@@ -25,6 +26,7 @@ import (
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestOperator(t *testing.T) {
@@ -43,3 +45,30 @@ func TestOperator(t *testing.T) {
 		}
 	}
 }
+
+func TestWaitForLeadership(t *testing.T) {
+	clientset := test.New(t, 1)
+	context := &clusterd.Context{Clientset: clientset}
+
+	// the operator is stopped while on standby
+	other := New(context, &attachment.MockAttachment{}, "", "")
+	signalChan := make(chan os.Signal, 1)
+	signalChan <- os.Interrupt
+	leaseLostChan, err := other.waitForLeadership(signalChan, make(chan struct{}))
+	assert.NoError(t, err)
+	assert.Nil(t, leaseLostChan)
+
+	// the operator runs once it holds the lease, and releases the lease when stopped
+	os.Setenv("POD_NAME", "rook-ceph-operator-a")
+	defer os.Unsetenv("POD_NAME")
+	o := New(context, &attachment.MockAttachment{}, "", "")
+	leaderStopChan := make(chan struct{})
+	leaseLostChan, err = o.waitForLeadership(make(chan os.Signal, 1), leaderStopChan)
+	assert.NoError(t, err)
+	assert.NotNil(t, leaseLostChan)
+	lease, err := clientset.CoordinationV1().Leases("").Get(operatorLease, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-operator-a", *lease.Spec.HolderIdentity)
+	close(leaderStopChan)
+	<-leaseLostChan
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/coreos/pkg/capnslog"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
@@ -30,13 +31,17 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -48,8 +53,15 @@ const (
 	erasureCodeType        = "erasure-coded"
 	poolApplicationNameRBD = "rbd"
 	controllerName         = "ceph-block-pool-controller"
+	// poolPeerTokenSecretPrefix is the prefix of the name of the secret with the rbd mirroring bootstrap peer token of a pool
+	poolPeerTokenSecretPrefix = "pool-peer-token"
+	// rbdMirrorBootstrapPeerSecretNameKey is the key of the pool status info with the name of its bootstrap peer token secret
+	rbdMirrorBootstrapPeerSecretNameKey = "rbdMirrorBootstrapPeerSecretName"
 )
 
+// waitForRebalance waits for the data of a pool to be moved to its new failure domain
+var waitForRebalance = reconcile.Result{Requeue: true, RequeueAfter: 30 * time.Second}
+
 var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
 
 var cephBlockPoolKind = reflect.TypeOf(cephv1.CephBlockPool{}).Name()
@@ -67,6 +79,8 @@ type ReconcileCephBlockPool struct {
 	client  client.Client
 	scheme  *runtime.Scheme
 	context *clusterd.Context
+	// poolChannels are the channels to stop the status checkers of the pools, by namespaced name
+	poolChannels map[string]chan struct{}
 }
 
 // Add creates a new CephBlockPool Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -82,15 +96,16 @@ func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Rec
 	cephv1.AddToScheme(mgr.GetScheme())
 
 	return &ReconcileCephBlockPool{
-		client:  mgr.GetClient(),
-		scheme:  mgrScheme,
-		context: context,
+		client:       mgr.GetClient(),
+		scheme:       mgrScheme,
+		context:      context,
+		poolChannels: make(map[string]chan struct{}),
 	}
 }
 
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
 	if err != nil {
 		return err
 	}
@@ -110,10 +125,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileCephBlockPool) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephBlockPool", request)
+	reconcileLogger.Debug("reconciling")
 	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
 	reconcileResponse, err := r.reconcile(request)
 	if err != nil {
-		logger.Errorf("failed to reconcile %v", err)
+		reconcileLogger.Errorf("failed to reconcile %v", err)
 	}
 
 	return reconcileResponse, err
@@ -134,7 +151,7 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 
 	// The CR was just created, initializing status fields
 	if cephBlockPool.Status == nil {
-		updateStatus(r.client, request.NamespacedName, k8sutil.Created)
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, nil, nil)
 	}
 
 	// Make sure a CephCluster is present otherwise do nothing
@@ -147,6 +164,8 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 		// If not, we should wait for it to be ready
 		// This handles the case where the operator is not ready to accept Ceph command but the cluster exists
 		if !cephBlockPool.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			r.stopMonitoring(request.NamespacedName)
+
 			// Remove finalizer
 			err = opcontroller.RemoveFinalizer(r.client, cephBlockPool)
 			if err != nil {
@@ -168,10 +187,23 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 	// DELETE: the CR was deleted
 	if !cephBlockPool.GetDeletionTimestamp().IsZero() {
 		logger.Debugf("deleting pool %q", cephBlockPool.Name)
-		err := deletePool(r.context, cephBlockPool)
-		if err != nil {
+		// the pool is not deleted while rbd images are present, unless the deletion is forced
+		if !cephBlockPool.Spec.PreservePoolsOnDelete && !opcontroller.IsForceDeletion(cephBlockPool) {
+			if err := cephclient.CheckForImagesInPool(r.context, cephBlockPool.Name, cephBlockPool.Namespace); err != nil {
+				logger.Errorf("cannot delete pool %q, remove its images or set the %q annotation to delete them with the pool. %v", cephBlockPool.Name, opcontroller.ForceDeletionAnnotation, err)
+				updateStatus(r.client, request.NamespacedName, k8sutil.DeletionBlockedStatus, nil, nil)
+				return opcontroller.WaitForRequeueIfFinalizerBlocked, nil
+			}
+		}
+		r.stopMonitoring(request.NamespacedName)
+		if cephBlockPool.Spec.PreservePoolsOnDelete {
+			logger.Infof("preservePoolsOnDelete is set in pool %q. Pool not deleted", cephBlockPool.Name)
+		} else if err := deletePool(r.context, cephBlockPool); err != nil {
 			return reconcile.Result{}, errors.Wrapf(err, "failed to delete pool %q. ", cephBlockPool.Name)
 		}
+		if err := csi.DeleteRBDSnapshotClass(r.client, cephBlockPool.Namespace, cephBlockPool.Name, cephBlockPool.Spec.SnapshotClass); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to delete the snapshot class of pool %q", cephBlockPool.Name)
+		}
 
 		// Remove finalizer
 		err = opcontroller.RemoveFinalizer(r.client, cephBlockPool)
@@ -188,20 +220,27 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, errors.Wrapf(err, "invalid pool CR %q spec", cephBlockPool.Name)
 	}
 
-	updateStatus(r.client, request.NamespacedName, k8sutil.ReconcilingStatus)
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReconcilingStatus, nil, nil)
 
 	// Get CephCluster version
-	cephVersion, err := opcontroller.GetImageVersion(cephCluster)
+	cephVersion, err := opcontroller.GetClusterCephVersion(cephCluster)
 	if err != nil {
 		return reconcile.Result{}, errors.Wrapf(err, "failed to fetch ceph version from cephcluster %q", cephCluster.Name)
 	}
 
+	// refuse the settings the cluster does not support rather than failing in the middle of the pool creation
+	if err := validatePoolVersion(cephBlockPool, *cephVersion); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "invalid pool CR %q spec", cephBlockPool.Name)
+	}
+
 	// If the CephCluster has enabled the "pg_autoscaler" module and is running Nautilus
-	// we force the pg_autoscale_mode to "on"
+	// we force the pg_autoscale_mode to "on", unless the pool sets its mode or its pg count
 	_, propertyExists := cephBlockPool.Spec.Parameters[cephclient.PgAutoscaleModeProperty]
+	_, pgCountExists := cephBlockPool.Spec.Parameters["pg_num"]
 	if mgr.IsModuleInSpec(cephCluster.Spec.Mgr.Modules, mgr.PgautoscalerModuleName) &&
 		!cephVersion.IsAtLeastOctopus() &&
-		!propertyExists {
+		!propertyExists && !pgCountExists && cephBlockPool.Spec.PgAutoscaleMode == "" {
 		if len(cephBlockPool.Spec.Parameters) == 0 {
 			cephBlockPool.Spec.Parameters = make(map[string]string)
 		}
@@ -211,18 +250,185 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 	// CREATE/UPDATE
 	reconcileResponse, err = r.reconcileCreatePool(cephBlockPool)
 	if err != nil {
-		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus)
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
 		return reconcileResponse, errors.Wrapf(err, "failed to create pool %q.", cephBlockPool.GetName())
 	}
 
+	// FAILURE DOMAIN
+	rebalancing, err := r.reconcileFailureDomain(cephBlockPool)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to update the failure domain of pool %q", cephBlockPool.GetName())
+	}
+
+	// SNAPSHOT CLASS
+	if err := csi.ReconcileRBDSnapshotClass(r.client, cephBlockPool.Namespace, cephBlockPool.Name, cephBlockPool.Spec.SnapshotClass); err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to reconcile the snapshot class of pool %q", cephBlockPool.GetName())
+	}
+
+	// MIRRORING
+	if cephBlockPool.Spec.Mirroring.Enabled {
+		info, err := r.reconcileMirroring(cephBlockPool, cephVersion.IsAtLeastOctopus())
+		if err != nil {
+			updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
+			return reconcile.Result{}, errors.Wrapf(err, "failed to enable mirroring of pool %q", cephBlockPool.GetName())
+		}
+
+		r.startMonitoring(request.NamespacedName)
+		if rebalancing {
+			updateStatus(r.client, request.NamespacedName, k8sutil.ProcessingStatus, nil, nil)
+			return waitForRebalance, nil
+		}
+
+		// Set Ready status with the mirroring status, which is refreshed periodically by the status checker
+		mirroringStatus := getMirroringStatus(r.context, cephBlockPool)
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, mirroringStatus, info)
+		logger.Debug("done reconciling")
+		return reconcile.Result{}, nil
+	}
+
+	// Disable the mirroring only if it was enabled by the operator before
+	if cephBlockPool.Status != nil && cephBlockPool.Status.MirroringStatus != nil {
+		if err := cephclient.DisablePoolMirroring(r.context, cephBlockPool.Namespace, cephBlockPool.Name); err != nil {
+			updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil, nil)
+			return reconcile.Result{}, errors.Wrapf(err, "failed to disable mirroring of pool %q", cephBlockPool.GetName())
+		}
+	}
+
+	// Start the periodic refresh of the usage of the pool
+	r.startMonitoring(request.NamespacedName)
+
+	// The pool is not ready until its data is moved to its new failure domain
+	if rebalancing {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ProcessingStatus, nil, nil)
+		return waitForRebalance, nil
+	}
+
 	// Set Ready status, we are done reconciling
-	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus)
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, nil, nil)
 
 	// Return and do not requeue
 	logger.Debug("done reconciling")
 	return reconcile.Result{}, nil
 }
 
+// validatePoolVersion checks the settings of the pool are supported by the ceph version of the cluster
+func validatePoolVersion(cephBlockPool *cephv1.CephBlockPool, cephVersion cephver.CephVersion) error {
+	if _, ok := cephBlockPool.Spec.Parameters[cephclient.PgAutoscaleModeProperty]; ok && !cephVersion.IsAtLeastNautilus() {
+		return errors.Errorf("the %q parameter requires ceph nautilus, the cluster runs %q", cephclient.PgAutoscaleModeProperty, cephVersion.String())
+	}
+	if cephBlockPool.Spec.PgAutoscaleMode != "" && !cephVersion.IsAtLeastNautilus() {
+		return errors.Errorf("pgAutoscaleMode requires ceph nautilus, the cluster runs %q", cephVersion.String())
+	}
+	if err := cephclient.ValidateCompressionVersion(cephBlockPool.Spec.CompressionAlgorithm, cephVersion); err != nil {
+		return err
+	}
+	return nil
+}
+
+// reconcileFailureDomain moves a replicated pool to the failure domain of its spec, ceph then rebalancing its data. It
+// returns whether the pool is still rebalancing, until all its PGs are clean again. The rebalance of a pool whose
+// failure domain was changed by a previous reconcile is waited for as well, the pool being left in the Processing phase.
+func (r *ReconcileCephBlockPool) reconcileFailureDomain(cephBlockPool *cephv1.CephBlockPool) (bool, error) {
+	if !cephBlockPool.Spec.IsReplicated() {
+		return false, nil
+	}
+	changed, err := cephclient.UpdatePoolFailureDomain(r.context, cephBlockPool.Namespace, cephBlockPool.Name, cephBlockPool.Spec)
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		// the PGs of the pool may not be peered with the new crush rule yet
+		logger.Infof("waiting for pool %q to rebalance its data to failure domain %q", cephBlockPool.Name, cephBlockPool.Spec.FailureDomain)
+		return true, nil
+	}
+	if cephBlockPool.Status == nil || cephBlockPool.Status.Phase != k8sutil.ProcessingStatus {
+		return false, nil
+	}
+
+	states, err := cephclient.GetPoolPGStates(r.context, cephBlockPool.Namespace, cephBlockPool.Name)
+	if err != nil {
+		return false, err
+	}
+	if pgHealth(states) != pgHealthOK {
+		logger.Infof("waiting for pool %q to rebalance its data to its failure domain. pg states: %v", cephBlockPool.Name, states)
+		return true, nil
+	}
+	logger.Infof("pool %q is rebalanced to its failure domain", cephBlockPool.Name)
+	return false, nil
+}
+
+// reconcileMirroring enables the rbd mirroring of the pool and its snapshot schedules, and stores its bootstrap peer token
+// in a secret, to import in the peer clusters. It returns the info about the secret to set in the pool status.
+func (r *ReconcileCephBlockPool) reconcileMirroring(cephBlockPool *cephv1.CephBlockPool, snapshotSchedulesSupported bool) (map[string]string, error) {
+	if err := cephclient.EnablePoolMirroring(r.context, cephBlockPool.Namespace, cephBlockPool.Spec, cephBlockPool.Name); err != nil {
+		return nil, err
+	}
+
+	// the snapshot based mirroring is only available from octopus
+	// the schedules not declared in the spec are removed, unless the spec declares none
+	if len(cephBlockPool.Spec.Mirroring.SnapshotSchedules) > 0 {
+		if snapshotSchedulesSupported {
+			if err := cephclient.ReconcileSnapshotSchedules(r.context, cephBlockPool.Namespace, cephBlockPool.Name, cephBlockPool.Spec.Mirroring.SnapshotSchedules); err != nil {
+				return nil, err
+			}
+		} else {
+			logger.Warningf("ignoring the snapshot schedules of pool %q, they require ceph octopus or newer", cephBlockPool.Name)
+		}
+	}
+
+	// the fsid tells the peers apart, it is unique unlike the namespace of the clusters
+	status, err := cephclient.Status(r.context, cephBlockPool.Namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get the fsid of the cluster")
+	}
+	token, err := cephclient.CreateRBDMirrorBootstrapPeer(r.context, cephBlockPool.Namespace, cephBlockPool.Name, status.FSID)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := generatePeerTokenSecret(cephBlockPool, token)
+	err = controllerutil.SetControllerReference(cephBlockPool, secret, r.scheme)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference for pool %q bootstrap peer token secret", cephBlockPool.Name)
+	}
+	err = opcontroller.CreateOrUpdateObject(r.client, secret)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create or update pool %q bootstrap peer token secret", cephBlockPool.Name)
+	}
+
+	return map[string]string{rbdMirrorBootstrapPeerSecretNameKey: secret.Name}, nil
+}
+
+// generatePeerTokenSecret returns the secret with the rbd mirroring bootstrap peer token of the pool
+func generatePeerTokenSecret(cephBlockPool *cephv1.CephBlockPool, token []byte) *v1.Secret {
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%s", poolPeerTokenSecretPrefix, cephBlockPool.Name),
+			Namespace: cephBlockPool.Namespace,
+		},
+		Data: map[string][]byte{
+			"token": token,
+			"pool":  []byte(cephBlockPool.Name),
+		},
+		Type: k8sutil.RookType,
+	}
+}
+
+// getMirroringStatus returns the rbd mirroring status of the pool, with the error in the details if it failed to be retrieved
+func getMirroringStatus(context *clusterd.Context, cephBlockPool *cephv1.CephBlockPool) *cephv1.MirroringStatusSpec {
+	mirroringStatus := &cephv1.MirroringStatusSpec{LastChecked: time.Now().UTC().Format(time.RFC3339)}
+	status, err := cephclient.GetPoolMirroringStatus(context, cephBlockPool.Namespace, cephBlockPool.Name)
+	if err != nil {
+		logger.Warningf("failed to get mirroring status of pool %q. %v", cephBlockPool.Name, err)
+		mirroringStatus.Details = err.Error()
+		return mirroringStatus
+	}
+	mirroringStatus.Summary = status.Summary
+	return mirroringStatus
+}
+
 func (r *ReconcileCephBlockPool) reconcileCreatePool(cephBlockPool *cephv1.CephBlockPool) (reconcile.Result, error) {
 	err := createPool(r.context, cephBlockPool)
 	if err != nil {
@@ -254,7 +460,12 @@ func deletePool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 	// Only delete the pool if it exists...
 	for _, pool := range pools {
 		if pool.Name == p.Name {
-			err := cephclient.DeletePool(context, p.Namespace, p.Name)
+			deletePool := cephclient.DeletePool
+			if opcontroller.IsForceDeletion(p) {
+				logger.Warningf("force deleting pool %q along with its images", p.Name)
+				deletePool = cephclient.ForceDeletePool
+			}
+			err := deletePool(context, p.Namespace, p.Name)
 			if err != nil {
 				return errors.Wrapf(err, "failed to delete pool %q", p.Name)
 			}
@@ -264,8 +475,8 @@ func deletePool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 	return nil
 }
 
-// updateStatus updates a pool CR with the given status
-func updateStatus(client client.Client, poolName types.NamespacedName, status string) {
+// updateStatus updates a pool CR with the given status, and the mirroring status and info if any
+func updateStatus(client client.Client, poolName types.NamespacedName, status string, mirroringStatus *cephv1.MirroringStatusSpec, info map[string]string) {
 	pool := &cephv1.CephBlockPool{}
 	err := client.Get(context.TODO(), poolName, pool)
 	if err != nil {
@@ -278,10 +489,14 @@ func updateStatus(client client.Client, poolName types.NamespacedName, status st
 	}
 
 	if pool.Status == nil {
-		pool.Status = &cephv1.Status{}
+		pool.Status = &cephv1.CephBlockPoolStatus{}
 	}
 
 	pool.Status.Phase = status
+	if status == k8sutil.ReadyStatus {
+		pool.Status.MirroringStatus = mirroringStatus
+		pool.Status.Info = info
+	}
 	if err := opcontroller.UpdateStatus(client, pool); err != nil {
 		logger.Warningf("failed to set pool %q status to %q. %v", pool.Name, status, err)
 		return
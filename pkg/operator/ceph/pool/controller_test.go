@@ -23,11 +23,15 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 
 	"github.com/rook/rook/pkg/clusterd"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -116,6 +120,51 @@ func TestValidatePool(t *testing.T) {
 	p.Spec.CompressionMode = "passive"
 	err = ValidatePool(context, &p)
 	assert.Nil(t, err)
+
+	// the compression algorithm must be known
+	p.Spec.CompressionAlgorithm = "zstd"
+	assert.NoError(t, ValidatePool(context, &p))
+	p.Spec.CompressionAlgorithm = "gzip"
+	assert.Error(t, ValidatePool(context, &p))
+
+	// mirroring needs a valid mode
+	p = cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Mirroring.Enabled = true
+	assert.Error(t, ValidatePool(context, &p))
+	p.Spec.Mirroring.Mode = "image"
+	assert.NoError(t, ValidatePool(context, &p))
+
+	// the snapshot schedules need an interval
+	p.Spec.Mirroring.SnapshotSchedules = []cephv1.SnapshotScheduleSpec{{StartTime: "14:00:00-05:00"}}
+	assert.Error(t, ValidatePool(context, &p))
+	p.Spec.Mirroring.SnapshotSchedules[0].Interval = "24h"
+	assert.NoError(t, ValidatePool(context, &p))
+
+	// the retention of the snapshots is not supported by the pools
+	p.Spec.Mirroring.SnapshotSchedules[0].Retention = "24h"
+	assert.Error(t, ValidatePool(context, &p))
+}
+
+func TestValidatePoolVersion(t *testing.T) {
+	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	assert.NoError(t, validatePoolVersion(p, cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}))
+
+	// the pg autoscaler requires nautilus
+	p.Spec.Parameters = map[string]string{cephclient.PgAutoscaleModeProperty: cephclient.PgAutoscaleModeOn}
+	assert.Error(t, validatePoolVersion(p, cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}))
+	assert.NoError(t, validatePoolVersion(p, cephver.Nautilus))
+	p.Spec.Parameters = nil
+	p.Spec.PgAutoscaleMode = "warn"
+	assert.Error(t, validatePoolVersion(p, cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}))
+	assert.NoError(t, validatePoolVersion(p, cephver.Nautilus))
+
+	// the zstd compression requires nautilus
+	p.Spec.PgAutoscaleMode = ""
+	p.Spec.CompressionAlgorithm = "zstd"
+	assert.Error(t, validatePoolVersion(p, cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}))
+	assert.NoError(t, validatePoolVersion(p, cephver.Nautilus))
+	p.Spec.CompressionAlgorithm = "snappy"
+	assert.NoError(t, validatePoolVersion(p, cephver.CephVersion{Major: 13, Minor: 2, Extra: 3}))
 }
 
 func TestValidateCrushProperties(t *testing.T) {
@@ -157,6 +206,89 @@ func TestValidateCrushProperties(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestValidateHybridStorage(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	crushMap := `{"devices":[{"id":0,"name":"osd.0","class":"ssd"},{"id":1,"name":"osd.1","class":"hdd"}],"types":[{"type_id":0,"name":"osd"}],
+		"buckets":[{"id":-1,"name":"default","type_name":"root","items":[{"id":0},{"id":1}]}]}`
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return crushMap, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.FailureDomain = "osd"
+	p.Spec.Replicated = cephv1.ReplicatedSpec{Size: 2, HybridStorage: &cephv1.HybridStorageSpec{PrimaryDeviceClass: "ssd", SecondaryDeviceClass: "hdd"}}
+	assert.NoError(t, ValidatePool(context, p))
+
+	// the cluster must have osds of both device classes
+	p.Spec.Replicated.HybridStorage.SecondaryDeviceClass = "nvme"
+	err := ValidatePool(context, p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"nvme"`)
+
+	// a cluster without any osd yet is only warned about
+	crushMap = `{"types":[{"type_id":0,"name":"osd"}],"buckets":[{"id":-1,"name":"default","type_name":"root"}]}`
+	assert.NoError(t, ValidatePool(context, p))
+}
+
+func TestValidateFailureDomainCount(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	// a single host with two osds, and a second root with two hosts
+	crushMap := `{"types":[{"type_id":0,"name":"osd"},{"type_id":1,"name":"host"},{"type_id":10,"name":"root"}],"buckets":[
+		{"id":-1,"name":"default","type_name":"root","items":[{"id":-2}]},
+		{"id":-2,"name":"node1","type_name":"host","items":[{"id":0},{"id":1}]},
+		{"id":-3,"name":"ssd","type_name":"root","items":[{"id":-4},{"id":-5}]},
+		{"id":-4,"name":"node2","type_name":"host","items":[{"id":2}]},
+		{"id":-5,"name":"node3","type_name":"host","items":[{"id":3}]}]}`
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "crush" && args[2] == "dump" {
+			return crushMap, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	// a size 3 pool can never be clean on a single host
+	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Replicated.Size = 3
+	err := ValidatePool(context, p)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "pool needs 3 failure domains of type \"host\"")
+
+	// the replicas fit on two osds
+	p.Spec.Replicated.Size = 2
+	p.Spec.FailureDomain = "osd"
+	assert.NoError(t, ValidatePool(context, p))
+
+	// the chunks of the ec pool need 3 hosts under the crush root
+	p = &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CodingChunks = 1
+	p.Spec.FailureDomain = "host"
+	p.Spec.CrushRoot = "ssd"
+	assert.Error(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.DataChunks = 1
+	assert.NoError(t, ValidatePool(context, p))
+
+	// the failure domain and the crush root of the chunks override the ones of the pool
+	p.Spec.ErasureCoded.DataChunks = 2
+	p.Spec.ErasureCoded.CrushRoot = "default"
+	assert.Error(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.FailureDomain = "osd"
+	assert.Error(t, ValidatePool(context, p))
+	p.Spec.ErasureCoded.DataChunks = 1
+	assert.NoError(t, ValidatePool(context, p))
+
+	// a cluster without any osd yet is only warned about
+	crushMap = `{"types":[{"type_id":0,"name":"osd"},{"type_id":1,"name":"host"}],"buckets":[{"id":-1,"name":"default","type_name":"root"}]}`
+	p = &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
+	p.Spec.Replicated.Size = 3
+	assert.NoError(t, ValidatePool(context, p))
+}
+
 func TestCreatePool(t *testing.T) {
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
@@ -233,6 +365,11 @@ func TestDeletePool(t *testing.T) {
 	p = &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: "myns"}}
 	err = deletePool(context, p)
 	assert.NotNil(t, err)
+
+	// succeed with the images if the deletion is forced
+	p.Annotations = map[string]string{opcontroller.ForceDeletionAnnotation: "true"}
+	err = deletePool(context, p)
+	assert.Nil(t, err)
 }
 
 // TestCephBlockPoolController runs ReconcileCephBlockPool.Reconcile() against a
@@ -260,7 +397,7 @@ func TestCephBlockPoolController(t *testing.T) {
 				Size: replicas,
 			},
 		},
-		Status: &cephv1.Status{
+		Status: &cephv1.CephBlockPoolStatus{
 			Phase: "",
 		},
 	}
@@ -379,4 +516,98 @@ func TestCephBlockPoolController(t *testing.T) {
 	err = r.client.Get(context.TODO(), req.NamespacedName, pool)
 	assert.NoError(t, err)
 	assert.Equal(t, "Ready", pool.Status.Phase)
+
+	//
+	// TEST 4:
+	//
+	// SUCCESS! The pool is mirrored
+	//
+	pool.Spec.Mirroring = cephv1.MirroringSpec{Enabled: true, Mode: "image"}
+	err = r.client.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+
+	mirrorEnabled := false
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if args[0] == "mirror" && args[1] == "pool" {
+			switch args[2] {
+			case "enable":
+				mirrorEnabled = true
+				return "", nil
+			case "peer":
+				return "token", nil
+			case "status":
+				return `{"summary":{"health":"OK","daemon_health":"OK","image_health":"OK","states":{}}}`, nil
+			}
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.True(t, mirrorEnabled)
+	assert.Contains(t, r.poolChannels, req.NamespacedName.String())
+
+	err = r.client.Get(context.TODO(), req.NamespacedName, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, "Ready", pool.Status.Phase)
+	assert.Equal(t, "OK", pool.Status.MirroringStatus.Summary.Health)
+	secretName := pool.Status.Info[rbdMirrorBootstrapPeerSecretNameKey]
+	assert.Equal(t, "pool-peer-token-my-pool", secretName)
+	secret := &v1.Secret{}
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: secretName, Namespace: namespace}, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("token"), secret.Data["token"])
+}
+
+func TestReconcileFailureDomain(t *testing.T) {
+	crushRule := "replicapool"
+	pgState := "active+clean"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "get" {
+				return `{"pool":"replicapool","pool_id":1,"crush_rule":"` + crushRule + `"}`, nil
+			}
+			if args[0] == "osd" && args[1] == "crush" && args[2] == "dump" {
+				return `{"rules":[{"rule_id":1,"rule_name":"replicapool","steps":[{"op":"chooseleaf_firstn","type":"host"}]},
+					{"rule_id":2,"rule_name":"replicapool_rack","steps":[{"op":"chooseleaf_firstn","type":"rack"}]}]}`, nil
+			}
+			if args[0] == "pg" && args[1] == "ls-by-pool" {
+				return `{"pg_stats":[{"pgid":"1.0","state":"` + pgState + `"}]}`, nil
+			}
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "set" {
+				crushRule = args[5]
+			}
+			return "", nil
+		},
+	}
+	r := &ReconcileCephBlockPool{context: &clusterd.Context{Executor: executor}}
+	p := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: "myns"},
+		Spec:       cephv1.PoolSpec{FailureDomain: "host", Replicated: cephv1.ReplicatedSpec{Size: 3}},
+	}
+
+	// the pool is on its failure domain
+	rebalancing, err := r.reconcileFailureDomain(p)
+	assert.NoError(t, err)
+	assert.False(t, rebalancing)
+
+	// the pool is moved to the new failure domain
+	p.Spec.FailureDomain = "rack"
+	rebalancing, err = r.reconcileFailureDomain(p)
+	assert.NoError(t, err)
+	assert.True(t, rebalancing)
+	assert.Equal(t, "replicapool_rack", crushRule)
+
+	// the pool is rebalancing until its pgs are clean
+	p.Status = &cephv1.CephBlockPoolStatus{Phase: k8sutil.ProcessingStatus}
+	pgState = "active+remapped+backfilling"
+	rebalancing, err = r.reconcileFailureDomain(p)
+	assert.NoError(t, err)
+	assert.True(t, rebalancing)
+
+	pgState = "active+clean"
+	rebalancing, err = r.reconcileFailureDomain(p)
+	assert.NoError(t, err)
+	assert.False(t, rebalancing)
 }
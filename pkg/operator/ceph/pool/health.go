@@ -0,0 +1,184 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// poolStatusCheckInterval is how often the usage of a pool and its mirroring status are refreshed
+	poolStatusCheckInterval = time.Minute
+	pgHealthOK              = "HEALTH_OK"
+	pgHealthWarn            = "HEALTH_WARN"
+	pgHealthErr             = "HEALTH_ERR"
+)
+
+// poolChecker periodically refreshes the usage and the mirroring status of a pool in its status
+type poolChecker struct {
+	context        *clusterd.Context
+	interval       time.Duration
+	client         client.Client
+	namespacedName types.NamespacedName
+}
+
+// newPoolChecker creates a new status checker of a pool
+func newPoolChecker(context *clusterd.Context, client client.Client, namespacedName types.NamespacedName) *poolChecker {
+	return &poolChecker{
+		context:        context,
+		interval:       poolStatusCheckInterval,
+		client:         client,
+		namespacedName: namespacedName,
+	}
+}
+
+// checkPool periodically refreshes the status of the pool until stopped
+func (c *poolChecker) checkPool(stopCh chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring of pool %q", c.namespacedName.Name)
+			return
+
+		case <-time.After(c.interval):
+			logger.Debugf("checking status of pool %q", c.namespacedName.Name)
+			if err := c.checkPoolStatus(); err != nil {
+				logger.Warningf("failed to update status of pool %q. %v", c.namespacedName.Name, err)
+			}
+		}
+	}
+}
+
+// checkPoolStatus updates the status of the pool with its current usage, and its mirroring status if mirrored
+func (c *poolChecker) checkPoolStatus() error {
+	usage := getPoolUsage(c.context, c.namespacedName)
+
+	pool := &cephv1.CephBlockPool{}
+	err := c.client.Get(context.TODO(), c.namespacedName, pool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return nil
+		}
+		return errors.Wrapf(err, "failed to retrieve pool %q", c.namespacedName.Name)
+	}
+	if pool.Status == nil {
+		pool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+
+	pool.Status.Usage = usage
+	if pool.Spec.Mirroring.Enabled {
+		pool.Status.MirroringStatus = getMirroringStatus(c.context, pool)
+	}
+	return opcontroller.UpdateStatus(c.client, pool)
+}
+
+// getPoolUsage returns the usage of the pool and the health of its PGs, with the error in the details if it failed
+// to be retrieved
+func getPoolUsage(context *clusterd.Context, namespacedName types.NamespacedName) *cephv1.PoolUsageStatus {
+	usage := &cephv1.PoolUsageStatus{LastChecked: time.Now().UTC().Format(time.RFC3339)}
+
+	stats, err := cephclient.GetPoolStats(context, namespacedName.Namespace)
+	if err != nil {
+		usage.Details = err.Error()
+		return usage
+	}
+	found := false
+	for _, pool := range stats.Pools {
+		if pool.Name == namespacedName.Name {
+			usage.UsedBytes = uint64(pool.Stats.BytesUsed)
+			usage.AvailableBytes = uint64(pool.Stats.MaxAvail)
+			found = true
+			break
+		}
+	}
+	if !found {
+		usage.Details = "pool not found in the cluster usage"
+		return usage
+	}
+
+	states, err := cephclient.GetPoolPGStates(context, namespacedName.Namespace, namespacedName.Name)
+	if err != nil {
+		usage.Details = err.Error()
+		return usage
+	}
+	usage.PGStates = states
+	usage.PGHealth = pgHealth(states)
+	return usage
+}
+
+// pgHealth returns the health of PGs from the number of PGs in each state
+func pgHealth(states map[string]int) string {
+	health := pgHealthOK
+	for state := range states {
+		flags := strings.Split(state, "+")
+		if !contains(flags, "active") {
+			return pgHealthErr
+		}
+		if !contains(flags, "clean") {
+			health = pgHealthWarn
+		}
+	}
+	return health
+}
+
+func contains(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// startMonitoring starts the status checker of the pool if not running
+func (r *ReconcileCephBlockPool) startMonitoring(namespacedName types.NamespacedName) {
+	if r.poolChannels == nil {
+		r.poolChannels = make(map[string]chan struct{})
+	}
+	if _, ok := r.poolChannels[namespacedName.String()]; ok {
+		logger.Debugf("status checker of pool %q already running", namespacedName.Name)
+		return
+	}
+
+	stopChan := make(chan struct{})
+	r.poolChannels[namespacedName.String()] = stopChan
+	checker := newPoolChecker(r.context, r.client, namespacedName)
+	logger.Infof("starting status checker of pool %q", namespacedName.Name)
+	go checker.checkPool(stopChan)
+}
+
+// stopMonitoring stops the status checker of the pool if running
+func (r *ReconcileCephBlockPool) stopMonitoring(namespacedName types.NamespacedName) {
+	stopChan, ok := r.poolChannels[namespacedName.String()]
+	if !ok {
+		return
+	}
+	close(stopChan)
+	delete(r.poolChannels, namespacedName.String())
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPGHealth(t *testing.T) {
+	assert.Equal(t, "HEALTH_OK", pgHealth(map[string]int{}))
+	assert.Equal(t, "HEALTH_OK", pgHealth(map[string]int{"active+clean": 30, "active+clean+scrubbing+deep": 2}))
+	assert.Equal(t, "HEALTH_WARN", pgHealth(map[string]int{"active+clean": 30, "active+undersized+degraded": 2}))
+	assert.Equal(t, "HEALTH_ERR", pgHealth(map[string]int{"active+clean": 30, "undersized+degraded+peered": 2}))
+}
+
+func TestCheckPoolStatus(t *testing.T) {
+	namespacedName := types.NamespacedName{Name: "mypool", Namespace: "myns"}
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: namespacedName.Name, Namespace: namespacedName.Namespace},
+		Spec:       cephv1.PoolSpec{Mirroring: cephv1.MirroringSpec{Enabled: true, Mode: "image"}},
+		Status:     &cephv1.CephBlockPoolStatus{Phase: "Ready"},
+	}
+	failDf := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "df" {
+				if failDf {
+					return "", errors.New("timed out")
+				}
+				return `{"pools":[{"name":"otherpool","id":1,"stats":{"bytes_used":1,"max_avail":2}},{"name":"mypool","id":2,"stats":{"bytes_used":3072,"max_avail":1048576}}]}`, nil
+			}
+			if args[0] == "pg" && args[1] == "ls-by-pool" {
+				return `{"pg_stats":[{"pgid":"2.0","state":"active+clean"},{"pgid":"2.1","state":"active+recovering"}]}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "mirror" && args[1] == "pool" && args[2] == "status" {
+				return `{"summary":{"health":"WARNING","daemon_health":"OK","image_health":"WARNING","states":{"replaying":3,"error":1}}}`, nil
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, pool)
+	cl := fake.NewFakeClientWithScheme(s, pool)
+	checker := newPoolChecker(&clusterd.Context{Executor: executor}, cl, namespacedName)
+
+	// the usage, the health of the pgs and the mirroring status are set in the status
+	assert.NoError(t, checker.checkPoolStatus())
+	updated := &cephv1.CephBlockPool{}
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Equal(t, "Ready", updated.Status.Phase)
+	usage := updated.Status.Usage
+	assert.Equal(t, uint64(3072), usage.UsedBytes)
+	assert.Equal(t, uint64(1048576), usage.AvailableBytes)
+	assert.Equal(t, "HEALTH_WARN", usage.PGHealth)
+	assert.Equal(t, map[string]int{"active+clean": 1, "active+recovering": 1}, usage.PGStates)
+	assert.Empty(t, usage.Details)
+	assert.Equal(t, "WARNING", updated.Status.MirroringStatus.Summary.ImageHealth)
+	assert.Equal(t, map[string]int{"replaying": 3, "error": 1}, updated.Status.MirroringStatus.Summary.States)
+
+	// the error of the check is reported in the status
+	failDf = true
+	assert.NoError(t, checker.checkPoolStatus())
+	assert.NoError(t, cl.Get(context.TODO(), namespacedName, updated))
+	assert.Contains(t, updated.Status.Usage.Details, "timed out")
+	assert.NotEmpty(t, updated.Status.Usage.LastChecked)
+}
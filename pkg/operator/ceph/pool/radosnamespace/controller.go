@@ -0,0 +1,279 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package radosnamespace to manage the RADOS namespaces of the block pools, sharing a pool between tenants.
+package radosnamespace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-block-pool-rados-namespace-controller"
+	// clusterIDKey is the key of the clusterID of the namespace in the csi config, in the status info
+	clusterIDKey = "clusterID"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBlockPoolRadosNamespaceKind = reflect.TypeOf(cephv1.CephBlockPoolRadosNamespace{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBlockPoolRadosNamespaceKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephBlockPoolRadosNamespace reconciles a CephBlockPoolRadosNamespace object
+type ReconcileCephBlockPoolRadosNamespace struct {
+	client  client.Client
+	scheme  *runtime.Scheme
+	context *clusterd.Context
+}
+
+// Add creates a new CephBlockPoolRadosNamespace Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	cephv1.AddToScheme(mgr.GetScheme())
+
+	return &ReconcileCephBlockPoolRadosNamespace{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, opcontroller.ControllerOptions(controllerName, r))
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephBlockPoolRadosNamespace CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBlockPoolRadosNamespace{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephBlockPoolRadosNamespace object and makes changes based on the
+// state read and what is in the CephBlockPoolRadosNamespace.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephBlockPoolRadosNamespace) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reconcileLogger := opcontroller.ReconcileLogger(logger, "CephBlockPoolRadosNamespace", request)
+	reconcileLogger.Debug("reconciling")
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime loggin interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		reconcileLogger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephBlockPoolRadosNamespace) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephBlockPoolRadosNamespace instance
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, radosNamespace)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephBlockPoolRadosNamespace")
+	}
+
+	// The CR was just created, initializing status fields
+	if radosNamespace.Status == nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.Created, nil)
+	}
+
+	// The clusterID of the namespace in the csi config, unique across the clusters and the pools
+	clusterID := buildClusterID(radosNamespace)
+
+	// Make sure a CephCluster is present otherwise do nothing
+	cephCluster, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The namespaces are gone with the pools of a deleted CephCluster, only the csi config is left
+		if !radosNamespace.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			if err := csi.RemoveClusterConfig(r.context.Clientset, clusterID, csi.ConfigMutex); err != nil {
+				return reconcile.Result{}, errors.Wrapf(err, "failed to remove the csi config of rados namespace %q", request.NamespacedName.String())
+			}
+
+			// Remove finalizer
+			err = opcontroller.RemoveFinalizer(r.client, radosNamespace)
+			if err != nil {
+				return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, nil
+		}
+		return reconcileResponse, nil
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, radosNamespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The namespace is created in a block pool of the same namespace
+	poolName := types.NamespacedName{Name: radosNamespace.Spec.BlockPoolName, Namespace: radosNamespace.Namespace}
+	pool := &cephv1.CephBlockPool{}
+	err = r.client.Get(context.TODO(), poolName, pool)
+	if err != nil && !kerrors.IsNotFound(err) {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to get CephBlockPool %q", poolName.String())
+	}
+	poolExists := err == nil
+
+	// DELETE: the CR was deleted
+	if !radosNamespace.GetDeletionTimestamp().IsZero() {
+		// The namespace is gone with its pool. The namespace is not removed while it still has images.
+		if poolExists {
+			logger.Infof("deleting rados namespace %q", request.NamespacedName.String())
+			if err := cephclient.DeleteRadosNamespace(r.context, radosNamespace.Namespace, poolName.Name, radosNamespace.Name); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		if err := csi.RemoveClusterConfig(r.context.Clientset, clusterID, csi.ConfigMutex); err != nil {
+			return reconcile.Result{}, errors.Wrapf(err, "failed to remove the csi config of rados namespace %q", request.NamespacedName.String())
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.client, radosNamespace)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, nil
+	}
+
+	// validate the namespace settings
+	if radosNamespace.Spec.BlockPoolName == "" {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Errorf("invalid rados namespace CR %q spec, the blockPoolName must be set", radosNamespace.Name)
+	}
+
+	// The rbd namespaces are only available from nautilus
+	cephVersion, err := opcontroller.GetClusterCephVersion(cephCluster)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrapf(err, "failed to fetch ceph version from cephcluster %q", cephCluster.Name)
+	}
+	if !cephVersion.IsAtLeastNautilus() {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Errorf("ceph version %q does not support the rados namespaces of the rbd images, they require ceph nautilus or newer", cephVersion.String())
+	}
+
+	if !poolExists || pool.Status == nil || pool.Status.Phase != k8sutil.ReadyStatus {
+		logger.Debugf("CephBlockPool %q not ready for rados namespace %q, retrying in %q", poolName.String(), request.NamespacedName.String(), opcontroller.WaitForRequeueIfCephClusterNotReady.RequeueAfter.String())
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+	}
+
+	// CREATE/UPDATE RADOS NAMESPACE
+	err = cephclient.CreateRadosNamespace(r.context, radosNamespace.Namespace, poolName.Name, radosNamespace.Name)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, err
+	}
+
+	// The csi drivers find the namespace of the images from the clusterID of the storage class
+	clusterInfo, _, _, err := mon.LoadClusterInfo(r.context, radosNamespace.Namespace)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to populate cluster info")
+	}
+	err = csi.SaveRadosNamespaceConfig(r.context.Clientset, radosNamespace.Namespace, clusterID, radosNamespace.Name, clusterInfo, csi.ConfigMutex)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, k8sutil.ReconcileFailedStatus, nil)
+		return reconcile.Result{}, errors.Wrapf(err, "failed to save the csi config of rados namespace %q", request.NamespacedName.String())
+	}
+
+	// Set Ready status, we are done reconciling
+	updateStatus(r.client, request.NamespacedName, k8sutil.ReadyStatus, map[string]string{clusterIDKey: clusterID})
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// buildClusterID returns the clusterID of the namespace in the csi config, derived from the namespace of the cluster,
+// the pool and the name of the namespace
+func buildClusterID(radosNamespace *cephv1.CephBlockPoolRadosNamespace) string {
+	return k8sutil.Hash(fmt.Sprintf("%s-%s-%s", radosNamespace.Namespace, radosNamespace.Spec.BlockPoolName, radosNamespace.Name))
+}
+
+// updateStatus updates a rados namespace with a given status, and its info if known
+func updateStatus(client client.Client, name types.NamespacedName, status string, info map[string]string) {
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	if err := client.Get(context.TODO(), name, radosNamespace); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rados namespace %q to update status to %q. %v", name, status, err)
+		return
+	}
+	if radosNamespace.Status == nil {
+		radosNamespace.Status = &cephv1.CephBlockPoolRadosNamespaceStatus{}
+	}
+
+	radosNamespace.Status.Phase = status
+	if info != nil {
+		radosNamespace.Status.Info = info
+	}
+	if err := opcontroller.UpdateStatus(client, radosNamespace); err != nil {
+		logger.Errorf("failed to set rados namespace %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("rados namespace %q status updated to %q", name, status)
+}
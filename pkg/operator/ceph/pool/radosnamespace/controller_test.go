@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package radosnamespace
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	testop "github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCephBlockPoolRadosNamespaceController(t *testing.T) {
+	csi.EnableRBD = true
+	defer func() { csi.EnableRBD = false }()
+	os.Setenv(k8sutil.PodNamespaceEnvVar, "rook-ceph")
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+
+	namespace := "rook-ceph"
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "tenant1", Namespace: namespace},
+		Spec:       cephv1.RadosNamespaceSpec{BlockPoolName: "replicapool"},
+	}
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace},
+		Status:     &cephv1.CephBlockPoolStatus{Phase: ""},
+	}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:       k8sutil.ReadyStatus,
+			CephVersion: &cephv1.ClusterVersion{Version: "14.2.9-0"},
+			CephStatus:  &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+
+	// the mons of the cluster, for the csi config
+	clientset := testop.New(t, 1)
+	_, err := clientset.CoreV1().Secrets(namespace).Create(&v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.AppName, Namespace: namespace},
+		Data:       map[string][]byte{"cluster-name": []byte(namespace), "fsid": []byte("fsid"), "admin-secret": []byte("adminkey")},
+	})
+	assert.NoError(t, err)
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: mon.EndpointConfigMapName, Namespace: namespace},
+		Data:       map[string]string{mon.EndpointDataKey: "a=1.2.3.4:6789"},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, csi.CreateCsiConfigMap(namespace, clientset, nil))
+
+	namespaces := "[]"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if args[0] == "namespace" {
+				switch args[1] {
+				case "ls":
+					return namespaces, nil
+				case "create":
+					namespaces = `[{"name":"` + args[5] + `"}]`
+					return "", nil
+				case "remove":
+					namespaces = "[]"
+					return "", nil
+				}
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolRadosNamespace{}, &cephv1.CephBlockPool{}, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fake.NewFakeClientWithScheme(s, []runtime.Object{radosNamespace, pool, cephCluster}...)
+	r := &ReconcileCephBlockPoolRadosNamespace{client: cl, scheme: s, context: &clusterd.Context{Executor: executor, Clientset: clientset}}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: radosNamespace.Name, Namespace: namespace}}
+
+	// the namespace waits for its pool to be ready
+	res, err := r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.Equal(t, "[]", namespaces)
+
+	// the namespace is created in the pool and added to the csi config
+	pool.Status.Phase = k8sutil.ReadyStatus
+	assert.NoError(t, cl.Update(context.TODO(), pool))
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, `[{"name":"tenant1"}]`, namespaces)
+	assert.NoError(t, cl.Get(context.TODO(), req.NamespacedName, radosNamespace))
+	assert.Equal(t, k8sutil.ReadyStatus, radosNamespace.Status.Phase)
+	clusterID := radosNamespace.Status.Info["clusterID"]
+	assert.Equal(t, buildClusterID(radosNamespace), clusterID)
+	cm, err := clientset.CoreV1().ConfigMaps(namespace).Get(csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"clusterID":"`+clusterID+`","monitors":["1.2.3.4:6789"],"radosNamespace":"tenant1","namespace":"rook-ceph"}]`, cm.Data[csi.ConfigKey])
+
+	// the namespace is removed from the pool and the csi config when deleted
+	now := metav1.Now()
+	radosNamespace.DeletionTimestamp = &now
+	assert.NoError(t, cl.Update(context.TODO(), radosNamespace))
+	res, err = r.Reconcile(req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.Equal(t, "[]", namespaces)
+	cm, err = clientset.CoreV1().ConfigMaps(namespace).Get(csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", cm.Data[csi.ConfigKey])
+	deleted := &cephv1.CephBlockPoolRadosNamespace{}
+	assert.NoError(t, cl.Get(context.TODO(), req.NamespacedName, deleted))
+	assert.Empty(t, deleted.Finalizers)
+}
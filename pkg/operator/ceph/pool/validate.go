@@ -23,6 +23,14 @@ import (
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+)
+
+const (
+	// defaultCrushRoot is the crush root of the pools not setting one
+	defaultCrushRoot = "default"
+	// osdFailureDomain places each replica or chunk of a pool on a distinct osd
+	osdFailureDomain = "osd"
 )
 
 // ValidatePool Validate the pool arguments
@@ -36,6 +44,9 @@ func ValidatePool(context *clusterd.Context, p *cephv1.CephBlockPool) error {
 	if err := ValidatePoolSpec(context, p.Namespace, &p.Spec); err != nil {
 		return err
 	}
+	if err := csi.ValidateSnapshotClass(p.Spec.SnapshotClass); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -47,38 +58,42 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.Poo
 
 	var crush cephclient.CrushMap
 	var err error
-	if p.FailureDomain != "" || p.CrushRoot != "" {
+	crushLoaded := false
+	failureDomain := p.GetFailureDomain()
+	crushRoot := p.GetCrushRoot()
+	if failureDomain != "" || crushRoot != "" {
 		crush, err = cephclient.GetCrushMap(context, namespace)
 		if err != nil {
 			return errors.Wrap(err, "failed to get crush map")
 		}
+		crushLoaded = true
 	}
 
 	// validate the failure domain if specified
-	if p.FailureDomain != "" {
+	if failureDomain != "" {
 		found := false
 		for _, t := range crush.Types {
-			if t.Name == p.FailureDomain {
+			if t.Name == failureDomain {
 				found = true
 				break
 			}
 		}
 		if !found {
-			return errors.Errorf("unrecognized failure domain %s", p.FailureDomain)
+			return errors.Errorf("unrecognized failure domain %s", failureDomain)
 		}
 	}
 
 	// validate the crush root if specified
-	if p.CrushRoot != "" {
+	if crushRoot != "" {
 		found := false
 		for _, t := range crush.Buckets {
-			if t.Name == p.CrushRoot {
+			if t.Name == crushRoot {
 				found = true
 				break
 			}
 		}
 		if !found {
-			return errors.Errorf("unrecognized crush root %s", p.CrushRoot)
+			return errors.Errorf("unrecognized crush root %s", crushRoot)
 		}
 	}
 
@@ -87,15 +102,159 @@ func ValidatePoolSpec(context *clusterd.Context, namespace string, p *cephv1.Poo
 		return errors.Errorf("error pool size is %d and requireSafeReplicaSize is %t, must be false", p.Replicated.Size, p.Replicated.RequireSafeReplicaSize)
 	}
 
-	// validate pool compression mode if specified
-	if p.CompressionMode != "" {
-		switch p.CompressionMode {
-		case "none", "passive", "aggressive", "force":
+	// validate the cluster has enough failure domains for the replicas or the chunks of the pool
+	if required := requiredFailureDomains(p); required > 1 {
+		if !crushLoaded {
+			crush, err = cephclient.GetCrushMap(context, namespace)
+			if err != nil {
+				// the pool is still created, its failure domains just cannot be checked yet
+				logger.Warningf("failed to get crush map to check the failure domains of the pool. %v", err)
+			}
+			crushLoaded = err == nil
+		}
+		if crushLoaded {
+			if err := validateFailureDomainCount(crush, p, required); err != nil {
+				return err
+			}
+		}
+	}
+
+	// validate the cluster has osds of the device classes of a hybrid pool
+	if hybrid := p.Replicated.HybridStorage; hybrid != nil {
+		if !crushLoaded {
+			crush, err = cephclient.GetCrushMap(context, namespace)
+			if err != nil {
+				return errors.Wrap(err, "failed to get crush map")
+			}
+		}
+		if err := validateDeviceClasses(crush, hybrid.PrimaryDeviceClass, hybrid.SecondaryDeviceClass); err != nil {
+			return err
+		}
+	}
+
+	// validate the pg autoscaler settings
+	if err := cephv1.ValidatePoolAutoscaling(*p); err != nil {
+		return err
+	}
+
+	// validate pool compression mode and algorithm if specified
+	if err := cephv1.ValidateCompression(p.CompressionMode, p.CompressionAlgorithm); err != nil {
+		return err
+	}
+
+	// validate the mirroring settings if enabled
+	if p.Mirroring.Enabled {
+		switch p.Mirroring.Mode {
+		case "pool", "image":
 			break
 		default:
-			return errors.Errorf("unrecognized compression mode %q", p.CompressionMode)
+			return errors.Errorf("unrecognized mirroring mode %q. only 'image' and 'pool' are supported", p.Mirroring.Mode)
+		}
+		for _, schedule := range p.Mirroring.SnapshotSchedules {
+			if schedule.Interval == "" {
+				return errors.New("the interval of a mirroring snapshot schedule must be specified")
+			}
+			if schedule.Path != "" || schedule.Retention != "" {
+				return errors.New("the path and the retention of a snapshot schedule are only supported by the filesystems")
+			}
 		}
 	}
 
 	return nil
 }
+
+// requiredFailureDomains returns the number of failure domains needed to place the replicas or the chunks of the pool
+func requiredFailureDomains(p *cephv1.PoolSpec) uint {
+	if p.IsErasureCoded() {
+		return p.ErasureCoded.DataChunks + p.ErasureCoded.CodingChunks
+	}
+	return p.Replicated.Size
+}
+
+// validateFailureDomainCount ensures the crush root of the pool has enough failure domains for the pool to become healthy.
+// A cluster without any failure domain yet, which has no osd, is only warned about.
+func validateFailureDomainCount(crush cephclient.CrushMap, p *cephv1.PoolSpec, required uint) error {
+	failureDomain := p.GetFailureDomain()
+	if failureDomain == "" {
+		failureDomain = cephv1.DefaultFailureDomain
+	}
+	crushRoot := p.GetCrushRoot()
+	if crushRoot == "" {
+		crushRoot = defaultCrushRoot
+	}
+
+	count := countFailureDomains(crush, crushRoot, failureDomain)
+	if count == 0 {
+		logger.Warningf("no failure domain of type %q found under crush root %q yet, the pool needs %d of them to become healthy", failureDomain, crushRoot, required)
+		return nil
+	}
+	if count < required {
+		return errors.Errorf("pool needs %d failure domains of type %q but crush root %q only has %d, its placement groups would never be clean", required, failureDomain, crushRoot, count)
+	}
+	return nil
+}
+
+// validateDeviceClasses ensures the cluster has osds of the device classes. A cluster without any osd yet is only warned about.
+func validateDeviceClasses(crush cephclient.CrushMap, deviceClasses ...string) error {
+	if len(crush.Devices) == 0 {
+		logger.Warningf("no osd found yet, the pool needs osds of the device classes %v to become healthy", deviceClasses)
+		return nil
+	}
+	for _, deviceClass := range deviceClasses {
+		found := false
+		for _, device := range crush.Devices {
+			if device.Class == deviceClass {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("no osd of device class %q found, its placement groups would never be clean", deviceClass)
+		}
+	}
+	return nil
+}
+
+// countFailureDomains counts the buckets of the failure domain type under the crush root, or its osds for the osd failure domain
+func countFailureDomains(crush cephclient.CrushMap, crushRoot, failureDomain string) uint {
+	buckets := map[int]int{}
+	root := -1
+	for i, b := range crush.Buckets {
+		buckets[b.ID] = i
+		if b.Name == crushRoot {
+			root = i
+		}
+	}
+	if root < 0 {
+		return 0
+	}
+
+	var count uint
+	visited := map[int]struct{}{}
+	pending := []int{root}
+	for len(pending) > 0 {
+		bucket := crush.Buckets[pending[0]]
+		pending = pending[1:]
+		if _, ok := visited[bucket.ID]; ok {
+			continue
+		}
+		visited[bucket.ID] = struct{}{}
+		if bucket.TypeName == failureDomain {
+			count++
+			continue
+		}
+		for _, item := range bucket.Items {
+			// the osds have a positive id, the buckets a negative one
+			if item.ID >= 0 {
+				if failureDomain == osdFailureDomain {
+					count++
+				}
+				continue
+			}
+			if i, ok := buckets[item.ID]; ok {
+				pending = append(pending, i)
+			}
+		}
+	}
+	return count
+}
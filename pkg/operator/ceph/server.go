@@ -17,6 +17,9 @@ limitations under the License.
 package operator
 
 import (
+	"os"
+	"strings"
+
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -30,7 +33,7 @@ type AdmissionController struct {
 
 var (
 	scheme    = runtime.NewScheme()
-	resources = []webhook.Validator{&cephv1.CephCluster{}, &cephv1.CephBlockPool{}}
+	resources = []webhook.Validator{&cephv1.CephCluster{}, &cephv1.CephBlockPool{}, &cephv1.CephObjectStore{}, &cephv1.CephFilesystem{}}
 )
 
 const (
@@ -38,11 +41,21 @@ const (
 	certDir = "/etc/webhook"
 	// Default port for server
 	port = 8079
+	// Env var rejecting the downgrades of the ceph image instead of only warning
+	rejectCephDowngradesEnvVar = "ROOK_WEBHOOK_REJECT_CEPH_DOWNGRADES"
+	// Env var listing the comma-separated namespaces, besides their own, where the clusters may create their prometheus rules
+	allowedRulesNamespacesEnvVar = "ROOK_WEBHOOK_ALLOWED_RULES_NAMESPACES"
 )
 
 // StartAdmissionController will start the server
 func StartAdmissionController() error {
 	logger.Infof("starting the webhook for backend ceph")
+	cephv1.RejectCephDowngrades = os.Getenv(rejectCephDowngradesEnvVar) == "true"
+	for _, namespace := range strings.Split(os.Getenv(allowedRulesNamespacesEnvVar), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			cephv1.AllowedRulesNamespaces = append(cephv1.AllowedRulesNamespaces, namespace)
+		}
+	}
 	err := cephv1.AddToScheme(scheme)
 	if err != nil {
 		return errors.Wrap(err, "failed to add to scheme")
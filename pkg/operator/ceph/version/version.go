@@ -40,12 +40,16 @@ const (
 var (
 	// Minimum supported version is 14.2.5
 	Minimum = CephVersion{14, 2, 5, 0}
+	// MinimumExternal is the minimum version of an external cluster, 12.2.0
+	MinimumExternal = CephVersion{12, 2, 0, 0}
 	// Nautilus Ceph version
 	Nautilus = CephVersion{14, 0, 0, 0}
 	// Octopus Ceph version
 	Octopus = CephVersion{15, 0, 0, 0}
 	// Pacific Ceph version
 	Pacific = CephVersion{16, 0, 0, 0}
+	// Quincy Ceph version
+	Quincy = CephVersion{17, 0, 0, 0}
 
 	// supportedVersions are production-ready versions that rook supports
 	supportedVersions   = []CephVersion{Nautilus, Octopus}
@@ -174,6 +178,11 @@ func (v *CephVersion) IsAtLeast(other CephVersion) bool {
 	return true
 }
 
+// IsAtLeastQuincy check that the Ceph version is at least Quincy
+func (v *CephVersion) IsAtLeastQuincy() bool {
+	return v.IsAtLeast(Quincy)
+}
+
 // IsAtLeastPacific check that the Ceph version is at least Pacific
 func (v *CephVersion) IsAtLeastPacific() bool {
 	return v.IsAtLeast(Pacific)
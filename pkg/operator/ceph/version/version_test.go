@@ -145,6 +145,8 @@ func TestVersionAtLeastX(t *testing.T) {
 	assert.True(t, Pacific.IsAtLeastPacific())
 	assert.False(t, Nautilus.IsAtLeastOctopus())
 	assert.False(t, Nautilus.IsAtLeastPacific())
+	assert.True(t, Quincy.IsAtLeastQuincy())
+	assert.False(t, Pacific.IsAtLeastQuincy())
 }
 
 func TestIsIdentical(t *testing.T) {
@@ -123,6 +123,7 @@ func createWebhookDeployment(context *clusterd.Context, admissionImage string) e
 
 	antiAffinity := csi.GetPodAntiAffinity(k8sutil.AppAttr, appName)
 	admissionControllerDeployment := getDeployment(secretVolume, antiAffinity, admissionImage, admission_parameters, secretVolumeMount)
+	k8sutil.ApplyImagePullSecrets(namespace, &admissionControllerDeployment.Spec.Template.Spec)
 
 	err := k8sutil.CreateDeployment(context.Clientset, appName, namespace, &admissionControllerDeployment)
 	if err != nil {
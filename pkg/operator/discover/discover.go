@@ -167,6 +167,7 @@ func (d *Discover) createDiscoverDaemonSet(namespace, discoverImage, securityAcc
 					},
 					HostNetwork:       false,
 					PriorityClassName: os.Getenv(discoverDaemonsetPriorityClassNameEnv),
+					ImagePullSecrets:  k8sutil.GetImagePullSecrets(namespace),
 				},
 			},
 		},
@@ -299,6 +300,35 @@ func ListDevices(context *clusterd.Context, namespace, nodeName string) (map[str
 	return devices, nil
 }
 
+// GetNodeDevices returns the devices published by the discovery daemon for a node. Unlike ListDevices it
+// does not wait for the configmap of the node, the second value being false when it does not exist yet.
+func GetNodeDevices(context *clusterd.Context, namespace, nodeName string) ([]sys.LocalDisk, bool, error) {
+	// convert the host name label to the k8s node name to look up the configmap with the devices
+	nodeName, err := k8sutil.GetNodeNameFromHostname(context.Clientset, nodeName)
+	if err != nil {
+		logger.Warningf("failed to get node name from hostname. %+v", err)
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s=%s", k8sutil.AppAttr, discoverDaemon.AppName, discoverDaemon.NodeAttr, nodeName)}
+	cms, err := context.Clientset.CoreV1().ConfigMaps(namespace).List(listOpts)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to list device configmaps of node %s: %+v", nodeName, err)
+	}
+	if len(cms.Items) == 0 {
+		return nil, false, nil
+	}
+
+	var devices []sys.LocalDisk
+	deviceJson := cms.Items[0].Data[discoverDaemon.LocalDiskCMData]
+	if len(deviceJson) == 0 {
+		return devices, true, nil
+	}
+	if err := json.Unmarshal([]byte(deviceJson), &devices); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal the devices of node %s: %+v", nodeName, err)
+	}
+	return devices, true, nil
+}
+
 // ListDevicesInUse lists all devices on a node that are already used by existing clusters.
 func ListDevicesInUse(context *clusterd.Context, namespace, nodeName string) ([]sys.LocalDisk, error) {
 	var devices []sys.LocalDisk
@@ -293,6 +293,7 @@ func (cr *cmdReporterCfg) initJobSpec() (*batch.Job, error) {
 	}
 	copyBinsVol, _ := copyBinariesVolAndMount()
 	podSpec.Volumes = []v1.Volume{copyBinsVol}
+	k8sutil.ApplyImagePullSecrets(cr.jobNamespace, &podSpec)
 
 	commonLabels := map[string]string{k8sutil.AppAttr: cr.appName}
 	job := &batch.Job{
@@ -33,6 +33,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 )
 
+// RestartedAtAnnotation is the annotation of the pod template of a deployment restarting its pods when changed
+const RestartedAtAnnotation = "kubectl.kubernetes.io/restartedAt"
+
 // GetDeploymentImage returns the version of the image running in the pod spec for the desired container
 func GetDeploymentImage(clientset kubernetes.Interface, namespace, name, container string) (string, error) {
 	d, err := clientset.AppsV1().Deployments(namespace).Get(name, metav1.GetOptions{})
@@ -150,6 +153,27 @@ func GetDeployments(clientset kubernetes.Interface, namespace, labelSelector str
 	return deployments, nil
 }
 
+// RestartDeployments rolls the pods of the deployments matching the label selector, the same way as `kubectl rollout restart`
+func RestartDeployments(clientset kubernetes.Interface, namespace, labelSelector string) error {
+	deployments, err := GetDeployments(clientset, namespace, labelSelector)
+	if err != nil {
+		return err
+	}
+	restartedAt := time.Now().Format(time.RFC3339)
+	for i := range deployments.Items {
+		d := &deployments.Items[i]
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations[RestartedAtAnnotation] = restartedAt
+		logger.Infof("restarting deployment %q", d.Name)
+		if _, err := clientset.AppsV1().Deployments(namespace).Update(d); err != nil {
+			return fmt.Errorf("failed to restart deployment %s: %v", d.Name, err)
+		}
+	}
+	return nil
+}
+
 // DeleteDeployment makes a best effort at deleting a deployment and its pods, then waits for them to be deleted
 func DeleteDeployment(clientset kubernetes.Interface, namespace, name string) error {
 	logger.Debugf("removing %s deployment if it exists", name)
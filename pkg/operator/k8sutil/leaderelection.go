@@ -0,0 +1,140 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+	// leaseExpiryTolerance is how long the renewal of a held lease may be overdue before the operator is unhealthy
+	leaseExpiryTolerance = 20 * time.Second
+)
+
+// LeaderElections runs the watchers of the operator that must only run in a single replica of the operator, each
+// watcher running in the replica holding its lease in the namespace of the operator
+type LeaderElections struct {
+	clientset kubernetes.Interface
+	namespace string
+	identity  string
+	mutex     sync.Mutex
+	electors  map[string]*leaderelection.LeaderElector
+}
+
+// NewLeaderElections returns the leader elections of the operator running in the given namespace, the operator being
+// identified by the name of its pod
+func NewLeaderElections(clientset kubernetes.Interface, namespace string) *LeaderElections {
+	identity := os.Getenv(PodNameEnvVar)
+	if identity == "" {
+		identity, _ = os.Hostname()
+	}
+	return &LeaderElections{
+		clientset: clientset,
+		namespace: namespace,
+		identity:  identity,
+		electors:  map[string]*leaderelection.LeaderElector{},
+	}
+}
+
+// Run runs a watcher while the operator holds the lease of the given name, until the stop channel is closed. The
+// stop channel passed to the watcher is closed when the lease is lost, the watcher being started again once the lease
+// is acquired again.
+func (l *LeaderElections) Run(name string, stopCh <-chan struct{}, run func(stopCh chan struct{})) error {
+	lock, err := resourcelock.New(resourcelock.LeasesResourceLock, l.namespace, name,
+		l.clientset.CoreV1(), l.clientset.CoordinationV1(), resourcelock.ResourceLockConfig{Identity: l.identity})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the lease %q", name)
+	}
+	elector, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		Name:            name,
+		LeaseDuration:   leaseDuration,
+		RenewDeadline:   renewDeadline,
+		RetryPeriod:     retryPeriod,
+		ReleaseOnCancel: true,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				logger.Infof("acquired lease %q, starting its watcher", name)
+				watcherStopCh := make(chan struct{})
+				go func() {
+					<-ctx.Done()
+					close(watcherStopCh)
+				}()
+				run(watcherStopCh)
+			},
+			OnStoppedLeading: func() {
+				logger.Infof("released lease %q, its watcher is stopped", name)
+			},
+		},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to create the leader election of lease %q", name)
+	}
+
+	l.mutex.Lock()
+	l.electors[name] = elector
+	l.mutex.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+	go func() {
+		defer func() {
+			l.mutex.Lock()
+			// the lease may be run again by a new election once this one is stopped
+			if l.electors[name] == elector {
+				delete(l.electors, name)
+			}
+			l.mutex.Unlock()
+		}()
+		// the election returns when the lease is lost, the operator then competing for the lease again
+		for {
+			elector.Run(ctx)
+			if ctx.Err() != nil {
+				return
+			}
+			logger.Warningf("lost lease %q, waiting to acquire it again", name)
+		}
+	}()
+	return nil
+}
+
+// Check is the health check of the leader elections, failing when the operator holds a lease it failed to renew
+func (l *LeaderElections) Check(req *http.Request) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for name, elector := range l.electors {
+		if err := elector.Check(leaseExpiryTolerance); err != nil {
+			return errors.Wrapf(err, "failed to renew lease %q", name)
+		}
+	}
+	return nil
+}
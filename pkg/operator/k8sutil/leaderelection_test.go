@@ -0,0 +1,61 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestLeaderElections(t *testing.T) {
+	os.Setenv(PodNameEnvVar, "rook-ceph-operator-a")
+	defer os.Unsetenv(PodNameEnvVar)
+	clientset := fake.NewSimpleClientset()
+	elections := NewLeaderElections(clientset, "rook-ceph")
+
+	started := make(chan chan struct{}, 1)
+	stopCh := make(chan struct{})
+	err := elections.Run("rook-ceph-test-provisioner", stopCh, func(watcherStopCh chan struct{}) {
+		started <- watcherStopCh
+	})
+	assert.NoError(t, err)
+
+	// the watcher is started once the lease is acquired
+	var watcherStopCh chan struct{}
+	select {
+	case watcherStopCh = <-started:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the watcher was not started")
+	}
+	lease, err := clientset.CoordinationV1().Leases("rook-ceph").Get("rook-ceph-test-provisioner", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-operator-a", *lease.Spec.HolderIdentity)
+	assert.NoError(t, elections.Check(nil))
+
+	// the watcher is stopped with the election
+	close(stopCh)
+	select {
+	case <-watcherStopCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the watcher was not stopped")
+	}
+}
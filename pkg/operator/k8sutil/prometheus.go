@@ -34,6 +34,9 @@ import (
 
 func getMonitoringClient() (*monitoringclient.Clientset, error) {
 	cfg, err := clientcmd.BuildConfigFromFlags("", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build the monitoring client config. %v", err)
+	}
 	client, err := monitoringclient.NewForConfig(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monitoring client. %v", err)
@@ -83,6 +86,19 @@ func CreateOrUpdateServiceMonitor(serviceMonitorDefinition *monitoringv1.Service
 	return sm, nil
 }
 
+// DeleteServiceMonitor deletes a serviceMonitor, ignoring a serviceMonitor or a monitoring api not found
+func DeleteServiceMonitor(namespace, name string) error {
+	client, err := getMonitoringClient()
+	if err != nil {
+		return fmt.Errorf("failed to get monitoring client. %v", err)
+	}
+	err = client.MonitoringV1().ServiceMonitors(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete servicemonitor. %v", err)
+	}
+	return nil
+}
+
 // GetPrometheusRule returns provided prometheus rules or an error
 func GetPrometheusRule(ruleFilePath string) (*monitoringv1.PrometheusRule, error) {
 	ruleFile, err := ioutil.ReadFile(filepath.Clean(ruleFilePath))
@@ -118,6 +134,8 @@ func CreateOrUpdatePrometheusRule(prometheusRule *monitoringv1.PrometheusRule) (
 			return nil, fmt.Errorf("failed to get prometheusRule object. %v", err)
 		}
 		prometheusRule.ObjectMeta.ResourceVersion = promRule.ObjectMeta.ResourceVersion
+		// keep the owners of the rule, which may be shared by several clusters
+		prometheusRule.OwnerReferences = mergeOwnerRefs(promRule.OwnerReferences, prometheusRule.OwnerReferences)
 
 		promRule, err = client.MonitoringV1().PrometheusRules(namespace).Update(prometheusRule)
 		if err != nil {
@@ -126,3 +144,64 @@ func CreateOrUpdatePrometheusRule(prometheusRule *monitoringv1.PrometheusRule) (
 	}
 	return promRule, nil
 }
+
+// RemovePrometheusRuleOwner removes an owner of a prometheusRule, and deletes the prometheusRule once it has no
+// owner left. A prometheusRule or a monitoring api not found is ignored.
+func RemovePrometheusRuleOwner(namespace, name string, owner metav1.OwnerReference) error {
+	client, err := getMonitoringClient()
+	if err != nil {
+		return fmt.Errorf("failed to get monitoring client. %v", err)
+	}
+	promRule, err := client.MonitoringV1().PrometheusRules(namespace).Get(name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get prometheusRule object. %v", err)
+	}
+	owners := removeOwnerRef(promRule.OwnerReferences, owner)
+	if len(owners) == len(promRule.OwnerReferences) {
+		return nil
+	}
+	if len(owners) > 0 {
+		promRule.OwnerReferences = owners
+		if _, err := client.MonitoringV1().PrometheusRules(namespace).Update(promRule); err != nil {
+			return fmt.Errorf("failed to update prometheusRule. %v", err)
+		}
+		return nil
+	}
+	return DeletePrometheusRule(namespace, name)
+}
+
+func mergeOwnerRefs(existing, owners []metav1.OwnerReference) []metav1.OwnerReference {
+	merged := append([]metav1.OwnerReference{}, owners...)
+	for _, ref := range existing {
+		if len(removeOwnerRef(merged, ref)) == len(merged) {
+			merged = append(merged, ref)
+		}
+	}
+	return merged
+}
+
+func removeOwnerRef(owners []metav1.OwnerReference, owner metav1.OwnerReference) []metav1.OwnerReference {
+	result := []metav1.OwnerReference{}
+	for _, ref := range owners {
+		if ref.UID != owner.UID {
+			result = append(result, ref)
+		}
+	}
+	return result
+}
+
+// DeletePrometheusRule deletes a prometheusRule, ignoring a prometheusRule or a monitoring api not found
+func DeletePrometheusRule(namespace, name string) error {
+	client, err := getMonitoringClient()
+	if err != nil {
+		return fmt.Errorf("failed to get monitoring client. %v", err)
+	}
+	err = client.MonitoringV1().PrometheusRules(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete prometheusRule. %v", err)
+	}
+	return nil
+}
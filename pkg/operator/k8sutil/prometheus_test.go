@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestGetServiceMonitor(t *testing.T) {
@@ -47,3 +48,16 @@ func TestGetPrometheusRule(t *testing.T) {
 	assert.NotNil(t, rules.GetLabels())
 	assert.NotNil(t, rules.Spec.Groups)
 }
+
+func TestPrometheusRuleOwners(t *testing.T) {
+	owner1 := metav1.OwnerReference{Name: "cluster1", UID: "uid1"}
+	owner2 := metav1.OwnerReference{Name: "cluster2", UID: "uid2"}
+
+	// The owners of a shared rule are kept when it is updated by a cluster
+	owners := mergeOwnerRefs([]metav1.OwnerReference{owner1, owner2}, []metav1.OwnerReference{owner1})
+	assert.Equal(t, []metav1.OwnerReference{owner1, owner2}, owners)
+
+	owners = removeOwnerRef(owners, owner1)
+	assert.Equal(t, []metav1.OwnerReference{owner2}, owners)
+	assert.Empty(t, removeOwnerRef(owners, owner2))
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"sync"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// pullSecrets are the image pull secrets added to the pods generated by the operator. The secrets being namespaced,
+// the secrets of the clusters are kept by namespace, and the secrets of the operator pod are only added to the pods
+// of the operator namespace.
+var pullSecrets = struct {
+	sync.RWMutex
+	namespaces        map[string][]v1.LocalObjectReference
+	operatorNamespace string
+	operator          []v1.LocalObjectReference
+}{namespaces: map[string][]v1.LocalObjectReference{}}
+
+// SetImagePullSecrets sets the image pull secrets of the pods generated in the namespace
+func SetImagePullSecrets(namespace string, secrets []v1.LocalObjectReference) {
+	pullSecrets.Lock()
+	defer pullSecrets.Unlock()
+	if len(secrets) == 0 {
+		delete(pullSecrets.namespaces, namespace)
+		return
+	}
+	pullSecrets.namespaces[namespace] = append([]v1.LocalObjectReference{}, secrets...)
+}
+
+// SetOperatorImagePullSecrets sets the image pull secrets of the operator pod, added to the pods generated in the
+// operator namespace
+func SetOperatorImagePullSecrets(namespace string, secrets []v1.LocalObjectReference) {
+	pullSecrets.Lock()
+	defer pullSecrets.Unlock()
+	pullSecrets.operatorNamespace = namespace
+	pullSecrets.operator = append([]v1.LocalObjectReference{}, secrets...)
+}
+
+// GetImagePullSecrets returns the image pull secrets of the pods generated in the namespace
+func GetImagePullSecrets(namespace string) []v1.LocalObjectReference {
+	pullSecrets.RLock()
+	defer pullSecrets.RUnlock()
+	var secrets []v1.LocalObjectReference
+	if namespace == pullSecrets.operatorNamespace {
+		secrets = appendPullSecrets(secrets, pullSecrets.operator)
+	}
+	return appendPullSecrets(secrets, pullSecrets.namespaces[namespace])
+}
+
+// ApplyImagePullSecrets adds the image pull secrets of the namespace to the pod spec
+func ApplyImagePullSecrets(namespace string, podSpec *v1.PodSpec) {
+	podSpec.ImagePullSecrets = appendPullSecrets(podSpec.ImagePullSecrets, GetImagePullSecrets(namespace))
+}
+
+func appendPullSecrets(secrets, add []v1.LocalObjectReference) []v1.LocalObjectReference {
+	for _, secret := range add {
+		found := false
+		for _, s := range secrets {
+			if s.Name == secret.Name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
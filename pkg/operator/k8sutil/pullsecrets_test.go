@@ -0,0 +1,57 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package k8sutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestApplyImagePullSecrets(t *testing.T) {
+	defer SetOperatorImagePullSecrets("", nil)
+	defer SetImagePullSecrets("rook-ceph", nil)
+	defer SetImagePullSecrets("other", nil)
+
+	// no secrets by default
+	podSpec := v1.PodSpec{}
+	ApplyImagePullSecrets("rook-ceph", &podSpec)
+	assert.Nil(t, podSpec.ImagePullSecrets)
+
+	// the secrets of the cluster are added to the pods of its namespace only
+	SetImagePullSecrets("rook-ceph", []v1.LocalObjectReference{{Name: "registry"}})
+	ApplyImagePullSecrets("rook-ceph", &podSpec)
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "registry"}}, podSpec.ImagePullSecrets)
+	assert.Nil(t, GetImagePullSecrets("other"))
+
+	// the secrets are not added twice
+	ApplyImagePullSecrets("rook-ceph", &podSpec)
+	assert.Equal(t, 1, len(podSpec.ImagePullSecrets))
+
+	// the secrets of the operator are added to the pods of the operator namespace
+	SetOperatorImagePullSecrets("rook-ceph", []v1.LocalObjectReference{{Name: "operator"}, {Name: "registry"}})
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "operator"}, {Name: "registry"}}, GetImagePullSecrets("rook-ceph"))
+	SetImagePullSecrets("other", []v1.LocalObjectReference{{Name: "other"}})
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "other"}}, GetImagePullSecrets("other"))
+
+	// the secrets removed from the cluster are no longer added
+	SetImagePullSecrets("rook-ceph", nil)
+	assert.Equal(t, []v1.LocalObjectReference{{Name: "operator"}, {Name: "registry"}}, GetImagePullSecrets("rook-ceph"))
+	SetOperatorImagePullSecrets("rook-ceph", nil)
+	assert.Nil(t, GetImagePullSecrets("rook-ceph"))
+}
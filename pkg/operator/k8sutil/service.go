@@ -19,6 +19,7 @@ package k8sutil
 import (
 	"fmt"
 
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
 	v1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -31,6 +32,7 @@ func CreateOrUpdateService(
 ) (*v1.Service, error) {
 	name := serviceDefinition.Name
 	logger.Debugf("creating service %s", name)
+	setServiceLastApplied(serviceDefinition)
 	s, err := clientset.CoreV1().Services(namespace).Create(serviceDefinition)
 	if err != nil {
 		if !errors.IsAlreadyExists(err) {
@@ -55,13 +57,59 @@ func UpdateService(
 	if err != nil {
 		return nil, fmt.Errorf("could not get existing service %s in order to update. %+v", name, err)
 	}
+	setServiceLastApplied(serviceDefinition)
 	// ClusterIP is immutable for k8s services and cannot be left empty in k8s v1 API
 	serviceDefinition.Spec.ClusterIP = existing.Spec.ClusterIP
+	// the node ports are allocated by kubernetes, they would be reallocated if left empty
+	keepNodePorts(serviceDefinition, existing)
 	// ResourceVersion required to update services in k8s v1 API to prevent race conditions
 	serviceDefinition.ResourceVersion = existing.ResourceVersion
 	return clientset.CoreV1().Services(namespace).Update(serviceDefinition)
 }
 
+// keepNodePorts keeps the node ports allocated to the existing service when the ports are not set by the definition
+func keepNodePorts(serviceDefinition, existing *v1.Service) {
+	if serviceDefinition.Spec.Type != v1.ServiceTypeNodePort && serviceDefinition.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return
+	}
+	nodePorts := map[string]int32{}
+	for _, port := range existing.Spec.Ports {
+		nodePorts[port.Name] = port.NodePort
+	}
+	for i, port := range serviceDefinition.Spec.Ports {
+		if port.NodePort == 0 {
+			serviceDefinition.Spec.Ports[i].NodePort = nodePorts[port.Name]
+		}
+	}
+	if serviceDefinition.Spec.HealthCheckNodePort == 0 {
+		serviceDefinition.Spec.HealthCheckNodePort = existing.Spec.HealthCheckNodePort
+	}
+}
+
+// LoadBalancerAddresses returns the IPs or the hostnames of the load balancer of a service, none until the load
+// balancer is provisioned
+func LoadBalancerAddresses(service *v1.Service) []string {
+	addresses := []string{}
+	if service.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return addresses
+	}
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		if ingress.IP != "" {
+			addresses = append(addresses, ingress.IP)
+		} else if ingress.Hostname != "" {
+			addresses = append(addresses, ingress.Hostname)
+		}
+	}
+	return addresses
+}
+
+// setServiceLastApplied keeps the applied service in an annotation, to detect the changes made out of band
+func setServiceLastApplied(serviceDefinition *v1.Service) {
+	if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(serviceDefinition); err != nil {
+		logger.Warningf("failed to set the last applied annotation on service %q. %v", serviceDefinition.Name, err)
+	}
+}
+
 // DeleteService deletes a Service and returns the error if any
 func DeleteService(clientset kubernetes.Interface, namespace, name string) error {
 	err := clientset.CoreV1().Services(namespace).Delete(name, &metav1.DeleteOptions{})
@@ -22,6 +22,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestParseServiceType(t *testing.T) {
@@ -35,3 +37,42 @@ func TestParseServiceType(t *testing.T) {
 		assert.Equal(t, v1.ServiceType(""), ParseServiceType(serviceType))
 	}
 }
+
+func TestUpdateServiceKeepsNodePorts(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	svc := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "rgw", Namespace: "ns"},
+		Spec: v1.ServiceSpec{
+			Type:  v1.ServiceTypeLoadBalancer,
+			Ports: []v1.ServicePort{{Name: "http", Port: 80, NodePort: 30080}},
+		},
+	}
+	_, err := clientset.CoreV1().Services("ns").Create(svc)
+	assert.NoError(t, err)
+
+	// the node port allocated to the service is kept
+	updated := svc.DeepCopy()
+	updated.Spec.Ports[0].NodePort = 0
+	updated.Spec.Ports = append(updated.Spec.Ports, v1.ServicePort{Name: "https", Port: 443})
+	svc, err = UpdateService(clientset, "ns", updated)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(30080), svc.Spec.Ports[0].NodePort)
+	assert.Equal(t, int32(0), svc.Spec.Ports[1].NodePort)
+
+	// the node ports are not kept by the services without node ports
+	updated = svc.DeepCopy()
+	updated.Spec.Type = v1.ServiceTypeClusterIP
+	updated.Spec.Ports[0].NodePort = 0
+	svc, err = UpdateService(clientset, "ns", updated)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), svc.Spec.Ports[0].NodePort)
+}
+
+func TestLoadBalancerAddresses(t *testing.T) {
+	svc := &v1.Service{}
+	svc.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "1.2.3.4"}, {Hostname: "lb.example.com"}}
+	assert.Equal(t, []string{}, LoadBalancerAddresses(svc))
+
+	svc.Spec.Type = v1.ServiceTypeLoadBalancer
+	assert.Equal(t, []string{"1.2.3.4", "lb.example.com"}, LoadBalancerAddresses(svc))
+}
@@ -27,6 +27,8 @@ const (
 	ReconcilingStatus = "Reconciling"
 	// ReconcileFailedStatus indicates a reconciliation failed
 	ReconcileFailedStatus = "ReconcileFailed"
+	// DeletionBlockedStatus indicates the CR is not deleted until its dependents are removed
+	DeletionBlockedStatus = "DeletionIsBlocked"
 	// Created indicates the object just got created
 	Created = "Created"
 )
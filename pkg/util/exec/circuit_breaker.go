@@ -0,0 +1,130 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CircuitOpenError is returned instead of running a command while the circuit breaker is open
+type CircuitOpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("not running the command, %s is unreachable. retrying in %s", e.Name, e.RetryAfter.Round(time.Second).String())
+}
+
+// IsCircuitOpen returns whether the error, or its cause, is a CircuitOpenError
+func IsCircuitOpen(err error) bool {
+	_, ok := errors.Cause(err).(*CircuitOpenError)
+	return ok
+}
+
+// CircuitBreaker fails the commands immediately after consecutive commands failed to reach their target, instead of
+// running more commands that would hang until their timeout. Once the backoff elapsed, a single command runs to probe
+// the target, closing the breaker if it succeeds or doubling the backoff up to the max backoff if it fails.
+type CircuitBreaker struct {
+	name        string
+	threshold   int
+	baseBackoff time.Duration
+	maxBackoff  time.Duration
+	mutex       sync.Mutex
+	failures    int
+	backoff     time.Duration
+	openUntil   time.Time
+	probing     bool
+	now         func() time.Time
+}
+
+// NewCircuitBreaker returns a closed circuit breaker opening after threshold consecutive failures
+func NewCircuitBreaker(name string, threshold int, baseBackoff, maxBackoff time.Duration) *CircuitBreaker {
+	if maxBackoff < baseBackoff {
+		maxBackoff = baseBackoff
+	}
+	return &CircuitBreaker{
+		name:        name,
+		threshold:   threshold,
+		baseBackoff: baseBackoff,
+		maxBackoff:  maxBackoff,
+		now:         time.Now,
+	}
+}
+
+// Allow returns a CircuitOpenError if the command must not run
+func (b *CircuitBreaker) Allow() error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if b.backoff == 0 {
+		return nil
+	}
+	now := b.now()
+	if now.Before(b.openUntil) || b.probing {
+		retryAfter := b.openUntil.Sub(now)
+		if retryAfter < 0 {
+			retryAfter = 0
+		}
+		return &CircuitOpenError{Name: b.name, RetryAfter: retryAfter}
+	}
+	logger.Infof("probing whether %s is reachable again", b.name)
+	b.probing = true
+	return nil
+}
+
+// Record records the result of a command allowed by the breaker, unreachable being whether the command failed to
+// reach the target
+func (b *CircuitBreaker) Record(unreachable bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if !unreachable {
+		if b.backoff != 0 {
+			logger.Infof("%s is reachable again, running the commands", b.name)
+		}
+		b.failures = 0
+		b.backoff = 0
+		b.probing = false
+		return
+	}
+
+	b.failures++
+	switch {
+	case b.probing:
+		b.backoff *= 2
+		if b.backoff > b.maxBackoff {
+			b.backoff = b.maxBackoff
+		}
+	case b.backoff == 0 && b.threshold > 0 && b.failures >= b.threshold:
+		b.backoff = b.baseBackoff
+	default:
+		return
+	}
+	b.probing = false
+	b.openUntil = b.now().Add(b.backoff)
+	logger.Warningf("%s is unreachable after %d failed commands, failing the commands for %s", b.name, b.failures, b.backoff.String())
+}
+
+// IsOpen returns whether the breaker fails the commands
+func (b *CircuitBreaker) IsOpen() bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.backoff != 0
+}
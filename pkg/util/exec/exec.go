@@ -42,6 +42,14 @@ type Executor interface {
 	ExecuteCommandWithTimeout(timeout time.Duration, command string, arg ...string) (string, error)
 }
 
+// ContextExecutor is an Executor whose processes are killed when the context of their command is done
+type ContextExecutor interface {
+	ExecuteCommandWithEnvContext(ctx context.Context, env []string, command string, arg ...string) error
+	ExecuteCommandWithOutputContext(ctx context.Context, command string, arg ...string) (string, error)
+	ExecuteCommandWithCombinedOutputContext(ctx context.Context, command string, arg ...string) (string, error)
+	ExecuteCommandWithOutputFileContext(ctx context.Context, command, outfileArg string, arg ...string) (string, error)
+}
+
 // CommandExecutor is the type of the Executor
 type CommandExecutor struct {
 }
@@ -52,8 +60,14 @@ func (c *CommandExecutor) ExecuteCommand(command string, arg ...string) error {
 }
 
 // ExecuteCommandWithEnv starts a process with env variables and wait for its completion
-func (*CommandExecutor) ExecuteCommandWithEnv(env []string, command string, arg ...string) error {
-	cmd, stdout, stderr, err := startCommand(env, command, arg...)
+func (c *CommandExecutor) ExecuteCommandWithEnv(env []string, command string, arg ...string) error {
+	return c.ExecuteCommandWithEnvContext(context.Background(), env, command, arg...)
+}
+
+// ExecuteCommandWithEnvContext starts a process with env variables and wait for its completion, killing it when the
+// context is done
+func (*CommandExecutor) ExecuteCommandWithEnvContext(ctx context.Context, env []string, command string, arg ...string) error {
+	cmd, stdout, stderr, err := startCommand(ctx, env, command, arg...)
 	if err != nil {
 		return err
 	}
@@ -120,16 +134,26 @@ func (*CommandExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command
 }
 
 // ExecuteCommandWithOutput executes a command with output
-func (*CommandExecutor) ExecuteCommandWithOutput(command string, arg ...string) (string, error) {
+func (c *CommandExecutor) ExecuteCommandWithOutput(command string, arg ...string) (string, error) {
+	return c.ExecuteCommandWithOutputContext(context.Background(), command, arg...)
+}
+
+// ExecuteCommandWithOutputContext executes a command with output, killing it when the context is done
+func (*CommandExecutor) ExecuteCommandWithOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
 	logCommand(command, arg...)
-	cmd := exec.Command(command, arg...)
+	cmd := exec.CommandContext(ctx, command, arg...)
 	return runCommandWithOutput(cmd, false)
 }
 
 // ExecuteCommandWithCombinedOutput executes a command with combined output
-func (*CommandExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...string) (string, error) {
+func (c *CommandExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...string) (string, error) {
+	return c.ExecuteCommandWithCombinedOutputContext(context.Background(), command, arg...)
+}
+
+// ExecuteCommandWithCombinedOutputContext executes a command with combined output, killing it when the context is done
+func (*CommandExecutor) ExecuteCommandWithCombinedOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
 	logCommand(command, arg...)
-	cmd := exec.Command(command, arg...)
+	cmd := exec.CommandContext(ctx, command, arg...)
 	return runCommandWithOutput(cmd, true)
 }
 
@@ -172,7 +196,12 @@ func (*CommandExecutor) ExecuteCommandWithOutputFileTimeout(timeout time.Duratio
 }
 
 // ExecuteCommandWithOutputFile executes a command with output on a file
-func (*CommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string, arg ...string) (string, error) {
+func (c *CommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string, arg ...string) (string, error) {
+	return c.ExecuteCommandWithOutputFileContext(context.Background(), command, outfileArg, arg...)
+}
+
+// ExecuteCommandWithOutputFileContext executes a command with output on a file, killing it when the context is done
+func (*CommandExecutor) ExecuteCommandWithOutputFileContext(ctx context.Context, command, outfileArg string, arg ...string) (string, error) {
 
 	// create a temporary file to serve as the output file for the command to be run and ensure
 	// it is cleaned up after this function is done
@@ -187,7 +216,7 @@ func (*CommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string,
 	arg = append(arg, outfileArg, outFile.Name())
 
 	logCommand(command, arg...)
-	cmd := exec.Command(command, arg...)
+	cmd := exec.CommandContext(ctx, command, arg...)
 	cmdOut, err := cmd.CombinedOutput()
 	// if there was anything that went to stdout/stderr then log it, even before we return an error
 	if string(cmdOut) != "" {
@@ -202,10 +231,10 @@ func (*CommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string,
 	return string(fileOut), err
 }
 
-func startCommand(env []string, command string, arg ...string) (*exec.Cmd, io.ReadCloser, io.ReadCloser, error) {
+func startCommand(ctx context.Context, env []string, command string, arg ...string) (*exec.Cmd, io.ReadCloser, io.ReadCloser, error) {
 	logCommand(command, arg...)
 
-	cmd := exec.Command(command, arg...)
+	cmd := exec.CommandContext(ctx, command, arg...)
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		logger.Warningf("failed to open stdout pipe: %+v", err)
@@ -264,8 +293,9 @@ func runCommandWithOutput(cmd *exec.Cmd, combinedOutput bool) (string, error) {
 		output, err = cmd.CombinedOutput()
 	} else {
 		output, err = cmd.Output()
-		if err != nil {
-			output = []byte(fmt.Sprintf("%s. %s", string(output), string(err.(*exec.ExitError).Stderr)))
+		// the command fails without exit error when it could not start or its context was done before
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			output = []byte(fmt.Sprintf("%s. %s", string(output), string(exitErr.Stderr)))
 		}
 	}
 
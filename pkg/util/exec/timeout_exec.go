@@ -0,0 +1,172 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package exec
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimeoutError is returned when a command did not return before the timeout of the TimeoutCommandExecutor
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("timeout after %s waiting for the command %s to return", e.Timeout.String(), e.Command)
+}
+
+// IsTimeout returns whether the error, or its cause, is a TimeoutError
+func IsTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*TimeoutError)
+	return ok
+}
+
+// TimeoutCommandExecutor is an exec.Executor that returns an error for every command not completing within the timeout
+// This is useful for the goroutines that must not block forever on a hung command.
+type TimeoutCommandExecutor struct {
+
+	// Executor is probably a exec.CommandExecutor that will run the commands
+	Executor Executor
+
+	// Timeout is the duration after which a command is considered failed, no timeout if not positive
+	Timeout time.Duration
+
+	// Context, if set, fails the running commands and refuses the new ones once done, so that the goroutines of a
+	// stopped cluster do not keep on running commands
+	Context context.Context
+}
+
+// ExecuteCommand starts a process and wait for its completion
+func (e *TimeoutCommandExecutor) ExecuteCommand(command string, arg ...string) error {
+	return e.ExecuteCommandWithEnv([]string{}, command, arg...)
+}
+
+// ExecuteCommandWithEnv starts a process with an env variable and wait for its completion
+func (e *TimeoutCommandExecutor) ExecuteCommandWithEnv(env []string, command string, arg ...string) error {
+	_, err := e.run(command, func(ctx context.Context) (string, error) {
+		if executor, ok := e.Executor.(ContextExecutor); ok {
+			return "", executor.ExecuteCommandWithEnvContext(ctx, env, command, arg...)
+		}
+		return "", e.Executor.ExecuteCommandWithEnv(env, command, arg...)
+	})
+	return err
+}
+
+// ExecuteCommandWithOutput starts a process and wait for its completion
+func (e *TimeoutCommandExecutor) ExecuteCommandWithOutput(command string, arg ...string) (string, error) {
+	return e.run(command, func(ctx context.Context) (string, error) {
+		if executor, ok := e.Executor.(ContextExecutor); ok {
+			return executor.ExecuteCommandWithOutputContext(ctx, command, arg...)
+		}
+		return e.Executor.ExecuteCommandWithOutput(command, arg...)
+	})
+}
+
+// ExecuteCommandWithCombinedOutput starts a process and returns its stdout and stderr combined.
+func (e *TimeoutCommandExecutor) ExecuteCommandWithCombinedOutput(command string, arg ...string) (string, error) {
+	return e.run(command, func(ctx context.Context) (string, error) {
+		if executor, ok := e.Executor.(ContextExecutor); ok {
+			return executor.ExecuteCommandWithCombinedOutputContext(ctx, command, arg...)
+		}
+		return e.Executor.ExecuteCommandWithCombinedOutput(command, arg...)
+	})
+}
+
+// ExecuteCommandWithOutputFile starts a process and saves output to file
+func (e *TimeoutCommandExecutor) ExecuteCommandWithOutputFile(command, outfileArg string, arg ...string) (string, error) {
+	return e.run(command, func(ctx context.Context) (string, error) {
+		if executor, ok := e.Executor.(ContextExecutor); ok {
+			return executor.ExecuteCommandWithOutputFileContext(ctx, command, outfileArg, arg...)
+		}
+		return e.Executor.ExecuteCommandWithOutputFile(command, outfileArg, arg...)
+	})
+}
+
+// ExecuteCommandWithOutputFileTimeout is the same as ExecuteCommandWithOutputFile but with a timeout limit.
+func (e *TimeoutCommandExecutor) ExecuteCommandWithOutputFileTimeout(
+	timeout time.Duration,
+	command, outfileArg string, arg ...string) (string, error) {
+	return e.run(command, func(ctx context.Context) (string, error) {
+		return e.Executor.ExecuteCommandWithOutputFileTimeout(e.shortestTimeout(timeout), command, outfileArg, arg...)
+	})
+}
+
+// ExecuteCommandWithTimeout starts a process and wait for its completion with timeout.
+func (e *TimeoutCommandExecutor) ExecuteCommandWithTimeout(timeout time.Duration, command string, arg ...string) (string, error) {
+	return e.run(command, func(ctx context.Context) (string, error) {
+		return e.Executor.ExecuteCommandWithTimeout(e.shortestTimeout(timeout), command, arg...)
+	})
+}
+
+// shortestTimeout returns the timeout of a command when it is shorter than the timeout of the executor, so that the
+// underlying executor kills the process when the executor stops waiting for it
+func (e *TimeoutCommandExecutor) shortestTimeout(timeout time.Duration) time.Duration {
+	if e.Timeout > 0 && e.Timeout < timeout {
+		return e.Timeout
+	}
+	return timeout
+}
+
+// run returns the result of the command, or a TimeoutError if it does not complete within the timeout
+// The process of the command is killed on timeout or when the context is done if the underlying executor is a
+// ContextExecutor, otherwise it keeps on running in the background until the underlying executor returns.
+func (e *TimeoutCommandExecutor) run(command string, execute func(ctx context.Context) (string, error)) (string, error) {
+	parent := e.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+	if err := parent.Err(); err != nil {
+		return "", errors.Wrapf(err, "not running command %s", command)
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if e.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(parent, e.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(parent)
+	}
+	defer cancel()
+
+	type result struct {
+		output string
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		output, err := execute(ctx)
+		done <- result{output, err}
+	}()
+
+	select {
+	case r := <-done:
+		// a process killed when the context is done fails with the reason it was killed
+		if r.err == nil || ctx.Err() == nil {
+			return r.output, r.err
+		}
+	case <-ctx.Done():
+	}
+	if err := parent.Err(); err != nil {
+		return "", errors.Wrapf(err, "stopped waiting for process %s to return", command)
+	}
+	logger.Warningf("timeout after %s waiting for process %s to return", e.Timeout.String(), command)
+	return "", &TimeoutError{Command: command, Timeout: e.Timeout}
+}
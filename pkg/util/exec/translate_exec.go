@@ -17,6 +17,7 @@ limitations under the License.
 package exec
 
 import (
+	"context"
 	"time"
 )
 
@@ -75,3 +76,43 @@ func (e *TranslateCommandExecutor) ExecuteCommandWithTimeout(timeout time.Durati
 	transCommand, transArgs := e.Translator(command, arg...)
 	return e.Executor.ExecuteCommandWithTimeout(timeout, transCommand, transArgs...)
 }
+
+// ExecuteCommandWithEnvContext starts a process with an env variable and wait for its completion, killing it when the
+// context is done if the underlying executor supports it
+func (e *TranslateCommandExecutor) ExecuteCommandWithEnvContext(ctx context.Context, env []string, command string, arg ...string) error {
+	transCommand, transArgs := e.Translator(command, arg...)
+	if executor, ok := e.Executor.(ContextExecutor); ok {
+		return executor.ExecuteCommandWithEnvContext(ctx, env, transCommand, transArgs...)
+	}
+	return e.Executor.ExecuteCommandWithEnv(env, transCommand, transArgs...)
+}
+
+// ExecuteCommandWithOutputContext starts a process and wait for its completion, killing it when the context is done
+// if the underlying executor supports it
+func (e *TranslateCommandExecutor) ExecuteCommandWithOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
+	transCommand, transArgs := e.Translator(command, arg...)
+	if executor, ok := e.Executor.(ContextExecutor); ok {
+		return executor.ExecuteCommandWithOutputContext(ctx, transCommand, transArgs...)
+	}
+	return e.Executor.ExecuteCommandWithOutput(transCommand, transArgs...)
+}
+
+// ExecuteCommandWithCombinedOutputContext starts a process and returns its stdout and stderr combined, killing it
+// when the context is done if the underlying executor supports it
+func (e *TranslateCommandExecutor) ExecuteCommandWithCombinedOutputContext(ctx context.Context, command string, arg ...string) (string, error) {
+	transCommand, transArgs := e.Translator(command, arg...)
+	if executor, ok := e.Executor.(ContextExecutor); ok {
+		return executor.ExecuteCommandWithCombinedOutputContext(ctx, transCommand, transArgs...)
+	}
+	return e.Executor.ExecuteCommandWithCombinedOutput(transCommand, transArgs...)
+}
+
+// ExecuteCommandWithOutputFileContext starts a process and saves output to file, killing it when the context is done
+// if the underlying executor supports it
+func (e *TranslateCommandExecutor) ExecuteCommandWithOutputFileContext(ctx context.Context, command, outfileArg string, arg ...string) (string, error) {
+	transCommand, transArgs := e.Translator(command, arg...)
+	if executor, ok := e.Executor.(ContextExecutor); ok {
+		return executor.ExecuteCommandWithOutputFileContext(ctx, transCommand, outfileArg, transArgs...)
+	}
+	return e.Executor.ExecuteCommandWithOutputFile(transCommand, outfileArg, transArgs...)
+}
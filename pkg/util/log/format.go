@@ -0,0 +1,99 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// TextFormat is the default format of the logs, a line of text prefixed with the fields
+	TextFormat = "text"
+	// JSONFormat logs a JSON object by line, with the time, level, package, message and fields as keys
+	JSONFormat = "json"
+)
+
+// SetFormat sets the format of the logs of all the package loggers
+func SetFormat(format string) error {
+	switch format {
+	case "", TextFormat:
+		return nil
+	case JSONFormat:
+		capnslog.SetFormatter(NewJSONFormatter(os.Stderr))
+		return nil
+	}
+	return errors.Errorf("invalid log format %q, expected %q or %q", format, TextFormat, JSONFormat)
+}
+
+type jsonFormatter struct {
+	sync.Mutex
+	w *bufio.Writer
+}
+
+// NewJSONFormatter returns a capnslog formatter writing each log line as a JSON object, e.g.
+// {"time":"2020-06-01T10:00:00Z","level":"INFO","pkg":"op-mon","msg":"...","namespace":"rook-ceph","cluster":"rook-ceph"}
+func NewJSONFormatter(w io.Writer) capnslog.Formatter {
+	return &jsonFormatter{w: bufio.NewWriter(w)}
+}
+
+// Format writes a log line, the Fields entries being added as keys of the line
+func (j *jsonFormatter) Format(pkg string, level capnslog.LogLevel, depth int, entries ...interface{}) {
+	line := map[string]interface{}{}
+	message := []interface{}{}
+	for _, entry := range entries {
+		if fields, ok := entry.(Fields); ok {
+			for k, v := range fields {
+				line[k] = v
+			}
+			continue
+		}
+		message = append(message, entry)
+	}
+	line["time"] = time.Now().UTC().Format(time.RFC3339Nano)
+	line["level"] = level.String()
+	line["pkg"] = pkg
+	line["msg"] = strings.TrimRight(fmt.Sprint(message...), "\n")
+
+	raw, err := json.Marshal(line)
+	if err != nil {
+		// the keys and values being strings, this is not expected
+		return
+	}
+
+	j.Lock()
+	defer j.Unlock()
+	j.w.Write(raw)
+	j.w.WriteByte('\n')
+	j.w.Flush()
+}
+
+// Flush writes the buffered lines
+func (j *jsonFormatter) Flush() {
+	j.Lock()
+	defer j.Unlock()
+	j.w.Flush()
+}
@@ -0,0 +1,150 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package log adds the fields identifying a cluster, a custom resource or a reconcile to the lines of the package
+// loggers, so that the logs of the clusters handled by a same operator can be filtered and correlated.
+package log
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+const (
+	// NamespaceField is the namespace of the cluster or of the custom resource
+	NamespaceField = "namespace"
+	// ClusterField is the name of the CephCluster
+	ClusterField = "cluster"
+	// KindField is the kind of the reconciled custom resource
+	KindField = "kind"
+	// NameField is the name of the reconciled custom resource
+	NameField = "name"
+	// ReconcileIDField identifies the lines of a same reconcile
+	ReconcileIDField = "reconcileID"
+	// CheckField is the name of the health check logging the line
+	CheckField = "check"
+)
+
+var defaultLogger = capnslog.NewPackageLogger("github.com/rook/rook", "log")
+
+// Fields are the key/value pairs added to the log lines
+type Fields map[string]string
+
+// String formats the fields as a prefix of a text log line, e.g. "[cluster=rook-ceph namespace=rook-ceph] "
+func (f Fields) String() string {
+	if len(f) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(f))
+	for k := range f {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+f[k])
+	}
+	return "[" + strings.Join(pairs, " ") + "] "
+}
+
+// Logger logs to a package logger with fields. The text log lines are prefixed with the fields, and the JSON log lines
+// hold them as keys. The zero Logger logs without fields.
+type Logger struct {
+	logger *capnslog.PackageLogger
+	fields Fields
+}
+
+// New returns a logger adding the fields to the lines of the package logger
+func New(logger *capnslog.PackageLogger, fields Fields) Logger {
+	return Logger{logger: logger, fields: fields}
+}
+
+// With returns a copy of the logger with one more field
+func (l Logger) With(key, value string) Logger {
+	fields := make(Fields, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+	fields[key] = value
+	return Logger{logger: l.logger, fields: fields}
+}
+
+// NewReconcileID returns a short random ID correlating the log lines of a reconcile
+func NewReconcileID() string {
+	return string(uuid.NewUUID())[:8]
+}
+
+func (l Logger) packageLogger() *capnslog.PackageLogger {
+	if l.logger == nil {
+		return defaultLogger
+	}
+	return l.logger
+}
+
+func (l Logger) entries(entries []interface{}) []interface{} {
+	if len(l.fields) == 0 {
+		return entries
+	}
+	return append([]interface{}{l.fields}, entries...)
+}
+
+// Debug logs the entries at the debug level
+func (l Logger) Debug(entries ...interface{}) {
+	if p := l.packageLogger(); p.LevelAt(capnslog.DEBUG) {
+		p.Debug(l.entries([]interface{}{fmt.Sprint(entries...)})...)
+	}
+}
+
+// Debugf logs a formatted message at the debug level
+func (l Logger) Debugf(format string, args ...interface{}) {
+	if p := l.packageLogger(); p.LevelAt(capnslog.DEBUG) {
+		p.Debug(l.entries([]interface{}{fmt.Sprintf(format, args...)})...)
+	}
+}
+
+// Info logs the entries at the info level
+func (l Logger) Info(entries ...interface{}) {
+	l.packageLogger().Info(l.entries([]interface{}{fmt.Sprint(entries...)})...)
+}
+
+// Infof logs a formatted message at the info level
+func (l Logger) Infof(format string, args ...interface{}) {
+	l.packageLogger().Info(l.entries([]interface{}{fmt.Sprintf(format, args...)})...)
+}
+
+// Warning logs the entries at the warning level
+func (l Logger) Warning(entries ...interface{}) {
+	l.packageLogger().Warning(l.entries([]interface{}{fmt.Sprint(entries...)})...)
+}
+
+// Warningf logs a formatted message at the warning level
+func (l Logger) Warningf(format string, args ...interface{}) {
+	l.packageLogger().Warning(l.entries([]interface{}{fmt.Sprintf(format, args...)})...)
+}
+
+// Error logs the entries at the error level
+func (l Logger) Error(entries ...interface{}) {
+	l.packageLogger().Error(l.entries([]interface{}{fmt.Sprint(entries...)})...)
+}
+
+// Errorf logs a formatted message at the error level
+func (l Logger) Errorf(format string, args ...interface{}) {
+	l.packageLogger().Error(l.entries([]interface{}{fmt.Sprintf(format, args...)})...)
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2020 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFields(t *testing.T) {
+	assert.Equal(t, "", Fields{}.String())
+	assert.Equal(t, "[cluster=my-cluster namespace=rook-ceph] ", Fields{NamespaceField: "rook-ceph", ClusterField: "my-cluster"}.String())
+
+	// the fields of the parent logger are not changed
+	l := New(nil, Fields{NamespaceField: "rook-ceph"})
+	child := l.With(ReconcileIDField, "abc")
+	assert.Equal(t, Fields{NamespaceField: "rook-ceph"}, l.fields)
+	assert.Equal(t, Fields{NamespaceField: "rook-ceph", ReconcileIDField: "abc"}, child.fields)
+
+	assert.Equal(t, 8, len(NewReconcileID()))
+	assert.Error(t, SetFormat("yaml"))
+	assert.NoError(t, SetFormat(TextFormat))
+}
+
+func TestJSONFormatter(t *testing.T) {
+	var out bytes.Buffer
+	f := NewJSONFormatter(&out)
+	f.Format("op-mon", capnslog.WARNING, 0, Fields{NamespaceField: "rook-ceph", CheckField: "mon"}, "mon \"a\" is out of quorum\n")
+
+	line := map[string]string{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, "WARNING", line["level"])
+	assert.Equal(t, "op-mon", line["pkg"])
+	assert.Equal(t, "mon \"a\" is out of quorum", line["msg"])
+	assert.Equal(t, "rook-ceph", line["namespace"])
+	assert.Equal(t, "mon", line["check"])
+	assert.NotEmpty(t, line["time"])
+
+	// the lines without fields only have the message
+	out.Reset()
+	f.Format("op-mon", capnslog.INFO, 0, "checking mons")
+	line = map[string]string{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &line))
+	assert.Equal(t, 4, len(line))
+}
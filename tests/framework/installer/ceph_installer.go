@@ -576,6 +576,7 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(systemNamespace string, name
 		"crd",
 		"cephclusters.ceph.rook.io",
 		"cephblockpools.ceph.rook.io",
+		"cephblockpoolradosnamespaces.ceph.rook.io",
 		"cephobjectstores.ceph.rook.io",
 		"cephobjectstoreusers.ceph.rook.io",
 		"cephobjectrealms.ceph.rook.io",
@@ -587,7 +588,12 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(systemNamespace string, name
 		"volumes.rook.io",
 		"objectbuckets.objectbucket.io",
 		"objectbucketclaims.objectbucket.io",
-		"cephrbdmirrors.ceph.rook.io")
+		"cephrbdmirrors.ceph.rook.io",
+		"cephfilesystemmirrors.ceph.rook.io",
+		"cephfilesystemsubvolumegroups.ceph.rook.io",
+		"cephbuckettopics.ceph.rook.io",
+		"cephbucketnotifications.ceph.rook.io",
+		"cephcosidrivers.ceph.rook.io")
 	checkError(h.T(), err, "cannot delete CRDs")
 
 	if h.useHelm {
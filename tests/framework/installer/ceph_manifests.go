@@ -109,12 +109,107 @@ spec:
         spec:
           properties:
             annotations: {}
+            labels: {}
+            env: {}
+            volumeMounts: {}
+            cephConfig: {}
+            toolbox:
+              properties:
+                enabled:
+                  type: boolean
+                placement: {}
+                resources: {}
+            logCollector:
+              properties:
+                enabled:
+                  type: boolean
+                periodicity:
+                  type: string
+                  pattern: ^$|^(hourly|daily|weekly|monthly)$
+                maxLogSize: {}
+            backup:
+              properties:
+                enabled:
+                  type: boolean
+                schedule:
+                  type: string
+                retain:
+                  type: integer
+                  minimum: 0
+                persistentVolumeClaim: {}
+                bucket:
+                  properties:
+                    endpoint:
+                      type: string
+                    name:
+                      type: string
+                    prefix:
+                      type: string
+                    secretName:
+                      type: string
+                resources: {}
+                placement: {}
+            telemetry:
+              properties:
+                enabled:
+                  type: boolean
+                channels:
+                  type: array
+                  items:
+                    type: string
+                    enum:
+                    - basic
+                    - crash
+                    - device
+                    - ident
+                contact:
+                  type: string
+                description:
+                  type: string
+                organization:
+                  type: string
+                report:
+                  type: boolean
+            imagePullSecrets:
+              type: array
+              items:
+                properties:
+                  name:
+                    type: string
+                required:
+                - name
+            updateStrategy:
+              properties:
+                type:
+                  type: string
+                  enum:
+                  - ""
+                  - OneByOne
+                  - Waves
+                failureDomain:
+                  type: string
+                waveSize:
+                  type: integer
+                  minimum: 0
+                waveTimeout:
+                  type: string
+            driftPolicy:
+              type: string
+              enum:
+              - ""
+              - revert
+              - report
             cephVersion:
               properties:
                 allowUnsupported:
                   type: boolean
                 image:
                   type: string
+                skipImageVersionDetection:
+                  type: boolean
+                version:
+                  type: string
+                  pattern: ^$|^[0-9]+\.[0-9]+\.[0-9]+(-[0-9]+)?$
             dashboard:
               properties:
                 enabled:
@@ -127,6 +222,28 @@ spec:
                   maximum: 65535
                 ssl:
                   type: boolean
+                adminPasswordSecret:
+                  properties:
+                    name:
+                      type: string
+                    key:
+                      type: string
+                  required:
+                  - name
+                service:
+                  properties:
+                    type:
+                      type: string
+                      enum:
+                      - ClusterIP
+                      - NodePort
+                      - LoadBalancer
+                    annotations:
+                      type: object
+                    loadBalancerSourceRanges:
+                      type: array
+                      items:
+                        type: string
             dataDirHostPath:
               pattern: ^/(\S+)
               type: string
@@ -140,8 +257,17 @@ spec:
                   type: integer
                 manageMachineDisruptionBudgets:
                   type: boolean
+                manageNodeMaintenance:
+                  type: boolean
             skipUpgradeChecks:
               type: boolean
+            crashCollector:
+              properties:
+                disable:
+                  type: boolean
+                daysToRetain:
+                  type: integer
+                  minimum: 0
             mon:
               properties:
                 allowMultiplePerNode:
@@ -160,10 +286,41 @@ spec:
                         type: string
                       enabled:
                         type: boolean
+                balancer: {}
             network:
               properties:
                 hostNetwork:
                   type: boolean
+                daemonHostNetwork:
+                  type: object
+                  properties:
+                    mon:
+                      type: boolean
+                    mgr:
+                      type: boolean
+                    osd:
+                      type: boolean
+                    rgw:
+                      type: boolean
+                    mds:
+                      type: boolean
+                ipFamily:
+                  type: string
+                  enum:
+                  - IPv4
+                  - IPv6
+                dualStack:
+                  type: boolean
+                connections:
+                  properties:
+                    encryption:
+                      properties:
+                        enabled:
+                          type: boolean
+                    compression:
+                      properties:
+                        enabled:
+                          type: boolean
                 provider:
                   type: string
                 selectors: {}
@@ -179,8 +336,13 @@ spec:
                       type: integer
                     manageMachineDisruptionBudgets:
                       type: boolean
+                compression: {}
+                prepare: {}
+                scrubbing: {}
                 useAllNodes:
                   type: boolean
+                removedNodeGracePeriod:
+                  type: string
                 nodes:
                   items:
                     properties:
@@ -207,6 +369,10 @@ spec:
                       useAllDevices:
                         type: boolean
                       deviceFilter: {}
+                      minSize: {}
+                      maxSize: {}
+                      deviceType: {}
+                      deviceClass: {}
                       devices:
                         type: array
                         items:
@@ -219,8 +385,13 @@ spec:
                 useAllDevices:
                   type: boolean
                 deviceFilter: {}
+                minSize: {}
+                maxSize: {}
+                deviceType: {}
+                deviceClass: {}
                 config: {}
                 storageClassDeviceSets: {}
+                topologyMapping: {}
             driveGroups:
               type: array
               nullable: true
@@ -239,6 +410,22 @@ spec:
                   type: boolean
                 rulesNamespace:
                   type: string
+                disablePrometheusRules:
+                  type: boolean
+                externalMgrEndpoints:
+                  type: array
+                  items:
+                    properties:
+                      ip:
+                        type: string
+                      hostname:
+                        type: string
+                    required:
+                    - ip
+                externalMgrPrometheusPort:
+                  type: integer
+                  minimum: 0
+                  maximum: 65535
             rbdMirroring:
               properties:
                 workers:
@@ -249,6 +436,19 @@ spec:
               properties:
                 enable:
                   type: boolean
+            security:
+              properties:
+                kms:
+                  properties:
+                    connectionDetails: {}
+                    tokenSecretName:
+                      type: string
+                keyRotation:
+                  properties:
+                    enabled:
+                      type: boolean
+                    period:
+                      type: string
             cleanupPolicy:
               properties:
                 confirmation:
@@ -318,6 +518,12 @@ spec:
                       type: integer
                     requireSafeReplicaSize:
                       type: boolean
+                    hybridStorage:
+                      properties:
+                        primaryDeviceClass:
+                          type: string
+                        secondaryDeviceClass:
+                          type: string
                 erasureCoded:
                   properties:
                     dataChunks:
@@ -328,6 +534,20 @@ spec:
                       minimum: 0
                       maximum: 10
                       type: integer
+                    plugin:
+                      type: string
+                      enum:
+                      - jerasure
+                      - isa
+                      - clay
+                    technique:
+                      type: string
+                    failureDomain:
+                      type: string
+                    crushRoot:
+                      type: string
+                    deviceClass:
+                      type: string
                 compressionMode:
                   type: string
                   enum:
@@ -336,10 +556,22 @@ spec:
                   - passive
                   - aggressive
                   - force
+                compressionAlgorithm:
+                  type: string
+                  enum:
+                  - ""
+                  - snappy
+                  - zlib
+                  - zstd
+                  - lz4
             dataPools:
               type: array
               items:
                 properties:
+                  name:
+                    type: string
+                  default:
+                    type: boolean
                   failureDomain:
                     type: string
                   replicated:
@@ -350,6 +582,12 @@ spec:
                         type: integer
                       requireSafeReplicaSize:
                         type: boolean
+                      hybridStorage:
+                        properties:
+                          primaryDeviceClass:
+                            type: string
+                          secondaryDeviceClass:
+                            type: string
                   erasureCoded:
                     properties:
                       dataChunks:
@@ -360,6 +598,20 @@ spec:
                         minimum: 0
                         maximum: 10
                         type: integer
+                      plugin:
+                        type: string
+                        enum:
+                        - jerasure
+                        - isa
+                        - clay
+                      technique:
+                        type: string
+                      failureDomain:
+                        type: string
+                      crushRoot:
+                        type: string
+                      deviceClass:
+                        type: string
                   compressionMode:
                     type: string
                     enum:
@@ -368,15 +620,56 @@ spec:
                     - passive
                     - aggressive
                     - force
+                  compressionAlgorithm:
+                    type: string
+                    enum:
+                    - ""
+                    - snappy
+                    - zlib
+                    - zstd
+                    - lz4
+                  quotas:
+                    properties:
+                      maxBytes:
+                        type: integer
+                        minimum: 0
+                      maxObjects:
+                        type: integer
+                        minimum: 0
                   parameters:
                     type: object
             preservePoolsOnDelete:
               type: boolean
+            snapshotClass:
+              properties:
+                enabled:
+                  type: boolean
+                name:
+                  type: string
+                deletionPolicy:
+                  type: string
+                  enum:
+                  - Delete
+                  - Retain
+            healthCheck:
+              properties:
+                mds:
+                  properties:
+                    disabled:
+                      type: boolean
+                    interval:
+                      type: string
+                    restartStuckAfter:
+                      type: string
   additionalPrinterColumns:
     - name: ActiveMDS
       type: string
       description: Number of desired active MDS daemons
       JSONPath: .spec.metadataServer.activeCount
+    - name: MDSHealth
+      type: string
+      description: Health of the MDS daemons of the filesystem
+      JSONPath: .status.mdsHealth.health
     - name: Age
       type: date
       JSONPath: .metadata.creationTimestamp
@@ -416,6 +709,45 @@ spec:
                 annotations: {}
                 placement: {}
                 resources: {}
+            exports:
+              type: array
+              items:
+                properties:
+                  id:
+                    type: integer
+                    minimum: 1
+                    maximum: 65535
+                  pseudoPath:
+                    type: string
+                  accessType:
+                    type: string
+                    enum:
+                    - RW
+                    - RO
+                    - None
+                  squash:
+                    type: string
+                    enum:
+                    - none
+                    - root
+                    - all
+                  cephfs:
+                    properties:
+                      filesystem:
+                        type: string
+                      path:
+                        type: string
+                  rgw:
+                    properties:
+                      objectStore:
+                        type: string
+                      bucket:
+                        type: string
+                      user:
+                        type: string
+                required:
+                - id
+                - pseudoPath
   subresources:
     status: {}
 ---
@@ -442,6 +774,7 @@ spec:
                 type:
                   type: string
                 sslCertificateRef: {}
+                caBundleRef: {}
                 port:
                   type: integer
                   minimum: 1
@@ -452,6 +785,20 @@ spec:
                 annotations: {}
                 placement: {}
                 resources: {}
+                service:
+                  properties:
+                    type:
+                      type: string
+                      enum:
+                      - ClusterIP
+                      - NodePort
+                      - LoadBalancer
+                    annotations:
+                      type: object
+                    loadBalancerSourceRanges:
+                      type: array
+                      items:
+                        type: string
             metadataPool:
               properties:
                 failureDomain:
@@ -462,12 +809,32 @@ spec:
                       type: integer
                     requireSafeReplicaSize:
                       type: boolean
+                    hybridStorage:
+                      properties:
+                        primaryDeviceClass:
+                          type: string
+                        secondaryDeviceClass:
+                          type: string
                 erasureCoded:
                   properties:
                     dataChunks:
                       type: integer
                     codingChunks:
                       type: integer
+                    plugin:
+                      type: string
+                      enum:
+                      - jerasure
+                      - isa
+                      - clay
+                    technique:
+                      type: string
+                    failureDomain:
+                      type: string
+                    crushRoot:
+                      type: string
+                    deviceClass:
+                      type: string
                 compressionMode:
                   type: string
                   enum:
@@ -476,6 +843,14 @@ spec:
                   - passive
                   - aggressive
                   - force
+                compressionAlgorithm:
+                  type: string
+                  enum:
+                  - ""
+                  - snappy
+                  - zlib
+                  - zstd
+                  - lz4
                 parameters:
                   type: object
             dataPool:
@@ -488,12 +863,32 @@ spec:
                       type: integer
                     requireSafeReplicaSize:
                       type: boolean
+                    hybridStorage:
+                      properties:
+                        primaryDeviceClass:
+                          type: string
+                        secondaryDeviceClass:
+                          type: string
                 erasureCoded:
                   properties:
                     dataChunks:
                       type: integer
                     codingChunks:
                       type: integer
+                    plugin:
+                      type: string
+                      enum:
+                      - jerasure
+                      - isa
+                      - clay
+                    technique:
+                      type: string
+                    failureDomain:
+                      type: string
+                    crushRoot:
+                      type: string
+                    deviceClass:
+                      type: string
                 compressionMode:
                   type: string
                   enum:
@@ -502,6 +897,14 @@ spec:
                   - passive
                   - aggressive
                   - force
+                compressionAlgorithm:
+                  type: string
+                  enum:
+                  - ""
+                  - snappy
+                  - zlib
+                  - zstd
+                  - lz4
                 parameters:
                   type: object
             preservePoolsOnDelete:
@@ -590,6 +993,38 @@ spec:
 ---
 apiVersion: apiextensions.k8s.io/v1beta1
 kind: CustomResourceDefinition
+metadata:
+  name: cephbuckettopics.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephBucketTopic
+    listKind: CephBucketTopicList
+    plural: cephbuckettopics
+    singular: cephbuckettopic
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephbucketnotifications.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephBucketNotification
+    listKind: CephBucketNotificationList
+    plural: cephbucketnotifications
+    singular: cephbucketnotification
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
 metadata:
   name: cephblockpools.ceph.rook.io
 spec:
@@ -618,6 +1053,12 @@ spec:
                   type: number
                 requireSafeReplicaSize:
                   type: boolean
+                hybridStorage:
+                  properties:
+                    primaryDeviceClass:
+                      type: string
+                    secondaryDeviceClass:
+                      type: string
             erasureCoded:
               properties:
                 dataChunks:
@@ -628,6 +1069,20 @@ spec:
                   type: integer
                   minimum: 0
                   maximum: 9
+                plugin:
+                  type: string
+                  enum:
+                  - jerasure
+                  - isa
+                  - clay
+                technique:
+                  type: string
+                failureDomain:
+                  type: string
+                crushRoot:
+                  type: string
+                deviceClass:
+                  type: string
             compressionMode:
                 type: string
                 enum:
@@ -636,8 +1091,108 @@ spec:
                 - passive
                 - aggressive
                 - force
+            compressionAlgorithm:
+              type: string
+              enum:
+              - ""
+              - snappy
+              - zlib
+              - zstd
+              - lz4
             parameters:
               type: object
+            targetSizeRatio:
+              type: number
+              minimum: 0
+            pgAutoscaleMode:
+              type: string
+              enum:
+              - ""
+              - "on"
+              - "off"
+              - warn
+            quotas:
+              properties:
+                maxBytes:
+                  type: integer
+                  minimum: 0
+                maxObjects:
+                  type: integer
+                  minimum: 0
+            snapshotClass:
+              properties:
+                enabled:
+                  type: boolean
+                name:
+                  type: string
+                deletionPolicy:
+                  type: string
+                  enum:
+                  - Delete
+                  - Retain
+            preservePoolsOnDelete:
+              type: boolean
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the pool
+      JSONPath: .status.phase
+    - name: Used
+      type: integer
+      description: Bytes used by the pool
+      JSONPath: .status.usage.usedBytes
+    - name: Available
+      type: integer
+      description: Bytes available to the pool
+      JSONPath: .status.usage.availableBytes
+    - name: PGHealth
+      type: string
+      description: Health of the PGs of the pool
+      JSONPath: .status.usage.pgHealth
+    - name: MirroringHealth
+      type: string
+      description: Health of the mirrored images of the pool
+      JSONPath: .status.mirroringStatus.summary.image_health
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephblockpoolradosnamespaces.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephBlockPoolRadosNamespace
+    listKind: CephBlockPoolRadosNamespaceList
+    plural: cephblockpoolradosnamespaces
+    singular: cephblockpoolradosnamespace
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            blockPoolName:
+              type: string
+          required:
+          - blockPoolName
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the rados namespace
+      JSONPath: .status.phase
+    - name: ClusterID
+      type: string
+      description: ClusterID of the rados namespace in the storage classes
+      JSONPath: .status.info.clusterID
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
   subresources:
     status: {}
 ---
@@ -729,6 +1284,15 @@ spec:
                   type: string
                 mds:
                   type: string
+                mgr:
+                  type: string
+            secretNamespace:
+              type: string
+            keyRotationPolicy:
+              type: string
+              enum:
+              - Never
+              - OnDemand
   subresources:
     status: {}
 ---
@@ -754,6 +1318,116 @@ spec:
               type: integer
               minimum: 1
               maximum: 100
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephfilesystemmirrors.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephFilesystemMirror
+    listKind: CephFilesystemMirrorList
+    plural: cephfilesystemmirrors
+    singular: cephfilesystemmirror
+  scope: Namespaced
+  version: v1
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephfilesystemsubvolumegroups.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephFilesystemSubVolumeGroup
+    listKind: CephFilesystemSubVolumeGroupList
+    plural: cephfilesystemsubvolumegroups
+    singular: cephfilesystemsubvolumegroup
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            filesystemName:
+              type: string
+            pinning:
+              properties:
+                export:
+                  type: integer
+                  minimum: -1
+                distributed:
+                  type: integer
+                  minimum: 0
+                  maximum: 1
+                random:
+                  type: number
+                  minimum: 0
+                  maximum: 1
+            quota: {}
+          required:
+          - filesystemName
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the subvolume group
+      JSONPath: .status.phase
+    - name: ClusterID
+      type: string
+      description: ClusterID of the subvolume group in the storage classes
+      JSONPath: .status.info.clusterID
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
+  subresources:
+    status: {}
+---
+apiVersion: apiextensions.k8s.io/v1beta1
+kind: CustomResourceDefinition
+metadata:
+  name: cephcosidrivers.ceph.rook.io
+spec:
+  group: ceph.rook.io
+  names:
+    kind: CephCOSIDriver
+    listKind: CephCOSIDriverList
+    plural: cephcosidrivers
+    singular: cephcosidriver
+    shortNames:
+    - cephcosi
+  scope: Namespaced
+  version: v1
+  validation:
+    openAPIV3Schema:
+      properties:
+        spec:
+          properties:
+            image:
+              type: string
+            objectProvisionerImage:
+              type: string
+            deploymentStrategy:
+              type: string
+              enum:
+              - Auto
+              - Force
+              - Never
+            placement: {}
+            resources: {}
+  additionalPrinterColumns:
+    - name: Phase
+      type: string
+      description: Phase of the COSI driver
+      JSONPath: .status.phase
+    - name: Age
+      type: date
+      JSONPath: .metadata.creationTimestamp
   subresources:
     status: {}`
 }
@@ -795,6 +1469,17 @@ rules:
   - network-attachment-definitions
   verbs:
   - get
+- apiGroups:
+  - coordination.k8s.io
+  resources:
+  - leases
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - delete
 ---
 apiVersion: rbac.authorization.k8s.io/v1beta1
 kind: ClusterRole
@@ -907,10 +1592,22 @@ rules:
   - get
   - list
   - watch
+- apiGroups:
+  - snapshot.storage.k8s.io
+  resources:
+  - volumesnapshotclasses
+  verbs:
+  - get
+  - list
+  - watch
+  - create
+  - update
+  - delete
 - apiGroups:
   - batch
   resources:
   - jobs
+  - cronjobs
   verbs:
   - get
   - list
@@ -918,6 +1615,18 @@ rules:
   - create
   - update
   - delete
+- apiGroups:
+  - authentication.k8s.io
+  resources:
+  - tokenreviews
+  verbs:
+  - create
+- apiGroups:
+  - authorization.k8s.io
+  resources:
+  - subjectaccessreviews
+  verbs:
+  - create
 - apiGroups:
   - ceph.rook.io
   resources:
@@ -1726,6 +2435,9 @@ rules:
 - apiGroups: [""]
   resources: ["configmaps"]
   verbs: [ "get", "list", "watch", "create", "update", "delete" ]
+- apiGroups: [""]
+  resources: ["secrets"]
+  verbs: [ "get", "create", "update" ]
 - apiGroups: ["ceph.rook.io"]
   resources: ["cephclusters", "cephclusters/finalizers"]
   verbs: [ "get", "list", "create", "update", "delete" ]
@@ -1752,6 +2464,7 @@ rules:
   - batch
   resources:
   - jobs
+  - cronjobs
   verbs:
   - get
   - list